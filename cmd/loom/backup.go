@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jordanhubbard/loom/internal/backup"
+	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// runBackupCommand handles "loom backup list|run|verify|restore" and returns
+// the process exit code
+func runBackupCommand(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		printBackupHelp()
+		return 2
+	}
+	ctx := context.Background()
+	bcfg := backup.WithDefaults(cfg.Backup)
+	store, err := backup.NewStore(bcfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backup: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "list":
+		backups, err := backup.List(ctx, store)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "backup list: %v\n", err)
+			return 1
+		}
+		for _, b := range backups {
+			fmt.Printf("%s\t%d\t%s\n", b.Name, b.Size, b.CreatedAt.Format("2006-01-02 15:04:05Z"))
+		}
+		return 0
+
+	case "run":
+		db, err := openDatabase(cfg.Database)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "backup run: %v\n", err)
+			return 1
+		}
+		defer db.Close()
+		m := backup.NewManager(store, backup.Source{
+			DatabaseType: db.Type(),
+			DB:           db.DB(),
+			PostgresDSN:  cfg.Database.DSN,
+			KeyStorePath: bcfg.KeyStorePath,
+		}, backup.RetentionFrom(bcfg))
+		info, err := m.Run(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "backup run: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Stored %s (%d bytes)\n", info.Name, info.Size)
+		return 0
+
+	case "verify":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "usage: loom backup verify NAME")
+			return 2
+		}
+		manifest, err := backup.Verify(ctx, store, args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "backup verify: %v\n", err)
+			return 1
+		}
+		fmt.Printf("%s OK (%s, %d files)\n", args[1], manifest.DatabaseType, len(manifest.Files))
+		return 0
+
+	case "restore":
+		fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+		force := fs.Bool("force", false, "Replace the existing database and key store")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "usage: loom backup restore [-force] NAME")
+			return 2
+		}
+		m := backup.NewManager(store, backup.Source{}, backup.RetentionFrom(bcfg))
+		manifest, err := m.Restore(ctx, fs.Arg(0), backup.RestoreOptions{
+			SQLitePath:   cfg.Database.Path,
+			PostgresDSN:  cfg.Database.DSN,
+			KeyStorePath: bcfg.KeyStorePath,
+			Force:        *force,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "backup restore: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Restored %s (%s database from %s)\n", fs.Arg(0), manifest.DatabaseType, manifest.CreatedAt.Format("2006-01-02 15:04:05Z"))
+		return 0
+	}
+
+	printBackupHelp()
+	return 2
+}
+
+func openDatabase(cfg config.DatabaseConfig) (*database.Database, error) {
+	switch {
+	case cfg.Type == "postgres" && cfg.DSN != "":
+		return database.NewPostgres(cfg.DSN)
+	case cfg.Path != "":
+		return database.New(cfg.Path)
+	}
+	return nil, fmt.Errorf("no database configured")
+}
+
+func printBackupHelp() {
+	fmt.Println("Usage: loom [flags] backup <command>")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  list                   List stored backups, newest first")
+	fmt.Println("  run                    Take a backup now and apply the retention policy")
+	fmt.Println("  verify NAME            Check a backup's checksums and database integrity")
+	fmt.Println("  restore [-force] NAME  Restore the database and key store; stop Loom first")
+}
@@ -5,22 +5,32 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/loom"
 	"github.com/jordanhubbard/loom/internal/api"
 	"github.com/jordanhubbard/loom/internal/auth"
+	"github.com/jordanhubbard/loom/internal/grpcapi"
 	"github.com/jordanhubbard/loom/internal/hotreload"
 	"github.com/jordanhubbard/loom/internal/keymanager"
+	"github.com/jordanhubbard/loom/internal/tlsserver"
 	"github.com/jordanhubbard/loom/pkg/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+var mainLog = logging.Module("main")
+
 const version = "0.1.0"
 
 func main() {
@@ -41,19 +51,41 @@ func main() {
 		return
 	}
 
+	if args := flag.Args(); len(args) > 0 && args[0] == "tui" {
+		os.Exit(runTUICommand(*configPath, args[1:]))
+	}
+
 	cfg, err := config.LoadConfigFromFile(*configPath)
 	if err != nil {
 		log.Fatalf("failed to load config from %s: %v", *configPath, err)
 	}
 
+	if level := os.Getenv("LOOM_LOG_LEVEL"); level != "" {
+		cfg.Logging.Level = level
+	}
+	if format := os.Getenv("LOOM_LOG_FORMAT"); format != "" {
+		cfg.Logging.Format = format
+	}
+	if err := logging.Setup(logging.Options{
+		Level:   cfg.Logging.Level,
+		Format:  cfg.Logging.Format,
+		Modules: cfg.Logging.Modules,
+	}); err != nil {
+		log.Fatalf("invalid logging configuration: %v", err)
+	}
+
 	// Override with environment variables if set
 	if temporalHost := os.Getenv("TEMPORAL_HOST"); temporalHost != "" {
 		cfg.Temporal.Host = temporalHost
-		log.Printf("Using Temporal host from environment: %s", temporalHost)
+		mainLog.Info("Using Temporal host from environment", "host", temporalHost)
 	}
 	if temporalNamespace := os.Getenv("TEMPORAL_NAMESPACE"); temporalNamespace != "" {
 		cfg.Temporal.Namespace = temporalNamespace
-		log.Printf("Using Temporal namespace from environment: %s", temporalNamespace)
+		mainLog.Info("Using Temporal namespace from environment", "namespace", temporalNamespace)
+	}
+
+	if args := flag.Args(); len(args) > 0 && args[0] == "backup" {
+		os.Exit(runBackupCommand(cfg, args[1:]))
 	}
 
 	arb, err := loom.New(cfg)
@@ -68,12 +100,12 @@ func main() {
 
 	password := loadPassword()
 	if password == "" {
-		log.Printf("Warning: No password found. Using default password. Set LOOM_PASSWORD environment variable or create .env file")
+		mainLog.Warn("No password found, using default password. Set LOOM_PASSWORD environment variable or create .env file")
 		password = "loom-default-password"
 	}
 
 	if err := km.Unlock(password); err != nil {
-		log.Printf("Password unlock failed: %v. Trying default password...", err)
+		mainLog.Warn("Password unlock failed, trying default password", "error", err)
 		if err := km.Unlock("loom-default-password"); err != nil {
 			log.Fatalf("Failed to unlock key manager with both passwords: %v", err)
 		}
@@ -96,16 +128,26 @@ func main() {
 			cfg.HotReload.Patterns,
 		)
 		if err != nil {
-			log.Printf("Hot-reload initialization failed: %v", err)
+			mainLog.Error("Hot-reload initialization failed", "error", err)
 		} else {
 			defer hrManager.Close()
 		}
 	}
 
 	go arb.StartMaintenanceLoop(runCtx)
+	go arb.StartScheduler(runCtx)
+	go arb.StartPolicyWatcher(runCtx)
+	go arb.StartCIMonitor(runCtx)
+	go arb.StartTrackerSync(runCtx)
+	go arb.StartBackups(runCtx)
+	go arb.StartRetention(runCtx)
+	go arb.StartSLA(runCtx)
+	go arb.StartSearchIndexer(runCtx)
+	go arb.StartPatternSnapshots(runCtx)
+	go arb.StartAnomalyAlerts(runCtx)
 
 	// Ralph dispatch loop: drain all dispatchable work every 10 seconds.
-	log.Printf("Starting dispatch loop goroutine")
+	mainLog.Info("Starting dispatch loop goroutine")
 	go arb.StartDispatchLoop(runCtx, 10*time.Second)
 
 	// Initialize auth manager (JWT + API key support)
@@ -121,23 +163,76 @@ func main() {
 		mux.HandleFunc("/ws/hotreload", hrManager.GetServer().HandleWebSocket)
 		mux.HandleFunc("/api/v1/hotreload/status", hrManager.GetServer().HandleStatus)
 		handler = mux
-		log.Println("[HotReload] WebSocket endpoint registered at /ws/hotreload")
+		logging.Module("hotreload").Info("WebSocket endpoint registered", "path", "/ws/hotreload")
 	}
 
-	httpSrv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Server.HTTPPort),
-		Handler:      handler,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  cfg.Server.IdleTimeout,
+	var servers []*http.Server
+
+	var reloader *tlsserver.Reloader
+	if cfg.Server.EnableHTTPS {
+		reloader, err = tlsserver.NewReloader(tlsserver.OptionsFrom(cfg.Server, cfg.Security))
+		if err != nil {
+			log.Fatalf("failed to configure TLS: %v", err)
+		}
+		go reloader.Watch(runCtx, cfg.Server.TLSReloadInterval)
+
+		httpsSrv := &http.Server{
+			Addr:         fmt.Sprintf(":%d", cfg.Server.HTTPSPort),
+			Handler:      handler,
+			TLSConfig:    reloader.TLSConfig(),
+			ReadTimeout:  cfg.Server.ReadTimeout,
+			WriteTimeout: cfg.Server.WriteTimeout,
+			IdleTimeout:  cfg.Server.IdleTimeout,
+		}
+		servers = append(servers, httpsSrv)
+		go func() {
+			mainLog.Info("Loom API listening", "addr", httpsSrv.Addr, "tls", true)
+			if err := httpsSrv.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("https server error: %v", err)
+			}
+		}()
 	}
 
-	go func() {
-		log.Printf("Loom API listening on %s", httpSrv.Addr)
-		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("http server error: %v", err)
+	// Plain HTTP stays on unless HTTPS replaces it
+	if cfg.Server.EnableHTTP || !cfg.Server.EnableHTTPS {
+		httpHandler := handler
+		if cfg.Server.EnableHTTPS && cfg.Security.RequireHTTPS {
+			httpHandler = redirectToHTTPS(cfg.Server.HTTPSPort, handler)
 		}
-	}()
+		httpSrv := &http.Server{
+			Addr:         fmt.Sprintf(":%d", cfg.Server.HTTPPort),
+			Handler:      httpHandler,
+			ReadTimeout:  cfg.Server.ReadTimeout,
+			WriteTimeout: cfg.Server.WriteTimeout,
+			IdleTimeout:  cfg.Server.IdleTimeout,
+		}
+		servers = append(servers, httpSrv)
+		go func() {
+			mainLog.Info("Loom API listening", "addr", httpSrv.Addr)
+			if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("http server error: %v", err)
+			}
+		}()
+	}
+
+	var grpcSrv *grpc.Server
+	if cfg.GRPC.Enabled {
+		grpcSrv = newGRPCServer(arb, authManager, cfg, reloader)
+		port := cfg.GRPC.Port
+		if port == 0 {
+			port = 9090
+		}
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			log.Fatalf("grpc listen error: %v", err)
+		}
+		go func() {
+			mainLog.Info("Loom gRPC agent runtime listening", "addr", lis.Addr().String())
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Fatalf("grpc server error: %v", err)
+			}
+		}()
+	}
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -147,11 +242,67 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	_ = httpSrv.Shutdown(shutdownCtx)
+	for _, srv := range servers {
+		_ = srv.Shutdown(shutdownCtx)
+	}
+	if grpcSrv != nil {
+		// Event subscriptions never finish on their own
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			grpcSrv.Stop()
+		}
+	}
 	arb.Shutdown()
 
 }
 
+// newGRPCServer creates the gRPC agent runtime server on the same router,
+// event bus, agent manager and credentials as the REST API
+func newGRPCServer(arb *loom.Loom, authManager *auth.Manager, cfg *config.Config, reloader *tlsserver.Reloader) *grpc.Server {
+	var rt grpcapi.Runtime
+	if router := arb.GetActionRouter(); router != nil {
+		rt.Actions = router
+	}
+	if bus := arb.GetEventBus(); bus != nil {
+		rt.Events = bus
+	}
+	if agents := arb.GetAgentManager(); agents != nil {
+		rt.Agents = agents
+	}
+	opts := grpcapi.Options{ClientCertRole: cfg.Security.ClientCertRole}
+	if cfg.Security.EnableAuth {
+		opts.Auth = authManager
+	}
+	var serverOpts []grpc.ServerOption
+	if reloader != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(reloader.TLSConfig())))
+	}
+	return grpcapi.NewServer(rt, opts, serverOpts...)
+}
+
+// redirectToHTTPS sends plain HTTP requests to the HTTPS listener, except
+// health checks, which probes make over plain HTTP
+func redirectToHTTPS(httpsPort int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/health") || r.URL.Path == "/api/v1/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		target := url.URL{Scheme: "https", Host: net.JoinHostPort(host, strconv.Itoa(httpsPort)), Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+		http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+	})
+}
+
 func loadPassword() string {
 	// First, check environment variable
 	if pwd := os.Getenv("LOOM_PASSWORD"); pwd != "" {
@@ -180,13 +331,20 @@ func loadPassword() string {
 }
 
 func printHelp() {
-	fmt.Println("Usage: loom [flags]")
+	fmt.Println("Usage: loom [flags] [backup <command> | tui]")
 	fmt.Println()
 	fmt.Println("Flags:")
 	fmt.Println("  -config   Path to configuration file (default: config.yaml)")
 	fmt.Println("  -version  Show version information")
 	fmt.Println("  -help     Show help message")
 	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  backup    List, take, verify or restore backups (loom backup for details)")
+	fmt.Println("  tui       Terminal dashboard of a running server (loom tui -help for flags)")
+	fmt.Println()
 	fmt.Println("Environment:")
-	fmt.Println("  LOOM_PASSWORD  Master password for UI login and key encryption")
+	fmt.Println("  LOOM_PASSWORD    Master password for UI login and key encryption")
+	fmt.Println("  LOOM_API_KEY     API key used by loom tui")
+	fmt.Println("  LOOM_LOG_LEVEL   Log level: debug, info, warn or error")
+	fmt.Println("  LOOM_LOG_FORMAT  Log format: text or json")
 }
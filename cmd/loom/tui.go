@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jordanhubbard/loom/internal/tui"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// runTUICommand handles "loom tui": a terminal dashboard on a running
+// server. It returns the process exit code.
+func runTUICommand(configPath string, args []string) int {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	server := fs.String("server", "", "Server URL (default: the HTTP port from the config file on localhost)")
+	apiKey := fs.String("api-key", os.Getenv("LOOM_API_KEY"), "API key (default: $LOOM_API_KEY)")
+	token := fs.String("token", os.Getenv("LOOM_TOKEN"), "JWT used when no API key is given (default: $LOOM_TOKEN)")
+	project := fs.String("project", "", "Only show this project's beads and logs")
+	refresh := fs.Duration("refresh", tui.DefaultRefresh, "How often beads and agents are reloaded")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *server == "" {
+		port := 8080
+		// The config file is optional here: the dashboard may run far from
+		// the server's installation
+		if cfg, err := config.LoadConfigFromFile(configPath); err == nil && cfg.Server.HTTPPort > 0 {
+			port = cfg.Server.HTTPPort
+		}
+		*server = fmt.Sprintf("http://localhost:%d", port)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client := tui.NewClient(*server, *apiKey, *token)
+	if err := tui.Run(ctx, client, *server, tui.Options{ProjectID: *project, Refresh: *refresh}, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "tui: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/benchmarks"
+)
+
+// handleBenchmarkAction runs the project's benchmarks and reports how they
+// compare with the baseline branch. The run is stored against the bead, so
+// the workflow's performance gate sees its regressions.
+func (r *Router) handleBenchmarkAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Benchmarks == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "benchmarks not configured"}
+	}
+
+	path := action.Path
+	if path == "" {
+		_, sp, err := r.resolveSubproject(action, actx)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		if sp != nil {
+			path = sp.Path
+		}
+	}
+
+	res, err := r.Benchmarks.Run(ctx, actx.ProjectID, actx.BeadID, benchmarks.Options{
+		Tool:      action.Tool,
+		Path:      path,
+		Bench:     action.Bench,
+		Commands:  action.Commands,
+		Count:     action.Count,
+		Threshold: action.Threshold,
+		Baseline:  action.Baseline,
+		Timeout:   time.Duration(action.TimeoutSeconds) * time.Second,
+	})
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+
+	run := res.Run
+	var message string
+	switch {
+	case !res.Success:
+		message = res.Error
+	case res.Baseline == nil || len(res.Comparisons) == 0:
+		message = fmt.Sprintf("ran %d benchmarks; %s", len(run.Results), res.BaselineNote)
+	case len(run.Regressions) > 0:
+		message = fmt.Sprintf("%d regressions beyond %g%% against %s at %s", len(run.Regressions), run.Threshold, res.Baseline.Branch, shortSHA(res.Baseline.Commit))
+	default:
+		message = fmt.Sprintf("ran %d benchmarks, no regressions beyond %g%% against %s at %s", len(run.Results), run.Threshold, res.Baseline.Branch, shortSHA(res.Baseline.Commit))
+	}
+	metadata := map[string]interface{}{
+		"success":       res.Success,
+		"run_id":        run.ID,
+		"tool":          run.Tool,
+		"branch":        run.Branch,
+		"commit":        run.Commit,
+		"threshold":     run.Threshold,
+		"benchmarks":    run.Results,
+		"comparisons":   res.Comparisons,
+		"regressions":   len(run.Regressions),
+		"baseline_note": res.BaselineNote,
+		"output":        res.Output,
+		"error":         res.Error,
+		"duration":      res.Duration,
+	}
+	if res.Baseline != nil {
+		metadata["baseline_branch"] = res.Baseline.Branch
+		metadata["baseline_commit"] = res.Baseline.Commit
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    message,
+		Metadata:   metadata,
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
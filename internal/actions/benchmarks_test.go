@@ -0,0 +1,76 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/benchmarks"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeBenchmarks struct {
+	opts   benchmarks.Options
+	beadID string
+	result *benchmarks.Result
+}
+
+func (f *fakeBenchmarks) Run(_ context.Context, _, beadID string, opts benchmarks.Options) (*benchmarks.Result, error) {
+	f.opts, f.beadID = opts, beadID
+	return f.result, nil
+}
+
+func TestRouterRunBenchmarks(t *testing.T) {
+	run := &models.BenchmarkRun{
+		ID: "bench-1", Tool: benchmarks.Go, Branch: "bead-1", Commit: "c2", Threshold: 10,
+		Results: []models.BenchmarkResult{{Name: "BenchmarkParse", Package: "example.com/app", NsPerOp: 1500, BytesPerOp: 64, AllocsPerOp: 4, Memory: true}},
+		Regressions: []models.BenchmarkComparison{
+			{Name: "example.com/app.BenchmarkParse", Metric: models.MetricNsPerOp, Baseline: 1000, Current: 1500, Delta: 50, Regression: true},
+		},
+	}
+	fake := &fakeBenchmarks{result: &benchmarks.Result{
+		Success:     true,
+		Run:         run,
+		Baseline:    &models.BenchmarkRun{Branch: "main", Commit: "c1c1c1c1c1c1"},
+		Comparisons: append([]models.BenchmarkComparison{}, run.Regressions...),
+	}}
+	r := &Router{Benchmarks: fake}
+
+	res := r.executeAction(context.Background(), Action{Type: ActionRunBenchmarks, Bench: "Parse", Count: 5, Threshold: 10}, ActionContext{ProjectID: "p", BeadID: "bead-1"})
+	if res.Status != "executed" || res.Message != "1 regressions beyond 10% against main at c1c1c1c1" {
+		t.Fatalf("unexpected result %s: %s", res.Status, res.Message)
+	}
+	if fake.beadID != "bead-1" || fake.opts.Bench != "Parse" || fake.opts.Count != 5 {
+		t.Errorf("options not passed through: %+v", fake.opts)
+	}
+	feedback := FormatResultsAsUserMessage([]Result{res})
+	for _, want := range []string{"**Benchmarks: REGRESSED**", "- example.com/app.BenchmarkParse ns/op: 1000 -> 1500 (+50.0%) REGRESSION"} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback missing %q:\n%s", want, feedback)
+		}
+	}
+
+	// Without a baseline the metrics themselves are fed back
+	fake.result = &benchmarks.Result{Success: true, Run: run, BaselineNote: "baseline branch main not found"}
+	res = r.executeAction(context.Background(), Action{Type: ActionRunBenchmarks}, ActionContext{ProjectID: "p"})
+	feedback = FormatResultsAsUserMessage([]Result{res})
+	for _, want := range []string{"ran 1 benchmarks; baseline branch main not found", "- example.com/app.BenchmarkParse: 1500 ns/op, 64 B/op, 4 allocs/op"} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback missing %q:\n%s", want, feedback)
+		}
+	}
+
+	fake.result = &benchmarks.Result{Run: &models.BenchmarkRun{}, Error: "go test failed: exit status 1", Output: "syntax error"}
+	res = r.executeAction(context.Background(), Action{Type: ActionRunBenchmarks}, ActionContext{ProjectID: "p"})
+	feedback = FormatResultsAsUserMessage([]Result{res})
+	if !strings.Contains(feedback, "**Benchmarks: FAILED** go test failed") || !strings.Contains(feedback, "syntax error") {
+		t.Errorf("unexpected feedback:\n%s", feedback)
+	}
+
+	if err := Validate(&ActionEnvelope{Actions: []Action{{Type: ActionRunBenchmarks, Tool: "hyperfine"}}}); err == nil {
+		t.Error("expected hyperfine without commands to be rejected")
+	}
+	if res := (&Router{}).executeAction(context.Background(), Action{Type: ActionRunBenchmarks}, ActionContext{}); res.Status != "error" {
+		t.Errorf("expected an error without a benchmark runner, got %s", res.Status)
+	}
+}
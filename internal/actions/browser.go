@@ -0,0 +1,89 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/attachments"
+	"github.com/jordanhubbard/loom/internal/browser"
+)
+
+// handleBrowserAction runs a headless browser session against a web UI and
+// attaches its screenshots to the bead, so the model sees them with the
+// results and reviewers find them on the bead.
+func (r *Router) handleBrowserAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Browser == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "browser automation not configured"}
+	}
+
+	session, err := r.Browser.Run(ctx, actx.ProjectID, browser.Session{
+		URL:     action.URL,
+		Steps:   action.Steps,
+		Browser: action.Browser,
+		Timeout: time.Duration(action.TimeoutSeconds) * time.Second,
+	})
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+
+	var imageIDs []string
+	var attachErrors []string
+	if r.Images != nil && actx.BeadID != "" {
+		for _, shot := range session.Screenshots {
+			img, err := r.Images.Attach(actx.BeadID, shot.Name, "image/png", shot.Data, attachments.SourceBrowser)
+			if err != nil {
+				attachErrors = append(attachErrors, fmt.Sprintf("%s: %v", shot.Name, err))
+				continue
+			}
+			imageIDs = append(imageIDs, img.ID)
+		}
+	}
+
+	passed := 0
+	var failed *browser.StepResult
+	for i, step := range session.Steps {
+		if step.Passed {
+			passed++
+		} else if failed == nil && !step.Skipped {
+			failed = &session.Steps[i]
+		}
+	}
+	var message string
+	switch {
+	case session.Error != "":
+		message = "browser session failed: " + session.Error
+	case session.Success:
+		message = fmt.Sprintf("all %d browser steps passed in %s", len(session.Steps), session.Duration)
+	case failed != nil:
+		message = fmt.Sprintf("browser step %d (%s %s) failed: %s", failed.Index, failed.Action, failed.Target, failed.Error)
+	case session.TimedOut:
+		message = fmt.Sprintf("browser session timed out after %s with %d of %d steps passed", session.Duration, passed, len(session.Steps))
+	default:
+		message = fmt.Sprintf("%d of %d browser steps passed", passed, len(session.Steps))
+	}
+	if len(imageIDs) > 0 {
+		message += fmt.Sprintf("; %d screenshots attached to bead %s follow", len(imageIDs), actx.BeadID)
+	}
+
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    message,
+		Metadata: map[string]interface{}{
+			"browser":       session.Browser,
+			"success":       session.Success,
+			"url":           session.URL,
+			"title":         session.Title,
+			"steps":         session.Steps,
+			"screenshots":   session.Screenshots,
+			"image_ids":     imageIDs,
+			"attach_errors": attachErrors,
+			"console":       session.Console,
+			"page_errors":   session.PageErrors,
+			"duration":      session.Duration,
+			"timed_out":     session.TimedOut,
+			"error":         session.Error,
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/browser"
+)
+
+type fakeBrowser struct {
+	session browser.Session
+	result  *browser.Result
+}
+
+func (f *fakeBrowser) Run(ctx context.Context, projectID string, s browser.Session) (*browser.Result, error) {
+	if err := browser.Validate(s); err != nil {
+		return nil, err
+	}
+	f.session = s
+	return f.result, nil
+}
+
+func TestRouterBrowser(t *testing.T) {
+	fb := &fakeBrowser{result: &browser.Result{
+		Browser: browser.Chromium,
+		URL:     "http://localhost:3000/settings",
+		Title:   "Settings",
+		Steps: []browser.StepResult{
+			{Index: 0, Action: browser.StepNavigate, Target: "http://localhost:3000/", Passed: true},
+			{Index: 1, Action: browser.StepScreenshot, Passed: true, Screenshot: "home.png"},
+			{Index: 2, Action: browser.StepClick, Target: "#save", Error: "Timeout 10000ms exceeded", Screenshot: "failure.png"},
+			{Index: 3, Action: browser.StepAssertText, Target: "Saved", Skipped: true},
+		},
+		Screenshots: []browser.Screenshot{
+			{Name: "home.png", Step: 1, Data: []byte("a")},
+			{Name: "failure.png", Step: 2, Data: []byte("b")},
+		},
+		Console:    []browser.ConsoleMessage{{Type: "error", Text: "Failed to load resource: 500"}},
+		PageErrors: []string{"TypeError: settings is undefined"},
+		Duration:   "11.2s",
+	}}
+	images := &fakeImages{}
+	r := &Router{Browser: fb, Images: images}
+
+	res := r.executeAction(context.Background(), Action{
+		Type:  ActionBrowser,
+		URL:   "http://localhost:3000/",
+		Steps: []browser.Step{{Action: browser.StepScreenshot, Name: "home"}, {Action: browser.StepClick, Selector: "#save"}, {Action: browser.StepAssertText, Text: "Saved"}},
+	}, ActionContext{ProjectID: "web", BeadID: "b1"})
+	if res.Status != "executed" || len(fb.session.Steps) != 3 {
+		t.Fatalf("unexpected result %s: %s", res.Status, res.Message)
+	}
+	if ids, _ := res.Metadata["image_ids"].([]string); len(ids) != 1 || ids[0] != "img-home.png" {
+		t.Errorf("expected the first screenshot to be attached, got %v", res.Metadata["image_ids"])
+	}
+	feedback := FormatResultsAsUserMessage([]Result{res})
+	for _, want := range []string{
+		"**Browser: FAILED** browser step 2 (click #save) failed: Timeout 10000ms exceeded",
+		"1 screenshots attached to bead b1",
+		"2. click #save: FAILED: Timeout 10000ms exceeded [screenshot failure.png]",
+		"3. assert_text Saved: skipped",
+		"- TypeError: settings is undefined",
+		"- [error] Failed to load resource: 500",
+		"Screenshot not attached: failure.png",
+	} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback missing %q:\n%s", want, feedback)
+		}
+	}
+
+	if res := r.executeAction(context.Background(), Action{Type: ActionBrowser, URL: "file:///etc/passwd"}, ActionContext{}); res.Status != "error" {
+		t.Errorf("expected a file URL to be rejected, got %s", res.Status)
+	}
+	if res := (&Router{}).executeAction(context.Background(), Action{Type: ActionBrowser, URL: "http://localhost/"}, ActionContext{}); res.Status != "error" {
+		t.Errorf("expected an error without a browser, got %s", res.Status)
+	}
+	if err := Validate(&ActionEnvelope{Actions: []Action{{Type: ActionBrowser}}}); err == nil {
+		t.Error("expected browser without url to be rejected")
+	}
+}
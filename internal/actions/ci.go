@@ -0,0 +1,46 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/ci"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// handleCIAction reports the latest CI run for the bead's pushed branch so
+// agents can react to failures instead of pushing and hoping.
+func (r *Router) handleCIAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.CI == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "CI integration not configured"}
+	}
+
+	run, err := r.CI.Status(ctx, actx.ProjectID, actx.BeadID, action.Branch)
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+	if run == nil {
+		return Result{ActionType: action.Type, Status: "executed", Message: "CI has not reported a run for this branch yet; check again later"}
+	}
+
+	metadata := map[string]interface{}{
+		"run_id":   run.ID,
+		"provider": run.Provider,
+		"branch":   run.Branch,
+		"commit":   run.CommitSHA,
+		"status":   run.Status,
+		"url":      run.URL,
+		"jobs":     run.Jobs,
+	}
+	var message string
+	switch run.Status {
+	case models.CIStatusFailure:
+		message = fmt.Sprintf("CI failed on %s: %d of %d jobs failed", run.Branch, len(run.FailedJobs()), len(run.Jobs))
+		metadata["feedback"] = ci.FailureMessage(run)
+	case models.CIStatusSuccess:
+		message = fmt.Sprintf("CI passed on %s (%d jobs)", run.Branch, len(run.Jobs))
+	default:
+		message = fmt.Sprintf("CI %s on %s; check again later", run.Status, run.Branch)
+	}
+	return Result{ActionType: action.Type, Status: "executed", Message: message, Metadata: metadata}
+}
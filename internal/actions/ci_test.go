@@ -0,0 +1,61 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type mockCIMonitor struct {
+	watched string
+	run     *models.CIRun
+}
+
+func (m *mockCIMonitor) Watch(projectID, beadID, branch string) {
+	m.watched = projectID + "/" + beadID + "/" + branch
+}
+
+func (m *mockCIMonitor) Status(ctx context.Context, projectID, beadID, branch string) (*models.CIRun, error) {
+	return m.run, nil
+}
+
+func TestRouterGitPushWatchesCI(t *testing.T) {
+	monitor := &mockCIMonitor{}
+	r := &Router{Git: &mockGitOperator{result: map[string]interface{}{"branch": "agent/bd-1/fix"}}, CI: monitor}
+
+	res := r.executeAction(context.Background(), Action{Type: ActionGitPush}, ActionContext{ProjectID: "p1", BeadID: "bd-1"})
+	if res.Status != "executed" {
+		t.Fatalf("expected executed, got %s: %s", res.Status, res.Message)
+	}
+	if monitor.watched != "p1/bd-1/agent/bd-1/fix" {
+		t.Errorf("expected pushed branch to be watched, got %q", monitor.watched)
+	}
+}
+
+func TestRouterCheckCI(t *testing.T) {
+	monitor := &mockCIMonitor{}
+	r := &Router{CI: monitor}
+	actx := ActionContext{ProjectID: "p1", BeadID: "bd-1"}
+
+	res := r.executeAction(context.Background(), Action{Type: ActionCheckCI}, actx)
+	if res.Status != "executed" || !strings.Contains(res.Message, "has not reported") {
+		t.Errorf("unexpected result before CI reports: %s: %s", res.Status, res.Message)
+	}
+
+	monitor.run = &models.CIRun{ID: "7", Provider: "github", Branch: "agent/bd-1/fix", Status: models.CIStatusFailure,
+		Jobs: []models.CIJob{{Name: "test", Status: models.CIStatusFailure, LogExcerpt: "--- FAIL: TestWidget"}, {Name: "lint", Status: models.CIStatusSuccess}}}
+	res = r.executeAction(context.Background(), Action{Type: ActionCheckCI}, actx)
+	if res.Message != "CI failed on agent/bd-1/fix: 1 of 2 jobs failed" {
+		t.Errorf("unexpected message: %s", res.Message)
+	}
+	feedback := FormatResultsAsUserMessage([]Result{res})
+	if !strings.Contains(feedback, "CI failed: job test (github on agent/bd-1/fix)") || !strings.Contains(feedback, "--- FAIL: TestWidget") {
+		t.Errorf("feedback missing failure details:\n%s", feedback)
+	}
+
+	if res := (&Router{}).executeAction(context.Background(), Action{Type: ActionCheckCI}, actx); res.Status != "error" {
+		t.Errorf("expected error without CI integration, got %s", res.Status)
+	}
+}
@@ -0,0 +1,129 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/containers"
+)
+
+// handleBuildImageAction builds a container image from the project, or the
+// bead's subproject, and reports the build log.
+func (r *Router) handleBuildImageAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Containers == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "container runtime not configured"}
+	}
+
+	contextDir := action.Path
+	if contextDir == "" {
+		_, sp, err := r.resolveSubproject(action, actx)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		if sp != nil {
+			contextDir = sp.Path
+		}
+	}
+
+	build, err := r.Containers.Build(ctx, actx.ProjectID, containers.BuildOptions{
+		Dockerfile: action.Dockerfile,
+		Context:    contextDir,
+		Tag:        action.Image,
+		BuildArgs:  action.BuildArgs,
+		Target:     action.BuildTarget,
+		BeadID:     actx.BeadID,
+		Timeout:    time.Duration(action.TimeoutSeconds) * time.Second,
+	})
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+
+	message := fmt.Sprintf("built %s with %s in %s", build.Image, build.Runtime, build.Duration)
+	if !build.Success {
+		message = fmt.Sprintf("build of %s failed with exit code %d", build.Image, build.ExitCode)
+		if build.TimedOut {
+			message = fmt.Sprintf("build of %s timed out after %s", build.Image, build.Duration)
+		}
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    message,
+		Metadata: map[string]interface{}{
+			"image":     build.Image,
+			"runtime":   build.Runtime,
+			"success":   build.Success,
+			"exit_code": build.ExitCode,
+			"duration":  build.Duration,
+			"timed_out": build.TimedOut,
+			"output":    strings.Join(build.Log, "\n"),
+			"log_lines": build.LogLines,
+			"error":     build.Error,
+		},
+	}
+}
+
+// handleRunContainerAction runs a smoke-test container and reports its
+// health, exit status and logs.
+func (r *Router) handleRunContainerAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Containers == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "container runtime not configured"}
+	}
+
+	opts := containers.RunOptions{
+		Image:   action.Image,
+		Command: action.ContainerCommand,
+		Env:     action.Env,
+		Ports:   action.Ports,
+		BeadID:  actx.BeadID,
+		Timeout: time.Duration(action.TimeoutSeconds) * time.Second,
+	}
+	if action.HealthPath != "" || action.HealthPort != 0 {
+		opts.Health = &containers.HealthCheck{Port: action.HealthPort, Path: action.HealthPath}
+	}
+	run, err := r.Containers.Run(ctx, actx.ProjectID, opts)
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+
+	var message string
+	switch {
+	case run.Healthy != nil && *run.Healthy:
+		message = fmt.Sprintf("%s passed its health check in %s", run.Image, run.Duration)
+	case run.Healthy != nil:
+		message = fmt.Sprintf("%s failed its health check: %s", run.Image, run.HealthError)
+	case run.ExitCode != nil:
+		message = fmt.Sprintf("%s exited with code %d after %s", run.Image, *run.ExitCode, run.Duration)
+	default:
+		message = fmt.Sprintf("%s was still running after %s and was stopped", run.Image, run.Duration)
+	}
+
+	metadata := map[string]interface{}{
+		"container_id":   run.ContainerID,
+		"image":          run.Image,
+		"runtime":        run.Runtime,
+		"success":        run.Success,
+		"running":        run.Running,
+		"timed_out":      run.TimedOut,
+		"duration":       run.Duration,
+		"ports":          run.Ports,
+		"logs":           run.Logs,
+		"logs_truncated": run.LogsTruncated,
+	}
+	if run.ExitCode != nil {
+		metadata["exit_code"] = *run.ExitCode
+	}
+	if run.Healthy != nil {
+		metadata["healthy"] = *run.Healthy
+		metadata["health_status"] = run.HealthStatus
+		metadata["health_error"] = run.HealthError
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    message,
+		Metadata:   metadata,
+	}
+}
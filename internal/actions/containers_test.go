@@ -0,0 +1,110 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/containers"
+)
+
+type mockContainerRuntime struct {
+	buildOpts containers.BuildOptions
+	runOpts   containers.RunOptions
+	build     *containers.BuildResult
+	run       *containers.RunResult
+	err       error
+}
+
+func (m *mockContainerRuntime) Build(ctx context.Context, projectID string, opts containers.BuildOptions) (*containers.BuildResult, error) {
+	m.buildOpts = opts
+	return m.build, m.err
+}
+
+func (m *mockContainerRuntime) Run(ctx context.Context, projectID string, opts containers.RunOptions) (*containers.RunResult, error) {
+	m.runOpts = opts
+	return m.run, m.err
+}
+
+func TestRouterBuildImage(t *testing.T) {
+	rt := &mockContainerRuntime{build: &containers.BuildResult{
+		Image:    "loom/mono:bd-1",
+		Runtime:  "docker",
+		ExitCode: 1,
+		Duration: "4.2s",
+		Log:      []string{"#5 [build 2/3] RUN go build ./...", "main.go:3:2: undefined: foo", "ERROR: process did not complete successfully"},
+	}}
+	r := &Router{Containers: rt, Projects: monorepoProject("/repo")}
+
+	res := r.executeAction(context.Background(),
+		Action{Type: ActionBuildImage, BuildArgs: map[string]string{"GO_VERSION": "1.25"}, BuildTarget: "build", TimeoutSeconds: 60},
+		ActionContext{ProjectID: "mono", BeadID: "bd-1", Subproject: "api"})
+	if res.Status != "executed" || res.Message != "build of loom/mono:bd-1 failed with exit code 1" {
+		t.Fatalf("unexpected result %s: %s", res.Status, res.Message)
+	}
+	if rt.buildOpts.Context != "services/api" || rt.buildOpts.Target != "build" || rt.buildOpts.BeadID != "bd-1" || rt.buildOpts.Timeout.Seconds() != 60 {
+		t.Errorf("unexpected build options: %+v", rt.buildOpts)
+	}
+
+	feedback := FormatResultsAsUserMessage([]Result{res})
+	for _, want := range []string{"**Image build: FAILED**", "undefined: foo", "Please fix the Dockerfile"} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback missing %q:\n%s", want, feedback)
+		}
+	}
+}
+
+func TestRouterRunContainer(t *testing.T) {
+	healthy := true
+	rt := &mockContainerRuntime{run: &containers.RunResult{
+		Image:    "loom/app:dev",
+		Runtime:  "podman",
+		Success:  true,
+		Running:  true,
+		Healthy:  &healthy,
+		Ports:    map[string]string{"8080": "127.0.0.1:41234"},
+		Logs:     []containers.LogLine{{Stream: "stdout", Text: "listening on :8080"}, {Stream: "stderr", Text: "warning: no config"}},
+		Duration: "2.1s",
+	}}
+	r := &Router{Containers: rt}
+
+	res := r.executeAction(context.Background(),
+		Action{Type: ActionRunContainer, Image: "loom/app:dev", Ports: []string{"8080"}, HealthPath: "/healthz", Env: map[string]string{"MODE": "test"}},
+		ActionContext{ProjectID: "p"})
+	if res.Status != "executed" || res.Message != "loom/app:dev passed its health check in 2.1s" {
+		t.Fatalf("unexpected result %s: %s", res.Status, res.Message)
+	}
+	if rt.runOpts.Health == nil || rt.runOpts.Health.Path != "/healthz" || rt.runOpts.Env["MODE"] != "test" {
+		t.Errorf("unexpected run options: %+v", rt.runOpts)
+	}
+	if _, ok := res.Metadata["exit_code"]; ok {
+		t.Error("a running container should not report an exit code")
+	}
+
+	feedback := FormatResultsAsUserMessage([]Result{res})
+	for _, want := range []string{"**Container: PASSED**", "port 8080 -> 127.0.0.1:41234", "[stderr] warning: no config"} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback missing %q:\n%s", want, feedback)
+		}
+	}
+}
+
+func TestRouterContainerErrors(t *testing.T) {
+	r := &Router{}
+	for _, action := range []Action{{Type: ActionBuildImage}, {Type: ActionRunContainer, Image: "app"}} {
+		if res := r.executeAction(context.Background(), action, ActionContext{ProjectID: "p"}); res.Status != "error" {
+			t.Errorf("expected %s to fail without a runtime, got %s", action.Type, res.Status)
+		}
+	}
+
+	r.Containers = &mockContainerRuntime{err: errors.New("no container runtime installed (need docker or podman)")}
+	res := r.executeAction(context.Background(), Action{Type: ActionRunContainer, Image: "app"}, ActionContext{ProjectID: "p"})
+	if res.Status != "error" || !strings.Contains(res.Message, "no container runtime") {
+		t.Errorf("expected runtime error, got %s: %s", res.Status, res.Message)
+	}
+
+	if err := Validate(&ActionEnvelope{Actions: []Action{{Type: ActionRunContainer}}}); err == nil {
+		t.Error("expected run_container without image to be rejected")
+	}
+}
@@ -0,0 +1,146 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/metrics"
+)
+
+// DefaultMaxCorrectionAttempts is how many times a malformed response is
+// sent back to the model before falling back to AutoFileParseFailure.
+const DefaultMaxCorrectionAttempts = 2
+
+// MaxCorrectionAttempts resolves a configured number of correction
+// attempts: zero uses DefaultMaxCorrectionAttempts and a negative number
+// disables correction.
+func MaxCorrectionAttempts(configured int) int {
+	switch {
+	case configured == 0:
+		return DefaultMaxCorrectionAttempts
+	case configured < 0:
+		return 0
+	}
+	return configured
+}
+
+// ActionEnvelopeSchema is the JSON schema shown to models when their
+// response fails to decode or validate.
+const ActionEnvelopeSchema = `{
+  "type": "object",
+  "required": ["actions"],
+  "additionalProperties": false,
+  "properties": {
+    "actions": {
+      "type": "array",
+      "minItems": 1,
+      "items": {
+        "type": "object",
+        "required": ["type"],
+        "properties": {
+          "type": {"type": "string"}
+        }
+      }
+    },
+    "notes": {"type": "string"}
+  }
+}`
+
+// Correction outcomes recorded in metrics.
+const (
+	CorrectionOutcomeCorrected = "corrected"
+	CorrectionOutcomeExhausted = "exhausted"
+	CorrectionOutcomeError     = "reprompt_error"
+)
+
+// Reprompter sends correction feedback to the model and returns its new raw response.
+type Reprompter func(ctx context.Context, feedback string) (string, error)
+
+// CorrectionResult describes the outcome of DecodeWithCorrection.
+type CorrectionResult struct {
+	Envelope *ActionEnvelope
+	Raw      string // Last raw response received from the model
+	Attempts int    // Number of re-prompts issued
+	Err      error  // Last decode error when Envelope is nil
+}
+
+// Corrected reports whether the envelope was only obtained after re-prompting.
+func (r *CorrectionResult) Corrected() bool {
+	return r.Envelope != nil && r.Attempts > 0
+}
+
+// BuildCorrectionPrompt builds the user message sent back to the model after
+// a parse or validation failure.
+func BuildCorrectionPrompt(err error, raw string, attempt, maxAttempts int) string {
+	var sb strings.Builder
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		sb.WriteString("## Action Validation Error\n\n")
+		sb.WriteString("Your JSON was valid but the actions are incomplete: ")
+	} else {
+		sb.WriteString("## Parse Error\n\n")
+		sb.WriteString("Your response could not be parsed as JSON actions: ")
+	}
+	sb.WriteString(err.Error())
+	sb.WriteString(fmt.Sprintf("\n\nCorrection attempt %d of %d.", attempt, maxAttempts))
+
+	if raw = strings.TrimSpace(raw); raw != "" {
+		sb.WriteString("\n\nYour previous response was:\n```\n")
+		sb.WriteString(truncateContent(raw, 1000))
+		sb.WriteString("\n```")
+	}
+
+	sb.WriteString("\n\nRespond again with a single JSON object matching this schema:\n```json\n")
+	sb.WriteString(ActionEnvelopeSchema)
+	sb.WriteString("\n```\n\nEach action must include the required fields for its type. Do not include any text outside the JSON.")
+	return sb.String()
+}
+
+// DecodeWithCorrection decodes raw with DecodeLenient. On failure it re-prompts
+// the model with the error and the expected schema up to maxAttempts times.
+// A nil reprompt or maxAttempts <= 0 disables correction.
+func DecodeWithCorrection(ctx context.Context, raw string, maxAttempts int, reprompt Reprompter) *CorrectionResult {
+	result := &CorrectionResult{Raw: raw}
+
+	env, err := DecodeLenient([]byte(raw))
+	if err == nil {
+		result.Envelope = env
+		return result
+	}
+	result.Err = err
+	if reprompt == nil || maxAttempts <= 0 {
+		return result
+	}
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if ctx.Err() != nil {
+			break
+		}
+		result.Attempts = attempt
+		next, repromptErr := reprompt(ctx, BuildCorrectionPrompt(result.Err, result.Raw, attempt, maxAttempts))
+		if repromptErr != nil {
+			recordCorrection(CorrectionOutcomeError, attempt)
+			result.Err = fmt.Errorf("%w (correction re-prompt failed: %v)", result.Err, repromptErr)
+			return result
+		}
+		result.Raw = next
+		env, err = DecodeLenient([]byte(next))
+		if err == nil {
+			recordCorrection(CorrectionOutcomeCorrected, attempt)
+			result.Envelope = env
+			result.Err = nil
+			return result
+		}
+		result.Err = err
+	}
+
+	recordCorrection(CorrectionOutcomeExhausted, result.Attempts)
+	return result
+}
+
+func recordCorrection(outcome string, attempts int) {
+	metrics.NewMetrics().RecordActionCorrection(outcome, attempts)
+}
@@ -0,0 +1,97 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeWithCorrection_ValidFirstPass(t *testing.T) {
+	called := false
+	reprompt := func(ctx context.Context, feedback string) (string, error) {
+		called = true
+		return "", nil
+	}
+	res := DecodeWithCorrection(context.Background(), `{"actions":[{"type":"done"}]}`, 2, reprompt)
+	if res.Envelope == nil || res.Err != nil {
+		t.Fatalf("expected envelope, got err %v", res.Err)
+	}
+	if called || res.Attempts != 0 || res.Corrected() {
+		t.Errorf("expected no re-prompt, attempts=%d", res.Attempts)
+	}
+}
+
+func TestDecodeWithCorrection_CorrectedOnRetry(t *testing.T) {
+	var feedbacks []string
+	reprompt := func(ctx context.Context, feedback string) (string, error) {
+		feedbacks = append(feedbacks, feedback)
+		return `{"actions":[{"type":"read_file","path":"main.go"}]}`, nil
+	}
+	res := DecodeWithCorrection(context.Background(), `{"actions":[{"type":"read_file"}]}`, 2, reprompt)
+	if res.Envelope == nil {
+		t.Fatalf("expected corrected envelope, got err %v", res.Err)
+	}
+	if !res.Corrected() || res.Attempts != 1 {
+		t.Errorf("Attempts = %d, want 1", res.Attempts)
+	}
+	if len(feedbacks) != 1 {
+		t.Fatalf("expected 1 re-prompt, got %d", len(feedbacks))
+	}
+	if !strings.Contains(feedbacks[0], "read_file requires path") {
+		t.Errorf("feedback missing validation error: %s", feedbacks[0])
+	}
+	if !strings.Contains(feedbacks[0], `"required": ["actions"]`) {
+		t.Errorf("feedback missing schema: %s", feedbacks[0])
+	}
+}
+
+func TestDecodeWithCorrection_Exhausted(t *testing.T) {
+	calls := 0
+	reprompt := func(ctx context.Context, feedback string) (string, error) {
+		calls++
+		return "still not json", nil
+	}
+	res := DecodeWithCorrection(context.Background(), "not json", 3, reprompt)
+	if res.Envelope != nil {
+		t.Fatal("expected no envelope")
+	}
+	if calls != 3 || res.Attempts != 3 {
+		t.Errorf("calls = %d, attempts = %d, want 3", calls, res.Attempts)
+	}
+	if res.Raw != "still not json" {
+		t.Errorf("Raw = %q, want last response", res.Raw)
+	}
+}
+
+func TestDecodeWithCorrection_RepromptError(t *testing.T) {
+	reprompt := func(ctx context.Context, feedback string) (string, error) {
+		return "", errors.New("provider down")
+	}
+	res := DecodeWithCorrection(context.Background(), "not json", 2, reprompt)
+	if res.Err == nil || !strings.Contains(res.Err.Error(), "provider down") {
+		t.Errorf("expected re-prompt error to be reported, got %v", res.Err)
+	}
+	if res.Attempts != 1 {
+		t.Errorf("Attempts = %d, want 1", res.Attempts)
+	}
+}
+
+func TestDecodeWithCorrection_Disabled(t *testing.T) {
+	res := DecodeWithCorrection(context.Background(), "not json", 0, func(ctx context.Context, feedback string) (string, error) {
+		t.Fatal("reprompt should not be called")
+		return "", nil
+	})
+	if res.Err == nil || res.Attempts != 0 {
+		t.Errorf("expected plain decode failure, got err=%v attempts=%d", res.Err, res.Attempts)
+	}
+}
+
+func TestBuildCorrectionPrompt_ParseError(t *testing.T) {
+	prompt := BuildCorrectionPrompt(errors.New("unexpected EOF"), "{\"actions\":", 1, 2)
+	for _, want := range []string{"## Parse Error", "unexpected EOF", "attempt 1 of 2", "{\"actions\":"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
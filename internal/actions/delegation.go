@@ -0,0 +1,26 @@
+package actions
+
+import "context"
+
+// Delegation strategies decide what happens to the parent bead while a
+// delegated child is open
+const (
+	DelegationContinue = "continue" // Parent keeps running alongside the child
+	DelegationBlock    = "block"    // Parent waits until the child closes
+)
+
+// Delegation ties a delegated child bead to the bead it was delegated from
+type Delegation struct {
+	ParentBeadID string
+	ChildBeadID  string
+	Role         string
+	AgentID      string // Explicit assignee; resolved from Role when empty
+	Strategy     string
+	DelegatorID  string // Agent that delegated the task
+}
+
+// TaskDelegator assigns delegated child beads and links them to their parent
+type TaskDelegator interface {
+	// Delegate returns the agent the child bead was assigned to, if any
+	Delegate(ctx context.Context, d Delegation) (string, error)
+}
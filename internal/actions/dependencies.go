@@ -0,0 +1,60 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/dependencies"
+)
+
+// handleDependencyAction lists the project's outdated dependencies with
+// changelog and upgrade data, optionally filing update beads.
+func (r *Router) handleDependencyAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Dependencies == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "dependency manager not configured"}
+	}
+
+	path := action.Path
+	if path == "" {
+		_, sp, err := r.resolveSubproject(action, actx)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		if sp != nil {
+			path = sp.Path
+		}
+	}
+
+	report, err := r.Dependencies.Outdated(ctx, actx.ProjectID, dependencies.Options{Path: path, Ecosystems: action.Ecosystems})
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+
+	direct, breaking := 0, 0
+	for _, d := range report.Dependencies {
+		if d.Direct {
+			direct++
+			if d.Breaking {
+				breaking++
+			}
+		}
+	}
+	message := fmt.Sprintf("%d outdated dependencies (%d direct, %d with major version changes)", len(report.Dependencies), direct, breaking)
+	metadata := map[string]interface{}{
+		"path":         report.Path,
+		"ecosystems":   report.Ecosystems,
+		"dependencies": report.Dependencies,
+		"errors":       report.Errors,
+	}
+
+	if action.CreateBeads {
+		beadIDs, err := r.Dependencies.FileBeads(report, action.BeadMode)
+		metadata["bead_ids"] = beadIDs
+		if err != nil {
+			return Result{ActionType: action.Type, Status: "error", Message: fmt.Sprintf("%s; filing beads failed: %v", message, err), Metadata: metadata}
+		}
+		message = fmt.Sprintf("%s; filed %d beads", message, len(beadIDs))
+	}
+
+	return Result{ActionType: action.Type, Status: "executed", Message: message, Metadata: metadata}
+}
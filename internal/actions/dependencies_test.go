@@ -0,0 +1,63 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/dependencies"
+)
+
+type mockDependencyChecker struct {
+	opts   dependencies.Options
+	mode   string
+	report *dependencies.Report
+}
+
+func (m *mockDependencyChecker) Outdated(ctx context.Context, projectID string, opts dependencies.Options) (*dependencies.Report, error) {
+	m.opts = opts
+	m.report.Path = opts.Path
+	return m.report, nil
+}
+
+func (m *mockDependencyChecker) FileBeads(report *dependencies.Report, mode string) ([]string, error) {
+	m.mode = mode
+	return []string{"bd-1"}, nil
+}
+
+func TestRouterCheckDependencies(t *testing.T) {
+	checker := &mockDependencyChecker{report: &dependencies.Report{
+		Ecosystems: []string{dependencies.Go},
+		Dependencies: []dependencies.Dependency{
+			{Name: "github.com/spf13/cobra", Ecosystem: dependencies.Go, Current: "v1.7.0", Latest: "v2.0.0", Direct: true, Breaking: true,
+				ChangelogURL: "https://github.com/spf13/cobra/releases", UpgradeCommand: "go get github.com/spf13/cobra@v2.0.0 && go mod tidy"},
+			{Name: "golang.org/x/net", Ecosystem: dependencies.Go, Current: "v0.17.0", Latest: "v0.30.0"},
+		},
+	}}
+	r := &Router{Dependencies: checker, Projects: monorepoProject("/repo")}
+
+	res := r.executeAction(context.Background(),
+		Action{Type: ActionCheckDependencies, Ecosystems: []string{"go"}, CreateBeads: true, BeadMode: dependencies.BeadModeBatch},
+		ActionContext{ProjectID: "mono", Subproject: "api"})
+	if res.Status != "executed" {
+		t.Fatalf("expected executed, got %s: %s", res.Status, res.Message)
+	}
+	if checker.opts.Path != "services/api" || len(checker.opts.Ecosystems) != 1 || checker.mode != dependencies.BeadModeBatch {
+		t.Errorf("unexpected options: %+v mode=%q", checker.opts, checker.mode)
+	}
+	if res.Message != "2 outdated dependencies (1 direct, 1 with major version changes); filed 1 beads" {
+		t.Errorf("unexpected message: %s", res.Message)
+	}
+
+	feedback := FormatResultsAsUserMessage([]Result{res})
+	if !strings.Contains(feedback, "- go github.com/spf13/cobra: v1.7.0 -> v2.0.0") || !strings.Contains(feedback, "[major]") {
+		t.Errorf("feedback missing dependency:\n%s", feedback)
+	}
+	if strings.Contains(feedback, "golang.org/x/net") {
+		t.Errorf("feedback should only list direct dependencies:\n%s", feedback)
+	}
+
+	if res := (&Router{}).executeAction(context.Background(), Action{Type: ActionCheckDependencies}, ActionContext{ProjectID: "p"}); res.Status != "error" {
+		t.Errorf("expected error without dependency manager, got %s", res.Status)
+	}
+}
@@ -3,13 +3,39 @@ package actions
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/browser"
+	"github.com/jordanhubbard/loom/internal/containers"
+	"github.com/jordanhubbard/loom/internal/dependencies"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/git"
+	"github.com/jordanhubbard/loom/internal/httpcheck"
+	"github.com/jordanhubbard/loom/internal/impact"
+	"github.com/jordanhubbard/loom/internal/k8s"
+	"github.com/jordanhubbard/loom/internal/testdb"
+	"github.com/jordanhubbard/loom/pkg/models"
 )
 
 const (
 	maxFileContentLen = 8000
 	maxBuildOutputLen = 4000
 	maxCommandOutput  = 6000
+	// maxContainerLogLines caps the run_container log lines fed back
+	maxContainerLogLines = 100
+	// maxBrowserConsoleLines caps the browser console messages and page
+	// errors fed back
+	maxBrowserConsoleLines = 20
+	// maxAssertionRows caps the rows of each db_assert query fed back
+	maxAssertionRows = 10
+	// maxBenchmarkLines caps the benchmarks and comparisons fed back
+	maxBenchmarkLines = 20
+	// maxProfileLines caps the functions fed back from each profile
+	maxProfileLines = 20
+	// maxImpactLines caps the affected packages and API changes fed back
+	maxImpactLines = 30
 )
 
 // FormatResultsAsUserMessage converts action execution results into a user message
@@ -40,10 +66,17 @@ func formatSingleResult(r Result) string {
 
 	if r.Status == "error" {
 		sb.WriteString(fmt.Sprintf("**Error:** %s\n", r.Message))
+		if r.Code != "" {
+			sb.WriteString(fmt.Sprintf("**Error code:** %s\n", r.Code))
+		}
 		// Phase 4: Specific recovery suggestions based on error type
 		writeErrorSuggestion(&sb, r)
 		return sb.String()
 	}
+	if r.Status == "approval_required" {
+		sb.WriteString(r.Message + "\n")
+		return sb.String()
+	}
 
 	switch r.ActionType {
 	case ActionReadCode, ActionReadFile:
@@ -51,13 +84,49 @@ func formatSingleResult(r Result) string {
 	case ActionWriteFile:
 		formatFileWrite(&sb, r)
 	case ActionEditCode, ActionApplyPatch:
-		formatPatchApply(&sb, r)
+		if _, ok := r.Metadata["diff"]; ok {
+			formatFileEdit(&sb, r)
+		} else {
+			formatPatchApply(&sb, r)
+		}
+	case ActionMultiEdit, ActionAddImport, ActionInsertFunctionAfter, ActionAddStructField:
+		formatFileEdit(&sb, r)
 	case ActionBuildProject:
 		formatBuildResult(&sb, r)
 	case ActionRunTests:
 		formatTestResult(&sb, r)
 	case ActionRunLinter:
 		formatLintResult(&sb, r)
+	case ActionRunFormatter:
+		formatFormatterResult(&sb, r)
+	case ActionRunSecurityScan:
+		formatSecurityScanResult(&sb, r)
+	case ActionCheckDependencies:
+		formatDependencyResult(&sb, r)
+	case ActionBuildImage:
+		formatBuildImageResult(&sb, r)
+	case ActionRunContainer:
+		formatRunContainerResult(&sb, r)
+	case ActionDeployStaging, ActionStagingStatus:
+		formatStagingResult(&sb, r)
+	case ActionHTTPCheck:
+		formatHTTPCheckResult(&sb, r)
+	case ActionBrowser:
+		formatBrowserResult(&sb, r)
+	case ActionDBMigrate, ActionDBLoadFixtures, ActionDBAssert:
+		formatTestDBResult(&sb, r)
+	case ActionRunBenchmarks:
+		formatBenchmarkResult(&sb, r)
+	case ActionProfileProject:
+		formatProfileResult(&sb, r)
+	case ActionAnalyzeImpact:
+		formatImpactResult(&sb, r)
+	case ActionCheckCI:
+		formatCIResult(&sb, r)
+	case ActionReadResult:
+		formatResultChunk(&sb, r)
+	case ActionReadAgentMessages:
+		formatAgentMessages(&sb, r)
 	case ActionSearchText:
 		formatSearchResult(&sb, r)
 	case ActionReadTree:
@@ -70,8 +139,18 @@ func formatSingleResult(r Result) string {
 		formatGitCommit(&sb, r)
 	case ActionGitLog:
 		formatGitOutput(&sb, r, "git log")
+	case ActionGitDiffRevisions:
+		formatRevisionDiff(&sb, r)
+	case ActionGitBlame:
+		formatBlame(&sb, r)
+	case ActionGitChangelog:
+		formatChangelog(&sb, r)
 	case ActionRunCommand:
 		formatCommandResult(&sb, r)
+	case ActionSessionInput, ActionSessionRead:
+		formatSessionOutput(&sb, r)
+	case ActionJobStatus, ActionJobLogs:
+		formatJobOutput(&sb, r)
 	case ActionCloseBead:
 		sb.WriteString(fmt.Sprintf("Bead closed: %s\n", r.Message))
 	case ActionCreateBead:
@@ -92,9 +171,7 @@ func formatFileRead(sb *strings.Builder, r Result) {
 
 	sb.WriteString(fmt.Sprintf("**File:** `%s` (%d bytes)\n", path, int(size)))
 
-	if len(content) > maxFileContentLen {
-		content = content[:maxFileContentLen] + "\n... (truncated)"
-	}
+	content = truncateResultOutput(r, "content", content, maxFileContentLen)
 	sb.WriteString("```\n")
 	sb.WriteString(content)
 	if !strings.HasSuffix(content, "\n") {
@@ -112,11 +189,37 @@ func formatFileWrite(sb *strings.Builder, r Result) {
 func formatPatchApply(sb *strings.Builder, r Result) {
 	output, _ := r.Metadata["output"].(string)
 	sb.WriteString("Patch applied successfully.\n")
+	if strategy, _ := r.Metadata["strategy"].(string); strategy != "" {
+		sb.WriteString(fmt.Sprintf("It did not apply as written and was applied with the %s fallback; READ the changed regions to confirm them.\n", strategy))
+	}
 	if output != "" {
 		sb.WriteString(fmt.Sprintf("Output: %s\n", output))
 	}
 }
 
+func formatFileEdit(sb *strings.Builder, r Result) {
+	sb.WriteString(r.Message + "\n")
+	if diff, _ := r.Metadata["diff"].(string); diff != "" {
+		sb.WriteString("```diff\n" + diff)
+		if !strings.HasSuffix(diff, "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("```\n")
+	}
+}
+
+// writeFailedHunks lists the hunks of a partially applied patch that still
+// need to be applied
+func writeFailedHunks(sb *strings.Builder, r Result) {
+	hunks, _ := r.Metadata["hunks"].([]files.HunkResult)
+	for _, h := range hunks {
+		if h.Status != files.HunkFailed {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\nFailed: %s hunk %d (%s)\n```diff\n%s\n```\n", h.File, h.Index, h.Reason, h.Hunk))
+	}
+}
+
 func formatBuildResult(sb *strings.Builder, r Result) {
 	if r.Metadata == nil {
 		sb.WriteString(r.Message + "\n")
@@ -142,6 +245,9 @@ func formatBuildResult(sb *strings.Builder, r Result) {
 			sb.WriteString("\n")
 		}
 		sb.WriteString("```\n")
+		if truncated != output {
+			writeOutputRef(sb, r, "output")
+		}
 	}
 
 	if !success {
@@ -165,9 +271,18 @@ func formatTestResult(sb *strings.Builder, r Result) {
 	} else {
 		sb.WriteString(fmt.Sprintf("**Tests: FAILED** (%d passed, %d failed)\n", int(passed), int(failed)))
 	}
+	switch mode, _ := r.Metadata["test_mode"].(string); mode {
+	case "smart":
+		targets, _ := r.Metadata["test_targets"].([]string)
+		sb.WriteString(fmt.Sprintf("Ran only the %d test targets covering the change; the full suite may still be required before merge.\n", len(targets)))
+	case "full":
+		if reason, _ := r.Metadata["full_suite_reason"].(string); reason != "" {
+			sb.WriteString("Ran the full suite: " + reason + "\n")
+		}
+	}
 
 	if output != "" && !success {
-		truncated := truncateOutput(output, maxBuildOutputLen)
+		truncated := truncateResultOutput(r, "output", output, maxBuildOutputLen)
 		sb.WriteString("```\n")
 		sb.WriteString(truncated)
 		if !strings.HasSuffix(truncated, "\n") {
@@ -186,7 +301,7 @@ func formatLintResult(sb *strings.Builder, r Result) {
 
 	output, _ := r.Metadata["output"].(string)
 	if output != "" {
-		truncated := truncateOutput(output, maxBuildOutputLen)
+		truncated := truncateResultOutput(r, "output", output, maxBuildOutputLen)
 		sb.WriteString("```\n")
 		sb.WriteString(truncated)
 		if !strings.HasSuffix(truncated, "\n") {
@@ -198,6 +313,452 @@ func formatLintResult(sb *strings.Builder, r Result) {
 	}
 }
 
+func formatFormatterResult(sb *strings.Builder, r Result) {
+	sb.WriteString(r.Message + "\n")
+	if files, ok := r.Metadata["files"].([]map[string]interface{}); ok {
+		for _, f := range files {
+			if f["status"] == "error" {
+				sb.WriteString(fmt.Sprintf("- `%v`: %v\n", f["path"], f["error"]))
+			}
+		}
+	}
+	diff, _ := r.Metadata["diff"].(string)
+	if diff != "" {
+		sb.WriteString("```diff\n")
+		sb.WriteString(truncateResultOutput(r, "diff", diff, maxCommandOutput))
+		if !strings.HasSuffix(diff, "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("```\n")
+	}
+}
+
+func formatSecurityScanResult(sb *strings.Builder, r Result) {
+	sb.WriteString(r.Message + "\n")
+	if findings, ok := r.Metadata["findings"].([]models.SecurityFinding); ok {
+		for _, f := range findings {
+			location := f.File
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", f.File, f.Line)
+			}
+			marker := ""
+			if f.New {
+				marker = " (new)"
+			}
+			sb.WriteString(fmt.Sprintf("- [%s] %s %s `%s`%s: %s\n", f.Severity, f.Tool, f.RuleID, location, marker, f.Message))
+		}
+		if total, _ := r.Metadata["total_findings"].(int); total > len(findings) {
+			sb.WriteString(fmt.Sprintf("... %d more findings in scan %v\n", total-len(findings), r.Metadata["scan_id"]))
+		}
+	}
+	if errs, ok := r.Metadata["errors"].(map[string]string); ok {
+		for tool, msg := range errs {
+			sb.WriteString(fmt.Sprintf("- %s failed: %s\n", tool, msg))
+		}
+	}
+}
+
+func formatBuildImageResult(sb *strings.Builder, r Result) {
+	success, _ := r.Metadata["success"].(bool)
+	if success {
+		sb.WriteString("**Image build: PASSED** " + r.Message + "\n")
+	} else {
+		sb.WriteString("**Image build: FAILED** " + r.Message + "\n")
+	}
+	if errMsg, _ := r.Metadata["error"].(string); errMsg != "" {
+		sb.WriteString("Error: " + errMsg + "\n")
+	}
+	output, _ := r.Metadata["output"].(string)
+	if output != "" && !success {
+		truncated := truncateBuildOutput(output)
+		sb.WriteString("```\n")
+		sb.WriteString(truncated)
+		if !strings.HasSuffix(truncated, "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("```\n")
+		if truncated != output {
+			writeOutputRef(sb, r, "output")
+		}
+		sb.WriteString("\nPlease fix the Dockerfile or the build errors and rebuild.\n")
+	}
+}
+
+func formatRunContainerResult(sb *strings.Builder, r Result) {
+	success, _ := r.Metadata["success"].(bool)
+	if success {
+		sb.WriteString("**Container: PASSED** " + r.Message + "\n")
+	} else {
+		sb.WriteString("**Container: FAILED** " + r.Message + "\n")
+	}
+	if ports, ok := r.Metadata["ports"].(map[string]string); ok {
+		for port, addr := range ports {
+			sb.WriteString(fmt.Sprintf("- port %s -> %s\n", port, addr))
+		}
+	}
+	logs, _ := r.Metadata["logs"].([]containers.LogLine)
+	if len(logs) == 0 {
+		sb.WriteString("No container output.\n")
+		return
+	}
+	if truncated, _ := r.Metadata["logs_truncated"].(bool); truncated || len(logs) > maxContainerLogLines {
+		sb.WriteString(fmt.Sprintf("Last %d log lines:\n", min(len(logs), maxContainerLogLines)))
+	}
+	if len(logs) > maxContainerLogLines {
+		logs = logs[len(logs)-maxContainerLogLines:]
+	}
+	sb.WriteString("```\n")
+	for _, l := range logs {
+		if l.Stream == "stderr" {
+			sb.WriteString("[stderr] ")
+		}
+		sb.WriteString(l.Text + "\n")
+	}
+	sb.WriteString("```\n")
+}
+
+func formatStagingResult(sb *strings.Builder, r Result) {
+	success, _ := r.Metadata["success"].(bool)
+	if success {
+		sb.WriteString("**Staging: READY** " + r.Message + "\n")
+	} else {
+		sb.WriteString("**Staging: NOT READY** " + r.Message + "\n")
+	}
+	if errMsg, _ := r.Metadata["error"].(string); errMsg != "" {
+		sb.WriteString("Error: " + errMsg + "\n")
+	}
+	if output, _ := r.Metadata["output"].(string); output != "" && !success {
+		sb.WriteString("```\n")
+		sb.WriteString(truncateResultOutput(r, "output", output, maxBuildOutputLen))
+		sb.WriteString("\n```\n")
+	}
+	if rollouts, ok := r.Metadata["rollouts"].([]k8s.Rollout); ok {
+		for _, ro := range rollouts {
+			if ro.Ready {
+				sb.WriteString(fmt.Sprintf("- %s: ready\n", ro.Resource))
+			} else {
+				sb.WriteString(fmt.Sprintf("- %s: not ready: %s\n", ro.Resource, ro.Message))
+			}
+		}
+	}
+	pods, _ := r.Metadata["pods"].([]k8s.Pod)
+	for _, p := range pods {
+		line := fmt.Sprintf("- pod %s: %s, %s ready, %d restarts", p.Name, p.Phase, p.Ready, p.Restarts)
+		if p.Reason != "" {
+			line += ", " + p.Reason
+		}
+		sb.WriteString(line + "\n")
+	}
+	logs, _ := r.Metadata["logs"].(map[string]string)
+	for _, p := range pods {
+		podLogs := logs[p.Name]
+		if podLogs == "" {
+			continue
+		}
+		// Keep the end of each pod's log, where crashes are reported
+		if len(podLogs) > maxBuildOutputLen/2 {
+			podLogs = "..." + podLogs[len(podLogs)-maxBuildOutputLen/2:]
+		}
+		sb.WriteString(fmt.Sprintf("Logs of %s:\n```\n%s\n```\n", p.Name, podLogs))
+	}
+}
+
+func formatHTTPCheckResult(sb *strings.Builder, r Result) {
+	passed, _ := r.Metadata["passed"].(bool)
+	if passed {
+		sb.WriteString("**HTTP check: PASSED** " + r.Message + "\n")
+	} else {
+		sb.WriteString("**HTTP check: FAILED** " + r.Message + "\n")
+	}
+	if assertions, ok := r.Metadata["assertions"].([]httpcheck.Assertion); ok {
+		for _, a := range assertions {
+			if !a.Passed {
+				sb.WriteString(fmt.Sprintf("- %s: %s\n", a.Check, a.Message))
+			}
+		}
+	}
+	if body, _ := r.Metadata["body"].(string); body != "" && !passed {
+		sb.WriteString("Response body:\n```\n")
+		sb.WriteString(truncateResultOutput(r, "body", body, maxBuildOutputLen/2))
+		sb.WriteString("\n```\n")
+	}
+}
+
+func formatBrowserResult(sb *strings.Builder, r Result) {
+	success, _ := r.Metadata["success"].(bool)
+	if success {
+		sb.WriteString("**Browser: PASSED** " + r.Message + "\n")
+	} else {
+		sb.WriteString("**Browser: FAILED** " + r.Message + "\n")
+	}
+	if steps, ok := r.Metadata["steps"].([]browser.StepResult); ok {
+		for _, s := range steps {
+			status := "ok"
+			switch {
+			case s.Skipped:
+				status = "skipped"
+			case !s.Passed:
+				status = "FAILED: " + s.Error
+			}
+			line := fmt.Sprintf("%d. %s %s: %s", s.Index, s.Action, s.Target, status)
+			if s.Screenshot != "" {
+				line += " [screenshot " + s.Screenshot + "]"
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+	if url, _ := r.Metadata["url"].(string); url != "" {
+		title, _ := r.Metadata["title"].(string)
+		sb.WriteString(fmt.Sprintf("Final page: %s %q\n", url, title))
+	}
+	if pageErrors, ok := r.Metadata["page_errors"].([]string); ok && len(pageErrors) > 0 {
+		sb.WriteString("Uncaught page errors:\n")
+		for _, e := range pageErrors[:min(len(pageErrors), maxBrowserConsoleLines)] {
+			sb.WriteString("- " + e + "\n")
+		}
+	}
+	if console, ok := r.Metadata["console"].([]browser.ConsoleMessage); ok && len(console) > 0 {
+		sb.WriteString("Console:\n")
+		for _, m := range console[:min(len(console), maxBrowserConsoleLines)] {
+			sb.WriteString(fmt.Sprintf("- [%s] %s\n", m.Type, m.Text))
+		}
+	}
+	if errs, ok := r.Metadata["attach_errors"].([]string); ok {
+		for _, e := range errs {
+			sb.WriteString("Screenshot not attached: " + e + "\n")
+		}
+	}
+}
+
+func formatTestDBResult(sb *strings.Builder, r Result) {
+	success, _ := r.Metadata["success"].(bool)
+	if success {
+		sb.WriteString("**Database: PASSED** " + r.Message + "\n")
+	} else {
+		sb.WriteString("**Database: FAILED** " + r.Message + "\n")
+	}
+	if migrations, ok := r.Metadata["migrations"].([]testdb.Migration); ok {
+		for _, m := range migrations {
+			switch {
+			case m.Error != "":
+				sb.WriteString(fmt.Sprintf("- %s: FAILED: %s\n", m.Name, m.Error))
+			case m.Applied:
+				sb.WriteString(fmt.Sprintf("- %s: applied in %s\n", m.Name, m.Duration))
+			}
+		}
+	}
+	if output, _ := r.Metadata["output"].(string); output != "" {
+		sb.WriteString("```\n")
+		sb.WriteString(truncateResultOutput(r, "output", output, maxBuildOutputLen))
+		sb.WriteString("\n```\n")
+	}
+	if schema, ok := r.Metadata["schema"].([]testdb.Table); ok && len(schema) > 0 {
+		sb.WriteString("Schema:\n")
+		for _, t := range schema {
+			columns := make([]string, len(t.Columns))
+			for i, c := range t.Columns {
+				columns[i] = c.Name + " " + c.Type
+				if !c.Nullable {
+					columns[i] += " NOT NULL"
+				}
+			}
+			sb.WriteString(fmt.Sprintf("- %s(%s)\n", t.Name, strings.Join(columns, ", ")))
+		}
+	}
+	if files, ok := r.Metadata["files"].([]testdb.FixtureFile); ok {
+		for _, f := range files {
+			if f.Error != "" {
+				sb.WriteString(fmt.Sprintf("- %s: FAILED: %s\n", f.Path, f.Error))
+			}
+		}
+	}
+	if assertions, ok := r.Metadata["assertions"].([]testdb.AssertionResult); ok {
+		for _, a := range assertions {
+			label := a.Query
+			if a.Name != "" {
+				label = a.Name + " (" + a.Query + ")"
+			}
+			if a.Passed {
+				sb.WriteString(fmt.Sprintf("- PASSED %s: %d rows\n", label, a.RowCount))
+			} else {
+				sb.WriteString(fmt.Sprintf("- FAILED %s: %s\n", label, a.Message))
+			}
+			for _, row := range a.Rows[:min(len(a.Rows), maxAssertionRows)] {
+				b, _ := json.Marshal(row)
+				sb.WriteString("  " + string(b) + "\n")
+			}
+			if a.RowCount > maxAssertionRows {
+				sb.WriteString(fmt.Sprintf("  ... %d rows in all\n", a.RowCount))
+			}
+		}
+	}
+}
+
+func formatBenchmarkResult(sb *strings.Builder, r Result) {
+	success, _ := r.Metadata["success"].(bool)
+	regressions, _ := r.Metadata["regressions"].(int)
+	switch {
+	case !success:
+		sb.WriteString("**Benchmarks: FAILED** " + r.Message + "\n")
+		if output, _ := r.Metadata["output"].(string); output != "" {
+			sb.WriteString("```\n")
+			sb.WriteString(truncateResultOutput(r, "output", output, maxBuildOutputLen))
+			sb.WriteString("\n```\n")
+		}
+		return
+	case regressions > 0:
+		sb.WriteString("**Benchmarks: REGRESSED** " + r.Message + "\n")
+	default:
+		sb.WriteString("**Benchmarks: PASSED** " + r.Message + "\n")
+	}
+
+	if comparisons, ok := r.Metadata["comparisons"].([]models.BenchmarkComparison); ok && len(comparisons) > 0 {
+		for _, c := range comparisons[:min(len(comparisons), maxBenchmarkLines)] {
+			line := fmt.Sprintf("- %s %s: %s -> %s (%+.1f%%)", c.Name, c.Metric, benchmarkValue(c.Baseline), benchmarkValue(c.Current), c.Delta)
+			if c.Regression {
+				line += " REGRESSION"
+			}
+			sb.WriteString(line + "\n")
+		}
+		if len(comparisons) > maxBenchmarkLines {
+			sb.WriteString(fmt.Sprintf("... %d comparisons in all\n", len(comparisons)))
+		}
+		return
+	}
+	if results, ok := r.Metadata["benchmarks"].([]models.BenchmarkResult); ok {
+		for _, b := range results[:min(len(results), maxBenchmarkLines)] {
+			line := fmt.Sprintf("- %s: %s ns/op", b.Key(), benchmarkValue(b.NsPerOp))
+			if b.Memory {
+				line += fmt.Sprintf(", %s B/op, %s allocs/op", benchmarkValue(b.BytesPerOp), benchmarkValue(b.AllocsPerOp))
+			}
+			sb.WriteString(line + "\n")
+		}
+		if len(results) > maxBenchmarkLines {
+			sb.WriteString(fmt.Sprintf("... %d benchmarks in all\n", len(results)))
+		}
+	}
+}
+
+func formatProfileResult(sb *strings.Builder, r Result) {
+	success, _ := r.Metadata["success"].(bool)
+	profiles, _ := r.Metadata["profiles"].([]*models.Profile)
+	if success {
+		sb.WriteString("**Profile: CAPTURED** " + r.Message + "\n")
+	} else {
+		sb.WriteString("**Profile: FAILED** " + r.Message + "\n")
+		if output, _ := r.Metadata["output"].(string); output != "" {
+			sb.WriteString("```\n")
+			sb.WriteString(truncateResultOutput(r, "output", output, maxBuildOutputLen))
+			sb.WriteString("\n```\n")
+		}
+	}
+	for _, p := range profiles {
+		heading := fmt.Sprintf("%s profile %s", p.Kind, p.ID)
+		if p.Total != "" {
+			heading += " (" + p.Total + " total)"
+		}
+		sb.WriteString(heading + ", flat then cumulative:\n")
+		for _, e := range p.Top[:min(len(p.Top), maxProfileLines)] {
+			sb.WriteString(fmt.Sprintf("- %s %.1f%%  %s %.1f%%  %s\n", e.Flat, e.FlatPercent, e.Cum, e.CumPercent, e.Function))
+		}
+	}
+	if notes, _ := r.Metadata["notes"].([]string); len(notes) > 0 {
+		sb.WriteString("Notes: " + strings.Join(notes, "; ") + "\n")
+	}
+}
+
+func formatImpactResult(sb *strings.Builder, r Result) {
+	report, _ := r.Metadata["report"].(*impact.Report)
+	if report == nil {
+		formatDefault(sb, r)
+		return
+	}
+	sb.WriteString("**Impact:** " + r.Message + "\n")
+	if len(report.Packages) > 0 {
+		sb.WriteString("Affected packages:\n")
+		for _, p := range report.Packages[:min(len(report.Packages), maxImpactLines)] {
+			sb.WriteString(fmt.Sprintf("- %s (%s)\n", p.Dir, p.Reason))
+		}
+		if extra := len(report.Packages) - maxImpactLines; extra > 0 {
+			sb.WriteString(fmt.Sprintf("- ... %d more\n", extra))
+		}
+	}
+	var others []string
+	for _, t := range report.Tests {
+		if t != "." && !strings.HasPrefix(t, "./") {
+			others = append(others, t)
+		}
+	}
+	if cmd := report.GoTestCommand(); cmd != "" {
+		sb.WriteString("Run: `" + cmd + "`\n")
+	}
+	if len(others) > 0 {
+		sb.WriteString("Test files: " + strings.Join(others, ", ") + "\n")
+	}
+	if len(report.APIChanges) > 0 {
+		heading := "Public API changes:"
+		if report.Breaking {
+			heading = "Public API changes (BREAKING: update the callers):"
+		}
+		sb.WriteString(heading + "\n")
+		for _, c := range report.APIChanges[:min(len(report.APIChanges), maxImpactLines)] {
+			switch c.Change {
+			case "added":
+				sb.WriteString(fmt.Sprintf("- added %s in %s\n", c.After, c.Path))
+			case "removed":
+				sb.WriteString(fmt.Sprintf("- removed %s from %s\n", c.Before, c.Path))
+			default:
+				sb.WriteString(fmt.Sprintf("- changed %s -> %s in %s\n", c.Before, c.After, c.Path))
+			}
+		}
+		if extra := len(report.APIChanges) - maxImpactLines; extra > 0 {
+			sb.WriteString(fmt.Sprintf("- ... %d more\n", extra))
+		}
+	}
+	if len(report.Notes) > 0 {
+		sb.WriteString("Notes: " + strings.Join(report.Notes, "; ") + "\n")
+	}
+}
+
+// benchmarkValue formats a metric without exponents or needless decimals
+func benchmarkValue(v float64) string {
+	if v >= 100 {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return strconv.FormatFloat(v, 'g', 3, 64)
+}
+
+func formatDependencyResult(sb *strings.Builder, r Result) {
+	sb.WriteString(r.Message + "\n")
+	if deps, ok := r.Metadata["dependencies"].([]dependencies.Dependency); ok {
+		for _, d := range deps {
+			if !d.Direct {
+				continue
+			}
+			line := fmt.Sprintf("- %s %s: %s -> %s (`%s`)", d.Ecosystem, d.Name, d.Current, d.Latest, d.UpgradeCommand)
+			if d.Breaking {
+				line += " [major]"
+			}
+			if d.ChangelogURL != "" {
+				line += " changelog: " + d.ChangelogURL
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+	if errs, ok := r.Metadata["errors"].(map[string]string); ok {
+		for eco, msg := range errs {
+			sb.WriteString(fmt.Sprintf("- %s check failed: %s\n", eco, msg))
+		}
+	}
+}
+
+func formatCIResult(sb *strings.Builder, r Result) {
+	sb.WriteString(r.Message + "\n")
+	if feedback, ok := r.Metadata["feedback"].(string); ok && feedback != "" {
+		sb.WriteString(feedback + "\n")
+	}
+}
+
 func formatSearchResult(sb *strings.Builder, r Result) {
 	matches := r.Metadata["matches"]
 	if matches == nil {
@@ -249,7 +810,7 @@ func formatGitOutput(sb *strings.Builder, r Result, label string) {
 		return
 	}
 
-	truncated := truncateOutput(output, maxCommandOutput)
+	truncated := truncateResultOutput(r, "output", output, maxCommandOutput)
 	sb.WriteString(fmt.Sprintf("**%s:**\n```\n", label))
 	sb.WriteString(truncated)
 	if !strings.HasSuffix(truncated, "\n") {
@@ -258,8 +819,58 @@ func formatGitOutput(sb *strings.Builder, r Result, label string) {
 	sb.WriteString("```\n")
 }
 
+// formatRevisionDiff renders a structured diff as numbered hunks. Metadata
+// that went through JSON holds the diff as a plain map.
+func formatRevisionDiff(sb *strings.Builder, r Result) {
+	var diff git.RevisionDiff
+	b, err := json.Marshal(r.Metadata["diff"])
+	if err != nil || json.Unmarshal(b, &diff) != nil {
+		formatDefault(sb, r)
+		return
+	}
+	if len(diff.Files) == 0 {
+		sb.WriteString(fmt.Sprintf("git diff %s..%s: no changes\n", diff.From, diff.To))
+		return
+	}
+	sb.WriteString("**git diff:**\n```diff\n")
+	sb.WriteString(truncateResultOutput(r, "diff", diff.Format(), maxCommandOutput))
+	sb.WriteString("\n```\n")
+}
+
+func formatBlame(sb *strings.Builder, r Result) {
+	var blame git.Blame
+	b, err := json.Marshal(r.Metadata["blame"])
+	if err != nil || json.Unmarshal(b, &blame) != nil {
+		formatDefault(sb, r)
+		return
+	}
+	sb.WriteString("**git blame:**\n```\n")
+	sb.WriteString(truncateResultOutput(r, "blame", blame.Format(), maxCommandOutput))
+	sb.WriteString("```\n")
+	if len(blame.Beads) > 0 {
+		sb.WriteString(fmt.Sprintf("Reference the originating bead(s) %s when changing these lines.\n", strings.Join(blame.Beads, ", ")))
+	}
+}
+
+func formatChangelog(sb *strings.Builder, r Result) {
+	changelog, _ := r.Metadata["changelog"].(string)
+	if changelog == "" {
+		formatDefault(sb, r)
+		return
+	}
+	if path, _ := r.Metadata["path"].(string); path != "" {
+		sb.WriteString(fmt.Sprintf("Added to %s:\n", path))
+	}
+	sb.WriteString("```markdown\n")
+	sb.WriteString(truncateResultOutput(r, "changelog", changelog, maxCommandOutput))
+	sb.WriteString("```\n")
+}
+
 func formatGitCommit(sb *strings.Builder, r Result) {
 	sha, _ := r.Metadata["sha"].(string)
+	if sha == "" {
+		sha, _ = r.Metadata["commit_sha"].(string)
+	}
 	message, _ := r.Metadata["message"].(string)
 	if sha != "" {
 		sb.WriteString(fmt.Sprintf("Commit created: `%s`\n", sha))
@@ -267,6 +878,9 @@ func formatGitCommit(sb *strings.Builder, r Result) {
 	if message != "" {
 		sb.WriteString(fmt.Sprintf("Message: %s\n", message))
 	}
+	if fixes, _ := r.Metadata["policy_fixes"].([]string); len(fixes) > 0 {
+		sb.WriteString(fmt.Sprintf("The commit policy %s.\n", strings.Join(fixes, ", ")))
+	}
 	if sha == "" && message == "" {
 		sb.WriteString(r.Message + "\n")
 	}
@@ -280,7 +894,7 @@ func formatCommandResult(sb *strings.Builder, r Result) {
 	sb.WriteString(fmt.Sprintf("**Exit code:** %d\n", int(exitCode)))
 
 	if stdout != "" {
-		truncated := truncateOutput(stdout, maxCommandOutput)
+		truncated := truncateResultOutput(r, "stdout", stdout, maxCommandOutput)
 		sb.WriteString("**stdout:**\n```\n")
 		sb.WriteString(truncated)
 		if !strings.HasSuffix(truncated, "\n") {
@@ -290,7 +904,7 @@ func formatCommandResult(sb *strings.Builder, r Result) {
 	}
 
 	if stderr != "" {
-		truncated := truncateOutput(stderr, maxCommandOutput)
+		truncated := truncateResultOutput(r, "stderr", stderr, maxCommandOutput)
 		sb.WriteString("**stderr:**\n```\n")
 		sb.WriteString(truncated)
 		if !strings.HasSuffix(truncated, "\n") {
@@ -300,6 +914,73 @@ func formatCommandResult(sb *strings.Builder, r Result) {
 	}
 }
 
+func formatSessionOutput(sb *strings.Builder, r Result) {
+	sessionID, _ := r.Metadata["session_id"].(string)
+	output, _ := r.Metadata["output"].(string)
+	truncated, _ := r.Metadata["truncated"].(bool)
+
+	sb.WriteString(fmt.Sprintf("**Session:** `%s` — %s\n", sessionID, r.Message))
+	if truncated {
+		sb.WriteString("(earlier output was dropped; read more often to keep up)\n")
+	}
+	if output == "" {
+		sb.WriteString("No new output.\n")
+		return
+	}
+	output = truncateResultOutput(r, "output", output, maxCommandOutput)
+	sb.WriteString("```\n")
+	sb.WriteString(output)
+	if !strings.HasSuffix(output, "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```\n")
+}
+
+func formatJobOutput(sb *strings.Builder, r Result) {
+	output, _ := r.Metadata["output"].(string)
+	if tail, ok := r.Metadata["output_tail"].(string); ok {
+		output = tail
+	}
+
+	sb.WriteString(fmt.Sprintf("**Job:** %s\n", r.Message))
+	if next, ok := r.Metadata["next_offset"]; ok {
+		sb.WriteString(fmt.Sprintf("Continue reading with offset %v\n", next))
+	}
+	if output == "" {
+		sb.WriteString("No new output.\n")
+		return
+	}
+	output = truncateOutput(output, maxCommandOutput)
+	sb.WriteString("```\n")
+	sb.WriteString(output)
+	if !strings.HasSuffix(output, "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```\n")
+}
+
+func formatResultChunk(sb *strings.Builder, r Result) {
+	content, _ := r.Metadata["content"].(string)
+	sb.WriteString(fmt.Sprintf("**Result:** %s\n", r.Message))
+	sb.WriteString("```\n")
+	sb.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```\n")
+	if eof, _ := r.Metadata["eof"].(bool); !eof {
+		sb.WriteString(fmt.Sprintf("Continue reading with read_result offset %v\n", r.Metadata["next_offset"]))
+	}
+}
+
+// writeOutputRef points at the stored full text of a field shown as an
+// excerpt
+func writeOutputRef(sb *strings.Builder, r Result, field string) {
+	if ref, ok := outputRef(r, field); ok {
+		sb.WriteString(fmt.Sprintf("Full output: result %s, %s, see /api/v1/results/%s or use read_result\n", ref.ID, formatSize(ref.Size), ref.ID))
+	}
+}
+
 func formatBeadCreated(sb *strings.Builder, r Result) {
 	beadID, _ := r.Metadata["bead_id"].(string)
 	sb.WriteString(fmt.Sprintf("Created bead: `%s`\n", beadID))
@@ -368,7 +1049,11 @@ func writeErrorSuggestion(sb *strings.Builder, r Result) {
 	msg := strings.ToLower(r.Message)
 
 	switch {
-	case strings.Contains(msg, "not found in") && r.ActionType == ActionEditCode:
+	case r.Metadata["error_type"] == "patch_hunks_failed":
+		writeFailedHunks(sb, r)
+		sb.WriteString("\n**Suggestion:** Hunks not listed as failed are already applied; don't resend them. READ the file around each failed hunk and send a new patch with just those hunks, written against the current content.\n")
+
+	case strings.Contains(msg, "not found in") && (r.ActionType == ActionEditCode || r.ActionType == ActionMultiEdit):
 		sb.WriteString("\n**Suggestion:** The OLD text didn't match the file content. Try:\n")
 		sb.WriteString("1. READ the file first to see its current content\n")
 		sb.WriteString("2. Copy the exact text from the READ output\n")
@@ -386,6 +1071,22 @@ func writeErrorSuggestion(sb *strings.Builder, r Result) {
 	case strings.Contains(msg, "build") && strings.Contains(msg, "fail"):
 		sb.WriteString("\n**Suggestion:** Read the error output above, fix the issue, then BUILD again.\n")
 
+	case strings.Contains(msg, "exceeded executor quota"):
+		sb.WriteString("\n**Suggestion:** The project has hit a resource quota. Wait for running commands or jobs to finish, use lighter commands, or ESCALATE if the work needs a higher limit.\n")
+
+	case r.Metadata["error_type"] == "file_locked":
+		sb.WriteString(fmt.Sprintf("\n**Suggestion:** Agent %v is editing this file for bead %v. Work on other files first and retry after the lock expires (about %vs), or message that agent to coordinate.\n",
+			r.Metadata["held_by_agent"], r.Metadata["held_by_bead"], r.Metadata["retry_after_seconds"]))
+
+	case r.Metadata["error_type"] == "file_co_edited":
+		sb.WriteString("\n**Suggestion:** Someone is editing this file live in a shared buffer. Work on other files and retry once the co-editing session is closed.\n")
+
+	case r.Metadata["error_type"] == "secrets_detected":
+		sb.WriteString("\n**Suggestion:** The listed lines look like credentials. Remove them, read the values from the environment or configuration instead, and stage the files again. Unpushed commits that added them must be amended so the values leave the history.\n")
+
+	case r.Code == apperr.CodePolicyDenied:
+		sb.WriteString("\n**Suggestion:** Your role or the project policy forbids this operation. Don't retry it; choose another approach or ESCALATE.\n")
+
 	case strings.Contains(msg, "not cloned"):
 		sb.WriteString("\n**Suggestion:** The project repository is not cloned locally. This may be a configuration issue.\n")
 
@@ -3,6 +3,8 @@ package actions
 import (
 	"strings"
 	"testing"
+
+	"github.com/jordanhubbard/loom/internal/git"
 )
 
 func TestFormatResultsAsUserMessage_Empty(t *testing.T) {
@@ -52,6 +54,15 @@ func TestFormatSingleResult_Error(t *testing.T) {
 	if !strings.Contains(output, "build failed") {
 		t.Error("expected error message")
 	}
+	if strings.Contains(output, "**Error code:**") {
+		t.Error("expected no code line for a result without a code")
+	}
+
+	r = Result{ActionType: ActionGitPush, Status: "error", Message: "role forbids git_push", Code: "policy_denied"}
+	output = formatSingleResult(r)
+	if !strings.Contains(output, "**Error code:** policy_denied") || !strings.Contains(output, "ESCALATE") {
+		t.Errorf("expected the code and a policy suggestion, got %q", output)
+	}
 }
 
 func TestFormatFileRead(t *testing.T) {
@@ -624,3 +635,50 @@ func TestFormatResultsWithContext_NoRoot(t *testing.T) {
 		t.Error("should not contain working directory when empty")
 	}
 }
+
+func TestFormatRevisionDiff(t *testing.T) {
+	diff := git.NewRevisionDiff(git.RevisionDiffRequest{From: "main", To: "HEAD"}, `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1 +1 @@
+-old
++new
+`)
+	msg := FormatResultsAsUserMessage([]Result{{
+		ActionType: ActionGitDiffRevisions,
+		Status:     "executed",
+		Message:    "revision diff retrieved",
+		Metadata:   map[string]interface{}{"diff": diff},
+	}})
+	for _, want := range []string{"main..HEAD: 1 file(s), +1 -1", "a.go (modified, +1 -1)", "Hunk 1: @@ -1,1 +1,1 @@", "-old\n+new"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("feedback is missing %q:\n%s", want, msg)
+		}
+	}
+
+	empty := FormatResultsAsUserMessage([]Result{{
+		ActionType: ActionGitDiffRevisions,
+		Status:     "executed",
+		Metadata:   map[string]interface{}{"diff": git.NewRevisionDiff(git.RevisionDiffRequest{From: "HEAD"}, "")},
+	}})
+	if !strings.Contains(empty, "HEAD..working tree: no changes") {
+		t.Errorf("unexpected feedback for an empty diff:\n%s", empty)
+	}
+}
+
+func TestFormatBlame(t *testing.T) {
+	blame := &git.Blame{Path: "main.go", Beads: []string{"loom-42"}, Lines: []*git.BlameLine{
+		{Line: 7, SHA: "d3fab6e2ecf3229595bfe92b8b1be26dd839d8bd", Author: "Ada", AgeDays: 3, Summary: "Add retries", BeadID: "loom-42", Text: "retry()"},
+	}}
+	msg := FormatResultsAsUserMessage([]Result{{
+		ActionType: ActionGitBlame,
+		Status:     "executed",
+		Message:    "blame retrieved",
+		Metadata:   map[string]interface{}{"blame": blame, "beads": blame.Beads},
+	}})
+	for _, want := range []string{"main.go lines 7-7 (beads: loom-42)", "d3fab6e2ecf3 Ada, 3 days ago [loom-42]: Add retries", "    7| retry()", "Reference the originating bead(s) loom-42"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("feedback is missing %q:\n%s", want, msg)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package actions
+
+import (
+	"context"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+)
+
+// FileLocker takes advisory locks on the files an action is about to write,
+// so agents working on different beads don't edit the same file at once. A
+// file locked by another bead is reported as an apperr conflict whose
+// details name the holder.
+type FileLocker interface {
+	LockFiles(ctx context.Context, actx ActionContext, paths []string) error
+}
+
+// lockingActions are the actions that write files and so take file locks
+var lockingActions = map[string]bool{
+	ActionWriteFile:           true,
+	ActionEditCode:            true,
+	ActionMultiEdit:           true,
+	ActionApplyPatch:          true,
+	ActionMoveFile:            true,
+	ActionRenameFile:          true,
+	ActionDeleteFile:          true,
+	ActionAddImport:           true,
+	ActionInsertFunctionAfter: true,
+	ActionAddStructField:      true,
+}
+
+// WritesFiles reports whether an action type writes project files
+func WritesFiles(actionType string) bool {
+	return lockingActions[actionType]
+}
+
+// firstPath returns the first file an action names, if any
+func firstPath(action Action) string {
+	if paths := TouchedPaths(action); len(paths) > 0 {
+		return paths[0]
+	}
+	return ""
+}
+
+// checkFileLocks locks the files a writing action names and returns a
+// failed result when another bead holds one of them
+func (r *Router) checkFileLocks(ctx context.Context, action Action, actx ActionContext) *Result {
+	if r.Locks == nil || actx.AgentID == "" || !lockingActions[action.Type] {
+		return nil
+	}
+	paths := TouchedPaths(action)
+	if len(paths) == 0 {
+		return nil
+	}
+	err := r.Locks.LockFiles(ctx, actx, paths)
+	if err == nil {
+		return nil
+	}
+	result := errorResult(action.Type, err)
+	if details := apperr.DetailsOf(err); details != nil {
+		result.Metadata = make(map[string]interface{}, len(details))
+		for k, v := range details {
+			result.Metadata[k] = v
+		}
+	}
+	return &result
+}
@@ -0,0 +1,59 @@
+package actions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+)
+
+type stubLocker struct {
+	holders map[string]string // path -> agent
+	calls   [][]string
+}
+
+func (l *stubLocker) LockFiles(_ context.Context, actx ActionContext, paths []string) error {
+	l.calls = append(l.calls, paths)
+	for _, p := range paths {
+		if holder := l.holders[p]; holder != "" && holder != actx.AgentID {
+			return apperr.Conflict("file %s already locked by agent %s", p, holder).
+				WithDetail("error_type", "file_locked").
+				WithDetail("held_by_agent", holder)
+		}
+	}
+	return nil
+}
+
+func TestRouter_FileLocks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	locker := &stubLocker{holders: map[string]string{"a.go": "agent-2"}}
+	r := &Router{Files: files.NewManager(toolrun.StaticWorkDir(dir)), Locks: locker}
+	actx := ActionContext{AgentID: "agent-1", BeadID: "bead-1"}
+
+	result := r.executeAllowedAction(context.Background(), Action{Type: ActionWriteFile, Path: "a.go", Content: "package b\n"}, actx)
+	if result.Status != "error" || result.Code != apperr.CodeConflict || result.Metadata["error_type"] != "file_locked" || result.Metadata["held_by_agent"] != "agent-2" {
+		t.Fatalf("expected a file_locked conflict, got %+v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.go")); string(data) != "package a\n" {
+		t.Error("expected the locked file to be left alone")
+	}
+
+	result = r.executeAllowedAction(context.Background(), Action{Type: ActionWriteFile, Path: "b.go", Content: "package b\n"}, actx)
+	if result.Status != "executed" {
+		t.Fatalf("expected an unlocked file to be written, got %+v", result)
+	}
+
+	// Reads don't take locks
+	calls := len(locker.calls)
+	r.executeAllowedAction(context.Background(), Action{Type: ActionReadFile, Path: "a.go"}, actx)
+	if len(locker.calls) != calls {
+		t.Error("expected read_file not to lock")
+	}
+}
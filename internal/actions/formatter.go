@@ -0,0 +1,121 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/formatter"
+)
+
+// maxFormatDiffBytes caps the combined diff returned by run_formatter
+const maxFormatDiffBytes = 32 * 1024
+
+// handleFormatAction formats the requested paths, or the workdir's changed
+// files, writes the results back through the file manager and reports a
+// diff of what changed.
+func (r *Router) handleFormatAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Formatter == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "formatter not configured"}
+	}
+	if r.Files == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "file manager not configured"}
+	}
+
+	paths := action.Files
+	if len(paths) == 0 && action.Path != "" {
+		paths = []string{action.Path}
+	}
+	source := "paths"
+	if len(paths) == 0 {
+		changed, err := r.Formatter.ChangedFiles(ctx, actx.ProjectID)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		// Stay inside the bead's subproject when formatting changed files
+		_, sp, err := r.resolveSubproject(Action{Subproject: action.Subproject}, actx)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		for _, path := range changed {
+			if sp == nil || sp.Contains(path) {
+				paths = append(paths, path)
+			}
+		}
+		source = "changed_files"
+	}
+
+	var (
+		fileResults []map[string]interface{}
+		diffs       strings.Builder
+		formatted   int
+		failed      int
+		firstErr    error
+	)
+	for _, path := range paths {
+		tool := action.Framework
+		if tool == "" {
+			tool = formatter.ToolFor(path)
+		}
+		entry := map[string]interface{}{"path": path, "formatter": tool}
+		fileResults = append(fileResults, entry)
+		if tool == "" {
+			entry["status"] = "skipped"
+			continue
+		}
+
+		file, err := r.Files.ReadFile(ctx, actx.ProjectID, path)
+		if err == nil {
+			var out string
+			if out, err = r.Formatter.Format(ctx, actx.ProjectID, path, file.Content, tool); err == nil {
+				if out == file.Content {
+					entry["status"] = "unchanged"
+					continue
+				}
+				if _, err = r.Files.WriteFile(ctx, actx.ProjectID, path, out); err == nil {
+					diff, added, removed := formatter.Diff(path, file.Content, out)
+					diffs.WriteString(diff)
+					entry["status"] = "formatted"
+					entry["lines_added"] = added
+					entry["lines_removed"] = removed
+					formatted++
+					continue
+				}
+			}
+		}
+		entry["status"] = "error"
+		entry["error"] = err.Error()
+		if firstErr == nil {
+			firstErr = err
+		}
+		failed++
+	}
+
+	diff := diffs.String()
+	if len(diff) > maxFormatDiffBytes {
+		diff = diff[:maxFormatDiffBytes] + "\n... (truncated)"
+	}
+	message := fmt.Sprintf("formatted %d of %d files", formatted, len(paths))
+	if len(paths) == 0 {
+		message = "no files to format"
+	} else if failed > 0 {
+		message = fmt.Sprintf("%s (%d failed)", message, failed)
+	}
+	status := "executed"
+	if failed > 0 && failed == len(paths) {
+		status = "error"
+		message = fmt.Sprintf("%s: %v", message, firstErr)
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     status,
+		Message:    message,
+		Metadata: map[string]interface{}{
+			"files":     fileResults,
+			"formatted": formatted,
+			"failed":    failed,
+			"source":    source,
+			"diff":      diff,
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/files"
+)
+
+type mockFormatter struct {
+	changed []string
+	outputs map[string]string
+	errs    map[string]error
+	tools   map[string]string
+}
+
+func (m *mockFormatter) Format(ctx context.Context, projectID, path, content, tool string) (string, error) {
+	if m.tools == nil {
+		m.tools = map[string]string{}
+	}
+	m.tools[path] = tool
+	if err := m.errs[path]; err != nil {
+		return "", err
+	}
+	if out, ok := m.outputs[path]; ok {
+		return out, nil
+	}
+	return content, nil
+}
+
+func (m *mockFormatter) ChangedFiles(ctx context.Context, projectID string) ([]string, error) {
+	return m.changed, nil
+}
+
+type recordingFiles struct {
+	mockFileManager
+	written map[string]string
+}
+
+func (f *recordingFiles) ReadFile(ctx context.Context, projectID, path string) (*files.FileResult, error) {
+	return &files.FileResult{Path: path, Content: "before\n"}, nil
+}
+
+func (f *recordingFiles) WriteFile(ctx context.Context, projectID, path, content string) (*files.WriteResult, error) {
+	f.written[path] = content
+	return &files.WriteResult{Path: path, BytesWritten: int64(len(content))}, nil
+}
+
+func TestRouterRunFormatterChangedFiles(t *testing.T) {
+	fm := &recordingFiles{written: map[string]string{}}
+	fmtr := &mockFormatter{
+		changed: []string{"main.go", "README.md", "util.go", "web/app.ts"},
+		outputs: map[string]string{"main.go": "after\n"},
+		errs:    map[string]error{"web/app.ts": errors.New("prettier failed on web/app.ts: not found")},
+	}
+	r := &Router{Files: fm, Formatter: fmtr}
+
+	res := r.executeAction(context.Background(), Action{Type: ActionRunFormatter}, ActionContext{ProjectID: "p"})
+	if res.Status != "executed" {
+		t.Fatalf("expected executed, got %s: %s", res.Status, res.Message)
+	}
+	if res.Message != "formatted 1 of 4 files (1 failed)" {
+		t.Fatalf("unexpected message %q", res.Message)
+	}
+	if len(fm.written) != 1 || fm.written["main.go"] != "after\n" {
+		t.Fatalf("expected only main.go rewritten, got %v", fm.written)
+	}
+	if fmtr.tools["main.go"] != "gofmt" || fmtr.tools["web/app.ts"] != "prettier" {
+		t.Fatalf("unexpected formatter choice: %v", fmtr.tools)
+	}
+	if _, called := fmtr.tools["README.md"]; called {
+		t.Fatal("files without a formatter should be skipped")
+	}
+	diff, _ := res.Metadata["diff"].(string)
+	if !strings.Contains(diff, "-before") || !strings.Contains(diff, "+after") {
+		t.Fatalf("expected diff in metadata, got %q", diff)
+	}
+	if res.Metadata["source"] != "changed_files" {
+		t.Fatalf("expected changed_files source, got %v", res.Metadata["source"])
+	}
+}
+
+func TestRouterRunFormatterExplicitTool(t *testing.T) {
+	fm := &recordingFiles{written: map[string]string{}}
+	fmtr := &mockFormatter{errs: map[string]error{"main.go": errors.New("goimports failed on main.go: exit status 2")}}
+	r := &Router{Files: fm, Formatter: fmtr}
+
+	res := r.executeAction(context.Background(), Action{Type: ActionRunFormatter, Path: "main.go", Framework: "goimports"}, ActionContext{ProjectID: "p"})
+	if fmtr.tools["main.go"] != "goimports" {
+		t.Fatalf("expected explicit formatter to be used, got %v", fmtr.tools)
+	}
+	if res.Status != "error" || !strings.Contains(res.Message, "goimports failed") {
+		t.Fatalf("expected error when every file fails, got %s: %s", res.Status, res.Message)
+	}
+}
+
+func TestRouterRunFormatterNotConfigured(t *testing.T) {
+	r := &Router{}
+	res := r.executeAction(context.Background(), Action{Type: ActionRunFormatter}, ActionContext{ProjectID: "p"})
+	if res.Status != "error" {
+		t.Fatalf("expected error, got %s", res.Status)
+	}
+}
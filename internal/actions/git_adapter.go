@@ -73,6 +73,8 @@ func (a *GitServiceAdapter) Commit(ctx context.Context, beadID, agentID, message
 		"insertions":    result.Insertions,
 		"deletions":     result.Deletions,
 		"files":         result.Files,
+		"message":       result.Message,
+		"policy_fixes":  result.PolicyFixes,
 	}, nil
 }
 
@@ -251,6 +253,82 @@ func (a *GitServiceAdapter) DiffBranches(ctx context.Context, branch1, branch2 s
 	}, nil
 }
 
+// DiffRevisions returns the structured diff between two revisions; an
+// empty to compares against the working tree
+func (a *GitServiceAdapter) DiffRevisions(ctx context.Context, from, to, path string, contextLines int) (map[string]interface{}, error) {
+	diff, err := a.service.DiffRevisions(ctx, git.RevisionDiffRequest{From: from, To: to, Path: path, Context: contextLines})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"diff":      diff,
+		"files":     len(diff.Files),
+		"additions": diff.Additions,
+		"deletions": diff.Deletions,
+	}, nil
+}
+
+// Blame returns who last changed each line of a file range, with the bead
+// each change was made for
+func (a *GitServiceAdapter) Blame(ctx context.Context, path string, startLine, endLine int) (map[string]interface{}, error) {
+	blame, err := a.service.Blame(ctx, git.BlameRequest{Path: path, StartLine: startLine, EndLine: endLine})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"blame": blame,
+		"lines": len(blame.Lines),
+		"beads": blame.Beads,
+	}, nil
+}
+
+// CreateTag creates an annotated tag at ref (default HEAD)
+func (a *GitServiceAdapter) CreateTag(ctx context.Context, beadID, tag, message, ref string) (map[string]interface{}, error) {
+	result, err := a.service.CreateTag(ctx, git.TagRequest{Name: tag, Message: message, Ref: ref, BeadID: beadID})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"tag":        result.Name,
+		"commit_sha": result.CommitSHA,
+	}, nil
+}
+
+// Changelog generates the changelog of the commits between two revisions
+// and, when path is set, adds it to the top of that file
+func (a *GitServiceAdapter) Changelog(ctx context.Context, from, to, version, path string) (map[string]interface{}, error) {
+	log, err := a.service.Changelog(ctx, git.ChangelogRequest{From: from, To: to, Version: version})
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		if err := a.service.WriteChangelog(path, log); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]interface{}{
+		"changelog": log.Markdown(),
+		"commits":   len(log.Entries),
+		"beads":     log.Beads,
+		"from":      log.From,
+		"path":      path,
+	}, nil
+}
+
+// BumpVersion writes the next version into the project's version files
+func (a *GitServiceAdapter) BumpVersion(ctx context.Context, beadID, bump, version string, files []string) (map[string]interface{}, error) {
+	result, err := a.service.BumpVersion(ctx, git.BumpVersionRequest{Bump: bump, Version: version, Files: files, BeadID: beadID})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"previous": result.Previous,
+		"version":  result.Version,
+		"tag":      result.Tag,
+		"files":    result.Files,
+	}, nil
+}
+
 // GetBeadCommits returns all commits for a bead ID
 func (a *GitServiceAdapter) GetBeadCommits(ctx context.Context, beadID string) (map[string]interface{}, error) {
 	commits, err := a.service.GetBeadCommits(ctx, beadID)
@@ -263,3 +341,22 @@ func (a *GitServiceAdapter) GetBeadCommits(ctx context.Context, beadID string) (
 		"bead_id": beadID,
 	}, nil
 }
+
+// CommitDiff returns the patch introduced by a commit
+func (a *GitServiceAdapter) CommitDiff(ctx context.Context, sha string) (string, error) {
+	return a.service.CommitDiff(ctx, sha)
+}
+
+// FindBeadPR returns the open PR number for a bead's branch, or 0
+func (a *GitServiceAdapter) FindBeadPR(ctx context.Context, beadID string) (int, error) {
+	return a.service.FindBeadPR(ctx, beadID)
+}
+
+// CommentPR posts a comment on a pull request
+func (a *GitServiceAdapter) CommentPR(ctx context.Context, beadID string, prNumber int, body string) error {
+	return a.service.CommentPR(ctx, git.PRCommentRequest{
+		BeadID: beadID,
+		Number: prNumber,
+		Body:   body,
+	})
+}
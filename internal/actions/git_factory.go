@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/jordanhubbard/loom/internal/git"
 	"github.com/jordanhubbard/loom/internal/gitops"
+	"github.com/jordanhubbard/loom/internal/secretscan"
 )
 
 // contextKey is an unexported type for context keys in this package.
@@ -33,6 +35,10 @@ type ProjectGitRouter struct {
 	gitopsMgr *gitops.Manager
 	mu        sync.RWMutex
 	cache     map[string]*GitServiceAdapter // projectID -> adapter
+
+	secrets    *secretscan.Scanner
+	secretsSet bool
+	policy     *git.CommitPolicy
 }
 
 // NewProjectGitRouter creates a project-aware GitOperator.
@@ -43,6 +49,28 @@ func NewProjectGitRouter(gitopsMgr *gitops.Manager) *ProjectGitRouter {
 	}
 }
 
+// SetSecretScanner configures the secrets gate of every project's commits
+// and pushes; nil turns it off. Without a call the built-in rules apply.
+func (r *ProjectGitRouter) SetSecretScanner(scanner *secretscan.Scanner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secrets, r.secretsSet = scanner, true
+	for _, adapter := range r.cache {
+		adapter.service.SetSecretScanner(scanner)
+	}
+}
+
+// SetCommitPolicy sets the convention every project's commit messages are
+// checked against
+func (r *ProjectGitRouter) SetCommitPolicy(policy *git.CommitPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policy = policy
+	for _, adapter := range r.cache {
+		adapter.service.SetCommitPolicy(policy)
+	}
+}
+
 // forProject returns a cached or newly-created GitServiceAdapter for the project.
 func (r *ProjectGitRouter) forProject(projectID string) (*GitServiceAdapter, error) {
 	if projectID == "" {
@@ -65,6 +93,12 @@ func (r *ProjectGitRouter) forProject(projectID string) (*GitServiceAdapter, err
 	}
 
 	r.mu.Lock()
+	if r.secretsSet {
+		adapter.service.SetSecretScanner(r.secrets)
+	}
+	if r.policy != nil {
+		adapter.service.SetCommitPolicy(r.policy)
+	}
 	r.cache[projectID] = adapter
 	r.mu.Unlock()
 
@@ -210,6 +244,70 @@ func (r *ProjectGitRouter) GetBeadCommits(ctx context.Context, beadID string) (m
 	return adapter.GetBeadCommits(ctx, beadID)
 }
 
+func (r *ProjectGitRouter) DiffRevisions(ctx context.Context, from, to, path string, contextLines int) (map[string]interface{}, error) {
+	adapter, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return adapter.DiffRevisions(ctx, from, to, path, contextLines)
+}
+
+func (r *ProjectGitRouter) Blame(ctx context.Context, path string, startLine, endLine int) (map[string]interface{}, error) {
+	adapter, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return adapter.Blame(ctx, path, startLine, endLine)
+}
+
+func (r *ProjectGitRouter) CreateTag(ctx context.Context, beadID, tag, message, ref string) (map[string]interface{}, error) {
+	adapter, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return adapter.CreateTag(ctx, beadID, tag, message, ref)
+}
+
+func (r *ProjectGitRouter) Changelog(ctx context.Context, from, to, version, path string) (map[string]interface{}, error) {
+	adapter, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return adapter.Changelog(ctx, from, to, version, path)
+}
+
+func (r *ProjectGitRouter) BumpVersion(ctx context.Context, beadID, bump, version string, files []string) (map[string]interface{}, error) {
+	adapter, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return adapter.BumpVersion(ctx, beadID, bump, version, files)
+}
+
+func (r *ProjectGitRouter) CommitDiff(ctx context.Context, sha string) (string, error) {
+	adapter, err := r.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	return adapter.CommitDiff(ctx, sha)
+}
+
+func (r *ProjectGitRouter) FindBeadPR(ctx context.Context, beadID string) (int, error) {
+	adapter, err := r.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return adapter.FindBeadPR(ctx, beadID)
+}
+
+func (r *ProjectGitRouter) CommentPR(ctx context.Context, beadID string, prNumber int, body string) error {
+	adapter, err := r.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	return adapter.CommentPR(ctx, beadID, prNumber, body)
+}
+
 // ForProject returns a project-scoped GitOperator.
 func (r *ProjectGitRouter) ForProject(projectID string) (GitOperator, error) {
 	return r.forProject(projectID)
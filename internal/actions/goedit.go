@@ -0,0 +1,66 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/formatter"
+	"github.com/jordanhubbard/loom/internal/goedit"
+)
+
+// handleGoEdit applies add_import, insert_function_after or add_struct_field
+// to a Go file. The file is only written when the edit leaves it valid Go.
+func (r *Router) handleGoEdit(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Files == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "file manager not configured"}
+	}
+	if !strings.HasSuffix(action.Path, ".go") {
+		return Result{ActionType: action.Type, Status: "error", Message: fmt.Sprintf("%s only edits Go files; use edit_code for %s", action.Type, action.Path)}
+	}
+	res, err := r.Files.ReadFile(ctx, actx.ProjectID, action.Path)
+	if err != nil {
+		return Result{ActionType: action.Type, Status: "error", Message: fmt.Sprintf("cannot read %s: %v", action.Path, err)}
+	}
+
+	var out []byte
+	switch action.Type {
+	case ActionAddImport:
+		out, err = goedit.AddImport([]byte(res.Content), action.ImportPath, action.ImportName)
+	case ActionInsertFunctionAfter:
+		out, err = goedit.InsertFunctionAfter([]byte(res.Content), action.Symbol, action.Content)
+	case ActionAddStructField:
+		out, err = goedit.AddStructField([]byte(res.Content), action.Symbol, action.Content)
+	}
+	if err != nil {
+		return Result{ActionType: action.Type, Status: "error", Message: fmt.Sprintf("%s: %v", action.Path, err),
+			Metadata: map[string]interface{}{"path": action.Path}}
+	}
+	updated := string(out)
+	if updated == res.Content {
+		return Result{ActionType: action.Type, Status: "executed", Message: fmt.Sprintf("%s already up to date", action.Path),
+			Metadata: map[string]interface{}{"path": action.Path, "changed": false}}
+	}
+
+	writeRes, err := r.Files.WriteFile(ctx, actx.ProjectID, action.Path, updated)
+	if err != nil {
+		return Result{ActionType: action.Type, Status: "error", Message: fmt.Sprintf("write failed: %v", err)}
+	}
+	diff, added, removed := formatter.Diff(action.Path, res.Content, updated)
+	if len(diff) > maxEditDiffBytes {
+		diff = diff[:maxEditDiffBytes] + "\n... (diff truncated)\n"
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    fmt.Sprintf("edited %s: +%d -%d lines, gofmt'd", action.Path, added, removed),
+		Metadata: map[string]interface{}{
+			"path":          writeRes.Path,
+			"bytes_written": writeRes.BytesWritten,
+			"changed":       true,
+			"diff":          diff,
+			"lines_added":   added,
+			"lines_removed": removed,
+		},
+	}
+}
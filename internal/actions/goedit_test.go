@@ -0,0 +1,60 @@
+package actions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+)
+
+func TestRouter_GoEdits(t *testing.T) {
+	dir := t.TempDir()
+	src := "package main\n\ntype Config struct {\n\tName string\n}\n\nfunc main() {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Router{Files: files.NewManager(toolrun.StaticWorkDir(dir))}
+	ctx := context.Background()
+
+	steps := []Action{
+		{Type: ActionAddImport, Path: "main.go", ImportPath: "time"},
+		{Type: ActionAddStructField, Path: "main.go", Symbol: "Config", Content: "Timeout time.Duration"},
+		{Type: ActionInsertFunctionAfter, Path: "main.go", Symbol: "main", Content: "func timeout(c Config) time.Duration { return c.Timeout }"},
+	}
+	for _, action := range steps {
+		result := r.executeAction(ctx, action, ActionContext{})
+		if result.Status != "executed" || result.Metadata["diff"] == "" {
+			t.Fatalf("%s: expected executed with a diff, got %+v", action.Type, result)
+		}
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "main.go"))
+	got := string(data)
+	for _, want := range []string{"import \"time\"", "\tName    string\n\tTimeout time.Duration\n", "func timeout(c Config) time.Duration { return c.Timeout }"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+
+	result := r.executeAction(ctx, Action{Type: ActionAddImport, Path: "main.go", ImportPath: "time"}, ActionContext{})
+	if result.Status != "executed" || result.Metadata["changed"] != false {
+		t.Errorf("expected a repeated import to be a no-op, got %+v", result)
+	}
+	result = r.executeAction(ctx, Action{Type: ActionInsertFunctionAfter, Path: "main.go", Content: "func broken( {"}, ActionContext{})
+	if result.Status != "error" {
+		t.Errorf("expected invalid Go to be refused, got %+v", result)
+	}
+	if after, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(after) != got {
+		t.Error("expected a refused edit to leave the file alone")
+	}
+	result = r.executeAction(ctx, Action{Type: ActionAddImport, Path: "notes.txt", ImportPath: "time"}, ActionContext{})
+	if result.Status != "error" || !strings.Contains(result.Message, "only edits Go files") {
+		t.Errorf("expected non-Go files to be refused, got %+v", result)
+	}
+}
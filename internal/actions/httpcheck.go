@@ -0,0 +1,66 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/httpcheck"
+)
+
+// handleHTTPCheckAction probes a URL and checks the response against the
+// action's expectations.
+func (r *Router) handleHTTPCheckAction(ctx context.Context, action Action) Result {
+	if r.HTTPChecks == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "http checker not configured"}
+	}
+
+	check, err := r.HTTPChecks.Run(ctx, httpcheck.Request{
+		URL:          action.URL,
+		Method:       action.Method,
+		Headers:      action.Headers,
+		Body:         action.Body,
+		ExpectStatus: action.ExpectStatus,
+		ExpectBody:   action.ExpectBody,
+		ExpectJSON:   action.ExpectJSON,
+		Retries:      action.Retries,
+		Timeout:      time.Duration(action.TimeoutSeconds) * time.Second,
+	})
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+
+	failed := 0
+	for _, a := range check.Assertions {
+		if !a.Passed {
+			failed++
+		}
+	}
+	var message string
+	switch {
+	case check.Passed:
+		message = fmt.Sprintf("%s %s returned %d; %d checks passed after %d attempts", check.Method, check.URL, check.Status, len(check.Assertions), len(check.Attempts))
+	case check.Status == 0:
+		message = fmt.Sprintf("%s %s failed after %d attempts: %s", check.Method, check.URL, len(check.Attempts), check.Error)
+	default:
+		message = fmt.Sprintf("%s %s returned %d; %d of %d checks failed after %d attempts", check.Method, check.URL, check.Status, failed, len(check.Assertions), len(check.Attempts))
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    message,
+		Metadata: map[string]interface{}{
+			"url":            check.URL,
+			"method":         check.Method,
+			"passed":         check.Passed,
+			"status":         check.Status,
+			"headers":        check.Headers,
+			"body":           check.Body,
+			"body_truncated": check.BodyTruncated,
+			"assertions":     check.Assertions,
+			"attempts":       check.Attempts,
+			"error":          check.Error,
+			"duration":       check.Duration,
+		},
+	}
+}
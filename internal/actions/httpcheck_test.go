@@ -0,0 +1,49 @@
+package actions
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/httpcheck"
+)
+
+func TestRouterHTTPCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"user":{"name":"ada","admin":false}}`)
+	}))
+	defer srv.Close()
+	r := &Router{HTTPChecks: httpcheck.NewChecker()}
+
+	res := r.executeAction(context.Background(), Action{
+		Type:       ActionHTTPCheck,
+		URL:        srv.URL + "/users/1",
+		ExpectJSON: map[string]interface{}{"user.name": "ada"},
+	}, ActionContext{ProjectID: "p"})
+	if res.Status != "executed" || res.Metadata["passed"] != true || !strings.Contains(res.Message, "2 checks passed after 1 attempts") {
+		t.Fatalf("unexpected result %s: %s", res.Status, res.Message)
+	}
+
+	res = r.executeAction(context.Background(), Action{
+		Type:       ActionHTTPCheck,
+		URL:        srv.URL + "/users/1",
+		ExpectJSON: map[string]interface{}{"user.admin": true},
+	}, ActionContext{ProjectID: "p"})
+	feedback := FormatResultsAsUserMessage([]Result{res})
+	for _, want := range []string{"**HTTP check: FAILED**", "1 of 2 checks failed", "json:user.admin: user.admin is false, expected true", `"name":"ada"`} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback missing %q:\n%s", want, feedback)
+		}
+	}
+
+	if res := r.executeAction(context.Background(), Action{Type: ActionHTTPCheck, URL: "ftp://example.com"}, ActionContext{}); res.Status != "error" {
+		t.Errorf("expected an ftp URL to be rejected, got %s", res.Status)
+	}
+	if err := Validate(&ActionEnvelope{Actions: []Action{{Type: ActionHTTPCheck}}}); err == nil {
+		t.Error("expected http_check without url to be rejected")
+	}
+}
@@ -0,0 +1,34 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+)
+
+// handleAttachImage attaches an image from the workdir to the bead. The
+// worker shows it to the model with the action results, and later
+// dispatches of the bead include it in the task prompt.
+func (r *Router) handleAttachImage(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Images == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "image attachments not configured"}
+	}
+	if actx.BeadID == "" {
+		return Result{ActionType: action.Type, Status: "error", Message: "attach_image needs a bead to attach the image to"}
+	}
+
+	img, err := r.Images.AttachFile(ctx, actx.ProjectID, actx.BeadID, action.Path)
+	if err != nil {
+		return Result{ActionType: action.Type, Status: "error", Message: err.Error(), Metadata: map[string]interface{}{"path": action.Path}}
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    fmt.Sprintf("attached %s (%s, %d bytes) to bead %s; the image follows", action.Path, img.MediaType, img.Size, actx.BeadID),
+		Metadata: map[string]interface{}{
+			"path":       action.Path,
+			"image_id":   img.ID,
+			"media_type": img.MediaType,
+			"size":       img.Size,
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/attachments"
+)
+
+type fakeImages struct {
+	path, beadID string
+	attached     []string
+}
+
+func (f *fakeImages) AttachFile(ctx context.Context, projectID, beadID, path string) (*attachments.Image, error) {
+	if strings.HasSuffix(path, ".txt") {
+		return nil, errors.New("unsupported image type text/plain")
+	}
+	f.path, f.beadID = path, beadID
+	return &attachments.Image{ID: "img-1", BeadID: beadID, MediaType: "image/png", Size: 42}, nil
+}
+
+func (f *fakeImages) Attach(beadID, name, mediaType string, data []byte, source string) (*attachments.Image, error) {
+	if len(f.attached) >= 1 {
+		return nil, errors.New("bead already has 1 images")
+	}
+	f.attached = append(f.attached, name)
+	return &attachments.Image{ID: "img-" + name, BeadID: beadID, Name: name, MediaType: mediaType, Size: len(data), Source: source}, nil
+}
+
+func TestRouterAttachImage(t *testing.T) {
+	images := &fakeImages{}
+	r := &Router{Images: images}
+	actx := ActionContext{ProjectID: "p1", BeadID: "b1"}
+
+	res := r.executeAction(context.Background(), Action{Type: ActionAttachImage, Path: "shots/login.png"}, actx)
+	if res.Status != "executed" || res.Metadata["image_id"] != "img-1" || images.beadID != "b1" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res := r.executeAction(context.Background(), Action{Type: ActionAttachImage, Path: "notes.txt"}, actx); res.Status != "error" {
+		t.Errorf("expected a non-image to fail, got %s", res.Status)
+	}
+	if res := r.executeAction(context.Background(), Action{Type: ActionAttachImage, Path: "a.png"}, ActionContext{ProjectID: "p1"}); res.Status != "error" {
+		t.Errorf("expected an error without a bead, got %s", res.Status)
+	}
+	if res := (&Router{}).executeAction(context.Background(), Action{Type: ActionAttachImage, Path: "a.png"}, actx); res.Status != "error" {
+		t.Errorf("expected an error without attachments, got %s", res.Status)
+	}
+
+	env, err := ParseSimpleJSON([]byte(`{"action": "attach_image", "path": "shots/login.png"}`))
+	if err != nil || env.Actions[0].Type != ActionAttachImage || env.Actions[0].Path != "shots/login.png" {
+		t.Errorf("ParseSimpleJSON() = %+v, %v", env, err)
+	}
+	if _, err := ParseSimpleJSON([]byte(`{"action": "attach_image"}`)); err == nil {
+		t.Error("expected attach_image without a path to be rejected")
+	}
+}
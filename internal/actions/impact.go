@@ -0,0 +1,43 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/impact"
+)
+
+// handleImpactAction reports what a change affects so the agent can scope
+// its verification: the packages depending on the changed files, the tests
+// covering them and the public declarations the change added, removed or
+// changed. References to changed symbols come from the LSP operator when
+// one is configured.
+func (r *Router) handleImpactAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Impact == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "impact analysis not configured"}
+	}
+	req := impact.Request{Files: action.Files, Symbols: action.Symbols, Base: action.FromRef}
+	if r.LSP != nil {
+		req.References = r.LSP
+	}
+	report, err := r.Impact.Analyze(r.lspContext(ctx, action, actx), actx.ProjectID, req)
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+
+	message := fmt.Sprintf("%d changed files affect %d packages; %d test targets to run", len(report.ChangedFiles), len(report.Packages), len(report.Tests))
+	if report.Breaking {
+		message += "; the public API changed incompatibly"
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    message,
+		Metadata: map[string]interface{}{
+			"report":       report,
+			"tests":        report.Tests,
+			"test_command": report.GoTestCommand(),
+			"breaking":     report.Breaking,
+		},
+	}
+}
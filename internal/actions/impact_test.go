@@ -0,0 +1,56 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/impact"
+)
+
+type fakeImpact struct {
+	projectID string
+	req       impact.Request
+	report    *impact.Report
+}
+
+func (f *fakeImpact) Analyze(_ context.Context, projectID string, req impact.Request) (*impact.Report, error) {
+	f.projectID, f.req = projectID, req
+	return f.report, nil
+}
+
+func TestRouterAnalyzeImpact(t *testing.T) {
+	fake := &fakeImpact{report: &impact.Report{
+		ChangedFiles: []string{"store/store.go"},
+		Packages: []*impact.Package{
+			{Dir: "store", Reason: "changed"},
+			{Dir: "api", Reason: "imports store", Distance: 1},
+		},
+		Tests: []string{"./api", "./store", "web/store.test.ts"},
+		APIChanges: []impact.APIChange{
+			{Path: "store/store.go", Symbol: "New", Change: "changed", Before: "func New(string) *Store", After: "func New(string, int) *Store"},
+		},
+		Breaking: true,
+	}}
+	r := &Router{Impact: fake, LSP: &mockLSPOperator{}}
+
+	action := Action{Type: ActionAnalyzeImpact, Files: []string{"store/store.go"}, Symbols: []string{"New"}, FromRef: "main"}
+	res := r.executeAction(context.Background(), action, ActionContext{ProjectID: "p"})
+	if res.Status != "executed" || res.Message != "1 changed files affect 2 packages; 3 test targets to run; the public API changed incompatibly" {
+		t.Fatalf("unexpected result %s: %s", res.Status, res.Message)
+	}
+	if fake.projectID != "p" || fake.req.Base != "main" || len(fake.req.Symbols) != 1 || fake.req.References == nil {
+		t.Errorf("unexpected request %+v", fake.req)
+	}
+
+	msg := FormatResultsAsUserMessage([]Result{res})
+	for _, want := range []string{"- api (imports store)", "Run: `go test ./api ./store`", "Test files: web/store.test.ts", "BREAKING", "changed func New(string) *Store -> func New(string, int) *Store in store/store.go"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("feedback is missing %q:\n%s", want, msg)
+		}
+	}
+
+	if res := (&Router{}).executeAction(context.Background(), action, ActionContext{}); res.Status != "error" {
+		t.Errorf("expected an error without an analyzer, got %s", res.Status)
+	}
+}
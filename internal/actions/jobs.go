@@ -0,0 +1,109 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/executor"
+)
+
+const (
+	jobStatusTailBytes = 2000
+	maxJobLogWait      = 30 * time.Second
+)
+
+// handleJobAction starts, inspects and cancels background commands.
+func (r *Router) handleJobAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Jobs == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "background jobs not configured"}
+	}
+
+	switch action.Type {
+	case ActionStartCommand:
+		info, err := r.Jobs.StartJob(ctx, executor.ExecuteCommandRequest{
+			AgentID:    actx.AgentID,
+			BeadID:     actx.BeadID,
+			ProjectID:  actx.ProjectID,
+			Command:    action.Command,
+			WorkingDir: action.WorkingDir,
+			Timeout:    action.TimeoutSeconds,
+			Context: map[string]interface{}{
+				"action_type": action.Type,
+				"reason":      action.Reason,
+			},
+		})
+		if err != nil {
+			return executorErrorResult(action.Type, err)
+		}
+		return Result{
+			ActionType: action.Type,
+			Status:     "executed",
+			Message:    fmt.Sprintf("job %s started", info.ID),
+			Metadata: map[string]interface{}{
+				"job_id":  info.ID,
+				"command": info.Command,
+				"status":  info.Status,
+			},
+		}
+	case ActionJobStatus:
+		info, err := r.Jobs.GetJob(action.JobID)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		metadata := jobMetadata(info)
+		if tail, err := r.Jobs.ReadLog(ctx, action.JobID, -1, jobStatusTailBytes, 0); err == nil {
+			metadata["output_tail"] = tail.Data
+		}
+		return Result{
+			ActionType: action.Type,
+			Status:     "executed",
+			Message:    fmt.Sprintf("job %s %s", info.ID, info.Status),
+			Metadata:   metadata,
+		}
+	case ActionJobLogs:
+		wait := time.Duration(action.TimeoutSeconds) * time.Second
+		if wait > maxJobLogWait {
+			wait = maxJobLogWait
+		}
+		page, err := r.Jobs.ReadLog(ctx, action.JobID, action.Offset, action.Limit, wait)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		return Result{
+			ActionType: action.Type,
+			Status:     "executed",
+			Message:    fmt.Sprintf("job %s %s", page.JobID, page.Status),
+			Metadata: map[string]interface{}{
+				"job_id":      page.JobID,
+				"output":      page.Data,
+				"offset":      page.Offset,
+				"next_offset": page.NextOffset,
+				"truncated":   page.Truncated,
+				"done":        page.Done,
+			},
+		}
+	case ActionCancelJob:
+		info, err := r.Jobs.CancelJob(action.JobID)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		return Result{
+			ActionType: action.Type,
+			Status:     "executed",
+			Message:    fmt.Sprintf("job %s %s", info.ID, info.Status),
+			Metadata:   jobMetadata(info),
+		}
+	}
+	return Result{ActionType: action.Type, Status: "error", Message: "unsupported job action"}
+}
+
+func jobMetadata(info *executor.JobInfo) map[string]interface{} {
+	return map[string]interface{}{
+		"job_id":      info.ID,
+		"command":     info.Command,
+		"status":      info.Status,
+		"exit_code":   info.ExitCode,
+		"duration_ms": info.Duration,
+	}
+}
@@ -0,0 +1,118 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/executor"
+)
+
+type mockJobRunner struct {
+	startReq   executor.ExecuteCommandRequest
+	lastOffset int64
+	lastWait   time.Duration
+	canceled   []string
+	startErr   error
+}
+
+func (m *mockJobRunner) StartJob(ctx context.Context, req executor.ExecuteCommandRequest) (*executor.JobInfo, error) {
+	m.startReq = req
+	if m.startErr != nil {
+		return nil, m.startErr
+	}
+	return &executor.JobInfo{ID: "job-1", Command: req.Command, Status: executor.JobRunning}, nil
+}
+
+func (m *mockJobRunner) GetJob(id string) (*executor.JobInfo, error) {
+	return &executor.JobInfo{ID: id, Status: executor.JobRunning, ExitCode: -1}, nil
+}
+
+func (m *mockJobRunner) ReadLog(ctx context.Context, id string, offset int64, limit int, wait time.Duration) (*executor.JobLog, error) {
+	m.lastOffset = offset
+	m.lastWait = wait
+	return &executor.JobLog{JobID: id, Offset: 0, NextOffset: 18, Data: "listening on :8080", Status: executor.JobRunning}, nil
+}
+
+func (m *mockJobRunner) CancelJob(id string) (*executor.JobInfo, error) {
+	m.canceled = append(m.canceled, id)
+	return &executor.JobInfo{ID: id, Status: executor.JobCanceled}, nil
+}
+
+func TestRouterJobActions(t *testing.T) {
+	jobs := &mockJobRunner{}
+	r := &Router{Jobs: jobs}
+	actx := ActionContext{AgentID: "agent-1", BeadID: "bead-1", ProjectID: "proj-1"}
+
+	env := &ActionEnvelope{Actions: []Action{
+		{Type: ActionStartCommand, Command: "npm run dev", TimeoutSeconds: 900},
+		{Type: ActionJobStatus, JobID: "job-1"},
+		{Type: ActionJobLogs, JobID: "job-1", Offset: 5, TimeoutSeconds: 120},
+		{Type: ActionCancelJob, JobID: "job-1"},
+	}}
+	if err := Validate(env); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	results, err := r.Execute(context.Background(), env, actx)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for _, res := range results {
+		if res.Status != "executed" {
+			t.Fatalf("%s: status %s (%s)", res.ActionType, res.Status, res.Message)
+		}
+	}
+
+	if jobs.startReq.BeadID != "bead-1" || jobs.startReq.Timeout != 900 {
+		t.Errorf("start request = %+v", jobs.startReq)
+	}
+	if results[0].Metadata["job_id"] != "job-1" {
+		t.Errorf("start_command metadata = %v", results[0].Metadata)
+	}
+	if results[1].Metadata["output_tail"] != "listening on :8080" {
+		t.Errorf("job_status metadata = %v", results[1].Metadata)
+	}
+	if jobs.lastOffset != 5 || jobs.lastWait != maxJobLogWait {
+		t.Errorf("job_logs offset=%d wait=%v", jobs.lastOffset, jobs.lastWait)
+	}
+	if len(jobs.canceled) != 1 {
+		t.Errorf("canceled = %v", jobs.canceled)
+	}
+
+	msg := FormatResultsAsUserMessage(results[2:3])
+	if !strings.Contains(msg, "listening on :8080") || !strings.Contains(msg, "offset 18") {
+		t.Errorf("feedback missing job output: %s", msg)
+	}
+}
+
+func TestRouterJobActionsNotConfigured(t *testing.T) {
+	r := &Router{}
+	res := r.executeAction(context.Background(), Action{Type: ActionStartCommand, Command: "make watch"}, ActionContext{})
+	if res.Status != "error" {
+		t.Errorf("expected error without job runner, got %s", res.Status)
+	}
+}
+
+func TestRouterJobActionsQuotaExceeded(t *testing.T) {
+	jobs := &mockJobRunner{startErr: &executor.QuotaExceededError{
+		ProjectID: "proj-1", Quota: executor.QuotaConcurrentCommands, Limit: 2, Used: 2,
+	}}
+	r := &Router{Jobs: jobs}
+
+	env := &ActionEnvelope{Actions: []Action{{Type: ActionStartCommand, Command: "make test"}}}
+	results, err := r.Execute(context.Background(), env, ActionContext{ProjectID: "proj-1"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	res := results[0]
+	if res.Status != "error" || res.Metadata["error_type"] != "quota_exceeded" {
+		t.Fatalf("result = %+v", res)
+	}
+	if res.Metadata["quota"] != executor.QuotaConcurrentCommands || res.Metadata["limit"] != float64(2) {
+		t.Errorf("metadata = %+v", res.Metadata)
+	}
+	if msg := FormatResultsAsUserMessage(results); !strings.Contains(msg, "resource quota") {
+		t.Errorf("feedback missing quota suggestion:\n%s", msg)
+	}
+}
@@ -37,6 +37,7 @@ func (a *LSPServiceAdapter) FindReferences(ctx context.Context, file string, lin
 		Line:   line,
 		Column: column,
 		Symbol: symbol,
+		Root:   LSPRootFromContext(ctx),
 	}
 
 	locations, err := a.service.FindReferences(ctx, req)
@@ -68,6 +69,7 @@ func (a *LSPServiceAdapter) GoToDefinition(ctx context.Context, file string, lin
 		Line:   line,
 		Column: column,
 		Symbol: symbol,
+		Root:   LSPRootFromContext(ctx),
 	}
 
 	location, err := a.service.GoToDefinition(ctx, req)
@@ -97,6 +99,7 @@ func (a *LSPServiceAdapter) FindImplementations(ctx context.Context, file string
 		Line:   line,
 		Column: column,
 		Symbol: symbol,
+		Root:   LSPRootFromContext(ctx),
 	}
 
 	locations, err := a.service.FindImplementations(ctx, req)
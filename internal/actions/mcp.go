@@ -0,0 +1,82 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/mcp"
+)
+
+// handleMCPListTools lists the tools of the project's MCP servers, with
+// their input schemas, so the agent can call them with mcp_call.
+func (r *Router) handleMCPListTools(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.MCP == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "mcp tools not configured"}
+	}
+
+	servers := r.MCP.ListTools(ctx, actx.ProjectID)
+	if action.MCPServer != "" {
+		var selected []mcp.ServerTools
+		for _, s := range servers {
+			if s.Server == action.MCPServer {
+				selected = append(selected, s)
+			}
+		}
+		if len(selected) == 0 {
+			return Result{ActionType: action.Type, Status: "error", Message: fmt.Sprintf("no mcp server %q configured for this project", action.MCPServer)}
+		}
+		servers = selected
+	}
+	if len(servers) == 0 {
+		return Result{ActionType: action.Type, Status: "executed", Message: "no mcp servers are configured for this project"}
+	}
+
+	var lines []string
+	count := 0
+	for _, s := range servers {
+		if s.Error != "" {
+			lines = append(lines, fmt.Sprintf("%s: unavailable (%s)", s.Server, s.Error))
+			continue
+		}
+		for _, t := range s.Tools {
+			count++
+			line := fmt.Sprintf("%s/%s", s.Server, t.Name)
+			if t.Description != "" {
+				line += ": " + t.Description
+			}
+			if len(t.InputSchema) > 0 {
+				line += "\n  arguments schema: " + string(t.InputSchema)
+			}
+			lines = append(lines, line)
+		}
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    fmt.Sprintf("%d mcp tools\n%s", count, strings.Join(lines, "\n")),
+		Metadata:   map[string]interface{}{"servers": servers},
+	}
+}
+
+// handleMCPCall calls a tool of one of the project's MCP servers. The
+// arguments are checked against the tool's input schema before the call.
+func (r *Router) handleMCPCall(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.MCP == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "mcp tools not configured"}
+	}
+
+	metadata := map[string]interface{}{"mcp_server": action.MCPServer, "mcp_tool": action.MCPTool}
+	result, err := r.MCP.CallTool(ctx, actx.ProjectID, action.MCPServer, action.MCPTool, action.Arguments)
+	if err != nil {
+		return Result{ActionType: action.Type, Status: "error", Message: err.Error(), Metadata: metadata}
+	}
+	if len(result.StructuredContent) > 0 {
+		metadata["structured_content"] = result.StructuredContent
+	}
+	status := "executed"
+	if result.IsError {
+		status = "error"
+	}
+	return Result{ActionType: action.Type, Status: status, Message: result.Text(), Metadata: metadata}
+}
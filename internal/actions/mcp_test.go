@@ -0,0 +1,83 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/mcp"
+)
+
+type mockMCPTools struct {
+	servers []mcp.ServerTools
+	called  string
+	args    map[string]interface{}
+	result  *mcp.CallResult
+	err     error
+}
+
+func (m *mockMCPTools) ListTools(ctx context.Context, projectID string) []mcp.ServerTools {
+	return m.servers
+}
+
+func (m *mockMCPTools) CallTool(ctx context.Context, projectID, server, tool string, args map[string]interface{}) (*mcp.CallResult, error) {
+	m.called = projectID + "/" + server + "/" + tool
+	m.args = args
+	return m.result, m.err
+}
+
+func TestRouterMCPListTools(t *testing.T) {
+	tools := &mockMCPTools{servers: []mcp.ServerTools{
+		{Server: "search", Tools: []mcp.Tool{{Name: "query", Description: "Search the docs", InputSchema: json.RawMessage(`{"type":"object"}`)}}},
+		{Server: "db", Error: "connection refused"},
+	}}
+	r := &Router{MCP: tools}
+	actx := ActionContext{ProjectID: "p1"}
+
+	res := r.executeAction(context.Background(), Action{Type: ActionMCPListTools}, actx)
+	if res.Status != "executed" || !strings.Contains(res.Message, "search/query: Search the docs") || !strings.Contains(res.Message, "db: unavailable (connection refused)") {
+		t.Errorf("unexpected result: %s: %s", res.Status, res.Message)
+	}
+
+	res = r.executeAction(context.Background(), Action{Type: ActionMCPListTools, MCPServer: "db"}, actx)
+	if strings.Contains(res.Message, "search/query") {
+		t.Errorf("expected only the db server, got %s", res.Message)
+	}
+	if res := r.executeAction(context.Background(), Action{Type: ActionMCPListTools, MCPServer: "other"}, actx); res.Status != "error" {
+		t.Errorf("expected error for an unknown server, got %s", res.Status)
+	}
+	if res := (&Router{}).executeAction(context.Background(), Action{Type: ActionMCPListTools}, actx); res.Status != "error" {
+		t.Errorf("expected error without MCP integration, got %s", res.Status)
+	}
+}
+
+func TestRouterMCPCall(t *testing.T) {
+	tools := &mockMCPTools{result: &mcp.CallResult{Content: []mcp.Content{{Type: "text", Text: "3 matches"}}}}
+	r := &Router{MCP: tools}
+	actx := ActionContext{ProjectID: "p1"}
+	action := Action{Type: ActionMCPCall, MCPServer: "search", MCPTool: "query", Arguments: map[string]interface{}{"q": "retry"}}
+
+	res := r.executeAction(context.Background(), action, actx)
+	if res.Status != "executed" || res.Message != "3 matches" {
+		t.Errorf("unexpected result: %s: %s", res.Status, res.Message)
+	}
+	if tools.called != "p1/search/query" || tools.args["q"] != "retry" {
+		t.Errorf("unexpected call %s with %v", tools.called, tools.args)
+	}
+
+	tools.result = &mcp.CallResult{Content: []mcp.Content{{Type: "text", Text: "bad query"}}, IsError: true}
+	if res := r.executeAction(context.Background(), action, actx); res.Status != "error" || res.Message != "bad query" {
+		t.Errorf("expected the tool error to be reported, got %s: %s", res.Status, res.Message)
+	}
+
+	tools.err = errors.New("arguments.q is required")
+	if res := r.executeAction(context.Background(), action, actx); res.Status != "error" || res.Message != "arguments.q is required" {
+		t.Errorf("expected the validation error, got %s: %s", res.Status, res.Message)
+	}
+
+	if err := validateAction(Action{Type: ActionMCPCall, MCPServer: "search"}); err == nil {
+		t.Error("expected mcp_call without a tool to be invalid")
+	}
+}
@@ -0,0 +1,95 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultInboxMessages = 20
+	maxInboxMessages     = 100
+)
+
+// MessageInbox reads the messages other agents have sent to an agent
+type MessageInbox interface {
+	// ReadInbox returns the agent's unacknowledged messages, oldest first,
+	// and acknowledges them. An empty beadID reads every thread.
+	ReadInbox(ctx context.Context, agentID, beadID string, limit int) ([]map[string]interface{}, error)
+}
+
+func (r *Router) handleReadAgentMessages(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Inbox == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "message inbox not configured"}
+	}
+	if actx.AgentID == "" {
+		return Result{ActionType: action.Type, Status: "error", Message: "agent id is required to read messages"}
+	}
+
+	limit := action.Limit
+	if limit <= 0 {
+		limit = defaultInboxMessages
+	}
+	if limit > maxInboxMessages {
+		limit = maxInboxMessages
+	}
+
+	messages, err := r.Inbox.ReadInbox(ctx, actx.AgentID, action.BeadID, limit)
+	if err != nil {
+		return Result{ActionType: action.Type, Status: "error", Message: fmt.Sprintf("failed to read messages: %v", err)}
+	}
+
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    fmt.Sprintf("%d new message(s)", len(messages)),
+		Metadata: map[string]interface{}{
+			"messages": messages,
+			"count":    len(messages),
+			"bead_id":  action.BeadID,
+		},
+	}
+}
+
+// messagePayload returns the payload for an outgoing message, tagged with
+// the sender's bead so the message is threaded under it
+func messagePayload(payload map[string]interface{}, beadID string) map[string]interface{} {
+	if beadID == "" {
+		return payload
+	}
+	if _, ok := payload["bead_id"]; ok {
+		return payload
+	}
+	tagged := make(map[string]interface{}, len(payload)+1)
+	for k, v := range payload {
+		tagged[k] = v
+	}
+	tagged["bead_id"] = beadID
+	return tagged
+}
+
+func formatAgentMessages(sb *strings.Builder, r Result) {
+	messages, _ := r.Metadata["messages"].([]map[string]interface{})
+	if len(messages) == 0 {
+		sb.WriteString("No new messages.\n")
+		return
+	}
+	for _, msg := range messages {
+		sb.WriteString(fmt.Sprintf("- **%v** from `%v`", msg["type"], msg["from_agent_id"]))
+		if bead, _ := msg["bead_id"].(string); bead != "" {
+			sb.WriteString(fmt.Sprintf(" on `%s`", bead))
+		}
+		if subject, _ := msg["subject"].(string); subject != "" {
+			sb.WriteString(": " + subject)
+		}
+		sb.WriteString("\n")
+		if body, _ := msg["body"].(string); body != "" {
+			sb.WriteString("  " + strings.ReplaceAll(strings.TrimSpace(body), "\n", "\n  ") + "\n")
+		}
+		if id, _ := msg["message_id"].(string); id != "" {
+			if rr, _ := msg["requires_response"].(bool); rr {
+				sb.WriteString(fmt.Sprintf("  (reply with send_agent_message, message_payload.in_reply_to = %q)\n", id))
+			}
+		}
+	}
+}
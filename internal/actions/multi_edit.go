@@ -0,0 +1,108 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/formatter"
+)
+
+// maxEditDiffBytes caps the diff returned by line-range edits and multi_edit
+const maxEditDiffBytes = 16 * 1024
+
+// EditOp is one change in a multi_edit: OLD text replaced by NEW, or, when
+// StartLine is set, a line range replaced by NEW
+type EditOp struct {
+	OldText   string `json:"old_text,omitempty"`
+	NewText   string `json:"new_text,omitempty"`
+	StartLine int    `json:"start_line,omitempty"` // 1-based
+	EndLine   int    `json:"end_line,omitempty"`   // Inclusive; defaults to start_line
+}
+
+// ReplaceLines replaces lines start through end (1-based, inclusive) of
+// content with newText. An empty newText deletes the lines.
+func ReplaceLines(content string, start, end int, newText string) (string, error) {
+	if end == 0 {
+		end = start
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if start < 1 || end < start || end > len(lines) {
+		return "", fmt.Errorf("line range %d-%d is outside the file's %d lines", start, end, len(lines))
+	}
+	if newText != "" && !strings.HasSuffix(newText, "\n") && strings.HasSuffix(lines[end-1], "\n") {
+		newText += "\n"
+	}
+	return strings.Join(lines[:start-1], "") + newText + strings.Join(lines[end:], ""), nil
+}
+
+// applyEdit applies one edit to content, returning the new content and the
+// strategy that located it
+func applyEdit(content, path string, op EditOp) (string, string, error) {
+	if op.StartLine > 0 {
+		updated, err := ReplaceLines(content, op.StartLine, op.EndLine, op.NewText)
+		return updated, "line_range", err
+	}
+	if op.OldText == "" {
+		return "", "", fmt.Errorf("edit needs old_text or start_line")
+	}
+	updated, matched, strategy := MatchAndReplace(content, op.OldText, op.NewText)
+	if !matched {
+		return "", "", fmt.Errorf("OLD text not found in %s (tried exact, line-trimmed, whitespace-normalized, indentation-flexible, block-anchor matching). Re-read the file with ACTION: READ and copy the exact text.", path)
+	}
+	return updated, strategy, nil
+}
+
+// applyFileEdits applies edits to one file in order, each to the result of
+// the ones before it, and writes the file only if every edit applies
+func (r *Router) applyFileEdits(ctx context.Context, actionType, path string, edits []EditOp, actx ActionContext) Result {
+	if r.Files == nil {
+		return Result{ActionType: actionType, Status: "error", Message: "file manager not configured"}
+	}
+	res, err := r.Files.ReadFile(ctx, actx.ProjectID, path)
+	if err != nil {
+		return Result{ActionType: actionType, Status: "error", Message: fmt.Sprintf("cannot read %s: %v", path, err)}
+	}
+
+	content := res.Content
+	strategies := make([]string, 0, len(edits))
+	for i, op := range edits {
+		updated, strategy, err := applyEdit(content, path, op)
+		if err != nil {
+			message := err.Error()
+			if len(edits) > 1 {
+				message = fmt.Sprintf("edit %d of %d failed, so none were written: %v", i+1, len(edits), err)
+			}
+			return Result{ActionType: actionType, Status: "error", Message: message,
+				Metadata: map[string]interface{}{"path": path, "failed_edit": i + 1}}
+		}
+		content = updated
+		strategies = append(strategies, strategy)
+	}
+
+	writeRes, err := r.Files.WriteFile(ctx, actx.ProjectID, path, content)
+	if err != nil {
+		return Result{ActionType: actionType, Status: "error", Message: fmt.Sprintf("write failed: %v", err)}
+	}
+	diff, added, removed := formatter.Diff(path, res.Content, content)
+	if len(diff) > maxEditDiffBytes {
+		diff = diff[:maxEditDiffBytes] + "\n... (diff truncated)\n"
+	}
+	return Result{
+		ActionType: actionType,
+		Status:     "executed",
+		Message:    fmt.Sprintf("edited %s: %d edit(s), +%d -%d lines", path, len(edits), added, removed),
+		Metadata: map[string]interface{}{
+			"path":             writeRes.Path,
+			"bytes_written":    writeRes.BytesWritten,
+			"edits":            len(edits),
+			"match_strategies": strategies,
+			"diff":             diff,
+			"lines_added":      added,
+			"lines_removed":    removed,
+		},
+	}
+}
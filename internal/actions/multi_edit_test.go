@@ -0,0 +1,132 @@
+package actions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+)
+
+func TestReplaceLines(t *testing.T) {
+	content := "one\ntwo\nthree\nfour\n"
+	tests := []struct {
+		name       string
+		start, end int
+		newText    string
+		want       string
+	}{
+		{"single line", 2, 0, "TWO", "one\nTWO\nthree\nfour\n"},
+		{"range", 2, 3, "middle\n", "one\nmiddle\nfour\n"},
+		{"grow", 1, 1, "zero\none", "zero\none\ntwo\nthree\nfour\n"},
+		{"delete", 3, 4, "", "one\ntwo\n"},
+		{"last line", 4, 4, "end", "one\ntwo\nthree\nend\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReplaceLines(content, tt.start, tt.end, tt.newText)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got, _ := ReplaceLines("a\nb", 2, 2, "c"); got != "a\nc" {
+		t.Errorf("expected a missing final newline to stay missing, got %q", got)
+	}
+	for _, r := range [][2]int{{0, 1}, {3, 2}, {4, 5}} {
+		if _, err := ReplaceLines("a\nb\nc\n", r[0], r[1], "x"); err == nil {
+			t.Errorf("expected range %v to be rejected", r)
+		}
+	}
+}
+
+func TestRouter_MultiEdit(t *testing.T) {
+	dir := t.TempDir()
+	original := "package main\n\nfunc a() int { return 1 }\n\nfunc b() int { return 2 }\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Router{Files: files.NewManager(toolrun.StaticWorkDir(dir))}
+	read := func() string {
+		data, err := os.ReadFile(filepath.Join(dir, "main.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// One edit doesn't match, so none are written
+	result := r.executeAction(context.Background(), Action{Type: ActionMultiEdit, Path: "main.go", Edits: []EditOp{
+		{OldText: "return 1", NewText: "return 10"},
+		{OldText: "return 3", NewText: "return 30"},
+	}}, ActionContext{})
+	if result.Status != "error" || !strings.Contains(result.Message, "edit 2 of 2") || result.Metadata["failed_edit"] != 2 {
+		t.Fatalf("expected edit 2 to fail, got %+v", result)
+	}
+	if read() != original {
+		t.Fatal("expected the file to be unchanged after a failed multi_edit")
+	}
+
+	result = r.executeAction(context.Background(), Action{Type: ActionMultiEdit, Path: "main.go", Edits: []EditOp{
+		{OldText: "return 1", NewText: "return 10"},
+		{StartLine: 5, NewText: "func b() int { return 20 }"},
+	}}, ActionContext{})
+	if result.Status != "executed" {
+		t.Fatalf("expected executed, got %s: %s", result.Status, result.Message)
+	}
+	if got := read(); !strings.Contains(got, "return 10") || !strings.Contains(got, "return 20") {
+		t.Errorf("expected both edits, got:\n%s", got)
+	}
+	diff, _ := result.Metadata["diff"].(string)
+	if !strings.Contains(diff, "-func a() int { return 1 }") || !strings.Contains(diff, "+func b() int { return 20 }") {
+		t.Errorf("expected a combined diff, got:\n%s", diff)
+	}
+	if strategies, _ := result.Metadata["match_strategies"].([]string); len(strategies) != 2 || strategies[1] != "line_range" {
+		t.Errorf("unexpected strategies %v", result.Metadata["match_strategies"])
+	}
+
+	// A line-range edit_code
+	result = r.executeAction(context.Background(), Action{Type: ActionEditCode, Path: "main.go", StartLine: 1, NewText: "package app"}, ActionContext{})
+	if result.Status != "executed" || !strings.HasPrefix(read(), "package app\n") {
+		t.Errorf("expected line 1 to be replaced, got %+v", result)
+	}
+}
+
+func TestParseEditVariants(t *testing.T) {
+	env, err := ParseTextAction("ACTION: EDIT main.go 3-4\nNEW:\n<<<\nfunc a() {}\n>>>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := env.Actions[0]; a.Type != ActionEditCode || a.StartLine != 3 || a.EndLine != 4 || a.NewText != "func a() {}" {
+		t.Errorf("unexpected line-range edit %+v", a)
+	}
+
+	env, err = ParseTextAction("ACTION: EDIT main.go\nOLD:\n<<<\na\n>>>\nNEW:\n<<<\nb\n>>>\nOLD:\n<<<\nc\n>>>\nNEW:\n<<<\nd\n>>>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := env.Actions[0]; a.Type != ActionMultiEdit || len(a.Edits) != 2 || a.Edits[1].OldText != "c" || a.Edits[1].NewText != "d" {
+		t.Errorf("unexpected multi_edit %+v", a)
+	}
+	if _, err := ParseTextAction("ACTION: EDIT main.go\n<<<\na\n>>>\n<<<\nb\n>>>\n<<<\nc\n>>>"); err == nil {
+		t.Error("expected an unpaired block to be rejected")
+	}
+
+	env, err = ParseSimpleJSON([]byte(`{"action": "edit", "path": "main.go", "edits": [{"old": "a", "new": "b"}, {"start_line": 2, "new": "c"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := env.Actions[0]; a.Type != ActionMultiEdit || len(a.Edits) != 2 || a.Edits[1].StartLine != 2 {
+		t.Errorf("unexpected simple multi_edit %+v", a)
+	}
+	if _, err := ParseSimpleJSON([]byte(`{"action": "edit", "path": "main.go", "edits": [{"new": "c"}]}`)); err == nil {
+		t.Error("expected an edit without old or start_line to be rejected")
+	}
+}
@@ -0,0 +1,69 @@
+package actions
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jordanhubbard/loom/internal/features"
+)
+
+// readOnlyActions have no side effects and don't share per-project state
+// that would need ordering (git and LSP calls are left out: they contend on
+// the index and the language server), so runs of them may execute
+// concurrently
+var readOnlyActions = map[string]bool{
+	ActionReadCode:   true,
+	ActionReadFile:   true,
+	ActionReadTree:   true,
+	ActionSearchText: true,
+	ActionReadResult: true,
+	ActionJobStatus:  true,
+	ActionJobLogs:    true,
+}
+
+// parallelEnabled reports whether the parallel_actions flag is on for the
+// project
+func (r *Router) parallelEnabled(actx ActionContext) bool {
+	return r.Features != nil && r.Features.Enabled(features.ParallelActions, actx.ProjectID)
+}
+
+// executeReadRun runs the read-only actions starting at start concurrently
+// and returns their results by index. Runs shorter than two actions, and
+// actions that already have a result, are left to the caller.
+func (r *Router) executeReadRun(ctx context.Context, env *ActionEnvelope, start int, actx ActionContext, done map[int]Result) map[int]Result {
+	end := start
+	for end < len(env.Actions) && readOnlyActions[env.Actions[end].Type] {
+		if _, ok := done[end]; ok {
+			break
+		}
+		end++
+	}
+	if end-start < 2 {
+		return nil
+	}
+
+	results := make(map[int]Result, end-start)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := start; i < end; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result := r.executeWithTimeout(withProgressScope(ctx, i, len(env.Actions)), env.Actions[i], actx)
+			result.Metadata = withMetadata(result.Metadata, "parallel", true)
+			mu.Lock()
+			results[i] = result
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+func withMetadata(metadata map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata[key] = value
+	return metadata
+}
@@ -0,0 +1,88 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/files"
+)
+
+type staticGate map[string]bool
+
+func (g staticGate) Enabled(name, projectID string) bool { return g[name] }
+
+// barrierFileManager blocks reads until `want` of them are in flight, so
+// sequential reads time out
+type barrierFileManager struct {
+	mockFileManager
+	want    int
+	mu      sync.Mutex
+	arrived int
+	release chan struct{}
+}
+
+func (m *barrierFileManager) ReadFile(ctx context.Context, projectID, path string) (*files.FileResult, error) {
+	m.mu.Lock()
+	m.arrived++
+	if m.arrived == m.want {
+		close(m.release)
+	}
+	m.mu.Unlock()
+	select {
+	case <-m.release:
+		return &files.FileResult{Path: path, Content: "content", Size: 7}, nil
+	case <-time.After(time.Second):
+		return nil, errors.New("reads did not run concurrently")
+	}
+}
+
+func TestRouter_Execute_ParallelReads(t *testing.T) {
+	fm := &barrierFileManager{want: 2, release: make(chan struct{})}
+	r := &Router{Files: fm, Features: staticGate{"parallel_actions": true}}
+	env := &ActionEnvelope{Actions: []Action{
+		{Type: ActionReadFile, Path: "a.go"},
+		{Type: ActionReadFile, Path: "b.go"},
+		{Type: ActionWriteFile, Path: "c.go", Content: "x"},
+		{Type: ActionReadTree, Path: "."},
+	}}
+
+	results, err := r.Execute(context.Background(), env, ActionContext{ProjectID: "proj-1"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	for i, want := range []string{ActionReadFile, ActionReadFile, ActionWriteFile, ActionReadTree} {
+		if results[i].ActionType != want || results[i].Status != "executed" {
+			t.Errorf("result %d = %+v, want executed %s", i, results[i], want)
+		}
+	}
+	if results[0].Metadata["parallel"] != true || results[1].Metadata["parallel"] != true {
+		t.Errorf("expected the reads to be marked parallel: %v %v", results[0].Metadata, results[1].Metadata)
+	}
+	// A single read after a write runs on its own
+	if _, ok := results[3].Metadata["parallel"]; ok {
+		t.Errorf("expected a lone read not to be batched, got %v", results[3].Metadata)
+	}
+}
+
+func TestRouter_Execute_ParallelReadsFlagOff(t *testing.T) {
+	r := &Router{Files: &mockFileManager{}, Features: staticGate{}}
+	env := &ActionEnvelope{Actions: []Action{
+		{Type: ActionReadFile, Path: "a.go"},
+		{Type: ActionReadFile, Path: "b.go"},
+	}}
+	results, err := r.Execute(context.Background(), env, ActionContext{ProjectID: "proj-1"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for _, res := range results {
+		if _, ok := res.Metadata["parallel"]; ok {
+			t.Errorf("expected sequential execution with the flag off, got %v", res.Metadata)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/policy"
+)
+
+// PolicyEvaluator decides whether an action may run
+type PolicyEvaluator interface {
+	Evaluate(in policy.Input) policy.Decision
+}
+
+// PolicyFacts supplies the policy inputs an action doesn't carry
+type PolicyFacts interface {
+	AgentRole(agentID string) string
+	AgentSpendToday(ctx context.Context, agentID string) float64
+}
+
+// ApprovalStatus is the state of an approval requested by a policy
+type ApprovalStatus string
+
+const (
+	ApprovalPending ApprovalStatus = "pending"
+	ApprovalGranted ApprovalStatus = "approved"
+	ApprovalDenied  ApprovalStatus = "denied"
+)
+
+// ActionApprover files approval requests for actions a policy holds back,
+// returning the request's status and ID. Asking again for the same action
+// returns the existing request; a granted approval covers one execution.
+type ActionApprover interface {
+	RequestActionApproval(ctx context.Context, actx ActionContext, action Action, decision policy.Decision) (ApprovalStatus, string, error)
+}
+
+// checkPolicy evaluates the action against the policies and returns the
+// result to report instead of running it, or nil when it may run
+func (r *Router) checkPolicy(ctx context.Context, action Action, actx ActionContext) *Result {
+	if r.Policies == nil {
+		return nil
+	}
+	in := policy.Input{
+		ProjectID:  actx.ProjectID,
+		AgentID:    actx.AgentID,
+		BeadID:     actx.BeadID,
+		ActionType: action.Type,
+		Paths:      TouchedPaths(action),
+		Command:    actionCommand(action),
+		Time:       time.Now(),
+	}
+	if r.PolicyFacts != nil && actx.AgentID != "" {
+		in.Role = r.PolicyFacts.AgentRole(actx.AgentID)
+		in.CostUSD = r.PolicyFacts.AgentSpendToday(ctx, actx.AgentID)
+	}
+
+	decision := r.Policies.Evaluate(in)
+	switch decision.Effect {
+	case policy.Deny:
+		return policyDenied(action, decision, "denied by policy: "+decision.Reason)
+	case policy.RequireApproval:
+		if r.Approvals == nil {
+			return policyDenied(action, decision, "requires approval, but no approver is configured: "+decision.Reason)
+		}
+		status, approvalID, err := r.Approvals.RequestActionApproval(ctx, actx, action, decision)
+		if err != nil {
+			result := errorResult(action.Type, err)
+			return &result
+		}
+		switch status {
+		case ApprovalGranted:
+			return nil
+		case ApprovalDenied:
+			result := policyDenied(action, decision, fmt.Sprintf("approval denied in decision %s: %s", approvalID, decision.Reason))
+			result.Metadata["decision_id"] = approvalID
+			return result
+		}
+		return &Result{
+			ActionType: action.Type,
+			Status:     "approval_required",
+			Message: fmt.Sprintf("%s needs approval (%s). Decision %s is pending; continue with other work and retry the action once it is approved.",
+				action.Type, decision.Reason, approvalID),
+			Metadata: map[string]interface{}{
+				"decision_id":  approvalID,
+				"policy_rule":  decision.Rule,
+				"policy_scope": decision.Scope,
+			},
+		}
+	}
+	return nil
+}
+
+func policyDenied(action Action, decision policy.Decision, message string) *Result {
+	return &Result{
+		ActionType: action.Type,
+		Status:     "error",
+		Message:    message,
+		Code:       apperr.CodePolicyDenied,
+		Metadata: map[string]interface{}{
+			"error_type":   "policy_denied",
+			"policy_rule":  decision.Rule,
+			"policy_scope": decision.Scope,
+		},
+	}
+}
+
+// actionCommand returns the shell command or terminal input an action runs
+func actionCommand(action Action) string {
+	switch {
+	case action.Command != "":
+		return action.Command
+	case action.BuildCommand != "":
+		return action.BuildCommand
+	}
+	return action.Input
+}
+
+// TouchedPaths returns the project-relative files an action reads or
+// changes, as far as the action itself names them
+func TouchedPaths(action Action) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	add := func(p string) {
+		if p == "" {
+			return
+		}
+		p = path.Clean(strings.TrimPrefix(p, "./"))
+		if p != "." && !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	add(action.Path)
+	add(action.SourcePath)
+	add(action.TargetPath)
+	if action.SourcePath != "" && action.NewName != "" {
+		add(path.Join(path.Dir(action.SourcePath), action.NewName))
+	}
+	for _, f := range action.Files {
+		add(f)
+	}
+	for _, p := range PatchPaths(action.Patch) {
+		add(p)
+	}
+	return paths
+}
+
+// PatchPaths reads the files a unified diff touches from its ---/+++ lines
+func PatchPaths(patch string) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, line := range strings.Split(patch, "\n") {
+		var p string
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			p = strings.TrimPrefix(line, "--- ")
+		case strings.HasPrefix(line, "+++ "):
+			p = strings.TrimPrefix(line, "+++ ")
+		default:
+			continue
+		}
+		if i := strings.IndexByte(p, '\t'); i >= 0 {
+			p = p[:i]
+		}
+		p = strings.TrimSpace(p)
+		if p == "/dev/null" {
+			continue
+		}
+		if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+			p = p[2:]
+		}
+		if p = path.Clean(strings.TrimPrefix(p, "./")); p != "." && p != "" && !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
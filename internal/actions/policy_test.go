@@ -0,0 +1,100 @@
+package actions
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/policy"
+)
+
+type recordingPolicy struct {
+	decision policy.Decision
+	last     policy.Input
+}
+
+func (p *recordingPolicy) Evaluate(in policy.Input) policy.Decision {
+	p.last = in
+	return p.decision
+}
+
+type staticFacts struct{}
+
+func (staticFacts) AgentRole(agentID string) string { return "engineer" }
+
+func (staticFacts) AgentSpendToday(ctx context.Context, agentID string) float64 { return 12.5 }
+
+type mockApprover struct {
+	status ApprovalStatus
+	calls  int
+}
+
+func (m *mockApprover) RequestActionApproval(ctx context.Context, actx ActionContext, action Action, decision policy.Decision) (ApprovalStatus, string, error) {
+	m.calls++
+	return m.status, "bd-dec-1", nil
+}
+
+func TestRouter_Policy(t *testing.T) {
+	actx := ActionContext{AgentID: "agent-1", BeadID: "bead-1", ProjectID: "proj-1"}
+	action := Action{Type: ActionRunCommand, Command: "rm -rf build"}
+
+	t.Run("deny", func(t *testing.T) {
+		exec := &mockCommandExecutor{}
+		p := &recordingPolicy{decision: policy.Decision{Effect: policy.Deny, Scope: "global", Rule: "no-rm", Reason: "no rm"}}
+		r := &Router{Commands: exec, Policies: p, PolicyFacts: staticFacts{}}
+		result := r.executeAllowedAction(context.Background(), action, actx)
+		if result.Status != "error" || result.Code != apperr.CodePolicyDenied || result.Metadata["policy_rule"] != "no-rm" {
+			t.Errorf("unexpected result %+v", result)
+		}
+		if p.last.Role != "engineer" || p.last.CostUSD != 12.5 || p.last.Command != "rm -rf build" || p.last.Time.IsZero() {
+			t.Errorf("unexpected policy input %+v", p.last)
+		}
+	})
+
+	t.Run("approval", func(t *testing.T) {
+		exec := &mockCommandExecutor{}
+		approver := &mockApprover{status: ApprovalPending}
+		p := &recordingPolicy{decision: policy.Decision{Effect: policy.RequireApproval, Scope: "proj-1", Rule: "rm", Reason: "destructive"}}
+		r := &Router{Commands: exec, Policies: p, Approvals: approver}
+
+		result := r.executeAllowedAction(context.Background(), action, actx)
+		if result.Status != "approval_required" || result.Metadata["decision_id"] != "bd-dec-1" {
+			t.Errorf("expected a pending approval, got %+v", result)
+		}
+		if exec.lastReq.Command != "" {
+			t.Error("expected the command not to run while approval is pending")
+		}
+
+		approver.status = ApprovalGranted
+		if result := r.executeAllowedAction(context.Background(), action, actx); result.Status != "executed" {
+			t.Errorf("expected the approved action to run, got %+v", result)
+		}
+
+		approver.status = ApprovalDenied
+		if result := r.executeAllowedAction(context.Background(), action, actx); result.Code != apperr.CodePolicyDenied {
+			t.Errorf("expected a denied approval to be a policy denial, got %+v", result)
+		}
+
+		r.Approvals = nil
+		if result := r.executeAllowedAction(context.Background(), action, actx); result.Code != apperr.CodePolicyDenied {
+			t.Errorf("expected a denial without an approver, got %+v", result)
+		}
+	})
+}
+
+func TestTouchedPaths(t *testing.T) {
+	action := Action{
+		Type:  ActionApplyPatch,
+		Path:  "./a.go",
+		Patch: "--- a/b.go\n+++ b/b.go\n@@ -1 +1 @@\n--- /dev/null\n+++ b/c/d.go\n",
+	}
+	want := []string{"a.go", "b.go", "c/d.go"}
+	if got := TouchedPaths(action); !reflect.DeepEqual(got, want) {
+		t.Errorf("TouchedPaths() = %v, want %v", got, want)
+	}
+	rename := Action{Type: ActionRenameFile, SourcePath: "pkg/old.go", NewName: "new.go"}
+	if got := TouchedPaths(rename); !reflect.DeepEqual(got, []string{"pkg/old.go", "pkg/new.go"}) {
+		t.Errorf("unexpected rename paths %v", got)
+	}
+}
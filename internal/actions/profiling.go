@@ -0,0 +1,79 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/profiling"
+)
+
+// handleProfileAction profiles the project's test package or binary and
+// returns the top functions of each profile. The pprof files are kept on
+// the bead for anyone picking up the performance work.
+func (r *Router) handleProfileAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Profiler == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "profiling not configured"}
+	}
+
+	path := action.Path
+	if path == "" {
+		_, sp, err := r.resolveSubproject(action, actx)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		if sp != nil {
+			path = sp.Path
+		}
+	}
+
+	res, err := r.Profiler.Run(ctx, actx.ProjectID, actx.BeadID, profiling.Options{
+		Path:        path,
+		TestPattern: action.TestPattern,
+		Bench:       action.Bench,
+		Command:     action.ProfileCommand,
+		Kinds:       action.Profiles,
+		Top:         action.Top,
+		Timeout:     time.Duration(action.TimeoutSeconds) * time.Second,
+	})
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+
+	var ids, kinds []string
+	for _, p := range res.Profiles {
+		ids = append(ids, p.ID)
+		kinds = append(kinds, p.Kind)
+	}
+	var message string
+	switch {
+	case len(res.Profiles) == 0 && res.Error != "":
+		message = res.Error
+	case len(res.Profiles) == 0:
+		message = fmt.Sprintf("%s wrote no profiles", res.Target)
+	default:
+		message = fmt.Sprintf("captured %s profiles of %s", strings.Join(kinds, " and "), res.Target)
+		if actx.BeadID != "" {
+			message += " and attached them to bead " + actx.BeadID
+		}
+		if res.Error != "" {
+			message += "; " + res.Error
+		}
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    message,
+		Metadata: map[string]interface{}{
+			"success":     res.Success,
+			"target":      res.Target,
+			"profiles":    res.Profiles,
+			"profile_ids": ids,
+			"notes":       res.Notes,
+			"output":      res.Output,
+			"error":       res.Error,
+			"duration":    res.Duration,
+		},
+	}
+}
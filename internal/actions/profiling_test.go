@@ -0,0 +1,63 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/profiling"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeProfiler struct {
+	opts   profiling.Options
+	beadID string
+	result *profiling.Result
+}
+
+func (f *fakeProfiler) Run(_ context.Context, _, beadID string, opts profiling.Options) (*profiling.Result, error) {
+	f.opts, f.beadID = opts, beadID
+	return f.result, nil
+}
+
+func TestRouterProfileProject(t *testing.T) {
+	fake := &fakeProfiler{result: &profiling.Result{
+		Success: true,
+		Target:  "./store",
+		Profiles: []*models.Profile{{
+			ID: "prof-1", Kind: models.ProfileCPU, Total: "1.10s",
+			Top: []models.ProfileEntry{{Function: "example.com/app/store.(*Index).Put", Flat: "0.50s", FlatPercent: 45.45, Cum: "0.60s", CumPercent: 54.55}},
+		}},
+		Notes: []string{"no heap profile was written"},
+	}}
+	r := &Router{Profiler: fake}
+
+	action := Action{Type: ActionProfileProject, Path: "store", Bench: "Put", Profiles: []string{"cpu"}, Top: 5}
+	res := r.executeAction(context.Background(), action, ActionContext{ProjectID: "p", BeadID: "bead-1"})
+	if res.Status != "executed" || res.Message != "captured cpu profiles of ./store and attached them to bead bead-1" {
+		t.Fatalf("unexpected result %s: %s", res.Status, res.Message)
+	}
+	if fake.beadID != "bead-1" || fake.opts.Bench != "Put" || fake.opts.Top != 5 || len(fake.opts.Kinds) != 1 {
+		t.Errorf("options not passed through: %+v", fake.opts)
+	}
+	feedback := FormatResultsAsUserMessage([]Result{res})
+	for _, want := range []string{"**Profile: CAPTURED**", "cpu profile prof-1 (1.10s total)", "- 0.50s 45.5%  0.60s 54.5%  example.com/app/store.(*Index).Put", "no heap profile was written"} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback missing %q:\n%s", want, feedback)
+		}
+	}
+
+	fake.result = &profiling.Result{Target: ".", Error: "go test failed: exit status 1", Output: "undefined: Put"}
+	res = r.executeAction(context.Background(), Action{Type: ActionProfileProject}, ActionContext{ProjectID: "p"})
+	feedback = FormatResultsAsUserMessage([]Result{res})
+	if !strings.Contains(feedback, "**Profile: FAILED** go test failed") || !strings.Contains(feedback, "undefined: Put") {
+		t.Errorf("unexpected feedback:\n%s", feedback)
+	}
+
+	if err := Validate(&ActionEnvelope{Actions: []Action{{Type: ActionProfileProject, Profiles: []string{"block"}}}}); err == nil {
+		t.Error("expected an unsupported profile to be rejected")
+	}
+	if res := (&Router{}).executeAction(context.Background(), Action{Type: ActionProfileProject}, ActionContext{}); res.Status != "error" {
+		t.Errorf("expected an error without a profiler, got %s", res.Status)
+	}
+}
@@ -0,0 +1,76 @@
+package actions
+
+import (
+	"context"
+	"time"
+)
+
+// Progress phases reported while an envelope executes.
+const (
+	ProgressStarted   = "started"
+	ProgressOutput    = "output"
+	ProgressCompleted = "completed"
+)
+
+// ProgressEvent describes one step of an action's execution.
+type ProgressEvent struct {
+	Index      int       `json:"index"` // Position of the action in the envelope
+	Total      int       `json:"total"` // Number of actions in the envelope
+	ActionType string    `json:"action_type"`
+	Path       string    `json:"path,omitempty"`        // File the action works on, if any
+	Phase      string    `json:"phase"`                 // started, output, completed
+	Stream     string    `json:"stream,omitempty"`      // stdout or stderr for output events
+	Chunk      string    `json:"chunk,omitempty"`       // Output text for output events
+	Status     string    `json:"status,omitempty"`      // Result status for completed events
+	Message    string    `json:"message,omitempty"`     // Result message for completed events
+	DurationMs int64     `json:"duration_ms,omitempty"` // Set on completed events
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// ProgressReporter receives per-action progress while an envelope executes.
+type ProgressReporter interface {
+	ReportActionProgress(ctx context.Context, actx ActionContext, event ProgressEvent)
+}
+
+const progressKey contextKey = "actionProgress"
+
+type progressScope struct {
+	index int
+	total int
+}
+
+func withProgressScope(ctx context.Context, index, total int) context.Context {
+	return context.WithValue(ctx, progressKey, progressScope{index: index, total: total})
+}
+
+// reportProgress sends event to the router's ProgressReporter, filling in the
+// action's position from ctx. It is a no-op when no reporter is configured.
+func (r *Router) reportProgress(ctx context.Context, actx ActionContext, event ProgressEvent) {
+	if r.Progress == nil {
+		return
+	}
+	if scope, ok := ctx.Value(progressKey).(progressScope); ok {
+		event.Index = scope.index
+		event.Total = scope.total
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	r.Progress.ReportActionProgress(ctx, actx, event)
+}
+
+// commandOutputHandler returns an executor OnOutput callback that forwards
+// command output as progress events, or nil when no reporter is configured.
+func (r *Router) commandOutputHandler(ctx context.Context, actx ActionContext, actionType string) func(stream string, chunk []byte) {
+	if r.Progress == nil {
+		return nil
+	}
+	return func(stream string, chunk []byte) {
+		r.reportProgress(ctx, actx, ProgressEvent{
+			ActionType: actionType,
+			Phase:      ProgressOutput,
+			Stream:     stream,
+			Chunk:      string(chunk),
+		})
+	}
+}
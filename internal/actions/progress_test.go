@@ -0,0 +1,82 @@
+package actions
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/executor"
+)
+
+type recordingProgressReporter struct {
+	mu     sync.Mutex
+	events []ProgressEvent
+}
+
+func (r *recordingProgressReporter) ReportActionProgress(ctx context.Context, actx ActionContext, event ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func TestRouterExecute_ReportsProgress(t *testing.T) {
+	reporter := &recordingProgressReporter{}
+	router := &Router{
+		Progress: reporter,
+		Commands: &mockCommandExecutorFunc{fn: func(ctx context.Context, req executor.ExecuteCommandRequest) (*executor.ExecuteCommandResult, error) {
+			if req.OnOutput == nil {
+				t.Fatal("expected OnOutput to be set when a progress reporter is configured")
+			}
+			req.OnOutput("stdout", []byte("building...\n"))
+			return &executor.ExecuteCommandResult{ID: "cmd-1", Success: true}, nil
+		}},
+	}
+	env := &ActionEnvelope{Actions: []Action{
+		{Type: ActionRunCommand, Command: "make build"},
+		{Type: ActionDone},
+	}}
+
+	if _, err := router.Execute(context.Background(), env, ActionContext{BeadID: "bead-1"}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	want := []struct {
+		index int
+		phase string
+	}{
+		{0, ProgressStarted},
+		{0, ProgressOutput},
+		{0, ProgressCompleted},
+		{1, ProgressStarted},
+		{1, ProgressCompleted},
+	}
+	if len(reporter.events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(reporter.events), len(want), reporter.events)
+	}
+	for i, w := range want {
+		ev := reporter.events[i]
+		if ev.Index != w.index || ev.Phase != w.phase || ev.Total != 2 {
+			t.Errorf("event %d = {index %d, phase %s, total %d}, want {index %d, phase %s, total 2}",
+				i, ev.Index, ev.Phase, ev.Total, w.index, w.phase)
+		}
+	}
+	if reporter.events[1].Chunk != "building...\n" || reporter.events[1].Stream != "stdout" {
+		t.Errorf("unexpected output event: %+v", reporter.events[1])
+	}
+	if reporter.events[2].Status != "executed" {
+		t.Errorf("completed status = %q, want executed", reporter.events[2].Status)
+	}
+}
+
+func TestRouterExecute_NoProgressReporter(t *testing.T) {
+	cmd := &mockCommandExecutor{}
+	router := &Router{Commands: cmd}
+	env := &ActionEnvelope{Actions: []Action{{Type: ActionRunCommand, Command: "ls"}}}
+
+	if _, err := router.Execute(context.Background(), env, ActionContext{}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if cmd.lastReq.OnOutput != nil {
+		t.Error("expected OnOutput to be nil without a progress reporter")
+	}
+}
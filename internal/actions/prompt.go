@@ -22,23 +22,58 @@ The response must be a single JSON object with this shape:
 - read_file / read_code: Read file contents. Required: path
 - write_file: Write entire file contents. Required: path, content (PREFERRED for code changes)
 - edit_code / apply_patch: Apply unified diff patch. Required: path, patch (unified diff format)
+- edit_code (line range): Replace lines start_line-end_line (1-based, inclusive) with new_text. Required: path, start_line, new_text. Optional: end_line (defaults to start_line)
+- multi_edit: Apply several edits to one file, all or nothing. Required: path, edits (list of {old_text, new_text} or {start_line, end_line, new_text}, applied in order; line numbers count earlier edits)
+- add_import: Add an import to a Go file (no-op if present). Required: path, import_path. Optional: import_name (alias)
+- insert_function_after: Insert Go function(s) after a declaration. Required: path, content (the functions). Optional: symbol (function, Type.Method or type to insert after; default end of file)
+- add_struct_field: Add field(s) to a Go struct. Required: path, symbol (struct name), content (field lines as written inside the struct, e.g. "Timeout time.Duration")
+  (The Go edits above are gofmt'd and refused if they would not parse; prefer them to edit_code for these changes in Go files.)
 - read_tree: List directory structure. Required: path. Optional: max_depth, limit
-- search_text: Search for text/regex in files. Required: query. Optional: path, limit
+- search_text: Search for text/regex in files. Required: query. Optional: path, limit (defaults to your subproject in a monorepo)
 - move_file: Move/rename file. Required: source_path, target_path
 - delete_file: Delete a file. Required: path
 - rename_file: Rename a file. Required: source_path, new_name
 
 ### Build & Test
-- build_project: Build the project. Optional: build_target, build_command, framework, timeout_seconds
-- run_tests: Run test suite. Optional: test_pattern, framework, timeout_seconds
-- run_linter: Run linter. Optional: files, framework, timeout_seconds
+- build_project: Build the project. Optional: build_target, build_command, framework, timeout_seconds, subproject
+- analyze_impact: Before verifying a change, find the packages it affects, the tests to run and any public API it added, removed or changed, instead of running the whole suite. Optional: files (changed paths), symbols (changed declarations), from_ref (default HEAD; without files or symbols the changes since it are used)
+- run_tests: Run test suite. Optional: test_pattern, framework, timeout_seconds, subproject, test_mode ("smart" runs only the tests covering files, default the changes since HEAD; the full suite runs when a safety run is due and may be required before merge)
+- run_linter: Run linter. Optional: files, framework, timeout_seconds, subproject
+  (In a monorepo these run only the subproject you are working in; pass subproject to target another one.)
+- run_formatter: Format code and write the result back (gofmt, goimports, prettier, black, rustfmt). Optional: files or path (defaults to changed files), framework (formatter; chosen by file extension)
+- run_security_scan: Run security scanners (gosec, semgrep, npm-audit, trivy) and report findings by severity. Optional: scanners, path or subproject, create_beads (file beads for new high/critical findings)
+- check_dependencies: List outdated dependencies (go, npm, pip) with changelog links, upgrade commands and breaking-change flags. Optional: ecosystems, path or subproject, create_beads, bead_mode (per_dependency or batch)
+- build_image: Build a container image with docker or podman and return the build log. Optional: image (tag), dockerfile, path (build context; defaults to your subproject), build_args, build_target (stage), timeout_seconds
+- run_container: Smoke-test an image: run it with ports on localhost, poll an HTTP health check or wait for it to exit, then return its logs and exit status and remove it. Required: image. Optional: container_command, env, ports ("8080:80" or "80"), health_path, health_port, timeout_seconds
+- deploy_staging: Deploy your bead's build to its own Kubernetes staging namespace and wait for the rollout; reports pod health and logs. Optional: image (defaults to the project's staging image), timeout_seconds
+- staging_status: Rollout status, pod health and recent logs of your bead's staging namespace
+- teardown_staging: Delete your bead's staging namespace (also done when the bead closes)
+- http_check: Verify a running service responds correctly: send a request and check the status, body text and JSON values, retrying with backoff while it starts. Required: url. Optional: method, headers, body, expect_status (list, default any 2xx), expect_body, expect_json (dotted path to value, e.g. {"data.items.0.id": 7}), retries, timeout_seconds
+- browser: Check a web UI in a headless browser: open url, run steps in order and return each step's outcome, console errors and screenshots (attached to your bead; you see them with the result). Required: url. Optional: steps, browser, timeout_seconds. Steps: {"action": "navigate", "url"} (absolute or a path), click/assert_visible {"selector"}, fill/select {"selector", "value"}, press {"value": key, "selector"?}, wait_for {"selector" or "text"}, assert_text {"text", "selector"?}, assert_url {"text"}, screenshot {"name"?, "selector"?, "full_page"?}. Steps stop at the first failure, which is screenshotted
+- db_create: Create a throwaway database for your bead, replacing any it had, to test schema changes. Optional: engine (sqlite, the default, or postgres)
+- db_migrate: Run migrations against your bead's database and return the resulting schema. Required: path (directory of .sql, .up.sql/.down.sql or goose files) or command (the project's migration tool, run with DATABASE_URL). Optional: down (revert applied migrations)
+- db_load_fixtures: Load fixtures in one transaction: .sql files, or .yaml/.json files mapping table names to lists of rows. Required: path (file or directory) or files
+- db_assert: Run queries against your bead's database and check the results. Required: assertions (list of {"query", "name"?, "expect_count"?, "expect_rows"? (list of column: value objects, in order), "expect_value"? (first column of the first row)}; without expectations the rows are returned)
+- db_drop: Drop your bead's database (also done when the bead closes)
+- run_benchmarks: Run benchmarks and compare them with the baseline branch at your branch's merge base; regressions beyond the threshold hold your bead at verify and commit steps until a run comes back clean. Optional: tool (go, the default, or hyperfine), path (directory; go benchmarks its packages), bench (go -bench regexp), commands (required for hyperfine), count, threshold (percent), baseline (branch), timeout_seconds
+- profile_project: Profile a Go package's tests or benchmarks (or a binary) for CPU and heap and return each profile's top functions; the pprof files are kept on your bead. Use it before optimizing to find where time and memory go. Optional: path (Go package directory, or where profile_command runs), test_pattern (go test -run), bench (go test -bench; usually the better workload), profile_command (binary and arguments, with {cpu} and {heap} where it takes the profile output paths), profiles (cpu, heap), top, timeout_seconds
 - run_command: Execute shell command. Required: command. Optional: working_dir
+- open_session: Start an interactive terminal session (database CLI, debugger, REPL). Required: command. Optional: working_dir, timeout_seconds (idle timeout)
+- session_input: Send a line of input to a session and return new output. Required: session_id, input
+- session_read: Read new session output, waiting briefly if none. Required: session_id. Optional: timeout_seconds, limit
+- close_session: Terminate a session. Required: session_id
+- start_command: Start a long-running command (dev server, watch build) in the background and return a job_id. Required: command. Optional: working_dir, timeout_seconds
+- job_status: Check a background job's status and recent output. Required: job_id
+- job_logs: Read a background job's output. Required: job_id. Optional: offset (from a previous next_offset; negative for the tail), limit, timeout_seconds (wait for new output)
+- cancel_job: Stop a background job. Required: job_id
+- read_result: Page through the full text of a truncated result ("result r-123 ... use read_result"). Required: result_id. Optional: offset (from a previous next_offset; negative for the tail), limit
 
 ### Git Operations
 - git_status: Show working tree status
 - git_diff: Show unstaged changes
 - git_commit: Create a commit. Optional: commit_message, files
 - git_push: Push to remote. Optional: branch, set_upstream
+- check_ci: Check CI results for your pushed branch; failed jobs come with log excerpts. Optional: branch
 - git_log: View commit history. Optional: branch, max_count
 - git_fetch: Fetch from remote
 - git_checkout: Switch branches. Required: branch
@@ -46,22 +81,33 @@ The response must be a single JSON object with this shape:
 - git_revert: Revert commits. Required: commit_sha or commit_shas. Optional: reason
 - git_list_branches: List all branches
 - git_diff_branches: Diff two branches. Required: source_branch, target_branch
+- git_diff_revisions: Diff a file or directory between revisions as numbered hunks. Optional: from_ref (default HEAD), to_ref (default the working tree), path, context_lines
+- git_blame: See who last changed each line, when, and for which bead, before changing code you did not write. Required: path. Optional: start_line, end_line
 - git_bead_commits: Get commits for the current bead
+- bump_version: Bump the version in VERSION, package.json, pyproject.toml, Cargo.toml or Chart.yaml (left uncommitted). Required: bump (major, minor, patch) or version. Optional: files
+- git_changelog: Changelog of the commits since the last tag, grouped by type. Optional: from_ref, to_ref, version (heading), path (file to add it to, e.g. CHANGELOG.md)
+- git_tag: Create an annotated release tag; git_push publishes it. Required: tag. Optional: commit_message, commit_sha
 
 ### Bead Management
 - create_bead: Create a work item. Required: bead object with title, project_id
 - close_bead: Close/complete a bead. Required: bead_id. Optional: reason
 - escalate_ceo: Escalate to CEO for decision. Required: bead_id, reason
 - done: Signal that work is complete — no more actions needed. Optional: reason
+- attach_image: Look at an image in the workdir (e.g. a screenshot a failing UI test saved) and attach it to the bead. Required: path
 
 ### Code Navigation (when LSP is available)
 - find_references: Find all references. Required: path + (symbol or line+column)
 - go_to_definition: Go to symbol definition. Required: path + (symbol or line+column)
 - find_implementations: Find implementations. Required: path + (symbol or line+column)
 
+### External Tools (MCP servers configured for the project)
+- mcp_list_tools: List the tools of the project's MCP servers with their argument schemas. Optional: mcp_server
+- mcp_call: Call an MCP tool. Required: mcp_server, mcp_tool. Optional: arguments (object matching the tool's schema)
+
 ### Agent Communication
-- send_agent_message: Send message to another agent. Required: to_agent_id or to_agent_role, message_type
-- delegate_task: Delegate work to another agent. Required: delegate_to_role, task_title
+- send_agent_message: Send message to another agent. Required: to_agent_id or to_agent_role, message_type. Messages are threaded under your bead
+- read_agent_messages: Read and acknowledge unread messages sent to you. Optional: bead_id (one thread), limit
+- delegate_task: Delegate work to another agent as a child bead. Required: delegate_to_role, task_title. Optional: task_description, task_priority, to_agent_id, delegation_strategy ("continue" keeps working, "block" pauses this bead until the child closes). The child's results are added to this bead's context when it closes
 
 ## Code Change Workflow
 
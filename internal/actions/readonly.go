@@ -0,0 +1,51 @@
+package actions
+
+import "github.com/jordanhubbard/loom/internal/apperr"
+
+// ReadOnlyGate reports whether the server is read-only: Check returns the
+// error to refuse a mutating action with, or nil
+type ReadOnlyGate interface {
+	Check() error
+}
+
+// inspectionActions only look at the workspace, the repository or the
+// bead's jobs, so they keep running while the server is read-only, along
+// with readOnlyActions
+var inspectionActions = map[string]bool{
+	ActionFindReferences:      true,
+	ActionGoToDefinition:      true,
+	ActionFindImplementations: true,
+	ActionAnalyzeImpact:       true,
+	ActionGitStatus:           true,
+	ActionGitDiff:             true,
+	ActionGitLog:              true,
+	ActionGitListBranches:     true,
+	ActionGitDiffBranches:     true,
+	ActionGitBeadCommits:      true,
+	ActionGitDiffRevisions:    true,
+	ActionGitBlame:            true,
+	ActionStagingStatus:       true,
+	ActionCheckCI:             true,
+	ActionFetchPR:             true,
+	ActionMCPListTools:        true,
+	ActionWhatsNext:           true,
+}
+
+// checkReadOnly refuses actions that change anything while the server is
+// read-only
+func (r *Router) checkReadOnly(action Action) *Result {
+	if r.ReadOnly == nil || readOnlyActions[action.Type] || inspectionActions[action.Type] {
+		return nil
+	}
+	err := r.ReadOnly.Check()
+	if err == nil {
+		return nil
+	}
+	return &Result{
+		ActionType: action.Type,
+		Status:     "error",
+		Message:    err.Error(),
+		Code:       apperr.CodeReadOnly,
+		Metadata:   map[string]interface{}{"error_type": "read_only"},
+	}
+}
@@ -0,0 +1,35 @@
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/readonly"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+func TestRouter_ReadOnly(t *testing.T) {
+	actx := ActionContext{AgentID: "agent-1", BeadID: "bead-1", ProjectID: "proj-1"}
+	gate := readonly.New(config.ReadOnlyConfig{Enabled: true, Reason: "migration"})
+	exec := &mockCommandExecutor{}
+	r := &Router{Commands: exec, ReadOnly: gate}
+
+	result := r.executeAllowedAction(context.Background(), Action{Type: ActionRunCommand, Command: "make"}, actx)
+	if result.Status != "error" || result.Code != apperr.CodeReadOnly || result.Metadata["error_type"] != "read_only" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if exec.lastReq.Command != "" {
+		t.Error("the command must not run while the server is read-only")
+	}
+	for _, actionType := range []string{ActionReadFile, ActionGitDiff, ActionFindReferences} {
+		if blocked := r.checkReadOnly(Action{Type: actionType}); blocked != nil {
+			t.Errorf("expected %s to be allowed while read-only, got %+v", actionType, blocked)
+		}
+	}
+
+	gate.Set(false, "", "admin")
+	if result := r.executeAllowedAction(context.Background(), Action{Type: ActionRunCommand, Command: "make"}, actx); result.Code == apperr.CodeReadOnly {
+		t.Errorf("expected the command to run once read-only mode is off, got %+v", result)
+	}
+}
@@ -0,0 +1,214 @@
+package actions
+
+import (
+	"bytes"
+
+	"github.com/jordanhubbard/loom/internal/metrics"
+)
+
+// Repair strategies applied by DecodeLenient, recorded in metrics so prompt
+// engineering can target the most common model mistakes
+const (
+	RepairSingleQuotes      = "single_quotes"
+	RepairTrailingCommas    = "trailing_commas"
+	RepairUnquotedKeys      = "unquoted_keys"
+	RepairDuplicateEnvelope = "duplicate_envelope"
+)
+
+// recordRepair counts a repair strategy that was needed to decode a response
+var recordRepair = func(strategy string) {
+	metrics.NewMetrics().RecordActionRepair(strategy)
+}
+
+// repairJSON rewrites common LLM JSON mistakes into valid JSON: single-quoted
+// strings, trailing commas and unquoted object keys. It returns the repaired
+// payload and the strategies that changed it. Text inside double-quoted
+// strings is never modified.
+func repairJSON(payload []byte) ([]byte, []string) {
+	out := make([]byte, 0, len(payload)+16)
+	used := map[string]bool{}
+	// last is the previous significant byte written outside a string, used
+	// to tell object keys from values
+	var last byte
+
+	for i := 0; i < len(payload); i++ {
+		b := payload[i]
+		switch {
+		case b == '"':
+			end := scanString(payload, i, '"')
+			out = append(out, payload[i:end]...)
+			i = end - 1
+			last = '"'
+		case b == '\'':
+			end := scanString(payload, i, '\'')
+			out = appendSingleQuoted(out, payload[i:end])
+			i = end - 1
+			last = '"'
+			used[RepairSingleQuotes] = true
+		case b == ',':
+			if next := nextSignificant(payload, i+1); next == '}' || next == ']' {
+				used[RepairTrailingCommas] = true
+				continue
+			}
+			out = append(out, b)
+			last = b
+		case isIdentStart(b) && (last == '{' || last == ','):
+			j := i
+			for j < len(payload) && isIdentPart(payload[j]) {
+				j++
+			}
+			if nextSignificant(payload, j) == ':' {
+				out = append(out, '"')
+				out = append(out, payload[i:j]...)
+				out = append(out, '"')
+				used[RepairUnquotedKeys] = true
+			} else {
+				out = append(out, payload[i:j]...)
+			}
+			i = j - 1
+			last = 'a'
+		default:
+			out = append(out, b)
+			if !isSpace(b) {
+				last = b
+			}
+		}
+	}
+
+	var strategies []string
+	for _, s := range []string{RepairSingleQuotes, RepairTrailingCommas, RepairUnquotedKeys} {
+		if used[s] {
+			strategies = append(strategies, s)
+		}
+	}
+	return out, strategies
+}
+
+// scanString returns the index just past the string starting at payload[start],
+// or len(payload) when it is unterminated
+func scanString(payload []byte, start int, quote byte) int {
+	for i := start + 1; i < len(payload); i++ {
+		switch payload[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(payload)
+}
+
+// appendSingleQuoted converts a single-quoted string to a double-quoted one
+func appendSingleQuoted(out, s []byte) []byte {
+	out = append(out, '"')
+	body := s[1:]
+	if len(body) > 0 && body[len(body)-1] == '\'' {
+		body = body[:len(body)-1]
+	}
+	for i := 0; i < len(body); i++ {
+		switch c := body[i]; {
+		case c == '\\' && i+1 < len(body) && body[i+1] == '\'':
+			out = append(out, '\'')
+			i++
+		case c == '\\' && i+1 < len(body):
+			out = append(out, c, body[i+1])
+			i++
+		case c == '"':
+			out = append(out, '\\', '"')
+		default:
+			out = append(out, c)
+		}
+	}
+	return append(out, '"')
+}
+
+func nextSignificant(payload []byte, from int) byte {
+	for i := from; i < len(payload); i++ {
+		if !isSpace(payload[i]) {
+			return payload[i]
+		}
+	}
+	return 0
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func isIdentStart(b byte) bool {
+	return b == '_' || b == '$' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func isIdentPart(b byte) bool {
+	return isIdentStart(b) || b == '-' || (b >= '0' && b <= '9')
+}
+
+// decodeFirstEnvelope decodes each top-level JSON object in payload in turn
+// and returns the first valid envelope. Models sometimes repeat the envelope
+// or precede it with another object.
+func decodeFirstEnvelope(payload []byte) (*ActionEnvelope, int, error) {
+	var firstErr error
+	objects := 0
+	for rest := payload; ; {
+		obj, err := extractJSONObject(rest)
+		if err != nil {
+			break
+		}
+		objects++
+		env, decodeErr := DecodeStrict(obj)
+		if decodeErr == nil {
+			return env, objects, nil
+		}
+		if firstErr == nil {
+			firstErr = decodeErr
+		}
+		rest = rest[bytes.Index(rest, obj)+len(obj):]
+	}
+	if firstErr == nil {
+		firstErr = errNoJSONObject
+	}
+	return nil, objects, firstErr
+}
+
+// hasTrailingObject reports whether another JSON object follows obj in payload
+func hasTrailingObject(payload, obj []byte) bool {
+	idx := bytes.Index(payload, obj)
+	if idx < 0 {
+		return false
+	}
+	_, err := extractJSONObject(payload[idx+len(obj):])
+	return err == nil
+}
+
+// repairEnvelope recovers an envelope from a response that still fails to
+// decode after fences and think tags are stripped, recording each repair
+// strategy that was needed. It returns nil when no repair helps.
+func repairEnvelope(payload []byte) *ActionEnvelope {
+	if env, objects, err := decodeFirstEnvelope(payload); err == nil {
+		if objects > 1 {
+			recordRepair(RepairDuplicateEnvelope)
+		}
+		return env
+	}
+
+	// Leading prose may contain apostrophes, so repair from the first brace
+	start := bytes.IndexByte(payload, '{')
+	if start < 0 {
+		return nil
+	}
+	repaired, strategies := repairJSON(payload[start:])
+	if len(strategies) == 0 {
+		return nil
+	}
+	env, objects, err := decodeFirstEnvelope(repaired)
+	if err != nil {
+		return nil
+	}
+	if objects > 1 {
+		strategies = append(strategies, RepairDuplicateEnvelope)
+	}
+	for _, s := range strategies {
+		recordRepair(s)
+	}
+	return env
+}
@@ -0,0 +1,165 @@
+package actions
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func captureRepairs(t *testing.T) *[]string {
+	t.Helper()
+	var got []string
+	prev := recordRepair
+	recordRepair = func(strategy string) { got = append(got, strategy) }
+	t.Cleanup(func() { recordRepair = prev })
+	return &got
+}
+
+func TestDecodeLenient_Repairs(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		path    string
+		repairs []string
+	}{
+		{
+			name:    "single quotes",
+			payload: `{'actions': [{'type': 'read_file', 'path': 'it\'s "quoted".go'}]}`,
+			path:    `it's "quoted".go`,
+			repairs: []string{RepairSingleQuotes},
+		},
+		{
+			name:    "trailing commas",
+			payload: "{\"actions\": [{\"type\": \"read_file\", \"path\": \"a.go\",},\n]}",
+			path:    "a.go",
+			repairs: []string{RepairTrailingCommas},
+		},
+		{
+			name:    "unquoted keys",
+			payload: `{actions: [{type: "read_file", path: "a.go", $ref_id: "x"}]}`,
+			repairs: []string{RepairUnquotedKeys},
+		},
+		{
+			name:    "duplicated envelope",
+			payload: `{"actions": [{"type": "read_file", "path": "a.go"}]}` + "\n" + `{"actions": [{"type": "read_file", "path": "a.go"}]}`,
+			path:    "a.go",
+			repairs: []string{RepairDuplicateEnvelope},
+		},
+		{
+			name:    "object before envelope",
+			payload: `{"thought": "read it"} {"actions": [{"type": "read_file", "path": "a.go"}]}`,
+			path:    "a.go",
+			repairs: []string{RepairDuplicateEnvelope},
+		},
+		{
+			name:    "combined with prose and fences",
+			payload: "Here's my plan:\n```json\n{actions: [{'type': 'read_file', 'path': 'a.go',}],}\n```",
+			path:    "a.go",
+			repairs: []string{RepairSingleQuotes, RepairTrailingCommas, RepairUnquotedKeys},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := captureRepairs(t)
+			env, err := DecodeLenient([]byte(tt.payload))
+			if tt.name == "unquoted keys" {
+				// $ref_id is an unknown field, so strict decoding still rejects it
+				if err == nil {
+					t.Fatalf("expected unknown field to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeLenient: %v", err)
+			}
+			if len(env.Actions) != 1 || env.Actions[0].Path != tt.path {
+				t.Errorf("unexpected envelope: %+v", env)
+			}
+			if !reflect.DeepEqual(*got, tt.repairs) {
+				t.Errorf("repairs = %v, want %v", *got, tt.repairs)
+			}
+		})
+	}
+}
+
+func TestDecodeLenient_UnrepairableKeepsOriginalError(t *testing.T) {
+	got := captureRepairs(t)
+	payload := `{"actions": [{"type": "read_file", "path": "a.go"}`
+	_, err := DecodeLenient([]byte(payload))
+	_, strictErr := DecodeStrict([]byte(payload))
+	if err == nil || err.Error() != strictErr.Error() {
+		t.Errorf("got %v, want %v", err, strictErr)
+	}
+	if len(*got) != 0 {
+		t.Errorf("unexpected repairs recorded: %v", *got)
+	}
+}
+
+func TestDecodeLenient_CleanResponseRecordsNoRepair(t *testing.T) {
+	got := captureRepairs(t)
+	if _, err := DecodeLenient([]byte("```json\n{\"actions\": [{\"type\": \"done\"}]}\n```")); err != nil {
+		t.Fatalf("DecodeLenient: %v", err)
+	}
+	if len(*got) != 0 {
+		t.Errorf("unexpected repairs recorded: %v", *got)
+	}
+}
+
+func TestRepairJSON_LeavesStringsAlone(t *testing.T) {
+	in := `{"content": "a, } b: 'c' {d: e,]"}`
+	out, strategies := repairJSON([]byte(in))
+	if string(out) != in || len(strategies) != 0 {
+		t.Errorf("string contents changed: %s %v", out, strategies)
+	}
+}
+
+var repairSeeds = []string{
+	`{"actions": [{"type": "done"}]}`,
+	`{'actions': [{'type': 'done'}]}`,
+	`{actions: [{type: "done",},],}`,
+	`{"actions": [{"type": "done"}]}{"actions": [{"type": "done"}]}`,
+	`<think>'</think>{"actions":[{"type":"read_file","path":"x"}]}`,
+	"```json\n{'a': \"b\\\"\", c: [1,2,],}\n```",
+	`{'unterminated`,
+	`{"a": "\`,
+	`,,,}}]]{{`,
+}
+
+// FuzzRepairJSON checks that repair never changes valid JSON
+func FuzzRepairJSON(f *testing.F) {
+	for _, s := range repairSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		out, strategies := repairJSON([]byte(in))
+		if json.Valid([]byte(in)) && (string(out) != in || len(strategies) != 0) {
+			t.Errorf("valid JSON %q was rewritten to %q (%v)", in, out, strategies)
+		}
+	})
+}
+
+// FuzzDecodeLenient checks that lenient decoding never panics and only
+// returns envelopes that validate
+func FuzzDecodeLenient(f *testing.F) {
+	for _, s := range repairSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		prev := recordRepair
+		recordRepair = func(string) {}
+		defer func() { recordRepair = prev }()
+
+		env, err := DecodeLenient([]byte(in))
+		if err == nil {
+			if verr := Validate(env); verr != nil {
+				t.Errorf("DecodeLenient(%q) returned invalid envelope: %v", in, verr)
+			}
+		} else if env != nil {
+			t.Errorf("DecodeLenient(%q) returned envelope with error", in)
+		}
+		if strings.TrimSpace(in) == "" && err == nil {
+			t.Errorf("empty input decoded")
+		}
+	})
+}
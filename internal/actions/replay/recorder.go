@@ -0,0 +1,64 @@
+package replay
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+)
+
+// Recorder captures executed actions into a trace. Install it as the
+// router's Logger; set Next to keep the existing logger running.
+type Recorder struct {
+	Next actions.ActionLogger
+
+	mu    sync.Mutex
+	trace Trace
+}
+
+// NewRecorder starts a trace for the given session
+func NewRecorder(name string, actx actions.ActionContext) *Recorder {
+	return &Recorder{trace: Trace{
+		Name:    name,
+		Context: Context{AgentID: actx.AgentID, BeadID: actx.BeadID, ProjectID: actx.ProjectID},
+	}}
+}
+
+// LogAction records the action and its result as a single-action step
+func (r *Recorder) LogAction(ctx context.Context, actx actions.ActionContext, action actions.Action, result actions.Result) {
+	r.mu.Lock()
+	r.trace.Steps = append(r.trace.Steps, Step{
+		Envelope: &actions.ActionEnvelope{Actions: []actions.Action{action}},
+		Expected: []actions.Result{result},
+	})
+	r.mu.Unlock()
+
+	if r.Next != nil {
+		r.Next.LogAction(ctx, actx, action, result)
+	}
+}
+
+// RecordCommand scripts a command's output for the replay sandbox
+func (r *Recorder) RecordCommand(command string, out CommandOutput) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.trace.Commands == nil {
+		r.trace.Commands = map[string]CommandOutput{}
+	}
+	r.trace.Commands[command] = out
+}
+
+// Trace returns a copy of the recorded trace
+func (r *Recorder) Trace() *Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.trace
+	t.Steps = append([]Step(nil), r.trace.Steps...)
+	if r.trace.Commands != nil {
+		t.Commands = make(map[string]CommandOutput, len(r.trace.Commands))
+		for k, v := range r.trace.Commands {
+			t.Commands[k] = v
+		}
+	}
+	return &t
+}
@@ -0,0 +1,230 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+)
+
+// DefaultIgnore lists result metadata keys that change on every run and are
+// skipped when comparing results
+var DefaultIgnore = []string{
+	"command_id",
+	"duration_ms",
+	"started_at",
+	"completed_at",
+	"snapshot_id",
+	"output_refs",
+	"timestamp",
+}
+
+// Options configure a replay
+type Options struct {
+	// Router executes the envelopes. When nil a router over a fresh Sandbox
+	// is used.
+	Router *actions.Router
+	// Ignore replaces DefaultIgnore as the metadata keys left out of the diff
+	Ignore []string
+}
+
+// Diff is a single mismatch between a recorded and a replayed result
+type Diff struct {
+	Step     int         `json:"step"`
+	Action   int         `json:"action"`
+	Field    string      `json:"field"`
+	Expected interface{} `json:"expected"`
+	Actual   interface{} `json:"actual"`
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("step %d action %d %s: expected %s, got %s", d.Step, d.Action, d.Field, render(d.Expected), render(d.Actual))
+}
+
+// StepReport holds the replayed results of one step
+type StepReport struct {
+	Step   int              `json:"step"`
+	Actual []actions.Result `json:"actual"`
+	Error  string           `json:"error,omitempty"`
+	Diffs  []Diff           `json:"diffs,omitempty"`
+}
+
+// Report is the outcome of a replay
+type Report struct {
+	Trace string       `json:"trace"`
+	Steps []StepReport `json:"steps"`
+}
+
+// Passed reports whether every step reproduced its recorded results
+func (r *Report) Passed() bool {
+	for _, step := range r.Steps {
+		if step.Error != "" || len(step.Diffs) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Diffs returns every mismatch in step order
+func (r *Report) Diffs() []Diff {
+	var out []Diff
+	for _, step := range r.Steps {
+		out = append(out, step.Diffs...)
+	}
+	return out
+}
+
+// String renders the report as a readable expected/actual diff
+func (r *Report) String() string {
+	var sb strings.Builder
+	failed := 0
+	for _, step := range r.Steps {
+		if step.Error == "" && len(step.Diffs) == 0 {
+			continue
+		}
+		failed++
+		if step.Error != "" {
+			sb.WriteString(fmt.Sprintf("step %d: %s\n", step.Step, step.Error))
+		}
+		for _, d := range step.Diffs {
+			sb.WriteString(fmt.Sprintf("step %d action %d %s\n  - %s\n  + %s\n", d.Step, d.Action, d.Field, render(d.Expected), render(d.Actual)))
+		}
+	}
+	status := "PASS"
+	if failed > 0 {
+		status = "FAIL"
+	}
+	sb.WriteString(fmt.Sprintf("%s %s: %d/%d steps reproduced\n", status, r.Trace, len(r.Steps)-failed, len(r.Steps)))
+	return sb.String()
+}
+
+// Replay executes each step of the trace in order and compares the results
+// with the recorded ones. Steps that fail to decode are reported and the
+// replay continues; the returned error covers setup failures only.
+func Replay(ctx context.Context, trace *Trace, opts Options) (*Report, error) {
+	if trace == nil {
+		return nil, fmt.Errorf("trace is nil")
+	}
+	router := opts.Router
+	if router == nil {
+		sb, err := NewSandbox(trace)
+		if err != nil {
+			return nil, err
+		}
+		defer sb.Close()
+		router = sb.Router()
+	}
+	ignore := opts.Ignore
+	if ignore == nil {
+		ignore = DefaultIgnore
+	}
+
+	report := &Report{Trace: trace.Name, Steps: make([]StepReport, 0, len(trace.Steps))}
+	actx := trace.Context.ActionContext()
+	for i, step := range trace.Steps {
+		sr := StepReport{Step: i}
+		env, err := step.envelope()
+		if err != nil {
+			sr.Error = fmt.Sprintf("failed to decode envelope: %v", err)
+			report.Steps = append(report.Steps, sr)
+			continue
+		}
+		actual, err := router.Execute(ctx, env, actx)
+		if err != nil {
+			sr.Error = err.Error()
+		}
+		sr.Actual = actual
+		sr.Diffs = Compare(i, step.Expected, actual, ignore)
+		report.Steps = append(report.Steps, sr)
+	}
+	return report, nil
+}
+
+// Compare diffs recorded results against replayed ones. Values are compared
+// in their JSON form so recorded numbers match replayed ints.
+func Compare(step int, expected, actual []actions.Result, ignore []string) []Diff {
+	skip := make(map[string]bool, len(ignore))
+	for _, key := range ignore {
+		skip[key] = true
+	}
+
+	var diffs []Diff
+	if len(expected) != len(actual) {
+		diffs = append(diffs, Diff{Step: step, Action: -1, Field: "results", Expected: len(expected), Actual: len(actual)})
+	}
+	for i := 0; i < len(expected) && i < len(actual); i++ {
+		exp, act := expected[i], actual[i]
+		add := func(field string, e, a interface{}) {
+			diffs = append(diffs, Diff{Step: step, Action: i, Field: field, Expected: e, Actual: a})
+		}
+		if exp.ActionType != act.ActionType {
+			add("action_type", exp.ActionType, act.ActionType)
+		}
+		if exp.Status != act.Status {
+			add("status", exp.Status, act.Status)
+		}
+		if exp.Message != act.Message {
+			add("message", exp.Message, act.Message)
+		}
+
+		expMeta, actMeta := normalize(exp.Metadata), normalize(act.Metadata)
+		for _, key := range unionKeys(expMeta, actMeta) {
+			if skip[key] {
+				continue
+			}
+			e, eok := expMeta[key]
+			a, aok := actMeta[key]
+			if eok != aok || !reflect.DeepEqual(e, a) {
+				add("metadata."+key, e, a)
+			}
+		}
+	}
+	return diffs
+}
+
+// normalize round-trips metadata through JSON so values compare the same
+// whether they were recorded from a file or produced in memory
+func normalize(meta map[string]interface{}) map[string]interface{} {
+	if len(meta) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return meta
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return meta
+	}
+	return out
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var keys []string
+	for _, m := range []map[string]interface{}{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func render(v interface{}) string {
+	if v == nil {
+		return "<missing>"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,132 @@
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+)
+
+func TestReplay_ReproducesRecordedTrace(t *testing.T) {
+	trace, err := LoadTrace(filepath.Join("testdata", "hello.json"))
+	if err != nil {
+		t.Fatalf("LoadTrace: %v", err)
+	}
+
+	report, err := Replay(context.Background(), trace, Options{})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if !report.Passed() {
+		t.Fatalf("replay diverged:\n%s", report)
+	}
+	if !strings.HasPrefix(report.String(), "PASS hello: 3/3") {
+		t.Errorf("unexpected summary: %q", report.String())
+	}
+}
+
+func TestReplay_ReportsDiffs(t *testing.T) {
+	trace, err := LoadTrace(filepath.Join("testdata", "hello.json"))
+	if err != nil {
+		t.Fatalf("LoadTrace: %v", err)
+	}
+	trace.Steps[0].Expected[0].Metadata["content"] = "goodbye\n"
+	trace.Steps[1].Expected = trace.Steps[1].Expected[:1]
+	delete(trace.Commands, "go test ./...")
+
+	report, err := Replay(context.Background(), trace, Options{})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if report.Passed() {
+		t.Fatal("expected replay to diverge")
+	}
+	fields := map[string]bool{}
+	for _, d := range report.Diffs() {
+		fields[d.Field] = true
+	}
+	if !fields["metadata.content"] || !fields["results"] || len(fields) != 2 {
+		t.Errorf("unexpected diffs: %+v", report.Diffs())
+	}
+	if out := report.String(); !strings.Contains(out, `- "goodbye\n"`) || !strings.Contains(out, "FAIL hello: 1/3") {
+		t.Errorf("unexpected report:\n%s", out)
+	}
+}
+
+func TestSandbox_IsolatesFixture(t *testing.T) {
+	trace, err := LoadTrace(filepath.Join("testdata", "hello.json"))
+	if err != nil {
+		t.Fatalf("LoadTrace: %v", err)
+	}
+	sb, err := NewSandbox(trace)
+	if err != nil {
+		t.Fatalf("NewSandbox: %v", err)
+	}
+	defer sb.Close()
+
+	if _, err := Replay(context.Background(), trace, Options{Router: sb.Router()}); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(sb.WorkDir, "notes.md")); err != nil {
+		t.Errorf("write_file did not land in the sandbox: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join("testdata", "fixtures", "hello", "notes.md")); !os.IsNotExist(err) {
+		t.Errorf("fixture was modified")
+	}
+	if cmds := sb.Commands(); len(cmds) != 1 || cmds[0] != "go test ./..." {
+		t.Errorf("unexpected commands: %v", cmds)
+	}
+	if beads := sb.Beads(); len(beads) != 1 || beads[0].Title != "Follow up" {
+		t.Errorf("unexpected beads: %+v", beads)
+	}
+}
+
+func TestRecorder_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	actx := actions.ActionContext{AgentID: "agent-1", ProjectID: "p"}
+	rec := NewRecorder("live", actx)
+	rec.RecordCommand("make", CommandOutput{ExitCode: 2, Stderr: "boom"})
+
+	live, err := NewSandbox(&Trace{Fixture: dir, Commands: map[string]CommandOutput{"make": {ExitCode: 2}}})
+	if err != nil {
+		t.Fatalf("NewSandbox: %v", err)
+	}
+	defer live.Close()
+	router := live.Router()
+	router.Logger = rec
+	env := &actions.ActionEnvelope{Actions: []actions.Action{
+		{Type: actions.ActionReadFile, Path: "a.txt"},
+		{Type: actions.ActionRunCommand, Command: "make"},
+	}}
+	if _, err := router.Execute(context.Background(), env, actx); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	trace := rec.Trace()
+	trace.Fixture = dir
+	path := filepath.Join(t.TempDir(), "live.json")
+	if err := trace.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := LoadTrace(path)
+	if err != nil {
+		t.Fatalf("LoadTrace: %v", err)
+	}
+	if len(loaded.Steps) != 2 || loaded.Context.AgentID != "agent-1" {
+		t.Fatalf("unexpected trace: %+v", loaded)
+	}
+
+	report, err := Replay(context.Background(), loaded, Options{})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if !report.Passed() {
+		t.Errorf("recorded trace did not replay:\n%s", report)
+	}
+}
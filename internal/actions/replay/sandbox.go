@@ -0,0 +1,189 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/executor"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Sandbox provides throwaway backends for a replay: a temporary copy of the
+// trace fixture served through a real files.Manager, an in-memory bead store
+// and a command executor that answers from the trace's recorded outputs.
+type Sandbox struct {
+	WorkDir string
+
+	mu       sync.Mutex
+	commands map[string]CommandOutput
+	beads    map[string]*models.Bead
+	nextBead int
+	nextCmd  int
+	ran      []string
+}
+
+// NewSandbox copies the trace fixture into a temporary workdir. Call Close
+// to remove it.
+func NewSandbox(trace *Trace) (*Sandbox, error) {
+	dir, err := os.MkdirTemp("", "loom-replay-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	if fixture := trace.FixturePath(); fixture != "" {
+		if err := copyTree(fixture, dir); err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to copy fixture %s: %w", fixture, err)
+		}
+	}
+	return &Sandbox{
+		WorkDir:  dir,
+		commands: trace.Commands,
+		beads:    map[string]*models.Bead{},
+	}, nil
+}
+
+// Close removes the sandbox workdir
+func (s *Sandbox) Close() error {
+	return os.RemoveAll(s.WorkDir)
+}
+
+// Router returns a router wired to the sandbox backends
+func (s *Sandbox) Router() *actions.Router {
+	return &actions.Router{
+		Beads:    s,
+		Closer:   s,
+		Commands: s,
+		Files:    files.NewManager(s),
+	}
+}
+
+// GetProjectWorkDir resolves every project to the sandbox workdir
+func (s *Sandbox) GetProjectWorkDir(projectID string) string {
+	return s.WorkDir
+}
+
+// CreateBead records a bead with a deterministic ID
+func (s *Sandbox) CreateBead(title, description string, priority models.BeadPriority, beadType, projectID string) (*models.Bead, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextBead++
+	bead := &models.Bead{
+		ID:          fmt.Sprintf("replay-%d", s.nextBead),
+		Title:       title,
+		Description: description,
+		Priority:    priority,
+		Type:        beadType,
+		ProjectID:   projectID,
+		Status:      models.BeadStatusOpen,
+	}
+	s.beads[bead.ID] = bead
+	return bead, nil
+}
+
+// CloseBead closes a bead created during the replay. Beads the sandbox does
+// not know about are assumed to exist in the recorded project.
+func (s *Sandbox) CloseBead(beadID, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bead, ok := s.beads[beadID]; ok {
+		bead.Status = models.BeadStatusClosed
+	}
+	return nil
+}
+
+// Beads returns the beads created during the replay
+func (s *Sandbox) Beads() []*models.Bead {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*models.Bead, 0, len(s.beads))
+	for i := 1; i <= s.nextBead; i++ {
+		out = append(out, s.beads[fmt.Sprintf("replay-%d", i)])
+	}
+	return out
+}
+
+// ExecuteCommand answers from the recorded command outputs. Commands that
+// were not recorded fail with exit code 127 rather than running on the host.
+func (s *Sandbox) ExecuteCommand(ctx context.Context, req executor.ExecuteCommandRequest) (*executor.ExecuteCommandResult, error) {
+	s.mu.Lock()
+	s.nextCmd++
+	id := fmt.Sprintf("replay-cmd-%d", s.nextCmd)
+	s.ran = append(s.ran, req.Command)
+	out, ok := s.commands[req.Command]
+	s.mu.Unlock()
+
+	if !ok {
+		out = CommandOutput{ExitCode: 127, Stderr: fmt.Sprintf("replay: command not recorded: %s", req.Command)}
+	}
+	if req.OnOutput != nil {
+		if out.Stdout != "" {
+			req.OnOutput("stdout", []byte(out.Stdout))
+		}
+		if out.Stderr != "" {
+			req.OnOutput("stderr", []byte(out.Stderr))
+		}
+	}
+	now := time.Now()
+	return &executor.ExecuteCommandResult{
+		ID:          id,
+		Command:     req.Command,
+		ExitCode:    out.ExitCode,
+		Stdout:      out.Stdout,
+		Stderr:      out.Stderr,
+		StartedAt:   now,
+		CompletedAt: now,
+		Success:     out.ExitCode == 0,
+	}, nil
+}
+
+// Commands returns the command lines executed during the replay, in order
+func (s *Sandbox) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ran...)
+}
+
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
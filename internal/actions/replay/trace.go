@@ -0,0 +1,99 @@
+// Package replay runs recorded action envelopes against an actions.Router
+// with sandboxed backends and diffs the results against what was recorded.
+// It is used to reproduce agent sessions offline and to catch behaviour
+// changes in the router before they reach a live project.
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+)
+
+// Trace is a recorded agent session: the envelopes the router executed and
+// the results it returned for each one
+type Trace struct {
+	Name string `json:"name"`
+	// Fixture is a project directory copied into the sandbox workdir before
+	// the first step. Relative paths are resolved against the trace file.
+	Fixture string  `json:"fixture,omitempty"`
+	Context Context `json:"context"`
+	// Commands scripts run_command output by exact command line
+	Commands map[string]CommandOutput `json:"commands,omitempty"`
+	Steps    []Step                   `json:"steps"`
+
+	dir string
+}
+
+// Context is the serialized form of actions.ActionContext
+type Context struct {
+	AgentID   string `json:"agent_id,omitempty"`
+	BeadID    string `json:"bead_id,omitempty"`
+	ProjectID string `json:"project_id,omitempty"`
+}
+
+// ActionContext converts the trace context for the router
+func (c Context) ActionContext() actions.ActionContext {
+	return actions.ActionContext{AgentID: c.AgentID, BeadID: c.BeadID, ProjectID: c.ProjectID}
+}
+
+// CommandOutput is the recorded result of a shell command
+type CommandOutput struct {
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+}
+
+// Step is one envelope and the results recorded for it. Raw holds the model
+// output when the envelope should be decoded the way the worker does it.
+type Step struct {
+	Envelope *actions.ActionEnvelope `json:"envelope,omitempty"`
+	Raw      string                  `json:"raw,omitempty"`
+	Expected []actions.Result        `json:"expected"`
+}
+
+// LoadTrace reads a trace from a JSON file
+func LoadTrace(path string) (*Trace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trace: %w", err)
+	}
+	var trace Trace
+	if err := json.Unmarshal(data, &trace); err != nil {
+		return nil, fmt.Errorf("failed to parse trace %s: %w", path, err)
+	}
+	trace.dir = filepath.Dir(path)
+	return &trace, nil
+}
+
+// Save writes the trace as indented JSON
+func (t *Trace) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trace: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// FixturePath returns the fixture directory resolved against the trace file
+func (t *Trace) FixturePath() string {
+	if t.Fixture == "" || filepath.IsAbs(t.Fixture) {
+		return t.Fixture
+	}
+	return filepath.Join(t.dir, t.Fixture)
+}
+
+// envelope returns the step's envelope, decoding Raw when no envelope was
+// recorded
+func (s Step) envelope() (*actions.ActionEnvelope, error) {
+	if s.Envelope != nil {
+		return s.Envelope, nil
+	}
+	if s.Raw == "" {
+		return nil, fmt.Errorf("step has neither envelope nor raw output")
+	}
+	return actions.DecodeLenient([]byte(s.Raw))
+}
@@ -0,0 +1,104 @@
+package actions
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SimpleJSONActions are the action names the simple JSON format accepts
+var SimpleJSONActions = []string{
+	"scope", "tree", "read", "search", "edit", "write", "build", "test", "bash",
+	"git_commit", "git_push", "git_status", "done", "close_bead", "escalate", "attach_image",
+}
+
+// ActionTypes are the action types an ActionEnvelope may contain
+var ActionTypes = []string{
+	ActionAskFollowup, ActionReadCode, ActionEditCode, ActionMultiEdit, ActionWriteFile,
+	ActionRunCommand, ActionOpenSession, ActionSessionInput, ActionSessionRead, ActionCloseSession,
+	ActionStartCommand, ActionJobStatus, ActionJobLogs, ActionReadResult, ActionCancelJob,
+	ActionRunTests, ActionRunLinter, ActionBuildProject, ActionRunFormatter, ActionRunSecurityScan,
+	ActionCheckDependencies, ActionCreateBead, ActionCloseBead, ActionEscalateCEO, ActionReadFile,
+	ActionReadTree, ActionSearchText, ActionApplyPatch, ActionGitStatus, ActionGitDiff,
+	ActionGitCommit, ActionGitPush, ActionCreatePR, ActionCheckCI, ActionStartDev,
+	ActionWhatsNext, ActionProceedToPhase, ActionConductReview, ActionResumeWorkflow, ActionApproveBead,
+	ActionRejectBead, ActionFindReferences, ActionGoToDefinition, ActionFindImplementations, ActionExtractMethod,
+	ActionRenameSymbol, ActionInlineVariable, ActionAddImport, ActionInsertFunctionAfter, ActionAddStructField,
+	ActionMoveFile, ActionDeleteFile, ActionRenameFile, ActionAddLog, ActionAddBreakpoint,
+	ActionGenerateDocs, ActionFetchPR, ActionReviewCode, ActionAddPRComment, ActionSubmitReview,
+	ActionRequestReview, ActionGitMerge, ActionGitRevert, ActionGitBranchDelete, ActionGitCheckout,
+	ActionGitLog, ActionGitFetch, ActionGitListBranches, ActionGitDiffBranches, ActionGitBeadCommits,
+	ActionDone, ActionSendAgentMessage, ActionReadAgentMessages, ActionDelegateTask, ActionMCPListTools,
+	ActionMCPCall, ActionAttachImage, ActionGitDiffRevisions, ActionGitBlame,
+	ActionGitTag, ActionGitChangelog, ActionBumpVersion, ActionBuildImage, ActionRunContainer,
+	ActionDeployStaging, ActionStagingStatus, ActionTeardownStaging, ActionHTTPCheck,
+	ActionBrowser, ActionDBCreate, ActionDBMigrate, ActionDBLoadFixtures, ActionDBAssert, ActionDBDrop,
+	ActionRunBenchmarks, ActionProfileProject, ActionAnalyzeImpact,
+}
+
+// SimpleJSONSchema is the JSON schema of one simple-format action, for
+// providers that constrain generation to a schema
+func SimpleJSONSchema() map[string]interface{} {
+	schema := schemaOf(reflect.TypeOf(SimpleJSONAction{}))
+	schema["properties"].(map[string]interface{})["action"] = map[string]interface{}{
+		"type": "string",
+		"enum": SimpleJSONActions,
+	}
+	schema["required"] = []string{"action"}
+	return schema
+}
+
+// EnvelopeSchema is the JSON schema of an ActionEnvelope, generated from the
+// Action struct so new fields are constrained as soon as they are added
+func EnvelopeSchema() map[string]interface{} {
+	schema := schemaOf(reflect.TypeOf(ActionEnvelope{}))
+	actionsProp := schema["properties"].(map[string]interface{})["actions"].(map[string]interface{})
+	actionsProp["minItems"] = 1
+	item := actionsProp["items"].(map[string]interface{})
+	item["properties"].(map[string]interface{})["type"] = map[string]interface{}{
+		"type": "string",
+		"enum": ActionTypes,
+	}
+	item["required"] = []string{"type"}
+	schema["required"] = []string{"actions"}
+	return schema
+}
+
+// schemaOf describes a Go type the way encoding/json marshals it
+func schemaOf(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object"}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = schemaOf(f.Type)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,65 @@
+package actions
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSimpleJSONSchema(t *testing.T) {
+	schema := SimpleJSONSchema()
+	props := schema["properties"].(map[string]interface{})
+	for _, name := range []string{"action", "path", "old", "start_line", "edits"} {
+		if props[name] == nil {
+			t.Errorf("schema is missing %q", name)
+		}
+	}
+	if props["start_line"].(map[string]interface{})["type"] != "integer" {
+		t.Errorf("start_line should be an integer, got %v", props["start_line"])
+	}
+	edits := props["edits"].(map[string]interface{})
+	if edits["type"] != "array" || edits["items"].(map[string]interface{})["properties"].(map[string]interface{})["old"] == nil {
+		t.Errorf("unexpected edits schema %v", edits)
+	}
+
+	// Every name the schema allows must be one the parser knows
+	for _, name := range SimpleJSONActions {
+		_, err := simpleToAction(SimpleJSONAction{Action: name, Path: "a.go", Query: "q", Old: "x", Content: "c", Command: "ls"})
+		if err != nil && strings.Contains(err.Error(), "unknown action") {
+			t.Errorf("schema allows %q but the parser rejects it", name)
+		}
+	}
+	if _, err := json.Marshal(schema); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnvelopeSchema(t *testing.T) {
+	schema := EnvelopeSchema()
+	actions := schema["properties"].(map[string]interface{})["actions"].(map[string]interface{})
+	item := actions["items"].(map[string]interface{})
+	props := item["properties"].(map[string]interface{})
+
+	actionType := reflect.TypeOf(Action{})
+	for i := 0; i < actionType.NumField(); i++ {
+		name := strings.Split(actionType.Field(i).Tag.Get("json"), ",")[0]
+		if props[name] == nil {
+			t.Errorf("schema is missing action field %q", name)
+		}
+	}
+	bead := props["bead"].(map[string]interface{})
+	if bead["type"] != "object" || bead["properties"].(map[string]interface{})["title"] == nil {
+		t.Errorf("unexpected bead schema %v", bead)
+	}
+	enum := props["type"].(map[string]interface{})["enum"].([]string)
+	for _, want := range []string{ActionReadFile, ActionMultiEdit, ActionDone, ActionMCPCall} {
+		found := false
+		for _, v := range enum {
+			found = found || v == want
+		}
+		if !found {
+			t.Errorf("type enum is missing %q", want)
+		}
+	}
+}
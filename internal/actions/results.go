@@ -0,0 +1,195 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+const (
+	// spillOutputBytes is the smallest limit feedback truncates output
+	// fields to; longer fields are stored in full
+	spillOutputBytes = maxBuildOutputLen
+	// defaultResultChunk and maxResultChunk bound read_result pages
+	defaultResultChunk = maxFileContentLen
+	maxResultChunk     = 32000
+)
+
+// spillFields are the result metadata fields that can hold long output
+var spillFields = []string{"content", "output", "stdout", "stderr", "diff"}
+
+// OutputStore persists full action outputs so truncated feedback can
+// reference them
+type OutputStore interface {
+	SaveActionOutput(out *models.ActionOutput) error
+	GetActionOutput(id string) (*models.ActionOutput, error)
+	ReadActionOutput(id string, offset, limit int64) (string, error)
+}
+
+// OutputRef points at the stored full text of a truncated result field
+type OutputRef struct {
+	ID   string `json:"id"`
+	Size int    `json:"size"`
+}
+
+// storeLargeOutputs saves output fields too long to be fed back in full and
+// records references to them under the result's output_refs metadata
+func (r *Router) storeLargeOutputs(result *Result, actx ActionContext) {
+	if r.Outputs == nil || result.Metadata == nil {
+		return
+	}
+	// These already page through their output
+	if result.ActionType == ActionReadResult || result.ActionType == ActionJobLogs {
+		return
+	}
+
+	refs := map[string]OutputRef{}
+	for _, field := range spillFields {
+		text, ok := result.Metadata[field].(string)
+		if !ok || len(text) <= spillOutputBytes {
+			continue
+		}
+		out := &models.ActionOutput{
+			ID:         "r-" + uuid.New().String()[:8],
+			ProjectID:  actx.ProjectID,
+			BeadID:     actx.BeadID,
+			AgentID:    actx.AgentID,
+			ActionType: result.ActionType,
+			Field:      field,
+			Size:       int64(len(text)),
+			Content:    text,
+			CreatedAt:  time.Now().UTC(),
+		}
+		if err := r.Outputs.SaveActionOutput(out); err != nil {
+			actionsLog.Warn("Failed to store action output", "field", field, "action_type", result.ActionType, "error", err)
+			continue
+		}
+		refs[field] = OutputRef{ID: out.ID, Size: len(text)}
+	}
+	if len(refs) > 0 {
+		result.Metadata["output_refs"] = refs
+	}
+}
+
+// handleReadResultAction pages through a stored action output
+func (r *Router) handleReadResultAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Outputs == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "result storage not configured"}
+	}
+	out, err := r.Outputs.GetActionOutput(action.ResultID)
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+
+	limit := int64(action.Limit)
+	if limit <= 0 {
+		limit = defaultResultChunk
+	}
+	if limit > maxResultChunk {
+		limit = maxResultChunk
+	}
+	offset := action.Offset
+	if offset < 0 {
+		offset += out.Size
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	if offset > out.Size {
+		offset = out.Size
+	}
+
+	chunk := ""
+	if offset < out.Size {
+		chunk, err = r.Outputs.ReadActionOutput(out.ID, offset, limit)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		// Don't split a character across pages
+		if offset+int64(len(chunk)) < out.Size {
+			chunk = trimPartialRune(chunk)
+		}
+	}
+	next := offset + int64(len(chunk))
+
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    fmt.Sprintf("result %s bytes %d-%d of %d", out.ID, offset, next, out.Size),
+		Metadata: map[string]interface{}{
+			"result_id":   out.ID,
+			"action_type": out.ActionType,
+			"field":       out.Field,
+			"size":        out.Size,
+			"offset":      offset,
+			"next_offset": next,
+			"eof":         next >= out.Size,
+			"content":     chunk,
+		},
+	}
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence from the end of s
+func trimPartialRune(s string) string {
+	for i := len(s) - 1; i >= 0 && i >= len(s)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			if !utf8.FullRuneInString(s[i:]) {
+				return s[:i]
+			}
+			break
+		}
+	}
+	return s
+}
+
+// outputRef returns the stored output reference for a result field.
+// Metadata that went through JSON holds the refs as plain maps.
+func outputRef(r Result, field string) (OutputRef, bool) {
+	switch refs := r.Metadata["output_refs"].(type) {
+	case map[string]OutputRef:
+		ref, ok := refs[field]
+		return ref, ok
+	case map[string]interface{}:
+		raw, ok := refs[field].(map[string]interface{})
+		if !ok {
+			return OutputRef{}, false
+		}
+		id, _ := raw["id"].(string)
+		size, _ := raw["size"].(float64)
+		return OutputRef{ID: id, Size: int(size)}, id != ""
+	}
+	return OutputRef{}, false
+}
+
+// truncationMarker is appended to truncated output: a stable reference to
+// the full text when it was stored
+func truncationMarker(r Result, field string) string {
+	ref, ok := outputRef(r, field)
+	if !ok {
+		return "\n... (truncated)"
+	}
+	return fmt.Sprintf("\n... (truncated; result %s, %s, see /api/v1/results/%s or use read_result)", ref.ID, formatSize(ref.Size), ref.ID)
+}
+
+// truncateResultOutput truncates a result field, pointing at its stored
+// full text
+func truncateResultOutput(r Result, field, s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + truncationMarker(r, field)
+}
+
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
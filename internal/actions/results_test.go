@@ -0,0 +1,133 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type mockOutputStore struct {
+	outputs map[string]*models.ActionOutput
+}
+
+func (m *mockOutputStore) SaveActionOutput(out *models.ActionOutput) error {
+	if m.outputs == nil {
+		m.outputs = map[string]*models.ActionOutput{}
+	}
+	m.outputs[out.ID] = out
+	return nil
+}
+
+func (m *mockOutputStore) GetActionOutput(id string) (*models.ActionOutput, error) {
+	out, ok := m.outputs[id]
+	if !ok {
+		return nil, fmt.Errorf("result not found: %s", id)
+	}
+	return out, nil
+}
+
+func (m *mockOutputStore) ReadActionOutput(id string, offset, limit int64) (string, error) {
+	out, err := m.GetActionOutput(id)
+	if err != nil {
+		return "", err
+	}
+	end := offset + limit
+	if end > int64(len(out.Content)) {
+		end = int64(len(out.Content))
+	}
+	return out.Content[offset:end], nil
+}
+
+func TestRouterStoresLargeOutputs(t *testing.T) {
+	content := strings.Repeat("line of source\n", 2000)
+	store := &mockOutputStore{}
+	r := &Router{
+		Files:   &mockFileManager{readResult: &files.FileResult{Path: "big.go", Content: content, Size: int64(len(content))}},
+		Outputs: store,
+	}
+	actx := ActionContext{AgentID: "agent-1", BeadID: "bead-1", ProjectID: "proj-1"}
+
+	env := &ActionEnvelope{Actions: []Action{
+		{Type: ActionReadFile, Path: "big.go"},
+		{Type: ActionReadFile, Path: "small.go"},
+	}}
+	results, err := r.Execute(context.Background(), env, actx)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	ref, ok := outputRef(results[0], "content")
+	if !ok {
+		t.Fatalf("expected output ref, metadata = %v", results[0].Metadata)
+	}
+	stored := store.outputs[ref.ID]
+	if stored == nil || stored.Content != content || stored.BeadID != "bead-1" || stored.Field != "content" {
+		t.Fatalf("stored output = %+v", stored)
+	}
+
+	msg := FormatResultsAsUserMessage(results[:1])
+	if !strings.Contains(msg, "result "+ref.ID) || !strings.Contains(msg, "/api/v1/results/"+ref.ID) {
+		t.Errorf("feedback missing result reference: %s", msg)
+	}
+
+	r.Files = &mockFileManager{}
+	results, err = r.Execute(context.Background(), &ActionEnvelope{Actions: env.Actions[1:]}, actx)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if _, ok := results[0].Metadata["output_refs"]; ok {
+		t.Errorf("short output should not be stored: %v", results[0].Metadata)
+	}
+}
+
+func TestRouterReadResult(t *testing.T) {
+	content := strings.Repeat("0123456789", 10)
+	store := &mockOutputStore{}
+	_ = store.SaveActionOutput(&models.ActionOutput{ID: "r-1", ActionType: ActionRunTests, Field: "output", Size: int64(len(content)), Content: content})
+	r := &Router{Outputs: store}
+
+	env := &ActionEnvelope{Actions: []Action{{Type: ActionReadResult, ResultID: "r-1", Offset: 10, Limit: 25}}}
+	if err := Validate(env); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	res := r.executeAction(context.Background(), env.Actions[0], ActionContext{})
+	if res.Status != "executed" {
+		t.Fatalf("status %s (%s)", res.Status, res.Message)
+	}
+	if res.Metadata["content"] != content[10:35] || res.Metadata["next_offset"] != int64(35) || res.Metadata["eof"] != false {
+		t.Errorf("page = %v", res.Metadata)
+	}
+
+	res = r.executeAction(context.Background(), Action{Type: ActionReadResult, ResultID: "r-1", Offset: -20}, ActionContext{})
+	if res.Metadata["content"] != content[80:] || res.Metadata["eof"] != true {
+		t.Errorf("tail page = %v", res.Metadata)
+	}
+
+	msg := FormatResultsAsUserMessage([]Result{res})
+	if !strings.Contains(msg, content[80:]) {
+		t.Errorf("feedback missing chunk: %s", msg)
+	}
+
+	res = r.executeAction(context.Background(), Action{Type: ActionReadResult, ResultID: "r-missing"}, ActionContext{})
+	if res.Status != "error" {
+		t.Errorf("expected error for unknown result, got %s", res.Status)
+	}
+
+	if err := Validate(&ActionEnvelope{Actions: []Action{{Type: ActionReadResult}}}); err == nil {
+		t.Error("expected validation error without result_id")
+	}
+}
+
+func TestTrimPartialRune(t *testing.T) {
+	s := "abcé世"
+	if got := trimPartialRune(s[:len(s)-1]); got != "abcé" {
+		t.Errorf("trimPartialRune = %q", got)
+	}
+	if got := trimPartialRune(s); got != s {
+		t.Errorf("trimPartialRune(full) = %q", got)
+	}
+}
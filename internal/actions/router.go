@@ -3,11 +3,29 @@ package actions
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
-
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/attachments"
+	"github.com/jordanhubbard/loom/internal/benchmarks"
+	"github.com/jordanhubbard/loom/internal/browser"
+	"github.com/jordanhubbard/loom/internal/containers"
+	"github.com/jordanhubbard/loom/internal/dependencies"
 	"github.com/jordanhubbard/loom/internal/executor"
+	"github.com/jordanhubbard/loom/internal/features"
 	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/httpcheck"
+	"github.com/jordanhubbard/loom/internal/impact"
+	"github.com/jordanhubbard/loom/internal/k8s"
+	"github.com/jordanhubbard/loom/internal/mcp"
+	"github.com/jordanhubbard/loom/internal/profiling"
+	"github.com/jordanhubbard/loom/internal/securityscan"
+	"github.com/jordanhubbard/loom/internal/testdb"
+	"github.com/jordanhubbard/loom/internal/testselect"
+	"github.com/jordanhubbard/loom/internal/toolchain"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
@@ -27,6 +45,20 @@ type CommandExecutor interface {
 	ExecuteCommand(ctx context.Context, req executor.ExecuteCommandRequest) (*executor.ExecuteCommandResult, error)
 }
 
+type SessionOperator interface {
+	OpenSession(ctx context.Context, req executor.OpenSessionRequest) (*executor.SessionInfo, error)
+	SendInput(id string, input []byte) error
+	ReadOutput(ctx context.Context, id string, maxBytes int, wait time.Duration) (*executor.SessionOutput, error)
+	CloseSession(id string) (*executor.SessionInfo, error)
+}
+
+type JobRunner interface {
+	StartJob(ctx context.Context, req executor.ExecuteCommandRequest) (*executor.JobInfo, error)
+	GetJob(id string) (*executor.JobInfo, error)
+	ReadLog(ctx context.Context, id string, offset int64, limit int, wait time.Duration) (*executor.JobLog, error)
+	CancelJob(id string) (*executor.JobInfo, error)
+}
+
 type TestRunner interface {
 	Run(ctx context.Context, projectPath string, testPattern, framework string, timeoutSeconds int) (map[string]interface{}, error)
 }
@@ -39,6 +71,101 @@ type BuildRunner interface {
 	Run(ctx context.Context, projectPath, buildTarget, buildCommand, framework string, timeoutSeconds int) (map[string]interface{}, error)
 }
 
+type CodeFormatter interface {
+	Format(ctx context.Context, projectID, path, content, tool string) (string, error)
+	ChangedFiles(ctx context.Context, projectID string) ([]string, error)
+}
+
+type SecurityScanner interface {
+	Scan(ctx context.Context, projectID string, opts securityscan.Options) (*models.SecurityScan, error)
+}
+
+// ContainerRuntime builds project images and runs smoke-test containers
+type ContainerRuntime interface {
+	Build(ctx context.Context, projectID string, opts containers.BuildOptions) (*containers.BuildResult, error)
+	Run(ctx context.Context, projectID string, opts containers.RunOptions) (*containers.RunResult, error)
+}
+
+// HTTPChecker probes HTTP endpoints
+type HTTPChecker interface {
+	Run(ctx context.Context, req httpcheck.Request) (*httpcheck.Result, error)
+}
+
+// BrowserDriver runs headless browser sessions against a project's web UI
+type BrowserDriver interface {
+	Run(ctx context.Context, projectID string, s browser.Session) (*browser.Result, error)
+}
+
+// TestDatabases gives beads throwaway databases to migrate, load fixtures
+// into and query
+type TestDatabases interface {
+	Create(ctx context.Context, projectID, beadID, engine string) (*testdb.Database, error)
+	Migrate(ctx context.Context, beadID string, opts testdb.MigrateOptions) (*testdb.MigrateResult, error)
+	LoadFixtures(ctx context.Context, beadID string, paths []string) (*testdb.FixtureResult, error)
+	Assert(ctx context.Context, beadID string, assertions []testdb.Assertion) ([]testdb.AssertionResult, error)
+	Drop(ctx context.Context, beadID string) error
+}
+
+// BenchmarkRunner runs a project's benchmarks and compares them with the
+// baseline branch
+type BenchmarkRunner interface {
+	Run(ctx context.Context, projectID, beadID string, opts benchmarks.Options) (*benchmarks.Result, error)
+}
+
+// Profiler runs a project's test or binary under the CPU and heap profilers
+// and keeps the profiles on the bead
+type Profiler interface {
+	Run(ctx context.Context, projectID, beadID string, opts profiling.Options) (*profiling.Result, error)
+}
+
+// ImpactAnalyzer estimates which packages, tests and public declarations a
+// change to the project affects
+type ImpactAnalyzer interface {
+	Analyze(ctx context.Context, projectID string, req impact.Request) (*impact.Report, error)
+}
+
+// TestSelector picks the tests covering a change for run_tests in smart
+// mode and keeps count of the runs
+type TestSelector interface {
+	Select(ctx context.Context, projectID string, files []string, framework string) *testselect.Selection
+	Record(projectID, beadID, mode string, success bool)
+}
+
+// StagingDeployer deploys a bead's build into its own Kubernetes namespace
+type StagingDeployer interface {
+	Deploy(ctx context.Context, projectID, beadID string, opts k8s.DeployOptions) (*k8s.Deployment, error)
+	Status(ctx context.Context, projectID, beadID string) (*k8s.Deployment, error)
+	Teardown(ctx context.Context, projectID, beadID string) error
+}
+
+// ImageAttacher attaches images from a project's workdir, or captured by
+// actions, to beads
+type ImageAttacher interface {
+	AttachFile(ctx context.Context, projectID, beadID, path string) (*attachments.Image, error)
+	Attach(beadID, name, mediaType string, data []byte, source string) (*attachments.Image, error)
+}
+
+// MCPTools lists and calls the tools of a project's MCP servers
+type MCPTools interface {
+	ListTools(ctx context.Context, projectID string) []mcp.ServerTools
+	CallTool(ctx context.Context, projectID, server, tool string, args map[string]interface{}) (*mcp.CallResult, error)
+}
+
+type DependencyChecker interface {
+	Outdated(ctx context.Context, projectID string, opts dependencies.Options) (*dependencies.Report, error)
+	FileBeads(report *dependencies.Report, mode string) ([]string, error)
+}
+
+type CIMonitor interface {
+	Watch(projectID, beadID, branch string)
+	Status(ctx context.Context, projectID, beadID, branch string) (*models.CIRun, error)
+}
+
+// RolePolicy restricts the action types an agent's role may execute
+type RolePolicy interface {
+	CheckAction(agentID, actionType string) error
+}
+
 type FileManager interface {
 	ReadFile(ctx context.Context, projectID, path string) (*files.FileResult, error)
 	WriteFile(ctx context.Context, projectID, path, content string) (*files.WriteResult, error)
@@ -69,6 +196,15 @@ type GitOperator interface {
 	ListBranches(ctx context.Context) (map[string]interface{}, error)
 	DiffBranches(ctx context.Context, branch1, branch2 string) (map[string]interface{}, error)
 	GetBeadCommits(ctx context.Context, beadID string) (map[string]interface{}, error)
+	DiffRevisions(ctx context.Context, from, to, path string, contextLines int) (map[string]interface{}, error)
+	Blame(ctx context.Context, path string, startLine, endLine int) (map[string]interface{}, error)
+	CreateTag(ctx context.Context, beadID, tag, message, ref string) (map[string]interface{}, error)
+	Changelog(ctx context.Context, from, to, version, path string) (map[string]interface{}, error)
+	BumpVersion(ctx context.Context, beadID, bump, version string, files []string) (map[string]interface{}, error)
+}
+
+type WorkspaceSnapshotter interface {
+	CreateSnapshot(ctx context.Context, projectID, label string) (*files.Snapshot, error)
 }
 
 type ActionLogger interface {
@@ -90,38 +226,86 @@ type MessageSender interface {
 }
 
 type ActionContext struct {
-	AgentID   string
-	BeadID    string
-	ProjectID string
+	AgentID    string
+	BeadID     string
+	ProjectID  string
+	Subproject string // Monorepo subproject the bead is scoped to, if any
 }
 
 type Result struct {
 	ActionType string                 `json:"action_type"`
 	Status     string                 `json:"status"`
 	Message    string                 `json:"message"`
+	Code       apperr.Code            `json:"code,omitempty"` // Error class of a failed action
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type Router struct {
 	Beads        BeadCreator
 	Closer       BeadCloser
+	Delegations  TaskDelegator
 	Escalator    BeadEscalator
 	Commands     CommandExecutor
+	Sessions     SessionOperator
+	Jobs         JobRunner
 	Tests        TestRunner
 	Linter       LinterRunner
 	Builder      BuildRunner
+	Formatter    CodeFormatter
+	Security     SecurityScanner
+	Dependencies DependencyChecker
+	Containers   ContainerRuntime
+	Staging      StagingDeployer
+	HTTPChecks   HTTPChecker
+	Browser      BrowserDriver
+	Databases    TestDatabases
+	Benchmarks   BenchmarkRunner
+	Profiler     Profiler
+	Impact       ImpactAnalyzer
+	Selector     TestSelector
+	CI           CIMonitor
+	Outputs      OutputStore
+	Roles        RolePolicy
 	Files        FileManager
 	Git          GitOperator
 	Logger       ActionLogger
 	Workflow     WorkflowOperator
 	LSP          LSPOperator
 	MessageBus   MessageSender
+	Inbox        MessageInbox
+	Progress     ProgressReporter
+	Snapshots    WorkspaceSnapshotter
+	Projects     ProjectLookup
+	MCP          MCPTools
+	Images       ImageAttacher
+	Features     features.Gate
+	Policies     PolicyEvaluator
+	PolicyFacts  PolicyFacts
+	Approvals    ActionApprover
+	Locks        FileLocker
+	ReadOnly     ReadOnlyGate
 	BeadType     string
 	BeadTags     []string
 	DefaultP0 bool
+	// SecretBeads files a remediation bead when the secrets gate blocks a
+	// commit or push
+	SecretBeads bool
+	// AutoSnapshotPatchBytes snapshots the workdir before envelopes whose
+	// apply_patch actions total at least this many bytes (0 disables)
+	AutoSnapshotPatchBytes int
+	// Timeouts bounds execution per action type; DefaultTimeout applies to
+	// types without an entry (0 disables)
+	Timeouts       map[string]time.Duration
+	DefaultTimeout time.Duration
 }
 
 func (r *Router) Execute(ctx context.Context, env *ActionEnvelope, actx ActionContext) ([]Result, error) {
+	return r.execute(ctx, env, actx, nil)
+}
+
+// execute runs an envelope, using prefetched results (by action index) for
+// actions that were already executed while the response streamed
+func (r *Router) execute(ctx context.Context, env *ActionEnvelope, actx ActionContext, prefetched map[int]Result) ([]Result, error) {
 	if env == nil {
 		return nil, fmt.Errorf("action envelope is nil")
 	}
@@ -131,9 +315,55 @@ func (r *Router) Execute(ctx context.Context, env *ActionEnvelope, actx ActionCo
 		ctx = WithProjectID(ctx, actx.ProjectID)
 	}
 
+	snapshotID := r.autoSnapshot(ctx, env, actx)
+
+	// With parallel_actions on, runs of read-only actions execute together
+	// when the loop reaches them and are then handled like prefetched ones
+	parallel := r.parallelEnabled(actx)
+	if parallel {
+		ready := make(map[int]Result, len(prefetched))
+		for i, result := range prefetched {
+			ready[i] = result
+		}
+		prefetched = ready
+	}
+
 	results := make([]Result, 0, len(env.Actions))
-	for _, action := range env.Actions {
-		result := r.executeAction(ctx, action, actx)
+	for i, action := range env.Actions {
+		if parallel && ctx.Err() == nil {
+			for j, result := range r.executeReadRun(ctx, env, i, actx, prefetched) {
+				prefetched[j] = result
+			}
+		}
+		actionCtx := withProgressScope(ctx, i, len(env.Actions))
+		r.reportProgress(actionCtx, actx, ProgressEvent{ActionType: action.Type, Phase: ProgressStarted, Path: firstPath(action)})
+		started := time.Now()
+		result, ok := prefetched[i]
+		switch {
+		case ok:
+		case ctx.Err() != nil:
+			// Don't start further actions once the caller has gone away
+			result = cancelledResult(action.Type, ctx.Err())
+		default:
+			result = r.executeWithTimeout(actionCtx, action, actx)
+		}
+		if result.Status == "error" && result.Code == "" {
+			result.Code = apperr.Classify(result.Message)
+		}
+		r.storeLargeOutputs(&result, actx)
+		if snapshotID != "" && action.Type == ActionApplyPatch {
+			if result.Metadata == nil {
+				result.Metadata = map[string]interface{}{}
+			}
+			result.Metadata["snapshot_id"] = snapshotID
+		}
+		r.reportProgress(actionCtx, actx, ProgressEvent{
+			ActionType: action.Type,
+			Phase:      ProgressCompleted,
+			Status:     result.Status,
+			Message:    result.Message,
+			DurationMs: time.Since(started).Milliseconds(),
+		})
 		if r.Logger != nil {
 			r.Logger.LogAction(ctx, actx, action, result)
 		}
@@ -143,6 +373,32 @@ func (r *Router) Execute(ctx context.Context, env *ActionEnvelope, actx ActionCo
 	return results, nil
 }
 
+// executeAllowedAction executes an action unless the agent's role or the
+// action policies forbid it
+func (r *Router) executeAllowedAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if blocked := r.checkReadOnly(action); blocked != nil {
+		return *blocked
+	}
+	if r.Roles != nil {
+		if err := r.Roles.CheckAction(actx.AgentID, action.Type); err != nil {
+			return Result{
+				ActionType: action.Type,
+				Status:     "error",
+				Message:    err.Error(),
+				Code:       apperr.CodePolicyDenied,
+				Metadata:   map[string]interface{}{"error_type": "permission_denied"},
+			}
+		}
+	}
+	if blocked := r.checkPolicy(ctx, action, actx); blocked != nil {
+		return *blocked
+	}
+	if blocked := r.checkFileLocks(ctx, action, actx); blocked != nil {
+		return *blocked
+	}
+	return r.executeAction(ctx, action, actx)
+}
+
 func (r *Router) AutoFileParseFailure(ctx context.Context, actx ActionContext, err error, raw string) Result {
 	if r.Beads == nil {
 		return Result{ActionType: ActionCreateBead, Status: "error", Message: "bead creator not configured"}
@@ -154,7 +410,7 @@ func (r *Router) AutoFileParseFailure(ctx context.Context, actx ActionContext, e
 	description := fmt.Sprintf("Failed to parse strict JSON actions.\n\nError:\n%s\n\nRaw response:\n%s", err.Error(), raw)
 	bead, beadErr := r.Beads.CreateBead("Action parse failed", description, priority, "bug", actx.ProjectID)
 	if beadErr != nil {
-		return Result{ActionType: ActionCreateBead, Status: "error", Message: beadErr.Error()}
+		return errorResult(ActionCreateBead, beadErr)
 	}
 	result := Result{
 		ActionType: ActionCreateBead,
@@ -178,7 +434,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		res, err := r.Files.ReadFile(ctx, actx.ProjectID, action.Path)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -194,6 +450,11 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		if r.Files == nil {
 			return r.createBeadFromAction("Edit code", fmt.Sprintf("%s\n\nPatch:\n%s", action.Path, action.Patch), actx)
 		}
+		// Line-range EDIT: replace lines StartLine-EndLine with NewText
+		if action.StartLine > 0 && action.OldText == "" && action.Patch == "" && action.Path != "" {
+			return r.applyFileEdits(ctx, action.Type, action.Path,
+				[]EditOp{{StartLine: action.StartLine, EndLine: action.EndLine, NewText: action.NewText}}, actx)
+		}
 		// Text-based EDIT: use OldText/NewText with multi-strategy matching
 		if action.OldText != "" && action.Path != "" {
 			res, readErr := r.Files.ReadFile(ctx, actx.ProjectID, action.Path)
@@ -224,26 +485,18 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		// Legacy: unified diff patch
 		res, err := r.Files.ApplyPatch(ctx, actx.ProjectID, action.Patch)
-		if err != nil {
-			message := err.Error()
-			if res != nil && res.Output != "" {
-				message = fmt.Sprintf("%s: %s", message, res.Output)
-			}
-			return Result{ActionType: action.Type, Status: "error", Message: message}
-		}
-		return Result{
-			ActionType: action.Type,
-			Status:     "executed",
-			Message:    "patch applied",
-			Metadata:   map[string]interface{}{"output": res.Output},
-		}
+		return patchResult(action.Type, res, err)
+	case ActionMultiEdit:
+		return r.applyFileEdits(ctx, action.Type, action.Path, action.Edits, actx)
+	case ActionAddImport, ActionInsertFunctionAfter, ActionAddStructField:
+		return r.handleGoEdit(ctx, action, actx)
 	case ActionWriteFile:
 		if r.Files == nil {
 			return r.createBeadFromAction("Write file", fmt.Sprintf("%s\n\nContent:\n%s", action.Path, truncateContent(action.Content, 500)), actx)
 		}
 		res, err := r.Files.WriteFile(ctx, actx.ProjectID, action.Path, action.Content)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -260,7 +513,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		res, err := r.Files.ReadFile(ctx, actx.ProjectID, action.Path)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -276,13 +529,17 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		if r.Files == nil {
 			return Result{ActionType: action.Type, Status: "error", Message: "file manager not configured"}
 		}
-		path := action.Path
+		_, sp, err := r.resolveSubproject(action, actx)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		treeCtx, path := scopeFileWalk(ctx, sp, action.Path)
 		if path == "" {
 			path = "."
 		}
-		res, err := r.Files.ReadTree(ctx, actx.ProjectID, path, action.MaxDepth, action.Limit)
+		res, err := r.Files.ReadTree(treeCtx, actx.ProjectID, path, action.MaxDepth, action.Limit)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -294,45 +551,42 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		if r.Files == nil {
 			return Result{ActionType: action.Type, Status: "error", Message: "file manager not configured"}
 		}
-		path := action.Path
+		_, sp, err := r.resolveSubproject(action, actx)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		searchCtx, path := scopeFileWalk(ctx, sp, action.Path)
 		if path == "" {
 			path = "."
 		}
-		res, err := r.Files.SearchText(ctx, actx.ProjectID, path, action.Query, action.Limit)
+		res, err := r.Files.SearchText(searchCtx, actx.ProjectID, path, action.Query, action.Limit)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
+		}
+		metadata := map[string]interface{}{"matches": res}
+		if sp != nil {
+			metadata["subproject"] = sp.Name
+			metadata["path"] = path
 		}
 		return Result{
 			ActionType: action.Type,
 			Status:     "executed",
 			Message:    "search completed",
-			Metadata:   map[string]interface{}{"matches": res},
+			Metadata:   metadata,
 		}
 	case ActionApplyPatch:
 		if r.Files == nil {
 			return Result{ActionType: action.Type, Status: "error", Message: "file manager not configured"}
 		}
 		res, err := r.Files.ApplyPatch(ctx, actx.ProjectID, action.Patch)
-		if err != nil {
-			message := err.Error()
-			if res != nil && res.Output != "" {
-				message = fmt.Sprintf("%s: %s", message, res.Output)
-			}
-			return Result{ActionType: action.Type, Status: "error", Message: message}
-		}
-		return Result{
-			ActionType: action.Type,
-			Status:     "executed",
-			Message:    "patch applied",
-			Metadata:   map[string]interface{}{"output": res.Output},
-		}
+		return patchResult(action.Type, res, err)
 	case ActionGitStatus:
 		if r.Git == nil {
 			return Result{ActionType: action.Type, Status: "error", Message: "git operator not configured"}
 		}
 		out, err := r.Git.Status(ctx, actx.ProjectID)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -346,7 +600,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		out, err := r.Git.Diff(ctx, actx.ProjectID)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -369,7 +623,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 
 		result, err := r.Git.Commit(ctx, actx.BeadID, actx.AgentID, message, action.Files, len(action.Files) == 0)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return r.gitErrorResult(action.Type, err, actx)
 		}
 
 		return Result{
@@ -385,7 +639,12 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 
 		result, err := r.Git.Push(ctx, actx.BeadID, action.Branch, action.SetUpstream)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return r.gitErrorResult(action.Type, err, actx)
+		}
+		if r.CI != nil {
+			if branch, _ := result["branch"].(string); branch != "" {
+				r.CI.Watch(actx.ProjectID, actx.BeadID, branch)
+			}
 		}
 
 		return Result{
@@ -417,7 +676,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 
 		result, err := r.Git.CreatePR(ctx, actx.BeadID, title, body, base, action.Branch, action.PRReviewers, false)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 
 		return Result{
@@ -437,7 +696,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		result, err := r.Git.Merge(ctx, actx.BeadID, action.SourceBranch, action.CommitMessage, noFF)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{ActionType: action.Type, Status: "executed", Message: "branch merged", Metadata: result}
 
@@ -451,7 +710,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		result, err := r.Git.Revert(ctx, actx.BeadID, shas, action.Reason)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{ActionType: action.Type, Status: "executed", Message: "commits reverted", Metadata: result}
 
@@ -461,7 +720,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		result, err := r.Git.DeleteBranch(ctx, action.Branch, action.DeleteRemote)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{ActionType: action.Type, Status: "executed", Message: "branch deleted", Metadata: result}
 
@@ -471,7 +730,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		result, err := r.Git.Checkout(ctx, action.Branch)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{ActionType: action.Type, Status: "executed", Message: fmt.Sprintf("switched to %s", action.Branch), Metadata: result}
 
@@ -481,7 +740,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		result, err := r.Git.Log(ctx, action.Branch, action.MaxCount)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{ActionType: action.Type, Status: "executed", Message: "log retrieved", Metadata: result}
 
@@ -491,7 +750,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		result, err := r.Git.Fetch(ctx)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{ActionType: action.Type, Status: "executed", Message: "fetch completed", Metadata: result}
 
@@ -501,7 +760,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		result, err := r.Git.ListBranches(ctx)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{ActionType: action.Type, Status: "executed", Message: "branches listed", Metadata: result}
 
@@ -511,10 +770,60 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		result, err := r.Git.DiffBranches(ctx, action.SourceBranch, action.TargetBranch)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{ActionType: action.Type, Status: "executed", Message: "branch diff retrieved", Metadata: result}
 
+	case ActionGitDiffRevisions:
+		if r.Git == nil {
+			return Result{ActionType: action.Type, Status: "error", Message: "git operator not configured"}
+		}
+		result, err := r.Git.DiffRevisions(ctx, action.FromRef, action.ToRef, action.Path, action.ContextLines)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		return Result{ActionType: action.Type, Status: "executed", Message: "revision diff retrieved", Metadata: result}
+
+	case ActionGitBlame:
+		if r.Git == nil {
+			return Result{ActionType: action.Type, Status: "error", Message: "git operator not configured"}
+		}
+		result, err := r.Git.Blame(ctx, action.Path, action.StartLine, action.EndLine)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		return Result{ActionType: action.Type, Status: "executed", Message: "blame retrieved", Metadata: result}
+
+	case ActionGitTag:
+		if r.Git == nil {
+			return Result{ActionType: action.Type, Status: "error", Message: "git operator not configured"}
+		}
+		result, err := r.Git.CreateTag(ctx, actx.BeadID, action.Tag, action.CommitMessage, action.CommitSHA)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		return Result{ActionType: action.Type, Status: "executed", Message: "tag created", Metadata: result}
+
+	case ActionGitChangelog:
+		if r.Git == nil {
+			return Result{ActionType: action.Type, Status: "error", Message: "git operator not configured"}
+		}
+		result, err := r.Git.Changelog(ctx, action.FromRef, action.ToRef, action.Version, action.Path)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		return Result{ActionType: action.Type, Status: "executed", Message: "changelog generated", Metadata: result}
+
+	case ActionBumpVersion:
+		if r.Git == nil {
+			return Result{ActionType: action.Type, Status: "error", Message: "git operator not configured"}
+		}
+		result, err := r.Git.BumpVersion(ctx, actx.BeadID, action.Bump, action.Version, action.Files)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		return Result{ActionType: action.Type, Status: "executed", Message: "version bumped", Metadata: result}
+
 	case ActionGitBeadCommits:
 		if r.Git == nil {
 			return Result{ActionType: action.Type, Status: "error", Message: "git operator not configured"}
@@ -525,7 +834,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		result, err := r.Git.GetBeadCommits(ctx, beadID)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{ActionType: action.Type, Status: "executed", Message: "bead commits retrieved", Metadata: result}
 
@@ -543,10 +852,11 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 				"action_type": action.Type,
 				"reason":      action.Reason,
 			},
+			OnOutput: r.commandOutputHandler(ctx, actx, action.Type),
 		}
 		res, err := r.Commands.ExecuteCommand(ctx, req)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return executorErrorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -557,35 +867,31 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 				"exit_code":  res.ExitCode,
 			},
 		}
+	case ActionOpenSession, ActionSessionInput, ActionSessionRead, ActionCloseSession:
+		return r.handleSessionAction(ctx, action, actx)
+	case ActionStartCommand, ActionJobStatus, ActionJobLogs, ActionCancelJob:
+		return r.handleJobAction(ctx, action, actx)
 	case ActionRunTests:
-		if r.Tests == nil {
-			return Result{ActionType: action.Type, Status: "error", Message: "test runner not configured"}
-		}
-		// Get project path from Files manager or use default
-		projectPath := "."
-		// TODO: Get actual project path from context or Files manager
-
-		result, err := r.Tests.Run(ctx, projectPath, action.TestPattern, action.Framework, action.TimeoutSeconds)
+		return r.handleRunTests(ctx, action, actx)
+	case ActionRunLinter:
+		project, sp, err := r.resolveSubproject(action, actx)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
-		return Result{
-			ActionType: action.Type,
-			Status:     "executed",
-			Message:    "tests executed",
-			Metadata:   result,
+		if command, source := toolchainCommand(project, sp, toolchain.Lint); len(action.Files) == 0 && r.preferToolchainCommand(command, source, r.Linter != nil) {
+			return r.runToolchainCommand(ctx, action, actx, project, sp, command, source, "linter executed")
 		}
-	case ActionRunLinter:
 		if r.Linter == nil {
 			return Result{ActionType: action.Type, Status: "error", Message: "linter not configured"}
 		}
-		// Get project path from Files manager or use default
 		projectPath := "."
-		// TODO: Get actual project path from context or Files manager
+		if sp != nil {
+			projectPath = subprojectDir(project, sp)
+		}
 
 		result, err := r.Linter.Run(ctx, projectPath, action.Files, action.Framework, action.TimeoutSeconds)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -594,16 +900,28 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 			Metadata:   result,
 		}
 	case ActionBuildProject:
+		project, sp, err := r.resolveSubproject(action, actx)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		if command, source := toolchainCommand(project, sp, toolchain.Build); action.BuildCommand == "" && action.BuildTarget == "" && r.preferToolchainCommand(command, source, r.Builder != nil) {
+			return r.runToolchainCommand(ctx, action, actx, project, sp, command, source, "build executed")
+		}
 		if r.Builder == nil {
 			return Result{ActionType: action.Type, Status: "error", Message: "builder not configured"}
 		}
-		// Get project path from Files manager or use default
 		projectPath := "."
-		// TODO: Get actual project path from context or Files manager
+		buildCommand := action.BuildCommand
+		if sp != nil {
+			projectPath = subprojectDir(project, sp)
+			if buildCommand == "" {
+				buildCommand = sp.BuildCommand
+			}
+		}
 
-		result, err := r.Builder.Run(ctx, projectPath, action.BuildTarget, action.BuildCommand, action.Framework, action.TimeoutSeconds)
+		result, err := r.Builder.Run(ctx, projectPath, action.BuildTarget, buildCommand, action.Framework, action.TimeoutSeconds)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -611,6 +929,34 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 			Message:    "build executed",
 			Metadata:   result,
 		}
+	case ActionRunFormatter:
+		return r.handleFormatAction(ctx, action, actx)
+	case ActionRunSecurityScan:
+		return r.handleSecurityScanAction(ctx, action, actx)
+	case ActionCheckDependencies:
+		return r.handleDependencyAction(ctx, action, actx)
+	case ActionBuildImage:
+		return r.handleBuildImageAction(ctx, action, actx)
+	case ActionRunContainer:
+		return r.handleRunContainerAction(ctx, action, actx)
+	case ActionDeployStaging, ActionStagingStatus, ActionTeardownStaging:
+		return r.handleStagingAction(ctx, action, actx)
+	case ActionHTTPCheck:
+		return r.handleHTTPCheckAction(ctx, action)
+	case ActionBrowser:
+		return r.handleBrowserAction(ctx, action, actx)
+	case ActionDBCreate, ActionDBMigrate, ActionDBLoadFixtures, ActionDBAssert, ActionDBDrop:
+		return r.handleTestDBAction(ctx, action, actx)
+	case ActionRunBenchmarks:
+		return r.handleBenchmarkAction(ctx, action, actx)
+	case ActionProfileProject:
+		return r.handleProfileAction(ctx, action, actx)
+	case ActionAnalyzeImpact:
+		return r.handleImpactAction(ctx, action, actx)
+	case ActionCheckCI:
+		return r.handleCIAction(ctx, action, actx)
+	case ActionReadResult:
+		return r.handleReadResultAction(ctx, action, actx)
 	case ActionCreateBead:
 		if action.Bead == nil {
 			return Result{ActionType: action.Type, Status: "error", Message: "missing bead payload"}
@@ -628,7 +974,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		priority := models.BeadPriority(action.Bead.Priority)
 		bead, err := r.Beads.CreateBead(action.Bead.Title, action.Bead.Description, priority, beadType, action.Bead.ProjectID)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -642,7 +988,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		err := r.Closer.CloseBead(action.BeadID, action.Reason)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -656,7 +1002,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		decision, err := r.Escalator.EscalateBeadToCEO(action.BeadID, action.Reason, action.ReturnedTo)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -675,7 +1021,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		err := r.Workflow.AdvanceWorkflowWithCondition(action.BeadID, actx.AgentID, "approved", resultData)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -694,7 +1040,7 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 		err := r.Workflow.AdvanceWorkflowWithCondition(action.BeadID, actx.AgentID, "rejected", resultData)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 		return Result{
 			ActionType: action.Type,
@@ -760,9 +1106,9 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 			return Result{ActionType: action.Type, Status: "error", Message: "LSP operator not configured"}
 		}
 
-		result, err := r.LSP.FindReferences(ctx, action.Path, action.Line, action.Column, action.Symbol)
+		result, err := r.LSP.FindReferences(r.lspContext(ctx, action, actx), action.Path, action.Line, action.Column, action.Symbol)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 
 		return Result{
@@ -776,9 +1122,9 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 			return Result{ActionType: action.Type, Status: "error", Message: "LSP operator not configured"}
 		}
 
-		result, err := r.LSP.GoToDefinition(ctx, action.Path, action.Line, action.Column, action.Symbol)
+		result, err := r.LSP.GoToDefinition(r.lspContext(ctx, action, actx), action.Path, action.Line, action.Column, action.Symbol)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 
 		message := "Definition not found"
@@ -797,9 +1143,9 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 			return Result{ActionType: action.Type, Status: "error", Message: "LSP operator not configured"}
 		}
 
-		result, err := r.LSP.FindImplementations(ctx, action.Path, action.Line, action.Column, action.Symbol)
+		result, err := r.LSP.FindImplementations(r.lspContext(ctx, action, actx), action.Path, action.Line, action.Column, action.Symbol)
 		if err != nil {
-			return Result{ActionType: action.Type, Status: "error", Message: err.Error()}
+			return errorResult(action.Type, err)
 		}
 
 		return Result{
@@ -954,8 +1300,16 @@ func (r *Router) executeAction(ctx context.Context, action Action, actx ActionCo
 		}
 	case ActionSendAgentMessage:
 		return r.handleSendAgentMessage(ctx, action, actx)
+	case ActionReadAgentMessages:
+		return r.handleReadAgentMessages(ctx, action, actx)
 	case ActionDelegateTask:
 		return r.handleDelegateTask(ctx, action, actx)
+	case ActionMCPListTools:
+		return r.handleMCPListTools(ctx, action, actx)
+	case ActionMCPCall:
+		return r.handleMCPCall(ctx, action, actx)
+	case ActionAttachImage:
+		return r.handleAttachImage(ctx, action, actx)
 
 	default:
 		return Result{ActionType: action.Type, Status: "error", Message: "unsupported action"}
@@ -976,7 +1330,7 @@ func (r *Router) createBeadFromAction(title, detail string, actx ActionContext)
 	}
 	bead, err := r.Beads.CreateBead(title, detail, priority, beadType, actx.ProjectID)
 	if err != nil {
-		return Result{ActionType: ActionCreateBead, Status: "error", Message: err.Error()}
+		return errorResult(ActionCreateBead, err)
 	}
 	return Result{
 		ActionType: ActionCreateBead,
@@ -1280,7 +1634,7 @@ func (r *Router) handleSendAgentMessage(ctx context.Context, action Action, actx
 		action.MessageType,
 		action.MessageSubject,
 		action.MessageBody,
-		action.MessagePayload,
+		messagePayload(action.MessagePayload, actx.BeadID),
 	)
 	if err != nil {
 		return Result{ActionType: action.Type, Status: "error", Message: fmt.Sprintf("failed to send message: %v", err)}
@@ -1315,6 +1669,14 @@ func (r *Router) handleDelegateTask(ctx context.Context, action Action, actx Act
 		return Result{ActionType: action.Type, Status: "error", Message: "task_title is required"}
 	}
 
+	strategy := action.DelegationStrategy
+	if strategy == "" {
+		strategy = DelegationContinue
+	}
+	if strategy != DelegationContinue && strategy != DelegationBlock {
+		return Result{ActionType: action.Type, Status: "error", Message: "delegation_strategy must be one of: continue, block"}
+	}
+
 	if r.Beads == nil {
 		return Result{ActionType: action.Type, Status: "error", Message: "bead creator not configured"}
 	}
@@ -1349,10 +1711,29 @@ func (r *Router) handleDelegateTask(ctx context.Context, action Action, actx Act
 		parentBeadID = actx.BeadID // Use current bead as parent if not specified
 	}
 
+	// Assign the child and link it to the parent
+	assignedTo := ""
+	if r.Delegations != nil {
+		assignedTo, err = r.Delegations.Delegate(ctx, Delegation{
+			ParentBeadID: parentBeadID,
+			ChildBeadID:  childBead.ID,
+			Role:         action.DelegateToRole,
+			AgentID:      action.ToAgentID,
+			Strategy:     strategy,
+			DelegatorID:  actx.AgentID,
+		})
+		if err != nil {
+			return Result{ActionType: action.Type, Status: "error", Message: fmt.Sprintf("created child bead %s but failed to delegate it: %v", childBead.ID, err)}
+		}
+	}
+
 	// Build result message
 	resultMessage := fmt.Sprintf("Delegated task '%s' to %s (child bead: %s)", action.TaskTitle, action.DelegateToRole, childBead.ID)
 	if parentBeadID != "" {
 		resultMessage = fmt.Sprintf("Delegated task '%s' to %s (parent: %s, child: %s)", action.TaskTitle, action.DelegateToRole, parentBeadID, childBead.ID)
+		if r.Delegations != nil && strategy == DelegationBlock {
+			resultMessage += fmt.Sprintf(". Bead %s is blocked until the child closes; its results will be added to this bead's context", parentBeadID)
+		}
 	}
 
 	return Result{
@@ -1360,11 +1741,69 @@ func (r *Router) handleDelegateTask(ctx context.Context, action Action, actx Act
 		Status:     "executed",
 		Message:    resultMessage,
 		Metadata: map[string]interface{}{
-			"child_bead_id":    childBead.ID,
-			"parent_bead_id":   parentBeadID,
-			"delegate_to_role": action.DelegateToRole,
-			"task_title":       action.TaskTitle,
-			"task_priority":    priority,
+			"child_bead_id":       childBead.ID,
+			"parent_bead_id":      parentBeadID,
+			"delegate_to_role":    action.DelegateToRole,
+			"task_title":          action.TaskTitle,
+			"task_priority":       priority,
+			"delegation_strategy": strategy,
+			"assigned_to":         assignedTo,
 		},
 	}
 }
+
+// errorResult converts an error into a failed Result carrying its code
+func errorResult(actionType string, err error) Result {
+	return Result{ActionType: actionType, Status: "error", Message: err.Error(), Code: apperr.CodeOf(err)}
+}
+
+// executorErrorResult converts an executor error into a Result, attaching
+// structured details when a project quota was hit.
+func executorErrorResult(actionType string, err error) Result {
+	res := errorResult(actionType, err)
+	var qerr *executor.QuotaExceededError
+	if errors.As(err, &qerr) {
+		res.Code = apperr.CodeRateLimited
+		res.Metadata = map[string]interface{}{
+			"error_type": "quota_exceeded",
+			"project_id": qerr.ProjectID,
+			"quota":      qerr.Quota,
+			"limit":      qerr.Limit,
+			"used":       qerr.Used,
+		}
+	}
+	return res
+}
+
+// patchResult converts an ApplyPatch outcome into a Result. When a fallback
+// strategy was used the per-hunk report is attached, so a partially applied
+// patch can be finished by resending only the failed hunks.
+func patchResult(actionType string, res *files.PatchResult, err error) Result {
+	if err != nil {
+		message := err.Error()
+		if res != nil && res.Output != "" {
+			message = fmt.Sprintf("%s: %s", message, res.Output)
+		}
+		out := Result{ActionType: actionType, Status: "error", Message: message}
+		if res != nil && len(res.Hunks) > 0 {
+			out.Metadata = map[string]interface{}{
+				"error_type": "patch_hunks_failed",
+				"strategy":   res.Strategy,
+				"hunks":      res.Hunks,
+			}
+		}
+		return out
+	}
+	out := Result{
+		ActionType: actionType,
+		Status:     "executed",
+		Message:    "patch applied",
+		Metadata:   map[string]interface{}{"output": res.Output},
+	}
+	if res.Strategy != "" && res.Strategy != files.PatchStrict {
+		out.Message = fmt.Sprintf("patch applied (%s)", res.Strategy)
+		out.Metadata["strategy"] = res.Strategy
+		out.Metadata["hunks"] = res.Hunks
+	}
+	return out
+}
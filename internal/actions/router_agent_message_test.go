@@ -154,6 +154,7 @@ func TestHandleSendAgentMessage_Notification(t *testing.T) {
 	msg := mockBus.sentMessages[0]
 	assert.Equal(t, "notification", msg.messageType)
 	assert.Equal(t, "build-789", msg.payload["build_id"])
+	assert.Equal(t, "bead-build-123", msg.payload["bead_id"], "messages are threaded under the sender's bead")
 }
 
 func TestHandleSendAgentMessage_ValidationErrors(t *testing.T) {
@@ -260,3 +261,39 @@ func TestHandleSendAgentMessage_SendError(t *testing.T) {
 	assert.Equal(t, "error", result.Status)
 	assert.Contains(t, result.Message, "failed to send message")
 }
+
+type mockMessageInbox struct {
+	messages []map[string]interface{}
+	agentID  string
+	beadID   string
+	limit    int
+}
+
+func (m *mockMessageInbox) ReadInbox(ctx context.Context, agentID, beadID string, limit int) ([]map[string]interface{}, error) {
+	m.agentID, m.beadID, m.limit = agentID, beadID, limit
+	return m.messages, nil
+}
+
+func TestHandleReadAgentMessages(t *testing.T) {
+	inbox := &mockMessageInbox{messages: []map[string]interface{}{{
+		"message_id": "msg-1", "type": "request", "from_agent_id": "agent-pm-1", "bead_id": "bead-1",
+		"subject": "Scope", "body": "Please confirm\nthe scope", "requires_response": true,
+	}}}
+	router := &Router{Inbox: inbox}
+
+	result := router.executeAction(context.Background(), Action{Type: ActionReadAgentMessages, BeadID: "bead-1", Limit: 500},
+		ActionContext{AgentID: "agent-eng-1"})
+
+	assert.Equal(t, "executed", result.Status)
+	assert.Equal(t, 1, result.Metadata["count"])
+	assert.Equal(t, "agent-eng-1", inbox.agentID)
+	assert.Equal(t, "bead-1", inbox.beadID)
+	assert.Equal(t, maxInboxMessages, inbox.limit)
+
+	feedback := FormatResultsAsUserMessage([]Result{result})
+	assert.Contains(t, feedback, "from `agent-pm-1` on `bead-1`: Scope")
+	assert.Contains(t, feedback, `in_reply_to = "msg-1"`)
+
+	result = (&Router{}).executeAction(context.Background(), Action{Type: ActionReadAgentMessages}, ActionContext{AgentID: "a"})
+	assert.Equal(t, "error", result.Status)
+}
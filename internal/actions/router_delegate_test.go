@@ -276,3 +276,47 @@ func TestHandleDelegateTask_AllPriorities(t *testing.T) {
 		assert.Equal(t, models.BeadPriority(priority), mockBeads.createdBeads[i].Priority)
 	}
 }
+
+type mockTaskDelegator struct {
+	delegations []Delegation
+}
+
+func (m *mockTaskDelegator) Delegate(ctx context.Context, d Delegation) (string, error) {
+	m.delegations = append(m.delegations, d)
+	return "agent-qa-1", nil
+}
+
+func TestHandleDelegateTask_Strategy(t *testing.T) {
+	delegator := &mockTaskDelegator{}
+	router := &Router{Beads: &mockBeadCreator{}, Delegations: delegator}
+	actx := ActionContext{AgentID: "agent-eng-1", BeadID: "bead-parent-123", ProjectID: "project-1"}
+
+	result := router.handleDelegateTask(context.Background(), Action{
+		Type:               ActionDelegateTask,
+		DelegateToRole:     "qa-engineer",
+		TaskTitle:          "Run integration tests",
+		DelegationStrategy: DelegationBlock,
+	}, actx)
+
+	assert.Equal(t, "executed", result.Status)
+	assert.Equal(t, "agent-qa-1", result.Metadata["assigned_to"])
+	assert.Equal(t, DelegationBlock, result.Metadata["delegation_strategy"])
+	assert.Contains(t, result.Message, "blocked until the child closes")
+	require.Len(t, delegator.delegations, 1)
+	assert.Equal(t, Delegation{
+		ParentBeadID: "bead-parent-123",
+		ChildBeadID:  "bead-child-1",
+		Role:         "qa-engineer",
+		Strategy:     DelegationBlock,
+		DelegatorID:  "agent-eng-1",
+	}, delegator.delegations[0])
+
+	result = router.handleDelegateTask(context.Background(), Action{
+		Type:               ActionDelegateTask,
+		DelegateToRole:     "qa-engineer",
+		TaskTitle:          "Run integration tests",
+		DelegationStrategy: "wait",
+	}, actx)
+	assert.Equal(t, "error", result.Status)
+	assert.Len(t, delegator.delegations, 1)
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/jordanhubbard/loom/internal/apperr"
 	"github.com/jordanhubbard/loom/internal/executor"
 	"github.com/jordanhubbard/loom/internal/files"
 	"github.com/jordanhubbard/loom/pkg/models"
@@ -198,6 +200,21 @@ func (m *mockGitOperator) DiffBranches(ctx context.Context, branch1, branch2 str
 func (m *mockGitOperator) GetBeadCommits(ctx context.Context, beadID string) (map[string]interface{}, error) {
 	return m.result, m.err
 }
+func (m *mockGitOperator) DiffRevisions(ctx context.Context, from, to, path string, contextLines int) (map[string]interface{}, error) {
+	return m.result, m.err
+}
+func (m *mockGitOperator) Blame(ctx context.Context, path string, startLine, endLine int) (map[string]interface{}, error) {
+	return m.result, m.err
+}
+func (m *mockGitOperator) CreateTag(ctx context.Context, beadID, tag, message, ref string) (map[string]interface{}, error) {
+	return m.result, m.err
+}
+func (m *mockGitOperator) Changelog(ctx context.Context, from, to, version, path string) (map[string]interface{}, error) {
+	return m.result, m.err
+}
+func (m *mockGitOperator) BumpVersion(ctx context.Context, beadID, bump, version string, files []string) (map[string]interface{}, error) {
+	return m.result, m.err
+}
 
 type mockWorkflowOperator struct {
 	advanceErr error
@@ -411,6 +428,32 @@ func TestRouter_EditCode_PatchBased_Error(t *testing.T) {
 	}
 }
 
+func TestRouter_ApplyPatch_PartialHunks(t *testing.T) {
+	fm := &mockFileManager{
+		patchErr: errors.New("patch partially applied: 1 of 2 hunks failed"),
+		patchResult: &files.PatchResult{Strategy: files.PatchFuzzy, Output: "1 clean, 0 fuzzy, 1 failed", Hunks: []files.HunkResult{
+			{File: "foo.go", Index: 1, Status: files.HunkClean},
+			{File: "foo.go", Index: 2, Status: files.HunkFailed, Reason: "context not found", Hunk: "@@ -9 +9 @@\n-old\n+new"},
+		}},
+	}
+	r := &Router{Files: fm}
+	result := r.executeAction(context.Background(), Action{Type: ActionApplyPatch, Patch: "diff"}, ActionContext{})
+	if result.Status != "error" || result.Metadata["error_type"] != "patch_hunks_failed" {
+		t.Fatalf("expected a hunk report, got %+v", result)
+	}
+	feedback := FormatResultsAsUserMessage([]Result{result})
+	if !strings.Contains(feedback, "foo.go hunk 2 (context not found)") || strings.Contains(feedback, "hunk 1 (") {
+		t.Errorf("expected only the failed hunk in the feedback, got:\n%s", feedback)
+	}
+
+	fm.patchErr = nil
+	fm.patchResult = &files.PatchResult{Applied: true, Strategy: files.Patch3Way, Hunks: []files.HunkResult{{File: "foo.go", Index: 1, Status: files.HunkMerged}}}
+	result = r.executeAction(context.Background(), Action{Type: ActionApplyPatch, Patch: "diff"}, ActionContext{})
+	if result.Status != "executed" || result.Metadata["strategy"] != files.Patch3Way {
+		t.Errorf("expected the strategy in the result, got %+v", result)
+	}
+}
+
 func TestRouter_EditCode_NoFiles(t *testing.T) {
 	beads := &mockBeadCreator{}
 	r := &Router{Beads: beads}
@@ -697,6 +740,38 @@ func TestRouter_GitDiffBranches(t *testing.T) {
 	}
 }
 
+func TestRouter_GitDiffRevisions(t *testing.T) {
+	git := &mockGitOperator{result: map[string]interface{}{"files": 1}}
+	r := &Router{Git: git}
+	result := r.executeAction(context.Background(), Action{Type: ActionGitDiffRevisions, FromRef: "main", Path: "README.md"}, ActionContext{})
+	if result.Status != "executed" {
+		t.Errorf("expected executed, got %s", result.Status)
+	}
+}
+
+func TestRouter_GitBlame(t *testing.T) {
+	git := &mockGitOperator{result: map[string]interface{}{"lines": 2}}
+	r := &Router{Git: git}
+	result := r.executeAction(context.Background(), Action{Type: ActionGitBlame, Path: "main.go", StartLine: 10, EndLine: 11}, ActionContext{})
+	if result.Status != "executed" {
+		t.Errorf("expected executed, got %s", result.Status)
+	}
+}
+
+func TestRouter_ReleaseActions(t *testing.T) {
+	git := &mockGitOperator{result: map[string]interface{}{"version": "1.1.0"}}
+	r := &Router{Git: git}
+	for _, action := range []Action{
+		{Type: ActionBumpVersion, Bump: "minor"},
+		{Type: ActionGitChangelog, Version: "v1.1.0", Path: "CHANGELOG.md"},
+		{Type: ActionGitTag, Tag: "v1.1.0"},
+	} {
+		if result := r.executeAction(context.Background(), action, ActionContext{BeadID: "bead-1"}); result.Status != "executed" {
+			t.Errorf("%s: expected executed, got %s: %s", action.Type, result.Status, result.Message)
+		}
+	}
+}
+
 func TestRouter_GitBeadCommits(t *testing.T) {
 	git := &mockGitOperator{result: map[string]interface{}{"commits": []interface{}{}}}
 	r := &Router{Git: git}
@@ -1579,3 +1654,55 @@ func TestRouter_CreateBeadFromAction_DefaultType(t *testing.T) {
 	}
 	// When BeadType is empty, default is "task"
 }
+
+type mockRolePolicy struct {
+	denied map[string]bool
+}
+
+func (m *mockRolePolicy) CheckAction(agentID, actionType string) error {
+	if m.denied[actionType] {
+		return fmt.Errorf("action %s is not permitted for role reviewer", actionType)
+	}
+	return nil
+}
+
+func TestRouter_Execute_RoleDenied(t *testing.T) {
+	git := &mockGitOperator{}
+	r := &Router{Git: git, Roles: &mockRolePolicy{denied: map[string]bool{ActionGitPush: true}}}
+	env := &ActionEnvelope{Actions: []Action{{Type: ActionGitPush}, {Type: ActionGitStatus}}}
+
+	results, err := r.Execute(context.Background(), env, ActionContext{AgentID: "agent-rev", ProjectID: "proj-1"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if results[0].Status != "error" || results[0].Metadata["error_type"] != "permission_denied" || results[0].Code != apperr.CodePolicyDenied {
+		t.Errorf("git_push result = %+v", results[0])
+	}
+	if results[1].Status != "executed" || results[1].Code != "" {
+		t.Errorf("git_status should still run, got %+v", results[1])
+	}
+}
+
+func TestRouter_Execute_ErrorCodes(t *testing.T) {
+	fm := &mockFileManager{
+		treeErr:   apperr.PolicyDenied("path is not allowed"),
+		searchErr: errors.New("file not found: missing.go"),
+	}
+	r := &Router{Files: fm}
+	env := &ActionEnvelope{Actions: []Action{
+		{Type: ActionReadTree, Path: "../etc"},
+		{Type: ActionSearchText, Query: "x"},
+		{Type: ActionGitStatus},
+	}}
+
+	results, err := r.Execute(context.Background(), env, ActionContext{ProjectID: "proj-1"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	want := []apperr.Code{apperr.CodePolicyDenied, apperr.CodeNotFound, apperr.CodeUnavailable}
+	for i, code := range want {
+		if results[i].Status != "error" || results[i].Code != code {
+			t.Errorf("result %d = %+v, want code %s", i, results[i], code)
+		}
+	}
+}
@@ -6,17 +6,47 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/jordanhubbard/loom/internal/browser"
+	"github.com/jordanhubbard/loom/internal/testdb"
 )
 
 const (
 	ActionAskFollowup   = "ask_followup"
 	ActionReadCode      = "read_code"
 	ActionEditCode      = "edit_code"
+	ActionMultiEdit     = "multi_edit"
 	ActionWriteFile     = "write_file"
 	ActionRunCommand    = "run_command"
+	ActionOpenSession   = "open_session"
+	ActionSessionInput  = "session_input"
+	ActionSessionRead   = "session_read"
+	ActionCloseSession  = "close_session"
+	ActionStartCommand  = "start_command"
+	ActionJobStatus     = "job_status"
+	ActionJobLogs       = "job_logs"
+	ActionReadResult    = "read_result"
+	ActionCancelJob     = "cancel_job"
 	ActionRunTests      = "run_tests"
 	ActionRunLinter     = "run_linter"
 	ActionBuildProject  = "build_project"
+	ActionRunFormatter  = "run_formatter"
+	ActionRunSecurityScan = "run_security_scan"
+	ActionCheckDependencies = "check_dependencies"
+	ActionBuildImage    = "build_image"
+	ActionRunContainer  = "run_container"
+	ActionDeployStaging   = "deploy_staging"
+	ActionStagingStatus   = "staging_status"
+	ActionTeardownStaging = "teardown_staging"
+	ActionHTTPCheck       = "http_check"
+	ActionBrowser         = "browser"
+	ActionDBCreate        = "db_create"
+	ActionDBMigrate       = "db_migrate"
+	ActionDBLoadFixtures  = "db_load_fixtures"
+	ActionDBAssert        = "db_assert"
+	ActionDBDrop          = "db_drop"
+	ActionRunBenchmarks   = "run_benchmarks"
+	ActionProfileProject  = "profile_project"
 	ActionCreateBead    = "create_bead"
 	ActionCloseBead     = "close_bead"
 	ActionEscalateCEO   = "escalate_ceo"
@@ -29,6 +59,7 @@ const (
 	ActionGitCommit       = "git_commit"
 	ActionGitPush         = "git_push"
 	ActionCreatePR        = "create_pr"
+	ActionCheckCI         = "check_ci"
 	ActionStartDev        = "start_development"
 	ActionWhatsNext       = "whats_next"
 	ActionProceedToPhase  = "proceed_to_phase"
@@ -41,12 +72,18 @@ const (
 	ActionFindReferences      = "find_references"
 	ActionGoToDefinition      = "go_to_definition"
 	ActionFindImplementations = "find_implementations"
+	ActionAnalyzeImpact       = "analyze_impact"
 
 	// Refactoring actions
 	ActionExtractMethod  = "extract_method"
 	ActionRenameSymbol   = "rename_symbol"
 	ActionInlineVariable = "inline_variable"
 
+	// Go structural edits, syntax-checked with go/ast
+	ActionAddImport           = "add_import"
+	ActionInsertFunctionAfter = "insert_function_after"
+	ActionAddStructField      = "add_struct_field"
+
 	// File management actions
 	ActionMoveFile   = "move_file"
 	ActionDeleteFile = "delete_file"
@@ -67,22 +104,37 @@ const (
 	ActionRequestReview   = "request_review"
 
 	// Extended git operations
-	ActionGitMerge        = "git_merge"
-	ActionGitRevert       = "git_revert"
-	ActionGitBranchDelete = "git_branch_delete"
-	ActionGitCheckout     = "git_checkout"
-	ActionGitLog          = "git_log"
-	ActionGitFetch        = "git_fetch"
-	ActionGitListBranches = "git_list_branches"
-	ActionGitDiffBranches = "git_diff_branches"
-	ActionGitBeadCommits  = "git_bead_commits"
+	ActionGitMerge         = "git_merge"
+	ActionGitRevert        = "git_revert"
+	ActionGitBranchDelete  = "git_branch_delete"
+	ActionGitCheckout      = "git_checkout"
+	ActionGitLog           = "git_log"
+	ActionGitFetch         = "git_fetch"
+	ActionGitListBranches  = "git_list_branches"
+	ActionGitDiffBranches  = "git_diff_branches"
+	ActionGitBeadCommits   = "git_bead_commits"
+	ActionGitDiffRevisions = "git_diff_revisions"
+	ActionGitBlame         = "git_blame"
+
+	// Release management
+	ActionGitTag       = "git_tag"
+	ActionGitChangelog = "git_changelog"
+	ActionBumpVersion  = "bump_version"
 
 	// Agent signals
 	ActionDone = "done"
 
 	// Agent communication actions
-	ActionSendAgentMessage = "send_agent_message"
-	ActionDelegateTask     = "delegate_task"
+	ActionSendAgentMessage  = "send_agent_message"
+	ActionReadAgentMessages = "read_agent_messages"
+	ActionDelegateTask      = "delegate_task"
+
+	// External tool actions (MCP servers configured for the project)
+	ActionMCPListTools = "mcp_list_tools"
+	ActionMCPCall      = "mcp_call"
+
+	// Vision: attach an image from the workdir to the bead and the conversation
+	ActionAttachImage = "attach_image"
 )
 
 type ActionEnvelope struct {
@@ -95,22 +147,35 @@ type Action struct {
 
 	Question string `json:"question,omitempty"`
 
-	Path     string `json:"path,omitempty"`
-	Content  string `json:"content,omitempty"`
-	Patch    string `json:"patch,omitempty"`
-	OldText  string `json:"old_text,omitempty"`  // For text-based EDIT: exact text to replace
-	NewText  string `json:"new_text,omitempty"`  // For text-based EDIT: replacement text
-	Query    string `json:"query,omitempty"`
-	MaxDepth int    `json:"max_depth,omitempty"`
-	Limit    int    `json:"limit,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Content  string   `json:"content,omitempty"`
+	Patch    string   `json:"patch,omitempty"`
+	OldText  string   `json:"old_text,omitempty"` // For text-based EDIT: exact text to replace
+	NewText  string   `json:"new_text,omitempty"` // For text-based EDIT: replacement text
+	Edits    []EditOp `json:"edits,omitempty"`    // For multi_edit: applied in order, all or nothing
+	Query    string   `json:"query,omitempty"`
+	MaxDepth int      `json:"max_depth,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
 
 	Command    string `json:"command,omitempty"`
 	WorkingDir string `json:"working_dir,omitempty"`
 
+	// Interactive session fields
+	SessionID string `json:"session_id,omitempty"` // Session returned by open_session
+	Input     string `json:"input,omitempty"`      // Text to send to the session's terminal
+
+	// Background job fields
+	JobID  string `json:"job_id,omitempty"` // Job returned by start_command
+	Offset int64  `json:"offset,omitempty"` // Log offset for job_logs and read_result (negative reads the tail)
+
+	// Stored result fields
+	ResultID string `json:"result_id,omitempty"` // Stored output referenced by truncated feedback
+
 	// Test execution fields
 	TestPattern    string `json:"test_pattern,omitempty"`
 	Framework      string `json:"framework,omitempty"`
 	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+	TestMode       string `json:"test_mode,omitempty"` // smart runs only the tests covering files (default the changes since HEAD); full (default)
 
 	// Linter execution fields
 	Files []string `json:"files,omitempty"` // Specific files to lint
@@ -119,6 +184,59 @@ type Action struct {
 	BuildTarget  string `json:"build_target,omitempty"`  // Build target (e.g., binary name)
 	BuildCommand string `json:"build_command,omitempty"` // Custom build command
 
+	// Security scan fields
+	Scanners    []string `json:"scanners,omitempty"`     // gosec, semgrep, npm-audit, trivy; defaults to those that apply
+	CreateBeads bool     `json:"create_beads,omitempty"` // File beads for new high and critical findings (or outdated dependencies)
+
+	// Container fields
+	Image            string            `json:"image,omitempty"`             // Image to run or deploy_staging, or the tag for build_image
+	Dockerfile       string            `json:"dockerfile,omitempty"`        // Dockerfile for build_image; defaults to the one in the build context (path)
+	BuildArgs        map[string]string `json:"build_args,omitempty"`        // --build-arg values for build_image
+	Ports            []string          `json:"ports,omitempty"`             // "8080:80" or "80" (free host port), published on localhost
+	Env              map[string]string `json:"env,omitempty"`               // Container environment for run_container
+	ContainerCommand []string          `json:"container_command,omitempty"` // Overrides the image's command
+	HealthPath       string            `json:"health_path,omitempty"`       // HTTP path polled until the container is healthy
+	HealthPort       int               `json:"health_port,omitempty"`       // Container port for the health check (default the first in ports)
+
+	// HTTP check fields
+	URL          string                 `json:"url,omitempty"`           // URL for http_check
+	Method       string                 `json:"method,omitempty"`        // HTTP method (default GET)
+	Headers      map[string]string      `json:"headers,omitempty"`       // Request headers
+	Body         string                 `json:"body,omitempty"`          // Request body
+	ExpectStatus []int                  `json:"expect_status,omitempty"` // Accepted statuses (default any 2xx)
+	ExpectBody   string                 `json:"expect_body,omitempty"`   // Text the response body must contain
+	ExpectJSON   map[string]interface{} `json:"expect_json,omitempty"`   // Dotted JSON path (e.g. data.items.0.id) to its expected value
+	Retries      int                    `json:"retries,omitempty"`       // Retries with exponential backoff until the checks pass
+
+	// Browser fields
+	Steps   []browser.Step `json:"steps,omitempty"`   // Browser steps run in order after opening url
+	Browser string         `json:"browser,omitempty"` // chromium, firefox or webkit; default the configured one
+
+	// Test database fields
+	Engine     string             `json:"engine,omitempty"`     // sqlite (default) or postgres for db_create
+	Down       bool               `json:"down,omitempty"`       // Revert migrations in db_migrate
+	Assertions []testdb.Assertion `json:"assertions,omitempty"` // Queries and expected results for db_assert
+
+	// Benchmark fields
+	Tool      string   `json:"tool,omitempty"`      // go (default) or hyperfine for run_benchmarks
+	Bench     string   `json:"bench,omitempty"`     // go test -bench regexp (default .)
+	Commands  []string `json:"commands,omitempty"`  // Commands timed by hyperfine
+	Count     int      `json:"count,omitempty"`     // go test -count (default 3) or hyperfine --runs
+	Threshold float64  `json:"threshold,omitempty"` // Percent slowdown counted as a regression (default the configured one)
+	Baseline  string   `json:"baseline,omitempty"`  // Branch to compare with (default the configured one)
+
+	// Profiling fields
+	Profiles       []string `json:"profiles,omitempty"`        // cpu and/or heap for profile_project (default both)
+	ProfileCommand []string `json:"profile_command,omitempty"` // Binary and arguments profiled instead of go test; {cpu} and {heap} become the profile paths
+	Top            int      `json:"top,omitempty"`             // Functions in each profile summary (default the configured number)
+
+	// Dependency check fields
+	Ecosystems []string `json:"ecosystems,omitempty"` // go, npm, pip; defaults to those with a manifest
+	BeadMode   string   `json:"bead_mode,omitempty"`  // per_dependency (default) or batch
+
+	// Monorepo scoping
+	Subproject string `json:"subproject,omitempty"` // Subproject name or path; defaults to the bead's subproject
+
 	// Git operation fields
 	CommitMessage string   `json:"commit_message,omitempty"` // Commit message (auto-generated if empty)
 	Branch        string   `json:"branch,omitempty"`         // Branch name
@@ -134,9 +252,17 @@ type Action struct {
 	CommitSHA    string   `json:"commit_sha,omitempty"`    // Single commit SHA
 	CommitSHAs   []string `json:"commit_shas,omitempty"`   // Multiple commit SHAs (for revert)
 	MaxCount     int      `json:"max_count,omitempty"`     // Max entries for log
+	FromRef      string   `json:"from_ref,omitempty"`      // Revision to diff from (default HEAD)
+	ToRef        string   `json:"to_ref,omitempty"`        // Revision to diff to (default the working tree)
+	ContextLines int      `json:"context_lines,omitempty"` // Unchanged lines around each hunk (default 3)
 	NoFF         bool     `json:"no_ff,omitempty"`         // No fast-forward merge
 	DeleteRemote bool     `json:"delete_remote,omitempty"` // Delete remote branch too
 
+	// Release fields
+	Tag     string `json:"tag,omitempty"`     // Tag name for git_tag
+	Version string `json:"version,omitempty"` // Version for bump_version, or the git_changelog heading
+	Bump    string `json:"bump,omitempty"`    // major, minor or patch for bump_version
+
 	// Workflow management fields
 	Workflow       string `json:"workflow,omitempty"`        // Workflow type (epcc, tdd, waterfall, etc.)
 	RequireReviews bool   `json:"require_reviews,omitempty"` // Require reviews before phase transitions
@@ -144,18 +270,24 @@ type Action struct {
 	ReviewState    string `json:"review_state,omitempty"`    // Review state (not-required, pending, performed)
 
 	// Code navigation fields
-	Symbol   string `json:"symbol,omitempty"`    // Symbol name for find_references/go_to_definition
-	Line     int    `json:"line,omitempty"`      // Line number for position-based queries
-	Column   int    `json:"column,omitempty"`    // Column number for position-based queries
-	Language string `json:"language,omitempty"`  // Language hint (go, typescript, python, etc.)
+	Symbol   string   `json:"symbol,omitempty"`   // Symbol name for find_references/go_to_definition
+	Line     int      `json:"line,omitempty"`     // Line number for position-based queries
+	Column   int      `json:"column,omitempty"`   // Column number for position-based queries
+	Language string   `json:"language,omitempty"` // Language hint (go, typescript, python, etc.)
+	Symbols  []string `json:"symbols,omitempty"`  // Changed symbols for analyze_impact
 
 	// Refactoring fields
 	NewName       string `json:"new_name,omitempty"`       // New name for rename_symbol/rename_file
 	MethodName    string `json:"method_name,omitempty"`    // Method name for extract_method
-	StartLine     int    `json:"start_line,omitempty"`     // Start line for extract_method
-	EndLine       int    `json:"end_line,omitempty"`       // End line for extract_method
+	StartLine     int    `json:"start_line,omitempty"`     // Start line for extract_method, git_blame or a line-range edit_code
+	EndLine       int    `json:"end_line,omitempty"`       // End line for extract_method, git_blame or a line-range edit_code
 	VariableName  string `json:"variable_name,omitempty"`  // Variable name for inline_variable
 
+	// Go structural edit fields; symbol names the anchor declaration or the
+	// struct and content holds the function or field source
+	ImportPath string `json:"import_path,omitempty"` // Package path for add_import
+	ImportName string `json:"import_name,omitempty"` // Optional alias for add_import
+
 	// File management fields
 	SourcePath string `json:"source_path,omitempty"` // Source file path for move/rename
 	TargetPath string `json:"target_path,omitempty"` // Target file path for move/rename
@@ -189,11 +321,17 @@ type Action struct {
 	MessagePayload map[string]interface{} `json:"message_payload,omitempty"`  // Optional message payload/context
 
 	// Task delegation fields
-	DelegateToRole  string                 `json:"delegate_to_role,omitempty"`  // Role to delegate task to
-	TaskTitle       string                 `json:"task_title,omitempty"`        // Title for delegated task
-	TaskDescription string                 `json:"task_description,omitempty"`  // Description for delegated task
-	TaskPriority    int                    `json:"task_priority,omitempty"`     // Priority for delegated task (0-4)
-	ParentBeadID    string                 `json:"parent_bead_id,omitempty"`    // Parent bead that created this delegation
+	DelegateToRole     string `json:"delegate_to_role,omitempty"`    // Role to delegate task to
+	TaskTitle          string `json:"task_title,omitempty"`          // Title for delegated task
+	TaskDescription    string `json:"task_description,omitempty"`    // Description for delegated task
+	TaskPriority       int    `json:"task_priority,omitempty"`       // Priority for delegated task (0-4)
+	ParentBeadID       string `json:"parent_bead_id,omitempty"`      // Parent bead that created this delegation
+	DelegationStrategy string `json:"delegation_strategy,omitempty"` // continue (default) or block the parent until the child closes
+
+	// MCP tool fields
+	MCPServer string                 `json:"mcp_server,omitempty"` // Server name from the project's mcp_servers
+	MCPTool   string                 `json:"mcp_tool,omitempty"`   // Tool name as listed by mcp_list_tools
+	Arguments map[string]interface{} `json:"arguments,omitempty"`  // Tool arguments, checked against its input schema
 
 	Bead *BeadPayload `json:"bead,omitempty"`
 
@@ -240,6 +378,9 @@ func DecodeStrict(payload []byte) (*ActionEnvelope, error) {
 
 // DecodeLenient attempts strict decode first, then tries to recover a JSON object
 // from responses that include extra text (e.g., markdown fences, model traces, or <think> blocks).
+// As a last resort it repairs common JSON mistakes (single quotes, trailing
+// commas, unquoted keys, repeated envelopes); the error returned when that
+// fails is the one from the unrepaired payload.
 func DecodeLenient(payload []byte) (*ActionEnvelope, error) {
 	env, err := DecodeStrict(payload)
 	if err == nil {
@@ -249,10 +390,18 @@ func DecodeLenient(payload []byte) (*ActionEnvelope, error) {
 	trimmed = stripCodeFences(trimmed)
 	trimmed = stripThinkTags(trimmed)
 	extracted, extractErr := extractJSONObject(trimmed)
-	if extractErr != nil {
-		return nil, err
+	if extractErr == nil {
+		if env, err = DecodeStrict(extracted); err == nil {
+			if hasTrailingObject(trimmed, extracted) {
+				recordRepair(RepairDuplicateEnvelope)
+			}
+			return env, nil
+		}
+	}
+	if repaired := repairEnvelope(trimmed); repaired != nil {
+		return repaired, nil
 	}
-	return DecodeStrict(extracted)
+	return nil, err
 }
 
 func stripCodeFences(payload []byte) []byte {
@@ -307,6 +456,8 @@ func stripThinkTags(payload []byte) []byte {
 	return []byte(strings.TrimSpace(s))
 }
 
+var errNoJSONObject = errors.New("no JSON object found in response")
+
 func extractJSONObject(payload []byte) ([]byte, error) {
 	inString := false
 	escaped := false
@@ -345,7 +496,7 @@ func extractJSONObject(payload []byte) ([]byte, error) {
 			}
 		}
 	}
-	return nil, errors.New("no JSON object found in response")
+	return nil, errNoJSONObject
 }
 
 func Validate(env *ActionEnvelope) error {
@@ -378,8 +529,29 @@ func validateAction(action Action) error {
 			return errors.New("read_code requires path")
 		}
 	case ActionEditCode:
-		if action.Path == "" || action.Patch == "" {
-			return errors.New("edit_code requires path and patch")
+		if action.Path == "" || (action.Patch == "" && action.OldText == "" && action.StartLine == 0) {
+			return errors.New("edit_code requires path and patch, old_text or start_line")
+		}
+	case ActionMultiEdit:
+		if action.Path == "" || len(action.Edits) == 0 {
+			return errors.New("multi_edit requires path and edits")
+		}
+		for i, op := range action.Edits {
+			if op.OldText == "" && op.StartLine == 0 {
+				return fmt.Errorf("multi_edit edit %d requires old_text or start_line", i+1)
+			}
+		}
+	case ActionAddImport:
+		if action.Path == "" || action.ImportPath == "" {
+			return errors.New("add_import requires path and import_path")
+		}
+	case ActionInsertFunctionAfter:
+		if action.Path == "" || action.Content == "" {
+			return errors.New("insert_function_after requires path and content")
+		}
+	case ActionAddStructField:
+		if action.Path == "" || action.Symbol == "" || action.Content == "" {
+			return errors.New("add_struct_field requires path, symbol and content")
 		}
 	case ActionWriteFile:
 		if action.Path == "" || action.Content == "" {
@@ -414,6 +586,8 @@ func validateAction(action Action) error {
 	case ActionCreatePR:
 		// pr_title and pr_body optional (auto-generated from bead)
 		// pr_base optional (defaults to main)
+	case ActionCheckCI:
+		// branch is optional (defaults to the branch last pushed for the bead)
 	case ActionGitMerge:
 		if action.SourceBranch == "" {
 			return errors.New("git_merge requires source_branch")
@@ -440,21 +614,112 @@ func validateAction(action Action) error {
 		if action.SourceBranch == "" || action.TargetBranch == "" {
 			return errors.New("git_diff_branches requires source_branch and target_branch")
 		}
+	case ActionGitDiffRevisions:
+		// from_ref defaults to HEAD, to_ref to the working tree
+	case ActionGitBlame:
+		if action.Path == "" {
+			return errors.New("git_blame requires path")
+		}
+	case ActionGitTag:
+		if action.Tag == "" {
+			return errors.New("git_tag requires tag")
+		}
+	case ActionGitChangelog:
+		// from_ref defaults to the latest tag, to_ref to HEAD
+	case ActionBumpVersion:
+		if (action.Bump == "") == (action.Version == "") {
+			return errors.New("bump_version requires bump or version")
+		}
 	case ActionGitBeadCommits:
 		// bead_id comes from action context
 	case ActionRunCommand:
 		if action.Command == "" {
 			return errors.New("run_command requires command")
 		}
+	case ActionOpenSession:
+		if action.Command == "" {
+			return errors.New("open_session requires command")
+		}
+	case ActionSessionInput:
+		if action.SessionID == "" || action.Input == "" {
+			return errors.New("session_input requires session_id and input")
+		}
+	case ActionSessionRead, ActionCloseSession:
+		if action.SessionID == "" {
+			return fmt.Errorf("%s requires session_id", action.Type)
+		}
+	case ActionStartCommand:
+		if action.Command == "" {
+			return errors.New("start_command requires command")
+		}
+	case ActionJobStatus, ActionJobLogs, ActionCancelJob:
+		if action.JobID == "" {
+			return fmt.Errorf("%s requires job_id", action.Type)
+		}
+	case ActionReadResult:
+		if action.ResultID == "" {
+			return errors.New("read_result requires result_id")
+		}
 	case ActionRunTests:
 		// All fields are optional - defaults will be used
 		// test_pattern, framework (auto-detect), timeout_seconds (default)
+		if action.TestMode != "" && action.TestMode != "smart" && action.TestMode != "full" {
+			return fmt.Errorf("run_tests test_mode must be smart or full, not %q", action.TestMode)
+		}
 	case ActionRunLinter:
 		// All fields are optional - defaults will be used
 		// files, framework (auto-detect), timeout_seconds (default)
 	case ActionBuildProject:
 		// All fields are optional - defaults will be used
 		// build_target, framework (auto-detect), build_command, timeout_seconds (default)
+	case ActionRunFormatter:
+		// All fields are optional - formats changed files when no files/path are given
+		// files, path, framework (formatter; chosen per file extension by default)
+	case ActionRunSecurityScan:
+		// All fields are optional - runs every applicable installed scanner
+		// scanners, path, subproject, create_beads
+	case ActionCheckDependencies:
+		// All fields are optional - checks every ecosystem with a manifest
+		// ecosystems, path, subproject, create_beads, bead_mode
+	case ActionBuildImage:
+		// All fields are optional - builds the Dockerfile at the root or the subproject
+		// image, dockerfile, path, build_args, build_target, timeout_seconds
+	case ActionRunContainer:
+		if action.Image == "" {
+			return errors.New("run_container requires image")
+		}
+	case ActionDeployStaging, ActionStagingStatus, ActionTeardownStaging:
+		// The bead comes from the action context; deploy_staging takes an optional image
+	case ActionHTTPCheck:
+		if action.URL == "" {
+			return errors.New("http_check requires url")
+		}
+	case ActionBrowser:
+		if action.URL == "" {
+			return errors.New("browser requires url")
+		}
+	case ActionDBMigrate:
+		if action.Path == "" && action.Command == "" {
+			return errors.New("db_migrate requires path or command")
+		}
+	case ActionDBLoadFixtures:
+		if action.Path == "" && len(action.Files) == 0 {
+			return errors.New("db_load_fixtures requires path or files")
+		}
+	case ActionDBAssert:
+		if len(action.Assertions) == 0 {
+			return errors.New("db_assert requires assertions")
+		}
+	case ActionRunBenchmarks:
+		if strings.EqualFold(action.Tool, "hyperfine") && len(action.Commands) == 0 {
+			return errors.New("run_benchmarks with hyperfine requires commands")
+		}
+	case ActionProfileProject:
+		for _, kind := range action.Profiles {
+			if k := strings.ToLower(kind); k != "cpu" && k != "heap" && k != "mem" && k != "memory" {
+				return fmt.Errorf("profile_project profiles must be cpu or heap, not %q", kind)
+			}
+		}
 	case ActionCreateBead:
 		if action.Bead == nil {
 			return errors.New("create_bead requires bead payload")
@@ -521,6 +786,8 @@ func validateAction(action Action) error {
 		if action.Symbol == "" && (action.Line == 0 || action.Column == 0) {
 			return errors.New("find_implementations requires either symbol or (line and column)")
 		}
+	case ActionAnalyzeImpact:
+		// files and symbols default to the changes since from_ref
 	case ActionExtractMethod:
 		if action.Path == "" {
 			return errors.New("extract_method requires path")
@@ -587,6 +854,20 @@ func validateAction(action Action) error {
 		if action.Path == "" {
 			return errors.New("generate_docs requires path")
 		}
+	case ActionReadAgentMessages:
+		if action.Limit < 0 {
+			return errors.New("read_agent_messages limit must not be negative")
+		}
+	case ActionMCPListTools:
+		// Optional: mcp_server (defaults to every server of the project)
+	case ActionMCPCall:
+		if action.MCPServer == "" || action.MCPTool == "" {
+			return errors.New("mcp_call requires mcp_server and mcp_tool")
+		}
+	case ActionAttachImage:
+		if action.Path == "" {
+			return errors.New("attach_image requires path")
+		}
 	default:
 		return fmt.Errorf("unknown action type: %s", action.Type)
 	}
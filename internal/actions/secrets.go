@@ -0,0 +1,67 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/git"
+	"github.com/jordanhubbard/loom/internal/secretscan"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// gitErrorResult converts a git operation error into a Result, listing the
+// violations when the commit policy rejected it, or the findings when the
+// secrets gate blocked it and filing a remediation bead when the router is
+// configured to
+func (r *Router) gitErrorResult(actionType string, err error, actx ActionContext) Result {
+	res := errorResult(actionType, err)
+	var policyErr *git.CommitPolicyError
+	if errors.As(err, &policyErr) {
+		res.Code = apperr.CodePolicyDenied
+		res.Metadata = map[string]interface{}{
+			"error_type": "commit_policy",
+			"violations": policyErr.Violations,
+		}
+		return res
+	}
+	var blocked *secretscan.BlockedError
+	if !errors.As(err, &blocked) {
+		return res
+	}
+	res.Code = apperr.CodePolicyDenied
+	res.Metadata = map[string]interface{}{
+		"error_type": "secrets_detected",
+		"operation":  blocked.Operation,
+		"findings":   blocked.Findings,
+	}
+	if r.SecretBeads && r.Beads != nil {
+		title := fmt.Sprintf("Remove secrets blocked from a %s", blocked.Operation)
+		if actx.BeadID != "" {
+			title += " for " + actx.BeadID
+		}
+		bead, beadErr := r.Beads.CreateBead(title, secretsRemediation(blocked, actx), models.BeadPriorityP1, "bug", actx.ProjectID)
+		if beadErr == nil && bead != nil {
+			res.Metadata["remediation_bead_id"] = bead.ID
+		}
+	}
+	return res
+}
+
+// secretsRemediation describes a blocked operation for a remediation bead
+func secretsRemediation(blocked *secretscan.BlockedError, actx ActionContext) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The secrets scan blocked a %s by agent %s on bead %s.\n\nFindings:\n", blocked.Operation, actx.AgentID, actx.BeadID)
+	for _, f := range blocked.Findings {
+		fmt.Fprintf(&sb, "- %s (%s) at %s:%d", f.Description, f.RuleID, f.File, f.Line)
+		if f.Commit != "" {
+			fmt.Fprintf(&sb, " in commit %s", f.Commit)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\nRemove the values from the code and load them from the environment or a secret store. ")
+	sb.WriteString("Commits that were never pushed must be amended or rebased so the value leaves the history; ")
+	sb.WriteString("rotate any credential that may have been real.\n")
+	return sb.String()
+}
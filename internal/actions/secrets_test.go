@@ -0,0 +1,69 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/git"
+	"github.com/jordanhubbard/loom/internal/secretscan"
+)
+
+func TestRouter_GitCommit_SecretsBlocked(t *testing.T) {
+	blocked := &secretscan.BlockedError{Operation: "commit", Findings: []secretscan.Finding{
+		{RuleID: "aws-access-key-id", Description: "AWS access key ID", File: "config.go", Line: 12, Match: "AKIA************"},
+	}}
+	git := &mockGitOperator{err: fmt.Errorf("secret detected: %w", blocked)}
+	beads := &mockBeadCreator{}
+	r := &Router{Git: git, Beads: beads}
+	actx := ActionContext{BeadID: "bead-1", AgentID: "agent-1", ProjectID: "proj-1"}
+
+	result := r.executeAction(context.Background(), Action{Type: ActionGitCommit, CommitMessage: "add config"}, actx)
+	if result.Status != "error" || result.Code != apperr.CodePolicyDenied {
+		t.Fatalf("expected a policy_denied error, got %s %s: %s", result.Status, result.Code, result.Message)
+	}
+	if result.Metadata["error_type"] != "secrets_detected" || result.Metadata["operation"] != "commit" {
+		t.Errorf("unexpected metadata %+v", result.Metadata)
+	}
+	if findings, _ := result.Metadata["findings"].([]secretscan.Finding); len(findings) != 1 || findings[0].File != "config.go" {
+		t.Errorf("expected the findings in the result, got %+v", result.Metadata["findings"])
+	}
+	if _, ok := result.Metadata["remediation_bead_id"]; ok || len(beads.createdBeads) != 0 {
+		t.Error("expected no remediation bead unless configured")
+	}
+
+	r.SecretBeads = true
+	result = r.executeAction(context.Background(), Action{Type: ActionGitPush}, actx)
+	if len(beads.createdBeads) != 1 {
+		t.Fatalf("expected a remediation bead, got %d", len(beads.createdBeads))
+	}
+	bead := beads.createdBeads[0]
+	if result.Metadata["remediation_bead_id"] != bead.ID || bead.ProjectID != "proj-1" || bead.Type != "bug" {
+		t.Errorf("unexpected remediation bead %+v for %+v", bead, result.Metadata)
+	}
+	if !strings.Contains(bead.Description, "config.go:12") {
+		t.Errorf("expected the bead to list the findings, got %q", bead.Description)
+	}
+
+	git.err = fmt.Errorf("push rejected")
+	result = r.executeAction(context.Background(), Action{Type: ActionGitPush}, actx)
+	if result.Metadata["error_type"] == "secrets_detected" || len(beads.createdBeads) != 1 {
+		t.Error("expected other git errors to pass through unchanged")
+	}
+}
+
+func TestRouter_GitCommit_PolicyRejected(t *testing.T) {
+	rejected := &git.CommitPolicyError{Violations: []git.CommitPolicyViolation{
+		{Rule: git.RuleConventional, Message: "the subject must start with a conventional type"},
+	}}
+	r := &Router{Git: &mockGitOperator{err: rejected}}
+	result := r.executeAction(context.Background(), Action{Type: ActionGitCommit, CommitMessage: "stuff"}, ActionContext{BeadID: "bead-1"})
+	if result.Status != "error" || result.Code != apperr.CodePolicyDenied || result.Metadata["error_type"] != "commit_policy" {
+		t.Fatalf("expected a commit_policy rejection, got %s %s %+v", result.Status, result.Code, result.Metadata)
+	}
+	if v, _ := result.Metadata["violations"].([]git.CommitPolicyViolation); len(v) != 1 || v[0].Rule != git.RuleConventional {
+		t.Errorf("expected the violations in the result, got %+v", result.Metadata["violations"])
+	}
+}
@@ -0,0 +1,77 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/securityscan"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// maxReportedFindings caps the findings returned to the agent; the full list
+// is persisted with the scan.
+const maxReportedFindings = 50
+
+// handleSecurityScanAction runs security scanners over the project, or the
+// bead's subproject, and reports structured findings.
+func (r *Router) handleSecurityScanAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Security == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "security scanner not configured"}
+	}
+
+	path := action.Path
+	if path == "" {
+		_, sp, err := r.resolveSubproject(action, actx)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		if sp != nil {
+			path = sp.Path
+		}
+	}
+
+	scan, err := r.Security.Scan(ctx, actx.ProjectID, securityscan.Options{
+		Tools:       action.Scanners,
+		Path:        path,
+		CreateBeads: action.CreateBeads,
+	})
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+
+	newCount := 0
+	for _, f := range scan.Findings {
+		if f.New {
+			newCount++
+		}
+	}
+	findings := scan.Findings
+	if len(findings) > maxReportedFindings {
+		findings = findings[:maxReportedFindings]
+	}
+	message := fmt.Sprintf("%d findings (%d new): %d critical, %d high, %d medium, %d low",
+		len(scan.Findings), newCount,
+		scan.Summary[models.SeverityCritical], scan.Summary[models.SeverityHigh],
+		scan.Summary[models.SeverityMedium], scan.Summary[models.SeverityLow])
+	if len(scan.BeadIDs) > 0 {
+		message = fmt.Sprintf("%s; filed %d beads", message, len(scan.BeadIDs))
+	}
+
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    message,
+		Metadata: map[string]interface{}{
+			"scan_id":        scan.ID,
+			"path":           scan.Path,
+			"tools":          scan.Tools,
+			"summary":        scan.Summary,
+			"findings":       findings,
+			"total_findings": len(scan.Findings),
+			"new_findings":   newCount,
+			"errors":         scan.Errors,
+			"skipped":        scan.Skipped,
+			"bead_ids":       scan.BeadIDs,
+		},
+	}
+}
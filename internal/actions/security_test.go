@@ -0,0 +1,68 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/securityscan"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type mockSecurityScanner struct {
+	opts securityscan.Options
+	scan *models.SecurityScan
+	err  error
+}
+
+func (m *mockSecurityScanner) Scan(ctx context.Context, projectID string, opts securityscan.Options) (*models.SecurityScan, error) {
+	m.opts = opts
+	return m.scan, m.err
+}
+
+func TestRouterRunSecurityScan(t *testing.T) {
+	scanner := &mockSecurityScanner{scan: &models.SecurityScan{
+		ID:    "scan-1",
+		Path:  "services/api",
+		Tools: []string{"gosec"},
+		Findings: []models.SecurityFinding{
+			{Tool: "gosec", RuleID: "G101", Severity: models.SeverityHigh, File: "services/api/config.go", Line: 12, Message: "Potential hardcoded credentials", New: true},
+			{Tool: "gosec", RuleID: "G304", Severity: models.SeverityMedium, File: "services/api/read.go", Line: 20, Message: "Potential file inclusion"},
+		},
+		Summary: map[string]int{models.SeverityHigh: 1, models.SeverityMedium: 1},
+		BeadIDs: []string{"bd-1"},
+	}}
+	r := &Router{Security: scanner, Projects: monorepoProject("/repo")}
+
+	res := r.executeAction(context.Background(),
+		Action{Type: ActionRunSecurityScan, Scanners: []string{"gosec"}, CreateBeads: true},
+		ActionContext{ProjectID: "mono", Subproject: "api"})
+	if res.Status != "executed" {
+		t.Fatalf("expected executed, got %s: %s", res.Status, res.Message)
+	}
+	if scanner.opts.Path != "services/api" || !scanner.opts.CreateBeads || len(scanner.opts.Tools) != 1 {
+		t.Errorf("unexpected scan options: %+v", scanner.opts)
+	}
+	if res.Message != "2 findings (1 new): 0 critical, 1 high, 1 medium, 0 low; filed 1 beads" {
+		t.Errorf("unexpected message: %s", res.Message)
+	}
+
+	feedback := FormatResultsAsUserMessage([]Result{res})
+	if !strings.Contains(feedback, "[high] gosec G101 `services/api/config.go:12` (new)") {
+		t.Errorf("feedback missing finding:\n%s", feedback)
+	}
+}
+
+func TestRouterRunSecurityScanErrors(t *testing.T) {
+	r := &Router{}
+	if res := r.executeAction(context.Background(), Action{Type: ActionRunSecurityScan}, ActionContext{ProjectID: "p"}); res.Status != "error" {
+		t.Errorf("expected error without scanner, got %s", res.Status)
+	}
+
+	r.Security = &mockSecurityScanner{err: errors.New("no applicable security scanners installed")}
+	res := r.executeAction(context.Background(), Action{Type: ActionRunSecurityScan}, ActionContext{ProjectID: "p"})
+	if res.Status != "error" || !strings.Contains(res.Message, "no applicable") {
+		t.Errorf("expected scanner error, got %s: %s", res.Status, res.Message)
+	}
+}
@@ -0,0 +1,104 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/executor"
+)
+
+const (
+	defaultSessionReadWait = 2 * time.Second
+	maxSessionReadWait     = 30 * time.Second
+)
+
+// handleSessionAction drives interactive PTY sessions: open, send input, read output, close.
+func (r *Router) handleSessionAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Sessions == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "interactive sessions not configured"}
+	}
+
+	switch action.Type {
+	case ActionOpenSession:
+		info, err := r.Sessions.OpenSession(ctx, executor.OpenSessionRequest{
+			AgentID:     actx.AgentID,
+			BeadID:      actx.BeadID,
+			ProjectID:   actx.ProjectID,
+			Command:     action.Command,
+			WorkingDir:  action.WorkingDir,
+			IdleTimeout: action.TimeoutSeconds,
+		})
+		if err != nil {
+			return executorErrorResult(action.Type, err)
+		}
+		return Result{
+			ActionType: action.Type,
+			Status:     "executed",
+			Message:    fmt.Sprintf("session %s opened", info.ID),
+			Metadata: map[string]interface{}{
+				"session_id": info.ID,
+				"command":    info.Command,
+			},
+		}
+	case ActionSessionInput:
+		// Interactive tools act on a line at a time; terminate the line unless the
+		// agent already did.
+		input := action.Input
+		if !strings.HasSuffix(input, "\n") {
+			input += "\n"
+		}
+		if err := r.Sessions.SendInput(action.SessionID, []byte(input)); err != nil {
+			return errorResult(action.Type, err)
+		}
+		return r.readSessionOutput(ctx, action, defaultSessionReadWait)
+	case ActionSessionRead:
+		wait := defaultSessionReadWait
+		if action.TimeoutSeconds > 0 {
+			wait = time.Duration(action.TimeoutSeconds) * time.Second
+			if wait > maxSessionReadWait {
+				wait = maxSessionReadWait
+			}
+		}
+		return r.readSessionOutput(ctx, action, wait)
+	case ActionCloseSession:
+		info, err := r.Sessions.CloseSession(action.SessionID)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		return Result{
+			ActionType: action.Type,
+			Status:     "executed",
+			Message:    fmt.Sprintf("session %s closed", info.ID),
+			Metadata: map[string]interface{}{
+				"session_id": info.ID,
+				"exit_code":  info.ExitCode,
+			},
+		}
+	}
+	return Result{ActionType: action.Type, Status: "error", Message: "unsupported session action"}
+}
+
+func (r *Router) readSessionOutput(ctx context.Context, action Action, wait time.Duration) Result {
+	out, err := r.Sessions.ReadOutput(ctx, action.SessionID, action.Limit, wait)
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+	message := "session output read"
+	if !out.Running {
+		message = fmt.Sprintf("session exited (exit code %d)", out.ExitCode)
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    message,
+		Metadata: map[string]interface{}{
+			"session_id": out.SessionID,
+			"output":     out.Output,
+			"running":    out.Running,
+			"exit_code":  out.ExitCode,
+			"truncated":  out.Truncated,
+		},
+	}
+}
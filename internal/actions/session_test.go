@@ -0,0 +1,103 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/executor"
+)
+
+type mockSessionOperator struct {
+	openReq  executor.OpenSessionRequest
+	inputs   []string
+	lastWait time.Duration
+	output   string
+	closed   []string
+}
+
+func (m *mockSessionOperator) OpenSession(ctx context.Context, req executor.OpenSessionRequest) (*executor.SessionInfo, error) {
+	m.openReq = req
+	return &executor.SessionInfo{ID: "sess-1", Command: req.Command, Running: true}, nil
+}
+
+func (m *mockSessionOperator) SendInput(id string, input []byte) error {
+	if id != "sess-1" {
+		return errors.New("session not found: " + id)
+	}
+	m.inputs = append(m.inputs, string(input))
+	return nil
+}
+
+func (m *mockSessionOperator) ReadOutput(ctx context.Context, id string, maxBytes int, wait time.Duration) (*executor.SessionOutput, error) {
+	m.lastWait = wait
+	return &executor.SessionOutput{SessionID: id, Output: m.output, Running: true}, nil
+}
+
+func (m *mockSessionOperator) CloseSession(id string) (*executor.SessionInfo, error) {
+	m.closed = append(m.closed, id)
+	return &executor.SessionInfo{ID: id, ExitCode: 0}, nil
+}
+
+func TestRouterSessionActions(t *testing.T) {
+	sessions := &mockSessionOperator{output: "psql> "}
+	r := &Router{Sessions: sessions}
+	actx := ActionContext{AgentID: "agent-1", BeadID: "bead-1", ProjectID: "proj-1"}
+
+	env := &ActionEnvelope{Actions: []Action{
+		{Type: ActionOpenSession, Command: "psql", TimeoutSeconds: 60},
+		{Type: ActionSessionInput, SessionID: "sess-1", Input: `\dt`},
+		{Type: ActionSessionRead, SessionID: "sess-1", TimeoutSeconds: 600},
+		{Type: ActionCloseSession, SessionID: "sess-1"},
+	}}
+	if err := Validate(env); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	results, err := r.Execute(context.Background(), env, actx)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for _, res := range results {
+		if res.Status != "executed" {
+			t.Fatalf("%s: status %s (%s)", res.ActionType, res.Status, res.Message)
+		}
+	}
+
+	if sessions.openReq.BeadID != "bead-1" || sessions.openReq.IdleTimeout != 60 {
+		t.Errorf("open request = %+v", sessions.openReq)
+	}
+	if len(sessions.inputs) != 1 || sessions.inputs[0] != "\\dt\n" {
+		t.Errorf("inputs = %q, want newline-terminated line", sessions.inputs)
+	}
+	if results[1].Metadata["output"] != "psql> " {
+		t.Errorf("session_input should return new output, got %v", results[1].Metadata)
+	}
+	if sessions.lastWait != maxSessionReadWait {
+		t.Errorf("read wait = %v, want capped at %v", sessions.lastWait, maxSessionReadWait)
+	}
+	if len(sessions.closed) != 1 {
+		t.Errorf("closed = %v", sessions.closed)
+	}
+}
+
+func TestRouterSessionActionsNotConfigured(t *testing.T) {
+	r := &Router{}
+	res := r.executeAction(context.Background(), Action{Type: ActionOpenSession, Command: "psql"}, ActionContext{})
+	if res.Status != "error" {
+		t.Errorf("expected error without session operator, got %s", res.Status)
+	}
+}
+
+func TestValidateSessionActions(t *testing.T) {
+	for _, a := range []Action{
+		{Type: ActionOpenSession},
+		{Type: ActionSessionInput, SessionID: "sess-1"},
+		{Type: ActionSessionRead},
+		{Type: ActionCloseSession},
+	} {
+		if err := validateAction(a); err == nil {
+			t.Errorf("%s: expected validation error", a.Type)
+		}
+	}
+}
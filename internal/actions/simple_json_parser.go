@@ -12,12 +12,24 @@ type SimpleJSONAction struct {
 	Query   string `json:"query,omitempty"`
 	Old     string `json:"old,omitempty"`
 	New     string `json:"new,omitempty"`
+	Start   int    `json:"start_line,omitempty"`
+	End     int    `json:"end_line,omitempty"`
 	Content string `json:"content,omitempty"`
 	Command string `json:"command,omitempty"`
 	Pattern string `json:"pattern,omitempty"`
 	Message string `json:"message,omitempty"`
 	Reason  string `json:"reason,omitempty"`
 	Notes   string `json:"notes,omitempty"`
+
+	Edits []SimpleJSONEdit `json:"edits,omitempty"` // Several edits to one file, all or nothing
+}
+
+// SimpleJSONEdit is one edit of a multi-edit in simple mode
+type SimpleJSONEdit struct {
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+	Start int    `json:"start_line,omitempty"`
+	End   int    `json:"end_line,omitempty"`
 }
 
 // ParseSimpleJSON parses the minimal JSON action format into an ActionEnvelope.
@@ -82,8 +94,22 @@ func simpleToAction(s SimpleJSONAction) (Action, error) {
 		return Action{Type: ActionSearchText, Query: s.Query, Path: s.Path}, nil
 
 	case "edit":
+		if s.Path != "" && len(s.Edits) > 0 {
+			edits := make([]EditOp, 0, len(s.Edits))
+			for _, e := range s.Edits {
+				edits = append(edits, EditOp{OldText: e.Old, NewText: e.New, StartLine: e.Start, EndLine: e.End})
+			}
+			action := Action{Type: ActionMultiEdit, Path: s.Path, Edits: edits}
+			if err := validateAction(action); err != nil {
+				return Action{}, &ValidationError{Err: err}
+			}
+			return action, nil
+		}
+		if s.Path != "" && s.Old == "" && s.Start > 0 {
+			return Action{Type: ActionEditCode, Path: s.Path, StartLine: s.Start, EndLine: s.End, NewText: s.New}, nil
+		}
 		if s.Path == "" || s.Old == "" {
-			return Action{}, &ValidationError{Err: fmt.Errorf("edit requires 'path' and 'old'")}
+			return Action{}, &ValidationError{Err: fmt.Errorf("edit requires 'path' and 'old' (or 'start_line', or 'edits')")}
 		}
 		return Action{Type: ActionEditCode, Path: s.Path, OldText: s.Old, NewText: s.New}, nil
 
@@ -123,6 +149,12 @@ func simpleToAction(s SimpleJSONAction) (Action, error) {
 	case "escalate":
 		return Action{Type: ActionEscalateCEO, Reason: s.Reason}, nil
 
+	case "attach_image":
+		if s.Path == "" {
+			return Action{}, &ValidationError{Err: fmt.Errorf("attach_image requires 'path'")}
+		}
+		return Action{Type: ActionAttachImage, Path: s.Path}, nil
+
 	default:
 		return Action{}, &ValidationError{Err: fmt.Errorf("unknown action '%s'. Use: scope, read, search, edit, write, build, test, bash, done, close_bead, git_commit, git_push", s.Action)}
 	}
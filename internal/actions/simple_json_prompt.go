@@ -37,9 +37,12 @@ UNCOMMITTED WORK IS LOST. Always git_commit before done.
 {"action": "scope", "path": "."}                       — List directory contents
 {"action": "read", "path": "file.go"}                   — Read a file
 {"action": "search", "query": "pattern"}                 — Search for text in project
+{"action": "attach_image", "path": "shot.png"}           — Look at an image (screenshot, mock)
 
 ### Change
 {"action": "edit", "path": "file.go", "old": "exact text to find", "new": "replacement text"}
+{"action": "edit", "path": "file.go", "start_line": 10, "end_line": 12, "new": "replacement for lines 10-12"}
+{"action": "edit", "path": "file.go", "edits": [{"old": "a", "new": "b"}, {"old": "c", "new": "d"}]}  — All or nothing
 {"action": "write", "path": "file.go", "content": "full file content"}
 
 ### Verify
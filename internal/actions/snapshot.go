@@ -0,0 +1,44 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/logging"
+)
+
+var actionsLog = logging.Module("actions")
+
+// DefaultAutoSnapshotPatchBytes is the combined apply_patch size at which the
+// router snapshots the workdir before running an envelope.
+const DefaultAutoSnapshotPatchBytes = 4096
+
+// autoSnapshot takes a restore point before envelopes whose apply_patch
+// actions together reach AutoSnapshotPatchBytes. It returns the snapshot ID,
+// or "" when no snapshot was needed or it could not be taken; a failed
+// snapshot never blocks the envelope.
+func (r *Router) autoSnapshot(ctx context.Context, env *ActionEnvelope, actx ActionContext) string {
+	if r.Snapshots == nil || r.AutoSnapshotPatchBytes <= 0 || actx.ProjectID == "" {
+		return ""
+	}
+	size, patches := 0, 0
+	for _, action := range env.Actions {
+		if action.Type == ActionApplyPatch {
+			size += len(action.Patch)
+			patches++
+		}
+	}
+	if size < r.AutoSnapshotPatchBytes {
+		return ""
+	}
+	label := fmt.Sprintf("auto: before %d apply_patch action(s), %d bytes", patches, size)
+	if actx.BeadID != "" {
+		label += " for " + actx.BeadID
+	}
+	snap, err := r.Snapshots.CreateSnapshot(ctx, actx.ProjectID, label)
+	if err != nil {
+		actionsLog.Warn("Auto-snapshot failed", "project_id", actx.ProjectID, "error", err)
+		return ""
+	}
+	return snap.ID
+}
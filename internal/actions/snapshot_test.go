@@ -0,0 +1,77 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/files"
+)
+
+type mockSnapshotter struct {
+	labels []string
+	err    error
+}
+
+func (m *mockSnapshotter) CreateSnapshot(ctx context.Context, projectID, label string) (*files.Snapshot, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.labels = append(m.labels, label)
+	return &files.Snapshot{ID: "snap-1", ProjectID: projectID, Label: label}, nil
+}
+
+func TestRouterAutoSnapshotsLargePatchEnvelopes(t *testing.T) {
+	snaps := &mockSnapshotter{}
+	r := &Router{Files: &mockFileManager{}, Snapshots: snaps, AutoSnapshotPatchBytes: 10}
+	actx := ActionContext{ProjectID: "proj-1", BeadID: "bead-1"}
+
+	small := &ActionEnvelope{Actions: []Action{{Type: ActionApplyPatch, Patch: "diff"}}}
+	if _, err := r.Execute(context.Background(), small, actx); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if len(snaps.labels) != 0 {
+		t.Fatalf("snapshot taken for small envelope: %v", snaps.labels)
+	}
+
+	large := &ActionEnvelope{Actions: []Action{
+		{Type: ActionReadFile, Path: "main.go"},
+		{Type: ActionApplyPatch, Patch: "diff --git a/x b/x"},
+		{Type: ActionApplyPatch, Patch: "diff"},
+	}}
+	results, err := r.Execute(context.Background(), large, actx)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if len(snaps.labels) != 1 || !strings.Contains(snaps.labels[0], "2 apply_patch") || !strings.Contains(snaps.labels[0], "bead-1") {
+		t.Fatalf("labels = %v", snaps.labels)
+	}
+	if _, ok := results[0].Metadata["snapshot_id"]; ok {
+		t.Error("snapshot_id attached to a non-patch result")
+	}
+	for _, res := range results[1:] {
+		if res.Metadata["snapshot_id"] != "snap-1" {
+			t.Errorf("result metadata = %+v", res.Metadata)
+		}
+	}
+}
+
+func TestRouterAutoSnapshotFailureDoesNotBlock(t *testing.T) {
+	r := &Router{
+		Files:                  &mockFileManager{},
+		Snapshots:              &mockSnapshotter{err: errors.New("not a git repository")},
+		AutoSnapshotPatchBytes: 1,
+	}
+	env := &ActionEnvelope{Actions: []Action{{Type: ActionApplyPatch, Patch: "diff"}}}
+	results, err := r.Execute(context.Background(), env, ActionContext{ProjectID: "proj-1"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if results[0].Status != "executed" {
+		t.Errorf("status = %s", results[0].Status)
+	}
+	if _, ok := results[0].Metadata["snapshot_id"]; ok {
+		t.Error("unexpected snapshot_id")
+	}
+}
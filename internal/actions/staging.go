@@ -0,0 +1,66 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/k8s"
+)
+
+// handleStagingAction deploys the bead's build to its staging namespace,
+// reports the namespace's state or tears it down.
+func (r *Router) handleStagingAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Staging == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "kubernetes staging not configured"}
+	}
+	if actx.BeadID == "" {
+		return Result{ActionType: action.Type, Status: "error", Message: "staging deployments need a bead"}
+	}
+
+	var dep *k8s.Deployment
+	var err error
+	switch action.Type {
+	case ActionTeardownStaging:
+		if err := r.Staging.Teardown(ctx, actx.ProjectID, actx.BeadID); err != nil {
+			return errorResult(action.Type, err)
+		}
+		return Result{ActionType: action.Type, Status: "executed", Message: "staging namespace deleted"}
+	case ActionStagingStatus:
+		dep, err = r.Staging.Status(ctx, actx.ProjectID, actx.BeadID)
+	default:
+		dep, err = r.Staging.Deploy(ctx, actx.ProjectID, actx.BeadID, k8s.DeployOptions{
+			Image:   action.Image,
+			Timeout: time.Duration(action.TimeoutSeconds) * time.Second,
+		})
+	}
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+
+	ready := 0
+	for _, ro := range dep.Rollouts {
+		if ro.Ready {
+			ready++
+		}
+	}
+	message := fmt.Sprintf("namespace %s: %d/%d workloads rolled out, %d pods", dep.Namespace, ready, len(dep.Rollouts), len(dep.Pods))
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    message,
+		Metadata: map[string]interface{}{
+			"namespace": dep.Namespace,
+			"mode":      dep.Mode,
+			"release":   dep.Release,
+			"image":     dep.Image,
+			"success":   dep.Success,
+			"rollouts":  dep.Rollouts,
+			"pods":      dep.Pods,
+			"logs":      dep.Logs,
+			"output":    dep.Output,
+			"error":     dep.Error,
+			"duration":  dep.Duration,
+		},
+	}
+}
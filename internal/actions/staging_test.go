@@ -0,0 +1,65 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/k8s"
+)
+
+type mockStaging struct {
+	opts     k8s.DeployOptions
+	dep      *k8s.Deployment
+	tornDown string
+}
+
+func (m *mockStaging) Deploy(ctx context.Context, projectID, beadID string, opts k8s.DeployOptions) (*k8s.Deployment, error) {
+	m.opts = opts
+	return m.dep, nil
+}
+
+func (m *mockStaging) Status(ctx context.Context, projectID, beadID string) (*k8s.Deployment, error) {
+	return m.dep, nil
+}
+
+func (m *mockStaging) Teardown(ctx context.Context, projectID, beadID string) error {
+	m.tornDown = beadID
+	return nil
+}
+
+func TestRouterStagingActions(t *testing.T) {
+	staging := &mockStaging{dep: &k8s.Deployment{
+		Namespace: "loom-web-bd-1",
+		Mode:      k8s.ModeHelm,
+		Rollouts:  []k8s.Rollout{{Resource: "deployment.apps/web", Ready: true}, {Resource: "deployment.apps/worker", Message: "0 of 1 updated replicas are available"}},
+		Pods:      []k8s.Pod{{Name: "worker-1", Phase: "Running", Ready: "0/1", Restarts: 3, Reason: "CrashLoopBackOff"}},
+		Logs:      map[string]string{"worker-1": "panic: missing DATABASE_URL"},
+	}}
+	r := &Router{Staging: staging}
+	actx := ActionContext{ProjectID: "web", BeadID: "bd-1"}
+
+	res := r.executeAction(context.Background(), Action{Type: ActionDeployStaging, Image: "registry.local/web:bd-1", TimeoutSeconds: 120}, actx)
+	if res.Status != "executed" || res.Message != "namespace loom-web-bd-1: 1/2 workloads rolled out, 1 pods" {
+		t.Fatalf("unexpected result %s: %s", res.Status, res.Message)
+	}
+	if staging.opts.Image != "registry.local/web:bd-1" || staging.opts.Timeout.Seconds() != 120 {
+		t.Errorf("unexpected deploy options %+v", staging.opts)
+	}
+	feedback := FormatResultsAsUserMessage([]Result{res})
+	for _, want := range []string{"**Staging: NOT READY**", "deployment.apps/worker: not ready", "CrashLoopBackOff", "panic: missing DATABASE_URL"} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback missing %q:\n%s", want, feedback)
+		}
+	}
+
+	if res := r.executeAction(context.Background(), Action{Type: ActionTeardownStaging}, actx); res.Status != "executed" || staging.tornDown != "bd-1" {
+		t.Errorf("teardown = %s: %s", res.Status, res.Message)
+	}
+	if res := r.executeAction(context.Background(), Action{Type: ActionStagingStatus}, ActionContext{ProjectID: "web"}); res.Status != "error" {
+		t.Errorf("expected staging without a bead to fail, got %s", res.Status)
+	}
+	if res := (&Router{}).executeAction(context.Background(), Action{Type: ActionStagingStatus}, actx); res.Status != "error" {
+		t.Errorf("expected staging without a deployer to fail, got %s", res.Status)
+	}
+}
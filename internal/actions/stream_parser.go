@@ -0,0 +1,240 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// earlyActions are read-only actions safe to execute before the envelope
+// has finished streaming
+var earlyActions = map[string]bool{
+	ActionReadFile:            true,
+	ActionReadCode:            true,
+	ActionReadTree:            true,
+	ActionSearchText:          true,
+	ActionGitStatus:           true,
+	ActionGitDiff:             true,
+	ActionGitLog:              true,
+	ActionGitListBranches:     true,
+	ActionGitDiffBranches:     true,
+	ActionGitBeadCommits:      true,
+	ActionGitDiffRevisions:    true,
+	ActionGitBlame:            true,
+	ActionFindReferences:      true,
+	ActionGoToDefinition:      true,
+	ActionFindImplementations: true,
+	ActionAnalyzeImpact:       true,
+}
+
+// IsEarlyAction reports whether an action type is read-only and may be
+// dispatched while the response is still streaming
+func IsEarlyAction(actionType string) bool {
+	return earlyActions[actionType]
+}
+
+// StreamedAction is an action found in a partial envelope
+type StreamedAction struct {
+	Index  int
+	Action Action
+}
+
+// StreamParser incrementally scans a streamed action envelope and reports
+// each element of its "actions" array as soon as the element's closing
+// brace arrives. It tolerates leading prose, code fences and think blocks.
+type StreamParser struct {
+	buf []byte
+	pos int
+
+	thinking bool
+	inString bool
+	escaped  bool
+	stack    []byte
+
+	strStart   int
+	pendingKey string
+	currentKey string
+	arrayDepth int // stack depth inside the actions array, 0 until found
+	objStart   int
+	index      int
+	done       bool
+}
+
+// NewStreamParser creates a parser for one streamed response
+func NewStreamParser() *StreamParser {
+	return &StreamParser{objStart: -1}
+}
+
+// Write appends a chunk and returns the actions completed by it
+func (p *StreamParser) Write(chunk string) []StreamedAction {
+	p.buf = append(p.buf, chunk...)
+	var found []StreamedAction
+	for ; p.pos < len(p.buf) && !p.done; p.pos++ {
+		if a, ok := p.step(); ok {
+			found = append(found, a)
+		}
+	}
+	return found
+}
+
+// step consumes the byte at p.pos
+func (p *StreamParser) step() (StreamedAction, bool) {
+	b := p.buf[p.pos]
+
+	if p.thinking {
+		if bytes.HasSuffix(p.buf[:p.pos+1], []byte("</think>")) {
+			p.thinking = false
+		}
+		return StreamedAction{}, false
+	}
+	if p.inString {
+		switch {
+		case p.escaped:
+			p.escaped = false
+		case b == '\\':
+			p.escaped = true
+		case b == '"':
+			p.inString = false
+			if len(p.stack) == 1 {
+				var key string
+				if json.Unmarshal(p.buf[p.strStart:p.pos+1], &key) == nil {
+					p.pendingKey = key
+				}
+			}
+		}
+		return StreamedAction{}, false
+	}
+	if len(p.stack) == 0 {
+		// Outside the envelope only an opening brace or a think block matters
+		switch {
+		case b == '{':
+			p.stack = append(p.stack, b)
+		case bytes.HasSuffix(p.buf[:p.pos+1], []byte("<think>")):
+			p.thinking = true
+		}
+		return StreamedAction{}, false
+	}
+
+	switch b {
+	case '"':
+		p.inString = true
+		p.strStart = p.pos
+	case ':':
+		if len(p.stack) == 1 {
+			p.currentKey = p.pendingKey
+		}
+	case ',':
+		if len(p.stack) == 1 {
+			p.currentKey = ""
+		}
+	case '{', '[':
+		p.stack = append(p.stack, b)
+		switch {
+		case b == '[' && len(p.stack) == 2 && p.currentKey == "actions" && p.arrayDepth == 0:
+			p.arrayDepth = len(p.stack)
+		case b == '{' && p.arrayDepth > 0 && len(p.stack) == p.arrayDepth+1:
+			p.objStart = p.pos
+		}
+	case '}', ']':
+		depth := len(p.stack)
+		p.stack = p.stack[:depth-1]
+		if b == '}' && p.objStart >= 0 && depth == p.arrayDepth+1 {
+			obj := p.buf[p.objStart : p.pos+1]
+			p.objStart = -1
+			idx := p.index
+			p.index++
+			var action Action
+			if json.Unmarshal(obj, &action) == nil && action.Type != "" {
+				return StreamedAction{Index: idx, Action: action}, true
+			}
+		}
+		if depth == 1 {
+			// Stop after the first envelope; an object without actions was
+			// prose, so keep looking
+			p.done = p.arrayDepth > 0
+			p.currentKey, p.pendingKey = "", ""
+		}
+	}
+	return StreamedAction{}, false
+}
+
+// EarlyDispatcher executes read-only actions while an envelope is still
+// streaming. Only actions preceded exclusively by read-only actions are
+// started early, so no early read can observe the envelope's own writes.
+// Finish executes the complete envelope, reusing early results for actions
+// that match what was dispatched.
+type EarlyDispatcher struct {
+	router *Router
+	ctx    context.Context
+	actx   ActionContext
+	parser *StreamParser
+
+	blocked bool
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	early   map[int]earlyResult
+}
+
+type earlyResult struct {
+	action Action
+	result Result
+}
+
+// NewEarlyDispatcher creates a dispatcher for one streamed response
+func (r *Router) NewEarlyDispatcher(ctx context.Context, actx ActionContext) *EarlyDispatcher {
+	if actx.ProjectID != "" {
+		ctx = WithProjectID(ctx, actx.ProjectID)
+	}
+	return &EarlyDispatcher{
+		router: r,
+		ctx:    ctx,
+		actx:   actx,
+		parser: NewStreamParser(),
+		early:  map[int]earlyResult{},
+	}
+}
+
+// Write feeds streamed text and starts any read-only actions it completes
+func (d *EarlyDispatcher) Write(chunk string) {
+	for _, sa := range d.parser.Write(chunk) {
+		if d.blocked || !IsEarlyAction(sa.Action.Type) || validateAction(sa.Action) != nil {
+			d.blocked = true
+			continue
+		}
+		d.wg.Add(1)
+		go func(sa StreamedAction) {
+			defer d.wg.Done()
+			result := d.router.executeWithTimeout(d.ctx, sa.Action, d.actx)
+			d.mu.Lock()
+			d.early[sa.Index] = earlyResult{action: sa.Action, result: result}
+			d.mu.Unlock()
+		}(sa)
+	}
+}
+
+// Dispatched returns how many actions were started early
+func (d *EarlyDispatcher) Dispatched() int {
+	d.wg.Wait()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.early)
+}
+
+// Finish waits for early actions and executes the decoded envelope. Early
+// results whose action differs from the final envelope are discarded.
+func (d *EarlyDispatcher) Finish(ctx context.Context, env *ActionEnvelope) ([]Result, error) {
+	d.wg.Wait()
+	d.mu.Lock()
+	prefetched := make(map[int]Result, len(d.early))
+	if env != nil {
+		for i, e := range d.early {
+			if i < len(env.Actions) && reflect.DeepEqual(env.Actions[i], e.action) {
+				prefetched[i] = e.result
+			}
+		}
+	}
+	d.mu.Unlock()
+	return d.router.execute(ctx, env, d.actx, prefetched)
+}
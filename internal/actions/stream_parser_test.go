@@ -0,0 +1,116 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/files"
+)
+
+func feed(p *StreamParser, s string, size int) []StreamedAction {
+	var out []StreamedAction
+	for i := 0; i < len(s); i += size {
+		end := i + size
+		if end > len(s) {
+			end = len(s)
+		}
+		out = append(out, p.Write(s[i:end])...)
+	}
+	return out
+}
+
+func TestStreamParser_EmitsCompletedActions(t *testing.T) {
+	stream := "<think>maybe {\"actions\": [{\"type\": \"x\"}]}</think>\nSure {not json}:\n```json\n" +
+		`{"notes": "a \"quoted\" } brace", "actions": [` +
+		`{"type": "read_file", "path": "a{b}.go"},` +
+		`{"type": "write_file", "path": "b.go", "content": "x := map[string]int{\"a\": 1}"},` +
+		`{"type": "search_text", "query": "]"}` +
+		"]}\n```\n{\"actions\": [{\"type\": \"done\"}]}"
+
+	for _, size := range []int{1, 3, 7, len(stream)} {
+		p := NewStreamParser()
+		got := feed(p, stream, size)
+		if len(got) != 3 {
+			t.Fatalf("chunk size %d: got %d actions: %+v", size, len(got), got)
+		}
+		if got[0].Action.Path != "a{b}.go" || got[1].Index != 1 || got[2].Action.Query != "]" {
+			t.Errorf("chunk size %d: unexpected actions: %+v", size, got)
+		}
+	}
+}
+
+func TestStreamParser_ActionAvailableBeforeStreamEnds(t *testing.T) {
+	p := NewStreamParser()
+	if got := p.Write(`{"actions": [{"type": "read_file", "path": "a.go"`); len(got) != 0 {
+		t.Fatalf("incomplete action emitted: %+v", got)
+	}
+	if got := p.Write(`}, {"type": "run_tests"`); len(got) != 1 || got[0].Action.Type != ActionReadFile {
+		t.Fatalf("completed action not emitted: %+v", got)
+	}
+}
+
+// countingFiles records reads so tests can tell early and final execution apart
+type countingFiles struct {
+	FileManager
+	mu    sync.Mutex
+	reads int
+}
+
+func (f *countingFiles) ReadFile(ctx context.Context, projectID, path string) (*files.FileResult, error) {
+	f.mu.Lock()
+	f.reads++
+	f.mu.Unlock()
+	return &files.FileResult{Path: path, Content: "content of " + path}, nil
+}
+
+func (f *countingFiles) WriteFile(ctx context.Context, projectID, path, content string) (*files.WriteResult, error) {
+	return &files.WriteResult{Path: path, BytesWritten: int64(len(content))}, nil
+}
+
+func TestEarlyDispatcher_ReusesReadOnlyResults(t *testing.T) {
+	fm := &countingFiles{}
+	router := &Router{Files: fm}
+	raw := `{"actions": [{"type": "read_file", "path": "a.go"}, {"type": "read_file", "path": "b.go"},` +
+		` {"type": "write_file", "path": "a.go", "content": "new"}, {"type": "read_file", "path": "a.go"}]}`
+
+	d := router.NewEarlyDispatcher(context.Background(), ActionContext{ProjectID: "p"})
+	for _, chunk := range strings.SplitAfter(raw, "},") {
+		d.Write(chunk)
+	}
+	if n := d.Dispatched(); n != 2 {
+		t.Fatalf("expected the two leading reads to run early, got %d", n)
+	}
+
+	env, err := DecodeLenient([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := d.Finish(context.Background(), env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 || results[1].Metadata["content"] != "content of b.go" || results[2].ActionType != ActionWriteFile {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if fm.reads != 3 {
+		t.Errorf("expected 3 reads (2 early, 1 after the write), got %d", fm.reads)
+	}
+}
+
+func TestEarlyDispatcher_DiscardsMismatchedResults(t *testing.T) {
+	fm := &countingFiles{}
+	router := &Router{Files: fm}
+	d := router.NewEarlyDispatcher(context.Background(), ActionContext{})
+	d.Write(`{"actions": [{"type": "read_file", "path": "draft.go"}]}`)
+
+	env := &ActionEnvelope{Actions: []Action{{Type: ActionReadFile, Path: "final.go"}}}
+	results, err := d.Finish(context.Background(), env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Metadata["path"] != "final.go" || fm.reads != 2 {
+		t.Errorf("stale early result used: %+v (reads=%d)", results, fm.reads)
+	}
+}
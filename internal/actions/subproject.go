@@ -0,0 +1,113 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+const lspRootKey contextKey = "lspRoot"
+
+// ProjectLookup resolves projects so actions can be scoped to a monorepo subproject
+type ProjectLookup interface {
+	GetProject(id string) (*models.Project, error)
+}
+
+// WithLSPRoot returns a context carrying the workspace root, relative to the
+// project, that language servers should use.
+func WithLSPRoot(ctx context.Context, root string) context.Context {
+	return context.WithValue(ctx, lspRootKey, root)
+}
+
+// LSPRootFromContext extracts the language server workspace root from context.
+func LSPRootFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(lspRootKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// resolveSubproject looks up the action's project and picks the subproject it
+// applies to: the action's explicit subproject, then the most specific
+// subproject containing the action's path or files, then the bead's. A nil
+// subproject means the action applies to the whole repository.
+func (r *Router) resolveSubproject(action Action, actx ActionContext) (*models.Project, *models.Subproject, error) {
+	if r.Projects == nil || actx.ProjectID == "" {
+		return nil, nil, nil
+	}
+	project, err := r.Projects.GetProject(actx.ProjectID)
+	if err != nil || project == nil {
+		return nil, nil, nil
+	}
+
+	if action.Subproject != "" {
+		sp := project.FindSubproject(action.Subproject)
+		if sp == nil {
+			return nil, nil, fmt.Errorf("unknown subproject %q (known: %s)", action.Subproject, subprojectNames(project))
+		}
+		return project, sp, nil
+	}
+
+	paths := append([]string{action.Path}, action.Files...)
+	for _, p := range paths {
+		if p == "" || p == "." {
+			continue
+		}
+		if sp := project.SubprojectForPath(p); sp != nil {
+			return project, sp, nil
+		}
+	}
+
+	if actx.Subproject != "" {
+		if sp := project.FindSubproject(actx.Subproject); sp != nil {
+			return project, sp, nil
+		}
+	}
+	return project, nil, nil
+}
+
+// subprojectDir returns the directory a subproject's commands run in
+func subprojectDir(project *models.Project, sp *models.Subproject) string {
+	if project.WorkDir == "" {
+		return sp.Path
+	}
+	return filepath.Join(project.WorkDir, sp.Path)
+}
+
+// scopeFileWalk limits tree listings and searches to the subproject: an
+// empty path defaults to the subproject root and its ignore rules apply.
+func scopeFileWalk(ctx context.Context, sp *models.Subproject, path string) (context.Context, string) {
+	if sp == nil {
+		return ctx, path
+	}
+	if path == "" {
+		path = sp.Path
+	}
+	if len(sp.Ignore) > 0 {
+		ctx = files.WithIgnore(ctx, sp.Ignores)
+	}
+	return ctx, path
+}
+
+// lspContext roots language servers at the subproject the action resolves to
+func (r *Router) lspContext(ctx context.Context, action Action, actx ActionContext) context.Context {
+	if _, sp, err := r.resolveSubproject(action, actx); err == nil && sp != nil {
+		return WithLSPRoot(ctx, sp.LSPRootPath())
+	}
+	return ctx
+}
+
+func subprojectNames(project *models.Project) string {
+	if len(project.Subprojects) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(project.Subprojects))
+	for _, sp := range project.Subprojects {
+		names = append(names, sp.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,151 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type staticProjects map[string]*models.Project
+
+func (s staticProjects) GetProject(id string) (*models.Project, error) {
+	if p, ok := s[id]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("project not found: %s", id)
+}
+
+func monorepoProject(workDir string) staticProjects {
+	return staticProjects{"mono": {
+		ID:      "mono",
+		WorkDir: workDir,
+		Subprojects: []models.Subproject{
+			{Name: "api", Path: "services/api", BuildCommand: "go build ./...", TestCommand: "go test ./...", LSPRoot: "cmd"},
+			{Name: "web", Path: "web", Ignore: []string{"node_modules"}},
+		},
+	}}
+}
+
+func TestRouterRunsSubprojectCommandFromBeadScope(t *testing.T) {
+	cmds := &mockCommandExecutor{}
+	r := &Router{Commands: cmds, Projects: monorepoProject("/work/mono")}
+	actx := ActionContext{AgentID: "agent-1", BeadID: "bead-1", ProjectID: "mono", Subproject: "api"}
+
+	res := r.executeAction(context.Background(), Action{Type: ActionRunTests}, actx)
+	if res.Status != "executed" {
+		t.Fatalf("expected executed, got %s: %s", res.Status, res.Message)
+	}
+	if cmds.lastReq.Command != "go test ./..." {
+		t.Fatalf("expected subproject test command, got %q", cmds.lastReq.Command)
+	}
+	if cmds.lastReq.WorkingDir != filepath.Join("/work/mono", "services/api") {
+		t.Fatalf("expected subproject working dir, got %q", cmds.lastReq.WorkingDir)
+	}
+	if res.Metadata["subproject"] != "api" {
+		t.Fatalf("expected subproject metadata, got %v", res.Metadata)
+	}
+
+	res = r.executeAction(context.Background(), Action{Type: ActionBuildProject}, actx)
+	if cmds.lastReq.Command != "go build ./..." || res.Message != "build executed" {
+		t.Fatalf("expected subproject build command, got %q (%s)", cmds.lastReq.Command, res.Message)
+	}
+}
+
+func TestRouterPassesSubprojectDirToRunner(t *testing.T) {
+	var gotPath string
+	r := &Router{
+		Projects: monorepoProject("/work/mono"),
+		Tests: &mockTestRunner{runFunc: func(ctx context.Context, projectPath, testPattern, framework string, timeoutSeconds int) (map[string]interface{}, error) {
+			gotPath = projectPath
+			return map[string]interface{}{"success": true}, nil
+		}},
+	}
+
+	// web has no test command, so the runner handles it from the subproject dir
+	res := r.executeAction(context.Background(), Action{Type: ActionRunTests, Subproject: "web"}, ActionContext{ProjectID: "mono"})
+	if res.Status != "executed" {
+		t.Fatalf("expected executed, got %s: %s", res.Status, res.Message)
+	}
+	if gotPath != filepath.Join("/work/mono", "web") {
+		t.Fatalf("expected runner to get subproject dir, got %q", gotPath)
+	}
+
+	gotPath = ""
+	r.executeAction(context.Background(), Action{Type: ActionRunTests}, ActionContext{ProjectID: "mono"})
+	if gotPath != "." {
+		t.Fatalf("expected whole-repo run without a subproject, got %q", gotPath)
+	}
+}
+
+func TestRouterRejectsUnknownSubproject(t *testing.T) {
+	r := &Router{Commands: &mockCommandExecutor{}, Projects: monorepoProject("/work/mono")}
+	res := r.executeAction(context.Background(), Action{Type: ActionBuildProject, Subproject: "billing"}, ActionContext{ProjectID: "mono"})
+	if res.Status != "error" {
+		t.Fatalf("expected error for unknown subproject, got %s", res.Status)
+	}
+}
+
+func TestRouterScopesSearchToSubproject(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"web/src/app.js":                "const needle = 1",
+		"web/node_modules/lib/index.js": "needle",
+		"services/api/main.go":          "// needle",
+	} {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Router{Files: files.NewManager(toolrun.StaticWorkDir(dir)), Projects: monorepoProject(dir)}
+
+	res := r.executeAction(context.Background(), Action{Type: ActionSearchText, Query: "needle"}, ActionContext{ProjectID: "mono", Subproject: "web"})
+	if res.Status != "executed" {
+		t.Fatalf("expected executed, got %s: %s", res.Status, res.Message)
+	}
+	matches := res.Metadata["matches"].([]files.SearchMatch)
+	if len(matches) != 1 || matches[0].Path != "web/src/app.js" {
+		t.Fatalf("expected only the non-ignored web match, got %+v", matches)
+	}
+
+	// Without a bead scope the whole repository is searched
+	res = r.executeAction(context.Background(), Action{Type: ActionSearchText, Query: "needle"}, ActionContext{ProjectID: "mono"})
+	if got := len(res.Metadata["matches"].([]files.SearchMatch)); got != 3 {
+		t.Fatalf("expected 3 repository-wide matches, got %d", got)
+	}
+}
+
+type recordingLSP struct{ root string }
+
+func (l *recordingLSP) FindReferences(ctx context.Context, file string, line, column int, symbol string) (map[string]interface{}, error) {
+	l.root = LSPRootFromContext(ctx)
+	return map[string]interface{}{"count": 0}, nil
+}
+
+func (l *recordingLSP) GoToDefinition(ctx context.Context, file string, line, column int, symbol string) (map[string]interface{}, error) {
+	l.root = LSPRootFromContext(ctx)
+	return map[string]interface{}{"found": false}, nil
+}
+
+func (l *recordingLSP) FindImplementations(ctx context.Context, file string, line, column int, symbol string) (map[string]interface{}, error) {
+	l.root = LSPRootFromContext(ctx)
+	return map[string]interface{}{"count": 0}, nil
+}
+
+func TestRouterRootsLSPAtSubproject(t *testing.T) {
+	lsp := &recordingLSP{}
+	r := &Router{LSP: lsp, Projects: monorepoProject("/work/mono")}
+	r.executeAction(context.Background(), Action{Type: ActionFindReferences, Path: "services/api/cmd/main.go", Line: 3}, ActionContext{ProjectID: "mono"})
+	if lsp.root != "services/api/cmd" {
+		t.Fatalf("expected LSP root services/api/cmd, got %q", lsp.root)
+	}
+}
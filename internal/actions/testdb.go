@@ -0,0 +1,126 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/testdb"
+)
+
+// handleTestDBAction creates, migrates, loads fixtures into, queries or
+// drops the bead's throwaway database.
+func (r *Router) handleTestDBAction(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Databases == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "test databases not configured"}
+	}
+	if actx.BeadID == "" {
+		return Result{ActionType: action.Type, Status: "error", Message: "test databases need a bead"}
+	}
+
+	switch action.Type {
+	case ActionDBCreate:
+		db, err := r.Databases.Create(ctx, actx.ProjectID, actx.BeadID, action.Engine)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		return Result{
+			ActionType: action.Type,
+			Status:     "executed",
+			Message:    fmt.Sprintf("created an empty %s database for bead %s; migration commands get it as DATABASE_URL", db.Engine, actx.BeadID),
+			Metadata: map[string]interface{}{
+				"engine":    db.Engine,
+				"url":       db.URL,
+				"path":      db.Path,
+				"container": db.Container,
+			},
+		}
+
+	case ActionDBMigrate:
+		mig, err := r.Databases.Migrate(ctx, actx.BeadID, testdb.MigrateOptions{Path: action.Path, Command: action.Command, Down: action.Down})
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		applied := 0
+		for _, m := range mig.Migrations {
+			if m.Applied {
+				applied++
+			}
+		}
+		message := fmt.Sprintf("applied %d migrations in %s; the schema has %d tables", applied, mig.Duration, len(mig.Schema))
+		switch {
+		case action.Command != "" && mig.Success:
+			message = fmt.Sprintf("migration command succeeded in %s; the schema has %d tables", mig.Duration, len(mig.Schema))
+		case action.Down && mig.Success:
+			message = fmt.Sprintf("reverted %d migrations in %s; the schema has %d tables", applied, mig.Duration, len(mig.Schema))
+		case !mig.Success:
+			message = mig.Error
+		}
+		return Result{
+			ActionType: action.Type,
+			Status:     "executed",
+			Message:    message,
+			Metadata: map[string]interface{}{
+				"success":    mig.Success,
+				"migrations": mig.Migrations,
+				"output":     strings.Join(mig.Output, "\n"),
+				"schema":     mig.Schema,
+				"duration":   mig.Duration,
+				"error":      mig.Error,
+			},
+		}
+
+	case ActionDBLoadFixtures:
+		paths := action.Files
+		if action.Path != "" {
+			paths = append([]string{action.Path}, paths...)
+		}
+		fix, err := r.Databases.LoadFixtures(ctx, actx.BeadID, paths)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		message := fmt.Sprintf("loaded %d fixture files (%d rows)", len(fix.Files), fix.Rows)
+		if !fix.Success {
+			message = fix.Error + "; no fixtures were loaded"
+		}
+		return Result{
+			ActionType: action.Type,
+			Status:     "executed",
+			Message:    message,
+			Metadata: map[string]interface{}{
+				"success": fix.Success,
+				"files":   fix.Files,
+				"rows":    fix.Rows,
+				"error":   fix.Error,
+			},
+		}
+
+	case ActionDBAssert:
+		results, err := r.Databases.Assert(ctx, actx.BeadID, action.Assertions)
+		if err != nil {
+			return errorResult(action.Type, err)
+		}
+		passed := 0
+		for _, a := range results {
+			if a.Passed {
+				passed++
+			}
+		}
+		return Result{
+			ActionType: action.Type,
+			Status:     "executed",
+			Message:    fmt.Sprintf("%d of %d assertions passed", passed, len(results)),
+			Metadata: map[string]interface{}{
+				"success":    passed == len(results),
+				"assertions": results,
+			},
+		}
+
+	default:
+		if err := r.Databases.Drop(ctx, actx.BeadID); err != nil && !errors.Is(err, testdb.ErrNoDatabase) {
+			return errorResult(action.Type, err)
+		}
+		return Result{ActionType: action.Type, Status: "executed", Message: "test database dropped"}
+	}
+}
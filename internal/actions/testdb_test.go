@@ -0,0 +1,77 @@
+package actions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/testdb"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+func TestRouterTestDB(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"migrations/001_init.sql":  "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL);",
+		"migrations/002_price.sql": "ALTER TABLE items ADD COLUMN price INTEGER;",
+		"fixtures/items.yaml":      "items:\n  - {id: 1, name: pen, price: 3}\n  - {id: 2, name: ink}\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dbs := testdb.NewManager(toolrun.StaticWorkDir(dir), config.TestDatabasesConfig{})
+	defer dbs.Shutdown()
+	r := &Router{Databases: dbs}
+	actx := ActionContext{ProjectID: "shop", BeadID: "b1"}
+	ctx := context.Background()
+
+	if res := r.executeAction(ctx, Action{Type: ActionDBMigrate, Path: "migrations"}, actx); res.Status != "error" || !strings.Contains(res.Message, "db_create") {
+		t.Errorf("expected migrating without a database to point at db_create, got %s: %s", res.Status, res.Message)
+	}
+	if res := r.executeAction(ctx, Action{Type: ActionDBCreate}, actx); res.Status != "executed" || res.Metadata["engine"] != testdb.SQLite {
+		t.Fatalf("unexpected db_create result %s: %s", res.Status, res.Message)
+	}
+
+	res := r.executeAction(ctx, Action{Type: ActionDBMigrate, Path: "migrations"}, actx)
+	feedback := FormatResultsAsUserMessage([]Result{res})
+	for _, want := range []string{"**Database: PASSED** applied 2 migrations", "- 002_price.sql: applied", "- items(id INTEGER, name TEXT NOT NULL, price INTEGER)"} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("migrate feedback missing %q:\n%s", want, feedback)
+		}
+	}
+
+	if res := r.executeAction(ctx, Action{Type: ActionDBLoadFixtures, Path: "fixtures"}, actx); res.Metadata["success"] != true || res.Metadata["rows"] != 2 {
+		t.Fatalf("unexpected fixtures result %s: %s", res.Status, res.Message)
+	}
+
+	res = r.executeAction(ctx, Action{Type: ActionDBAssert, Assertions: []testdb.Assertion{
+		{Name: "priced items", Query: "SELECT name FROM items WHERE price IS NOT NULL", ExpectRows: []map[string]interface{}{{"name": "pen"}}},
+		{Query: "SELECT count(*) AS n FROM items", ExpectValue: 3},
+	}}, actx)
+	feedback = FormatResultsAsUserMessage([]Result{res})
+	for _, want := range []string{"**Database: FAILED** 1 of 2 assertions passed", "- PASSED priced items", `{"name":"pen"}`, "- FAILED SELECT count(*) AS n FROM items: n is 2, expected 3"} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("assert feedback missing %q:\n%s", want, feedback)
+		}
+	}
+
+	if res := r.executeAction(ctx, Action{Type: ActionDBDrop}, actx); res.Status != "executed" {
+		t.Errorf("unexpected db_drop result %s: %s", res.Status, res.Message)
+	}
+	if res := r.executeAction(ctx, Action{Type: ActionDBCreate}, ActionContext{ProjectID: "shop"}); res.Status != "error" {
+		t.Errorf("expected an error without a bead, got %s", res.Status)
+	}
+	if err := Validate(&ActionEnvelope{Actions: []Action{{Type: ActionDBAssert}}}); err == nil {
+		t.Error("expected db_assert without assertions to be rejected")
+	}
+	if err := Validate(&ActionEnvelope{Actions: []Action{{Type: ActionDBMigrate}}}); err == nil {
+		t.Error("expected db_migrate without path or command to be rejected")
+	}
+}
@@ -0,0 +1,95 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/testselect"
+	"github.com/jordanhubbard/loom/internal/toolchain"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// handleRunTests runs the project's tests. In smart mode only the tests
+// covering the changed files run, from the checkout root, unless the
+// selector calls for the full suite. Runs without a test pattern are
+// recorded with the selector so it can schedule full-suite safety runs and
+// gate merges on them.
+func (r *Router) handleRunTests(ctx context.Context, action Action, actx ActionContext) Result {
+	if r.Selector == nil || action.TestPattern != "" {
+		return r.runTests(ctx, action, actx)
+	}
+	sel := &testselect.Selection{Mode: testselect.ModeFull}
+	if action.TestMode == testselect.ModeSmart {
+		sel = r.Selector.Select(ctx, actx.ProjectID, action.Files, action.Framework)
+	}
+
+	var project *models.Project
+	if sel.Mode == testselect.ModeSmart && sel.Command != "" {
+		var err error
+		if project, _, err = r.resolveSubproject(action, actx); err != nil {
+			return errorResult(action.Type, err)
+		}
+		if project == nil || r.Commands == nil {
+			sel = &testselect.Selection{Mode: testselect.ModeFull, Reason: "selected tests need the project checkout and a command executor"}
+		}
+	}
+
+	var result Result
+	switch {
+	case sel.Mode == testselect.ModeFull:
+		result = r.runTests(ctx, action, actx)
+	case sel.Command == "":
+		result = Result{
+			ActionType: action.Type,
+			Status:     "executed",
+			Message:    "no tests cover the changed files",
+			Metadata:   map[string]interface{}{"success": true},
+		}
+	default:
+		result = r.runToolchainCommand(ctx, action, actx, project, nil, sel.Command, "selected", fmt.Sprintf("%d selected test targets executed", len(sel.Targets)))
+	}
+	if result.Status != "executed" || result.Metadata == nil {
+		return result
+	}
+
+	success, _ := result.Metadata["success"].(bool)
+	r.Selector.Record(actx.ProjectID, actx.BeadID, sel.Mode, success)
+	if action.TestMode == testselect.ModeSmart {
+		result.Metadata["test_mode"] = sel.Mode
+		result.Metadata["test_targets"] = sel.Targets
+		if sel.Reason != "" {
+			result.Metadata["full_suite_reason"] = sel.Reason
+		}
+	}
+	return result
+}
+
+// runTests runs the test command configured or detected for the project or
+// subproject, else the test runner
+func (r *Router) runTests(ctx context.Context, action Action, actx ActionContext) Result {
+	project, sp, err := r.resolveSubproject(action, actx)
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+	if command, source := toolchainCommand(project, sp, toolchain.Test); action.TestPattern == "" && r.preferToolchainCommand(command, source, r.Tests != nil) {
+		return r.runToolchainCommand(ctx, action, actx, project, sp, command, source, "tests executed")
+	}
+	if r.Tests == nil {
+		return Result{ActionType: action.Type, Status: "error", Message: "test runner not configured"}
+	}
+	projectPath := "."
+	if sp != nil {
+		projectPath = subprojectDir(project, sp)
+	}
+
+	result, err := r.Tests.Run(ctx, projectPath, action.TestPattern, action.Framework, action.TimeoutSeconds)
+	if err != nil {
+		return errorResult(action.Type, err)
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    "tests executed",
+		Metadata:   result,
+	}
+}
@@ -0,0 +1,69 @@
+package actions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/testselect"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeSelector struct {
+	sel      *testselect.Selection
+	files    []string
+	recorded []string
+}
+
+func (f *fakeSelector) Select(_ context.Context, _ string, files []string, _ string) *testselect.Selection {
+	f.files = files
+	return f.sel
+}
+
+func (f *fakeSelector) Record(projectID, beadID, mode string, success bool) {
+	f.recorded = append(f.recorded, mode)
+}
+
+func TestRouterRunTestsSmartMode(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project := &models.Project{ID: "p", WorkDir: dir}
+	selector := &fakeSelector{sel: &testselect.Selection{Mode: testselect.ModeSmart, Command: "go test ./store", Targets: []string{"./store"}}}
+	cmds := &mockCommandExecutor{}
+	r := &Router{Commands: cmds, Projects: staticProjects{"p": project}, Selector: selector}
+	actx := ActionContext{ProjectID: "p", BeadID: "b"}
+
+	action := Action{Type: ActionRunTests, TestMode: testselect.ModeSmart, Files: []string{"store/store.go"}}
+	res := r.executeAction(context.Background(), action, actx)
+	if res.Status != "executed" || cmds.lastReq.Command != "go test ./store" || cmds.lastReq.WorkingDir != dir {
+		t.Fatalf("expected the selected tests from the checkout root, ran %q in %q: %s", cmds.lastReq.Command, cmds.lastReq.WorkingDir, res.Message)
+	}
+	if res.Metadata["test_mode"] != testselect.ModeSmart || len(selector.files) != 1 {
+		t.Errorf("unexpected metadata %v for files %v", res.Metadata, selector.files)
+	}
+	if msg := FormatResultsAsUserMessage([]Result{res}); !strings.Contains(msg, "Ran only the 1 test targets") {
+		t.Errorf("feedback doesn't mention the selection:\n%s", msg)
+	}
+
+	// A due safety run goes through the usual test command
+	selector.sel = &testselect.Selection{Mode: testselect.ModeFull, Reason: "the full suite runs after every 10 selected runs"}
+	res = r.executeAction(context.Background(), action, actx)
+	if cmds.lastReq.Command != "go test ./..." || res.Metadata["full_suite_reason"] == nil {
+		t.Fatalf("expected the full suite, ran %q (%v)", cmds.lastReq.Command, res.Metadata)
+	}
+
+	// Runs without a mode count as full ones; pattern runs aren't recorded
+	r.executeAction(context.Background(), Action{Type: ActionRunTests}, actx)
+	r.executeAction(context.Background(), Action{Type: ActionRunTests, TestPattern: "TestX"}, actx)
+	if strings.Join(selector.recorded, ",") != "smart,full,full" {
+		t.Errorf("unexpected recorded runs %v", selector.recorded)
+	}
+
+	if err := validateAction(Action{Type: ActionRunTests, TestMode: "quick"}); err == nil {
+		t.Error("expected an unknown test_mode to be rejected")
+	}
+}
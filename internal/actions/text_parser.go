@@ -2,6 +2,7 @@ package actions
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -14,6 +15,9 @@ var actionRE = regexp.MustCompile(
 // blockRE extracts <<<...>>> delimited blocks.
 var blockRE = regexp.MustCompile(`(?s)<<<\s*\n?(.*?)\n?\s*>>>`)
 
+// lineRangeRE matches the "10-20" line range of a line-range EDIT.
+var lineRangeRE = regexp.MustCompile(`^(?:L|lines?\s*)?(\d+)(?:\s*-\s*(\d+))?$`)
+
 // ParseTextAction parses a text-based agent response into an ActionEnvelope.
 // Returns nil if no action is found (the model just wrote analysis text).
 func ParseTextAction(response string) (*ActionEnvelope, error) {
@@ -92,9 +96,34 @@ func buildActionFromText(command, args, body string) (Action, error) {
 			return Action{}, &ValidationError{Err: errMissing("EDIT", "file path")}
 		}
 		blocks := blockRE.FindAllStringSubmatch(body, -1)
+		// EDIT <file> <start>-<end> replaces a line range with one NEW block
+		if parts := splitArgs(args, 2); len(parts) == 2 {
+			if m := lineRangeRE.FindStringSubmatch(parts[1]); m != nil {
+				if len(blocks) == 0 {
+					return Action{}, &ValidationError{Err: errMissing("EDIT", "a NEW block delimited by <<< and >>>")}
+				}
+				start, _ := strconv.Atoi(m[1])
+				end := start
+				if m[2] != "" {
+					end, _ = strconv.Atoi(m[2])
+				}
+				return Action{Type: ActionEditCode, Path: parts[0], StartLine: start, EndLine: end, NewText: blocks[len(blocks)-1][1]}, nil
+			}
+		}
 		if len(blocks) < 2 {
 			return Action{}, &ValidationError{Err: errMissing("EDIT", "OLD and NEW blocks delimited by <<< and >>>")}
 		}
+		// Several OLD/NEW pairs become one all-or-nothing multi_edit
+		if len(blocks) > 2 {
+			if len(blocks)%2 != 0 {
+				return Action{}, &ValidationError{Err: errorf("EDIT blocks must come in OLD/NEW pairs, got %s blocks", strconv.Itoa(len(blocks)))}
+			}
+			edits := make([]EditOp, 0, len(blocks)/2)
+			for i := 0; i < len(blocks); i += 2 {
+				edits = append(edits, EditOp{OldText: blocks[i][1], NewText: blocks[i+1][1]})
+			}
+			return Action{Type: ActionMultiEdit, Path: args, Edits: edits}, nil
+		}
 		oldText := blocks[0][1]
 		newText := blocks[1][1]
 		// Build a unified diff-style patch
@@ -37,6 +37,14 @@ Available commands:
   <<<
   replacement lines
   >>>
+  (Repeat OLD/NEW pairs to make several edits to one file; if any OLD
+  block doesn't match, none of them are applied.)
+
+  ACTION: EDIT <file> <start>-<end>
+  NEW:
+  <<<
+  lines that replace lines start through end
+  >>>
 
   ACTION: WRITE <file>
   <<<
@@ -0,0 +1,97 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/metrics"
+)
+
+// timeoutGrace is how long a timed-out handler gets to return its partial
+// result after its context is cancelled
+const timeoutGrace = 2 * time.Second
+
+// recordTimeout counts an action that exceeded its timeout
+var recordTimeout = func(actionType string) {
+	metrics.NewMetrics().RecordActionTimeout(actionType)
+}
+
+// actionTimeout returns the configured limit for an action type, 0 for none
+func (r *Router) actionTimeout(actionType string) time.Duration {
+	if d, ok := r.Timeouts[actionType]; ok {
+		return d
+	}
+	return r.DefaultTimeout
+}
+
+// executeWithTimeout runs an action under its configured timeout. The
+// handler's context is cancelled at the deadline, which stops executor and
+// git subprocesses; whatever the handler returns within the grace period is
+// kept as a partial result.
+func (r *Router) executeWithTimeout(ctx context.Context, action Action, actx ActionContext) Result {
+	timeout := r.actionTimeout(action.Type)
+	if timeout <= 0 {
+		return r.executeAllowedAction(ctx, action, actx)
+	}
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan Result, 1)
+	go func() {
+		done <- r.executeAllowedAction(tctx, action, actx)
+	}()
+
+	select {
+	case result := <-done:
+		if errors.Is(tctx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return timedOutResult(action.Type, timeout, &result)
+		}
+		return result
+	case <-tctx.Done():
+	}
+	if ctx.Err() != nil {
+		return cancelledResult(action.Type, ctx.Err())
+	}
+	select {
+	case result := <-done:
+		return timedOutResult(action.Type, timeout, &result)
+	case <-time.After(timeoutGrace):
+		return timedOutResult(action.Type, timeout, nil)
+	}
+}
+
+// timedOutResult marks an action as timed out, keeping the metadata of any
+// partial result the handler produced
+func timedOutResult(actionType string, timeout time.Duration, partial *Result) Result {
+	recordTimeout(actionType)
+	result := Result{ActionType: actionType, Status: "error", Metadata: map[string]interface{}{}}
+	if partial != nil {
+		for k, v := range partial.Metadata {
+			result.Metadata[k] = v
+		}
+		if partial.Message != "" {
+			result.Metadata["partial_message"] = partial.Message
+		}
+		result.Metadata["partial"] = true
+	}
+	result.Message = fmt.Sprintf("%s timed out after %s", actionType, timeout)
+	result.Metadata["error_type"] = "timeout"
+	result.Code = apperr.CodeUnavailable
+	result.Metadata["timeout_seconds"] = timeout.Seconds()
+	return result
+}
+
+// cancelledResult reports an action that was stopped or skipped because the
+// envelope's context was cancelled
+func cancelledResult(actionType string, err error) Result {
+	return Result{
+		ActionType: actionType,
+		Status:     "error",
+		Message:    fmt.Sprintf("%s cancelled: %v", actionType, err),
+		Code:       apperr.CodeUnavailable,
+		Metadata:   map[string]interface{}{"error_type": "cancelled"},
+	}
+}
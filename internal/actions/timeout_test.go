@@ -0,0 +1,95 @@
+package actions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/executor"
+)
+
+func captureTimeouts(t *testing.T) *[]string {
+	t.Helper()
+	var got []string
+	prev := recordTimeout
+	recordTimeout = func(actionType string) { got = append(got, actionType) }
+	t.Cleanup(func() { recordTimeout = prev })
+	return &got
+}
+
+// blockingCommand runs until its context ends, like a killed subprocess
+func blockingCommand(started chan<- struct{}) *mockCommandExecutorFunc {
+	return &mockCommandExecutorFunc{fn: func(ctx context.Context, req executor.ExecuteCommandRequest) (*executor.ExecuteCommandResult, error) {
+		if started != nil {
+			close(started)
+		}
+		<-ctx.Done()
+		return &executor.ExecuteCommandResult{ID: "cmd-1", ExitCode: -1, Stdout: "partial"}, nil
+	}}
+}
+
+func TestExecute_ActionTimeoutKeepsPartialResult(t *testing.T) {
+	timeouts := captureTimeouts(t)
+	r := &Router{
+		Commands:       blockingCommand(nil),
+		Files:          &countingFiles{},
+		Timeouts:       map[string]time.Duration{ActionRunCommand: 20 * time.Millisecond, ActionReadFile: 0},
+		DefaultTimeout: time.Millisecond,
+	}
+	env := &ActionEnvelope{Actions: []Action{
+		{Type: ActionRunCommand, Command: "sleep 60"},
+		{Type: ActionReadFile, Path: "a.go"},
+	}}
+
+	start := time.Now()
+	results, err := r.Execute(context.Background(), env, ActionContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("timeout not enforced, took %v", elapsed)
+	}
+
+	res := results[0]
+	if res.Status != "error" || res.Metadata["error_type"] != "timeout" || res.Metadata["partial"] != true {
+		t.Errorf("unexpected timeout result: %+v", res)
+	}
+	if res.Metadata["command_id"] != "cmd-1" || res.Metadata["timeout_seconds"] != 0.02 {
+		t.Errorf("partial metadata lost: %+v", res.Metadata)
+	}
+	// A zero per-type entry overrides the default
+	if results[1].Status != "executed" {
+		t.Errorf("read_file should not time out: %+v", results[1])
+	}
+	if len(*timeouts) != 1 || (*timeouts)[0] != ActionRunCommand {
+		t.Errorf("timeouts recorded = %v", *timeouts)
+	}
+}
+
+func TestExecute_CancellationStopsEnvelope(t *testing.T) {
+	timeouts := captureTimeouts(t)
+	started := make(chan struct{})
+	r := &Router{Commands: blockingCommand(started), Files: &countingFiles{}, DefaultTimeout: time.Minute}
+	env := &ActionEnvelope{Actions: []Action{
+		{Type: ActionRunCommand, Command: "make"},
+		{Type: ActionReadFile, Path: "a.go"},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	results, err := r.Execute(ctx, env, ActionContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, res := range results {
+		if res.Metadata["error_type"] != "cancelled" {
+			t.Errorf("result %d: expected cancellation, got %+v", i, res)
+		}
+	}
+	if len(*timeouts) != 0 {
+		t.Errorf("cancellation counted as timeout: %v", *timeouts)
+	}
+}
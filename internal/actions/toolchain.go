@@ -0,0 +1,121 @@
+package actions
+
+import (
+	"context"
+
+	"github.com/jordanhubbard/loom/internal/executor"
+	"github.com/jordanhubbard/loom/internal/toolchain"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// maxToolchainOutputBytes caps the command output returned for build, test
+// and lint runs; the full output stays in the command log.
+const maxToolchainOutputBytes = 16 * 1024
+
+// Where a toolchain command came from
+const (
+	commandSourceSubproject = "subproject"
+	commandSourceProject    = "project"
+	commandSourceDetected   = "detected"
+)
+
+// toolchainCommand picks the command build_project, run_tests or run_linter
+// runs when the action doesn't spell one out. Inside a subproject that is its
+// configured command, else whatever is detected in the subproject directory;
+// otherwise the project's <kind>_command context key, else whatever is
+// detected in the project workdir.
+func toolchainCommand(project *models.Project, sp *models.Subproject, kind string) (command, source string) {
+	if project == nil {
+		return "", ""
+	}
+	if sp != nil {
+		switch kind {
+		case toolchain.Build:
+			command = sp.BuildCommand
+		case toolchain.Test:
+			command = sp.TestCommand
+		case toolchain.Lint:
+			command = sp.LintCommand
+		}
+		if command != "" {
+			return command, commandSourceSubproject
+		}
+	} else if command = project.Context[kind+"_command"]; command != "" {
+		return command, commandSourceProject
+	}
+
+	dir := toolchainDir(project, sp)
+	if dir == "" {
+		return "", ""
+	}
+	if command = toolchain.Detect(dir).Command(kind); command != "" {
+		return command, commandSourceDetected
+	}
+	return "", ""
+}
+
+// toolchainDir returns the directory toolchain commands run in
+func toolchainDir(project *models.Project, sp *models.Subproject) string {
+	if sp != nil {
+		return subprojectDir(project, sp)
+	}
+	return project.WorkDir
+}
+
+// preferToolchainCommand reports whether a resolved command should run
+// instead of the action's dedicated runner. Configured commands always win;
+// detected ones only stand in when no runner is configured, since runners
+// do their own detection.
+func (r *Router) preferToolchainCommand(command, source string, runnerConfigured bool) bool {
+	if command == "" || r.Commands == nil {
+		return false
+	}
+	return source != commandSourceDetected || !runnerConfigured
+}
+
+// runToolchainCommand runs a build, test or lint command from the project or
+// subproject directory.
+func (r *Router) runToolchainCommand(ctx context.Context, action Action, actx ActionContext, project *models.Project, sp *models.Subproject, command, source, message string) Result {
+	dir := toolchainDir(project, sp)
+	res, err := r.Commands.ExecuteCommand(ctx, executor.ExecuteCommandRequest{
+		AgentID:    actx.AgentID,
+		BeadID:     actx.BeadID,
+		ProjectID:  actx.ProjectID,
+		Command:    command,
+		WorkingDir: dir,
+		Timeout:    action.TimeoutSeconds,
+		Context: map[string]interface{}{
+			"action_type":    action.Type,
+			"command_source": source,
+		},
+		OnOutput: r.commandOutputHandler(ctx, actx, action.Type),
+	})
+	if err != nil {
+		return executorErrorResult(action.Type, err)
+	}
+
+	output := res.Stdout + res.Stderr
+	if len(output) > maxToolchainOutputBytes {
+		output = "...(truncated)\n" + output[len(output)-maxToolchainOutputBytes:]
+	}
+	metadata := map[string]interface{}{
+		"command_id":     res.ID,
+		"command":        command,
+		"command_source": source,
+		"exit_code":      res.ExitCode,
+		"success":        res.Success,
+		"output":         output,
+	}
+	if dir != "" {
+		metadata["working_dir"] = dir
+	}
+	if sp != nil {
+		metadata["subproject"] = sp.Name
+	}
+	return Result{
+		ActionType: action.Type,
+		Status:     "executed",
+		Message:    message,
+		Metadata:   metadata,
+	}
+}
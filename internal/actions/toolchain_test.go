@@ -0,0 +1,72 @@
+package actions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestRouterUsesDetectedToolchainDefaults(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmds := &mockCommandExecutor{}
+	r := &Router{Commands: cmds, Projects: staticProjects{"go": {ID: "go", WorkDir: dir}}}
+	actx := ActionContext{ProjectID: "go"}
+
+	for actionType, want := range map[string]string{
+		ActionBuildProject: "go build ./...",
+		ActionRunTests:     "go test ./...",
+		ActionRunLinter:    "go vet ./...",
+	} {
+		res := r.executeAction(context.Background(), Action{Type: actionType}, actx)
+		if res.Status != "executed" {
+			t.Fatalf("%s: expected executed, got %s: %s", actionType, res.Status, res.Message)
+		}
+		if cmds.lastReq.Command != want || cmds.lastReq.WorkingDir != dir {
+			t.Errorf("%s: ran %q in %q, want %q in %q", actionType, cmds.lastReq.Command, cmds.lastReq.WorkingDir, want, dir)
+		}
+		if res.Metadata["command_source"] != commandSourceDetected {
+			t.Errorf("%s: expected detected source, got %v", actionType, res.Metadata["command_source"])
+		}
+	}
+}
+
+func TestRouterToolchainPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runnerCalled := false
+	tests := &mockTestRunner{runFunc: func(ctx context.Context, projectPath, testPattern, framework string, timeoutSeconds int) (map[string]interface{}, error) {
+		runnerCalled = true
+		return map[string]interface{}{"success": true}, nil
+	}}
+	project := &models.Project{ID: "go", WorkDir: dir}
+	cmds := &mockCommandExecutor{}
+	r := &Router{Commands: cmds, Tests: tests, Projects: staticProjects{"go": project}}
+
+	// A configured runner does its own detection
+	r.executeAction(context.Background(), Action{Type: ActionRunTests}, ActionContext{ProjectID: "go"})
+	if !runnerCalled || cmds.lastReq.Command != "" {
+		t.Fatalf("expected the test runner to handle detected toolchains (ran %q)", cmds.lastReq.Command)
+	}
+
+	// A command configured on the project wins over the runner
+	runnerCalled = false
+	project.Context = map[string]string{"test_command": "make test"}
+	res := r.executeAction(context.Background(), Action{Type: ActionRunTests}, ActionContext{ProjectID: "go"})
+	if runnerCalled || cmds.lastReq.Command != "make test" || res.Metadata["command_source"] != commandSourceProject {
+		t.Fatalf("expected configured project command, got %q (runner called: %v)", cmds.lastReq.Command, runnerCalled)
+	}
+
+	// A test pattern needs the runner
+	r.executeAction(context.Background(), Action{Type: ActionRunTests, TestPattern: "TestFoo"}, ActionContext{ProjectID: "go"})
+	if !runnerCalled {
+		t.Fatal("expected test_pattern to go to the test runner")
+	}
+}
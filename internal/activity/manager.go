@@ -1,17 +1,20 @@
 package activity
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
 )
 
+var activityLog = logging.Module("activity")
+
 const (
 	aggregationWindow = 5 * time.Minute
 )
@@ -73,6 +76,12 @@ func buildEventFilterSet() map[string]bool {
 		"decision.created":  true,
 		"decision.resolved": true,
 
+		// Executor events
+		"executor.quota_exceeded": true,
+
+		// Usage pattern events
+		"pattern.anomaly_detected": true,
+
 		// Motivation events
 		"motivation.fired":    true,
 		"motivation.enabled":  true,
@@ -94,7 +103,7 @@ func (m *Manager) subscribeToEvents() {
 
 	for event := range subscriber.Channel {
 		if err := m.RecordActivity(event); err != nil {
-			log.Printf("Failed to record activity: %v", err)
+			activityLog.Error("Failed to record activity", "error", err)
 		}
 	}
 }
@@ -134,7 +143,7 @@ func (m *Manager) RecordActivity(event *eventbus.Event) error {
 		since := time.Now().Add(-aggregationWindow)
 		existing, err := m.db.GetRecentAggregatableActivity(activity.AggregationKey, since)
 		if err != nil {
-			log.Printf("Failed to check for aggregatable activity: %v", err)
+			activityLog.Warn("Failed to check for aggregatable activity", "error", err)
 		}
 
 		if existing != nil {
@@ -165,7 +174,7 @@ func (m *Manager) RecordActivity(event *eventbus.Event) error {
 	if activity.Metadata != nil {
 		metadataJSON, err := json.Marshal(activity.Metadata)
 		if err != nil {
-			log.Printf("Failed to marshal activity metadata: %v", err)
+			activityLog.Warn("Failed to marshal activity metadata", "error", err)
 		} else {
 			dbActivity.MetadataJSON = string(metadataJSON)
 		}
@@ -289,6 +298,49 @@ func (m *Manager) eventToActivity(event *eventbus.Event) *Activity {
 		}
 		activity.Visibility = "project"
 
+	case "executor.quota_exceeded":
+		activity.ResourceType = "project"
+		activity.ResourceID = event.ProjectID
+		activity.Action = extractAction(string(event.Type))
+		if quota, ok := event.Data["quota"].(string); ok {
+			activity.ResourceTitle = quota
+		}
+		activity.Visibility = "project"
+
+	case "pattern.anomaly_detected":
+		activity.ResourceType = "anomaly"
+		if anomalyID, ok := event.Data["anomaly_id"].(string); ok {
+			activity.ResourceID = anomalyID
+		}
+		activity.Action = "detected"
+		if description, ok := event.Data["description"].(string); ok {
+			activity.ResourceTitle = description
+		}
+		activity.Visibility = "global"
+
+	case "digest.generated":
+		activity.ResourceType = "digest"
+		if digestID, ok := event.Data["digest_id"].(string); ok {
+			activity.ResourceID = digestID
+		}
+		activity.Action = "generated"
+		if title, ok := event.Data["title"].(string); ok {
+			activity.ResourceTitle = title
+		}
+		activity.Visibility = "project"
+
+	case "external.github_pr_closed":
+		activity.ResourceType = "pull_request"
+		activity.ResourceID = fmt.Sprintf("%v", event.Data["pr_number"])
+		activity.Action = "closed"
+		if merged, ok := event.Data["merged"].(bool); ok && merged {
+			activity.Action = "merged"
+		}
+		if title, ok := event.Data["pr_title"].(string); ok {
+			activity.ResourceTitle = title
+		}
+		activity.Visibility = "project"
+
 	default:
 		// Unknown event type, skip
 		return nil
@@ -322,39 +374,51 @@ func buildAggregationKey(event *eventbus.Event, activity *Activity) string {
 
 // GetActivities retrieves activities with filters
 func (m *Manager) GetActivities(filters ActivityFilters) ([]*Activity, error) {
-	dbFilters := database.ActivityFilters{
-		ProjectIDs:   filters.ProjectIDs,
-		EventType:    filters.EventType,
-		ActorID:      filters.ActorID,
-		ResourceType: filters.ResourceType,
-		Since:        filters.Since,
-		Until:        filters.Until,
-		Limit:        filters.Limit,
-		Offset:       filters.Offset,
-		Aggregated:   filters.Aggregated,
-	}
-
-	dbActivities, err := m.db.ListActivities(dbFilters)
+	dbActivities, err := m.db.ListActivities(filters.toDB())
 	if err != nil {
 		return nil, err
 	}
 
 	activities := make([]*Activity, 0, len(dbActivities))
 	for _, dbActivity := range dbActivities {
-		activity := FromDBActivity(dbActivity)
+		activities = append(activities, fromDBWithMetadata(dbActivity))
+	}
 
-		// Parse metadata JSON
-		if dbActivity.MetadataJSON != "" {
-			var metadata map[string]interface{}
-			if err := json.Unmarshal([]byte(dbActivity.MetadataJSON), &metadata); err == nil {
-				activity.Metadata = metadata
-			}
-		}
+	return activities, nil
+}
 
-		activities = append(activities, activity)
+// EachActivity calls fn with each activity matching filters, oldest first,
+// for exports too large to hold in memory
+func (m *Manager) EachActivity(ctx context.Context, filters ActivityFilters, fn func(*Activity) error) error {
+	return m.db.EachActivity(ctx, filters.toDB(), func(dbActivity *database.Activity) error {
+		return fn(fromDBWithMetadata(dbActivity))
+	})
+}
+
+func (f ActivityFilters) toDB() database.ActivityFilters {
+	return database.ActivityFilters{
+		ProjectIDs:   f.ProjectIDs,
+		EventType:    f.EventType,
+		ActorID:      f.ActorID,
+		ResourceType: f.ResourceType,
+		Since:        f.Since,
+		Until:        f.Until,
+		Limit:        f.Limit,
+		Offset:       f.Offset,
+		Aggregated:   f.Aggregated,
 	}
+}
 
-	return activities, nil
+// fromDBWithMetadata converts a stored activity, parsing its metadata
+func fromDBWithMetadata(dbActivity *database.Activity) *Activity {
+	activity := FromDBActivity(dbActivity)
+	if dbActivity.MetadataJSON != "" {
+		var metadata map[string]interface{}
+		if err := json.Unmarshal([]byte(dbActivity.MetadataJSON), &metadata); err == nil {
+			activity.Metadata = metadata
+		}
+	}
+	return activity
 }
 
 // Subscribe creates a new activity stream subscriber
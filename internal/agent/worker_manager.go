@@ -3,21 +3,25 @@ package agent
 import (
 	"context"
 	"fmt"
-	"log"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/jordanhubbard/loom/internal/actions"
 	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/contextpack"
 	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/observability"
 	"github.com/jordanhubbard/loom/internal/provider"
+	"github.com/jordanhubbard/loom/internal/roles"
 	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
 	"github.com/jordanhubbard/loom/internal/worker"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
+var managerLog = logging.Module("agents")
+
 // WorkerManager manages agents with worker pool integration
 type WorkerManager struct {
 	agents             map[string]*models.Agent
@@ -29,7 +33,12 @@ type WorkerManager struct {
 	analyticsLogger    *analytics.Logger
 	actionLoopEnabled  bool
 	maxLoopIterations  int
+	maxCorrections     int
+	repairAttempts     int
 	lessonsProvider    worker.LessonsProvider
+	contextPriorities  []contextpack.Kind
+	images             worker.ImageSource
+	roles              *roles.Registry
 	db                 *database.Database
 	mu                 sync.RWMutex
 	maxAgents          int
@@ -76,6 +85,46 @@ func (m *WorkerManager) SetMaxLoopIterations(max int) {
 	m.maxLoopIterations = max
 }
 
+// SetRepairAttempts sets how many failed builds or test runs after an
+// agent's changes are fed back for repair before the bead is escalated.
+// Zero uses the worker default; negative disables the repair loop.
+func (m *WorkerManager) SetRepairAttempts(attempts int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.repairAttempts = attempts
+}
+
+// SetMaxCorrections sets how many times in a row a malformed action
+// response is re-prompted before the attempt fails. Zero uses
+// actions.DefaultMaxCorrectionAttempts; negative disables correction.
+func (m *WorkerManager) SetMaxCorrections(max int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxCorrections = max
+}
+
+// SetContextPriorities sets the order in which optional context is packed
+// into agent prompts when it doesn't all fit
+func (m *WorkerManager) SetContextPriorities(priorities []contextpack.Kind) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.contextPriorities = priorities
+}
+
+// SetImageSource sets where action loops get bead images from
+func (m *WorkerManager) SetImageSource(images worker.ImageSource) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.images = images
+}
+
+// SetRoleRegistry sets the registry supplying role prompts for agents
+func (m *WorkerManager) SetRoleRegistry(r *roles.Registry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.roles = r
+}
+
 func (m *WorkerManager) SetLessonsProvider(lp worker.LessonsProvider) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -140,7 +189,7 @@ func (m *WorkerManager) CreateAgent(ctx context.Context, name, personaName, proj
 	m.agents[agentID] = agent
 	m.persistAgent(agent)
 
-	log.Printf("Created paused agent %s (role: %s) - waiting for provider", agent.Name, role)
+	managerLog.InfoContext(ctx, "Created paused agent, waiting for provider", "agent", agent.Name, "role", role)
 	if m.eventBus != nil {
 		_ = m.eventBus.PublishAgentEvent(eventbus.EventTypeAgentSpawned, agent.ID, projectID, map[string]interface{}{
 			"name":         agent.Name,
@@ -191,7 +240,7 @@ func (m *WorkerManager) SpawnAgentWorker(ctx context.Context, name, personaName,
 
 	m.persistAgent(agent)
 
-	log.Printf("Spawned agent %s with worker using provider %s", agent.Name, providerID)
+	managerLog.InfoContext(ctx, "Spawned agent", "agent", agent.Name, "provider_id", providerID)
 	if m.eventBus != nil {
 		_ = m.eventBus.PublishAgentEvent(eventbus.EventTypeAgentSpawned, agent.ID, projectID, map[string]interface{}{
 			"name":         agent.Name,
@@ -322,12 +371,12 @@ func (m *WorkerManager) RestoreAgentWorker(ctx context.Context, agent *models.Ag
 		// Ensure worker exists for this agent with the correct provider
 		if agent.ProviderID != "" {
 			if _, err := m.workerPool.SpawnWorker(existing, existing.ProviderID); err != nil {
-				log.Printf("Warning: Failed to spawn/update worker for agent %s: %v", existing.ID, err)
+				managerLog.WarnContext(ctx, "Failed to spawn or update worker", "agent_id", existing.ID, "error", err)
 			}
 		}
 
 		m.persistAgent(existing)
-		log.Printf("Updated existing agent %s with provider %s, status %s", existing.Name, existing.ProviderID, existing.Status)
+		managerLog.InfoContext(ctx, "Updated existing agent", "agent", existing.Name, "provider_id", existing.ProviderID, "status", existing.Status)
 		return existing, nil
 	}
 
@@ -356,7 +405,7 @@ func (m *WorkerManager) RestoreAgentWorker(ctx context.Context, agent *models.Ag
 
 	m.persistAgent(agent)
 
-	log.Printf("Restored agent %s with worker using provider %s", agent.Name, agent.ProviderID)
+	managerLog.InfoContext(ctx, "Restored agent", "agent", agent.Name, "provider_id", agent.ProviderID)
 	return agent, nil
 }
 
@@ -401,6 +450,8 @@ func (m *WorkerManager) ExecuteTask(ctx context.Context, agentID string, task *w
 		}
 		taskID = task.ID
 		beadID = task.BeadID
+		ctx = logging.WithFields(ctx, logging.FieldAgentID, agent.ID,
+			logging.FieldBeadID, beadID, logging.FieldProjectID, projectID)
 		observability.Info("agent.task_start", map[string]interface{}{
 			"agent_id":    agent.ID,
 			"project_id":  projectID,
@@ -438,7 +489,7 @@ func (m *WorkerManager) ExecuteTask(ctx context.Context, agentID string, task *w
 	// were later auto-assigned one by the dispatcher).
 	if _, workerErr := m.workerPool.GetWorker(agentID); workerErr != nil && agent.ProviderID != "" {
 		if _, spawnErr := m.workerPool.SpawnWorker(agent, agent.ProviderID); spawnErr != nil {
-			log.Printf("[WorkerManager] Auto-spawn worker for %s failed: %v", agentID, spawnErr)
+			managerLog.ErrorContext(ctx, "Auto-spawn worker failed", "agent_id", agentID, "error", spawnErr)
 		}
 	}
 
@@ -464,13 +515,20 @@ func (m *WorkerManager) ExecuteTask(ctx context.Context, agentID string, task *w
 			MaxIterations: maxIter,
 			Router:        router,
 			ActionContext: actions.ActionContext{
-				AgentID:   agentID,
-				BeadID:    task.BeadID,
-				ProjectID: task.ProjectID,
+				AgentID:    agentID,
+				BeadID:     task.BeadID,
+				ProjectID:  task.ProjectID,
+				Subproject: task.Subproject,
 			},
 			LessonsProvider: m.lessonsProvider,
 			DB:              m.db,
 			TextMode:        true, // Default to simple text actions for local model effectiveness
+
+			ContextPriorities: m.contextPriorities,
+			Images:            m.images,
+			MaxRepairAttempts: m.repairAttempts,
+			MaxCorrections:    m.maxCorrections,
+			RolePrompt:        roles.RenderPrompt(m.roles.RoleForAgent(agent), agent, task.ProjectID),
 		}
 
 		loopResult, loopErr := workerInstance.ExecuteTaskWithLoop(ctx, task, loopConfig)
@@ -520,8 +578,8 @@ func (m *WorkerManager) ExecuteTask(ctx context.Context, agentID string, task *w
 				"loop_mode":       true,
 			})
 		}
-		log.Printf("Agent %s completed task %s via action loop (%d iterations, reason: %s)",
-			agent.Name, task.ID, loopResult.Iterations, loopResult.TerminalReason)
+		managerLog.InfoContext(ctx, "Agent completed task via action loop", "agent", agent.Name, "task_id", task.ID,
+			"iterations", loopResult.Iterations, "reason", loopResult.TerminalReason)
 
 		if al := m.analyticsLogger; al != nil && result != nil {
 			statusCode := 200
@@ -587,16 +645,22 @@ func (m *WorkerManager) ExecuteTask(ctx context.Context, agentID string, task *w
 		router := m.actionRouter
 		if router != nil {
 			actx := actions.ActionContext{
-				AgentID:   agentID,
-				BeadID:    task.BeadID,
-				ProjectID: task.ProjectID,
+				AgentID:    agentID,
+				BeadID:     task.BeadID,
+				ProjectID:  task.ProjectID,
+				Subproject: task.Subproject,
+			}
+			correction := actions.DecodeWithCorrection(ctx, result.Response, actions.MaxCorrectionAttempts(m.maxCorrections),
+				m.correctionReprompter(agentID, task, result))
+			env, parseErr := correction.Envelope, correction.Err
+			if correction.Attempts > 0 {
+				result.Response = correction.Raw
 			}
-			env, parseErr := actions.DecodeLenient([]byte(result.Response))
 			if parseErr != nil {
-				actionResult := router.AutoFileParseFailure(ctx, actx, parseErr, result.Response)
+				actionResult := router.AutoFileParseFailure(ctx, actx, parseErr, correction.Raw)
 				result.Actions = []actions.Result{actionResult}
 				result.Success = false
-				result.Error = fmt.Sprintf("action parse failed: %v", parseErr)
+				result.Error = fmt.Sprintf("action parse failed after %d correction attempts: %v", correction.Attempts, parseErr)
 			} else {
 				actionsResult, execErr := router.Execute(ctx, env, actx)
 				result.Actions = actionsResult
@@ -632,7 +696,7 @@ func (m *WorkerManager) ExecuteTask(ctx context.Context, agentID string, task *w
 			"error":       result.Error,
 		})
 	}
-	log.Printf("Agent %s completed task %s", agent.Name, task.ID)
+	managerLog.InfoContext(ctx, "Agent completed task", "agent", agent.Name, "task_id", task.ID)
 
 	// Log to analytics for the observability dashboard
 	if al := m.analyticsLogger; al != nil && result != nil {
@@ -667,6 +731,27 @@ func (m *WorkerManager) ExecuteTask(ctx context.Context, agentID string, task *w
 	return result, nil
 }
 
+// correctionReprompter returns a Reprompter that sends correction feedback to
+// the agent's worker as a follow-up task on the same bead. Tokens used by the
+// follow-up are added to result.
+func (m *WorkerManager) correctionReprompter(agentID string, task *worker.Task, result *worker.TaskResult) actions.Reprompter {
+	return func(ctx context.Context, feedback string) (string, error) {
+		followUp := &worker.Task{
+			ID:                  fmt.Sprintf("%s-correction-%d", task.ID, time.Now().UnixNano()),
+			Description:         feedback,
+			BeadID:              task.BeadID,
+			ProjectID:           task.ProjectID,
+			ConversationSession: task.ConversationSession,
+		}
+		res, err := m.workerPool.ExecuteTask(ctx, followUp, agentID)
+		if err != nil {
+			return "", err
+		}
+		result.TokensUsed += res.TokensUsed
+		return res.Response, nil
+	}
+}
+
 // StopAgent stops and removes an agent and its worker
 func (m *WorkerManager) StopAgent(id string) error {
 	m.mu.Lock()
@@ -679,13 +764,13 @@ func (m *WorkerManager) StopAgent(id string) error {
 
 	// Stop the worker
 	if err := m.workerPool.StopWorker(id); err != nil {
-		log.Printf("Warning: failed to stop worker for agent %s: %v", id, err)
+		managerLog.Warn("Failed to stop worker", "agent_id", id, "error", err)
 	}
 
 	// Remove agent
 	delete(m.agents, id)
 
-	log.Printf("Stopped agent %s", agent.Name)
+	managerLog.Info("Stopped agent", "agent", agent.Name)
 	if m.eventBus != nil {
 		_ = m.eventBus.PublishAgentEvent(eventbus.EventTypeAgentCompleted, id, agent.ProjectID, map[string]interface{}{"reason": "stopped"})
 	}
@@ -865,7 +950,7 @@ func (m *WorkerManager) ResetStuckAgents(maxWorkingDuration time.Duration) int {
 		if agent.Status == "working" {
 			elapsed := now.Sub(agent.LastActive)
 			if elapsed > maxWorkingDuration {
-				log.Printf("[WorkerManager] Resetting stuck agent %s (working for %v)", agent.ID, elapsed)
+				managerLog.Warn("Resetting stuck agent", "agent_id", agent.ID, "working_for", elapsed)
 				agent.Status = "idle"
 				agent.CurrentBead = ""
 
@@ -894,11 +979,11 @@ func (m *WorkerManager) ResetStuckAgents(maxWorkingDuration time.Duration) int {
 
 	// Restore paused agents outside the lock
 	for _, agent := range toRestore {
-		log.Printf("[WorkerManager] Attempting to restore paused agent %s", agent.ID)
+		managerLog.Info("Attempting to restore paused agent", "agent_id", agent.ID)
 		if _, err := m.RestoreAgentWorker(context.Background(), agent); err != nil {
-			log.Printf("[WorkerManager] Failed to restore agent %s: %v", agent.ID, err)
+			managerLog.Error("Failed to restore agent", "agent_id", agent.ID, "error", err)
 		} else {
-			log.Printf("[WorkerManager] Successfully restored paused agent %s to idle", agent.ID)
+			managerLog.Info("Restored paused agent to idle", "agent_id", agent.ID)
 			count++
 		}
 	}
@@ -917,5 +1002,5 @@ func (m *WorkerManager) StopAll() {
 	// Clear agents
 	m.agents = make(map[string]*models.Agent)
 
-	log.Println("Stopped all agents and workers")
+	managerLog.Info("Stopped all agents and workers")
 }
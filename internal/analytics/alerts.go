@@ -6,14 +6,17 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"net/smtp"
 	"os"
 	"strconv"
 	"time"
+
+	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var alertLog = logging.Module("alert")
+
 // SMTPConfig defines SMTP server configuration for email notifications
 type SMTPConfig struct {
 	Host     string // SMTP server hostname (e.g., smtp.gmail.com)
@@ -237,17 +240,17 @@ func (ac *AlertChecker) checkAnomalies(ctx context.Context) *Alert {
 // notify sends notifications for an alert
 func (ac *AlertChecker) notify(alert *Alert) {
 	// Log the alert
-	log.Printf("[ALERT] %s: %s", alert.Severity, alert.Message)
+	alertLog.Warn(alert.Message, "severity", alert.Severity)
 
 	// Send email notifications if enabled
 	if ac.config.EnableEmailAlerts && ac.config.EmailAddress != "" {
 		if ac.smtpConfig == nil {
-			log.Printf("[ALERT] Email notifications enabled but SMTP not configured (set SMTP_HOST env var)")
+			alertLog.Warn("Email notifications enabled but SMTP not configured (set SMTP_HOST env var)")
 		} else {
 			if err := ac.sendEmail(alert); err != nil {
-				log.Printf("[ALERT] Failed to send email to %s: %v", ac.config.EmailAddress, err)
+				alertLog.Error("Failed to send email", "to", ac.config.EmailAddress, "error", err)
 			} else {
-				log.Printf("[ALERT] Email notification sent to %s: %s", ac.config.EmailAddress, alert.Message)
+				alertLog.Info("Email notification sent", "to", ac.config.EmailAddress, "message", alert.Message)
 			}
 		}
 	}
@@ -255,9 +258,9 @@ func (ac *AlertChecker) notify(alert *Alert) {
 	// Send webhook notifications if enabled
 	if ac.config.EnableWebhookAlerts && ac.config.WebhookURL != "" {
 		if err := ac.sendWebhook(alert); err != nil {
-			log.Printf("[ALERT] Failed to send webhook to %s: %v", ac.config.WebhookURL, err)
+			alertLog.Error("Failed to send webhook", "url", ac.config.WebhookURL, "error", err)
 		} else {
-			log.Printf("[ALERT] Webhook notification sent to %s: %s", ac.config.WebhookURL, alert.Message)
+			alertLog.Info("Webhook notification sent", "url", ac.config.WebhookURL, "message", alert.Message)
 		}
 	}
 }
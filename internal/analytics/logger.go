@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sync/atomic"
 	"time"
 )
 
+// PricingRateKey is the metadata key recording which pricing rate a
+// request's cost was computed with
+const PricingRateKey = "pricing_rate_id"
+
 // RequestLog represents a logged API request
 type RequestLog struct {
 	ID               string            `json:"id"`
@@ -58,8 +63,16 @@ func DefaultPrivacyConfig() *PrivacyConfig {
 
 // Logger handles request/response logging with privacy controls
 type Logger struct {
-	storage Storage
-	privacy *PrivacyConfig
+	storage   Storage
+	privacy   *PrivacyConfig
+	pricer    Pricer
+	observers []func(*RequestLog)
+}
+
+// Pricer computes the cost of a request from its token counts. The rate ID
+// identifies the rate used and is empty when the cost came from a fallback.
+type Pricer interface {
+	Price(log *RequestLog) (costUSD float64, rateID string, ok bool)
 }
 
 // Storage interface for persisting logs
@@ -70,14 +83,24 @@ type Storage interface {
 	DeleteOldLogs(ctx context.Context, before time.Time) (int64, error)
 }
 
+// LogIterator is storage that can read logs one at a time, oldest first,
+// for exports too large to hold in memory
+type LogIterator interface {
+	EachLog(ctx context.Context, filter *LogFilter, fn func(*RequestLog) error) error
+}
+
 // LogFilter for querying logs
 type LogFilter struct {
 	UserID     string
 	ProviderID string
+	ModelName  string
 	StartTime  time.Time
 	EndTime    time.Time
 	Limit      int
 	Offset     int
+
+	MinCostUSD   float64 // Only requests costing at least this much
+	MinLatencyMs int64   // Only requests taking at least this long
 }
 
 // LogStats provides aggregate statistics
@@ -96,6 +119,14 @@ type LogStats struct {
 	LatencyByProvider  map[string]float64 `json:"latency_by_provider"`
 }
 
+// CostPoint is the spend in one time bucket
+type CostPoint struct {
+	Start    time.Time `json:"start"`
+	CostUSD  float64   `json:"cost_usd"`
+	Requests int64     `json:"requests"`
+	Tokens   int64     `json:"tokens"`
+}
+
 // NewLogger creates a new request logger
 func NewLogger(storage Storage, privacy *PrivacyConfig) *Logger {
 	if privacy == nil {
@@ -140,7 +171,38 @@ func (l *Logger) LogRequest(ctx context.Context, log *RequestLog) error {
 		log.Timestamp = time.Now()
 	}
 
-	return l.storage.SaveLog(ctx, log)
+	// Price requests the caller didn't
+	if log.CostUSD == 0 && l.pricer != nil {
+		if cost, rateID, ok := l.pricer.Price(log); ok {
+			log.CostUSD = cost
+			if rateID != "" {
+				if log.Metadata == nil {
+					log.Metadata = map[string]string{}
+				}
+				log.Metadata[PricingRateKey] = rateID
+			}
+		}
+	}
+
+	if err := l.storage.SaveLog(ctx, log); err != nil {
+		return err
+	}
+	for _, observe := range l.observers {
+		observe(log)
+	}
+	return nil
+}
+
+// SetPricer sets the pricer used for requests logged without a cost
+func (l *Logger) SetPricer(p Pricer) {
+	l.pricer = p
+}
+
+// AddObserver registers a function called with every log after it is saved.
+// Observers run on the caller's goroutine and must be fast. Register them
+// before the logger is shared.
+func (l *Logger) AddObserver(observe func(*RequestLog)) {
+	l.observers = append(l.observers, observe)
 }
 
 // GetLogs retrieves logs with filtering
@@ -148,6 +210,31 @@ func (l *Logger) GetLogs(ctx context.Context, filter *LogFilter) ([]*RequestLog,
 	return l.storage.GetLogs(ctx, filter)
 }
 
+// EachLog calls fn with each log matching the filter. Storage that is a
+// LogIterator streams them; other storage is read whole. Either way logs
+// come oldest first.
+func (l *Logger) EachLog(ctx context.Context, filter *LogFilter, fn func(*RequestLog) error) error {
+	if it, ok := l.storage.(LogIterator); ok {
+		return it.EachLog(ctx, filter, fn)
+	}
+	return eachLogOf(ctx, l.storage, filter, fn)
+}
+
+// eachLogOf reads the logs matching filter from storage that can't stream
+// them and calls fn with each, oldest first
+func eachLogOf(ctx context.Context, storage Storage, filter *LogFilter, fn func(*RequestLog) error) error {
+	logs, err := storage.GetLogs(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for i := len(logs) - 1; i >= 0; i-- {
+		if err := fn(logs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetStats retrieves aggregate statistics
 func (l *Logger) GetStats(ctx context.Context, filter *LogFilter) (*LogStats, error) {
 	return l.storage.GetLogStats(ctx, filter)
@@ -170,9 +257,12 @@ func (l *Logger) redactSensitiveData(data string) string {
 	return data
 }
 
+// logSeq keeps IDs generated in the same nanosecond apart
+var logSeq atomic.Uint64
+
 // generateLogID creates a unique log ID
 func generateLogID() string {
-	return fmt.Sprintf("log-%d", time.Now().UnixNano())
+	return fmt.Sprintf("log-%d-%d", time.Now().UnixNano(), logSeq.Add(1))
 }
 
 // CalculateCost computes cost based on token usage and provider pricing
@@ -70,6 +70,23 @@ func TestLogRequest_PrivacyDefaults(t *testing.T) {
 	}
 }
 
+func TestLogRequest_Observers(t *testing.T) {
+	storage := &MockStorage{}
+	logger := NewLogger(storage, nil)
+	var observed []*RequestLog
+	logger.AddObserver(func(l *RequestLog) { observed = append(observed, l) })
+
+	if err := logger.LogRequest(context.Background(), &RequestLog{Method: "POST"}); err != nil {
+		t.Fatalf("LogRequest failed: %v", err)
+	}
+	if len(observed) != 1 || observed[0] != storage.logs[0] {
+		t.Fatalf("expected the observer to see the saved log, got %+v", observed)
+	}
+	if observed[0].ID == "" {
+		t.Error("expected the observer to see the log after its ID was set")
+	}
+}
+
 func TestLogRequest_WithBodiesEnabled(t *testing.T) {
 	storage := &MockStorage{}
 	privacy := &PrivacyConfig{
@@ -0,0 +1,457 @@
+package analytics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/metrics"
+)
+
+// DefaultSpillDir is where request logs spill when the config names no
+// directory
+const DefaultSpillDir = "./analytics-spill"
+
+const (
+	defaultQueueSize     = 10000
+	defaultBatchSize     = 200
+	defaultFlushInterval = time.Second
+	defaultMaxSpillBytes = 256 << 20
+	// maxRetryBackoff caps the wait between failed writes
+	maxRetryBackoff = 30 * time.Second
+
+	spillPrefix = "requests-"
+	spillSuffix = ".jsonl"
+)
+
+// ErrLogDropped is returned by QueuedStorage.SaveLog when a log could be
+// neither queued nor spilled to disk
+var ErrLogDropped = errors.New("analytics queue full, request log dropped")
+
+// BatchStorage is storage that can write many logs at once. Writing a log
+// that is already stored must succeed without storing it twice.
+type BatchStorage interface {
+	SaveLogs(ctx context.Context, logs []*RequestLog) error
+}
+
+// QueueConfig configures a QueuedStorage
+type QueueConfig struct {
+	QueueSize     int           // Logs held in memory (default 10000)
+	BatchSize     int           // Logs per write (default 200)
+	FlushInterval time.Duration // Longest a log waits before a write is attempted (default 1s)
+	SpillDir      string        // Directory for logs that don't fit in memory; empty drops them instead
+	MaxSpillBytes int64         // Spill size beyond which logs are dropped (default 256MB)
+}
+
+// QueueStats describes the state of a QueuedStorage
+type QueueStats struct {
+	Depth       int    `json:"depth"`       // Logs queued in memory
+	SpillBytes  int64  `json:"spill_bytes"` // Logs spilled to disk awaiting replay
+	Written     uint64 `json:"written"`
+	Spilled     uint64 `json:"spilled"`
+	Dropped     uint64 `json:"dropped"`
+	WriteErrors uint64 `json:"write_errors"`
+}
+
+// QueuedStorage takes SaveLog off the request path. Logs are queued in
+// memory and written in batches by a background goroutine; when the queue
+// is full they are appended to spill files, which are replayed once the
+// queue drains and on the next start. A failed write is retried with
+// backoff, so each log is delivered at least once; use a BatchStorage to
+// make redelivery harmless. Reads go straight to the wrapped storage and
+// don't see logs still queued.
+type QueuedStorage struct {
+	Storage
+	cfg QueueConfig
+
+	mu         sync.Mutex
+	queue      []*RequestLog
+	spill      *os.File // Segment spilled logs are appended to; nil until the next spill
+	spillBytes int64
+	closed     bool
+	stats      QueueStats
+
+	drainMu sync.Mutex // Serializes writers of the wrapped storage
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	metrics *metrics.Metrics
+}
+
+// NewQueuedStorage wraps storage with a write queue and starts writing in
+// the background. Logs spilled by an earlier run are replayed; the spill
+// directory is created on the first spill. Call Close to flush the queue on
+// shutdown.
+func NewQueuedStorage(storage Storage, cfg QueueConfig) (*QueuedStorage, error) {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	if cfg.MaxSpillBytes <= 0 {
+		cfg.MaxSpillBytes = defaultMaxSpillBytes
+	}
+	q := &QueuedStorage{
+		Storage: storage,
+		cfg:     cfg,
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		metrics: metrics.NewMetrics(),
+	}
+	if cfg.SpillDir != "" {
+		segments, err := q.segments()
+		if err != nil {
+			return nil, err
+		}
+		for _, segment := range segments {
+			if info, err := os.Stat(segment); err == nil {
+				q.spillBytes += info.Size()
+			}
+		}
+	}
+	q.observeLocked()
+	go q.run()
+	if q.spillBytes > 0 {
+		q.signal()
+	}
+	return q, nil
+}
+
+// SaveLog queues a log for writing. When the queue is full the log is
+// spilled to disk, and ErrLogDropped is returned if that fails too. After
+// Close logs are written directly.
+func (q *QueuedStorage) SaveLog(ctx context.Context, log *RequestLog) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return q.Storage.SaveLog(ctx, log)
+	}
+	defer q.mu.Unlock()
+	if len(q.queue) < q.cfg.QueueSize {
+		q.queue = append(q.queue, log)
+		q.observeLocked()
+		if len(q.queue) >= q.cfg.BatchSize {
+			q.signal()
+		}
+		return nil
+	}
+	if err := q.spillLocked([]*RequestLog{log}); err != nil {
+		q.dropLocked(1, err)
+		return ErrLogDropped
+	}
+	return nil
+}
+
+// Flush writes the queued and spilled logs now
+func (q *QueuedStorage) Flush(ctx context.Context) error {
+	if err := q.drain(ctx); err != nil {
+		return err
+	}
+	return q.replay(ctx)
+}
+
+// EachLog streams stored logs when the wrapped storage can. Logs still
+// queued are not included.
+func (q *QueuedStorage) EachLog(ctx context.Context, filter *LogFilter, fn func(*RequestLog) error) error {
+	if it, ok := q.Storage.(LogIterator); ok {
+		return it.EachLog(ctx, filter, fn)
+	}
+	return eachLogOf(ctx, q.Storage, filter, fn)
+}
+
+// Stats returns the queue's depth and counters
+func (q *QueuedStorage) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats := q.stats
+	stats.Depth = len(q.queue)
+	stats.SpillBytes = q.spillBytes
+	return stats
+}
+
+// Close stops the background writer and writes what is queued until ctx is
+// done. Logs still queued then are spilled for the next start; an error
+// reports logs that were lost.
+func (q *QueuedStorage) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+	close(q.stop)
+	<-q.done
+
+	err := q.drain(ctx)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if remaining := q.queue; len(remaining) > 0 {
+		q.queue = nil
+		if spillErr := q.spillLocked(remaining); spillErr != nil {
+			q.dropLocked(len(remaining), spillErr)
+			err = fmt.Errorf("%d request logs lost on shutdown: %w", len(remaining), spillErr)
+		} else {
+			err = nil
+		}
+	}
+	if q.spill != nil {
+		q.spill.Close()
+		q.spill = nil
+	}
+	q.observeLocked()
+	return err
+}
+
+// run writes queued logs in batches, backing off while writes fail
+func (q *QueuedStorage) run() {
+	defer close(q.done)
+	ticker := time.NewTicker(q.cfg.FlushInterval)
+	defer ticker.Stop()
+	// Close interrupts a write in progress; its logs go back to the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-q.stop
+		cancel()
+	}()
+
+	var backoff time.Duration
+	var retryAt time.Time
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+		if time.Now().Before(retryAt) {
+			continue
+		}
+		err := q.drain(ctx)
+		if err == nil {
+			err = q.replay(ctx)
+		}
+		if err != nil {
+			backoff = min(max(backoff*2, q.cfg.FlushInterval), maxRetryBackoff)
+			retryAt = time.Now().Add(backoff)
+			continue
+		}
+		backoff = 0
+	}
+}
+
+// drain writes queued logs until the queue is empty. Logs of a failed write
+// go back to the front of the queue.
+func (q *QueuedStorage) drain(ctx context.Context) error {
+	q.drainMu.Lock()
+	defer q.drainMu.Unlock()
+	for {
+		q.mu.Lock()
+		n := min(len(q.queue), q.cfg.BatchSize)
+		batch := q.queue[:n:n]
+		q.queue = q.queue[n:]
+		q.mu.Unlock()
+		if n == 0 {
+			return nil
+		}
+
+		remaining, err := q.write(ctx, batch)
+		q.mu.Lock()
+		q.stats.Written += uint64(n - len(remaining))
+		if err != nil {
+			q.stats.WriteErrors++
+			q.metrics.AnalyticsWriteErrors.Inc()
+			q.queue = append(remaining, q.queue...)
+		}
+		q.observeLocked()
+		q.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// replay writes the logs of closed spill segments, oldest first, deleting
+// each once it is stored
+func (q *QueuedStorage) replay(ctx context.Context) error {
+	if q.cfg.SpillDir == "" {
+		return nil
+	}
+	q.drainMu.Lock()
+	defer q.drainMu.Unlock()
+
+	// Later spills go to a new segment, so the ones listed here are complete
+	q.mu.Lock()
+	if q.spillBytes == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	if q.spill != nil {
+		q.spill.Close()
+		q.spill = nil
+	}
+	segments, err := q.segments()
+	q.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		data, err := os.ReadFile(segment)
+		if err != nil {
+			return err
+		}
+		logs := decodeSpill(data)
+		for len(logs) > 0 {
+			n := min(len(logs), q.cfg.BatchSize)
+			if _, err := q.write(ctx, logs[:n]); err != nil {
+				q.mu.Lock()
+				q.stats.WriteErrors++
+				q.mu.Unlock()
+				q.metrics.AnalyticsWriteErrors.Inc()
+				return err
+			}
+			logs = logs[n:]
+			q.mu.Lock()
+			q.stats.Written += uint64(n)
+			q.mu.Unlock()
+		}
+		if err := os.Remove(segment); err != nil {
+			return err
+		}
+		q.mu.Lock()
+		q.spillBytes = max(q.spillBytes-int64(len(data)), 0)
+		q.observeLocked()
+		q.mu.Unlock()
+	}
+	return nil
+}
+
+// write stores a batch, returning the logs not stored when it fails
+func (q *QueuedStorage) write(ctx context.Context, batch []*RequestLog) ([]*RequestLog, error) {
+	if bs, ok := q.Storage.(BatchStorage); ok {
+		if err := bs.SaveLogs(ctx, batch); err != nil {
+			return batch, err
+		}
+		return nil, nil
+	}
+	for i, log := range batch {
+		if err := q.Storage.SaveLog(ctx, log); err != nil {
+			return batch[i:], err
+		}
+	}
+	return nil, nil
+}
+
+// spillLocked appends logs to the current spill segment
+func (q *QueuedStorage) spillLocked(logs []*RequestLog) error {
+	if q.cfg.SpillDir == "" {
+		return errors.New("no spill directory")
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, log := range logs {
+		if err := enc.Encode(log); err != nil {
+			return err
+		}
+	}
+	if q.spillBytes+int64(buf.Len()) > q.cfg.MaxSpillBytes {
+		return errSpillFull
+	}
+	if q.spill == nil {
+		if err := os.MkdirAll(q.cfg.SpillDir, 0700); err != nil {
+			return err
+		}
+		name := filepath.Join(q.cfg.SpillDir, fmt.Sprintf("%s%d%s", spillPrefix, time.Now().UnixNano(), spillSuffix))
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		q.spill = f
+	}
+	n, err := q.spill.Write(buf.Bytes())
+	q.spillBytes += int64(n)
+	if err != nil {
+		return err
+	}
+	q.stats.Spilled += uint64(len(logs))
+	q.metrics.AnalyticsLogsSpilled.Add(float64(len(logs)))
+	q.observeLocked()
+	return nil
+}
+
+var errSpillFull = errors.New("spill limit reached")
+
+func (q *QueuedStorage) dropLocked(n int, err error) {
+	reason := "spill_error"
+	switch {
+	case q.closed:
+		reason = "closed"
+	case errors.Is(err, errSpillFull):
+		reason = "spill_full"
+	}
+	q.stats.Dropped += uint64(n)
+	q.metrics.AnalyticsLogsDropped.WithLabelValues(reason).Add(float64(n))
+}
+
+// segments lists the spill files, oldest first
+func (q *QueuedStorage) segments() ([]string, error) {
+	entries, err := os.ReadDir(q.cfg.SpillDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spill directory: %w", err)
+	}
+	var segments []string
+	for _, e := range entries {
+		if name := e.Name(); !e.IsDir() && strings.HasPrefix(name, spillPrefix) && strings.HasSuffix(name, spillSuffix) {
+			segments = append(segments, filepath.Join(q.cfg.SpillDir, name))
+		}
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+func (q *QueuedStorage) observeLocked() {
+	q.metrics.AnalyticsQueueDepth.Set(float64(len(q.queue)))
+	q.metrics.AnalyticsSpillBytes.Set(float64(q.spillBytes))
+}
+
+func (q *QueuedStorage) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// decodeSpill reads the logs of a spill segment. A line cut short by a
+// crash is skipped.
+func decodeSpill(data []byte) []*RequestLog {
+	var logs []*RequestLog
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		log := &RequestLog{}
+		if err := json.Unmarshal(scanner.Bytes(), log); err != nil || log.ID == "" {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	return logs
+}
@@ -0,0 +1,194 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyStorage stores logs in memory and fails writes while failing is set
+type flakyStorage struct {
+	Storage
+	mu      sync.Mutex
+	failing bool
+	logs    map[string]*RequestLog
+	batches int
+}
+
+func newFlakyStorage() *flakyStorage {
+	return &flakyStorage{logs: map[string]*RequestLog{}}
+}
+
+func (f *flakyStorage) SaveLogs(_ context.Context, logs []*RequestLog) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing {
+		return errors.New("database is locked")
+	}
+	f.batches++
+	for _, l := range logs {
+		f.logs[l.ID] = l
+	}
+	return nil
+}
+
+func (f *flakyStorage) SaveLog(ctx context.Context, log *RequestLog) error {
+	return f.SaveLogs(ctx, []*RequestLog{log})
+}
+
+func (f *flakyStorage) setFailing(failing bool) {
+	f.mu.Lock()
+	f.failing = failing
+	f.mu.Unlock()
+}
+
+func (f *flakyStorage) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.logs)
+}
+
+func saveLogs(t *testing.T, q *QueuedStorage, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		if err := q.SaveLog(context.Background(), &RequestLog{ID: fmt.Sprintf("log-%d", i), UserID: "u"}); err != nil {
+			t.Fatalf("SaveLog(%d): %v", i, err)
+		}
+	}
+}
+
+func TestQueuedStorage_BatchesIntoDatabase(t *testing.T) {
+	db, err := NewDatabaseStorage(newTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := NewQueuedStorage(db, QueueConfig{BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close(context.Background())
+
+	ctx := context.Background()
+	saveLogs(t, q, 0, 5)
+	if err := q.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	// Delivering a log again is harmless
+	if err := db.SaveLogs(ctx, []*RequestLog{{ID: "log-0", UserID: "u"}}); err != nil {
+		t.Fatalf("SaveLogs of a stored log: %v", err)
+	}
+	logs, err := q.GetLogs(ctx, &LogFilter{})
+	if err != nil || len(logs) != 5 {
+		t.Fatalf("GetLogs = %d logs, %v; want 5", len(logs), err)
+	}
+	if stats := q.Stats(); stats.Written != 5 || stats.Depth != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestQueuedStorage_SpillsAndReplays(t *testing.T) {
+	store := newFlakyStorage()
+	store.setFailing(true)
+	dir := t.TempDir()
+	q, err := NewQueuedStorage(store, QueueConfig{QueueSize: 2, FlushInterval: time.Hour, SpillDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close(context.Background())
+
+	saveLogs(t, q, 0, 5)
+	if err := q.Flush(context.Background()); err == nil {
+		t.Fatal("Flush succeeded with failing storage")
+	}
+	stats := q.Stats()
+	if stats.Depth != 2 || stats.Spilled != 3 || stats.SpillBytes == 0 || stats.WriteErrors == 0 {
+		t.Fatalf("stats = %+v, want 2 queued and 3 spilled", stats)
+	}
+
+	store.setFailing(false)
+	if err := q.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if n := store.count(); n != 5 {
+		t.Errorf("stored %d logs, want 5", n)
+	}
+	entries, _ := os.ReadDir(dir)
+	if stats := q.Stats(); len(entries) != 0 || stats.SpillBytes != 0 {
+		t.Errorf("spill not cleared: %d files, stats %+v", len(entries), stats)
+	}
+}
+
+func TestQueuedStorage_CloseSpillsForNextStart(t *testing.T) {
+	store := newFlakyStorage()
+	store.setFailing(true)
+	dir := t.TempDir()
+	q, err := NewQueuedStorage(store, QueueConfig{FlushInterval: time.Hour, SpillDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saveLogs(t, q, 0, 3)
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if n := store.count(); n != 0 {
+		t.Fatalf("stored %d logs with failing storage", n)
+	}
+
+	// The next start replays them in the background
+	store.setFailing(false)
+	q, err = NewQueuedStorage(store, QueueConfig{FlushInterval: 10 * time.Millisecond, SpillDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close(context.Background())
+	deadline := time.Now().Add(5 * time.Second)
+	for store.count() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := store.count(); n != 3 {
+		t.Errorf("replayed %d logs, want 3", n)
+	}
+}
+
+func TestQueuedStorage_RetriesFailedWrites(t *testing.T) {
+	store := newFlakyStorage()
+	store.setFailing(true)
+	q, err := NewQueuedStorage(store, QueueConfig{BatchSize: 1, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close(context.Background())
+
+	saveLogs(t, q, 0, 2)
+	time.Sleep(30 * time.Millisecond)
+	store.setFailing(false)
+	deadline := time.Now().Add(5 * time.Second)
+	for store.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := store.count(); n != 2 {
+		t.Errorf("stored %d logs after retries, want 2", n)
+	}
+}
+
+func TestQueuedStorage_DropsWithoutSpill(t *testing.T) {
+	store := newFlakyStorage()
+	store.setFailing(true)
+	q, err := NewQueuedStorage(store, QueueConfig{QueueSize: 1, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close(context.Background())
+
+	saveLogs(t, q, 0, 1)
+	if err := q.SaveLog(context.Background(), &RequestLog{ID: "log-1"}); !errors.Is(err, ErrLogDropped) {
+		t.Fatalf("SaveLog on a full queue = %v, want ErrLogDropped", err)
+	}
+	if stats := q.Stats(); stats.Dropped != 1 || stats.Depth != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
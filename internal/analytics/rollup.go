@@ -0,0 +1,414 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Rollup totals the requests of one hour that share a provider, model,
+// user, cost band and latency band. Rollups are kept up to date as logs are
+// stored, so reports over long windows read a few rows per hour instead of
+// every log.
+type Rollup struct {
+	Hour           time.Time `json:"hour"` // Start of the hour, in UTC
+	ProviderID     string    `json:"provider_id"`
+	ModelName      string    `json:"model_name"`
+	UserID         string    `json:"user_id"`
+	CostBand       string    `json:"cost_band"`
+	LatencyBand    string    `json:"latency_band"`
+	Requests       int64     `json:"requests"`
+	Errors         int64     `json:"errors"` // Requests with an error message
+	Tokens         int64     `json:"tokens"`
+	CostUSD        float64   `json:"cost_usd"`
+	CostSquares    float64   `json:"cost_squares"` // Sum of squared costs, for deviations
+	LatencyMs      int64     `json:"latency_ms"`   // Sum of latencies
+	LatencySquares float64   `json:"latency_squares"`
+	FirstSeen      time.Time `json:"first_seen"`
+	LastSeen       time.Time `json:"last_seen"`
+}
+
+// RollupStorage is storage that keeps hourly rollups of its logs
+type RollupStorage interface {
+	// GetRollups returns the rollups of the logs matching the filter's
+	// user, provider, model and time range. Hours the range only partly
+	// covers are rolled up from the logs themselves, so the totals are
+	// exact.
+	GetRollups(ctx context.Context, filter *LogFilter) ([]*Rollup, error)
+}
+
+// CostBand names the cost range a request falls in
+func CostBand(costUSD float64) string {
+	switch {
+	case costUSD < 0.01:
+		return "<$0.01"
+	case costUSD < 0.10:
+		return "$0.01-$0.10"
+	case costUSD < 1.00:
+		return "$0.10-$1.00"
+	default:
+		return ">$1.00"
+	}
+}
+
+// LatencyBand names the latency range a request falls in
+func LatencyBand(latencyMs int64) string {
+	switch {
+	case latencyMs < 100:
+		return "<100ms"
+	case latencyMs < 500:
+		return "100-500ms"
+	case latencyMs < 2000:
+		return "500-2000ms"
+	default:
+		return ">2000ms"
+	}
+}
+
+type rollupKey struct {
+	hour                                         time.Time
+	provider, model, user, costBand, latencyBand string
+}
+
+func rollupKeyOf(log *RequestLog) rollupKey {
+	return rollupKey{
+		hour:        log.Timestamp.UTC().Truncate(time.Hour),
+		provider:    log.ProviderID,
+		model:       log.ModelName,
+		user:        log.UserID,
+		costBand:    CostBand(log.CostUSD),
+		latencyBand: LatencyBand(log.LatencyMs),
+	}
+}
+
+// rollupOf is the rollup of a single log
+func rollupOf(log *RequestLog) *Rollup {
+	k := rollupKeyOf(log)
+	r := &Rollup{
+		Hour:        k.hour,
+		ProviderID:  k.provider,
+		ModelName:   k.model,
+		UserID:      k.user,
+		CostBand:    k.costBand,
+		LatencyBand: k.latencyBand,
+		FirstSeen:   log.Timestamp.UTC(),
+		LastSeen:    log.Timestamp.UTC(),
+	}
+	r.add(log)
+	return r
+}
+
+func (r *Rollup) add(log *RequestLog) {
+	r.Requests++
+	if log.ErrorMessage != "" {
+		r.Errors++
+	}
+	r.Tokens += log.TotalTokens
+	r.CostUSD += log.CostUSD
+	r.CostSquares += log.CostUSD * log.CostUSD
+	r.LatencyMs += log.LatencyMs
+	r.LatencySquares += float64(log.LatencyMs) * float64(log.LatencyMs)
+	ts := log.Timestamp.UTC()
+	if ts.Before(r.FirstSeen) {
+		r.FirstSeen = ts
+	}
+	if ts.After(r.LastSeen) {
+		r.LastSeen = ts
+	}
+}
+
+// RollupLogs rolls logs up by hour, provider, model, user, cost band and
+// latency band, oldest hour first
+func RollupLogs(logs []*RequestLog) []*Rollup {
+	set := rollupSet{}
+	for _, log := range logs {
+		set.add(log)
+	}
+	return set.list()
+}
+
+// rollupSet rolls up logs one at a time
+type rollupSet map[rollupKey]*Rollup
+
+func (set rollupSet) add(log *RequestLog) {
+	k := rollupKeyOf(log)
+	if r, ok := set[k]; ok {
+		r.add(log)
+		return
+	}
+	set[k] = rollupOf(log)
+}
+
+func (set rollupSet) list() []*Rollup {
+	rollups := make([]*Rollup, 0, len(set))
+	for _, r := range set {
+		rollups = append(rollups, r)
+	}
+	sort.Slice(rollups, func(i, j int) bool {
+		a, b := rollups[i], rollups[j]
+		if !a.Hour.Equal(b.Hour) {
+			return a.Hour.Before(b.Hour)
+		}
+		if a.ProviderID != b.ProviderID {
+			return a.ProviderID < b.ProviderID
+		}
+		if a.ModelName != b.ModelName {
+			return a.ModelName < b.ModelName
+		}
+		if a.UserID != b.UserID {
+			return a.UserID < b.UserID
+		}
+		if a.CostBand != b.CostBand {
+			return a.CostBand < b.CostBand
+		}
+		return a.LatencyBand < b.LatencyBand
+	})
+	return rollups
+}
+
+const rollupSchema = `
+	CREATE TABLE IF NOT EXISTS request_log_rollups (
+		hour DATETIME NOT NULL,
+		provider_id TEXT NOT NULL,
+		model_name TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		cost_band TEXT NOT NULL,
+		latency_band TEXT NOT NULL,
+		requests INTEGER NOT NULL,
+		errors INTEGER NOT NULL,
+		tokens INTEGER NOT NULL,
+		cost_usd REAL NOT NULL,
+		cost_squares REAL NOT NULL,
+		latency_ms INTEGER NOT NULL,
+		latency_squares REAL NOT NULL,
+		first_seen DATETIME NOT NULL,
+		last_seen DATETIME NOT NULL,
+		PRIMARY KEY (hour, provider_id, model_name, user_id, cost_band, latency_band)
+	);
+`
+
+// upsertRollup adds r to the stored rollup with the same key. Negative
+// counts take a log back out; rollups left without requests are removed.
+const upsertRollup = `
+	INSERT INTO request_log_rollups (
+		hour, provider_id, model_name, user_id, cost_band, latency_band,
+		requests, errors, tokens, cost_usd, cost_squares, latency_ms,
+		latency_squares, first_seen, last_seen
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(hour, provider_id, model_name, user_id, cost_band, latency_band) DO UPDATE SET
+		requests = requests + excluded.requests,
+		errors = errors + excluded.errors,
+		tokens = tokens + excluded.tokens,
+		cost_usd = cost_usd + excluded.cost_usd,
+		cost_squares = cost_squares + excluded.cost_squares,
+		latency_ms = latency_ms + excluded.latency_ms,
+		latency_squares = latency_squares + excluded.latency_squares,
+		first_seen = MIN(first_seen, excluded.first_seen),
+		last_seen = MAX(last_seen, excluded.last_seen)
+`
+
+// initRollups creates the rollup table, filling it from the stored logs
+// when the logs predate it
+func (s *DatabaseStorage) initRollups() error {
+	if _, err := s.db.Exec(rollupSchema); err != nil {
+		return err
+	}
+	var haveRollups, haveLogs bool
+	if err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM request_log_rollups)").Scan(&haveRollups); err != nil {
+		return err
+	}
+	if haveRollups {
+		return nil
+	}
+	if err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM request_logs)").Scan(&haveLogs); err != nil {
+		return err
+	}
+	if !haveLogs {
+		return nil
+	}
+
+	rollups, err := s.rollupRange(context.Background(), &LogFilter{}, "", nil)
+	if err != nil {
+		return fmt.Errorf("roll up stored logs: %w", err)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, r := range rollups {
+		if err := applyRollup(context.Background(), tx, r, 1); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// applyRollup adds r, times sign, to the stored rollups
+func applyRollup(ctx context.Context, tx *sql.Tx, r *Rollup, sign int64) error {
+	f := float64(sign)
+	if _, err := tx.ExecContext(ctx, upsertRollup,
+		r.Hour, r.ProviderID, r.ModelName, r.UserID, r.CostBand, r.LatencyBand,
+		sign*r.Requests, sign*r.Errors, sign*r.Tokens, f*r.CostUSD, f*r.CostSquares,
+		sign*r.LatencyMs, f*r.LatencySquares, r.FirstSeen, r.LastSeen,
+	); err != nil {
+		return err
+	}
+	if sign < 0 {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM request_log_rollups
+			WHERE hour = ? AND provider_id = ? AND model_name = ? AND user_id = ?
+				AND cost_band = ? AND latency_band = ? AND requests <= 0`,
+			r.Hour, r.ProviderID, r.ModelName, r.UserID, r.CostBand, r.LatencyBand)
+		return err
+	}
+	return nil
+}
+
+// GetRollups returns the stored rollups of the whole hours in the filter's
+// time range, and rolls up the logs of the partial hours at either end
+func (s *DatabaseStorage) GetRollups(ctx context.Context, filter *LogFilter) ([]*Rollup, error) {
+	start, end := filter.StartTime, filter.EndTime
+	first := start
+	if !start.IsZero() {
+		first = start.UTC().Truncate(time.Hour)
+		if first.Before(start) {
+			first = first.Add(time.Hour)
+		}
+	}
+	last := end
+	if !end.IsZero() {
+		last = end.UTC().Truncate(time.Hour)
+	}
+	if !start.IsZero() && !end.IsZero() && !first.Before(last) {
+		return s.rollupRange(ctx, filter, " AND timestamp >= ? AND timestamp <= ?", []interface{}{start, end})
+	}
+
+	var rollups []*Rollup
+	if !start.IsZero() && start.Before(first) {
+		head, err := s.rollupRange(ctx, filter, " AND timestamp >= ? AND timestamp < ?", []interface{}{start, first})
+		if err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, head...)
+	}
+
+	query := `
+		SELECT hour, provider_id, model_name, user_id, cost_band, latency_band,
+			requests, errors, tokens, cost_usd, cost_squares, latency_ms,
+			latency_squares, first_seen, last_seen
+		FROM request_log_rollups
+		WHERE 1=1`
+	args := []interface{}{}
+	if filter.UserID != "" {
+		query += " AND user_id = ?"
+		args = append(args, filter.UserID)
+	}
+	if filter.ProviderID != "" {
+		query += " AND provider_id = ?"
+		args = append(args, filter.ProviderID)
+	}
+	if filter.ModelName != "" {
+		query += " AND model_name = ?"
+		args = append(args, filter.ModelName)
+	}
+	if !first.IsZero() {
+		query += " AND hour >= ?"
+		args = append(args, first)
+	}
+	if !last.IsZero() {
+		query += " AND hour < ?"
+		args = append(args, last)
+	}
+	query += " ORDER BY hour"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		r := &Rollup{}
+		if err := rows.Scan(&r.Hour, &r.ProviderID, &r.ModelName, &r.UserID, &r.CostBand, &r.LatencyBand,
+			&r.Requests, &r.Errors, &r.Tokens, &r.CostUSD, &r.CostSquares, &r.LatencyMs,
+			&r.LatencySquares, &r.FirstSeen, &r.LastSeen); err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if !end.IsZero() {
+		tail, err := s.rollupRange(ctx, filter, " AND timestamp >= ? AND timestamp <= ?", []interface{}{last, end})
+		if err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, tail...)
+	}
+	return rollups, nil
+}
+
+// rollupRange rolls up the logs matching the filter's user, provider and
+// model and the given time condition
+func (s *DatabaseStorage) rollupRange(ctx context.Context, filter *LogFilter, timeCond string, timeArgs []interface{}) ([]*Rollup, error) {
+	query := rollupLogColumns + " WHERE 1=1"
+	args := []interface{}{}
+	if filter.UserID != "" {
+		query += " AND user_id = ?"
+		args = append(args, filter.UserID)
+	}
+	if filter.ProviderID != "" {
+		query += " AND provider_id = ?"
+		args = append(args, filter.ProviderID)
+	}
+	if filter.ModelName != "" {
+		query += " AND model_name = ?"
+		args = append(args, filter.ModelName)
+	}
+	query += timeCond
+	args = append(args, timeArgs...)
+
+	set := rollupSet{}
+	if err := eachRollupLog(s.db.QueryContext(ctx, query, args...))(set.add); err != nil {
+		return nil, err
+	}
+	return set.list(), nil
+}
+
+// rollupLogColumns selects the log columns rollups are made of
+const rollupLogColumns = `
+	SELECT timestamp, COALESCE(provider_id, ''), COALESCE(model_name, ''), user_id,
+		COALESCE(total_tokens, 0), COALESCE(latency_ms, 0), COALESCE(cost_usd, 0),
+		COALESCE(error_message, '')
+	FROM request_logs`
+
+// eachRollupLog returns a function calling fn with each log selected with
+// rollupLogColumns
+func eachRollupLog(rows *sql.Rows, err error) func(fn func(*RequestLog)) error {
+	return func(fn func(*RequestLog)) error {
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			log := &RequestLog{}
+			if err := rows.Scan(&log.Timestamp, &log.ProviderID, &log.ModelName, &log.UserID,
+				&log.TotalTokens, &log.LatencyMs, &log.CostUSD, &log.ErrorMessage); err != nil {
+				return err
+			}
+			fn(log)
+		}
+		return rows.Err()
+	}
+}
+
+// scanRollupLogs reads logs selected with rollupLogColumns
+func scanRollupLogs(rows *sql.Rows, err error) ([]*RequestLog, error) {
+	var logs []*RequestLog
+	err = eachRollupLog(rows, err)(func(log *RequestLog) { logs = append(logs, log) })
+	return logs, err
+}
@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// rollupTotals keys rollups by everything but the hour's first and last
+// request, with sums rounded against float noise
+func rollupTotals(rollups []*Rollup) map[string]string {
+	totals := map[string]string{}
+	for _, r := range rollups {
+		key := fmt.Sprintf("%s|%s|%s|%s|%s|%s", r.Hour.UTC().Format(time.RFC3339), r.ProviderID, r.ModelName, r.UserID, r.CostBand, r.LatencyBand)
+		totals[key] = fmt.Sprintf("%d/%d/%d/%.6f/%d", r.Requests, r.Errors, r.Tokens, r.CostUSD, r.LatencyMs)
+	}
+	return totals
+}
+
+func checkRollups(t *testing.T, s *DatabaseStorage, filter *LogFilter) {
+	t.Helper()
+	ctx := context.Background()
+	logs, err := s.GetLogs(ctx, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rollups, err := s.GetRollups(ctx, filter)
+	if err != nil {
+		t.Fatalf("GetRollups: %v", err)
+	}
+	want, got := rollupTotals(RollupLogs(logs)), rollupTotals(rollups)
+	if len(got) != len(want) {
+		t.Fatalf("GetRollups(%+v) = %d rollups, want %d:\n%v\n%v", filter, len(got), len(want), got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("rollup %s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestDatabaseStorage_Rollups(t *testing.T) {
+	s, err := NewDatabaseStorage(newTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	base := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+
+	var batch []*RequestLog
+	for i := 0; i < 48; i++ {
+		log := &RequestLog{
+			ID:          fmt.Sprintf("log-%d", i),
+			Timestamp:   base.Add(time.Duration(i) * 17 * time.Minute),
+			UserID:      []string{"alice", "bob"}[i%2],
+			ProviderID:  "openai",
+			ModelName:   []string{"gpt-4", "gpt-4o-mini"}[i%3%2],
+			TotalTokens: int64(100 * i),
+			LatencyMs:   int64(50 * i),
+			CostUSD:     float64(i) * 0.01,
+		}
+		if i%5 == 0 {
+			log.ErrorMessage = "rate limited"
+		}
+		if i < 10 {
+			if err := s.SaveLog(ctx, log); err != nil {
+				t.Fatalf("SaveLog: %v", err)
+			}
+		} else {
+			batch = append(batch, log)
+		}
+	}
+	if err := s.SaveLogs(ctx, batch); err != nil {
+		t.Fatalf("SaveLogs: %v", err)
+	}
+	// A redelivered batch leaves the rollups alone
+	if err := s.SaveLogs(ctx, batch[:5]); err != nil {
+		t.Fatalf("SaveLogs again: %v", err)
+	}
+
+	checkRollups(t, s, &LogFilter{})
+	checkRollups(t, s, &LogFilter{StartTime: base.Add(95 * time.Minute), EndTime: base.Add(10*time.Hour + 7*time.Minute)})
+	checkRollups(t, s, &LogFilter{StartTime: base.Add(20 * time.Minute), EndTime: base.Add(40 * time.Minute)})
+	checkRollups(t, s, &LogFilter{UserID: "bob", StartTime: base.Add(3 * time.Hour)})
+
+	// Re-pricing moves a request between cost bands
+	if err := s.UpdateLogCost(ctx, "log-3", 2.5, nil); err != nil {
+		t.Fatalf("UpdateLogCost: %v", err)
+	}
+	checkRollups(t, s, &LogFilter{})
+
+	// Deleting up to the middle of an hour takes the deleted requests out of
+	// that hour's rollups
+	if _, err := s.DeleteOldLogs(ctx, base.Add(3*time.Hour+30*time.Minute)); err != nil {
+		t.Fatalf("DeleteOldLogs: %v", err)
+	}
+	checkRollups(t, s, &LogFilter{})
+
+	series, err := s.GetCostSeries(ctx, &LogFilter{}, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs, _ := s.GetLogs(ctx, &LogFilter{})
+	var cost float64
+	for _, l := range logs {
+		cost += l.CostUSD
+	}
+	if len(series) != 1 || series[0].Requests != int64(len(logs)) || math.Abs(series[0].CostUSD-cost) > 1e-9 {
+		t.Errorf("daily series = %+v, want one day of %d requests costing %.2f", series, len(logs), cost)
+	}
+}
+
+func TestDatabaseStorage_RollupBackfill(t *testing.T) {
+	db := newTestDB(t)
+	s, err := NewDatabaseStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for i := 0; i < 5; i++ {
+		if err := s.SaveLog(ctx, &RequestLog{ID: fmt.Sprintf("log-%d", i), Timestamp: now.Add(-time.Duration(i) * time.Hour), UserID: "u", CostUSD: 0.2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A database whose logs predate rollups is rolled up on open
+	if _, err := db.Exec("DROP TABLE request_log_rollups"); err != nil {
+		t.Fatal(err)
+	}
+	s, err = NewDatabaseStorage(db)
+	if err != nil {
+		t.Fatalf("NewDatabaseStorage: %v", err)
+	}
+	checkRollups(t, s, &LogFilter{})
+}
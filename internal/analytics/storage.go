@@ -52,17 +52,25 @@ func (s *DatabaseStorage) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_request_logs_created_at ON request_logs(created_at);
 	`
 
-	_, err := s.db.Exec(schema)
-	return err
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+	return s.initRollups()
 }
 
-// SaveLog persists a request log
+// SaveLog persists a request log and adds it to its hour's rollup
 func (s *DatabaseStorage) SaveLog(ctx context.Context, log *RequestLog) error {
 	metadataJSON, err := json.Marshal(log.Metadata)
 	if err != nil {
 		metadataJSON = []byte("{}")
 	}
 
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
 	query := `
 		INSERT INTO request_logs (
 			id, timestamp, user_id, method, path, provider_id, model_name,
@@ -72,7 +80,7 @@ func (s *DatabaseStorage) SaveLog(ctx context.Context, log *RequestLog) error {
 		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err = s.db.ExecContext(ctx, query,
+	_, err = tx.ExecContext(ctx, query,
 		log.ID,
 		log.Timestamp,
 		log.UserID,
@@ -91,12 +99,85 @@ func (s *DatabaseStorage) SaveLog(ctx context.Context, log *RequestLog) error {
 		log.ResponseBody,
 		string(metadataJSON),
 	)
+	if err != nil {
+		return err
+	}
+	if err := applyRollup(ctx, tx, rollupOf(log), 1); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveLogs persists a batch of logs in one transaction and adds them to
+// their rollups. Logs already stored are skipped, so a batch can be
+// delivered again after a failure.
+func (s *DatabaseStorage) SaveLogs(ctx context.Context, logs []*RequestLog) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
 
-	return err
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO request_logs (
+			id, timestamp, user_id, method, path, provider_id, model_name,
+			prompt_tokens, completion_tokens, total_tokens, latency_ms,
+			status_code, cost_usd, error_message, request_body, response_body,
+			metadata_json
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO NOTHING
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, log := range logs {
+		metadataJSON, err := json.Marshal(log.Metadata)
+		if err != nil {
+			metadataJSON = []byte("{}")
+		}
+		res, err := stmt.ExecContext(ctx,
+			log.ID, log.Timestamp, log.UserID, log.Method, log.Path, log.ProviderID, log.ModelName,
+			log.PromptTokens, log.CompletionTokens, log.TotalTokens, log.LatencyMs,
+			log.StatusCode, log.CostUSD, log.ErrorMessage, log.RequestBody, log.ResponseBody,
+			string(metadataJSON),
+		)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			continue
+		}
+		if err := applyRollup(ctx, tx, rollupOf(log), 1); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // GetLogs retrieves logs with filtering
 func (s *DatabaseStorage) GetLogs(ctx context.Context, filter *LogFilter) ([]*RequestLog, error) {
+	var logs []*RequestLog
+	err := s.eachLog(ctx, filter, "DESC", func(log *RequestLog) error {
+		logs = append(logs, log)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// EachLog calls fn with each log matching the filter, oldest first, without
+// loading them all. It stops at the first error fn returns.
+func (s *DatabaseStorage) EachLog(ctx context.Context, filter *LogFilter, fn func(*RequestLog) error) error {
+	return s.eachLog(ctx, filter, "ASC", fn)
+}
+
+func (s *DatabaseStorage) eachLog(ctx context.Context, filter *LogFilter, order string, fn func(*RequestLog) error) error {
 	query := `
 		SELECT 
 			id, timestamp, user_id, method, path, provider_id, model_name,
@@ -118,6 +199,11 @@ func (s *DatabaseStorage) GetLogs(ctx context.Context, filter *LogFilter) ([]*Re
 		args = append(args, filter.ProviderID)
 	}
 
+	if filter.ModelName != "" {
+		query += " AND model_name = ?"
+		args = append(args, filter.ModelName)
+	}
+
 	if !filter.StartTime.IsZero() {
 		query += " AND timestamp >= ?"
 		args = append(args, filter.StartTime)
@@ -128,7 +214,17 @@ func (s *DatabaseStorage) GetLogs(ctx context.Context, filter *LogFilter) ([]*Re
 		args = append(args, filter.EndTime)
 	}
 
-	query += " ORDER BY timestamp DESC"
+	if filter.MinCostUSD > 0 {
+		query += " AND cost_usd >= ?"
+		args = append(args, filter.MinCostUSD)
+	}
+
+	if filter.MinLatencyMs > 0 {
+		query += " AND latency_ms >= ?"
+		args = append(args, filter.MinLatencyMs)
+	}
+
+	query += " ORDER BY timestamp " + order
 
 	if filter.Limit > 0 {
 		query += " LIMIT ?"
@@ -141,11 +237,10 @@ func (s *DatabaseStorage) GetLogs(ctx context.Context, filter *LogFilter) ([]*Re
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var logs []*RequestLog
 	for rows.Next() {
 		log := &RequestLog{}
 		var metadataJSON string
@@ -170,7 +265,7 @@ func (s *DatabaseStorage) GetLogs(ctx context.Context, filter *LogFilter) ([]*Re
 			&metadataJSON,
 		)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if metadataJSON != "" {
@@ -180,10 +275,12 @@ func (s *DatabaseStorage) GetLogs(ctx context.Context, filter *LogFilter) ([]*Re
 			}
 		}
 
-		logs = append(logs, log)
+		if err := fn(log); err != nil {
+			return err
+		}
 	}
 
-	return logs, rows.Err()
+	return rows.Err()
 }
 
 // GetLogStats computes aggregate statistics
@@ -210,6 +307,11 @@ func (s *DatabaseStorage) GetLogStats(ctx context.Context, filter *LogFilter) (*
 		args = append(args, filter.ProviderID)
 	}
 
+	if filter.ModelName != "" {
+		baseQuery += " AND model_name = ?"
+		args = append(args, filter.ModelName)
+	}
+
 	if !filter.StartTime.IsZero() {
 		baseQuery += " AND timestamp >= ?"
 		args = append(args, filter.StartTime)
@@ -220,6 +322,16 @@ func (s *DatabaseStorage) GetLogStats(ctx context.Context, filter *LogFilter) (*
 		args = append(args, filter.EndTime)
 	}
 
+	if filter.MinCostUSD > 0 {
+		baseQuery += " AND cost_usd >= ?"
+		args = append(args, filter.MinCostUSD)
+	}
+
+	if filter.MinLatencyMs > 0 {
+		baseQuery += " AND latency_ms >= ?"
+		args = append(args, filter.MinLatencyMs)
+	}
+
 	stats := &LogStats{
 		RequestsByUser:     make(map[string]int64),
 		RequestsByProvider: make(map[string]int64),
@@ -302,13 +414,124 @@ func (s *DatabaseStorage) GetLogStats(ctx context.Context, filter *LogFilter) (*
 	return stats, nil
 }
 
-// DeleteOldLogs removes logs older than the specified time
+// GetCostSeries totals cost, requests and tokens per bucket, oldest bucket
+// first. Buckets are aligned to bucket boundaries in UTC, and empty buckets
+// are omitted. Buckets of whole hours are totalled from rollups.
+func (s *DatabaseStorage) GetCostSeries(ctx context.Context, filter *LogFilter, bucket time.Duration) ([]*CostPoint, error) {
+	if bucket <= 0 {
+		bucket = time.Hour
+	}
+	if bucket%time.Hour == 0 && filter.MinCostUSD == 0 && filter.MinLatencyMs == 0 {
+		rollups, err := s.GetRollups(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		var series []*CostPoint
+		for _, r := range rollups {
+			start := r.Hour.Truncate(bucket)
+			if n := len(series); n == 0 || !series[n-1].Start.Equal(start) {
+				series = append(series, &CostPoint{Start: start})
+			}
+			p := series[len(series)-1]
+			p.CostUSD += r.CostUSD
+			p.Requests += r.Requests
+			p.Tokens += r.Tokens
+		}
+		return series, nil
+	}
+	query := "SELECT timestamp, COALESCE(cost_usd, 0), COALESCE(total_tokens, 0) FROM request_logs WHERE 1=1" +
+		buildWhereClause(filter) + " ORDER BY timestamp"
+	rows, err := s.db.QueryContext(ctx, query, buildWhereArgs(filter)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var series []*CostPoint
+	for rows.Next() {
+		var ts time.Time
+		var cost float64
+		var tokens int64
+		if err := rows.Scan(&ts, &cost, &tokens); err != nil {
+			return nil, err
+		}
+		start := ts.UTC().Truncate(bucket)
+		if n := len(series); n == 0 || !series[n-1].Start.Equal(start) {
+			series = append(series, &CostPoint{Start: start})
+		}
+		p := series[len(series)-1]
+		p.CostUSD += cost
+		p.Requests++
+		p.Tokens += tokens
+	}
+	return series, rows.Err()
+}
+
+// UpdateLogCost replaces a log's cost and metadata, for re-pricing, and
+// moves the log's cost between rollups
+func (s *DatabaseStorage) UpdateLogCost(ctx context.Context, id string, costUSD float64, metadata map[string]string) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	old, err := scanRollupLogs(tx.QueryContext(ctx, rollupLogColumns+" WHERE id = ?", id))
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE request_logs SET cost_usd = ?, metadata_json = ? WHERE id = ?",
+		costUSD, string(metadataJSON), id); err != nil {
+		return err
+	}
+	for _, log := range old {
+		if err := applyRollup(ctx, tx, rollupOf(log), -1); err != nil {
+			return err
+		}
+		log.CostUSD = costUSD
+		if err := applyRollup(ctx, tx, rollupOf(log), 1); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteOldLogs removes logs older than the specified time with their
+// rollups. Logs deleted from the hour containing before are taken out of
+// its rollups.
 func (s *DatabaseStorage) DeleteOldLogs(ctx context.Context, before time.Time) (int64, error) {
-	result, err := s.db.ExecContext(ctx, "DELETE FROM request_logs WHERE timestamp < ?", before)
+	hour := before.UTC().Truncate(time.Hour)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	partial, err := scanRollupLogs(tx.QueryContext(ctx, rollupLogColumns+" WHERE timestamp >= ? AND timestamp < ?", hour, before))
+	if err != nil {
+		return 0, err
+	}
+	for _, log := range partial {
+		if err := applyRollup(ctx, tx, rollupOf(log), -1); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM request_log_rollups WHERE hour < ?", hour); err != nil {
+		return 0, err
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM request_logs WHERE timestamp < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
-	return result.RowsAffected()
+	return n, tx.Commit()
 }
 
 // Helper functions for building queries
@@ -320,12 +543,21 @@ func buildWhereClause(filter *LogFilter) string {
 	if filter.ProviderID != "" {
 		where += " AND provider_id = ?"
 	}
+	if filter.ModelName != "" {
+		where += " AND model_name = ?"
+	}
 	if !filter.StartTime.IsZero() {
 		where += " AND timestamp >= ?"
 	}
 	if !filter.EndTime.IsZero() {
 		where += " AND timestamp <= ?"
 	}
+	if filter.MinCostUSD > 0 {
+		where += " AND cost_usd >= ?"
+	}
+	if filter.MinLatencyMs > 0 {
+		where += " AND latency_ms >= ?"
+	}
 	return where
 }
 
@@ -337,11 +569,20 @@ func buildWhereArgs(filter *LogFilter) []interface{} {
 	if filter.ProviderID != "" {
 		args = append(args, filter.ProviderID)
 	}
+	if filter.ModelName != "" {
+		args = append(args, filter.ModelName)
+	}
 	if !filter.StartTime.IsZero() {
 		args = append(args, filter.StartTime)
 	}
 	if !filter.EndTime.IsZero() {
 		args = append(args, filter.EndTime)
 	}
+	if filter.MinCostUSD > 0 {
+		args = append(args, filter.MinCostUSD)
+	}
+	if filter.MinLatencyMs > 0 {
+		args = append(args, filter.MinLatencyMs)
+	}
 	return args
 }
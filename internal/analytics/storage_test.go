@@ -289,6 +289,35 @@ func TestDatabaseStorage_DeleteOldLogs(t *testing.T) {
 	}
 }
 
+func TestDatabaseStorage_GetCostSeries(t *testing.T) {
+	db := newTestDB(t)
+	storage, err := NewDatabaseStorage(db)
+	if err != nil {
+		t.Fatalf("NewDatabaseStorage failed: %v", err)
+	}
+
+	ctx := context.Background()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	_ = storage.SaveLog(ctx, &RequestLog{ID: "c1", Timestamp: day.Add(1 * time.Hour), UserID: "u", Method: "POST", Path: "/api", CostUSD: 0.5, TotalTokens: 100})
+	_ = storage.SaveLog(ctx, &RequestLog{ID: "c2", Timestamp: day.Add(9 * time.Hour), UserID: "u", Method: "POST", Path: "/api", CostUSD: 0.25, TotalTokens: 50})
+	_ = storage.SaveLog(ctx, &RequestLog{ID: "c3", Timestamp: day.Add(50 * time.Hour), UserID: "u", Method: "POST", Path: "/api", CostUSD: 1, TotalTokens: 10})
+
+	series, err := storage.GetCostSeries(ctx, &LogFilter{}, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GetCostSeries failed: %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(series))
+	}
+	if !series[0].Start.Equal(day) || series[0].Requests != 2 || series[0].CostUSD != 0.75 || series[0].Tokens != 150 {
+		t.Errorf("unexpected first bucket: %+v", series[0])
+	}
+	if !series[1].Start.Equal(day.Add(48*time.Hour)) || series[1].Requests != 1 {
+		t.Errorf("unexpected second bucket: %+v", series[1])
+	}
+}
+
 func TestDatabaseStorage_SaveLog_NilMetadata(t *testing.T) {
 	db := newTestDB(t)
 	storage, err := NewDatabaseStorage(db)
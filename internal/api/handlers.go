@@ -212,6 +212,10 @@ func (s *Server) handleAgentAction(w http.ResponseWriter, r *http.Request, id, a
 	switch action {
 	case "clone":
 		s.handleCloneAgent(w, r, id)
+	case "inbox":
+		s.handleAgentInbox(w, r, id)
+	case "working-set":
+		s.handleAgentWorkingSet(w, r, id)
 	default:
 		s.respondError(w, http.StatusNotFound, "Unknown action")
 	}
@@ -311,6 +315,50 @@ func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
 			s.handleProjectFiles(w, r, id, parts[2:])
 			return
 		}
+		if action == "snapshots" {
+			s.handleProjectSnapshots(w, r, id, parts[2:])
+			return
+		}
+		if action == "security-scans" {
+			s.handleProjectSecurityScans(w, r, id, parts[2:])
+			return
+		}
+		if action == "dependencies" {
+			s.handleProjectDependencies(w, r, id, parts[2:])
+			return
+		}
+		if action == "maintenance" {
+			s.handleProjectMaintenance(w, r, id, parts[2:])
+			return
+		}
+		if action == "fields" {
+			s.handleProjectFields(w, r, id, parts[2:])
+			return
+		}
+		if action == "repo-map" {
+			s.handleProjectRepoMap(w, r, id)
+			return
+		}
+		if action == "impact" {
+			s.handleProjectImpact(w, r, id)
+			return
+		}
+		if action == "provision" {
+			s.handleRetryProvisioning(w, r, id)
+			return
+		}
+		if action == "quota" {
+			s.handleProjectQuota(w, r, id)
+			return
+		}
+		if action == "mcp-tools" {
+			s.handleProjectMCPTools(w, r, id)
+			return
+		}
+		if action == "diff" {
+			s.handleProjectDiff(w, r, id)
+			return
+		}
 		s.handleProjectStateEndpoints(w, r, id, action)
 		return
 	}
@@ -327,15 +375,16 @@ func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
 
 	case http.MethodPut:
 		var req struct {
-			Name        string            `json:"name"`
-			GitRepo     string            `json:"git_repo"`
-			Branch      string            `json:"branch"`
-			BeadsPath   string            `json:"beads_path"`
-			Context     map[string]string `json:"context"`
-			Status      string            `json:"status"`
-			GitStrategy *string           `json:"git_strategy"`
-			IsPerpetual *bool             `json:"is_perpetual"`
-			IsSticky    *bool             `json:"is_sticky"`
+			Name        string               `json:"name"`
+			GitRepo     string               `json:"git_repo"`
+			Branch      string               `json:"branch"`
+			BeadsPath   string               `json:"beads_path"`
+			Context     map[string]string    `json:"context"`
+			Status      string               `json:"status"`
+			GitStrategy *string              `json:"git_strategy"`
+			IsPerpetual *bool                `json:"is_perpetual"`
+			IsSticky    *bool                `json:"is_sticky"`
+			Subprojects *[]models.Subproject `json:"subprojects"`
 		}
 		if err := s.parseJSON(r, &req); err != nil {
 			s.respondError(w, http.StatusBadRequest, "Invalid request body")
@@ -369,6 +418,9 @@ func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
 		if req.GitStrategy != nil {
 			updates["git_strategy"] = *req.GitStrategy
 		}
+		if req.Subprojects != nil {
+			updates["subprojects"] = *req.Subprojects
+		}
 
 		if err := s.app.GetProjectManager().UpdateProject(id, updates); err != nil {
 			s.respondError(w, http.StatusBadRequest, err.Error())
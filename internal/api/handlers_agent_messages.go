@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/messaging"
+)
+
+// handleAgentMessages lists and sends agent-to-agent messages
+// GET /api/v1/agent-messages?agent_id=...&to_agent_id=...&from_agent_id=...&bead_id=...&unacknowledged=true&limit=100
+// POST /api/v1/agent-messages - Send a message (e.g. from a human operator)
+func (s *Server) handleAgentMessages(w http.ResponseWriter, r *http.Request) {
+	bus := s.app.GetMessageBus()
+	if bus == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Message bus not available")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		messages, err := bus.Messages(messaging.MessageQuery{
+			Participant:    q.Get("agent_id"),
+			ToAgentID:      q.Get("to_agent_id"),
+			FromAgentID:    q.Get("from_agent_id"),
+			BeadID:         q.Get("bead_id"),
+			Unacknowledged: q.Get("unacknowledged") == "true",
+			Limit:          limit,
+		})
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, messages)
+	case http.MethodPost:
+		var msg messaging.AgentMessage
+		if err := s.parseJSON(r, &msg); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if msg.Type == "" {
+			msg.Type = messaging.MessageTypeDirect
+		}
+		// Delivery state is owned by the bus
+		msg.MessageID, msg.Status = "", ""
+		msg.Timestamp = time.Time{}
+		msg.DeliveredAt, msg.ReadAt, msg.AcknowledgedAt = nil, nil, nil
+		if err := bus.Send(r.Context(), &msg); err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusCreated, msg)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleAgentMessage reads or acknowledges a single message
+// GET /api/v1/agent-messages/{id}
+// POST /api/v1/agent-messages/{id}/ack - Body: {"agent_id": "..."}
+func (s *Server) handleAgentMessage(w http.ResponseWriter, r *http.Request) {
+	bus := s.app.GetMessageBus()
+	if bus == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Message bus not available")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/agent-messages/"), "/"), "/")
+	id := parts[0]
+	if id == "" {
+		s.respondError(w, http.StatusBadRequest, "Message ID is required")
+		return
+	}
+	if len(parts) > 1 {
+		if parts[1] != "ack" {
+			s.respondError(w, http.StatusNotFound, "Unknown action")
+			return
+		}
+		if r.Method != http.MethodPost {
+			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		var req struct {
+			AgentID string `json:"agent_id"`
+		}
+		if err := s.parseJSON(r, &req); err != nil || req.AgentID == "" {
+			s.respondError(w, http.StatusBadRequest, "agent_id is required")
+			return
+		}
+		msg, err := bus.Acknowledge(id, req.AgentID)
+		if err != nil {
+			status := http.StatusBadRequest
+			if strings.Contains(err.Error(), "not found") {
+				status = http.StatusNotFound
+			}
+			s.respondError(w, status, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, msg)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	msg, err := bus.GetMessage(id)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, msg)
+}
+
+// handleAgentInbox returns the messages addressed to an agent and marks
+// them delivered
+// GET /api/v1/agents/{id}/inbox?bead_id=...&unacknowledged=true&limit=100
+func (s *Server) handleAgentInbox(w http.ResponseWriter, r *http.Request, agentID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	bus := s.app.GetMessageBus()
+	if bus == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Message bus not available")
+		return
+	}
+
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	messages, err := bus.Inbox(agentID, q.Get("bead_id"), q.Get("unacknowledged") == "true", limit)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, messages)
+}
+
+// handleAgentMessageStream streams agent messages over SSE
+// GET /api/v1/agent-messages/stream?agent_id=...&bead_id=...
+func (s *Server) handleAgentMessageStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	bus := s.app.GetMessageBus()
+	if bus == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Message bus not available")
+		return
+	}
+
+	// Disable write timeout for SSE - the server's WriteTimeout (30s default)
+	// would kill long-running streams.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	agentID := r.URL.Query().Get("agent_id")
+	filter := messaging.MessageFilter{BeadID: r.URL.Query().Get("bead_id")}
+	if agentID != "" {
+		filter.ToAgentID = agentID
+	}
+
+	subscriptionID := fmt.Sprintf("sse-agent-messages-%d", time.Now().UnixNano())
+	sub := bus.Subscribe(subscriptionID, agentID, filter)
+	defer bus.Unsubscribe(subscriptionID)
+
+	fmt.Fprintf(w, "event: connected\n")
+	fmt.Fprintf(w, "data: {\"message\": \"Connected to agent message stream\"}\n\n")
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-sub.Channel:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\n", msg.MessageID)
+			fmt.Fprintf(w, "event: %s\n", msg.Type)
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		case <-time.After(30 * time.Second):
+			fmt.Fprintf(w, ": keepalive\n\n")
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+	}
+}
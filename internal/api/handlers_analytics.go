@@ -15,14 +15,14 @@ import (
 // handleGetLogs handles GET /api/v1/analytics/logs
 func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID := auth.GetUserIDFromRequest(r)
 	// If auth is disabled, allow access with empty userID (show all logs)
 	if userID == "" && s.config.Security.EnableAuth {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		s.respondError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -50,27 +50,27 @@ func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 
 	logs, err := s.analyticsLogger.GetLogs(r.Context(), filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(logs); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleGetLogStats handles GET /api/v1/analytics/stats
 func (s *Server) handleGetLogStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID := auth.GetUserIDFromRequest(r)
 	// If auth is disabled, allow access with empty userID (show all stats)
 	if userID == "" && s.config.Security.EnableAuth {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		s.respondError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -102,27 +102,27 @@ func (s *Server) handleGetLogStats(w http.ResponseWriter, r *http.Request) {
 
 	stats, err := s.analyticsLogger.GetStats(r.Context(), filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(stats); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleExportLogs handles GET /api/v1/analytics/export
 func (s *Server) handleExportLogs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID := auth.GetUserIDFromRequest(r)
 	// If auth is disabled, allow access with empty userID (export all logs)
 	if userID == "" && s.config.Security.EnableAuth {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		s.respondError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -150,7 +150,7 @@ func (s *Server) handleExportLogs(w http.ResponseWriter, r *http.Request) {
 
 	logs, err := s.analyticsLogger.GetLogs(r.Context(), filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -163,7 +163,7 @@ func (s *Server) handleExportLogs(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("Content-Disposition", "attachment; filename=\"logs.json\"")
 		if err := json.NewEncoder(w).Encode(logs); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 		}
 	}
 }
@@ -171,14 +171,14 @@ func (s *Server) handleExportLogs(w http.ResponseWriter, r *http.Request) {
 // handleGetCostReport handles GET /api/v1/analytics/costs
 func (s *Server) handleGetCostReport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID := auth.GetUserIDFromRequest(r)
 	// If auth is disabled, allow access with empty userID (show all costs)
 	if userID == "" && s.config.Security.EnableAuth {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		s.respondError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -210,7 +210,7 @@ func (s *Server) handleGetCostReport(w http.ResponseWriter, r *http.Request) {
 
 	stats, err := s.analyticsLogger.GetStats(r.Context(), filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -241,25 +241,25 @@ func (s *Server) handleGetCostReport(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(costReport); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleGetBatchingRecommendations handles GET /api/v1/analytics/batching
 func (s *Server) handleGetBatchingRecommendations(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	if s.analyticsLogger == nil {
-		http.Error(w, "Analytics unavailable", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Analytics unavailable")
 		return
 	}
 
 	userID := auth.GetUserIDFromRequest(r)
 	if userID == "" && s.config.Security.EnableAuth {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		s.respondError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -303,7 +303,7 @@ func (s *Server) handleGetBatchingRecommendations(w http.ResponseWriter, r *http
 
 	logs, err := s.analyticsLogger.GetLogs(r.Context(), filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -325,21 +325,21 @@ func (s *Server) handleGetBatchingRecommendations(w http.ResponseWriter, r *http
 	recommendations := analytics.BuildBatchingRecommendations(logs, options)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(recommendations); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleExportStats handles GET /api/v1/analytics/export-stats
 func (s *Server) handleExportStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID := auth.GetUserIDFromRequest(r)
 	// If auth is disabled, allow access with empty userID (export all stats)
 	if userID == "" && s.config.Security.EnableAuth {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		s.respondError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -371,7 +371,7 @@ func (s *Server) handleExportStats(w http.ResponseWriter, r *http.Request) {
 
 	stats, err := s.analyticsLogger.GetStats(r.Context(), filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -402,7 +402,7 @@ func (s *Server) handleExportStats(w http.ResponseWriter, r *http.Request) {
 			"requests_by_provider": stats.RequestsByProvider,
 			"requests_by_user":     stats.RequestsByUser,
 		}); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 		}
 	}
 }
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/attachments"
+	"github.com/jordanhubbard/loom/internal/provider"
+)
+
+// handleBeadImages handles images attached to a bead, which agents on
+// providers with vision see with the task
+// GET    /api/v1/beads/{id}/images - The bead's images, without their data
+// POST   /api/v1/beads/{id}/images - Attach one: a raw image/* body (?name=), or JSON {name, media_type, data (base64 or data URL) | url}
+// GET    /api/v1/beads/{id}/images/{imageID} - The image itself
+// DELETE /api/v1/beads/{id}/images/{imageID} - Remove it
+func (s *Server) handleBeadImages(w http.ResponseWriter, r *http.Request, beadID, imageID string) {
+	mgr := s.app.GetAttachments()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Attachments not available")
+		return
+	}
+	if imageID != "" {
+		s.handleBeadImage(w, r, mgr, beadID, imageID)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		images, err := mgr.List(beadID)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if images == nil {
+			images = []*attachments.Image{}
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"bead_id": beadID, "images": images})
+
+	case http.MethodPost:
+		if bm := s.app.GetBeadsManager(); bm != nil {
+			if _, err := bm.GetBead(beadID); err != nil {
+				s.respondError(w, http.StatusNotFound, "Bead not found")
+				return
+			}
+		}
+		var img *attachments.Image
+		var err error
+		if contentType := r.Header.Get("Content-Type"); strings.HasPrefix(contentType, "image/") {
+			data, readErr := io.ReadAll(io.LimitReader(r.Body, attachments.MaxImageBytes+1))
+			if readErr != nil {
+				s.respondError(w, http.StatusBadRequest, "Failed to read image")
+				return
+			}
+			mediaType, _, _ := strings.Cut(contentType, ";")
+			img, err = mgr.Attach(beadID, r.URL.Query().Get("name"), mediaType, data, attachments.SourceAPI)
+		} else {
+			var req struct {
+				Name      string `json:"name"`
+				MediaType string `json:"media_type"`
+				Data      string `json:"data"`
+				URL       string `json:"url"`
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, attachments.MaxImageBytes*2)
+			if err := s.parseJSON(r, &req); err != nil {
+				s.respondError(w, http.StatusBadRequest, "Invalid request body")
+				return
+			}
+			switch {
+			case req.URL != "" && req.Data != "":
+				s.respondError(w, http.StatusBadRequest, "Give data or url, not both")
+				return
+			case req.URL != "":
+				img, err = mgr.AttachURL(beadID, req.Name, req.URL, attachments.SourceAPI)
+			case req.Data != "":
+				payload := req.Data
+				if mediaType, p, ok := provider.ParseDataURL(req.Data); ok {
+					payload = p
+					if req.MediaType == "" {
+						req.MediaType = mediaType
+					}
+				}
+				data, decodeErr := base64.StdEncoding.DecodeString(payload)
+				if decodeErr != nil {
+					s.respondError(w, http.StatusBadRequest, "data must be base64")
+					return
+				}
+				img, err = mgr.Attach(beadID, req.Name, req.MediaType, data, attachments.SourceAPI)
+			default:
+				s.respondError(w, http.StatusBadRequest, "data or url is required")
+				return
+			}
+		}
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusCreated, img)
+
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (s *Server) handleBeadImage(w http.ResponseWriter, r *http.Request, mgr *attachments.Manager, beadID, imageID string) {
+	img, err := mgr.Get(imageID)
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	if img.BeadID != beadID {
+		s.respondError(w, http.StatusNotFound, "Image not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if img.URL != "" {
+			http.Redirect(w, r, img.URL, http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", img.MediaType)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(img.Data)
+	case http.MethodDelete:
+		if err := mgr.Delete(imageID); err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]string{"status": "deleted", "id": imageID})
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleBeadAudio handles the voice memos a bead was filed from
+// GET /api/v1/beads/{id}/audio - The bead's recordings and transcripts, without their data
+// GET /api/v1/beads/{id}/audio/{audioID} - The recording itself
+func (s *Server) handleBeadAudio(w http.ResponseWriter, r *http.Request, beadID, audioID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	mgr := s.app.GetAttachments()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Attachments not available")
+		return
+	}
+
+	if audioID == "" {
+		audio, err := mgr.ListAudio(beadID)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if audio == nil {
+			audio = []*attachments.Audio{}
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"bead_id": beadID, "audio": audio})
+		return
+	}
+
+	audio, err := mgr.GetAudio(audioID)
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	if audio.BeadID != beadID {
+		s.respondError(w, http.StatusNotFound, "Audio not found")
+		return
+	}
+	w.Header().Set("Content-Type", audio.MediaType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": audio.Name}))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(audio.Data)
+}
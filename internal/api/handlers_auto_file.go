@@ -24,7 +24,7 @@ type AutoFileBugRequest struct {
 // HandleAutoFileBug handles automatic bug report filing
 func (s *Server) HandleAutoFileBug(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -123,7 +123,7 @@ func (s *Server) HandleAutoFileBug(w http.ResponseWriter, r *http.Request) {
 	//
 	// Old code (disabled):
 	// if err := s.assignToQAEngineer(bead.ID, projectID); err != nil {
-	// 	fmt.Printf("[WARN] Failed to assign auto-filed bead %s to QA Engineer: %v\n", bead.ID, err)
+	// 	httpLog.Warn("Failed to assign auto-filed bead to QA Engineer", "bead_id", bead.ID, "error", err)
 	// }
 
 	// Add tags
@@ -131,7 +131,7 @@ func (s *Server) HandleAutoFileBug(w http.ResponseWriter, r *http.Request) {
 		"tags": []string{"auto-filed", req.Source, req.ErrorType},
 	}
 	if _, err := s.app.UpdateBead(bead.ID, updates); err != nil {
-		fmt.Printf("[WARN] Failed to update tags for bead %s: %v\n", bead.ID, err)
+		httpLog.Warn("Failed to update tags of auto-filed bead", "bead_id", bead.ID, "error", err)
 	}
 
 	s.respondJSON(w, http.StatusCreated, map[string]interface{}{
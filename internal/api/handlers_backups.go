@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/auth"
+)
+
+// handleBackups lists and takes database backups
+// GET /api/v1/backups - List stored backups, newest first, with the last run
+// POST /api/v1/backups - Admin only: take a backup now and apply the retention policy
+func (s *Server) handleBackups(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost && auth.GetRoleFromRequest(r) != "admin" {
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
+		return
+	}
+	if s.app == nil || s.app.GetBackupManager() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Backups not enabled")
+		return
+	}
+	mgr := s.app.GetBackupManager()
+
+	switch r.Method {
+	case http.MethodGet:
+		backups, err := mgr.List(r.Context())
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp := map[string]interface{}{"backups": backups}
+		if lastRun, lastErr := mgr.LastRun(); !lastRun.IsZero() {
+			resp["last_run"] = lastRun
+			if lastErr != nil {
+				resp["last_error"] = lastErr.Error()
+			}
+		}
+		s.respondJSON(w, http.StatusOK, resp)
+	case http.MethodPost:
+		info, err := mgr.Run(r.Context())
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusCreated, info)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleBackup verifies a stored backup
+// POST /api/v1/backups/{name}/verify - Admin only: check checksums and database integrity
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if auth.GetRoleFromRequest(r) != "admin" {
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
+		return
+	}
+	if s.app == nil || s.app.GetBackupManager() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Backups not enabled")
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/backups/"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "verify" {
+		s.respondError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	manifest, err := s.app.GetBackupManager().Verify(r.Context(), parts[0])
+	if err != nil {
+		s.respondJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"name": parts[0], "valid": false, "error": err.Error()})
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{"name": parts[0], "valid": true, "manifest": manifest})
+}
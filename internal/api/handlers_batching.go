@@ -0,0 +1,73 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jordanhubbard/loom/internal/provider"
+)
+
+// EmbeddingsRequest asks for the embeddings of one or more texts
+type EmbeddingsRequest struct {
+	ProviderID string   `json:"provider_id"`
+	Model      string   `json:"model,omitempty"`
+	Input      []string `json:"input"`
+}
+
+// handleEmbeddings handles POST /api/v1/embeddings. Requests arriving
+// together for the same provider and model are embedded in one provider
+// call when request batching is on.
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil || s.app.GetProviderRegistry() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Provider registry not available")
+		return
+	}
+	var req EmbeddingsRequest
+	if err := s.parseJSON(r, &req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.ProviderID == "" || len(req.Input) == 0 {
+		s.respondError(w, http.StatusBadRequest, "provider_id and input are required")
+		return
+	}
+
+	var embeddings [][]float32
+	var err error
+	if batcher := s.app.GetBatcher(); batcher != nil {
+		embeddings, err = batcher.Embed(r.Context(), req.ProviderID, req.Model, req.Input)
+	} else {
+		var resp *provider.EmbeddingResponse
+		resp, err = s.app.GetProviderRegistry().SendEmbeddings(r.Context(), req.ProviderID, &provider.EmbeddingRequest{Model: req.Model, Input: req.Input})
+		if resp != nil {
+			embeddings = resp.Embeddings
+		}
+	}
+	if err != nil {
+		s.respondError(w, http.StatusBadGateway, fmt.Sprintf("Provider error: %v", err))
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"provider_id": req.ProviderID,
+		"model":       req.Model,
+		"embeddings":  embeddings,
+	})
+}
+
+// handleRequestBatching handles GET /api/v1/request-batching, reporting how
+// many requests were batched and the provider calls that saved
+func (s *Server) handleRequestBatching(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Request batching not available")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, s.app.GetBatcher().Stats())
+}
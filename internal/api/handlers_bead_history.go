@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// handleBeadHistory returns a bead's event history
+// GET /api/v1/beads/{id}/history - Every event, oldest first
+// GET /api/v1/beads/{id}/history?at=RFC3339 - The bead as it was at that time, with the events that produced it
+func (s *Server) handleBeadHistory(w http.ResponseWriter, r *http.Request, beadID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	bm := s.app.GetBeadsManager()
+	if bm == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Beads manager not available")
+		return
+	}
+
+	at := r.URL.Query().Get("at")
+	if at == "" {
+		events, err := bm.History(beadID)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"bead_id": beadID, "events": events})
+		return
+	}
+
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "at must be an RFC3339 time")
+		return
+	}
+	bead, events, err := bm.Replay(beadID, t)
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"bead_id": beadID,
+		"at":      t,
+		"bead":    bead,
+		"events":  events,
+	})
+}
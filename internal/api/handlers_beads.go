@@ -1,11 +1,14 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/beads"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
@@ -29,6 +32,24 @@ func (s *Server) handleBeads(w http.ResponseWriter, r *http.Request) {
 		if beadType != "" {
 			filters["type"] = beadType
 		}
+		if selector := r.URL.Query().Get("labels"); selector != "" {
+			sel, err := beads.ParseLabelSelector(selector)
+			if err != nil {
+				s.respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			filters["labels"] = sel
+		}
+		for key, values := range r.URL.Query() {
+			if name, ok := strings.CutPrefix(key, "field."); ok && len(values) > 0 {
+				fields, _ := filters["fields"].(map[string]string)
+				if fields == nil {
+					fields = make(map[string]string)
+					filters["fields"] = fields
+				}
+				fields[name] = values[0]
+			}
+		}
 		if assignedTo != "" {
 			if strings.Contains(assignedTo, ",") {
 				parts := strings.Split(assignedTo, ",")
@@ -57,24 +78,61 @@ func (s *Server) handleBeads(w http.ResponseWriter, r *http.Request) {
 
 	case http.MethodPost:
 		var req struct {
-			Type        string            `json:"type"`
-			Title       string            `json:"title"`
-			Description string            `json:"description"`
-			Priority    int               `json:"priority"`
-			ProjectID   string            `json:"project_id"`
-			Parent      string            `json:"parent"`
-			Tags        []string          `json:"tags"`
-			Context     map[string]string `json:"context"`
+			Type        string                 `json:"type"`
+			Title       string                 `json:"title"`
+			Description string                 `json:"description"`
+			Priority    int                    `json:"priority"`
+			ProjectID   string                 `json:"project_id"`
+			Parent      string                 `json:"parent"`
+			Tags        []string               `json:"tags"`
+			Context     map[string]string      `json:"context"`
+			Labels      []string               `json:"labels"`   // "key" or "key=value"
+			DueDate     *string                `json:"due_date"` // RFC 3339
+			DueIn       *string                `json:"due_in"`   // Duration after creation, e.g. "48h"
+			Fields      map[string]interface{} `json:"fields"`   // Custom field values
+			Tracker     *trackerLinkRequest    `json:"tracker"`  // Issue to link; fills an empty title and description
 		}
 		if err := s.parseJSON(r, &req); err != nil {
 			s.respondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
+		labels, err := beads.ParseLabels(req.Labels)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if _, _, err := parseDueDate(req.DueDate, req.DueIn, time.Now()); err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if req.Tracker != nil {
+			if s.app.GetTracker() == nil {
+				s.respondError(w, http.StatusServiceUnavailable, "Issue tracker sync not available")
+				return
+			}
+			issue, err := s.app.GetTracker().Lookup(r.Context(), req.Tracker.Provider, req.Tracker.Key)
+			if err != nil {
+				s.respondAppError(w, err)
+				return
+			}
+			if req.Title == "" {
+				req.Title = issue.Title
+			}
+			if req.Description == "" {
+				req.Description = issue.Description
+			}
+		}
 
 		if req.Title == "" || req.ProjectID == "" {
 			s.respondError(w, http.StatusBadRequest, "title and project_id are required")
 			return
 		}
+		fields, err := s.app.GetCustomFields().Validate(req.ProjectID, req.Fields, true)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
 
 		if req.Type == "" {
 			req.Type = "task"
@@ -88,6 +146,32 @@ func (s *Server) handleBeads(w http.ResponseWriter, r *http.Request) {
 			s.respondError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+		updates := make(map[string]interface{})
+		if due, set, _ := parseDueDate(req.DueDate, req.DueIn, bead.CreatedAt); set && due != nil {
+			updates["due_date"] = due
+		}
+		if len(labels) > 0 {
+			updates["labels"] = labels
+		}
+		if len(fields) > 0 {
+			updates["fields"] = fields
+		}
+		if len(updates) > 0 {
+			if bead, err = s.app.UpdateBead(bead.ID, updates); err != nil {
+				s.respondAppError(w, err)
+				return
+			}
+		}
+		if req.Tracker != nil {
+			if _, err := s.app.GetTracker().Link(r.Context(), bead.ID, req.Tracker.Provider, req.Tracker.Key); err != nil {
+				s.respondAppError(w, err)
+				return
+			}
+			if bead, err = s.app.GetBeadsManager().GetBead(bead.ID); err != nil {
+				s.respondError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
 
 		s.respondJSON(w, http.StatusCreated, bead)
 
@@ -114,6 +198,70 @@ func (s *Server) handleBead(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Handle /context, /context/stream and /context/presence endpoints
+	if len(parts) > 1 && parts[1] == "context" {
+		s.handleBeadContext(w, r)
+		return
+	}
+
+	// Handle /history endpoint
+	if len(parts) > 1 && parts[1] == "history" {
+		s.handleBeadHistory(w, r, id)
+		return
+	}
+
+	// Handle /estimate endpoint
+	if len(parts) > 1 && parts[1] == "estimate" {
+		s.handleBeadEstimate(w, r, id)
+		return
+	}
+
+	// Handle /images and /images/{imageID} endpoints
+	if len(parts) > 1 && parts[1] == "images" {
+		imageID := ""
+		if len(parts) > 2 {
+			imageID = parts[2]
+		}
+		s.handleBeadImages(w, r, id, imageID)
+		return
+	}
+
+	// Handle /audio and /audio/{audioID} endpoints
+	if len(parts) > 1 && parts[1] == "audio" {
+		audioID := ""
+		if len(parts) > 2 {
+			audioID = parts[2]
+		}
+		s.handleBeadAudio(w, r, id, audioID)
+		return
+	}
+
+	// Handle /profiles and /profiles/{profileID} endpoints
+	if len(parts) > 1 && parts[1] == "profiles" {
+		profileID := ""
+		if len(parts) > 2 {
+			profileID = parts[2]
+		}
+		s.handleBeadProfiles(w, r, id, profileID)
+		return
+	}
+
+	// Handle /ci endpoint
+	if len(parts) > 1 && parts[1] == "ci" {
+		s.handleBeadCI(w, r, id)
+		return
+	}
+
+	// Handle /tracker and /tracker/sync endpoints
+	if len(parts) > 1 && parts[1] == "tracker" {
+		action := ""
+		if len(parts) > 2 {
+			action = parts[2]
+		}
+		s.handleBeadTracker(w, r, id, action)
+		return
+	}
+
 	// Handle /claim endpoint
 	if len(parts) > 1 && parts[1] == "claim" {
 		if r.Method != http.MethodPost {
@@ -135,11 +283,7 @@ func (s *Server) handleBead(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if err := s.app.ClaimBead(id, req.AgentID); err != nil {
-			if strings.Contains(err.Error(), "already claimed") {
-				s.respondError(w, http.StatusConflict, err.Error())
-			} else {
-				s.respondError(w, http.StatusInternalServerError, err.Error())
-			}
+			s.respondAppError(w, err)
 			return
 		}
 
@@ -207,24 +351,29 @@ func (s *Server) handleBead(w http.ResponseWriter, r *http.Request) {
 			s.respondError(w, http.StatusNotFound, "Bead not found")
 			return
 		}
+		w.Header().Set("ETag", beadETag(bead))
 		s.respondJSON(w, http.StatusOK, bead)
 
 	case http.MethodPatch:
 		var req struct {
-			Title       *string           `json:"title"`
-			Type        *string           `json:"type"`
-			Status      *string           `json:"status"`
-			Priority    *int              `json:"priority"`
-			ProjectID   *string           `json:"project_id"`
-			AssignedTo  *string           `json:"assigned_to"`
-			Description *string           `json:"description"`
-			Parent      *string           `json:"parent"`
-			Tags        *[]string         `json:"tags"`
-			BlockedBy   *[]string         `json:"blocked_by"`
-			Blocks      *[]string         `json:"blocks"`
-			RelatedTo   *[]string         `json:"related_to"`
-			Children    *[]string         `json:"children"`
-			Context     map[string]string `json:"context"`
+			Title       *string                `json:"title"`
+			Type        *string                `json:"type"`
+			Status      *string                `json:"status"`
+			Priority    *int                   `json:"priority"`
+			ProjectID   *string                `json:"project_id"`
+			AssignedTo  *string                `json:"assigned_to"`
+			Description *string                `json:"description"`
+			Parent      *string                `json:"parent"`
+			Tags        *[]string              `json:"tags"`
+			BlockedBy   *[]string              `json:"blocked_by"`
+			Blocks      *[]string              `json:"blocks"`
+			RelatedTo   *[]string              `json:"related_to"`
+			Children    *[]string              `json:"children"`
+			Context     map[string]string      `json:"context"`
+			Labels      *[]string              `json:"labels"`   // Replaces the bead's labels
+			DueDate     *string                `json:"due_date"` // RFC 3339; "" clears it
+			DueIn       *string                `json:"due_in"`   // Duration after the bead's creation
+			Fields      map[string]interface{} `json:"fields"`   // Merged into custom fields; null clears one
 		}
 		if err := s.parseJSON(r, &req); err != nil {
 			s.respondError(w, http.StatusBadRequest, "Invalid request body")
@@ -232,6 +381,50 @@ func (s *Server) handleBead(w http.ResponseWriter, r *http.Request) {
 		}
 
 		updates := make(map[string]interface{})
+		if req.Labels != nil {
+			labels, err := beads.ParseLabels(*req.Labels)
+			if err != nil {
+				s.respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			updates["labels"] = labels
+		}
+		if len(req.Fields) > 0 {
+			projectID := ""
+			if req.ProjectID != nil {
+				projectID = *req.ProjectID
+			} else {
+				bead, err := s.app.GetBeadsManager().GetBead(id)
+				if err != nil {
+					s.respondError(w, http.StatusNotFound, "Bead not found")
+					return
+				}
+				projectID = bead.ProjectID
+			}
+			fields, err := s.app.GetCustomFields().Validate(projectID, req.Fields, false)
+			if err != nil {
+				s.respondAppError(w, err)
+				return
+			}
+			updates["fields"] = fields
+		}
+		if req.DueDate != nil || req.DueIn != nil {
+			var created time.Time
+			if req.DueIn != nil {
+				bead, err := s.app.GetBeadsManager().GetBead(id)
+				if err != nil {
+					s.respondError(w, http.StatusNotFound, "Bead not found")
+					return
+				}
+				created = bead.CreatedAt
+			}
+			due, _, err := parseDueDate(req.DueDate, req.DueIn, created)
+			if err != nil {
+				s.respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			updates["due_date"] = due
+		}
 		if req.Title != nil {
 			updates["title"] = *req.Title
 		}
@@ -275,11 +468,42 @@ func (s *Server) handleBead(w http.ResponseWriter, r *http.Request) {
 			updates["context"] = req.Context
 		}
 
-		bead, err := s.app.UpdateBead(id, updates)
+		// Updates must name the version they were based on so concurrent
+		// writers can't silently overwrite each other; "*" opts out
+		ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+		if ifMatch == "" {
+			s.respondError(w, http.StatusPreconditionRequired, "If-Match header with the bead's ETag is required")
+			return
+		}
+		var bead *models.Bead
+		var err error
+		if ifMatch == "*" {
+			bead, err = s.app.UpdateBead(id, updates)
+		} else {
+			version, ok := parseETag(ifMatch)
+			if !ok {
+				s.respondError(w, http.StatusBadRequest, "Invalid If-Match header")
+				return
+			}
+			bead, err = s.app.UpdateBeadIfVersion(id, version, updates)
+		}
 		if err != nil {
-			s.respondError(w, http.StatusInternalServerError, err.Error())
+			var conflict *beads.ConflictError
+			switch {
+			case errors.As(err, &conflict):
+				w.Header().Set("ETag", beadETag(conflict.Current))
+				s.respondJSON(w, http.StatusConflict, map[string]interface{}{
+					"code":    apperr.CodeConflict,
+					"message": conflict.Error(),
+					"error":   conflict.Error(),
+					"current": conflict.Current,
+				})
+			default:
+				s.respondAppError(w, err)
+			}
 			return
 		}
+		w.Header().Set("ETag", beadETag(bead))
 		s.respondJSON(w, http.StatusOK, bead)
 
 	default:
@@ -287,6 +511,25 @@ func (s *Server) handleBead(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// beadETag formats a bead's version as a strong entity tag
+func beadETag(bead *models.Bead) string {
+	return `"` + strconv.FormatInt(bead.Version, 10) + `"`
+}
+
+// parseETag reads the bead version from an If-Match value. Weak tags are
+// accepted since bead versions are exact either way.
+func parseETag(tag string) (int64, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
 // handleDecisions handles GET /api/v1/decisions
 func (s *Server) handleDecisions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -402,11 +645,7 @@ func (s *Server) handleFileLocks(w http.ResponseWriter, r *http.Request) {
 
 		lock, err := s.app.RequestFileAccess(req.ProjectID, req.FilePath, req.AgentID, req.BeadID)
 		if err != nil {
-			if strings.Contains(err.Error(), "already locked") {
-				s.respondError(w, http.StatusConflict, err.Error())
-			} else {
-				s.respondError(w, http.StatusInternalServerError, err.Error())
-			}
+			s.respondAppError(w, err)
 			return
 		}
 
@@ -418,6 +657,8 @@ func (s *Server) handleFileLocks(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleFileLock handles DELETE /api/v1/file-locks/{project_id}/{path}
+// ?agent_id= releases the agent's own lock; ?force=true releases the lock
+// whoever holds it and returns the released lock
 func (s *Server) handleFileLock(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
@@ -435,6 +676,20 @@ func (s *Server) handleFileLock(w http.ResponseWriter, r *http.Request) {
 	projectID := parts[0]
 	filePath := parts[1]
 
+	if r.URL.Query().Get("force") == "true" {
+		releasedBy := "api"
+		if user := s.getUserFromContext(r); user != nil {
+			releasedBy = user.Username
+		}
+		lock, err := s.app.ForceReleaseFileAccess(projectID, filePath, releasedBy)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, lock)
+		return
+	}
+
 	// Get agent ID from request (could be from body or query)
 	agentID := r.URL.Query().Get("agent_id")
 	if agentID == "" {
@@ -12,20 +12,20 @@ import (
 // handleGetCacheStats handles GET /api/v1/cache/stats
 func (s *Server) handleGetCacheStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Authentication required
 	userID := auth.GetUserIDFromRequest(r)
 	if userID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		s.respondError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Get cache stats
 	if s.cache == nil {
-		http.Error(w, "Cache not initialized", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Cache not initialized")
 		return
 	}
 
@@ -33,27 +33,27 @@ func (s *Server) handleGetCacheStats(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(stats); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleGetCacheConfig handles GET /api/v1/cache/config
 func (s *Server) handleGetCacheConfig(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Authentication required (admin only for config)
 	role := auth.GetRoleFromRequest(r)
 	if role != "admin" {
-		http.Error(w, "Forbidden: admin access required", http.StatusForbidden)
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
 		return
 	}
 
 	// Return current cache configuration
 	if s.config == nil || s.cache == nil {
-		http.Error(w, "Cache not configured", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Cache not configured")
 		return
 	}
 
@@ -67,26 +67,26 @@ func (s *Server) handleGetCacheConfig(w http.ResponseWriter, r *http.Request) {
 		"max_memory_mb":  cacheConfig.MaxMemoryMB,
 		"cleanup_period": cacheConfig.CleanupPeriod.String(),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleClearCache handles POST /api/v1/cache/clear
 func (s *Server) handleClearCache(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Authentication required (admin only)
 	role := auth.GetRoleFromRequest(r)
 	if role != "admin" {
-		http.Error(w, "Forbidden: admin access required", http.StatusForbidden)
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
 		return
 	}
 
 	if s.cache == nil {
-		http.Error(w, "Cache not initialized", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Cache not initialized")
 		return
 	}
 
@@ -98,26 +98,26 @@ func (s *Server) handleClearCache(w http.ResponseWriter, r *http.Request) {
 		"success": true,
 		"message": "Cache cleared successfully",
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleInvalidateCache handles POST /api/v1/cache/invalidate
 func (s *Server) handleInvalidateCache(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Authentication required (admin only)
 	role := auth.GetRoleFromRequest(r)
 	if role != "admin" {
-		http.Error(w, "Forbidden: admin access required", http.StatusForbidden)
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
 		return
 	}
 
 	if s.cache == nil {
-		http.Error(w, "Cache not initialized", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Cache not initialized")
 		return
 	}
 
@@ -128,7 +128,7 @@ func (s *Server) handleInvalidateCache(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
 		return
 	}
 
@@ -145,7 +145,7 @@ func (s *Server) handleInvalidateCache(w http.ResponseWriter, r *http.Request) {
 	case "age":
 		duration, err := time.ParseDuration(req.Value)
 		if err != nil {
-			http.Error(w, "Invalid age duration: "+err.Error(), http.StatusBadRequest)
+			s.respondError(w, http.StatusBadRequest, "Invalid age duration: "+err.Error())
 			return
 		}
 		removed = s.cache.InvalidateByAge(r.Context(), duration)
@@ -154,7 +154,7 @@ func (s *Server) handleInvalidateCache(w http.ResponseWriter, r *http.Request) {
 		removed = s.cache.InvalidateByPattern(r.Context(), req.Value)
 		invalidationType = "pattern: " + req.Value
 	default:
-		http.Error(w, "Invalid invalidation type. Use: provider, model, age, or pattern", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "Invalid invalidation type. Use: provider, model, age, or pattern")
 		return
 	}
 
@@ -165,7 +165,7 @@ func (s *Server) handleInvalidateCache(w http.ResponseWriter, r *http.Request) {
 		"type":           invalidationType,
 		"invalidated_at": time.Now().Format(time.RFC3339),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
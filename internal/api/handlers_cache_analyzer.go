@@ -10,6 +10,8 @@ import (
 
 	"github.com/jordanhubbard/loom/internal/analytics"
 	"github.com/jordanhubbard/loom/internal/cache"
+	"github.com/jordanhubbard/loom/internal/features"
+	"github.com/jordanhubbard/loom/internal/memory"
 )
 
 // handleCacheAnalysis runs cache opportunity analysis
@@ -50,6 +52,35 @@ func (s *Server) handleCacheAnalysis(w http.ResponseWriter, r *http.Request) {
 		config.AutoEnable = autoEnable
 	}
 
+	if nearStr := r.URL.Query().Get("near_duplicates"); nearStr != "" {
+		near, err := strconv.ParseBool(nearStr)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid near_duplicates: %v", err))
+			return
+		}
+		config.NearDuplicates = near
+	}
+
+	if semanticStr := r.URL.Query().Get("semantic"); semanticStr != "" {
+		semantic, err := strconv.ParseBool(semanticStr)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid semantic: %v", err))
+			return
+		}
+		if semantic {
+			config.Embedder = memory.NewHashEmbedder()
+		}
+	}
+
+	if similarityStr := r.URL.Query().Get("similarity"); similarityStr != "" {
+		similarity, err := strconv.ParseFloat(similarityStr, 64)
+		if err != nil || similarity <= 0 || similarity > 1 {
+			s.respondError(w, http.StatusBadRequest, "Invalid similarity: must be in (0, 1]")
+			return
+		}
+		config.SimilarityThreshold = similarity
+	}
+
 	// Get database for analytics storage
 	db := s.app.GetDatabase()
 	if db == nil {
@@ -164,6 +195,10 @@ func (s *Server) handleCacheOptimize(w http.ResponseWriter, r *http.Request) {
 		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
 		return
 	}
+	if req.AutoEnable && !s.featureEnabled(features.CacheAutoEnable, "") {
+		s.respondError(w, http.StatusForbidden, "auto_enable is disabled (feature flag cache_auto_enable)")
+		return
+	}
 
 	// Get database for analytics storage
 	db := s.app.GetDatabase()
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"crypto/subtle"
+	"io"
+	"net/http"
+
+	"github.com/jordanhubbard/loom/internal/ci"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// handleGitLabWebhook records GitLab pipeline events for agent branches
+// POST /api/v1/webhooks/gitlab
+func (s *Server) handleGitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	s.handleCIWebhook(w, r, r.Header.Get("X-Gitlab-Token"), ci.ParseGitLabPipeline)
+}
+
+// handleJenkinsWebhook records Jenkins Notification plugin events for agent
+// branches
+// POST /api/v1/webhooks/jenkins
+func (s *Server) handleJenkinsWebhook(w http.ResponseWriter, r *http.Request) {
+	s.handleCIWebhook(w, r, r.Header.Get("X-Webhook-Token"), ci.ParseJenkinsNotification)
+}
+
+func (s *Server) handleCIWebhook(w http.ResponseWriter, r *http.Request, token string, parse func([]byte) (*models.CIRun, error)) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.config != nil && s.config.CI.WebhookSecret != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(s.config.CI.WebhookSecret)) != 1 {
+		s.respondError(w, http.StatusUnauthorized, "Invalid webhook token")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	run, err := parse(body)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.recordCIRun(w, r, run)
+}
+
+// recordCIRun attaches a webhook-reported run to its bead. Runs for
+// branches that don't belong to a bead are acknowledged and ignored.
+func (s *Server) recordCIRun(w http.ResponseWriter, r *http.Request, run *models.CIRun) {
+	if s.app == nil || s.app.GetCIManager() == nil {
+		s.respondError(w, http.StatusInternalServerError, "CI integration not configured")
+		return
+	}
+	if ci.BeadIDFromBranch(run.Branch) == "" {
+		s.respondJSON(w, http.StatusOK, map[string]string{"status": "ignored", "reason": "not an agent branch"})
+		return
+	}
+	recorded, err := s.app.GetCIManager().Record(r.Context(), run)
+	if err != nil {
+		s.respondJSON(w, http.StatusOK, map[string]string{"status": "ignored", "reason": err.Error()})
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{"status": "recorded", "run": recorded})
+}
+
+// handleBeadCI reports and watches CI runs for a bead's branch
+// GET /api/v1/beads/{id}/ci - List recorded runs, newest first
+// POST /api/v1/beads/{id}/ci - Watch a branch ({"branch": "agent/..."}) and poll it now
+func (s *Server) handleBeadCI(w http.ResponseWriter, r *http.Request, beadID string) {
+	mgr := s.app.GetCIManager()
+	if mgr == nil {
+		s.respondError(w, http.StatusInternalServerError, "CI integration not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"bead_id": beadID, "runs": mgr.Runs(beadID)})
+	case http.MethodPost:
+		var req struct {
+			Branch string `json:"branch"`
+		}
+		if err := s.parseJSON(r, &req); err != nil || req.Branch == "" {
+			s.respondError(w, http.StatusBadRequest, "branch is required")
+			return
+		}
+		bead, err := s.app.GetBeadsManager().GetBead(beadID)
+		if err != nil {
+			s.respondError(w, http.StatusNotFound, "Bead not found")
+			return
+		}
+		mgr.Watch(bead.ProjectID, bead.ID, req.Branch)
+		run, err := mgr.Status(r.Context(), bead.ProjectID, bead.ID, req.Branch)
+		if err != nil {
+			s.respondJSON(w, http.StatusAccepted, map[string]interface{}{"status": "watching", "branch": req.Branch, "error": err.Error()})
+			return
+		}
+		s.respondJSON(w, http.StatusAccepted, map[string]interface{}{"status": "watching", "branch": req.Branch, "run": run})
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
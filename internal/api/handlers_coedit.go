@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/collaboration"
+)
+
+// coEditRequest is the body of the co-editing POST endpoints
+type coEditRequest struct {
+	ProjectID string             `json:"project_id"`
+	Path      string             `json:"path"`
+	Site      string             `json:"site"`
+	Ops       []collaboration.Op `json:"ops,omitempty"`
+	Position  int                `json:"position,omitempty"`
+	Delete    int                `json:"delete,omitempty"`
+	Insert    string             `json:"insert,omitempty"`
+	Save      *bool              `json:"save,omitempty"`
+}
+
+// handleCoEdit handles shared editing buffers for files
+// GET  /api/v1/coedit?project_id= - Files being co-edited
+// GET  /api/v1/coedit/document?project_id=&path=&since= - Snapshot with the operations after since
+// GET  /api/v1/coedit/stream?project_id=&path=&site=&since= - SSE stream of operations
+// POST /api/v1/coedit/open - Join (or start) co-editing a file
+// POST /api/v1/coedit/ops - Apply CRDT operations from a site
+// POST /api/v1/coedit/edit - Replace delete characters at position with insert
+// POST /api/v1/coedit/save - Write the buffer to the file
+// POST /api/v1/coedit/close - Leave; the last site out saves the buffer unless save is false
+func (s *Server) handleCoEdit(w http.ResponseWriter, r *http.Request) {
+	store := s.app.GetCoEditStore()
+	if store == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Co-editing not available")
+		return
+	}
+	action := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/coedit"), "/")
+
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		switch action {
+		case "":
+			s.respondJSON(w, http.StatusOK, map[string]interface{}{"documents": store.List(q.Get("project_id"))})
+		case "document":
+			since, _ := strconv.Atoi(q.Get("since"))
+			snapshot, err := store.Snapshot(q.Get("project_id"), q.Get("path"), since)
+			if err != nil {
+				s.respondError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			s.respondJSON(w, http.StatusOK, snapshot)
+		case "stream":
+			collaboration.ServeDocumentStream(store, w, r)
+		default:
+			s.respondError(w, http.StatusNotFound, "Unknown co-editing endpoint")
+		}
+		return
+	}
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req coEditRequest
+	if err := s.parseJSON(r, &req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.ProjectID == "" || req.Path == "" {
+		s.respondError(w, http.StatusBadRequest, "project_id and path are required")
+		return
+	}
+
+	switch action {
+	case "open":
+		if s.fileManager == nil {
+			s.respondError(w, http.StatusInternalServerError, "file manager not configured")
+			return
+		}
+		snapshot, err := store.Open(req.ProjectID, req.Path, req.Site, func() (string, error) {
+			res, err := s.fileManager.ReadFile(r.Context(), req.ProjectID, req.Path)
+			if err != nil {
+				return "", err
+			}
+			return res.Content, nil
+		})
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, snapshot)
+
+	case "ops":
+		update, err := store.ApplyOps(req.ProjectID, req.Path, req.Site, req.Ops)
+		if err != nil {
+			s.respondAppError(w, apperr.Validation("%s", err.Error()))
+			return
+		}
+		s.respondJSON(w, http.StatusOK, update)
+
+	case "edit":
+		if req.Site == "" {
+			s.respondError(w, http.StatusBadRequest, "site is required")
+			return
+		}
+		update, err := store.Edit(req.ProjectID, req.Path, req.Site, req.Position, req.Delete, req.Insert)
+		if err != nil {
+			s.respondAppError(w, apperr.Validation("%s", err.Error()))
+			return
+		}
+		s.respondJSON(w, http.StatusOK, update)
+
+	case "save":
+		text, ok := store.Text(req.ProjectID, req.Path)
+		if !ok {
+			s.respondError(w, http.StatusNotFound, req.Path+" is not being co-edited")
+			return
+		}
+		s.saveCoEdited(w, r, req.ProjectID, req.Path, text)
+
+	case "close":
+		text, last, err := store.Close(req.ProjectID, req.Path, req.Site)
+		if err != nil {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if last && (req.Save == nil || *req.Save) {
+			s.saveCoEdited(w, r, req.ProjectID, req.Path, text)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"path": req.Path, "closed": last, "saved": false})
+
+	default:
+		s.respondError(w, http.StatusNotFound, "Unknown co-editing endpoint")
+	}
+}
+
+// saveCoEdited writes a shared buffer's text to its file
+func (s *Server) saveCoEdited(w http.ResponseWriter, r *http.Request, projectID, path, text string) {
+	if s.fileManager == nil {
+		s.respondError(w, http.StatusInternalServerError, "file manager not configured")
+		return
+	}
+	res, err := s.fileManager.WriteFile(r.Context(), projectID, path, text)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{"path": res.Path, "bytes_written": res.BytesWritten, "saved": true})
+}
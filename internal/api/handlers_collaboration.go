@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/collaboration"
+)
+
+// handleBeadContext handles the shared collaboration context for a bead
+// GET /api/v1/beads/{id}/context - Current context (agents, data, activity log)
+// GET /api/v1/beads/{id}/context/stream - SSE stream of context updates, presence changes and action progress
+// GET /api/v1/beads/{id}/context/activity - Page of the activity log (see handleBeadActivity)
+// GET /api/v1/beads/{id}/context/presence - Who is present and what they're doing
+// POST /api/v1/beads/{id}/context/presence - Presence heartbeat ({"agent_id", "status", "file", "phase"})
+// DELETE /api/v1/beads/{id}/context/presence?agent_id= - Leave
+func (s *Server) handleBeadContext(w http.ResponseWriter, r *http.Request) {
+	presence := strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/context/presence")
+	if r.Method != http.MethodGet && !presence {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	store := s.app.GetContextStore()
+	if store == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Collaboration context store not available")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/beads/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[1] != "context" {
+		s.respondError(w, http.StatusBadRequest, "Invalid path")
+		return
+	}
+	beadID := parts[0]
+
+	// Create the context on first access so clients can subscribe before
+	// an agent starts executing actions on the bead.
+	projectID := r.URL.Query().Get("project_id")
+	if bm := s.app.GetBeadsManager(); bm != nil && projectID == "" {
+		if bead, err := bm.GetBead(beadID); err == nil && bead != nil {
+			projectID = bead.ProjectID
+		}
+	}
+	if _, err := store.GetOrCreate(r.Context(), beadID, projectID); err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if presence {
+		s.handleBeadPresence(w, r, store, beadID, projectID)
+		return
+	}
+
+	if len(parts) > 2 && parts[2] == "activity" {
+		s.handleBeadActivity(w, r, store, beadID)
+		return
+	}
+
+	q := r.URL.Query()
+	q.Set("bead_id", beadID)
+	r.URL.RawQuery = q.Encode()
+
+	handler := collaboration.NewSSEHandler(store)
+	if len(parts) > 2 && parts[2] == "stream" {
+		handler.ServeHTTP(w, r)
+		return
+	}
+	handler.HandleGetContext(w, r)
+}
+
+// handleBeadPresence reads, renews or clears presence on a bead
+func (s *Server) handleBeadPresence(w http.ResponseWriter, r *http.Request, store *collaboration.ContextStore, beadID, projectID string) {
+	switch r.Method {
+	case http.MethodGet:
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{
+			"bead_id":  beadID,
+			"presence": store.ActivePresence(r.Context(), beadID),
+		})
+	case http.MethodPost:
+		var p collaboration.Presence
+		if err := s.parseJSON(r, &p); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if err := store.Heartbeat(r.Context(), beadID, projectID, p); err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{
+			"bead_id":  beadID,
+			"presence": store.ActivePresence(r.Context(), beadID),
+		})
+	case http.MethodDelete:
+		agentID := r.URL.Query().Get("agent_id")
+		if agentID == "" {
+			s.respondError(w, http.StatusBadRequest, "agent_id is required")
+			return
+		}
+		store.ClearPresence(r.Context(), beadID, agentID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleBeadActivity returns a page of a bead's activity log with counts by
+// type across all matching entries
+// Query: agent_id, activity_type (comma-separated), since, until (RFC3339),
+// cursor (next_cursor of the previous page), limit, order (desc|asc)
+func (s *Server) handleBeadActivity(w http.ResponseWriter, r *http.Request, store *collaboration.ContextStore, beadID string) {
+	params := r.URL.Query()
+	q := collaboration.ActivityQuery{
+		AgentID:   params.Get("agent_id"),
+		Ascending: params.Get("order") == "asc",
+	}
+	if types := params.Get("activity_type"); types != "" {
+		q.ActivityTypes = strings.Split(types, ",")
+	}
+	for name, dst := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := params.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				s.respondError(w, http.StatusBadRequest, name+" must be an RFC3339 time")
+				return
+			}
+			*dst = t
+		}
+	}
+	if v := params.Get("cursor"); v != "" {
+		cursor, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || cursor < 0 {
+			s.respondError(w, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+		q.Cursor = cursor
+	}
+	if v := params.Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			q.Limit = l
+		}
+	}
+
+	page, err := store.QueryActivity(r.Context(), beadID, q)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, page)
+}
@@ -11,7 +11,7 @@ import (
 // HandleExecuteCommand executes a shell command
 func (s *Server) HandleExecuteCommand(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/jordanhubbard/loom/internal/cache"
+	"github.com/jordanhubbard/loom/internal/keymanager"
 	"github.com/jordanhubbard/loom/internal/provider"
 	"github.com/jordanhubbard/loom/pkg/config"
 	"github.com/jordanhubbard/loom/pkg/models"
@@ -155,6 +157,88 @@ func TestHandleHealthDetail_MethodNotAllowed(t *testing.T) {
 	}
 }
 
+func TestHandleHealthz(t *testing.T) {
+	s := newTestServer()
+	w := httptest.NewRecorder()
+	s.handleHealthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	// Loops that haven't started don't fail liveness
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp ProbeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "ok" || resp.Components["scheduler"].Status != "unknown" || resp.Components["dispatcher"].Status != "unknown" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleHealthz(w, httptest.NewRequest(http.MethodPost, "/healthz", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestHandleReadyz_NoApp(t *testing.T) {
+	s := newTestServer()
+	s.keyManager = keymanager.NewKeyManager(filepath.Join(t.TempDir(), "keys.json"))
+	w := httptest.NewRecorder()
+	s.handleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+	var resp ProbeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "fail" {
+		t.Errorf("expected status fail, got %s", resp.Status)
+	}
+	for _, name := range []string{"database", "keystore", "providers", "scheduler", "dispatcher", "maintenance"} {
+		if _, ok := resp.Components[name]; !ok {
+			t.Errorf("expected component %s, got %v", name, resp.Components)
+		}
+	}
+	if ks := resp.Components["keystore"]; ks.Status != "unhealthy" || ks.Message != "key store is locked" {
+		t.Errorf("expected a locked key store to be unhealthy, got %+v", ks)
+	}
+}
+
+func TestLoopHealth(t *testing.T) {
+	if h := loopHealth(time.Time{}, time.Minute); h.Status != "unknown" {
+		t.Errorf("expected unknown before the first run, got %+v", h)
+	}
+	if h := loopHealth(time.Now().Add(-10*time.Second), time.Minute); h.Status != "healthy" {
+		t.Errorf("expected healthy, got %+v", h)
+	}
+	if h := loopHealth(time.Now().Add(-2*time.Minute), time.Minute); h.Status != "unhealthy" || !strings.HasPrefix(h.Message, "stalled") {
+		t.Errorf("expected stalled, got %+v", h)
+	}
+}
+
+func TestProbeProviders(t *testing.T) {
+	registry := provider.NewRegistry()
+	if h := probeProviders(context.Background(), registry); h.Status != "unknown" {
+		t.Errorf("expected unknown without providers, got %+v", h)
+	}
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	if err := registry.Register(&provider.ProviderConfig{ID: "mock-1", Type: "mock"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(&provider.ProviderConfig{ID: "down-1", Type: "openai", Endpoint: down.URL}); err != nil {
+		t.Fatal(err)
+	}
+	h := probeProviders(context.Background(), registry)
+	if h.Status != "degraded" || !strings.Contains(h.Message, "down-1") {
+		t.Errorf("expected degraded naming down-1, got %+v", h)
+	}
+}
+
 // ============================================================
 // Dependency checks (unit tests for nil paths)
 // ============================================================
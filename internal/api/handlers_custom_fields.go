@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// handleProjectFields manages the custom fields a project's beads carry
+// GET    /api/v1/projects/{id}/fields - Field definitions in display order
+// POST   /api/v1/projects/{id}/fields - Define a field ({"name", "type", "options", "required", "widget", ...})
+// GET    /api/v1/projects/{id}/fields/{name} - One field definition
+// PUT    /api/v1/projects/{id}/fields/{name} - Replace a field definition
+// DELETE /api/v1/projects/{id}/fields/{name} - Remove it; values on beads are kept
+func (s *Server) handleProjectFields(w http.ResponseWriter, r *http.Request, projectID string, parts []string) {
+	registry := s.app.GetCustomFields()
+	if registry == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Custom fields not available")
+		return
+	}
+	if _, err := s.app.GetProjectManager().GetProject(projectID); err != nil {
+		s.respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	name := strings.Trim(strings.Join(parts, "/"), "/")
+	if name == "" {
+		switch r.Method {
+		case http.MethodGet:
+			s.respondJSON(w, http.StatusOK, registry.List(projectID))
+		case http.MethodPost:
+			var def models.BeadFieldDefinition
+			if err := s.parseJSON(r, &def); err != nil {
+				s.respondError(w, http.StatusBadRequest, "Invalid request body")
+				return
+			}
+			if registry.Get(projectID, def.Name) != nil {
+				s.respondError(w, http.StatusConflict, "Field already defined: "+def.Name)
+				return
+			}
+			def.ProjectID = projectID
+			created, err := registry.Define(&def)
+			if err != nil {
+				s.respondAppError(w, err)
+				return
+			}
+			s.respondJSON(w, http.StatusCreated, created)
+		default:
+			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		def := registry.Get(projectID, name)
+		if def == nil {
+			s.respondError(w, http.StatusNotFound, "Field not found: "+name)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, def)
+	case http.MethodPut:
+		var def models.BeadFieldDefinition
+		if err := s.parseJSON(r, &def); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		def.ProjectID, def.Name = projectID, name
+		updated, err := registry.Define(&def)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, updated)
+	case http.MethodDelete:
+		if err := registry.Delete(projectID, name); err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/dashboard"
+)
+
+// handleDashboard handles GET /api/v1/dashboard, returning the Web UI's
+// aggregate views in one response.
+//
+// Query parameters: project_id, sections (comma-separated agents, burndown,
+// costs, anomalies, escalations; default all) and days (burn-down and cost
+// window, 1-90, default 14).
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil || s.app.GetDashboard() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Dashboard not available")
+		return
+	}
+
+	params := r.URL.Query()
+	opts := dashboard.Options{ProjectID: params.Get("project_id")}
+	if v := params.Get("sections"); v != "" {
+		sections, err := dashboard.ParseSections(strings.Split(v, ","))
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.Sections = sections
+	}
+	if v := params.Get("days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 || days > 90 {
+			s.respondError(w, http.StatusBadRequest, "days must be between 1 and 90")
+			return
+		}
+		opts.Days = days
+	}
+
+	s.respondJSON(w, http.StatusOK, s.app.GetDashboard().Build(r.Context(), opts))
+}
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/auth"
 	"github.com/jordanhubbard/loom/pkg/config"
 )
 
@@ -1029,6 +1033,32 @@ func TestAuthMiddleware_WithUserHeader(t *testing.T) {
 	}
 }
 
+func TestAuthMiddleware_ClientCertificate(t *testing.T) {
+	s := newTestServerWithAuth()
+	s.authManager = auth.NewManager("test-secret")
+	handler := s.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := s.getUserFromContext(r)
+		w.Write([]byte(user.ID + "|" + user.Role + "|" + r.Header.Get("X-Client-Identity")))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/beads", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "agent-7"}}}}}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "cert:agent-7|service|agent-7" {
+		t.Errorf("expected the certificate identity, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Without a verified certificate the identity header can't be spoofed
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/beads", nil)
+	req.Header.Set("X-Client-Identity", "agent-7")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
 func TestHandleGitHubWebhook_MissingEventType(t *testing.T) {
 	cfg := &config.Config{
 		Security: config.SecurityConfig{
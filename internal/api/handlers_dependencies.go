@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/dependencies"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// defaultDependencyAuditInterval runs dependency audits weekly
+const defaultDependencyAuditInterval = 7 * 24 * time.Hour
+
+// handleProjectDependencies reports outdated dependencies and manages the
+// project's recurring dependency audit
+// GET /api/v1/projects/{id}/dependencies - List outdated dependencies (?path=...&ecosystems=go,npm)
+// POST /api/v1/projects/{id}/dependencies/beads - File update beads ({"path", "ecosystems", "bead_mode"})
+// GET /api/v1/projects/{id}/dependencies/schedule - Get the audit schedule
+// PUT /api/v1/projects/{id}/dependencies/schedule - Create or update it ({"interval": "168h", "path", "ecosystems", "bead_mode", "enabled"})
+// DELETE /api/v1/projects/{id}/dependencies/schedule - Remove it
+// POST /api/v1/projects/{id}/dependencies/schedule/run - Run the audit now
+func (s *Server) handleProjectDependencies(w http.ResponseWriter, r *http.Request, projectID string, parts []string) {
+	deps := s.app.GetDependencyManager()
+	if deps == nil {
+		s.respondError(w, http.StatusInternalServerError, "dependency manager not configured")
+		return
+	}
+	if _, err := s.app.GetProjectManager().GetProject(projectID); err != nil {
+		s.respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	sub := strings.Trim(strings.Join(parts, "/"), "/")
+	switch {
+	case sub == "" && r.Method == http.MethodGet:
+		opts := dependencies.Options{Path: r.URL.Query().Get("path"), Ecosystems: splitList(r.URL.Query().Get("ecosystems"))}
+		report, err := deps.Outdated(r.Context(), projectID, opts)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, report)
+	case sub == "beads" && r.Method == http.MethodPost:
+		var req struct {
+			Path       string   `json:"path"`
+			Ecosystems []string `json:"ecosystems"`
+			BeadMode   string   `json:"bead_mode"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		report, err := deps.Outdated(r.Context(), projectID, dependencies.Options{Path: req.Path, Ecosystems: req.Ecosystems})
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		beadIDs, err := deps.FileBeads(report, req.BeadMode)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusCreated, map[string]interface{}{"report": report, "bead_ids": beadIDs})
+	case sub == "schedule" || sub == "schedule/run":
+		s.handleDependencyAuditSchedule(w, r, projectID, sub == "schedule/run")
+	case sub == "" || sub == "beads":
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	default:
+		s.respondError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) handleDependencyAuditSchedule(w http.ResponseWriter, r *http.Request, projectID string, runNow bool) {
+	sched := s.app.GetScheduler()
+	if sched == nil {
+		s.respondError(w, http.StatusInternalServerError, "scheduler not configured")
+		return
+	}
+	id := "dependency-audit-" + projectID
+
+	if runNow {
+		if r.Method != http.MethodPost {
+			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		result, err := sched.RunNow(r.Context(), id)
+		if err != nil {
+			s.respondError(w, scheduleErrorStatus(err), err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, result)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		existing, err := sched.Get(id)
+		if err != nil {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, existing)
+	case http.MethodPut:
+		var req struct {
+			Interval   string   `json:"interval"`
+			Path       string   `json:"path"`
+			Ecosystems []string `json:"ecosystems"`
+			BeadMode   string   `json:"bead_mode"`
+			Enabled    *bool    `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		interval := defaultDependencyAuditInterval
+		if req.Interval != "" {
+			d, err := time.ParseDuration(req.Interval)
+			if err != nil {
+				s.respondError(w, http.StatusBadRequest, "invalid interval: "+err.Error())
+				return
+			}
+			interval = d
+		}
+		if req.BeadMode != "" && req.BeadMode != dependencies.BeadModePerDependency && req.BeadMode != dependencies.BeadModeBatch {
+			s.respondError(w, http.StatusBadRequest, "bead_mode must be per_dependency or batch")
+			return
+		}
+		enabled := true
+		if req.Enabled != nil {
+			enabled = *req.Enabled
+		}
+		params := map[string]string{}
+		if req.Path != "" {
+			params["path"] = req.Path
+		}
+		if len(req.Ecosystems) > 0 {
+			params["ecosystems"] = strings.Join(req.Ecosystems, ",")
+		}
+		if req.BeadMode != "" {
+			params["bead_mode"] = req.BeadMode
+		}
+		result, err := sched.Upsert(&models.Schedule{
+			ID:              id,
+			Name:            "Dependency audit",
+			Kind:            dependencies.ScheduleKind,
+			ProjectID:       projectID,
+			IntervalSeconds: int64(interval / time.Second),
+			Params:          params,
+			Enabled:         enabled,
+		})
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, result)
+	case http.MethodDelete:
+		if err := sched.Delete(id); err != nil {
+			s.respondError(w, scheduleErrorStatus(err), err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]string{"status": "deleted", "id": id})
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleSchedules lists recurring jobs
+// GET /api/v1/schedules - List schedules (?kind=...&project_id=...)
+func (s *Server) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	sched := s.app.GetScheduler()
+	if sched == nil {
+		s.respondError(w, http.StatusInternalServerError, "scheduler not configured")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, sched.List(r.URL.Query().Get("kind"), r.URL.Query().Get("project_id")))
+}
+
+func scheduleErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "schedule not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusConflict
+}
+
+// splitList splits a comma-separated query parameter, dropping empty items
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
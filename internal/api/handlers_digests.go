@@ -0,0 +1,227 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/digest"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// handleDigests handles project status digests
+// GET  /api/v1/digests - Archived digests, newest first (?project_id=&period=&limit=)
+// POST /api/v1/digests - Generate a digest now, archive it and deliver it; preview skips both
+func (s *Server) handleDigests(w http.ResponseWriter, r *http.Request) {
+	gen := s.app.GetDigests()
+	if gen == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Digests not available")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		limit := 0
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				s.respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			limit = n
+		}
+		reports, err := gen.List(q.Get("project_id"), q.Get("period"), limit)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if reports == nil {
+			reports = []*digest.Report{}
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"digests": reports})
+
+	case http.MethodPost:
+		var req struct {
+			ProjectID string `json:"project_id"`
+			Period    string `json:"period"`
+			End       string `json:"end"`
+			Summarize bool   `json:"summarize"`
+			Preview   bool   `json:"preview"`
+		}
+		if err := s.parseJSON(r, &req); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		opts := digest.Options{ProjectID: req.ProjectID, Period: req.Period, Summarize: req.Summarize}
+		if req.End != "" {
+			end, err := time.Parse(time.RFC3339, req.End)
+			if err != nil {
+				s.respondError(w, http.StatusBadRequest, "end must be an RFC3339 time")
+				return
+			}
+			opts.End = end
+		}
+		if opts.ProjectID == "" {
+			s.respondError(w, http.StatusBadRequest, "project_id is required")
+			return
+		}
+		if _, err := digest.Window(opts.Period, time.Now()); err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		generate := gen.Run
+		if req.Preview {
+			generate = gen.Generate
+		}
+		report, err := generate(r.Context(), opts)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		status := http.StatusCreated
+		if req.Preview {
+			status = http.StatusOK
+		}
+		s.respondJSON(w, status, report)
+
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleDigest handles a single digest or digest schedules
+// GET    /api/v1/digests/{id}?format=json|markdown|html - An archived digest
+// GET    /api/v1/digests/schedule?project_id=&period= - A project's digest schedule
+// PUT    /api/v1/digests/schedule - Create or update one {project_id, period, summarize, enabled}
+// DELETE /api/v1/digests/schedule?project_id=&period= - Stop it
+func (s *Server) handleDigest(w http.ResponseWriter, r *http.Request) {
+	gen := s.app.GetDigests()
+	if gen == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Digests not available")
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/digests/"), "/")
+	if id == "schedule" {
+		s.handleDigestSchedule(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	report, err := gen.Get(id)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if report == nil {
+		s.respondError(w, http.StatusNotFound, "Digest not found")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" || format == "json" {
+		s.respondJSON(w, http.StatusOK, report)
+		return
+	}
+	body, err := digest.Render(report, format)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	contentType := "text/markdown; charset=utf-8"
+	if format == digest.FormatHTML {
+		contentType = "text/html; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(body))
+}
+
+func (s *Server) handleDigestSchedule(w http.ResponseWriter, r *http.Request) {
+	sched := s.app.GetScheduler()
+	if sched == nil {
+		s.respondError(w, http.StatusInternalServerError, "scheduler not configured")
+		return
+	}
+
+	var req struct {
+		ProjectID string `json:"project_id"`
+		Period    string `json:"period"`
+		Summarize bool   `json:"summarize"`
+		Enabled   *bool  `json:"enabled"`
+	}
+	if r.Method == http.MethodPut {
+		if err := s.parseJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	} else {
+		req.ProjectID = r.URL.Query().Get("project_id")
+		req.Period = r.URL.Query().Get("period")
+	}
+	if req.ProjectID == "" {
+		s.respondError(w, http.StatusBadRequest, "project_id is required")
+		return
+	}
+	if req.Period == "" {
+		req.Period = digest.PeriodDaily
+	}
+	interval := 24 * time.Hour
+	switch req.Period {
+	case digest.PeriodDaily:
+	case digest.PeriodWeekly:
+		interval = 7 * 24 * time.Hour
+	default:
+		s.respondError(w, http.StatusBadRequest, "period must be daily or weekly")
+		return
+	}
+	id := "digest-" + req.Period + "-" + req.ProjectID
+
+	switch r.Method {
+	case http.MethodGet:
+		existing, err := sched.Get(id)
+		if err != nil {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, existing)
+	case http.MethodPut:
+		enabled := true
+		if req.Enabled != nil {
+			enabled = *req.Enabled
+		}
+		params := map[string]string{"period": req.Period}
+		if req.Summarize {
+			params["summarize"] = "true"
+		}
+		result, err := sched.Upsert(&models.Schedule{
+			ID:              id,
+			Name:            "Project digest (" + req.Period + ")",
+			Kind:            digest.ScheduleKind,
+			ProjectID:       req.ProjectID,
+			IntervalSeconds: int64(interval / time.Second),
+			Params:          params,
+			Enabled:         enabled,
+		})
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, result)
+	case http.MethodDelete:
+		if err := sched.Delete(id); err != nil {
+			s.respondError(w, scheduleErrorStatus(err), err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]string{"status": "deleted", "id": id})
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/dispatch"
+)
+
+// dispatcher returns the bead dispatcher, or nil without one
+func (s *Server) dispatcher() *dispatch.Dispatcher {
+	if s.app == nil {
+		return nil
+	}
+	return s.app.GetDispatcher()
+}
+
+// handleDispatchQueue handles GET /api/v1/dispatch/queue: ready beads in
+// dispatch order (project_id, limit) and the load of capped roles
+func (s *Server) handleDispatchQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	d := s.dispatcher()
+	if d == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Dispatcher not available")
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			s.respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	entries, err := d.Queue(r.URL.Query().Get("project_id"))
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	count := len(entries)
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"queue": entries,
+		"count": count,
+		"roles": d.RoleLoads(),
+	})
+}
+
+// handleDispatchQueueBead handles pinning beads to the head of the queue
+// POST   /api/v1/dispatch/queue/{bead_id}/pin - Pin it, optionally to an agent {agent_id}
+// DELETE /api/v1/dispatch/queue/{bead_id}/pin - Return it to its place by score
+func (s *Server) handleDispatchQueueBead(w http.ResponseWriter, r *http.Request) {
+	d := s.dispatcher()
+	if d == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Dispatcher not available")
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/dispatch/queue/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "pin" {
+		s.respondError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	beadID := parts[0]
+
+	switch r.Method {
+	case http.MethodPost:
+		var req struct {
+			AgentID string `json:"agent_id"`
+		}
+		if r.ContentLength != 0 {
+			if err := s.parseJSON(r, &req); err != nil {
+				s.respondError(w, http.StatusBadRequest, "Invalid request body")
+				return
+			}
+		}
+		if err := d.PinBead(beadID, req.AgentID); err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]string{"status": "pinned", "bead_id": beadID, "agent_id": req.AgentID})
+	case http.MethodDelete:
+		if err := d.UnpinBead(beadID); err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]string{"status": "unpinned", "bead_id": beadID})
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
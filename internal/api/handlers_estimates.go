@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+)
+
+// handleBeadEstimate handles a bead's cost and time estimate
+// GET  /api/v1/beads/{id}/estimate - The recorded estimate, with actuals once the bead closed; estimated now if there is none
+// POST /api/v1/beads/{id}/estimate - Estimate the bead again from the current history
+func (s *Server) handleBeadEstimate(w http.ResponseWriter, r *http.Request, beadID string) {
+	est := s.app.GetEstimator()
+	if est == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Estimates not available")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		existing, err := est.Get(beadID)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if existing != nil {
+			s.respondJSON(w, http.StatusOK, existing)
+			return
+		}
+		fallthrough
+	case http.MethodPost:
+		result, err := est.EstimateBead(r.Context(), beadID)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, result)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleEstimateAccuracy reports how recent estimates compared with actuals
+// GET /api/v1/estimates/accuracy?project_id= - One project, or every project without project_id
+func (s *Server) handleEstimateAccuracy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	est := s.app.GetEstimator()
+	if est == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Estimates not available")
+		return
+	}
+	acc, err := est.Accuracy(r.URL.Query().Get("project_id"))
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, acc)
+}
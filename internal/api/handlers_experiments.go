@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/experiment"
+)
+
+// handleExperiments handles model A/B experiments
+// GET  /api/v1/experiments - Every experiment, oldest first
+// POST /api/v1/experiments - Create and start one {name, category, project_id, variants: [A, B]}
+func (s *Server) handleExperiments(w http.ResponseWriter, r *http.Request) {
+	mgr := s.app.GetExperiments()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Experiments not available")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := mgr.List()
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if list == nil {
+			list = []*experiment.Experiment{}
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"experiments": list})
+	case http.MethodPost:
+		var req experiment.Experiment
+		if err := s.parseJSON(r, &req); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		created, err := mgr.Create(&req)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusCreated, created)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleExperiment handles a single experiment
+// GET    /api/v1/experiments/{id} - The experiment
+// DELETE /api/v1/experiments/{id} - Delete it and its assignments
+// POST   /api/v1/experiments/{id}/status - Run, pause or stop it {status}
+// GET    /api/v1/experiments/{id}/report - Per-variant results and significance tests
+// GET    /api/v1/experiments/{id}/assignments - The beads assigned and their outcomes
+func (s *Server) handleExperiment(w http.ResponseWriter, r *http.Request) {
+	mgr := s.app.GetExperiments()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Experiments not available")
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/experiments/"), "/"), "/")
+	id := parts[0]
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		e, err := mgr.Get(id)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, e)
+	case action == "" && r.Method == http.MethodDelete:
+		if err := mgr.Delete(id); err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]string{"status": "deleted", "id": id})
+	case action == "status" && r.Method == http.MethodPost:
+		var req struct {
+			Status string `json:"status"`
+		}
+		if err := s.parseJSON(r, &req); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		e, err := mgr.SetStatus(id, req.Status)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, e)
+	case action == "report" && r.Method == http.MethodGet:
+		report, err := mgr.Report(id)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, report)
+	case action == "assignments" && r.Method == http.MethodGet:
+		assignments, err := mgr.Assignments(id)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		if assignments == nil {
+			assignments = []*experiment.Assignment{}
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"experiment_id": id, "assignments": assignments})
+	case action == "" || action == "status" || action == "report" || action == "assignments":
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	default:
+		s.respondError(w, http.StatusNotFound, "Not found")
+	}
+}
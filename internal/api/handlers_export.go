@@ -0,0 +1,332 @@
+package api
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/activity"
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/auth"
+	"github.com/jordanhubbard/loom/internal/logging"
+)
+
+// Streaming exports write each record as it is read from the database, so
+// an export of any size is sent in constant memory. Records are written
+// oldest first as NDJSON (the default) or CSV.
+
+// exportFlushEvery is how many records are written between flushes to the
+// client
+const exportFlushEvery = 500
+
+// exportEmit writes one record: the value itself for NDJSON, or the row
+// returned by row for CSV
+type exportEmit func(record interface{}, row func() []string) error
+
+// handleExportRequestLogs handles GET /api/v1/export/request-logs
+func (s *Server) handleExportRequestLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.analyticsLogger == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Analytics not available")
+		return
+	}
+
+	userID := auth.GetUserIDFromRequest(r)
+	// If auth is disabled, allow access with empty userID (export all logs)
+	if userID == "" && s.config.Security.EnableAuth {
+		s.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	start, end, ok := s.exportRange(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	filter := &analytics.LogFilter{
+		UserID:     userID, // Users can only export their own logs
+		ProviderID: q.Get("provider_id"),
+		ModelName:  q.Get("model"),
+		StartTime:  start,
+		EndTime:    end,
+	}
+	if auth.GetRoleFromRequest(r) == "admin" {
+		filter.UserID = q.Get("user_id")
+	}
+
+	header := []string{"id", "timestamp", "user_id", "method", "path", "provider_id", "model_name",
+		"prompt_tokens", "completion_tokens", "total_tokens", "latency_ms", "status_code", "cost_usd",
+		"error_message", "metadata"}
+	s.streamExport(w, r, "request-logs", header, func(ctx context.Context, emit exportEmit) error {
+		return s.analyticsLogger.EachLog(ctx, filter, func(l *analytics.RequestLog) error {
+			return emit(l, func() []string {
+				return []string{
+					l.ID, l.Timestamp.UTC().Format(time.RFC3339Nano), l.UserID, l.Method, l.Path,
+					l.ProviderID, l.ModelName,
+					strconv.FormatInt(l.PromptTokens, 10), strconv.FormatInt(l.CompletionTokens, 10),
+					strconv.FormatInt(l.TotalTokens, 10), strconv.FormatInt(l.LatencyMs, 10),
+					strconv.Itoa(l.StatusCode), strconv.FormatFloat(l.CostUSD, 'f', -1, 64),
+					l.ErrorMessage, exportJSON(l.Metadata),
+				}
+			})
+		})
+	})
+}
+
+// handleExportAuditEvents handles GET /api/v1/export/audit-events, the
+// activity feed of who did what to which resource
+func (s *Server) handleExportAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !s.requireExportAdmin(w, r) {
+		return
+	}
+	var mgr *activity.Manager
+	if s.app != nil {
+		mgr = s.app.GetActivityManager()
+	}
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Activity feed not available")
+		return
+	}
+	start, end, ok := s.exportRange(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	filters := activity.ActivityFilters{
+		EventType:    q.Get("event_type"),
+		ActorID:      q.Get("actor_id"),
+		ResourceType: q.Get("resource_type"),
+		Since:        start,
+		Until:        end,
+	}
+	if projectID := q.Get("project_id"); projectID != "" {
+		filters.ProjectIDs = []string{projectID}
+	}
+
+	header := []string{"id", "timestamp", "event_type", "source", "actor_id", "actor_type", "project_id",
+		"agent_id", "bead_id", "provider_id", "action", "resource_type", "resource_id", "resource_title",
+		"metadata"}
+	s.streamExport(w, r, "audit-events", header, func(ctx context.Context, emit exportEmit) error {
+		return mgr.EachActivity(ctx, filters, func(a *activity.Activity) error {
+			return emit(a, func() []string {
+				return []string{
+					a.ID, a.Timestamp.UTC().Format(time.RFC3339Nano), a.EventType, a.Source, a.ActorID,
+					a.ActorType, a.ProjectID, a.AgentID, a.BeadID, a.ProviderID, a.Action,
+					a.ResourceType, a.ResourceID, a.ResourceTitle, exportJSON(a.Metadata),
+				}
+			})
+		})
+	})
+}
+
+// handleExportActionLogs handles GET /api/v1/export/action-logs, the
+// record of every action agents executed
+func (s *Server) handleExportActionLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !s.requireExportAdmin(w, r) {
+		return
+	}
+	if s.logManager == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Log storage not available")
+		return
+	}
+	start, end, ok := s.exportRange(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	level, agentID, beadID, projectID := q.Get("level"), q.Get("agent_id"), q.Get("bead_id"), q.Get("project_id")
+	header := []string{"id", "timestamp", "level", "agent_id", "bead_id", "project_id", "action_type",
+		"status", "message", "metadata"}
+	s.streamExport(w, r, "action-logs", header, func(ctx context.Context, emit exportEmit) error {
+		return s.logManager.Each(ctx, level, "actions", agentID, beadID, projectID, start, end, func(e logging.LogEntry) error {
+			return emit(e, func() []string {
+				field := func(key string) string {
+					if v, ok := e.Metadata[key]; ok && v != nil {
+						return fmt.Sprint(v)
+					}
+					return ""
+				}
+				return []string{
+					e.ID, e.Timestamp.UTC().Format(time.RFC3339Nano), e.Level, field("agent_id"),
+					field("bead_id"), field("project_id"), field("action_type"), field("status"),
+					field("message"), exportJSON(e.Metadata),
+				}
+			})
+		})
+	})
+}
+
+// requireExportAdmin allows only admins to export data that isn't scoped
+// to the caller, when auth is enabled
+func (s *Server) requireExportAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if s.config.Security.EnableAuth && auth.GetRoleFromRequest(r) != "admin" {
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
+		return false
+	}
+	return true
+}
+
+// exportRange parses the start_time and end_time parameters
+func (s *Server) exportRange(w http.ResponseWriter, r *http.Request) (start, end time.Time, ok bool) {
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"start_time", &start}, {"end_time", &end}} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid '%s' parameter: %v", p.name, err))
+			return start, end, false
+		}
+		*p.t = t
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		s.respondError(w, http.StatusBadRequest, "end_time is before start_time")
+		return start, end, false
+	}
+	return start, end, true
+}
+
+// streamExport writes the records each emits in the format the request
+// asks for. gzip=true sends a .gz file; otherwise the response is
+// compressed in transit when the client accepts gzip. Once records are
+// being sent a failure can't change the status, so the connection is
+// aborted and the client sees a truncated transfer rather than a short
+// export.
+func (s *Server) streamExport(w http.ResponseWriter, r *http.Request, name string, header []string, each func(context.Context, exportEmit) error) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	ext, contentType := "ndjson", "application/x-ndjson"
+	switch format {
+	case "", "ndjson", "jsonl":
+	case "csv":
+		ext, contentType = "csv", "text/csv"
+	default:
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported format %q: use ndjson or csv", format))
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102T150405Z"), ext)
+	var out io.Writer = w
+	var zw *gzip.Writer
+	if v, _ := strconv.ParseBool(r.URL.Query().Get("gzip")); v {
+		filename += ".gz"
+		contentType = "application/gzip"
+		zw = gzip.NewWriter(w)
+	} else if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Add("Vary", "Accept-Encoding")
+		zw = gzip.NewWriter(w)
+	}
+	if zw != nil {
+		out = zw
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+
+	// Disable write timeout - a large export takes longer than the server's
+	// WriteTimeout (30s default)
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.WriteHeader(http.StatusOK)
+
+	flush := func() error {
+		if zw != nil {
+			if err := zw.Flush(); err != nil {
+				return err
+			}
+		}
+		if err := rc.Flush(); err != nil && err != http.ErrNotSupported {
+			return err
+		}
+		return nil
+	}
+
+	var enc *json.Encoder
+	var cw *csv.Writer
+	if ext == "csv" {
+		cw = csv.NewWriter(out)
+		_ = cw.Write(header)
+	} else {
+		enc = json.NewEncoder(out)
+	}
+	count := 0
+	emit := func(record interface{}, row func() []string) error {
+		var err error
+		if cw != nil {
+			err = cw.Write(row())
+		} else {
+			err = enc.Encode(record)
+		}
+		if err != nil {
+			return err
+		}
+		if count++; count%exportFlushEvery == 0 {
+			if cw != nil {
+				cw.Flush()
+				if err := cw.Error(); err != nil {
+					return err
+				}
+			}
+			return flush()
+		}
+		return nil
+	}
+
+	err := each(r.Context(), emit)
+	if err == nil && cw != nil {
+		cw.Flush()
+		err = cw.Error()
+	}
+	if err == nil && zw != nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		if r.Context().Err() == nil {
+			httpLog.WarnContext(r.Context(), "Export failed", "export", name, "records", count, "error", err)
+		}
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportJSON renders metadata for a CSV column
+func exportJSON(v interface{}) string {
+	switch m := v.(type) {
+	case map[string]string:
+		if len(m) == 0 {
+			return ""
+		}
+	case map[string]interface{}:
+		if len(m) == 0 {
+			return ""
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
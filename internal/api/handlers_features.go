@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/auth"
+	"github.com/jordanhubbard/loom/internal/features"
+)
+
+// featureFlags returns the feature flag registry; nil reports defaults
+func (s *Server) featureFlags() *features.Registry {
+	return s.features
+}
+
+// featureEnabled reports whether a flag is on for a project (empty for the
+// global state)
+func (s *Server) featureEnabled(name, projectID string) bool {
+	return s.featureFlags().Enabled(name, projectID)
+}
+
+// handleFeatures handles GET /api/v1/features[?project_id=]
+func (s *Server) handleFeatures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	projectID := r.URL.Query().Get("project_id")
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"project_id": projectID,
+		"flags":      s.featureFlags().List(projectID),
+	})
+}
+
+type featureRequest struct {
+	Enabled   *bool  `json:"enabled"` // null removes the runtime override
+	ProjectID string `json:"project_id,omitempty"`
+}
+
+// handleFeature handles PUT /api/v1/features/{name}. Changes last until
+// restart; the config is the durable place for flag states.
+func (s *Server) handleFeature(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if auth.GetRoleFromRequest(r) != "admin" {
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
+		return
+	}
+	registry := s.featureFlags()
+	if registry == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Feature flags not available")
+		return
+	}
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/features/"), "/")
+	var req featureRequest
+	if err := s.parseJSON(r, &req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := registry.Set(name, req.ProjectID, req.Enabled); err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	change := "cleared"
+	if req.Enabled != nil {
+		change = strconv.FormatBool(*req.Enabled)
+	}
+	httpLog.InfoContext(r.Context(), "Feature flag changed", "user_id", auth.GetUserIDFromRequest(r),
+		"flag", name, "project_id", req.ProjectID, "enabled", change)
+
+	for _, state := range registry.List(req.ProjectID) {
+		if state.Name == name {
+			s.respondJSON(w, http.StatusOK, state)
+			return
+		}
+	}
+}
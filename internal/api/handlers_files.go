@@ -92,13 +92,25 @@ func (s *Server) handleProjectFiles(w http.ResponseWriter, r *http.Request, proj
 			return
 		}
 		res, err := s.fileManager.ApplyPatch(r.Context(), projectID, req.Patch)
+		if err != nil && res != nil && len(res.Hunks) > 0 {
+			// Some hunks may have applied; report which
+			s.respondJSON(w, http.StatusConflict, map[string]interface{}{
+				"error":    err.Error(),
+				"applied":  res.Applied,
+				"strategy": res.Strategy,
+				"hunks":    res.Hunks,
+			})
+			return
+		}
 		if err != nil {
 			s.respondError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 		s.respondJSON(w, http.StatusOK, map[string]interface{}{
-			"applied": res.Applied,
-			"output":  res.Output,
+			"applied":  res.Applied,
+			"output":   res.Output,
+			"strategy": res.Strategy,
+			"hunks":    res.Hunks,
 		})
 	default:
 		s.respondError(w, http.StatusNotFound, "Unknown file action")
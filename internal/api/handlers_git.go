@@ -4,37 +4,40 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+
+	"github.com/jordanhubbard/loom/internal/git"
 )
 
 // handleGitSync handles git pull for a project
 func (s *Server) handleGitSync(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	projectID := r.URL.Query().Get("project_id")
 	if projectID == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "project_id required")
 		return
 	}
 
 	// Get project
 	project, err := s.app.GetProjectManager().GetProject(projectID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Project not found: %v", err), http.StatusNotFound)
+		s.respondError(w, http.StatusNotFound, fmt.Sprintf("Project not found: %v", err))
 		return
 	}
 
 	if project.GitRepo == "" || project.GitRepo == "." {
-		http.Error(w, "Project does not have a remote git repository", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "Project does not have a remote git repository")
 		return
 	}
 
 	// Pull latest changes
 	gitops := s.app.GetGitopsManager()
 	if err := gitops.PullProject(r.Context(), project); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to pull: %v", err), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to pull: %v", err))
 		return
 	}
 
@@ -55,20 +58,20 @@ func (s *Server) handleGitSync(w http.ResponseWriter, r *http.Request) {
 		"last_commit_hash": project.LastCommitHash,
 		"last_sync_at":     project.LastSyncAt,
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleGitCommit handles committing changes for a project
 func (s *Server) handleGitCommit(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	projectID := r.URL.Query().Get("project_id")
 	if projectID == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "project_id required")
 		return
 	}
 
@@ -78,31 +81,31 @@ func (s *Server) handleGitCommit(w http.ResponseWriter, r *http.Request) {
 		AuthorEmail string `json:"author_email"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if req.Message == "" {
-		http.Error(w, "commit message required", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "commit message required")
 		return
 	}
 
 	// Get project
 	project, err := s.app.GetProjectManager().GetProject(projectID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Project not found: %v", err), http.StatusNotFound)
+		s.respondError(w, http.StatusNotFound, fmt.Sprintf("Project not found: %v", err))
 		return
 	}
 
 	if project.GitRepo == "" || project.GitRepo == "." {
-		http.Error(w, "Project does not have a remote git repository", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "Project does not have a remote git repository")
 		return
 	}
 
 	// Commit changes
 	gitops := s.app.GetGitopsManager()
 	if err := gitops.CommitChanges(r.Context(), project, req.Message, req.AuthorName, req.AuthorEmail); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to commit: %v", err), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to commit: %v", err))
 		return
 	}
 
@@ -120,39 +123,39 @@ func (s *Server) handleGitCommit(w http.ResponseWriter, r *http.Request) {
 		"project_id":       projectID,
 		"last_commit_hash": project.LastCommitHash,
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleGitPush handles pushing changes for a project
 func (s *Server) handleGitPush(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	projectID := r.URL.Query().Get("project_id")
 	if projectID == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "project_id required")
 		return
 	}
 
 	// Get project
 	project, err := s.app.GetProjectManager().GetProject(projectID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Project not found: %v", err), http.StatusNotFound)
+		s.respondError(w, http.StatusNotFound, fmt.Sprintf("Project not found: %v", err))
 		return
 	}
 
 	if project.GitRepo == "" || project.GitRepo == "." {
-		http.Error(w, "Project does not have a remote git repository", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "Project does not have a remote git repository")
 		return
 	}
 
 	// Push changes
 	gitops := s.app.GetGitopsManager()
 	if err := gitops.PushChanges(r.Context(), project); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to push: %v", err), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to push: %v", err))
 		return
 	}
 
@@ -161,27 +164,27 @@ func (s *Server) handleGitPush(w http.ResponseWriter, r *http.Request) {
 		"success":    true,
 		"project_id": projectID,
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleGitStatus handles getting git status for a project
 func (s *Server) handleGitStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	projectID := r.URL.Query().Get("project_id")
 	if projectID == "" {
-		http.Error(w, "project_id required", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "project_id required")
 		return
 	}
 
 	// Get project
 	project, err := s.app.GetProjectManager().GetProject(projectID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Project not found: %v", err), http.StatusNotFound)
+		s.respondError(w, http.StatusNotFound, fmt.Sprintf("Project not found: %v", err))
 		return
 	}
 
@@ -192,7 +195,7 @@ func (s *Server) handleGitStatus(w http.ResponseWriter, r *http.Request) {
 			"project_id": projectID,
 			"has_git":    false,
 		}); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 		}
 		return
 	}
@@ -217,6 +220,42 @@ func (s *Server) handleGitStatus(w http.ResponseWriter, r *http.Request) {
 		"last_sync_at":     project.LastSyncAt,
 		"git_auth_method":  project.GitAuthMethod,
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
+	}
+}
+
+// handleProjectDiff handles GET /api/v1/projects/{id}/diff?from=&to=&path=&context=
+// returning the diff as files, hunks and numbered lines. from defaults to
+// HEAD and an empty to compares against the working tree.
+func (s *Server) handleProjectDiff(w http.ResponseWriter, r *http.Request, projectID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if _, err := s.app.GetProjectManager().GetProject(projectID); err != nil {
+		s.respondError(w, http.StatusNotFound, fmt.Sprintf("Project not found: %v", err))
+		return
+	}
+
+	q := r.URL.Query()
+	req := git.RevisionDiffRequest{From: q.Get("from"), To: q.Get("to"), Path: q.Get("path")}
+	if c := q.Get("context"); c != "" {
+		n, err := strconv.Atoi(c)
+		if err != nil || n < 1 {
+			s.respondError(w, http.StatusBadRequest, "context must be a positive integer")
+			return
+		}
+		req.Context = n
+	}
+	if _, err := git.RevisionDiffArgs(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	diff, err := s.app.GetGitopsManager().DiffRevisions(r.Context(), projectID, req)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
+	s.respondJSON(w, http.StatusOK, diff)
 }
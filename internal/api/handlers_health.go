@@ -3,10 +3,18 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/jordanhubbard/loom/internal/housekeeping"
+	"github.com/jordanhubbard/loom/internal/provider"
+	"github.com/jordanhubbard/loom/internal/scheduler"
 )
 
 // HealthStatus represents the overall health status.
@@ -18,6 +26,7 @@ type HealthStatus struct {
 	Version      string                 `json:"version,omitempty"`
 	Dependencies map[string]DepHealth   `json:"dependencies"`
 	Metrics      map[string]interface{} `json:"metrics,omitempty"`
+	Features     map[string]interface{} `json:"features,omitempty"` // Flag states and project overrides
 }
 
 // DepHealth represents the health of a dependency.
@@ -36,7 +45,7 @@ var (
 // Returns 200 if the application is running.
 func (s *Server) handleHealthLive(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -51,7 +60,7 @@ func (s *Server) handleHealthLive(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
@@ -59,7 +68,7 @@ func (s *Server) handleHealthLive(w http.ResponseWriter, r *http.Request) {
 // Returns 200 if the application is ready to serve traffic.
 func (s *Server) handleHealthReady(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -95,14 +104,14 @@ func (s *Server) handleHealthReady(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleHealthDetail handles GET /health - Detailed health information.
 func (s *Server) handleHealthDetail(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -150,6 +159,7 @@ func (s *Server) handleHealthDetail(w http.ResponseWriter, r *http.Request) {
 		Version:      getVersion(),
 		Dependencies: deps,
 		Metrics:      metrics,
+		Features:     s.featureFlags().Snapshot(),
 	}
 
 	httpStatus := http.StatusOK
@@ -160,7 +170,7 @@ func (s *Server) handleHealthDetail(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpStatus)
 	if err := json.NewEncoder(w).Encode(status); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
@@ -181,10 +191,25 @@ func (s *Server) checkDependencies(ctx context.Context) map[string]DepHealth {
 
 	// Check analytics (optional)
 	if s.analyticsLogger != nil {
-		deps["analytics"] = DepHealth{
+		dep := DepHealth{
 			Status:  "healthy",
 			Message: "operational",
 		}
+		if s.app != nil {
+			if queue := s.app.GetAnalyticsQueue(); queue != nil {
+				stats := queue.Stats()
+				dep.Message = fmt.Sprintf("operational; %d request logs queued, %d bytes spilled, %d dropped", stats.Depth, stats.SpillBytes, stats.Dropped)
+			}
+			if mirror := s.app.GetAnalyticsMirror(); mirror != nil {
+				stats := mirror.Stats()
+				deps["analytics_sink"] = DepHealth{
+					Status: "healthy",
+					Message: fmt.Sprintf("%s: %d rows sent, %d queued, %d dropped, %d rejected",
+						stats.Sink, stats.Sent, stats.Depth, stats.Dropped, stats.Rejected),
+				}
+			}
+		}
+		deps["analytics"] = dep
 	}
 
 	return deps
@@ -272,6 +297,208 @@ func (s *Server) checkProviders(ctx context.Context) DepHealth {
 	}
 }
 
+// Probe timings for /healthz and /readyz
+const (
+	// providerProbeTTL is how long a provider reachability result is reused,
+	// so frequent probes don't turn into provider traffic
+	providerProbeTTL     = 30 * time.Second
+	providerProbeTimeout = 3 * time.Second
+	// dispatchStallIntervals is how many dispatch intervals may pass without
+	// a finished pass before the dispatch loop is reported stuck
+	dispatchStallIntervals = 6
+)
+
+// ProbeResponse is the body of /healthz and /readyz
+type ProbeResponse struct {
+	Status     string               `json:"status"` // "ok" or "fail"
+	Timestamp  time.Time            `json:"timestamp"`
+	Components map[string]DepHealth `json:"components"`
+}
+
+// handleHealthz handles GET /healthz - Kubernetes liveness probe. Fails only
+// when a background loop is stuck; dependency outages are left to /readyz so
+// they don't restart every replica.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	components := map[string]DepHealth{
+		"scheduler":   s.checkScheduler(),
+		"dispatcher":  s.checkDispatchLoop(),
+		"maintenance": s.checkMaintenanceLoop(),
+	}
+	ok := true
+	for _, health := range components {
+		if health.Status == "unhealthy" {
+			ok = false
+		}
+	}
+	s.respondProbe(w, ok, components)
+}
+
+// handleReadyz handles GET /readyz - Kubernetes readiness probe. Ready when
+// the database answers, the key store is unlocked and the background loops
+// are running. Provider reachability is reported but doesn't fail the probe:
+// the API is still needed to fix providers when none can be reached.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	components := map[string]DepHealth{
+		"database":    s.checkDatabase(ctx),
+		"keystore":    s.checkKeyStore(),
+		"providers":   s.checkProviderReachability(ctx),
+		"scheduler":   s.checkScheduler(),
+		"dispatcher":  s.checkDispatchLoop(),
+		"maintenance": s.checkMaintenanceLoop(),
+	}
+	ok := true
+	for name, health := range components {
+		if name != "providers" && health.Status != "healthy" && health.Status != "degraded" {
+			ok = false
+		}
+	}
+	s.respondProbe(w, ok, components)
+}
+
+func (s *Server) respondProbe(w http.ResponseWriter, ok bool, components map[string]DepHealth) {
+	resp := ProbeResponse{Status: "ok", Timestamp: time.Now(), Components: components}
+	status := http.StatusOK
+	if !ok {
+		resp.Status = "fail"
+		status = http.StatusServiceUnavailable
+	}
+	s.respondJSON(w, status, resp)
+}
+
+// checkKeyStore checks that the credential key store is unlocked
+func (s *Server) checkKeyStore() DepHealth {
+	km := s.keyManager
+	if km == nil && s.app != nil {
+		km = s.app.GetKeyManager()
+	}
+	if km == nil {
+		return DepHealth{Status: "unknown", Message: "key store not configured"}
+	}
+	if !km.IsUnlocked() {
+		return DepHealth{Status: "unhealthy", Message: "key store is locked"}
+	}
+	return DepHealth{Status: "healthy", Message: "unlocked"}
+}
+
+// checkScheduler checks that the recurring job scheduler is still ticking
+func (s *Server) checkScheduler() DepHealth {
+	if s.app == nil || s.app.GetScheduler() == nil {
+		return DepHealth{Status: "unknown", Message: "scheduler not configured"}
+	}
+	return loopHealth(s.app.GetScheduler().LastTick(), scheduler.StallAfter)
+}
+
+// checkDispatchLoop checks that the dispatch loop is still finishing passes
+func (s *Server) checkDispatchLoop() DepHealth {
+	if s.app == nil {
+		return DepHealth{Status: "unknown", Message: "not initialized"}
+	}
+	last, interval := s.app.DispatchLoopStatus()
+	return loopHealth(last, dispatchStallIntervals*interval)
+}
+
+// checkMaintenanceLoop checks that the maintenance loop is still checking
+// for due tasks
+func (s *Server) checkMaintenanceLoop() DepHealth {
+	runner := s.maintenanceTasks()
+	if runner == nil {
+		return DepHealth{Status: "unknown", Message: "maintenance loop not configured"}
+	}
+	return loopHealth(runner.LastTick(), housekeeping.StallAfter)
+}
+
+// loopHealth reports a background loop that last ran at last as stuck when
+// that is longer than stallAfter ago
+func loopHealth(last time.Time, stallAfter time.Duration) DepHealth {
+	if last.IsZero() {
+		return DepHealth{Status: "unknown", Message: "not started"}
+	}
+	age := time.Since(last).Round(time.Second)
+	if age > stallAfter {
+		return DepHealth{Status: "unhealthy", Message: fmt.Sprintf("stalled: last ran %s ago", age)}
+	}
+	return DepHealth{Status: "healthy", Message: fmt.Sprintf("last ran %s ago", age)}
+}
+
+// checkProviderReachability probes the registered providers, reusing the
+// last result for providerProbeTTL
+func (s *Server) checkProviderReachability(ctx context.Context) DepHealth {
+	if s.app == nil || s.app.GetProviderRegistry() == nil {
+		return DepHealth{Status: "unknown", Message: "not initialized"}
+	}
+
+	s.providerProbeMu.Lock()
+	defer s.providerProbeMu.Unlock()
+	if !s.providerProbeAt.IsZero() && time.Since(s.providerProbeAt) < providerProbeTTL {
+		return s.providerProbe
+	}
+	// A caller giving up must not cache a failure for everyone else
+	s.providerProbe = probeProviders(context.WithoutCancel(ctx), s.app.GetProviderRegistry())
+	s.providerProbeAt = time.Now()
+	return s.providerProbe
+}
+
+// probeProviders lists the models of every registered provider in parallel
+func probeProviders(ctx context.Context, registry *provider.Registry) DepHealth {
+	var ids []string
+	for _, p := range registry.List() {
+		if p != nil && p.Config != nil {
+			ids = append(ids, p.Config.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return DepHealth{Status: "unknown", Message: "no providers registered"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, providerProbeTimeout)
+	defer cancel()
+	start := time.Now()
+	errs := make([]error, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			_, errs[i] = registry.GetModels(ctx, id)
+		}(i, id)
+	}
+	wg.Wait()
+
+	var unreachable []string
+	for i, err := range errs {
+		if err != nil {
+			unreachable = append(unreachable, ids[i])
+		}
+	}
+	sort.Strings(unreachable)
+	health := DepHealth{Latency: time.Since(start).Milliseconds()}
+	switch {
+	case len(unreachable) == 0:
+		health.Status = "healthy"
+		health.Message = fmt.Sprintf("%d reachable", len(ids))
+	case len(unreachable) < len(ids):
+		health.Status = "degraded"
+		health.Message = fmt.Sprintf("%d of %d reachable; unreachable: %s", len(ids)-len(unreachable), len(ids), strings.Join(unreachable, ", "))
+	default:
+		health.Status = "unhealthy"
+		health.Message = "no provider reachable"
+	}
+	return health
+}
+
 // Helper functions
 
 func getInstanceID() string {
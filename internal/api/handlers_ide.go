@@ -0,0 +1,455 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/auth"
+	"github.com/jordanhubbard/loom/internal/ide"
+	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
+)
+
+// ideKeyTTL is the lifetime of API keys issued to paired editors
+const ideKeyTTL = 30 * 24 * time.Hour
+
+// ideHandshakePrefix is served without authentication: editors use it to
+// obtain their credential
+const ideHandshakePrefix = "/api/v1/ide/handshake"
+
+func (s *Server) ideTracker() *ide.Tracker {
+	if s.app == nil {
+		return nil
+	}
+	return s.app.GetIDETracker()
+}
+
+func (s *Server) idePairings() *ide.Pairings {
+	if s.app == nil {
+		return nil
+	}
+	return s.app.GetIDEPairings()
+}
+
+// handleIDE serves the editor plugin API
+// POST /api/v1/ide/handshake - Start pairing an editor; body {"client": "vscode"}
+// GET  /api/v1/ide/handshake/{id} - Poll a pairing; header X-Pairing-Secret
+// POST /api/v1/ide/pair - Approve an editor's pairing code; body {"code": "K7QD-9MWX"}
+// GET  /api/v1/ide/files?project_id=&path= - Beads with agent edits to a file
+// GET  /api/v1/ide/beads/{id} - Bead, workflow and edited file status
+// GET  /api/v1/ide/beads/{id}/files?path= - Live diffs of the bead's edited files
+// POST /api/v1/ide/beads/{id}/feedback - Reject or comment on a hunk
+// GET  /api/v1/ide/stream?project_id=&bead_id=&path= - SSE of diffs, feedback and bead status
+func (s *Server) handleIDE(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/ide/"), "/"), "/")
+	switch {
+	case parts[0] == "handshake" && len(parts) == 1:
+		s.handleIDEHandshakeStart(w, r)
+	case parts[0] == "handshake" && len(parts) == 2:
+		s.handleIDEHandshakePoll(w, r, parts[1])
+	case parts[0] == "pair" && len(parts) == 1:
+		s.handleIDEPair(w, r)
+	case parts[0] == "files" && len(parts) == 1:
+		s.handleIDEFileStatus(w, r)
+	case parts[0] == "stream" && len(parts) == 1:
+		s.handleIDEStream(w, r)
+	case parts[0] == "beads" && len(parts) == 2:
+		s.handleIDEBeadStatus(w, r, parts[1])
+	case parts[0] == "beads" && len(parts) == 3 && parts[2] == "files":
+		s.handleIDEBeadFiles(w, r, parts[1])
+	case parts[0] == "beads" && len(parts) == 3 && parts[2] == "feedback":
+		s.handleIDEFeedback(w, r, parts[1])
+	default:
+		s.respondError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) ideAuthRequired() bool {
+	return s.config != nil && s.config.Security.EnableAuth && s.authManager != nil
+}
+
+func (s *Server) handleIDEHandshakeStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	var req struct {
+		Client string `json:"client"`
+	}
+	if err := s.parseJSON(r, &req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !s.ideAuthRequired() {
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"auth_required": false})
+		return
+	}
+	pairings := s.idePairings()
+	if pairings == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Editor pairing not available")
+		return
+	}
+	pairing, secret := pairings.Start(req.Client)
+	s.respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"auth_required": true,
+		"pairing_id":    pairing.ID,
+		"code":          pairing.Code,
+		"secret":        secret,
+		"status":        pairing.Status,
+		"expires_at":    pairing.ExpiresAt,
+		"poll_interval": 2,
+	})
+}
+
+func (s *Server) handleIDEHandshakePoll(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	pairings := s.idePairings()
+	if pairings == nil || !s.ideAuthRequired() {
+		s.respondError(w, http.StatusServiceUnavailable, "Editor pairing not available")
+		return
+	}
+	pairing, err := pairings.Poll(id, r.Header.Get("X-Pairing-Secret"))
+	switch {
+	case errors.Is(err, ide.ErrPairingNotFound):
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, ide.ErrPairingClaimed):
+		s.respondError(w, http.StatusGone, err.Error())
+		return
+	}
+	if pairing.Status == ide.PairingPending {
+		s.respondJSON(w, http.StatusOK, pairing)
+		return
+	}
+
+	name := "ide"
+	if pairing.Client != "" {
+		name += ":" + pairing.Client
+	}
+	key, err := s.authManager.CreateAPIKey(pairing.UserID, auth.CreateAPIKeyRequest{
+		Name:      name,
+		ExpiresIn: int64(ideKeyTTL / time.Second),
+	})
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to issue editor key: %v", err))
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pairing_id": pairing.ID,
+		"status":     ide.PairingApproved,
+		"api_key":    key.Key,
+		"key_id":     key.ID,
+		"expires_at": key.ExpiresAt,
+		"user_id":    pairing.UserID,
+	})
+}
+
+func (s *Server) handleIDEPair(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	pairings := s.idePairings()
+	if pairings == nil || !s.ideAuthRequired() {
+		s.respondError(w, http.StatusServiceUnavailable, "Editor pairing not available")
+		return
+	}
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := s.parseJSON(r, &req); err != nil || req.Code == "" {
+		s.respondError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+	userID := auth.GetUserIDFromRequest(r)
+	if _, err := s.authManager.GetUser(userID); err != nil {
+		s.respondError(w, http.StatusForbidden, "Only user accounts can pair editors")
+		return
+	}
+	pairing, err := pairings.Approve(req.Code, userID)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, pairing)
+}
+
+// handleIDEFileStatus lists the beads whose agents have edits to a file,
+// for inline status in the editor
+func (s *Server) handleIDEFileStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	tracker := s.ideTracker()
+	if tracker == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Editor integration not available")
+		return
+	}
+	projectID, path := r.URL.Query().Get("project_id"), r.URL.Query().Get("path")
+	if projectID == "" || path == "" {
+		s.respondError(w, http.StatusBadRequest, "project_id and path are required")
+		return
+	}
+
+	editing := tracker.Editing(projectID, path)
+	beads := make([]map[string]interface{}, 0, len(editing))
+	for _, state := range editing {
+		entry := map[string]interface{}{
+			"bead_id":    state.BeadID,
+			"agent_id":   state.AgentID,
+			"added":      state.Added,
+			"removed":    state.Removed,
+			"hunks":      len(state.Hunks),
+			"updated_at": state.UpdatedAt,
+		}
+		if bm := s.app.GetBeadsManager(); bm != nil {
+			if bead, err := bm.GetBead(state.BeadID); err == nil && bead != nil {
+				entry["title"] = bead.Title
+				entry["status"] = bead.Status
+			}
+		}
+		beads = append(beads, entry)
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"project_id": projectID,
+		"path":       path,
+		"beads":      beads,
+	})
+}
+
+// handleIDEBeadStatus returns what an editor shows for a bead: its status,
+// its workflow position and the files its agent is editing
+func (s *Server) handleIDEBeadStatus(w http.ResponseWriter, r *http.Request, beadID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	tracker := s.ideTracker()
+	if tracker == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Editor integration not available")
+		return
+	}
+	bm := s.app.GetBeadsManager()
+	if bm == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Beads manager not available")
+		return
+	}
+	bead, err := bm.GetBead(beadID)
+	if err != nil || bead == nil {
+		s.respondError(w, http.StatusNotFound, "Bead not found")
+		return
+	}
+
+	files := make([]map[string]interface{}, 0)
+	for _, state := range tracker.Files(beadID) {
+		files = append(files, map[string]interface{}{
+			"path":        state.Path,
+			"agent_id":    state.AgentID,
+			"action_type": state.ActionType,
+			"added":       state.Added,
+			"removed":     state.Removed,
+			"hunks":       len(state.Hunks),
+			"updated_at":  state.UpdatedAt,
+		})
+	}
+	resp := map[string]interface{}{
+		"bead": map[string]interface{}{
+			"id":          bead.ID,
+			"title":       bead.Title,
+			"status":      bead.Status,
+			"priority":    bead.Priority,
+			"project_id":  bead.ProjectID,
+			"assigned_to": bead.AssignedTo,
+		},
+		"files": files,
+	}
+	// The most recently changed file is the one the agent is working in
+	if len(files) > 0 {
+		resp["active_file"] = files[0]["path"]
+	}
+	if project, err := s.app.GetProjectManager().GetProject(bead.ProjectID); err == nil && project != nil {
+		resp["work_dir"] = project.WorkDir
+	}
+	if engine := s.app.GetWorkflowEngine(); engine != nil {
+		if execution, err := engine.GetDatabase().GetWorkflowExecutionByBeadID(beadID); err == nil && execution != nil {
+			resp["workflow"] = map[string]interface{}{
+				"workflow_id":  execution.WorkflowID,
+				"status":       execution.Status,
+				"current_node": execution.CurrentNodeKey,
+				"cycle_count":  execution.CycleCount,
+			}
+		}
+	}
+	s.respondJSON(w, http.StatusOK, resp)
+}
+
+// handleIDEBeadFiles returns the live diffs of the files a bead's agent has
+// edited, or of one file with ?path=
+func (s *Server) handleIDEBeadFiles(w http.ResponseWriter, r *http.Request, beadID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	tracker := s.ideTracker()
+	if tracker == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Editor integration not available")
+		return
+	}
+	if path := r.URL.Query().Get("path"); path != "" {
+		state, ok := tracker.File(beadID, path)
+		if !ok {
+			s.respondError(w, http.StatusNotFound, ide.ErrFileNotTracked.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, state)
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"bead_id": beadID,
+		"files":   tracker.Files(beadID),
+	})
+}
+
+// handleIDEFeedback applies a human verdict on one hunk of an agent's edit
+func (s *Server) handleIDEFeedback(w http.ResponseWriter, r *http.Request, beadID string) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	tracker := s.ideTracker()
+	if tracker == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Editor integration not available")
+		return
+	}
+	var fb ide.Feedback
+	if err := s.parseJSON(r, &fb); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if fb.Path == "" || fb.HunkID == "" {
+		s.respondError(w, http.StatusBadRequest, "path and hunk_id are required")
+		return
+	}
+	if fb.Verdict == "" {
+		fb.Verdict = ide.VerdictReject
+	}
+	fb.BeadID = beadID
+	fb.UserID = auth.GetUserIDFromRequest(r)
+
+	state, err := tracker.ApplyFeedback(r.Context(), fb)
+	switch {
+	case errors.Is(err, ide.ErrFileNotTracked), errors.Is(err, ide.ErrHunkNotFound):
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		s.respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, state)
+}
+
+// handleIDEStream streams file diffs and hunk feedback, plus bead status
+// changes, to an editor. Filters narrow it to a project, a bead or a file.
+func (s *Server) handleIDEStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	tracker := s.ideTracker()
+	if tracker == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Editor integration not available")
+		return
+	}
+	eventBus := s.app.GetEventBus()
+	if eventBus == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Event bus not available")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	q := r.URL.Query()
+	projectID, beadID, path := q.Get("project_id"), q.Get("bead_id"), q.Get("path")
+	subscriberID := fmt.Sprintf("ide-%d", time.Now().UnixNano())
+	subscriber := eventBus.Subscribe(subscriberID, func(event *eventbus.Event) bool {
+		return ideEventMatches(event, projectID, beadID, path)
+	})
+	defer eventBus.Unsubscribe(subscriberID)
+
+	flush := func() {
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	writeEvent := func(eventType string, v interface{}) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, data)
+		flush()
+	}
+
+	// Current diffs first, so the editor starts from the live state
+	if beadID != "" {
+		for _, state := range tracker.Files(beadID) {
+			if path == "" || state.Path == path {
+				writeEvent("snapshot", state)
+			}
+		}
+	} else if projectID != "" && path != "" {
+		for _, state := range tracker.Editing(projectID, path) {
+			writeEvent("snapshot", state)
+		}
+	}
+	writeEvent("connected", map[string]string{"project_id": projectID, "bead_id": beadID, "path": path})
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-subscriber.Channel:
+			if !ok {
+				return
+			}
+			writeEvent(string(event.Type), event)
+		case <-time.After(30 * time.Second):
+			fmt.Fprintf(w, ": keepalive\n\n")
+			flush()
+		}
+	}
+}
+
+// ideEventMatches selects the events an editor stream carries: file diffs and
+// feedback, and bead events for streams that are not narrowed to one file
+func ideEventMatches(event *eventbus.Event, projectID, beadID, path string) bool {
+	if projectID != "" && event.ProjectID != projectID {
+		return false
+	}
+	eventType := string(event.Type)
+	switch {
+	case event.Type == eventbus.EventTypeFileChanged, event.Type == eventbus.EventTypeFileFeedback:
+		if path != "" && event.Data["path"] != path {
+			return false
+		}
+	case strings.HasPrefix(eventType, "bead."):
+		if path != "" && beadID == "" {
+			return false
+		}
+	default:
+		return false
+	}
+	return beadID == "" || event.Data["bead_id"] == beadID
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jordanhubbard/loom/internal/impact"
+)
+
+// handleProjectImpact reports what a change to a project's checkout
+// affects, for scoping verification to the affected packages and tests
+// GET /api/v1/projects/{id}/impact?base=main - Changes since base
+// GET /api/v1/projects/{id}/impact?files=a.go,b.go&symbols=New
+// POST /api/v1/projects/{id}/impact - {"files": [...], "symbols": [...], "base": "..."}
+func (s *Server) handleProjectImpact(w http.ResponseWriter, r *http.Request, projectID string) {
+	var req impact.Request
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Files = splitList(q.Get("files"))
+		req.Symbols = splitList(q.Get("symbols"))
+		req.Base = q.Get("base")
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	analyzer := s.app.GetImpactAnalyzer()
+	if analyzer == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Impact analysis not available")
+		return
+	}
+	if _, err := s.app.GetProjectManager().GetProject(projectID); err != nil {
+		s.respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	report, err := analyzer.Analyze(r.Context(), projectID, req)
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	s.respondJSON(w, http.StatusOK, report)
+}
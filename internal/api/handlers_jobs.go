@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/executor"
+)
+
+const jobLogStreamWait = 15 * time.Second
+
+// handleJobs lists and starts background jobs
+// GET /api/v1/jobs?bead_id=...&status=... - List jobs
+// POST /api/v1/jobs - Start a command in the background (same body as /commands/execute)
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	mgr := s.app.GetJobManager()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Job manager not available")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		s.respondJSON(w, http.StatusOK, mgr.ListJobs(q.Get("bead_id"), q.Get("status")))
+	case http.MethodPost:
+		var req executor.ExecuteCommandRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.Command == "" {
+			s.respondError(w, http.StatusBadRequest, "command is required")
+			return
+		}
+		if req.AgentID == "" {
+			s.respondError(w, http.StatusBadRequest, "agent_id is required")
+			return
+		}
+		info, err := mgr.StartJob(r.Context(), req)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusAccepted, info)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleJob handles a single background job
+// GET /api/v1/jobs/{id} - Job status
+// DELETE /api/v1/jobs/{id} or POST /api/v1/jobs/{id}/cancel - Cancel the job
+// GET /api/v1/jobs/{id}/logs?offset=&limit=&wait= - Page of output
+// GET /api/v1/jobs/{id}/logs/stream?offset= - SSE stream of output until the job finishes
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	mgr := s.app.GetJobManager()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Job manager not available")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/jobs/"), "/")
+	id := parts[0]
+	if id == "" {
+		s.respondError(w, http.StatusBadRequest, "Job ID required")
+		return
+	}
+	sub := strings.Join(parts[1:], "/")
+
+	switch {
+	case sub == "" && r.Method == http.MethodGet:
+		info, err := mgr.GetJob(id)
+		if err != nil {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, info)
+	case (sub == "" && r.Method == http.MethodDelete) || (sub == "cancel" && r.Method == http.MethodPost):
+		if _, err := mgr.GetJob(id); err != nil {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		info, err := mgr.CancelJob(id)
+		if err != nil {
+			s.respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, info)
+	case sub == "logs" && r.Method == http.MethodGet:
+		q := r.URL.Query()
+		offset, _ := strconv.ParseInt(q.Get("offset"), 10, 64)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		waitSeconds, _ := strconv.Atoi(q.Get("wait"))
+		if waitSeconds > 30 {
+			waitSeconds = 30
+		}
+		page, err := mgr.ReadLog(r.Context(), id, offset, limit, time.Duration(waitSeconds)*time.Second)
+		if err != nil {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, page)
+	case sub == "logs/stream" && r.Method == http.MethodGet:
+		s.streamJobLogs(w, r, mgr, id)
+	case sub == "" || sub == "cancel" || sub == "logs" || sub == "logs/stream":
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	default:
+		s.respondError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// streamJobLogs sends "log" events as output arrives and a final "status"
+// event with the job's outcome.
+func (s *Server) streamJobLogs(w http.ResponseWriter, r *http.Request, mgr *executor.JobManager, id string) {
+	if _, err := mgr.GetJob(id); err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.respondError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	// Jobs can run far longer than the server's write timeout.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	ctx := r.Context()
+	for ctx.Err() == nil {
+		page, err := mgr.ReadLog(ctx, id, offset, 0, jobLogStreamWait)
+		if err != nil {
+			fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error())
+			flusher.Flush()
+			return
+		}
+		if page.Data != "" {
+			data, _ := json.Marshal(page)
+			fmt.Fprintf(w, "event: log\ndata: %s\n\n", data)
+			offset = page.NextOffset
+		} else if !page.Done {
+			fmt.Fprintf(w, ": heartbeat\n\n")
+		}
+		if page.Done && page.Data == "" {
+			if info, err := mgr.GetJob(id); err == nil {
+				data, _ := json.Marshal(info)
+				fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+			}
+			flusher.Flush()
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/jordanhubbard/loom/internal/beads"
+)
+
+// maxBulkLabelBeads caps how many beads one bulk label request may change
+const maxBulkLabelBeads = 1000
+
+// handleBeadLabels handles bead labels in bulk
+// GET  /api/v1/beads/labels - Label keys in use with how many beads carry each
+// POST /api/v1/beads/labels - Add and remove labels on beads named by
+// {bead_ids} or matching {selector} (optionally within {project_id}):
+// {"add": ["security", "team=core"], "remove": ["wip"]}
+func (s *Server) handleBeadLabels(w http.ResponseWriter, r *http.Request) {
+	mgr := s.app.GetBeadsManager()
+	switch r.Method {
+	case http.MethodGet:
+		s.respondJSON(w, http.StatusOK, mgr.LabelKeys())
+	case http.MethodPost:
+		var req struct {
+			BeadIDs   []string `json:"bead_ids"`
+			Selector  string   `json:"selector"`
+			ProjectID string   `json:"project_id"`
+			Add       []string `json:"add"`
+			Remove    []string `json:"remove"`
+		}
+		if err := s.parseJSON(r, &req); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if (len(req.BeadIDs) == 0) == (req.Selector == "") {
+			s.respondError(w, http.StatusBadRequest, "exactly one of bead_ids and selector is required")
+			return
+		}
+		add, err := beads.ParseLabels(req.Add)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(add) == 0 && len(req.Remove) == 0 {
+			s.respondError(w, http.StatusBadRequest, "add or remove is required")
+			return
+		}
+
+		ids := req.BeadIDs
+		if req.Selector != "" {
+			sel, err := beads.ParseLabelSelector(req.Selector)
+			if err != nil {
+				s.respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			filters := map[string]interface{}{"labels": sel}
+			if req.ProjectID != "" {
+				filters["project_id"] = req.ProjectID
+			}
+			matched, err := mgr.ListBeads(filters)
+			if err != nil {
+				s.respondError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			ids = make([]string, 0, len(matched))
+			for _, b := range matched {
+				ids = append(ids, b.ID)
+			}
+			sort.Strings(ids)
+		}
+		if len(ids) > maxBulkLabelBeads {
+			s.respondError(w, http.StatusBadRequest, "too many beads; narrow the selector")
+			return
+		}
+
+		updated := []string{}
+		failed := map[string]string{}
+		for _, id := range ids {
+			_, err := s.app.UpdateBead(id, map[string]interface{}{"label_set": add, "label_remove": req.Remove})
+			if err != nil {
+				failed[id] = err.Error()
+				continue
+			}
+			updated = append(updated, id)
+		}
+		resp := map[string]interface{}{"updated": updated, "count": len(updated)}
+		if len(failed) > 0 {
+			resp["failed"] = failed
+		}
+		s.respondJSON(w, http.StatusOK, resp)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
@@ -8,13 +8,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/auth"
 	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var httpLog = logging.Module("http")
+
 // HandleLogsRecent returns recent log entries
 func (s *Server) HandleLogsRecent(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -45,7 +48,7 @@ func (s *Server) HandleLogsRecent(w http.ResponseWriter, r *http.Request) {
 		var parseErr error
 		since, parseErr = time.Parse(time.RFC3339, sinceStr)
 		if parseErr != nil {
-			http.Error(w, fmt.Sprintf("Invalid 'since' parameter: %v", parseErr), http.StatusBadRequest)
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid 'since' parameter: %v", parseErr))
 			return
 		}
 	}
@@ -53,14 +56,14 @@ func (s *Server) HandleLogsRecent(w http.ResponseWriter, r *http.Request) {
 		var parseErr error
 		until, parseErr = time.Parse(time.RFC3339, untilStr)
 		if parseErr != nil {
-			http.Error(w, fmt.Sprintf("Invalid 'until' parameter: %v", parseErr), http.StatusBadRequest)
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid 'until' parameter: %v", parseErr))
 			return
 		}
 	}
 
 	logs, err = s.logManager.Query(limit, level, source, agentID, beadID, projectID, since, until)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to query logs: %v", err), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to query logs: %v", err))
 		return
 	}
 
@@ -75,7 +78,7 @@ func (s *Server) HandleLogsRecent(w http.ResponseWriter, r *http.Request) {
 // HandleLogsStream streams log entries via Server-Sent Events (SSE)
 func (s *Server) HandleLogsStream(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -131,7 +134,7 @@ func (s *Server) HandleLogsStream(w http.ResponseWriter, r *http.Request) {
 	// Send logs to client
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Streaming not supported")
 		return
 	}
 
@@ -174,7 +177,7 @@ func (s *Server) HandleLogsStream(w http.ResponseWriter, r *http.Request) {
 // HandleLogsExport exports logs as JSON or CSV
 func (s *Server) HandleLogsExport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -198,7 +201,7 @@ func (s *Server) HandleLogsExport(w http.ResponseWriter, r *http.Request) {
 	if startTimeStr != "" {
 		startTime, err = time.Parse(time.RFC3339, startTimeStr)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Invalid 'start_time' parameter: %v", err), http.StatusBadRequest)
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid 'start_time' parameter: %v", err))
 			return
 		}
 	}
@@ -206,7 +209,7 @@ func (s *Server) HandleLogsExport(w http.ResponseWriter, r *http.Request) {
 	if endTimeStr != "" {
 		endTime, err = time.Parse(time.RFC3339, endTimeStr)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Invalid 'end_time' parameter: %v", err), http.StatusBadRequest)
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid 'end_time' parameter: %v", err))
 			return
 		}
 	}
@@ -214,7 +217,7 @@ func (s *Server) HandleLogsExport(w http.ResponseWriter, r *http.Request) {
 	// Query logs
 	logs, err := s.logManager.Query(0, level, source, agentID, beadID, projectID, startTime, endTime)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to export logs: %v", err), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to export logs: %v", err))
 		return
 	}
 
@@ -226,7 +229,7 @@ func (s *Server) HandleLogsExport(w http.ResponseWriter, r *http.Request) {
 			"logs":  logs,
 			"count": len(logs),
 		}); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 		}
 	case "csv":
 		w.Header().Set("Content-Type", "text/csv")
@@ -254,7 +257,7 @@ func (s *Server) HandleLogsExport(w http.ResponseWriter, r *http.Request) {
 			)
 		}
 	default:
-		http.Error(w, "Unsupported format. Use 'json' or 'csv'", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "Unsupported format. Use 'json' or 'csv'")
 	}
 }
 
@@ -267,3 +270,60 @@ func getLogMeta(meta map[string]interface{}, key string) string {
 	}
 	return ""
 }
+
+// logLevelsRequest changes the base level and module overrides; an empty
+// module level removes the override
+type logLevelsRequest struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+// handleLogLevels handles GET and PUT /api/v1/logs/levels
+func (s *Server) handleLogLevels(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.respondJSON(w, http.StatusOK, logging.Levels())
+	case http.MethodPut:
+		if auth.GetRoleFromRequest(r) != "admin" {
+			s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
+			return
+		}
+		var req logLevelsRequest
+		if err := s.parseJSON(r, &req); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		// Check everything before changing anything
+		if req.Level != "" {
+			if _, err := logging.ParseLevel(req.Level); err != nil {
+				s.respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		for module, level := range req.Modules {
+			if strings.TrimSpace(module) == "" {
+				s.respondError(w, http.StatusBadRequest, "module is required")
+				return
+			}
+			if level == "" {
+				continue
+			}
+			if _, err := logging.ParseLevel(level); err != nil {
+				s.respondError(w, http.StatusBadRequest, fmt.Sprintf("module %s: %v", module, err))
+				return
+			}
+		}
+
+		if req.Level != "" {
+			_ = logging.SetLevel(req.Level)
+		}
+		for module, level := range req.Modules {
+			_ = logging.SetModuleLevel(module, level)
+		}
+		httpLog.InfoContext(r.Context(), "Log levels changed", "user_id", auth.GetUserIDFromRequest(r),
+			"level", req.Level, "modules", req.Modules)
+		s.respondJSON(w, http.StatusOK, logging.Levels())
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/auth"
+	"github.com/jordanhubbard/loom/internal/housekeeping"
+	"github.com/jordanhubbard/loom/internal/maintenance"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// handleMaintenanceTemplates handles GET /api/v1/maintenance/templates,
+// listing the templates recurring maintenance beads are filed from
+func (s *Server) handleMaintenanceTemplates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	gen := s.app.GetMaintenance()
+	if gen == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Maintenance beads not available")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, gen.Templates())
+}
+
+// maintenanceTasks returns the runner of the maintenance loop's tasks, or
+// nil when there is none
+func (s *Server) maintenanceTasks() *housekeeping.Runner {
+	if s.app == nil {
+		return nil
+	}
+	return s.app.GetHousekeeping()
+}
+
+// handleMaintenanceTasks handles GET /api/v1/maintenance/tasks, listing the
+// maintenance loop's tasks with their last and next runs
+func (s *Server) handleMaintenanceTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	runner := s.maintenanceTasks()
+	if runner == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Maintenance tasks not available")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, runner.List())
+}
+
+// handleMaintenanceTask manages one task of the maintenance loop. Pausing
+// lasts until restart.
+// GET  /api/v1/maintenance/tasks/{name} - The task and its last run
+// POST /api/v1/maintenance/tasks/{name}/run - Run it now and return the outcome
+// POST /api/v1/maintenance/tasks/{name}/pause - Stop running it on schedule
+// POST /api/v1/maintenance/tasks/{name}/resume - Run it on schedule again
+func (s *Server) handleMaintenanceTask(w http.ResponseWriter, r *http.Request) {
+	name, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/maintenance/tasks/"), "/"), "/")
+	switch {
+	case action == "" && r.Method != http.MethodGet, action != "" && r.Method != http.MethodPost:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	case action != "" && auth.GetRoleFromRequest(r) != "admin":
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
+		return
+	}
+	runner := s.maintenanceTasks()
+	if runner == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Maintenance tasks not available")
+		return
+	}
+	if action == "" {
+		status, err := runner.Get(name)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, status)
+		return
+	}
+
+	var (
+		status housekeeping.Status
+		err    error
+	)
+	switch action {
+	case "run":
+		status, err = runner.Trigger(r.Context(), name)
+	case "pause":
+		status, err = runner.SetPaused(name, true)
+	case "resume":
+		status, err = runner.SetPaused(name, false)
+	default:
+		s.respondError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	httpLog.InfoContext(r.Context(), "Maintenance task "+action, "user_id", auth.GetUserIDFromRequest(r), "task", name)
+	s.respondJSON(w, http.StatusOK, status)
+}
+
+// handleProjectMaintenance manages a project's recurring maintenance beads
+// GET    /api/v1/projects/{id}/maintenance - The project's maintenance schedules
+// GET    /api/v1/projects/{id}/maintenance/{template} - A schedule and the beads it filed, newest first
+// PUT    /api/v1/projects/{id}/maintenance/{template} - Create or update it ({"interval": "168h", "enabled"})
+// DELETE /api/v1/projects/{id}/maintenance/{template} - Remove it; filed beads are kept
+// POST   /api/v1/projects/{id}/maintenance/{template}/run - File the next bead now
+func (s *Server) handleProjectMaintenance(w http.ResponseWriter, r *http.Request, projectID string, parts []string) {
+	gen := s.app.GetMaintenance()
+	sched := s.app.GetScheduler()
+	if gen == nil || sched == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Maintenance beads not available")
+		return
+	}
+	if _, err := s.app.GetProjectManager().GetProject(projectID); err != nil {
+		s.respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	sub := strings.Trim(strings.Join(parts, "/"), "/")
+	if sub == "" {
+		if r.Method != http.MethodGet {
+			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		s.respondJSON(w, http.StatusOK, sched.List(maintenance.ScheduleKind, projectID))
+		return
+	}
+	name, action, _ := strings.Cut(sub, "/")
+	tmpl, ok := gen.Template(name)
+	if !ok {
+		s.respondError(w, http.StatusNotFound, "Unknown maintenance template: "+name)
+		return
+	}
+	id := maintenance.ScheduleID(projectID, name)
+
+	switch {
+	case action == "run":
+		if r.Method != http.MethodPost {
+			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		result, err := sched.RunNow(r.Context(), id)
+		if err != nil {
+			s.respondError(w, scheduleErrorStatus(err), err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, result)
+	case action != "":
+		s.respondError(w, http.StatusNotFound, "Not found")
+	case r.Method == http.MethodGet:
+		existing, err := sched.Get(id)
+		if err != nil {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		instances, err := gen.Instances(projectID, id)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"schedule": existing, "instances": instances})
+	case r.Method == http.MethodPut:
+		var req struct {
+			Interval string `json:"interval"`
+			Enabled  *bool  `json:"enabled"`
+		}
+		if err := s.parseJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		interval := tmpl.Interval
+		if req.Interval != "" {
+			d, err := time.ParseDuration(req.Interval)
+			if err != nil {
+				s.respondError(w, http.StatusBadRequest, "invalid interval: "+err.Error())
+				return
+			}
+			interval = d
+		}
+		enabled := true
+		if req.Enabled != nil {
+			enabled = *req.Enabled
+		}
+		result, err := sched.Upsert(&models.Schedule{
+			ID:              id,
+			Name:            "Maintenance (" + name + ")",
+			Kind:            maintenance.ScheduleKind,
+			ProjectID:       projectID,
+			IntervalSeconds: int64(interval / time.Second),
+			Params:          map[string]string{"template": name},
+			Enabled:         enabled,
+		})
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, result)
+	case r.Method == http.MethodDelete:
+		if err := sched.Delete(id); err != nil {
+			s.respondError(w, scheduleErrorStatus(err), err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]string{"status": "deleted", "id": id})
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/jordanhubbard/loom/internal/mcp"
+)
+
+func (s *Server) mcpManager() *mcp.Manager {
+	if s.app == nil {
+		return nil
+	}
+	return s.app.GetMCPManager()
+}
+
+// handleProjectMCPTools lists the tools of a project's MCP servers
+// GET /api/v1/projects/{id}/mcp-tools - Tools per server; unreachable servers carry an error
+func (s *Server) handleProjectMCPTools(w http.ResponseWriter, r *http.Request, projectID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	mgr := s.mcpManager()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "MCP manager not available")
+		return
+	}
+	servers := mgr.ListTools(r.Context(), projectID)
+	if servers == nil {
+		servers = []mcp.ServerTools{}
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"project_id": projectID,
+		"servers":    servers,
+	})
+}
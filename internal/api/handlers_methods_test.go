@@ -1,7 +1,12 @@
 package api
 
 import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -9,8 +14,15 @@ import (
 	"time"
 
 	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/apperr"
 	"github.com/jordanhubbard/loom/internal/cache"
+	"github.com/jordanhubbard/loom/internal/features"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/internal/policy"
+	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
 	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 // ============================================================
@@ -1199,6 +1211,85 @@ func TestHandleLogsExport_InvalidStartTime(t *testing.T) {
 	}
 }
 
+func TestHandleLogLevels(t *testing.T) {
+	s := newTestServer()
+	t.Cleanup(func() {
+		_ = logging.SetLevel("info")
+		_ = logging.SetModuleLevel("dispatcher", "")
+	})
+
+	put := func(role, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/logs/levels", strings.NewReader(body))
+		req.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		s.handleLogLevels(w, req)
+		return w
+	}
+
+	if w := put("user", `{"level":"debug"}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for non-admin, got %d", w.Code)
+	}
+	if w := put("admin", `{"level":"warn","modules":{"dispatcher":"loud"}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown level, got %d", w.Code)
+	}
+	if got := logging.Levels().Level; got != "info" {
+		t.Errorf("expected a rejected request to change nothing, level is %s", got)
+	}
+
+	w := put("admin", `{"level":"warn","modules":{"dispatcher":"debug"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var settings logging.LevelSettings
+	if err := json.Unmarshal(w.Body.Bytes(), &settings); err != nil {
+		t.Fatal(err)
+	}
+	if settings.Level != "warn" || settings.Modules["dispatcher"] != "debug" {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/logs/levels", nil)
+	rec := httptest.NewRecorder()
+	s.handleLogLevels(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestLoggingMiddleware_RequestID(t *testing.T) {
+	s := newTestServer()
+	var seen string
+	handler := s.loggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.Field(r.Context(), logging.FieldRequestID)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/beads", nil)
+	req.Header.Set("X-Request-ID", "client-42")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if seen != "client-42" || w.Header().Get("X-Request-ID") != "client-42" {
+		t.Errorf("expected the client's request ID, got %q / %q", seen, w.Header().Get("X-Request-ID"))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/beads", nil)
+	req.Header.Set("X-Request-ID", "bad id\n")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if seen == "" || seen == "bad id\n" || w.Header().Get("X-Request-ID") != seen {
+		t.Errorf("expected a generated request ID, got %q", seen)
+	}
+}
+
+func TestHandleLogLevels_NonAdminKey(t *testing.T) {
+	s, key := apiKeyServer(t)
+	if w := keyRequest(s, key, s.handleLogLevels, http.MethodPut, "/api/v1/logs/levels", `{"level":"debug"}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a non-admin key, got %d: %s", w.Code, w.Body.String())
+	}
+	if logging.Levels().Level == "debug" {
+		t.Error("a non-admin key must not change the log level")
+	}
+}
+
 func TestHandleLogsExport_InvalidEndTime(t *testing.T) {
 	s := &Server{
 		config:         &config.Config{},
@@ -1371,6 +1462,336 @@ func TestHandleBead_PatchInvalidBody(t *testing.T) {
 	}
 }
 
+func TestHandleBead_PatchRequiresIfMatch(t *testing.T) {
+	s := newTestServer()
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/beads/b1", strings.NewReader(`{"title":"x"}`))
+	w := httptest.NewRecorder()
+	s.handleBead(w, req)
+	if w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/api/v1/beads/b1", strings.NewReader(`{"title":"x"}`))
+	req.Header.Set("If-Match", "three")
+	w = httptest.NewRecorder()
+	s.handleBead(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for malformed If-Match, got %d", w.Code)
+	}
+}
+
+func TestParseETag(t *testing.T) {
+	for tag, want := range map[string]int64{`"3"`: 3, `W/"12"`: 12, ` "0" `: 0} {
+		if got, ok := parseETag(tag); !ok || got != want {
+			t.Errorf("parseETag(%q) = %d, %v; want %d", tag, got, ok, want)
+		}
+	}
+	for _, tag := range []string{`3`, `"x"`, `"`, ``} {
+		if _, ok := parseETag(tag); ok {
+			t.Errorf("parseETag(%q) accepted a malformed tag", tag)
+		}
+	}
+	if tag := beadETag(&models.Bead{Version: 7}); tag != `"7"` {
+		t.Errorf("beadETag = %s", tag)
+	}
+}
+
+func TestHandleStateImport_Validation(t *testing.T) {
+	s := newTestServer()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/state/import", nil)
+	w := httptest.NewRecorder()
+	s.handleStateImport(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/state/import", strings.NewReader("not json"))
+	req.Header.Set("X-Role", "admin")
+	w = httptest.NewRecorder()
+	s.handleStateImport(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestHandleState_NonAdminKey(t *testing.T) {
+	s, key := apiKeyServer(t)
+	if w := keyRequest(s, key, s.handleStateExport, http.MethodGet, "/api/v1/state/export", ""); w.Code != http.StatusForbidden {
+		t.Errorf("export with a non-admin key: expected 403, got %d", w.Code)
+	}
+	if w := keyRequest(s, key, s.handleStateImport, http.MethodPost, "/api/v1/state/import", "{}"); w.Code != http.StatusForbidden {
+		t.Errorf("import with a non-admin key: expected 403, got %d", w.Code)
+	}
+}
+
+func TestHandleBackups_NotEnabled(t *testing.T) {
+	s := newTestServer()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/backups", nil)
+	w := httptest.NewRecorder()
+	s.handleBackups(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/backups/loom-backup-20260101T000000Z.tar.gz/verify", nil)
+	req.Header.Set("X-Role", "admin")
+	w = httptest.NewRecorder()
+	s.handleBackup(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+}
+
+func TestHandleBackups_NonAdminKey(t *testing.T) {
+	s, key := apiKeyServer(t)
+	if w := keyRequest(s, key, s.handleBackups, http.MethodPost, "/api/v1/backups", ""); w.Code != http.StatusForbidden {
+		t.Errorf("backup with a non-admin key: expected 403, got %d", w.Code)
+	}
+	if w := keyRequest(s, key, s.handleBackup, http.MethodPost, "/api/v1/backups/loom-backup-20260101T000000Z.tar.gz/verify", ""); w.Code != http.StatusForbidden {
+		t.Errorf("verify with a non-admin key: expected 403, got %d", w.Code)
+	}
+}
+
+func TestHandleRetention_NotEnabled(t *testing.T) {
+	s := newTestServer()
+	for path, handler := range map[string]http.HandlerFunc{
+		"/api/v1/storage":          s.handleStorage,
+		"/api/v1/archive/beads/b1": s.handleArchivedBead,
+	} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected 503, got %d", path, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleRetentionRun(w, httptest.NewRequest(http.MethodGet, "/api/v1/retention/run", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestHandleSearch_NotAvailable(t *testing.T) {
+	s := newTestServer()
+	w := httptest.NewRecorder()
+	s.handleSearch(w, httptest.NewRequest(http.MethodGet, "/api/v1/search?q=deploy", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestHandleDashboard_NotAvailable(t *testing.T) {
+	s := newTestServer()
+	w := httptest.NewRecorder()
+	s.handleDashboard(w, httptest.NewRequest(http.MethodGet, "/api/v1/dashboard", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleDashboard(w, httptest.NewRequest(http.MethodPost, "/api/v1/dashboard", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestHandlePatternReports_NotAvailable(t *testing.T) {
+	s := newTestServer()
+	for path, handler := range map[string]http.HandlerFunc{
+		"/api/v1/patterns/reports":        s.handlePatternReports,
+		"/api/v1/patterns/reports/r1":     s.handlePatternReport,
+		"/api/v1/patterns/trends":         s.handlePatternTrends,
+		"/api/v1/patterns/anomalies/live": s.handleLiveAnomalies,
+	} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected 503, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestHandlePricing_NotAvailable(t *testing.T) {
+	s := newTestServer()
+	for path, handler := range map[string]http.HandlerFunc{
+		"/api/v1/pricing/rates":       s.handlePricingRates,
+		"/api/v1/pricing/recalculate": s.handlePricingRecalculate,
+	} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}")))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected 503, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestHandleProviderQueues_NotAvailable(t *testing.T) {
+	s := newTestServer()
+	for path, handler := range map[string]http.HandlerFunc{
+		"/api/v1/provider-queues":        s.handleProviderQueues,
+		"/api/v1/providers/p1/queue":     s.handleProvider,
+		"/api/v1/provider-hedging":       s.handleProviderHedging,
+		"/api/v1/providers/p1/endpoints": s.handleProvider,
+	} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected 503, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestHandleRequestBatching_NotAvailable(t *testing.T) {
+	s := newTestServer()
+	for _, tc := range []struct {
+		method, path string
+		handler      http.HandlerFunc
+	}{
+		{http.MethodGet, "/api/v1/request-batching", s.handleRequestBatching},
+		{http.MethodPost, "/api/v1/embeddings", s.handleEmbeddings},
+	} {
+		w := httptest.NewRecorder()
+		tc.handler(w, httptest.NewRequest(tc.method, tc.path, strings.NewReader("{}")))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected 503, got %d", tc.path, w.Code)
+		}
+	}
+}
+
+func TestHandleMaintenanceTasks_NotAvailable(t *testing.T) {
+	s := newTestServer()
+	for _, tc := range []struct {
+		method, path string
+		handler      http.HandlerFunc
+	}{
+		{http.MethodGet, "/api/v1/maintenance/tasks", s.handleMaintenanceTasks},
+		{http.MethodGet, "/api/v1/maintenance/tasks/file_locks", s.handleMaintenanceTask},
+		{http.MethodPost, "/api/v1/maintenance/tasks/file_locks/run", s.handleMaintenanceTask},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("X-Role", "admin")
+		w := httptest.NewRecorder()
+		tc.handler(w, req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: expected 503, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+}
+
+func TestHandleMaintenanceTask_NonAdminKey(t *testing.T) {
+	s, key := apiKeyServer(t)
+	for _, action := range []string{"run", "pause", "resume"} {
+		if w := keyRequest(s, key, s.handleMaintenanceTask, http.MethodPost, "/api/v1/maintenance/tasks/file_locks/"+action, ""); w.Code != http.StatusForbidden {
+			t.Errorf("%s with a non-admin key: expected 403, got %d", action, w.Code)
+		}
+	}
+}
+
+func TestHandleDispatchQueue_NotAvailable(t *testing.T) {
+	s := newTestServer()
+	for _, tc := range []struct {
+		method, path string
+		handler      http.HandlerFunc
+	}{
+		{http.MethodGet, "/api/v1/dispatch/queue", s.handleDispatchQueue},
+		{http.MethodPost, "/api/v1/dispatch/queue/bd-1/pin", s.handleDispatchQueueBead},
+		{http.MethodDelete, "/api/v1/dispatch/queue/bd-1/pin", s.handleDispatchQueueBead},
+	} {
+		w := httptest.NewRecorder()
+		tc.handler(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: expected 503, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+}
+
+func TestHandleProjectMCPTools_NotAvailable(t *testing.T) {
+	s := newTestServer()
+	w := httptest.NewRecorder()
+	s.handleProject(w, httptest.NewRequest(http.MethodGet, "/api/v1/projects/p1/mcp-tools", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+}
+
+func TestHandleIDE_NotAvailable(t *testing.T) {
+	s := newTestServer()
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/ide/files?project_id=p1&path=main.go"},
+		{http.MethodGet, "/api/v1/ide/beads/bd-1"},
+		{http.MethodGet, "/api/v1/ide/beads/bd-1/files"},
+		{http.MethodPost, "/api/v1/ide/beads/bd-1/feedback"},
+		{http.MethodGet, "/api/v1/ide/stream"},
+		{http.MethodPost, "/api/v1/ide/pair"},
+	} {
+		w := httptest.NewRecorder()
+		s.handleIDE(w, httptest.NewRequest(tc.method, tc.path, strings.NewReader("{}")))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: expected 503, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+
+	// Without auth editors need no handshake
+	w := httptest.NewRecorder()
+	s.handleIDE(w, httptest.NewRequest(http.MethodPost, "/api/v1/ide/handshake", strings.NewReader(`{"client":"vscode"}`)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"auth_required":false`) {
+		t.Errorf("expected no auth required, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	s.handleIDE(w, httptest.NewRequest(http.MethodGet, "/api/v1/ide/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestIDEEventMatches(t *testing.T) {
+	fileEvent := &eventbus.Event{Type: eventbus.EventTypeFileChanged, ProjectID: "p1", Data: map[string]interface{}{"bead_id": "bd-1", "path": "main.go"}}
+	beadEvent := &eventbus.Event{Type: eventbus.EventTypeBeadStatusChange, ProjectID: "p1", Data: map[string]interface{}{"bead_id": "bd-1"}}
+	other := &eventbus.Event{Type: eventbus.EventTypeProviderUpdated, ProjectID: "p1"}
+
+	cases := []struct {
+		event               *eventbus.Event
+		project, bead, path string
+		want                bool
+	}{
+		{fileEvent, "", "", "", true},
+		{fileEvent, "p1", "bd-1", "main.go", true},
+		{fileEvent, "p2", "", "", false},
+		{fileEvent, "", "bd-2", "", false},
+		{fileEvent, "", "", "other.go", false},
+		{beadEvent, "p1", "bd-1", "", true},
+		{beadEvent, "p1", "bd-1", "main.go", true},
+		{beadEvent, "p1", "", "main.go", false},
+		{other, "", "", "", false},
+	}
+	for i, c := range cases {
+		if got := ideEventMatches(c.event, c.project, c.bead, c.path); got != c.want {
+			t.Errorf("case %d: expected %v, got %v", i, c.want, got)
+		}
+	}
+}
+
+func TestParseSearchTime(t *testing.T) {
+	until, err := parseSearchTime("2026-03-01", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 1, 23, 59, 59, 999999999, time.UTC); !until.Equal(want) {
+		t.Errorf("until = %v, want %v", until, want)
+	}
+	if _, err := parseSearchTime("yesterday", false); err == nil {
+		t.Error("expected error for unparseable time")
+	}
+}
+
 // ============================================================
 // Decision handler validation
 // ============================================================
@@ -1488,3 +1909,299 @@ func TestRespondJSON_WithNestedStruct(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRespondError_Body(t *testing.T) {
+	s := newTestServer()
+	w := httptest.NewRecorder()
+	s.respondError(w, http.StatusNotFound, "Bead not found")
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["code"] != "not_found" || body["message"] != "Bead not found" || body["error"] != "Bead not found" {
+		t.Errorf("unexpected body %v", body)
+	}
+
+	w = httptest.NewRecorder()
+	s.respondAppError(w, apperr.Conflict("bead already claimed by agent a1").WithDetail("assigned_to", "a1"))
+	body = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	details, _ := body["details"].(map[string]interface{})
+	if w.Code != http.StatusConflict || body["code"] != "conflict" || details["assigned_to"] != "a1" {
+		t.Errorf("unexpected response %d %v", w.Code, body)
+	}
+}
+
+func TestHandleFeatures(t *testing.T) {
+	s := newTestServer()
+	registry, err := features.New(config.FeaturesConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.features = registry
+
+	put := func(role, name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/features/"+name, strings.NewReader(body))
+		req.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		s.handleFeature(w, req)
+		return w
+	}
+
+	if w := put("user", "parallel_actions", `{"enabled":true}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for non-admin, got %d", w.Code)
+	}
+	if w := put("admin", "warp_drive", `{"enabled":true}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown flag, got %d", w.Code)
+	}
+	w := put("admin", "parallel_actions", `{"enabled":true,"project_id":"p1"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var state features.State
+	if err := json.Unmarshal(w.Body.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if !state.Enabled || state.Source != features.SourceProjectRuntime {
+		t.Errorf("unexpected state %+v", state)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleFeatures(w, httptest.NewRequest(http.MethodGet, "/api/v1/features?project_id=p2", nil))
+	var resp struct {
+		Flags []features.State `json:"flags"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range resp.Flags {
+		if f.Name == features.ParallelActions && f.Enabled {
+			t.Errorf("expected the p1 override not to apply to p2, got %+v", f)
+		}
+	}
+
+	// Without the flag, the cache optimizer refuses to auto-enable
+	w = httptest.NewRecorder()
+	s.handleCacheOptimize(w, httptest.NewRequest(http.MethodPost, "/api/v1/cache/optimize", strings.NewReader(`{"auto_enable":true}`)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for auto_enable with the flag off, got %d", w.Code)
+	}
+}
+
+func TestHandlePolicies(t *testing.T) {
+	s := newTestServer()
+
+	w := httptest.NewRecorder()
+	s.handlePolicies(w, httptest.NewRequest(http.MethodGet, "/api/v1/policies", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without policies, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/policies/reload", nil)
+	req.Header.Set("X-Role", "user")
+	w = httptest.NewRecorder()
+	s.handleReloadPolicies(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for non-admin reload, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleEvaluatePolicy(w, httptest.NewRequest(http.MethodPost, "/api/v1/policies/evaluate", strings.NewReader(`{}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without action_type, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleEvaluatePolicy(w, httptest.NewRequest(http.MethodPost, "/api/v1/policies/evaluate",
+		strings.NewReader(`{"project_id":"p1","action_type":"run_command","command":"ls"}`)))
+	var resp struct {
+		Decision policy.Decision `json:"decision"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || resp.Decision.Effect != policy.Allow {
+		t.Errorf("expected allow without policies, got %d %+v", w.Code, resp.Decision)
+	}
+}
+
+func TestHandlePprof(t *testing.T) {
+	s := newTestServer()
+	req := httptest.NewRequest(http.MethodGet, pprofPrefix, nil)
+	req.Header.Set("X-Role", "admin")
+	w := httptest.NewRecorder()
+	s.handlePprof(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected pprof to be off unless configured, got %d", w.Code)
+	}
+
+	s.config.Profiling.ServerPprof = true
+	get := func(role, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		s.handlePprof(w, req)
+		return w
+	}
+
+	if w := get("user", pprofPrefix); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for non-admin, got %d", w.Code)
+	}
+	if w := get("admin", pprofPrefix); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine") {
+		t.Errorf("expected the profile index, got %d", w.Code)
+	}
+	if w := get("admin", pprofPrefix+"goroutine?debug=1"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine profile:") {
+		t.Errorf("expected a goroutine dump, got %d", w.Code)
+	}
+	if w := get("admin", pprofPrefix+"nonsense"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown profile, got %d", w.Code)
+	}
+}
+
+func TestHandleFeature_NonAdminKey(t *testing.T) {
+	s, key := apiKeyServer(t)
+	if w := keyRequest(s, key, s.handleFeature, http.MethodPut, "/api/v1/features/parallel_actions", `{"enabled":true}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a non-admin key, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCheckSessionOrigin(t *testing.T) {
+	s := newTestServer()
+	s.config.Security.AllowedOrigins = []string{"*", "https://ui.example.com"}
+	for _, tc := range []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://loom.local:8080", true},
+		{"https://ui.example.com", true},
+		{"https://evil.example.com", false},
+		{"http://loom.local:9999", false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "http://loom.local:8080/api/v1/sessions/ws", nil)
+		if tc.origin != "" {
+			req.Header.Set("Origin", tc.origin)
+		}
+		if got := s.checkSessionOrigin(req); got != tc.want {
+			t.Errorf("origin %q: allowed = %v, want %v", tc.origin, got, tc.want)
+		}
+	}
+}
+
+func TestHandleReloadPolicies_NonAdminKey(t *testing.T) {
+	s, key := apiKeyServer(t)
+	if w := keyRequest(s, key, s.handleReloadPolicies, http.MethodPost, "/api/v1/policies/reload", ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a non-admin key, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleExports(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	storage, err := analytics.NewDatabaseStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logMgr := logging.NewManager(db)
+	base := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := storage.SaveLog(context.Background(), &analytics.RequestLog{
+			ID: fmt.Sprintf("log-%d", i), Timestamp: base.Add(time.Duration(i) * time.Hour),
+			UserID: []string{"alice", "bob"}[i%2], ProviderID: "openai", ModelName: "gpt-4", CostUSD: 0.5,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`INSERT INTO logs (id, timestamp, level, source, message, metadata_json, bead_id)
+			VALUES (?, ?, 'info', ?, 'action executed', ?, 'bd-1')`,
+			fmt.Sprintf("entry-%d", i), base.Add(time.Duration(i)*time.Hour), []string{"actions", "http", "actions"}[i],
+			`{"action_type":"write_file","status":"executed","bead_id":"bd-1"}`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &Server{
+		config:          &config.Config{Security: config.SecurityConfig{EnableAuth: true}},
+		apiFailureLast:  make(map[string]time.Time),
+		analyticsLogger: analytics.NewLogger(storage, nil),
+		logManager:      logMgr,
+	}
+	get := func(handler http.HandlerFunc, url string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+	admin := map[string]string{"X-User-ID": "root", "X-Role": "admin"}
+
+	// A user exports only their own request logs, oldest first
+	w := get(s.handleExportRequestLogs, "/api/v1/export/request-logs", map[string]string{"X-User-ID": "alice"})
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" || len(lines) != 2 {
+		t.Fatalf("user export = %d %q:\n%s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	var first analytics.RequestLog
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first.ID != "log-0" {
+		t.Errorf("first record = %+v, %v", first, err)
+	}
+
+	// Admins filter by time range and can take CSV, gzipped
+	w = get(s.handleExportRequestLogs, "/api/v1/export/request-logs?format=csv&gzip=true&start_time="+base.Add(time.Hour).Format(time.RFC3339), admin)
+	if w.Code != http.StatusOK || !strings.HasSuffix(w.Header().Get("Content-Disposition"), `.csv.gz"`) {
+		t.Fatalf("gzip export = %d %v", w.Code, w.Header())
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(zr).ReadAll()
+	if err != nil || len(rows) != 3 || rows[0][0] != "id" || rows[1][0] != "log-1" || rows[2][12] != "0.5" {
+		t.Errorf("csv rows = %v, %v", rows, err)
+	}
+
+	// Action logs are the actions module's entries and need an admin
+	if w := get(s.handleExportActionLogs, "/api/v1/export/action-logs", map[string]string{"X-User-ID": "alice"}); w.Code != http.StatusForbidden {
+		t.Errorf("non-admin action log export = %d, want 403", w.Code)
+	}
+	w = get(s.handleExportActionLogs, "/api/v1/export/action-logs?format=csv&bead_id=bd-1", admin)
+	rows, err = csv.NewReader(w.Body).ReadAll()
+	if err != nil || len(rows) != 3 || rows[1][0] != "entry-0" || rows[2][6] != "write_file" || rows[2][4] != "bd-1" {
+		t.Errorf("action log rows = %v, %v", rows, err)
+	}
+
+	if w := get(s.handleExportActionLogs, "/api/v1/export/action-logs?format=xml", admin); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format = %d, want 400", w.Code)
+	}
+	if w := get(s.handleExportRequestLogs, "/api/v1/export/request-logs?end_time=yesterday", admin); w.Code != http.StatusBadRequest {
+		t.Errorf("bad end_time = %d, want 400", w.Code)
+	}
+	if w := get(s.handleExportAuditEvents, "/api/v1/export/audit-events", admin); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("audit export without an activity feed = %d, want 503", w.Code)
+	}
+
+	// A non-admin API key claiming admin stays scoped to its own logs and
+	// can't export unscoped data
+	ks, key := apiKeyServer(t)
+	ks.analyticsLogger, ks.logManager = s.analyticsLogger, s.logManager
+	if w := keyRequest(ks, key, ks.handleExportRequestLogs, http.MethodGet, "/api/v1/export/request-logs?user_id=alice", ""); w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "" {
+		t.Errorf("non-admin key export of another user's logs = %d:\n%s", w.Code, w.Body.String())
+	}
+	for _, tc := range []struct {
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"/api/v1/export/audit-events", ks.handleExportAuditEvents},
+		{"/api/v1/export/action-logs", ks.handleExportActionLogs},
+	} {
+		if w := keyRequest(ks, key, tc.handler, http.MethodGet, tc.path, ""); w.Code != http.StatusForbidden {
+			t.Errorf("%s with a non-admin key = %d, want 403", tc.path, w.Code)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -121,7 +120,7 @@ func (s *Server) handlePairChat(w http.ResponseWriter, r *http.Request) {
 
 	// Save user message immediately
 	if err := db.UpdateConversationContext(conversationCtx); err != nil {
-		log.Printf("Warning: Failed to save user message: %v", err)
+		httpLog.Warn("Failed to save user message", "session_id", conversationCtx.SessionID, "error", err)
 	}
 
 	// Build provider message list from conversation history
@@ -165,12 +164,26 @@ func (s *Server) handlePairChat(w http.ResponseWriter, r *http.Request) {
 		Stream:      true,
 	}
 
-	// Create context with timeout
+	// Create context with timeout; the conversation's streams stay on one
+	// endpoint of a balanced provider
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
 	defer cancel()
+	ctx = provider.WithSessionKey(ctx, conversationCtx.SessionID)
 
 	var streamedText strings.Builder
 
+	// Start read-only actions as soon as they are complete in the stream
+	router := s.app.GetActionRouter()
+	actx := actions.ActionContext{
+		AgentID:   req.AgentID,
+		BeadID:    req.BeadID,
+		ProjectID: conversationCtx.ProjectID,
+	}
+	var early *actions.EarlyDispatcher
+	if router != nil {
+		early = router.NewEarlyDispatcher(ctx, actx)
+	}
+
 	// Stream response
 	err = providerReg.SendChatCompletionStream(ctx, providerID, providerReq, func(chunk *provider.StreamChunk) error {
 		select {
@@ -181,6 +194,9 @@ func (s *Server) handlePairChat(w http.ResponseWriter, r *http.Request) {
 
 		if len(chunk.Choices) > 0 {
 			streamedText.WriteString(chunk.Choices[0].Delta.Content)
+			if early != nil {
+				early.Write(chunk.Choices[0].Delta.Content)
+			}
 		}
 
 		data, err := json.Marshal(chunk)
@@ -205,19 +221,14 @@ func (s *Server) handlePairChat(w http.ResponseWriter, r *http.Request) {
 	responseText := streamedText.String()
 	conversationCtx.AddMessage("assistant", responseText, len(responseText)/4)
 	if err := db.UpdateConversationContext(conversationCtx); err != nil {
-		log.Printf("Warning: Failed to save assistant response: %v", err)
+		httpLog.Warn("Failed to save assistant response", "session_id", conversationCtx.SessionID, "error", err)
 	}
 
 	// Try lenient action parsing (optional — no error if no actions)
-	if router := s.app.GetActionRouter(); router != nil {
-		actx := actions.ActionContext{
-			AgentID:   req.AgentID,
-			BeadID:    req.BeadID,
-			ProjectID: conversationCtx.ProjectID,
-		}
+	if router != nil {
 		env, parseErr := actions.DecodeLenient([]byte(responseText))
 		if parseErr == nil && env != nil && len(env.Actions) > 0 {
-			results, _ := router.Execute(ctx, env, actx)
+			results, _ := early.Finish(ctx, env)
 			actionData, _ := json.Marshal(map[string]any{
 				"actions": env.Actions,
 				"results": results,
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// handlePatternReports handles GET /api/v1/patterns/reports, listing stored
+// pattern reports newest first, and POST, which stores a report now
+func (s *Server) handlePatternReports(w http.ResponseWriter, r *http.Request) {
+	if s.app == nil || s.app.GetPatternManager() == nil || !s.app.GetPatternManager().HasReportStore() {
+		s.respondError(w, http.StatusServiceUnavailable, "Pattern report history not available")
+		return
+	}
+	pm := s.app.GetPatternManager()
+
+	switch r.Method {
+	case http.MethodGet:
+		limit := 50
+		if v := r.URL.Query().Get("limit"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				limit = n
+			}
+		}
+		reports, err := pm.ListReports(r.Context(), limit)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"reports": reports, "count": len(reports)})
+	case http.MethodPost:
+		stored, err := pm.Snapshot(r.Context())
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusCreated, stored)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handlePatternReport handles GET /api/v1/patterns/reports/{id}
+func (s *Server) handlePatternReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil || s.app.GetPatternManager() == nil || !s.app.GetPatternManager().HasReportStore() {
+		s.respondError(w, http.StatusServiceUnavailable, "Pattern report history not available")
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/patterns/reports/"), "/")
+	stored, err := s.app.GetPatternManager().GetReport(r.Context(), id)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if stored == nil {
+		s.respondError(w, http.StatusNotFound, "Report not found")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, stored)
+}
+
+// handlePatternTrends handles GET /api/v1/patterns/trends, returning how the
+// newest stored report moved against its baseline
+func (s *Server) handlePatternTrends(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil || s.app.GetPatternManager() == nil || !s.app.GetPatternManager().HasReportStore() {
+		s.respondError(w, http.StatusServiceUnavailable, "Pattern report history not available")
+		return
+	}
+	trends, err := s.app.GetPatternManager().LatestTrends(r.Context())
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if trends == nil {
+		s.respondError(w, http.StatusNotFound, "No report has a baseline to compare against yet")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, trends)
+}
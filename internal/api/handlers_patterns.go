@@ -12,7 +12,7 @@ import (
 // handlePatternAnalysis handles GET /api/v1/patterns/analysis
 func (s *Server) handlePatternAnalysis(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -40,27 +40,27 @@ func (s *Server) handlePatternAnalysis(w http.ResponseWriter, r *http.Request) {
 	// Get pattern manager
 	patternManager := s.app.GetPatternManager()
 	if patternManager == nil {
-		http.Error(w, "Pattern analysis not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Pattern analysis not available")
 		return
 	}
 
 	// Run analysis
 	report, err := patternManager.AnalyzePatterns(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(report); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleExpensivePatterns handles GET /api/v1/patterns/expensive
 func (s *Server) handleExpensivePatterns(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -75,14 +75,14 @@ func (s *Server) handleExpensivePatterns(w http.ResponseWriter, r *http.Request)
 	// Get pattern manager
 	patternManager := s.app.GetPatternManager()
 	if patternManager == nil {
-		http.Error(w, "Pattern analysis not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Pattern analysis not available")
 		return
 	}
 
 	// Get expensive patterns
 	patterns, err := patternManager.GetExpensivePatterns(r.Context(), limit)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -93,28 +93,28 @@ func (s *Server) handleExpensivePatterns(w http.ResponseWriter, r *http.Request)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleAnomalies handles GET /api/v1/patterns/anomalies
 func (s *Server) handleAnomalies(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Get pattern manager
 	patternManager := s.app.GetPatternManager()
 	if patternManager == nil {
-		http.Error(w, "Pattern analysis not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Pattern analysis not available")
 		return
 	}
 
 	// Get anomalies
 	anomalies, err := patternManager.GetAnomalies(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -125,28 +125,56 @@ func (s *Server) handleAnomalies(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
+// handleLiveAnomalies handles GET /api/v1/patterns/anomalies/live, returning
+// the anomalies flagged as requests were logged and the baselines they were
+// measured against
+func (s *Server) handleLiveAnomalies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil || s.app.GetPatternManager() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Pattern analysis not available")
+		return
+	}
+	pm := s.app.GetPatternManager()
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	anomalies := pm.LiveAnomalies(limit)
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"anomalies": anomalies,
+		"count":     len(anomalies),
+		"baselines": pm.StreamStats(),
+	})
+}
+
 // handleOptimizations handles GET /api/v1/optimizations
 func (s *Server) handleOptimizations(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Get pattern manager
 	patternManager := s.app.GetPatternManager()
 	if patternManager == nil {
-		http.Error(w, "Pattern analysis not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Pattern analysis not available")
 		return
 	}
 
 	// Get comprehensive report
 	report, err := patternManager.AnalyzeAll(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -185,28 +213,28 @@ func (s *Server) handleOptimizations(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleSubstitutions handles GET /api/v1/optimizations/substitutions
 func (s *Server) handleSubstitutions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Get pattern manager
 	patternManager := s.app.GetPatternManager()
 	if patternManager == nil {
-		http.Error(w, "Pattern analysis not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Pattern analysis not available")
 		return
 	}
 
 	// Get all optimizations
 	optimizations, err := patternManager.GetOptimizations(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -225,14 +253,14 @@ func (s *Server) handleSubstitutions(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleOptimizationActions handles POST /api/v1/optimizations/{id}/apply
 func (s *Server) handleOptimizationActions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -247,41 +275,41 @@ func (s *Server) handleOptimizationActions(w http.ResponseWriter, r *http.Reques
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handlePromptAnalysis handles GET /api/v1/prompts/analysis
 func (s *Server) handlePromptAnalysis(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Get pattern manager
 	patternManager := s.app.GetPatternManager()
 	if patternManager == nil {
-		http.Error(w, "Pattern analysis not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Pattern analysis not available")
 		return
 	}
 
 	// Run prompt analysis
 	report, err := patternManager.AnalyzePrompts(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(report); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handlePromptOptimizations handles GET /api/v1/prompts/optimizations
 func (s *Server) handlePromptOptimizations(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -296,14 +324,14 @@ func (s *Server) handlePromptOptimizations(w http.ResponseWriter, r *http.Reques
 	// Get pattern manager
 	patternManager := s.app.GetPatternManager()
 	if patternManager == nil {
-		http.Error(w, "Pattern analysis not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Pattern analysis not available")
 		return
 	}
 
 	// Get prompt optimizations
 	optimizations, err := patternManager.GetPromptOptimizations(r.Context(), limit)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -314,6 +342,6 @@ func (s *Server) handlePromptOptimizations(w http.ResponseWriter, r *http.Reques
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
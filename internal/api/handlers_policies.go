@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/auth"
+	"github.com/jordanhubbard/loom/internal/policy"
+)
+
+// policyEngine returns the action policy engine, nil when policies are off
+func (s *Server) policyEngine() *policy.Engine {
+	if s.app == nil {
+		return nil
+	}
+	return s.app.GetPolicies()
+}
+
+// handlePolicies handles GET /api/v1/policies
+func (s *Server) handlePolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	engine := s.policyEngine()
+	if engine == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Action policies not configured")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"loaded_at": engine.LoadedAt(),
+		"policies":  engine.Policies(),
+	})
+}
+
+// handleReloadPolicies handles POST /api/v1/policies/reload, for applying
+// edits before the next periodic check
+func (s *Server) handleReloadPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if auth.GetRoleFromRequest(r) != "admin" {
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
+		return
+	}
+	engine := s.policyEngine()
+	if engine == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Action policies not configured")
+		return
+	}
+	reloaded, err := engine.Reload()
+	if err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if reloaded {
+		httpLog.InfoContext(r.Context(), "Action policies reloaded", "user_id", auth.GetUserIDFromRequest(r))
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"reloaded":  reloaded,
+		"loaded_at": engine.LoadedAt(),
+		"policies":  len(engine.Policies()),
+	})
+}
+
+// handleEvaluatePolicy handles POST /api/v1/policies/evaluate: a dry run
+// of the decision for an input, without requesting any approval
+func (s *Server) handleEvaluatePolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	var in policy.Input
+	if err := s.parseJSON(r, &in); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if in.ActionType == "" {
+		s.respondError(w, http.StatusBadRequest, "action_type is required")
+		return
+	}
+	if in.Time.IsZero() {
+		in.Time = time.Now()
+	}
+	if in.AgentID != "" && s.app != nil {
+		if in.Role == "" {
+			in.Role = s.app.AgentRole(in.AgentID)
+		}
+		if in.CostUSD == 0 {
+			in.CostUSD = s.app.AgentSpendToday(r.Context(), in.AgentID)
+		}
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"input":    in,
+		"decision": s.policyEngine().Evaluate(in),
+	})
+}
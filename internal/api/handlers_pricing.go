@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jordanhubbard/loom/internal/pricing"
+)
+
+// handlePricingRates handles GET /api/v1/pricing/rates, listing rates newest
+// first, and POST, which adds a rate
+func (s *Server) handlePricingRates(w http.ResponseWriter, r *http.Request) {
+	if s.app == nil || s.app.GetPricing() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Pricing not available")
+		return
+	}
+	svc := s.app.GetPricing()
+
+	switch r.Method {
+	case http.MethodGet:
+		provider := r.URL.Query().Get("provider_id")
+		model := r.URL.Query().Get("model")
+		rates := []*pricing.Rate{}
+		for _, rate := range svc.Rates() {
+			if (provider == "" || rate.ProviderID == provider) && (model == "" || rate.ModelName == model) {
+				rates = append(rates, rate)
+			}
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"rates": rates, "count": len(rates)})
+	case http.MethodPost:
+		var req struct {
+			ProviderID    string  `json:"provider_id"`
+			ModelName     string  `json:"model_name"`
+			InputPerMTok  float64 `json:"input_per_mtok"`
+			OutputPerMTok float64 `json:"output_per_mtok"`
+			EffectiveFrom string  `json:"effective_from"`
+			Note          string  `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		effective, err := pricing.ParseEffective(req.EffectiveFrom)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		rate, err := svc.AddRate(r.Context(), &pricing.Rate{
+			ProviderID:    req.ProviderID,
+			ModelName:     req.ModelName,
+			InputPerMTok:  req.InputPerMTok,
+			OutputPerMTok: req.OutputPerMTok,
+			EffectiveFrom: effective,
+			Note:          req.Note,
+		})
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusCreated, rate)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handlePricingRecalculate handles POST /api/v1/pricing/recalculate,
+// re-pricing stored request logs with the rates in effect when they were made
+func (s *Server) handlePricingRecalculate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil || s.app.GetPricing() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Pricing not available")
+		return
+	}
+
+	var req struct {
+		ProviderID string `json:"provider_id"`
+		ModelName  string `json:"model_name"`
+		Since      string `json:"since"`
+		Until      string `json:"until"`
+		DryRun     bool   `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	since, err := parseSearchTime(req.Since, false)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid since: use RFC3339 or YYYY-MM-DD")
+		return
+	}
+	until, err := parseSearchTime(req.Until, true)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid until: use RFC3339 or YYYY-MM-DD")
+		return
+	}
+
+	result, err := s.app.GetPricing().Recalculate(r.Context(), pricing.RecalcOptions{
+		ProviderID: req.ProviderID,
+		ModelName:  req.ModelName,
+		Since:      since,
+		Until:      until,
+		DryRun:     req.DryRun,
+	})
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, result)
+}
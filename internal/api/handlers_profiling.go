@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/auth"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+const pprofPrefix = "/api/v1/debug/pprof/"
+
+// handlePprof serves the server's own runtime profiles to admins, when
+// profiling.server_pprof is set
+// GET /api/v1/debug/pprof/ - Index of the available profiles
+// GET /api/v1/debug/pprof/profile?seconds=N - CPU profile
+// GET /api/v1/debug/pprof/trace?seconds=N - Execution trace
+// GET /api/v1/debug/pprof/{heap,goroutine,allocs,block,mutex,threadcreate} - Snapshot profiles
+// GET /api/v1/debug/pprof/{cmdline,symbol}
+func (s *Server) handlePprof(w http.ResponseWriter, r *http.Request) {
+	if s.config == nil || !s.config.Profiling.ServerPprof {
+		s.respondError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	if auth.GetRoleFromRequest(r) != "admin" {
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
+		return
+	}
+
+	switch name := strings.TrimPrefix(r.URL.Path, pprofPrefix); name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "profile", "trace":
+		// Both sample for ?seconds=, which may outlast the server's write
+		// timeout; pprof refuses such requests unless the deadline is lifted
+		seconds, _ := strconv.Atoi(r.URL.Query().Get("seconds"))
+		if seconds <= 0 {
+			seconds = 30
+		}
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Now().Add(time.Duration(seconds)*time.Second + time.Minute)); err == nil {
+			r = r.WithContext(context.WithValue(r.Context(), http.ServerContextKey, nil))
+		}
+		if name == "profile" {
+			pprof.Profile(w, r)
+		} else {
+			pprof.Trace(w, r)
+		}
+	default:
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
+
+// handleBeadProfiles handles the pprof files profile_project kept on a bead
+// GET /api/v1/beads/{id}/profiles - The bead's profiles and top functions, without their data
+// GET /api/v1/beads/{id}/profiles/{profileID} - The pprof file itself, for go tool pprof
+func (s *Server) handleBeadProfiles(w http.ResponseWriter, r *http.Request, beadID, profileID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	mgr := s.app.GetProfiler()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Profiling not available")
+		return
+	}
+
+	if profileID == "" {
+		profiles, err := mgr.List(beadID)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if profiles == nil {
+			profiles = []*models.Profile{}
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"bead_id": beadID, "profiles": profiles})
+		return
+	}
+
+	profile, err := mgr.Get(profileID)
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	if profile.BeadID != beadID {
+		s.respondError(w, http.StatusNotFound, "Profile not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": profile.ID + "-" + profile.Name}))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(profile.Data)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 
+	loompkg "github.com/jordanhubbard/loom/internal/loom"
 	"github.com/jordanhubbard/loom/internal/project"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
@@ -317,3 +318,46 @@ func (s *Server) handleBootstrapProject(w http.ResponseWriter, r *http.Request)
 
 	s.respondJSON(w, http.StatusCreated, result)
 }
+
+// handleProvisionProject handles POST /api/v1/projects/provision. It clones
+// git_url into a managed workdir, registers the project and files setup beads.
+func (s *Server) handleProvisionProject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req loompkg.ProvisionRequest
+	if err := s.parseJSON(r, &req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	result, err := s.app.ProvisionProject(r.Context(), req)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusCreated, result)
+}
+
+// handleRetryProvisioning handles POST /api/v1/projects/{id}/provision,
+// retrying the clone after a failed provisioning (e.g. once the deploy key
+// has been added to the repository).
+func (s *Server) handleRetryProvisioning(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if _, err := s.app.GetProjectManager().GetProject(id); err != nil {
+		s.respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	result, err := s.app.RetryProvisioning(r.Context(), id)
+	if err != nil {
+		s.respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, result)
+}
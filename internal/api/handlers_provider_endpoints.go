@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+)
+
+// handleProviderEndpoints handles GET /api/v1/providers/{id}/endpoints,
+// reporting the rolling latency, error rate and load of each endpoint the
+// provider's requests are balanced across
+func (s *Server) handleProviderEndpoints(w http.ResponseWriter, r *http.Request, providerID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil || s.app.GetProviderRegistry() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Provider registry not available")
+		return
+	}
+	endpoints, err := s.app.GetProviderRegistry().EndpointStats(providerID)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{"endpoints": endpoints, "count": len(endpoints)})
+}
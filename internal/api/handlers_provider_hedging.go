@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+)
+
+// handleProviderHedging handles GET /api/v1/provider-hedging, reporting how
+// often hedged requests were won by the second provider and what the
+// losing requests cost
+func (s *Server) handleProviderHedging(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil || s.app.GetProviderRegistry() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Provider registry not available")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, s.app.GetProviderRegistry().HedgeStats())
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/jordanhubbard/loom/internal/provider"
+)
+
+// requestQueue returns the provider rate-limit queue, or nil if requests
+// aren't queued
+func (s *Server) requestQueue() *provider.RequestQueue {
+	if s.app == nil || s.app.GetProviderRegistry() == nil {
+		return nil
+	}
+	return s.app.GetProviderRegistry().RequestQueue()
+}
+
+// handleProviderQueues handles GET /api/v1/provider-queues, listing the
+// rate-limit queue of every provider that has been used
+func (s *Server) handleProviderQueues(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	q := s.requestQueue()
+	if q == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Provider queueing not enabled")
+		return
+	}
+	queues := q.Statuses()
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{"queues": queues, "count": len(queues)})
+}
+
+// handleProviderQueue handles GET /api/v1/providers/{id}/queue. The optional
+// priority (0-3, default 2) sets which requests count as ahead when
+// estimating the wait, so an agent can decide whether to switch models.
+func (s *Server) handleProviderQueue(w http.ResponseWriter, r *http.Request, providerID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	q := s.requestQueue()
+	if q == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Provider queueing not enabled")
+		return
+	}
+	if _, err := s.app.GetProviderRegistry().Get(providerID); err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	priority := provider.DefaultPriority
+	if v := r.URL.Query().Get("priority"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 0 || p > 3 {
+			s.respondError(w, http.StatusBadRequest, "Invalid priority: must be 0-3")
+			return
+		}
+		priority = p
+	}
+	s.respondJSON(w, http.StatusOK, q.Status(providerID, priority))
+}
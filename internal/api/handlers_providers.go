@@ -11,13 +11,14 @@ import (
 
 // ProviderRequest is a request wrapper for provider registration with API key
 type ProviderRequest struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Type        string `json:"type"`
-	Endpoint    string `json:"endpoint"`
-	APIKey      string `json:"api_key"`
-	Model       string `json:"model"`
-	Description string `json:"description"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Endpoint    string   `json:"endpoint"`
+	Endpoints   []string `json:"endpoints,omitempty"` // Additional endpoints serving the same model
+	APIKey      string   `json:"api_key"`
+	Model       string   `json:"model"`
+	Description string   `json:"description"`
 }
 
 // handleProviders handles GET/POST /api/v1/providers
@@ -47,6 +48,7 @@ func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
 			Name:        req.Name,
 			Type:        req.Type,
 			Endpoint:    req.Endpoint,
+			Endpoints:   req.Endpoints,
 			Model:       req.Model,
 			Description: req.Description,
 		}
@@ -81,7 +83,8 @@ func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleProvider handles GET/DELETE /api/v1/providers/{id} and GET /api/v1/providers/{id}/models
+// handleProvider handles GET/DELETE /api/v1/providers/{id}, GET /api/v1/providers/{id}/models
+// and GET /api/v1/providers/{id}/queue
 func (s *Server) handleProvider(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/v1/providers/")
 	parts := strings.Split(path, "/")
@@ -109,6 +112,14 @@ func (s *Server) handleProvider(w http.ResponseWriter, r *http.Request) {
 		s.respondJSON(w, http.StatusOK, map[string]interface{}{"models": models})
 		return
 	}
+	if len(parts) > 1 && parts[1] == "queue" {
+		s.handleProviderQueue(w, r, providerID)
+		return
+	}
+	if len(parts) > 1 && parts[1] == "endpoints" {
+		s.handleProviderEndpoints(w, r, providerID)
+		return
+	}
 	if len(parts) > 1 && parts[1] == "negotiate" {
 		if r.Method != http.MethodPost {
 			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
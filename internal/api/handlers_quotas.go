@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/executor"
+)
+
+// projectQuotaBody is the API form of executor.ProjectQuota, with the daily
+// wall-clock budget in seconds.
+type projectQuotaBody struct {
+	MaxConcurrentCommands     int     `json:"max_concurrent_commands"`
+	MaxCPUSecondsPerDay       float64 `json:"max_cpu_seconds_per_day"`
+	MaxMemoryMB               int64   `json:"max_memory_mb"`
+	MaxDiskMB                 int64   `json:"max_disk_mb"`
+	MaxWallClockSecondsPerDay float64 `json:"max_wall_clock_seconds_per_day"`
+}
+
+func toProjectQuotaBody(q executor.ProjectQuota) projectQuotaBody {
+	return projectQuotaBody{
+		MaxConcurrentCommands:     q.MaxConcurrentCommands,
+		MaxCPUSecondsPerDay:       q.MaxCPUSecondsPerDay,
+		MaxMemoryMB:               q.MaxMemoryMB,
+		MaxDiskMB:                 q.MaxDiskMB,
+		MaxWallClockSecondsPerDay: q.MaxWallClockPerDay.Seconds(),
+	}
+}
+
+func (b projectQuotaBody) quota() executor.ProjectQuota {
+	return executor.ProjectQuota{
+		MaxConcurrentCommands: b.MaxConcurrentCommands,
+		MaxCPUSecondsPerDay:   b.MaxCPUSecondsPerDay,
+		MaxMemoryMB:           b.MaxMemoryMB,
+		MaxDiskMB:             b.MaxDiskMB,
+		MaxWallClockPerDay:    time.Duration(b.MaxWallClockSecondsPerDay * float64(time.Second)),
+	}
+}
+
+// handleProjectQuota reports and updates a project's executor quota
+// GET /api/v1/projects/{id}/quota - Quota and today's usage
+// PUT /api/v1/projects/{id}/quota - Override the project's quota (zero values are unlimited)
+func (s *Server) handleProjectQuota(w http.ResponseWriter, r *http.Request, projectID string) {
+	mgr := s.app.GetQuotaManager()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Quota manager not available")
+		return
+	}
+	if _, err := s.app.GetProjectManager().GetProject(projectID); err != nil {
+		s.respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var body projectQuotaBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if body.MaxConcurrentCommands < 0 || body.MaxCPUSecondsPerDay < 0 || body.MaxMemoryMB < 0 ||
+			body.MaxDiskMB < 0 || body.MaxWallClockSecondsPerDay < 0 {
+			s.respondError(w, http.StatusBadRequest, "quota values must not be negative")
+			return
+		}
+		mgr.SetProjectQuota(projectID, body.quota())
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"project_id": projectID,
+		"quota":      toProjectQuotaBody(mgr.QuotaFor(projectID)),
+		"usage":      mgr.Usage(projectID),
+	})
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/jordanhubbard/loom/internal/auth"
+)
+
+type readOnlyRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// handleReadOnly handles GET and PUT /api/v1/admin/read-only. Changes last
+// until restart; read_only in the config sets the state at startup.
+func (s *Server) handleReadOnly(w http.ResponseWriter, r *http.Request) {
+	if s.readOnly == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Read-only mode not available")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.respondJSON(w, http.StatusOK, s.readOnly.Status())
+	case http.MethodPut:
+		if auth.GetRoleFromRequest(r) != "admin" {
+			s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
+			return
+		}
+		var req readOnlyRequest
+		if err := s.parseJSON(r, &req); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		userID := auth.GetUserIDFromRequest(r)
+		status := s.readOnly.Set(req.Enabled, req.Reason, userID)
+		httpLog.WarnContext(r.Context(), "Read-only mode changed", "user_id", userID,
+			"enabled", status.Enabled, "reason", status.Reason)
+		s.respondJSON(w, http.StatusOK, status)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
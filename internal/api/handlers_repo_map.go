@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/jordanhubbard/loom/internal/repomap"
+)
+
+// handleProjectRepoMap serves a project's repository map, built for the
+// checkout's current commit and cached until it changes
+// GET /api/v1/projects/{id}/repo-map?refresh=true - The map as JSON
+// GET /api/v1/projects/{id}/repo-map?format=markdown&budget=1500 - The prompt block
+func (s *Server) handleProjectRepoMap(w http.ResponseWriter, r *http.Request, projectID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	maps := s.app.GetRepoMaps()
+	if maps == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Repository maps not available")
+		return
+	}
+	if _, err := s.app.GetProjectManager().GetProject(projectID); err != nil {
+		s.respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	q := r.URL.Query()
+	budget := repomap.DefaultBudget
+	if v := q.Get("budget"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.respondError(w, http.StatusBadRequest, "budget must be a number of tokens; 0 for no limit")
+			return
+		}
+		budget = n
+	}
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "markdown" {
+		s.respondError(w, http.StatusBadRequest, "format must be json or markdown")
+		return
+	}
+
+	m, err := maps.Get(r.Context(), projectID, q.Get("refresh") == "true")
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(repomap.Markdown(m, budget)))
+		return
+	}
+	s.respondJSON(w, http.StatusOK, m)
+}
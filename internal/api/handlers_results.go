@@ -0,0 +1,162 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultResultPage = 64 << 10
+	maxResultPage     = 1 << 20
+)
+
+// handleResults lists stored action outputs
+// GET /api/v1/results?project_id=...&bead_id=...&limit=50
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	db := s.app.GetDatabase()
+	if db == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Database not available")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	outputs, err := db.ListActionOutputs(r.URL.Query().Get("project_id"), r.URL.Query().Get("bead_id"), limit)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, outputs)
+}
+
+// handleResult pages through a stored action output
+// GET /api/v1/results/{id}?offset=0&limit=65536 - JSON page with next_offset
+// GET /api/v1/results/{id}/raw - Plain text; honors "Range: bytes=start-end"
+func (s *Server) handleResult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	db := s.app.GetDatabase()
+	if db == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Database not available")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/results/"), "/"), "/")
+	out, err := db.GetActionOutput(parts[0])
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if len(parts) > 1 && parts[1] == "raw" {
+		start, end := int64(0), out.Size-1
+		status := http.StatusOK
+		if rng := r.Header.Get("Range"); rng != "" {
+			var ok bool
+			start, end, ok = parseByteRange(rng, out.Size)
+			if !ok {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", out.Size))
+				s.respondError(w, http.StatusRequestedRangeNotSatisfiable, "Invalid range")
+				return
+			}
+			status = http.StatusPartialContent
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, out.Size))
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+		w.WriteHeader(status)
+		for offset := start; offset <= end; offset += maxResultPage {
+			n := end - offset + 1
+			if n > maxResultPage {
+				n = maxResultPage
+			}
+			chunk, err := db.ReadActionOutput(out.ID, offset, n)
+			if err != nil {
+				return
+			}
+			if _, err := w.Write([]byte(chunk)); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if offset < 0 {
+		offset += out.Size
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	if offset > out.Size {
+		offset = out.Size
+	}
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	if limit <= 0 {
+		limit = defaultResultPage
+	}
+	if limit > maxResultPage {
+		limit = maxResultPage
+	}
+
+	content := ""
+	if offset < out.Size {
+		if content, err = db.ReadActionOutput(out.ID, offset, limit); err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	next := offset + int64(len(content))
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"result":      out,
+		"offset":      offset,
+		"next_offset": next,
+		"eof":         next >= out.Size,
+		"content":     content,
+	})
+}
+
+// parseByteRange parses a single "bytes=start-end" range (either bound may
+// be omitted) against a resource of the given size
+func parseByteRange(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") || size == 0 {
+		return 0, 0, false
+	}
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	if first == "" {
+		// Suffix range: the last N bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, true
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, true
+}
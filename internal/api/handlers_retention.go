@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// handleStorage handles GET /api/v1/storage, reporting database size, row
+// counts of the tables that grow with use, and archive size
+func (s *Server) handleStorage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Database not configured")
+		return
+	}
+	if s.app.GetRetentionManager() == nil {
+		db := s.app.GetDatabase()
+		if db == nil {
+			s.respondError(w, http.StatusServiceUnavailable, "Database not configured")
+			return
+		}
+		stats, err := db.StorageStats()
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]interface{}{"database": stats, "retention_enabled": false})
+		return
+	}
+
+	usage, err := s.app.GetRetentionManager().Usage()
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, usage)
+}
+
+// handleRetentionRun handles POST /api/v1/retention/run, applying the
+// retention policy now
+func (s *Server) handleRetentionRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil || s.app.GetRetentionManager() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Retention not enabled")
+		return
+	}
+	report, err := s.app.GetRetentionManager().Run(r.Context())
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, report)
+}
+
+// handleArchivedBead handles GET /api/v1/archive/beads/{id}, returning an
+// archived bead with its conversations, command logs and action outputs
+func (s *Server) handleArchivedBead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil || s.app.GetRetentionManager() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Retention not enabled")
+		return
+	}
+	beadID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/archive/beads/"), "/")
+	archive, err := s.app.GetRetentionManager().LoadArchive(beadID)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, archive)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// handleAgentRoles lists and creates agent role definitions
+// GET /api/v1/agent-roles - List roles
+// POST /api/v1/agent-roles - Create a role
+func (s *Server) handleAgentRoles(w http.ResponseWriter, r *http.Request) {
+	registry := s.app.GetRoleRegistry()
+	if registry == nil {
+		s.respondError(w, http.StatusInternalServerError, "role registry not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.respondJSON(w, http.StatusOK, registry.List())
+	case http.MethodPost:
+		var role models.AgentRole
+		if err := s.parseJSON(r, &role); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if err := registry.Create(&role); err != nil {
+			s.respondError(w, roleErrorStatus(err), err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusCreated, role)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleAgentRole reads, replaces and deletes a single role
+// GET /api/v1/agent-roles/{name}
+// PUT /api/v1/agent-roles/{name}
+// DELETE /api/v1/agent-roles/{name}
+func (s *Server) handleAgentRole(w http.ResponseWriter, r *http.Request) {
+	registry := s.app.GetRoleRegistry()
+	if registry == nil {
+		s.respondError(w, http.StatusInternalServerError, "role registry not configured")
+		return
+	}
+	name := s.extractID(r.URL.Path, "/api/v1/agent-roles")
+	if name == "" {
+		s.respondError(w, http.StatusBadRequest, "Role name is required")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		role, err := registry.Get(name)
+		if err != nil {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, role)
+	case http.MethodPut:
+		var role models.AgentRole
+		if err := s.parseJSON(r, &role); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		role.Name = name
+		if err := registry.Update(&role); err != nil {
+			s.respondError(w, roleErrorStatus(err), err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, role)
+	case http.MethodDelete:
+		if err := registry.Delete(name); err != nil {
+			s.respondError(w, roleErrorStatus(err), err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func roleErrorStatus(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "already exists"):
+		return http.StatusConflict
+	case strings.Contains(msg, "required"), strings.Contains(msg, "invalid"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -10,7 +10,7 @@ import (
 // handleSelectProvider handles provider selection with routing policy
 func (s *Server) handleSelectProvider(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -20,7 +20,7 @@ func (s *Server) handleSelectProvider(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -31,20 +31,20 @@ func (s *Server) handleSelectProvider(w http.ResponseWriter, r *http.Request) {
 
 	provider, err := s.app.SelectProvider(r.Context(), req.Requirements, req.Policy)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(provider); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleGetRoutingPolicies handles listing available routing policies
 func (s *Server) handleGetRoutingPolicies(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -77,6 +77,6 @@ func (s *Server) handleGetRoutingPolicies(w http.ResponseWriter, r *http.Request
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(policies); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/search"
+)
+
+// handleSearch handles GET /api/v1/search, a ranked full-text search across
+// beads, conversations, action outputs and command logs.
+//
+// Query parameters: q (required), project_id, agent_id, type (comma-separated
+// bead, conversation, action_output, command), since and until (RFC3339 or
+// YYYY-MM-DD), limit and offset.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.app == nil || s.app.GetSearchIndex() == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Search not available")
+		return
+	}
+
+	params := r.URL.Query()
+	q := search.Query{
+		Text:      strings.TrimSpace(params.Get("q")),
+		ProjectID: params.Get("project_id"),
+		AgentID:   params.Get("agent_id"),
+	}
+	if q.Text == "" {
+		s.respondError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	if types := params.Get("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			switch t = strings.TrimSpace(t); t {
+			case search.KindBead, search.KindConversation, search.KindActionOutput, search.KindCommand:
+				q.Kinds = append(q.Kinds, t)
+			default:
+				s.respondError(w, http.StatusBadRequest, "unknown type: "+t)
+				return
+			}
+		}
+	}
+	var err error
+	if q.Since, err = parseSearchTime(params.Get("since"), false); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid since: "+err.Error())
+		return
+	}
+	if q.Until, err = parseSearchTime(params.Get("until"), true); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid until: "+err.Error())
+		return
+	}
+	if v := params.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil {
+			s.respondError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+	if v := params.Get("offset"); v != "" {
+		if q.Offset, err = strconv.Atoi(v); err != nil {
+			s.respondError(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+	}
+
+	results, err := s.app.GetSearchIndex().Search(r.Context(), q)
+	if errors.Is(err, search.ErrEmptyQuery) {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if results == nil {
+		results = []search.Result{}
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"query":   q.Text,
+		"results": results,
+		"count":   len(results),
+	})
+}
+
+// parseSearchTime accepts RFC3339 or a bare date. A bare date used as an
+// upper bound covers the whole day.
+func parseSearchTime(v string, endOfDay bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/securityscan"
+)
+
+// handleProjectSecurityScans runs and lists security scans for a project
+// GET /api/v1/projects/{id}/security-scans - List scans, newest first (?limit=N)
+// POST /api/v1/projects/{id}/security-scans - Run a scan ({"tools": [...], "path": "...", "subproject": "...", "create_beads": true})
+// GET /api/v1/projects/{id}/security-scans/{sid} - Get a single scan with all findings
+func (s *Server) handleProjectSecurityScans(w http.ResponseWriter, r *http.Request, projectID string, parts []string) {
+	scanner := s.app.GetSecurityScanner()
+	if scanner == nil {
+		s.respondError(w, http.StatusInternalServerError, "security scanner not configured")
+		return
+	}
+	project, err := s.app.GetProjectManager().GetProject(projectID)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	if len(parts) > 0 && parts[0] != "" {
+		if r.Method != http.MethodGet {
+			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		scan, err := scanner.GetScan(parts[0])
+		if err != nil || scan.ProjectID != projectID {
+			s.respondError(w, http.StatusNotFound, "Scan not found")
+			return
+		}
+		s.respondJSON(w, http.StatusOK, scan)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		limit := 20
+		if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+			limit = l
+		}
+		scans, err := scanner.ListScans(projectID, limit)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, scans)
+	case http.MethodPost:
+		var req struct {
+			Tools       []string `json:"tools"`
+			Path        string   `json:"path"`
+			Subproject  string   `json:"subproject"`
+			CreateBeads bool     `json:"create_beads"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			s.respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.Subproject != "" && req.Path == "" {
+			sp := project.FindSubproject(req.Subproject)
+			if sp == nil {
+				s.respondError(w, http.StatusBadRequest, "unknown subproject: "+req.Subproject)
+				return
+			}
+			req.Path = sp.Path
+		}
+		scan, err := scanner.Scan(r.Context(), projectID, securityscan.Options{
+			Tools:       req.Tools,
+			Path:        req.Path,
+			CreateBeads: req.CreateBeads,
+		})
+		if err != nil {
+			status := http.StatusBadRequest
+			if strings.Contains(err.Error(), "workdir") {
+				status = http.StatusConflict
+			}
+			s.respondError(w, status, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusCreated, scan)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// checkSessionOrigin allows session WebSockets from the server's own origin
+// and origins listed in security.allowed_origins, so another site can't open
+// a terminal through a visitor's browser. A "*" entry, fine for CORS, doesn't
+// count here. Requests without an Origin don't come from a browser.
+func (s *Server) checkSessionOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if s.config != nil {
+		for _, allowed := range s.config.Security.AllowedOrigins {
+			if allowed == origin {
+				return true
+			}
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// sessionClientMessage is sent by UI clients over the sessions WebSocket
+type sessionClientMessage struct {
+	Type      string `json:"type"` // subscribe, unsubscribe, input, resize
+	SessionID string `json:"session_id"`
+	Data      string `json:"data,omitempty"`
+	Rows      uint16 `json:"rows,omitempty"`
+	Cols      uint16 `json:"cols,omitempty"`
+}
+
+// sessionServerMessage is sent to clients for snapshots and errors; live
+// output and exit notifications are sent as executor.SessionEvent.
+type sessionServerMessage struct {
+	Type      string `json:"type"` // snapshot, error
+	SessionID string `json:"session_id,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
+// handleSessions lists interactive sessions
+// GET /api/v1/sessions?bead_id=... - List sessions, optionally for one bead
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	mgr := s.app.GetSessionManager()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Session manager not available")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, mgr.ListSessions(r.URL.Query().Get("bead_id")))
+}
+
+// handleSession handles a single interactive session
+// GET /api/v1/sessions/ws - WebSocket multiplexing session output and input
+// GET /api/v1/sessions/{id} - Session state
+// DELETE /api/v1/sessions/{id} - Terminate the session
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	mgr := s.app.GetSessionManager()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Session manager not available")
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/v1/sessions/")
+	if id == "" || strings.Contains(id, "/") {
+		s.respondError(w, http.StatusBadRequest, "Invalid path")
+		return
+	}
+	if id == "ws" {
+		s.handleSessionsWebSocket(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		info, err := mgr.GetSession(id)
+		if err != nil {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, info)
+	case http.MethodDelete:
+		info, err := mgr.CloseSession(id)
+		if err != nil {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, info)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleSessionsWebSocket lets a UI client watch and drive any number of
+// sessions over one connection. Clients subscribe per session; every message
+// carries its session_id.
+func (s *Server) handleSessionsWebSocket(w http.ResponseWriter, r *http.Request) {
+	mgr := s.app.GetSessionManager()
+	upgrader := websocket.Upgrader{CheckOrigin: s.checkSessionOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		httpLog.WarnContext(r.Context(), "Failed to upgrade session connection", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	var writeMu sync.Mutex
+	send := func(v interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteJSON(v)
+	}
+	sendError := func(sessionID string, err error) {
+		_ = send(sessionServerMessage{Type: "error", SessionID: sessionID, Data: err.Error()})
+	}
+
+	subscriptions := make(map[string]func())
+	defer func() {
+		for _, unsubscribe := range subscriptions {
+			unsubscribe()
+		}
+	}()
+
+	for {
+		var msg sessionClientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case "subscribe":
+			if _, ok := subscriptions[msg.SessionID]; ok {
+				continue
+			}
+			events, unsubscribe, err := mgr.Subscribe(msg.SessionID)
+			if err != nil {
+				sendError(msg.SessionID, err)
+				continue
+			}
+			subscriptions[msg.SessionID] = unsubscribe
+			if buffered, err := mgr.PeekOutput(msg.SessionID); err == nil && buffered != "" {
+				_ = send(sessionServerMessage{Type: "snapshot", SessionID: msg.SessionID, Data: buffered})
+			}
+			go func() {
+				for event := range events {
+					if err := send(event); err != nil {
+						return
+					}
+				}
+			}()
+		case "unsubscribe":
+			if unsubscribe, ok := subscriptions[msg.SessionID]; ok {
+				unsubscribe()
+				delete(subscriptions, msg.SessionID)
+			}
+		case "input":
+			if err := mgr.SendInput(msg.SessionID, []byte(msg.Data)); err != nil {
+				sendError(msg.SessionID, err)
+			}
+		case "resize":
+			if err := mgr.Resize(msg.SessionID, msg.Rows, msg.Cols); err != nil {
+				sendError(msg.SessionID, err)
+			}
+		default:
+			_ = send(sessionServerMessage{Type: "error", SessionID: msg.SessionID, Data: "unknown message type: " + msg.Type})
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/sla"
+)
+
+// parseDueDate reads a bead's due date from an absolute RFC 3339 time or a
+// duration after created. It reports whether either was given; an empty
+// due_date clears the due date.
+func parseDueDate(dueDate, dueIn *string, created time.Time) (*time.Time, bool, error) {
+	switch {
+	case dueDate != nil && dueIn != nil:
+		return nil, false, fmt.Errorf("due_date and due_in are mutually exclusive")
+	case dueIn != nil:
+		d, err := time.ParseDuration(*dueIn)
+		if err != nil || d <= 0 {
+			return nil, false, fmt.Errorf("invalid due_in: %q", *dueIn)
+		}
+		due := created.Add(d)
+		return &due, true, nil
+	case dueDate != nil:
+		if *dueDate == "" {
+			return nil, true, nil
+		}
+		due, err := time.Parse(time.RFC3339, *dueDate)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid due_date: %q", *dueDate)
+		}
+		return &due, true, nil
+	}
+	return nil, false, nil
+}
+
+// slaManager returns the bead deadline manager, or nil without one
+func (s *Server) slaManager() *sla.Manager {
+	if s.app == nil {
+		return nil
+	}
+	return s.app.GetSLAManager()
+}
+
+// handleSLABeads handles GET /api/v1/sla/beads: open beads at risk or
+// breached, most overdue first (project_id, state)
+func (s *Server) handleSLABeads(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	m := s.slaManager()
+	if m == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "SLA tracking not available")
+		return
+	}
+	state := sla.State(r.URL.Query().Get("state"))
+	if state != "" && state != sla.StateAtRisk && state != sla.StateBreached {
+		s.respondError(w, http.StatusBadRequest, "state must be at_risk or breached")
+		return
+	}
+	flagged, err := m.Flagged(r.URL.Query().Get("project_id"), state)
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{"beads": flagged, "count": len(flagged)})
+}
+
+// handleSLACompliance handles GET /api/v1/sla/compliance: per-project
+// deadline compliance over the last days (project_id, days; default 30)
+func (s *Server) handleSLACompliance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	m := s.slaManager()
+	if m == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "SLA tracking not available")
+		return
+	}
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			s.respondError(w, http.StatusBadRequest, "Invalid days")
+			return
+		}
+		days = n
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	report, err := m.Compliance(r.URL.Query().Get("project_id"), since)
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"since":    since.UTC(),
+		"projects": report,
+	})
+}
+
+// handleSLARun handles POST /api/v1/sla/run, evaluating bead deadlines now
+func (s *Server) handleSLARun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	m := s.slaManager()
+	if m == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "SLA tracking not available")
+		return
+	}
+	report, err := m.Run(r.Context())
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// handleProjectSnapshots manages workspace restore points for a project
+// GET /api/v1/projects/{id}/snapshots - List snapshots, newest first
+// POST /api/v1/projects/{id}/snapshots - Snapshot the workdir ({"label": "..."})
+// GET /api/v1/projects/{id}/snapshots/{sid}/diff - Diff the snapshot against the current workdir
+// POST /api/v1/projects/{id}/snapshots/{sid}/restore - Restore the workdir to the snapshot
+// DELETE /api/v1/projects/{id}/snapshots/{sid} - Delete the snapshot
+func (s *Server) handleProjectSnapshots(w http.ResponseWriter, r *http.Request, projectID string, parts []string) {
+	if s.fileManager == nil {
+		s.respondError(w, http.StatusInternalServerError, "file manager not configured")
+		return
+	}
+	ctx := r.Context()
+
+	if len(parts) == 0 || parts[0] == "" {
+		switch r.Method {
+		case http.MethodGet:
+			snaps, err := s.fileManager.ListSnapshots(ctx, projectID)
+			if err != nil {
+				s.respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			s.respondJSON(w, http.StatusOK, snaps)
+		case http.MethodPost:
+			var req struct {
+				Label string `json:"label"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+				s.respondError(w, http.StatusBadRequest, "Invalid request body")
+				return
+			}
+			snap, err := s.fileManager.CreateSnapshot(ctx, projectID, req.Label)
+			if err != nil {
+				s.respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			s.respondJSON(w, http.StatusCreated, snap)
+		default:
+			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	snapshotID := parts[0]
+	sub := strings.Join(parts[1:], "/")
+	switch {
+	case sub == "diff" && r.Method == http.MethodGet:
+		diff, err := s.fileManager.DiffSnapshot(ctx, projectID, snapshotID)
+		if err != nil {
+			s.respondError(w, snapshotErrorStatus(err), err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, diff)
+	case sub == "restore" && r.Method == http.MethodPost:
+		res, err := s.fileManager.RestoreSnapshot(ctx, projectID, snapshotID)
+		if err != nil {
+			s.respondError(w, snapshotErrorStatus(err), err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, res)
+	case sub == "" && r.Method == http.MethodDelete:
+		if err := s.fileManager.DeleteSnapshot(ctx, projectID, snapshotID); err != nil {
+			s.respondError(w, snapshotErrorStatus(err), err.Error())
+			return
+		}
+		s.respondJSON(w, http.StatusOK, map[string]string{"status": "deleted", "id": snapshotID})
+	case sub == "" || sub == "diff" || sub == "restore":
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	default:
+		s.respondError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func snapshotErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "snapshot not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/auth"
+	loompkg "github.com/jordanhubbard/loom/internal/loom"
+)
+
+// maxStateArchiveBytes bounds an uploaded state archive
+const maxStateArchiveBytes = 256 << 20
+
+// handleStateExport handles GET /api/v1/state/export, returning providers
+// (without secrets), projects, beads, workflows, roles and schedules as a
+// versioned JSON archive
+func (s *Server) handleStateExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if auth.GetRoleFromRequest(r) != "admin" {
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
+		return
+	}
+
+	archive, err := s.app.ExportStateArchive(r.Context())
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Disposition", "attachment; filename=\"loom-state-"+time.Now().Format("2006-01-02")+".json\"")
+	s.respondJSON(w, http.StatusOK, archive)
+}
+
+// handleStateImport handles POST /api/v1/state/import?on_conflict=skip|overwrite|fail&dry_run=true
+// with an archive from /api/v1/state/export as the body
+func (s *Server) handleStateImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if auth.GetRoleFromRequest(r) != "admin" {
+		s.respondError(w, http.StatusForbidden, "Forbidden: admin access required")
+		return
+	}
+
+	var archive loompkg.StateArchive
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxStateArchiveBytes)).Decode(&archive); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid archive")
+		return
+	}
+	opts := loompkg.ImportOptions{
+		OnConflict: r.URL.Query().Get("on_conflict"),
+		DryRun:     r.URL.Query().Get("dry_run") == "true",
+	}
+
+	report, err := s.app.ImportStateArchive(r.Context(), &archive, opts)
+	if err != nil {
+		if report == nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		status := http.StatusInternalServerError
+		if opts.OnConflict == loompkg.ImportFail && len(report.Conflicts) > 0 {
+			status = http.StatusConflict
+		}
+		s.respondJSON(w, status, map[string]interface{}{
+			"error":  err.Error(),
+			"report": report,
+		})
+		return
+	}
+	s.respondJSON(w, http.StatusOK, report)
+}
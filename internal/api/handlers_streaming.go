@@ -95,12 +95,30 @@ func (s *Server) handleStreamChatCompletion(w http.ResponseWriter, r *http.Reque
 		Stream:      true,
 	}
 
-	// Create context with timeout
+	// Create context with timeout; a bead's or agent's streams stay on one
+	// endpoint of a balanced provider
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
 	defer cancel()
+	sessionKey := req.BeadID
+	if sessionKey == "" {
+		sessionKey = req.AgentID
+	}
+	ctx = provider.WithSessionKey(ctx, sessionKey)
 
 	var streamedText strings.Builder
 
+	// Start read-only actions as soon as they are complete in the stream
+	router := s.app.GetActionRouter()
+	actx := actions.ActionContext{
+		AgentID:   req.AgentID,
+		BeadID:    req.BeadID,
+		ProjectID: defaultProjectID(req.ProjectID),
+	}
+	var early *actions.EarlyDispatcher
+	if router != nil {
+		early = router.NewEarlyDispatcher(ctx, actx)
+	}
+
 	// Stream response via registry
 	err = providerReg.SendChatCompletionStream(ctx, req.ProviderID, providerReq, func(chunk *provider.StreamChunk) error {
 		// Check if client disconnected
@@ -113,6 +131,9 @@ func (s *Server) handleStreamChatCompletion(w http.ResponseWriter, r *http.Reque
 		// Capture chunk text for action parsing
 		if len(chunk.Choices) > 0 {
 			streamedText.WriteString(chunk.Choices[0].Delta.Content)
+			if early != nil {
+				early.Write(chunk.Choices[0].Delta.Content)
+			}
 		}
 
 		// Send chunk to client
@@ -138,13 +159,8 @@ func (s *Server) handleStreamChatCompletion(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Enforce strict JSON action output
-	if router := s.app.GetActionRouter(); router != nil {
+	if router != nil {
 		raw := streamedText.String()
-		actx := actions.ActionContext{
-			AgentID:   req.AgentID,
-			BeadID:    req.BeadID,
-			ProjectID: defaultProjectID(req.ProjectID),
-		}
 		env, parseErr := actions.DecodeLenient([]byte(raw))
 		if parseErr != nil {
 			router.AutoFileParseFailure(ctx, actx, parseErr, raw)
@@ -153,7 +169,7 @@ func (s *Server) handleStreamChatCompletion(w http.ResponseWriter, r *http.Reque
 			fmt.Fprintf(w, "data: %s\n\n", errorData)
 			flusher.Flush()
 		} else {
-			_, _ = router.Execute(ctx, env, actx)
+			_, _ = early.Finish(ctx, env)
 		}
 	}
 
@@ -218,7 +234,7 @@ func (s *Server) handleChatCompletion(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call provider directly (testing endpoint - skip health checks)
-	resp, err := registeredProvider.Protocol.CreateChatCompletion(r.Context(), providerReq)
+	resp, err := registeredProvider.ChatCompletion(r.Context(), providerReq)
 	if err != nil {
 		s.respondError(w, http.StatusBadGateway, fmt.Sprintf("Provider error: %v", err))
 		return
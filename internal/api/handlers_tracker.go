@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/jordanhubbard/loom/internal/tracker"
+)
+
+// trackerLinkRequest names a tracker issue to link a bead to
+type trackerLinkRequest struct {
+	Provider string `json:"provider"` // jira or linear
+	Key      string `json:"key"`      // e.g. PROJ-123
+}
+
+// handleTracker handles GET /api/v1/tracker, listing the configured issue
+// trackers
+func (s *Server) handleTracker(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	mgr := s.app.GetTracker()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Issue tracker sync not available")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{"providers": mgr.Providers()})
+}
+
+// handleTrackerSync handles POST /api/v1/tracker/sync, syncing every linked
+// bead now
+func (s *Server) handleTrackerSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	mgr := s.app.GetTracker()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Issue tracker sync not available")
+		return
+	}
+	results, err := mgr.SyncAll(r.Context())
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{"results": results, "count": len(results)})
+}
+
+// handleBeadTracker manages a bead's link to a tracker issue
+// GET    /api/v1/beads/{id}/tracker - The bead's link
+// PUT    /api/v1/beads/{id}/tracker - Link it to an issue ({"provider": "jira", "key": "PROJ-123"})
+// DELETE /api/v1/beads/{id}/tracker - Unlink it; neither side is changed
+// POST   /api/v1/beads/{id}/tracker/sync - Sync with the issue now
+func (s *Server) handleBeadTracker(w http.ResponseWriter, r *http.Request, beadID, action string) {
+	mgr := s.app.GetTracker()
+	if mgr == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Issue tracker sync not available")
+		return
+	}
+
+	if action == "sync" {
+		if r.Method != http.MethodPost {
+			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		result, err := mgr.Sync(r.Context(), beadID)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, result)
+		return
+	}
+	if action != "" {
+		s.respondError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		bead, err := s.app.GetBeadsManager().GetBead(beadID)
+		if err != nil {
+			s.respondError(w, http.StatusNotFound, "Bead not found")
+			return
+		}
+		link := tracker.LinkOf(bead)
+		if link == nil {
+			s.respondError(w, http.StatusNotFound, "Bead is not linked to a tracker issue")
+			return
+		}
+		s.respondJSON(w, http.StatusOK, link)
+	case http.MethodPut:
+		var req trackerLinkRequest
+		if err := s.parseJSON(r, &req); err != nil || req.Provider == "" || req.Key == "" {
+			s.respondError(w, http.StatusBadRequest, "provider and key are required")
+			return
+		}
+		link, err := mgr.Link(r.Context(), beadID, req.Provider, req.Key)
+		if err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		s.respondJSON(w, http.StatusOK, link)
+	case http.MethodDelete:
+		if err := mgr.Unlink(beadID); err != nil {
+			s.respondAppError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleGitHubImport handles POST /api/v1/tracker/github/import, filing
+// beads for a repository's GitHub issues:
+// {"project_id", "repository": "owner/repo", "labels": ["bug"], "milestone": "v2",
+// "state": "open", "limit": 100, "comment_on_close": true, "dry_run": false}
+func (s *Server) handleGitHubImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	importer := s.app.GetGitHubImporter()
+	if importer == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "GitHub import not available")
+		return
+	}
+	var req tracker.ImportRequest
+	if err := s.parseJSON(r, &req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if _, err := s.app.GetProjectManager().GetProject(req.ProjectID); err != nil {
+		s.respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+	result, err := importer.Import(r.Context(), req)
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	s.respondJSON(w, http.StatusOK, result)
+}
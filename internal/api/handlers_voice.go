@@ -0,0 +1,85 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/attachments"
+	"github.com/jordanhubbard/loom/internal/loom"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// handleVoiceBead files a bead from a voice memo
+// POST /api/v1/beads/voice - multipart form with an audio file field and
+// project_id, type and priority fields, or a raw audio/* body with those
+// as query parameters (plus name)
+func (s *Server) handleVoiceBead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !s.app.VoiceIntakeEnabled() {
+		s.respondError(w, http.StatusServiceUnavailable, "Voice intake is not configured")
+		return
+	}
+
+	req := loom.VoiceBeadRequest{}
+	var priority string
+	r.Body = http.MaxBytesReader(w, r.Body, attachments.MaxAudioBytes+1<<20)
+	contentType := r.Header.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "multipart/form-data"):
+		if err := r.ParseMultipartForm(8 << 20); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid multipart form")
+			return
+		}
+		file, header, err := r.FormFile("audio")
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, "audio file is required")
+			return
+		}
+		defer file.Close()
+		if req.Audio, err = io.ReadAll(file); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Failed to read audio")
+			return
+		}
+		req.Name = header.Filename
+		req.ProjectID = r.FormValue("project_id")
+		req.Type = r.FormValue("type")
+		priority = r.FormValue("priority")
+	case strings.HasPrefix(contentType, "audio/"), strings.HasPrefix(contentType, "video/webm"):
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, "Failed to read audio")
+			return
+		}
+		q := r.URL.Query()
+		req.Audio = data
+		req.Name = q.Get("name")
+		req.ProjectID = q.Get("project_id")
+		req.Type = q.Get("type")
+		priority = q.Get("priority")
+	default:
+		s.respondError(w, http.StatusUnsupportedMediaType, "Send multipart/form-data or an audio/* body")
+		return
+	}
+
+	req.Priority = models.BeadPriorityP2
+	if priority != "" {
+		p, err := strconv.Atoi(priority)
+		if err != nil || p < int(models.BeadPriorityP0) || p > int(models.BeadPriorityP3) {
+			s.respondError(w, http.StatusBadRequest, "priority must be 0-3")
+			return
+		}
+		req.Priority = models.BeadPriority(p)
+	}
+
+	result, err := s.app.CreateVoiceBead(r.Context(), req)
+	if err != nil {
+		s.respondAppError(w, err)
+		return
+	}
+	s.respondJSON(w, http.StatusCreated, result)
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/ci"
 	"github.com/jordanhubbard/loom/internal/motivation"
 	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
 )
@@ -155,6 +156,17 @@ func (s *Server) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// CI results for agent branches go to the CI tracker
+	if eventType == "workflow_run" {
+		run, err := ci.ParseGitHubWorkflowRun(body)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.recordCIRun(w, r, run)
+		return
+	}
+
 	// Parse the payload
 	var payload GitHubWebhookPayload
 	if err := json.Unmarshal(body, &payload); err != nil {
@@ -202,6 +214,8 @@ func (s *Server) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
 				ebEventType = eventbus.EventType("external.github_pr_update")
 			case "github_comment_added":
 				ebEventType = eventbus.EventType("external.github_comment")
+			case "github_pr_closed":
+				ebEventType = eventbus.EventTypePullRequestClosed
 			case "release_published":
 				ebEventType = eventbus.EventType("external.release")
 			default:
@@ -209,9 +223,10 @@ func (s *Server) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
 			}
 
 			_ = eb.Publish(&eventbus.Event{
-				Type:   ebEventType,
-				Source: "github-webhook",
-				Data:   eventData,
+				Type:      ebEventType,
+				Source:    "github-webhook",
+				ProjectID: s.getOrCreateProjectForRepo(webhookEvent.Repository),
+				Data:      eventData,
 			})
 		}
 	}
@@ -321,7 +336,12 @@ func (s *Server) processGitHubEvent(eventType string, payload *GitHubWebhookPayl
 		case "closed":
 			event.Type = "github_pr_closed"
 			event.Data["pr_number"] = payload.PullRequest.Number
+			event.Data["pr_url"] = payload.PullRequest.URL
+			event.Data["pr_title"] = payload.PullRequest.Title
 			event.Data["merged"] = payload.PullRequest.Merged
+			if payload.PullRequest.User != nil {
+				event.Data["author"] = payload.PullRequest.User.Login
+			}
 		default:
 			return nil
 		}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// handleAgentWorkingSet returns the curated context for an agent's turn on
+// a bead, its current bead unless bead_id names another
+// GET /api/v1/agents/{id}/working-set?bead_id=...&budget=8000
+func (s *Server) handleAgentWorkingSet(w http.ResponseWriter, r *http.Request, agentID string) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	builder := s.app.GetWorkingSets()
+	if builder == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Working sets not available")
+		return
+	}
+	agent, err := s.app.GetAgentManager().GetAgent(agentID)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, "Agent not found")
+		return
+	}
+
+	q := r.URL.Query()
+	beadID := q.Get("bead_id")
+	if beadID == "" {
+		beadID = agent.CurrentBead
+	}
+	if beadID == "" {
+		s.respondError(w, http.StatusBadRequest, "Agent has no current bead; pass bead_id")
+		return
+	}
+	if _, err := s.app.GetBeadsManager().GetBead(beadID); err != nil {
+		s.respondError(w, http.StatusNotFound, "Bead not found")
+		return
+	}
+	budget := 0
+	if v := q.Get("budget"); v != "" {
+		if budget, err = strconv.Atoi(v); err != nil || budget <= 0 {
+			s.respondError(w, http.StatusBadRequest, "budget must be a positive number of tokens")
+			return
+		}
+	}
+
+	ws, err := builder.Build(r.Context(), beadID, budget)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, ws)
+}
@@ -1,21 +1,31 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jordanhubbard/loom/internal/loom"
 	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/apperr"
 	"github.com/jordanhubbard/loom/internal/auth"
 	"github.com/jordanhubbard/loom/internal/cache"
+	"github.com/jordanhubbard/loom/internal/features"
 	"github.com/jordanhubbard/loom/internal/files"
 	"github.com/jordanhubbard/loom/internal/keymanager"
 	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/metrics"
+	"github.com/jordanhubbard/loom/internal/readonly"
+	"github.com/jordanhubbard/loom/internal/throttle"
 	"github.com/jordanhubbard/loom/pkg/config"
 	"github.com/jordanhubbard/loom/pkg/models"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -31,9 +41,18 @@ type Server struct {
 	cache           *cache.Cache
 	config          *config.Config
 	fileManager     *files.Manager
+	features        *features.Registry
+	readOnly        *readonly.Switch
 	metrics         *metrics.Metrics
 	apiFailureMu    sync.Mutex
 	apiFailureLast  map[string]time.Time
+	throttle        *throttle.Limiter
+	throttlePolicy  throttle.Policy
+
+	// Last provider reachability result for /readyz
+	providerProbeMu sync.Mutex
+	providerProbe   DepHealth
+	providerProbeAt time.Time
 
 	// Circuit breaker for auto-filing API failures as beads.
 	// Prevents cascading failures when the bead subsystem itself is broken.
@@ -87,7 +106,7 @@ func NewServer(arb *loom.Loom, km *keymanager.KeyManager, am *auth.Manager, cfg
 			redisCache, err := cache.NewRedisCache(cfg.Cache.RedisURL, cacheConfig)
 			if err != nil {
 				// Redis failed - fallback to in-memory cache with warning
-				fmt.Printf("[WARN] Redis cache initialization failed: %v, falling back to in-memory cache\n", err)
+				httpLog.Warn("Redis cache initialization failed, falling back to in-memory cache", "error", err)
 				responseCache = cache.New(cacheConfig)
 			} else {
 				// Wrap Redis cache to match Cache interface
@@ -99,14 +118,21 @@ func NewServer(arb *loom.Loom, km *keymanager.KeyManager, am *auth.Manager, cfg
 	}
 
 	var fileManager *files.Manager
+	var featureFlags *features.Registry
+	var readOnlySwitch *readonly.Switch
 	if arb != nil {
 		fileManager = files.NewManager(arb.GetGitOpsManager())
+		if cfg != nil {
+			fileManager.PatchFuzz = cfg.Git.PatchFuzz
+		}
+		featureFlags = arb.GetFeatures()
+		readOnlySwitch = arb.GetReadOnly()
 	}
 
 	// Initialize Prometheus metrics
 	promMetrics := metrics.NewMetrics()
 
-	return &Server{
+	s := &Server{
 		app:             arb,
 		keyManager:      km,
 		authManager:     am,
@@ -115,9 +141,16 @@ func NewServer(arb *loom.Loom, km *keymanager.KeyManager, am *auth.Manager, cfg
 		cache:           responseCache,
 		config:          cfg,
 		fileManager:     fileManager,
+		features:        featureFlags,
+		readOnly:        readOnlySwitch,
 		metrics:         promMetrics,
 		apiFailureLast:  make(map[string]time.Time),
 	}
+	if cfg != nil && cfg.APIThrottle.Enabled {
+		s.throttle = throttle.NewLimiter()
+		s.throttlePolicy = throttle.PolicyFrom(throttle.WithDefaults(cfg.APIThrottle))
+	}
+	return s
 }
 
 // SetupRoutes configures HTTP routes
@@ -164,7 +197,7 @@ func (s *Server) SetupRoutes() http.Handler {
 		case http.MethodGet:
 			authHandlers.HandleListUsers(w, r)
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		}
 	})
 
@@ -178,6 +211,7 @@ func (s *Server) SetupRoutes() http.Handler {
 
 	// Projects (includes /projects/{id}/files/*)
 	mux.HandleFunc("/api/v1/projects/bootstrap", s.handleBootstrapProject)
+	mux.HandleFunc("/api/v1/projects/provision", s.handleProvisionProject)
 	mux.HandleFunc("/api/v1/projects", s.handleProjects)
 	mux.HandleFunc("/api/v1/projects/", s.handleProject)
 
@@ -187,6 +221,7 @@ func (s *Server) SetupRoutes() http.Handler {
 	// Beads
 	mux.HandleFunc("/api/v1/beads", s.handleBeads)
 	mux.HandleFunc("/api/v1/beads/", s.handleBead)
+	mux.HandleFunc("/api/v1/beads/labels", s.handleBeadLabels)
 
 	// Federation
 	mux.HandleFunc("/api/v1/federation/status", s.handleFederationStatus)
@@ -210,12 +245,20 @@ func (s *Server) SetupRoutes() http.Handler {
 	mux.HandleFunc("/api/v1/file-locks", s.handleFileLocks)
 	mux.HandleFunc("/api/v1/file-locks/", s.handleFileLock)
 
+	// Shared editing buffers
+	mux.HandleFunc("/api/v1/coedit", s.handleCoEdit)
+	mux.HandleFunc("/api/v1/coedit/", s.handleCoEdit)
+
 	// Work graph
 	mux.HandleFunc("/api/v1/work-graph", s.handleWorkGraph)
 
 	// Providers
 	mux.HandleFunc("/api/v1/providers", s.handleProviders)
 	mux.HandleFunc("/api/v1/providers/", s.handleProvider)
+	mux.HandleFunc("/api/v1/provider-queues", s.handleProviderQueues)
+	mux.HandleFunc("/api/v1/provider-hedging", s.handleProviderHedging)
+	mux.HandleFunc("/api/v1/request-batching", s.handleRequestBatching)
+	mux.HandleFunc("/api/v1/embeddings", s.handleEmbeddings)
 	mux.HandleFunc("/api/v1/routing/select", s.handleSelectProvider)
 	mux.HandleFunc("/api/v1/routing/policies", s.handleGetRoutingPolicies)
 
@@ -224,6 +267,16 @@ func (s *Server) SetupRoutes() http.Handler {
 
 	// System
 	mux.HandleFunc("/api/v1/system/status", s.handleSystemStatus)
+	mux.HandleFunc("/api/v1/dispatch/queue", s.handleDispatchQueue)
+	mux.HandleFunc("/api/v1/dispatch/queue/", s.handleDispatchQueueBead)
+
+	// Bead deadlines
+	mux.HandleFunc("/api/v1/sla/beads", s.handleSLABeads)
+	mux.HandleFunc("/api/v1/sla/compliance", s.handleSLACompliance)
+	mux.HandleFunc("/api/v1/sla/run", s.handleSLARun)
+	mux.HandleFunc("/api/v1/tracker", s.handleTracker)
+	mux.HandleFunc("/api/v1/tracker/sync", s.handleTrackerSync)
+	mux.HandleFunc("/api/v1/tracker/github/import", s.handleGitHubImport)
 
 	// Work (non-bead prompts)
 	mux.HandleFunc("/api/v1/work", s.handleWork)
@@ -236,13 +289,32 @@ func (s *Server) SetupRoutes() http.Handler {
 	mux.HandleFunc("/api/v1/commands", s.HandleGetCommandLogs)
 	mux.HandleFunc("/api/v1/commands/", s.HandleGetCommandLogs)
 
+	// Background jobs (long-running commands with job handles)
+	mux.HandleFunc("/api/v1/jobs", s.handleJobs)
+	mux.HandleFunc("/api/v1/jobs/", s.handleJob)
+
+	// Interactive PTY sessions (includes /sessions/ws for live terminals)
+	mux.HandleFunc("/api/v1/sessions", s.handleSessions)
+	mux.HandleFunc("/api/v1/sessions/", s.handleSession)
+
 	// Auto-filed bug reports
 	mux.HandleFunc("/api/v1/beads/auto-file", s.HandleAutoFileBug)
 
+	// Beads filed by voice memo
+	mux.HandleFunc("/api/v1/beads/voice", s.handleVoiceBead)
+
 	// Logging endpoints
 	mux.HandleFunc("/api/v1/logs/recent", s.HandleLogsRecent)
 	mux.HandleFunc("/api/v1/logs/stream", s.HandleLogsStream)
 	mux.HandleFunc("/api/v1/logs/export", s.HandleLogsExport)
+	mux.HandleFunc("/api/v1/logs/levels", s.handleLogLevels)
+	mux.HandleFunc("/api/v1/features", s.handleFeatures)
+	mux.HandleFunc("/api/v1/features/", s.handleFeature)
+	mux.HandleFunc("/api/v1/admin/read-only", s.handleReadOnly)
+	mux.HandleFunc(pprofPrefix, s.handlePprof)
+	mux.HandleFunc("/api/v1/policies", s.handlePolicies)
+	mux.HandleFunc("/api/v1/policies/reload", s.handleReloadPolicies)
+	mux.HandleFunc("/api/v1/policies/evaluate", s.handleEvaluatePolicy)
 
 	// Chat completions (with streaming support)
 	mux.HandleFunc("/api/v1/chat/completions/stream", s.handleStreamChatCompletion)
@@ -262,6 +334,9 @@ func (s *Server) SetupRoutes() http.Handler {
 	mux.HandleFunc("/api/v1/analytics/stats", s.handleGetLogStats)
 	mux.HandleFunc("/api/v1/analytics/export", s.handleExportLogs)
 	mux.HandleFunc("/api/v1/analytics/export-stats", s.handleExportStats)
+	mux.HandleFunc("/api/v1/export/request-logs", s.handleExportRequestLogs)
+	mux.HandleFunc("/api/v1/export/audit-events", s.handleExportAuditEvents)
+	mux.HandleFunc("/api/v1/export/action-logs", s.handleExportActionLogs)
 	mux.HandleFunc("/api/v1/analytics/costs", s.handleGetCostReport)
 	mux.HandleFunc("/api/v1/analytics/batching", s.handleGetBatchingRecommendations)
 
@@ -281,6 +356,12 @@ func (s *Server) SetupRoutes() http.Handler {
 	mux.HandleFunc("/api/v1/patterns/analysis", s.handlePatternAnalysis)
 	mux.HandleFunc("/api/v1/patterns/expensive", s.handleExpensivePatterns)
 	mux.HandleFunc("/api/v1/patterns/anomalies", s.handleAnomalies)
+	mux.HandleFunc("/api/v1/patterns/anomalies/live", s.handleLiveAnomalies)
+	mux.HandleFunc("/api/v1/pricing/rates", s.handlePricingRates)
+	mux.HandleFunc("/api/v1/pricing/recalculate", s.handlePricingRecalculate)
+	mux.HandleFunc("/api/v1/patterns/reports", s.handlePatternReports)
+	mux.HandleFunc("/api/v1/patterns/reports/", s.handlePatternReport)
+	mux.HandleFunc("/api/v1/patterns/trends", s.handlePatternTrends)
 	mux.HandleFunc("/api/v1/optimizations", s.handleOptimizations)
 	mux.HandleFunc("/api/v1/prompts/analysis", s.handlePromptAnalysis)
 	mux.HandleFunc("/api/v1/prompts/optimizations", s.handlePromptOptimizations)
@@ -291,13 +372,48 @@ func (s *Server) SetupRoutes() http.Handler {
 	mux.HandleFunc("/health", s.handleHealthDetail)      // Detailed health
 	mux.HandleFunc("/health/live", s.handleHealthLive)   // Liveness probe
 	mux.HandleFunc("/health/ready", s.handleHealthReady) // Readiness probe
+	mux.HandleFunc("/healthz", s.handleHealthz)          // Liveness probe with loop checks
+	mux.HandleFunc("/readyz", s.handleReadyz)            // Readiness probe with component checks
 
 	// Configuration
 	mux.HandleFunc("/api/v1/config", s.handleConfig)
 	mux.HandleFunc("/api/v1/config/export.yaml", s.handleConfigExportYAML)
 	mux.HandleFunc("/api/v1/config/import.yaml", s.handleConfigImportYAML)
 
+	// State export/import (instance migration, restore drills)
+	mux.HandleFunc("/api/v1/state/export", s.handleStateExport)
+	mux.HandleFunc("/api/v1/state/import", s.handleStateImport)
+
+	// Database backups
+	mux.HandleFunc("/api/v1/backups", s.handleBackups)
+	mux.HandleFunc("/api/v1/backups/", s.handleBackup)
+
+	// Storage usage and retention
+	mux.HandleFunc("/api/v1/storage", s.handleStorage)
+	mux.HandleFunc("/api/v1/retention/run", s.handleRetentionRun)
+	mux.HandleFunc("/api/v1/archive/beads/", s.handleArchivedBead)
+
+	// Web UI dashboard
+	mux.HandleFunc("/api/v1/dashboard", s.handleDashboard)
+
+	// Project status digests
+	mux.HandleFunc("/api/v1/digests", s.handleDigests)
+	mux.HandleFunc("/api/v1/digests/", s.handleDigest)
+
+	// Bead cost and time estimate accuracy
+	mux.HandleFunc("/api/v1/estimates/accuracy", s.handleEstimateAccuracy)
+
+	// Model A/B experiments
+	mux.HandleFunc("/api/v1/experiments", s.handleExperiments)
+	mux.HandleFunc("/api/v1/experiments/", s.handleExperiment)
+
+	// Full-text search
+	mux.HandleFunc("/api/v1/search", s.handleSearch)
+
 	// Events (real-time updates and event bus)
+	// Editor plugin API
+	mux.HandleFunc("/api/v1/ide/", s.handleIDE)
+
 	mux.HandleFunc("/api/v1/events/stream", s.handleEventStream)
 	mux.HandleFunc("/api/v1/events/stats", s.handleGetEventStats)
 	mux.HandleFunc("/api/v1/events", s.handleGetEvents) // GET for history
@@ -329,17 +445,40 @@ func (s *Server) SetupRoutes() http.Handler {
 	mux.HandleFunc("/api/v1/workflows/analytics", s.handleWorkflowAnalytics)
 	mux.HandleFunc("/api/v1/beads/workflow", s.handleBeadWorkflow)
 
+	// Stored action outputs
+	mux.HandleFunc("/api/v1/results", s.handleResults)
+	mux.HandleFunc("/api/v1/results/", s.handleResult)
+
+	// Agent role registry
+	mux.HandleFunc("/api/v1/agent-roles", s.handleAgentRoles)
+	mux.HandleFunc("/api/v1/agent-roles/", s.handleAgentRole)
+
+	// Agent-to-agent messages
+	mux.HandleFunc("/api/v1/agent-messages", s.handleAgentMessages)
+	mux.HandleFunc("/api/v1/agent-messages/stream", s.handleAgentMessageStream)
+	mux.HandleFunc("/api/v1/agent-messages/", s.handleAgentMessage)
+
+	// Recurring jobs
+	mux.HandleFunc("/api/v1/schedules", s.handleSchedules)
+	mux.HandleFunc("/api/v1/maintenance/templates", s.handleMaintenanceTemplates)
+	mux.HandleFunc("/api/v1/maintenance/tasks", s.handleMaintenanceTasks)
+	mux.HandleFunc("/api/v1/maintenance/tasks/", s.handleMaintenanceTask)
+
 	// Webhooks (external event integration)
 	mux.HandleFunc("/api/v1/webhooks/github", s.handleGitHubWebhook)
 	mux.HandleFunc("/api/v1/webhooks/openclaw", s.handleOpenClawWebhook)
+	mux.HandleFunc("/api/v1/webhooks/gitlab", s.handleGitLabWebhook)
+	mux.HandleFunc("/api/v1/webhooks/jenkins", s.handleJenkinsWebhook)
 	mux.HandleFunc("/api/v1/webhooks/status", s.handleWebhookStatus)
 
 	// OpenClaw messaging gateway
 	mux.HandleFunc("/api/v1/openclaw/status", s.handleOpenClawStatus)
 
 	// Apply middleware
-	handler := s.loggingMiddleware(mux)
+	handler := s.readOnlyMiddleware(mux)
+	handler = s.loggingMiddleware(handler)
 	handler = s.corsMiddleware(handler)
+	handler = s.throttleMiddleware(handler)
 	handler = s.authMiddleware(handler)
 
 	return handler
@@ -348,7 +487,7 @@ func (s *Server) SetupRoutes() http.Handler {
 // handleHealth handles health check requests
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -357,15 +496,40 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 // Middleware
 
-// loggingMiddleware logs HTTP requests
+// loggingMiddleware logs HTTP requests. Each request gets an ID (the
+// client's X-Request-ID if it sent a usable one), returned in the response
+// and added to every line logged with the request's context.
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+		r = r.WithContext(logging.WithFields(r.Context(), logging.FieldRequestID, requestID))
+
+		start := time.Now()
 		recorder := &statusRecorder{ResponseWriter: w}
 		next.ServeHTTP(recorder, r)
+		httpLog.DebugContext(r.Context(), "Request", "method", r.Method, "path", r.URL.Path,
+			"status", recorder.statusCode, "duration", time.Since(start))
 		s.recordAPIFailure(r, recorder.statusCode)
 	})
 }
 
+// validRequestID accepts short IDs of letters, digits and -_.:
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.:", c)) {
+			return false
+		}
+	}
+	return true
+}
+
 type statusRecorder struct {
 	http.ResponseWriter
 	statusCode int
@@ -390,6 +554,12 @@ func (r *statusRecorder) Write(b []byte) (int, error) {
 	return r.ResponseWriter.Write(b)
 }
 
+// Unwrap lets http.ResponseController reach the connection, so handlers can
+// extend their write deadline
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 func (s *Server) recordAPIFailure(r *http.Request, statusCode int) {
 	if statusCode < http.StatusInternalServerError {
 		return
@@ -398,6 +568,12 @@ func (s *Server) recordAPIFailure(r *http.Request, statusCode int) {
 		return
 	}
 
+	// Filing a bead is a write: read-only mode files nothing, including for
+	// the requests it rejects
+	if s.readOnly.Enabled() {
+		return
+	}
+
 	// Don't auto-file failures on the auto-file endpoint itself (prevents loops)
 	if strings.HasSuffix(r.URL.Path, "/auto-file") {
 		return
@@ -470,7 +646,7 @@ func (s *Server) recordAutoFileResult(err error) {
 		if s.autoFileConsecFails >= autoFileCBMaxFails {
 			s.autoFileCircuitOpen = true
 			s.autoFileCircuitOpenAt = time.Now()
-			fmt.Printf("[WARN] Auto-file circuit breaker tripped after %d consecutive failures\n", s.autoFileConsecFails)
+			httpLog.Warn("Auto-file circuit breaker tripped", "consecutive_failures", s.autoFileConsecFails)
 		}
 	} else {
 		s.autoFileConsecFails = 0
@@ -550,21 +726,17 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 // authMiddleware handles authentication
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Skip auth for health check endpoints (all variants for monitoring/probes)
-		if r.URL.Path == "/api/v1/health" ||
-			r.URL.Path == "/health" ||
-			r.URL.Path == "/health/live" ||
-			r.URL.Path == "/health/ready" ||
-			r.URL.Path == "/api/v1/auth/login" ||
-			r.URL.Path == "/api/v1/auth/refresh" ||
-			r.URL.Path == "/" ||
-			r.URL.Path == "/api/openapi.yaml" ||
-			r.URL.Path == "/api/v1/events/stream" ||
-			r.URL.Path == "/api/v1/chat/completions/stream" ||
-			r.URL.Path == "/api/v1/chat/completions" ||
-			r.URL.Path == "/api/v1/pair" ||
-			r.URL.Path == "/api/v1/webhooks/openclaw" ||
-			strings.HasPrefix(r.URL.Path, "/static/") {
+		// Identity of the connection's client certificate (mutual TLS); never
+		// taken from the client
+		r.Header.Del("X-Client-Identity")
+		// Identity headers are set below, never taken from the client
+		auth.StripIdentity(r)
+		certIdentity := auth.ClientCertIdentity(r)
+		if certIdentity != "" {
+			r.Header.Set("X-Client-Identity", certIdentity)
+		}
+
+		if authExempt(r.URL.Path) {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -578,11 +750,136 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// A verified client certificate authenticates callers sending no
+		// token or API key
+		if certIdentity != "" && r.Header.Get("Authorization") == "" && r.Header.Get("X-API-Key") == "" {
+			role := s.config.Security.ClientCertRole
+			if role == "" {
+				role = "service"
+			}
+			r.Header.Set("X-User-ID", "cert:"+certIdentity)
+			r.Header.Set("X-Username", certIdentity)
+			r.Header.Set("X-Role", role)
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		// Apply JWT/API key auth
 		s.authManager.Middleware("")(next).ServeHTTP(w, r)
 	})
 }
 
+// authExempt reports whether path is served without authentication
+func authExempt(path string) bool {
+	// Health check endpoints (all variants for monitoring/probes)
+	return isHealthPath(path) ||
+		path == "/api/v1/auth/login" ||
+		path == "/api/v1/auth/refresh" ||
+		path == "/" ||
+		path == "/api/openapi.yaml" ||
+		path == "/api/v1/events/stream" ||
+		path == "/api/v1/chat/completions/stream" ||
+		path == "/api/v1/chat/completions" ||
+		path == "/api/v1/pair" ||
+		path == "/api/v1/webhooks/openclaw" ||
+		path == ideHandshakePrefix || strings.HasPrefix(path, ideHandshakePrefix+"/") ||
+		strings.HasPrefix(path, "/static/")
+}
+
+func isHealthPath(path string) bool {
+	return path == "/api/v1/health" ||
+		path == "/health" ||
+		path == "/health/live" ||
+		path == "/health/ready" ||
+		path == "/healthz" ||
+		path == "/readyz"
+}
+
+// throttleMiddleware limits request rates per API key, or per authenticated
+// user, at the limit of the caller's role. Anonymous callers (and everyone
+// when auth is disabled) are limited per client address at the default
+// limit. Responses carry X-RateLimit-* headers; refused requests get 429
+// with Retry-After.
+func (s *Server) throttleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.throttle == nil || isHealthPath(r.URL.Path) || r.URL.Path == "/" || strings.HasPrefix(r.URL.Path, "/static/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, role := s.throttleKey(r)
+		d := s.throttle.Allow(key, s.throttlePolicy.For(role))
+		if d.Limit >= 0 {
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+		}
+		if !d.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(d.RetryAfter)))
+			s.respondError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// throttleKey identifies the caller a request is counted against, and its
+// role. The key and user headers are only trusted once auth has checked them.
+func (s *Server) throttleKey(r *http.Request) (key, role string) {
+	authenticated := s.config != nil && s.config.Security.EnableAuth && s.authManager != nil && !authExempt(r.URL.Path)
+	if authenticated {
+		role = r.Header.Get("X-Role")
+	}
+	if apiKey := r.Header.Get("X-API-Key"); authenticated && apiKey != "" {
+		sum := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(sum[:8]), role
+	}
+	if userID := r.Header.Get("X-User-ID"); authenticated && userID != "" {
+		return "user:" + userID, role
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host, role
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// readOnlyMiddleware rejects mutating requests with 503 and a read_only
+// error while the server is read-only. Reads, event streams and health
+// checks carry on, as do logging in and the switch itself, so an admin can
+// turn it off.
+func (s *Server) readOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if readOnlyExempt(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := s.readOnly.Check(); err != nil {
+			apperr.Write(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readOnlyExempt reports whether a request is served while the server is
+// read-only
+func readOnlyExempt(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	path := r.URL.Path
+	return isHealthPath(path) ||
+		path == "/api/v1/auth/login" ||
+		path == "/api/v1/auth/refresh" ||
+		path == "/api/v1/admin/read-only"
+}
+
 // Helper functions
 
 // getUserFromContext extracts the user from request headers (set by auth middleware)
@@ -607,7 +904,7 @@ func (s *Server) getUserFromContext(r *http.Request) *auth.User {
 func (s *Server) respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	body, err := json.Marshal(data)
 	if err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -616,9 +913,16 @@ func (s *Server) respondJSON(w http.ResponseWriter, status int, data interface{}
 	w.Write([]byte("\n"))
 }
 
-// respondError writes an error response
+// respondError writes an error response: {"code", "message", "error"},
+// with the code derived from the status
 func (s *Server) respondError(w http.ResponseWriter, status int, message string) {
-	s.respondJSON(w, status, map[string]string{"error": message})
+	apperr.WriteStatus(w, status, message)
+}
+
+// respondAppError writes err with the status and code of its class, and its
+// details
+func (s *Server) respondAppError(w http.ResponseWriter, err error) {
+	apperr.Write(w, err)
 }
 
 // parseJSON parses JSON request body
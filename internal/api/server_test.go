@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/jordanhubbard/loom/internal/auth"
+	"github.com/jordanhubbard/loom/internal/readonly"
 	"github.com/jordanhubbard/loom/pkg/config"
 )
 
@@ -353,3 +355,176 @@ func TestServer_ConcurrentResponses(t *testing.T) {
 		<-done
 	}
 }
+
+func TestServer_throttleMiddleware(t *testing.T) {
+	server := NewServer(nil, nil, nil, &config.Config{
+		APIThrottle: config.APIThrottleConfig{Enabled: true, RequestsPerMinute: 60, Burst: 2},
+	})
+	handler := server.throttleMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		// Unauthenticated headers must not change the bucket
+		req.Header.Set("X-API-Key", "key-"+strings.Repeat("x", len(path)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := request("/api/v1/beads", "10.0.0.1:5000"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, w.Code)
+		}
+	}
+	w := request("/api/v1/beads/x", "10.0.0.1:5001")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" || w.Header().Get("X-RateLimit-Limit") != "60" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+
+	if w := request("/api/v1/beads", "10.0.0.2:5000"); w.Code != http.StatusOK {
+		t.Errorf("expected another client to be allowed, got %d", w.Code)
+	}
+	if w := request("/health", "10.0.0.1:5000"); w.Code != http.StatusOK {
+		t.Errorf("expected health checks to bypass the throttle, got %d", w.Code)
+	}
+}
+
+func TestServer_readOnlyMiddleware(t *testing.T) {
+	server := &Server{readOnly: readonly.New(config.ReadOnlyConfig{Enabled: true, Reason: "migration"})}
+	handler := server.readOnlyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader("{}")))
+		return w
+	}
+
+	w := request(http.MethodPost, "/api/v1/beads")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for a mutation, got %d", w.Code)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["code"] != "read_only" || !strings.Contains(body["error"].(string), "migration") {
+		t.Errorf("unexpected body %v", body)
+	}
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/beads"},
+		{http.MethodGet, "/api/v1/events/stream"},
+		{http.MethodPost, "/api/v1/auth/login"},
+		{http.MethodPut, "/api/v1/admin/read-only"},
+	} {
+		if w := request(tc.method, tc.path); w.Code != http.StatusOK {
+			t.Errorf("%s %s: expected the request through, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+
+	server.readOnly.Set(false, "", "admin")
+	if w := request(http.MethodDelete, "/api/v1/beads/bd-1"); w.Code != http.StatusOK {
+		t.Errorf("expected mutations through once read-only mode is off, got %d", w.Code)
+	}
+}
+
+func TestServer_handleReadOnly(t *testing.T) {
+	server := &Server{readOnly: readonly.New(config.ReadOnlyConfig{})}
+	put := func(role, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/read-only", strings.NewReader(body))
+		req.Header.Set("X-Role", role)
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		server.handleReadOnly(w, req)
+		return w
+	}
+
+	if w := put("user", `{"enabled": true}`); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-admin, got %d", w.Code)
+	}
+	if w := put("admin", `{"enabled": true, "reason": "audit"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if status := server.readOnly.Status(); !status.Enabled || status.Reason != "audit" || status.By != "user-1" {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	w := httptest.NewRecorder()
+	server.handleReadOnly(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/read-only", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"enabled":true`) {
+		t.Errorf("unexpected GET response %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	newTestServer().handleReadOnly(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/read-only", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without the switch, got %d", w.Code)
+	}
+}
+
+// apiKeyServer returns a server with auth and throttling on, and an API
+// key of a user with the "user" role
+func apiKeyServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	am := auth.NewManager("test-secret")
+	user, err := am.CreateUser("dev", "dev@example.com", "user", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := am.CreateAPIKey(user.ID, auth.CreateAPIKeyRequest{Name: "ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(nil, nil, am, &config.Config{
+		Security: config.SecurityConfig{EnableAuth: true},
+		APIThrottle: config.APIThrottleConfig{
+			Enabled: true, RequestsPerMinute: 60, Burst: 10,
+			Roles: map[string]config.APIThrottleLimit{"admin": {RequestsPerMinute: -1}},
+		},
+	})
+	return server, key.Key
+}
+
+// keyRequest sends a request to handler through the auth middleware with
+// key, claiming the admin role in a forged header
+func keyRequest(s *Server, key string, handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("X-API-Key", key)
+	req.Header.Set("X-Role", "admin")
+	w := httptest.NewRecorder()
+	s.authMiddleware(handler).ServeHTTP(w, req)
+	return w
+}
+
+func TestServer_authMiddlewareIgnoresForgedIdentity(t *testing.T) {
+	server, key := apiKeyServer(t)
+	var role, userID string
+	handler := server.authMiddleware(server.throttleMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role, userID = auth.GetRoleFromRequest(r), auth.GetUserIDFromRequest(r)
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/beads", nil)
+	req.Header.Set("X-API-Key", key)
+	req.Header.Set("X-Role", "admin")
+	req.Header.Set("X-User-ID", "user-admin")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if role != "user" || userID == "user-admin" {
+		t.Errorf("expected the key owner's identity, got role %q user %q", role, userID)
+	}
+	if w.Header().Get("X-RateLimit-Limit") != "60" {
+		t.Errorf("expected the user limit, not the admin one; headers %v", w.Header())
+	}
+}
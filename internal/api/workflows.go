@@ -11,7 +11,7 @@ import (
 // handleWorkflows handles GET /api/v1/workflows - list all workflows
 func (s *Server) handleWorkflows(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -22,14 +22,14 @@ func (s *Server) handleWorkflows(w http.ResponseWriter, r *http.Request) {
 	// Get workflow engine
 	engine := s.app.GetWorkflowEngine()
 	if engine == nil {
-		http.Error(w, "Workflow engine not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Workflow engine not available")
 		return
 	}
 
 	// List workflows
 	workflows, err := engine.GetDatabase().ListWorkflows(workflowType, projectID)
 	if err != nil {
-		http.Error(w, "Failed to list workflows: "+err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to list workflows: "+err.Error())
 		return
 	}
 
@@ -38,14 +38,14 @@ func (s *Server) handleWorkflows(w http.ResponseWriter, r *http.Request) {
 		"workflows": workflows,
 		"count":     len(workflows),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleWorkflow handles GET /api/v1/workflows/{id} - get workflow details
 func (s *Server) handleWorkflow(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -54,38 +54,38 @@ func (s *Server) handleWorkflow(w http.ResponseWriter, r *http.Request) {
 	workflowID := strings.Split(path, "/")[0]
 
 	if workflowID == "" {
-		http.Error(w, "Workflow ID required", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "Workflow ID required")
 		return
 	}
 
 	// Get workflow engine
 	engine := s.app.GetWorkflowEngine()
 	if engine == nil {
-		http.Error(w, "Workflow engine not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Workflow engine not available")
 		return
 	}
 
 	// Get workflow
 	wf, err := engine.GetDatabase().GetWorkflow(workflowID)
 	if err != nil {
-		http.Error(w, "Failed to get workflow: "+err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to get workflow: "+err.Error())
 		return
 	}
 	if wf == nil {
-		http.Error(w, "Workflow not found", http.StatusNotFound)
+		s.respondError(w, http.StatusNotFound, "Workflow not found")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(wf); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleWorkflowExecutions handles GET /api/v1/workflows/executions - list workflow executions
 func (s *Server) handleWorkflowExecutions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -97,7 +97,7 @@ func (s *Server) handleWorkflowExecutions(w http.ResponseWriter, r *http.Request
 	// Get workflow engine
 	engine := s.app.GetWorkflowEngine()
 	if engine == nil {
-		http.Error(w, "Workflow engine not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Workflow engine not available")
 		return
 	}
 
@@ -105,11 +105,11 @@ func (s *Server) handleWorkflowExecutions(w http.ResponseWriter, r *http.Request
 	if beadID != "" {
 		execution, err := engine.GetDatabase().GetWorkflowExecutionByBeadID(beadID)
 		if err != nil {
-			http.Error(w, "Failed to get execution: "+err.Error(), http.StatusInternalServerError)
+			s.respondError(w, http.StatusInternalServerError, "Failed to get execution: "+err.Error())
 			return
 		}
 		if execution == nil {
-			http.Error(w, "Execution not found", http.StatusNotFound)
+			s.respondError(w, http.StatusNotFound, "Execution not found")
 			return
 		}
 
@@ -124,7 +124,7 @@ func (s *Server) handleWorkflowExecutions(w http.ResponseWriter, r *http.Request
 			"execution": execution,
 			"history":   history,
 		}); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 		}
 		return
 	}
@@ -139,34 +139,34 @@ func (s *Server) handleWorkflowExecutions(w http.ResponseWriter, r *http.Request
 		"status":      status,
 		"workflow_id": workflowID,
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleBeadWorkflow handles GET /api/v1/beads/workflow?bead_id={id} - get workflow for a bead
 func (s *Server) handleBeadWorkflow(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	beadID := r.URL.Query().Get("bead_id")
 	if beadID == "" {
-		http.Error(w, "bead_id parameter required", http.StatusBadRequest)
+		s.respondError(w, http.StatusBadRequest, "bead_id parameter required")
 		return
 	}
 
 	// Get workflow engine
 	engine := s.app.GetWorkflowEngine()
 	if engine == nil {
-		http.Error(w, "Workflow engine not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Workflow engine not available")
 		return
 	}
 
 	// Get workflow execution for this bead
 	execution, err := engine.GetDatabase().GetWorkflowExecutionByBeadID(beadID)
 	if err != nil {
-		http.Error(w, "Failed to get execution: "+err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to get execution: "+err.Error())
 		return
 	}
 	if execution == nil {
@@ -175,7 +175,7 @@ func (s *Server) handleBeadWorkflow(w http.ResponseWriter, r *http.Request) {
 			"message": "No workflow execution found for this bead",
 			"bead_id": beadID,
 		}); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 		}
 		return
 	}
@@ -183,7 +183,7 @@ func (s *Server) handleBeadWorkflow(w http.ResponseWriter, r *http.Request) {
 	// Get workflow details
 	wf, err := engine.GetDatabase().GetWorkflow(execution.WorkflowID)
 	if err != nil {
-		http.Error(w, "Failed to get workflow: "+err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to get workflow: "+err.Error())
 		return
 	}
 
@@ -210,21 +210,21 @@ func (s *Server) handleBeadWorkflow(w http.ResponseWriter, r *http.Request) {
 		"current_node": currentNode,
 		"history":      history,
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // handleWorkflowAnalytics handles GET /api/v1/workflows/analytics - get workflow metrics
 func (s *Server) handleWorkflowAnalytics(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Get workflow engine
 	engine := s.app.GetWorkflowEngine()
 	if engine == nil {
-		http.Error(w, "Workflow engine not available", http.StatusServiceUnavailable)
+		s.respondError(w, http.StatusServiceUnavailable, "Workflow engine not available")
 		return
 	}
 
@@ -237,7 +237,7 @@ func (s *Server) handleWorkflowAnalytics(w http.ResponseWriter, r *http.Request)
 	`
 	statusRows, err := s.app.GetDatabase().DB().Query(statusQuery)
 	if err != nil {
-		http.Error(w, "Failed to query execution stats: "+err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to query execution stats: "+err.Error())
 		return
 	}
 	defer statusRows.Close()
@@ -260,7 +260,7 @@ func (s *Server) handleWorkflowAnalytics(w http.ResponseWriter, r *http.Request)
 	`
 	typeRows, err := s.app.GetDatabase().DB().Query(typeQuery)
 	if err != nil {
-		http.Error(w, "Failed to query type stats: "+err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to query type stats: "+err.Error())
 		return
 	}
 	defer typeRows.Close()
@@ -316,7 +316,7 @@ func (s *Server) handleWorkflowAnalytics(w http.ResponseWriter, r *http.Request)
 	`
 	recentRows, err := s.app.GetDatabase().DB().Query(recentQuery)
 	if err != nil {
-		http.Error(w, "Failed to query recent executions: "+err.Error(), http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to query recent executions: "+err.Error())
 		return
 	}
 	defer recentRows.Close()
@@ -351,6 +351,6 @@ func (s *Server) handleWorkflowAnalytics(w http.ResponseWriter, r *http.Request)
 		"escalated_count":   escalatedCount,
 		"recent_executions": recentExecutions,
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		s.respondError(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
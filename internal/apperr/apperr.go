@@ -0,0 +1,181 @@
+// Package apperr is the error taxonomy shared by the API and agent actions.
+// Errors carry a machine-readable Code so API clients and agents can branch
+// on the class of a failure instead of parsing its message.
+package apperr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Code classifies an error
+type Code string
+
+const (
+	CodeNotFound     Code = "not_found"
+	CodeConflict     Code = "conflict"
+	CodeUnauthorized Code = "unauthorized"
+	CodePolicyDenied Code = "policy_denied"
+	CodeValidation   Code = "validation_error"
+	CodeProvider     Code = "provider_error"
+	CodeRateLimited  Code = "rate_limited"
+	CodeUnavailable  Code = "unavailable"
+	CodeReadOnly     Code = "read_only"
+	CodeInternal     Code = "internal"
+)
+
+// Error is an error with a code and optional details
+type Error struct {
+	Code    Code
+	Message string
+	Details map[string]interface{}
+	Err     error // Underlying cause, if any
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Message == "" && e.Err != nil:
+		return e.Err.Error()
+	case e.Err != nil:
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is matches another *Error with the same code, so errors.Is(err,
+// &Error{Code: CodeNotFound}) tests the class
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Message == "" && t.Err == nil && t.Code == e.Code
+}
+
+// WithDetail returns the error with a detail added
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	details := make(map[string]interface{}, len(e.Details)+1)
+	for k, v := range e.Details {
+		details[k] = v
+	}
+	details[key] = value
+	next := *e
+	next.Details = details
+	return &next
+}
+
+// New creates an error of a code
+func New(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap gives err a code, keeping it as the cause. A nil err stays nil.
+func Wrap(code Code, err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	msg := ""
+	if format != "" {
+		msg = fmt.Sprintf(format, args...)
+	}
+	return &Error{Code: code, Message: msg, Err: err}
+}
+
+// NotFound reports a missing resource
+func NotFound(format string, args ...interface{}) *Error {
+	return New(CodeNotFound, format, args...)
+}
+
+// Conflict reports a request that clashes with the current state
+func Conflict(format string, args ...interface{}) *Error {
+	return New(CodeConflict, format, args...)
+}
+
+// Unauthorized reports missing or invalid credentials
+func Unauthorized(format string, args ...interface{}) *Error {
+	return New(CodeUnauthorized, format, args...)
+}
+
+// PolicyDenied reports an operation that a role, sandbox or policy forbids
+func PolicyDenied(format string, args ...interface{}) *Error {
+	return New(CodePolicyDenied, format, args...)
+}
+
+// Validation reports malformed or missing input
+func Validation(format string, args ...interface{}) *Error {
+	return New(CodeValidation, format, args...)
+}
+
+// Provider reports a failure of an LLM provider or other upstream service
+func Provider(err error, format string, args ...interface{}) error {
+	return Wrap(CodeProvider, err, format, args...)
+}
+
+// CodeOf returns the code of err: that of the first *Error in its chain,
+// else one inferred from well-known errors and the message. nil has no
+// code.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, fs.ErrNotExist):
+		return CodeNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return CodePolicyDenied
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return CodeUnavailable
+	}
+	return Classify(err.Error())
+}
+
+// Classify infers a code from an error message, for errors created before
+// the taxonomy (fmt.Errorf("bead not found: %s", id) and the like)
+func Classify(message string) Code {
+	msg := strings.ToLower(message)
+	has := func(words ...string) bool {
+		for _, w := range words {
+			if strings.Contains(msg, w) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case msg == "":
+		return CodeInternal
+	case has("not found", "no such file", "does not exist", "unknown bead", "unknown agent", "unknown project"):
+		return CodeNotFound
+	case has("already exists", "conflict", "modified since", "version mismatch", "already claimed", "already assigned", "already locked"):
+		return CodeConflict
+	case has("unauthorized", "unauthenticated", "invalid token", "invalid api key", "missing authorization"):
+		return CodeUnauthorized
+	case has("not allowed", "permission denied", "forbidden", "insufficient permissions", "denied"):
+		return CodePolicyDenied
+	case has("rate limit", "too many requests", "quota"):
+		return CodeRateLimited
+	case has("provider", "upstream", "model returned", "completion failed"):
+		return CodeProvider
+	case has("invalid", "required", "must be", "must not", "missing", "malformed", "unsupported", "unknown action"):
+		return CodeValidation
+	case has("not configured", "not available", "unavailable", "timed out", "timeout"):
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
+
+// DetailsOf returns the details of the first *Error in err's chain
+func DetailsOf(err error) map[string]interface{} {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Details
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package apperr
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Code
+	}{
+		{nil, ""},
+		{NotFound("bead not found: %s", "bd-1"), CodeNotFound},
+		{fmt.Errorf("claim: %w", Conflict("already claimed")), CodeConflict},
+		{Provider(errors.New("502 from upstream"), "completion failed"), CodeProvider},
+		{fmt.Errorf("lookup: %w", sql.ErrNoRows), CodeNotFound},
+		{&os.PathError{Op: "open", Path: "x", Err: os.ErrNotExist}, CodeNotFound},
+		{context.DeadlineExceeded, CodeUnavailable},
+		{errors.New("command not allowed: rm"), CodePolicyDenied},
+		{errors.New("boom"), CodeInternal},
+	}
+	for _, tt := range tests {
+		if got := CodeOf(tt.err); got != tt.want {
+			t.Errorf("CodeOf(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+
+	err := fmt.Errorf("wrapped: %w", PolicyDenied("path is not allowed"))
+	if !errors.Is(err, &Error{Code: CodePolicyDenied}) {
+		t.Error("expected errors.Is to match the code")
+	}
+	if errors.Is(err, &Error{Code: CodeNotFound}) {
+		t.Error("expected errors.Is not to match another code")
+	}
+	if Wrap(CodeInternal, nil, "x") != nil {
+		t.Error("expected Wrap(nil) to be nil")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := map[string]Code{
+		"":                                      CodeInternal,
+		"project not found: p1":                 CodeNotFound,
+		"file already locked by agent a1":       CodeConflict,
+		"Invalid API key":                       CodeUnauthorized,
+		"role reviewer is not allowed git_push": CodePolicyDenied,
+		"rate limit exceeded":                   CodeRateLimited,
+		"agent_id is required":                  CodeValidation,
+		"git operator not configured":           CodeUnavailable,
+		"exit status 1":                         CodeInternal,
+	}
+	for msg, want := range tests {
+		if got := Classify(msg); got != want {
+			t.Errorf("Classify(%q) = %q, want %q", msg, got, want)
+		}
+	}
+}
+
+func TestStatusMapping(t *testing.T) {
+	for _, code := range []Code{CodeNotFound, CodeConflict, CodeUnauthorized, CodePolicyDenied, CodeValidation, CodeProvider, CodeRateLimited, CodeUnavailable, CodeInternal} {
+		if got := CodeForStatus(HTTPStatus(code)); got != code {
+			t.Errorf("CodeForStatus(HTTPStatus(%s)) = %s", code, got)
+		}
+	}
+	if got := CodeForStatus(http.StatusMethodNotAllowed); got != CodeValidation {
+		t.Errorf("expected other 4xx statuses to be validation errors, got %s", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	w := httptest.NewRecorder()
+	Write(w, NotFound("bead not found").WithDetail("bead_id", "bd-1"))
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var body Body
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != CodeNotFound || body.Message != "bead not found" || body.Error != body.Message || body.Details["bead_id"] != "bd-1" {
+		t.Errorf("unexpected body %+v", body)
+	}
+
+	w = httptest.NewRecorder()
+	WriteStatus(w, http.StatusTooManyRequests, "slow down")
+	body = Body{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusTooManyRequests || body.Code != CodeRateLimited || body.Details != nil {
+		t.Errorf("unexpected response %d %+v", w.Code, body)
+	}
+}
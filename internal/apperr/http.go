@@ -0,0 +1,86 @@
+package apperr
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Body is the JSON body of an API error. Error repeats Message for clients
+// written before codes existed.
+type Body struct {
+	Code    Code                   `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	Error   string                 `json:"error"`
+}
+
+// HTTPStatus returns the status an error code is reported with
+func HTTPStatus(code Code) int {
+	switch code {
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeConflict:
+		return http.StatusConflict
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodePolicyDenied:
+		return http.StatusForbidden
+	case CodeValidation:
+		return http.StatusBadRequest
+	case CodeProvider:
+		return http.StatusBadGateway
+	case CodeRateLimited:
+		return http.StatusTooManyRequests
+	case CodeUnavailable, CodeReadOnly:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeForStatus returns the code of an HTTP error status
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusNotFound, http.StatusGone:
+		return CodeNotFound
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return CodeConflict
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodePolicyDenied
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return CodeProvider
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 400 && status < 500 {
+		return CodeValidation
+	}
+	return CodeInternal
+}
+
+// Write sends err as a JSON error response with the status of its code
+func Write(w http.ResponseWriter, err error) {
+	code := CodeOf(err)
+	WriteBody(w, HTTPStatus(code), Body{Code: code, Message: err.Error(), Details: DetailsOf(err)})
+}
+
+// WriteStatus sends a JSON error response with an explicit status
+func WriteStatus(w http.ResponseWriter, status int, message string) {
+	WriteBody(w, status, Body{Code: CodeForStatus(status), Message: message})
+}
+
+// WriteBody sends body with status
+func WriteBody(w http.ResponseWriter, status int, body Body) {
+	body.Error = body.Message
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, body.Message, status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
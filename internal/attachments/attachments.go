@@ -0,0 +1,405 @@
+// Package attachments keeps the images attached to beads — screenshots of
+// failing UI tests, design mocks — and turns them into message parts for
+// providers with vision. It also keeps the voice memos beads were filed
+// from, with their transcripts.
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/provider"
+)
+
+const (
+	// MaxImageBytes is the largest image that can be attached
+	MaxImageBytes = 4 << 20
+	// MaxImagesPerBead caps how many images one bead can carry
+	MaxImagesPerBead = 20
+	// MaxPromptImages is how many of a bead's images, newest first, go into
+	// an agent's prompt
+	MaxPromptImages = 4
+	// MaxAudioBytes is the largest recording that can be attached, the
+	// OpenAI transcription upload limit
+	MaxAudioBytes = 25 << 20
+)
+
+// Sources of images
+const (
+	SourceAPI     = "api"
+	SourceAgent   = "agent"
+	SourceBrowser = "browser"
+)
+
+// mediaTypes are the image types providers accept
+var mediaTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// audioTypes maps sniffed content types of the recordings transcription
+// backends accept to their audio media types
+var audioTypes = map[string]string{
+	"audio/mpeg":      "audio/mpeg",
+	"audio/wave":      "audio/wav",
+	"application/ogg": "audio/ogg",
+	"video/webm":      "audio/webm",
+	"video/mp4":       "audio/mp4",
+}
+
+// Image is an image attached to a bead, stored inline or referenced by URL
+type Image struct {
+	ID        string    `json:"id"`
+	BeadID    string    `json:"bead_id"`
+	Name      string    `json:"name"`
+	MediaType string    `json:"media_type,omitempty"`
+	Size      int       `json:"size,omitempty"`
+	URL       string    `json:"url,omitempty"` // Remote images only
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      []byte    `json:"-"`
+}
+
+// DataURL is the image as a URL a provider can read: the remote URL, or the
+// data inline
+func (i *Image) DataURL() string {
+	if i.URL != "" {
+		return i.URL
+	}
+	return provider.DataURL(i.MediaType, i.Data)
+}
+
+// Audio is a voice memo attached to a bead, with its transcript
+type Audio struct {
+	ID            string    `json:"id"`
+	BeadID        string    `json:"bead_id"`
+	Name          string    `json:"name"`
+	MediaType     string    `json:"media_type"`
+	Size          int       `json:"size"`
+	Transcript    string    `json:"transcript,omitempty"`
+	TranscribedBy string    `json:"transcribed_by,omitempty"`
+	Language      string    `json:"language,omitempty"`
+	Duration      float64   `json:"duration_seconds,omitempty"`
+	Source        string    `json:"source"`
+	CreatedAt     time.Time `json:"created_at"`
+	Data          []byte    `json:"-"`
+}
+
+// Store persists images and audio
+type Store interface {
+	SaveImage(img *Image) error
+	GetImage(id string) (*Image, error)
+	ListImages(beadID string) ([]*Image, error)
+	DeleteImage(id string) error
+	SaveAudio(a *Audio) error
+	GetAudio(id string) (*Audio, error)
+	ListAudio(beadID string) ([]*Audio, error)
+}
+
+// FileReader reads files from a project's workdir
+type FileReader interface {
+	ReadFile(ctx context.Context, projectID, path string) (*files.FileResult, error)
+}
+
+// Manager attaches images to beads
+type Manager struct {
+	files FileReader
+	mu    sync.RWMutex
+	store Store
+}
+
+// NewManager creates a manager that reads agent attachments through files
+// and keeps images in memory until SetStore is called
+func NewManager(files FileReader) *Manager {
+	return &Manager{files: files, store: newMemoryStore()}
+}
+
+// SetStore persists images in store
+func (m *Manager) SetStore(store Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+}
+
+func (m *Manager) getStore() Store {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.store
+}
+
+// Attach stores image data against a bead. An empty mediaType is detected
+// from the data.
+func (m *Manager) Attach(beadID, name, mediaType string, data []byte, source string) (*Image, error) {
+	if beadID == "" {
+		return nil, apperr.Validation("bead_id is required")
+	}
+	if len(data) == 0 {
+		return nil, apperr.Validation("image is empty")
+	}
+	if len(data) > MaxImageBytes {
+		return nil, apperr.Validation("image exceeds %d bytes", MaxImageBytes)
+	}
+	detected := http.DetectContentType(data)
+	if !mediaTypes[detected] {
+		return nil, apperr.Validation("unsupported image type %s; use PNG, JPEG, GIF or WebP", detected)
+	}
+	if mediaType = strings.ToLower(strings.TrimSpace(mediaType)); mediaType != "" && mediaType != detected {
+		return nil, apperr.Validation("image data is %s, not %s", detected, mediaType)
+	}
+	return m.save(&Image{BeadID: beadID, Name: name, MediaType: detected, Size: len(data), Data: data, Source: source})
+}
+
+// AttachURL attaches a remote image by reference; providers fetch it
+func (m *Manager) AttachURL(beadID, name, imageURL, source string) (*Image, error) {
+	if beadID == "" {
+		return nil, apperr.Validation("bead_id is required")
+	}
+	u, err := url.Parse(imageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, apperr.Validation("url must be an http(s) URL")
+	}
+	if name == "" {
+		name = path.Base(u.Path)
+	}
+	return m.save(&Image{BeadID: beadID, Name: name, URL: imageURL, Source: source})
+}
+
+// AttachFile attaches an image from the project's workdir
+func (m *Manager) AttachFile(ctx context.Context, projectID, beadID, filePath string) (*Image, error) {
+	if m.files == nil {
+		return nil, fmt.Errorf("project files are not available")
+	}
+	if filePath == "" {
+		return nil, apperr.Validation("path is required")
+	}
+	f, err := m.files.ReadFile(ctx, projectID, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return m.Attach(beadID, path.Base(filePath), "", []byte(f.Content), SourceAgent)
+}
+
+func (m *Manager) save(img *Image) (*Image, error) {
+	store := m.getStore()
+	existing, err := store.ListImages(img.BeadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= MaxImagesPerBead {
+		return nil, apperr.Conflict("bead %s already has %d images", img.BeadID, MaxImagesPerBead)
+	}
+	img.ID = "img-" + uuid.New().String()[:8]
+	img.CreatedAt = time.Now().UTC()
+	if img.Name == "" {
+		img.Name = img.ID
+	}
+	if err := store.SaveImage(img); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// Get returns an image with its data
+func (m *Manager) Get(id string) (*Image, error) {
+	img, err := m.getStore().GetImage(id)
+	if err != nil {
+		return nil, err
+	}
+	if img == nil {
+		return nil, apperr.NotFound("image %s not found", id)
+	}
+	return img, nil
+}
+
+// List returns a bead's images, oldest first
+func (m *Manager) List(beadID string) ([]*Image, error) {
+	return m.getStore().ListImages(beadID)
+}
+
+// Delete removes an image
+func (m *Manager) Delete(id string) error {
+	if _, err := m.Get(id); err != nil {
+		return err
+	}
+	return m.getStore().DeleteImage(id)
+}
+
+// AudioType returns the media type of a recording, or a validation error
+// when it is too big or not a format transcription accepts
+func AudioType(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", apperr.Validation("audio is empty")
+	}
+	if len(data) > MaxAudioBytes {
+		return "", apperr.Validation("audio exceeds %d bytes", MaxAudioBytes)
+	}
+	// http.DetectContentType doesn't know FLAC
+	if bytes.HasPrefix(data, []byte("fLaC")) {
+		return "audio/flac", nil
+	}
+	detected := http.DetectContentType(data)
+	if mediaType, ok := audioTypes[detected]; ok {
+		return mediaType, nil
+	}
+	return "", apperr.Validation("unsupported audio type %s; use MP3, WAV, OGG, WebM, M4A or FLAC", detected)
+}
+
+// AttachAudio stores a recording, with whatever transcript is set on a,
+// against a bead. The media type is detected from the data.
+func (m *Manager) AttachAudio(a *Audio) (*Audio, error) {
+	if a.BeadID == "" {
+		return nil, apperr.Validation("bead_id is required")
+	}
+	mediaType, err := AudioType(a.Data)
+	if err != nil {
+		return nil, err
+	}
+	a.ID = "aud-" + uuid.New().String()[:8]
+	a.MediaType = mediaType
+	a.Size = len(a.Data)
+	a.CreatedAt = time.Now().UTC()
+	if a.Name == "" {
+		a.Name = a.ID
+	}
+	if err := m.getStore().SaveAudio(a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetAudio returns a recording with its data
+func (m *Manager) GetAudio(id string) (*Audio, error) {
+	a, err := m.getStore().GetAudio(id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, apperr.NotFound("audio %s not found", id)
+	}
+	return a, nil
+}
+
+// ListAudio returns a bead's recordings, oldest first
+func (m *Manager) ListAudio(beadID string) ([]*Audio, error) {
+	return m.getStore().ListAudio(beadID)
+}
+
+// BeadImageParts returns message parts for a bead's newest images, up to
+// MaxPromptImages, in the order they were attached
+func (m *Manager) BeadImageParts(beadID string) ([]provider.ContentPart, error) {
+	images, err := m.List(beadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) > MaxPromptImages {
+		images = images[len(images)-MaxPromptImages:]
+	}
+	parts := make([]provider.ContentPart, 0, len(images))
+	for _, img := range images {
+		parts = append(parts, provider.ImagePart(img.DataURL()))
+	}
+	return parts, nil
+}
+
+// ImagePart returns the message part of one image
+func (m *Manager) ImagePart(id string) (provider.ContentPart, error) {
+	img, err := m.Get(id)
+	if err != nil {
+		return provider.ContentPart{}, err
+	}
+	return provider.ImagePart(img.DataURL()), nil
+}
+
+// memoryStore keeps images and audio until restart
+type memoryStore struct {
+	mu     sync.RWMutex
+	images map[string]*Image
+	audio  map[string]*Audio
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{images: map[string]*Image{}, audio: map[string]*Audio{}}
+}
+
+func (s *memoryStore) SaveImage(img *Image) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.images[img.ID] = img
+	return nil
+}
+
+func (s *memoryStore) GetImage(id string) (*Image, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.images[id], nil
+}
+
+func (s *memoryStore) ListImages(beadID string) ([]*Image, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Image
+	for _, img := range s.images {
+		if img.BeadID == beadID {
+			out = append(out, img)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out, nil
+}
+
+func (s *memoryStore) DeleteImage(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.images, id)
+	return nil
+}
+
+func (s *memoryStore) SaveAudio(a *Audio) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.audio[a.ID] = a
+	return nil
+}
+
+func (s *memoryStore) GetAudio(id string) (*Audio, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.audio[id], nil
+}
+
+func (s *memoryStore) ListAudio(beadID string) ([]*Audio, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Audio
+	for _, a := range s.audio {
+		if a.BeadID == beadID {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out, nil
+}
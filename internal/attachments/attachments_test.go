@@ -0,0 +1,132 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/files"
+)
+
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type fakeFiles map[string]string
+
+func (f fakeFiles) ReadFile(ctx context.Context, projectID, path string) (*files.FileResult, error) {
+	content, ok := f[path]
+	if !ok {
+		return nil, errors.New("no such file")
+	}
+	return &files.FileResult{Path: path, Content: content}, nil
+}
+
+func TestAttach(t *testing.T) {
+	m := NewManager(fakeFiles{"shots/login.png": string(png), "notes.txt": "hello"})
+
+	img, err := m.Attach("b1", "mock.png", "", png, SourceAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.MediaType != "image/png" || img.Size != len(png) || !strings.HasPrefix(img.ID, "img-") {
+		t.Errorf("unexpected image %+v", img)
+	}
+	if _, err := m.Attach("b1", "x", "image/jpeg", png, SourceAPI); apperr.CodeOf(err) != apperr.CodeValidation {
+		t.Errorf("expected a mismatched media type to be rejected, got %v", err)
+	}
+	if _, err := m.Attach("b1", "x", "", []byte("plain text"), SourceAPI); apperr.CodeOf(err) != apperr.CodeValidation {
+		t.Errorf("expected non-images to be rejected, got %v", err)
+	}
+	if _, err := m.AttachURL("b1", "", "file:///etc/passwd", SourceAPI); apperr.CodeOf(err) != apperr.CodeValidation {
+		t.Errorf("expected a non-http URL to be rejected, got %v", err)
+	}
+	remote, err := m.AttachURL("b1", "", "https://example.com/designs/home.png", SourceAPI)
+	if err != nil || remote.Name != "home.png" || remote.DataURL() != "https://example.com/designs/home.png" {
+		t.Fatalf("AttachURL() = %+v, %v", remote, err)
+	}
+
+	shot, err := m.AttachFile(context.Background(), "p1", "b1", "shots/login.png")
+	if err != nil || shot.Source != SourceAgent || shot.Name != "login.png" {
+		t.Fatalf("AttachFile() = %+v, %v", shot, err)
+	}
+	if _, err := m.AttachFile(context.Background(), "p1", "b1", "notes.txt"); err == nil {
+		t.Error("expected a text file to be rejected")
+	}
+
+	part, err := m.ImagePart(img.ID)
+	if err != nil || !strings.HasPrefix(part.ImageURL.URL, "data:image/png;base64,") {
+		t.Errorf("ImagePart() = %+v, %v", part, err)
+	}
+	if err := m.Delete(img.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(img.ID); apperr.CodeOf(err) != apperr.CodeNotFound {
+		t.Errorf("expected a deleted image to be gone, got %v", err)
+	}
+}
+
+func TestBeadImageParts(t *testing.T) {
+	m := NewManager(nil)
+	for i := 0; i < MaxPromptImages+2; i++ {
+		if _, err := m.AttachURL("b1", "", "https://example.com/"+string(rune('a'+i))+".png", SourceAPI); err != nil {
+			t.Fatal(err)
+		}
+	}
+	parts, err := m.BeadImageParts("b1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != MaxPromptImages || parts[len(parts)-1].ImageURL.URL != "https://example.com/f.png" {
+		t.Errorf("expected the newest %d images, got %+v", MaxPromptImages, parts)
+	}
+	if parts, _ := m.BeadImageParts("other"); len(parts) != 0 {
+		t.Errorf("expected no images for another bead, got %d", len(parts))
+	}
+
+	for i := MaxPromptImages + 2; i < MaxImagesPerBead; i++ {
+		if _, err := m.AttachURL("b1", "", "https://example.com/x.png", SourceAPI); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.AttachURL("b1", "", "https://example.com/y.png", SourceAPI); apperr.CodeOf(err) != apperr.CodeConflict {
+		t.Errorf("expected the per-bead cap to be enforced, got %v", err)
+	}
+}
+
+func TestAttachAudio(t *testing.T) {
+	m := NewManager(nil)
+	for data, want := range map[string]string{
+		"ID3\x04\x00\x00\x00\x00\x00\x00":          "audio/mpeg",
+		"RIFF\x24\x00\x00\x00WAVEfmt ":             "audio/wav",
+		"OggS\x00\x02\x00\x00\x00\x00\x00\x00\x00": "audio/ogg",
+		"fLaC\x00\x00\x00\x22":                     "audio/flac",
+	} {
+		if got, err := AudioType([]byte(data)); got != want || err != nil {
+			t.Errorf("AudioType(%q) = %q, %v; want %q", data, got, err, want)
+		}
+	}
+	if _, err := AudioType(png); apperr.CodeOf(err) != apperr.CodeValidation {
+		t.Errorf("expected an image to be rejected, got %v", err)
+	}
+
+	memo, err := m.AttachAudio(&Audio{BeadID: "b1", Name: "memo.mp3", Data: []byte("ID3\x04\x00\x00"), Transcript: "Fix it.", Source: SourceAPI})
+	if err != nil || !strings.HasPrefix(memo.ID, "aud-") || memo.MediaType != "audio/mpeg" || memo.Size != 6 {
+		t.Fatalf("AttachAudio() = %+v, %v", memo, err)
+	}
+	if _, err := m.AttachAudio(&Audio{Data: []byte("ID3")}); apperr.CodeOf(err) != apperr.CodeValidation {
+		t.Errorf("expected a missing bead to be rejected, got %v", err)
+	}
+	if got, err := m.GetAudio(memo.ID); err != nil || got.Transcript != "Fix it." {
+		t.Errorf("GetAudio() = %+v, %v", got, err)
+	}
+	if _, err := m.GetAudio("aud-missing"); apperr.CodeOf(err) != apperr.CodeNotFound {
+		t.Errorf("expected not found, got %v", err)
+	}
+	if list, _ := m.ListAudio("b1"); len(list) != 1 {
+		t.Errorf("ListAudio() returned %d recordings", len(list))
+	}
+	if images, _ := m.List("b1"); len(images) != 0 {
+		t.Errorf("audio should not be listed with images, got %d", len(images))
+	}
+}
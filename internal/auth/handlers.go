@@ -3,6 +3,8 @@ package auth
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
 )
 
 // Handlers provides HTTP handlers for auth operations
@@ -18,126 +20,126 @@ func NewHandlers(manager *Manager) *Handlers {
 // HandleLogin handles POST /auth/login
 func (h *Handlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	resp, err := h.manager.Login(req.Username, req.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		apperr.WriteStatus(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // HandleChangePassword handles POST /auth/change-password
 func (h *Handlers) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID := GetUserIDFromRequest(r)
 	if userID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		apperr.WriteStatus(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	var req ChangePasswordRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if err := h.manager.ChangePassword(userID, req.CurrentPassword, req.NewPassword); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]string{"message": "Password changed successfully"}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // HandleCreateAPIKey handles POST /auth/api-keys
 func (h *Handlers) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID := GetUserIDFromRequest(r)
 	if userID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		apperr.WriteStatus(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	var req CreateAPIKeyRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	resp, err := h.manager.CreateAPIKey(userID, req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // HandleGetCurrentUser handles GET /auth/me
 func (h *Handlers) HandleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID := GetUserIDFromRequest(r)
 	if userID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		apperr.WriteStatus(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	user, err := h.manager.GetUser(userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apperr.WriteStatus(w, http.StatusNotFound, err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(user); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // HandleCreateUser handles POST /auth/users (admin only)
 func (h *Handlers) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Check admin permission
 	role := GetRoleFromRequest(r)
 	if role != "admin" {
-		http.Error(w, "Admin access required", http.StatusForbidden)
+		apperr.WriteStatus(w, http.StatusForbidden, "Admin access required")
 		return
 	}
 
@@ -149,34 +151,34 @@ func (h *Handlers) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	user, err := h.manager.CreateUser(req.Username, req.Email, req.Role, req.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(user); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // HandleListUsers handles GET /auth/users (admin only)
 func (h *Handlers) HandleListUsers(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Check admin permission
 	role := GetRoleFromRequest(r)
 	if role != "admin" {
-		http.Error(w, "Admin access required", http.StatusForbidden)
+		apperr.WriteStatus(w, http.StatusForbidden, "Admin access required")
 		return
 	}
 
@@ -184,45 +186,45 @@ func (h *Handlers) HandleListUsers(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(users); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // HandleHealthCheck handles GET /health (no auth required)
 func (h *Handlers) HandleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]string{"status": "ok"}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
 
 // HandleRefreshToken handles POST /auth/refresh (returns new token)
 func (h *Handlers) HandleRefreshToken(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID := GetUserIDFromRequest(r)
 	if userID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		apperr.WriteStatus(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	user, err := h.manager.GetUser(userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apperr.WriteStatus(w, http.StatusNotFound, err.Error())
 		return
 	}
 
 	token, err := h.manager.GenerateToken(user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -231,6 +233,6 @@ func (h *Handlers) HandleRefreshToken(w http.ResponseWriter, r *http.Request) {
 		"token":      token,
 		"expires_in": int64(h.manager.tokenTTL.Seconds()),
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, "Failed to encode response")
 	}
 }
@@ -3,14 +3,17 @@ package auth
 import (
 	"crypto/rand"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var authLog = logging.Module("auth")
+
 // Manager handles authentication and authorization
 type Manager struct {
 	jwtSecret string
@@ -27,7 +30,7 @@ func NewManager(jwtSecret string) *Manager {
 	if jwtSecret == "" {
 		// Generate a random JWT secret if not provided
 		jwtSecret = generateRandomSecret(32)
-		log.Printf("Generated random JWT secret for session (not persistent)")
+		authLog.Warn("Generated random JWT secret for session (not persistent)")
 	}
 
 	m := &Manager{
@@ -206,7 +209,7 @@ func (m *Manager) CreateAPIKey(userID string, req CreateAPIKeyRequest) (*CreateA
 
 	m.apiKeys[keyID] = apiKey
 
-	log.Printf("Created API key %s for user %s", keyPrefix, user.Username)
+	authLog.Info("Created API key", "key_prefix", keyPrefix, "username", user.Username)
 
 	return &CreateAPIKeyResponse{
 		ID:        keyID,
@@ -269,7 +272,7 @@ func (m *Manager) ChangePassword(userID, oldPassword, newPassword string) error
 	m.passwords[userID] = string(newHash)
 	user.UpdatedAt = time.Now()
 
-	log.Printf("Password changed for user %s", user.Username)
+	authLog.Info("Password changed", "username", user.Username)
 	return nil
 }
 
@@ -304,7 +307,7 @@ func (m *Manager) CreateUser(username, email, role, password string) (*User, err
 
 	m.users[userID] = user
 
-	log.Printf("Created user %s with role %s", username, role)
+	authLog.Info("Created user", "username", username, "role", role)
 	return user, nil
 }
 
@@ -1,29 +1,33 @@
 package auth
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"strings"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
 )
 
 // Middleware wraps an HTTP handler with authentication
 func (m *Manager) Middleware(requiredPermission string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			StripIdentity(r)
 			// Get token from Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				// Try API key auth
 				apiKey := r.Header.Get("X-API-Key")
 				if apiKey == "" {
-					http.Error(w, "Missing authorization header", http.StatusUnauthorized)
+					apperr.WriteStatus(w, http.StatusUnauthorized, "Missing authorization header")
 					return
 				}
 
 				// Validate API key
 				userID, permissions, err := m.ValidateAPIKey(apiKey)
 				if err != nil {
-					http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					apperr.WriteStatus(w, http.StatusUnauthorized, "Invalid API key")
 					return
 				}
 
@@ -37,13 +41,13 @@ func (m *Manager) Middleware(requiredPermission string) func(http.Handler) http.
 						}
 					}
 					if !hasPermission {
-						http.Error(w, "Insufficient permissions", http.StatusForbidden)
+						apperr.WriteStatus(w, http.StatusForbidden, "Insufficient permissions")
 						return
 					}
 				}
 
-				// Store userID in context
-				r.Header.Set("X-User-ID", userID)
+				// Store the key owner in context
+				m.setKeyOwner(r, userID)
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -51,7 +55,7 @@ func (m *Manager) Middleware(requiredPermission string) func(http.Handler) http.
 			// Extract token from "Bearer <token>" format
 			parts := strings.Split(authHeader, " ")
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+				apperr.WriteStatus(w, http.StatusUnauthorized, "Invalid authorization header format")
 				return
 			}
 
@@ -60,13 +64,13 @@ func (m *Manager) Middleware(requiredPermission string) func(http.Handler) http.
 			// Validate token
 			claims, err := m.ValidateToken(tokenString)
 			if err != nil {
-				http.Error(w, fmt.Sprintf("Invalid token: %v", err), http.StatusUnauthorized)
+				apperr.WriteStatus(w, http.StatusUnauthorized, fmt.Sprintf("Invalid token: %v", err))
 				return
 			}
 
 			// Check permission
 			if requiredPermission != "" && !m.HasPermission(claims, requiredPermission) {
-				http.Error(w, "Insufficient permissions", http.StatusForbidden)
+				apperr.WriteStatus(w, http.StatusForbidden, "Insufficient permissions")
 				return
 			}
 
@@ -85,13 +89,14 @@ func (m *Manager) Middleware(requiredPermission string) func(http.Handler) http.
 func (m *Manager) OptionalAuth() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			StripIdentity(r)
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				// Try API key
 				apiKey := r.Header.Get("X-API-Key")
 				if apiKey != "" {
 					if userID, _, err := m.ValidateAPIKey(apiKey); err == nil {
-						r.Header.Set("X-User-ID", userID)
+						m.setKeyOwner(r, userID)
 					}
 				}
 				next.ServeHTTP(w, r)
@@ -114,6 +119,24 @@ func (m *Manager) OptionalAuth() func(http.Handler) http.Handler {
 	}
 }
 
+// StripIdentity removes the identity headers a client may have sent, so
+// only the server sets them
+func StripIdentity(r *http.Request) {
+	r.Header.Del("X-User-ID")
+	r.Header.Del("X-Username")
+	r.Header.Del("X-Role")
+}
+
+// setKeyOwner stores the owner of a validated API key, and the owner's
+// role, in the request headers
+func (m *Manager) setKeyOwner(r *http.Request, userID string) {
+	r.Header.Set("X-User-ID", userID)
+	if user, err := m.GetUser(userID); err == nil {
+		r.Header.Set("X-Username", user.Username)
+		r.Header.Set("X-Role", user.Role)
+	}
+}
+
 // GetUserIDFromRequest extracts the user ID from request context
 func GetUserIDFromRequest(r *http.Request) string {
 	return r.Header.Get("X-User-ID")
@@ -128,3 +151,28 @@ func GetUsernameFromRequest(r *http.Request) string {
 func GetRoleFromRequest(r *http.Request) string {
 	return r.Header.Get("X-Role")
 }
+
+// ClientCertIdentity returns the identity of the request's verified client
+// certificate, or "" when the connection presented none
+func ClientCertIdentity(r *http.Request) string {
+	return CertificateIdentity(r.TLS)
+}
+
+// CertificateIdentity returns the identity of a connection's verified
+// client certificate: its common name, else its first DNS or URI name. It
+// is empty when the connection presented no verified certificate.
+func CertificateIdentity(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	leaf := state.VerifiedChains[0][0]
+	switch {
+	case leaf.Subject.CommonName != "":
+		return leaf.Subject.CommonName
+	case len(leaf.DNSNames) > 0:
+		return leaf.DNSNames[0]
+	case len(leaf.URIs) > 0:
+		return leaf.URIs[0].String()
+	}
+	return ""
+}
@@ -0,0 +1,771 @@
+// Package backup takes online backups of the Loom database and key store,
+// ships them to a local directory or S3, prunes them by a retention policy,
+// and verifies and restores them. Each backup is a single tar.gz whose
+// manifest records a SHA-256 for every file it holds.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/jordanhubbard/loom/internal/logging"
+)
+
+var backupLog = logging.Module("backup")
+
+// FormatVersion is the manifest version written by this package
+const FormatVersion = 1
+
+// Files inside a backup archive
+const (
+	ManifestFile = "manifest.json"
+	SQLiteFile   = "database.sqlite"
+	PostgresFile = "database.sql"
+	KeyStoreFile = "keystore.json"
+)
+
+const (
+	namePrefix = "loom-backup-"
+	nameSuffix = ".tar.gz"
+	nameTime   = "20060102T150405Z"
+
+	// pgDumpTrailer ends every complete plain-format pg_dump
+	pgDumpTrailer = "PostgreSQL database dump complete"
+)
+
+// Source describes what to back up
+type Source struct {
+	DatabaseType string  // "sqlite" or "postgres"
+	DB           *sql.DB // open SQLite handle, backed up with VACUUM INTO
+	PostgresDSN  string  // dumped with pg_dump
+	KeyStorePath string  // encrypted key store file; skipped when missing
+}
+
+// Retention decides which backups Prune deletes. The KeepLast newest
+// backups are always kept; older ones go once they are older than MaxAge,
+// or right away when MaxAge is zero.
+type Retention struct {
+	KeepLast int
+	MaxAge   time.Duration
+}
+
+// FileEntry is one file recorded in a manifest
+type FileEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes a backup's contents
+type Manifest struct {
+	Version      int         `json:"version"`
+	CreatedAt    time.Time   `json:"created_at"`
+	DatabaseType string      `json:"database_type"`
+	Files        []FileEntry `json:"files"`
+}
+
+// Info is a stored backup
+type Info struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Manager creates, lists, verifies and prunes backups
+type Manager struct {
+	store     Store
+	source    Source
+	retention Retention
+
+	mu         sync.Mutex // serializes runs
+	lastRun    time.Time
+	lastErr    error
+	now        func() time.Time
+	pgDumpPath string
+	psqlPath   string
+}
+
+// NewManager creates a backup manager writing to store
+func NewManager(store Store, source Source, retention Retention) *Manager {
+	return &Manager{
+		store:      store,
+		source:     source,
+		retention:  retention,
+		now:        time.Now,
+		pgDumpPath: "pg_dump",
+		psqlPath:   "psql",
+	}
+}
+
+// Name returns the object name for a backup taken at t
+func Name(t time.Time) string {
+	return namePrefix + t.UTC().Format(nameTime) + nameSuffix
+}
+
+// parseName returns when a backup was taken, or false for objects that are
+// not backups
+func parseName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, nameSuffix) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(nameTime, strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), nameSuffix))
+	return t, err == nil
+}
+
+// Start takes a backup every interval until ctx is cancelled
+func (m *Manager) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if info, err := m.Run(ctx); err != nil {
+				backupLog.Error("Scheduled backup failed", "error", err)
+			} else {
+				backupLog.Info("Stored backup", "name", info.Name, "bytes", info.Size)
+			}
+		}
+	}
+}
+
+// LastRun reports when the last backup finished and its error, if any
+func (m *Manager) LastRun() (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastRun, m.lastErr
+}
+
+// Run takes a backup, verifies it, stores it and prunes old backups
+func (m *Manager) Run(ctx context.Context) (*Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, err := m.run(ctx)
+	m.lastRun, m.lastErr = m.now(), err
+	return info, err
+}
+
+func (m *Manager) run(ctx context.Context) (*Info, error) {
+	work, err := os.MkdirTemp("", "loom-backup-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(work)
+
+	created := m.now().UTC()
+	manifest := &Manifest{Version: FormatVersion, CreatedAt: created, DatabaseType: m.source.DatabaseType}
+	files := filepath.Join(work, "files")
+	if err := os.Mkdir(files, 0700); err != nil {
+		return nil, err
+	}
+
+	switch m.source.DatabaseType {
+	case "sqlite":
+		if err := dumpSQLite(ctx, m.source.DB, filepath.Join(files, SQLiteFile)); err != nil {
+			return nil, err
+		}
+	case "postgres":
+		if err := m.dumpPostgres(ctx, filepath.Join(files, PostgresFile)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported database type %q", m.source.DatabaseType)
+	}
+	if m.source.KeyStorePath != "" {
+		if err := copyFile(m.source.KeyStorePath, filepath.Join(files, KeyStoreFile)); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to copy key store: %w", err)
+		}
+	}
+
+	name := Name(created)
+	archive := filepath.Join(work, name)
+	if err := writeArchive(archive, files, manifest); err != nil {
+		return nil, err
+	}
+	// Never store a backup that would not restore
+	if _, err := verifyArchive(archive, filepath.Join(work, "verify")); err != nil {
+		return nil, fmt.Errorf("backup failed verification: %w", err)
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.Put(ctx, name, f, st.Size()); err != nil {
+		return nil, fmt.Errorf("failed to store backup: %w", err)
+	}
+
+	if _, err := m.Prune(ctx); err != nil {
+		backupLog.Error("Pruning old backups failed", "error", err)
+	}
+	return &Info{Name: name, Size: st.Size(), CreatedAt: created}, nil
+}
+
+// List returns stored backups, newest first
+func (m *Manager) List(ctx context.Context) ([]Info, error) {
+	return List(ctx, m.store)
+}
+
+// List returns the backups in store, newest first
+func List(ctx context.Context, store Store) ([]Info, error) {
+	objects, err := store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var backups []Info
+	for _, obj := range objects {
+		if t, ok := parseName(obj.Name); ok {
+			backups = append(backups, Info{Name: obj.Name, Size: obj.Size, CreatedAt: t})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// Prune deletes backups outside the retention policy and returns their names
+func (m *Manager) Prune(ctx context.Context) ([]string, error) {
+	backups, err := m.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keep := m.retention.KeepLast
+	if keep < 1 {
+		keep = 1
+	}
+	var deleted []string
+	for i, b := range backups {
+		if i < keep {
+			continue
+		}
+		if m.retention.MaxAge > 0 && m.now().Sub(b.CreatedAt) <= m.retention.MaxAge {
+			continue
+		}
+		if err := m.store.Delete(ctx, b.Name); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", b.Name, err)
+		}
+		deleted = append(deleted, b.Name)
+	}
+	return deleted, nil
+}
+
+// Verify checks a stored backup against its manifest
+func (m *Manager) Verify(ctx context.Context, name string) (*Manifest, error) {
+	return Verify(ctx, m.store, name)
+}
+
+// Verify downloads a backup and checks every file against its manifest,
+// including a SQLite integrity check of the database copy
+func Verify(ctx context.Context, store Store, name string) (*Manifest, error) {
+	work, err := os.MkdirTemp("", "loom-verify-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(work)
+
+	archive, err := fetch(ctx, store, name, work)
+	if err != nil {
+		return nil, err
+	}
+	return verifyArchive(archive, filepath.Join(work, "files"))
+}
+
+// RestoreOptions names where a backup is restored to
+type RestoreOptions struct {
+	SQLitePath   string // target database file for SQLite backups
+	PostgresDSN  string // target database for Postgres backups
+	KeyStorePath string // target key store; empty skips the key store
+	// Force replaces existing files, keeping them beside the target with a
+	// .pre-restore suffix
+	Force bool
+}
+
+// Restore verifies a backup and writes it back. Loom must be stopped while
+// a SQLite database is restored.
+func (m *Manager) Restore(ctx context.Context, name string, opts RestoreOptions) (*Manifest, error) {
+	work, err := os.MkdirTemp("", "loom-restore-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(work)
+
+	archive, err := fetch(ctx, m.store, name, work)
+	if err != nil {
+		return nil, err
+	}
+	files := filepath.Join(work, "files")
+	manifest, err := verifyArchive(archive, files)
+	if err != nil {
+		return nil, fmt.Errorf("refusing to restore: %w", err)
+	}
+
+	switch manifest.DatabaseType {
+	case "sqlite":
+		if opts.SQLitePath == "" {
+			return nil, fmt.Errorf("no SQLite path to restore to")
+		}
+		// A stale WAL beside the restored file would be replayed into it
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if err := setAside(opts.SQLitePath+suffix, opts.Force || suffix != ""); err != nil {
+				return nil, err
+			}
+		}
+		if err := copyFile(filepath.Join(files, SQLiteFile), opts.SQLitePath); err != nil {
+			return nil, err
+		}
+	case "postgres":
+		if opts.PostgresDSN == "" {
+			return nil, fmt.Errorf("no Postgres DSN to restore to")
+		}
+		if !opts.Force {
+			return nil, fmt.Errorf("restoring replaces the tables in %s; pass force to continue", redactDSN(opts.PostgresDSN))
+		}
+		conn, env := pgConn(opts.PostgresDSN)
+		cmd := exec.CommandContext(ctx, m.psqlPath, "-v", "ON_ERROR_STOP=1", "-q", "-f", filepath.Join(files, PostgresFile), conn)
+		cmd.Env = env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("psql failed: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	if opts.KeyStorePath != "" && manifest.has(KeyStoreFile) {
+		if err := setAside(opts.KeyStorePath, opts.Force); err != nil {
+			return nil, err
+		}
+		if err := copyFile(filepath.Join(files, KeyStoreFile), opts.KeyStorePath); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+func (m *Manifest) has(name string) bool {
+	for _, f := range m.Files {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setAside renames an existing file out of the way of a restore
+func setAside(path string, force bool) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("%s already exists; pass force to replace it", path)
+	}
+	return os.Rename(path, path+".pre-restore")
+}
+
+func dumpSQLite(ctx context.Context, db *sql.DB, dest string) error {
+	if db == nil {
+		return fmt.Errorf("no database handle to back up")
+	}
+	// VACUUM INTO takes a consistent snapshot without blocking writers for
+	// longer than a read transaction
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", dest); err != nil {
+		return fmt.Errorf("sqlite backup failed: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) dumpPostgres(ctx context.Context, dest string) error {
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	var stderr strings.Builder
+	conn, env := pgConn(m.source.PostgresDSN)
+	// --clean lets a restore replace the tables of a populated database
+	cmd := exec.CommandContext(ctx, m.pgDumpPath, "--format=plain", "--no-owner", "--clean", "--if-exists", conn)
+	cmd.Env = env
+	cmd.Stdout = out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pg_dump failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out.Close()
+}
+
+// writeArchive tars and gzips the files in dir behind a manifest of their
+// checksums
+func writeArchive(dest, dir string, manifest *Manifest) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		sum, size, err := hashFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, FileEntry{Name: e.Name(), Size: size, SHA256: sum})
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	if err := tw.WriteHeader(&tar.Header{Name: ManifestFile, Mode: 0600, Size: int64(len(manifestData)), ModTime: manifest.CreatedAt}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifestData); err != nil {
+		return err
+	}
+	for _, entry := range manifest.Files {
+		if err := tw.WriteHeader(&tar.Header{Name: entry.Name, Mode: 0600, Size: entry.Size, ModTime: manifest.CreatedAt}); err != nil {
+			return err
+		}
+		f, err := os.Open(filepath.Join(dir, entry.Name))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// verifyArchive extracts an archive into dir and checks it against its
+// manifest
+func verifyArchive(archive, dir string) (*Manifest, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	var manifest *Manifest
+	extracted := map[string]bool{}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt archive: %w", err)
+		}
+		name := filepath.Base(hdr.Name)
+		if name != hdr.Name || name == "." || name == ".." {
+			return nil, fmt.Errorf("unexpected path %q in archive", hdr.Name)
+		}
+		if name == ManifestFile {
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+			continue
+		}
+		out, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return nil, fmt.Errorf("corrupt archive: %w", err)
+		}
+		extracted[name] = true
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("archive has no manifest")
+	}
+	if manifest.Version < 1 || manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("unsupported backup format version %d", manifest.Version)
+	}
+
+	for _, entry := range manifest.Files {
+		if !extracted[entry.Name] {
+			return nil, fmt.Errorf("%s is listed in the manifest but missing", entry.Name)
+		}
+		sum, size, err := hashFile(filepath.Join(dir, entry.Name))
+		if err != nil {
+			return nil, err
+		}
+		if sum != entry.SHA256 || size != entry.Size {
+			return nil, fmt.Errorf("%s does not match its checksum", entry.Name)
+		}
+	}
+
+	switch manifest.DatabaseType {
+	case "sqlite":
+		if !manifest.has(SQLiteFile) {
+			return nil, fmt.Errorf("backup has no database")
+		}
+		if err := checkSQLite(filepath.Join(dir, SQLiteFile)); err != nil {
+			return nil, err
+		}
+	case "postgres":
+		if !manifest.has(PostgresFile) {
+			return nil, fmt.Errorf("backup has no database")
+		}
+		if err := checkPostgresDump(filepath.Join(dir, PostgresFile)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported database type %q", manifest.DatabaseType)
+	}
+	return manifest, nil
+}
+
+func checkSQLite(path string) error {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("sqlite integrity check failed: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("sqlite integrity check failed: %s", result)
+	}
+	return nil
+}
+
+// checkPostgresDump rejects dumps that were cut off before pg_dump finished
+func checkPostgresDump(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	tail := make([]byte, 256)
+	offset := st.Size() - int64(len(tail))
+	if offset < 0 {
+		offset = 0
+	}
+	n, _ := f.ReadAt(tail, offset)
+	if !strings.Contains(string(tail[:n]), pgDumpTrailer) {
+		return fmt.Errorf("postgres dump is incomplete")
+	}
+	return nil
+}
+
+// fetch downloads a backup into dir
+func fetch(ctx context.Context, store Store, name, dir string) (string, error) {
+	if _, ok := parseName(name); !ok {
+		return "", fmt.Errorf("%q is not a backup name", name)
+	}
+	rc, err := store.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	path := filepath.Join(dir, name)
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return "", err
+	}
+	return path, out.Close()
+}
+
+func hashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if dir := filepath.Dir(dest); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// pgConn splits the password out of a Postgres DSN, URL or keyword=value
+// form, so it reaches pg_dump and psql through PGPASSWORD rather than their
+// arguments, which other users can read. env is nil when there is no
+// password.
+func pgConn(dsn string) (conn string, env []string) {
+	var password string
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return dsn, nil
+		}
+		if u.User != nil {
+			if p, ok := u.User.Password(); ok {
+				password = p
+				u.User = url.User(u.User.Username())
+			}
+		}
+		if q := u.Query(); q.Has("password") {
+			password = q.Get("password")
+			q.Del("password")
+			u.RawQuery = q.Encode()
+		}
+		conn = u.String()
+	} else if strings.Contains(dsn, "=") {
+		var kept []string
+		for _, kv := range splitConninfo(dsn) {
+			if kv[0] == "password" {
+				password = kv[1]
+				continue
+			}
+			kept = append(kept, kv[0]+"="+quoteConninfo(kv[1]))
+		}
+		conn = strings.Join(kept, " ")
+	} else {
+		// A bare database name
+		conn = dsn
+	}
+	if password == "" {
+		return conn, nil
+	}
+	return conn, append(os.Environ(), "PGPASSWORD="+password)
+}
+
+// splitConninfo parses a keyword=value connection string into its unquoted
+// pairs
+func splitConninfo(s string) [][2]string {
+	var pairs [][2]string
+	i := 0
+	skipSpace := func() {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n') {
+			i++
+		}
+	}
+	for {
+		skipSpace()
+		if i >= len(s) {
+			return pairs
+		}
+		start := i
+		for i < len(s) && s[i] != '=' && s[i] != ' ' {
+			i++
+		}
+		key := s[start:i]
+		skipSpace()
+		if i < len(s) && s[i] == '=' {
+			i++
+		}
+		skipSpace()
+		var value strings.Builder
+		quoted := i < len(s) && s[i] == '\''
+		if quoted {
+			i++
+		}
+		for i < len(s) {
+			c := s[i]
+			if quoted && c == '\'' {
+				i++
+				break
+			}
+			if !quoted && (c == ' ' || c == '\t' || c == '\n') {
+				break
+			}
+			if c == '\\' && i+1 < len(s) {
+				i++
+				c = s[i]
+			}
+			value.WriteByte(c)
+			i++
+		}
+		pairs = append(pairs, [2]string{key, value.String()})
+	}
+}
+
+// quoteConninfo quotes a keyword=value connection string value when it
+// needs it
+func quoteConninfo(v string) string {
+	if v != "" && !strings.ContainsAny(v, " \t\n'\\") {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// redactDSN hides the password in a Postgres DSN for error messages
+func redactDSN(dsn string) string {
+	if i := strings.Index(dsn, "://"); i >= 0 {
+		if at := strings.Index(dsn[i+3:], "@"); at >= 0 {
+			return dsn[:i+3] + "***" + dsn[i+3+at:]
+		}
+	}
+	return "the target database"
+}
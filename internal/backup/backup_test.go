@@ -0,0 +1,269 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testSource(t *testing.T) (Source, string) {
+	t.Helper()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "loom.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(`CREATE TABLE beads (id TEXT PRIMARY KEY, title TEXT); INSERT INTO beads VALUES ('bd-1', 'Ship it')`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	keys := filepath.Join(dir, ".keys.json")
+	if err := os.WriteFile(keys, []byte(`{"keys":{}}`), 0600); err != nil {
+		t.Fatalf("write keys: %v", err)
+	}
+	return Source{DatabaseType: "sqlite", DB: db, KeyStorePath: keys}, dir
+}
+
+func TestRunVerifyRestore(t *testing.T) {
+	src, _ := testSource(t)
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	m := NewManager(store, src, Retention{KeepLast: 3})
+	ctx := context.Background()
+
+	info, err := m.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	manifest, err := Verify(ctx, store, info.Name)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !manifest.has(SQLiteFile) || !manifest.has(KeyStoreFile) {
+		t.Errorf("manifest missing files: %+v", manifest.Files)
+	}
+
+	target := t.TempDir()
+	dbPath := filepath.Join(target, "loom.db")
+	keysPath := filepath.Join(target, ".keys.json")
+	if err := os.WriteFile(dbPath, []byte("stale"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	opts := RestoreOptions{SQLitePath: dbPath, KeyStorePath: keysPath}
+	if _, err := m.Restore(ctx, info.Name, opts); err == nil {
+		t.Fatal("restore over an existing database without force should fail")
+	}
+	opts.Force = true
+	if _, err := m.Restore(ctx, info.Name, opts); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if _, err := os.Stat(dbPath + ".pre-restore"); err != nil {
+		t.Errorf("existing database not set aside: %v", err)
+	}
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var title string
+	if err := db.QueryRow(`SELECT title FROM beads WHERE id = 'bd-1'`).Scan(&title); err != nil || title != "Ship it" {
+		t.Errorf("restored database: %q %v", title, err)
+	}
+	if data, _ := os.ReadFile(keysPath); string(data) != `{"keys":{}}` {
+		t.Errorf("restored key store = %q", data)
+	}
+}
+
+func TestVerifyDetectsCorruption(t *testing.T) {
+	src, _ := testSource(t)
+	dir := t.TempDir()
+	store, _ := NewLocalStore(dir)
+	m := NewManager(store, src, Retention{KeepLast: 1})
+	ctx := context.Background()
+
+	info, err := m.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	path := filepath.Join(dir, info.Name)
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, data[:len(data)/2], 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(ctx, store, info.Name); err == nil {
+		t.Error("expected a truncated backup to fail verification")
+	}
+	if _, err := Verify(ctx, store, "../etc/passwd"); err == nil {
+		t.Error("expected a non-backup name to be rejected")
+	}
+}
+
+func TestPruneRetention(t *testing.T) {
+	src, _ := testSource(t)
+	dir := t.TempDir()
+	store, _ := NewLocalStore(dir)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	m := NewManager(store, src, Retention{KeepLast: 2, MaxAge: 72 * time.Hour})
+	m.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for _, age := range []time.Duration{0, 24 * time.Hour, 48 * time.Hour, 96 * time.Hour, 200 * time.Hour} {
+		name := Name(now.Add(-age))
+		if err := store.Put(ctx, name, strings.NewReader("x"), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Unrelated files in the directory are never touched
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("keep"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := m.Prune(ctx)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if len(deleted) != 2 || deleted[0] != Name(now.Add(-96*time.Hour)) {
+		t.Errorf("deleted = %v", deleted)
+	}
+	backups, _ := m.List(ctx)
+	if len(backups) != 3 {
+		t.Errorf("%d backups left, want 3", len(backups))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("prune removed an unrelated file")
+	}
+
+	m.retention = Retention{KeepLast: 1}
+	if deleted, _ := m.Prune(ctx); len(deleted) != 2 {
+		t.Errorf("without MaxAge everything past KeepLast goes: %v", deleted)
+	}
+}
+
+// fakeS3 is a minimal S3 that checks requests carry a SigV4 signature
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/us-west-2/s3/aws4_request") || r.Header.Get("x-amz-date") == "" {
+		http.Error(w, "unsigned", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		var result listBucketResult
+		for k, v := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				result.Contents = append(result.Contents, struct {
+					Key  string `xml:"Key"`
+					Size int64  `xml:"Size"`
+				}{k, int64(len(v))})
+			}
+		}
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	store, err := NewS3Store(S3Config{Bucket: "bucket", Prefix: "loom", Region: "us-west-2", Endpoint: srv.URL, AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewS3Store: %v", err)
+	}
+	ctx := context.Background()
+	name := Name(time.Now())
+	if err := store.Put(ctx, name, bytes.NewReader([]byte("archive")), 7); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, ok := fake.objects["loom/"+name]; !ok {
+		t.Fatalf("object not stored under prefix: %v", fake.objects)
+	}
+	objects, err := store.List(ctx)
+	if err != nil || len(objects) != 1 || objects[0].Name != name || objects[0].Size != 7 {
+		t.Fatalf("List = %+v, %v", objects, err)
+	}
+	rc, err := store.Get(ctx, name)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "archive" {
+		t.Errorf("Get = %q", data)
+	}
+	if err := store.Delete(ctx, name); err != nil || len(fake.objects) != 0 {
+		t.Errorf("Delete: %v, %d left", err, len(fake.objects))
+	}
+	if _, err := store.Get(ctx, name); err == nil {
+		t.Error("expected missing object to fail")
+	}
+}
+
+func TestAWSEscape(t *testing.T) {
+	if got := awsEscape("loom backups/a+b~c", true); got != "loom%20backups/a%2Bb~c" {
+		t.Errorf("awsEscape = %q", got)
+	}
+	if got := canonicalQuery(map[string][]string{"prefix": {"a/b"}, "list-type": {"2"}}); got != "list-type=2&prefix=a%2Fb" {
+		t.Errorf("canonicalQuery = %q", got)
+	}
+}
+
+func TestPgConn(t *testing.T) {
+	cases := []struct {
+		dsn, conn, password string
+	}{
+		{"postgres://loom:s3cret@db:5432/loom?sslmode=disable", "postgres://loom@db:5432/loom?sslmode=disable", "s3cret"},
+		{"postgres://db/loom?password=s3cret&user=loom", "postgres://db/loom?user=loom", "s3cret"},
+		{"postgres://loom@db/loom", "postgres://loom@db/loom", ""},
+		{"host=db user=loom password=s3cret dbname=loom", "host=db user=loom dbname=loom", "s3cret"},
+		{"host=db password = 'a b\\'c' dbname='my db'", "host=db dbname='my db'", "a b'c"},
+		{"loom", "loom", ""},
+	}
+	for _, tc := range cases {
+		conn, env := pgConn(tc.dsn)
+		if conn != tc.conn {
+			t.Errorf("pgConn(%q) conn = %q, want %q", tc.dsn, conn, tc.conn)
+		}
+		var password string
+		if len(env) > 0 {
+			password = strings.TrimPrefix(env[len(env)-1], "PGPASSWORD=")
+		}
+		if password != tc.password {
+			t.Errorf("pgConn(%q) password = %q, want %q", tc.dsn, password, tc.password)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package backup
+
+import (
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Defaults for settings left empty in the backup config
+const (
+	DefaultInterval     = 24 * time.Hour
+	DefaultDir          = "./backups"
+	DefaultKeepLast     = 7
+	DefaultKeyStorePath = ".keys.json"
+)
+
+// WithDefaults fills in unset backup settings
+func WithDefaults(cfg config.BackupConfig) config.BackupConfig {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = DefaultDir
+	}
+	if cfg.KeepLast <= 0 {
+		cfg.KeepLast = DefaultKeepLast
+	}
+	if cfg.KeyStorePath == "" {
+		cfg.KeyStorePath = DefaultKeyStorePath
+	}
+	return cfg
+}
+
+// NewStore opens the store a backup config points at: its S3 bucket when
+// one is set, otherwise its local directory
+func NewStore(cfg config.BackupConfig) (Store, error) {
+	cfg = WithDefaults(cfg)
+	if cfg.S3.Bucket != "" {
+		return NewS3Store(S3Config{
+			Bucket:          cfg.S3.Bucket,
+			Prefix:          cfg.S3.Prefix,
+			Region:          cfg.S3.Region,
+			Endpoint:        cfg.S3.Endpoint,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+		})
+	}
+	return NewLocalStore(cfg.Dir)
+}
+
+// RetentionFrom returns the retention policy of a backup config
+func RetentionFrom(cfg config.BackupConfig) Retention {
+	cfg = WithDefaults(cfg)
+	return Retention{KeepLast: cfg.KeepLast, MaxAge: cfg.MaxAge}
+}
@@ -0,0 +1,115 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Object is an entry in a Store
+type Object struct {
+	Name string
+	Size int64
+}
+
+// Store holds backup archives
+type Store interface {
+	Put(ctx context.Context, name string, r io.Reader, size int64) error
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	List(ctx context.Context) ([]Object, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// LocalStore keeps backups in a directory
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates a store in dir, creating it if needed
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+func (s *LocalStore) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid backup name %q", name)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// Put writes an object atomically so a crash never leaves a partial backup
+// under its final name
+func (s *LocalStore) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	dest, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".tmp-"+name+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// Get opens an object
+func (s *LocalStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("backup not found: %s", name)
+	}
+	return f, err
+}
+
+// List returns the objects in the directory
+func (s *LocalStore) List(ctx context.Context) ([]Object, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var objects []Object
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		objects = append(objects, Object{Name: e.Name(), Size: info.Size()})
+	}
+	return objects, nil
+}
+
+// Delete removes an object
+func (s *LocalStore) Delete(ctx context.Context, name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
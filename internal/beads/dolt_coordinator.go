@@ -3,7 +3,6 @@ package beads
 import (
 	"context"
 	"fmt"
-	"log"
 	"net"
 	"os"
 	"os/exec"
@@ -12,8 +11,12 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var doltLog = logging.Module("doltcoordinator")
+
 // DoltInstance represents a running Dolt SQL server for a project
 type DoltInstance struct {
 	ProjectID string
@@ -145,7 +148,7 @@ func (dc *DoltCoordinator) Shutdown() {
 
 		for _, inst := range instances {
 			if err := dc.stopDoltServer(inst); err != nil {
-				log.Printf("[DoltCoordinator] Error stopping %s: %v", inst.ProjectID, err)
+				doltLog.Error("Error stopping Dolt server", "project_id", inst.ProjectID, "error", err)
 			}
 		}
 	})
@@ -234,7 +237,7 @@ func (dc *DoltCoordinator) startDoltServer(ctx context.Context, projectID, doltD
 		if err == nil {
 			conn.Close()
 			inst.Ready = true
-			log.Printf("[DoltCoordinator] Dolt server for %s ready on port %d (PID %d)", projectID, port, inst.PID)
+			doltLog.Info("Dolt server ready", "project_id", projectID, "port", port, "pid", inst.PID)
 			return inst, nil
 		}
 		time.Sleep(500 * time.Millisecond)
@@ -254,7 +257,7 @@ func (dc *DoltCoordinator) stopDoltServer(inst *DoltInstance) error {
 	defer inst.mu.Unlock()
 
 	inst.Ready = false
-	log.Printf("[DoltCoordinator] Stopping Dolt server for %s (PID %d)", inst.ProjectID, inst.PID)
+	doltLog.Info("Stopping Dolt server", "project_id", inst.ProjectID, "pid", inst.PID)
 
 	if err := inst.Cmd.Process.Signal(os.Interrupt); err != nil {
 		_ = inst.Cmd.Process.Kill()
@@ -284,10 +287,10 @@ func (dc *DoltCoordinator) setupFederation(projectID string, projectInst, master
 	if out, err := cmd.CombinedOutput(); err != nil {
 		outStr := strings.TrimSpace(string(out))
 		if !strings.Contains(outStr, "already exists") {
-			log.Printf("[DoltCoordinator] Failed to add federation remote for %s: %v: %s", projectID, err, outStr)
+			doltLog.Warn("Failed to add federation remote", "project_id", projectID, "error", err, "output", outStr)
 		}
 	} else {
-		log.Printf("[DoltCoordinator] Federation remote added: %s -> master (%s)", projectID, remoteURL)
+		doltLog.Info("Federation remote added to master", "project_id", projectID, "remote", remoteURL)
 	}
 
 	// Add the project as a remote in the master's Dolt instance
@@ -299,10 +302,10 @@ func (dc *DoltCoordinator) setupFederation(projectID string, projectInst, master
 	if out, err := cmd.CombinedOutput(); err != nil {
 		outStr := strings.TrimSpace(string(out))
 		if !strings.Contains(outStr, "already exists") {
-			log.Printf("[DoltCoordinator] Failed to add project remote to master for %s: %v: %s", projectID, err, outStr)
+			doltLog.Warn("Failed to add project remote to master", "project_id", projectID, "error", err, "output", outStr)
 		}
 	} else {
-		log.Printf("[DoltCoordinator] Federation remote added: master -> %s (%s)", projectID, projectRemoteURL)
+		doltLog.Info("Federation remote added from master", "project_id", projectID, "remote", projectRemoteURL)
 	}
 }
 
@@ -330,10 +333,10 @@ func (dc *DoltCoordinator) SyncFederationAll(ctx context.Context) error {
 		cmd := exec.CommandContext(ctx, "dolt", "fetch", remoteName)
 		cmd.Dir = masterInst.DataDir
 		if out, err := cmd.CombinedOutput(); err != nil {
-			log.Printf("[DoltCoordinator] Federation fetch from %s failed: %v: %s", projectID, err, strings.TrimSpace(string(out)))
+			doltLog.Warn("Federation fetch failed", "project_id", projectID, "error", err, "output", strings.TrimSpace(string(out)))
 			lastErr = err
 		} else {
-			log.Printf("[DoltCoordinator] Federation fetch from %s succeeded", projectID)
+			doltLog.Info("Federation fetch succeeded", "project_id", projectID)
 		}
 	}
 
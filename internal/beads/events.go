@@ -0,0 +1,291 @@
+package beads
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Bead event types. Every change to a bead is recorded as one event whose
+// type is the most significant thing the change did.
+const (
+	EventCreated        = "created"
+	EventSnapshot       = "snapshot" // State of a bead first changed after history began
+	EventImported       = "imported"
+	EventAssigned       = "assigned"
+	EventPhaseAdvanced  = "phase_advanced"
+	EventActionExecuted = "action_executed"
+	EventEscalated      = "escalated"
+	EventClosed         = "closed"
+	EventReopened       = "reopened"
+	EventUpdated        = "updated"
+)
+
+// BeadEvent is an entry in a bead's append-only history. Changes holds the
+// fields (by JSON name) the event set, with nil for fields it cleared;
+// created, snapshot and imported events hold every field. Updates that
+// change nothing but the version aren't recorded.
+type BeadEvent struct {
+	Seq       int64                  `json:"seq"`
+	BeadID    string                 `json:"bead_id"`
+	Type      string                 `json:"type"`
+	Actor     string                 `json:"actor,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+	Version   int64                  `json:"version"`
+	Changes   map[string]interface{} `json:"changes,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// EventStore keeps bead histories
+type EventStore interface {
+	AppendBeadEvent(event *BeadEvent) error
+	// ListBeadEvents returns a bead's events oldest first, up to and
+	// including until unless it is zero
+	ListBeadEvents(beadID string, until time.Time) ([]*BeadEvent, error)
+}
+
+// memoryEventStore is the EventStore used until a persistent one is set
+type memoryEventStore struct {
+	mu     sync.RWMutex
+	events map[string][]*BeadEvent
+	seq    int64
+}
+
+func newMemoryEventStore() *memoryEventStore {
+	return &memoryEventStore{events: make(map[string][]*BeadEvent)}
+}
+
+func (s *memoryEventStore) AppendBeadEvent(event *BeadEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	event.Seq = s.seq
+	s.events[event.BeadID] = append(s.events[event.BeadID], event)
+	return nil
+}
+
+func (s *memoryEventStore) ListBeadEvents(beadID string, until time.Time) ([]*BeadEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var events []*BeadEvent
+	for _, e := range s.events[beadID] {
+		if !until.IsZero() && e.Timestamp.After(until) {
+			break
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// SetEventStore makes bead history persistent. Events recorded before are
+// not carried over.
+func (m *Manager) SetEventStore(store EventStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = store
+	m.historied = make(map[string]bool)
+}
+
+// History returns a bead's events oldest first
+func (m *Manager) History(beadID string) ([]*BeadEvent, error) {
+	m.mu.RLock()
+	store := m.events
+	m.mu.RUnlock()
+	return store.ListBeadEvents(beadID, time.Time{})
+}
+
+// Replay reconstructs a bead as it was at a point in time by projecting its
+// events up to then. It also returns the events applied.
+func (m *Manager) Replay(beadID string, at time.Time) (*models.Bead, []*BeadEvent, error) {
+	m.mu.RLock()
+	store := m.events
+	m.mu.RUnlock()
+
+	events, err := store.ListBeadEvents(beadID, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(events) == 0 {
+		return nil, nil, apperr.NotFound("bead %s has no history at %s", beadID, at.Format(time.RFC3339))
+	}
+	bead, err := Project(events)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bead, events, nil
+}
+
+// Project folds events, oldest first, into the bead state they produce
+func Project(events []*BeadEvent) (*models.Bead, error) {
+	state := make(map[string]interface{})
+	for _, e := range events {
+		for field, value := range e.Changes {
+			if value == nil {
+				delete(state, field)
+			} else {
+				state[field] = value
+			}
+		}
+	}
+	if _, ok := state["id"]; !ok {
+		return nil, fmt.Errorf("history does not start with the bead's state")
+	}
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var bead models.Bead
+	if err := json.Unmarshal(raw, &bead); err != nil {
+		return nil, fmt.Errorf("failed to project bead: %w", err)
+	}
+	return &bead, nil
+}
+
+// RecordEvent appends an event that doesn't change the bead's fields, such
+// as an action an agent executed on it
+func (m *Manager) RecordEvent(beadID, eventType, actor string, data map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bead, ok := m.beads[beadID]
+	if !ok {
+		return apperr.NotFound("bead not found: %s", beadID)
+	}
+	return m.appendEvent(bead, &BeadEvent{
+		BeadID:    beadID,
+		Type:      eventType,
+		Actor:     actor,
+		Timestamp: time.Now(),
+		Version:   bead.Version,
+		Data:      data,
+	}, nil)
+}
+
+// recordCreated records a bead's full state as the start of its history.
+// Callers must hold m.mu.
+func (m *Manager) recordCreated(bead *models.Bead, eventType string) {
+	m.historied[bead.ID] = true
+	m.logEventError(m.events.AppendBeadEvent(&BeadEvent{
+		BeadID:    bead.ID,
+		Type:      eventType,
+		Timestamp: bead.UpdatedAt,
+		Version:   bead.Version,
+		Changes:   beadFields(bead),
+	}))
+}
+
+// recordChange records what a change did to a bead, given its fields from
+// before. Callers must hold m.mu.
+func (m *Manager) recordChange(bead *models.Bead, before map[string]interface{}, actor string) {
+	after := beadFields(bead)
+	changes := make(map[string]interface{})
+	for field, value := range after {
+		if !reflect.DeepEqual(before[field], value) {
+			changes[field] = value
+		}
+	}
+	for field := range before {
+		if _, ok := after[field]; !ok {
+			changes[field] = nil
+		}
+	}
+	delete(changes, "updated_at")
+	delete(changes, "version")
+	if len(changes) == 0 {
+		return
+	}
+	changes["updated_at"] = after["updated_at"]
+	changes["version"] = after["version"]
+
+	m.logEventError(m.appendEvent(bead, &BeadEvent{
+		BeadID:    bead.ID,
+		Type:      classifyChange(before, changes),
+		Actor:     actor,
+		Timestamp: time.Now(),
+		Version:   bead.Version,
+		Changes:   changes,
+	}, before))
+}
+
+// appendEvent appends an event, first recording the bead's state before it
+// as a snapshot if its history hasn't begun. Callers must hold m.mu.
+func (m *Manager) appendEvent(bead *models.Bead, event *BeadEvent, before map[string]interface{}) error {
+	if !m.historied[bead.ID] {
+		existing, err := m.events.ListBeadEvents(bead.ID, time.Time{})
+		if err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			if before == nil {
+				before = beadFields(bead)
+			}
+			snapshot := &BeadEvent{BeadID: bead.ID, Type: EventSnapshot, Changes: before}
+			snapshot.Timestamp, _ = time.Parse(time.RFC3339Nano, fmt.Sprint(before["updated_at"]))
+			if v, ok := before["version"].(float64); ok {
+				snapshot.Version = int64(v)
+			}
+			if err := m.events.AppendBeadEvent(snapshot); err != nil {
+				return err
+			}
+		}
+		m.historied[bead.ID] = true
+	}
+	return m.events.AppendBeadEvent(event)
+}
+
+func (m *Manager) logEventError(err error) {
+	if err != nil {
+		beadsLog.Warn("Failed to record bead event", "error", err)
+	}
+}
+
+// classifyChange names the most significant thing a change did
+func classifyChange(before, changes map[string]interface{}) string {
+	if status, ok := changes["status"]; ok {
+		if status == string(models.BeadStatusClosed) {
+			return EventClosed
+		}
+		if before["status"] == string(models.BeadStatusClosed) {
+			return EventReopened
+		}
+	}
+	newContext, _ := changes["context"].(map[string]interface{})
+	oldContext, _ := before["context"].(map[string]interface{})
+	var changedKeys []string
+	for key, value := range newContext {
+		if !reflect.DeepEqual(oldContext[key], value) {
+			changedKeys = append(changedKeys, key)
+		}
+	}
+	sort.Strings(changedKeys)
+	for _, key := range changedKeys {
+		if strings.HasPrefix(key, "escalat") {
+			return EventEscalated
+		}
+	}
+	if _, ok := changes["assigned_to"]; ok {
+		return EventAssigned
+	}
+	for _, key := range changedKeys {
+		if key == "workflow_node" {
+			return EventPhaseAdvanced
+		}
+	}
+	return EventUpdated
+}
+
+// beadFields returns a bead's fields by JSON name, in the form they take
+// after a round trip through an event store
+func beadFields(bead *models.Bead) map[string]interface{} {
+	raw, _ := json.Marshal(bead)
+	fields := make(map[string]interface{})
+	_ = json.Unmarshal(raw, &fields)
+	return fields
+}
@@ -0,0 +1,90 @@
+package beads
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestManager_EventHistory(t *testing.T) {
+	m := NewManager("")
+	m.SetBeadsPath(t.TempDir())
+
+	bead, err := m.CreateBead("Fix login", "", models.BeadPriorityP2, "task", "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := m.ClaimBead(bead.ID, "agent-1"); err != nil {
+		t.Fatal(err)
+	}
+	_ = m.UpdateBead(bead.ID, map[string]interface{}{"context": map[string]string{"workflow_node": "review"}})
+	_ = m.UpdateBead(bead.ID, map[string]interface{}{"context": map[string]string{"escalated_to_ceo_reason": "stuck"}})
+	_ = m.RecordEvent(bead.ID, EventActionExecuted, "agent-1", map[string]interface{}{"action_type": "run_tests"})
+	_ = m.UpdateBead(bead.ID, map[string]interface{}{"status": models.BeadStatusClosed})
+	_ = m.UpdateBead(bead.ID, map[string]interface{}{"status": models.BeadStatusOpen})
+	// An update that changes nothing but the version isn't an event
+	_ = m.UpdateBead(bead.ID, map[string]interface{}{"title": "Fix login"})
+
+	events, err := m.History(bead.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	want := []string{EventCreated, EventAssigned, EventPhaseAdvanced, EventEscalated, EventActionExecuted, EventClosed, EventReopened}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("events = %v, want %v", types, want)
+	}
+	if events[1].Actor != "agent-1" || events[5].Changes["closed_at"] == nil || events[6].Changes["closed_at"] != nil {
+		t.Errorf("unexpected events %+v %+v %+v", events[1], events[5], events[6])
+	}
+
+	// Projecting every event gives the current state
+	current, _ := m.GetBead(bead.ID)
+	projected, err := Project(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projected.Status != current.Status || projected.AssignedTo != current.AssignedTo || projected.Title != current.Title ||
+		projected.Version != current.Version-1 || !reflect.DeepEqual(projected.Context, current.Context) {
+		t.Errorf("projection %+v differs from current %+v", projected, current)
+	}
+
+	// Replaying to just after creation gives the unclaimed bead
+	past, applied, err := m.Replay(bead.ID, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 1 || past.AssignedTo != "" || past.Status != models.BeadStatusOpen || past.Version != 1 {
+		t.Errorf("unexpected replayed bead %+v", past)
+	}
+	if _, _, err := m.Replay(bead.ID, created.Add(-time.Hour)); err == nil {
+		t.Error("expected no state before the bead existed")
+	}
+}
+
+func TestManager_EventHistorySnapshot(t *testing.T) {
+	m := NewManager("")
+	m.SetBeadsPath(t.TempDir())
+	bead, _ := m.CreateBead("Old bead", "", models.BeadPriorityP2, "task", "p1")
+
+	// A bead whose history began before the current store starts from a
+	// snapshot of its state when first changed
+	m.SetEventStore(newMemoryEventStore())
+	_ = m.UpdateBead(bead.ID, map[string]interface{}{"priority": models.BeadPriorityP0})
+
+	events, _ := m.History(bead.ID)
+	if len(events) != 2 || events[0].Type != EventSnapshot || events[1].Type != EventUpdated {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if got, _ := Project(events[:1]); got.Priority != models.BeadPriorityP2 || got.Version != 1 {
+		t.Errorf("unexpected snapshot %+v", got)
+	}
+}
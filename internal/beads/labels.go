@@ -0,0 +1,289 @@
+package beads
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Label selector operators
+const (
+	LabelExists    = "exists"
+	LabelNotExists = "!"
+	LabelEquals    = "="
+	LabelNotEquals = "!="
+	LabelIn        = "in"
+	LabelNotIn     = "notin"
+)
+
+// LabelRequirement is one clause of a label selector
+type LabelRequirement struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// LabelSelector matches beads by label, in the style of Kubernetes label
+// selectors: "security", "!wip", "team=core", "team!=infra",
+// "env in (prod,staging)" and "env notin (dev)", comma separated and all
+// required to match.
+type LabelSelector []LabelRequirement
+
+// ParseLabel reads a label written as "key" or "key=value"
+func ParseLabel(s string) (string, string, error) {
+	key, value, _ := strings.Cut(strings.TrimSpace(s), "=")
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if err := validLabelKey(key); err != nil {
+		return "", "", err
+	}
+	if strings.ContainsAny(value, ",()!= \t\n") {
+		return "", "", fmt.Errorf("invalid label value %q", value)
+	}
+	return key, value, nil
+}
+
+// ParseLabels reads labels written as "key" or "key=value" into a map
+func ParseLabels(list []string) (map[string]string, error) {
+	labels := make(map[string]string, len(list))
+	for _, s := range list {
+		key, value, err := ParseLabel(s)
+		if err != nil {
+			return nil, err
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// FormatLabels writes labels as sorted "key" or "key=value" strings
+func FormatLabels(labels map[string]string) []string {
+	list := make([]string, 0, len(labels))
+	for k, v := range labels {
+		if v == "" {
+			list = append(list, k)
+		} else {
+			list = append(list, k+"="+v)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+func validLabelKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("label key is required")
+	}
+	if len(key) > 63 || strings.ContainsAny(key, ",()!= \t\n") {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+	return nil
+}
+
+// ParseLabelSelector reads a label selector. An empty selector matches
+// every bead.
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	var sel LabelSelector
+	for _, clause := range splitSelector(s) {
+		req, err := parseRequirement(clause)
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, req)
+	}
+	return sel, nil
+}
+
+// splitSelector splits a selector on the commas outside parentheses
+func splitSelector(s string) []string {
+	var clauses []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				clauses = append(clauses, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	clauses = append(clauses, s[start:])
+	out := clauses[:0]
+	for _, c := range clauses {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func parseRequirement(clause string) (LabelRequirement, error) {
+	if strings.HasPrefix(clause, "!") {
+		key := strings.TrimSpace(clause[1:])
+		if err := validLabelKey(key); err != nil {
+			return LabelRequirement{}, err
+		}
+		return LabelRequirement{Key: key, Operator: LabelNotExists}, nil
+	}
+	if i := strings.Index(clause, "("); i >= 0 {
+		fields := strings.Fields(clause[:i])
+		if len(fields) != 2 || (fields[1] != LabelIn && fields[1] != LabelNotIn) || !strings.HasSuffix(clause, ")") {
+			return LabelRequirement{}, fmt.Errorf("invalid label selector %q", clause)
+		}
+		if err := validLabelKey(fields[0]); err != nil {
+			return LabelRequirement{}, err
+		}
+		var values []string
+		for _, v := range strings.Split(clause[i+1:len(clause)-1], ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			return LabelRequirement{}, fmt.Errorf("label selector %q has no values", clause)
+		}
+		return LabelRequirement{Key: fields[0], Operator: fields[1], Values: values}, nil
+	}
+	for _, op := range []string{"!=", "==", "="} {
+		if key, value, ok := strings.Cut(clause, op); ok {
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if err := validLabelKey(key); err != nil {
+				return LabelRequirement{}, err
+			}
+			if op == "==" {
+				op = LabelEquals
+			}
+			return LabelRequirement{Key: key, Operator: op, Values: []string{value}}, nil
+		}
+	}
+	if err := validLabelKey(clause); err != nil {
+		return LabelRequirement{}, err
+	}
+	return LabelRequirement{Key: clause, Operator: LabelExists}, nil
+}
+
+// Matches reports whether labels satisfy every requirement
+func (sel LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range sel {
+		value, has := labels[req.Key]
+		switch req.Operator {
+		case LabelExists:
+			if !has {
+				return false
+			}
+		case LabelNotExists:
+			if has {
+				return false
+			}
+		case LabelEquals, LabelIn:
+			if !has || !contains(req.Values, value) {
+				return false
+			}
+		case LabelNotEquals, LabelNotIn:
+			if has && contains(req.Values, value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String writes the selector back in its text form
+func (sel LabelSelector) String() string {
+	parts := make([]string, 0, len(sel))
+	for _, req := range sel {
+		switch req.Operator {
+		case LabelExists:
+			parts = append(parts, req.Key)
+		case LabelNotExists:
+			parts = append(parts, "!"+req.Key)
+		case LabelEquals, LabelNotEquals:
+			parts = append(parts, req.Key+req.Operator+req.Values[0])
+		default:
+			parts = append(parts, req.Key+" "+req.Operator+" ("+strings.Join(req.Values, ",")+")")
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// requiredKeys are the keys a matching bead must carry
+func (sel LabelSelector) requiredKeys() []string {
+	var keys []string
+	for _, req := range sel {
+		if req.Operator == LabelExists || req.Operator == LabelEquals || req.Operator == LabelIn {
+			keys = append(keys, req.Key)
+		}
+	}
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// indexLabels records a cached bead's labels. Callers must hold m.mu.
+func (m *Manager) indexLabels(bead *models.Bead) {
+	if m.labelIndex == nil {
+		m.labelIndex = make(map[string]map[string]bool)
+	}
+	for key := range bead.Labels {
+		ids := m.labelIndex[key]
+		if ids == nil {
+			ids = make(map[string]bool)
+			m.labelIndex[key] = ids
+		}
+		ids[bead.ID] = true
+	}
+}
+
+// unindexLabels forgets a cached bead's labels. Callers must hold m.mu.
+func (m *Manager) unindexLabels(bead *models.Bead) {
+	if bead == nil {
+		return
+	}
+	for key := range bead.Labels {
+		if ids := m.labelIndex[key]; ids != nil {
+			delete(ids, bead.ID)
+			if len(ids) == 0 {
+				delete(m.labelIndex, key)
+			}
+		}
+	}
+}
+
+// labelCandidates narrows a selector to the beads carrying its rarest
+// required key, and reports false when it requires none. Callers must hold
+// m.mu.
+func (m *Manager) labelCandidates(sel LabelSelector) (map[string]bool, bool) {
+	var best map[string]bool
+	found := false
+	for _, key := range sel.requiredKeys() {
+		ids := m.labelIndex[key]
+		if !found || len(ids) < len(best) {
+			best, found = ids, true
+		}
+	}
+	return best, found
+}
+
+// LabelKeys returns every label key in use with the number of beads
+// carrying it
+func (m *Manager) LabelKeys() map[string]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	counts := make(map[string]int, len(m.labelIndex))
+	for key, ids := range m.labelIndex {
+		counts[key] = len(ids)
+	}
+	return counts
+}
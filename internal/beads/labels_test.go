@@ -0,0 +1,102 @@
+package beads
+
+import (
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	labels := map[string]string{"security": "", "team": "core", "env": "prod"}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"security", true},
+		{"!security", false},
+		{"!wip", true},
+		{"team=core", true},
+		{"team==core", true},
+		{"team!=core", false},
+		{"owner!=alice", true},
+		{"env in (prod, staging)", true},
+		{"env notin (prod)", false},
+		{"security,team=core,env in (prod)", true},
+		{"security,team=infra", false},
+	}
+	for _, tt := range tests {
+		sel, err := ParseLabelSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("ParseLabelSelector(%q): %v", tt.selector, err)
+		}
+		if got := sel.Matches(labels); got != tt.want {
+			t.Errorf("%q matches = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"env in ()", "env within (a)", "=core", "!"} {
+		if _, err := ParseLabelSelector(bad); err == nil {
+			t.Errorf("ParseLabelSelector(%q) should fail", bad)
+		}
+	}
+	sel, _ := ParseLabelSelector("security, env in (prod,staging),!wip")
+	if got := sel.String(); got != "security,env in (prod,staging),!wip" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ParseLabels([]string{"security", "team=core"})
+	if err != nil || len(labels) != 2 || labels["security"] != "" || labels["team"] != "core" {
+		t.Fatalf("ParseLabels = %v, %v", labels, err)
+	}
+	if got := FormatLabels(labels); len(got) != 2 || got[0] != "security" || got[1] != "team=core" {
+		t.Errorf("FormatLabels = %v", got)
+	}
+	if _, err := ParseLabels([]string{"a b"}); err == nil {
+		t.Error("expected error for key with a space")
+	}
+}
+
+func TestManager_LabelIndex(t *testing.T) {
+	m := NewManager("")
+	m.SetBeadsPath(t.TempDir())
+	a, _ := m.CreateBead("a", "", models.BeadPriorityP2, "task", "p1")
+	b, _ := m.CreateBead("b", "", models.BeadPriorityP2, "task", "p1")
+	if _, err := m.CreateBead("c", "", models.BeadPriorityP2, "task", "p2"); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = m.UpdateBead(a.ID, map[string]interface{}{"labels": map[string]string{"security": "", "team": "core"}})
+	_ = m.UpdateBead(b.ID, map[string]interface{}{"label_set": map[string]string{"team": "infra"}})
+
+	list := func(selector string) []*models.Bead {
+		sel, err := ParseLabelSelector(selector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		beads, err := m.ListBeads(map[string]interface{}{"labels": sel})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return beads
+	}
+	if got := list("security"); len(got) != 1 || got[0].ID != a.ID {
+		t.Errorf("security = %v", got)
+	}
+	if got := list("team"); len(got) != 2 {
+		t.Errorf("team = %d beads, want 2", len(got))
+	}
+	if got := list("!team"); len(got) != 1 {
+		t.Errorf("!team = %d beads, want 1", len(got))
+	}
+
+	_ = m.UpdateBead(a.ID, map[string]interface{}{"label_remove": []string{"security"}})
+	if got := list("security"); len(got) != 0 {
+		t.Errorf("security after removal = %v", got)
+	}
+	if keys := m.LabelKeys(); keys["team"] != 2 || keys["security"] != 0 {
+		t.Errorf("LabelKeys = %v", keys)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -13,12 +12,16 @@ import (
 	"sync"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/observability"
 	"github.com/jordanhubbard/loom/pkg/config"
 	"github.com/jordanhubbard/loom/pkg/models"
 	"gopkg.in/yaml.v3"
 )
 
+var beadsLog = logging.Module("beads")
+
 // Manager integrates with the bd (beads) CLI tool
 type Manager struct {
 	bdPath          string
@@ -27,9 +30,26 @@ type Manager struct {
 	beads           map[string]*models.Bead
 	beadFiles       map[string]string
 	workGraph       *models.WorkGraph
-	nextID          int               // For generating IDs when bd CLI is not available
-	projectPrefixes map[string]string // Project ID -> bead prefix (e.g., "loom-self" -> "ac")
-	projectNextIDs  map[string]int    // Per-project next ID counter
+	nextID          int                        // For generating IDs when bd CLI is not available
+	projectPrefixes map[string]string          // Project ID -> bead prefix (e.g., "loom-self" -> "ac")
+	projectNextIDs  map[string]int             // Per-project next ID counter
+	archived        map[string]bool            // Closed beads moved to the retention archive
+	events          EventStore                 // Bead histories
+	historied       map[string]bool            // Beads whose history is known to have begun
+	labelIndex      map[string]map[string]bool // Label key -> IDs of beads carrying it
+}
+
+// ConflictError indicates a bead changed since the caller last read it
+type ConflictError struct {
+	BeadID          string
+	ExpectedVersion int64
+	ActualVersion   int64
+	Current         *models.Bead
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("version conflict for bead %s: expected %d, got %d",
+		e.BeadID, e.ExpectedVersion, e.ActualVersion)
 }
 
 // NewManager creates a new beads manager
@@ -47,6 +67,10 @@ func NewManager(bdPath string) *Manager {
 		nextID:          1,
 		projectPrefixes: make(map[string]string),
 		projectNextIDs:  make(map[string]int),
+		archived:        make(map[string]bool),
+		events:          newMemoryEventStore(),
+		historied:       make(map[string]bool),
+		labelIndex:      make(map[string]map[string]bool),
 	}
 }
 
@@ -56,6 +80,7 @@ func (m *Manager) Reset() {
 	defer m.mu.Unlock()
 	m.beads = make(map[string]*models.Bead)
 	m.beadFiles = make(map[string]string)
+	m.labelIndex = make(map[string]map[string]bool)
 	m.workGraph = &models.WorkGraph{Beads: make(map[string]*models.Bead), Edges: []models.Edge{}, UpdatedAt: time.Now()}
 	m.nextID = 1
 	m.projectPrefixes = make(map[string]string)
@@ -144,7 +169,7 @@ func (m *Manager) CreateBead(title, description string, priority models.BeadPrio
 			// Auto-initialize if bd reports uninitialized database
 			if strings.Contains(outStr, "database not initialized") || strings.Contains(outStr, "issue_prefix config is missing") {
 				if initErr := m.tryAutoInitBD(prefix); initErr != nil {
-					log.Printf("[Beads] bd auto-init failed: %v", initErr)
+					beadsLog.Warn("bd auto-init failed", "error", initErr)
 				} else {
 					// Retry the create after init
 					cmd2 := exec.Command(m.bdPath, args...)
@@ -153,11 +178,11 @@ func (m *Manager) CreateBead(title, description string, priority models.BeadPrio
 					}
 					output, err = cmd2.CombinedOutput()
 					if err != nil {
-						log.Printf("[Beads] bd create failed after auto-init, falling back to filesystem: %v: %s", err, strings.TrimSpace(string(output)))
+						beadsLog.Warn("bd create failed after auto-init, falling back to filesystem", "error", err, "output", strings.TrimSpace(string(output)))
 					}
 				}
 			} else {
-				log.Printf("[Beads] bd create failed, falling back to filesystem: %v: %s", err, outStr)
+				beadsLog.Warn("bd create failed, falling back to filesystem", "error", err, "output", outStr)
 			}
 		}
 		if err == nil {
@@ -167,7 +192,7 @@ func (m *Manager) CreateBead(title, description string, priority models.BeadPrio
 				beadID = m.extractBeadID(outputStr)
 			}
 			if beadID == "" {
-				log.Printf("[Beads] bd create did not return bead id, falling back to filesystem: %s", strings.TrimSpace(outputStr))
+				beadsLog.Warn("bd create did not return bead id, falling back to filesystem", "output", strings.TrimSpace(outputStr))
 			} else {
 				usedBD = true
 			}
@@ -209,17 +234,19 @@ func (m *Manager) CreateBead(title, description string, priority models.BeadPrio
 		ProjectID:   projectID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
+		Version:     1,
 	}
 
 	m.beads[beadID] = bead
 	m.workGraph.Beads[beadID] = bead
 	m.workGraph.UpdatedAt = time.Now()
+	m.recordCreated(bead, EventCreated)
 
 	// Save to filesystem only when not using bd CLI
 	if !usedBD {
 		if err := m.SaveBeadToFilesystem(bead, m.beadsPath); err != nil {
 			// Log error but don't fail - the bead is in memory
-			fmt.Fprintf(os.Stderr, "Warning: failed to save bead to filesystem: %v\n", err)
+			beadsLog.Warn("Failed to save bead to filesystem", "bead_id", bead.ID, "error", err)
 		}
 	}
 
@@ -250,6 +277,16 @@ func (m *Manager) ListBeads(filters map[string]interface{}) ([]*models.Bead, err
 
 	beads := make([]*models.Bead, 0, len(m.beads))
 
+	if sel, ok := filters["labels"].(LabelSelector); ok {
+		if ids, narrowed := m.labelCandidates(sel); narrowed {
+			for id := range ids {
+				if bead := m.beads[id]; bead != nil && m.matchesFilters(bead, filters) {
+					beads = append(beads, bead)
+				}
+			}
+			return beads, nil
+		}
+	}
 	for _, bead := range m.beads {
 		if m.matchesFilters(bead, filters) {
 			beads = append(beads, bead)
@@ -259,6 +296,27 @@ func (m *Manager) ListBeads(filters map[string]interface{}) ([]*models.Bead, err
 	return beads, nil
 }
 
+// EvictBead drops an archived bead from the cache. It stays out on reload
+// for as long as it remains closed.
+func (m *Manager) EvictBead(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unindexLabels(m.beads[id])
+	delete(m.beads, id)
+	delete(m.beadFiles, id)
+	delete(m.workGraph.Beads, id)
+	if m.archived == nil {
+		m.archived = make(map[string]bool)
+	}
+	m.archived[id] = true
+}
+
+// isArchived reports whether a reloaded bead should stay evicted. Callers
+// must hold m.mu.
+func (m *Manager) isArchived(bead *models.Bead) bool {
+	return m.archived[bead.ID] && bead.Status == models.BeadStatusClosed
+}
+
 // UpdateBead updates a bead
 func (m *Manager) UpdateBead(id string, updates map[string]interface{}) error {
 	m.mu.Lock()
@@ -266,9 +324,42 @@ func (m *Manager) UpdateBead(id string, updates map[string]interface{}) error {
 
 	bead, ok := m.beads[id]
 	if !ok {
-		return fmt.Errorf("bead not found: %s", id)
+		return apperr.NotFound("bead not found: %s", id)
+	}
+
+	m.applyUpdates(bead, updates)
+	return nil
+}
+
+// UpdateBeadIfVersion updates a bead only if it is still at expectedVersion.
+// It returns a *ConflictError carrying the current bead when another writer
+// got there first.
+func (m *Manager) UpdateBeadIfVersion(id string, expectedVersion int64, updates map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bead, ok := m.beads[id]
+	if !ok {
+		return apperr.NotFound("bead not found: %s", id)
+	}
+	if bead.Version != expectedVersion {
+		current := *bead
+		return &ConflictError{
+			BeadID:          id,
+			ExpectedVersion: expectedVersion,
+			ActualVersion:   bead.Version,
+			Current:         &current,
+		}
 	}
 
+	m.applyUpdates(bead, updates)
+	return nil
+}
+
+// applyUpdates applies updates to a cached bead and persists it. Callers
+// must hold m.mu.
+func (m *Manager) applyUpdates(bead *models.Bead, updates map[string]interface{}) {
+	before := beadFields(bead)
 	previousAssigned := bead.AssignedTo
 	assignedUpdated := false
 
@@ -321,6 +412,45 @@ func (m *Manager) UpdateBead(id string, updates map[string]interface{}) error {
 	if children, ok := updates["children"].([]string); ok {
 		bead.Children = children
 	}
+	if dueDate, ok := updates["due_date"].(*time.Time); ok {
+		bead.DueDate = dueDate // nil clears it
+	}
+	if labels, ok := updates["labels"].(map[string]string); ok {
+		m.unindexLabels(bead)
+		bead.Labels = labels
+		m.indexLabels(bead)
+	}
+	setLabels, hasSet := updates["label_set"].(map[string]string)
+	removeLabels, hasRemove := updates["label_remove"].([]string)
+	if hasSet || hasRemove {
+		m.unindexLabels(bead)
+		labels := make(map[string]string, len(bead.Labels)+len(setLabels))
+		for k, v := range bead.Labels {
+			labels[k] = v
+		}
+		for _, k := range removeLabels {
+			delete(labels, k)
+		}
+		for k, v := range setLabels {
+			labels[k] = v
+		}
+		bead.Labels = labels
+		m.indexLabels(bead)
+	}
+	if fields, ok := updates["fields"].(map[string]interface{}); ok {
+		merged := make(map[string]interface{}, len(bead.Fields)+len(fields))
+		for k, v := range bead.Fields {
+			merged[k] = v
+		}
+		for k, v := range fields {
+			if v == nil {
+				delete(merged, k) // nil clears a field
+			} else {
+				merged[k] = v
+			}
+		}
+		bead.Fields = merged
+	}
 	if ctxUpdates, ok := updates["context"].(map[string]string); ok {
 		if bead.Context == nil {
 			bead.Context = make(map[string]string)
@@ -331,7 +461,9 @@ func (m *Manager) UpdateBead(id string, updates map[string]interface{}) error {
 	}
 
 	bead.UpdatedAt = time.Now()
+	bead.Version++
 	m.workGraph.UpdatedAt = time.Now()
+	m.recordChange(bead, before, "")
 
 	if assignedUpdated && previousAssigned != bead.AssignedTo {
 		observability.Info("bead.assignment_updated", map[string]interface{}{
@@ -344,10 +476,39 @@ func (m *Manager) UpdateBead(id string, updates map[string]interface{}) error {
 
 	// Save to filesystem
 	if err := m.SaveBeadToFilesystem(bead, m.beadsPath); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to save bead to filesystem: %v\n", err)
+		beadsLog.Warn("Failed to save bead to filesystem", "bead_id", bead.ID, "error", err)
 	}
+}
 
-	return nil
+// ImportBead inserts or replaces a bead as-is, keeping its ID. A replaced
+// bead's version keeps counting up so ETags read before the import go stale.
+func (m *Manager) ImportBead(bead *models.Bead) error {
+	if bead == nil || bead.ID == "" {
+		return fmt.Errorf("bead id is required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := *bead
+	existing, replaced := m.beads[b.ID]
+	if replaced && b.Version <= existing.Version {
+		b.Version = existing.Version + 1
+	}
+	if b.Version == 0 {
+		b.Version = 1
+	}
+	m.unindexLabels(existing)
+	m.beads[b.ID] = &b
+	m.indexLabels(&b)
+	m.workGraph.Beads[b.ID] = &b
+	m.workGraph.UpdatedAt = time.Now()
+	if replaced {
+		m.recordChange(&b, beadFields(existing), "")
+	} else {
+		m.recordCreated(&b, EventImported)
+	}
+
+	return m.SaveBeadToFilesystem(&b, m.beadsPath)
 }
 
 // ClaimBead assigns a bead to an agent
@@ -357,7 +518,7 @@ func (m *Manager) ClaimBead(beadID, agentID string) error {
 
 	bead, ok := m.beads[beadID]
 	if !ok {
-		err := fmt.Errorf("bead not found: %s", beadID)
+		err := apperr.NotFound("bead not found: %s", beadID)
 		observability.Error("bead.claim", map[string]interface{}{
 			"agent_id": agentID,
 			"bead_id":  beadID,
@@ -366,7 +527,7 @@ func (m *Manager) ClaimBead(beadID, agentID string) error {
 	}
 
 	if bead.AssignedTo != "" && bead.AssignedTo != agentID {
-		err := fmt.Errorf("bead already claimed by agent %s", bead.AssignedTo)
+		err := apperr.Conflict("bead already claimed by agent %s", bead.AssignedTo)
 		observability.Error("bead.claim", map[string]interface{}{
 			"agent_id":    agentID,
 			"bead_id":     beadID,
@@ -376,9 +537,12 @@ func (m *Manager) ClaimBead(beadID, agentID string) error {
 		return err
 	}
 
+	before := beadFields(bead)
 	bead.AssignedTo = agentID
 	bead.Status = models.BeadStatusInProgress
 	bead.UpdatedAt = time.Now()
+	bead.Version++
+	m.recordChange(bead, before, agentID)
 
 	observability.Info("bead.claim", map[string]interface{}{
 		"agent_id":   agentID,
@@ -388,7 +552,7 @@ func (m *Manager) ClaimBead(beadID, agentID string) error {
 	})
 
 	if err := m.SaveBeadToFilesystem(bead, m.beadsPath); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to save bead to filesystem: %v\n", err)
+		beadsLog.Warn("Failed to save bead to filesystem", "bead_id", bead.ID, "error", err)
 	}
 
 	return nil
@@ -409,6 +573,8 @@ func (m *Manager) AddDependency(childID, parentID, relationship string) error {
 		return fmt.Errorf("parent bead not found: %s", parentID)
 	}
 
+	childBefore, parentBefore := beadFields(child), beadFields(parent)
+
 	// Update bead relationships
 	switch relationship {
 	case "blocks":
@@ -426,6 +592,10 @@ func (m *Manager) AddDependency(childID, parentID, relationship string) error {
 	default:
 		return fmt.Errorf("unknown relationship: %s", relationship)
 	}
+	child.Version++
+	parent.Version++
+	m.recordChange(child, childBefore, "")
+	m.recordChange(parent, parentBefore, "")
 
 	// Update work graph
 	m.workGraph.Edges = append(m.workGraph.Edges, models.Edge{
@@ -481,9 +651,11 @@ func (m *Manager) UnblockBead(beadID, blockerID string) error {
 
 	bead, ok := m.beads[beadID]
 	if !ok {
-		return fmt.Errorf("bead not found: %s", beadID)
+		return apperr.NotFound("bead not found: %s", beadID)
 	}
 
+	before := beadFields(bead)
+
 	// Remove blocker
 	for i, id := range bead.BlockedBy {
 		if id == blockerID {
@@ -498,7 +670,9 @@ func (m *Manager) UnblockBead(beadID, blockerID string) error {
 	}
 
 	bead.UpdatedAt = time.Now()
+	bead.Version++
 	m.workGraph.UpdatedAt = time.Now()
+	m.recordChange(bead, before, "")
 
 	return nil
 }
@@ -582,7 +756,7 @@ func (m *Manager) tryAutoInitBD(prefix string) error {
 		}
 		return fmt.Errorf("bd init --prefix %s failed: %w: %s", prefix, err, outStr)
 	}
-	log.Printf("[Beads] Auto-initialized bd database with prefix %q", prefix)
+	beadsLog.Info("Auto-initialized bd database", "prefix", prefix)
 	return nil
 }
 
@@ -641,6 +815,19 @@ func (m *Manager) matchesFilters(bead *models.Bead, filters map[string]interface
 		}
 	}
 
+	if sel, ok := filters["labels"].(LabelSelector); ok && !sel.Matches(bead.Labels) {
+		return false
+	}
+
+	if fields, ok := filters["fields"].(map[string]string); ok {
+		for name, want := range fields {
+			v, has := bead.Fields[name]
+			if !has || fmt.Sprint(v) != want {
+				return false
+			}
+		}
+	}
+
 	if assignedTo, ok := filters["assigned_to"]; ok {
 		switch value := assignedTo.(type) {
 		case string:
@@ -679,7 +866,7 @@ func (m *Manager) LoadBeadsFromFilesystem(projectID, beadsPath string) error {
 		if err := m.loadBeadsFromBD(projectID, beadsPath); err == nil {
 			return nil
 		} else {
-			fmt.Fprintf(os.Stderr, "Warning: failed to load beads via bd CLI: %v\n", err)
+			beadsLog.Warn("Failed to load beads via bd CLI", "error", err)
 		}
 	}
 
@@ -705,28 +892,35 @@ func (m *Manager) LoadBeadsFromFilesystem(projectID, beadsPath string) error {
 		beadPath := filepath.Join(beadsDir, entry.Name())
 		data, err := os.ReadFile(beadPath)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to read bead file %s: %v\n", entry.Name(), err)
+			beadsLog.Warn("Failed to read bead file", "file", entry.Name(), "error", err)
 			continue // Skip files we can't read
 		}
 
 		var bead models.Bead
 		if err := yaml.Unmarshal(data, &bead); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to parse bead file %s: %v\n", entry.Name(), err)
+			beadsLog.Warn("Failed to parse bead file", "file", entry.Name(), "error", err)
 			continue // Skip invalid YAML
 		}
 
+		if m.isArchived(&bead) {
+			continue
+		}
+
 		// Add to internal cache
 		if bead.ProjectID == "" && projectID != "" {
 			bead.ProjectID = projectID
 		}
+		m.carryVersion(&bead)
+		m.unindexLabels(m.beads[bead.ID])
 		m.beads[bead.ID] = &bead
+		m.indexLabels(&bead)
 		m.workGraph.Beads[bead.ID] = &bead
 		m.beadFiles[bead.ID] = beadPath
 		loadedCount++
 	}
 
 	if loadedCount > 0 {
-		fmt.Fprintf(os.Stderr, "Loaded %d bead(s) from %s\n", loadedCount, beadsDir)
+		beadsLog.Info("Loaded beads", "count", loadedCount, "dir", beadsDir)
 	}
 
 	m.workGraph.UpdatedAt = time.Now()
@@ -764,7 +958,7 @@ func (m *Manager) loadBeadsFromBD(projectID, beadsPath string) error {
 		}
 		output, err := cmd.CombinedOutput()
 		if err != nil {
-			log.Printf("[BeadManager] bd list --status=%s failed: %v: %s", status, err, strings.TrimSpace(string(output)))
+			beadsLog.Warn("bd list failed", "status", status, "error", err, "output", strings.TrimSpace(string(output)))
 			continue
 		}
 		trimmed := strings.TrimSpace(string(output))
@@ -827,8 +1021,18 @@ func (m *Manager) loadBeadsFromBD(projectID, beadsPath string) error {
 			UpdatedAt:   issue.UpdatedAt,
 			ClosedAt:    issue.ClosedAt,
 		}
+		if m.isArchived(bead) {
+			continue
+		}
 
+		m.carryVersion(bead)
+		m.unindexLabels(m.beads[bead.ID])
+		if cached := m.beads[bead.ID]; cached != nil {
+			bead.Labels = cached.Labels // bd has no labels or fields of its own
+			bead.Fields = cached.Fields
+		}
 		m.beads[bead.ID] = bead
+		m.indexLabels(bead)
 		m.workGraph.Beads[bead.ID] = bead
 	}
 
@@ -836,6 +1040,25 @@ func (m *Manager) loadBeadsFromBD(projectID, beadsPath string) error {
 	return nil
 }
 
+// carryVersion keeps a reloaded bead's version monotonic so stale ETags
+// never match again: it starts from the cached copy's version and bumps it
+// when the bead was changed outside loom. Callers must hold m.mu.
+func (m *Manager) carryVersion(bead *models.Bead) {
+	existing, ok := m.beads[bead.ID]
+	if !ok {
+		if bead.Version == 0 {
+			bead.Version = 1
+		}
+		return
+	}
+	if bead.Version < existing.Version {
+		bead.Version = existing.Version
+	}
+	if bead.Version == existing.Version && !bead.UpdatedAt.Equal(existing.UpdatedAt) {
+		bead.Version++
+	}
+}
+
 // SaveBeadToFilesystem saves a bead to the filesystem
 func (m *Manager) SaveBeadToFilesystem(bead *models.Bead, beadsPath string) error {
 	beadsDir := filepath.Join(beadsPath, "beads")
@@ -883,7 +1106,7 @@ func (m *Manager) SyncFederation(ctx context.Context, cfg *config.BeadsFederatio
 			continue
 		}
 		if err := m.syncWithPeer(ctx, peer, cfg.SyncStrategy); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: federation sync with peer %s failed: %v\n", peer.Name, err)
+			beadsLog.Warn("Federation sync with peer failed", "peer", peer.Name, "error", err)
 			lastErr = err
 		}
 	}
@@ -942,3 +1165,16 @@ func sanitizeFilename(s string) string {
 	}
 	return result.String()
 }
+
+// ClosedTime returns when a bead closed, or nil if it is still open. Beads
+// closed before ClosedAt was recorded fall back to their last update.
+func ClosedTime(bead *models.Bead) *time.Time {
+	if bead.Status != models.BeadStatusClosed {
+		return nil
+	}
+	if bead.ClosedAt != nil {
+		return bead.ClosedAt
+	}
+	t := bead.UpdatedAt
+	return &t
+}
@@ -2,6 +2,7 @@ package beads
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -950,6 +951,123 @@ func TestManager_SaveAndLoadBead(t *testing.T) {
 	}
 }
 
+// TestManager_UpdateBeadIfVersion tests optimistic concurrency on updates
+func TestManager_UpdateBeadIfVersion(t *testing.T) {
+	manager := NewManager("")
+	manager.SetBeadsPath(filepath.Join(t.TempDir(), ".beads"))
+
+	bead, err := manager.CreateBead("Versioned", "", models.BeadPriorityP2, "task", "p")
+	if err != nil {
+		t.Fatalf("CreateBead() error = %v", err)
+	}
+	if bead.Version != 1 {
+		t.Fatalf("new bead version = %d, want 1", bead.Version)
+	}
+
+	if err := manager.UpdateBeadIfVersion(bead.ID, 1, map[string]interface{}{"title": "First"}); err != nil {
+		t.Fatalf("UpdateBeadIfVersion() error = %v", err)
+	}
+	if bead.Version != 2 {
+		t.Errorf("version after update = %d, want 2", bead.Version)
+	}
+
+	// A second writer still holding version 1 must not overwrite the first
+	err = manager.UpdateBeadIfVersion(bead.ID, 1, map[string]interface{}{"title": "Second"})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+	if conflict.ActualVersion != 2 || conflict.Current.Title != "First" {
+		t.Errorf("conflict = %+v, want current version 2 titled First", conflict)
+	}
+	if bead.Title != "First" {
+		t.Errorf("title = %q, conflicting update was applied", bead.Title)
+	}
+
+	// Unconditional mutators bump the version too
+	if err := manager.ClaimBead(bead.ID, "agent-1"); err != nil {
+		t.Fatalf("ClaimBead() error = %v", err)
+	}
+	if bead.Version != 3 {
+		t.Errorf("version after claim = %d, want 3", bead.Version)
+	}
+}
+
+// TestManager_ReloadKeepsVersionMonotonic tests that reloading beads never
+// lets a stale version match again
+func TestManager_ReloadKeepsVersionMonotonic(t *testing.T) {
+	beadsPath := filepath.Join(t.TempDir(), ".beads")
+	manager := NewManager("")
+	manager.SetBeadsPath(beadsPath)
+
+	bead, err := manager.CreateBead("Reloaded", "", models.BeadPriorityP2, "task", "p")
+	if err != nil {
+		t.Fatalf("CreateBead() error = %v", err)
+	}
+	if err := manager.UpdateBead(bead.ID, map[string]interface{}{"title": "Edited"}); err != nil {
+		t.Fatalf("UpdateBead() error = %v", err)
+	}
+
+	// Unchanged on disk: the version survives the reload
+	if err := manager.RefreshBeads("p", beadsPath); err != nil {
+		t.Fatalf("RefreshBeads() error = %v", err)
+	}
+	reloaded, _ := manager.GetBead(bead.ID)
+	if reloaded.Version != 2 {
+		t.Errorf("version after reload = %d, want 2", reloaded.Version)
+	}
+
+	// Changed outside loom without a version bump: treat it as a new version
+	external := *reloaded
+	external.Title = "Edited elsewhere"
+	external.UpdatedAt = reloaded.UpdatedAt.Add(time.Minute)
+	if err := manager.SaveBeadToFilesystem(&external, beadsPath); err != nil {
+		t.Fatalf("SaveBeadToFilesystem() error = %v", err)
+	}
+	if err := manager.RefreshBeads("p", beadsPath); err != nil {
+		t.Fatalf("RefreshBeads() error = %v", err)
+	}
+	reloaded, _ = manager.GetBead(bead.ID)
+	if reloaded.Version != 3 || reloaded.Title != "Edited elsewhere" {
+		t.Errorf("reloaded = %q v%d, want external edit at v3", reloaded.Title, reloaded.Version)
+	}
+}
+
+func TestManager_EvictBeadStaysEvictedWhileClosed(t *testing.T) {
+	beadsPath := filepath.Join(t.TempDir(), ".beads")
+	manager := NewManager("")
+	manager.SetBeadsPath(beadsPath)
+
+	bead, err := manager.CreateBead("Done", "", models.BeadPriorityP2, "task", "p")
+	if err != nil {
+		t.Fatalf("CreateBead() error = %v", err)
+	}
+	if err := manager.UpdateBead(bead.ID, map[string]interface{}{"status": models.BeadStatusClosed}); err != nil {
+		t.Fatalf("UpdateBead() error = %v", err)
+	}
+
+	manager.EvictBead(bead.ID)
+	if err := manager.RefreshBeads("p", beadsPath); err != nil {
+		t.Fatalf("RefreshBeads() error = %v", err)
+	}
+	if beads, _ := manager.ListBeads(nil); len(beads) != 0 {
+		t.Fatalf("archived bead reloaded: %+v", beads)
+	}
+
+	// Reopened outside loom: it comes back
+	reopened := *bead
+	reopened.Status = models.BeadStatusOpen
+	if err := manager.SaveBeadToFilesystem(&reopened, beadsPath); err != nil {
+		t.Fatalf("SaveBeadToFilesystem() error = %v", err)
+	}
+	if err := manager.RefreshBeads("p", beadsPath); err != nil {
+		t.Fatalf("RefreshBeads() error = %v", err)
+	}
+	if beads, _ := manager.ListBeads(nil); len(beads) != 1 {
+		t.Errorf("reopened bead not reloaded: %d beads", len(beads))
+	}
+}
+
 // TestManager_LoadProjectPrefixFromConfig tests loading prefix from config
 func TestManager_LoadProjectPrefixFromConfig(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -998,3 +1116,37 @@ func TestManager_SyncFederation(t *testing.T) {
 		t.Errorf("SyncFederation() with nil config error = %v, want nil", err)
 	}
 }
+
+func TestUpdateBead_Fields(t *testing.T) {
+	m := NewManager("")
+	m.SetBeadsPath(t.TempDir())
+	b, err := m.CreateBead("fields", "", models.BeadPriorityP2, "task", "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = m.UpdateBead(b.ID, map[string]interface{}{"fields": map[string]interface{}{"customer": "acme", "severity": "high"}})
+	_ = m.UpdateBead(b.ID, map[string]interface{}{"fields": map[string]interface{}{"severity": nil, "seats": 12.0}})
+
+	got, _ := m.GetBead(b.ID)
+	if len(got.Fields) != 2 || got.Fields["customer"] != "acme" || got.Fields["seats"] != 12.0 {
+		t.Errorf("Fields = %v", got.Fields)
+	}
+	list, _ := m.ListBeads(map[string]interface{}{"fields": map[string]string{"seats": "12"}})
+	if len(list) != 1 {
+		t.Errorf("field filter matched %d beads, want 1", len(list))
+	}
+}
+
+func TestClosedTime(t *testing.T) {
+	updated := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	closed := updated.Add(-time.Hour)
+	if got := ClosedTime(&models.Bead{Status: models.BeadStatusOpen, UpdatedAt: updated}); got != nil {
+		t.Errorf("open bead closed at %v", got)
+	}
+	if got := ClosedTime(&models.Bead{Status: models.BeadStatusClosed, ClosedAt: &closed, UpdatedAt: updated}); got == nil || !got.Equal(closed) {
+		t.Errorf("ClosedTime = %v, want %v", got, closed)
+	}
+	if got := ClosedTime(&models.Bead{Status: models.BeadStatusClosed, UpdatedAt: updated}); got == nil || !got.Equal(updated) {
+		t.Errorf("expected the last update without ClosedAt, got %v", got)
+	}
+}
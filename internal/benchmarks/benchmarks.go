@@ -0,0 +1,461 @@
+// Package benchmarks runs a project's benchmarks with go test -bench or
+// hyperfine, stores their time and allocation metrics per commit, and
+// compares each run with the baseline branch at the merge base to flag
+// regressions. As a workflow gate it holds beads whose last run regressed.
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/internal/workflow"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var benchmarksLog = logging.Module("benchmarks")
+
+// Supported benchmark tools
+const (
+	Go        = "go"
+	Hyperfine = "hyperfine"
+)
+
+const (
+	defaultTimeout   = 20 * time.Minute
+	defaultThreshold = 10.0
+	defaultBaseline  = "main"
+	// defaultGoCount repeats go benchmarks so their median absorbs noise
+	defaultGoCount = 3
+	// maxOutput caps the benchmark output kept in a result, from the end
+	maxOutput = 16 * 1024
+	// gitTimeout bounds the git commands run around a benchmark
+	gitTimeout = time.Minute
+)
+
+// Store persists benchmark runs
+type Store interface {
+	SaveBenchmarkRun(run *models.BenchmarkRun) error
+	LatestBenchmarkRun(projectID, tool, branch, commit string) (*models.BenchmarkRun, error)
+	LatestBeadBenchmarkRun(beadID string) (*models.BenchmarkRun, error)
+}
+
+// Options selects what a run measures
+type Options struct {
+	Tool      string        // go (default) or hyperfine
+	Path      string        // Directory to run in, relative to the repository root; go benchmarks its packages
+	Bench     string        // -bench regexp for go test (default .)
+	Commands  []string      // Commands timed by hyperfine
+	Count     int           // go test -count (default 3) or hyperfine --runs
+	Threshold float64       // Percent change counted as a regression (default the manager's)
+	Baseline  string        // Baseline branch (default the manager's)
+	Timeout   time.Duration // Default the manager's
+}
+
+// Result is the outcome of a run. A failed run is reported in Error rather
+// than as an error, and is not stored.
+type Result struct {
+	Success      bool                         `json:"success"`
+	Run          *models.BenchmarkRun         `json:"run"`
+	Baseline     *models.BenchmarkRun         `json:"baseline,omitempty"`
+	BaselineNote string                       `json:"baseline_note,omitempty"` // Why there is nothing to compare with
+	Comparisons  []models.BenchmarkComparison `json:"comparisons,omitempty"`
+	Output       string                       `json:"output,omitempty"`
+	Duration     time.Duration                `json:"duration"`
+	Error        string                       `json:"error,omitempty"`
+}
+
+// Manager runs benchmarks inside project workdirs
+type Manager struct {
+	WorkDirs       files.WorkDirResolver
+	Store          Store
+	BaselineBranch string
+	Threshold      float64
+	Timeout        time.Duration
+	// SkipBaseline compares only with stored runs instead of benchmarking a
+	// missing baseline in a temporary worktree
+	SkipBaseline bool
+	// Gate holds beads with regressions at verify and commit nodes
+	Gate bool
+
+	mu   sync.Mutex
+	runs map[string]*models.BenchmarkRun // Latest run per bead, kept when there is no store
+
+	runner toolrun.Runner
+}
+
+// NewManager creates a benchmark manager, or returns nil when benchmarks are
+// disabled in cfg. store may be nil.
+func NewManager(resolver files.WorkDirResolver, store Store, cfg config.BenchmarksConfig) *Manager {
+	if cfg.Disabled {
+		return nil
+	}
+	m := &Manager{
+		WorkDirs:       resolver,
+		Store:          store,
+		BaselineBranch: cfg.BaselineBranch,
+		Threshold:      cfg.Threshold,
+		Timeout:        cfg.Timeout,
+		SkipBaseline:   cfg.SkipBaseline,
+		Gate:           !cfg.DisableGate,
+		runs:           make(map[string]*models.BenchmarkRun),
+	}
+	if m.Threshold <= 0 {
+		m.Threshold = defaultThreshold
+	}
+	if m.Timeout <= 0 {
+		m.Timeout = defaultTimeout
+	}
+	return m
+}
+
+// Run benchmarks the project's working tree, compares the results with the
+// baseline and stores the run. An error means the run could not start.
+func (m *Manager) Run(ctx context.Context, projectID, beadID string, opts Options) (*Result, error) {
+	if m.WorkDirs == nil {
+		return nil, fmt.Errorf("workdir resolver not configured")
+	}
+	workDir := m.WorkDirs.GetProjectWorkDir(projectID)
+	if workDir == "" {
+		return nil, fmt.Errorf("project workdir not found")
+	}
+	workDir = filepath.Clean(workDir)
+	path := models.CleanSubprojectPath(opts.Path)
+	dir := filepath.Join(workDir, filepath.FromSlash(path))
+	if rel, err := filepath.Rel(workDir, dir); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("path %q is outside the project", opts.Path)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("path %q is not a directory", opts.Path)
+	}
+
+	opts.Tool = strings.ToLower(strings.TrimSpace(opts.Tool))
+	if opts.Tool == "" {
+		opts.Tool = Go
+	}
+	switch opts.Tool {
+	case Go:
+	case Hyperfine:
+		if len(opts.Commands) == 0 {
+			return nil, fmt.Errorf("hyperfine needs commands to time")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported benchmark tool %q (use go or hyperfine)", opts.Tool)
+	}
+	if _, err := m.runner.Find(opts.Tool); err != nil {
+		return nil, fmt.Errorf("%s is not installed", opts.Tool)
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = m.Threshold
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = m.Timeout
+	}
+
+	start := time.Now()
+	branch, commit := m.head(ctx, workDir)
+	run := &models.BenchmarkRun{
+		ID:        "bench-" + uuid.New().String()[:8],
+		ProjectID: projectID,
+		BeadID:    beadID,
+		Branch:    branch,
+		Commit:    commit,
+		Tool:      opts.Tool,
+		Path:      path,
+		Threshold: opts.Threshold,
+		StartedAt: start,
+	}
+	result := &Result{Run: run}
+
+	results, output, err := m.bench(ctx, dir, opts)
+	result.Output = output
+	if err == nil && len(results) == 0 {
+		err = fmt.Errorf("no benchmarks matched")
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Duration = time.Since(start)
+		return result, nil
+	}
+	run.Results = results
+	result.Success = true
+
+	result.Baseline, result.BaselineNote = m.baseline(ctx, workDir, run, opts)
+	if result.Baseline != nil {
+		run.BaselineID = result.Baseline.ID
+		run.BaselineCommit = result.Baseline.Commit
+		result.Comparisons = compare(result.Baseline, run, opts.Threshold)
+		for _, c := range result.Comparisons {
+			if c.Regression {
+				run.Regressions = append(run.Regressions, c)
+			}
+		}
+		if len(result.Comparisons) == 0 {
+			result.BaselineNote = fmt.Sprintf("the baseline run on %s has none of these benchmarks", result.Baseline.Branch)
+		}
+	}
+	run.CompletedAt = time.Now()
+	result.Duration = run.CompletedAt.Sub(start)
+	m.save(run)
+	return result, nil
+}
+
+// CheckGate implements workflow.Gate. It holds a bead whose last benchmark
+// run regressed at verify and commit nodes, or at any node whose
+// performance_gate metadata is "true"; "false" turns the gate off.
+func (m *Manager) CheckGate(exec *workflow.WorkflowExecution, node *workflow.WorkflowNode) string {
+	switch node.Metadata["performance_gate"] {
+	case "true":
+	case "false":
+		return ""
+	default:
+		if !m.Gate || (node.NodeType != workflow.NodeTypeVerify && node.NodeType != workflow.NodeTypeCommit) {
+			return ""
+		}
+	}
+	run := m.latestBeadRun(exec.BeadID)
+	if run == nil || len(run.Regressions) == 0 {
+		return ""
+	}
+	worst := run.Regressions[0]
+	return fmt.Sprintf("performance gate: the last run_benchmarks found %d regressions over %g%% against %s (worst %s %s %+.1f%%); fix them and run run_benchmarks again",
+		len(run.Regressions), run.Threshold, shortSHA(run.BaselineCommit), worst.Name, worst.Metric, worst.Delta)
+}
+
+// bench runs the benchmarks in dir and parses their results
+func (m *Manager) bench(ctx context.Context, dir string, opts Options) ([]models.BenchmarkResult, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	if opts.Tool == Hyperfine {
+		export, err := os.CreateTemp("", "loom-hyperfine-*.json")
+		if err != nil {
+			return nil, "", err
+		}
+		export.Close()
+		defer os.Remove(export.Name())
+
+		args := []string{"--export-json", export.Name(), "--warmup", "1", "--style", "basic"}
+		if opts.Count > 0 {
+			args = append(args, "--runs", strconv.Itoa(max(opts.Count, 2)))
+		}
+		args = append(args, opts.Commands...)
+		stdout, stderr, err := m.runner.Exec(ctx, toolrun.Command{Dir: dir, Name: Hyperfine, Args: args})
+		output := toolrun.Tail(stdout, stderr, maxOutput)
+		if err != nil {
+			return nil, output, commandError(ctx, Hyperfine, err, opts.Timeout)
+		}
+		data, err := os.ReadFile(export.Name())
+		if err != nil {
+			return nil, output, err
+		}
+		results, err := parseHyperfine(data)
+		return results, output, err
+	}
+
+	bench := opts.Bench
+	if bench == "" {
+		bench = "."
+	}
+	count := opts.Count
+	if count <= 0 {
+		count = defaultGoCount
+	}
+	stdout, stderr, err := m.runner.Exec(ctx, toolrun.Command{Dir: dir, Name: Go, Args: []string{"test", "-run", "^$", "-bench", bench, "-benchmem",
+		"-count", strconv.Itoa(count), "-timeout", opts.Timeout.String(), "./..."}})
+	output := toolrun.Tail(stdout, stderr, maxOutput)
+	if err != nil {
+		return nil, output, commandError(ctx, "go test", err, opts.Timeout)
+	}
+	return parseGoBench(stdout), output, nil
+}
+
+// baseline finds the run to compare with: the previous run when benchmarking
+// the baseline branch itself, otherwise a stored run at the merge base with
+// the baseline branch, benchmarked in a temporary worktree when missing. It
+// returns why there is none instead.
+func (m *Manager) baseline(ctx context.Context, workDir string, run *models.BenchmarkRun, opts Options) (*models.BenchmarkRun, string) {
+	if run.Commit == "" {
+		return nil, "not a git repository, so there is no baseline"
+	}
+	ref := m.baselineBranch(ctx, workDir, opts.Baseline)
+	if run.Branch == ref {
+		if prev := m.latest(run.ProjectID, run.Tool, ref, ""); prev != nil {
+			return prev, ""
+		}
+		return nil, fmt.Sprintf("first %s run on %s; later runs compare with this one", run.Tool, ref)
+	}
+
+	base := m.mergeBase(ctx, workDir, ref)
+	if base == "" {
+		return nil, fmt.Sprintf("baseline branch %s not found", ref)
+	}
+	if stored := m.latest(run.ProjectID, run.Tool, ref, base); stored != nil && overlaps(stored, run) {
+		return stored, ""
+	}
+	if m.SkipBaseline {
+		return nil, fmt.Sprintf("no stored %s run on %s at %s", run.Tool, ref, shortSHA(base))
+	}
+
+	tree, err := os.MkdirTemp("", "loom-bench-")
+	if err != nil {
+		return nil, err.Error()
+	}
+	defer os.RemoveAll(tree)
+	if _, err := m.git(ctx, workDir, "worktree", "add", "--detach", tree, base); err != nil {
+		return nil, fmt.Sprintf("checking out %s at %s failed: %v", ref, shortSHA(base), err)
+	}
+	defer func() {
+		if _, err := m.git(context.WithoutCancel(ctx), workDir, "worktree", "remove", "--force", tree); err != nil {
+			benchmarksLog.Warn("Failed to remove worktree", "path", tree, "error", err)
+		}
+	}()
+
+	start := time.Now()
+	results, _, err := m.bench(ctx, filepath.Join(tree, filepath.FromSlash(run.Path)), opts)
+	if err != nil {
+		return nil, fmt.Sprintf("benchmarking %s at %s failed: %v", ref, shortSHA(base), err)
+	}
+	baseline := &models.BenchmarkRun{
+		ID:          "bench-" + uuid.New().String()[:8],
+		ProjectID:   run.ProjectID,
+		Branch:      ref,
+		Commit:      base,
+		Tool:        run.Tool,
+		Path:        run.Path,
+		Results:     results,
+		Threshold:   run.Threshold,
+		StartedAt:   start,
+		CompletedAt: time.Now(),
+	}
+	m.save(baseline)
+	return baseline, ""
+}
+
+// baselineBranch picks the requested branch, the configured one, the
+// remote's default branch or main
+func (m *Manager) baselineBranch(ctx context.Context, workDir, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if m.BaselineBranch != "" {
+		return m.BaselineBranch
+	}
+	if ref, err := m.git(ctx, workDir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		if branch, ok := strings.CutPrefix(ref, "origin/"); ok && branch != "" {
+			return branch
+		}
+	}
+	return defaultBaseline
+}
+
+// mergeBase returns the commit HEAD forked from ref, preferring the remote
+// branch, or "" when ref doesn't exist
+func (m *Manager) mergeBase(ctx context.Context, workDir, ref string) string {
+	for _, candidate := range []string{"origin/" + ref, ref} {
+		if base, err := m.git(ctx, workDir, "merge-base", "HEAD", candidate); err == nil && base != "" {
+			return base
+		}
+	}
+	return ""
+}
+
+// head returns the checked-out branch ("" when detached) and commit
+func (m *Manager) head(ctx context.Context, workDir string) (string, string) {
+	commit, err := m.git(ctx, workDir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", ""
+	}
+	branch, _ := m.git(ctx, workDir, "rev-parse", "--abbrev-ref", "HEAD")
+	if branch == "HEAD" {
+		branch = ""
+	}
+	return branch, commit
+}
+
+func (m *Manager) git(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	stdout, stderr, err := m.runner.Exec(ctx, toolrun.Command{Dir: dir, Name: "git", Args: args})
+	if err != nil {
+		if msg := strings.TrimSpace(string(stderr)); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(stdout)), nil
+}
+
+func (m *Manager) latest(projectID, tool, branch, commit string) *models.BenchmarkRun {
+	if m.Store == nil {
+		return nil
+	}
+	run, err := m.Store.LatestBenchmarkRun(projectID, tool, branch, commit)
+	if err != nil {
+		benchmarksLog.Error("Failed to look up benchmark runs", "project_id", projectID, "tool", tool, "branch", branch, "error", err)
+		return nil
+	}
+	return run
+}
+
+func (m *Manager) latestBeadRun(beadID string) *models.BenchmarkRun {
+	if m.Store == nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.runs[beadID]
+	}
+	run, err := m.Store.LatestBeadBenchmarkRun(beadID)
+	if err != nil {
+		benchmarksLog.Error("Failed to look up benchmark runs", "bead_id", beadID, "error", err)
+		return nil
+	}
+	return run
+}
+
+func (m *Manager) save(run *models.BenchmarkRun) {
+	if m.Store == nil {
+		if run.BeadID != "" {
+			m.mu.Lock()
+			m.runs[run.BeadID] = run
+			m.mu.Unlock()
+		}
+		return
+	}
+	if err := m.Store.SaveBenchmarkRun(run); err != nil {
+		benchmarksLog.Error("Failed to save benchmark run", "run_id", run.ID, "project_id", run.ProjectID, "error", err)
+	}
+}
+
+// overlaps reports whether two runs share a benchmark
+func overlaps(a, b *models.BenchmarkRun) bool {
+	for _, r := range b.Results {
+		if a.Result(r.Key()) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func commandError(ctx context.Context, name string, err error, timeout time.Duration) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s timed out after %s", name, timeout)
+	}
+	return fmt.Errorf("%s failed: %v", name, err)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
@@ -0,0 +1,333 @@
+package benchmarks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/internal/workflow"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type memStore struct {
+	runs []*models.BenchmarkRun
+}
+
+func (s *memStore) SaveBenchmarkRun(run *models.BenchmarkRun) error {
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+func (s *memStore) LatestBenchmarkRun(projectID, tool, branch, commit string) (*models.BenchmarkRun, error) {
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		r := s.runs[i]
+		if r.ProjectID == projectID && r.Tool == tool && r.Branch == branch && (commit == "" || r.Commit == commit) {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memStore) LatestBeadBenchmarkRun(beadID string) (*models.BenchmarkRun, error) {
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		if s.runs[i].BeadID == beadID {
+			return s.runs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// fakeGit answers the git commands Run makes for a checkout of branch at
+// commit, forked from main at base
+type fakeGit struct {
+	toolrun.Fake
+	branch, commit, base string
+	bench                func(dir string, args []string) (string, error)
+}
+
+func (f *fakeGit) handle(c toolrun.Command) ([]byte, []byte, error) {
+	call := c.String()
+	if c.Name != "git" {
+		out, err := f.bench(c.Dir, c.Args)
+		return []byte(out), nil, err
+	}
+	switch {
+	case call == "git rev-parse HEAD":
+		return []byte(f.commit + "\n"), nil, nil
+	case call == "git rev-parse --abbrev-ref HEAD":
+		return []byte(f.branch + "\n"), nil, nil
+	case call == "git merge-base HEAD main" && f.base != "":
+		return []byte(f.base + "\n"), nil, nil
+	case strings.HasPrefix(call, "git worktree "):
+		return nil, nil, nil
+	}
+	return nil, []byte("fatal: not a valid ref"), errors.New("exit status 128")
+}
+
+func (f *fakeGit) ran(prefix string) bool {
+	return f.Called(prefix) != ""
+}
+
+func goOutput(ns, allocs string) string {
+	return "goos: linux\npkg: example.com/app\n" +
+		"BenchmarkParse-8   \t  1000\t " + ns + " ns/op\t  64 B/op\t  " + allocs + " allocs/op\n" +
+		"PASS\nok  \texample.com/app\t1.2s\n"
+}
+
+func newTestManager(t *testing.T, store Store, git *fakeGit) *Manager {
+	t.Helper()
+	m := NewManager(toolrun.StaticWorkDir(t.TempDir()), store, config.BenchmarksConfig{})
+	git.Handle = git.handle
+	m.runner = git.Runner()
+	return m
+}
+
+func TestParseGoBench(t *testing.T) {
+	out := `goos: linux
+pkg: example.com/app/parse
+BenchmarkParse-8          	  100	      1200 ns/op	     256 B/op	       4 allocs/op
+BenchmarkParse-8          	  100	      1000 ns/op	     256 B/op	       4 allocs/op
+BenchmarkParse-8          	  100	      1100 ns/op	     256 B/op	       5 allocs/op
+BenchmarkSizes/size-10-8  	 5000	       300 ns/op
+PASS
+pkg: example.com/app/store
+BenchmarkParse-8          	   50	      9000 ns/op	    1024 B/op	      12 allocs/op
+`
+	results := parseGoBench([]byte(out))
+	if len(results) != 3 {
+		t.Fatalf("expected 3 benchmarks, got %+v", results)
+	}
+	parse := results[0]
+	if parse.Key() != "example.com/app/parse.BenchmarkParse" || parse.Samples != 3 {
+		t.Errorf("unexpected benchmark: %+v", parse)
+	}
+	if parse.NsPerOp != 1100 || parse.BytesPerOp != 256 || parse.AllocsPerOp != 4 || !parse.Memory {
+		t.Errorf("expected medians 1100 ns, 256 B, 4 allocs; got %+v", parse)
+	}
+	if results[1].Name != "BenchmarkSizes/size-10" || results[1].Memory || results[1].NsPerOp != 300 {
+		t.Errorf("unexpected sub-benchmark: %+v", results[1])
+	}
+	if results[2].Key() != "example.com/app/store.BenchmarkParse" || results[2].NsPerOp != 9000 {
+		t.Errorf("benchmarks of different packages must stay apart: %+v", results[2])
+	}
+}
+
+func TestParseHyperfine(t *testing.T) {
+	data := `{"results": [{"command": "./app --check", "mean": 0.25, "median": 0.2, "times": [0.2, 0.2, 0.35]}]}`
+	results, err := parseHyperfine([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Name != "./app --check" || results[0].NsPerOp != 2e8 || results[0].Samples != 3 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if _, err := parseHyperfine([]byte("not json")); err == nil {
+		t.Error("expected an error for invalid output")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &models.BenchmarkRun{Results: []models.BenchmarkResult{
+		{Name: "BenchmarkA", NsPerOp: 100, BytesPerOp: 64, AllocsPerOp: 0, Memory: true},
+		{Name: "BenchmarkB", NsPerOp: 100},
+		{Name: "BenchmarkGone", NsPerOp: 100},
+	}}
+	current := &models.BenchmarkRun{Results: []models.BenchmarkResult{
+		{Name: "BenchmarkA", NsPerOp: 105, BytesPerOp: 64, AllocsPerOp: 1, Memory: true},
+		{Name: "BenchmarkB", NsPerOp: 150},
+		{Name: "BenchmarkNew", NsPerOp: 100},
+	}}
+	comparisons := compare(baseline, current, 10)
+	if len(comparisons) != 4 {
+		t.Fatalf("expected 4 comparisons, got %+v", comparisons)
+	}
+	if c := comparisons[0]; c.Name != "BenchmarkA" || c.Metric != models.MetricAllocsPerOp || !c.Regression || c.Delta != 100 {
+		t.Errorf("expected the new allocation first, got %+v", c)
+	}
+	if c := comparisons[1]; c.Name != "BenchmarkB" || !c.Regression || c.Delta != 50 {
+		t.Errorf("expected BenchmarkB 50%% slower, got %+v", c)
+	}
+	for _, c := range comparisons[2:] {
+		if c.Regression {
+			t.Errorf("%s %s within the threshold flagged: %+v", c.Name, c.Metric, c)
+		}
+	}
+}
+
+func TestRun_BenchmarksMissingBaselineInWorktree(t *testing.T) {
+	store := &memStore{}
+	git := &fakeGit{branch: "bead-1", commit: "c2c2c2c2c2", base: "c1c1c1c1c1"}
+	git.bench = func(dir string, args []string) (string, error) {
+		if strings.Contains(dir, "loom-bench-") {
+			return goOutput("1000", "4"), nil
+		}
+		return goOutput("1500", "4"), nil
+	}
+	m := newTestManager(t, store, git)
+
+	res, err := m.Run(context.Background(), "proj-1", "bead-1", Options{Bench: "Parse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Baseline == nil || res.BaselineNote != "" {
+		t.Fatalf("expected a comparison with the baseline, got %+v", res)
+	}
+	if !git.ran("git worktree add --detach") || !git.ran("git worktree remove --force") {
+		t.Errorf("expected a temporary worktree, calls: %v", git.Calls)
+	}
+	if !git.ran("go test -run ^$ -bench Parse -benchmem -count 3") {
+		t.Errorf("unexpected go test invocation: %v", git.Calls)
+	}
+	if len(store.runs) != 2 || store.runs[0].Branch != "main" || store.runs[0].Commit != "c1c1c1c1c1" || store.runs[0].BeadID != "" {
+		t.Fatalf("expected the baseline and the bead's run stored, got %+v", store.runs)
+	}
+	run := store.runs[1]
+	if run.BeadID != "bead-1" || run.Branch != "bead-1" || run.BaselineCommit != "c1c1c1c1c1" {
+		t.Errorf("unexpected run: %+v", run)
+	}
+	if len(run.Regressions) != 1 || run.Regressions[0].Metric != models.MetricNsPerOp || run.Regressions[0].Delta != 50 {
+		t.Errorf("expected one ns/op regression, got %+v", run.Regressions)
+	}
+
+	exec := &workflow.WorkflowExecution{BeadID: "bead-1"}
+	if reason := m.CheckGate(exec, &workflow.WorkflowNode{NodeType: workflow.NodeTypeVerify}); !strings.Contains(reason, "1 regressions over 10%") {
+		t.Errorf("expected the gate to hold the bead, got %q", reason)
+	}
+	if reason := m.CheckGate(exec, &workflow.WorkflowNode{NodeType: workflow.NodeTypeTask}); reason != "" {
+		t.Errorf("task nodes are not gated by default, got %q", reason)
+	}
+	if reason := m.CheckGate(exec, &workflow.WorkflowNode{NodeType: workflow.NodeTypeTask, Metadata: map[string]string{"performance_gate": "true"}}); reason == "" {
+		t.Error("expected performance_gate metadata to gate a task node")
+	}
+	if reason := m.CheckGate(exec, &workflow.WorkflowNode{NodeType: workflow.NodeTypeCommit, Metadata: map[string]string{"performance_gate": "false"}}); reason != "" {
+		t.Errorf("expected performance_gate false to turn the gate off, got %q", reason)
+	}
+
+	// Back within the threshold, the stored baseline is reused and the gate opens
+	git.Calls = nil
+	git.bench = func(string, []string) (string, error) { return goOutput("1050", "4"), nil }
+	res, err = m.Run(context.Background(), "proj-1", "bead-1", Options{Bench: "Parse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if git.ran("git worktree") || res.Baseline == nil || res.Baseline.ID != store.runs[0].ID {
+		t.Errorf("expected the stored baseline to be reused, calls: %v", git.Calls)
+	}
+	if reason := m.CheckGate(exec, &workflow.WorkflowNode{NodeType: workflow.NodeTypeVerify}); reason != "" {
+		t.Errorf("expected the gate to open, got %q", reason)
+	}
+}
+
+func TestRun_OnBaselineBranch(t *testing.T) {
+	git := &fakeGit{branch: "main", commit: "c1"}
+	git.bench = func(string, []string) (string, error) { return goOutput("1000", "4"), nil }
+	m := newTestManager(t, &memStore{}, git)
+
+	res, err := m.Run(context.Background(), "proj-1", "", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Baseline != nil || !strings.Contains(res.BaselineNote, "first go run on main") {
+		t.Errorf("expected the first run to have no baseline, got %+v", res)
+	}
+
+	git.commit = "c2"
+	git.bench = func(string, []string) (string, error) { return goOutput("1000", "6"), nil }
+	res, err = m.Run(context.Background(), "proj-1", "", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Baseline == nil || res.Baseline.Commit != "c1" || len(res.Run.Regressions) != 1 || res.Run.Regressions[0].Metric != models.MetricAllocsPerOp {
+		t.Errorf("expected an allocation regression against c1, got %+v", res.Run)
+	}
+}
+
+func TestRun_SkipBaseline(t *testing.T) {
+	git := &fakeGit{branch: "bead-1", commit: "c2", base: "c1"}
+	git.bench = func(string, []string) (string, error) { return goOutput("1000", "4"), nil }
+	m := newTestManager(t, nil, git)
+	m.SkipBaseline = true
+
+	res, err := m.Run(context.Background(), "proj-1", "bead-1", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Baseline != nil || git.ran("git worktree") || !strings.Contains(res.BaselineNote, "no stored go run on main") {
+		t.Errorf("expected no baseline, got %+v (calls %v)", res, git.Calls)
+	}
+}
+
+func TestRun_FailureIsReported(t *testing.T) {
+	store := &memStore{}
+	git := &fakeGit{branch: "bead-1", commit: "c2", base: "c1"}
+	git.bench = func(string, []string) (string, error) {
+		return "# example.com/app\n./app.go:3:1: syntax error\nFAIL\texample.com/app [build failed]\n", errors.New("exit status 1")
+	}
+	m := newTestManager(t, store, git)
+
+	res, err := m.Run(context.Background(), "proj-1", "bead-1", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || !strings.Contains(res.Error, "go test failed") || !strings.Contains(res.Output, "syntax error") {
+		t.Errorf("expected a reported failure, got %+v", res)
+	}
+	if len(store.runs) != 0 {
+		t.Errorf("failed runs must not be stored, got %+v", store.runs)
+	}
+
+	git.bench = func(string, []string) (string, error) { return "PASS\n", nil }
+	res, _ = m.Run(context.Background(), "proj-1", "bead-1", Options{Bench: "Nothing"})
+	if res.Success || res.Error != "no benchmarks matched" {
+		t.Errorf("expected no benchmarks matched, got %+v", res)
+	}
+}
+
+func TestRun_Hyperfine(t *testing.T) {
+	git := &fakeGit{branch: "bead-1", commit: "c2"}
+	git.bench = func(_ string, args []string) (string, error) {
+		for i, a := range args {
+			if a == "--export-json" {
+				return "", os.WriteFile(args[i+1], []byte(`{"results": [{"command": "./app", "mean": 0.1, "median": 0.1, "times": [0.1, 0.1]}]}`), 0644)
+			}
+		}
+		return "", errors.New("no --export-json")
+	}
+	m := newTestManager(t, nil, git)
+
+	if _, err := m.Run(context.Background(), "proj-1", "bead-1", Options{Tool: Hyperfine}); err == nil {
+		t.Error("expected hyperfine without commands to be rejected")
+	}
+	res, err := m.Run(context.Background(), "proj-1", "bead-1", Options{Tool: Hyperfine, Commands: []string{"./app"}, Count: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || len(res.Run.Results) != 1 || res.Run.Results[0].NsPerOp != 1e8 {
+		t.Errorf("unexpected result: %+v", res.Run)
+	}
+	if !git.ran("hyperfine --export-json") || !strings.Contains(git.Called("hyperfine"), "--runs 5 ./app") {
+		t.Errorf("unexpected hyperfine invocation: %v", git.Calls)
+	}
+	if !strings.Contains(res.BaselineNote, "baseline branch main not found") {
+		t.Errorf("unexpected baseline note: %q", res.BaselineNote)
+	}
+}
+
+func TestRun_RejectsPathsOutsideProject(t *testing.T) {
+	git := &fakeGit{}
+	m := newTestManager(t, nil, git)
+	if _, err := m.Run(context.Background(), "proj-1", "", Options{Path: "../elsewhere"}); err == nil {
+		t.Error("expected an error for a path outside the project")
+	}
+	m.runner = (&toolrun.Fake{Installed: []string{}}).Runner()
+	if _, err := m.Run(context.Background(), "proj-1", "", Options{}); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("expected a missing tool error, got %v", err)
+	}
+	if NewManager(toolrun.StaticWorkDir(t.TempDir()), nil, config.BenchmarksConfig{Disabled: true}) != nil {
+		t.Error("expected no manager when disabled")
+	}
+}
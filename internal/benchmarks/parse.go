@@ -0,0 +1,175 @@
+package benchmarks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// benchLine matches a go test -bench result line:
+// BenchmarkName-8   1000000   1234 ns/op   256 B/op   4 allocs/op
+var benchLine = regexp.MustCompile(`^(Benchmark\S*?)(?:-\d+)?\s+(\d+)\s+(.+)$`)
+
+// sample is one measurement of a benchmark before samples are reduced
+type sample struct {
+	iterations int64
+	ns         float64
+	bytes      float64
+	allocs     float64
+	memory     bool
+}
+
+// parseGoBench parses go test -bench output, reducing repeated lines of a
+// benchmark (-count) to their median, in the order benchmarks first appear
+func parseGoBench(out []byte) []models.BenchmarkResult {
+	var order []string
+	keys := map[string]models.BenchmarkResult{}
+	samples := map[string][]sample{}
+
+	pkg := ""
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if p, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(p)
+			continue
+		}
+		m := benchLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		s := sample{}
+		s.iterations, _ = strconv.ParseInt(m[2], 10, 64)
+		fields := strings.Fields(m[3])
+		hasNs := false
+		for i := 0; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			switch fields[i+1] {
+			case models.MetricNsPerOp:
+				s.ns, hasNs = v, true
+			case models.MetricBytesPerOp:
+				s.bytes, s.memory = v, true
+			case models.MetricAllocsPerOp:
+				s.allocs, s.memory = v, true
+			}
+		}
+		if !hasNs {
+			continue
+		}
+		r := models.BenchmarkResult{Name: m[1], Package: pkg}
+		key := r.Key()
+		if _, ok := keys[key]; !ok {
+			order = append(order, key)
+			keys[key] = r
+		}
+		samples[key] = append(samples[key], s)
+	}
+
+	results := make([]models.BenchmarkResult, 0, len(order))
+	for _, key := range order {
+		r := keys[key]
+		ss := samples[key]
+		r.Samples = len(ss)
+		r.Iterations = ss[len(ss)-1].iterations
+		r.NsPerOp = median(ss, func(s sample) float64 { return s.ns })
+		r.Memory = ss[0].memory
+		if r.Memory {
+			r.BytesPerOp = median(ss, func(s sample) float64 { return s.bytes })
+			r.AllocsPerOp = median(ss, func(s sample) float64 { return s.allocs })
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// parseHyperfine parses the file written by hyperfine --export-json. Times
+// are in seconds; the median is kept, in nanoseconds.
+func parseHyperfine(data []byte) ([]models.BenchmarkResult, error) {
+	var report struct {
+		Results []struct {
+			Command string    `json:"command"`
+			Mean    float64   `json:"mean"`
+			Median  float64   `json:"median"`
+			Times   []float64 `json:"times"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("invalid hyperfine output: %w", err)
+	}
+	results := make([]models.BenchmarkResult, 0, len(report.Results))
+	for _, r := range report.Results {
+		seconds := r.Median
+		if seconds == 0 {
+			seconds = r.Mean
+		}
+		results = append(results, models.BenchmarkResult{
+			Name:    r.Command,
+			Samples: len(r.Times),
+			NsPerOp: seconds * 1e9,
+		})
+	}
+	return results, nil
+}
+
+func median(ss []sample, value func(sample) float64) float64 {
+	vs := make([]float64, len(ss))
+	for i, s := range ss {
+		vs[i] = value(s)
+	}
+	sort.Float64s(vs)
+	n := len(vs)
+	if n%2 == 1 {
+		return vs[n/2]
+	}
+	return (vs[n/2-1] + vs[n/2]) / 2
+}
+
+// compare measures each metric of current's benchmarks against the same
+// benchmark in baseline. Regressions come first, worst first.
+func compare(baseline, current *models.BenchmarkRun, threshold float64) []models.BenchmarkComparison {
+	var comparisons []models.BenchmarkComparison
+	add := func(name, metric string, base, cur float64) {
+		c := models.BenchmarkComparison{Name: name, Metric: metric, Baseline: base, Current: cur}
+		switch {
+		case base > 0:
+			c.Delta = math.Round((cur-base)/base*1000) / 10
+		case cur > 0:
+			// Anything is infinitely more than nothing; report it as doubling
+			c.Delta = 100
+		}
+		c.Regression = c.Delta > threshold
+		comparisons = append(comparisons, c)
+	}
+	for _, cur := range current.Results {
+		key := cur.Key()
+		base := baseline.Result(key)
+		if base == nil {
+			continue
+		}
+		add(key, models.MetricNsPerOp, base.NsPerOp, cur.NsPerOp)
+		if base.Memory && cur.Memory {
+			add(key, models.MetricBytesPerOp, base.BytesPerOp, cur.BytesPerOp)
+			add(key, models.MetricAllocsPerOp, base.AllocsPerOp, cur.AllocsPerOp)
+		}
+	}
+	sort.SliceStable(comparisons, func(i, j int) bool {
+		a, b := comparisons[i], comparisons[j]
+		if a.Regression != b.Regression {
+			return a.Regression
+		}
+		return a.Regression && a.Delta > b.Delta
+	})
+	return comparisons
+}
@@ -0,0 +1,439 @@
+// Package browser drives a headless browser through Playwright to check a
+// web UI: it runs a session of steps (navigate, click, fill, assertions,
+// screenshots) in one page and returns each step's outcome, the page's
+// console errors and the screenshots taken.
+package browser
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Step actions
+const (
+	StepNavigate      = "navigate"
+	StepClick         = "click"
+	StepFill          = "fill"
+	StepSelect        = "select"
+	StepPress         = "press"
+	StepWaitFor       = "wait_for"
+	StepAssertText    = "assert_text"
+	StepAssertVisible = "assert_visible"
+	StepAssertURL     = "assert_url"
+	StepScreenshot    = "screenshot"
+)
+
+// Supported browsers
+const (
+	Chromium = "chromium"
+	Firefox  = "firefox"
+	WebKit   = "webkit"
+)
+
+const (
+	defaultNode        = "node"
+	defaultTimeout     = 2 * time.Minute
+	defaultStepTimeout = 10 * time.Second
+	defaultWidth       = 1280
+	defaultHeight      = 800
+	// MaxSteps caps the steps of one session
+	MaxSteps = 50
+	// MaxScreenshots caps the screenshot steps of one session
+	MaxScreenshots = 5
+	// maxConsoleMessages caps the console messages kept in a result
+	maxConsoleMessages = 50
+)
+
+//go:embed driver.js
+var driverScript []byte
+
+var namePattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Step is one browser step. Selectors are Playwright selectors (CSS, text=,
+// role= ...).
+type Step struct {
+	Action   string `json:"action"`
+	URL      string `json:"url,omitempty"`       // navigate; absolute, or a path relative to the session URL
+	Selector string `json:"selector,omitempty"`  // Element to act on; optional for assert_text, press, wait_for and screenshot
+	Value    string `json:"value,omitempty"`     // Text for fill, option for select, key for press
+	Text     string `json:"text,omitempty"`      // Expected text for assert_text, assert_url and wait_for
+	Name     string `json:"name,omitempty"`      // Screenshot name
+	FullPage bool   `json:"full_page,omitempty"` // Screenshot the whole page, not just the viewport
+}
+
+// Session is a sequence of steps run in one page
+type Session struct {
+	URL     string // Opened first; relative navigate URLs resolve against it
+	Steps   []Step
+	Browser string // Overrides the configured browser
+	Width   int    // Viewport; default 1280x800
+	Height  int
+	Timeout time.Duration // Shortens the configured session timeout
+}
+
+// StepResult is the outcome of one step. Step 0 opens the session URL; the
+// session's own steps follow from 1.
+type StepResult struct {
+	Index      int    `json:"index"`
+	Action     string `json:"action"`
+	Target     string `json:"target,omitempty"` // The step's selector, URL or text
+	Passed     bool   `json:"passed"`
+	Skipped    bool   `json:"skipped,omitempty"` // Not run after an earlier step failed
+	Error      string `json:"error,omitempty"`
+	Duration   string `json:"duration,omitempty"`
+	Screenshot string `json:"screenshot,omitempty"` // Name of the screenshot it took
+}
+
+// Screenshot is a PNG captured during a session: by a screenshot step, or
+// of the page when a step failed
+type Screenshot struct {
+	Name string `json:"name"`
+	Step int    `json:"step"`
+	Size int    `json:"size"`
+	Data []byte `json:"-"`
+}
+
+// ConsoleMessage is a console error or warning logged by the page
+type ConsoleMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Result is the outcome of a session
+type Result struct {
+	Browser     string           `json:"browser"`
+	Success     bool             `json:"success"`
+	URL         string           `json:"url,omitempty"` // Page URL after the last step
+	Title       string           `json:"title,omitempty"`
+	Steps       []StepResult     `json:"steps"`
+	Screenshots []Screenshot     `json:"screenshots,omitempty"`
+	Console     []ConsoleMessage `json:"console,omitempty"`
+	PageErrors  []string         `json:"page_errors,omitempty"` // Uncaught exceptions
+	Duration    string           `json:"duration"`
+	TimedOut    bool             `json:"timed_out,omitempty"`
+	Error       string           `json:"error,omitempty"` // Driver error when the session could not run
+}
+
+// Manager runs browser sessions with Node and Playwright
+type Manager struct {
+	WorkDirs    files.WorkDirResolver
+	Node        string
+	NodePath    string
+	Browser     string
+	Timeout     time.Duration
+	StepTimeout time.Duration
+
+	runner toolrun.Runner
+}
+
+// NewManager creates a browser manager, or returns nil when the browser is
+// disabled in cfg.
+func NewManager(resolver files.WorkDirResolver, cfg config.BrowserConfig) *Manager {
+	if cfg.Disabled {
+		return nil
+	}
+	m := &Manager{
+		WorkDirs:    resolver,
+		Node:        cfg.Node,
+		NodePath:    cfg.NodePath,
+		Browser:     strings.ToLower(strings.TrimSpace(cfg.Browser)),
+		Timeout:     cfg.Timeout,
+		StepTimeout: cfg.StepTimeout,
+	}
+	if m.Node == "" {
+		m.Node = defaultNode
+	}
+	if m.Browser == "" {
+		m.Browser = Chromium
+	}
+	if m.Timeout <= 0 {
+		m.Timeout = defaultTimeout
+	}
+	if m.StepTimeout <= 0 {
+		m.StepTimeout = defaultStepTimeout
+	}
+	return m
+}
+
+// Validate checks a session before it runs
+func Validate(s Session) error {
+	start, err := url.Parse(s.URL)
+	if err != nil || start.Host == "" || (start.Scheme != "http" && start.Scheme != "https") {
+		return fmt.Errorf("invalid URL %q (use an http or https URL)", s.URL)
+	}
+	if len(s.Steps) > MaxSteps {
+		return fmt.Errorf("%d steps exceed the limit of %d", len(s.Steps), MaxSteps)
+	}
+	if s.Browser != "" && !validBrowser(s.Browser) {
+		return fmt.Errorf("unsupported browser %q (use chromium, firefox or webkit)", s.Browser)
+	}
+	if s.Width < 0 || s.Height < 0 || s.Width > 4096 || s.Height > 4096 {
+		return fmt.Errorf("invalid viewport %dx%d", s.Width, s.Height)
+	}
+	screenshots := 0
+	for i, step := range s.Steps {
+		var missing string
+		switch step.Action {
+		case StepNavigate:
+			if step.URL == "" {
+				missing = "url"
+			} else if u, err := url.Parse(step.URL); err != nil || (u.IsAbs() && u.Scheme != "http" && u.Scheme != "https") {
+				return fmt.Errorf("step %d: invalid URL %q", i+1, step.URL)
+			}
+		case StepClick, StepFill, StepSelect, StepAssertVisible:
+			if step.Selector == "" {
+				missing = "selector"
+			}
+		case StepPress:
+			if step.Value == "" {
+				missing = "value (the key)"
+			}
+		case StepWaitFor:
+			if step.Selector == "" && step.Text == "" {
+				missing = "selector or text"
+			}
+		case StepAssertText, StepAssertURL:
+			if step.Text == "" {
+				missing = "text"
+			}
+		case StepScreenshot:
+			screenshots++
+		default:
+			return fmt.Errorf("step %d: unknown action %q", i+1, step.Action)
+		}
+		if missing != "" {
+			return fmt.Errorf("step %d: %s requires %s", i+1, step.Action, missing)
+		}
+	}
+	if screenshots > MaxScreenshots {
+		return fmt.Errorf("%d screenshots exceed the limit of %d", screenshots, MaxScreenshots)
+	}
+	return nil
+}
+
+// driverStep is a step as the driver receives it
+type driverStep struct {
+	Step
+	Path string `json:"path,omitempty"`
+}
+
+// driverSession is the session the driver reads from stdin
+type driverSession struct {
+	URL               string       `json:"url"`
+	Browser           string       `json:"browser"`
+	Width             int          `json:"width"`
+	Height            int          `json:"height"`
+	StepTimeoutMillis int64        `json:"step_timeout_ms"`
+	FailureScreenshot string       `json:"failure_screenshot"`
+	Steps             []driverStep `json:"steps"`
+}
+
+// driverOutput is what the driver writes to stdout
+type driverOutput struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Steps []struct {
+		Index      int    `json:"index"`
+		Passed     bool   `json:"passed"`
+		Skipped    bool   `json:"skipped"`
+		Error      string `json:"error"`
+		DurationMS int64  `json:"duration_ms"`
+		Screenshot string `json:"screenshot"`
+	} `json:"steps"`
+	Console    []ConsoleMessage `json:"console"`
+	PageErrors []string         `json:"page_errors"`
+	Error      string           `json:"error"`
+}
+
+// Run runs a session in the project's workdir, so the project's own
+// playwright package is used when it has one. A failing step is reported in
+// the result; an error means the session could not be started.
+func (m *Manager) Run(ctx context.Context, projectID string, s Session) (*Result, error) {
+	if err := Validate(s); err != nil {
+		return nil, err
+	}
+	if _, err := m.runner.Find(m.Node); err != nil {
+		return nil, fmt.Errorf("%s is not installed", m.Node)
+	}
+	if m.WorkDirs == nil {
+		return nil, fmt.Errorf("workdir resolver not configured")
+	}
+	workDir := m.WorkDirs.GetProjectWorkDir(projectID)
+	if workDir == "" {
+		return nil, fmt.Errorf("project workdir not found")
+	}
+
+	browserName := strings.ToLower(s.Browser)
+	if browserName == "" {
+		browserName = m.Browser
+	}
+	if !validBrowser(browserName) {
+		return nil, fmt.Errorf("unsupported browser %q (use chromium, firefox or webkit)", browserName)
+	}
+
+	tmp, err := os.MkdirTemp("", "loom-browser-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating session directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	driver := filepath.Join(tmp, "driver.js")
+	if err := os.WriteFile(driver, driverScript, 0600); err != nil {
+		return nil, fmt.Errorf("writing driver: %w", err)
+	}
+
+	input := driverSession{
+		URL:               s.URL,
+		Browser:           browserName,
+		Width:             defaultWidth,
+		Height:            defaultHeight,
+		StepTimeoutMillis: m.StepTimeout.Milliseconds(),
+		FailureScreenshot: filepath.Join(tmp, "failure.png"),
+	}
+	if s.Width > 0 && s.Height > 0 {
+		input.Width, input.Height = s.Width, s.Height
+	}
+	names := map[string]int{}
+	for i, step := range s.Steps {
+		ds := driverStep{Step: step}
+		if step.Action == StepScreenshot {
+			ds.Path = filepath.Join(tmp, screenshotName(step.Name, i+1, names))
+		}
+		input.Steps = append(input.Steps, ds)
+	}
+	// The first step opens the session URL
+	input.Steps = append([]driverStep{{Step: Step{Action: StepNavigate, URL: s.URL}}}, input.Steps...)
+	stdin, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	env := os.Environ()
+	if m.NodePath != "" {
+		env = append(env, "NODE_PATH="+m.NodePath)
+	}
+	runCtx, cancel := context.WithTimeout(ctx, toolrun.Limit(s.Timeout, m.Timeout))
+	defer cancel()
+	start := time.Now()
+	stdout, stderr, runErr := m.runner.Exec(runCtx, toolrun.Command{Dir: workDir, Env: env, Stdin: stdin, Name: m.Node, Args: []string{driver}})
+
+	result := &Result{
+		Browser:  browserName,
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+		TimedOut: errors.Is(runCtx.Err(), context.DeadlineExceeded),
+		Steps:    []StepResult{},
+	}
+	var out driverOutput
+	if err := json.Unmarshal(bytes.TrimSpace(stdout), &out); err != nil {
+		switch {
+		case result.TimedOut:
+			result.Error = "session timed out after " + result.Duration
+		case runErr != nil:
+			result.Error = errorOutput(stderr, runErr)
+		default:
+			result.Error = "unreadable driver output: " + err.Error()
+		}
+		return result, nil
+	}
+
+	result.URL, result.Title, result.Error = out.URL, out.Title, out.Error
+	result.Console = out.Console
+	if len(result.Console) > maxConsoleMessages {
+		result.Console = result.Console[:maxConsoleMessages]
+	}
+	result.PageErrors = out.PageErrors
+	result.Success = out.Error == "" && len(out.Steps) == len(input.Steps)
+	for _, ds := range out.Steps {
+		if ds.Index < 0 || ds.Index >= len(input.Steps) {
+			continue
+		}
+		step := input.Steps[ds.Index]
+		sr := StepResult{
+			Index:   ds.Index,
+			Action:  step.Action,
+			Target:  target(step.Step),
+			Passed:  ds.Passed,
+			Skipped: ds.Skipped,
+			Error:   ds.Error,
+		}
+		if !ds.Skipped {
+			sr.Duration = (time.Duration(ds.DurationMS) * time.Millisecond).String()
+		}
+		if ds.Screenshot != "" {
+			if shot, ok := readScreenshot(tmp, ds.Screenshot, ds.Index); ok {
+				sr.Screenshot = shot.Name
+				result.Screenshots = append(result.Screenshots, shot)
+			}
+		}
+		result.Success = result.Success && ds.Passed
+		result.Steps = append(result.Steps, sr)
+	}
+	return result, nil
+}
+
+// readScreenshot reads a screenshot the driver wrote to the session directory
+func readScreenshot(dir, path string, step int) (Screenshot, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") || filepath.IsAbs(rel) {
+		return Screenshot{}, false
+	}
+	data, err := os.ReadFile(filepath.Join(dir, rel))
+	if err != nil || len(data) == 0 {
+		return Screenshot{}, false
+	}
+	return Screenshot{Name: filepath.Base(rel), Step: step, Size: len(data), Data: data}, true
+}
+
+// screenshotName picks a unique file name for a screenshot step
+func screenshotName(name string, index int, seen map[string]int) string {
+	name = strings.Trim(namePattern.ReplaceAllString(strings.TrimSuffix(name, ".png"), "-"), "-.")
+	if name == "" || name == "failure" {
+		name = fmt.Sprintf("step-%d", index)
+	}
+	seen[name]++
+	if n := seen[name]; n > 1 {
+		name = fmt.Sprintf("%s-%d", name, n)
+	}
+	return name + ".png"
+}
+
+func target(s Step) string {
+	switch {
+	case s.Action == StepNavigate:
+		return s.URL
+	case s.Selector != "":
+		return s.Selector
+	case s.Action == StepPress:
+		return s.Value
+	default:
+		return s.Text
+	}
+}
+
+func validBrowser(name string) bool {
+	switch strings.ToLower(name) {
+	case Chromium, Firefox, WebKit:
+		return true
+	}
+	return false
+}
+
+func errorOutput(stderr []byte, err error) string {
+	if msg := strings.TrimSpace(string(stderr)); msg != "" {
+		return msg
+	}
+	return err.Error()
+}
@@ -0,0 +1,189 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+var png = []byte("\x89PNG\r\n\x1a\nfake")
+
+// fakeDriver stands in for node: it records the session it was given and
+// answers with out, writing the screenshots it names
+type fakeDriver struct {
+	toolrun.Fake
+	session driverSession
+	out     func(s driverSession) string
+}
+
+func (f *fakeDriver) handle(c toolrun.Command) ([]byte, []byte, error) {
+	if err := json.Unmarshal(c.Stdin, &f.session); err != nil {
+		return nil, nil, err
+	}
+	if _, err := os.Stat(c.Args[0]); err != nil {
+		return nil, []byte("driver missing"), errors.New("exit status 1")
+	}
+	return []byte(f.out(f.session)), nil, nil
+}
+
+func fakeManager(t *testing.T, out func(s driverSession) string) (*Manager, *fakeDriver) {
+	t.Helper()
+	m := NewManager(toolrun.StaticWorkDir(t.TempDir()), config.BrowserConfig{NodePath: "/opt/pw/node_modules"})
+	f := &fakeDriver{out: out}
+	f.Handle = f.handle
+	m.runner = f.Runner()
+	return m, f
+}
+
+func TestNewManagerDisabled(t *testing.T) {
+	if m := NewManager(toolrun.StaticWorkDir("/tmp"), config.BrowserConfig{Disabled: true}); m != nil {
+		t.Error("expected a disabled manager to be nil")
+	}
+}
+
+func TestRunSession(t *testing.T) {
+	m, f := fakeManager(t, func(s driverSession) string {
+		if err := os.WriteFile(s.Steps[4].Path, png, 0600); err != nil {
+			panic(err)
+		}
+		return `{"url":"http://localhost:3000/dashboard","title":"Dashboard","steps":[
+			{"index":0,"passed":true,"duration_ms":120},
+			{"index":1,"passed":true,"duration_ms":5},
+			{"index":2,"passed":true,"duration_ms":5},
+			{"index":3,"passed":true,"duration_ms":300},
+			{"index":4,"passed":true,"duration_ms":40,"screenshot":"` + s.Steps[4].Path + `"}],
+			"console":[{"type":"error","text":"favicon.ico 404"}]}`
+	})
+
+	result, err := m.Run(context.Background(), "web", Session{
+		URL: "http://localhost:3000/login",
+		Steps: []Step{
+			{Action: StepFill, Selector: "#email", Value: "a@example.com"},
+			{Action: StepClick, Selector: "text=Sign in"},
+			{Action: StepAssertText, Selector: "h1", Text: "Dashboard"},
+			{Action: StepScreenshot, Name: "after login"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success || result.Browser != Chromium || result.Title != "Dashboard" || len(result.Steps) != 5 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result.Steps[0].Action != StepNavigate || result.Steps[0].Target != "http://localhost:3000/login" || result.Steps[3].Target != "h1" {
+		t.Errorf("unexpected steps %+v", result.Steps)
+	}
+	if len(result.Screenshots) != 1 || result.Screenshots[0].Name != "after-login.png" || result.Screenshots[0].Step != 4 || string(result.Screenshots[0].Data) != string(png) {
+		t.Errorf("unexpected screenshots %+v", result.Screenshots)
+	}
+	if len(result.Console) != 1 {
+		t.Errorf("unexpected console %+v", result.Console)
+	}
+	if f.session.Steps[0].URL != "http://localhost:3000/login" || f.session.StepTimeoutMillis != 10000 || f.session.Width != 1280 {
+		t.Errorf("unexpected driver session %+v", f.session)
+	}
+	if env := f.Calls[0].Env; env[len(env)-1] != "NODE_PATH=/opt/pw/node_modules" {
+		t.Errorf("NODE_PATH not set: %v", env[len(env)-1])
+	}
+	if _, err := os.Stat(filepath.Dir(f.session.FailureScreenshot)); !os.IsNotExist(err) {
+		t.Errorf("session directory was not removed: %v", err)
+	}
+}
+
+func TestRunFailingStep(t *testing.T) {
+	m, _ := fakeManager(t, func(s driverSession) string {
+		if err := os.WriteFile(s.FailureScreenshot, png, 0600); err != nil {
+			panic(err)
+		}
+		return `{"url":"http://localhost:3000/","steps":[
+			{"index":0,"passed":true,"duration_ms":120},
+			{"index":1,"passed":false,"error":"Timeout 10000ms exceeded waiting for #save","duration_ms":10000,"screenshot":"` + s.FailureScreenshot + `"},
+			{"index":2,"skipped":true}],"page_errors":["TypeError: x is undefined"]}`
+	})
+	result, err := m.Run(context.Background(), "web", Session{
+		URL:   "http://localhost:3000/",
+		Steps: []Step{{Action: StepClick, Selector: "#save"}, {Action: StepAssertText, Text: "Saved"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || result.Steps[1].Passed || !strings.Contains(result.Steps[1].Error, "#save") || !result.Steps[2].Skipped || result.Steps[2].Duration != "" {
+		t.Errorf("unexpected steps %+v", result.Steps)
+	}
+	if len(result.Screenshots) != 1 || result.Screenshots[0].Name != "failure.png" || result.Steps[1].Screenshot != "failure.png" {
+		t.Errorf("expected a failure screenshot, got %+v", result.Screenshots)
+	}
+	if len(result.PageErrors) != 1 {
+		t.Errorf("unexpected page errors %v", result.PageErrors)
+	}
+}
+
+func TestRunDriverErrors(t *testing.T) {
+	m, _ := fakeManager(t, func(driverSession) string {
+		return `{"error":"playwright is not installed: run npm install playwright","steps":[]}`
+	})
+	result, err := m.Run(context.Background(), "web", Session{URL: "http://localhost:3000/"})
+	if err != nil || result.Success || !strings.Contains(result.Error, "playwright is not installed") {
+		t.Errorf("unexpected result %+v, %v", result, err)
+	}
+
+	m.runner = (&toolrun.Fake{Handle: func(c toolrun.Command) ([]byte, []byte, error) {
+		return nil, []byte("node: bad option"), errors.New("exit status 9")
+	}}).Runner()
+	if result, _ := m.Run(context.Background(), "web", Session{URL: "http://localhost:3000/"}); result.Error != "node: bad option" {
+		t.Errorf("expected the node error, got %+v", result)
+	}
+
+	m.runner = (&toolrun.Fake{Installed: []string{}}).Runner()
+	if _, err := m.Run(context.Background(), "web", Session{URL: "http://localhost:3000/"}); err == nil || !strings.Contains(err.Error(), "node is not installed") {
+		t.Errorf("expected missing node to be refused, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, bad := range []Session{
+		{URL: "file:///etc/passwd"},
+		{URL: "localhost:3000"},
+		{URL: "http://app", Browser: "lynx"},
+		{URL: "http://app", Steps: []Step{{Action: "hover", Selector: "a"}}},
+		{URL: "http://app", Steps: []Step{{Action: StepClick}}},
+		{URL: "http://app", Steps: []Step{{Action: StepAssertText, Selector: "h1"}}},
+		{URL: "http://app", Steps: []Step{{Action: StepNavigate, URL: "javascript:alert(1)"}}},
+		{URL: "http://app", Steps: make([]Step, MaxScreenshots+1)},
+	} {
+		if bad.Steps != nil && bad.Steps[0].Action == "" {
+			for i := range bad.Steps {
+				bad.Steps[i].Action = StepScreenshot
+			}
+		}
+		if err := Validate(bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+	ok := Session{URL: "https://app.local", Steps: []Step{{Action: StepNavigate, URL: "/settings"}, {Action: StepWaitFor, Text: "Settings"}, {Action: StepPress, Value: "Enter"}}}
+	if err := Validate(ok); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestScreenshotName(t *testing.T) {
+	seen := map[string]int{}
+	for _, tc := range []struct{ name, want string }{
+		{"Login page.png", "Login-page.png"},
+		{"../../etc", "etc.png"},
+		{"", "step-3.png"},
+		{"failure", "step-3-2.png"},
+		{"Login page", "Login-page-2.png"},
+	} {
+		if got := screenshotName(tc.name, 3, seen); got != tc.want {
+			t.Errorf("screenshotName(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Playwright driver for the browser action. Reads a session from stdin, runs
+// its steps in one page and writes the results to stdout as JSON. Steps stop
+// at the first failure; a screenshot of the page is taken when one fails.
+'use strict';
+
+function fail(error) {
+  process.stdout.write(JSON.stringify({ error: String(error), steps: [] }));
+  process.exit(0);
+}
+
+let playwright;
+try {
+  playwright = require('playwright');
+} catch (err) {
+  try {
+    playwright = require('playwright-core');
+  } catch (_) {
+    fail('playwright is not installed: run npm install playwright && npx playwright install chromium');
+  }
+}
+
+function readStdin() {
+  return new Promise((resolve, reject) => {
+    const chunks = [];
+    process.stdin.on('data', (c) => chunks.push(c));
+    process.stdin.on('end', () => resolve(Buffer.concat(chunks).toString('utf8')));
+    process.stdin.on('error', reject);
+  });
+}
+
+async function runStep(page, step, session) {
+  const timeout = session.step_timeout_ms;
+  switch (step.action) {
+    case 'navigate': {
+      const resp = await page.goto(step.url, { timeout, waitUntil: 'load' });
+      if (resp && resp.status() >= 400) {
+        throw new Error(`${step.url} returned status ${resp.status()}`);
+      }
+      return;
+    }
+    case 'click':
+      return page.click(step.selector, { timeout });
+    case 'fill':
+      return page.fill(step.selector, step.value, { timeout });
+    case 'select':
+      await page.selectOption(step.selector, step.value, { timeout });
+      return;
+    case 'press':
+      if (step.selector) {
+        return page.press(step.selector, step.value, { timeout });
+      }
+      return page.keyboard.press(step.value);
+    case 'wait_for':
+      if (step.selector) {
+        await page.waitForSelector(step.selector, { timeout, state: 'visible' });
+      } else {
+        await page.getByText(step.text).first().waitFor({ timeout, state: 'visible' });
+      }
+      return;
+    case 'assert_text': {
+      const deadline = Date.now() + timeout;
+      let actual = '';
+      for (;;) {
+        actual = step.selector
+          ? await page.locator(step.selector).first().innerText({ timeout })
+          : await page.locator('body').innerText({ timeout });
+        if (actual.includes(step.text) || Date.now() >= deadline) {
+          break;
+        }
+        await page.waitForTimeout(250);
+      }
+      if (!actual.includes(step.text)) {
+        const where = step.selector ? step.selector : 'the page';
+        throw new Error(`${where} does not contain ${JSON.stringify(step.text)}; it shows ${JSON.stringify(actual.slice(0, 300))}`);
+      }
+      return;
+    }
+    case 'assert_visible':
+      await page.locator(step.selector).first().waitFor({ timeout, state: 'visible' });
+      return;
+    case 'assert_url': {
+      const deadline = Date.now() + timeout;
+      while (!page.url().includes(step.text) && Date.now() < deadline) {
+        await page.waitForTimeout(250);
+      }
+      if (!page.url().includes(step.text)) {
+        throw new Error(`URL ${page.url()} does not contain ${JSON.stringify(step.text)}`);
+      }
+      return;
+    }
+    case 'screenshot':
+      if (step.selector) {
+        await page.locator(step.selector).first().screenshot({ path: step.path, timeout });
+      } else {
+        await page.screenshot({ path: step.path, fullPage: !!step.full_page, timeout });
+      }
+      return;
+    default:
+      throw new Error(`unknown step ${step.action}`);
+  }
+}
+
+async function main() {
+  const session = JSON.parse(await readStdin());
+  const launcher = playwright[session.browser];
+  if (!launcher) {
+    fail(`unsupported browser ${session.browser}`);
+  }
+
+  const out = { steps: [], console: [], page_errors: [] };
+  let browser;
+  try {
+    browser = await launcher.launch({ headless: true });
+  } catch (err) {
+    fail(`launching ${session.browser}: ${err.message}`);
+  }
+  try {
+    const context = await browser.newContext({
+      baseURL: session.url,
+      viewport: { width: session.width, height: session.height },
+    });
+    const page = await context.newPage();
+    page.on('console', (msg) => {
+      if (msg.type() === 'error' || msg.type() === 'warning') {
+        out.console.push({ type: msg.type(), text: msg.text() });
+      }
+    });
+    page.on('pageerror', (err) => out.page_errors.push(err.message));
+
+    let failed = false;
+    for (let i = 0; i < session.steps.length; i++) {
+      const step = session.steps[i];
+      const result = { index: i, action: step.action, passed: false };
+      if (failed) {
+        result.skipped = true;
+        out.steps.push(result);
+        continue;
+      }
+      const start = Date.now();
+      try {
+        await runStep(page, step, session);
+        result.passed = true;
+        if (step.action === 'screenshot') {
+          result.screenshot = step.path;
+        }
+      } catch (err) {
+        failed = true;
+        result.error = err.message.split('\n')[0];
+        if (session.failure_screenshot) {
+          try {
+            await page.screenshot({ path: session.failure_screenshot, timeout: 5000 });
+            result.screenshot = session.failure_screenshot;
+          } catch (_) {
+            // The page may be gone; the step error says why
+          }
+        }
+      }
+      result.duration_ms = Date.now() - start;
+      out.steps.push(result);
+    }
+    out.url = page.url();
+    out.title = await page.title().catch(() => '');
+  } catch (err) {
+    out.error = err.message;
+  } finally {
+    await browser.close().catch(() => {});
+  }
+  process.stdout.write(JSON.stringify(out));
+}
+
+main().catch((err) => fail(err.message));
@@ -64,6 +64,21 @@ func (a *Analyzer) Analyze(ctx context.Context) (*AnalysisReport, error) {
 	// Generate recommendations
 	recommendations := a.generateRecommendations(opportunities)
 
+	// Near-duplicates, reported separately so exact figures are unchanged
+	var nearDuplicates []*NearDuplicateGroup
+	nearCount := int64(0)
+	nearSavings := 0.0
+	if a.config.NearDuplicates || a.config.Embedder != nil {
+		nearDuplicates = a.detectNearDuplicates(ctx, logs)
+		for _, g := range nearDuplicates {
+			nearCount += int64(g.AdditionalHits)
+			nearSavings += g.AdditionalSavingsUSD
+		}
+		if len(nearDuplicates) > 0 {
+			recommendations = append(recommendations, nearDuplicateRecommendation(nearDuplicates, nearSavings, a.config.TimeWindow))
+		}
+	}
+
 	// Calculate duplicate percentage
 	duplicateCount := int64(0)
 	for _, dup := range duplicates {
@@ -97,6 +112,10 @@ func (a *Analyzer) Analyze(ctx context.Context) (*AnalysisReport, error) {
 		TotalLatencySaved: totalLatency,
 		MonthlyProjection: monthlyProjection,
 		Recommendations:   recommendations,
+
+		NearDuplicates:          nearDuplicates,
+		NearDuplicateCount:      nearCount,
+		NearDuplicateSavingsUSD: nearSavings,
 	}
 
 	return report, nil
@@ -0,0 +1,280 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/internal/memory"
+)
+
+const (
+	// maxSemanticCandidates caps how many distinct prompts are embedded per
+	// analysis
+	maxSemanticCandidates = 2000
+	// embedBatchSize is how many prompts are sent to the embedder at once
+	embedBatchSize = 100
+	// maxEmbedChars truncates prompts before embedding
+	maxEmbedChars = 4000
+)
+
+// variant is a set of byte-identical requests
+type variant struct {
+	logs []*analytics.RequestLog
+}
+
+// nearCluster is a set of variants treated as the same request
+type nearCluster struct {
+	providerID, modelName string
+	text                  string // prompt text used for embedding
+	variants              []*variant
+	semantic              bool
+	minSimilarity         float64
+}
+
+// detectNearDuplicates groups requests that exact hashing keeps apart: first
+// by body after normalizing whitespace and JSON key order, then, if an
+// embedder is configured, by prompt similarity. Each group's savings are
+// those on top of exact-match caching: one extra hit per additional variant.
+func (a *Analyzer) detectNearDuplicates(ctx context.Context, logs []*analytics.RequestLog) []*NearDuplicateGroup {
+	// Exact variants, in first-seen order so results are deterministic
+	variants := make(map[string]*variant)
+	var variantOrder []string
+	for _, log := range logs {
+		if log.StatusCode >= 400 {
+			continue
+		}
+		hash := a.hashRequest(log.ProviderID, log.ModelName, log.RequestBody)
+		v, ok := variants[hash]
+		if !ok {
+			v = &variant{}
+			variants[hash] = v
+			variantOrder = append(variantOrder, hash)
+		}
+		v.logs = append(v.logs, log)
+	}
+
+	// Variants with the same normalized body
+	clusters := make(map[string]*nearCluster)
+	var clusterOrder []string
+	for _, hash := range variantOrder {
+		v := variants[hash]
+		first := v.logs[0]
+		normalized := normalizeRequestBody(first.RequestBody)
+		key := a.hashRequest(first.ProviderID, first.ModelName, normalized)
+		c, ok := clusters[key]
+		if !ok {
+			c = &nearCluster{
+				providerID: first.ProviderID,
+				modelName:  first.ModelName,
+				text:       promptText(first.RequestBody, normalized),
+			}
+			clusters[key] = c
+			clusterOrder = append(clusterOrder, key)
+		}
+		c.variants = append(c.variants, v)
+	}
+
+	merged := make([]*nearCluster, 0, len(clusterOrder))
+	for _, key := range clusterOrder {
+		merged = append(merged, clusters[key])
+	}
+	if a.config.Embedder != nil {
+		semantic, err := a.mergeSimilar(ctx, merged)
+		if err != nil {
+			logging.Module("cacheanalyzer").Warn("Semantic near-duplicate detection skipped", "error", err)
+		} else {
+			merged = semantic
+		}
+	}
+
+	var groups []*NearDuplicateGroup
+	for _, c := range merged {
+		if len(c.variants) < 2 {
+			continue
+		}
+		group := &NearDuplicateGroup{
+			MatchType:  "normalized",
+			ProviderID: c.providerID,
+			ModelName:  c.modelName,
+			Variants:   len(c.variants),
+		}
+		if c.semantic {
+			group.MatchType = "semantic"
+			group.MinSimilarity = c.minSimilarity
+		}
+		var totalCost float64
+		var totalTokens int64
+		for _, v := range c.variants {
+			for _, log := range v.logs {
+				group.RequestCount++
+				totalCost += log.CostUSD
+				totalTokens += log.TotalTokens
+				if group.FirstSeen.IsZero() || log.Timestamp.Before(group.FirstSeen) {
+					group.FirstSeen = log.Timestamp
+				}
+				if log.Timestamp.After(group.LastSeen) {
+					group.LastSeen = log.Timestamp
+				}
+			}
+			if len(group.SampleRequests) < 3 {
+				group.SampleRequests = append(group.SampleRequests, truncateString(v.logs[0].RequestBody, 200))
+			}
+		}
+		group.AdditionalHits = len(c.variants) - 1
+		group.AdditionalSavingsUSD = totalCost / float64(group.RequestCount) * float64(group.AdditionalHits)
+		group.AdditionalTokens = totalTokens / int64(group.RequestCount) * int64(group.AdditionalHits)
+		if group.AdditionalSavingsUSD < a.config.MinSavingsUSD {
+			continue
+		}
+		groups = append(groups, group)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].AdditionalSavingsUSD > groups[j].AdditionalSavingsUSD
+	})
+	return groups
+}
+
+// mergeSimilar greedily merges clusters of the same provider and model whose
+// prompts embed within the similarity threshold of a cluster's first prompt
+func (a *Analyzer) mergeSimilar(ctx context.Context, clusters []*nearCluster) ([]*nearCluster, error) {
+	candidates := clusters
+	if len(candidates) > maxSemanticCandidates {
+		candidates = candidates[:maxSemanticCandidates]
+	}
+
+	texts := make([]string, len(candidates))
+	for i, c := range candidates {
+		texts[i] = truncateString(c.text, maxEmbedChars)
+	}
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(texts))
+		batch, err := a.config.Embedder.Embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("embedding prompts: %w", err)
+		}
+		vectors = append(vectors, batch...)
+	}
+
+	threshold := a.config.SimilarityThreshold
+	if threshold <= 0 {
+		threshold = DefaultAnalysisConfig().SimilarityThreshold
+	}
+
+	type seed struct {
+		cluster *nearCluster
+		vector  []float32
+	}
+	seeds := make(map[string][]*seed) // by provider:model
+	var out []*nearCluster
+	for i, c := range candidates {
+		key := c.providerID + ":" + c.modelName
+		var match *seed
+		best := 0.0
+		for _, s := range seeds[key] {
+			if sim := float64(memory.CosineSimilarity(vectors[i], s.vector)); sim >= threshold && sim > best {
+				match, best = s, sim
+			}
+		}
+		if match == nil {
+			seeds[key] = append(seeds[key], &seed{cluster: c, vector: vectors[i]})
+			out = append(out, c)
+			continue
+		}
+		m := match.cluster
+		m.variants = append(m.variants, c.variants...)
+		if !m.semantic || best < m.minSimilarity {
+			m.minSimilarity = best
+		}
+		m.semantic = true
+	}
+	return append(out, clusters[len(candidates):]...), nil
+}
+
+// normalizeRequestBody collapses whitespace and, for JSON bodies, orders
+// object keys, so formatting differences don't split identical requests
+func normalizeRequestBody(body string) string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err == nil {
+		if b, err := json.Marshal(normalizeJSON(v)); err == nil {
+			return string(b)
+		}
+	}
+	return strings.Join(strings.Fields(body), " ")
+}
+
+// normalizeJSON collapses whitespace in every string of a decoded JSON
+// value; json.Marshal then writes object keys in sorted order
+func normalizeJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = normalizeJSON(val)
+		}
+		return t
+	case []interface{}:
+		for i, val := range t {
+			t[i] = normalizeJSON(val)
+		}
+		return t
+	case string:
+		return strings.Join(strings.Fields(t), " ")
+	default:
+		return v
+	}
+}
+
+// promptText extracts the prompt from a chat or completion request for
+// embedding, falling back to the normalized body
+func promptText(body, normalized string) string {
+	var req struct {
+		Prompt   string `json:"prompt"`
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		return normalized
+	}
+	var parts []string
+	if req.Prompt != "" {
+		parts = append(parts, req.Prompt)
+	}
+	for _, m := range req.Messages {
+		var content string
+		if err := json.Unmarshal(m.Content, &content); err == nil && content != "" {
+			parts = append(parts, m.Role+": "+content)
+		}
+	}
+	if len(parts) == 0 {
+		return normalized
+	}
+	return strings.Join(parts, "\n")
+}
+
+// nearDuplicateRecommendation summarizes near-duplicate savings
+func nearDuplicateRecommendation(groups []*NearDuplicateGroup, savings float64, window time.Duration) string {
+	semantic := 0
+	for _, g := range groups {
+		if g.MatchType == "semantic" {
+			semantic++
+		}
+	}
+	monthly := savings
+	if days := window.Hours() / 24; days > 0 {
+		monthly = savings * (30.0 / days)
+	}
+	if semantic > 0 {
+		return fmt.Sprintf("Caching on normalized prompts, with semantic matching, would save about $%.2f more per month (%d groups, %d semantic)",
+			monthly, len(groups), semantic)
+	}
+	return fmt.Sprintf("Normalizing request bodies before caching would save about $%.2f more per month (%d groups)", monthly, len(groups))
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+)
+
+// topicEmbedder embeds prompts mentioning "weather" close together and
+// every other prompt orthogonally
+type topicEmbedder struct{}
+
+func (topicEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = make([]float32, len(texts)+1)
+		if strings.Contains(text, "weather") {
+			out[i][0] = 1
+			out[i][i+1] = 0.05
+		} else {
+			out[i][i+1] = 1
+		}
+	}
+	return out, nil
+}
+
+func nearLog(id, body string) *analytics.RequestLog {
+	return &analytics.RequestLog{
+		ID:          id,
+		Timestamp:   time.Now(),
+		ProviderID:  "openai",
+		ModelName:   "gpt-4o",
+		RequestBody: body,
+		StatusCode:  200,
+		CostUSD:     0.10,
+		TotalTokens: 100,
+	}
+}
+
+func TestNormalizeRequestBody(t *testing.T) {
+	a := normalizeRequestBody(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello   world"}]}`)
+	b := normalizeRequestBody("{\n  \"messages\": [{\"content\": \"hello world\\n\", \"role\": \"user\"}],\n  \"model\": \"gpt-4o\"\n}")
+	if a != b {
+		t.Errorf("expected formatting and key order to be ignored:\n%s\n%s", a, b)
+	}
+	if got := normalizeRequestBody("plain   text\n prompt"); got != "plain text prompt" {
+		t.Errorf("unexpected normalized text %q", got)
+	}
+}
+
+func TestAnalyze_NearDuplicates(t *testing.T) {
+	storage := &mockStorage{logs: []*analytics.RequestLog{
+		nearLog("1", `{"model":"gpt-4o","prompt":"summarize the file"}`),
+		nearLog("2", `{"model":"gpt-4o","prompt":"summarize the file"}`),
+		nearLog("3", `{"prompt":"summarize  the file", "model":"gpt-4o"}`),
+		nearLog("4", `{"model":"gpt-4o","prompt":"what is the weather in Paris"}`),
+		nearLog("5", `{"model":"gpt-4o","prompt":"tell me the weather for Paris"}`),
+		nearLog("6", `{"model":"gpt-4o","prompt":"write a poem"}`),
+	}}
+
+	// Off by default: exact figures only
+	report, err := NewAnalyzer(storage, nil).Analyze(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.NearDuplicates) != 0 || report.DuplicateCount != 1 {
+		t.Fatalf("expected only the exact duplicate, got %+v", report)
+	}
+
+	config := DefaultAnalysisConfig()
+	config.NearDuplicates = true
+	report, err = NewAnalyzer(storage, config).Analyze(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.DuplicateCount != 1 {
+		t.Errorf("near-duplicates must not change exact figures, got %d duplicates", report.DuplicateCount)
+	}
+	if len(report.NearDuplicates) != 1 {
+		t.Fatalf("expected the reformatted prompt to be grouped, got %+v", report.NearDuplicates)
+	}
+	g := report.NearDuplicates[0]
+	if g.MatchType != "normalized" || g.Variants != 2 || g.RequestCount != 3 || g.AdditionalHits != 1 {
+		t.Errorf("unexpected group %+v", g)
+	}
+	if report.NearDuplicateCount != 1 || report.NearDuplicateSavingsUSD < 0.099 {
+		t.Errorf("unexpected totals: %d hits, $%.3f", report.NearDuplicateCount, report.NearDuplicateSavingsUSD)
+	}
+
+	// With an embedder the two weather prompts match too
+	config.Embedder = topicEmbedder{}
+	report, err = NewAnalyzer(storage, config).Analyze(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.NearDuplicates) != 2 || report.NearDuplicateCount != 2 {
+		t.Fatalf("expected normalized and semantic groups, got %+v", report.NearDuplicates)
+	}
+	var semantic *NearDuplicateGroup
+	for _, g := range report.NearDuplicates {
+		if g.MatchType == "semantic" {
+			semantic = g
+		}
+	}
+	if semantic == nil || semantic.Variants != 2 || semantic.MinSimilarity < config.SimilarityThreshold {
+		t.Errorf("unexpected semantic group %+v", semantic)
+	}
+}
+
+func TestPromptText(t *testing.T) {
+	body := `{"messages":[{"role":"system","content":"be brief"},{"role":"user","content":"hi"}]}`
+	if got := promptText(body, "fallback"); got != "system: be brief\nuser: hi" {
+		t.Errorf("unexpected prompt text %q", got)
+	}
+	if got := promptText("not json", "fallback"); got != "fallback" {
+		t.Errorf("expected fallback, got %q", got)
+	}
+}
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"time"
+
+	"github.com/jordanhubbard/loom/internal/memory"
 )
 
 // DuplicateRequest represents a detected duplicate request pattern
@@ -55,6 +57,32 @@ type AnalysisReport struct {
 	MonthlyProjection float64             `json:"monthly_projection_usd"`
 	Recommendations   []string            `json:"recommendations"`
 	AutoOptimizations []string            `json:"auto_optimizations,omitempty"`
+
+	// Near-duplicate findings, reported separately from exact duplicates.
+	// Savings are what caching on normalized or semantically similar
+	// prompts would add on top of exact-match caching.
+	NearDuplicates          []*NearDuplicateGroup `json:"near_duplicates,omitempty"`
+	NearDuplicateCount      int64                 `json:"near_duplicate_count,omitempty"`
+	NearDuplicateSavingsUSD float64               `json:"near_duplicate_savings_usd,omitempty"`
+}
+
+// NearDuplicateGroup is a set of requests that differ byte-for-byte but ask
+// the same thing. MatchType is "normalized" when the bodies are identical
+// after normalizing whitespace and JSON key order, and "semantic" when
+// embeddings of the prompts were at least the similarity threshold apart.
+type NearDuplicateGroup struct {
+	MatchType            string    `json:"match_type"`
+	ProviderID           string    `json:"provider_id"`
+	ModelName            string    `json:"model_name"`
+	Variants             int       `json:"variants"` // distinct exact request bodies
+	RequestCount         int       `json:"request_count"`
+	AdditionalHits       int       `json:"additional_hits"`
+	AdditionalTokens     int64     `json:"additional_tokens"`
+	AdditionalSavingsUSD float64   `json:"additional_savings_usd"`
+	MinSimilarity        float64   `json:"min_similarity,omitempty"`
+	FirstSeen            time.Time `json:"first_seen"`
+	LastSeen             time.Time `json:"last_seen"`
+	SampleRequests       []string  `json:"sample_requests,omitempty"`
 }
 
 // AnalysisConfig configures the cache analysis
@@ -65,17 +93,22 @@ type AnalysisConfig struct {
 	AutoEnable        bool    // Auto-enable caching for high-value opportunities
 	AutoEnableMinUSD  float64 // Minimum monthly savings to auto-enable ($10 default)
 	AutoEnableMinRate float64 // Minimum hit rate to auto-enable (0.5 = 50% default)
+
+	NearDuplicates      bool            // Also look for requests that differ only in formatting
+	Embedder            memory.Embedder // If set, also group semantically similar prompts
+	SimilarityThreshold float64         // Minimum cosine similarity for a semantic match (0.92 default)
 }
 
 // DefaultAnalysisConfig returns sensible defaults
 func DefaultAnalysisConfig() *AnalysisConfig {
 	return &AnalysisConfig{
-		TimeWindow:        7 * 24 * time.Hour, // 7 days
-		MinOccurrences:    2,                  // At least 2 occurrences
-		MinSavingsUSD:     0.01,               // At least $0.01 savings
-		AutoEnable:        false,              // Don't auto-enable by default
-		AutoEnableMinUSD:  10.0,               // $10/month minimum for auto
-		AutoEnableMinRate: 0.5,                // 50% hit rate minimum for auto
+		TimeWindow:          7 * 24 * time.Hour, // 7 days
+		MinOccurrences:      2,                  // At least 2 occurrences
+		MinSavingsUSD:       0.01,               // At least $0.01 savings
+		AutoEnable:          false,              // Don't auto-enable by default
+		AutoEnableMinUSD:    10.0,               // $10/month minimum for auto
+		AutoEnableMinRate:   0.5,                // 50% hit rate minimum for auto
+		SimilarityThreshold: 0.92,               // 92% cosine similarity for semantic matches
 	}
 }
 
@@ -0,0 +1,476 @@
+// Package ci tracks CI runs for branches pushed by agents. Runs arrive by
+// webhook or by polling GitHub Actions, GitLab CI or Jenkins; each run is
+// attached to the bead that owns the branch, and failures are fed back to the
+// agent as structured messages with log excerpts.
+package ci
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var ciLog = logging.Module("ci")
+
+// Supported providers
+const (
+	ProviderGitHub  = "github"
+	ProviderGitLab  = "gitlab"
+	ProviderJenkins = "jenkins"
+)
+
+const (
+	// DefaultPollInterval is how often watched branches are polled
+	DefaultPollInterval = time.Minute
+	// maxWatchAge stops polling branches whose CI never reports
+	maxWatchAge = 24 * time.Hour
+	// maxRunsPerBead caps the run history kept per bead
+	maxRunsPerBead = 20
+	// agentBranchPrefix is the prefix of branches created for beads
+	agentBranchPrefix = "agent/"
+)
+
+// Target identifies what to poll on a provider
+type Target struct {
+	Repository string // owner/repo or group/project
+	Branch     string
+	Job        string // Jenkins job (folder/name) holding the branch
+}
+
+// Connector talks to one CI provider
+type Connector interface {
+	Name() string
+	// LatestRun returns the newest run for the target's branch, or nil
+	LatestRun(ctx context.Context, t Target) (*models.CIRun, error)
+	// JobLog returns the raw log of a job
+	JobLog(ctx context.Context, repository string, job models.CIJob) (string, error)
+}
+
+// BeadStore reads and updates the beads that own CI runs
+type BeadStore interface {
+	GetBead(id string) (*models.Bead, error)
+	UpdateBead(id string, updates map[string]interface{}) error
+}
+
+// ConversationStore appends CI feedback to a bead's agent conversation
+type ConversationStore interface {
+	GetConversationContextByBeadID(beadID string) (*models.ConversationContext, error)
+	UpdateConversationContext(ctx *models.ConversationContext) error
+}
+
+// ProjectLookup resolves projects for polling
+type ProjectLookup interface {
+	GetProject(id string) (*models.Project, error)
+}
+
+type watch struct {
+	ProjectID string
+	BeadID    string
+	Branch    string
+	Since     time.Time
+}
+
+// Manager records CI runs against beads
+type Manager struct {
+	Beads         BeadStore
+	Conversations ConversationStore
+	Projects      ProjectLookup
+
+	mu         sync.Mutex
+	connectors map[string]Connector
+	watches    map[string]*watch          // by bead ID
+	runs       map[string][]*models.CIRun // by bead ID, newest first
+	now        func() time.Time
+}
+
+// NewManager creates a CI manager. conversations may be nil.
+func NewManager(beads BeadStore, conversations ConversationStore, projects ProjectLookup) *Manager {
+	return &Manager{
+		Beads:         beads,
+		Conversations: conversations,
+		Projects:      projects,
+		connectors:    make(map[string]Connector),
+		watches:       make(map[string]*watch),
+		runs:          make(map[string][]*models.CIRun),
+		now:           time.Now,
+	}
+}
+
+// AddConnector registers a provider connector
+func (m *Manager) AddConnector(c Connector) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connectors[c.Name()] = c
+}
+
+// Watch starts polling CI for a branch pushed on behalf of a bead
+func (m *Manager) Watch(projectID, beadID, branch string) {
+	if beadID == "" || branch == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.watches[beadID] = &watch{ProjectID: projectID, BeadID: beadID, Branch: branch, Since: m.now()}
+}
+
+// Runs returns the recorded runs for a bead, newest first
+func (m *Manager) Runs(beadID string) []*models.CIRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	runs := make([]*models.CIRun, len(m.runs[beadID]))
+	copy(runs, m.runs[beadID])
+	return runs
+}
+
+// Status polls CI for a bead's branch and returns its latest run, or nil if
+// CI has not reported. branch may be empty to use the watched or last seen
+// branch.
+func (m *Manager) Status(ctx context.Context, projectID, beadID, branch string) (*models.CIRun, error) {
+	m.mu.Lock()
+	since := time.Time{}
+	if w := m.watches[beadID]; w != nil {
+		if branch == "" {
+			branch = w.Branch
+		}
+		since = w.Since
+	}
+	var last *models.CIRun
+	if runs := m.runs[beadID]; len(runs) > 0 {
+		last = runs[0]
+		if branch == "" {
+			branch = last.Branch
+		}
+	}
+	m.mu.Unlock()
+
+	if branch == "" {
+		return nil, fmt.Errorf("no branch has been pushed for bead %s", beadID)
+	}
+	run, err := m.poll(ctx, &watch{ProjectID: projectID, BeadID: beadID, Branch: branch, Since: since})
+	if err != nil {
+		if last != nil && last.Branch == branch {
+			ciLog.Warn("Poll failed, using last recorded run", "branch", branch, "error", err)
+			return last, nil
+		}
+		return nil, err
+	}
+	if run == nil && last != nil && last.Branch == branch {
+		return last, nil
+	}
+	return run, nil
+}
+
+// Poll checks every watched branch once and returns how many new run states
+// were recorded
+func (m *Manager) Poll(ctx context.Context) int {
+	m.mu.Lock()
+	watches := make([]*watch, 0, len(m.watches))
+	for id, w := range m.watches {
+		if m.now().Sub(w.Since) > maxWatchAge {
+			delete(m.watches, id)
+			continue
+		}
+		watches = append(watches, w)
+	}
+	m.mu.Unlock()
+
+	recorded := 0
+	for _, w := range watches {
+		m.mu.Lock()
+		before := m.latestState(w.BeadID)
+		m.mu.Unlock()
+
+		run, err := m.poll(ctx, w)
+		if err != nil {
+			ciLog.Warn("Poll failed", "bead_id", w.BeadID, "branch", w.Branch, "error", err)
+			continue
+		}
+		if run != nil && stateKey(run) != before {
+			recorded++
+		}
+	}
+	return recorded
+}
+
+// Start polls watched branches until ctx is cancelled
+func (m *Manager) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Poll(ctx)
+		}
+	}
+}
+
+// poll fetches the branch's latest run and records it. Runs that predate
+// the watch belong to an earlier push and are ignored.
+func (m *Manager) poll(ctx context.Context, w *watch) (*models.CIRun, error) {
+	project, err := m.Projects.GetProject(w.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	conn, target, err := m.connectorFor(project, w.Branch)
+	if err != nil {
+		return nil, err
+	}
+	run, err := conn.LatestRun(ctx, target)
+	if err != nil || run == nil {
+		return nil, err
+	}
+	if !w.Since.IsZero() && !run.UpdatedAt.IsZero() && run.UpdatedAt.Before(w.Since) {
+		return nil, nil
+	}
+	run.ProjectID = w.ProjectID
+	run.BeadID = w.BeadID
+	return m.Record(ctx, run)
+}
+
+// connectorFor picks the project's CI provider: the ci_provider context
+// key, else the git host. ci_repository and jenkins_job override the target.
+func (m *Manager) connectorFor(p *models.Project, branch string) (Connector, Target, error) {
+	name := p.Context["ci_provider"]
+	if name == "" {
+		name = providerForRepo(p.GitRepo)
+	}
+	target := Target{Repository: p.Context["ci_repository"], Branch: branch, Job: p.Context["jenkins_job"]}
+	if target.Repository == "" {
+		target.Repository = RepoPath(p.GitRepo)
+	}
+
+	m.mu.Lock()
+	conn := m.connectors[name]
+	m.mu.Unlock()
+	if conn == nil {
+		return nil, target, fmt.Errorf("no CI connector configured for project %s", p.ID)
+	}
+	if name == ProviderJenkins && target.Job == "" {
+		return nil, target, fmt.Errorf("project %s has no jenkins_job configured", p.ID)
+	}
+	return conn, target, nil
+}
+
+// Record attaches a run to the bead that owns its branch. Repeated
+// deliveries of the same run state are ignored. When a run fails, the bead
+// gets the failure feedback, is queued for redispatch and the feedback is
+// appended to the agent's conversation.
+func (m *Manager) Record(ctx context.Context, run *models.CIRun) (*models.CIRun, error) {
+	if run.BeadID == "" {
+		run.BeadID = BeadIDFromBranch(run.Branch)
+	}
+	if run.BeadID == "" {
+		return nil, fmt.Errorf("branch %q is not an agent branch", run.Branch)
+	}
+	bead, err := m.Beads.GetBead(run.BeadID)
+	if err != nil {
+		return nil, err
+	}
+	run.ProjectID = bead.ProjectID
+	if run.UpdatedAt.IsZero() {
+		run.UpdatedAt = m.now()
+	}
+
+	m.mu.Lock()
+	for _, prev := range m.runs[run.BeadID] {
+		if prev.ID == run.ID && prev.Provider == run.Provider && stateKey(prev) == stateKey(run) {
+			m.mu.Unlock()
+			return prev, nil
+		}
+	}
+	m.mu.Unlock()
+
+	if run.Status == models.CIStatusFailure {
+		m.collectLogs(ctx, run)
+	}
+
+	m.mu.Lock()
+	runs := []*models.CIRun{run}
+	for _, prev := range m.runs[run.BeadID] {
+		if (prev.ID != run.ID || prev.Provider != run.Provider) && len(runs) < maxRunsPerBead {
+			runs = append(runs, prev)
+		}
+	}
+	m.runs[run.BeadID] = runs
+	if run.Finished() {
+		delete(m.watches, run.BeadID)
+	}
+	m.mu.Unlock()
+
+	m.attach(bead, run)
+	return run, nil
+}
+
+// attach records the run on the bead and, for failures, feeds it back to
+// the agent loop
+func (m *Manager) attach(bead *models.Bead, run *models.CIRun) {
+	ctxUpdates := map[string]string{
+		"ci_status":     run.Status,
+		"ci_provider":   run.Provider,
+		"ci_branch":     run.Branch,
+		"ci_run_url":    run.URL,
+		"ci_updated_at": run.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if run.CommitSHA != "" {
+		ctxUpdates["ci_commit"] = run.CommitSHA
+	}
+	updates := map[string]interface{}{"context": ctxUpdates}
+
+	switch {
+	case run.Status == models.CIStatusFailure:
+		feedback := FailureMessage(run)
+		ctxUpdates["ci_feedback"] = feedback
+		ctxUpdates["redispatch_requested"] = "true"
+		ctxUpdates["redispatch_requested_at"] = m.now().UTC().Format(time.RFC3339)
+		if bead.Status == models.BeadStatusClosed {
+			updates["status"] = models.BeadStatusOpen
+		}
+		m.appendConversation(bead.ID, feedback)
+	case run.Status == models.CIStatusSuccess && bead.Context["ci_feedback"] != "":
+		ctxUpdates["ci_feedback"] = ""
+	}
+
+	if err := m.Beads.UpdateBead(bead.ID, updates); err != nil {
+		ciLog.Error("Failed to attach run to bead", "run_id", run.ID, "bead_id", bead.ID, "error", err)
+	}
+}
+
+func (m *Manager) appendConversation(beadID, message string) {
+	if m.Conversations == nil {
+		return
+	}
+	conv, err := m.Conversations.GetConversationContextByBeadID(beadID)
+	if err != nil || conv == nil {
+		return
+	}
+	conv.AddMessage("user", message, len(message)/4)
+	if err := m.Conversations.UpdateConversationContext(conv); err != nil {
+		ciLog.Error("Failed to append CI feedback to conversation", "session_id", conv.SessionID, "error", err)
+	}
+}
+
+// collectLogs fills in failed jobs and their log excerpts from the provider.
+// Webhooks often report only the run, so the jobs are refetched when none
+// are known.
+func (m *Manager) collectLogs(ctx context.Context, run *models.CIRun) {
+	m.mu.Lock()
+	conn := m.connectors[run.Provider]
+	m.mu.Unlock()
+	if conn == nil {
+		return
+	}
+
+	if len(run.Jobs) == 0 && run.Provider != ProviderJenkins {
+		latest, err := conn.LatestRun(ctx, Target{Repository: run.Repository, Branch: run.Branch})
+		if err == nil && latest != nil && latest.ID == run.ID {
+			run.Jobs = latest.Jobs
+		}
+	}
+	for i, job := range run.Jobs {
+		if job.Status != models.CIStatusFailure || job.LogExcerpt != "" {
+			continue
+		}
+		raw, err := conn.JobLog(ctx, run.Repository, job)
+		if err != nil {
+			ciLog.Warn("Failed to fetch job log", "job", job.Name, "run_id", run.ID, "error", err)
+			continue
+		}
+		run.Jobs[i].LogExcerpt = Excerpt(raw)
+	}
+}
+
+// latestState returns the state key of the bead's newest run. Callers hold mu.
+func (m *Manager) latestState(beadID string) string {
+	if runs := m.runs[beadID]; len(runs) > 0 {
+		return stateKey(runs[0])
+	}
+	return ""
+}
+
+func stateKey(run *models.CIRun) string {
+	return run.Provider + "|" + run.ID + "|" + run.Status
+}
+
+// FailureMessage renders a failed run as agent feedback
+func FailureMessage(run *models.CIRun) string {
+	var sb strings.Builder
+	failed := run.FailedJobs()
+	if len(failed) == 0 {
+		sb.WriteString(fmt.Sprintf("CI failed: %s", runLabel(run)))
+		if run.URL != "" {
+			sb.WriteString(" " + run.URL)
+		}
+		sb.WriteString("\n")
+	}
+	for _, job := range failed {
+		sb.WriteString(fmt.Sprintf("CI failed: job %s (%s)", job.Name, runLabel(run)))
+		if job.URL != "" {
+			sb.WriteString(" " + job.URL)
+		}
+		sb.WriteString("\n")
+		if job.LogExcerpt != "" {
+			sb.WriteString("Log excerpt:\n```\n" + job.LogExcerpt + "\n```\n")
+		}
+	}
+	sb.WriteString("Fix the failure, then commit and push again.")
+	return sb.String()
+}
+
+func runLabel(run *models.CIRun) string {
+	label := run.Provider
+	if run.Name != "" {
+		label += " " + run.Name
+	}
+	label += " on " + run.Branch
+	if len(run.CommitSHA) >= 7 {
+		label += " @ " + run.CommitSHA[:7]
+	}
+	return label
+}
+
+// BeadIDFromBranch extracts the bead ID from an agent branch
+// (agent/{bead-id}/{slug}), returning "" for other branches
+func BeadIDFromBranch(branch string) string {
+	branch = strings.TrimPrefix(branch, "refs/heads/")
+	branch = strings.TrimPrefix(branch, "origin/")
+	if !strings.HasPrefix(branch, agentBranchPrefix) {
+		return ""
+	}
+	rest := strings.TrimPrefix(branch, agentBranchPrefix)
+	if i := strings.Index(rest, "/"); i >= 0 {
+		rest = rest[:i]
+	}
+	return rest
+}
+
+// RepoPath extracts owner/repo from an SSH or HTTPS git URL
+func RepoPath(gitURL string) string {
+	path := gitURL
+	if u, err := url.Parse(gitURL); err == nil && u.Host != "" {
+		path = u.Path
+	} else if i := strings.Index(gitURL, ":"); i >= 0 && strings.Contains(gitURL[:i], "@") {
+		path = gitURL[i+1:]
+	}
+	return strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+}
+
+func providerForRepo(gitURL string) string {
+	switch {
+	case strings.Contains(gitURL, "github"):
+		return ProviderGitHub
+	case strings.Contains(gitURL, "gitlab"):
+		return ProviderGitLab
+	}
+	return ""
+}
@@ -0,0 +1,275 @@
+package ci
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeBeads struct {
+	beads map[string]*models.Bead
+}
+
+func (f *fakeBeads) GetBead(id string) (*models.Bead, error) {
+	b, ok := f.beads[id]
+	if !ok {
+		return nil, fmt.Errorf("bead not found: %s", id)
+	}
+	return b, nil
+}
+
+func (f *fakeBeads) UpdateBead(id string, updates map[string]interface{}) error {
+	b := f.beads[id]
+	if status, ok := updates["status"].(models.BeadStatus); ok {
+		b.Status = status
+	}
+	for k, v := range updates["context"].(map[string]string) {
+		b.Context[k] = v
+	}
+	return nil
+}
+
+type fakeConversations struct {
+	conv *models.ConversationContext
+}
+
+func (f *fakeConversations) GetConversationContextByBeadID(beadID string) (*models.ConversationContext, error) {
+	return f.conv, nil
+}
+
+func (f *fakeConversations) UpdateConversationContext(ctx *models.ConversationContext) error {
+	return nil
+}
+
+type fakeProjects map[string]*models.Project
+
+func (f fakeProjects) GetProject(id string) (*models.Project, error) {
+	if p, ok := f[id]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("project not found: %s", id)
+}
+
+type fakeConnector struct {
+	run   *models.CIRun
+	logs  map[string]string
+	polls int
+}
+
+func (f *fakeConnector) Name() string { return ProviderGitHub }
+
+func (f *fakeConnector) LatestRun(ctx context.Context, t Target) (*models.CIRun, error) {
+	f.polls++
+	if f.run == nil {
+		return nil, nil
+	}
+	c := *f.run
+	c.Jobs = append([]models.CIJob(nil), f.run.Jobs...)
+	return &c, nil
+}
+
+func (f *fakeConnector) JobLog(ctx context.Context, repository string, job models.CIJob) (string, error) {
+	return f.logs[job.ID], nil
+}
+
+func newTestManager(start time.Time) (*Manager, *fakeBeads, *fakeConversations, *fakeConnector, *time.Time) {
+	beads := &fakeBeads{beads: map[string]*models.Bead{
+		"bd-1": {ID: "bd-1", ProjectID: "p1", Status: models.BeadStatusClosed, Context: map[string]string{}},
+	}}
+	convs := &fakeConversations{conv: &models.ConversationContext{SessionID: "s1", BeadID: "bd-1"}}
+	projects := fakeProjects{"p1": {ID: "p1", GitRepo: "git@github.com:acme/widgets.git", Context: map[string]string{}}}
+	m := NewManager(beads, convs, projects)
+	clock := start
+	m.now = func() time.Time { return clock }
+	conn := &fakeConnector{logs: map[string]string{}}
+	m.AddConnector(conn)
+	return m, beads, convs, conn, &clock
+}
+
+func TestRecordFailureFeedsBackToAgent(t *testing.T) {
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	m, beads, convs, conn, _ := newTestManager(start)
+	conn.logs["11"] = "2026-03-02T10:01:00.1234567Z ##[group]Run go test ./...\n" +
+		"2026-03-02T10:01:02.0000000Z ok  \texample.com/a\n" +
+		"2026-03-02T10:01:03.0000000Z --- FAIL: TestWidget (0.00s)\n" +
+		"2026-03-02T10:01:03.0000000Z     widget_test.go:12: got 2, want 3\n" +
+		"2026-03-02T10:01:04.0000000Z ##[error]Process completed with exit code 1.\n"
+	conn.run = &models.CIRun{ID: "7", Provider: ProviderGitHub, Repository: "acme/widgets", Branch: "agent/bd-1/fix-widget",
+		CommitSHA: "abcdef1234", Name: "CI", Status: models.CIStatusFailure, UpdatedAt: start.Add(time.Minute),
+		Jobs: []models.CIJob{{ID: "10", Name: "lint", Status: models.CIStatusSuccess}, {ID: "11", Name: "test", Status: models.CIStatusFailure}}}
+
+	// Webhooks report the run without jobs; they are fetched for failures
+	hook := &models.CIRun{ID: "7", Provider: ProviderGitHub, Repository: "acme/widgets", Branch: "agent/bd-1/fix-widget",
+		CommitSHA: "abcdef1234", Name: "CI", Status: models.CIStatusFailure}
+	run, err := m.Record(context.Background(), hook)
+	if err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if run.BeadID != "bd-1" || run.ProjectID != "p1" || len(run.Jobs) != 2 {
+		t.Fatalf("unexpected run: %+v", run)
+	}
+
+	bead := beads.beads["bd-1"]
+	feedback := bead.Context["ci_feedback"]
+	if !strings.HasPrefix(feedback, "CI failed: job test (github CI on agent/bd-1/fix-widget @ abcdef1)") ||
+		!strings.Contains(feedback, "--- FAIL: TestWidget") || strings.Contains(feedback, "2026-03-02T") || strings.Contains(feedback, "lint") {
+		t.Errorf("unexpected feedback:\n%s", feedback)
+	}
+	if bead.Status != models.BeadStatusOpen || bead.Context["redispatch_requested"] != "true" || bead.Context["ci_status"] != models.CIStatusFailure {
+		t.Errorf("failed CI should reopen the bead for redispatch: %s %+v", bead.Status, bead.Context)
+	}
+	if len(convs.conv.Messages) != 1 || convs.conv.Messages[0].Content != feedback {
+		t.Errorf("expected feedback in the conversation, got %+v", convs.conv.Messages)
+	}
+
+	// Redelivered webhooks are ignored
+	if _, err := m.Record(context.Background(), hook); err != nil || len(convs.conv.Messages) != 1 {
+		t.Errorf("duplicate delivery should be ignored: %v, %d messages", err, len(convs.conv.Messages))
+	}
+
+	if _, err := m.Record(context.Background(), &models.CIRun{ID: "8", Provider: ProviderGitHub, Branch: "main"}); err == nil {
+		t.Error("expected error for a branch without a bead")
+	}
+}
+
+func TestPollWatchedBranch(t *testing.T) {
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	m, beads, _, conn, clock := newTestManager(start)
+	beads.beads["bd-1"].Context["ci_feedback"] = "CI failed: job test"
+
+	m.Watch("p1", "bd-1", "agent/bd-1/fix-widget")
+
+	// A run from before the push belongs to the previous push
+	conn.run = &models.CIRun{ID: "6", Provider: ProviderGitHub, Branch: "agent/bd-1/fix-widget", Status: models.CIStatusFailure, UpdatedAt: start.Add(-time.Hour)}
+	if n := m.Poll(context.Background()); n != 0 {
+		t.Fatalf("stale run should be ignored, recorded %d", n)
+	}
+
+	conn.run = &models.CIRun{ID: "7", Provider: ProviderGitHub, Branch: "agent/bd-1/fix-widget", Status: models.CIStatusRunning, UpdatedAt: start.Add(time.Minute)}
+	if n := m.Poll(context.Background()); n != 1 {
+		t.Fatalf("expected running state recorded, got %d", n)
+	}
+	if n := m.Poll(context.Background()); n != 0 {
+		t.Fatalf("unchanged state should not be recorded again, got %d", n)
+	}
+
+	conn.run.Status = models.CIStatusSuccess
+	*clock = start.Add(5 * time.Minute)
+	if n := m.Poll(context.Background()); n != 1 {
+		t.Fatalf("expected success recorded, got %d", n)
+	}
+	bead := beads.beads["bd-1"]
+	if bead.Context["ci_status"] != models.CIStatusSuccess || bead.Context["ci_feedback"] != "" || bead.Status != models.BeadStatusClosed {
+		t.Errorf("unexpected bead after success: %s %+v", bead.Status, bead.Context)
+	}
+	if runs := m.Runs("bd-1"); len(runs) != 1 || runs[0].Status != models.CIStatusSuccess {
+		t.Errorf("expected one run updated in place, got %+v", runs)
+	}
+
+	// Finished runs end the watch
+	polls := conn.polls
+	m.Poll(context.Background())
+	if conn.polls != polls {
+		t.Error("finished branch should no longer be polled")
+	}
+}
+
+func TestBranchAndRepoHelpers(t *testing.T) {
+	for branch, want := range map[string]string{
+		"agent/bd-42/fix-login":        "bd-42",
+		"refs/heads/agent/bd-7/x":      "bd-7",
+		"origin/agent/loom-a1b2/thing": "loom-a1b2",
+		"main":                         "",
+	} {
+		if got := BeadIDFromBranch(branch); got != want {
+			t.Errorf("BeadIDFromBranch(%q) = %q, want %q", branch, got, want)
+		}
+	}
+	for repo, want := range map[string]string{
+		"git@github.com:acme/widgets.git":       "acme/widgets",
+		"https://gitlab.com/group/sub/proj.git": "group/sub/proj",
+		"ssh://git@github.com/acme/widgets":     "acme/widgets",
+	} {
+		if got := RepoPath(repo); got != want {
+			t.Errorf("RepoPath(%q) = %q, want %q", repo, got, want)
+		}
+	}
+}
+
+func TestExcerptFallsBackToTail(t *testing.T) {
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf("\x1b[32mstep %d\x1b[0m", i))
+	}
+	got := Excerpt(strings.Join(lines, "\n") + "\n\n")
+	if !strings.HasPrefix(got, "step 60\n") || !strings.HasSuffix(got, "step 99") {
+		t.Errorf("expected the last %d lines without colors, got:\n%s", maxExcerptLines, got)
+	}
+}
+
+func TestWebhookParsers(t *testing.T) {
+	gh, err := ParseGitHubWorkflowRun([]byte(`{"action":"completed","workflow_run":{"id":99,"name":"CI","head_branch":"agent/bd-1/x",
+		"head_sha":"abc","status":"completed","conclusion":"timed_out","html_url":"https://github.com/acme/w/actions/runs/99"},
+		"repository":{"full_name":"acme/w"}}`))
+	if err != nil || gh.ID != "99" || gh.Status != models.CIStatusFailure || gh.Repository != "acme/w" {
+		t.Errorf("unexpected GitHub run %+v (%v)", gh, err)
+	}
+
+	gl, err := ParseGitLabPipeline([]byte(`{"object_kind":"pipeline","object_attributes":{"id":5,"ref":"agent/bd-1/x","sha":"def","status":"failed"},
+		"project":{"path_with_namespace":"group/proj","web_url":"https://gitlab.com/group/proj"},
+		"builds":[{"id":51,"name":"unit","status":"failed"},{"id":52,"name":"build","status":"success"}]}`))
+	if err != nil || gl.Status != models.CIStatusFailure || len(gl.FailedJobs()) != 1 || gl.Jobs[0].URL != "https://gitlab.com/group/proj/-/jobs/51" {
+		t.Errorf("unexpected GitLab run %+v (%v)", gl, err)
+	}
+	if _, err := ParseGitLabPipeline([]byte(`{"object_kind":"push"}`)); err == nil {
+		t.Error("expected error for non-pipeline GitLab event")
+	}
+
+	jk, err := ParseJenkinsNotification([]byte(`{"name":"widgets","build":{"full_url":"https://ci/job/widgets/3/","number":3,
+		"phase":"COMPLETED","status":"UNSTABLE","scm":{"url":"https://github.com/acme/widgets.git","branch":"origin/agent/bd-1/x","commit":"123"}}}`))
+	if err != nil || jk.ID != "widgets#3" || jk.Branch != "agent/bd-1/x" || jk.Status != models.CIStatusFailure || jk.Repository != "acme/widgets" {
+		t.Errorf("unexpected Jenkins run %+v (%v)", jk, err)
+	}
+}
+
+func TestGitHubConnector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/repos/acme/widgets/actions/runs":
+			if r.URL.Query().Get("branch") != "agent/bd-1/x" {
+				t.Errorf("unexpected branch query %q", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"workflow_runs":[{"id":7,"name":"CI","head_branch":"agent/bd-1/x","status":"in_progress","updated_at":"2026-03-02T10:00:00Z"}]}`)
+		case "/repos/acme/widgets/actions/runs/7/jobs":
+			fmt.Fprint(w, `{"jobs":[{"id":70,"name":"test","status":"completed","conclusion":"failure"},{"id":71,"name":"e2e","status":"queued"}]}`)
+		case "/repos/acme/widgets/actions/jobs/70/logs":
+			fmt.Fprint(w, "FAIL\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	g := NewGitHubConnector(srv.URL, "tok")
+	run, err := g.LatestRun(context.Background(), Target{Repository: "acme/widgets", Branch: "agent/bd-1/x"})
+	if err != nil {
+		t.Fatalf("LatestRun: %v", err)
+	}
+	if run.Status != models.CIStatusRunning || len(run.Jobs) != 2 || run.Jobs[0].Status != models.CIStatusFailure ||
+		run.Jobs[1].Status != models.CIStatusPending || run.UpdatedAt.IsZero() {
+		t.Errorf("unexpected run %+v", run)
+	}
+	if log, err := g.JobLog(context.Background(), "acme/widgets", run.Jobs[0]); err != nil || log != "FAIL\n" {
+		t.Errorf("JobLog = %q, %v", log, err)
+	}
+}
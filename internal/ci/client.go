@@ -0,0 +1,85 @@
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+	// maxLogBytes caps how much of a job log is downloaded
+	maxLogBytes = 4 << 20
+)
+
+// apiClient performs authenticated GET requests against a provider API
+type apiClient struct {
+	client    *http.Client
+	authorize func(req *http.Request)
+}
+
+func newAPIClient(authorize func(req *http.Request)) apiClient {
+	return apiClient{client: &http.Client{Timeout: requestTimeout}, authorize: authorize}
+}
+
+func (c apiClient) get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return body, nil
+}
+
+func (c apiClient) getJSON(ctx context.Context, url string, v interface{}) error {
+	body, err := c.get(ctx, url, maxLogBytes)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// getLog downloads a job log, keeping the end when it is too long since
+// failures are usually reported last
+func (c apiClient) getLog(ctx context.Context, url string) (string, error) {
+	body, err := c.get(ctx, url, 4*maxLogBytes)
+	if err != nil {
+		return "", err
+	}
+	if len(body) > maxLogBytes {
+		body = body[len(body)-maxLogBytes:]
+	}
+	return string(body), nil
+}
+
+var errNotFound = errors.New("not found")
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
@@ -0,0 +1,60 @@
+package ci
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxExcerptLines and maxExcerptBytes bound log excerpts fed to agents
+	maxExcerptLines = 40
+	maxExcerptBytes = 4000
+	// excerptLeadLines of context are kept above the first failure marker
+	excerptLeadLines = 5
+)
+
+var (
+	ansiPattern      = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	timestampPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z ?`)
+	failurePattern   = regexp.MustCompile(`(?i)(\berror\b|\bfail(ed|ure)?\b|--- FAIL|\bpanic:|\bfatal\b|exception|\bERR!)`)
+)
+
+// Excerpt cuts the interesting part out of a CI log: a window starting just
+// above the first failure marker, or the tail of the log when there is none.
+// ANSI colors and GitHub's per-line timestamps are stripped.
+func Excerpt(raw string) string {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	var lines []string
+	for _, line := range strings.Split(raw, "\n") {
+		line = timestampPattern.ReplaceAllString(ansiPattern.ReplaceAllString(line, ""), "")
+		if strings.HasPrefix(line, "##[group]") || strings.HasPrefix(line, "##[endgroup]") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	start := len(lines) - maxExcerptLines
+	for i, line := range lines {
+		// The runner's own exit-code line says nothing about the cause
+		if failurePattern.MatchString(line) && !strings.HasPrefix(line, "##[error]Process completed") {
+			start = i - excerptLeadLines
+			break
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + maxExcerptLines
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	excerpt := strings.Join(lines[start:end], "\n")
+	if len(excerpt) > maxExcerptBytes {
+		excerpt = excerpt[:maxExcerptBytes] + "\n... (truncated)"
+	}
+	return excerpt
+}
@@ -0,0 +1,145 @@
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// GitHubConnector reads GitHub Actions workflow runs
+type GitHubConnector struct {
+	baseURL string
+	api     apiClient
+}
+
+// NewGitHubConnector creates a GitHub Actions connector. An empty baseURL
+// uses api.github.com.
+func NewGitHubConnector(baseURL, token string) *GitHubConnector {
+	if baseURL == "" {
+		baseURL = defaultGitHubAPIURL
+	}
+	return &GitHubConnector{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		api: newAPIClient(func(req *http.Request) {
+			req.Header.Set("Accept", "application/vnd.github+json")
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}),
+	}
+}
+
+// Name implements Connector
+func (g *GitHubConnector) Name() string { return ProviderGitHub }
+
+type githubWorkflowRun struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	HeadBranch string `json:"head_branch"`
+	HeadSHA    string `json:"head_sha"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HTMLURL    string `json:"html_url"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+func (r githubWorkflowRun) toRun(repository string) *models.CIRun {
+	return &models.CIRun{
+		ID:         strconv.FormatInt(r.ID, 10),
+		Provider:   ProviderGitHub,
+		Repository: repository,
+		Branch:     r.HeadBranch,
+		CommitSHA:  r.HeadSHA,
+		Name:       r.Name,
+		Status:     githubStatus(r.Status, r.Conclusion),
+		URL:        r.HTMLURL,
+		UpdatedAt:  parseTime(r.UpdatedAt),
+	}
+}
+
+// LatestRun implements Connector
+func (g *GitHubConnector) LatestRun(ctx context.Context, t Target) (*models.CIRun, error) {
+	var runs struct {
+		WorkflowRuns []githubWorkflowRun `json:"workflow_runs"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/actions/runs?branch=%s&per_page=1", g.baseURL, t.Repository, url.QueryEscape(t.Branch))
+	if err := g.api.getJSON(ctx, endpoint, &runs); err != nil {
+		return nil, err
+	}
+	if len(runs.WorkflowRuns) == 0 {
+		return nil, nil
+	}
+	run := runs.WorkflowRuns[0].toRun(t.Repository)
+
+	var jobs struct {
+		Jobs []struct {
+			ID         int64  `json:"id"`
+			Name       string `json:"name"`
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+			HTMLURL    string `json:"html_url"`
+		} `json:"jobs"`
+	}
+	if err := g.api.getJSON(ctx, fmt.Sprintf("%s/repos/%s/actions/runs/%s/jobs?per_page=100", g.baseURL, t.Repository, run.ID), &jobs); err != nil {
+		return nil, err
+	}
+	for _, j := range jobs.Jobs {
+		run.Jobs = append(run.Jobs, models.CIJob{
+			ID:     strconv.FormatInt(j.ID, 10),
+			Name:   j.Name,
+			Status: githubStatus(j.Status, j.Conclusion),
+			URL:    j.HTMLURL,
+		})
+	}
+	return run, nil
+}
+
+// JobLog implements Connector
+func (g *GitHubConnector) JobLog(ctx context.Context, repository string, job models.CIJob) (string, error) {
+	return g.api.getLog(ctx, fmt.Sprintf("%s/repos/%s/actions/jobs/%s/logs", g.baseURL, repository, job.ID))
+}
+
+// ParseGitHubWorkflowRun converts a workflow_run webhook payload into a run.
+// The payload carries no jobs; they are fetched when the run fails.
+func ParseGitHubWorkflowRun(body []byte) (*models.CIRun, error) {
+	var payload struct {
+		WorkflowRun *githubWorkflowRun `json:"workflow_run"`
+		Repository  struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid workflow_run payload: %w", err)
+	}
+	if payload.WorkflowRun == nil {
+		return nil, fmt.Errorf("workflow_run payload has no run")
+	}
+	return payload.WorkflowRun.toRun(payload.Repository.FullName), nil
+}
+
+// githubStatus maps a GitHub status and conclusion onto a CI status
+func githubStatus(status, conclusion string) string {
+	switch status {
+	case "completed":
+	case "in_progress":
+		return models.CIStatusRunning
+	default:
+		return models.CIStatusPending
+	}
+	switch conclusion {
+	case "success", "neutral", "skipped":
+		return models.CIStatusSuccess
+	case "cancelled", "stale":
+		return models.CIStatusCanceled
+	default:
+		return models.CIStatusFailure
+	}
+}
@@ -0,0 +1,165 @@
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+const defaultGitLabURL = "https://gitlab.com"
+
+// GitLabConnector reads GitLab CI pipelines
+type GitLabConnector struct {
+	baseURL string
+	api     apiClient
+}
+
+// NewGitLabConnector creates a GitLab CI connector. An empty baseURL uses
+// gitlab.com.
+func NewGitLabConnector(baseURL, token string) *GitLabConnector {
+	if baseURL == "" {
+		baseURL = defaultGitLabURL
+	}
+	return &GitLabConnector{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		api: newAPIClient(func(req *http.Request) {
+			if token != "" {
+				req.Header.Set("PRIVATE-TOKEN", token)
+			}
+		}),
+	}
+}
+
+// Name implements Connector
+func (g *GitLabConnector) Name() string { return ProviderGitLab }
+
+func (g *GitLabConnector) projectURL(repository string) string {
+	return fmt.Sprintf("%s/api/v4/projects/%s", g.baseURL, url.PathEscape(repository))
+}
+
+// LatestRun implements Connector
+func (g *GitLabConnector) LatestRun(ctx context.Context, t Target) (*models.CIRun, error) {
+	var pipelines []struct {
+		ID        int64  `json:"id"`
+		SHA       string `json:"sha"`
+		Ref       string `json:"ref"`
+		Status    string `json:"status"`
+		WebURL    string `json:"web_url"`
+		UpdatedAt string `json:"updated_at"`
+	}
+	endpoint := fmt.Sprintf("%s/pipelines?ref=%s&per_page=1", g.projectURL(t.Repository), url.QueryEscape(t.Branch))
+	if err := g.api.getJSON(ctx, endpoint, &pipelines); err != nil {
+		return nil, err
+	}
+	if len(pipelines) == 0 {
+		return nil, nil
+	}
+	p := pipelines[0]
+	run := &models.CIRun{
+		ID:         strconv.FormatInt(p.ID, 10),
+		Provider:   ProviderGitLab,
+		Repository: t.Repository,
+		Branch:     p.Ref,
+		CommitSHA:  p.SHA,
+		Name:       "pipeline #" + strconv.FormatInt(p.ID, 10),
+		Status:     gitlabStatus(p.Status),
+		URL:        p.WebURL,
+		UpdatedAt:  parseTime(p.UpdatedAt),
+	}
+
+	var jobs []struct {
+		ID     int64  `json:"id"`
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		WebURL string `json:"web_url"`
+	}
+	if err := g.api.getJSON(ctx, fmt.Sprintf("%s/pipelines/%s/jobs?per_page=100", g.projectURL(t.Repository), run.ID), &jobs); err != nil {
+		return nil, err
+	}
+	for _, j := range jobs {
+		run.Jobs = append(run.Jobs, models.CIJob{
+			ID:     strconv.FormatInt(j.ID, 10),
+			Name:   j.Name,
+			Status: gitlabStatus(j.Status),
+			URL:    j.WebURL,
+		})
+	}
+	return run, nil
+}
+
+// JobLog implements Connector
+func (g *GitLabConnector) JobLog(ctx context.Context, repository string, job models.CIJob) (string, error) {
+	return g.api.getLog(ctx, fmt.Sprintf("%s/jobs/%s/trace", g.projectURL(repository), job.ID))
+}
+
+// ParseGitLabPipeline converts a GitLab "Pipeline Hook" payload into a run
+func ParseGitLabPipeline(body []byte) (*models.CIRun, error) {
+	var payload struct {
+		ObjectKind       string `json:"object_kind"`
+		ObjectAttributes struct {
+			ID         int64  `json:"id"`
+			Ref        string `json:"ref"`
+			SHA        string `json:"sha"`
+			Status     string `json:"status"`
+			URL        string `json:"url"`
+			FinishedAt string `json:"finished_at"`
+		} `json:"object_attributes"`
+		Project struct {
+			PathWithNamespace string `json:"path_with_namespace"`
+			WebURL            string `json:"web_url"`
+		} `json:"project"`
+		Builds []struct {
+			ID     int64  `json:"id"`
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		} `json:"builds"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid pipeline payload: %w", err)
+	}
+	if payload.ObjectKind != "pipeline" {
+		return nil, fmt.Errorf("unsupported GitLab event %q", payload.ObjectKind)
+	}
+
+	attrs := payload.ObjectAttributes
+	run := &models.CIRun{
+		ID:         strconv.FormatInt(attrs.ID, 10),
+		Provider:   ProviderGitLab,
+		Repository: payload.Project.PathWithNamespace,
+		Branch:     attrs.Ref,
+		CommitSHA:  attrs.SHA,
+		Name:       "pipeline #" + strconv.FormatInt(attrs.ID, 10),
+		Status:     gitlabStatus(attrs.Status),
+		URL:        attrs.URL,
+	}
+	for _, b := range payload.Builds {
+		job := models.CIJob{ID: strconv.FormatInt(b.ID, 10), Name: b.Name, Status: gitlabStatus(b.Status)}
+		if payload.Project.WebURL != "" {
+			job.URL = fmt.Sprintf("%s/-/jobs/%d", payload.Project.WebURL, b.ID)
+		}
+		run.Jobs = append(run.Jobs, job)
+	}
+	return run, nil
+}
+
+// gitlabStatus maps a GitLab pipeline or job status onto a CI status
+func gitlabStatus(status string) string {
+	switch status {
+	case "running":
+		return models.CIStatusRunning
+	case "success":
+		return models.CIStatusSuccess
+	case "failed":
+		return models.CIStatusFailure
+	case "canceled", "skipped":
+		return models.CIStatusCanceled
+	default:
+		return models.CIStatusPending
+	}
+}
@@ -0,0 +1,153 @@
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// JenkinsConnector reads builds of Jenkins multibranch pipeline jobs
+type JenkinsConnector struct {
+	baseURL string
+	api     apiClient
+}
+
+// NewJenkinsConnector creates a Jenkins connector authenticating with a user
+// and API token
+func NewJenkinsConnector(baseURL, user, token string) *JenkinsConnector {
+	return &JenkinsConnector{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		api: newAPIClient(func(req *http.Request) {
+			if user != "" {
+				req.SetBasicAuth(user, token)
+			}
+		}),
+	}
+}
+
+// Name implements Connector
+func (j *JenkinsConnector) Name() string { return ProviderJenkins }
+
+// branchURL addresses the branch job inside a multibranch pipeline. Jenkins
+// encodes "/" in branch names as %2F, which must itself be escaped in URLs.
+func (j *JenkinsConnector) branchURL(job, branch string) string {
+	var sb strings.Builder
+	sb.WriteString(j.baseURL)
+	for _, part := range strings.Split(strings.Trim(job, "/"), "/") {
+		sb.WriteString("/job/" + url.PathEscape(part))
+	}
+	sb.WriteString("/job/" + url.PathEscape(url.PathEscape(branch)))
+	return sb.String()
+}
+
+// LatestRun implements Connector
+func (j *JenkinsConnector) LatestRun(ctx context.Context, t Target) (*models.CIRun, error) {
+	var build struct {
+		Number    int64   `json:"number"`
+		Result    *string `json:"result"`
+		Building  bool    `json:"building"`
+		URL       string  `json:"url"`
+		Timestamp int64   `json:"timestamp"`
+		Duration  int64   `json:"duration"`
+	}
+	err := j.api.getJSON(ctx, j.branchURL(t.Job, t.Branch)+"/lastBuild/api/json", &build)
+	if errors.Is(err, errNotFound) {
+		// The branch has not been indexed or built yet
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := ""
+	if build.Result != nil {
+		result = *build.Result
+	}
+	status := jenkinsStatus(build.Building, result)
+	run := &models.CIRun{
+		ID:         fmt.Sprintf("%s#%d", t.Job, build.Number),
+		Provider:   ProviderJenkins,
+		Repository: t.Repository,
+		Branch:     t.Branch,
+		Name:       fmt.Sprintf("%s #%d", t.Job, build.Number),
+		Status:     status,
+		URL:        build.URL,
+		UpdatedAt:  time.UnixMilli(build.Timestamp + build.Duration),
+		Jobs:       []models.CIJob{{ID: strconv.FormatInt(build.Number, 10), Name: t.Job, Status: status, URL: build.URL}},
+	}
+	return run, nil
+}
+
+// JobLog implements Connector
+func (j *JenkinsConnector) JobLog(ctx context.Context, repository string, job models.CIJob) (string, error) {
+	if job.URL == "" {
+		return "", fmt.Errorf("job %s has no build URL", job.Name)
+	}
+	return j.api.getLog(ctx, strings.TrimSuffix(job.URL, "/")+"/consoleText")
+}
+
+// ParseJenkinsNotification converts a Notification plugin payload into a run
+func ParseJenkinsNotification(body []byte) (*models.CIRun, error) {
+	var payload struct {
+		Name  string `json:"name"`
+		Build struct {
+			FullURL string `json:"full_url"`
+			Number  int64  `json:"number"`
+			Phase   string `json:"phase"`
+			Status  string `json:"status"`
+			SCM     struct {
+				URL    string `json:"url"`
+				Branch string `json:"branch"`
+				Commit string `json:"commit"`
+			} `json:"scm"`
+		} `json:"build"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid Jenkins notification: %w", err)
+	}
+	if payload.Name == "" {
+		return nil, fmt.Errorf("Jenkins notification has no job name")
+	}
+
+	b := payload.Build
+	status := jenkinsStatus(b.Phase == "STARTED" || b.Phase == "QUEUED", b.Status)
+	if b.Phase == "QUEUED" {
+		status = models.CIStatusPending
+	}
+	return &models.CIRun{
+		ID:         fmt.Sprintf("%s#%d", payload.Name, b.Number),
+		Provider:   ProviderJenkins,
+		Repository: RepoPath(b.SCM.URL),
+		Branch:     strings.TrimPrefix(b.SCM.Branch, "origin/"),
+		CommitSHA:  b.SCM.Commit,
+		Name:       fmt.Sprintf("%s #%d", payload.Name, b.Number),
+		Status:     status,
+		URL:        b.FullURL,
+		Jobs:       []models.CIJob{{ID: strconv.FormatInt(b.Number, 10), Name: payload.Name, Status: status, URL: b.FullURL}},
+	}, nil
+}
+
+// jenkinsStatus maps a Jenkins build result onto a CI status
+func jenkinsStatus(building bool, result string) string {
+	if building {
+		return models.CIStatusRunning
+	}
+	switch result {
+	case "SUCCESS":
+		return models.CIStatusSuccess
+	case "FAILURE", "UNSTABLE":
+		return models.CIStatusFailure
+	case "ABORTED", "NOT_BUILT":
+		return models.CIStatusCanceled
+	default:
+		return models.CIStatusPending
+	}
+}
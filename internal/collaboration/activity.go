@@ -0,0 +1,96 @@
+package collaboration
+
+import (
+	"context"
+	"time"
+)
+
+// Activity page sizes
+const (
+	DefaultActivityLimit = 50
+	MaxActivityLimit     = 500
+)
+
+// ActivityQuery selects entries from a bead's activity log. Empty fields
+// match everything; Cursor is the NextCursor of the previous page.
+type ActivityQuery struct {
+	AgentID       string
+	ActivityTypes []string
+	Since         time.Time
+	Until         time.Time
+	Cursor        int64
+	Limit         int
+	Ascending     bool // Oldest first; newest first by default
+}
+
+// ActivityPage is one page of a bead's activity, with the number of
+// entries matching the query across all pages, by type
+type ActivityPage struct {
+	BeadID       string          `json:"bead_id"`
+	Activities   []ActivityEntry `json:"activities"`
+	NextCursor   int64           `json:"next_cursor,omitempty"`
+	HasMore      bool            `json:"has_more"`
+	Total        int             `json:"total"`
+	CountsByType map[string]int  `json:"counts_by_type"`
+}
+
+// appendActivity adds an entry to the log (whose lock is held), numbering
+// it, and returns the stored entry
+func (c *SharedBeadContext) appendActivity(entry ActivityEntry) *ActivityEntry {
+	entry.Seq = int64(len(c.ActivityLog)) + 1
+	c.ActivityLog = append(c.ActivityLog, entry)
+	return &entry
+}
+
+// QueryActivity returns a page of a bead's activity matching q. Cursors are
+// entry sequence numbers, so pages stay stable while activity is added.
+func (s *ContextStore) QueryActivity(ctx context.Context, beadID string, q ActivityQuery) (*ActivityPage, error) {
+	beadCtx, err := s.Get(ctx, beadID)
+	if err != nil {
+		return nil, err
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultActivityLimit
+	}
+	if q.Limit > MaxActivityLimit {
+		q.Limit = MaxActivityLimit
+	}
+	types := make(map[string]bool, len(q.ActivityTypes))
+	for _, t := range q.ActivityTypes {
+		types[t] = true
+	}
+
+	beadCtx.mu.RLock()
+	defer beadCtx.mu.RUnlock()
+
+	page := &ActivityPage{BeadID: beadID, Activities: []ActivityEntry{}, CountsByType: make(map[string]int)}
+	n := len(beadCtx.ActivityLog)
+	for i := 0; i < n; i++ {
+		idx := n - 1 - i
+		if q.Ascending {
+			idx = i
+		}
+		entry := beadCtx.ActivityLog[idx]
+		if (q.AgentID != "" && entry.AgentID != q.AgentID) ||
+			(len(types) > 0 && !types[entry.ActivityType]) ||
+			(!q.Since.IsZero() && entry.Timestamp.Before(q.Since)) ||
+			(!q.Until.IsZero() && !entry.Timestamp.Before(q.Until)) {
+			continue
+		}
+		page.Total++
+		page.CountsByType[entry.ActivityType]++
+
+		if q.Cursor > 0 && ((q.Ascending && entry.Seq <= q.Cursor) || (!q.Ascending && entry.Seq >= q.Cursor)) {
+			continue
+		}
+		if len(page.Activities) == q.Limit {
+			page.HasMore = true
+			continue
+		}
+		page.Activities = append(page.Activities, entry)
+	}
+	if page.HasMore {
+		page.NextCursor = page.Activities[len(page.Activities)-1].Seq
+	}
+	return page, nil
+}
@@ -0,0 +1,63 @@
+package collaboration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestQueryActivity(t *testing.T) {
+	store := NewContextStore()
+	defer store.Close()
+	ctx := context.Background()
+
+	if _, err := store.GetOrCreate(ctx, "bead-1", "p1"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 7; i++ {
+		agent, kind := "agent-1", "message"
+		if i%2 == 1 {
+			agent, kind = "agent-2", "file_modified"
+		}
+		if err := store.AddActivity(ctx, "bead-1", agent, kind, fmt.Sprintf("entry %d", i+1), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Newest first, three at a time; activity added between pages doesn't
+	// shift them
+	page, err := store.QueryActivity(ctx, "bead-1", ActivityQuery{Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Activities) != 3 || page.Activities[0].Seq != 7 || !page.HasMore || page.NextCursor != 5 {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+	if page.Total != 7 || page.CountsByType["message"] != 4 || page.CountsByType["file_modified"] != 3 {
+		t.Errorf("unexpected counts %d %v", page.Total, page.CountsByType)
+	}
+	_ = store.AddActivity(ctx, "bead-1", "agent-1", "message", "entry 8", nil)
+	page, _ = store.QueryActivity(ctx, "bead-1", ActivityQuery{Limit: 3, Cursor: page.NextCursor})
+	if len(page.Activities) != 3 || page.Activities[0].Seq != 4 || page.NextCursor != 2 {
+		t.Fatalf("unexpected second page %+v", page)
+	}
+	page, _ = store.QueryActivity(ctx, "bead-1", ActivityQuery{Limit: 3, Cursor: page.NextCursor})
+	if len(page.Activities) != 1 || page.Activities[0].Seq != 1 || page.HasMore || page.NextCursor != 0 {
+		t.Fatalf("unexpected last page %+v", page)
+	}
+
+	// Filters apply to the page and the counts
+	page, _ = store.QueryActivity(ctx, "bead-1", ActivityQuery{AgentID: "agent-2", Ascending: true})
+	if page.Total != 3 || page.Activities[0].Seq != 2 || page.CountsByType["message"] != 0 {
+		t.Errorf("unexpected agent page %+v", page)
+	}
+	page, _ = store.QueryActivity(ctx, "bead-1", ActivityQuery{ActivityTypes: []string{"message"}, Until: time.Now().Add(-time.Hour)})
+	if page.Total != 0 || len(page.Activities) != 0 {
+		t.Errorf("expected nothing before an hour ago, got %+v", page)
+	}
+
+	if _, err := store.QueryActivity(ctx, "bead-2", ActivityQuery{}); err == nil {
+		t.Error("expected an unknown bead to fail")
+	}
+}
@@ -0,0 +1,273 @@
+package collaboration
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DocumentUpdate is broadcast to a document's subscribers when operations
+// are applied to it
+type DocumentUpdate struct {
+	ProjectID string    `json:"project_id"`
+	Path      string    `json:"path"`
+	Site      string    `json:"site"`
+	Ops       []Op      `json:"ops"`
+	Version   int       `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DocumentSnapshot is a document's state for a replica joining the session:
+// replaying Ops in order on an empty document reproduces Text at Version
+type DocumentSnapshot struct {
+	ProjectID string    `json:"project_id"`
+	Path      string    `json:"path"`
+	Text      string    `json:"text"`
+	Version   int       `json:"version"`
+	Ops       []Op      `json:"ops,omitempty"`
+	Sites     []string  `json:"sites"`
+	OpenedAt  time.Time `json:"opened_at"`
+}
+
+type openDocument struct {
+	doc       *Document
+	sites     map[string]bool
+	listeners []chan DocumentUpdate
+	openedAt  time.Time
+}
+
+// CoEditStore holds the shared editing buffers of files under active
+// co-editing. A file gets a buffer when the first site opens it and loses
+// it when the last one closes it.
+type CoEditStore struct {
+	mu   sync.Mutex
+	docs map[string]*openDocument // projectID:path -> document
+}
+
+// NewCoEditStore creates an empty store
+func NewCoEditStore() *CoEditStore {
+	return &CoEditStore{docs: make(map[string]*openDocument)}
+}
+
+func docKey(projectID, path string) string {
+	return projectID + ":" + path
+}
+
+// Open joins site to the document for a file, creating it from load when
+// the file isn't being co-edited yet
+func (s *CoEditStore) Open(projectID, path, site string, load func() (string, error)) (*DocumentSnapshot, error) {
+	if site == "" {
+		return nil, fmt.Errorf("site is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := docKey(projectID, path)
+	od, ok := s.docs[key]
+	if !ok {
+		content, err := load()
+		if err != nil {
+			return nil, err
+		}
+		od = &openDocument{
+			doc:      NewDocument(projectID, path, "file", content),
+			sites:    make(map[string]bool),
+			openedAt: time.Now(),
+		}
+		s.docs[key] = od
+	}
+	od.sites[site] = true
+	return od.snapshot(0), nil
+}
+
+// Close removes site from a document. The text is returned along with
+// whether site was the last one, in which case the document is dropped and
+// the caller should save the text.
+func (s *CoEditStore) Close(projectID, path, site string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := docKey(projectID, path)
+	od, ok := s.docs[key]
+	if !ok {
+		return "", false, fmt.Errorf("%s is not being co-edited", path)
+	}
+	delete(od.sites, site)
+	if len(od.sites) > 0 {
+		return od.doc.Text(), false, nil
+	}
+	for _, ch := range od.listeners {
+		close(ch)
+	}
+	delete(s.docs, key)
+	return od.doc.Text(), true, nil
+}
+
+// Snapshot returns a document's state, with the operations after since
+func (s *CoEditStore) Snapshot(projectID, path string, since int) (*DocumentSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	od, ok := s.docs[docKey(projectID, path)]
+	if !ok {
+		return nil, fmt.Errorf("%s is not being co-edited", path)
+	}
+	return od.snapshot(since), nil
+}
+
+// ApplyOps integrates operations a site sent and broadcasts the new ones
+func (s *CoEditStore) ApplyOps(projectID, path, site string, ops []Op) (*DocumentUpdate, error) {
+	od, err := s.get(projectID, path)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := od.doc.Apply(ops)
+	if err != nil {
+		return nil, err
+	}
+	return s.broadcast(projectID, path, site, applied), nil
+}
+
+// Edit replaces deleteCount characters at pos with insert on behalf of site,
+// for clients that work with offsets instead of operations
+func (s *CoEditStore) Edit(projectID, path, site string, pos, deleteCount int, insert string) (*DocumentUpdate, error) {
+	od, err := s.get(projectID, path)
+	if err != nil {
+		return nil, err
+	}
+	var ops []Op
+	if deleteCount > 0 {
+		if ops, err = od.doc.Delete(pos, deleteCount); err != nil {
+			return nil, err
+		}
+	}
+	if insert != "" {
+		op, err := od.doc.Insert(site, pos, insert)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return s.broadcast(projectID, path, site, ops), nil
+}
+
+// Text returns the current text of a co-edited file
+func (s *CoEditStore) Text(projectID, path string) (string, bool) {
+	od, err := s.get(projectID, path)
+	if err != nil {
+		return "", false
+	}
+	return od.doc.Text(), true
+}
+
+// List returns the files being co-edited in a project, or in every project
+// when projectID is empty
+func (s *CoEditStore) List(projectID string) []DocumentSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]DocumentSnapshot, 0, len(s.docs))
+	for _, od := range s.docs {
+		if projectID != "" && od.doc.ProjectID != projectID {
+			continue
+		}
+		snap := od.snapshot(-1)
+		list = append(list, *snap)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].ProjectID != list[j].ProjectID {
+			return list[i].ProjectID < list[j].ProjectID
+		}
+		return list[i].Path < list[j].Path
+	})
+	return list
+}
+
+// Subscribe creates a listener channel for a document's updates. The channel
+// is closed when the document is.
+func (s *CoEditStore) Subscribe(projectID, path string) (chan DocumentUpdate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	od, ok := s.docs[docKey(projectID, path)]
+	if !ok {
+		return nil, fmt.Errorf("%s is not being co-edited", path)
+	}
+	ch := make(chan DocumentUpdate, 100)
+	od.listeners = append(od.listeners, ch)
+	return ch, nil
+}
+
+// Unsubscribe removes a listener channel
+func (s *CoEditStore) Unsubscribe(projectID, path string, ch chan DocumentUpdate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	od, ok := s.docs[docKey(projectID, path)]
+	if !ok {
+		return
+	}
+	for i, listener := range od.listeners {
+		if listener == ch {
+			od.listeners = append(od.listeners[:i], od.listeners[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
+func (s *CoEditStore) get(projectID, path string) (*openDocument, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	od, ok := s.docs[docKey(projectID, path)]
+	if !ok {
+		return nil, fmt.Errorf("%s is not being co-edited", path)
+	}
+	return od, nil
+}
+
+// broadcast sends applied operations to the document's listeners
+func (s *CoEditStore) broadcast(projectID, path, site string, ops []Op) *DocumentUpdate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update := &DocumentUpdate{ProjectID: projectID, Path: path, Site: site, Ops: ops, Timestamp: time.Now()}
+	od, ok := s.docs[docKey(projectID, path)]
+	if !ok {
+		return update
+	}
+	update.Version = od.doc.Version()
+	if len(ops) == 0 {
+		return update
+	}
+	for _, ch := range od.listeners {
+		select {
+		case ch <- *update:
+		default:
+			// Listener channel full, skip; it can catch up with OpsSince
+		}
+	}
+	return update
+}
+
+// snapshot returns the document's state with the operations after since;
+// a negative since leaves them out
+func (od *openDocument) snapshot(since int) *DocumentSnapshot {
+	sites := make([]string, 0, len(od.sites))
+	for site := range od.sites {
+		sites = append(sites, site)
+	}
+	sort.Strings(sites)
+	text, version, ops := od.doc.state(since)
+	return &DocumentSnapshot{
+		ProjectID: od.doc.ProjectID,
+		Path:      od.doc.Path,
+		Text:      text,
+		Version:   version,
+		Ops:       ops,
+		Sites:     sites,
+		OpenedAt:  od.openedAt,
+	}
+}
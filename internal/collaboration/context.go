@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/jordanhubbard/loom/internal/metrics"
 )
 
 // SharedBeadContext represents shared context for agents collaborating on a bead
@@ -18,6 +20,7 @@ type SharedBeadContext struct {
 	Version          int64                  `json:"version"` // For conflict resolution
 	LastUpdated      time.Time              `json:"last_updated"`
 	LastUpdatedBy    string                 `json:"last_updated_by"`
+	Presence         map[string]*Presence   `json:"presence,omitempty"` // agentID -> last heartbeat
 	mu               sync.RWMutex
 }
 
@@ -28,6 +31,7 @@ type ActivityEntry struct {
 	ActivityType string                `json:"activity_type"` // joined, updated, left, message, file_modified
 	Description string                 `json:"description"`
 	Data        map[string]interface{} `json:"data,omitempty"`
+	Seq         int64                  `json:"seq"` // Position in the bead's log, from 1
 }
 
 // ContextStore manages shared bead contexts
@@ -35,8 +39,18 @@ type ContextStore struct {
 	contexts  map[string]*SharedBeadContext // beadID -> context
 	mu        sync.RWMutex
 	updates   chan ContextUpdate // Channel for real-time updates
-	listeners map[string][]chan ContextUpdate // beadID -> listeners
-	listenerMu sync.RWMutex
+	shards    [subscriberShards]subscriberShard // Listeners by bead, spread over independently locked shards
+	presenceTTL time.Duration
+	done        chan struct{}
+	store       Store // Optional persistence; contexts above are then a cache
+
+	replayMu   sync.Mutex
+	replay     map[string]*eventBuffer // beadID -> latest events, for resuming streams
+	replaySize int
+	epoch      int64 // Start time, distinguishing this run's event IDs
+
+	sendTimeout time.Duration // How long a full listener may hold up an update before it is dropped
+	metrics     *metrics.Metrics
 }
 
 // ContextUpdate represents a context update event
@@ -47,6 +61,7 @@ type ContextUpdate struct {
 	Data      map[string]interface{}
 	Timestamp time.Time
 	Version   int64
+	EventID   int64 // Position in the bead's stream, from 1; set when distributed
 }
 
 // ConflictError indicates a version conflict during update
@@ -66,276 +81,221 @@ func NewContextStore() *ContextStore {
 	store := &ContextStore{
 		contexts:  make(map[string]*SharedBeadContext),
 		updates:   make(chan ContextUpdate, 1000),
-		listeners: make(map[string][]chan ContextUpdate),
+		presenceTTL: DefaultPresenceTTL,
+		done:        make(chan struct{}),
+		replay:      make(map[string]*eventBuffer),
+		replaySize:  DefaultEventBufferSize,
+		epoch:       time.Now().UnixNano(),
+		sendTimeout: defaultSendTimeout,
+		metrics:     metrics.NewMetrics(),
+	}
+
+	for i := range store.shards {
+		store.shards[i].beads = make(map[string]*beadSubscribers)
 	}
 
 	// Start update distributor
 	go store.distributeUpdates()
+	go store.expirePresenceLoop()
 
 	return store
 }
 
 // GetOrCreate gets existing context or creates new one
 func (s *ContextStore) GetOrCreate(ctx context.Context, beadID, projectID string) (*SharedBeadContext, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if existing, ok := s.contexts[beadID]; ok {
-		return existing, nil
+	existing, err := s.lookup(beadID)
+	if err != nil {
+		return nil, err
 	}
-
-	// Create new context
-	newCtx := &SharedBeadContext{
-		BeadID:              beadID,
-		ProjectID:           projectID,
-		CollaboratingAgents: []string{},
-		Data:                make(map[string]interface{}),
-		ActivityLog:         []ActivityEntry{},
-		Version:             1,
-		LastUpdated:         time.Now(),
+	if existing != nil {
+		return existing, nil
 	}
-
-	s.contexts[beadID] = newCtx
-	return newCtx, nil
+	return s.createContext(beadID, projectID)
 }
 
 // Get retrieves a context by bead ID
 func (s *ContextStore) Get(ctx context.Context, beadID string) (*SharedBeadContext, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if ctx, ok := s.contexts[beadID]; ok {
-		return ctx, nil
+	beadCtx, err := s.lookup(beadID)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil, fmt.Errorf("context not found for bead: %s", beadID)
+	if beadCtx == nil {
+		return nil, fmt.Errorf("context not found for bead: %s", beadID)
+	}
+	return beadCtx, nil
 }
 
 // JoinBead adds an agent to the bead context
 func (s *ContextStore) JoinBead(ctx context.Context, beadID, agentID string) error {
-	s.mu.Lock()
-	beadCtx, exists := s.contexts[beadID]
-	if !exists {
-		s.mu.Unlock()
-		return fmt.Errorf("context not found for bead: %s", beadID)
-	}
-	s.mu.Unlock()
-
-	beadCtx.mu.Lock()
-	defer beadCtx.mu.Unlock()
-
-	// Check if already in context
-	for _, agent := range beadCtx.CollaboratingAgents {
-		if agent == agentID {
-			return nil // Already joined
+	return s.mutate(beadID, func(beadCtx *SharedBeadContext) (*ActivityEntry, *ContextUpdate, error) {
+		// Check if already in context
+		for _, agent := range beadCtx.CollaboratingAgents {
+			if agent == agentID {
+				return nil, nil, nil // Already joined
+			}
 		}
-	}
-
-	// Add agent
-	beadCtx.CollaboratingAgents = append(beadCtx.CollaboratingAgents, agentID)
-	beadCtx.Version++
-	beadCtx.LastUpdated = time.Now()
-	beadCtx.LastUpdatedBy = agentID
-
-	// Add activity log
-	beadCtx.ActivityLog = append(beadCtx.ActivityLog, ActivityEntry{
-		Timestamp:    time.Now(),
-		AgentID:      agentID,
-		ActivityType: "joined",
-		Description:  fmt.Sprintf("Agent %s joined collaboration", agentID),
-	})
 
-	// Notify listeners
-	s.notifyUpdate(ContextUpdate{
-		BeadID:     beadID,
-		UpdateType: "joined",
-		AgentID:    agentID,
-		Timestamp:  time.Now(),
-		Version:    beadCtx.Version,
+		// Add agent
+		beadCtx.CollaboratingAgents = append(beadCtx.CollaboratingAgents, agentID)
+		beadCtx.Version++
+		beadCtx.LastUpdated = time.Now()
+		beadCtx.LastUpdatedBy = agentID
+
+		// Add activity log
+		entry := beadCtx.appendActivity(ActivityEntry{
+			Timestamp:    time.Now(),
+			AgentID:      agentID,
+			ActivityType: "joined",
+			Description:  fmt.Sprintf("Agent %s joined collaboration", agentID),
+		})
+
+		return entry, &ContextUpdate{
+			BeadID:     beadID,
+			UpdateType: "joined",
+			AgentID:    agentID,
+			Timestamp:  time.Now(),
+			Version:    beadCtx.Version,
+		}, nil
 	})
-
-	return nil
 }
 
 // LeaveBead removes an agent from the bead context
 func (s *ContextStore) LeaveBead(ctx context.Context, beadID, agentID string) error {
-	s.mu.Lock()
-	beadCtx, exists := s.contexts[beadID]
-	if !exists {
-		s.mu.Unlock()
-		return fmt.Errorf("context not found for bead: %s", beadID)
-	}
-	s.mu.Unlock()
-
-	beadCtx.mu.Lock()
-	defer beadCtx.mu.Unlock()
-
-	// Remove agent
-	newAgents := []string{}
-	found := false
-	for _, agent := range beadCtx.CollaboratingAgents {
-		if agent != agentID {
-			newAgents = append(newAgents, agent)
-		} else {
-			found = true
+	return s.mutate(beadID, func(beadCtx *SharedBeadContext) (*ActivityEntry, *ContextUpdate, error) {
+		// Remove agent
+		newAgents := []string{}
+		found := false
+		for _, agent := range beadCtx.CollaboratingAgents {
+			if agent != agentID {
+				newAgents = append(newAgents, agent)
+			} else {
+				found = true
+			}
 		}
-	}
-
-	if !found {
-		return nil // Not in context
-	}
 
-	beadCtx.CollaboratingAgents = newAgents
-	beadCtx.Version++
-	beadCtx.LastUpdated = time.Now()
-	beadCtx.LastUpdatedBy = agentID
-
-	// Add activity log
-	beadCtx.ActivityLog = append(beadCtx.ActivityLog, ActivityEntry{
-		Timestamp:    time.Now(),
-		AgentID:      agentID,
-		ActivityType: "left",
-		Description:  fmt.Sprintf("Agent %s left collaboration", agentID),
-	})
+		if !found {
+			return nil, nil, nil // Not in context
+		}
 
-	// Notify listeners
-	s.notifyUpdate(ContextUpdate{
-		BeadID:     beadID,
-		UpdateType: "left",
-		AgentID:    agentID,
-		Timestamp:  time.Now(),
-		Version:    beadCtx.Version,
+		beadCtx.CollaboratingAgents = newAgents
+		beadCtx.Version++
+		beadCtx.LastUpdated = time.Now()
+		beadCtx.LastUpdatedBy = agentID
+
+		// Add activity log
+		entry := beadCtx.appendActivity(ActivityEntry{
+			Timestamp:    time.Now(),
+			AgentID:      agentID,
+			ActivityType: "left",
+			Description:  fmt.Sprintf("Agent %s left collaboration", agentID),
+		})
+
+		return entry, &ContextUpdate{
+			BeadID:     beadID,
+			UpdateType: "left",
+			AgentID:    agentID,
+			Timestamp:  time.Now(),
+			Version:    beadCtx.Version,
+		}, nil
 	})
-
-	return nil
 }
 
 // UpdateData updates the shared data with optimistic locking
 func (s *ContextStore) UpdateData(ctx context.Context, beadID, agentID string, key string, value interface{}, expectedVersion int64) error {
-	s.mu.Lock()
-	beadCtx, exists := s.contexts[beadID]
-	if !exists {
-		s.mu.Unlock()
-		return fmt.Errorf("context not found for bead: %s", beadID)
-	}
-	s.mu.Unlock()
-
-	beadCtx.mu.Lock()
-	defer beadCtx.mu.Unlock()
-
-	// Check version for conflict detection
-	if expectedVersion > 0 && beadCtx.Version != expectedVersion {
-		return &ConflictError{
-			BeadID:          beadID,
-			ExpectedVersion: expectedVersion,
-			ActualVersion:   beadCtx.Version,
+	return s.mutate(beadID, func(beadCtx *SharedBeadContext) (*ActivityEntry, *ContextUpdate, error) {
+		// Check version for conflict detection
+		if expectedVersion > 0 && beadCtx.Version != expectedVersion {
+			return nil, nil, &ConflictError{
+				BeadID:          beadID,
+				ExpectedVersion: expectedVersion,
+				ActualVersion:   beadCtx.Version,
+			}
 		}
-	}
 
-	// Update data
-	beadCtx.Data[key] = value
-	beadCtx.Version++
-	beadCtx.LastUpdated = time.Now()
-	beadCtx.LastUpdatedBy = agentID
-
-	// Add activity log
-	beadCtx.ActivityLog = append(beadCtx.ActivityLog, ActivityEntry{
-		Timestamp:    time.Now(),
-		AgentID:      agentID,
-		ActivityType: "updated",
-		Description:  fmt.Sprintf("Agent %s updated '%s'", agentID, key),
-		Data: map[string]interface{}{
-			"key":   key,
-			"value": value,
-		},
+		// Update data
+		beadCtx.Data[key] = value
+		beadCtx.Version++
+		beadCtx.LastUpdated = time.Now()
+		beadCtx.LastUpdatedBy = agentID
+
+		// Add activity log
+		entry := beadCtx.appendActivity(ActivityEntry{
+			Timestamp:    time.Now(),
+			AgentID:      agentID,
+			ActivityType: "updated",
+			Description:  fmt.Sprintf("Agent %s updated '%s'", agentID, key),
+			Data: map[string]interface{}{
+				"key":   key,
+				"value": value,
+			},
+		})
+
+		return entry, &ContextUpdate{
+			BeadID:     beadID,
+			UpdateType: "data_changed",
+			AgentID:    agentID,
+			Data: map[string]interface{}{
+				"key":   key,
+				"value": value,
+			},
+			Timestamp: time.Now(),
+			Version:   beadCtx.Version,
+		}, nil
 	})
-
-	// Notify listeners
-	s.notifyUpdate(ContextUpdate{
-		BeadID:     beadID,
-		UpdateType: "data_changed",
-		AgentID:    agentID,
-		Data: map[string]interface{}{
-			"key":   key,
-			"value": value,
-		},
-		Timestamp: time.Now(),
-		Version:   beadCtx.Version,
-	})
-
-	return nil
 }
 
 // AddActivity adds an activity entry to the log
 func (s *ContextStore) AddActivity(ctx context.Context, beadID, agentID, activityType, description string, data map[string]interface{}) error {
-	s.mu.Lock()
-	beadCtx, exists := s.contexts[beadID]
-	if !exists {
-		s.mu.Unlock()
-		return fmt.Errorf("context not found for bead: %s", beadID)
-	}
-	s.mu.Unlock()
-
-	beadCtx.mu.Lock()
-	defer beadCtx.mu.Unlock()
+	return s.mutate(beadID, func(beadCtx *SharedBeadContext) (*ActivityEntry, *ContextUpdate, error) {
+		entry := beadCtx.appendActivity(ActivityEntry{
+			Timestamp:    time.Now(),
+			AgentID:      agentID,
+			ActivityType: activityType,
+			Description:  description,
+			Data:         data,
+		})
+		beadCtx.Version++
+		beadCtx.LastUpdated = time.Now()
+
+		return entry, &ContextUpdate{
+			BeadID:     beadID,
+			UpdateType: "activity",
+			AgentID:    agentID,
+			Data: map[string]interface{}{
+				"activity_type": activityType,
+				"description":   description,
+				"data":          data,
+				"seq":           entry.Seq,
+			},
+			Timestamp: time.Now(),
+			Version:   beadCtx.Version,
+		}, nil
+	})
+}
 
-	entry := ActivityEntry{
-		Timestamp:    time.Now(),
-		AgentID:      agentID,
-		ActivityType: activityType,
-		Description:  description,
-		Data:         data,
+// PublishEvent notifies bead listeners of an ephemeral event (for example,
+// streaming command output) without recording it in the activity log or
+// bumping the context version.
+func (s *ContextStore) PublishEvent(ctx context.Context, beadID, agentID, updateType string, data map[string]interface{}) {
+	s.mu.RLock()
+	beadCtx := s.contexts[beadID]
+	s.mu.RUnlock()
+
+	var version int64
+	if beadCtx != nil {
+		beadCtx.mu.RLock()
+		version = beadCtx.Version
+		beadCtx.mu.RUnlock()
 	}
 
-	beadCtx.ActivityLog = append(beadCtx.ActivityLog, entry)
-	beadCtx.Version++
-	beadCtx.LastUpdated = time.Now()
-
-	// Notify listeners
 	s.notifyUpdate(ContextUpdate{
 		BeadID:     beadID,
-		UpdateType: "activity",
+		UpdateType: updateType,
 		AgentID:    agentID,
-		Data: map[string]interface{}{
-			"activity_type": activityType,
-			"description":   description,
-			"data":          data,
-		},
-		Timestamp: time.Now(),
-		Version:   beadCtx.Version,
+		Data:       data,
+		Timestamp:  time.Now(),
+		Version:    version,
 	})
-
-	return nil
-}
-
-// Subscribe creates a listener channel for real-time updates
-func (s *ContextStore) Subscribe(beadID string) chan ContextUpdate {
-	s.listenerMu.Lock()
-	defer s.listenerMu.Unlock()
-
-	ch := make(chan ContextUpdate, 100)
-	s.listeners[beadID] = append(s.listeners[beadID], ch)
-
-	return ch
-}
-
-// Unsubscribe removes a listener channel
-func (s *ContextStore) Unsubscribe(beadID string, ch chan ContextUpdate) {
-	s.listenerMu.Lock()
-	defer s.listenerMu.Unlock()
-
-	listeners := s.listeners[beadID]
-	newListeners := []chan ContextUpdate{}
-
-	for _, listener := range listeners {
-		if listener != ch {
-			newListeners = append(newListeners, listener)
-		} else {
-			close(listener)
-		}
-	}
-
-	s.listeners[beadID] = newListeners
 }
 
 // notifyUpdate sends update to listeners (must be called without holding locks)
@@ -344,23 +304,18 @@ func (s *ContextStore) notifyUpdate(update ContextUpdate) {
 	case s.updates <- update:
 	default:
 		// Update channel full, drop update
+		s.metrics.ContextUpdatesDropped.Inc()
 	}
 }
 
-// distributeUpdates distributes updates to subscribed listeners
+// distributeUpdates numbers and buffers updates in the order they were
+// published, then hands each to its bead's fan-out goroutine. An update is
+// buffered before any listener sees it, so a stream subscribed before
+// reading the buffer misses nothing.
 func (s *ContextStore) distributeUpdates() {
 	for update := range s.updates {
-		s.listenerMu.RLock()
-		listeners := s.listeners[update.BeadID]
-		s.listenerMu.RUnlock()
-
-		for _, ch := range listeners {
-			select {
-			case ch <- update:
-			default:
-				// Listener channel full, skip
-			}
-		}
+		s.record(&update)
+		s.dispatch(update)
 	}
 }
 
@@ -379,16 +334,7 @@ func (s *ContextStore) ExportContext(ctx context.Context, beadID string) ([]byte
 
 // Close shuts down the context store
 func (s *ContextStore) Close() {
+	close(s.done)
 	close(s.updates)
-
-	s.listenerMu.Lock()
-	defer s.listenerMu.Unlock()
-
-	for _, listeners := range s.listeners {
-		for _, ch := range listeners {
-			close(ch)
-		}
-	}
-
-	s.listeners = make(map[string][]chan ContextUpdate)
+	s.closeSubscribers()
 }
@@ -362,3 +362,29 @@ func TestConcurrentAccess(t *testing.T) {
 	// Activity log should have entries
 	assert.Greater(t, len(beadCtx.ActivityLog), numAgents)
 }
+
+func TestPublishEvent(t *testing.T) {
+	store := NewContextStore()
+	defer store.Close()
+
+	ctx := context.Background()
+	beadCtx, _ := store.GetOrCreate(ctx, "bead-1", "project-1")
+
+	updateChan := store.Subscribe("bead-1")
+	defer store.Unsubscribe("bead-1", updateChan)
+
+	store.PublishEvent(ctx, "bead-1", "agent-1", "action_progress", map[string]interface{}{"chunk": "ok\n"})
+
+	select {
+	case update := <-updateChan:
+		assert.Equal(t, "action_progress", update.UpdateType)
+		assert.Equal(t, "ok\n", update.Data["chunk"])
+		assert.Equal(t, int64(1), update.Version)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for update")
+	}
+
+	// Ephemeral events are not persisted and do not bump the version
+	assert.Empty(t, beadCtx.ActivityLog)
+	assert.Equal(t, int64(1), beadCtx.Version)
+}
@@ -0,0 +1,324 @@
+package collaboration
+
+import (
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+// Shared editing buffers are RGA (replicated growable array) sequences of
+// characters. Every inserted character has a unique ID made of a Lamport
+// clock and the ID of the site (agent or browser tab) that inserted it, and
+// is placed after the character it was typed after; concurrent inserts at
+// the same place are ordered by ID, so every replica that has seen the same
+// operations holds the same text whatever order they arrived in. Deleted
+// characters stay behind as tombstones so later operations can still refer
+// to them.
+
+// Operation types
+const (
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// OpID identifies an inserted character
+type OpID struct {
+	Clock uint64 `json:"clock"`
+	Site  string `json:"site"`
+}
+
+// IsZero reports whether id is the document start
+func (id OpID) IsZero() bool {
+	return id.Clock == 0 && id.Site == ""
+}
+
+// after reports whether id sorts after other when both were inserted at the
+// same place
+func (id OpID) after(other OpID) bool {
+	if id.Clock != other.Clock {
+		return id.Clock > other.Clock
+	}
+	return id.Site > other.Site
+}
+
+func (id OpID) String() string {
+	return fmt.Sprintf("%d@%s", id.Clock, id.Site)
+}
+
+// Op is a shared editing operation. An insert puts Text after the character
+// After (the zero ID for the document start); its characters get IDs
+// ID.Clock, ID.Clock+1, ... at ID.Site. A delete removes the character ID.
+type Op struct {
+	Type  string `json:"type"`
+	ID    OpID   `json:"id"`
+	After OpID   `json:"after,omitempty"`
+	Text  string `json:"text,omitempty"`
+}
+
+type element struct {
+	id      OpID
+	char    rune
+	deleted bool
+}
+
+// Document is a shared editing buffer for one file
+type Document struct {
+	ProjectID string
+	Path      string
+
+	mu      sync.RWMutex
+	elems   []*element // document order, tombstones included
+	ids     map[OpID]*element
+	clock   uint64
+	log     []Op // applied operations, in the order applied
+	pending []Op // operations waiting for the characters they refer to
+}
+
+// NewDocument creates a document holding content, inserted by site
+func NewDocument(projectID, path, site, content string) *Document {
+	d := &Document{ProjectID: projectID, Path: path, ids: make(map[OpID]*element)}
+	if content != "" {
+		_, _ = d.Insert(site, 0, content)
+	}
+	return d
+}
+
+// Text returns the document's current text
+func (d *Document) Text() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.text()
+}
+
+func (d *Document) text() string {
+	buf := make([]rune, 0, len(d.elems))
+	for _, e := range d.elems {
+		if !e.deleted {
+			buf = append(buf, e.char)
+		}
+	}
+	return string(buf)
+}
+
+// Version is the number of operations applied so far
+func (d *Document) Version() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.log)
+}
+
+// OpsSince returns the operations applied after version, which replayed in
+// order on a replica at version bring it up to date
+func (d *Document) OpsSince(version int) []Op {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if version < 0 {
+		version = 0
+	}
+	if version >= len(d.log) {
+		return nil
+	}
+	return append([]Op(nil), d.log[version:]...)
+}
+
+// state returns the text, version and operations after since together, so
+// they agree; a negative since leaves the operations out
+func (d *Document) state(since int) (string, int, []Op) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var ops []Op
+	if since >= 0 && since < len(d.log) {
+		ops = append([]Op(nil), d.log[since:]...)
+	}
+	return d.text(), len(d.log), ops
+}
+
+// Apply integrates operations from a replica and returns the ones that were
+// new. Operations already seen are ignored, and ones referring to
+// characters not seen yet are held until those arrive.
+func (d *Document) Apply(ops []Op) ([]Op, error) {
+	for _, op := range ops {
+		if err := validateOp(op); err != nil {
+			return nil, err
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	start := len(d.log)
+	d.pending = append(d.pending, ops...)
+	for progress := true; progress; {
+		progress = false
+		waiting := d.pending[:0]
+		for _, op := range d.pending {
+			switch d.integrate(op) {
+			case opApplied:
+				d.log = append(d.log, op)
+				progress = true
+			case opWaiting:
+				waiting = append(waiting, op)
+			}
+		}
+		d.pending = waiting
+	}
+	return append([]Op(nil), d.log[start:]...), nil
+}
+
+// Insert inserts text at a character offset of the current text on behalf
+// of site and returns the operation to send to other replicas
+func (d *Document) Insert(site string, pos int, text string) (Op, error) {
+	if site == "" {
+		return Op{}, fmt.Errorf("site is required")
+	}
+	if text == "" || !utf8.ValidString(text) {
+		return Op{}, fmt.Errorf("insert text must be non-empty UTF-8")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var after OpID
+	if pos > 0 {
+		e := d.visible(pos - 1)
+		if e == nil {
+			return Op{}, fmt.Errorf("position %d is past the end of %s", pos, d.Path)
+		}
+		after = e.id
+	} else if pos < 0 {
+		return Op{}, fmt.Errorf("position must not be negative")
+	}
+	op := Op{Type: OpInsert, ID: OpID{Clock: d.clock + 1, Site: site}, After: after, Text: text}
+	d.integrate(op)
+	d.log = append(d.log, op)
+	return op, nil
+}
+
+// Delete removes count characters starting at a character offset of the
+// current text and returns the operations to send to other replicas
+func (d *Document) Delete(pos, count int) ([]Op, error) {
+	if pos < 0 || count < 0 {
+		return nil, fmt.Errorf("position and count must not be negative")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var targets []*element
+	seen := 0
+	for _, e := range d.elems {
+		if e.deleted {
+			continue
+		}
+		if seen >= pos && len(targets) < count {
+			targets = append(targets, e)
+		}
+		seen++
+	}
+	if len(targets) < count {
+		return nil, fmt.Errorf("range %d+%d is past the end of %s", pos, count, d.Path)
+	}
+	ops := make([]Op, 0, len(targets))
+	for _, e := range targets {
+		e.deleted = true
+		op := Op{Type: OpDelete, ID: e.id}
+		d.log = append(d.log, op)
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// visible returns the element holding the character at offset pos
+func (d *Document) visible(pos int) *element {
+	seen := 0
+	for _, e := range d.elems {
+		if e.deleted {
+			continue
+		}
+		if seen == pos {
+			return e
+		}
+		seen++
+	}
+	return nil
+}
+
+type integration int
+
+const (
+	opApplied integration = iota
+	opDuplicate
+	opWaiting
+)
+
+// integrate applies one operation to the sequence
+func (d *Document) integrate(op Op) integration {
+	if op.Type == OpDelete {
+		e, ok := d.ids[op.ID]
+		switch {
+		case !ok:
+			return opWaiting
+		case e.deleted:
+			return opDuplicate
+		}
+		e.deleted = true
+		return opApplied
+	}
+
+	if _, ok := d.ids[op.ID]; ok {
+		return opDuplicate
+	}
+	pos := 0
+	if !op.After.IsZero() {
+		ref, ok := d.ids[op.After]
+		if !ok {
+			return opWaiting
+		}
+		pos = d.indexOf(ref) + 1
+	}
+	clock := op.ID.Clock
+	for _, r := range op.Text {
+		id := OpID{Clock: clock, Site: op.ID.Site}
+		// Characters inserted concurrently at the same place with larger
+		// IDs, and everything inserted after them, go first
+		for pos < len(d.elems) && d.elems[pos].id.after(id) {
+			pos++
+		}
+		e := &element{id: id, char: r}
+		d.elems = append(d.elems, nil)
+		copy(d.elems[pos+1:], d.elems[pos:])
+		d.elems[pos] = e
+		d.ids[id] = e
+		pos++
+		clock++
+	}
+	if clock-1 > d.clock {
+		d.clock = clock - 1
+	}
+	return opApplied
+}
+
+func (d *Document) indexOf(e *element) int {
+	for i, el := range d.elems {
+		if el == e {
+			return i
+		}
+	}
+	return -1
+}
+
+func validateOp(op Op) error {
+	switch op.Type {
+	case OpInsert:
+		if op.ID.Clock == 0 || op.ID.Site == "" {
+			return fmt.Errorf("insert needs an id with a clock and site")
+		}
+		if op.Text == "" || !utf8.ValidString(op.Text) {
+			return fmt.Errorf("insert %s needs non-empty UTF-8 text", op.ID)
+		}
+	case OpDelete:
+		if op.ID.Clock == 0 || op.ID.Site == "" {
+			return fmt.Errorf("delete needs the id of a character")
+		}
+	default:
+		return fmt.Errorf("unknown operation type %q", op.Type)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package collaboration
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDocument_ConcurrentInserts(t *testing.T) {
+	alice := NewDocument("p1", "a.txt", "file", "ac")
+	bob := NewDocument("p1", "a.txt", "file", "ac")
+
+	// Both insert between a and c without seeing each other
+	opA, err := alice.Insert("alice", 1, "XX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opB, err := bob.Insert("bob", 1, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alice.Apply([]Op{opB}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bob.Apply([]Op{opA}); err != nil {
+		t.Fatal(err)
+	}
+	if alice.Text() != bob.Text() {
+		t.Fatalf("replicas diverged: %q vs %q", alice.Text(), bob.Text())
+	}
+	if got := alice.Text(); got != "abXXc" && got != "aXXbc" {
+		t.Errorf("expected both inserts to survive, got %q", got)
+	}
+
+	// Re-delivered operations are ignored
+	if applied, _ := alice.Apply([]Op{opB}); len(applied) != 0 {
+		t.Errorf("expected a duplicate to be ignored, got %v", applied)
+	}
+}
+
+func TestDocument_DeleteAndInsertConcurrently(t *testing.T) {
+	alice := NewDocument("p1", "a.txt", "file", "hello world")
+	bob := NewDocument("p1", "a.txt", "file", "hello world")
+
+	dels, err := alice.Delete(0, 6) // "hello "
+	if err != nil {
+		t.Fatal(err)
+	}
+	ins, err := bob.Insert("bob", 5, ",")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = alice.Apply([]Op{ins})
+	_, _ = bob.Apply(dels)
+	if alice.Text() != ",world" || bob.Text() != ",world" {
+		t.Errorf("expected the concurrent insert to survive the delete, got %q and %q", alice.Text(), bob.Text())
+	}
+
+	if _, err := alice.Delete(2, 5); err == nil {
+		t.Error("expected a range past the end to be rejected")
+	}
+	if _, err := alice.Apply([]Op{{Type: "move"}}); err == nil {
+		t.Error("expected an unknown operation to be rejected")
+	}
+}
+
+func TestDocument_OutOfOrderDelivery(t *testing.T) {
+	src := NewDocument("p1", "a.txt", "file", "")
+	var ops []Op
+	op, _ := src.Insert("alice", 0, "abc")
+	ops = append(ops, op)
+	op, _ = src.Insert("alice", 3, "def")
+	ops = append(ops, op)
+	del, _ := src.Delete(1, 1)
+	ops = append(ops, del...)
+
+	// The replica receives the operations newest first; each waits for the
+	// characters it refers to
+	replica := NewDocument("p1", "a.txt", "file", "")
+	for i := len(ops) - 1; i >= 0; i-- {
+		if _, err := replica.Apply([]Op{ops[i]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if replica.Text() != src.Text() || src.Text() != "acdef" {
+		t.Errorf("expected %q, got %q", src.Text(), replica.Text())
+	}
+}
+
+func TestDocument_Convergence(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		checkConvergence(t, rand.New(rand.NewSource(seed)))
+	}
+}
+
+func checkConvergence(t *testing.T, rng *rand.Rand) {
+	t.Helper()
+	sites := []string{"a", "b", "c"}
+	replicas := make([]*Document, len(sites))
+	for i := range replicas {
+		replicas[i] = NewDocument("p1", "f.go", "file", "package main\n")
+	}
+
+	// Each site edits its own replica, then every replica receives every
+	// other site's operations in a shuffled order
+	var all [][]Op
+	for round := 0; round < 20; round++ {
+		for i, site := range sites {
+			doc := replicas[i]
+			n := len([]rune(doc.Text()))
+			if n > 0 && rng.Intn(3) == 0 {
+				pos := rng.Intn(n)
+				ops, err := doc.Delete(pos, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				all = append(all, ops)
+				continue
+			}
+			op, err := doc.Insert(site, rng.Intn(n+1), string(rune('a'+rng.Intn(26))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			all = append(all, []Op{op})
+		}
+	}
+	for _, doc := range replicas {
+		order := rng.Perm(len(all))
+		for _, i := range order {
+			if _, err := doc.Apply(all[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for i := 1; i < len(replicas); i++ {
+		if replicas[i].Text() != replicas[0].Text() {
+			t.Fatalf("replica %d diverged: %q vs %q", i, replicas[i].Text(), replicas[0].Text())
+		}
+	}
+}
+
+func TestCoEditStore(t *testing.T) {
+	store := NewCoEditStore()
+	load := func() (string, error) { return "one\n", nil }
+	if _, err := store.Open("p1", "a.txt", "agent-1", load); err != nil {
+		t.Fatal(err)
+	}
+	ch, err := store.Subscribe("p1", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := store.Open("p1", "a.txt", "web-1", func() (string, error) {
+		t.Fatal("expected the open document to be reused")
+		return "", nil
+	})
+	if err != nil || len(snap.Sites) != 2 || snap.Text != "one\n" {
+		t.Fatalf("unexpected snapshot %+v, %v", snap, err)
+	}
+
+	update, err := store.Edit("p1", "a.txt", "agent-1", 0, 3, "two")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-ch; got.Site != "agent-1" || len(got.Ops) != 4 || got.Version != update.Version {
+		t.Errorf("unexpected broadcast %+v", got)
+	}
+	if text, _ := store.Text("p1", "a.txt"); text != "two\n" {
+		t.Errorf("expected the edit, got %q", text)
+	}
+
+	// A replica replaying the snapshot's operations gets the same text
+	snap, _ = store.Snapshot("p1", "a.txt", 0)
+	replica := NewDocument("p1", "a.txt", "file", "")
+	if _, err := replica.Apply(snap.Ops); err != nil || replica.Text() != snap.Text {
+		t.Errorf("replay gave %q, want %q (%v)", replica.Text(), snap.Text, err)
+	}
+
+	if _, last, _ := store.Close("p1", "a.txt", "agent-1"); last {
+		t.Error("expected web-1 to keep the document open")
+	}
+	text, last, err := store.Close("p1", "a.txt", "web-1")
+	if err != nil || !last || text != "two\n" {
+		t.Errorf("Close() = %q, %v, %v", text, last, err)
+	}
+	if _, ok := <-ch; ok {
+		t.Error("expected the listener to be closed with the document")
+	}
+	if len(store.List("")) != 0 {
+		t.Error("expected no open documents")
+	}
+}
@@ -0,0 +1,265 @@
+package collaboration
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const (
+	// subscriberShards is how many independently locked maps bead
+	// listeners are spread over
+	subscriberShards = 32
+	// beadQueueSize is how many updates can wait for a bead's fan-out
+	// goroutine before more are dropped
+	beadQueueSize = 1024
+	// listenerBuffer is how many updates a subscriber can fall behind
+	// before sends to it wait
+	listenerBuffer = 100
+	// defaultSendTimeout is how long updates wait for subscribers whose
+	// buffers are full before those subscribers are dropped
+	defaultSendTimeout = 100 * time.Millisecond
+)
+
+// subscriberShard holds the listeners of some of the beads. Handing an
+// update to a bead takes the read lock; adding or removing a bead or a
+// listener takes the write lock.
+type subscriberShard struct {
+	mu     sync.RWMutex
+	beads  map[string]*beadSubscribers
+	closed bool
+}
+
+// beadSubscribers is one bead's listeners and the queue its fan-out
+// goroutine sends to them from. The goroutine runs while the bead has
+// listeners, so a slow listener only holds up updates of its own bead.
+type beadSubscribers struct {
+	beadID    string
+	mu        sync.Mutex
+	listeners []*listener // Replaced, never modified, so senders can use a copy
+	queue     chan ContextUpdate
+}
+
+// listener is a subscriber's channel. Closing done makes a send waiting on
+// the channel give up, so a listener is closed without waiting out the send
+// timeout.
+type listener struct {
+	ch     chan ContextUpdate
+	done   chan struct{}
+	mu     sync.Mutex // Held while sending, so ch isn't closed mid-send
+	closed bool
+}
+
+// trySend delivers an update if the listener has room, reporting false when
+// it has none
+func (l *listener) trySend(update ContextUpdate) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return true
+	}
+	select {
+	case l.ch <- update:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendUntil delivers an update, waiting for room until expired fires or the
+// listener is closed. It reports false when expired fired first.
+func (l *listener) sendUntil(update ContextUpdate, expired <-chan time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return true
+	}
+	select {
+	case l.ch <- update:
+		return true
+	case <-l.done:
+		return true
+	case <-expired:
+		return false
+	}
+}
+
+// close closes the listener's channel once any send to it has given up.
+// It is called with the shard lock held, so only once per listener.
+func (l *listener) close() {
+	close(l.done)
+	l.mu.Lock()
+	l.closed = true
+	close(l.ch)
+	l.mu.Unlock()
+}
+
+func (s *ContextStore) shard(beadID string) *subscriberShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(beadID))
+	return &s.shards[h.Sum32()%subscriberShards]
+}
+
+// Subscribe creates a listener channel for real-time updates
+func (s *ContextStore) Subscribe(beadID string) chan ContextUpdate {
+	l := &listener{ch: make(chan ContextUpdate, listenerBuffer), done: make(chan struct{})}
+	sh := s.shard(beadID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.closed {
+		close(l.ch)
+		return l.ch
+	}
+
+	b := sh.beads[beadID]
+	if b == nil {
+		b = &beadSubscribers{beadID: beadID, queue: make(chan ContextUpdate, beadQueueSize)}
+		sh.beads[beadID] = b
+		go s.fanOut(sh, b)
+	}
+	b.mu.Lock()
+	b.listeners = append(b.listeners[:len(b.listeners):len(b.listeners)], l)
+	b.mu.Unlock()
+	s.metrics.ContextStreamSubscribers.Inc()
+
+	return l.ch
+}
+
+// Unsubscribe removes a listener channel and closes it. A listener already
+// dropped for falling behind is left alone.
+func (s *ContextStore) Unsubscribe(beadID string, ch chan ContextUpdate) {
+	sh := s.shard(beadID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	s.removeListenerLocked(sh, beadID, ch)
+}
+
+// removeListenerLocked removes and closes a listener if it is still
+// subscribed, stopping the bead's fan-out goroutine after its last
+// listener. sh.mu must be held for writing.
+func (s *ContextStore) removeListenerLocked(sh *subscriberShard, beadID string, ch chan ContextUpdate) bool {
+	b := sh.beads[beadID]
+	if b == nil {
+		return false
+	}
+
+	var removed *listener
+	b.mu.Lock()
+	for i, l := range b.listeners {
+		if l.ch == ch {
+			removed = l
+			b.listeners = append(b.listeners[:i:i], b.listeners[i+1:]...)
+			break
+		}
+	}
+	empty := len(b.listeners) == 0
+	b.mu.Unlock()
+
+	if removed != nil {
+		removed.close()
+		s.metrics.ContextStreamSubscribers.Dec()
+	}
+	if empty {
+		delete(sh.beads, beadID)
+		close(b.queue)
+	}
+	return removed != nil
+}
+
+// dispatch queues an update for its bead's fan-out goroutine without
+// waiting. Beads nobody listens to are skipped.
+func (s *ContextStore) dispatch(update ContextUpdate) {
+	sh := s.shard(update.BeadID)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	b := sh.beads[update.BeadID]
+	if b == nil {
+		return
+	}
+	select {
+	case b.queue <- update:
+	default:
+		s.metrics.ContextUpdatesDropped.Inc()
+	}
+}
+
+// fanOut sends a bead's updates to its listeners until the bead has none.
+// Listeners still full after the send timeout are dropped: their channels
+// close, and their streams end so the client reconnects and resumes from
+// the event buffer.
+func (s *ContextStore) fanOut(sh *subscriberShard, b *beadSubscribers) {
+	for update := range b.queue {
+		stalled := b.send(update, s.sendTimeout)
+		if len(stalled) == 0 {
+			continue
+		}
+		sh.mu.Lock()
+		if sh.beads[b.beadID] == b {
+			for _, l := range stalled {
+				if s.removeListenerLocked(sh, b.beadID, l.ch) {
+					s.metrics.ContextStreamSlowDisconnects.Inc()
+				}
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// send delivers an update to every listener and returns those whose
+// buffers stayed full. Listeners with room get it without waiting; full
+// ones share one timeout, so several stalled clients hold up the bead's
+// updates at most that long, once.
+func (b *beadSubscribers) send(update ContextUpdate, timeout time.Duration) (stalled []*listener) {
+	b.mu.Lock()
+	listeners := b.listeners
+	b.mu.Unlock()
+
+	var full []*listener
+	for _, l := range listeners {
+		if !l.trySend(update) {
+			full = append(full, l)
+		}
+	}
+	if len(full) == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	expired := false
+	for _, l := range full {
+		if !expired {
+			if l.sendUntil(update, timer.C) {
+				continue
+			}
+			expired = true
+		}
+		if !l.trySend(update) {
+			stalled = append(stalled, l)
+		}
+	}
+	return stalled
+}
+
+// closeSubscribers closes every listener and stops the fan-out goroutines.
+// Later subscriptions get a closed channel.
+func (s *ContextStore) closeSubscribers() {
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		for beadID, b := range sh.beads {
+			b.mu.Lock()
+			listeners := b.listeners
+			b.listeners = nil
+			b.mu.Unlock()
+			for _, l := range listeners {
+				l.close()
+				s.metrics.ContextStreamSubscribers.Dec()
+			}
+			close(b.queue)
+			delete(sh.beads, beadID)
+		}
+		sh.closed = true
+		sh.mu.Unlock()
+	}
+}
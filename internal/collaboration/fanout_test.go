@@ -0,0 +1,120 @@
+package collaboration
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextStore_StalledBeadDoesNotDelayOthers(t *testing.T) {
+	store := NewContextStore()
+	defer store.Close()
+	store.sendTimeout = time.Minute
+	start := time.Now()
+
+	// A full subscriber holds up its own bead's updates for the send timeout
+	stalled := store.Subscribe("bead-1")
+	publishEvents(t, store, "bead-1", listenerBuffer+5)
+
+	other := store.Subscribe("bead-2")
+	defer store.Unsubscribe("bead-2", other)
+	publishEvents(t, store, "bead-2", 1)
+	select {
+	case update := <-other:
+		assert.Equal(t, "bead-2", update.BeadID)
+	case <-time.After(2 * time.Second):
+		t.Fatal("update on bead-2 waited for bead-1's stalled subscriber")
+	}
+
+	// Unsubscribing cuts short the send waiting on the stalled subscriber
+	store.Unsubscribe("bead-1", stalled)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestContextStore_SubscribeAfterClose(t *testing.T) {
+	store := NewContextStore()
+	ch := store.Subscribe("bead-1")
+	store.Close()
+
+	_, open := <-ch
+	assert.False(t, open, "Close ends existing subscriptions")
+	_, open = <-store.Subscribe("bead-1")
+	assert.False(t, open, "subscriptions after Close are already closed")
+}
+
+func TestContextStore_ResubscribeRestartsFanOut(t *testing.T) {
+	store := NewContextStore()
+	defer store.Close()
+
+	// The bead's fan-out goroutine stops with its last listener
+	first := store.Subscribe("bead-1")
+	store.Unsubscribe("bead-1", first)
+	sh := store.shard("bead-1")
+	sh.mu.RLock()
+	require.Nil(t, sh.beads["bead-1"])
+	sh.mu.RUnlock()
+
+	second := store.Subscribe("bead-1")
+	defer store.Unsubscribe("bead-1", second)
+	publishEvents(t, store, "bead-1", 1)
+	select {
+	case update := <-second:
+		assert.Equal(t, int64(1), update.EventID)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update after resubscribing")
+	}
+}
+
+// BenchmarkContextStoreBroadcast publishes one update per op and waits
+// until every subscriber has it
+func BenchmarkContextStoreBroadcast(b *testing.B) {
+	for _, bc := range []struct {
+		subscribers, beads int
+	}{
+		{1, 1},
+		{100, 1},
+		{1000, 1},
+		{1000, 100},
+	} {
+		b.Run(fmt.Sprintf("subscribers=%d/beads=%d", bc.subscribers, bc.beads), func(b *testing.B) {
+			store := NewContextStore()
+
+			var delivered atomic.Int64
+			var wg sync.WaitGroup
+			for i := 0; i < bc.subscribers; i++ {
+				ch := store.Subscribe(fmt.Sprintf("bead-%d", i%bc.beads))
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range ch {
+						delivered.Add(1)
+					}
+				}()
+			}
+			perBead := int64(bc.subscribers / bc.beads)
+
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				want := delivered.Load() + perBead
+				store.PublishEvent(ctx, fmt.Sprintf("bead-%d", i%bc.beads), "agent-1", "action_progress", nil)
+				for delivered.Load() < want {
+					// Yield so subscribers run even on a single CPU
+					runtime.Gosched()
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(delivered.Load())/b.Elapsed().Seconds(), "deliveries/s")
+
+			store.Close()
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package collaboration
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrContextStale is returned by a Store when the stored context is not at
+// the version the write was based on, because another instance changed it
+var ErrContextStale = errors.New("bead context changed since it was loaded")
+
+// Store persists bead contexts and their activity so they survive restarts
+type Store interface {
+	// SaveBeadContext writes rec over the stored context at prevVersion (0
+	// for a context not stored yet) and appends activity, when not nil, to
+	// its log. It returns ErrContextStale when the stored version differs.
+	SaveBeadContext(rec *ContextRecord, prevVersion int64, activity *ActivityEntry) error
+	// LoadBeadContext returns a stored context with its activity log, or
+	// nil when the bead has none
+	LoadBeadContext(beadID string) (*ContextRecord, error)
+}
+
+// ContextRecord is the persisted state of a bead context
+type ContextRecord struct {
+	BeadID              string                 `json:"bead_id"`
+	ProjectID           string                 `json:"project_id"`
+	CollaboratingAgents []string               `json:"collaborating_agents"`
+	Data                map[string]interface{} `json:"data"`
+	ActivityLog         []ActivityEntry        `json:"activity_log,omitempty"`
+	Version             int64                  `json:"version"`
+	LastUpdated         time.Time              `json:"last_updated"`
+	LastUpdatedBy       string                 `json:"last_updated_by"`
+}
+
+// SetStore enables persistence. Contexts are then written through to the
+// store on every change and loaded from it on first access, and the
+// in-memory contexts act as a cache.
+func (s *ContextStore) SetStore(store Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = store
+}
+
+// Invalidate drops a bead's cached context so the next access reloads it
+// from the store. Without a store the context is simply forgotten.
+func (s *ContextStore) Invalidate(beadID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.contexts, beadID)
+}
+
+// lookup returns a bead's context from the cache, loading it from the store
+// on a miss; it returns nil when the bead has no context
+func (s *ContextStore) lookup(beadID string) (*SharedBeadContext, error) {
+	s.mu.RLock()
+	beadCtx, ok := s.contexts[beadID]
+	store := s.store
+	s.mu.RUnlock()
+	if ok || store == nil {
+		return beadCtx, nil
+	}
+
+	rec, err := store.LoadBeadContext(beadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load context for bead %s: %w", beadID, err)
+	}
+	if rec == nil {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Another caller may have loaded it meanwhile
+	if existing, ok := s.contexts[beadID]; ok {
+		return existing, nil
+	}
+	beadCtx = contextFromRecord(rec)
+	s.contexts[beadID] = beadCtx
+	return beadCtx, nil
+}
+
+// mutate applies change to a bead's context under its lock and writes the
+// result through to the store before telling listeners. change returns the
+// activity to record and the update to send, or a nil update when nothing
+// changed. A failed write drops the cached context, which the store still
+// holds the previous state of; a stale one is reported as a ConflictError.
+func (s *ContextStore) mutate(beadID string, change func(c *SharedBeadContext) (*ActivityEntry, *ContextUpdate, error)) error {
+	beadCtx, err := s.lookup(beadID)
+	if err != nil {
+		return err
+	}
+	if beadCtx == nil {
+		return fmt.Errorf("context not found for bead: %s", beadID)
+	}
+
+	beadCtx.mu.Lock()
+	prevVersion := beadCtx.Version
+	activity, update, err := change(beadCtx)
+	if err != nil || update == nil {
+		beadCtx.mu.Unlock()
+		return err
+	}
+	err = s.persist(beadCtx, prevVersion, activity)
+	beadCtx.mu.Unlock()
+
+	if err != nil {
+		s.Invalidate(beadID)
+		if errors.Is(err, ErrContextStale) {
+			conflict := &ConflictError{BeadID: beadID, ExpectedVersion: prevVersion}
+			if fresh, lerr := s.lookup(beadID); lerr == nil && fresh != nil {
+				fresh.mu.RLock()
+				conflict.ActualVersion = fresh.Version
+				fresh.mu.RUnlock()
+			}
+			return conflict
+		}
+		return fmt.Errorf("failed to persist context for bead %s: %w", beadID, err)
+	}
+	s.notifyUpdate(*update)
+	return nil
+}
+
+// persist writes beadCtx (whose lock is held) to the store, if there is one
+func (s *ContextStore) persist(beadCtx *SharedBeadContext, prevVersion int64, activity *ActivityEntry) error {
+	s.mu.RLock()
+	store := s.store
+	s.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+	return store.SaveBeadContext(&ContextRecord{
+		BeadID:              beadCtx.BeadID,
+		ProjectID:           beadCtx.ProjectID,
+		CollaboratingAgents: beadCtx.CollaboratingAgents,
+		Data:                beadCtx.Data,
+		Version:             beadCtx.Version,
+		LastUpdated:         beadCtx.LastUpdated,
+		LastUpdatedBy:       beadCtx.LastUpdatedBy,
+	}, prevVersion, activity)
+}
+
+func contextFromRecord(rec *ContextRecord) *SharedBeadContext {
+	c := &SharedBeadContext{
+		BeadID:              rec.BeadID,
+		ProjectID:           rec.ProjectID,
+		CollaboratingAgents: rec.CollaboratingAgents,
+		Data:                rec.Data,
+		ActivityLog:         rec.ActivityLog,
+		Version:             rec.Version,
+		LastUpdated:         rec.LastUpdated,
+		LastUpdatedBy:       rec.LastUpdatedBy,
+	}
+	if c.CollaboratingAgents == nil {
+		c.CollaboratingAgents = []string{}
+	}
+	if c.Data == nil {
+		c.Data = make(map[string]interface{})
+	}
+	if c.ActivityLog == nil {
+		c.ActivityLog = []ActivityEntry{}
+	}
+	for i := range c.ActivityLog {
+		c.ActivityLog[i].Seq = int64(i) + 1
+	}
+	return c
+}
+
+// createContext stores and caches a new context for a bead, or returns the
+// one another caller or instance created first
+func (s *ContextStore) createContext(beadID, projectID string) (*SharedBeadContext, error) {
+	newCtx := &SharedBeadContext{
+		BeadID:              beadID,
+		ProjectID:           projectID,
+		CollaboratingAgents: []string{},
+		Data:                make(map[string]interface{}),
+		ActivityLog:         []ActivityEntry{},
+		Version:             1,
+		LastUpdated:         time.Now(),
+	}
+	if err := s.persist(newCtx, 0, nil); err != nil {
+		if errors.Is(err, ErrContextStale) {
+			if existing, lerr := s.lookup(beadID); lerr == nil && existing != nil {
+				return existing, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to persist context for bead %s: %w", beadID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.contexts[beadID]; ok {
+		return existing, nil
+	}
+	s.contexts[beadID] = newCtx
+	return newCtx, nil
+}
@@ -0,0 +1,115 @@
+package collaboration
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// memoryStore is a Store keeping records in memory, as a database would
+type memoryStore struct {
+	mu         sync.Mutex
+	records    map[string]ContextRecord
+	activities map[string][]ActivityEntry
+	fail       error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]ContextRecord), activities: make(map[string][]ActivityEntry)}
+}
+
+func (m *memoryStore) SaveBeadContext(rec *ContextRecord, prevVersion int64, activity *ActivityEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail != nil {
+		return m.fail
+	}
+	if stored, ok := m.records[rec.BeadID]; ok != (prevVersion != 0) || (ok && stored.Version != prevVersion) {
+		return ErrContextStale
+	}
+	saved := *rec
+	saved.CollaboratingAgents = append([]string(nil), rec.CollaboratingAgents...)
+	saved.Data = make(map[string]interface{}, len(rec.Data))
+	for k, v := range rec.Data {
+		saved.Data[k] = v
+	}
+	m.records[rec.BeadID] = saved
+	if activity != nil {
+		m.activities[rec.BeadID] = append(m.activities[rec.BeadID], *activity)
+	}
+	return nil
+}
+
+func (m *memoryStore) LoadBeadContext(beadID string) (*ContextRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.records[beadID]
+	if !ok {
+		return nil, nil
+	}
+	rec.ActivityLog = append([]ActivityEntry(nil), m.activities[beadID]...)
+	return &rec, nil
+}
+
+func TestContextStore_Persistence(t *testing.T) {
+	ctx := context.Background()
+	backing := newMemoryStore()
+
+	store := NewContextStore()
+	store.SetStore(backing)
+	if _, err := store.GetOrCreate(ctx, "bead-1", "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.JoinBead(ctx, "bead-1", "agent-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateData(ctx, "bead-1", "agent-1", "plan", "refactor", 2); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// A restarted store loads the context on first access
+	restarted := NewContextStore()
+	defer restarted.Close()
+	restarted.SetStore(backing)
+	beadCtx, err := restarted.Get(ctx, "bead-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if beadCtx.Version != 3 || beadCtx.Data["plan"] != "refactor" || len(beadCtx.ActivityLog) != 2 ||
+		beadCtx.CollaboratingAgents[0] != "agent-1" || beadCtx.ActivityLog[1].Seq != 2 {
+		t.Fatalf("unexpected recovered context %+v", beadCtx)
+	}
+	if _, err := restarted.Get(ctx, "bead-2"); err == nil {
+		t.Error("expected an unknown bead to have no context")
+	}
+
+	// Another instance moves the stored context on; the cached copy is
+	// stale, so the write conflicts and the cache reloads
+	rec, _ := backing.LoadBeadContext("bead-1")
+	rec.Version = 5
+	backing.records["bead-1"] = *rec
+	err = restarted.AddActivity(ctx, "bead-1", "agent-1", "note", "hello", nil)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.ActualVersion != 5 {
+		t.Fatalf("expected a conflict at version 5, got %v", err)
+	}
+	if err := restarted.AddActivity(ctx, "bead-1", "agent-1", "note", "hello", nil); err != nil {
+		t.Fatalf("expected the retry on the reloaded context to succeed: %v", err)
+	}
+	if rec, _ := backing.LoadBeadContext("bead-1"); rec.Version != 6 || len(rec.ActivityLog) != 3 {
+		t.Errorf("unexpected stored context %+v", rec)
+	}
+
+	// A failed write leaves the store as it was and drops the cached copy
+	backing.fail = errors.New("disk full")
+	if err := restarted.JoinBead(ctx, "bead-1", "agent-2"); err == nil {
+		t.Fatal("expected the write failure to be returned")
+	}
+	backing.fail = nil
+	beadCtx, _ = restarted.Get(ctx, "bead-1")
+	if len(beadCtx.CollaboratingAgents) != 1 {
+		t.Errorf("expected the failed join to be dropped, got %v", beadCtx.CollaboratingAgents)
+	}
+}
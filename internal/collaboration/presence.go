@@ -0,0 +1,205 @@
+package collaboration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Presence states
+const (
+	PresenceActive = "active" // working on the bead
+	PresenceTyping = "typing" // editing a file
+	PresenceIdle   = "idle"   // connected but not doing anything
+)
+
+// DefaultPresenceTTL is how long a presence heartbeat lasts
+const DefaultPresenceTTL = 2 * time.Minute
+
+// Presence is what an agent (or a person in the UI) working on a bead is
+// doing, as of its last heartbeat
+type Presence struct {
+	AgentID   string    `json:"agent_id"`
+	Status    string    `json:"status"`
+	File      string    `json:"file,omitempty"`  // File they're focused on
+	Phase     string    `json:"phase,omitempty"` // Workflow phase or action type
+	LastSeen  time.Time `json:"last_seen"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// same reports whether two presences show the same thing to observers
+func (p *Presence) same(other *Presence) bool {
+	return p.Status == other.Status && p.File == other.File && p.Phase == other.Phase
+}
+
+// SetPresenceTTL sets how long presence heartbeats last (DefaultPresenceTTL
+// when ttl is not positive)
+func (s *ContextStore) SetPresenceTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultPresenceTTL
+	}
+	s.mu.Lock()
+	s.presenceTTL = ttl
+	s.mu.Unlock()
+}
+
+// Heartbeat records that agentID is present on a bead, creating the bead's
+// context if needed. Observers get a presence_changed update when the agent
+// appears or its status, file or phase changes; a heartbeat that only
+// renews presence is silent.
+func (s *ContextStore) Heartbeat(ctx context.Context, beadID, projectID string, p Presence) error {
+	if p.AgentID == "" {
+		return fmt.Errorf("agent_id is required")
+	}
+	switch p.Status {
+	case "":
+		p.Status = PresenceActive
+	case PresenceActive, PresenceTyping, PresenceIdle:
+	default:
+		return fmt.Errorf("unknown presence status %q", p.Status)
+	}
+	beadCtx, err := s.GetOrCreate(ctx, beadID, projectID)
+	if err != nil {
+		return err
+	}
+	s.mu.RLock()
+	ttl := s.presenceTTL
+	s.mu.RUnlock()
+
+	now := time.Now()
+	p.LastSeen = now
+	p.ExpiresAt = now.Add(ttl)
+
+	beadCtx.mu.Lock()
+	if beadCtx.Presence == nil {
+		beadCtx.Presence = make(map[string]*Presence)
+	}
+	previous := beadCtx.Presence[p.AgentID]
+	beadCtx.Presence[p.AgentID] = &p
+	version := beadCtx.Version
+	beadCtx.mu.Unlock()
+
+	if previous != nil && previous.same(&p) {
+		return nil
+	}
+	s.notifyUpdate(ContextUpdate{
+		BeadID:     beadID,
+		UpdateType: "presence_changed",
+		AgentID:    p.AgentID,
+		Data:       presenceData(&p),
+		Timestamp:  now,
+		Version:    version,
+	})
+	return nil
+}
+
+// ClearPresence removes an agent's presence from a bead, as when it
+// disconnects
+func (s *ContextStore) ClearPresence(ctx context.Context, beadID, agentID string) {
+	beadCtx, err := s.Get(ctx, beadID)
+	if err != nil {
+		return
+	}
+	beadCtx.mu.Lock()
+	p, ok := beadCtx.Presence[agentID]
+	delete(beadCtx.Presence, agentID)
+	version := beadCtx.Version
+	beadCtx.mu.Unlock()
+	if ok {
+		s.notifyPresenceGone(beadID, p, "left", version)
+	}
+}
+
+// ActivePresence returns the unexpired presences on a bead
+func (s *ContextStore) ActivePresence(ctx context.Context, beadID string) []Presence {
+	beadCtx, err := s.Get(ctx, beadID)
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	beadCtx.mu.RLock()
+	defer beadCtx.mu.RUnlock()
+	list := make([]Presence, 0, len(beadCtx.Presence))
+	for _, p := range beadCtx.Presence {
+		if now.Before(p.ExpiresAt) {
+			list = append(list, *p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].AgentID < list[j].AgentID })
+	return list
+}
+
+// ExpirePresence drops presences whose heartbeats ran out before now and
+// tells observers; it returns how many expired
+func (s *ContextStore) ExpirePresence(now time.Time) int {
+	s.mu.RLock()
+	contexts := make([]*SharedBeadContext, 0, len(s.contexts))
+	for _, c := range s.contexts {
+		contexts = append(contexts, c)
+	}
+	s.mu.RUnlock()
+
+	expired := 0
+	for _, beadCtx := range contexts {
+		beadCtx.mu.Lock()
+		var gone []*Presence
+		for agentID, p := range beadCtx.Presence {
+			if !now.Before(p.ExpiresAt) {
+				gone = append(gone, p)
+				delete(beadCtx.Presence, agentID)
+			}
+		}
+		beadID, version := beadCtx.BeadID, beadCtx.Version
+		beadCtx.mu.Unlock()
+
+		for _, p := range gone {
+			s.notifyPresenceGone(beadID, p, "expired", version)
+		}
+		expired += len(gone)
+	}
+	return expired
+}
+
+// expirePresenceLoop expires presences and prunes idle event buffers until
+// the store is closed
+func (s *ContextStore) expirePresenceLoop() {
+	ticker := time.NewTicker(presenceSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.ExpirePresence(now)
+			s.pruneEventBuffers(now.Add(-eventBufferIdle))
+		}
+	}
+}
+
+const presenceSweepInterval = 10 * time.Second
+
+func (s *ContextStore) notifyPresenceGone(beadID string, p *Presence, reason string, version int64) {
+	data := presenceData(p)
+	data["status"] = "gone"
+	data["reason"] = reason
+	s.notifyUpdate(ContextUpdate{
+		BeadID:     beadID,
+		UpdateType: "presence_changed",
+		AgentID:    p.AgentID,
+		Data:       data,
+		Timestamp:  time.Now(),
+		Version:    version,
+	})
+}
+
+func presenceData(p *Presence) map[string]interface{} {
+	return map[string]interface{}{
+		"agent_id":   p.AgentID,
+		"status":     p.Status,
+		"file":       p.File,
+		"phase":      p.Phase,
+		"last_seen":  p.LastSeen,
+		"expires_at": p.ExpiresAt,
+	}
+}
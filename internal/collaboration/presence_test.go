@@ -0,0 +1,62 @@
+package collaboration
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPresence(t *testing.T) {
+	store := NewContextStore()
+	defer store.Close()
+	ctx := context.Background()
+
+	updates := store.Subscribe("bead-1")
+	defer store.Unsubscribe("bead-1", updates)
+
+	next := func() ContextUpdate {
+		t.Helper()
+		select {
+		case u := <-updates:
+			return u
+		case <-time.After(time.Second):
+			t.Fatal("expected a presence update")
+		}
+		return ContextUpdate{}
+	}
+
+	if err := store.Heartbeat(ctx, "bead-1", "p1", Presence{AgentID: "agent-1", File: "main.go", Phase: "edit_code", Status: PresenceTyping}); err != nil {
+		t.Fatal(err)
+	}
+	if u := next(); u.UpdateType != "presence_changed" || u.Data["status"] != PresenceTyping || u.Data["file"] != "main.go" {
+		t.Errorf("unexpected update %+v", u)
+	}
+
+	// A renewal that changes nothing is silent; a change is broadcast
+	_ = store.Heartbeat(ctx, "bead-1", "p1", Presence{AgentID: "agent-1", File: "main.go", Phase: "edit_code", Status: PresenceTyping})
+	_ = store.Heartbeat(ctx, "bead-1", "p1", Presence{AgentID: "agent-2"})
+	if u := next(); u.UpdateType != "presence_changed" || u.AgentID != "agent-2" || u.Data["status"] != PresenceActive {
+		t.Errorf("unexpected update %+v", u)
+	}
+
+	present := store.ActivePresence(ctx, "bead-1")
+	if len(present) != 2 || present[0].AgentID != "agent-1" || present[0].File != "main.go" {
+		t.Fatalf("unexpected presence %+v", present)
+	}
+
+	if n := store.ExpirePresence(time.Now().Add(DefaultPresenceTTL + time.Second)); n != 2 {
+		t.Errorf("expected both presences to expire, got %d", n)
+	}
+	for i := 0; i < 2; i++ {
+		if u := next(); u.Data["status"] != "gone" || u.Data["reason"] != "expired" {
+			t.Errorf("unexpected expiry update %+v", u)
+		}
+	}
+	if len(store.ActivePresence(ctx, "bead-1")) != 0 {
+		t.Error("expected no presence after expiry")
+	}
+
+	if err := store.Heartbeat(ctx, "bead-1", "p1", Presence{AgentID: "agent-1", Status: "sleeping"}); err == nil {
+		t.Error("expected an unknown status to be rejected")
+	}
+}
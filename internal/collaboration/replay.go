@@ -0,0 +1,129 @@
+package collaboration
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEventBufferSize is how many of a bead's latest events are kept for
+// streams resuming after a reconnect
+const DefaultEventBufferSize = 256
+
+// eventBufferIdle is how long a bead's buffered events outlive its last
+// event. Its event IDs are kept, so a later resume still sees the gap.
+const eventBufferIdle = 30 * time.Minute
+
+// eventBuffer holds a bead's latest events, oldest first
+type eventBuffer struct {
+	lastID int64
+	events []ContextUpdate
+	last   time.Time
+}
+
+// SetEventBufferSize sets how many events each bead keeps for resuming
+// streams (DefaultEventBufferSize when n is not positive)
+func (s *ContextStore) SetEventBufferSize(n int) {
+	if n <= 0 {
+		n = DefaultEventBufferSize
+	}
+	s.replayMu.Lock()
+	s.replaySize = n
+	for _, buf := range s.replay {
+		if len(buf.events) > n {
+			buf.events = append([]ContextUpdate(nil), buf.events[len(buf.events)-n:]...)
+		}
+	}
+	s.replayMu.Unlock()
+}
+
+// record gives an update the next event ID of its bead and buffers it
+func (s *ContextStore) record(update *ContextUpdate) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+	buf := s.replay[update.BeadID]
+	if buf == nil {
+		buf = &eventBuffer{}
+		s.replay[update.BeadID] = buf
+	}
+	buf.lastID++
+	update.EventID = buf.lastID
+	buf.last = time.Now()
+	if len(buf.events) >= s.replaySize {
+		// Shift rather than reslice so the array doesn't grow
+		n := copy(buf.events, buf.events[len(buf.events)-s.replaySize+1:])
+		buf.events = buf.events[:n]
+	}
+	buf.events = append(buf.events, *update)
+}
+
+// LastEventID returns the ID of a bead's latest event, 0 before its first
+func (s *ContextStore) LastEventID(beadID string) int64 {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+	if buf := s.replay[beadID]; buf != nil {
+		return buf.lastID
+	}
+	return 0
+}
+
+// EventsSince returns a bead's buffered events after lastID, oldest first,
+// and its latest event ID. ok is false when some of those events are no
+// longer buffered, or lastID is from the future, so the caller must reload
+// the context instead.
+func (s *ContextStore) EventsSince(beadID string, lastID int64) (events []ContextUpdate, latest int64, ok bool) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+	buf := s.replay[beadID]
+	if buf == nil {
+		return nil, 0, lastID == 0
+	}
+	if lastID > buf.lastID {
+		return nil, buf.lastID, false
+	}
+	if lastID == buf.lastID {
+		return nil, buf.lastID, true
+	}
+	if len(buf.events) == 0 || buf.events[0].EventID > lastID+1 {
+		return nil, buf.lastID, false
+	}
+	for _, e := range buf.events {
+		if e.EventID > lastID {
+			events = append(events, e)
+		}
+	}
+	return events, buf.lastID, true
+}
+
+// pruneEventBuffers drops the events of beads idle since before cutoff
+func (s *ContextStore) pruneEventBuffers(cutoff time.Time) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+	for _, buf := range s.replay {
+		if buf.events != nil && buf.last.Before(cutoff) {
+			buf.events = nil
+		}
+	}
+}
+
+// FormatEventID renders an event ID for the SSE id field. IDs restart with
+// the process, so they carry the store's start time and an ID from an
+// earlier run is never mistaken for a current one.
+func (s *ContextStore) FormatEventID(id int64) string {
+	return fmt.Sprintf("%d-%d", s.epoch, id)
+}
+
+// ParseEventID parses an ID made by FormatEventID, such as a client's
+// Last-Event-ID. ok is false when it is malformed or from an earlier run.
+func (s *ContextStore) ParseEventID(v string) (id int64, ok bool) {
+	epoch, seq, found := strings.Cut(v, "-")
+	if !found || epoch != strconv.FormatInt(s.epoch, 10) {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(seq, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,133 @@
+package collaboration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publishEvents publishes n events on a bead and waits until they are
+// buffered
+func publishEvents(t *testing.T, store *ContextStore, beadID string, n int) {
+	t.Helper()
+	want := store.LastEventID(beadID) + int64(n)
+	for i := 0; i < n; i++ {
+		store.PublishEvent(context.Background(), beadID, "agent-1", "action_progress", map[string]interface{}{"i": i})
+	}
+	require.Eventually(t, func() bool { return store.LastEventID(beadID) == want }, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestContextStore_EventsSince(t *testing.T) {
+	store := NewContextStore()
+	defer store.Close()
+	store.SetEventBufferSize(3)
+
+	events, latest, ok := store.EventsSince("bead-1", 0)
+	assert.True(t, ok)
+	assert.Empty(t, events)
+	assert.Zero(t, latest)
+
+	publishEvents(t, store, "bead-1", 5)
+	events, latest, ok = store.EventsSince("bead-1", 3)
+	require.True(t, ok)
+	assert.Equal(t, int64(5), latest)
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(4), events[0].EventID)
+	assert.Equal(t, int64(5), events[1].EventID)
+
+	// Event 2 is no longer buffered
+	_, _, ok = store.EventsSince("bead-1", 1)
+	assert.False(t, ok)
+	// An ID the bead hasn't reached
+	_, _, ok = store.EventsSince("bead-1", 9)
+	assert.False(t, ok)
+
+	// Pruned buffers keep their IDs
+	store.pruneEventBuffers(time.Now().Add(time.Minute))
+	_, _, ok = store.EventsSince("bead-1", 4)
+	assert.False(t, ok)
+	_, latest, ok = store.EventsSince("bead-1", 5)
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), latest)
+}
+
+func TestContextStore_ParseEventID(t *testing.T) {
+	store := NewContextStore()
+	defer store.Close()
+
+	id, ok := store.ParseEventID(store.FormatEventID(42))
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), id)
+
+	other := NewContextStore()
+	defer other.Close()
+	other.epoch++
+	for _, v := range []string{"", "42", other.FormatEventID(42), store.FormatEventID(1) + "x"} {
+		_, ok := store.ParseEventID(v)
+		assert.False(t, ok, v)
+	}
+}
+
+// streamOnce runs ServeHTTP until the events already buffered are written
+func streamOnce(t *testing.T, handler *SSEHandler, lastEventID string) string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/beads/context/stream?bead_id=bead-1", nil).WithContext(ctx)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	w := &flushableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(w, req)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for ServeHTTP to return")
+	}
+	return w.ResponseRecorder.Body.String()
+}
+
+func TestServeHTTP_ResumesFromLastEventID(t *testing.T) {
+	store := NewContextStore()
+	defer store.Close()
+	store.SetEventBufferSize(4)
+	_, _ = store.GetOrCreate(context.Background(), "bead-1", "project-1")
+	handler := NewSSEHandler(store)
+
+	publishEvents(t, store, "bead-1", 3)
+	body := streamOnce(t, handler, "")
+	assert.Contains(t, body, "id: "+store.FormatEventID(3)+"\nevent: initial")
+
+	// Reconnecting after event 3 replays what came since, without the
+	// initial state
+	publishEvents(t, store, "bead-1", 2)
+	body = streamOnce(t, handler, store.FormatEventID(3))
+	assert.NotContains(t, body, "event: initial")
+	assert.Equal(t, 2, strings.Count(body, "event: update"))
+	assert.Contains(t, body, "id: "+store.FormatEventID(4)+"\n")
+	assert.Contains(t, body, "id: "+store.FormatEventID(5)+"\n")
+
+	// Up to date: nothing to send
+	body = streamOnce(t, handler, store.FormatEventID(5))
+	assert.NotContains(t, body, "event:")
+
+	// Missed events that left the buffer, or an ID from before a restart,
+	// get the initial state again
+	publishEvents(t, store, "bead-1", 5)
+	for _, lastEventID := range []string{store.FormatEventID(5), "1-5"} {
+		body = streamOnce(t, handler, lastEventID)
+		assert.Contains(t, body, "id: "+store.FormatEventID(10)+"\nevent: initial", lastEventID)
+		assert.NotContains(t, body, "event: update", lastEventID)
+	}
+}
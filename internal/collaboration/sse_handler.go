@@ -2,9 +2,11 @@ package collaboration
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
 )
 
 // SSEHandler handles Server-Sent Events for real-time context updates
@@ -21,11 +23,17 @@ func NewSSEHandler(store *ContextStore) *SSEHandler {
 
 // ServeHTTP handles SSE connections for a bead
 // URL format: /api/v1/beads/{bead_id}/context/stream
+//
+// Every event carries an ID. A client reconnecting with Last-Event-ID (or
+// the last_event_id parameter) gets the events it missed instead of the
+// initial state, unless they have left the bead's buffer; then it gets the
+// initial state again. A client that falls too far behind is disconnected
+// and resumes the same way.
 func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Get bead ID from request (implementation depends on router)
 	beadID := r.URL.Query().Get("bead_id")
 	if beadID == "" {
-		http.Error(w, "bead_id parameter required", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "bead_id parameter required")
 		return
 	}
 
@@ -34,38 +42,59 @@ func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
+	sw := newStreamWriter(w)
 
-	// Subscribe to updates
+	// Subscribe to updates before reading the buffer or the initial state,
+	// so no update falls between them
 	updateChan := h.store.Subscribe(beadID)
 	defer h.store.Unsubscribe(beadID, updateChan)
 
-	// Get initial context state
-	initialCtx, err := h.store.Get(r.Context(), beadID)
-	if err != nil {
-		// Send error event
-		fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
-		if f, ok := w.(http.Flusher); ok {
-			f.Flush()
+	// lastSent is the ID of the newest event the client has; updates up to
+	// it are skipped
+	var lastSent int64
+	resumed := false
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	if id, ok := h.store.ParseEventID(lastEventID); ok {
+		if missed, latest, ok := h.store.EventsSince(beadID, id); ok {
+			for _, update := range missed {
+				h.writeUpdate(sw, update)
+			}
+			lastSent, resumed = latest, true
 		}
-		return
 	}
 
-	// Send initial state
-	initialCtx.mu.RLock()
-	initialData, _ := json.Marshal(map[string]interface{}{
-		"type":    "initial",
-		"bead_id": initialCtx.BeadID,
-		"context": initialCtx,
-	})
-	initialCtx.mu.RUnlock()
+	if !resumed {
+		lastSent = h.store.LastEventID(beadID)
+
+		// Get initial context state
+		initialCtx, err := h.store.Get(r.Context(), beadID)
+		if err != nil {
+			// Send error event
+			sw.printf("event: error\ndata: %s\n\n", err.Error())
+			_ = sw.flush()
+			return
+		}
+
+		// Send initial state
+		initialCtx.mu.RLock()
+		initialData, _ := json.Marshal(map[string]interface{}{
+			"type":    "initial",
+			"bead_id": initialCtx.BeadID,
+			"context": initialCtx,
+		})
+		initialCtx.mu.RUnlock()
 
-	fmt.Fprintf(w, "event: initial\ndata: %s\n\n", string(initialData))
-	if f, ok := w.(http.Flusher); ok {
-		f.Flush()
+		sw.printf("id: %s\nevent: initial\ndata: %s\n\n", h.store.FormatEventID(lastSent), string(initialData))
+	}
+	if sw.flush() != nil {
+		return
 	}
 
 	// Stream updates
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(streamKeepalive)
 	defer ticker.Stop()
 
 	for {
@@ -75,38 +104,51 @@ func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		case update, ok := <-updateChan:
 			if !ok {
+				// Closed, or dropped for falling behind
 				return
 			}
+			if update.EventID <= lastSent {
+				continue
+			}
+			lastSent = update.EventID
 
-			// Send update event
-			updateData, _ := json.Marshal(update)
-			fmt.Fprintf(w, "event: update\ndata: %s\n\n", string(updateData))
-
-			if f, ok := w.(http.Flusher); ok {
-				f.Flush()
+			h.writeUpdate(sw, update)
+			if sw.flush() != nil {
+				return
 			}
 
 		case <-ticker.C:
 			// Send keep-alive ping
-			fmt.Fprintf(w, ": ping\n\n")
-			if f, ok := w.(http.Flusher); ok {
-				f.Flush()
+			sw.printf(": ping\n\n")
+			if sw.flush() != nil {
+				return
 			}
 		}
 	}
 }
 
+// writeUpdate sends an update event; presence changes get their own event
+// name so clients can listen for them alone
+func (h *SSEHandler) writeUpdate(sw *streamWriter, update ContextUpdate) {
+	event := "update"
+	if update.UpdateType == "presence_changed" {
+		event = "presence"
+	}
+	updateData, _ := json.Marshal(update)
+	sw.printf("id: %s\nevent: %s\ndata: %s\n\n", h.store.FormatEventID(update.EventID), event, string(updateData))
+}
+
 // HandleGetContext returns the current context state as JSON
 func (h *SSEHandler) HandleGetContext(w http.ResponseWriter, r *http.Request) {
 	beadID := r.URL.Query().Get("bead_id")
 	if beadID == "" {
-		http.Error(w, "bead_id parameter required", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "bead_id parameter required")
 		return
 	}
 
 	data, err := h.store.ExportContext(r.Context(), beadID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apperr.WriteStatus(w, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -117,7 +159,7 @@ func (h *SSEHandler) HandleGetContext(w http.ResponseWriter, r *http.Request) {
 // HandleJoinBead handles agent joining a bead context
 func (h *SSEHandler) HandleJoinBead(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -127,17 +169,17 @@ func (h *SSEHandler) HandleJoinBead(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if req.BeadID == "" || req.AgentID == "" {
-		http.Error(w, "bead_id and agent_id required", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "bead_id and agent_id required")
 		return
 	}
 
 	if err := h.store.JoinBead(r.Context(), req.BeadID, req.AgentID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -152,7 +194,7 @@ func (h *SSEHandler) HandleJoinBead(w http.ResponseWriter, r *http.Request) {
 // HandleLeaveBead handles agent leaving a bead context
 func (h *SSEHandler) HandleLeaveBead(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -162,17 +204,17 @@ func (h *SSEHandler) HandleLeaveBead(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if req.BeadID == "" || req.AgentID == "" {
-		http.Error(w, "bead_id and agent_id required", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "bead_id and agent_id required")
 		return
 	}
 
 	if err := h.store.LeaveBead(r.Context(), req.BeadID, req.AgentID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -187,7 +229,7 @@ func (h *SSEHandler) HandleLeaveBead(w http.ResponseWriter, r *http.Request) {
 // HandleUpdateData handles updating shared data
 func (h *SSEHandler) HandleUpdateData(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -200,12 +242,12 @@ func (h *SSEHandler) HandleUpdateData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if req.BeadID == "" || req.AgentID == "" || req.Key == "" {
-		http.Error(w, "bead_id, agent_id, and key required", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "bead_id, agent_id, and key required")
 		return
 	}
 
@@ -222,14 +264,14 @@ func (h *SSEHandler) HandleUpdateData(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	// Get current context to return version
 	ctx, err := h.store.Get(r.Context(), req.BeadID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -249,7 +291,7 @@ func (h *SSEHandler) HandleUpdateData(w http.ResponseWriter, r *http.Request) {
 // HandleAddActivity handles adding activity log entry
 func (h *SSEHandler) HandleAddActivity(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apperr.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -262,18 +304,18 @@ func (h *SSEHandler) HandleAddActivity(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if req.BeadID == "" || req.AgentID == "" || req.ActivityType == "" {
-		http.Error(w, "bead_id, agent_id, and activity_type required", http.StatusBadRequest)
+		apperr.WriteStatus(w, http.StatusBadRequest, "bead_id, agent_id, and activity_type required")
 		return
 	}
 
 	err := h.store.AddActivity(r.Context(), req.BeadID, req.AgentID, req.ActivityType, req.Description, req.Data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.WriteStatus(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -284,3 +326,77 @@ func (h *SSEHandler) HandleAddActivity(w http.ResponseWriter, r *http.Request) {
 		"activity_type": req.ActivityType,
 	})
 }
+
+// ServeDocumentStream streams a co-edited document over SSE: an initial
+// event with its snapshot, then an ops event for each batch of operations
+// applied by other sites. Query: project_id, path and optionally site (whose
+// own operations are not echoed back) and since (the replica's version).
+func ServeDocumentStream(store *CoEditStore, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	projectID, path, site := q.Get("project_id"), q.Get("path"), q.Get("site")
+	if projectID == "" || path == "" {
+		apperr.WriteStatus(w, http.StatusBadRequest, "project_id and path parameters required")
+		return
+	}
+
+	// Subscribe before the snapshot so no operation falls between them
+	updateChan, err := store.Subscribe(projectID, path)
+	if err != nil {
+		apperr.WriteStatus(w, http.StatusNotFound, err.Error())
+		return
+	}
+	defer store.Unsubscribe(projectID, path, updateChan)
+
+	since := 0
+	if v, err := strconv.Atoi(q.Get("since")); err == nil {
+		since = v
+	}
+	snapshot, err := store.Snapshot(projectID, path, since)
+	if err != nil {
+		apperr.WriteStatus(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	sw := newStreamWriter(w)
+
+	initialData, _ := json.Marshal(snapshot)
+	sw.printf("event: initial\ndata: %s\n\n", string(initialData))
+	if sw.flush() != nil {
+		return
+	}
+
+	ticker := time.NewTicker(streamKeepalive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case update, ok := <-updateChan:
+			if !ok {
+				sw.printf("event: closed\ndata: {}\n\n")
+				_ = sw.flush()
+				return
+			}
+			if update.Version <= snapshot.Version || (site != "" && update.Site == site) {
+				continue
+			}
+
+			updateData, _ := json.Marshal(update)
+			sw.printf("event: ops\ndata: %s\n\n", string(updateData))
+			if sw.flush() != nil {
+				return
+			}
+
+		case <-ticker.C:
+			sw.printf(": ping\n\n")
+			if sw.flush() != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package collaboration
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// streamKeepalive is how often an idle stream sends a comment, so
+	// proxies don't close it and dead connections are noticed
+	streamKeepalive = 15 * time.Second
+	// streamWriteTimeout is how long one write to a stream may take before
+	// the client is treated as gone
+	streamWriteTimeout = 10 * time.Second
+)
+
+// streamWriter writes SSE messages. Each write gets its own deadline in
+// place of the server's WriteTimeout, so a long-lived stream stays open
+// while a client that stops reading is dropped.
+type streamWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func newStreamWriter(w http.ResponseWriter) *streamWriter {
+	return &streamWriter{w: w, rc: http.NewResponseController(w)}
+}
+
+// printf writes part of a message
+func (s *streamWriter) printf(format string, args ...interface{}) {
+	_ = s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	fmt.Fprintf(s.w, format, args...)
+}
+
+// flush sends what has been written, returning an error once the client
+// is gone
+func (s *streamWriter) flush() error {
+	_ = s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
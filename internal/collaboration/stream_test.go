@@ -0,0 +1,76 @@
+package collaboration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextStore_DropsSlowSubscribers(t *testing.T) {
+	store := NewContextStore()
+	defer store.Close()
+	store.sendTimeout = 10 * time.Millisecond
+
+	stalled := store.Subscribe("bead-1")
+	active := store.Subscribe("bead-1")
+	defer store.Unsubscribe("bead-1", active)
+	received := make(chan int, 2*listenerBuffer)
+	go func() {
+		for range active {
+			received <- 1
+		}
+	}()
+
+	// The stalled subscriber's buffer fills, and the next update drops it
+	// without holding up the active one
+	publishEvents(t, store, "bead-1", listenerBuffer+5)
+	require.Eventually(t, func() bool { return len(received) == listenerBuffer+5 }, 2*time.Second, 5*time.Millisecond)
+
+	n := 0
+	for range stalled {
+		n++
+	}
+	assert.Equal(t, listenerBuffer, n, "stalled subscriber keeps what was buffered, then is closed")
+
+	// Unsubscribing a dropped subscriber is harmless
+	store.Unsubscribe("bead-1", stalled)
+}
+
+// brokenWriter is a connection whose client has gone away
+type brokenWriter struct {
+	header http.Header
+}
+
+func (b *brokenWriter) Header() http.Header         { return b.header }
+func (b *brokenWriter) Write(p []byte) (int, error) { return 0, errors.New("broken pipe") }
+func (b *brokenWriter) WriteHeader(int)             {}
+func (b *brokenWriter) FlushError() error           { return errors.New("broken pipe") }
+
+func TestServeHTTP_EndsWhenClientIsGone(t *testing.T) {
+	store := NewContextStore()
+	defer store.Close()
+	_, _ = store.GetOrCreate(context.Background(), "bead-1", "project-1")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/beads/context/stream?bead_id=bead-1", nil)
+	done := make(chan struct{})
+	go func() {
+		NewSSEHandler(store).ServeHTTP(&brokenWriter{header: http.Header{}}, req)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ServeHTTP kept streaming to a broken connection")
+	}
+
+	sh := store.shard("bead-1")
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	assert.Nil(t, sh.beads["bead-1"])
+}
@@ -7,10 +7,13 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/notifications"
 	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
 )
 
+var commentsLog = logging.Module("comments")
+
 // Manager handles comment operations
 type Manager struct {
 	db              *database.Database
@@ -83,7 +86,7 @@ func (m *Manager) CreateComment(beadID, authorID, authorUsername, content, paren
 
 	if err := m.processMentions(comment.ID, mentions); err != nil {
 		// Log error but don't fail comment creation
-		fmt.Printf("Failed to process mentions: %v\n", err)
+		commentsLog.Warn("Failed to process mentions", "comment_id", comment.ID, "error", err)
 	}
 
 	// Publish event to EventBus
@@ -291,7 +294,7 @@ func (m *Manager) processMentions(commentID string, mentions []string) error {
 		// Mark as notified
 		if err := m.db.MarkMentionNotified(mention.ID); err != nil {
 			// Log but don't fail
-			fmt.Printf("Failed to mark mention as notified: %v\n", err)
+			commentsLog.Warn("Failed to mark mention as notified", "mention_id", mention.ID, "error", err)
 		}
 	}
 
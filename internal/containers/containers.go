@@ -0,0 +1,590 @@
+// Package containers builds project images and runs smoke-test containers
+// with Docker or Podman, returning structured build logs, container logs,
+// health checks and exit status.
+package containers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Supported runtimes
+const (
+	Docker = "docker"
+	Podman = "podman"
+)
+
+const (
+	defaultBuildTimeout = 10 * time.Minute
+	defaultRunTimeout   = 2 * time.Minute
+	defaultMemory       = "1g"
+	defaultCPUs         = "2"
+	// maxLogLines caps the build and container log lines kept in a result
+	maxLogLines = 500
+	// healthInterval is how often a health check is retried until it passes
+	healthInterval = time.Second
+	// cleanupTimeout bounds removing a container after the run's context ends
+	cleanupTimeout = 30 * time.Second
+)
+
+var (
+	imagePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/:@+-]*$`)
+	keyPattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	portPattern  = regexp.MustCompile(`^(?:(\d{1,5}):)?(\d{1,5})(/tcp|/udp)?$`)
+	stagePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	tagSanitizer = regexp.MustCompile(`[^a-z0-9._-]+`)
+)
+
+// BuildOptions describes an image build
+type BuildOptions struct {
+	Dockerfile string            // Relative to the repository root; defaults to Dockerfile or Containerfile in Context
+	Context    string            // Build context relative to the repository root; defaults to the root
+	Tag        string            // Image tag; defaults to loom/<project>:<bead>
+	BuildArgs  map[string]string // --build-arg values
+	Target     string            // Multi-stage target
+	BeadID     string
+	Timeout    time.Duration // Shortens the configured build timeout
+}
+
+// BuildResult is the outcome of an image build
+type BuildResult struct {
+	Image    string   `json:"image"`
+	Runtime  string   `json:"runtime"`
+	Success  bool     `json:"success"`
+	ExitCode int      `json:"exit_code"`
+	Duration string   `json:"duration"`
+	TimedOut bool     `json:"timed_out,omitempty"`
+	Log      []string `json:"log"`             // Build output, the last maxLogLines lines
+	LogLines int      `json:"log_lines"`       // Lines of output before truncation
+	Error    string   `json:"error,omitempty"` // Runtime error when the build could not finish
+}
+
+// HealthCheck polls an HTTP endpoint of a running container
+type HealthCheck struct {
+	Port         int    // Container port; defaults to the first published port
+	Path         string // Defaults to /
+	ExpectStatus int    // Defaults to any 2xx or 3xx status
+}
+
+// RunOptions describes a smoke-test container
+type RunOptions struct {
+	Image   string
+	Command []string          // Overrides the image's command
+	Env     map[string]string // Environment variables
+	Ports   []string          // "8080:80" publishes container port 80 on localhost:8080; "80" picks a free host port
+	Health  *HealthCheck      // Without one the run waits for the container to exit
+	BeadID  string
+	Timeout time.Duration // Shortens the configured run timeout
+}
+
+// LogLine is one line of container output
+type LogLine struct {
+	Time   time.Time `json:"time"`
+	Stream string    `json:"stream"` // stdout or stderr
+	Text   string    `json:"text"`
+}
+
+// RunResult is the outcome of a smoke-test container. ExitCode is nil when
+// the container was still running when it was stopped, after a passing
+// health check or a timeout.
+type RunResult struct {
+	ContainerID   string            `json:"container_id"`
+	Image         string            `json:"image"`
+	Runtime       string            `json:"runtime"`
+	Success       bool              `json:"success"`
+	ExitCode      *int              `json:"exit_code,omitempty"`
+	Running       bool              `json:"running"` // Still running when stopped
+	Healthy       *bool             `json:"healthy,omitempty"`
+	HealthStatus  int               `json:"health_status,omitempty"` // Last HTTP status of the health check
+	HealthError   string            `json:"health_error,omitempty"`
+	Ports         map[string]string `json:"ports,omitempty"` // Container port to localhost address
+	Logs          []LogLine         `json:"logs"`
+	LogsTruncated bool              `json:"logs_truncated,omitempty"`
+	Duration      string            `json:"duration"`
+	TimedOut      bool              `json:"timed_out,omitempty"`
+}
+
+// Manager builds and runs containers for project workdirs
+type Manager struct {
+	WorkDirs     files.WorkDirResolver
+	Runtime      string // docker or podman; empty picks the first installed
+	BuildTimeout time.Duration
+	RunTimeout   time.Duration
+	Memory       string
+	CPUs         string
+
+	runner  toolrun.Runner
+	httpGet func(ctx context.Context, url string) (int, error)
+}
+
+// NewManager creates a container manager, or returns nil when containers are
+// disabled in cfg.
+func NewManager(resolver files.WorkDirResolver, cfg config.ContainersConfig) *Manager {
+	if cfg.Disabled {
+		return nil
+	}
+	m := &Manager{
+		WorkDirs:     resolver,
+		Runtime:      strings.ToLower(strings.TrimSpace(cfg.Runtime)),
+		BuildTimeout: cfg.BuildTimeout,
+		RunTimeout:   cfg.RunTimeout,
+		Memory:       cfg.Memory,
+		CPUs:         cfg.CPUs,
+		httpGet:      httpGet,
+	}
+	if m.BuildTimeout <= 0 {
+		m.BuildTimeout = defaultBuildTimeout
+	}
+	if m.RunTimeout <= 0 {
+		m.RunTimeout = defaultRunTimeout
+	}
+	if m.Memory == "" {
+		m.Memory = defaultMemory
+	}
+	if m.CPUs == "" {
+		m.CPUs = defaultCPUs
+	}
+	return m
+}
+
+// Build builds an image from a Dockerfile in the project. A failed build is
+// reported in the result; an error means the build could not be started.
+func (m *Manager) Build(ctx context.Context, projectID string, opts BuildOptions) (*BuildResult, error) {
+	runtime, err := m.runtime()
+	if err != nil {
+		return nil, err
+	}
+	workDir, err := m.workDir(projectID)
+	if err != nil {
+		return nil, err
+	}
+	contextDir, err := files.SafeJoin(workDir, opts.Context)
+	if err != nil {
+		return nil, fmt.Errorf("build context %q: %w", opts.Context, err)
+	}
+	if info, err := os.Stat(contextDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("build context %q is not a directory", opts.Context)
+	}
+	dockerfile, err := findDockerfile(workDir, contextDir, opts.Dockerfile)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := opts.Tag
+	if tag == "" {
+		tag = defaultTag(projectID, opts.BeadID)
+	}
+	if !imagePattern.MatchString(tag) {
+		return nil, fmt.Errorf("invalid image tag %q", tag)
+	}
+	if opts.Target != "" && !stagePattern.MatchString(opts.Target) {
+		return nil, fmt.Errorf("invalid build target %q", opts.Target)
+	}
+
+	args := []string{"build", "-f", dockerfile, "-t", tag, "--label", "loom.project=" + projectID}
+	if runtime == Docker {
+		args = append(args, "--progress=plain")
+	}
+	if opts.BeadID != "" {
+		args = append(args, "--label", "loom.bead="+opts.BeadID)
+	}
+	for _, key := range sortedKeys(opts.BuildArgs) {
+		if !keyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid build arg name %q", key)
+		}
+		args = append(args, "--build-arg", key+"="+opts.BuildArgs[key])
+	}
+	if opts.Target != "" {
+		args = append(args, "--target", opts.Target)
+	}
+	args = append(args, contextDir)
+
+	buildCtx, cancel := context.WithTimeout(ctx, toolrun.Limit(opts.Timeout, m.BuildTimeout))
+	defer cancel()
+	start := time.Now()
+	stdout, stderr, runErr := m.exec(buildCtx, workDir, runtime, args...)
+
+	lines := splitLines(append(append([]byte{}, stdout...), stderr...))
+	result := &BuildResult{
+		Image:    tag,
+		Runtime:  runtime,
+		Success:  runErr == nil,
+		ExitCode: exitCode(runErr),
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+		TimedOut: errors.Is(buildCtx.Err(), context.DeadlineExceeded),
+		Log:      tailLines(lines, maxLogLines),
+		LogLines: len(lines),
+	}
+	if runErr != nil && result.ExitCode < 0 {
+		result.Error = runErr.Error()
+	}
+	return result, nil
+}
+
+// Run starts a container from an image with its ports published on localhost
+// and resource limits applied, then either polls its health check or waits
+// for it to exit, collects its logs and removes it.
+func (m *Manager) Run(ctx context.Context, projectID string, opts RunOptions) (*RunResult, error) {
+	runtime, err := m.runtime()
+	if err != nil {
+		return nil, err
+	}
+	workDir, err := m.workDir(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if !imagePattern.MatchString(opts.Image) {
+		return nil, fmt.Errorf("invalid image %q", opts.Image)
+	}
+
+	name := "loom-" + uuid.New().String()[:8]
+	args := []string{"run", "-d", "--name", name, "--label", "loom.project=" + projectID,
+		"--memory", m.Memory, "--cpus", m.CPUs}
+	if opts.BeadID != "" {
+		args = append(args, "--label", "loom.bead="+opts.BeadID)
+	}
+	var containerPorts []string
+	for _, spec := range opts.Ports {
+		match := portPattern.FindStringSubmatch(strings.TrimSpace(spec))
+		if match == nil || !validPort(match[2]) || (match[1] != "" && !validPort(match[1])) {
+			return nil, fmt.Errorf("invalid port mapping %q (use \"8080:80\" or \"80\")", spec)
+		}
+		args = append(args, "-p", "127.0.0.1:"+match[1]+":"+match[2]+match[3])
+		containerPorts = append(containerPorts, match[2]+match[3])
+	}
+	for _, key := range sortedKeys(opts.Env) {
+		if !keyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid environment variable name %q", key)
+		}
+		args = append(args, "-e", key+"="+opts.Env[key])
+	}
+	var health *HealthCheck
+	if opts.Health != nil {
+		check := *opts.Health
+		health = &check
+		if health.Port == 0 && len(containerPorts) > 0 {
+			health.Port, _ = strconv.Atoi(strings.SplitN(containerPorts[0], "/", 2)[0])
+		}
+		if health.Port == 0 {
+			return nil, fmt.Errorf("health check needs a published port")
+		}
+		if !containsPort(containerPorts, health.Port) {
+			return nil, fmt.Errorf("health check port %d is not published", health.Port)
+		}
+	}
+	args = append(args, opts.Image)
+	args = append(args, opts.Command...)
+
+	runCtx, cancel := context.WithTimeout(ctx, toolrun.Limit(opts.Timeout, m.RunTimeout))
+	defer cancel()
+	start := time.Now()
+	stdout, stderr, err := m.exec(runCtx, workDir, runtime, args...)
+	if err != nil {
+		m.remove(runtime, workDir, name)
+		return nil, fmt.Errorf("%s run: %s", runtime, errorOutput(stderr, err))
+	}
+	defer m.remove(runtime, workDir, name)
+
+	result := &RunResult{
+		ContainerID: strings.TrimSpace(string(stdout)),
+		Image:       opts.Image,
+		Runtime:     runtime,
+		Ports:       map[string]string{},
+	}
+	if len(result.ContainerID) > 12 {
+		result.ContainerID = result.ContainerID[:12]
+	}
+	for _, port := range containerPorts {
+		out, _, err := m.exec(runCtx, workDir, runtime, "port", name, port)
+		if err == nil {
+			if addr := firstLine(out); addr != "" {
+				result.Ports[port] = addr
+			}
+		}
+	}
+
+	if health != nil {
+		m.checkHealth(runCtx, runtime, workDir, name, health, result)
+	} else {
+		// wait returns once the container exits; the timeout leaves it running
+		_, _, _ = m.exec(runCtx, workDir, runtime, "wait", name)
+	}
+	result.TimedOut = errors.Is(runCtx.Err(), context.DeadlineExceeded)
+
+	// Inspect and collect logs even after a timeout
+	collectCtx, cancelCollect := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancelCollect()
+	if out, _, err := m.exec(collectCtx, workDir, runtime, "inspect", "-f", "{{.State.Running}} {{.State.ExitCode}}", name); err == nil {
+		fields := strings.Fields(string(out))
+		if len(fields) == 2 {
+			result.Running = fields[0] == "true"
+			if code, err := strconv.Atoi(fields[1]); err == nil && !result.Running {
+				result.ExitCode = &code
+			}
+		}
+	}
+	if logOut, logErr, err := m.exec(collectCtx, workDir, runtime, "logs", "--timestamps", name); err == nil {
+		result.Logs = mergeLogs(logOut, logErr)
+		if len(result.Logs) > maxLogLines {
+			result.Logs = result.Logs[len(result.Logs)-maxLogLines:]
+			result.LogsTruncated = true
+		}
+	}
+	if result.Logs == nil {
+		result.Logs = []LogLine{}
+	}
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+
+	if health != nil {
+		result.Success = result.Healthy != nil && *result.Healthy
+	} else {
+		result.Success = result.ExitCode != nil && *result.ExitCode == 0
+	}
+	return result, nil
+}
+
+// checkHealth polls the health check until it passes, the container exits
+// or ctx ends
+func (m *Manager) checkHealth(ctx context.Context, runtime, workDir, name string, health *HealthCheck, result *RunResult) {
+	healthy := false
+	result.Healthy = &healthy
+	path := health.Path
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	addr := result.Ports[strconv.Itoa(health.Port)]
+	if addr == "" {
+		addr = result.Ports[strconv.Itoa(health.Port)+"/tcp"]
+	}
+	if addr == "" {
+		result.HealthError = fmt.Sprintf("port %d is not published on the host", health.Port)
+		return
+	}
+	url := "http://" + addr + path
+
+	get := m.httpGet
+	if get == nil {
+		get = httpGet
+	}
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for {
+		status, err := get(ctx, url)
+		result.HealthStatus = status
+		if err != nil {
+			result.HealthError = err.Error()
+		} else if (health.ExpectStatus == 0 && status >= 200 && status < 400) || status == health.ExpectStatus {
+			healthy = true
+			result.HealthError = ""
+			return
+		} else {
+			result.HealthError = fmt.Sprintf("GET %s returned %d", path, status)
+		}
+		if out, _, err := m.exec(ctx, workDir, runtime, "inspect", "-f", "{{.State.Running}}", name); err == nil && strings.TrimSpace(string(out)) == "false" {
+			result.HealthError = "container exited before the health check passed: " + result.HealthError
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runtime returns the configured runtime, or the first one installed
+func (m *Manager) runtime() (string, error) {
+	if m.Runtime != "" {
+		if m.Runtime != Docker && m.Runtime != Podman {
+			return "", fmt.Errorf("unsupported container runtime %q (use docker or podman)", m.Runtime)
+		}
+		if _, err := m.runner.Find(m.Runtime); err != nil {
+			return "", fmt.Errorf("%s is not installed", m.Runtime)
+		}
+		return m.Runtime, nil
+	}
+	for _, rt := range []string{Docker, Podman} {
+		if _, err := m.runner.Find(rt); err == nil {
+			return rt, nil
+		}
+	}
+	return "", fmt.Errorf("no container runtime installed (need docker or podman)")
+}
+
+func (m *Manager) workDir(projectID string) (string, error) {
+	if m.WorkDirs == nil {
+		return "", fmt.Errorf("workdir resolver not configured")
+	}
+	workDir := m.WorkDirs.GetProjectWorkDir(projectID)
+	if workDir == "" {
+		return "", fmt.Errorf("project workdir not found")
+	}
+	return filepath.Clean(workDir), nil
+}
+
+func (m *Manager) exec(ctx context.Context, dir, name string, args ...string) ([]byte, []byte, error) {
+	return m.runner.Exec(ctx, toolrun.Command{Dir: dir, Name: name, Args: args})
+}
+
+// remove force-removes a container, even when the run's context has ended
+func (m *Manager) remove(runtime, workDir, name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	_, _, _ = m.exec(ctx, workDir, runtime, "rm", "-f", name)
+}
+
+func httpGet(ctx context.Context, url string) (int, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func findDockerfile(workDir, contextDir, dockerfile string) (string, error) {
+	if dockerfile != "" {
+		path, err := files.SafeJoin(workDir, dockerfile)
+		if err != nil {
+			return "", fmt.Errorf("dockerfile %q: %w", dockerfile, err)
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return "", fmt.Errorf("dockerfile %q not found", dockerfile)
+		}
+		return path, nil
+	}
+	for _, name := range []string{"Dockerfile", "Containerfile"} {
+		path := filepath.Join(contextDir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no Dockerfile or Containerfile in the build context")
+}
+
+// defaultTag names a bead's image loom/<project>:<bead>
+func defaultTag(projectID, beadID string) string {
+	tag := "latest"
+	if beadID != "" {
+		tag = beadID
+	}
+	name := strings.Trim(tagSanitizer.ReplaceAllString(strings.ToLower(projectID), "-"), "-._")
+	if name == "" {
+		name = "project"
+	}
+	return "loom/" + name + ":" + strings.Trim(tagSanitizer.ReplaceAllString(strings.ToLower(tag), "-"), "-.")
+}
+
+// mergeLogs interleaves `logs --timestamps` stdout and stderr by time
+func mergeLogs(stdout, stderr []byte) []LogLine {
+	var lines []LogLine
+	for _, stream := range []struct {
+		name string
+		data []byte
+	}{{"stdout", stdout}, {"stderr", stderr}} {
+		for _, line := range splitLines(stream.data) {
+			entry := LogLine{Stream: stream.name, Text: line}
+			if ts, text, ok := strings.Cut(line, " "); ok {
+				if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+					entry.Time, entry.Text = t, text
+				}
+			}
+			lines = append(lines, entry)
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Time.Before(lines[j].Time) })
+	return lines
+}
+
+func splitLines(data []byte) []string {
+	text := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+func tailLines(lines []string, n int) []string {
+	if len(lines) > n {
+		return lines[len(lines)-n:]
+	}
+	if lines == nil {
+		return []string{}
+	}
+	return lines
+}
+
+func firstLine(data []byte) string {
+	lines := splitLines(data)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(lines[0])
+}
+
+// exitCode returns a command's exit status, 0 on success and -1 when it did
+// not exit normally
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exit interface{ ExitCode() int }
+	if errors.As(err, &exit) {
+		return exit.ExitCode()
+	}
+	return -1
+}
+
+func errorOutput(stderr []byte, err error) string {
+	if msg := strings.TrimSpace(string(stderr)); msg != "" {
+		return msg
+	}
+	return err.Error()
+}
+
+func validPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n <= 65535
+}
+
+func containsPort(ports []string, port int) bool {
+	for _, p := range ports {
+		if strings.SplitN(p, "/", 2)[0] == strconv.Itoa(port) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,254 @@
+package containers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+type exitError int
+
+func (e exitError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitError) ExitCode() int { return int(e) }
+
+// fakeRuntime records commands and answers them from a handler keyed by the
+// runtime subcommand
+type fakeRuntime struct {
+	toolrun.Fake
+	handlers map[string]func(args []string) ([]byte, []byte, error)
+}
+
+func (f *fakeRuntime) handle(c toolrun.Command) ([]byte, []byte, error) {
+	if h, ok := f.handlers[c.Args[0]]; ok {
+		return h(c.Args)
+	}
+	return nil, nil, nil
+}
+
+func (f *fakeRuntime) called(sub string) string {
+	for _, c := range f.Calls {
+		if c.Args[0] == sub {
+			return c.String()
+		}
+	}
+	return ""
+}
+
+func fakeManager(t *testing.T, installed ...string) (*Manager, *fakeRuntime, string) {
+	t.Helper()
+	dir := t.TempDir()
+	m := NewManager(toolrun.StaticWorkDir(dir), config.ContainersConfig{})
+	f := &fakeRuntime{
+		Fake:     toolrun.Fake{Installed: append([]string{}, installed...)},
+		handlers: map[string]func([]string) ([]byte, []byte, error){},
+	}
+	f.Handle = f.handle
+	m.runner = f.Runner()
+	return m, f, dir
+}
+
+func TestNewManagerDisabled(t *testing.T) {
+	if m := NewManager(toolrun.StaticWorkDir("/tmp"), config.ContainersConfig{Disabled: true}); m != nil {
+		t.Error("expected a disabled manager to be nil")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	m, f, dir := fakeManager(t, Podman)
+	if err := os.MkdirAll(filepath.Join(dir, "svc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "svc", "Containerfile"), []byte("FROM scratch\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f.handlers["build"] = func(args []string) ([]byte, []byte, error) {
+		return []byte("STEP 1/2: FROM scratch\nSTEP 2/2: RUN make\n"), []byte("make: *** [all] Error 2\n"), exitError(2)
+	}
+
+	result, err := m.Build(context.Background(), "My Project", BuildOptions{
+		Context:   "svc",
+		BuildArgs: map[string]string{"VERSION": "1.2", "GOOS": "linux"},
+		Target:    "runtime",
+		BeadID:    "loom-42",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || result.ExitCode != 2 || result.Runtime != Podman || result.Image != "loom/my-project:loom-42" || result.Error != "" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if result.LogLines != 3 || result.Log[2] != "make: *** [all] Error 2" {
+		t.Errorf("unexpected log %q", result.Log)
+	}
+	args := f.called("build")
+	for _, want := range []string{
+		"-f " + filepath.Join(dir, "svc", "Containerfile"),
+		"--label loom.bead=loom-42",
+		"--build-arg GOOS=linux --build-arg VERSION=1.2",
+		"--target runtime " + filepath.Join(dir, "svc"),
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("build args %q are missing %q", args, want)
+		}
+	}
+	if strings.Contains(args, "--progress") {
+		t.Error("podman builds should not pass --progress")
+	}
+
+	for _, bad := range []BuildOptions{
+		{Context: "../other"},
+		{Dockerfile: "missing/Dockerfile"},
+		{Tag: "-rm"},
+		{Context: "svc", BuildArgs: map[string]string{"BAD KEY": "x"}},
+		{Context: "svc", Target: "--push"},
+	} {
+		if _, err := m.Build(context.Background(), "p", bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestRuntimeSelection(t *testing.T) {
+	m, _, _ := fakeManager(t)
+	if _, err := m.Build(context.Background(), "p", BuildOptions{}); err == nil || !strings.Contains(err.Error(), "no container runtime") {
+		t.Errorf("expected a missing runtime error, got %v", err)
+	}
+	m, _, _ = fakeManager(t, Docker, Podman)
+	if rt, _ := m.runtime(); rt != Docker {
+		t.Errorf("runtime() = %q, want docker first", rt)
+	}
+	m.Runtime = "lxc"
+	if _, err := m.runtime(); err == nil {
+		t.Error("expected an unsupported runtime to be rejected")
+	}
+}
+
+func TestRunWaitsForExit(t *testing.T) {
+	m, f, _ := fakeManager(t, Docker)
+	f.handlers["run"] = func(args []string) ([]byte, []byte, error) {
+		return []byte("0123456789abcdef0123\n"), nil, nil
+	}
+	f.handlers["inspect"] = func(args []string) ([]byte, []byte, error) {
+		return []byte("false 3\n"), nil, nil
+	}
+	f.handlers["logs"] = func(args []string) ([]byte, []byte, error) {
+		return []byte("2026-10-16T10:00:00.000000001Z starting\n2026-10-16T10:00:02Z done\n"),
+			[]byte("2026-10-16T10:00:01Z panic: boom\n"), nil
+	}
+
+	result, err := m.Run(context.Background(), "p", RunOptions{
+		Image:   "loom/p:latest",
+		Command: []string{"./smoke", "--fast"},
+		Env:     map[string]string{"MODE": "test"},
+		BeadID:  "loom-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || result.ExitCode == nil || *result.ExitCode != 3 || result.Running || result.ContainerID != "0123456789ab" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(result.Logs) != 3 || result.Logs[1].Stream != "stderr" || result.Logs[1].Text != "panic: boom" {
+		t.Errorf("logs were not merged by time: %+v", result.Logs)
+	}
+	args := f.called("run")
+	for _, want := range []string{"--memory 1g --cpus 2", "-e MODE=test", "loom/p:latest ./smoke --fast"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("run args %q are missing %q", args, want)
+		}
+	}
+	if f.called("wait") == "" || f.called("rm") == "" {
+		t.Errorf("expected the run to wait for and remove the container: %v", f.Calls)
+	}
+}
+
+func TestRunHealthCheck(t *testing.T) {
+	m, f, _ := fakeManager(t, Docker)
+	f.handlers["run"] = func(args []string) ([]byte, []byte, error) { return []byte("abc\n"), nil, nil }
+	f.handlers["port"] = func(args []string) ([]byte, []byte, error) {
+		return []byte("127.0.0.1:49153\n"), nil, nil
+	}
+	f.handlers["inspect"] = func(args []string) ([]byte, []byte, error) {
+		if strings.Contains(args[2], "ExitCode") {
+			return []byte("true 0\n"), nil, nil
+		}
+		return []byte("true\n"), nil, nil
+	}
+	var urls []string
+	m.httpGet = func(ctx context.Context, url string) (int, error) {
+		urls = append(urls, url)
+		if len(urls) == 1 {
+			return 0, errors.New("connection refused")
+		}
+		return 200, nil
+	}
+
+	result, err := m.Run(context.Background(), "p", RunOptions{
+		Image:  "app:dev",
+		Ports:  []string{"80"},
+		Health: &HealthCheck{Path: "healthz"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success || result.Healthy == nil || !*result.Healthy || result.ExitCode != nil || !result.Running || result.HealthError != "" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(urls) != 2 || urls[1] != "http://127.0.0.1:49153/healthz" {
+		t.Errorf("unexpected health check URLs %v", urls)
+	}
+	if args := f.called("run"); !strings.Contains(args, "-p 127.0.0.1::80 ") {
+		t.Errorf("expected port 80 to be published on localhost: %q", args)
+	}
+	if f.called("wait") != "" || f.called("rm") == "" {
+		t.Errorf("expected no wait and a removal: %v", f.Calls)
+	}
+}
+
+func TestRunHealthCheckContainerExits(t *testing.T) {
+	m, f, _ := fakeManager(t, Docker)
+	f.handlers["run"] = func(args []string) ([]byte, []byte, error) { return []byte("abc\n"), nil, nil }
+	f.handlers["port"] = func(args []string) ([]byte, []byte, error) { return []byte("127.0.0.1:8080\n"), nil, nil }
+	f.handlers["inspect"] = func(args []string) ([]byte, []byte, error) {
+		if strings.Contains(args[2], "ExitCode") {
+			return []byte("false 1\n"), nil, nil
+		}
+		return []byte("false\n"), nil, nil
+	}
+	m.httpGet = func(ctx context.Context, url string) (int, error) { return 0, errors.New("connection refused") }
+
+	result, err := m.Run(context.Background(), "p", RunOptions{Image: "app", Ports: []string{"8080:80"}, Health: &HealthCheck{}, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || *result.Healthy || result.ExitCode == nil || *result.ExitCode != 1 || !strings.Contains(result.HealthError, "exited") {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestRunRejectsBadOptions(t *testing.T) {
+	m, f, _ := fakeManager(t, Docker)
+	for _, bad := range []RunOptions{
+		{Image: "--privileged"},
+		{Image: "app", Ports: []string{"0.0.0.0:80:80"}},
+		{Image: "app", Ports: []string{"70000"}},
+		{Image: "app", Env: map[string]string{"1X": "y"}},
+		{Image: "app", Health: &HealthCheck{}},
+		{Image: "app", Ports: []string{"80"}, Health: &HealthCheck{Port: 443}},
+	} {
+		if _, err := m.Run(context.Background(), "p", bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+	if len(f.Calls) != 0 {
+		t.Errorf("rejected runs should not start containers: %v", f.Calls)
+	}
+}
@@ -0,0 +1,374 @@
+// Package contextpack assembles model prompts within a token budget.
+//
+// Callers describe everything that could go into a prompt — the system
+// prompt, the conversation, workflow state, file excerpts and memories —
+// and a Packer decides what fits. The system prompt and pinned messages are
+// always kept; the remaining budget is filled in priority order and
+// everything left out is reported so callers can see what the model did
+// not get.
+package contextpack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/provider"
+)
+
+// Kind identifies a class of optional prompt context
+type Kind string
+
+const (
+	KindMessages Kind = "messages"
+	KindWorkflow Kind = "workflow"
+	KindFiles    Kind = "files"
+	KindMemories Kind = "memories"
+)
+
+// DefaultPriorities fills the budget with the (small) workflow state first,
+// then recent messages, file excerpts and memories
+var DefaultPriorities = []Kind{KindWorkflow, KindMessages, KindFiles, KindMemories}
+
+// minExcerptTokens is the smallest partial file excerpt worth including
+const minExcerptTokens = 64
+
+// EstimateTokens approximates the token count of s (~4 characters per token)
+func EstimateTokens(s string) int {
+	return len(s) / 4
+}
+
+// Message is a conversation message. Pinned messages, such as the task
+// description, are never dropped.
+type Message struct {
+	Role    string
+	Content string
+	Parts   []provider.ContentPart // Images and other non-text parts
+	Pinned  bool
+}
+
+// tokens estimates the message's size with count, counting each image part
+// as provider.ImageTokens
+func (m Message) tokens(count func(string) int) int {
+	t := count(m.Content)
+	for _, p := range m.Parts {
+		if p.Type == provider.ContentPartImageURL {
+			t += provider.ImageTokens
+		} else {
+			t += count(p.Text)
+		}
+	}
+	return t
+}
+
+// File is an excerpt of a project file relevant to the task
+type File struct {
+	Path    string
+	Content string
+}
+
+// Input is everything a prompt could contain
+type Input struct {
+	// System is the system prompt; it is always kept
+	System string
+	// Messages is the conversation after the system prompt, oldest first
+	Messages []Message
+	// Workflow describes the bead's current workflow state
+	Workflow string
+	// Files are relevant file excerpts, most relevant first
+	Files []File
+	// Memories are recalled lessons or notes, most relevant first
+	Memories []string
+}
+
+// Dropped records context left out of a packed prompt
+type Dropped struct {
+	Kind   Kind   `json:"kind"`
+	Label  string `json:"label"`
+	Tokens int    `json:"tokens"`
+	// Count is the number of messages when Kind is messages
+	Count int `json:"count,omitempty"`
+	// Truncated is set when part of the item was still included
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Result is a packed prompt
+type Result struct {
+	Messages []provider.ChatMessage `json:"-"`
+	Budget   int                    `json:"budget"`
+	Tokens   int                    `json:"tokens"`
+	Dropped  []Dropped              `json:"dropped,omitempty"`
+}
+
+// DroppedTokens is the estimated size of everything left out
+func (r *Result) DroppedTokens() int {
+	total := 0
+	for _, d := range r.Dropped {
+		total += d.Tokens
+	}
+	return total
+}
+
+// Summary describes what was left out, or "" when everything fit
+func (r *Result) Summary() string {
+	if len(r.Dropped) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(r.Dropped))
+	for _, d := range r.Dropped {
+		label := d.Label
+		if d.Truncated {
+			label += " (truncated)"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", d.Kind, label))
+	}
+	return fmt.Sprintf("dropped ~%d of %d tokens: %s", r.DroppedTokens(), r.Tokens+r.DroppedTokens(), strings.Join(parts, ", "))
+}
+
+// Packer packs prompts within a token budget
+type Packer struct {
+	// Budget is the token budget; 0 or less packs everything
+	Budget int
+	// Priorities orders optional context. Kinds not listed follow in
+	// DefaultPriorities order.
+	Priorities []Kind
+	// Count estimates the tokens of a piece of text; nil uses
+	// EstimateTokens
+	Count func(string) int
+}
+
+// NewPacker creates a packer. Nil priorities use DefaultPriorities.
+func NewPacker(budget int, priorities []Kind) *Packer {
+	return &Packer{Budget: budget, Priorities: priorities}
+}
+
+// ParsePriorities converts configured kind names into priorities
+func ParsePriorities(names []string) ([]Kind, error) {
+	var kinds []Kind
+	seen := map[Kind]bool{}
+	for _, name := range names {
+		kind := Kind(strings.ToLower(strings.TrimSpace(name)))
+		if !validKind(kind) {
+			return nil, fmt.Errorf("unknown context kind %q", name)
+		}
+		if !seen[kind] {
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
+
+func validKind(kind Kind) bool {
+	for _, k := range DefaultPriorities {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Packer) order() []Kind {
+	order := make([]Kind, 0, len(DefaultPriorities))
+	seen := map[Kind]bool{}
+	for _, kind := range append(append([]Kind{}, p.Priorities...), DefaultPriorities...) {
+		if validKind(kind) && !seen[kind] {
+			seen[kind] = true
+			order = append(order, kind)
+		}
+	}
+	return order
+}
+
+// packing tracks the remaining budget while a prompt is packed
+type packing struct {
+	unlimited bool
+	remaining int
+	result    *Result
+	count     func(string) int
+}
+
+func (s *packing) fits(tokens int) bool {
+	return s.unlimited || tokens <= s.remaining
+}
+
+func (s *packing) take(tokens int) {
+	s.remaining -= tokens
+	s.result.Tokens += tokens
+}
+
+func (s *packing) drop(d Dropped) {
+	s.result.Dropped = append(s.result.Dropped, d)
+}
+
+// Pack assembles the prompt: the system prompt followed by the rendered
+// workflow, file and memory sections, a note about dropped messages, and
+// the kept messages in their original order
+func (p *Packer) Pack(in Input) *Result {
+	result := &Result{Budget: p.Budget}
+	s := &packing{unlimited: p.Budget <= 0, remaining: p.Budget, result: result, count: p.Count}
+	if s.count == nil {
+		s.count = EstimateTokens
+	}
+
+	// Required context
+	s.take(s.count(in.System))
+	for _, m := range in.Messages {
+		if m.Pinned {
+			s.take(m.tokens(s.count))
+		}
+	}
+
+	keep := make([]bool, len(in.Messages))
+	for i, m := range in.Messages {
+		keep[i] = m.Pinned
+	}
+	var workflow string
+	var files, memories []string
+
+	for _, kind := range p.order() {
+		switch kind {
+		case KindMessages:
+			packMessages(s, in.Messages, keep)
+		case KindWorkflow:
+			if in.Workflow == "" {
+				continue
+			}
+			section := "## Workflow State\n\n" + in.Workflow
+			tokens := s.count(section)
+			if !s.fits(tokens) {
+				s.drop(Dropped{Kind: KindWorkflow, Label: "workflow state", Tokens: tokens})
+				continue
+			}
+			s.take(tokens)
+			workflow = section
+		case KindFiles:
+			files = packFiles(s, in.Files)
+		case KindMemories:
+			for _, mem := range in.Memories {
+				item := "- " + mem
+				tokens := s.count(item)
+				if !s.fits(tokens) {
+					s.drop(Dropped{Kind: KindMemories, Label: label(mem), Tokens: tokens})
+					continue
+				}
+				s.take(tokens)
+				memories = append(memories, item)
+			}
+		}
+	}
+
+	var sections []string
+	if workflow != "" {
+		sections = append(sections, workflow)
+	}
+	if len(files) > 0 {
+		sections = append(sections, "## Relevant Files\n\n"+strings.Join(files, "\n\n"))
+	}
+	if len(memories) > 0 {
+		sections = append(sections, "## Memories\n\n"+strings.Join(memories, "\n"))
+	}
+	system := in.System
+	if len(sections) > 0 {
+		system = strings.TrimRight(system, "\n") + "\n\n" + strings.Join(sections, "\n\n")
+	}
+	if system != "" {
+		result.Messages = append(result.Messages, provider.ChatMessage{Role: "system", Content: system})
+	}
+
+	dropped := 0
+	for _, k := range keep {
+		if !k {
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		result.Messages = append(result.Messages, provider.ChatMessage{
+			Role:    "system",
+			Content: fmt.Sprintf("[Note: %d older messages truncated to stay within token limit]", dropped),
+		})
+	}
+	for i, m := range in.Messages {
+		if keep[i] {
+			result.Messages = append(result.Messages, provider.ChatMessage{Role: m.Role, Content: m.Content, Parts: m.Parts})
+		}
+	}
+	return result
+}
+
+// packMessages keeps the most recent unpinned messages that fit. Once one
+// doesn't fit, it and everything older is dropped so the kept history has
+// no gaps.
+func packMessages(s *packing, messages []Message, keep []bool) {
+	full := false
+	count, tokens := 0, 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		m := messages[i]
+		if m.Pinned {
+			continue
+		}
+		t := m.tokens(s.count)
+		if !full && s.fits(t) {
+			s.take(t)
+			keep[i] = true
+			continue
+		}
+		full = true
+		count++
+		tokens += t
+	}
+	if count > 0 {
+		s.drop(Dropped{Kind: KindMessages, Label: fmt.Sprintf("%d older messages", count), Tokens: tokens, Count: count})
+	}
+}
+
+// packFiles renders the file excerpts that fit, cutting the first one that
+// doesn't short when enough budget remains for a useful excerpt
+func packFiles(s *packing, files []File) []string {
+	var blocks []string
+	for _, f := range files {
+		block := renderFile(f.Path, f.Content)
+		tokens := s.count(block)
+		if s.fits(tokens) {
+			s.take(tokens)
+			blocks = append(blocks, block)
+			continue
+		}
+		overhead := s.count(renderFile(f.Path, "")) + 8
+		if avail := s.remaining - overhead; avail >= minExcerptTokens {
+			excerpt := renderFile(f.Path, cut(f.Content, avail*4)+"\n... (truncated)")
+			s.take(s.count(excerpt))
+			blocks = append(blocks, excerpt)
+			s.drop(Dropped{Kind: KindFiles, Label: f.Path, Tokens: tokens - s.count(excerpt), Truncated: true})
+			continue
+		}
+		s.drop(Dropped{Kind: KindFiles, Label: f.Path, Tokens: tokens})
+	}
+	return blocks
+}
+
+func renderFile(path, content string) string {
+	return fmt.Sprintf("### %s\n```\n%s\n```", path, content)
+}
+
+// cut shortens s to at most n bytes, preferring a line boundary
+func cut(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	if i := strings.LastIndexByte(s, '\n'); i > n/2 {
+		s = s[:i]
+	}
+	return s
+}
+
+func label(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > 60 {
+		s = s[:60] + "..."
+	}
+	return fmt.Sprintf("%q", s)
+}
@@ -0,0 +1,144 @@
+package contextpack
+
+import (
+	"strings"
+	"testing"
+)
+
+func words(n int) string {
+	return strings.Repeat("word ", n) // ~1.25 tokens per word
+}
+
+func TestPackEverythingFits(t *testing.T) {
+	in := Input{
+		System:   "You are an agent.",
+		Messages: []Message{{Role: "user", Content: "fix the bug", Pinned: true}, {Role: "assistant", Content: "ok"}},
+		Workflow: "Current step: implement",
+		Files:    []File{{Path: "main.go", Content: "package main"}},
+		Memories: []string{"run go vet before pushing"},
+	}
+	res := NewPacker(1000, nil).Pack(in)
+	if len(res.Dropped) != 0 || res.Summary() != "" {
+		t.Fatalf("unexpected drops: %+v", res.Dropped)
+	}
+	if len(res.Messages) != 3 {
+		t.Fatalf("expected system + 2 messages, got %d", len(res.Messages))
+	}
+	system := res.Messages[0].Content
+	for _, want := range []string{"You are an agent.", "## Workflow State", "### main.go", "## Memories", "- run go vet"} {
+		if !strings.Contains(system, want) {
+			t.Errorf("system prompt missing %q:\n%s", want, system)
+		}
+	}
+}
+
+func TestPackSystemUnchangedWithoutSections(t *testing.T) {
+	res := NewPacker(0, nil).Pack(Input{System: "prompt\n\n", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if res.Messages[0].Content != "prompt\n\n" {
+		t.Errorf("system prompt changed: %q", res.Messages[0].Content)
+	}
+}
+
+func TestPackDropsOldestMessagesKeepsPinned(t *testing.T) {
+	in := Input{System: "sys", Messages: []Message{{Role: "user", Content: words(40), Pinned: true}}}
+	for i := 0; i < 10; i++ {
+		in.Messages = append(in.Messages, Message{Role: "assistant", Content: words(40)})
+	}
+	res := NewPacker(200, nil).Pack(in)
+
+	if res.Tokens > 200 {
+		t.Errorf("packed %d tokens over budget", res.Tokens)
+	}
+	if res.Messages[1].Role != "system" || !strings.HasPrefix(res.Messages[1].Content, "[Note:") {
+		t.Fatalf("expected truncation note, got %+v", res.Messages[1])
+	}
+	if res.Messages[2].Content != in.Messages[0].Content {
+		t.Error("pinned task message should be kept first")
+	}
+	if last := res.Messages[len(res.Messages)-1]; last.Content != in.Messages[10].Content {
+		t.Error("most recent message should be kept")
+	}
+	if len(res.Dropped) != 1 || res.Dropped[0].Kind != KindMessages || res.Dropped[0].Count != 11-(len(res.Messages)-2) {
+		t.Errorf("dropped = %+v", res.Dropped)
+	}
+}
+
+func TestPackPriorities(t *testing.T) {
+	in := Input{
+		System:   "sys",
+		Messages: []Message{{Role: "user", Content: words(60)}},
+		Files:    []File{{Path: "a.go", Content: words(60)}},
+		Memories: []string{words(60)},
+	}
+
+	res := NewPacker(100, nil).Pack(in)
+	if !droppedKind(res, KindMemories) || droppedKind(res, KindMessages) {
+		t.Errorf("default priorities should keep messages and drop memories: %s", res.Summary())
+	}
+
+	res = NewPacker(100, []Kind{KindMemories, KindFiles}).Pack(in)
+	if droppedKind(res, KindMemories) || !droppedKind(res, KindMessages) {
+		t.Errorf("memories first should drop messages: %s", res.Summary())
+	}
+}
+
+func TestPackTruncatesFileExcerpt(t *testing.T) {
+	lines := strings.Repeat("some line of code\n", 200)
+	res := NewPacker(300, nil).Pack(Input{System: "sys", Files: []File{{Path: "big.go", Content: lines}}})
+
+	if len(res.Dropped) != 1 || !res.Dropped[0].Truncated || res.Dropped[0].Label != "big.go" {
+		t.Fatalf("dropped = %+v", res.Dropped)
+	}
+	if !strings.Contains(res.Messages[0].Content, "... (truncated)") {
+		t.Error("expected truncated excerpt in prompt")
+	}
+	if res.Tokens > 300 {
+		t.Errorf("packed %d tokens over budget", res.Tokens)
+	}
+}
+
+func TestPackUsesCustomCount(t *testing.T) {
+	// A reference standing for large content is counted at its full size
+	count := func(s string) int {
+		if s == "[[ref]]" {
+			return 500
+		}
+		return EstimateTokens(s)
+	}
+	in := Input{Messages: []Message{{Role: "user", Content: "[[ref]]"}, {Role: "user", Content: "next"}}}
+	packer := NewPacker(100, nil)
+	packer.Count = count
+	res := packer.Pack(in)
+	if last := res.Messages[len(res.Messages)-1]; len(res.Messages) != 2 || last.Content != "next" {
+		t.Fatalf("expected the referenced content to be dropped, got %+v", res.Messages)
+	}
+	if res.DroppedTokens() != 500 {
+		t.Errorf("expected 500 dropped tokens, got %d", res.DroppedTokens())
+	}
+}
+
+func TestParsePriorities(t *testing.T) {
+	kinds, err := ParsePriorities([]string{"Files", " memories", "files"})
+	if err != nil {
+		t.Fatalf("ParsePriorities: %v", err)
+	}
+	if len(kinds) != 2 || kinds[0] != KindFiles || kinds[1] != KindMemories {
+		t.Errorf("kinds = %v", kinds)
+	}
+	order := NewPacker(0, kinds).order()
+	if len(order) != 4 || order[2] != KindWorkflow || order[3] != KindMessages {
+		t.Errorf("order = %v", order)
+	}
+	if _, err := ParsePriorities([]string{"emails"}); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
+
+func droppedKind(res *Result, kind Kind) bool {
+	for _, d := range res.Dropped {
+		if d.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
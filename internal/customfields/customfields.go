@@ -0,0 +1,308 @@
+// Package customfields lets each project declare typed fields for its
+// beads, such as a ticket URL, customer or severity, and validates the
+// values beads carry against them.
+package customfields
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// dateLayout is how date values are stored
+const dateLayout = "2006-01-02"
+
+// maxStringLen caps string field values
+const maxStringLen = 4096
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// defaultWidgets are the rendering hints for fields that don't set one
+var defaultWidgets = map[string]string{
+	models.FieldTypeString: "text",
+	models.FieldTypeNumber: "number",
+	models.FieldTypeEnum:   "select",
+	models.FieldTypeDate:   "date",
+}
+
+var validWidgets = map[string]bool{"text": true, "textarea": true, "url": true, "select": true, "date": true, "number": true}
+
+// Store persists field definitions
+type Store interface {
+	UpsertFieldDefinition(def *models.BeadFieldDefinition) error
+	ListFieldDefinitions() ([]*models.BeadFieldDefinition, error)
+	DeleteFieldDefinition(projectID, name string) error
+}
+
+// Registry holds each project's field definitions
+type Registry struct {
+	mu    sync.RWMutex
+	store Store
+	defs  map[string]map[string]*models.BeadFieldDefinition // project -> name -> definition
+	now   func() time.Time
+}
+
+// New creates a registry. store may be nil, in which case definitions only
+// live in memory.
+func New(store Store) *Registry {
+	return &Registry{
+		store: store,
+		defs:  make(map[string]map[string]*models.BeadFieldDefinition),
+		now:   time.Now,
+	}
+}
+
+// Load reads persisted definitions into memory
+func (r *Registry) Load() error {
+	if r.store == nil {
+		return nil
+	}
+	list, err := r.store.ListFieldDefinitions()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, def := range list {
+		r.put(def)
+	}
+	return nil
+}
+
+// put caches a definition. Callers must hold r.mu.
+func (r *Registry) put(def *models.BeadFieldDefinition) {
+	project := r.defs[def.ProjectID]
+	if project == nil {
+		project = make(map[string]*models.BeadFieldDefinition)
+		r.defs[def.ProjectID] = project
+	}
+	project[def.Name] = def
+}
+
+// Define creates or replaces a field definition
+func (r *Registry) Define(def *models.BeadFieldDefinition) (*models.BeadFieldDefinition, error) {
+	if def == nil {
+		return nil, apperr.Validation("field definition is required")
+	}
+	d := *def
+	d.Options = append([]string(nil), def.Options...)
+	if d.ProjectID == "" {
+		return nil, apperr.Validation("project_id is required")
+	}
+	if !namePattern.MatchString(d.Name) {
+		return nil, apperr.Validation("field name %q must be lowercase letters, digits and underscores, starting with a letter", d.Name)
+	}
+	if _, ok := defaultWidgets[d.Type]; !ok {
+		return nil, apperr.Validation("field type must be string, number, enum or date")
+	}
+	if d.Type == models.FieldTypeEnum {
+		seen := make(map[string]bool, len(d.Options))
+		for _, o := range d.Options {
+			if o == "" || seen[o] {
+				return nil, apperr.Validation("enum options must be unique and non-empty")
+			}
+			seen[o] = true
+		}
+		if len(d.Options) == 0 {
+			return nil, apperr.Validation("enum field %q needs options", d.Name)
+		}
+	} else {
+		d.Options = nil
+	}
+	if d.Widget == "" {
+		d.Widget = defaultWidgets[d.Type]
+	} else if !validWidgets[d.Widget] {
+		return nil, apperr.Validation("unknown widget %q", d.Widget)
+	}
+	if d.Label == "" {
+		d.Label = d.Name
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	d.CreatedAt, d.UpdatedAt = now, now
+	if existing := r.defs[d.ProjectID][d.Name]; existing != nil {
+		d.CreatedAt = existing.CreatedAt
+	}
+	if r.store != nil {
+		if err := r.store.UpsertFieldDefinition(&d); err != nil {
+			return nil, err
+		}
+	}
+	r.put(&d)
+	c := d
+	return &c, nil
+}
+
+// Get returns a project's field definition, or nil when there is none
+func (r *Registry) Get(projectID, name string) *models.BeadFieldDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if def := r.defs[projectID][name]; def != nil {
+		c := *def
+		return &c
+	}
+	return nil
+}
+
+// List returns a project's field definitions in display order, or every
+// project's when projectID is empty
+func (r *Registry) List(projectID string) []*models.BeadFieldDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := []*models.BeadFieldDefinition{}
+	for pid, project := range r.defs {
+		if projectID != "" && pid != projectID {
+			continue
+		}
+		for _, def := range project {
+			c := *def
+			list = append(list, &c)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].ProjectID != list[j].ProjectID {
+			return list[i].ProjectID < list[j].ProjectID
+		}
+		if list[i].Order != list[j].Order {
+			return list[i].Order < list[j].Order
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Delete removes a field definition. Values already on beads are kept but
+// no longer validated.
+func (r *Registry) Delete(projectID, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.defs[projectID][name] == nil {
+		return apperr.NotFound("field not found: %s", name)
+	}
+	if r.store != nil {
+		if err := r.store.DeleteFieldDefinition(projectID, name); err != nil {
+			return err
+		}
+	}
+	delete(r.defs[projectID], name)
+	return nil
+}
+
+// Validate checks values for a project's bead against its field
+// definitions and returns them normalized: numbers as float64 and dates as
+// YYYY-MM-DD. A nil value clears a field, unless it is required. Required
+// fields must all be given when creating a bead.
+func (r *Registry) Validate(projectID string, values map[string]interface{}, creating bool) (map[string]interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	defs := r.defs[projectID]
+	out := make(map[string]interface{}, len(values))
+	for name, raw := range values {
+		def := defs[name]
+		if def == nil {
+			return nil, apperr.Validation("unknown field %q", name)
+		}
+		if raw == nil {
+			if def.Required {
+				return nil, apperr.Validation("field %q is required", name)
+			}
+			out[name] = nil
+			continue
+		}
+		v, err := normalize(def, raw)
+		if err != nil {
+			return nil, apperr.Validation("field %q: %v", name, err)
+		}
+		out[name] = v
+	}
+	if creating {
+		for name, def := range defs {
+			if _, ok := out[name]; def.Required && !ok {
+				return nil, apperr.Validation("field %q is required", name)
+			}
+		}
+	}
+	return out, nil
+}
+
+func normalize(def *models.BeadFieldDefinition, raw interface{}) (interface{}, error) {
+	switch def.Type {
+	case models.FieldTypeNumber:
+		switch v := raw.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case json.Number:
+			return v.Float64()
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", v)
+			}
+			return f, nil
+		}
+		return nil, fmt.Errorf("expected a number")
+	case models.FieldTypeDate:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a date (YYYY-MM-DD)")
+		}
+		if t, err := time.Parse(dateLayout, s); err == nil {
+			return t.Format(dateLayout), nil
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a date (YYYY-MM-DD)", s)
+		}
+		return t.UTC().Format(dateLayout), nil
+	case models.FieldTypeEnum:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected one of %s", strings.Join(def.Options, ", "))
+		}
+		for _, o := range def.Options {
+			if o == s {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not one of %s", s, strings.Join(def.Options, ", "))
+	default:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string")
+		}
+		if len(s) > maxStringLen {
+			return nil, fmt.Errorf("longer than %d bytes", maxStringLen)
+		}
+		return s, nil
+	}
+}
+
+// SearchText returns the values of a bead's searchable fields as text for
+// the search index
+func (r *Registry) SearchText(b *models.Bead) string {
+	if len(b.Fields) == 0 {
+		return ""
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var parts []string
+	for _, def := range r.defs[b.ProjectID] {
+		if v, ok := b.Fields[def.Name]; ok && v != nil && def.Searchable {
+			parts = append(parts, fmt.Sprintf("%s: %v", def.Label, v))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,103 @@
+package customfields
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func newRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := New(nil)
+	defs := []*models.BeadFieldDefinition{
+		{ProjectID: "p1", Name: "ticket_url", Type: models.FieldTypeString, Widget: "url", Searchable: true},
+		{ProjectID: "p1", Name: "severity", Type: models.FieldTypeEnum, Options: []string{"low", "high"}, Required: true, Order: -1},
+		{ProjectID: "p1", Name: "customer_count", Type: models.FieldTypeNumber},
+		{ProjectID: "p1", Name: "go_live", Type: models.FieldTypeDate},
+	}
+	for _, def := range defs {
+		if _, err := r.Define(def); err != nil {
+			t.Fatalf("Define(%s): %v", def.Name, err)
+		}
+	}
+	return r
+}
+
+func TestDefine(t *testing.T) {
+	r := newRegistry(t)
+	list := r.List("p1")
+	if len(list) != 4 || list[0].Name != "severity" {
+		t.Fatalf("List = %+v, want severity first", list)
+	}
+	if def := r.Get("p1", "customer_count"); def.Widget != "number" || def.Label != "customer_count" {
+		t.Errorf("defaults not applied: %+v", def)
+	}
+
+	bad := []*models.BeadFieldDefinition{
+		{ProjectID: "p1", Name: "Bad Name", Type: models.FieldTypeString},
+		{ProjectID: "p1", Name: "kind", Type: "boolean"},
+		{ProjectID: "p1", Name: "tier", Type: models.FieldTypeEnum},
+		{ProjectID: "p1", Name: "tier", Type: models.FieldTypeEnum, Options: []string{"a", "a"}},
+		{ProjectID: "p1", Name: "notes", Type: models.FieldTypeString, Widget: "slider"},
+		{Name: "orphan", Type: models.FieldTypeString},
+	}
+	for _, def := range bad {
+		if _, err := r.Define(def); err == nil {
+			t.Errorf("Define(%+v) should fail", def)
+		}
+	}
+
+	if err := r.Delete("p1", "go_live"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := r.Delete("p1", "go_live"); err == nil {
+		t.Error("second Delete should fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	r := newRegistry(t)
+
+	got, err := r.Validate("p1", map[string]interface{}{
+		"severity":       "high",
+		"customer_count": "12",
+		"go_live":        "2026-03-01T10:00:00Z",
+	}, true)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if got["customer_count"] != 12.0 || got["go_live"] != "2026-03-01" {
+		t.Errorf("values not normalized: %v", got)
+	}
+
+	cases := []map[string]interface{}{
+		{"severity": "medium"},
+		{"severity": "low", "customer_count": "many"},
+		{"severity": "low", "go_live": "March"},
+		{"severity": "low", "unknown": "x"},
+		{"severity": nil},
+		{"ticket_url": 42, "severity": "low"},
+	}
+	for _, values := range cases {
+		if _, err := r.Validate("p1", values, false); err == nil {
+			t.Errorf("Validate(%v) should fail", values)
+		}
+	}
+
+	if _, err := r.Validate("p1", map[string]interface{}{"ticket_url": "https://x"}, true); err == nil {
+		t.Error("missing required field should fail on create")
+	}
+	if _, err := r.Validate("p1", map[string]interface{}{"ticket_url": nil}, false); err != nil {
+		t.Errorf("clearing an optional field: %v", err)
+	}
+}
+
+func TestSearchText(t *testing.T) {
+	r := newRegistry(t)
+	b := &models.Bead{ProjectID: "p1", Fields: map[string]interface{}{"ticket_url": "https://tracker/ABC-1", "severity": "high"}}
+	text := r.SearchText(b)
+	if !strings.Contains(text, "ABC-1") || strings.Contains(text, "high") {
+		t.Errorf("SearchText = %q, want only searchable fields", text)
+	}
+}
@@ -0,0 +1,357 @@
+// Package dashboard assembles the aggregate views the Web UI polls: active
+// agents with their current beads, per-project burn-down of open beads, cost
+// over time, the anomaly feed and recent escalations. Each section reads its
+// source once, so a full dashboard costs a handful of queries instead of one
+// client call per agent or project.
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/internal/patterns"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Sections
+const (
+	SectionAgents      = "agents"
+	SectionBurndown    = "burndown"
+	SectionCosts       = "costs"
+	SectionAnomalies   = "anomalies"
+	SectionEscalations = "escalations"
+)
+
+// AllSections lists every section in response order
+var AllSections = []string{SectionAgents, SectionBurndown, SectionCosts, SectionAnomalies, SectionEscalations}
+
+// maxEscalations caps the escalation feed
+const maxEscalations = 50
+
+// AgentSource lists agents
+type AgentSource interface {
+	ListAgents() []*models.Agent
+}
+
+// BeadSource lists beads
+type BeadSource interface {
+	ListBeads(filters map[string]interface{}) ([]*models.Bead, error)
+}
+
+// CostSource totals spend per time bucket
+type CostSource interface {
+	GetCostSeries(ctx context.Context, filter *analytics.LogFilter, bucket time.Duration) ([]*analytics.CostPoint, error)
+}
+
+// AnomalySource reports anomalies found by the patterns analyzer
+type AnomalySource interface {
+	GetAnomalies(ctx context.Context) ([]*patterns.PatternAnomaly, error)
+}
+
+// Sources are the subsystems a dashboard reads. Nil sources leave their
+// sections empty.
+type Sources struct {
+	Agents    AgentSource
+	Beads     BeadSource
+	Costs     CostSource
+	Anomalies AnomalySource
+}
+
+// Options select what a dashboard covers
+type Options struct {
+	ProjectID string
+	Sections  []string // empty means all
+	Days      int      // burn-down and cost window, default 14
+	Now       time.Time
+}
+
+// Dashboard is the combined response. Sections that were not requested are
+// omitted; a section whose source failed is listed in Errors.
+type Dashboard struct {
+	GeneratedAt time.Time                  `json:"generated_at"`
+	ProjectID   string                     `json:"project_id,omitempty"`
+	Agents      []*ActiveAgent             `json:"agents,omitempty"`
+	Burndown    []*ProjectBurndown         `json:"burndown,omitempty"`
+	Costs       []*analytics.CostPoint     `json:"costs,omitempty"`
+	Anomalies   []*patterns.PatternAnomaly `json:"anomalies,omitempty"`
+	Escalations []*Escalation              `json:"escalations,omitempty"`
+	Errors      map[string]string          `json:"errors,omitempty"`
+}
+
+// ActiveAgent is an agent that is not idle or paused, with its current bead
+type ActiveAgent struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	Role       string       `json:"role,omitempty"`
+	Status     string       `json:"status"`
+	ProjectID  string       `json:"project_id"`
+	LastActive time.Time    `json:"last_active"`
+	Bead       *BeadSummary `json:"bead,omitempty"`
+}
+
+// BeadSummary is the part of a bead a dashboard tile shows
+type BeadSummary struct {
+	ID       string              `json:"id"`
+	Title    string              `json:"title"`
+	Status   models.BeadStatus   `json:"status"`
+	Priority models.BeadPriority `json:"priority"`
+}
+
+// ProjectBurndown tracks a project's open beads by priority. Open holds the
+// current counts; Series holds the count at the end of each day in the window.
+type ProjectBurndown struct {
+	ProjectID string           `json:"project_id"`
+	Open      map[string]int   `json:"open"`
+	Series    []*BurndownPoint `json:"series"`
+}
+
+// BurndownPoint is the open count at the end of a day
+type BurndownPoint struct {
+	Date       string         `json:"date"`
+	Total      int            `json:"total"`
+	ByPriority map[string]int `json:"by_priority"`
+}
+
+// Escalation is a bead raised to the CEO, either directly or by a workflow
+type Escalation struct {
+	BeadID      string            `json:"bead_id"`
+	ProjectID   string            `json:"project_id"`
+	Title       string            `json:"title"`
+	Kind        string            `json:"kind"` // "ceo" or "workflow"
+	Reason      string            `json:"reason,omitempty"`
+	DecisionID  string            `json:"decision_id,omitempty"`
+	Status      models.BeadStatus `json:"status"`
+	EscalatedAt time.Time         `json:"escalated_at"`
+}
+
+// Builder assembles dashboards from its sources
+type Builder struct {
+	sources Sources
+}
+
+// NewBuilder creates a builder reading from sources
+func NewBuilder(sources Sources) *Builder {
+	return &Builder{sources: sources}
+}
+
+func validSection(name string) bool {
+	for _, s := range AllSections {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Build assembles the requested sections. Beads are listed once and shared by
+// the agents, burn-down and escalation sections.
+func (b *Builder) Build(ctx context.Context, opts Options) *Dashboard {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.Days <= 0 {
+		opts.Days = 14
+	}
+	want := map[string]bool{}
+	for _, s := range opts.Sections {
+		want[s] = true
+	}
+	if len(want) == 0 {
+		for _, s := range AllSections {
+			want[s] = true
+		}
+	}
+
+	d := &Dashboard{GeneratedAt: opts.Now.UTC(), ProjectID: opts.ProjectID}
+	fail := func(section string, err error) {
+		if d.Errors == nil {
+			d.Errors = map[string]string{}
+		}
+		d.Errors[section] = err.Error()
+	}
+
+	var beads map[string]*models.Bead
+	if (want[SectionAgents] || want[SectionBurndown] || want[SectionEscalations]) && b.sources.Beads != nil {
+		filters := map[string]interface{}{}
+		if opts.ProjectID != "" {
+			filters["project_id"] = opts.ProjectID
+		}
+		list, err := b.sources.Beads.ListBeads(filters)
+		if err != nil {
+			for _, s := range []string{SectionAgents, SectionBurndown, SectionEscalations} {
+				if want[s] {
+					fail(s, err)
+				}
+			}
+		}
+		beads = make(map[string]*models.Bead, len(list))
+		for _, bead := range list {
+			beads[bead.ID] = bead
+		}
+	}
+
+	if want[SectionAgents] && b.sources.Agents != nil {
+		d.Agents = activeAgents(b.sources.Agents.ListAgents(), beads, opts.ProjectID)
+	}
+	if want[SectionBurndown] {
+		d.Burndown = burndown(beads, opts.Now, opts.Days)
+	}
+	if want[SectionCosts] && b.sources.Costs != nil {
+		start := startOfDay(opts.Now).AddDate(0, 0, -(opts.Days - 1))
+		points, err := b.sources.Costs.GetCostSeries(ctx, &analytics.LogFilter{StartTime: start}, 24*time.Hour)
+		if err != nil {
+			fail(SectionCosts, err)
+		}
+		d.Costs = points
+	}
+	if want[SectionAnomalies] && b.sources.Anomalies != nil {
+		anomalies, err := b.sources.Anomalies.GetAnomalies(ctx)
+		if err != nil {
+			fail(SectionAnomalies, err)
+		}
+		sort.SliceStable(anomalies, func(i, j int) bool {
+			return anomalies[i].DetectedAt.After(anomalies[j].DetectedAt)
+		})
+		d.Anomalies = anomalies
+	}
+	if want[SectionEscalations] {
+		d.Escalations = escalations(beads)
+	}
+	return d
+}
+
+// activeAgents returns agents doing something, most recently active first,
+// with their current bead resolved from beads
+func activeAgents(agents []*models.Agent, beads map[string]*models.Bead, projectID string) []*ActiveAgent {
+	var out []*ActiveAgent
+	for _, a := range agents {
+		if a == nil || a.Status == "idle" || a.Status == "paused" {
+			continue
+		}
+		if projectID != "" && a.ProjectID != projectID {
+			continue
+		}
+		aa := &ActiveAgent{
+			ID:         a.ID,
+			Name:       a.Name,
+			Role:       a.Role,
+			Status:     a.Status,
+			ProjectID:  a.ProjectID,
+			LastActive: a.LastActive,
+		}
+		if bead, ok := beads[a.CurrentBead]; ok {
+			aa.Bead = &BeadSummary{ID: bead.ID, Title: bead.Title, Status: bead.Status, Priority: bead.Priority}
+		} else if a.CurrentBead != "" {
+			aa.Bead = &BeadSummary{ID: a.CurrentBead}
+		}
+		out = append(out, aa)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastActive.After(out[j].LastActive) })
+	return out
+}
+
+// burndown counts open beads per project and priority at the end of each of
+// the last days days. Decision beads are excluded; they are not work items.
+func burndown(all map[string]*models.Bead, now time.Time, days int) []*ProjectBurndown {
+	byProject := map[string]*ProjectBurndown{}
+	ends := make([]time.Time, days)
+	first := startOfDay(now).AddDate(0, 0, -(days - 1))
+	for i := range ends {
+		ends[i] = first.AddDate(0, 0, i+1)
+	}
+
+	for _, bead := range all {
+		if bead.Type == "decision" {
+			continue
+		}
+		pb, ok := byProject[bead.ProjectID]
+		if !ok {
+			pb = &ProjectBurndown{ProjectID: bead.ProjectID, Open: map[string]int{}, Series: make([]*BurndownPoint, days)}
+			for i, end := range ends {
+				pb.Series[i] = &BurndownPoint{Date: end.AddDate(0, 0, -1).Format("2006-01-02"), ByPriority: map[string]int{}}
+			}
+			byProject[bead.ProjectID] = pb
+		}
+
+		priority := priorityKey(bead.Priority)
+		closedAt := beads.ClosedTime(bead)
+		if closedAt == nil {
+			pb.Open[priority]++
+		}
+		for i, end := range ends {
+			if !bead.CreatedAt.Before(end) || (closedAt != nil && closedAt.Before(end)) {
+				continue
+			}
+			pb.Series[i].Total++
+			pb.Series[i].ByPriority[priority]++
+		}
+	}
+
+	out := make([]*ProjectBurndown, 0, len(byProject))
+	for _, pb := range byProject {
+		out = append(out, pb)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ProjectID < out[j].ProjectID })
+	return out
+}
+
+// escalations returns escalated beads, most recent first
+func escalations(beads map[string]*models.Bead) []*Escalation {
+	var out []*Escalation
+	for _, bead := range beads {
+		if bead.Context == nil {
+			continue
+		}
+		var e *Escalation
+		switch {
+		case bead.Context["escalated_to_ceo_at"] != "":
+			e = &Escalation{
+				Kind:       "ceo",
+				Reason:     bead.Context["escalated_to_ceo_reason"],
+				DecisionID: bead.Context["escalated_to_ceo_decision_id"],
+			}
+			e.EscalatedAt, _ = time.Parse(time.RFC3339, bead.Context["escalated_to_ceo_at"])
+		case bead.Context["escalated_at"] != "":
+			e = &Escalation{Kind: "workflow", Reason: bead.Context["escalation_reason"]}
+			e.EscalatedAt, _ = time.Parse(time.RFC3339, bead.Context["escalated_at"])
+		default:
+			continue
+		}
+		e.BeadID, e.ProjectID, e.Title, e.Status = bead.ID, bead.ProjectID, bead.Title, bead.Status
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].EscalatedAt.After(out[j].EscalatedAt) })
+	if len(out) > maxEscalations {
+		out = out[:maxEscalations]
+	}
+	return out
+}
+
+func priorityKey(p models.BeadPriority) string {
+	return "P" + strconv.Itoa(int(p))
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseSections validates section names, dropping empty ones
+func ParseSections(list []string) ([]string, error) {
+	var out []string
+	for _, s := range list {
+		if s == "" {
+			continue
+		}
+		if !validSection(s) {
+			return nil, fmt.Errorf("unknown section: %s", s)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/patterns"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeAgents []*models.Agent
+
+func (f fakeAgents) ListAgents() []*models.Agent { return f }
+
+type fakeBeads []*models.Bead
+
+func (f fakeBeads) ListBeads(filters map[string]interface{}) ([]*models.Bead, error) {
+	var out []*models.Bead
+	for _, b := range f {
+		if p, ok := filters["project_id"].(string); ok && b.ProjectID != p {
+			continue
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+type fakeCosts struct{ calls int }
+
+func (f *fakeCosts) GetCostSeries(ctx context.Context, filter *analytics.LogFilter, bucket time.Duration) ([]*analytics.CostPoint, error) {
+	f.calls++
+	return []*analytics.CostPoint{{Start: filter.StartTime, CostUSD: 1.5, Requests: 3}}, nil
+}
+
+type failingAnomalies struct{}
+
+func (failingAnomalies) GetAnomalies(ctx context.Context) ([]*patterns.PatternAnomaly, error) {
+	return nil, errors.New("analysis failed")
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	closed := now.Add(-36 * time.Hour) // March 9
+	beads := fakeBeads{
+		{ID: "b1", ProjectID: "p1", Title: "Fix login", Status: models.BeadStatusInProgress, Priority: models.BeadPriorityP1, CreatedAt: now.AddDate(0, 0, -5)},
+		{ID: "b2", ProjectID: "p1", Status: models.BeadStatusClosed, Priority: models.BeadPriorityP2, CreatedAt: now.AddDate(0, 0, -5), ClosedAt: &closed},
+		{ID: "b3", ProjectID: "p1", Status: models.BeadStatusOpen, Priority: models.BeadPriorityP0, CreatedAt: now.Add(-time.Hour),
+			Context: map[string]string{"escalated_to_ceo_at": now.Add(-30 * time.Minute).Format(time.RFC3339), "escalated_to_ceo_reason": "stuck"}},
+		{ID: "d1", ProjectID: "p1", Type: "decision", Status: models.BeadStatusOpen, CreatedAt: now.AddDate(0, 0, -1)},
+		{ID: "x1", ProjectID: "p2", Status: models.BeadStatusOpen, CreatedAt: now.AddDate(0, 0, -1)},
+	}
+	agents := fakeAgents{
+		{ID: "a1", Name: "Engineer", Status: "working", CurrentBead: "b1", ProjectID: "p1"},
+		{ID: "a2", Name: "Reviewer", Status: "idle", ProjectID: "p1"},
+		{ID: "a3", Name: "Other", Status: "working", CurrentBead: "x1", ProjectID: "p2"},
+	}
+	costs := &fakeCosts{}
+	b := NewBuilder(Sources{Agents: agents, Beads: beads, Costs: costs, Anomalies: failingAnomalies{}})
+
+	d := b.Build(context.Background(), Options{ProjectID: "p1", Days: 3, Now: now})
+
+	if len(d.Agents) != 1 || d.Agents[0].ID != "a1" || d.Agents[0].Bead == nil || d.Agents[0].Bead.Title != "Fix login" {
+		t.Fatalf("unexpected agents: %+v", d.Agents)
+	}
+
+	if len(d.Burndown) != 1 || d.Burndown[0].ProjectID != "p1" {
+		t.Fatalf("unexpected burndown: %+v", d.Burndown)
+	}
+	bd := d.Burndown[0]
+	if bd.Open["P0"] != 1 || bd.Open["P1"] != 1 || bd.Open["P2"] != 0 {
+		t.Errorf("unexpected open counts: %v", bd.Open)
+	}
+	var totals []int
+	for _, p := range bd.Series {
+		totals = append(totals, p.Total)
+	}
+	// March 8: b1, b2 open; March 9: b2 closed; March 10: b3 created
+	if len(totals) != 3 || totals[0] != 2 || totals[1] != 1 || totals[2] != 2 {
+		t.Errorf("unexpected burndown totals: %v", totals)
+	}
+	if bd.Series[0].Date != "2026-03-08" {
+		t.Errorf("expected series to start 2026-03-08, got %s", bd.Series[0].Date)
+	}
+
+	if len(d.Costs) != 1 || !d.Costs[0].Start.Equal(time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected costs: %+v", d.Costs)
+	}
+
+	if len(d.Escalations) != 1 || d.Escalations[0].BeadID != "b3" || d.Escalations[0].Kind != "ceo" || d.Escalations[0].Reason != "stuck" {
+		t.Errorf("unexpected escalations: %+v", d.Escalations)
+	}
+
+	if d.Errors[SectionAnomalies] == "" {
+		t.Error("expected anomaly failure to be reported")
+	}
+
+	d = b.Build(context.Background(), Options{Sections: []string{SectionAgents}, Now: now})
+	if len(d.Agents) != 2 || d.Burndown != nil || d.Escalations != nil || costs.calls != 1 {
+		t.Errorf("expected only agents section, got %+v", d)
+	}
+}
+
+func TestParseSections(t *testing.T) {
+	got, err := ParseSections([]string{"agents", "", "costs"})
+	if err != nil || len(got) != 2 {
+		t.Fatalf("ParseSections = %v, %v", got, err)
+	}
+	if _, err := ParseSections([]string{"weather"}); err == nil {
+		t.Error("expected unknown section to be rejected")
+	}
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+const actionOutputColumns = `id, project_id, bead_id, agent_id, action_type, field, size, created_at`
+
+// SaveActionOutput stores the full text of an action result field
+func (d *Database) SaveActionOutput(out *models.ActionOutput) error {
+	if out == nil {
+		return fmt.Errorf("action output cannot be nil")
+	}
+	_, err := d.db.Exec(`
+		INSERT INTO action_outputs (`+actionOutputColumns+`, content)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		out.ID, out.ProjectID, out.BeadID, out.AgentID, out.ActionType, out.Field,
+		int64(len(out.Content)), out.CreatedAt, []byte(out.Content),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save action output: %w", err)
+	}
+	return nil
+}
+
+// GetActionOutput returns an action output's metadata without its content
+func (d *Database) GetActionOutput(id string) (*models.ActionOutput, error) {
+	row := d.db.QueryRow(`SELECT `+actionOutputColumns+` FROM action_outputs WHERE id = ?`, id)
+	out, err := scanActionOutput(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("result not found: %s", id)
+	}
+	return out, err
+}
+
+// ReadActionOutput returns up to limit bytes of an action output starting
+// at byte offset
+func (d *Database) ReadActionOutput(id string, offset, limit int64) (string, error) {
+	if offset < 0 || limit <= 0 {
+		return "", fmt.Errorf("invalid range: offset %d, limit %d", offset, limit)
+	}
+	var chunk []byte
+	err := d.db.QueryRow(`SELECT substr(content, ?, ?) FROM action_outputs WHERE id = ?`, offset+1, limit, id).Scan(&chunk)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("result not found: %s", id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read action output: %w", err)
+	}
+	return string(chunk), nil
+}
+
+// ListActionOutputs returns stored outputs for a bead or project, newest
+// first. Empty filters match everything.
+func (d *Database) ListActionOutputs(projectID, beadID string, limit int) ([]*models.ActionOutput, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	var where []string
+	var args []interface{}
+	if projectID != "" {
+		where = append(where, "project_id = ?")
+		args = append(args, projectID)
+	}
+	if beadID != "" {
+		where = append(where, "bead_id = ?")
+		args = append(args, beadID)
+	}
+	query := `SELECT ` + actionOutputColumns + ` FROM action_outputs`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list action outputs: %w", err)
+	}
+	defer rows.Close()
+
+	outputs := []*models.ActionOutput{}
+	for rows.Next() {
+		out, err := scanActionOutput(rows)
+		if err != nil {
+			return outputs, err
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs, rows.Err()
+}
+
+func scanActionOutput(row interface{ Scan(...interface{}) error }) (*models.ActionOutput, error) {
+	out := &models.ActionOutput{}
+	if err := row.Scan(&out.ID, &out.ProjectID, &out.BeadID, &out.AgentID, &out.ActionType, &out.Field,
+		&out.Size, &out.CreatedAt); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestActionOutputs_SaveReadList(t *testing.T) {
+	db := newTestDB(t)
+	content := strings.Repeat("0123456789", 100) + "héllo"
+
+	out := &models.ActionOutput{ID: "r-1", ProjectID: "proj-1", BeadID: "bd-1", AgentID: "agent-1",
+		ActionType: "run_command", Field: "stdout", Content: content, CreatedAt: time.Now().UTC()}
+	if err := db.SaveActionOutput(out); err != nil {
+		t.Fatalf("SaveActionOutput: %v", err)
+	}
+
+	got, err := db.GetActionOutput("r-1")
+	if err != nil {
+		t.Fatalf("GetActionOutput: %v", err)
+	}
+	if got.Size != int64(len(content)) || got.Field != "stdout" || got.Content != "" {
+		t.Errorf("unexpected metadata: %+v", got)
+	}
+
+	// Offsets count bytes, including multi-byte characters
+	chunk, err := db.ReadActionOutput("r-1", 995, 100)
+	if err != nil {
+		t.Fatalf("ReadActionOutput: %v", err)
+	}
+	if chunk != "56789héllo" {
+		t.Errorf("unexpected chunk %q", chunk)
+	}
+	if chunk, _ := db.ReadActionOutput("r-1", int64(len(content)), 10); chunk != "" {
+		t.Errorf("expected empty read past the end, got %q", chunk)
+	}
+	if _, err := db.ReadActionOutput("r-missing", 0, 10); err == nil {
+		t.Error("expected error for unknown result")
+	}
+
+	list, err := db.ListActionOutputs("", "bd-1", 0)
+	if err != nil || len(list) != 1 || list[0].ID != "r-1" {
+		t.Errorf("ListActionOutputs = %+v, %v", list, err)
+	}
+	if list, _ := db.ListActionOutputs("proj-2", "", 0); len(list) != 0 {
+		t.Errorf("expected no outputs for proj-2, got %d", len(list))
+	}
+}
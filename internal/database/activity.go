@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -148,6 +149,24 @@ func (d *Database) UpdateAggregatedActivity(activityID string, newCount int) err
 
 // ListActivities retrieves activities with filters
 func (d *Database) ListActivities(filters ActivityFilters) ([]*Activity, error) {
+	var activities []*Activity
+	err := d.eachActivity(context.Background(), filters, "DESC", func(activity *Activity) error {
+		activities = append(activities, activity)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+// EachActivity calls fn with each activity matching filters, oldest first,
+// without loading them all. It stops at the first error fn returns.
+func (d *Database) EachActivity(ctx context.Context, filters ActivityFilters, fn func(*Activity) error) error {
+	return d.eachActivity(ctx, filters, "ASC", fn)
+}
+
+func (d *Database) eachActivity(ctx context.Context, filters ActivityFilters, order string, fn func(*Activity) error) error {
 	query := `
 		SELECT id, event_type, event_id, timestamp, source, actor_id, actor_type,
 			   project_id, agent_id, bead_id, provider_id, action, resource_type,
@@ -200,7 +219,7 @@ func (d *Database) ListActivities(filters ActivityFilters) ([]*Activity, error)
 		args = append(args, *filters.Aggregated)
 	}
 
-	query += " ORDER BY timestamp DESC"
+	query += " ORDER BY timestamp " + order
 
 	if filters.Limit > 0 {
 		query += " LIMIT ?"
@@ -212,13 +231,12 @@ func (d *Database) ListActivities(filters ActivityFilters) ([]*Activity, error)
 		args = append(args, filters.Offset)
 	}
 
-	rows, err := d.db.Query(query, args...)
+	rows, err := d.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list activities: %w", err)
+		return fmt.Errorf("failed to list activities: %w", err)
 	}
 	defer rows.Close()
 
-	var activities []*Activity
 	for rows.Next() {
 		activity := &Activity{}
 		var eventID, actorID, actorType, projectID, agentID, beadID, providerID, resourceTitle, metadataJSON, aggKey sql.NullString
@@ -247,7 +265,7 @@ func (d *Database) ListActivities(filters ActivityFilters) ([]*Activity, error)
 		)
 
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan activity: %w", err)
+			return fmt.Errorf("failed to scan activity: %w", err)
 		}
 
 		// Convert nullable fields
@@ -262,10 +280,12 @@ func (d *Database) ListActivities(filters ActivityFilters) ([]*Activity, error)
 		activity.MetadataJSON = metadataJSON.String
 		activity.AggregationKey = aggKey.String
 
-		activities = append(activities, activity)
+		if err := fn(activity); err != nil {
+			return err
+		}
 	}
 
-	return activities, nil
+	return rows.Err()
 }
 
 // ActivityFilters defines filters for querying activities
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/messaging"
+)
+
+const agentMessageColumns = `message_id, type, from_agent_id, to_agent_id, to_agent_ids_json, subject, body,
+	payload_json, context_json, priority, requires_response, in_reply_to, bead_id, status,
+	created_at, delivered_at, read_at, acknowledged_at`
+
+// SaveAgentMessage persists an agent-to-agent message
+func (d *Database) SaveAgentMessage(msg *messaging.AgentMessage) error {
+	if msg == nil {
+		return fmt.Errorf("agent message cannot be nil")
+	}
+	toIDs, _ := json.Marshal(msg.ToAgentIDs)
+	payload, _ := json.Marshal(msg.Payload)
+	msgContext, _ := json.Marshal(msg.Context)
+	_, err := d.db.Exec(`
+		INSERT INTO agent_messages (`+agentMessageColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		msg.MessageID, string(msg.Type), msg.FromAgentID, msg.ToAgentID, string(toIDs), msg.Subject, msg.Body,
+		string(payload), string(msgContext), string(msg.Priority), msg.RequiresResponse, msg.InReplyTo, msg.BeadID,
+		msg.Status, msg.Timestamp, sqlNullTime(msg.DeliveredAt), sqlNullTime(msg.ReadAt), sqlNullTime(msg.AcknowledgedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save agent message: %w", err)
+	}
+	return nil
+}
+
+// GetAgentMessage returns a stored agent message by ID
+func (d *Database) GetAgentMessage(id string) (*messaging.AgentMessage, error) {
+	row := d.db.QueryRow(`SELECT `+agentMessageColumns+` FROM agent_messages WHERE message_id = ?`, id)
+	msg, err := scanAgentMessage(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("message not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent message: %w", err)
+	}
+	return msg, nil
+}
+
+// ListAgentMessages returns the most recent messages matching the query,
+// oldest first
+func (d *Database) ListAgentMessages(q messaging.MessageQuery) ([]*messaging.AgentMessage, error) {
+	if q.Limit <= 0 {
+		q.Limit = messaging.DefaultInboxLimit
+	}
+	var where []string
+	var args []interface{}
+	if q.ToAgentID != "" {
+		where = append(where, "(to_agent_id = ? OR to_agent_ids_json LIKE ?)")
+		args = append(args, q.ToAgentID, recipientPattern(q.ToAgentID))
+	}
+	if q.FromAgentID != "" {
+		where = append(where, "from_agent_id = ?")
+		args = append(args, q.FromAgentID)
+	}
+	if q.Participant != "" {
+		where = append(where, "(from_agent_id = ? OR to_agent_id = ? OR to_agent_ids_json LIKE ?)")
+		args = append(args, q.Participant, q.Participant, recipientPattern(q.Participant))
+	}
+	if q.BeadID != "" {
+		where = append(where, "bead_id = ?")
+		args = append(args, q.BeadID)
+	}
+	if q.Unacknowledged {
+		where = append(where, "status != ?")
+		args = append(args, messaging.StatusAcknowledged)
+	}
+	query := `SELECT ` + agentMessageColumns + ` FROM agent_messages`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC LIMIT ?"
+	args = append(args, q.Limit)
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []*messaging.AgentMessage{}
+	for rows.Next() {
+		msg, err := scanAgentMessage(rows)
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return messages, err
+	}
+
+	// Newest were selected first; threads read oldest first
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// UpdateAgentMessageStatus records a delivery status change
+func (d *Database) UpdateAgentMessageStatus(id, status string, at time.Time) error {
+	var query string
+	var args []interface{}
+	switch status {
+	case messaging.StatusDelivered:
+		query = `UPDATE agent_messages SET status = ?, delivered_at = COALESCE(delivered_at, ?) WHERE message_id = ?`
+		args = []interface{}{status, at, id}
+	case messaging.StatusRead:
+		query = `UPDATE agent_messages SET status = ?, read_at = ? WHERE message_id = ?`
+		args = []interface{}{status, at, id}
+	case messaging.StatusAcknowledged:
+		query = `UPDATE agent_messages SET status = ?, delivered_at = COALESCE(delivered_at, ?), acknowledged_at = ?
+			WHERE message_id = ?`
+		args = []interface{}{status, at, at, id}
+	case messaging.StatusSent, messaging.StatusFailed:
+		query = `UPDATE agent_messages SET status = ? WHERE message_id = ?`
+		args = []interface{}{status, id}
+	default:
+		return fmt.Errorf("invalid message status: %s", status)
+	}
+	if _, err := d.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to update agent message: %w", err)
+	}
+	return nil
+}
+
+// recipientPattern matches an agent ID inside the JSON-encoded consensus
+// recipient list
+func recipientPattern(agentID string) string {
+	quoted, _ := json.Marshal(agentID)
+	return "%" + string(quoted) + "%"
+}
+
+func scanAgentMessage(row interface{ Scan(...interface{}) error }) (*messaging.AgentMessage, error) {
+	msg := &messaging.AgentMessage{}
+	var msgType, priority string
+	var toIDs, payload, msgContext sql.NullString
+	var deliveredAt, readAt, acknowledgedAt sql.NullTime
+	if err := row.Scan(&msg.MessageID, &msgType, &msg.FromAgentID, &msg.ToAgentID, &toIDs, &msg.Subject, &msg.Body,
+		&payload, &msgContext, &priority, &msg.RequiresResponse, &msg.InReplyTo, &msg.BeadID, &msg.Status,
+		&msg.Timestamp, &deliveredAt, &readAt, &acknowledgedAt); err != nil {
+		return nil, err
+	}
+	msg.Type = messaging.MessageType(msgType)
+	msg.Priority = messaging.Priority(priority)
+	if toIDs.Valid {
+		_ = json.Unmarshal([]byte(toIDs.String), &msg.ToAgentIDs)
+	}
+	if payload.Valid {
+		_ = json.Unmarshal([]byte(payload.String), &msg.Payload)
+	}
+	if msgContext.Valid {
+		_ = json.Unmarshal([]byte(msgContext.String), &msg.Context)
+	}
+	for _, field := range []struct {
+		raw sql.NullTime
+		dst **time.Time
+	}{
+		{deliveredAt, &msg.DeliveredAt},
+		{readAt, &msg.ReadAt},
+		{acknowledgedAt, &msg.AcknowledgedAt},
+	} {
+		if field.raw.Valid {
+			t := field.raw.Time
+			*field.dst = &t
+		}
+	}
+	return msg, nil
+}
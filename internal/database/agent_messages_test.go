@@ -0,0 +1,73 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/messaging"
+)
+
+func TestAgentMessages_SaveListUpdate(t *testing.T) {
+	db := newTestDB(t)
+	base := time.Now().UTC().Truncate(time.Second)
+
+	messages := []*messaging.AgentMessage{
+		{MessageID: "m1", Type: messaging.MessageTypeDirect, FromAgentID: "a", ToAgentID: "b", Body: "first",
+			BeadID: "bd-1", Priority: messaging.PriorityNormal, Status: messaging.StatusSent, Timestamp: base,
+			Payload: map[string]interface{}{"k": "v"}},
+		{MessageID: "m2", Type: messaging.MessageTypeConsensusRequest, FromAgentID: "c", ToAgentIDs: []string{"a", "b"},
+			Subject: "vote", BeadID: "bd-1", Priority: messaging.PriorityHigh, Status: messaging.StatusSent,
+			Timestamp: base.Add(time.Second)},
+		{MessageID: "m3", Type: messaging.MessageTypeDirect, FromAgentID: "b", ToAgentID: "a", Body: "other bead",
+			BeadID: "bd-2", Priority: messaging.PriorityNormal, Status: messaging.StatusSent, Timestamp: base.Add(2 * time.Second)},
+	}
+	for _, msg := range messages {
+		if err := db.SaveAgentMessage(msg); err != nil {
+			t.Fatalf("SaveAgentMessage(%s): %v", msg.MessageID, err)
+		}
+	}
+
+	inbox, err := db.ListAgentMessages(messaging.MessageQuery{ToAgentID: "b"})
+	if err != nil {
+		t.Fatalf("ListAgentMessages: %v", err)
+	}
+	if len(inbox) != 2 || inbox[0].MessageID != "m1" || inbox[1].MessageID != "m2" {
+		t.Fatalf("unexpected inbox for b: %+v", inbox)
+	}
+	if inbox[0].Payload["k"] != "v" || inbox[1].ToAgentIDs[1] != "b" || inbox[1].Type != messaging.MessageTypeConsensusRequest {
+		t.Errorf("fields not round-tripped: %+v %+v", inbox[0], inbox[1])
+	}
+
+	thread, err := db.ListAgentMessages(messaging.MessageQuery{BeadID: "bd-1", Participant: "a"})
+	if err != nil || len(thread) != 2 {
+		t.Fatalf("thread = %v, %v", thread, err)
+	}
+
+	latest, err := db.ListAgentMessages(messaging.MessageQuery{Participant: "a", Limit: 1})
+	if err != nil || len(latest) != 1 || latest[0].MessageID != "m3" {
+		t.Fatalf("limit should keep the newest message: %v, %v", latest, err)
+	}
+
+	if err := db.UpdateAgentMessageStatus("m1", messaging.StatusAcknowledged, base.Add(time.Minute)); err != nil {
+		t.Fatalf("UpdateAgentMessageStatus: %v", err)
+	}
+	got, err := db.GetAgentMessage("m1")
+	if err != nil {
+		t.Fatalf("GetAgentMessage: %v", err)
+	}
+	if got.Status != messaging.StatusAcknowledged || got.AcknowledgedAt == nil || got.DeliveredAt == nil {
+		t.Errorf("acknowledgement not recorded: %+v", got)
+	}
+
+	pending, err := db.ListAgentMessages(messaging.MessageQuery{ToAgentID: "b", Unacknowledged: true})
+	if err != nil || len(pending) != 1 || pending[0].MessageID != "m2" {
+		t.Errorf("unacknowledged = %v, %v", pending, err)
+	}
+
+	if err := db.UpdateAgentMessageStatus("m1", "bogus", base); err == nil {
+		t.Error("expected error for invalid status")
+	}
+	if _, err := db.GetAgentMessage("missing"); err == nil {
+		t.Error("expected error for missing message")
+	}
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+const agentRoleColumns = `name, description, system_prompt, allowed_actions_json, denied_actions_json,
+	aliases_json, preferred_providers_json, preferred_model, built_in, created_at, updated_at`
+
+// UpsertAgentRole creates or updates an agent role definition
+func (d *Database) UpsertAgentRole(role *models.AgentRole) error {
+	if role == nil {
+		return fmt.Errorf("agent role cannot be nil")
+	}
+	allowed, _ := json.Marshal(role.AllowedActions)
+	denied, _ := json.Marshal(role.DeniedActions)
+	aliases, _ := json.Marshal(role.Aliases)
+	providers, _ := json.Marshal(role.PreferredProviders)
+	_, err := d.db.Exec(`
+		INSERT INTO agent_roles (`+agentRoleColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			description = excluded.description,
+			system_prompt = excluded.system_prompt,
+			allowed_actions_json = excluded.allowed_actions_json,
+			denied_actions_json = excluded.denied_actions_json,
+			aliases_json = excluded.aliases_json,
+			preferred_providers_json = excluded.preferred_providers_json,
+			preferred_model = excluded.preferred_model,
+			built_in = excluded.built_in,
+			updated_at = excluded.updated_at`,
+		role.Name, role.Description, role.SystemPrompt, string(allowed), string(denied),
+		string(aliases), string(providers), role.PreferredModel, role.BuiltIn, role.CreatedAt, role.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert agent role: %w", err)
+	}
+	return nil
+}
+
+// GetAgentRole returns an agent role by name
+func (d *Database) GetAgentRole(name string) (*models.AgentRole, error) {
+	row := d.db.QueryRow(`SELECT `+agentRoleColumns+` FROM agent_roles WHERE name = ?`, name)
+	role, err := scanAgentRole(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("agent role not found: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent role: %w", err)
+	}
+	return role, nil
+}
+
+// ListAgentRoles returns every agent role ordered by name
+func (d *Database) ListAgentRoles() ([]*models.AgentRole, error) {
+	rows, err := d.db.Query(`SELECT ` + agentRoleColumns + ` FROM agent_roles ORDER BY name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []*models.AgentRole
+	for rows.Next() {
+		role, err := scanAgentRole(rows)
+		if err != nil {
+			return roles, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// DeleteAgentRole removes an agent role
+func (d *Database) DeleteAgentRole(name string) error {
+	if _, err := d.db.Exec(`DELETE FROM agent_roles WHERE name = ?`, name); err != nil {
+		return fmt.Errorf("failed to delete agent role: %w", err)
+	}
+	return nil
+}
+
+func scanAgentRole(row interface{ Scan(...interface{}) error }) (*models.AgentRole, error) {
+	role := &models.AgentRole{}
+	var allowed, denied, aliases, providers sql.NullString
+	if err := row.Scan(&role.Name, &role.Description, &role.SystemPrompt, &allowed, &denied,
+		&aliases, &providers, &role.PreferredModel, &role.BuiltIn, &role.CreatedAt, &role.UpdatedAt); err != nil {
+		return nil, err
+	}
+	for _, field := range []struct {
+		raw sql.NullString
+		dst *[]string
+	}{
+		{allowed, &role.AllowedActions},
+		{denied, &role.DeniedActions},
+		{aliases, &role.Aliases},
+		{providers, &role.PreferredProviders},
+	} {
+		if field.raw.Valid {
+			_ = json.Unmarshal([]byte(field.raw.String), field.dst)
+		}
+	}
+	return role, nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestAgentRoles_UpsertGetListDelete(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	role := &models.AgentRole{
+		Name: "reviewer", Description: "Reviews changes", SystemPrompt: "You are {{.AgentName}}",
+		DeniedActions: []string{"git_push"}, Aliases: []string{"code-reviewer"},
+		PreferredProviders: []string{"big-model"}, BuiltIn: true, CreatedAt: now, UpdatedAt: now,
+	}
+	if err := db.UpsertAgentRole(role); err != nil {
+		t.Fatalf("UpsertAgentRole: %v", err)
+	}
+	role.PreferredModel = "qwen-coder"
+	if err := db.UpsertAgentRole(role); err != nil {
+		t.Fatalf("UpsertAgentRole (update): %v", err)
+	}
+
+	got, err := db.GetAgentRole("reviewer")
+	if err != nil {
+		t.Fatalf("GetAgentRole: %v", err)
+	}
+	if got.PreferredModel != "qwen-coder" || len(got.DeniedActions) != 1 || got.Aliases[0] != "code-reviewer" ||
+		got.PreferredProviders[0] != "big-model" || !got.BuiltIn || len(got.AllowedActions) != 0 {
+		t.Errorf("unexpected role: %+v", got)
+	}
+
+	list, err := db.ListAgentRoles()
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListAgentRoles = %v, %v", list, err)
+	}
+
+	if err := db.DeleteAgentRole("reviewer"); err != nil {
+		t.Fatalf("DeleteAgentRole: %v", err)
+	}
+	if _, err := db.GetAgentRole("reviewer"); err == nil {
+		t.Error("expected not found after delete")
+	}
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/attachments"
+)
+
+const beadAudioColumns = `id, bead_id, name, media_type, size, transcript, transcribed_by, language, duration, source, created_at, data`
+
+func scanAudio(row interface{ Scan(...interface{}) error }) (*attachments.Audio, error) {
+	a := &attachments.Audio{}
+	err := row.Scan(&a.ID, &a.BeadID, &a.Name, &a.MediaType, &a.Size, &a.Transcript, &a.TranscribedBy,
+		&a.Language, &a.Duration, &a.Source, &a.CreatedAt, &a.Data)
+	return a, err
+}
+
+// SaveAudio stores a voice memo attached to a bead
+func (d *Database) SaveAudio(a *attachments.Audio) error {
+	if a == nil {
+		return fmt.Errorf("audio cannot be nil")
+	}
+	_, err := d.db.Exec(`
+		INSERT INTO bead_audio (`+beadAudioColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			transcript = excluded.transcript,
+			transcribed_by = excluded.transcribed_by,
+			language = excluded.language,
+			duration = excluded.duration`,
+		a.ID, a.BeadID, a.Name, a.MediaType, a.Size, a.Transcript, a.TranscribedBy,
+		a.Language, a.Duration, a.Source, a.CreatedAt, a.Data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save audio: %w", err)
+	}
+	return nil
+}
+
+// GetAudio returns a voice memo with its data, or nil if there is none with id
+func (d *Database) GetAudio(id string) (*attachments.Audio, error) {
+	a, err := scanAudio(d.db.QueryRow(`SELECT `+beadAudioColumns+` FROM bead_audio WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audio: %w", err)
+	}
+	return a, nil
+}
+
+// ListAudio returns a bead's voice memos with their data, oldest first
+func (d *Database) ListAudio(beadID string) ([]*attachments.Audio, error) {
+	rows, err := d.db.Query(`SELECT `+beadAudioColumns+` FROM bead_audio WHERE bead_id = ? ORDER BY created_at, id`, beadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audio: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*attachments.Audio
+	for rows.Next() {
+		a, err := scanAudio(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audio: %w", err)
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/attachments"
+)
+
+func TestBeadAudio_SaveGetList(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	data := []byte("ID3\x04\x00\x00")
+
+	for _, a := range []*attachments.Audio{
+		{ID: "aud-2", BeadID: "b1", Name: "memo.mp3", MediaType: "audio/mpeg", Size: len(data), Data: data,
+			Transcript: "Fix the login page.", TranscribedBy: "openai:whisper-1", Language: "en", Duration: 3.5,
+			Source: attachments.SourceAPI, CreatedAt: now.Add(time.Minute)},
+		{ID: "aud-1", BeadID: "b1", Name: "first.wav", MediaType: "audio/wav", Data: data, CreatedAt: now},
+		{ID: "aud-3", BeadID: "b2", Name: "other.ogg", MediaType: "audio/ogg", Data: data, CreatedAt: now},
+	} {
+		if err := db.SaveAudio(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := db.GetAudio("aud-2")
+	if err != nil || got == nil || !bytes.Equal(got.Data, data) || got.Transcript != "Fix the login page." || got.Duration != 3.5 {
+		t.Fatalf("GetAudio() = %+v, %v", got, err)
+	}
+	if got, err := db.GetAudio("missing"); got != nil || err != nil {
+		t.Errorf("GetAudio(missing) = %v, %v", got, err)
+	}
+	list, err := db.ListAudio("b1")
+	if err != nil || len(list) != 2 || list[0].ID != "aud-1" || list[1].TranscribedBy != "openai:whisper-1" {
+		t.Fatalf("ListAudio() = %+v, %v", list, err)
+	}
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/collaboration"
+)
+
+// SaveBeadContext writes a bead's shared context if the stored one is still
+// at prevVersion (0 when it isn't stored yet), appending activity to its log
+// in the same transaction. It returns collaboration.ErrContextStale when
+// another writer got there first.
+func (d *Database) SaveBeadContext(rec *collaboration.ContextRecord, prevVersion int64, activity *collaboration.ActivityEntry) error {
+	if rec == nil {
+		return fmt.Errorf("bead context cannot be nil")
+	}
+	agents, _ := json.Marshal(rec.CollaboratingAgents)
+	data, err := json.Marshal(rec.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bead context data: %w", err)
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var res sql.Result
+	if prevVersion == 0 {
+		res, err = tx.Exec(`
+			INSERT INTO bead_contexts (bead_id, project_id, agents_json, data_json, version, last_updated, last_updated_by)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(bead_id) DO NOTHING`,
+			rec.BeadID, rec.ProjectID, string(agents), string(data), rec.Version, rec.LastUpdated, rec.LastUpdatedBy,
+		)
+	} else {
+		res, err = tx.Exec(`
+			UPDATE bead_contexts
+			SET project_id = ?, agents_json = ?, data_json = ?, version = ?, last_updated = ?, last_updated_by = ?
+			WHERE bead_id = ? AND version = ?`,
+			rec.ProjectID, string(agents), string(data), rec.Version, rec.LastUpdated, rec.LastUpdatedBy,
+			rec.BeadID, prevVersion,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save bead context: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return collaboration.ErrContextStale
+	}
+
+	if activity != nil {
+		var activityData interface{}
+		if activity.Data != nil {
+			b, err := json.Marshal(activity.Data)
+			if err != nil {
+				return fmt.Errorf("failed to marshal activity data: %w", err)
+			}
+			activityData = string(b)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO bead_context_activities (bead_id, timestamp, agent_id, activity_type, description, data_json)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			rec.BeadID, activity.Timestamp, activity.AgentID, activity.ActivityType, activity.Description, activityData,
+		); err != nil {
+			return fmt.Errorf("failed to save bead context activity: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadBeadContext returns a bead's stored shared context with its activity
+// log oldest first, or nil when the bead has none
+func (d *Database) LoadBeadContext(beadID string) (*collaboration.ContextRecord, error) {
+	rec := &collaboration.ContextRecord{BeadID: beadID}
+	var agents, data string
+	err := d.db.QueryRow(`
+		SELECT project_id, agents_json, data_json, version, last_updated, last_updated_by
+		FROM bead_contexts WHERE bead_id = ?`, beadID,
+	).Scan(&rec.ProjectID, &agents, &data, &rec.Version, &rec.LastUpdated, &rec.LastUpdatedBy)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bead context: %w", err)
+	}
+	_ = json.Unmarshal([]byte(agents), &rec.CollaboratingAgents)
+	_ = json.Unmarshal([]byte(data), &rec.Data)
+
+	rows, err := d.db.Query(`
+		SELECT timestamp, agent_id, activity_type, description, data_json
+		FROM bead_context_activities WHERE bead_id = ? ORDER BY id ASC`, beadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bead context activity: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry collaboration.ActivityEntry
+		var entryData sql.NullString
+		if err := rows.Scan(&entry.Timestamp, &entry.AgentID, &entry.ActivityType, &entry.Description, &entryData); err != nil {
+			return nil, fmt.Errorf("failed to scan bead context activity: %w", err)
+		}
+		if entryData.Valid {
+			_ = json.Unmarshal([]byte(entryData.String), &entry.Data)
+		}
+		rec.ActivityLog = append(rec.ActivityLog, entry)
+	}
+	return rec, rows.Err()
+}
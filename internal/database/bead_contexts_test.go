@@ -0,0 +1,51 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/collaboration"
+)
+
+func TestBeadContexts_SaveLoad(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if rec, err := db.LoadBeadContext("bead-1"); err != nil || rec != nil {
+		t.Fatalf("LoadBeadContext on an unknown bead = %v, %v", rec, err)
+	}
+
+	rec := &collaboration.ContextRecord{BeadID: "bead-1", ProjectID: "p1", Version: 1, LastUpdated: now}
+	if err := db.SaveBeadContext(rec, 0, nil); err != nil {
+		t.Fatalf("SaveBeadContext (create): %v", err)
+	}
+	if err := db.SaveBeadContext(rec, 0, nil); !errors.Is(err, collaboration.ErrContextStale) {
+		t.Errorf("expected a second create to be stale, got %v", err)
+	}
+
+	rec.CollaboratingAgents = []string{"agent-1"}
+	rec.Data = map[string]interface{}{"plan": "refactor"}
+	rec.Version = 2
+	rec.LastUpdatedBy = "agent-1"
+	joined := &collaboration.ActivityEntry{Timestamp: now, AgentID: "agent-1", ActivityType: "joined", Description: "joined"}
+	if err := db.SaveBeadContext(rec, 1, joined); err != nil {
+		t.Fatalf("SaveBeadContext (update): %v", err)
+	}
+	rec.Version = 3
+	if err := db.SaveBeadContext(rec, 1, joined); !errors.Is(err, collaboration.ErrContextStale) {
+		t.Errorf("expected an update from version 1 to be stale, got %v", err)
+	}
+
+	got, err := db.LoadBeadContext("bead-1")
+	if err != nil {
+		t.Fatalf("LoadBeadContext: %v", err)
+	}
+	if got.Version != 2 || got.ProjectID != "p1" || got.Data["plan"] != "refactor" || len(got.CollaboratingAgents) != 1 ||
+		got.LastUpdatedBy != "agent-1" {
+		t.Errorf("unexpected context: %+v", got)
+	}
+	if len(got.ActivityLog) != 1 || got.ActivityLog[0].ActivityType != "joined" || got.ActivityLog[0].Data != nil {
+		t.Errorf("expected only the committed activity, got %+v", got.ActivityLog)
+	}
+}
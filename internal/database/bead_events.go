@@ -0,0 +1,83 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/beads"
+)
+
+// AppendBeadEvent adds an event to a bead's history, setting its Seq
+func (d *Database) AppendBeadEvent(event *beads.BeadEvent) error {
+	if event == nil {
+		return fmt.Errorf("bead event cannot be nil")
+	}
+	changes, err := marshalNullable(event.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bead event changes: %w", err)
+	}
+	data, err := marshalNullable(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bead event data: %w", err)
+	}
+	res, err := d.db.Exec(`
+		INSERT INTO bead_events (bead_id, type, actor, timestamp, version, changes_json, data_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		event.BeadID, event.Type, event.Actor, event.Timestamp, event.Version, changes, data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append bead event: %w", err)
+	}
+	if seq, err := res.LastInsertId(); err == nil {
+		event.Seq = seq
+	}
+	return nil
+}
+
+// ListBeadEvents returns a bead's events oldest first, up to and including
+// until unless it is zero
+func (d *Database) ListBeadEvents(beadID string, until time.Time) ([]*beads.BeadEvent, error) {
+	rows, err := d.db.Query(`
+		SELECT seq, bead_id, type, actor, timestamp, version, changes_json, data_json
+		FROM bead_events WHERE bead_id = ? ORDER BY seq ASC`, beadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bead events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*beads.BeadEvent
+	for rows.Next() {
+		e := &beads.BeadEvent{}
+		var changes, data sql.NullString
+		if err := rows.Scan(&e.Seq, &e.BeadID, &e.Type, &e.Actor, &e.Timestamp, &e.Version, &changes, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan bead event: %w", err)
+		}
+		if changes.Valid {
+			_ = json.Unmarshal([]byte(changes.String), &e.Changes)
+		}
+		// Events are appended in time order; filter here rather than
+		// comparing timestamps as SQLite text
+		if !until.IsZero() && e.Timestamp.After(until) {
+			break
+		}
+		if data.Valid {
+			_ = json.Unmarshal([]byte(data.String), &e.Data)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// marshalNullable encodes a map as JSON, or NULL when it is empty
+func marshalNullable(m map[string]interface{}) (interface{}, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
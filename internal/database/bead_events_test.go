@@ -0,0 +1,45 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestBeadEvents_AppendList(t *testing.T) {
+	db := newTestDB(t)
+	m := beads.NewManager("")
+	m.SetBeadsPath(t.TempDir())
+	m.SetEventStore(db)
+
+	bead, err := m.CreateBead("Persisted", "", models.BeadPriorityP2, "task", "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mid := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	if err := m.ClaimBead(bead.ID, "agent-1"); err != nil {
+		t.Fatal(err)
+	}
+	_ = m.RecordEvent(bead.ID, beads.EventActionExecuted, "agent-1", map[string]interface{}{"action_type": "write_file"})
+
+	events, err := db.ListBeadEvents(bead.ID, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[0].Seq >= events[1].Seq || events[1].Actor != "agent-1" ||
+		events[2].Data["action_type"] != "write_file" || events[2].Changes != nil {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	past, applied, err := m.Replay(bead.ID, mid)
+	if err != nil || len(applied) != 1 || past.AssignedTo != "" || past.Title != "Persisted" {
+		t.Fatalf("Replay = %+v, %d events, %v", past, len(applied), err)
+	}
+	now, _, _ := m.Replay(bead.ID, time.Now())
+	if now.AssignedTo != "agent-1" || now.Status != models.BeadStatusInProgress {
+		t.Errorf("unexpected current projection %+v", now)
+	}
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/attachments"
+)
+
+// SaveImage stores an image attached to a bead
+func (d *Database) SaveImage(img *attachments.Image) error {
+	if img == nil {
+		return fmt.Errorf("image cannot be nil")
+	}
+	_, err := d.db.Exec(`
+		INSERT INTO bead_images (id, bead_id, name, media_type, size, url, source, created_at, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			media_type = excluded.media_type,
+			size = excluded.size,
+			url = excluded.url,
+			data = excluded.data`,
+		img.ID, img.BeadID, img.Name, img.MediaType, img.Size, img.URL, img.Source, img.CreatedAt, img.Data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+	return nil
+}
+
+// GetImage returns an image with its data, or nil if there is none with id
+func (d *Database) GetImage(id string) (*attachments.Image, error) {
+	img := &attachments.Image{}
+	err := d.db.QueryRow(`
+		SELECT id, bead_id, name, media_type, size, url, source, created_at, data
+		FROM bead_images WHERE id = ?`, id,
+	).Scan(&img.ID, &img.BeadID, &img.Name, &img.MediaType, &img.Size, &img.URL, &img.Source, &img.CreatedAt, &img.Data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image: %w", err)
+	}
+	return img, nil
+}
+
+// ListImages returns a bead's images with their data, oldest first
+func (d *Database) ListImages(beadID string) ([]*attachments.Image, error) {
+	rows, err := d.db.Query(`
+		SELECT id, bead_id, name, media_type, size, url, source, created_at, data
+		FROM bead_images WHERE bead_id = ?
+		ORDER BY created_at, id`, beadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*attachments.Image
+	for rows.Next() {
+		img := &attachments.Image{}
+		if err := rows.Scan(&img.ID, &img.BeadID, &img.Name, &img.MediaType, &img.Size, &img.URL, &img.Source, &img.CreatedAt, &img.Data); err != nil {
+			return nil, fmt.Errorf("failed to scan image: %w", err)
+		}
+		out = append(out, img)
+	}
+	return out, rows.Err()
+}
+
+// DeleteImage removes an image
+func (d *Database) DeleteImage(id string) error {
+	if _, err := d.db.Exec(`DELETE FROM bead_images WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+	return nil
+}
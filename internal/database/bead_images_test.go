@@ -0,0 +1,44 @@
+package database
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/attachments"
+)
+
+func TestBeadImages_SaveListDelete(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	data := []byte("\x89PNG\r\n\x1a\n")
+
+	for _, img := range []*attachments.Image{
+		{ID: "img-2", BeadID: "b1", Name: "after.png", MediaType: "image/png", Size: len(data), Data: data, Source: attachments.SourceAgent, CreatedAt: now.Add(time.Minute)},
+		{ID: "img-1", BeadID: "b1", Name: "mock", URL: "https://example.com/mock.png", Source: attachments.SourceAPI, CreatedAt: now},
+		{ID: "img-3", BeadID: "b2", Name: "other.png", MediaType: "image/png", Data: data, CreatedAt: now},
+	} {
+		if err := db.SaveImage(img); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := db.GetImage("img-2")
+	if err != nil || got == nil || !bytes.Equal(got.Data, data) || got.Source != attachments.SourceAgent {
+		t.Fatalf("GetImage() = %+v, %v", got, err)
+	}
+	if got, err := db.GetImage("missing"); got != nil || err != nil {
+		t.Errorf("GetImage(missing) = %v, %v", got, err)
+	}
+	list, err := db.ListImages("b1")
+	if err != nil || len(list) != 2 || list[0].ID != "img-1" || list[0].URL == "" {
+		t.Fatalf("ListImages() = %+v, %v", list, err)
+	}
+
+	if err := db.DeleteImage("img-1"); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := db.ListImages("b1"); len(list) != 1 {
+		t.Errorf("expected one image left, got %d", len(list))
+	}
+}
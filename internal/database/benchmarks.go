@@ -0,0 +1,107 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+const benchmarkRunColumns = `id, project_id, bead_id, branch, commit_sha, tool, path,
+	baseline_id, baseline_commit, threshold, regressions_json, started_at, completed_at`
+
+// SaveBenchmarkRun stores a benchmark run and its per-benchmark metrics
+func (d *Database) SaveBenchmarkRun(run *models.BenchmarkRun) error {
+	if run == nil {
+		return fmt.Errorf("run cannot be nil")
+	}
+	regressions, _ := json.Marshal(run.Regressions)
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(`
+		INSERT INTO benchmark_runs (`+benchmarkRunColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.ID, run.ProjectID, run.BeadID, run.Branch, run.Commit, run.Tool, run.Path,
+		run.BaselineID, run.BaselineCommit, run.Threshold, string(regressions), run.StartedAt, run.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save benchmark run: %w", err)
+	}
+	for _, r := range run.Results {
+		_, err := tx.Exec(`
+			INSERT INTO benchmark_results (run_id, package, name, samples, iterations, ns_per_op, bytes_per_op, allocs_per_op, memory)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			run.ID, r.Package, r.Name, r.Samples, r.Iterations, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp, r.Memory,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save benchmark %s: %w", r.Key(), err)
+		}
+	}
+	return tx.Commit()
+}
+
+// LatestBenchmarkRun returns a project's newest run of tool on branch, at
+// commit unless it is empty, or nil when there is none
+func (d *Database) LatestBenchmarkRun(projectID, tool, branch, commit string) (*models.BenchmarkRun, error) {
+	query := `SELECT ` + benchmarkRunColumns + ` FROM benchmark_runs WHERE project_id = ? AND tool = ? AND branch = ?`
+	args := []interface{}{projectID, tool, branch}
+	if commit != "" {
+		query += ` AND commit_sha = ?`
+		args = append(args, commit)
+	}
+	query += ` ORDER BY started_at DESC LIMIT 1`
+	return d.latestBenchmarkRun(query, args...)
+}
+
+// LatestBeadBenchmarkRun returns the newest benchmark run made for a bead,
+// or nil when there is none
+func (d *Database) LatestBeadBenchmarkRun(beadID string) (*models.BenchmarkRun, error) {
+	return d.latestBenchmarkRun(`
+		SELECT `+benchmarkRunColumns+`
+		FROM benchmark_runs
+		WHERE bead_id = ?
+		ORDER BY started_at DESC
+		LIMIT 1`, beadID)
+}
+
+func (d *Database) latestBenchmarkRun(query string, args ...interface{}) (*models.BenchmarkRun, error) {
+	run := &models.BenchmarkRun{}
+	var regressions sql.NullString
+	err := d.db.QueryRow(query, args...).Scan(&run.ID, &run.ProjectID, &run.BeadID, &run.Branch, &run.Commit,
+		&run.Tool, &run.Path, &run.BaselineID, &run.BaselineCommit, &run.Threshold, &regressions,
+		&run.StartedAt, &run.CompletedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get benchmark run: %w", err)
+	}
+	if regressions.Valid {
+		_ = json.Unmarshal([]byte(regressions.String), &run.Regressions)
+	}
+
+	rows, err := d.db.Query(`
+		SELECT package, name, samples, iterations, ns_per_op, bytes_per_op, allocs_per_op, memory
+		FROM benchmark_results
+		WHERE run_id = ?
+		ORDER BY package, name`, run.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get benchmark results: %w", err)
+	}
+	defer rows.Close()
+	run.Results = []models.BenchmarkResult{}
+	for rows.Next() {
+		var r models.BenchmarkResult
+		if err := rows.Scan(&r.Package, &r.Name, &r.Samples, &r.Iterations, &r.NsPerOp, &r.BytesPerOp, &r.AllocsPerOp, &r.Memory); err != nil {
+			return nil, err
+		}
+		run.Results = append(run.Results, r)
+	}
+	return run, rows.Err()
+}
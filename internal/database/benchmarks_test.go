@@ -0,0 +1,72 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestBenchmarkRuns_SaveLatest(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	baseline := &models.BenchmarkRun{
+		ID: "bench-1", ProjectID: "proj-1", Branch: "main", Commit: "c1", Tool: "go",
+		Results: []models.BenchmarkResult{
+			{Name: "BenchmarkParse", Package: "example.com/app", Samples: 3, Iterations: 1000, NsPerOp: 1000, BytesPerOp: 64, AllocsPerOp: 2, Memory: true},
+			{Name: "BenchmarkParse", Package: "example.com/app/store", Samples: 3, NsPerOp: 9000},
+		},
+		StartedAt: now.Add(-time.Hour), CompletedAt: now.Add(-time.Hour),
+	}
+	later := &models.BenchmarkRun{
+		ID: "bench-2", ProjectID: "proj-1", Branch: "main", Commit: "c3", Tool: "go",
+		Results:   []models.BenchmarkResult{{Name: "BenchmarkParse", Package: "example.com/app", NsPerOp: 1100}},
+		StartedAt: now, CompletedAt: now,
+	}
+	beadRun := &models.BenchmarkRun{
+		ID: "bench-3", ProjectID: "proj-1", BeadID: "bd-1", Branch: "bd-1", Commit: "c2", Tool: "go",
+		Results:        []models.BenchmarkResult{{Name: "BenchmarkParse", Package: "example.com/app", NsPerOp: 1500}},
+		BaselineID:     "bench-1",
+		BaselineCommit: "c1",
+		Threshold:      10,
+		Regressions: []models.BenchmarkComparison{
+			{Name: "example.com/app.BenchmarkParse", Metric: models.MetricNsPerOp, Baseline: 1000, Current: 1500, Delta: 50, Regression: true},
+		},
+		StartedAt: now, CompletedAt: now,
+	}
+	for _, run := range []*models.BenchmarkRun{baseline, later, beadRun} {
+		if err := db.SaveBenchmarkRun(run); err != nil {
+			t.Fatalf("SaveBenchmarkRun(%s): %v", run.ID, err)
+		}
+	}
+
+	got, err := db.LatestBenchmarkRun("proj-1", "go", "main", "c1")
+	if err != nil {
+		t.Fatalf("LatestBenchmarkRun: %v", err)
+	}
+	if got == nil || got.ID != "bench-1" || len(got.Results) != 2 {
+		t.Fatalf("expected bench-1 with 2 results, got %+v", got)
+	}
+	if r := got.Result("example.com/app.BenchmarkParse"); r == nil || r.Iterations != 1000 || r.AllocsPerOp != 2 || !r.Memory {
+		t.Errorf("results not round-tripped: %+v", got.Results)
+	}
+
+	if got, _ := db.LatestBenchmarkRun("proj-1", "go", "main", ""); got == nil || got.ID != "bench-2" {
+		t.Errorf("expected the newest run on main, got %+v", got)
+	}
+	if got, err := db.LatestBenchmarkRun("proj-1", "hyperfine", "main", ""); err != nil || got != nil {
+		t.Errorf("expected no hyperfine run, got %+v (%v)", got, err)
+	}
+
+	got, err = db.LatestBeadBenchmarkRun("bd-1")
+	if err != nil {
+		t.Fatalf("LatestBeadBenchmarkRun: %v", err)
+	}
+	if got == nil || got.BaselineCommit != "c1" || got.Threshold != 10 || len(got.Regressions) != 1 || got.Regressions[0].Delta != 50 {
+		t.Errorf("unexpected bead run: %+v", got)
+	}
+	if got, err := db.LatestBeadBenchmarkRun("bd-2"); err != nil || got != nil {
+		t.Errorf("expected no run for bd-2, got %+v (%v)", got, err)
+	}
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// UpsertFieldDefinition creates or replaces a project's custom bead field
+func (d *Database) UpsertFieldDefinition(def *models.BeadFieldDefinition) error {
+	if def == nil {
+		return fmt.Errorf("field definition cannot be nil")
+	}
+	data, err := json.Marshal(def)
+	if err != nil {
+		return fmt.Errorf("failed to marshal field definition: %w", err)
+	}
+	_, err = d.db.Exec(`
+		INSERT INTO bead_field_definitions (project_id, name, definition_json, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(project_id, name) DO UPDATE SET
+			definition_json = excluded.definition_json,
+			updated_at = excluded.updated_at`,
+		def.ProjectID, def.Name, string(data), def.CreatedAt, def.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert field definition: %w", err)
+	}
+	return nil
+}
+
+// ListFieldDefinitions returns every custom bead field definition
+func (d *Database) ListFieldDefinitions() ([]*models.BeadFieldDefinition, error) {
+	rows, err := d.db.Query(`
+		SELECT definition_json FROM bead_field_definitions
+		ORDER BY project_id, name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list field definitions: %w", err)
+	}
+	defer rows.Close()
+
+	var defs []*models.BeadFieldDefinition
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan field definition: %w", err)
+		}
+		def := &models.BeadFieldDefinition{}
+		if err := json.Unmarshal([]byte(data), def); err != nil {
+			return nil, fmt.Errorf("failed to parse field definition: %w", err)
+		}
+		defs = append(defs, def)
+	}
+	return defs, rows.Err()
+}
+
+// DeleteFieldDefinition removes a project's custom bead field. Values
+// already on beads are left in place.
+func (d *Database) DeleteFieldDefinition(projectID, name string) error {
+	_, err := d.db.Exec(`DELETE FROM bead_field_definitions WHERE project_id = ? AND name = ?`, projectID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete field definition: %w", err)
+	}
+	return nil
+}
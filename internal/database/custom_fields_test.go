@@ -0,0 +1,53 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestFieldDefinitions_UpsertListDelete(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	def := &models.BeadFieldDefinition{
+		ProjectID: "proj-1", Name: "severity", Type: models.FieldTypeEnum,
+		Options: []string{"low", "high"}, Required: true, Widget: "select",
+		CreatedAt: now, UpdatedAt: now,
+	}
+	if err := db.UpsertFieldDefinition(def); err != nil {
+		t.Fatalf("UpsertFieldDefinition: %v", err)
+	}
+	def.Options = append(def.Options, "critical")
+	if err := db.UpsertFieldDefinition(def); err != nil {
+		t.Fatalf("UpsertFieldDefinition (update): %v", err)
+	}
+	if err := db.UpsertFieldDefinition(&models.BeadFieldDefinition{ProjectID: "proj-2", Name: "severity", Type: models.FieldTypeString}); err != nil {
+		t.Fatalf("UpsertFieldDefinition (other project): %v", err)
+	}
+
+	list, err := db.ListFieldDefinitions()
+	if err != nil {
+		t.Fatalf("ListFieldDefinitions: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 definitions, got %d", len(list))
+	}
+	var got *models.BeadFieldDefinition
+	for _, d := range list {
+		if d.ProjectID == "proj-1" {
+			got = d
+		}
+	}
+	if got == nil || got.Type != models.FieldTypeEnum || len(got.Options) != 3 || !got.Required {
+		t.Errorf("unexpected definition: %+v", got)
+	}
+
+	if err := db.DeleteFieldDefinition("proj-1", "severity"); err != nil {
+		t.Fatalf("DeleteFieldDefinition: %v", err)
+	}
+	if list, _ := db.ListFieldDefinitions(); len(list) != 1 || list[0].ProjectID != "proj-2" {
+		t.Errorf("expected only proj-2's field to remain, got %+v", list)
+	}
+}
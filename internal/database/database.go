@@ -7,11 +7,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/logging"
 	internalmodels "github.com/jordanhubbard/loom/internal/models"
 	"github.com/jordanhubbard/loom/pkg/models"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+var databaseLog = logging.Module("database")
+
 // Database represents the loom database
 type Database struct {
 	db         *sql.DB
@@ -101,6 +104,81 @@ func New(dbPath string) (*Database, error) {
 		return nil, fmt.Errorf("failed to migrate lessons: %w", err)
 	}
 
+	if err := d.migrateSecurityScans(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate security scans: %w", err)
+	}
+
+	if err := d.migrateSchedules(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schedules: %w", err)
+	}
+
+	if err := d.migrateActionOutputs(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate action outputs: %w", err)
+	}
+
+	if err := d.migrateAgentRoles(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate agent roles: %w", err)
+	}
+
+	if err := d.migrateAgentMessages(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate agent messages: %w", err)
+	}
+
+	if err := d.migrateBeadContexts(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate bead contexts: %w", err)
+	}
+
+	if err := d.migrateBeadEvents(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate bead events: %w", err)
+	}
+
+	if err := d.migrateDigests(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate digests: %w", err)
+	}
+
+	if err := d.migrateEstimates(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate estimates: %w", err)
+	}
+
+	if err := d.migrateExperiments(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate experiments: %w", err)
+	}
+
+	if err := d.migrateBeadImages(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate bead images: %w", err)
+	}
+
+	if err := d.migrateBeadAudio(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate bead audio: %w", err)
+	}
+
+	if err := d.migrateBenchmarks(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate benchmarks: %w", err)
+	}
+
+	if err := d.migrateProfiles(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate profiles: %w", err)
+	}
+
+	if err := d.migrateCustomFields(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate custom fields: %w", err)
+	}
+
 	return d, nil
 }
 
@@ -147,6 +225,7 @@ func (d *Database) initSchema() error {
 		model_score REAL,
 		selected_gpu TEXT,
 		gpu_constraints_json TEXT,
+		endpoints_json TEXT,
 		description TEXT,
 		requires_key BOOLEAN NOT NULL DEFAULT 0,
 		key_id TEXT,
@@ -176,6 +255,7 @@ func (d *Database) initSchema() error {
 		git_strategy TEXT NOT NULL DEFAULT 'direct',
 		status TEXT NOT NULL DEFAULT 'open',
 		context_json TEXT,
+		subprojects_json TEXT,
 		schema_version TEXT NOT NULL DEFAULT '1.0',
 		attributes_json TEXT,
 		created_at DATETIME NOT NULL,
@@ -286,6 +366,7 @@ func (d *Database) initSchema() error {
 	_, _ = d.db.Exec("ALTER TABLE providers ADD COLUMN last_heartbeat_error TEXT")
 	_, _ = d.db.Exec("ALTER TABLE providers ADD COLUMN schema_version TEXT DEFAULT '1.0'")
 	_, _ = d.db.Exec("ALTER TABLE providers ADD COLUMN attributes_json TEXT")
+	_, _ = d.db.Exec("ALTER TABLE providers ADD COLUMN endpoints_json TEXT")
 	_, _ = d.db.Exec("UPDATE providers SET schema_version = '1.0' WHERE schema_version IS NULL")
 
 	// Project migrations
@@ -297,6 +378,7 @@ func (d *Database) initSchema() error {
 	_, _ = d.db.Exec("ALTER TABLE projects ADD COLUMN attributes_json TEXT")
 	_, _ = d.db.Exec("UPDATE projects SET schema_version = '1.0' WHERE schema_version IS NULL")
 	_, _ = d.db.Exec("ALTER TABLE projects ADD COLUMN git_strategy TEXT NOT NULL DEFAULT 'direct'")
+	_, _ = d.db.Exec("ALTER TABLE projects ADD COLUMN subprojects_json TEXT")
 
 	// Agent migrations
 	_, _ = d.db.Exec("ALTER TABLE agents ADD COLUMN provider_id TEXT")
@@ -363,6 +445,15 @@ func (d *Database) UpsertProject(project *models.Project) error {
 		contextJSON = string(b)
 	}
 
+	subprojectsJSON := ""
+	if len(project.Subprojects) > 0 {
+		b, err := json.Marshal(project.Subprojects)
+		if err != nil {
+			return fmt.Errorf("failed to marshal project subprojects: %w", err)
+		}
+		subprojectsJSON = string(b)
+	}
+
 	if project.CreatedAt.IsZero() {
 		project.CreatedAt = time.Now()
 	}
@@ -374,8 +465,8 @@ func (d *Database) UpsertProject(project *models.Project) error {
 	}
 
 	query := `
-		INSERT INTO projects (id, name, git_repo, branch, beads_path, git_strategy, is_perpetual, is_sticky, status, context_json, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO projects (id, name, git_repo, branch, beads_path, git_strategy, is_perpetual, is_sticky, status, context_json, subprojects_json, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			name = excluded.name,
 			git_repo = excluded.git_repo,
@@ -386,6 +477,7 @@ func (d *Database) UpsertProject(project *models.Project) error {
 			is_sticky = excluded.is_sticky,
 			status = excluded.status,
 			context_json = excluded.context_json,
+			subprojects_json = excluded.subprojects_json,
 			updated_at = excluded.updated_at
 	`
 
@@ -400,6 +492,7 @@ func (d *Database) UpsertProject(project *models.Project) error {
 		project.IsSticky,
 		string(project.Status),
 		contextJSON,
+		subprojectsJSON,
 		project.CreatedAt,
 		project.UpdatedAt,
 	)
@@ -412,7 +505,7 @@ func (d *Database) UpsertProject(project *models.Project) error {
 
 func (d *Database) ListProjects() ([]*models.Project, error) {
 	query := `
-		SELECT id, name, git_repo, branch, beads_path, git_strategy, is_perpetual, is_sticky, status, context_json, created_at, updated_at
+		SELECT id, name, git_repo, branch, beads_path, git_strategy, is_perpetual, is_sticky, status, context_json, subprojects_json, created_at, updated_at
 		FROM projects
 		ORDER BY created_at DESC
 	`
@@ -429,6 +522,7 @@ func (d *Database) ListProjects() ([]*models.Project, error) {
 		var status string
 		var gitStrategy sql.NullString
 		var contextJSON sql.NullString
+		var subprojectsJSON sql.NullString
 		var isSticky sql.NullBool
 		err := rows.Scan(
 			&p.ID,
@@ -441,6 +535,7 @@ func (d *Database) ListProjects() ([]*models.Project, error) {
 			&isSticky,
 			&status,
 			&contextJSON,
+			&subprojectsJSON,
 			&p.CreatedAt,
 			&p.UpdatedAt,
 		)
@@ -462,6 +557,9 @@ func (d *Database) ListProjects() ([]*models.Project, error) {
 		if p.Context == nil {
 			p.Context = map[string]string{}
 		}
+		if subprojectsJSON.Valid && subprojectsJSON.String != "" {
+			_ = json.Unmarshal([]byte(subprojectsJSON.String), &p.Subprojects)
+		}
 		p.Agents = []string{}
 		p.Comments = []models.ProjectComment{}
 		projects = append(projects, p)
@@ -611,8 +709,8 @@ func (d *Database) CreateProvider(provider *internalmodels.Provider) error {
 	provider.UpdatedAt = time.Now()
 
 	query := `
-		INSERT INTO providers (id, name, type, endpoint, model, description, requires_key, key_id, status, last_heartbeat_at, last_heartbeat_latency_ms, last_heartbeat_error, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO providers (id, name, type, endpoint, endpoints_json, model, description, requires_key, key_id, status, last_heartbeat_at, last_heartbeat_latency_ms, last_heartbeat_error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := d.db.Exec(query,
@@ -620,6 +718,7 @@ func (d *Database) CreateProvider(provider *internalmodels.Provider) error {
 		provider.Name,
 		provider.Type,
 		provider.Endpoint,
+		endpointsJSON(provider.Endpoints),
 		provider.Model,
 		provider.Description,
 		provider.RequiresKey,
@@ -650,12 +749,13 @@ func (d *Database) UpsertProvider(provider *internalmodels.Provider) error {
 	provider.UpdatedAt = time.Now()
 
 	query := `
-		INSERT INTO providers (id, name, type, endpoint, model, configured_model, selected_model, selection_reason, model_score, selected_gpu, description, requires_key, key_id, owner_id, is_shared, status, last_heartbeat_at, last_heartbeat_latency_ms, last_heartbeat_error, context_window, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO providers (id, name, type, endpoint, endpoints_json, model, configured_model, selected_model, selection_reason, model_score, selected_gpu, description, requires_key, key_id, owner_id, is_shared, status, last_heartbeat_at, last_heartbeat_latency_ms, last_heartbeat_error, context_window, supports_vision, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			name = excluded.name,
 			type = excluded.type,
 			endpoint = excluded.endpoint,
+			endpoints_json = excluded.endpoints_json,
 			model = excluded.model,
 			configured_model = excluded.configured_model,
 			selected_model = excluded.selected_model,
@@ -672,6 +772,7 @@ func (d *Database) UpsertProvider(provider *internalmodels.Provider) error {
 			last_heartbeat_latency_ms = excluded.last_heartbeat_latency_ms,
 			last_heartbeat_error = excluded.last_heartbeat_error,
 			context_window = excluded.context_window,
+			supports_vision = excluded.supports_vision,
 			updated_at = excluded.updated_at
 	`
 
@@ -680,6 +781,7 @@ func (d *Database) UpsertProvider(provider *internalmodels.Provider) error {
 		provider.Name,
 		provider.Type,
 		provider.Endpoint,
+		endpointsJSON(provider.Endpoints),
 		provider.Model,
 		provider.ConfiguredModel,
 		provider.SelectedModel,
@@ -696,6 +798,7 @@ func (d *Database) UpsertProvider(provider *internalmodels.Provider) error {
 		provider.LastHeartbeatLatencyMs,
 		provider.LastHeartbeatError,
 		provider.ContextWindow,
+		provider.SupportsVision,
 		provider.CreatedAt,
 		provider.UpdatedAt,
 	)
@@ -733,17 +836,19 @@ func (d *Database) DeleteAllAgents() error {
 // GetProvider retrieves a provider by ID
 func (d *Database) GetProvider(id string) (*internalmodels.Provider, error) {
 	query := `
-		SELECT id, name, type, endpoint, model, configured_model, selected_model, selection_reason, model_score, selected_gpu, description, requires_key, key_id, status, last_heartbeat_at, last_heartbeat_latency_ms, last_heartbeat_error, context_window, created_at, updated_at
+		SELECT id, name, type, endpoint, endpoints_json, model, configured_model, selected_model, selection_reason, model_score, selected_gpu, description, requires_key, key_id, status, last_heartbeat_at, last_heartbeat_latency_ms, last_heartbeat_error, context_window, supports_vision, created_at, updated_at
 		FROM providers
 		WHERE id = ?
 	`
 
 	provider := &internalmodels.Provider{}
+	var endpoints sql.NullString
 	err := d.db.QueryRow(query, id).Scan(
 		&provider.ID,
 		&provider.Name,
 		&provider.Type,
 		&provider.Endpoint,
+		&endpoints,
 		&provider.Model,
 		&provider.ConfiguredModel,
 		&provider.SelectedModel,
@@ -758,6 +863,7 @@ func (d *Database) GetProvider(id string) (*internalmodels.Provider, error) {
 		&provider.LastHeartbeatLatencyMs,
 		&provider.LastHeartbeatError,
 		&provider.ContextWindow,
+		&provider.SupportsVision,
 		&provider.CreatedAt,
 		&provider.UpdatedAt,
 	)
@@ -768,6 +874,7 @@ func (d *Database) GetProvider(id string) (*internalmodels.Provider, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get provider: %w", err)
 	}
+	provider.Endpoints = parseEndpointsJSON(endpoints)
 
 	return provider, nil
 }
@@ -775,7 +882,7 @@ func (d *Database) GetProvider(id string) (*internalmodels.Provider, error) {
 // ListProviders retrieves all providers
 func (d *Database) ListProviders() ([]*internalmodels.Provider, error) {
 	query := `
-		SELECT id, name, type, endpoint, model, configured_model, selected_model, selection_reason, model_score, selected_gpu, description, requires_key, key_id, owner_id, is_shared, status, last_heartbeat_at, last_heartbeat_latency_ms, last_heartbeat_error, created_at, updated_at
+		SELECT id, name, type, endpoint, endpoints_json, model, configured_model, selected_model, selection_reason, model_score, selected_gpu, description, requires_key, key_id, owner_id, is_shared, status, last_heartbeat_at, last_heartbeat_latency_ms, last_heartbeat_error, supports_vision, created_at, updated_at
 		FROM providers
 		ORDER BY created_at DESC
 	`
@@ -789,13 +896,14 @@ func (d *Database) ListProviders() ([]*internalmodels.Provider, error) {
 	var providers []*internalmodels.Provider
 	for rows.Next() {
 		provider := &internalmodels.Provider{}
-		var ownerID sql.NullString
+		var endpoints, ownerID sql.NullString
 		var isShared sql.NullBool
 		err := rows.Scan(
 			&provider.ID,
 			&provider.Name,
 			&provider.Type,
 			&provider.Endpoint,
+			&endpoints,
 			&provider.Model,
 			&provider.ConfiguredModel,
 			&provider.SelectedModel,
@@ -811,6 +919,7 @@ func (d *Database) ListProviders() ([]*internalmodels.Provider, error) {
 			&provider.LastHeartbeatAt,
 			&provider.LastHeartbeatLatencyMs,
 			&provider.LastHeartbeatError,
+			&provider.SupportsVision,
 			&provider.CreatedAt,
 			&provider.UpdatedAt,
 		)
@@ -825,12 +934,32 @@ func (d *Database) ListProviders() ([]*internalmodels.Provider, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan provider: %w", err)
 		}
+		provider.Endpoints = parseEndpointsJSON(endpoints)
 		providers = append(providers, provider)
 	}
 
 	return providers, nil
 }
 
+// endpointsJSON encodes a provider's additional endpoints for storage
+func endpointsJSON(endpoints []string) interface{} {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(endpoints)
+	return string(data)
+}
+
+// parseEndpointsJSON decodes a provider's stored additional endpoints
+func parseEndpointsJSON(s sql.NullString) []string {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	var endpoints []string
+	_ = json.Unmarshal([]byte(s.String), &endpoints)
+	return endpoints
+}
+
 // ListProvidersForUser retrieves providers accessible to a specific user
 // Returns providers owned by the user OR shared providers
 func (d *Database) ListProvidersForUser(userID string) ([]*internalmodels.Provider, error) {
@@ -900,7 +1029,7 @@ func (d *Database) UpdateProvider(provider *internalmodels.Provider) error {
 
 	query := `
 		UPDATE providers
-		SET name = ?, type = ?, endpoint = ?, model = ?, description = ?, requires_key = ?, key_id = ?, status = ?, updated_at = ?
+		SET name = ?, type = ?, endpoint = ?, endpoints_json = ?, model = ?, description = ?, requires_key = ?, key_id = ?, status = ?, updated_at = ?
 		WHERE id = ?
 	`
 
@@ -908,6 +1037,7 @@ func (d *Database) UpdateProvider(provider *internalmodels.Provider) error {
 		provider.Name,
 		provider.Type,
 		provider.Endpoint,
+		endpointsJSON(provider.Endpoints),
 		provider.Model,
 		provider.Description,
 		provider.RequiresKey,
@@ -299,6 +299,29 @@ func TestUpsertProject_WithContext(t *testing.T) {
 	}
 }
 
+func TestUpsertProject_WithSubprojects(t *testing.T) {
+	db := newTestDB(t)
+	p := makeTestProject("proj-mono", "Monorepo")
+	p.Subprojects = []models.Subproject{
+		{Name: "api", Path: "services/api", TestCommand: "go test ./...", Ignore: []string{"testdata"}},
+	}
+	if err := db.UpsertProject(p); err != nil {
+		t.Fatalf("UpsertProject failed: %v", err)
+	}
+
+	projects, err := db.ListProjects()
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+	if len(projects) != 1 || len(projects[0].Subprojects) != 1 {
+		t.Fatalf("Expected 1 project with 1 subproject, got %+v", projects)
+	}
+	sp := projects[0].Subprojects[0]
+	if sp.Path != "services/api" || sp.TestCommand != "go test ./..." || len(sp.Ignore) != 1 {
+		t.Errorf("Subproject not round-tripped: %+v", sp)
+	}
+}
+
 func TestUpsertProject_NilContext(t *testing.T) {
 	db := newTestDB(t)
 	p := makeTestProject("proj-nil-ctx", "NilContextProject")
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/digest"
+)
+
+// SaveDigest archives a generated digest
+func (d *Database) SaveDigest(r *digest.Report) error {
+	if r == nil {
+		return fmt.Errorf("digest cannot be nil")
+	}
+	report, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest: %w", err)
+	}
+	_, err = d.db.Exec(`
+		INSERT INTO digests (id, project_id, period, period_start, period_end, generated_at, report_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		r.ID, r.ProjectID, r.Period, r.Start, r.End, r.GeneratedAt, string(report),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save digest: %w", err)
+	}
+	return nil
+}
+
+// GetDigest returns an archived digest, or nil if there is none with id
+func (d *Database) GetDigest(id string) (*digest.Report, error) {
+	var report string
+	err := d.db.QueryRow(`SELECT report_json FROM digests WHERE id = ?`, id).Scan(&report)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest: %w", err)
+	}
+	r := &digest.Report{}
+	if err := json.Unmarshal([]byte(report), r); err != nil {
+		return nil, fmt.Errorf("failed to decode digest %s: %w", id, err)
+	}
+	return r, nil
+}
+
+// ListDigests returns archived digests newest first, filtered by project and
+// period when they are not empty
+func (d *Database) ListDigests(projectID, period string, limit int) ([]*digest.Report, error) {
+	query := `SELECT id, report_json FROM digests WHERE 1=1`
+	var args []interface{}
+	if projectID != "" {
+		query += ` AND project_id = ?`
+		args = append(args, projectID)
+	}
+	if period != "" {
+		query += ` AND period = ?`
+		args = append(args, period)
+	}
+	query += ` ORDER BY generated_at DESC, rowid DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digests: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []*digest.Report
+	for rows.Next() {
+		var id, report string
+		if err := rows.Scan(&id, &report); err != nil {
+			return nil, fmt.Errorf("failed to scan digest: %w", err)
+		}
+		r := &digest.Report{}
+		if err := json.Unmarshal([]byte(report), r); err != nil {
+			return nil, fmt.Errorf("failed to decode digest %s: %w", id, err)
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
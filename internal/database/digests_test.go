@@ -0,0 +1,46 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/digest"
+)
+
+func TestDigests_SaveGetList(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, r := range []*digest.Report{
+		{ID: "d1", ProjectID: "p1", Period: digest.PeriodDaily, GeneratedAt: now.Add(-2 * time.Hour)},
+		{ID: "d2", ProjectID: "p1", Period: digest.PeriodWeekly, GeneratedAt: now.Add(-time.Hour)},
+		{ID: "d3", ProjectID: "p2", Period: digest.PeriodDaily, GeneratedAt: now,
+			Closed: []*digest.BeadItem{{ID: "b1", Title: "Done"}}, Errors: map[string]string{"costs": "unavailable"}},
+	} {
+		r.Start, r.End = now.AddDate(0, 0, -1), now
+		if err := db.SaveDigest(r); err != nil {
+			t.Fatalf("SaveDigest(%d): %v", i, err)
+		}
+	}
+	if err := db.SaveDigest(&digest.Report{ID: "d1"}); err == nil {
+		t.Error("expected a duplicate id to be rejected")
+	}
+
+	got, err := db.GetDigest("d3")
+	if err != nil || got == nil || len(got.Closed) != 1 || got.Closed[0].Title != "Done" || got.Errors["costs"] != "unavailable" {
+		t.Fatalf("GetDigest() = %+v, %v", got, err)
+	}
+	if got, err := db.GetDigest("missing"); got != nil || err != nil {
+		t.Errorf("GetDigest(missing) = %v, %v", got, err)
+	}
+
+	all, err := db.ListDigests("", "", 0)
+	if err != nil || len(all) != 3 || all[0].ID != "d3" || all[2].ID != "d1" {
+		t.Fatalf("ListDigests() = %d digests, %v", len(all), err)
+	}
+	if list, _ := db.ListDigests("p1", digest.PeriodDaily, 0); len(list) != 1 || list[0].ID != "d1" {
+		t.Errorf("expected only d1 for p1 daily, got %d", len(list))
+	}
+	if list, _ := db.ListDigests("p1", "", 1); len(list) != 1 || list[0].ID != "d2" {
+		t.Errorf("expected the limit to keep the newest, got %d", len(list))
+	}
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/estimate"
+	"github.com/jordanhubbard/loom/internal/memory"
+)
+
+// SaveEstimate records a bead's estimate, replacing any earlier one
+func (d *Database) SaveEstimate(e *estimate.Estimate) error {
+	if e == nil {
+		return fmt.Errorf("estimate cannot be nil")
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal estimate: %w", err)
+	}
+	var closedAt interface{}
+	if e.Actual != nil {
+		closedAt = e.Actual.ClosedAt
+	}
+	_, err = d.db.Exec(`
+		INSERT INTO bead_estimates (bead_id, project_id, estimated_at, closed_at, estimate_json, embedding)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(bead_id) DO UPDATE SET
+			project_id = excluded.project_id,
+			estimated_at = excluded.estimated_at,
+			closed_at = excluded.closed_at,
+			estimate_json = excluded.estimate_json,
+			embedding = excluded.embedding`,
+		e.BeadID, e.ProjectID, e.EstimatedAt, closedAt, string(data), memory.EncodeEmbedding(e.Embedding),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save estimate: %w", err)
+	}
+	return nil
+}
+
+// GetEstimate returns a bead's estimate, or nil if it has none
+func (d *Database) GetEstimate(beadID string) (*estimate.Estimate, error) {
+	var data string
+	var embedding []byte
+	err := d.db.QueryRow(`SELECT estimate_json, embedding FROM bead_estimates WHERE bead_id = ?`, beadID).Scan(&data, &embedding)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get estimate: %w", err)
+	}
+	return decodeEstimate(beadID, data, embedding)
+}
+
+// ListOutcomes returns estimates of closed beads, most recently closed
+// first, in one project or every project when projectID is empty
+func (d *Database) ListOutcomes(projectID string, limit int) ([]*estimate.Estimate, error) {
+	query := `SELECT bead_id, estimate_json, embedding FROM bead_estimates WHERE closed_at IS NOT NULL`
+	var args []interface{}
+	if projectID != "" {
+		query += ` AND project_id = ?`
+		args = append(args, projectID)
+	}
+	query += ` ORDER BY closed_at DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list estimates: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*estimate.Estimate
+	for rows.Next() {
+		var beadID, data string
+		var embedding []byte
+		if err := rows.Scan(&beadID, &data, &embedding); err != nil {
+			return nil, fmt.Errorf("failed to scan estimate: %w", err)
+		}
+		e, err := decodeEstimate(beadID, data, embedding)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func decodeEstimate(beadID, data string, embedding []byte) (*estimate.Estimate, error) {
+	e := &estimate.Estimate{}
+	if err := json.Unmarshal([]byte(data), e); err != nil {
+		return nil, fmt.Errorf("failed to decode estimate for %s: %w", beadID, err)
+	}
+	e.Embedding = memory.DecodeEmbedding(embedding)
+	return e, nil
+}
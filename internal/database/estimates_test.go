@@ -0,0 +1,48 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/estimate"
+)
+
+func TestEstimates_SaveGetOutcomes(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	open := &estimate.Estimate{BeadID: "b1", ProjectID: "p1", EstimatedAt: now, Method: estimate.MethodDefault,
+		CostUSD: estimate.Range{Low: 0.1, Expected: 0.5, High: 2}, Embedding: []float32{0.5, -0.25}}
+	if err := db.SaveEstimate(open); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.GetEstimate("b1")
+	if err != nil || got == nil || got.CostUSD.Expected != 0.5 || len(got.Embedding) != 2 || got.Embedding[1] != -0.25 {
+		t.Fatalf("GetEstimate() = %+v, %v", got, err)
+	}
+	if got, err := db.GetEstimate("missing"); got != nil || err != nil {
+		t.Errorf("GetEstimate(missing) = %v, %v", got, err)
+	}
+
+	if list, _ := db.ListOutcomes("", 0); len(list) != 0 {
+		t.Fatalf("expected no outcomes before a bead closed, got %d", len(list))
+	}
+	for i, id := range []string{"b1", "b2", "b3"} {
+		e := &estimate.Estimate{BeadID: id, ProjectID: "p1", EstimatedAt: now,
+			Actual: &estimate.Actual{ClosedAt: now.Add(time.Duration(i) * time.Hour), CostUSD: float64(i)}}
+		if id == "b3" {
+			e.ProjectID = "p2"
+		}
+		if err := db.SaveEstimate(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := db.ListOutcomes("p1", 0)
+	if err != nil || len(list) != 2 || list[0].BeadID != "b2" || list[1].Actual == nil {
+		t.Fatalf("ListOutcomes(p1) = %+v, %v", list, err)
+	}
+	if list, _ := db.ListOutcomes("", 1); len(list) != 1 || list[0].BeadID != "b3" {
+		t.Errorf("expected the most recently closed bead first, got %+v", list)
+	}
+}
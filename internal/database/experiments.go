@@ -0,0 +1,173 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/experiment"
+)
+
+// SaveExperiment creates or updates an experiment
+func (d *Database) SaveExperiment(e *experiment.Experiment) error {
+	if e == nil {
+		return fmt.Errorf("experiment cannot be nil")
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal experiment: %w", err)
+	}
+	_, err = d.db.Exec(`
+		INSERT INTO experiments (id, status, created_at, experiment_json)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			status = excluded.status,
+			experiment_json = excluded.experiment_json`,
+		e.ID, e.Status, e.CreatedAt, string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save experiment: %w", err)
+	}
+	return nil
+}
+
+// GetExperiment returns an experiment, or nil if there is none with id
+func (d *Database) GetExperiment(id string) (*experiment.Experiment, error) {
+	var data string
+	err := d.db.QueryRow(`SELECT experiment_json FROM experiments WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get experiment: %w", err)
+	}
+	e := &experiment.Experiment{}
+	if err := json.Unmarshal([]byte(data), e); err != nil {
+		return nil, fmt.Errorf("failed to decode experiment %s: %w", id, err)
+	}
+	return e, nil
+}
+
+// ListExperiments returns every experiment, oldest first
+func (d *Database) ListExperiments() ([]*experiment.Experiment, error) {
+	rows, err := d.db.Query(`SELECT id, experiment_json FROM experiments ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list experiments: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*experiment.Experiment
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan experiment: %w", err)
+		}
+		e := &experiment.Experiment{}
+		if err := json.Unmarshal([]byte(data), e); err != nil {
+			return nil, fmt.Errorf("failed to decode experiment %s: %w", id, err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// DeleteExperiment removes an experiment and its assignments
+func (d *Database) DeleteExperiment(id string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.Exec(`DELETE FROM experiment_assignments WHERE experiment_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete experiment assignments: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM experiments WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete experiment: %w", err)
+	}
+	return tx.Commit()
+}
+
+// SaveAssignment records the variant a bead was assigned, or its outcome
+func (d *Database) SaveAssignment(a *experiment.Assignment) error {
+	if a == nil {
+		return fmt.Errorf("assignment cannot be nil")
+	}
+	var outcome interface{}
+	if a.Outcome != nil {
+		data, err := json.Marshal(a.Outcome)
+		if err != nil {
+			return fmt.Errorf("failed to marshal outcome: %w", err)
+		}
+		outcome = string(data)
+	}
+	_, err := d.db.Exec(`
+		INSERT INTO experiment_assignments (bead_id, experiment_id, variant, assigned_at, excluded, outcome_json)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(bead_id) DO UPDATE SET
+			experiment_id = excluded.experiment_id,
+			variant = excluded.variant,
+			assigned_at = excluded.assigned_at,
+			excluded = excluded.excluded,
+			outcome_json = excluded.outcome_json`,
+		a.BeadID, a.ExperimentID, a.Variant, a.AssignedAt, a.Excluded, outcome,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save assignment: %w", err)
+	}
+	return nil
+}
+
+// GetAssignment returns a bead's experiment assignment, or nil if it has none
+func (d *Database) GetAssignment(beadID string) (*experiment.Assignment, error) {
+	row := d.db.QueryRow(`
+		SELECT bead_id, experiment_id, variant, assigned_at, excluded, outcome_json
+		FROM experiment_assignments WHERE bead_id = ?`, beadID)
+	a, err := scanAssignment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+// ListAssignments returns the beads assigned in an experiment, oldest first
+func (d *Database) ListAssignments(experimentID string) ([]*experiment.Assignment, error) {
+	rows, err := d.db.Query(`
+		SELECT bead_id, experiment_id, variant, assigned_at, excluded, outcome_json
+		FROM experiment_assignments WHERE experiment_id = ?
+		ORDER BY assigned_at, bead_id`, experimentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*experiment.Assignment
+	for rows.Next() {
+		a, err := scanAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func scanAssignment(row interface{ Scan(...interface{}) error }) (*experiment.Assignment, error) {
+	a := &experiment.Assignment{}
+	var assignedAt time.Time
+	var outcome sql.NullString
+	if err := row.Scan(&a.BeadID, &a.ExperimentID, &a.Variant, &assignedAt, &a.Excluded, &outcome); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan assignment: %w", err)
+	}
+	a.AssignedAt = assignedAt
+	if outcome.Valid && outcome.String != "" {
+		a.Outcome = &experiment.Outcome{}
+		if err := json.Unmarshal([]byte(outcome.String), a.Outcome); err != nil {
+			return nil, fmt.Errorf("failed to decode outcome for %s: %w", a.BeadID, err)
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/experiment"
+)
+
+func TestExperiments_SaveGetDelete(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	e := &experiment.Experiment{ID: "exp-1", Name: "larger model", Category: "bug", Status: experiment.StatusRunning, CreatedAt: now,
+		Variants: []*experiment.Variant{{Name: "A", ProviderID: "small", Percent: 50}, {Name: "B", Model: "large", Percent: 50}}}
+	if err := db.SaveExperiment(e); err != nil {
+		t.Fatal(err)
+	}
+	e.Status = experiment.StatusPaused
+	if err := db.SaveExperiment(e); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveExperiment(&experiment.Experiment{ID: "exp-2", Status: experiment.StatusRunning, CreatedAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.GetExperiment("exp-1")
+	if err != nil || got == nil || got.Status != experiment.StatusPaused || got.Variant("B").Model != "large" {
+		t.Fatalf("GetExperiment() = %+v, %v", got, err)
+	}
+	if got, err := db.GetExperiment("missing"); got != nil || err != nil {
+		t.Errorf("GetExperiment(missing) = %v, %v", got, err)
+	}
+	list, err := db.ListExperiments()
+	if err != nil || len(list) != 2 || list[0].ID != "exp-1" {
+		t.Fatalf("ListExperiments() = %+v, %v", list, err)
+	}
+
+	for i, id := range []string{"b1", "b2", "b3"} {
+		a := &experiment.Assignment{ExperimentID: "exp-1", BeadID: id, Variant: "A", AssignedAt: now.Add(time.Duration(i) * time.Minute)}
+		if id == "b3" {
+			a.ExperimentID = "exp-2"
+		}
+		if err := db.SaveAssignment(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.SaveAssignment(&experiment.Assignment{ExperimentID: "exp-1", BeadID: "b1", Variant: "A", AssignedAt: now,
+		Outcome: &experiment.Outcome{Success: true, CostUSD: 1.5, Retries: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	a, err := db.GetAssignment("b1")
+	if err != nil || a == nil || a.Outcome == nil || a.Outcome.CostUSD != 1.5 || !a.AssignedAt.Equal(now) {
+		t.Fatalf("GetAssignment() = %+v, %v", a, err)
+	}
+	if a, err := db.GetAssignment("missing"); a != nil || err != nil {
+		t.Errorf("GetAssignment(missing) = %v, %v", a, err)
+	}
+	assignments, err := db.ListAssignments("exp-1")
+	if err != nil || len(assignments) != 2 || assignments[1].BeadID != "b2" || assignments[1].Outcome != nil {
+		t.Fatalf("ListAssignments() = %+v, %v", assignments, err)
+	}
+
+	assignments[1].Excluded = true
+	if err := db.SaveAssignment(assignments[1]); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := db.GetAssignment("b2"); a == nil || !a.Excluded {
+		t.Errorf("expected b2 to be excluded, got %+v", a)
+	}
+	if err := db.DeleteExperiment("exp-1"); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := db.GetAssignment("b1"); a != nil {
+		t.Error("expected the experiment's assignments to be deleted with it")
+	}
+	if a, _ := db.GetAssignment("b3"); a == nil {
+		t.Error("expected other experiments' assignments to be kept")
+	}
+}
@@ -0,0 +1,24 @@
+package database
+
+// migrateActionOutputs creates the table holding full action outputs that
+// were truncated in agent feedback. content is a BLOB so ranged reads with
+// substr() count bytes rather than characters.
+func (d *Database) migrateActionOutputs() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS action_outputs (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL DEFAULT '',
+		bead_id TEXT NOT NULL DEFAULT '',
+		agent_id TEXT NOT NULL DEFAULT '',
+		action_type TEXT NOT NULL,
+		field TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		content BLOB NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_action_outputs_bead ON action_outputs(bead_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_action_outputs_project ON action_outputs(project_id, created_at DESC);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
@@ -1,9 +1,5 @@
 package database
 
-import (
-	"log"
-)
-
 // migrateActivity creates the activity feed and notifications tables
 func (d *Database) migrateActivity() error {
 	// Users table (persist users to database)
@@ -130,9 +126,9 @@ func (d *Database) migrateActivity() error {
 			INSERT INTO users (id, username, email, role, is_active, created_at, updated_at)
 			VALUES ('user-admin', 'admin', 'admin@loom.local', 'admin', 1, datetime('now'), datetime('now'))
 		`)
-		log.Println("Default admin user created in database")
+		databaseLog.Info("Default admin user created in database")
 	}
 
-	log.Println("Activity and notification tables migrated successfully")
+	databaseLog.Info("Activity and notification tables migrated")
 	return nil
 }
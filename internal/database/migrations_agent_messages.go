@@ -0,0 +1,33 @@
+package database
+
+// migrateAgentMessages creates the table persisting agent-to-agent messages
+// so inboxes and bead threads survive restarts
+func (d *Database) migrateAgentMessages() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS agent_messages (
+		message_id TEXT PRIMARY KEY,
+		type TEXT NOT NULL,
+		from_agent_id TEXT NOT NULL DEFAULT '',
+		to_agent_id TEXT NOT NULL DEFAULT '',
+		to_agent_ids_json TEXT,
+		subject TEXT NOT NULL DEFAULT '',
+		body TEXT NOT NULL DEFAULT '',
+		payload_json TEXT,
+		context_json TEXT,
+		priority TEXT NOT NULL DEFAULT 'normal',
+		requires_response BOOLEAN NOT NULL DEFAULT 0,
+		in_reply_to TEXT NOT NULL DEFAULT '',
+		bead_id TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'sent',
+		created_at DATETIME NOT NULL,
+		delivered_at DATETIME,
+		read_at DATETIME,
+		acknowledged_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS idx_agent_messages_to ON agent_messages(to_agent_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_agent_messages_from ON agent_messages(from_agent_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_agent_messages_bead ON agent_messages(bead_id, created_at DESC);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
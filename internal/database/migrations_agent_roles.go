@@ -0,0 +1,22 @@
+package database
+
+// migrateAgentRoles creates the table backing the agent role registry
+func (d *Database) migrateAgentRoles() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS agent_roles (
+		name TEXT PRIMARY KEY,
+		description TEXT NOT NULL DEFAULT '',
+		system_prompt TEXT NOT NULL DEFAULT '',
+		allowed_actions_json TEXT,
+		denied_actions_json TEXT,
+		aliases_json TEXT,
+		preferred_providers_json TEXT,
+		preferred_model TEXT NOT NULL DEFAULT '',
+		built_in BOOLEAN NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
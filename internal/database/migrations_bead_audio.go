@@ -0,0 +1,25 @@
+package database
+
+// migrateBeadAudio creates the table for the voice memos beads were filed
+// from
+func (d *Database) migrateBeadAudio() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS bead_audio (
+		id TEXT PRIMARY KEY,
+		bead_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		media_type TEXT NOT NULL DEFAULT '',
+		size INTEGER NOT NULL DEFAULT 0,
+		transcript TEXT NOT NULL DEFAULT '',
+		transcribed_by TEXT NOT NULL DEFAULT '',
+		language TEXT NOT NULL DEFAULT '',
+		duration REAL NOT NULL DEFAULT 0,
+		source TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		data BLOB
+	);
+	CREATE INDEX IF NOT EXISTS idx_bead_audio_bead ON bead_audio(bead_id, created_at);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
@@ -0,0 +1,29 @@
+package database
+
+// migrateBeadContexts creates the tables persisting shared bead contexts and
+// their activity logs so collaboration state survives restarts
+func (d *Database) migrateBeadContexts() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS bead_contexts (
+		bead_id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL DEFAULT '',
+		agents_json TEXT NOT NULL DEFAULT '[]',
+		data_json TEXT NOT NULL DEFAULT '{}',
+		version INTEGER NOT NULL DEFAULT 1,
+		last_updated DATETIME NOT NULL,
+		last_updated_by TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS bead_context_activities (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		bead_id TEXT NOT NULL,
+		timestamp DATETIME NOT NULL,
+		agent_id TEXT NOT NULL DEFAULT '',
+		activity_type TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		data_json TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_bead_context_activities_bead ON bead_context_activities(bead_id, id);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
@@ -0,0 +1,21 @@
+package database
+
+// migrateBeadEvents creates the append-only bead history table that bead
+// state is replayed from
+func (d *Database) migrateBeadEvents() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS bead_events (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		bead_id TEXT NOT NULL,
+		type TEXT NOT NULL,
+		actor TEXT NOT NULL DEFAULT '',
+		timestamp DATETIME NOT NULL,
+		version INTEGER NOT NULL DEFAULT 0,
+		changes_json TEXT,
+		data_json TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_bead_events_bead ON bead_events(bead_id, seq);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
@@ -0,0 +1,21 @@
+package database
+
+// migrateBeadImages creates the table for images attached to beads
+func (d *Database) migrateBeadImages() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS bead_images (
+		id TEXT PRIMARY KEY,
+		bead_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		media_type TEXT NOT NULL DEFAULT '',
+		size INTEGER NOT NULL DEFAULT 0,
+		url TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		data BLOB
+	);
+	CREATE INDEX IF NOT EXISTS idx_bead_images_bead ON bead_images(bead_id, created_at);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
@@ -0,0 +1,41 @@
+package database
+
+// migrateBenchmarks creates the tables holding benchmark runs and their
+// per-benchmark metrics
+func (d *Database) migrateBenchmarks() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS benchmark_runs (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL,
+		bead_id TEXT NOT NULL DEFAULT '',
+		branch TEXT NOT NULL DEFAULT '',
+		commit_sha TEXT NOT NULL DEFAULT '',
+		tool TEXT NOT NULL,
+		path TEXT NOT NULL DEFAULT '',
+		baseline_id TEXT NOT NULL DEFAULT '',
+		baseline_commit TEXT NOT NULL DEFAULT '',
+		threshold REAL NOT NULL DEFAULT 0,
+		regressions_json TEXT,
+		started_at DATETIME NOT NULL,
+		completed_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_benchmark_runs_branch ON benchmark_runs(project_id, branch, commit_sha, started_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_benchmark_runs_bead ON benchmark_runs(bead_id, started_at DESC);
+
+	CREATE TABLE IF NOT EXISTS benchmark_results (
+		run_id TEXT NOT NULL,
+		package TEXT NOT NULL DEFAULT '',
+		name TEXT NOT NULL,
+		samples INTEGER NOT NULL DEFAULT 0,
+		iterations INTEGER NOT NULL DEFAULT 0,
+		ns_per_op REAL NOT NULL,
+		bytes_per_op REAL NOT NULL DEFAULT 0,
+		allocs_per_op REAL NOT NULL DEFAULT 0,
+		memory BOOLEAN NOT NULL DEFAULT 0,
+		PRIMARY KEY (run_id, package, name),
+		FOREIGN KEY (run_id) REFERENCES benchmark_runs(id) ON DELETE CASCADE
+	);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
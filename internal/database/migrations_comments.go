@@ -1,9 +1,5 @@
 package database
 
-import (
-	"log"
-)
-
 // migrateComments creates the bead comments and mentions tables
 func (d *Database) migrateComments() error {
 	// Bead comments table
@@ -54,6 +50,6 @@ func (d *Database) migrateComments() error {
 		return err
 	}
 
-	log.Println("Comment tables migrated successfully")
+	databaseLog.Info("Comment tables migrated")
 	return nil
 }
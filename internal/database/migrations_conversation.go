@@ -1,9 +1,5 @@
 package database
 
-import (
-	"log"
-)
-
 // migrateConversations creates the conversation_contexts table for
 // storing multi-turn conversation sessions
 func (d *Database) migrateConversations() error {
@@ -32,6 +28,6 @@ func (d *Database) migrateConversations() error {
 		return err
 	}
 
-	log.Println("Conversation contexts table migrated successfully")
+	databaseLog.Info("Conversation contexts table migrated")
 	return nil
 }
@@ -1,7 +1,5 @@
 package database
 
-import "log"
-
 // migrateCredentials creates the credentials table for storing encrypted SSH keys
 func (d *Database) migrateCredentials() error {
 	schema := `
@@ -26,6 +24,6 @@ func (d *Database) migrateCredentials() error {
 		return err
 	}
 
-	log.Println("Credentials table migrated successfully")
+	databaseLog.Info("Credentials table migrated")
 	return nil
 }
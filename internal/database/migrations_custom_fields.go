@@ -0,0 +1,18 @@
+package database
+
+// migrateCustomFields creates the table of per-project custom bead field
+// definitions
+func (d *Database) migrateCustomFields() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS bead_field_definitions (
+		project_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		definition_json TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (project_id, name)
+	);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
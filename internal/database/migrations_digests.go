@@ -0,0 +1,19 @@
+package database
+
+// migrateDigests creates the archive of generated project digests
+func (d *Database) migrateDigests() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS digests (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL,
+		period TEXT NOT NULL,
+		period_start DATETIME NOT NULL,
+		period_end DATETIME NOT NULL,
+		generated_at DATETIME NOT NULL,
+		report_json TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_digests_project ON digests(project_id, period, generated_at);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
@@ -0,0 +1,18 @@
+package database
+
+// migrateEstimates creates the table of bead cost estimates and their actuals
+func (d *Database) migrateEstimates() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS bead_estimates (
+		bead_id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL DEFAULT '',
+		estimated_at DATETIME NOT NULL,
+		closed_at DATETIME,
+		estimate_json TEXT NOT NULL,
+		embedding BLOB
+	);
+	CREATE INDEX IF NOT EXISTS idx_bead_estimates_closed ON bead_estimates(project_id, closed_at);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
@@ -0,0 +1,26 @@
+package database
+
+// migrateExperiments creates the tables for model A/B experiments and the
+// beads assigned to them
+func (d *Database) migrateExperiments() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS experiments (
+		id TEXT PRIMARY KEY,
+		status TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		experiment_json TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS experiment_assignments (
+		bead_id TEXT PRIMARY KEY,
+		experiment_id TEXT NOT NULL,
+		variant TEXT NOT NULL,
+		assigned_at DATETIME NOT NULL,
+		excluded INTEGER NOT NULL DEFAULT 0,
+		outcome_json TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_experiment_assignments_experiment ON experiment_assignments(experiment_id, assigned_at);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
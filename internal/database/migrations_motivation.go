@@ -1,9 +1,5 @@
 package database
 
-import (
-	"log"
-)
-
 // migrateMotivations adds the motivations and milestones tables
 func (d *Database) migrateMotivations() error {
 	// Motivations table
@@ -106,6 +102,6 @@ func (d *Database) migrateMotivations() error {
 	_, _ = d.db.Exec("ALTER TABLE beads ADD COLUMN milestone_id TEXT")
 	_, _ = d.db.Exec("ALTER TABLE beads ADD COLUMN estimated_time INTEGER")
 
-	log.Println("Motivation and milestone tables migrated successfully")
+	databaseLog.Info("Motivation and milestone tables migrated")
 	return nil
 }
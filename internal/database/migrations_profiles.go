@@ -0,0 +1,24 @@
+package database
+
+// migrateProfiles creates the table holding pprof files captured by
+// profile_project, with their top functions
+func (d *Database) migrateProfiles() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS profiles (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL,
+		bead_id TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		target TEXT NOT NULL DEFAULT '',
+		name TEXT NOT NULL,
+		size INTEGER NOT NULL DEFAULT 0,
+		total TEXT NOT NULL DEFAULT '',
+		top_json TEXT,
+		created_at DATETIME NOT NULL,
+		data BLOB
+	);
+	CREATE INDEX IF NOT EXISTS idx_profiles_bead ON profiles(bead_id, created_at);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
@@ -0,0 +1,26 @@
+package database
+
+// migrateSchedules creates the table backing the recurring job scheduler
+func (d *Database) migrateSchedules() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS schedules (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		project_id TEXT NOT NULL DEFAULT '',
+		interval_seconds INTEGER NOT NULL,
+		params_json TEXT,
+		enabled BOOLEAN NOT NULL DEFAULT 1,
+		next_run_at DATETIME NOT NULL,
+		last_run_at DATETIME,
+		last_result TEXT,
+		last_error TEXT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_schedules_kind ON schedules(kind);
+	CREATE INDEX IF NOT EXISTS idx_schedules_project ON schedules(project_id);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
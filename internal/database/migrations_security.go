@@ -0,0 +1,23 @@
+package database
+
+// migrateSecurityScans creates the table holding security scan results
+func (d *Database) migrateSecurityScans() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS security_scans (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL,
+		path TEXT NOT NULL DEFAULT '',
+		tools_json TEXT NOT NULL DEFAULT '[]',
+		findings_json TEXT NOT NULL DEFAULT '[]',
+		summary_json TEXT NOT NULL DEFAULT '{}',
+		errors_json TEXT,
+		skipped_json TEXT,
+		bead_ids_json TEXT,
+		started_at DATETIME NOT NULL,
+		completed_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_security_scans_project ON security_scans(project_id, started_at DESC);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
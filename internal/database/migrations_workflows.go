@@ -1,9 +1,5 @@
 package database
 
-import (
-	"log"
-)
-
 // migrateWorkflows adds the workflow system tables
 func (d *Database) migrateWorkflows() error {
 	// Workflows table
@@ -132,6 +128,6 @@ func (d *Database) migrateWorkflows() error {
 		return err
 	}
 
-	log.Println("Workflow tables migrated successfully")
+	databaseLog.Info("Workflow tables migrated")
 	return nil
 }
@@ -88,6 +88,7 @@ func (d *Database) initSchemaPostgres() error {
 		model_score REAL,
 		selected_gpu TEXT,
 		gpu_constraints_json TEXT,
+		endpoints_json TEXT,
 		description TEXT,
 		requires_key BOOLEAN NOT NULL DEFAULT false,
 		key_id TEXT,
@@ -136,6 +137,9 @@ func (d *Database) initSchemaPostgres() error {
 	CREATE INDEX IF NOT EXISTS idx_request_logs_provider_id ON request_logs(provider_id);
 	CREATE INDEX IF NOT EXISTS idx_distributed_locks_expires_at ON distributed_locks(expires_at);
 	CREATE INDEX IF NOT EXISTS idx_instances_last_heartbeat ON instances(last_heartbeat);
+
+	-- Columns added after the providers table was first created
+	ALTER TABLE providers ADD COLUMN IF NOT EXISTS endpoints_json TEXT;
 	`
 
 	_, err := d.db.Exec(schema)
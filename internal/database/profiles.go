@@ -0,0 +1,72 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// SaveProfile stores a captured pprof file
+func (d *Database) SaveProfile(p *models.Profile) error {
+	if p == nil {
+		return fmt.Errorf("profile cannot be nil")
+	}
+	top, _ := json.Marshal(p.Top)
+	_, err := d.db.Exec(`
+		INSERT INTO profiles (id, project_id, bead_id, kind, target, name, size, total, top_json, created_at, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.ID, p.ProjectID, p.BeadID, p.Kind, p.Target, p.Name, p.Size, p.Total, string(top), p.CreatedAt, p.Data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	return nil
+}
+
+// GetProfile returns a profile with its data, or nil if there is none with id
+func (d *Database) GetProfile(id string) (*models.Profile, error) {
+	p := &models.Profile{}
+	var top sql.NullString
+	err := d.db.QueryRow(`
+		SELECT id, project_id, bead_id, kind, target, name, size, total, top_json, created_at, data
+		FROM profiles WHERE id = ?`, id,
+	).Scan(&p.ID, &p.ProjectID, &p.BeadID, &p.Kind, &p.Target, &p.Name, &p.Size, &p.Total, &top, &p.CreatedAt, &p.Data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	if top.Valid {
+		_ = json.Unmarshal([]byte(top.String), &p.Top)
+	}
+	return p, nil
+}
+
+// ListProfiles returns a bead's profiles without their data, oldest first
+func (d *Database) ListProfiles(beadID string) ([]*models.Profile, error) {
+	rows, err := d.db.Query(`
+		SELECT id, project_id, bead_id, kind, target, name, size, total, top_json, created_at
+		FROM profiles WHERE bead_id = ?
+		ORDER BY created_at, id`, beadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*models.Profile
+	for rows.Next() {
+		p := &models.Profile{}
+		var top sql.NullString
+		if err := rows.Scan(&p.ID, &p.ProjectID, &p.BeadID, &p.Kind, &p.Target, &p.Name, &p.Size, &p.Total, &top, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan profile: %w", err)
+		}
+		if top.Valid {
+			_ = json.Unmarshal([]byte(top.String), &p.Top)
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
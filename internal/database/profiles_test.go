@@ -0,0 +1,52 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestProfiles_SaveGetList(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	cpu := &models.Profile{
+		ID: "prof-1", ProjectID: "proj-1", BeadID: "bd-1", Kind: models.ProfileCPU,
+		Target: "./store", Name: "cpu.pprof", Size: 4, Total: "2.50s",
+		Top:       []models.ProfileEntry{{Function: "store.(*Index).Put", Flat: "1.20s", FlatPercent: 48, Cum: "2s", CumPercent: 80}},
+		CreatedAt: now, Data: []byte{0x1f, 0x8b, 0x08, 0x00},
+	}
+	heap := &models.Profile{
+		ID: "prof-2", ProjectID: "proj-1", BeadID: "bd-1", Kind: models.ProfileHeap,
+		Target: "./store", Name: "heap.pprof", Size: 1, CreatedAt: now.Add(time.Second), Data: []byte{1},
+	}
+	other := &models.Profile{ID: "prof-3", ProjectID: "proj-1", BeadID: "bd-2", Kind: models.ProfileCPU, Name: "cpu.pprof", CreatedAt: now}
+	for _, p := range []*models.Profile{cpu, heap, other} {
+		if err := db.SaveProfile(p); err != nil {
+			t.Fatalf("SaveProfile(%s): %v", p.ID, err)
+		}
+	}
+
+	got, err := db.GetProfile("prof-1")
+	if err != nil || got == nil {
+		t.Fatalf("GetProfile: %v, %v", got, err)
+	}
+	if got.Total != "2.50s" || len(got.Data) != 4 || len(got.Top) != 1 || got.Top[0].Function != "store.(*Index).Put" || got.Top[0].CumPercent != 80 {
+		t.Errorf("GetProfile = %+v", got)
+	}
+	if missing, err := db.GetProfile("nope"); err != nil || missing != nil {
+		t.Errorf("GetProfile(missing) = %v, %v", missing, err)
+	}
+
+	list, err := db.ListProfiles("bd-1")
+	if err != nil {
+		t.Fatalf("ListProfiles: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != "prof-1" || list[1].ID != "prof-2" {
+		t.Fatalf("ListProfiles = %+v", list)
+	}
+	if list[0].Data != nil || len(list[0].Top) != 1 {
+		t.Errorf("listed profile = %+v, want top without data", list[0])
+	}
+}
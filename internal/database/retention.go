@@ -0,0 +1,187 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// retentionTables are the tables reported by StorageStats, largest growers
+// first
+var retentionTables = []string{
+	"request_logs",
+	"command_logs",
+	"action_outputs",
+	"conversation_contexts",
+	"activity_feed",
+	"agent_messages",
+	"notifications",
+	"workflow_execution_history",
+}
+
+// BeadLogs holds everything the database records about one bead's work
+type BeadLogs struct {
+	Conversations []*models.ConversationContext `json:"conversations,omitempty"`
+	CommandLogs   []*models.CommandLog          `json:"command_logs,omitempty"`
+	ActionOutputs []*models.ActionOutput        `json:"action_outputs,omitempty"`
+}
+
+// StorageStats reports how much the database holds
+type StorageStats struct {
+	DatabaseType string           `json:"database_type"`
+	SizeBytes    int64            `json:"size_bytes"`
+	Rows         map[string]int64 `json:"rows"`
+}
+
+// GetBeadLogs returns a bead's conversations, command logs and action
+// outputs, including output content
+func (d *Database) GetBeadLogs(beadID string) (*BeadLogs, error) {
+	logs := &BeadLogs{}
+
+	sessionIDs, err := d.queryStrings(`SELECT session_id FROM conversation_contexts WHERE bead_id = ? ORDER BY created_at`, beadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	for _, id := range sessionIDs {
+		conv, err := d.GetConversationContext(id)
+		if err != nil {
+			return nil, err
+		}
+		logs.Conversations = append(logs.Conversations, conv)
+	}
+
+	rows, err := d.db.Query(`SELECT `+commandLogColumns+` FROM command_logs WHERE bead_id = ? ORDER BY created_at`, beadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list command logs: %w", err)
+	}
+	logs.CommandLogs, err = scanCommandLogs(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = d.db.Query(`SELECT `+actionOutputColumns+`, content FROM action_outputs WHERE bead_id = ? ORDER BY created_at`, beadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list action outputs: %w", err)
+	}
+	logs.ActionOutputs, err = scanActionOutputsWithContent(rows)
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// DeleteBeadLogs removes a bead's conversations, command logs and action
+// outputs in one transaction and returns the number of rows deleted
+func (d *Database) DeleteBeadLogs(beadID string) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var total int64
+	for _, table := range []string{"conversation_contexts", "command_logs", "action_outputs"} {
+		res, err := tx.Exec(`DELETE FROM `+table+` WHERE bead_id = ?`, beadID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// StorageStats returns the database size and row counts of the tables that
+// grow with use. Tables that don't exist on this backend are skipped.
+func (d *Database) StorageStats() (*StorageStats, error) {
+	stats := &StorageStats{DatabaseType: d.dbType, Rows: map[string]int64{}}
+
+	switch d.dbType {
+	case "postgres":
+		if err := d.db.QueryRow(`SELECT pg_database_size(current_database())`).Scan(&stats.SizeBytes); err != nil {
+			return nil, fmt.Errorf("failed to read database size: %w", err)
+		}
+	default:
+		var pages, pageSize int64
+		if err := d.db.QueryRow(`PRAGMA page_count`).Scan(&pages); err != nil {
+			return nil, fmt.Errorf("failed to read database size: %w", err)
+		}
+		if err := d.db.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+			return nil, fmt.Errorf("failed to read database size: %w", err)
+		}
+		stats.SizeBytes = pages * pageSize
+	}
+
+	for _, table := range retentionTables {
+		var n int64
+		if err := d.db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+			continue
+		}
+		stats.Rows[table] = n
+	}
+	return stats, nil
+}
+
+const commandLogColumns = `id, agent_id, bead_id, project_id, command, working_dir, exit_code, stdout, stderr,
+	duration_ms, started_at, completed_at, context, created_at`
+
+// scanCommandLogs reads and closes rows selected with commandLogColumns
+func scanCommandLogs(rows *sql.Rows) ([]*models.CommandLog, error) {
+	defer rows.Close()
+	var logs []*models.CommandLog
+	for rows.Next() {
+		var cmd models.CommandLog
+		var beadID, projectID, stdout, stderr, contextJSON sql.NullString
+		if err := rows.Scan(&cmd.ID, &cmd.AgentID, &beadID, &projectID, &cmd.Command, &cmd.WorkingDir,
+			&cmd.ExitCode, &stdout, &stderr, &cmd.Duration, &cmd.StartedAt, &cmd.CompletedAt,
+			&contextJSON, &cmd.CreatedAt); err != nil {
+			return nil, err
+		}
+		cmd.BeadID, cmd.ProjectID, cmd.Stdout, cmd.Stderr = beadID.String, projectID.String, stdout.String, stderr.String
+		if contextJSON.Valid && contextJSON.String != "" {
+			_ = json.Unmarshal([]byte(contextJSON.String), &cmd.Context)
+		}
+		logs = append(logs, &cmd)
+	}
+	return logs, rows.Err()
+}
+
+// scanActionOutputsWithContent reads and closes rows selected with
+// actionOutputColumns followed by content
+func scanActionOutputsWithContent(rows *sql.Rows) ([]*models.ActionOutput, error) {
+	defer rows.Close()
+	var outputs []*models.ActionOutput
+	for rows.Next() {
+		out := &models.ActionOutput{}
+		var content []byte
+		if err := rows.Scan(&out.ID, &out.ProjectID, &out.BeadID, &out.AgentID, &out.ActionType, &out.Field,
+			&out.Size, &out.CreatedAt, &content); err != nil {
+			return nil, err
+		}
+		out.Content = string(content)
+		outputs = append(outputs, out)
+	}
+	return outputs, rows.Err()
+}
+
+func (d *Database) queryStrings(query string, args ...interface{}) ([]string, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// UpsertSchedule creates or updates a scheduler entry
+func (d *Database) UpsertSchedule(s *models.Schedule) error {
+	if s == nil {
+		return fmt.Errorf("schedule cannot be nil")
+	}
+	params, _ := json.Marshal(s.Params)
+	var lastRun interface{}
+	if s.LastRunAt != nil {
+		lastRun = *s.LastRunAt
+	}
+	_, err := d.db.Exec(`
+		INSERT INTO schedules (id, name, kind, project_id, interval_seconds, params_json, enabled,
+			next_run_at, last_run_at, last_result, last_error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			kind = excluded.kind,
+			project_id = excluded.project_id,
+			interval_seconds = excluded.interval_seconds,
+			params_json = excluded.params_json,
+			enabled = excluded.enabled,
+			next_run_at = excluded.next_run_at,
+			last_run_at = excluded.last_run_at,
+			last_result = excluded.last_result,
+			last_error = excluded.last_error,
+			updated_at = excluded.updated_at`,
+		s.ID, s.Name, s.Kind, s.ProjectID, s.IntervalSeconds, string(params), s.Enabled,
+		s.NextRunAt, lastRun, s.LastResult, s.LastError, s.CreatedAt, s.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert schedule: %w", err)
+	}
+	return nil
+}
+
+// ListSchedules returns every scheduler entry
+func (d *Database) ListSchedules() ([]*models.Schedule, error) {
+	rows, err := d.db.Query(`
+		SELECT id, name, kind, project_id, interval_seconds, params_json, enabled,
+			next_run_at, last_run_at, last_result, last_error, created_at, updated_at
+		FROM schedules
+		ORDER BY next_run_at ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*models.Schedule
+	for rows.Next() {
+		s := &models.Schedule{}
+		var params, lastResult, lastError sql.NullString
+		var lastRun sql.NullTime
+		if err := rows.Scan(&s.ID, &s.Name, &s.Kind, &s.ProjectID, &s.IntervalSeconds, &params, &s.Enabled,
+			&s.NextRunAt, &lastRun, &lastResult, &lastError, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return schedules, err
+		}
+		if params.Valid {
+			_ = json.Unmarshal([]byte(params.String), &s.Params)
+		}
+		if lastRun.Valid {
+			t := lastRun.Time
+			s.LastRunAt = &t
+		}
+		s.LastResult = lastResult.String
+		s.LastError = lastError.String
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+// DeleteSchedule removes a scheduler entry
+func (d *Database) DeleteSchedule(id string) error {
+	if _, err := d.db.Exec(`DELETE FROM schedules WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	return nil
+}
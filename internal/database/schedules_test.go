@@ -0,0 +1,47 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestSchedules_UpsertListDelete(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	s := &models.Schedule{
+		ID: "sched-1", Name: "Weekly dependency audit", Kind: "dependency_audit", ProjectID: "proj-1",
+		IntervalSeconds: 7 * 24 * 3600, Params: map[string]string{"bead_mode": "batch"}, Enabled: true,
+		NextRunAt: now.Add(time.Hour), CreatedAt: now, UpdatedAt: now,
+	}
+	if err := db.UpsertSchedule(s); err != nil {
+		t.Fatalf("UpsertSchedule: %v", err)
+	}
+	s.LastRunAt = &now
+	s.LastError = "go: network unreachable"
+	if err := db.UpsertSchedule(s); err != nil {
+		t.Fatalf("UpsertSchedule (update): %v", err)
+	}
+
+	list, err := db.ListSchedules()
+	if err != nil {
+		t.Fatalf("ListSchedules: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("expected 1 schedule, got %d", len(list))
+	}
+	got := list[0]
+	if got.Kind != "dependency_audit" || got.Params["bead_mode"] != "batch" || !got.Enabled || got.LastRunAt == nil ||
+		got.LastError != "go: network unreachable" || got.Interval() != 7*24*time.Hour {
+		t.Errorf("unexpected schedule: %+v", got)
+	}
+
+	if err := db.DeleteSchedule("sched-1"); err != nil {
+		t.Fatalf("DeleteSchedule: %v", err)
+	}
+	if list, _ := db.ListSchedules(); len(list) != 0 {
+		t.Errorf("expected no schedules after delete, got %d", len(list))
+	}
+}
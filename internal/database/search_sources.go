@@ -0,0 +1,74 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// The *Since queries page through rows in (time, id) order so callers such
+// as the search indexer can resume after the last row they saw, even when
+// many rows share a timestamp.
+
+// ListConversationsUpdatedSince returns conversations updated after the
+// (after, afterID) position, oldest first
+func (d *Database) ListConversationsUpdatedSince(after time.Time, afterID string, limit int) ([]*models.ConversationContext, error) {
+	rows, err := d.db.Query(`
+		SELECT session_id, bead_id, project_id, messages,
+			   created_at, updated_at, expires_at, token_count, metadata
+		FROM conversation_contexts
+		WHERE updated_at > ? OR (updated_at = ? AND session_id > ?)
+		ORDER BY updated_at, session_id
+		LIMIT ?`, after, after, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation contexts: %w", err)
+	}
+	defer rows.Close()
+
+	var contexts []*models.ConversationContext
+	for rows.Next() {
+		ctx := &models.ConversationContext{}
+		var messagesJSON, metadataJSON []byte
+		if err := rows.Scan(&ctx.SessionID, &ctx.BeadID, &ctx.ProjectID, &messagesJSON,
+			&ctx.CreatedAt, &ctx.UpdatedAt, &ctx.ExpiresAt, &ctx.TokenCount, &metadataJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation context: %w", err)
+		}
+		if err := ctx.SetMessagesFromJSON(messagesJSON); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal messages: %w", err)
+		}
+		if err := ctx.SetMetadataFromJSON(metadataJSON); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		contexts = append(contexts, ctx)
+	}
+	return contexts, rows.Err()
+}
+
+// ListActionOutputsSince returns action outputs, with content, created after
+// the (after, afterID) position, oldest first
+func (d *Database) ListActionOutputsSince(after time.Time, afterID string, limit int) ([]*models.ActionOutput, error) {
+	rows, err := d.db.Query(`
+		SELECT `+actionOutputColumns+`, content FROM action_outputs
+		WHERE created_at > ? OR (created_at = ? AND id > ?)
+		ORDER BY created_at, id
+		LIMIT ?`, after, after, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list action outputs: %w", err)
+	}
+	return scanActionOutputsWithContent(rows)
+}
+
+// ListCommandLogsSince returns command logs created after the
+// (after, afterID) position, oldest first
+func (d *Database) ListCommandLogsSince(after time.Time, afterID string, limit int) ([]*models.CommandLog, error) {
+	rows, err := d.db.Query(`
+		SELECT `+commandLogColumns+` FROM command_logs
+		WHERE created_at > ? OR (created_at = ? AND id > ?)
+		ORDER BY created_at, id
+		LIMIT ?`, after, after, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list command logs: %w", err)
+	}
+	return scanCommandLogs(rows)
+}
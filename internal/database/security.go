@@ -0,0 +1,98 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+const securityScanColumns = `id, project_id, path, tools_json, findings_json, summary_json,
+	errors_json, skipped_json, bead_ids_json, started_at, completed_at`
+
+// SaveSecurityScan stores a security scan result
+func (d *Database) SaveSecurityScan(scan *models.SecurityScan) error {
+	if scan == nil {
+		return fmt.Errorf("scan cannot be nil")
+	}
+	tools, _ := json.Marshal(scan.Tools)
+	findings, _ := json.Marshal(scan.Findings)
+	summary, _ := json.Marshal(scan.Summary)
+	errs, _ := json.Marshal(scan.Errors)
+	skipped, _ := json.Marshal(scan.Skipped)
+	beadIDs, _ := json.Marshal(scan.BeadIDs)
+
+	_, err := d.db.Exec(`
+		INSERT INTO security_scans (`+securityScanColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		scan.ID, scan.ProjectID, scan.Path, string(tools), string(findings), string(summary),
+		string(errs), string(skipped), string(beadIDs), scan.StartedAt, scan.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save security scan: %w", err)
+	}
+	return nil
+}
+
+// ListSecurityScans returns a project's security scans, newest first
+func (d *Database) ListSecurityScans(projectID string, limit int) ([]*models.SecurityScan, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := d.db.Query(`
+		SELECT `+securityScanColumns+`
+		FROM security_scans
+		WHERE project_id = ?
+		ORDER BY started_at DESC
+		LIMIT ?`,
+		projectID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list security scans: %w", err)
+	}
+	defer rows.Close()
+
+	scans := []*models.SecurityScan{}
+	for rows.Next() {
+		scan, err := scanSecurityScan(rows)
+		if err != nil {
+			return scans, err
+		}
+		scans = append(scans, scan)
+	}
+	return scans, rows.Err()
+}
+
+// GetSecurityScan returns a single security scan
+func (d *Database) GetSecurityScan(id string) (*models.SecurityScan, error) {
+	row := d.db.QueryRow(`SELECT `+securityScanColumns+` FROM security_scans WHERE id = ?`, id)
+	scan, err := scanSecurityScan(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("scan not found: %s", id)
+	}
+	return scan, err
+}
+
+func scanSecurityScan(row interface{ Scan(...interface{}) error }) (*models.SecurityScan, error) {
+	scan := &models.SecurityScan{}
+	var tools, findings, summary string
+	var errs, skipped, beadIDs sql.NullString
+	if err := row.Scan(&scan.ID, &scan.ProjectID, &scan.Path, &tools, &findings, &summary,
+		&errs, &skipped, &beadIDs, &scan.StartedAt, &scan.CompletedAt); err != nil {
+		return nil, err
+	}
+	_ = json.Unmarshal([]byte(tools), &scan.Tools)
+	_ = json.Unmarshal([]byte(findings), &scan.Findings)
+	_ = json.Unmarshal([]byte(summary), &scan.Summary)
+	if errs.Valid {
+		_ = json.Unmarshal([]byte(errs.String), &scan.Errors)
+	}
+	if skipped.Valid {
+		_ = json.Unmarshal([]byte(skipped.String), &scan.Skipped)
+	}
+	if beadIDs.Valid {
+		_ = json.Unmarshal([]byte(beadIDs.String), &scan.BeadIDs)
+	}
+	return scan, nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestSecurityScans_SaveListGet(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	older := &models.SecurityScan{
+		ID: "scan-1", ProjectID: "proj-1", Tools: []string{"gosec"},
+		Findings:  []models.SecurityFinding{},
+		Summary:   map[string]int{},
+		StartedAt: now.Add(-time.Hour), CompletedAt: now.Add(-time.Hour),
+	}
+	newer := &models.SecurityScan{
+		ID: "scan-2", ProjectID: "proj-1", Path: "services/api", Tools: []string{"gosec", "trivy"},
+		Findings: []models.SecurityFinding{
+			{Tool: "gosec", RuleID: "G101", Severity: models.SeverityHigh, File: "services/api/main.go", Line: 7, Message: "creds", New: true},
+		},
+		Summary:   map[string]int{models.SeverityHigh: 1},
+		Errors:    map[string]string{"semgrep": "boom"},
+		BeadIDs:   []string{"bd-9"},
+		StartedAt: now, CompletedAt: now,
+	}
+	other := &models.SecurityScan{ID: "scan-3", ProjectID: "proj-2", StartedAt: now, CompletedAt: now}
+	for _, scan := range []*models.SecurityScan{older, newer, other} {
+		if err := db.SaveSecurityScan(scan); err != nil {
+			t.Fatalf("SaveSecurityScan(%s): %v", scan.ID, err)
+		}
+	}
+
+	scans, err := db.ListSecurityScans("proj-1", 0)
+	if err != nil {
+		t.Fatalf("ListSecurityScans: %v", err)
+	}
+	if len(scans) != 2 || scans[0].ID != "scan-2" || scans[1].ID != "scan-1" {
+		t.Fatalf("expected scan-2, scan-1; got %+v", scans)
+	}
+
+	got, err := db.GetSecurityScan("scan-2")
+	if err != nil {
+		t.Fatalf("GetSecurityScan: %v", err)
+	}
+	if got.Path != "services/api" || len(got.Tools) != 2 || got.Summary[models.SeverityHigh] != 1 {
+		t.Errorf("unexpected scan: %+v", got)
+	}
+	if len(got.Findings) != 1 || got.Findings[0].RuleID != "G101" || got.Findings[0].Line != 7 || !got.Findings[0].New {
+		t.Errorf("unexpected findings: %+v", got.Findings)
+	}
+	if got.Errors["semgrep"] != "boom" || len(got.BeadIDs) != 1 {
+		t.Errorf("errors/bead ids not round-tripped: %+v", got)
+	}
+
+	if _, err := db.GetSecurityScan("missing"); err == nil {
+		t.Error("expected error for missing scan")
+	}
+}
@@ -145,6 +145,16 @@ func (d *Database) ListWorkflows(workflowType, projectID string) ([]*workflow.Wo
 			wf.ProjectID = projID.String
 		}
 
+		workflows = append(workflows, wf)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Release the connection before loading nodes and edges; with a single
+	// SQLite connection the nested queries would otherwise block forever
+	rows.Close()
+
+	for _, wf := range workflows {
 		// Load nodes for this workflow
 		nodes, err := d.ListWorkflowNodes(wf.ID)
 		if err == nil {
@@ -156,8 +166,6 @@ func (d *Database) ListWorkflows(workflowType, projectID string) ([]*workflow.Wo
 		if err == nil {
 			wf.Edges = edges
 		}
-
-		workflows = append(workflows, wf)
 	}
 
 	return workflows, nil
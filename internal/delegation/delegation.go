@@ -0,0 +1,275 @@
+// Package delegation turns delegate_task actions into child beads: it assigns
+// the child to the target agent or role, optionally blocks the parent until
+// the child closes, and feeds the child's results back into the parent.
+package delegation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var delegationLog = logging.Module("delegation")
+
+// Bead context keys written on delegated children and their parents
+const (
+	ContextParent       = "delegated_from"
+	ContextStrategy     = "delegation_strategy"
+	ContextRole         = "delegate_to_role"
+	ContextDelegator    = "delegated_by"
+	ContextReportedAt   = "delegation_reported_at"
+	ContextResultPrefix = "delegation_result_" // + child bead ID, on the parent
+)
+
+// maxSummaryChars bounds the result text copied into the parent
+const maxSummaryChars = 2000
+
+// resultKeys are the child context fields summarized for the parent, in order
+var resultKeys = []string{"close_reason", "result", "summary"}
+
+// BeadStore reads and updates beads
+type BeadStore interface {
+	GetBead(id string) (*models.Bead, error)
+	UpdateBead(id string, updates map[string]interface{}) error
+}
+
+// AgentFinder resolves a role to an agent ID
+type AgentFinder interface {
+	FindAgentByRole(ctx context.Context, role string) (string, error)
+}
+
+// ConversationStore appends delegation results to the parent's agent
+// conversation
+type ConversationStore interface {
+	GetConversationContextByBeadID(beadID string) (*models.ConversationContext, error)
+	UpdateConversationContext(ctx *models.ConversationContext) error
+}
+
+// Notifier messages the delegating agent when a child closes
+type Notifier interface {
+	SendMessage(ctx context.Context, fromAgentID, toAgentID, messageType, subject, body string, payload map[string]interface{}) (string, error)
+}
+
+// Manager links delegated child beads to their parents
+type Manager struct {
+	Beads         BeadStore
+	Agents        AgentFinder
+	Conversations ConversationStore
+	Notifier      Notifier
+
+	now func() time.Time
+}
+
+// NewManager creates a delegation manager. agents and conversations may be nil.
+func NewManager(beads BeadStore, agents AgentFinder, conversations ConversationStore) *Manager {
+	return &Manager{
+		Beads:         beads,
+		Agents:        agents,
+		Conversations: conversations,
+		now:           time.Now,
+	}
+}
+
+// Delegate assigns the child bead and links it to its parent. With the block
+// strategy the parent is blocked by the child until it closes.
+func (m *Manager) Delegate(ctx context.Context, d actions.Delegation) (string, error) {
+	child, err := m.Beads.GetBead(d.ChildBeadID)
+	if err != nil {
+		return "", fmt.Errorf("child bead not found: %w", err)
+	}
+	strategy := d.Strategy
+	if strategy == "" {
+		strategy = actions.DelegationContinue
+	}
+
+	var parent *models.Bead
+	if d.ParentBeadID != "" {
+		if parent, err = m.Beads.GetBead(d.ParentBeadID); err != nil {
+			return "", fmt.Errorf("parent bead not found: %w", err)
+		}
+	}
+
+	assignee := d.AgentID
+	if assignee == "" && d.Role != "" && m.Agents != nil {
+		if assignee, err = m.Agents.FindAgentByRole(ctx, d.Role); err != nil {
+			// Leave the default assignment; the dispatcher still routes the bead
+			delegationLog.Warn("No agent for role, leaving bead with its assignee", "role", d.Role, "bead_id", child.ID, "assignee", child.AssignedTo, "error", err)
+			assignee = ""
+		}
+	}
+
+	childUpdates := map[string]interface{}{
+		"context": map[string]string{
+			ContextParent:    d.ParentBeadID,
+			ContextStrategy:  strategy,
+			ContextRole:      d.Role,
+			ContextDelegator: d.DelegatorID,
+		},
+	}
+	if assignee != "" {
+		childUpdates["assigned_to"] = assignee
+	}
+	if parent != nil {
+		childUpdates["parent"] = parent.ID
+		if strategy == actions.DelegationBlock {
+			childUpdates["blocks"] = appendUnique(child.Blocks, parent.ID)
+		}
+	}
+	if err := m.Beads.UpdateBead(child.ID, childUpdates); err != nil {
+		return "", fmt.Errorf("failed to update child bead: %w", err)
+	}
+
+	if parent == nil {
+		return assignee, nil
+	}
+	parentUpdates := map[string]interface{}{
+		"children": appendUnique(parent.Children, child.ID),
+	}
+	if strategy == actions.DelegationBlock {
+		parentUpdates["blocked_by"] = appendUnique(parent.BlockedBy, child.ID)
+		if parent.Status != models.BeadStatusClosed {
+			parentUpdates["status"] = models.BeadStatusBlocked
+		}
+	}
+	if err := m.Beads.UpdateBead(parent.ID, parentUpdates); err != nil {
+		return "", fmt.Errorf("failed to update parent bead: %w", err)
+	}
+	return assignee, nil
+}
+
+// BeadClosed propagates a closed delegated bead's results into its parent's
+// context and conversation, unblocks the parent once none of its blockers
+// are open, and notifies the delegating agent. It is a no-op for beads that
+// were not delegated or were already reported.
+func (m *Manager) BeadClosed(ctx context.Context, beadID string) error {
+	child, err := m.Beads.GetBead(beadID)
+	if err != nil {
+		return err
+	}
+	parentID := child.Context[ContextParent]
+	if parentID == "" || child.Context[ContextReportedAt] != "" || child.Status != models.BeadStatusClosed {
+		return nil
+	}
+	parent, err := m.Beads.GetBead(parentID)
+	if err != nil {
+		return fmt.Errorf("parent bead not found: %w", err)
+	}
+
+	summary := Summarize(child)
+	now := m.now().UTC().Format(time.RFC3339)
+	ctxUpdates := map[string]string{ContextResultPrefix + child.ID: summary}
+	updates := map[string]interface{}{"context": ctxUpdates}
+
+	blockedBy := remove(parent.BlockedBy, child.ID)
+	if len(blockedBy) != len(parent.BlockedBy) {
+		updates["blocked_by"] = blockedBy
+	}
+	if parent.Status != models.BeadStatusClosed {
+		// Let the parent's agent pick the results up on its next turn
+		ctxUpdates["redispatch_requested"] = "true"
+		ctxUpdates["redispatch_requested_at"] = now
+		if parent.Status == models.BeadStatusBlocked && !m.hasOpenBlocker(blockedBy) {
+			updates["status"] = models.BeadStatusOpen
+		}
+	}
+	if err := m.Beads.UpdateBead(parent.ID, updates); err != nil {
+		return fmt.Errorf("failed to update parent bead: %w", err)
+	}
+	if err := m.Beads.UpdateBead(child.ID, map[string]interface{}{
+		"context": map[string]string{ContextReportedAt: now},
+	}); err != nil {
+		delegationLog.Error("Failed to mark bead reported", "bead_id", child.ID, "error", err)
+	}
+
+	m.appendConversation(parent.ID, summary)
+	m.notify(ctx, child, parent, summary)
+	return nil
+}
+
+// Summarize describes a closed delegated bead for its parent
+func Summarize(child *models.Bead) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Delegated bead %s (%q", child.ID, child.Title))
+	if role := child.Context[ContextRole]; role != "" {
+		sb.WriteString(", role " + role)
+	}
+	if child.AssignedTo != "" {
+		sb.WriteString(", agent " + child.AssignedTo)
+	}
+	sb.WriteString(fmt.Sprintf(") is %s.", child.Status))
+	for _, key := range resultKeys {
+		if v := strings.TrimSpace(child.Context[key]); v != "" {
+			sb.WriteString(fmt.Sprintf("\n%s: %s", key, v))
+		}
+	}
+
+	summary := sb.String()
+	if len(summary) > maxSummaryChars {
+		summary = summary[:maxSummaryChars] + "... (truncated)"
+	}
+	return summary
+}
+
+func (m *Manager) hasOpenBlocker(ids []string) bool {
+	for _, id := range ids {
+		// Blockers that can no longer be loaded are treated as resolved
+		if b, err := m.Beads.GetBead(id); err == nil && b.Status != models.BeadStatusClosed {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) appendConversation(beadID, message string) {
+	if m.Conversations == nil {
+		return
+	}
+	conv, err := m.Conversations.GetConversationContextByBeadID(beadID)
+	if err != nil || conv == nil {
+		return
+	}
+	conv.AddMessage("user", message, len(message)/4)
+	if err := m.Conversations.UpdateConversationContext(conv); err != nil {
+		delegationLog.Error("Failed to append result to conversation", "session_id", conv.SessionID, "error", err)
+	}
+}
+
+func (m *Manager) notify(ctx context.Context, child, parent *models.Bead, summary string) {
+	to := child.Context[ContextDelegator]
+	if m.Notifier == nil || to == "" {
+		return
+	}
+	from := child.AssignedTo
+	if from == "" {
+		from = "loom"
+	}
+	subject := fmt.Sprintf("Delegated task %s closed", child.ID)
+	payload := map[string]interface{}{"bead_id": parent.ID, "child_bead_id": child.ID}
+	if _, err := m.Notifier.SendMessage(ctx, from, to, "notification", subject, summary, payload); err != nil {
+		delegationLog.Warn("Failed to notify about bead", "to", to, "bead_id", child.ID, "error", err)
+	}
+}
+
+func appendUnique(ids []string, id string) []string {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(append([]string(nil), ids...), id)
+}
+
+func remove(ids []string, id string) []string {
+	out := make([]string, 0, len(ids))
+	for _, existing := range ids {
+		if existing != id {
+			out = append(out, existing)
+		}
+	}
+	return out
+}
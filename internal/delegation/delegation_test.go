@@ -0,0 +1,186 @@
+package delegation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// fakeBeads applies updates the way beads.Manager does
+type fakeBeads struct {
+	beads map[string]*models.Bead
+}
+
+func newFakeBeads(beads ...*models.Bead) *fakeBeads {
+	f := &fakeBeads{beads: map[string]*models.Bead{}}
+	for _, b := range beads {
+		f.beads[b.ID] = b
+	}
+	return f
+}
+
+func (f *fakeBeads) GetBead(id string) (*models.Bead, error) {
+	b, ok := f.beads[id]
+	if !ok {
+		return nil, fmt.Errorf("bead not found: %s", id)
+	}
+	return b, nil
+}
+
+func (f *fakeBeads) UpdateBead(id string, updates map[string]interface{}) error {
+	b, ok := f.beads[id]
+	if !ok {
+		return fmt.Errorf("bead not found: %s", id)
+	}
+	if v, ok := updates["status"].(models.BeadStatus); ok {
+		b.Status = v
+	}
+	if v, ok := updates["assigned_to"].(string); ok {
+		b.AssignedTo = v
+	}
+	if v, ok := updates["parent"].(string); ok {
+		b.Parent = v
+	}
+	if v, ok := updates["blocked_by"].([]string); ok {
+		b.BlockedBy = v
+	}
+	if v, ok := updates["blocks"].([]string); ok {
+		b.Blocks = v
+	}
+	if v, ok := updates["children"].([]string); ok {
+		b.Children = v
+	}
+	if v, ok := updates["context"].(map[string]string); ok {
+		if b.Context == nil {
+			b.Context = map[string]string{}
+		}
+		for k, val := range v {
+			b.Context[k] = val
+		}
+	}
+	return nil
+}
+
+type fakeAgents map[string]string
+
+func (f fakeAgents) FindAgentByRole(ctx context.Context, role string) (string, error) {
+	if id, ok := f[role]; ok {
+		return id, nil
+	}
+	return "", errors.New("no agent")
+}
+
+type fakeNotifier struct {
+	from, to, body string
+	payload        map[string]interface{}
+}
+
+func (f *fakeNotifier) SendMessage(ctx context.Context, fromAgentID, toAgentID, messageType, subject, body string, payload map[string]interface{}) (string, error) {
+	f.from, f.to, f.body, f.payload = fromAgentID, toAgentID, body, payload
+	return "msg-1", nil
+}
+
+type fakeConversations struct {
+	conv *models.ConversationContext
+}
+
+func (f *fakeConversations) GetConversationContextByBeadID(beadID string) (*models.ConversationContext, error) {
+	return f.conv, nil
+}
+
+func (f *fakeConversations) UpdateConversationContext(ctx *models.ConversationContext) error {
+	return nil
+}
+
+func TestDelegateBlockAndPropagate(t *testing.T) {
+	parent := &models.Bead{ID: "bd-parent", Status: models.BeadStatusInProgress, AssignedTo: "eng"}
+	child := &models.Bead{ID: "bd-child", Title: "Run QA", Status: models.BeadStatusOpen, AssignedTo: "triage"}
+	beads := newFakeBeads(parent, child)
+	conv := &fakeConversations{conv: &models.ConversationContext{SessionID: "s1", BeadID: "bd-parent"}}
+	notifier := &fakeNotifier{}
+	m := NewManager(beads, fakeAgents{"qa-engineer": "qa"}, conv)
+	m.Notifier = notifier
+	m.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	assigned, err := m.Delegate(context.Background(), actions.Delegation{
+		ParentBeadID: parent.ID, ChildBeadID: child.ID, Role: "qa-engineer",
+		Strategy: actions.DelegationBlock, DelegatorID: "eng",
+	})
+	if err != nil {
+		t.Fatalf("Delegate: %v", err)
+	}
+	if assigned != "qa" || child.AssignedTo != "qa" || child.Parent != parent.ID || child.Context[ContextDelegator] != "eng" {
+		t.Errorf("child not assigned and linked: %+v", child)
+	}
+	if parent.Status != models.BeadStatusBlocked || len(parent.BlockedBy) != 1 || parent.Children[0] != child.ID {
+		t.Errorf("parent not blocked by child: %+v", parent)
+	}
+
+	// Closing an unrelated open bead must not touch the parent
+	if err := m.BeadClosed(context.Background(), child.ID); err != nil || parent.Status != models.BeadStatusBlocked {
+		t.Fatalf("open child reported: %v %+v", err, parent)
+	}
+
+	child.Status = models.BeadStatusClosed
+	child.Context["close_reason"] = "All 42 tests pass"
+	if err := m.BeadClosed(context.Background(), child.ID); err != nil {
+		t.Fatalf("BeadClosed: %v", err)
+	}
+
+	result := parent.Context[ContextResultPrefix+child.ID]
+	if !strings.Contains(result, "All 42 tests pass") || !strings.Contains(result, "role qa-engineer") {
+		t.Errorf("unexpected result summary: %q", result)
+	}
+	if parent.Status != models.BeadStatusOpen || len(parent.BlockedBy) != 0 || parent.Context["redispatch_requested"] != "true" {
+		t.Errorf("parent not unblocked: %+v", parent)
+	}
+	if len(conv.conv.Messages) != 1 || notifier.to != "eng" || notifier.from != "qa" || notifier.payload["bead_id"] != parent.ID {
+		t.Errorf("results not delivered: conv=%+v notify=%+v", conv.conv.Messages, notifier)
+	}
+
+	// Reporting is idempotent
+	notifier.to = ""
+	if err := m.BeadClosed(context.Background(), child.ID); err != nil || notifier.to != "" {
+		t.Errorf("child reported twice: %v", err)
+	}
+}
+
+func TestDelegateContinueKeepsParentRunning(t *testing.T) {
+	parent := &models.Bead{ID: "p", Status: models.BeadStatusInProgress, BlockedBy: []string{"other"}}
+	other := &models.Bead{ID: "other", Status: models.BeadStatusOpen}
+	child := &models.Bead{ID: "c", Status: models.BeadStatusOpen, AssignedTo: "triage"}
+	beads := newFakeBeads(parent, other, child)
+	m := NewManager(beads, fakeAgents{}, nil)
+
+	assigned, err := m.Delegate(context.Background(), actions.Delegation{ParentBeadID: "p", ChildBeadID: "c", Role: "designer"})
+	if err != nil {
+		t.Fatalf("Delegate: %v", err)
+	}
+	if assigned != "" || child.AssignedTo != "triage" || child.Context[ContextStrategy] != actions.DelegationContinue {
+		t.Errorf("unexpected child: %+v", child)
+	}
+	if parent.Status != models.BeadStatusInProgress || len(parent.BlockedBy) != 1 {
+		t.Errorf("continue strategy blocked the parent: %+v", parent)
+	}
+
+	child.Status = models.BeadStatusClosed
+	if err := m.BeadClosed(context.Background(), "c"); err != nil {
+		t.Fatalf("BeadClosed: %v", err)
+	}
+	if parent.Context[ContextResultPrefix+"c"] == "" || len(parent.BlockedBy) != 1 {
+		t.Errorf("unexpected parent after close: %+v", parent)
+	}
+}
+
+func TestSummarizeTruncates(t *testing.T) {
+	child := &models.Bead{ID: "c", Status: models.BeadStatusClosed, Context: map[string]string{"result": strings.Repeat("x", 3*maxSummaryChars)}}
+	if s := Summarize(child); len(s) > maxSummaryChars+20 || !strings.HasSuffix(s, "(truncated)") {
+		t.Errorf("summary not truncated: %d chars", len(s))
+	}
+}
@@ -0,0 +1,158 @@
+package dependencies
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// FileBeads files update beads for a report's direct dependencies: one per
+// dependency, or one per ecosystem in batch mode. Dependencies (or, in batch
+// mode, ecosystems) that already have an open update bead are skipped so
+// recurring audits don't pile up duplicates. It returns the new bead IDs.
+func (m *Manager) FileBeads(report *Report, mode string) ([]string, error) {
+	if m.Beads == nil {
+		return nil, fmt.Errorf("bead creator not configured")
+	}
+	if mode == "" {
+		mode = BeadModePerDependency
+	}
+	if mode != BeadModePerDependency && mode != BeadModeBatch {
+		return nil, fmt.Errorf("unknown bead mode %q (use %s or %s)", mode, BeadModePerDependency, BeadModeBatch)
+	}
+	open := m.openUpdateTitles(report.ProjectID)
+
+	var direct []Dependency
+	for _, d := range report.Dependencies {
+		if d.Direct {
+			direct = append(direct, d)
+		}
+	}
+
+	var beadIDs []string
+	file := func(title, description string) error {
+		bead, err := m.Beads.CreateBead(title, description, models.BeadPriorityP2, "task", report.ProjectID)
+		if err != nil {
+			return err
+		}
+		beadIDs = append(beadIDs, bead.ID)
+		return nil
+	}
+
+	if mode == BeadModePerDependency {
+		for _, d := range direct {
+			if hasPrefix(open, dependencyTitlePrefix(d)) {
+				continue
+			}
+			title := fmt.Sprintf("%s%s to %s", dependencyTitlePrefix(d), d.Current, d.Latest)
+			if err := file(title, dependencyDescription(d)); err != nil {
+				return beadIDs, err
+			}
+		}
+		return beadIDs, nil
+	}
+
+	byEcosystem := map[string][]Dependency{}
+	var order []string
+	for _, d := range direct {
+		if _, ok := byEcosystem[d.Ecosystem]; !ok {
+			order = append(order, d.Ecosystem)
+		}
+		byEcosystem[d.Ecosystem] = append(byEcosystem[d.Ecosystem], d)
+	}
+	for _, eco := range order {
+		prefix := fmt.Sprintf("%s (%s): ", beadTitlePrefix, eco)
+		if hasPrefix(open, prefix) {
+			continue
+		}
+		deps := byEcosystem[eco]
+		title := fmt.Sprintf("%s%d outdated dependencies", prefix, len(deps))
+		if report.Path != "" {
+			title += " in " + report.Path
+		}
+		if err := file(title, batchDescription(eco, deps)); err != nil {
+			return beadIDs, err
+		}
+	}
+	return beadIDs, nil
+}
+
+// openUpdateTitles returns the titles of the project's unclosed dependency
+// update beads
+func (m *Manager) openUpdateTitles(projectID string) []string {
+	if m.Existing == nil {
+		return nil
+	}
+	beads, err := m.Existing.ListBeads(map[string]interface{}{"project_id": projectID})
+	if err != nil {
+		return nil
+	}
+	var titles []string
+	for _, b := range beads {
+		if b.Status != models.BeadStatusClosed && strings.HasPrefix(b.Title, beadTitlePrefix) {
+			titles = append(titles, b.Title)
+		}
+	}
+	return titles
+}
+
+func hasPrefix(titles []string, prefix string) bool {
+	for _, t := range titles {
+		if strings.HasPrefix(t, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func dependencyTitlePrefix(d Dependency) string {
+	return fmt.Sprintf("%s (%s): %s ", beadTitlePrefix, d.Ecosystem, d.Name)
+}
+
+func dependencyDescription(d Dependency) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Update %s %s from %s to %s in %s.\n\n", d.Ecosystem, d.Name, d.Current, d.Latest, d.Manifest))
+	sb.WriteString(fmt.Sprintf("Upgrade: `%s`\n", d.UpgradeCommand))
+	if d.ChangelogURL != "" {
+		sb.WriteString("Changelog: " + d.ChangelogURL + "\n")
+	}
+	if d.CompareURL != "" {
+		sb.WriteString("Compare: " + d.CompareURL + "\n")
+	}
+	if d.Breaking {
+		sb.WriteString("\nThis crosses a major version: review the changelog for breaking changes and update call sites.\n")
+	}
+	sb.WriteString("\nRun the build and tests after upgrading.\n\n")
+	writeJSONBlock(&sb, d)
+	return sb.String()
+}
+
+func batchDescription(eco string, deps []Dependency) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Update %d outdated %s dependencies.\n\n", len(deps), eco))
+	sb.WriteString("| Dependency | Current | Latest | Breaking | Changelog |\n|---|---|---|---|---|\n")
+	for _, d := range deps {
+		breaking := ""
+		if d.Breaking {
+			breaking = "yes"
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", d.Name, d.Current, d.Latest, breaking, d.ChangelogURL))
+	}
+	sb.WriteString("\nUpgrade non-breaking dependencies first and run the build and tests between steps.\n\n")
+	writeJSONBlock(&sb, deps)
+	return sb.String()
+}
+
+// writeJSONBlock appends v as a fenced JSON block so agents get the upgrade
+// data in structured form
+func writeJSONBlock(sb *strings.Builder, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return
+	}
+	sb.WriteString("```json\n")
+	sb.Write(data)
+	sb.WriteString("\n```\n")
+}
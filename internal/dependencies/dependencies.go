@@ -0,0 +1,398 @@
+// Package dependencies lists outdated project dependencies across package
+// ecosystems, enriches them with changelog and upgrade data for agents, and
+// files update beads, either on demand or from a scheduled weekly audit.
+package dependencies
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/scheduler"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Supported ecosystems
+const (
+	Go  = "go"
+	Npm = "npm"
+	Pip = "pip"
+)
+
+// Bead filing modes
+const (
+	BeadModePerDependency = "per_dependency"
+	BeadModeBatch         = "batch"
+)
+
+// ScheduleKind is the scheduler kind for recurring dependency audits
+const ScheduleKind = "dependency_audit"
+
+const (
+	defaultTimeout = 5 * time.Minute
+	// maxErrorOutput caps tool stderr kept in a report's errors
+	maxErrorOutput = 2048
+	// beadTitlePrefix starts every dependency update bead title so open
+	// ones can be recognized on later audits
+	beadTitlePrefix = "Dependency update"
+)
+
+// Dependency is an outdated dependency with the data an agent needs to
+// upgrade it.
+type Dependency struct {
+	Name           string `json:"name"`
+	Ecosystem      string `json:"ecosystem"`
+	Current        string `json:"current"`
+	Wanted         string `json:"wanted,omitempty"` // Newest version allowed by the manifest's range (npm)
+	Latest         string `json:"latest"`
+	Direct         bool   `json:"direct"`        // Declared by the project rather than pulled in transitively
+	Dev            bool   `json:"dev,omitempty"` // Development-only dependency
+	Breaking       bool   `json:"breaking"`      // Crosses a major version (or a 0.x minor version)
+	Manifest       string `json:"manifest"`      // Manifest declaring it, relative to the repository root
+	ChangelogURL   string `json:"changelog_url,omitempty"`
+	CompareURL     string `json:"compare_url,omitempty"`
+	UpgradeCommand string `json:"upgrade_command"` // Run from the manifest's directory
+}
+
+// Report lists the outdated dependencies found in one directory
+type Report struct {
+	ProjectID    string            `json:"project_id"`
+	Path         string            `json:"path,omitempty"` // Relative to the repository root
+	Ecosystems   []string          `json:"ecosystems"`
+	Dependencies []Dependency      `json:"dependencies"`
+	Errors       map[string]string `json:"errors,omitempty"` // Failures by ecosystem
+	CheckedAt    time.Time         `json:"checked_at"`
+}
+
+// Options selects what Outdated checks
+type Options struct {
+	Path       string   // Directory to check, relative to the repository root
+	Ecosystems []string // Empty checks every ecosystem with a manifest in Path
+}
+
+// BeadCreator files update beads
+type BeadCreator interface {
+	CreateBead(title, description string, priority models.BeadPriority, beadType, projectID string) (*models.Bead, error)
+}
+
+// BeadLister finds existing beads so audits don't file duplicates
+type BeadLister interface {
+	ListBeads(filters map[string]interface{}) ([]*models.Bead, error)
+}
+
+// Manager checks project workdirs for outdated dependencies
+type Manager struct {
+	WorkDirs files.WorkDirResolver
+	Beads    BeadCreator
+	Existing BeadLister
+	Timeout  time.Duration
+
+	runner toolrun.Runner
+}
+
+// NewManager creates a dependency manager. beads and existing may be nil.
+func NewManager(resolver files.WorkDirResolver, beads BeadCreator, existing BeadLister) *Manager {
+	return &Manager{
+		WorkDirs: resolver,
+		Beads:    beads,
+		Existing: existing,
+		Timeout:  defaultTimeout,
+	}
+}
+
+// Outdated lists outdated dependencies. A failing ecosystem is recorded in
+// the report's errors; an error is returned only when nothing was checked.
+func (m *Manager) Outdated(ctx context.Context, projectID string, opts Options) (*Report, error) {
+	if m.WorkDirs == nil {
+		return nil, fmt.Errorf("workdir resolver not configured")
+	}
+	workDir := m.WorkDirs.GetProjectWorkDir(projectID)
+	if workDir == "" {
+		return nil, fmt.Errorf("project workdir not found")
+	}
+	relDir := models.CleanSubprojectPath(opts.Path)
+	dir := filepath.Join(filepath.Clean(workDir), filepath.FromSlash(relDir))
+	if rel, err := filepath.Rel(workDir, dir); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("path %q is outside the project", opts.Path)
+	}
+
+	ecosystems := opts.Ecosystems
+	if len(ecosystems) == 0 {
+		ecosystems = detect(dir)
+		if len(ecosystems) == 0 {
+			return nil, fmt.Errorf("no go.mod, package.json, requirements.txt or pyproject.toml in %q", "/"+relDir)
+		}
+	}
+
+	report := &Report{ProjectID: projectID, Path: relDir, Dependencies: []Dependency{}, CheckedAt: time.Now()}
+	for _, eco := range ecosystems {
+		deps, err := m.outdated(ctx, eco, dir)
+		if err != nil {
+			if report.Errors == nil {
+				report.Errors = map[string]string{}
+			}
+			report.Errors[eco] = err.Error()
+			continue
+		}
+		for i := range deps {
+			enrich(&deps[i], dir, relDir)
+		}
+		report.Ecosystems = append(report.Ecosystems, eco)
+		report.Dependencies = append(report.Dependencies, deps...)
+	}
+	if len(report.Ecosystems) == 0 {
+		parts := make([]string, 0, len(report.Errors))
+		for eco, msg := range report.Errors {
+			parts = append(parts, eco+": "+msg)
+		}
+		sort.Strings(parts)
+		return nil, fmt.Errorf("dependency check failed: %s", strings.Join(parts, "; "))
+	}
+
+	sort.SliceStable(report.Dependencies, func(i, j int) bool {
+		a, b := report.Dependencies[i], report.Dependencies[j]
+		if a.Direct != b.Direct {
+			return a.Direct
+		}
+		if a.Ecosystem != b.Ecosystem {
+			return a.Ecosystem < b.Ecosystem
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+func (m *Manager) outdated(ctx context.Context, eco, dir string) ([]Dependency, error) {
+	var (
+		name string
+		args []string
+	)
+	switch eco {
+	case Go:
+		name, args = "go", []string{"list", "-u", "-m", "-json", "all"}
+	case Npm:
+		name, args = "npm", []string{"outdated", "--json"}
+	case Pip:
+		name, args = "pip", []string{"list", "--outdated", "--format=json"}
+	default:
+		return nil, fmt.Errorf("unsupported ecosystem %q (use go, npm or pip)", eco)
+	}
+
+	if _, err := m.runner.Find(name); err != nil {
+		if eco != Pip {
+			return nil, fmt.Errorf("%s is not installed", name)
+		}
+		if _, err := m.runner.Find("pip3"); err != nil {
+			return nil, fmt.Errorf("pip is not installed")
+		}
+		name = "pip3"
+	}
+
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// npm outdated exits 1 whenever something is outdated, so judge the
+	// run by its output rather than its exit status.
+	stdout, stderr, err := m.runner.Exec(ctx, toolrun.Command{Dir: dir, Name: name, Args: args})
+	if err != nil && len(bytes.TrimSpace(stdout)) == 0 {
+		msg := strings.TrimSpace(string(stderr))
+		if len(msg) > maxErrorOutput {
+			msg = msg[:maxErrorOutput] + "..."
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("%s: %s", name, msg)
+	}
+
+	switch eco {
+	case Go:
+		return parseGoList(stdout)
+	case Npm:
+		return parseNpmOutdated(stdout)
+	}
+	return parsePipOutdated(stdout, declaredPythonPackages(readFile(dir, "requirements.txt"), readFile(dir, "pyproject.toml")))
+}
+
+// detect returns the ecosystems with a manifest in dir
+func detect(dir string) []string {
+	var ecosystems []string
+	if fileExists(dir, "go.mod") {
+		ecosystems = append(ecosystems, Go)
+	}
+	if fileExists(dir, "package.json") {
+		ecosystems = append(ecosystems, Npm)
+	}
+	if fileExists(dir, "requirements.txt") || fileExists(dir, "pyproject.toml") {
+		ecosystems = append(ecosystems, Pip)
+	}
+	return ecosystems
+}
+
+// enrich fills in the manifest, breaking-change flag, changelog links and
+// upgrade command.
+func enrich(d *Dependency, dir, relDir string) {
+	d.Breaking = isBreaking(d.Current, d.Latest)
+	manifest := ""
+	switch d.Ecosystem {
+	case Go:
+		manifest = "go.mod"
+		if repo := githubRepo("https://" + d.Name); repo != "" {
+			d.ChangelogURL = repo + "/releases"
+			if !isPseudoVersion(d.Current) && !isPseudoVersion(d.Latest) {
+				d.CompareURL = fmt.Sprintf("%s/compare/%s...%s", repo, d.Current, d.Latest)
+			}
+		} else {
+			d.ChangelogURL = "https://pkg.go.dev/" + d.Name + "?tab=versions"
+		}
+		d.UpgradeCommand = fmt.Sprintf("go get %s@%s && go mod tidy", d.Name, d.Latest)
+	case Npm:
+		manifest = "package.json"
+		if repo := npmRepository(dir, d.Name); repo != "" {
+			d.ChangelogURL = repo + "/releases"
+		} else {
+			d.ChangelogURL = "https://www.npmjs.com/package/" + d.Name + "?activeTab=versions"
+		}
+		d.UpgradeCommand = fmt.Sprintf("npm install %s@%s", d.Name, d.Latest)
+		if d.Dev {
+			d.UpgradeCommand += " --save-dev"
+		}
+	case Pip:
+		manifest = "requirements.txt"
+		if !fileExists(dir, manifest) {
+			manifest = "pyproject.toml"
+		}
+		d.ChangelogURL = "https://pypi.org/project/" + d.Name + "/#history"
+		d.UpgradeCommand = fmt.Sprintf("pip install --upgrade '%s==%s'", d.Name, d.Latest)
+	}
+	d.Manifest = filepath.ToSlash(filepath.Join(relDir, manifest))
+}
+
+// isBreaking reports whether moving from current to latest crosses a major
+// version, treating 0.x minor bumps as major as semver does.
+func isBreaking(current, latest string) bool {
+	cur, lat := versionParts(current), versionParts(latest)
+	if len(cur) == 0 || len(lat) == 0 {
+		return false
+	}
+	if cur[0] != lat[0] {
+		return true
+	}
+	return cur[0] == 0 && len(cur) > 1 && len(lat) > 1 && cur[1] != lat[1]
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, p := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// isPseudoVersion matches Go pseudo-versions such as v0.0.0-20240101000000-abcdef123456
+func isPseudoVersion(v string) bool {
+	return strings.Count(v, "-") >= 2
+}
+
+// githubRepo returns the https://github.com/owner/repo URL for a GitHub
+// repository reference (URL, git remote, "github:owner/repo" or npm's bare
+// "owner/repo" shorthand), or "" if it isn't one.
+func githubRepo(ref string) string {
+	ref = strings.TrimPrefix(strings.TrimSpace(ref), "git+")
+	switch {
+	case strings.HasPrefix(ref, "github:"):
+		ref = "github.com/" + strings.TrimPrefix(ref, "github:")
+	case strings.Contains(ref, "github.com"):
+	case strings.Count(ref, "/") == 1 && !strings.Contains(ref, ":"):
+		ref = "github.com/" + ref
+	default:
+		return ""
+	}
+	ref = strings.TrimLeft(ref[strings.Index(ref, "github.com")+len("github.com"):], ":/")
+	parts := strings.Split(strings.SplitN(ref, "#", 2)[0], "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	return "https://github.com/" + parts[0] + "/" + strings.TrimSuffix(parts[1], ".git")
+}
+
+// npmRepository reads the GitHub repository of an installed npm package
+func npmRepository(dir, name string) string {
+	var pkg struct {
+		Repository json.RawMessage `json:"repository"`
+	}
+	if err := json.Unmarshal([]byte(readFile(dir, filepath.Join("node_modules", name, "package.json"))), &pkg); err != nil {
+		return ""
+	}
+	var url string
+	if json.Unmarshal(pkg.Repository, &url) != nil {
+		var obj struct {
+			URL string `json:"url"`
+		}
+		if json.Unmarshal(pkg.Repository, &obj) != nil {
+			return ""
+		}
+		url = obj.URL
+	}
+	return githubRepo(url)
+}
+
+func fileExists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+func readFile(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// ScheduleHandler runs a scheduled audit: it checks the schedule's project
+// and files update beads. Params: path, ecosystems (comma separated) and
+// bead_mode.
+func (m *Manager) ScheduleHandler() scheduler.Handler {
+	return func(ctx context.Context, s *models.Schedule) (string, error) {
+		if s.ProjectID == "" {
+			return "", fmt.Errorf("dependency audit schedule has no project")
+		}
+		opts := Options{Path: s.Params["path"]}
+		if ecos := strings.TrimSpace(s.Params["ecosystems"]); ecos != "" {
+			for _, eco := range strings.Split(ecos, ",") {
+				opts.Ecosystems = append(opts.Ecosystems, strings.TrimSpace(eco))
+			}
+		}
+		report, err := m.Outdated(ctx, s.ProjectID, opts)
+		if err != nil {
+			return "", err
+		}
+		beadIDs, err := m.FileBeads(report, s.Params["bead_mode"])
+		summary := fmt.Sprintf("%d outdated dependencies, filed %d beads", len(report.Dependencies), len(beadIDs))
+		if len(report.Errors) > 0 {
+			summary += fmt.Sprintf(" (%d ecosystems failed)", len(report.Errors))
+		}
+		return summary, err
+	}
+}
@@ -0,0 +1,196 @@
+package dependencies
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeBeads struct {
+	beads []*models.Bead
+}
+
+func (f *fakeBeads) CreateBead(title, description string, priority models.BeadPriority, beadType, projectID string) (*models.Bead, error) {
+	b := &models.Bead{ID: fmt.Sprintf("bd-%d", len(f.beads)+1), Title: title, Description: description,
+		Priority: priority, ProjectID: projectID, Status: models.BeadStatusOpen}
+	f.beads = append(f.beads, b)
+	return b, nil
+}
+
+func (f *fakeBeads) ListBeads(filters map[string]interface{}) ([]*models.Bead, error) {
+	return f.beads, nil
+}
+
+const goListOutput = `{"Path":"example.com/app","Main":true}
+{"Path":"github.com/spf13/cobra","Version":"v1.7.0","Update":{"Version":"v1.8.1"}}
+{"Path":"golang.org/x/net","Version":"v0.17.0","Indirect":true,"Update":{"Version":"v0.30.0"}}
+{"Path":"github.com/pmezard/go-difflib","Version":"v1.0.0"}
+{"Path":"github.com/acme/lib","Version":"v0.3.1","Update":{"Version":"v0.4.0"}}
+`
+
+const npmOutdatedOutput = `{
+  "lodash": {"current": "4.17.11", "wanted": "4.17.21", "latest": "4.17.21", "type": "dependencies"},
+  "typescript": {"current": "4.9.5", "wanted": "4.9.5", "latest": "5.4.2", "type": "devDependencies"}
+}`
+
+func fakeManager(t *testing.T, files map[string]string, outputs map[string]string) (*Manager, *fakeBeads) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	beads := &fakeBeads{}
+	m := NewManager(toolrun.StaticWorkDir(dir), beads, beads)
+	installed := []string{}
+	for name := range outputs {
+		installed = append(installed, name)
+	}
+	m.runner = (&toolrun.Fake{Installed: installed, Handle: func(c toolrun.Command) ([]byte, []byte, error) {
+		return []byte(outputs[c.Name]), nil, fmt.Errorf("exit status 1")
+	}}).Runner()
+	return m, beads
+}
+
+func TestOutdatedGoModules(t *testing.T) {
+	m, _ := fakeManager(t, map[string]string{"go.mod": "module example.com/app\n"}, map[string]string{"go": goListOutput})
+
+	report, err := m.Outdated(context.Background(), "p", Options{})
+	if err != nil {
+		t.Fatalf("Outdated: %v", err)
+	}
+	if len(report.Dependencies) != 3 {
+		t.Fatalf("expected 3 outdated modules, got %+v", report.Dependencies)
+	}
+	// Direct dependencies sort first
+	acme, cobra, net := report.Dependencies[0], report.Dependencies[1], report.Dependencies[2]
+	if acme.Name != "github.com/acme/lib" || !acme.Breaking {
+		t.Errorf("expected 0.x minor bump to be breaking: %+v", acme)
+	}
+	if cobra.Breaking || cobra.ChangelogURL != "https://github.com/spf13/cobra/releases" ||
+		cobra.CompareURL != "https://github.com/spf13/cobra/compare/v1.7.0...v1.8.1" ||
+		cobra.UpgradeCommand != "go get github.com/spf13/cobra@v1.8.1 && go mod tidy" || cobra.Manifest != "go.mod" {
+		t.Errorf("unexpected cobra entry: %+v", cobra)
+	}
+	if net.Direct || net.ChangelogURL != "https://pkg.go.dev/golang.org/x/net?tab=versions" {
+		t.Errorf("unexpected x/net entry: %+v", net)
+	}
+}
+
+func TestOutdatedNpmAndMissingTools(t *testing.T) {
+	m, _ := fakeManager(t, map[string]string{
+		"web/package.json":                         `{"name":"web"}`,
+		"web/requirements.txt":                     "requests>=2\n",
+		"web/node_modules/lodash/package.json":     `{"repository":{"type":"git","url":"git+https://github.com/lodash/lodash.git"}}`,
+		"web/node_modules/typescript/package.json": `{"repository":"microsoft/TypeScript"}`,
+	}, map[string]string{"npm": npmOutdatedOutput})
+
+	report, err := m.Outdated(context.Background(), "p", Options{Path: "web"})
+	if err != nil {
+		t.Fatalf("Outdated: %v", err)
+	}
+	if report.Errors[Pip] == "" {
+		t.Errorf("expected pip to be reported as missing, got %v", report.Errors)
+	}
+	if len(report.Dependencies) != 2 {
+		t.Fatalf("expected 2 outdated packages, got %+v", report.Dependencies)
+	}
+	lodash, ts := report.Dependencies[0], report.Dependencies[1]
+	if lodash.ChangelogURL != "https://github.com/lodash/lodash/releases" || lodash.Manifest != "web/package.json" || lodash.Breaking {
+		t.Errorf("unexpected lodash entry: %+v", lodash)
+	}
+	if !ts.Dev || !ts.Breaking || ts.ChangelogURL != "https://github.com/microsoft/TypeScript/releases" ||
+		ts.UpgradeCommand != "npm install typescript@5.4.2 --save-dev" {
+		t.Errorf("unexpected typescript entry: %+v", ts)
+	}
+
+	if _, err := m.Outdated(context.Background(), "p", Options{Path: "../outside"}); err == nil {
+		t.Error("expected error for path outside the project")
+	}
+}
+
+func TestParsePipOutdatedMarksDeclaredPackages(t *testing.T) {
+	declared := declaredPythonPackages("Django==4.2\n# comment\n-r base.txt\n", `
+[project]
+dependencies = [
+  "requests>=2.31",
+  "typing_extensions; python_version < '3.11'",
+]
+`)
+	out := `[{"name":"django","version":"4.2","latest_version":"5.0"},{"name":"requests","version":"2.31.0","latest_version":"2.32.3"},
+		{"name":"typing-extensions","version":"4.8.0","latest_version":"4.12.2"},{"name":"pip","version":"23.0","latest_version":"24.0"}]`
+	deps, err := parsePipOutdated([]byte(out), declared)
+	if err != nil {
+		t.Fatalf("parsePipOutdated: %v", err)
+	}
+	for _, d := range deps {
+		if want := d.Name != "pip"; d.Direct != want {
+			t.Errorf("%s: direct = %v, want %v", d.Name, d.Direct, want)
+		}
+	}
+}
+
+func TestFileBeadsSkipsIndirectAndOpenDuplicates(t *testing.T) {
+	m, beads := fakeManager(t, map[string]string{"go.mod": "module example.com/app\n"}, map[string]string{"go": goListOutput})
+	report, err := m.Outdated(context.Background(), "p", Options{})
+	if err != nil {
+		t.Fatalf("Outdated: %v", err)
+	}
+
+	ids, err := m.FileBeads(report, "")
+	if err != nil {
+		t.Fatalf("FileBeads: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("expected beads for the 2 direct modules, got %d", len(ids))
+	}
+	cobra := beads.beads[1]
+	if cobra.Title != "Dependency update (go): github.com/spf13/cobra v1.7.0 to v1.8.1" ||
+		!strings.Contains(cobra.Description, "```json") || !strings.Contains(cobra.Description, `"upgrade_command"`) {
+		t.Errorf("unexpected bead: %q\n%s", cobra.Title, cobra.Description)
+	}
+
+	// A second audit finds the open beads and files nothing new
+	if ids, _ := m.FileBeads(report, BeadModePerDependency); len(ids) != 0 {
+		t.Errorf("expected no duplicate beads, got %v", ids)
+	}
+
+	beads.beads[0].Status = models.BeadStatusClosed
+	beads.beads[1].Status = models.BeadStatusClosed
+	ids, err = m.FileBeads(report, BeadModeBatch)
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("expected one batch bead, got %v (%v)", ids, err)
+	}
+	batch := beads.beads[2]
+	if batch.Title != "Dependency update (go): 2 outdated dependencies" || !strings.Contains(batch.Description, "| github.com/acme/lib | v0.3.1 | v0.4.0 | yes |") {
+		t.Errorf("unexpected batch bead: %q\n%s", batch.Title, batch.Description)
+	}
+
+	if _, err := m.FileBeads(report, "weekly"); err == nil {
+		t.Error("expected error for unknown bead mode")
+	}
+}
+
+func TestScheduleHandler(t *testing.T) {
+	m, beads := fakeManager(t, map[string]string{"go.mod": "module example.com/app\n"}, map[string]string{"go": goListOutput})
+	summary, err := m.ScheduleHandler()(context.Background(), &models.Schedule{
+		ProjectID: "p", Params: map[string]string{"bead_mode": BeadModeBatch},
+	})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if summary != "3 outdated dependencies, filed 1 beads" || len(beads.beads) != 1 {
+		t.Errorf("unexpected summary %q (beads %d)", summary, len(beads.beads))
+	}
+}
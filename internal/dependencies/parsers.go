@@ -0,0 +1,181 @@
+package dependencies
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// parseGoList parses the stream of JSON objects printed by
+// `go list -u -m -json all`, keeping modules with an available update.
+func parseGoList(out []byte) ([]Dependency, error) {
+	dec := json.NewDecoder(bytes.NewReader(out))
+	var deps []Dependency
+	for {
+		var mod struct {
+			Path     string
+			Version  string
+			Main     bool
+			Indirect bool
+			Update   *struct {
+				Version string
+			}
+		}
+		if err := dec.Decode(&mod); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid go list output: %w", err)
+		}
+		if mod.Main || mod.Update == nil || mod.Update.Version == "" {
+			continue
+		}
+		deps = append(deps, Dependency{
+			Name:      mod.Path,
+			Ecosystem: Go,
+			Current:   mod.Version,
+			Latest:    mod.Update.Version,
+			Direct:    !mod.Indirect,
+		})
+	}
+	return deps, nil
+}
+
+// parseNpmOutdated parses `npm outdated --json`. Workspaces report a list of
+// entries per package; the first is used.
+func parseNpmOutdated(out []byte) ([]Dependency, error) {
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var report map[string]json.RawMessage
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("invalid npm outdated output: %w", err)
+	}
+	if raw, ok := report["error"]; ok {
+		var e struct {
+			Summary string `json:"summary"`
+		}
+		_ = json.Unmarshal(raw, &e)
+		return nil, fmt.Errorf("npm outdated: %s", e.Summary)
+	}
+
+	type entry struct {
+		Current string `json:"current"`
+		Wanted  string `json:"wanted"`
+		Latest  string `json:"latest"`
+		Type    string `json:"type"`
+	}
+	names := make([]string, 0, len(report))
+	for name := range report {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var deps []Dependency
+	for _, name := range names {
+		var e entry
+		if err := json.Unmarshal(report[name], &e); err != nil {
+			var list []entry
+			if json.Unmarshal(report[name], &list) != nil || len(list) == 0 {
+				continue
+			}
+			e = list[0]
+		}
+		if e.Latest == "" || e.Current == e.Latest {
+			continue
+		}
+		deps = append(deps, Dependency{
+			Name:      name,
+			Ecosystem: Npm,
+			Current:   e.Current,
+			Wanted:    e.Wanted,
+			Latest:    e.Latest,
+			Direct:    true,
+			Dev:       e.Type == "devDependencies",
+		})
+	}
+	return deps, nil
+}
+
+// parsePipOutdated parses `pip list --outdated --format=json`. pip reports
+// the whole environment, so only packages named in declared are direct.
+func parsePipOutdated(out []byte, declared map[string]bool) ([]Dependency, error) {
+	var report []struct {
+		Name          string `json:"name"`
+		Version       string `json:"version"`
+		LatestVersion string `json:"latest_version"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("invalid pip output: %w", err)
+	}
+	deps := make([]Dependency, 0, len(report))
+	for _, p := range report {
+		deps = append(deps, Dependency{
+			Name:      p.Name,
+			Ecosystem: Pip,
+			Current:   p.Version,
+			Latest:    p.LatestVersion,
+			Direct:    declared[normalizePyName(p.Name)],
+		})
+	}
+	return deps, nil
+}
+
+// normalizePyName applies PEP 503 name normalization
+func normalizePyName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer("_", "-", ".", "-").Replace(name)
+}
+
+// declaredPythonPackages collects package names from requirements.txt and
+// pyproject.toml dependency lists.
+func declaredPythonPackages(requirements, pyproject string) map[string]bool {
+	declared := map[string]bool{}
+	add := func(spec string) {
+		spec = strings.TrimSpace(spec)
+		if spec == "" || strings.HasPrefix(spec, "#") || strings.HasPrefix(spec, "-") {
+			return
+		}
+		end := strings.IndexAny(spec, "<>=!~;[ @")
+		if end >= 0 {
+			spec = spec[:end]
+		}
+		if spec != "" {
+			declared[normalizePyName(spec)] = true
+		}
+	}
+	for _, line := range strings.Split(requirements, "\n") {
+		add(line)
+	}
+
+	// PEP 621 `dependencies = [...]` and Poetry `[tool.poetry.dependencies]`
+	inList, inPoetry := false, false
+	for _, line := range strings.Split(pyproject, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "["):
+			inPoetry = strings.HasPrefix(trimmed, "[tool.poetry.") && strings.Contains(trimmed, "dependencies]")
+			inList = false
+		case strings.HasPrefix(trimmed, "dependencies") && strings.Contains(trimmed, "["):
+			inList = !strings.Contains(trimmed, "]")
+			rest := trimmed[strings.Index(trimmed, "[")+1:]
+			for _, spec := range strings.Split(strings.TrimSuffix(rest, "]"), ",") {
+				add(strings.Trim(strings.TrimSpace(spec), `"'`))
+			}
+		case inList:
+			if strings.HasPrefix(trimmed, "]") {
+				inList = false
+				continue
+			}
+			add(strings.Trim(strings.TrimSuffix(trimmed, ","), `"'`))
+		case inPoetry && strings.Contains(trimmed, "="):
+			name := strings.TrimSpace(trimmed[:strings.Index(trimmed, "=")])
+			if name != "python" {
+				add(name)
+			}
+		}
+	}
+	return declared
+}
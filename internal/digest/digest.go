@@ -0,0 +1,502 @@
+// Package digest generates per-project status reports, the kind of thing a
+// standup or weekly update covers: beads opened and closed, pull requests
+// merged, provider spend, notable usage anomalies and blocked work. Reports
+// are assembled from data other subsystems already keep, optionally given a
+// short summary by an LLM, archived, and handed to a delivery hook that
+// fans them out to the notification channels.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/internal/patterns"
+	"github.com/jordanhubbard/loom/internal/scheduler"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// ScheduleKind is the scheduler kind for recurring digests
+const ScheduleKind = "project_digest"
+
+// Periods
+const (
+	PeriodDaily  = "daily"
+	PeriodWeekly = "weekly"
+)
+
+// Sections, as keys of Report.Errors
+const (
+	SectionBeads        = "beads"
+	SectionPullRequests = "pull_requests"
+	SectionCosts        = "costs"
+	SectionAnomalies    = "anomalies"
+	SectionSummary      = "summary"
+)
+
+const (
+	// maxTopBeads caps the most expensive beads listed in a report
+	maxTopBeads = 5
+	// DefaultListLimit is how many archived digests List returns by default
+	DefaultListLimit = 20
+)
+
+// BeadSource lists beads
+type BeadSource interface {
+	ListBeads(filters map[string]interface{}) ([]*models.Bead, error)
+}
+
+// CostSource lists logged provider requests
+type CostSource interface {
+	GetLogs(ctx context.Context, filter *analytics.LogFilter) ([]*analytics.RequestLog, error)
+}
+
+// AnomalySource reports anomalies found by the patterns analyzer
+type AnomalySource interface {
+	GetAnomalies(ctx context.Context) ([]*patterns.PatternAnomaly, error)
+}
+
+// PullRequestSource lists a project's pull requests merged in a window
+type PullRequestSource interface {
+	MergedPullRequests(ctx context.Context, projectID string, since, until time.Time) ([]*PullRequest, error)
+}
+
+// Summarizer writes a short prose summary of a report
+type Summarizer interface {
+	Summarize(ctx context.Context, r *Report) (string, error)
+}
+
+// Sources are the subsystems a digest reads. Nil sources leave their
+// sections empty.
+type Sources struct {
+	Beads        BeadSource
+	Costs        CostSource
+	Anomalies    AnomalySource
+	PullRequests PullRequestSource
+	Summarizer   Summarizer
+}
+
+// Store archives generated digests
+type Store interface {
+	SaveDigest(r *Report) error
+	// GetDigest returns nil, nil when there is no such digest
+	GetDigest(id string) (*Report, error)
+	// ListDigests returns digests newest first; empty filters match all
+	ListDigests(projectID, period string, limit int) ([]*Report, error)
+}
+
+// Options select what a digest covers
+type Options struct {
+	ProjectID string
+	Period    string    // daily (default) or weekly
+	End       time.Time // end of the covered window, default now
+	Summarize bool      // ask the summarizer for a prose summary
+}
+
+// Report is a generated digest. A section whose source failed is listed in
+// Errors; the rest of the report is still usable.
+type Report struct {
+	ID           string                     `json:"id"`
+	ProjectID    string                     `json:"project_id"`
+	Period       string                     `json:"period"`
+	Start        time.Time                  `json:"start"`
+	End          time.Time                  `json:"end"`
+	GeneratedAt  time.Time                  `json:"generated_at"`
+	Summary      string                     `json:"summary,omitempty"`
+	Opened       []*BeadItem                `json:"opened"`
+	Closed       []*BeadItem                `json:"closed"`
+	Blocked      []*BeadItem                `json:"blocked"`
+	PullRequests []*PullRequest             `json:"pull_requests"`
+	Cost         *CostSummary               `json:"cost,omitempty"`
+	Anomalies    []*patterns.PatternAnomaly `json:"anomalies,omitempty"`
+	Errors       map[string]string          `json:"errors,omitempty"`
+}
+
+// Title is the report's heading
+func (r *Report) Title() string {
+	name := "Daily"
+	if r.Period == PeriodWeekly {
+		name = "Weekly"
+	}
+	return fmt.Sprintf("%s digest for %s", name, r.ProjectID)
+}
+
+// Headline is a one-line count of what happened, for notifications and
+// schedule results
+func (r *Report) Headline() string {
+	return fmt.Sprintf("%d opened, %d closed, %d blocked, %d PRs merged",
+		len(r.Opened), len(r.Closed), len(r.Blocked), len(r.PullRequests))
+}
+
+// BeadItem is the part of a bead a digest lists
+type BeadItem struct {
+	ID         string              `json:"id"`
+	Title      string              `json:"title"`
+	Priority   models.BeadPriority `json:"priority"`
+	Status     models.BeadStatus   `json:"status"`
+	AssignedTo string              `json:"assigned_to,omitempty"`
+	BlockedBy  []string            `json:"blocked_by,omitempty"` // Open blockers only
+	At         time.Time           `json:"at"`                   // Opened or closed at; last update for blocked beads
+}
+
+// PullRequest is a merged pull request
+type PullRequest struct {
+	Number   int       `json:"number"`
+	Title    string    `json:"title"`
+	URL      string    `json:"url,omitempty"`
+	Author   string    `json:"author,omitempty"`
+	MergedAt time.Time `json:"merged_at"`
+}
+
+// CostSummary is the provider spend attributed to the project's beads
+type CostSummary struct {
+	USD      float64     `json:"usd"`
+	Requests int64       `json:"requests"`
+	Tokens   int64       `json:"tokens"`
+	TopBeads []*BeadCost `json:"top_beads,omitempty"`
+}
+
+// BeadCost is one bead's share of the spend
+type BeadCost struct {
+	BeadID string  `json:"bead_id"`
+	Title  string  `json:"title"`
+	USD    float64 `json:"usd"`
+}
+
+// Generator builds, archives and delivers digests
+type Generator struct {
+	sources Sources
+	store   Store
+
+	mu      sync.RWMutex
+	deliver func(*Report)
+}
+
+// NewGenerator creates a generator reading from sources. Digests are
+// archived in memory until SetStore is called.
+func NewGenerator(sources Sources) *Generator {
+	return &Generator{sources: sources, store: newMemoryStore()}
+}
+
+// SetStore archives digests in store
+func (g *Generator) SetStore(store Store) {
+	if store != nil {
+		g.store = store
+	}
+}
+
+// SetDeliveryHook sets the function Run hands each archived digest to
+func (g *Generator) SetDeliveryHook(deliver func(*Report)) {
+	g.mu.Lock()
+	g.deliver = deliver
+	g.mu.Unlock()
+}
+
+// Window returns the start of the period ending at end
+func Window(period string, end time.Time) (time.Time, error) {
+	switch period {
+	case "", PeriodDaily:
+		return end.Add(-24 * time.Hour), nil
+	case PeriodWeekly:
+		return end.AddDate(0, 0, -7), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown period %q (want daily or weekly)", period)
+}
+
+// Generate assembles a digest without archiving or delivering it
+func (g *Generator) Generate(ctx context.Context, opts Options) (*Report, error) {
+	if opts.ProjectID == "" {
+		return nil, fmt.Errorf("project_id is required")
+	}
+	if opts.Period == "" {
+		opts.Period = PeriodDaily
+	}
+	if opts.End.IsZero() {
+		opts.End = time.Now()
+	}
+	start, err := Window(opts.Period, opts.End)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{
+		ID:           uuid.New().String(),
+		ProjectID:    opts.ProjectID,
+		Period:       opts.Period,
+		Start:        start.UTC(),
+		End:          opts.End.UTC(),
+		GeneratedAt:  time.Now().UTC(),
+		Opened:       []*BeadItem{},
+		Closed:       []*BeadItem{},
+		Blocked:      []*BeadItem{},
+		PullRequests: []*PullRequest{},
+	}
+	fail := func(section string, err error) {
+		if r.Errors == nil {
+			r.Errors = map[string]string{}
+		}
+		r.Errors[section] = err.Error()
+	}
+
+	var beads map[string]*models.Bead
+	if g.sources.Beads != nil {
+		list, err := g.sources.Beads.ListBeads(map[string]interface{}{"project_id": opts.ProjectID})
+		if err != nil {
+			fail(SectionBeads, err)
+		} else {
+			beads = make(map[string]*models.Bead, len(list))
+			for _, bead := range list {
+				beads[bead.ID] = bead
+			}
+			r.Opened, r.Closed, r.Blocked = beadSections(beads, start, opts.End)
+		}
+	}
+	if g.sources.PullRequests != nil {
+		prs, err := g.sources.PullRequests.MergedPullRequests(ctx, opts.ProjectID, start, opts.End)
+		if err != nil {
+			fail(SectionPullRequests, err)
+		}
+		sort.Slice(prs, func(i, j int) bool { return prs[i].MergedAt.Before(prs[j].MergedAt) })
+		if prs != nil {
+			r.PullRequests = prs
+		}
+	}
+	// Spend is attributed to the project through the beads it was logged
+	// against, so it needs the bead list
+	if g.sources.Costs != nil && beads != nil {
+		logs, err := g.sources.Costs.GetLogs(ctx, &analytics.LogFilter{StartTime: start, EndTime: opts.End})
+		if err != nil {
+			fail(SectionCosts, err)
+		}
+		r.Cost = costSummary(logs, beads)
+	}
+	if g.sources.Anomalies != nil {
+		anomalies, err := g.sources.Anomalies.GetAnomalies(ctx)
+		if err != nil {
+			fail(SectionAnomalies, err)
+		}
+		r.Anomalies = notableAnomalies(anomalies, start, opts.End)
+	}
+	if opts.Summarize && g.sources.Summarizer != nil {
+		summary, err := g.sources.Summarizer.Summarize(ctx, r)
+		if err != nil {
+			fail(SectionSummary, err)
+		}
+		r.Summary = summary
+	}
+	return r, nil
+}
+
+// Run generates a digest, archives it and hands it to the delivery hook
+func (g *Generator) Run(ctx context.Context, opts Options) (*Report, error) {
+	r, err := g.Generate(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.store.SaveDigest(r); err != nil {
+		return nil, fmt.Errorf("failed to archive digest: %w", err)
+	}
+	g.mu.RLock()
+	deliver := g.deliver
+	g.mu.RUnlock()
+	if deliver != nil {
+		deliver(r)
+	}
+	return r, nil
+}
+
+// Get returns an archived digest, or nil when there is no such digest
+func (g *Generator) Get(id string) (*Report, error) {
+	return g.store.GetDigest(id)
+}
+
+// List returns archived digests newest first, DefaultListLimit of them
+// when limit is not positive
+func (g *Generator) List(projectID, period string, limit int) ([]*Report, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	return g.store.ListDigests(projectID, period, limit)
+}
+
+// ScheduleHandler runs a digest for a schedule's project. Params: period
+// (daily or weekly, default daily) and summarize ("true" to ask for an LLM
+// summary).
+func (g *Generator) ScheduleHandler() scheduler.Handler {
+	return func(ctx context.Context, s *models.Schedule) (string, error) {
+		if s.ProjectID == "" {
+			return "", fmt.Errorf("digest schedule has no project")
+		}
+		r, err := g.Run(ctx, Options{
+			ProjectID: s.ProjectID,
+			Period:    s.Params["period"],
+			Summarize: s.Params["summarize"] == "true",
+		})
+		if err != nil {
+			return "", err
+		}
+		return r.Headline(), nil
+	}
+}
+
+// beadSections sorts a project's beads into those opened and closed in
+// [start, end) and those currently blocked. Decision beads are left out;
+// they are not work items.
+func beadSections(all map[string]*models.Bead, start, end time.Time) (opened, closed, blocked []*BeadItem) {
+	opened, closed, blocked = []*BeadItem{}, []*BeadItem{}, []*BeadItem{}
+	in := func(t time.Time) bool { return !t.Before(start) && t.Before(end) }
+	for _, bead := range all {
+		if bead.Type == "decision" {
+			continue
+		}
+		if in(bead.CreatedAt) {
+			opened = append(opened, beadItem(bead, bead.CreatedAt, nil))
+		}
+		if closedAt := beads.ClosedTime(bead); closedAt != nil {
+			if in(*closedAt) {
+				closed = append(closed, beadItem(bead, *closedAt, nil))
+			}
+			continue
+		}
+		var openBlockers []string
+		for _, id := range bead.BlockedBy {
+			if blocker, ok := all[id]; !ok || blocker.Status != models.BeadStatusClosed {
+				openBlockers = append(openBlockers, id)
+			}
+		}
+		if bead.Status == models.BeadStatusBlocked || len(openBlockers) > 0 {
+			blocked = append(blocked, beadItem(bead, bead.UpdatedAt, openBlockers))
+		}
+	}
+	byTime := func(list []*BeadItem) {
+		sort.Slice(list, func(i, j int) bool {
+			if !list[i].At.Equal(list[j].At) {
+				return list[i].At.Before(list[j].At)
+			}
+			return list[i].ID < list[j].ID
+		})
+	}
+	byTime(opened)
+	byTime(closed)
+	sort.Slice(blocked, func(i, j int) bool {
+		if blocked[i].Priority != blocked[j].Priority {
+			return blocked[i].Priority < blocked[j].Priority
+		}
+		return blocked[i].ID < blocked[j].ID
+	})
+	return opened, closed, blocked
+}
+
+func beadItem(bead *models.Bead, at time.Time, blockers []string) *BeadItem {
+	return &BeadItem{
+		ID:         bead.ID,
+		Title:      bead.Title,
+		Priority:   bead.Priority,
+		Status:     bead.Status,
+		AssignedTo: bead.AssignedTo,
+		BlockedBy:  blockers,
+		At:         at.UTC(),
+	}
+}
+
+// costSummary totals the requests logged against the project's beads
+func costSummary(logs []*analytics.RequestLog, beads map[string]*models.Bead) *CostSummary {
+	sum := &CostSummary{}
+	perBead := map[string]float64{}
+	for _, l := range logs {
+		beadID := l.Metadata["bead_id"]
+		if _, ok := beads[beadID]; !ok {
+			continue
+		}
+		sum.USD += l.CostUSD
+		sum.Requests++
+		sum.Tokens += l.TotalTokens
+		perBead[beadID] += l.CostUSD
+	}
+	for id, usd := range perBead {
+		if usd > 0 {
+			sum.TopBeads = append(sum.TopBeads, &BeadCost{BeadID: id, Title: beads[id].Title, USD: usd})
+		}
+	}
+	sort.Slice(sum.TopBeads, func(i, j int) bool {
+		if sum.TopBeads[i].USD != sum.TopBeads[j].USD {
+			return sum.TopBeads[i].USD > sum.TopBeads[j].USD
+		}
+		return sum.TopBeads[i].BeadID < sum.TopBeads[j].BeadID
+	})
+	if len(sum.TopBeads) > maxTopBeads {
+		sum.TopBeads = sum.TopBeads[:maxTopBeads]
+	}
+	return sum
+}
+
+// notableAnomalies keeps high and critical anomalies that occurred in
+// [start, end), most recent first
+func notableAnomalies(anomalies []*patterns.PatternAnomaly, start, end time.Time) []*patterns.PatternAnomaly {
+	var out []*patterns.PatternAnomaly
+	for _, an := range anomalies {
+		if an.Severity != "high" && an.Severity != "critical" {
+			continue
+		}
+		at := anomalyTime(an)
+		if at.Before(start) || !at.Before(end) {
+			continue
+		}
+		out = append(out, an)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return anomalyTime(out[i]).After(anomalyTime(out[j])) })
+	return out
+}
+
+func anomalyTime(an *patterns.PatternAnomaly) time.Time {
+	if !an.OccurredAt.IsZero() {
+		return an.OccurredAt
+	}
+	return an.DetectedAt
+}
+
+// memoryStore archives digests in memory
+type memoryStore struct {
+	mu      sync.RWMutex
+	reports []*Report
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{}
+}
+
+func (s *memoryStore) SaveDigest(r *Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports = append(s.reports, r)
+	return nil
+}
+
+func (s *memoryStore) GetDigest(id string) (*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.reports {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) ListDigests(projectID, period string, limit int) ([]*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Report
+	for i := len(s.reports) - 1; i >= 0 && (limit <= 0 || len(out) < limit); i-- {
+		r := s.reports[i]
+		if (projectID == "" || r.ProjectID == projectID) && (period == "" || r.Period == period) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
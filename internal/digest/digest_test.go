@@ -0,0 +1,197 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/patterns"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeBeads []*models.Bead
+
+func (f fakeBeads) ListBeads(filters map[string]interface{}) ([]*models.Bead, error) {
+	var out []*models.Bead
+	for _, b := range f {
+		if p, ok := filters["project_id"].(string); ok && b.ProjectID != p {
+			continue
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+type fakeCosts []*analytics.RequestLog
+
+func (f fakeCosts) GetLogs(ctx context.Context, filter *analytics.LogFilter) ([]*analytics.RequestLog, error) {
+	var out []*analytics.RequestLog
+	for _, l := range f {
+		if !l.Timestamp.Before(filter.StartTime) && !l.Timestamp.After(filter.EndTime) {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+type fakeAnomalies []*patterns.PatternAnomaly
+
+func (f fakeAnomalies) GetAnomalies(ctx context.Context) ([]*patterns.PatternAnomaly, error) {
+	return f, nil
+}
+
+type failingPRs struct{}
+
+func (failingPRs) MergedPullRequests(ctx context.Context, projectID string, since, until time.Time) ([]*PullRequest, error) {
+	return nil, errors.New("activity feed unavailable")
+}
+
+type fakeSummarizer struct{ seen *Report }
+
+func (f *fakeSummarizer) Summarize(ctx context.Context, r *Report) (string, error) {
+	f.seen = r
+	return "Two beads moved <fast>.", nil
+}
+
+func TestGenerate(t *testing.T) {
+	end := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	closedAt := end.Add(-2 * time.Hour)
+	oldClose := end.AddDate(0, 0, -3)
+	beads := fakeBeads{
+		{ID: "b1", ProjectID: "p1", Title: "New work", Priority: models.BeadPriorityP2, Status: models.BeadStatusOpen, CreatedAt: end.Add(-time.Hour)},
+		{ID: "b2", ProjectID: "p1", Title: "Finished", Status: models.BeadStatusClosed, CreatedAt: end.AddDate(0, 0, -4), ClosedAt: &closedAt},
+		{ID: "b3", ProjectID: "p1", Title: "Waiting", Priority: models.BeadPriorityP1, Status: models.BeadStatusOpen, BlockedBy: []string{"b1", "b2"}, CreatedAt: end.AddDate(0, 0, -2)},
+		{ID: "b4", ProjectID: "p1", Title: "Stuck", Priority: models.BeadPriorityP0, Status: models.BeadStatusBlocked, CreatedAt: end.AddDate(0, 0, -2)},
+		{ID: "b5", ProjectID: "p1", Title: "Old", Status: models.BeadStatusClosed, CreatedAt: end.AddDate(0, 0, -9), ClosedAt: &oldClose},
+		{ID: "d1", ProjectID: "p1", Type: "decision", Status: models.BeadStatusOpen, CreatedAt: end.Add(-time.Hour)},
+		{ID: "x1", ProjectID: "p2", Status: models.BeadStatusOpen, CreatedAt: end.Add(-time.Hour)},
+	}
+	costs := fakeCosts{
+		{Timestamp: end.Add(-3 * time.Hour), CostUSD: 1.25, TotalTokens: 100, Metadata: map[string]string{"bead_id": "b2"}},
+		{Timestamp: end.Add(-2 * time.Hour), CostUSD: 0.50, TotalTokens: 40, Metadata: map[string]string{"bead_id": "b1"}},
+		{Timestamp: end.Add(-2 * time.Hour), CostUSD: 9, Metadata: map[string]string{"bead_id": "x1"}},
+		{Timestamp: end.AddDate(0, 0, -2), CostUSD: 9, Metadata: map[string]string{"bead_id": "b1"}},
+	}
+	anomalies := fakeAnomalies{
+		{ID: "an1", Type: "cost-spike", Severity: "critical", OccurredAt: end.Add(-time.Hour)},
+		{ID: "an2", Type: "latency-spike", Severity: "low", OccurredAt: end.Add(-time.Hour)},
+		{ID: "an3", Type: "error-spike", Severity: "high", OccurredAt: end.AddDate(0, 0, -3)},
+	}
+	summarizer := &fakeSummarizer{}
+	g := NewGenerator(Sources{Beads: beads, Costs: costs, Anomalies: anomalies, PullRequests: failingPRs{}, Summarizer: summarizer})
+
+	r, err := g.Generate(context.Background(), Options{ProjectID: "p1", End: end, Summarize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Period != PeriodDaily || !r.Start.Equal(end.Add(-24*time.Hour)) {
+		t.Errorf("unexpected window %s %s-%s", r.Period, r.Start, r.End)
+	}
+	if ids(r.Opened) != "b1" || ids(r.Closed) != "b2" {
+		t.Errorf("opened %s, closed %s", ids(r.Opened), ids(r.Closed))
+	}
+	if ids(r.Blocked) != "b4,b3" || len(r.Blocked[1].BlockedBy) != 1 || r.Blocked[1].BlockedBy[0] != "b1" {
+		t.Errorf("unexpected blocked beads %s %+v", ids(r.Blocked), r.Blocked)
+	}
+	if r.Cost == nil || r.Cost.USD != 1.75 || r.Cost.Requests != 2 || r.Cost.Tokens != 140 ||
+		len(r.Cost.TopBeads) != 2 || r.Cost.TopBeads[0].BeadID != "b2" {
+		t.Errorf("unexpected cost %+v", r.Cost)
+	}
+	if len(r.Anomalies) != 1 || r.Anomalies[0].ID != "an1" {
+		t.Errorf("expected only the critical anomaly in the window, got %+v", r.Anomalies)
+	}
+	if r.Errors[SectionPullRequests] == "" || len(r.PullRequests) != 0 {
+		t.Errorf("expected the pull request failure to be reported, got %+v", r.Errors)
+	}
+	if r.Summary == "" || summarizer.seen != r {
+		t.Error("expected the summarizer to see the assembled report")
+	}
+
+	if _, err := g.Generate(context.Background(), Options{ProjectID: "p1", Period: "hourly"}); err == nil {
+		t.Error("expected an unknown period to be rejected")
+	}
+	if _, err := g.Generate(context.Background(), Options{}); err == nil {
+		t.Error("expected a missing project to be rejected")
+	}
+}
+
+func TestRunArchivesAndDelivers(t *testing.T) {
+	g := NewGenerator(Sources{Beads: fakeBeads{}})
+	var delivered []*Report
+	g.SetDeliveryHook(func(r *Report) { delivered = append(delivered, r) })
+
+	handler := g.ScheduleHandler()
+	result, err := handler(context.Background(), &models.Schedule{ProjectID: "p1", Params: map[string]string{"period": PeriodWeekly}})
+	if err != nil || !strings.Contains(result, "0 opened") {
+		t.Fatalf("handler() = %q, %v", result, err)
+	}
+	if _, err := g.Run(context.Background(), Options{ProjectID: "p2"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(delivered) != 2 || delivered[0].Period != PeriodWeekly {
+		t.Fatalf("expected both digests delivered, got %d", len(delivered))
+	}
+
+	list, _ := g.List("", "", 0)
+	if len(list) != 2 || list[0].ProjectID != "p2" {
+		t.Errorf("expected newest first, got %d digests", len(list))
+	}
+	if list, _ := g.List("p1", PeriodWeekly, 0); len(list) != 1 {
+		t.Errorf("expected a filtered list of one, got %d", len(list))
+	}
+	if got, _ := g.Get(delivered[0].ID); got != delivered[0] {
+		t.Error("expected the archived digest back")
+	}
+	if got, err := g.Get("missing"); got != nil || err != nil {
+		t.Errorf("Get(missing) = %v, %v", got, err)
+	}
+	if _, err := handler(context.Background(), &models.Schedule{}); err == nil {
+		t.Error("expected a schedule without a project to fail")
+	}
+}
+
+func TestRender(t *testing.T) {
+	r := &Report{
+		ProjectID:    "p1",
+		Period:       PeriodWeekly,
+		Summary:      "Shipped <script>",
+		Opened:       []*BeadItem{{ID: "b1", Title: "Add <b>bold</b>", Priority: 1}},
+		Closed:       []*BeadItem{},
+		Blocked:      []*BeadItem{{ID: "b2", Title: "Waiting", BlockedBy: []string{"b1"}}},
+		PullRequests: []*PullRequest{{Number: 7, Title: "Fix", URL: "https://example.com/pr/7", Author: "sam"}},
+		Cost:         &CostSummary{USD: 2.5, Requests: 3},
+		Errors:       map[string]string{SectionAnomalies: "analysis failed"},
+	}
+	md := Markdown(r)
+	for _, want := range []string{"# Weekly digest for p1", "- P1 b1: Add <b>bold</b>", "[#7](https://example.com/pr/7) Fix by sam",
+		"$2.50 over 3 requests", "blocked by b1", "anomalies: analysis failed"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+
+	html, err := Render(r, FormatHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(html, "<script>") || !strings.Contains(html, "Shipped &lt;script&gt;") {
+		t.Errorf("expected HTML to escape report text:\n%s", html)
+	}
+	if !strings.Contains(html, `<a href="https://example.com/pr/7">#7</a>`) || !strings.Contains(html, "<h2>Blocked (1)</h2>") {
+		t.Errorf("unexpected HTML:\n%s", html)
+	}
+	if _, err := Render(r, "pdf"); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
+
+func ids(items []*BeadItem) string {
+	var out []string
+	for _, item := range items {
+		out = append(out, item.ID)
+	}
+	return strings.Join(out, ",")
+}
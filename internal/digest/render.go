@@ -0,0 +1,166 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Formats a digest can be rendered in
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Render renders a digest in format (markdown or html)
+func Render(r *Report, format string) (string, error) {
+	switch format {
+	case "", FormatMarkdown:
+		return Markdown(r), nil
+	case FormatHTML:
+		return HTML(r)
+	}
+	return "", fmt.Errorf("unknown format %q (want markdown or html)", format)
+}
+
+// Markdown renders a digest as Markdown
+func Markdown(r *Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title())
+	fmt.Fprintf(&b, "%s to %s\n\n", r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339))
+	if r.Summary != "" {
+		b.WriteString(r.Summary + "\n\n")
+	}
+	b.WriteString(r.Headline() + "\n")
+
+	beadList := func(title string, items []*BeadItem) {
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", title, len(items))
+		if len(items) == 0 {
+			b.WriteString("None.\n")
+			return
+		}
+		for _, item := range items {
+			fmt.Fprintf(&b, "- P%d %s: %s", item.Priority, item.ID, item.Title)
+			if item.AssignedTo != "" {
+				fmt.Fprintf(&b, " (%s)", item.AssignedTo)
+			}
+			if len(item.BlockedBy) > 0 {
+				fmt.Fprintf(&b, ", blocked by %s", strings.Join(item.BlockedBy, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	beadList("Opened", r.Opened)
+	beadList("Closed", r.Closed)
+
+	fmt.Fprintf(&b, "\n## Pull requests merged (%d)\n\n", len(r.PullRequests))
+	if len(r.PullRequests) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, pr := range r.PullRequests {
+		title := fmt.Sprintf("#%d %s", pr.Number, pr.Title)
+		if pr.URL != "" {
+			title = fmt.Sprintf("[#%d](%s) %s", pr.Number, pr.URL, pr.Title)
+		}
+		if pr.Author != "" {
+			title += " by " + pr.Author
+		}
+		b.WriteString("- " + title + "\n")
+	}
+
+	if r.Cost != nil {
+		fmt.Fprintf(&b, "\n## Costs\n\n$%.2f over %d requests (%d tokens)\n", r.Cost.USD, r.Cost.Requests, r.Cost.Tokens)
+		if len(r.Cost.TopBeads) > 0 {
+			b.WriteString("\n")
+		}
+		for _, bc := range r.Cost.TopBeads {
+			fmt.Fprintf(&b, "- %s: %s, $%.2f\n", bc.BeadID, bc.Title, bc.USD)
+		}
+	}
+
+	if len(r.Anomalies) > 0 {
+		fmt.Fprintf(&b, "\n## Anomalies (%d)\n\n", len(r.Anomalies))
+		for _, an := range r.Anomalies {
+			fmt.Fprintf(&b, "- %s %s: %s\n", an.Severity, an.Type, an.Description)
+		}
+	}
+
+	beadList("Blocked", r.Blocked)
+
+	if len(r.Errors) > 0 {
+		b.WriteString("\n## Incomplete sections\n\n")
+		for _, section := range sortedKeys(r.Errors) {
+			fmt.Fprintf(&b, "- %s: %s\n", section, r.Errors[section])
+		}
+	}
+	return b.String()
+}
+
+var htmlTemplate = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"rfc3339": func(t time.Time) string { return t.Format(time.RFC3339) },
+	"usd":     func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+	"join":    strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.R.Title}}</title></head>
+<body>
+<h1>{{.R.Title}}</h1>
+<p>{{rfc3339 .R.Start}} to {{rfc3339 .R.End}}</p>
+{{if .R.Summary}}<p>{{.R.Summary}}</p>{{end}}
+<p>{{.R.Headline}}</p>
+{{define "beads"}}<h2>{{.Title}} ({{len .Items}})</h2>
+{{if .Items}}<ul>{{range .Items}}
+<li>P{{printf "%d" .Priority}} {{.ID}}: {{.Title}}{{if .AssignedTo}} ({{.AssignedTo}}){{end}}{{if .BlockedBy}}, blocked by {{join .BlockedBy ", "}}{{end}}</li>{{end}}
+</ul>{{else}}<p>None.</p>{{end}}
+{{end}}{{template "beads" .Opened}}{{template "beads" .Closed}}
+<h2>Pull requests merged ({{len .R.PullRequests}})</h2>
+{{if .R.PullRequests}}<ul>{{range .R.PullRequests}}
+<li>{{if .URL}}<a href="{{.URL}}">#{{.Number}}</a>{{else}}#{{.Number}}{{end}} {{.Title}}{{if .Author}} by {{.Author}}{{end}}</li>{{end}}
+</ul>{{else}}<p>None.</p>{{end}}
+{{with .R.Cost}}<h2>Costs</h2>
+<p>{{usd .USD}} over {{.Requests}} requests ({{.Tokens}} tokens)</p>
+{{if .TopBeads}}<ul>{{range .TopBeads}}
+<li>{{.BeadID}}: {{.Title}}, {{usd .USD}}</li>{{end}}
+</ul>{{end}}{{end}}
+{{if .R.Anomalies}}<h2>Anomalies ({{len .R.Anomalies}})</h2>
+<ul>{{range .R.Anomalies}}
+<li>{{.Severity}} {{.Type}}: {{.Description}}</li>{{end}}
+</ul>{{end}}
+{{template "beads" .Blocked}}
+{{if .R.Errors}}<h2>Incomplete sections</h2>
+<ul>{{range $section, $err := .R.Errors}}
+<li>{{$section}}: {{$err}}</li>{{end}}
+</ul>{{end}}
+</body></html>
+`))
+
+type beadSection struct {
+	Title string
+	Items []*BeadItem
+}
+
+// HTML renders a digest as a standalone HTML page
+func HTML(r *Report) (string, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, map[string]interface{}{
+		"R":       r,
+		"Opened":  beadSection{"Opened", r.Opened},
+		"Closed":  beadSection{"Closed", r.Closed},
+		"Blocked": beadSection{"Blocked", r.Blocked},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render digest: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
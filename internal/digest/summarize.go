@@ -0,0 +1,55 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/provider"
+)
+
+// summaryPrompt asks for the prose that opens a digest
+const summaryPrompt = `Write a short status update (three to five sentences, plain prose, no headings or lists) for the project report below. Lead with what got done, then call out blocked work, unusual spend and anomalies that need attention. Do not invent anything that is not in the report.`
+
+// Completer sends chat completions to a provider
+type Completer interface {
+	SendChatCompletion(ctx context.Context, providerID string, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error)
+}
+
+// LLMSummarizer summarizes digests with a chat completion
+type LLMSummarizer struct {
+	llm        Completer
+	providerID func() string
+}
+
+// NewLLMSummarizer creates a summarizer sending completions to the provider
+// providerID returns at the time of each summary
+func NewLLMSummarizer(llm Completer, providerID func() string) *LLMSummarizer {
+	return &LLMSummarizer{llm: llm, providerID: providerID}
+}
+
+// Summarize asks the provider for a summary of the rendered report
+func (s *LLMSummarizer) Summarize(ctx context.Context, r *Report) (string, error) {
+	providerID := ""
+	if s.providerID != nil {
+		providerID = s.providerID()
+	}
+	if providerID == "" {
+		return "", fmt.Errorf("no active provider to summarize with")
+	}
+	resp, err := s.llm.SendChatCompletion(ctx, providerID, &provider.ChatCompletionRequest{
+		Messages: []provider.ChatMessage{
+			{Role: "system", Content: summaryPrompt},
+			{Role: "user", Content: Markdown(r)},
+		},
+		Temperature: 0.2,
+		MaxTokens:   400,
+	})
+	if err != nil {
+		return "", fmt.Errorf("summary failed: %w", err)
+	}
+	if resp == nil || len(resp.Choices) == 0 {
+		return "", fmt.Errorf("provider %s returned no summary", providerID)
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
@@ -4,10 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,15 +14,28 @@ import (
 	"github.com/jordanhubbard/loom/internal/agent"
 	"github.com/jordanhubbard/loom/internal/beads"
 	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/experiment"
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/observability"
 	"github.com/jordanhubbard/loom/internal/project"
 	"github.com/jordanhubbard/loom/internal/provider"
+	"github.com/jordanhubbard/loom/internal/review"
 	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
 	"github.com/jordanhubbard/loom/internal/worker"
 	"github.com/jordanhubbard/loom/internal/workflow"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
+var (
+	dispatchLog = logging.Module("dispatcher")
+	commitLog   = logging.Module("commit")
+	ralphLog    = logging.Module("ralph")
+	workflowLog = logging.Module("workflow")
+	reviewLog   = logging.Module("review")
+	lessonsLog  = logging.Module("lessonsprovider")
+	loopLog     = logging.Module("loopdetector")
+)
+
 type StatusState string
 
 const (
@@ -69,9 +80,17 @@ type Dispatcher struct {
 	complexityEstimator *provider.ComplexityEstimator
 	readinessCheck      func(context.Context, string) (bool, []string)
 	readinessMode       ReadinessMode
+	readOnlyCheck       func() error
 	escalator           Escalator
+	roles               RoleResolver
+	reviewer            Reviewer
+	experiments         Experimenter
+	repoMaps            RepoMapper
+	reviewAttempts      map[string]time.Time // beadID -> last review start
 	maxDispatchHops     int
 	loopDetector        *LoopDetector
+	queuePolicy         QueuePolicy
+	labelRoutes         []LabelRoute
 
 	// Commit serialization (Gap #2)
 	commitLock        sync.Mutex        // Global commit lock
@@ -105,6 +124,32 @@ type Escalator interface {
 	EscalateBeadToCEO(beadID, reason, returnedTo string) (*models.DecisionBead, error)
 }
 
+// RoleResolver maps agents to their role in the role registry
+type RoleResolver interface {
+	RoleForAgent(agent *models.Agent) *models.AgentRole
+}
+
+// Reviewer runs the automated code review for beads at a review node
+type Reviewer interface {
+	Review(ctx context.Context, bead *models.Bead, executionID string) (*review.Report, error)
+}
+
+// Experimenter splits beads between the variants of model A/B experiments
+// and records how they turned out
+type Experimenter interface {
+	Assign(bead *models.Bead) (*experiment.Experiment, *experiment.Variant)
+	Exclude(beadID string) error
+	Conclude(ctx context.Context, beadID string, success bool) error
+}
+
+// RepoMapper supplies the repository map added to a project's task context
+type RepoMapper interface {
+	RepoMapBlock(ctx context.Context, projectID string) string
+}
+
+// reviewRetryInterval spaces out review attempts for the same bead
+const reviewRetryInterval = 5 * time.Minute
+
 func NewDispatcher(beadsMgr *beads.Manager, projMgr *project.Manager, agentMgr *agent.WorkerManager, registry *provider.Registry, eb *eventbus.EventBus) *Dispatcher {
 	d := &Dispatcher{
 		beads:               beadsMgr,
@@ -116,6 +161,7 @@ func NewDispatcher(beadsMgr *beads.Manager, projMgr *project.Manager, agentMgr *
 		autoBugRouter:       NewAutoBugRouter(),
 		complexityEstimator: provider.NewComplexityEstimator(),
 		loopDetector:        NewLoopDetector(),
+		reviewAttempts:      make(map[string]time.Time),
 		readinessMode:       ReadinessWarn,
 		commitQueue:         make(chan commitRequest, 100), // Buffer 100 waiting commits
 		commitLockTimeout:   5 * time.Minute,
@@ -159,6 +205,37 @@ func (d *Dispatcher) SetEscalator(escalator Escalator) {
 	d.escalator = escalator
 }
 
+// SetRoleResolver sets the role registry whose provider and model
+// preferences steer provider selection
+func (d *Dispatcher) SetRoleResolver(roles RoleResolver) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.roles = roles
+}
+
+// SetReviewer sets the pipeline that reviews beads at review nodes. Without
+// one, review nodes are dispatched to agents like any other node.
+func (d *Dispatcher) SetReviewer(reviewer Reviewer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reviewer = reviewer
+}
+
+// SetExperimenter sets the A/B experiments whose variants override provider
+// selection for the beads assigned to them
+func (d *Dispatcher) SetExperimenter(experiments Experimenter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.experiments = experiments
+}
+
+// SetRepoMapper has each task's context carry its project's repository map
+func (d *Dispatcher) SetRepoMapper(maps RepoMapper) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.repoMaps = maps
+}
+
 // SetMaxDispatchHops configures the max hop limit before escalation.
 func (d *Dispatcher) SetMaxDispatchHops(maxHops int) {
 	d.mu.Lock()
@@ -172,6 +249,14 @@ func (d *Dispatcher) SetReadinessCheck(check func(context.Context, string) (bool
 	d.readinessCheck = check
 }
 
+// SetReadOnlyCheck installs a check run before each dispatch; while it
+// returns an error the dispatcher parks and hands out no work
+func (d *Dispatcher) SetReadOnlyCheck(check func() error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.readOnlyCheck = check
+}
+
 func (d *Dispatcher) SetReadinessMode(mode ReadinessMode) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -196,7 +281,7 @@ func (d *Dispatcher) processCommitQueue() {
 		}
 		d.commitStateMutex.Unlock()
 
-		log.Printf("[Commit] Processing commit for bead %s (agent %s)", req.BeadID, req.AgentID)
+		commitLog.Info("Processing commit", "bead_id", req.BeadID, "agent_id", req.AgentID)
 
 		// Signal that lock is acquired (requester can proceed with commit)
 		req.ResultCh <- nil
@@ -212,8 +297,8 @@ func (d *Dispatcher) acquireCommitLock(ctx context.Context, beadID, agentID stri
 	if d.commitInProgress != nil {
 		elapsed := time.Since(d.commitInProgress.StartedAt)
 		if elapsed > d.commitLockTimeout {
-			log.Printf("[Commit] WARNING: Previous commit by agent %s timed out after %v, forcibly releasing lock",
-				d.commitInProgress.AgentID, elapsed)
+			commitLog.Warn("Previous commit timed out, forcibly releasing lock",
+				"agent_id", d.commitInProgress.AgentID, "bead_id", d.commitInProgress.BeadID, "elapsed", elapsed)
 			d.commitStateMutex.RUnlock()
 			d.releaseCommitLock()
 		} else {
@@ -233,7 +318,7 @@ func (d *Dispatcher) acquireCommitLock(ctx context.Context, beadID, agentID stri
 
 	select {
 	case d.commitQueue <- req:
-		log.Printf("[Commit] Bead %s queued for commit (agent %s)", beadID, agentID)
+		commitLog.DebugContext(ctx, "Bead queued for commit", "bead_id", beadID, "agent_id", agentID)
 	case <-ctx.Done():
 		return fmt.Errorf("context cancelled while waiting for commit queue")
 	}
@@ -251,8 +336,8 @@ func (d *Dispatcher) acquireCommitLock(ctx context.Context, beadID, agentID stri
 func (d *Dispatcher) releaseCommitLock() {
 	d.commitStateMutex.Lock()
 	if d.commitInProgress != nil {
-		log.Printf("[Commit] Releasing commit lock for bead %s (held for %v)",
-			d.commitInProgress.BeadID, time.Since(d.commitInProgress.StartedAt))
+		commitLog.Debug("Releasing commit lock",
+			"bead_id", d.commitInProgress.BeadID, "held", time.Since(d.commitInProgress.StartedAt))
 		d.commitInProgress = nil
 	}
 	d.commitStateMutex.Unlock()
@@ -263,9 +348,18 @@ func (d *Dispatcher) releaseCommitLock() {
 // DispatchOnce finds at most one ready bead and asks an idle agent to work on it.
 func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*DispatchResult, error) {
 	activeProviders := d.providers.ListActive()
-	log.Printf("[Dispatcher] DispatchOnce called for project=%s, active_providers=%d", projectID, len(activeProviders))
+	dispatchLog.DebugContext(ctx, "DispatchOnce called", "project_id", projectID, "active_providers", len(activeProviders))
+	d.mu.RLock()
+	readOnlyCheck := d.readOnlyCheck
+	d.mu.RUnlock()
+	if readOnlyCheck != nil {
+		if err := readOnlyCheck(); err != nil {
+			d.setStatus(StatusParked, err.Error())
+			return &DispatchResult{Dispatched: false, ProjectID: projectID, Error: err.Error()}, nil
+		}
+	}
 	if len(activeProviders) == 0 {
-		log.Printf("[Dispatcher] Parked - no active providers")
+		dispatchLog.DebugContext(ctx, "Parked - no active providers")
 		d.setStatus(StatusParked, "no active providers registered")
 		return &DispatchResult{Dispatched: false, ProjectID: projectID}, nil
 	}
@@ -328,20 +422,10 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		}
 	}
 
-	log.Printf("[Dispatcher] GetReadyBeads returned %d beads for project %s", len(ready), projectID)
+	dispatchLog.DebugContext(ctx, "Ready beads", "project_id", projectID, "count", len(ready))
 
-	sort.SliceStable(ready, func(i, j int) bool {
-		if ready[i] == nil {
-			return false
-		}
-		if ready[j] == nil {
-			return true
-		}
-		if ready[i].Priority != ready[j].Priority {
-			return ready[i].Priority < ready[j].Priority
-		}
-		return ready[i].UpdatedAt.After(ready[j].UpdatedAt)
-	})
+	policy := d.getQueuePolicy()
+	policy.orderReady(ready, time.Now())
 
 	// Only auto-dispatch non-P0 task/epic beads.
 	idleAgents := d.agents.GetIdleAgentsByProject(projectID)
@@ -363,8 +447,8 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 			if len(activeProviders) > 0 {
 				best := activeProviders[0]
 				candidateAgent.ProviderID = best.Config.ID
-				log.Printf("[Dispatcher] Auto-assigned default provider %s (score=%.0f, latency=%dms) to agent %s",
-					best.Config.ID, best.Config.CapabilityScore, best.Config.LastHeartbeatLatencyMs, candidateAgent.Name)
+				dispatchLog.InfoContext(ctx, "Auto-assigned default provider", "provider_id", best.Config.ID,
+					"score", best.Config.CapabilityScore, "latency_ms", best.Config.LastHeartbeatLatencyMs, "agent", candidateAgent.Name)
 			} else {
 				continue
 			}
@@ -372,11 +456,11 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		// Promote paused agents to idle now that they have a provider.
 		if candidateAgent.Status == "paused" {
 			candidateAgent.Status = "idle"
-			log.Printf("[Dispatcher] Promoted agent %s from paused to idle", candidateAgent.Name)
+			dispatchLog.InfoContext(ctx, "Promoted agent from paused to idle", "agent", candidateAgent.Name)
 		}
 		filteredAgents = append(filteredAgents, candidateAgent)
 	}
-	idleAgents = filteredAgents
+	idleAgents = withinRoleCapacity(filteredAgents, d.agents.ListAgents(), policy.RoleCapacity)
 	idleByID := make(map[string]*models.Agent, len(idleAgents))
 	for _, a := range idleAgents {
 		if a != nil {
@@ -397,20 +481,20 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		// These should be handled manually or escalated to CEO, not auto-assigned to agents
 		if d.hasTag(b, "requires-human-config") {
 			skippedReasons["requires_human_config"]++
-			log.Printf("[Dispatcher] Skipping bead %s: requires human configuration", b.ID)
+			dispatchLog.DebugContext(ctx, "Skipping bead: requires human configuration", "bead_id", b.ID)
 			continue
 		}
 
 		// Check if this is an auto-filed bug that needs routing
 		if routeInfo := d.autoBugRouter.AnalyzeBugForRouting(b); routeInfo.ShouldRoute {
-			log.Printf("[Dispatcher] Auto-bug detected: %s - routing to %s (%s)", b.ID, routeInfo.PersonaHint, routeInfo.RoutingReason)
+			dispatchLog.InfoContext(ctx, "Auto-bug detected", "bead_id", b.ID, "persona_hint", routeInfo.PersonaHint, "reason", routeInfo.RoutingReason)
 
 			// Update the bead with persona hint in title
 			updates := map[string]interface{}{
 				"title": routeInfo.UpdatedTitle,
 			}
 			if err := d.beads.UpdateBead(b.ID, updates); err != nil {
-				log.Printf("[Dispatcher] Failed to update bead %s with persona hint: %v", b.ID, err)
+				dispatchLog.WarnContext(ctx, "Failed to update bead with persona hint", "bead_id", b.ID, "error", err)
 			} else {
 				// Refresh the bead to get updated title
 				b.Title = routeInfo.UpdatedTitle
@@ -434,7 +518,7 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 				b.Context["redispatch_requested"] = "true"
 				b.Context["redispatch_requested_at"] = time.Now().UTC().Format(time.RFC3339)
 				if err := d.beads.UpdateBead(b.ID, map[string]interface{}{"context": b.Context}); err != nil {
-					log.Printf("[Dispatcher] Failed to auto-enable redispatch for bead %s: %v", b.ID, err)
+					dispatchLog.WarnContext(ctx, "Failed to auto-enable redispatch", "bead_id", b.ID, "error", err)
 				}
 			}
 		}
@@ -462,16 +546,16 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 
 			if !stuck {
 				// Making progress - allow to continue beyond hop limit
-				log.Printf("[Dispatcher] Bead %s has %d dispatches but is making progress, allowing to continue. Progress: %s",
-					b.ID, dispatchCount, d.loopDetector.GetProgressSummary(b))
+				dispatchLog.InfoContext(ctx, "Bead has many dispatches but is making progress, allowing to continue",
+					"bead_id", b.ID, "dispatch_count", dispatchCount, "progress", d.loopDetector.GetProgressSummary(b))
 				skippedReasons["dispatch_limit_but_progressing"]++
 				// Don't continue - allow this bead to be dispatched
 			} else {
 				// Ralph auto-block: stuck in loop — block autonomously instead of CEO escalation
 				reason := fmt.Sprintf("dispatch_count=%d exceeded max_hops=%d, stuck in loop: %s",
 					dispatchCount, maxHops, loopReason)
-				log.Printf("[Ralph] Bead %s is stuck after %d dispatches, auto-blocking: %s",
-					b.ID, dispatchCount, loopReason)
+				ralphLog.WarnContext(ctx, "Bead is stuck, auto-blocking",
+					"bead_id", b.ID, "dispatch_count", dispatchCount, "reason", loopReason)
 
 				progressSummary := d.loopDetector.GetProgressSummary(b)
 
@@ -479,8 +563,8 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 				revertStatus := "not_attempted"
 				firstSHA, _, commitCount := d.loopDetector.GetAgentCommitRange(b)
 				if firstSHA != "" && commitCount > 0 {
-					log.Printf("[Ralph] Attempting auto-revert of %d agent commits for bead %s (from %s)",
-						commitCount, b.ID, firstSHA)
+					ralphLog.InfoContext(ctx, "Attempting auto-revert of agent commits",
+						"bead_id", b.ID, "commits", commitCount, "from", firstSHA)
 					// Record intent — actual revert requires git.GitService which
 					// is project-scoped. The revert metadata tells the next handler
 					// (or human) exactly what to revert.
@@ -506,9 +590,16 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 					"context":     ctxUpdates,
 				}
 				if err := d.beads.UpdateBead(b.ID, updates); err != nil {
-					log.Printf("[Ralph] Failed to block bead %s: %v", b.ID, err)
-				} else if triageAgent != "" {
-					log.Printf("[Ralph] Blocked bead %s reassigned to triage agent %s", b.ID, triageAgent)
+					ralphLog.ErrorContext(ctx, "Failed to block bead", "bead_id", b.ID, "error", err)
+				} else {
+					if triageAgent != "" {
+						ralphLog.InfoContext(ctx, "Blocked bead reassigned to triage agent", "bead_id", b.ID, "triage_agent", triageAgent)
+					}
+					if d.experiments != nil {
+						if err := d.experiments.Conclude(ctx, b.ID, false); err != nil {
+							ralphLog.WarnContext(ctx, "Failed to record experiment outcome", "bead_id", b.ID, "error", err)
+						}
+					}
 				}
 
 				if d.eventBus != nil {
@@ -526,7 +617,7 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		}
 
 		if dispatchCount >= maxHops-1 {
-			log.Printf("[Dispatcher] WARNING: Bead %s has been dispatched %d times", b.ID, dispatchCount)
+			dispatchLog.WarnContext(ctx, "Bead dispatched many times", "bead_id", b.ID, "dispatch_count", dispatchCount)
 		}
 
 		// Skip beads that recently failed — cooldown prevents re-dispatching
@@ -569,12 +660,17 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		if d.workflowEngine != nil {
 			execution, err := d.ensureBeadHasWorkflow(ctx, b)
 			if err != nil {
-				log.Printf("[Workflow] Error ensuring workflow for bead %s: %v", b.ID, err)
+				workflowLog.ErrorContext(ctx, "Error ensuring workflow", "bead_id", b.ID, "error", err)
 			} else if execution != nil {
 				// Check for timeout before processing
 				if !d.workflowEngine.IsNodeReady(execution) {
 					skippedReasons["workflow_node_not_ready"]++
-					log.Printf("[Workflow] Bead %s workflow node not ready (may have timed out)", b.ID)
+					workflowLog.DebugContext(ctx, "Workflow node not ready (may have timed out)", "bead_id", b.ID)
+					continue
+				}
+
+				if d.startReview(ctx, b, execution) {
+					skippedReasons["awaiting_review"]++
 					continue
 				}
 
@@ -586,7 +682,7 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 						if agent != nil && normalizeRoleName(agent.Role) == requiredRoleKey {
 							ag = agent
 							candidate = b
-							log.Printf("[Workflow] Matched bead %s to agent %s by workflow role %s", b.ID, agent.Name, workflowRoleRequired)
+							workflowLog.InfoContext(ctx, "Matched bead to agent by workflow role", "bead_id", b.ID, "agent", agent.Name, "role", workflowRoleRequired)
 							break
 						}
 					}
@@ -596,11 +692,30 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 					}
 
 					// No agent with exact role — fall through to persona/any-agent dispatch
-					log.Printf("[Dispatcher] Bead %s needs workflow role %q but no idle agent has it - falling through to any-agent dispatch", b.ID, workflowRoleRequired)
+					dispatchLog.InfoContext(ctx, "No idle agent has the workflow role, falling through to any-agent dispatch", "bead_id", b.ID, "role", workflowRoleRequired)
 				}
 			}
 		}
 
+		// Label routes send a bead only to agents of their role; it waits
+		// until one is idle
+		if role := d.labelRole(b); role != "" && workflowRoleRequired == "" {
+			roleKey := normalizeRoleName(role)
+			for _, a := range idleAgents {
+				if a != nil && normalizeRoleName(a.Role) == roleKey && (a.ProjectID == b.ProjectID || a.ProjectID == "" || b.ProjectID == "") {
+					ag = a
+					break
+				}
+			}
+			if ag == nil {
+				skippedReasons["label_route_role_not_idle"]++
+				continue
+			}
+			candidate = b
+			dispatchLog.InfoContext(ctx, "Matched bead to agent by label route", "bead_id", b.ID, "agent", ag.Name, "role", role)
+			break
+		}
+
 		// Try persona-based routing first, but fall back to any idle agent
 		personaHint := d.personaMatcher.ExtractPersonaHint(b)
 		if personaHint != "" {
@@ -608,11 +723,11 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 			if matchedAgent != nil {
 				ag = matchedAgent
 				candidate = b
-				log.Printf("[Dispatcher] Matched bead %s to agent %s via persona hint '%s'", b.ID, matchedAgent.Name, personaHint)
+				dispatchLog.InfoContext(ctx, "Matched bead to agent via persona hint", "bead_id", b.ID, "agent", matchedAgent.Name, "persona_hint", personaHint)
 				break
 			}
 			// Persona hint found but no match - log it but fall through to assign any idle agent
-			log.Printf("[Dispatcher] Bead %s has persona hint '%s' but no exact match - will assign to any idle agent", b.ID, personaHint)
+			dispatchLog.InfoContext(ctx, "No exact persona match, will assign to any idle agent", "bead_id", b.ID, "persona_hint", personaHint)
 		}
 
 		// Pick an idle agent for this bead's project.
@@ -637,18 +752,18 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 			skippedReasons["no_idle_agents_for_project"]++
 			continue
 		}
-		log.Printf("[Dispatcher] Assigning bead %s (project %s) to agent %s", b.ID, b.ProjectID, matchedAgent.Name)
+		dispatchLog.InfoContext(ctx, "Assigning bead", "bead_id", b.ID, "project_id", b.ProjectID, "agent", matchedAgent.Name)
 		ag = matchedAgent
 		candidate = b
 		break
 	}
 
 	if len(skippedReasons) > 0 {
-		log.Printf("[Dispatcher] Skipped beads: %+v", skippedReasons)
+		dispatchLog.DebugContext(ctx, "Skipped beads", "reasons", skippedReasons)
 	}
 
 	if candidate == nil {
-		log.Printf("[Dispatcher] No dispatchable beads found (ready: %d, idle agents: %d)", len(ready), len(idleAgents))
+		dispatchLog.DebugContext(ctx, "No dispatchable beads found", "ready", len(ready), "idle_agents", len(idleAgents))
 		d.setStatus(StatusParked, "no dispatchable beads")
 		return &DispatchResult{Dispatched: false, ProjectID: projectID}, nil
 	}
@@ -662,6 +777,11 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		return &DispatchResult{Dispatched: false, ProjectID: selectedProjectID}, nil
 	}
 
+	// Lines logged from here on, including the agent's, name the bead,
+	// agent and project
+	ctx = logging.WithFields(ctx, logging.FieldProjectID, selectedProjectID,
+		logging.FieldBeadID, candidate.ID, logging.FieldAgentID, ag.ID)
+
 	// Estimate task complexity for smart provider routing
 	complexity := d.estimateBeadComplexity(candidate)
 
@@ -673,15 +793,30 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 			best := activeProviders[0]
 			prevProvider := ag.ProviderID
 			ag.ProviderID = best.Config.ID
-			log.Printf("[Dispatcher] Selected provider %s (params=%.0fB, score=%.0f) for %s complexity task %s (prev=%s)",
-				best.Config.ID, best.Config.ModelParamsB, best.Config.CapabilityScore,
-				complexity.String(), candidate.ID, prevProvider)
+			dispatchLog.InfoContext(ctx, "Selected provider for task complexity", "provider_id", best.Config.ID,
+				"params_b", best.Config.ModelParamsB, "score", best.Config.CapabilityScore,
+				"complexity", complexity.String(), "previous", prevProvider)
 		} else if ag.ProviderID == "" {
 			d.setStatus(StatusParked, "no active providers available")
 			return &DispatchResult{Dispatched: false, ProjectID: selectedProjectID, AgentID: ag.ID}, nil
 		}
 	}
 
+	// Role preferences take precedence over complexity routing
+	if d.roles != nil {
+		if role := d.roles.RoleForAgent(ag); role != nil {
+			if p := preferredProvider(d.providers.ListActive(), role); p != nil && p.Config.ID != ag.ProviderID {
+				dispatchLog.InfoContext(ctx, "Selected provider for agent role",
+					"provider_id", p.Config.ID, "role", role.Name, "agent", ag.Name, "previous", ag.ProviderID)
+				ag.ProviderID = p.Config.ID
+			}
+		}
+	}
+
+	// A bead in an experiment goes to its variant's provider ahead of every
+	// other preference, or the comparison would measure the routing instead
+	exp, variant := d.experimentVariant(ctx, candidate, ag)
+
 	// Ensure bead is claimed/assigned.
 	if candidate.AssignedTo == "" {
 		if err := d.beads.ClaimBead(candidate.ID, ag.ID); err != nil {
@@ -710,20 +845,28 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 	dispatchCount++
 
 	// Update bead context with incremented dispatch count
+	countContext := map[string]string{
+		"dispatch_count": fmt.Sprintf("%d", dispatchCount),
+	}
+	if _, pinned := pinnedAt(candidate); pinned {
+		countContext[pinnedAtKey] = ""
+	}
+	if variant != nil {
+		countContext["experiment_id"] = exp.ID
+		countContext["experiment_variant"] = variant.Name
+	}
 	countUpdates := map[string]interface{}{
-		"context": map[string]string{
-			"dispatch_count": fmt.Sprintf("%d", dispatchCount),
-		},
+		"context": countContext,
 	}
 	if err := d.beads.UpdateBead(candidate.ID, countUpdates); err != nil {
-		log.Printf("[Dispatcher] WARNING: Failed to update dispatch count for bead %s: %v", candidate.ID, err)
+		dispatchLog.WarnContext(ctx, "Failed to update dispatch count", "error", err)
 		// Don't fail dispatch on this error - just log it
 	}
-	log.Printf("[Dispatcher] Bead %s dispatch count: %d", candidate.ID, dispatchCount)
+	dispatchLog.DebugContext(ctx, "Dispatch count", "dispatch_count", dispatchCount)
 
 	// FIX #7: Log errors instead of silently discarding them
 	if err := d.agents.AssignBead(ag.ID, candidate.ID); err != nil {
-		log.Printf("[Dispatcher] CRITICAL: Failed to assign bead %s to agent %s: %v", candidate.ID, ag.ID, err)
+		dispatchLog.ErrorContext(ctx, "Failed to assign bead to agent", "error", err)
 		// Continue anyway - the task will still be submitted to the worker
 	}
 	observability.Info("dispatch.assign", map[string]interface{}{
@@ -734,10 +877,10 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 	})
 	if d.eventBus != nil {
 		if err := d.eventBus.PublishBeadEvent(eventbus.EventTypeBeadAssigned, candidate.ID, selectedProjectID, map[string]interface{}{"assigned_to": ag.ID}); err != nil {
-			log.Printf("[Dispatcher] Warning: Failed to publish bead assigned event for %s: %v", candidate.ID, err)
+			dispatchLog.WarnContext(ctx, "Failed to publish bead assigned event", "error", err)
 		}
 		if err := d.eventBus.PublishBeadEvent(eventbus.EventTypeBeadStatusChange, candidate.ID, selectedProjectID, map[string]interface{}{"status": string(models.BeadStatusInProgress)}); err != nil {
-			log.Printf("[Dispatcher] Warning: Failed to publish bead status change event for %s: %v", candidate.ID, err)
+			dispatchLog.WarnContext(ctx, "Failed to publish bead status change event", "error", err)
 		}
 	}
 
@@ -749,11 +892,11 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		var err error
 		conversationSession, err = d.getOrCreateConversationSession(candidate, selectedProjectID)
 		if err != nil {
-			log.Printf("[Dispatcher] Warning: Failed to get/create conversation session for bead %s: %v", candidate.ID, err)
+			dispatchLog.WarnContext(ctx, "Failed to get or create conversation session", "error", err)
 			// Continue without conversation session (falls back to single-shot mode)
 		} else if conversationSession != nil {
-			log.Printf("[Dispatcher] Using conversation session %s for bead %s (messages: %d)",
-				conversationSession.SessionID, candidate.ID, len(conversationSession.Messages))
+			dispatchLog.DebugContext(ctx, "Using conversation session",
+				"session_id", conversationSession.SessionID, "messages", len(conversationSession.Messages))
 		}
 	}
 
@@ -764,6 +907,21 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		BeadID:              candidate.ID,
 		ProjectID:           selectedProjectID,
 		ConversationSession: conversationSession,
+		Workflow:            d.WorkflowState(candidate.ID),
+	}
+	if sp := beadSubproject(candidate, proj); sp != nil {
+		task.Subproject = sp.Name
+	}
+	if variant != nil && variant.Instructions != "" {
+		task.Context += "\n\n## Instructions\n\n" + variant.Instructions
+	}
+	d.mu.RLock()
+	repoMaps := d.repoMaps
+	d.mu.RUnlock()
+	if repoMaps != nil {
+		if block := repoMaps.RepoMapBlock(ctx, selectedProjectID); block != "" {
+			task.Context += "\n\n" + block
+		}
 	}
 
 	d.setStatus(StatusActive, fmt.Sprintf("dispatching %s", candidate.ID))
@@ -783,17 +941,18 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 				if err == nil && node != nil && node.NodeType == workflow.NodeTypeCommit {
 					// Acquire commit lock before executing
 					if err := d.acquireCommitLock(ctx, candidate.ID, ag.ID); err != nil {
-						log.Printf("[Commit] Failed to acquire commit lock for bead %s: %v", candidate.ID, err)
+						commitLog.WarnContext(ctx, "Failed to acquire commit lock", "error", err)
 						// Continue without lock (fallback behavior)
 					} else {
 						defer d.releaseCommitLock()
-						log.Printf("[Commit] Acquired commit lock for bead %s (agent %s)", candidate.ID, ag.ID)
+						commitLog.InfoContext(ctx, "Acquired commit lock")
 					}
 				}
 			}
 		}
 
-		result, execErr := d.agents.ExecuteTask(ctx, ag.ID, task)
+		// The bead's priority orders its LLM calls if the provider is rate limited
+		result, execErr := d.agents.ExecuteTask(provider.WithPriority(ctx, int(candidate.Priority)), ag.ID, task)
 	if execErr != nil {
 		d.setStatus(StatusParked, "execution failed")
 		observability.Error("dispatch.execute", map[string]interface{}{
@@ -809,7 +968,7 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		shouldRedispatch := "true"
 		if candidate.Context != nil && candidate.Context["terminal_reason"] == "max_iterations" {
 			shouldRedispatch = "false"
-			log.Printf("[Dispatcher] Bead %s previously hit max_iterations, not redispatching after error", candidate.ID)
+			dispatchLog.InfoContext(ctx, "Bead previously hit max_iterations, not redispatching after error")
 		}
 
 		ctxUpdates := map[string]string{
@@ -831,10 +990,10 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 			updates["priority"] = models.BeadPriorityP0
 			updates["status"] = models.BeadStatusOpen
 			updates["assigned_to"] = triageAgent
-			log.Printf("[Dispatcher] Loop detected for bead %s, reassigning to triage agent %s", candidate.ID, triageAgent)
+			dispatchLog.WarnContext(ctx, "Loop detected, reassigning to triage agent", "triage_agent", triageAgent)
 		}
 		if err := d.beads.UpdateBead(candidate.ID, updates); err != nil {
-			log.Printf("[Dispatcher] CRITICAL: Failed to update bead %s with context/loop detection: %v", candidate.ID, err)
+			dispatchLog.ErrorContext(ctx, "Failed to update bead with context/loop detection", "error", err)
 		}
 		if d.eventBus != nil {
 			status := string(models.BeadStatusInProgress)
@@ -842,7 +1001,7 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 				status = string(models.BeadStatusOpen)
 			}
 			if err := d.eventBus.PublishBeadEvent(eventbus.EventTypeBeadStatusChange, candidate.ID, selectedProjectID, map[string]interface{}{"status": status}); err != nil {
-				log.Printf("[Dispatcher] Warning: Failed to publish bead status change event for %s: %v", candidate.ID, err)
+				dispatchLog.WarnContext(ctx, "Failed to publish bead status change event", "error", err)
 			}
 		}
 
@@ -852,9 +1011,9 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 			if err == nil && execution != nil {
 				// Report failure to workflow
 				if err := d.workflowEngine.FailNode(execution.ID, ag.ID, execErr.Error()); err != nil {
-					log.Printf("[Workflow] Failed to report failure to workflow for bead %s: %v", candidate.ID, err)
+					workflowLog.WarnContext(ctx, "Failed to report failure to workflow", "error", err)
 				} else {
-					log.Printf("[Workflow] Reported failure to workflow for bead %s", candidate.ID)
+					workflowLog.InfoContext(ctx, "Reported failure to workflow")
 				}
 			}
 		}
@@ -890,7 +1049,7 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		if result.LoopTerminalReason == "max_iterations" {
 			ctxUpdates["redispatch_requested"] = "false"
 			ctxUpdates["max_iterations_reached_at"] = time.Now().UTC().Format(time.RFC3339)
-			log.Printf("[Dispatcher] Bead %s hit max_iterations, disabling redispatch to prevent infinite loop", candidate.ID)
+			dispatchLog.WarnContext(ctx, "Bead hit max_iterations, disabling redispatch to prevent infinite loop")
 		}
 
 		// On failure, set cooldown to prevent re-dispatching the same bead
@@ -898,6 +1057,11 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		switch result.LoopTerminalReason {
 		case "parse_failures", "validation_failures", "error":
 			ctxUpdates["last_failed_at"] = time.Now().UTC().Format(time.RFC3339)
+		case "remediation_failed":
+			// The worker escalated the bead with its failure dossier;
+			// redispatching would repeat the same failed repairs
+			ctxUpdates["redispatch_requested"] = "false"
+			ctxUpdates["last_failed_at"] = time.Now().UTC().Format(time.RFC3339)
 		}
 	}
 
@@ -915,10 +1079,10 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 		updates["priority"] = models.BeadPriorityP0
 		updates["status"] = models.BeadStatusOpen
 		updates["assigned_to"] = triageAgent
-		log.Printf("[Dispatcher] Task failure loop for bead %s, reassigning to triage agent %s", candidate.ID, triageAgent)
+		dispatchLog.WarnContext(ctx, "Task failure loop, reassigning to triage agent", "triage_agent", triageAgent)
 	}
 	if err := d.beads.UpdateBead(candidate.ID, updates); err != nil {
-		log.Printf("[Dispatcher] CRITICAL: Failed to update bead %s after task failure: %v", candidate.ID, err)
+		dispatchLog.ErrorContext(ctx, "Failed to update bead after task failure", "error", err)
 	}
 	if d.eventBus != nil {
 		status := string(models.BeadStatusInProgress)
@@ -926,7 +1090,7 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 			status = string(models.BeadStatusOpen)
 		}
 		if err := d.eventBus.PublishBeadEvent(eventbus.EventTypeBeadStatusChange, candidate.ID, selectedProjectID, map[string]interface{}{"status": status}); err != nil {
-			log.Printf("[Dispatcher] Warning: Failed to publish bead status change event for %s: %v", candidate.ID, err)
+			dispatchLog.WarnContext(ctx, "Failed to publish bead status change event", "error", err)
 		}
 	}
 
@@ -941,22 +1105,22 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 				"tokens_used": fmt.Sprintf("%d", result.TokensUsed),
 			}
 			if err := d.workflowEngine.AdvanceWorkflow(execution.ID, workflow.EdgeConditionSuccess, ag.ID, resultData); err != nil {
-				log.Printf("[Workflow] Failed to advance workflow for bead %s: %v", candidate.ID, err)
+				workflowLog.ErrorContext(ctx, "Failed to advance workflow", "error", err)
 			} else {
 				// Get updated execution to check status
 				updatedExec, _ := d.workflowEngine.GetDatabase().GetWorkflowExecution(execution.ID)
 				if updatedExec != nil {
-					log.Printf("[Workflow] Advanced workflow for bead %s: status=%s, node=%s, cycle=%d",
-						candidate.ID, updatedExec.Status, updatedExec.CurrentNodeKey, updatedExec.CycleCount)
+					workflowLog.InfoContext(ctx, "Advanced workflow",
+						"status", updatedExec.Status, "node", updatedExec.CurrentNodeKey, "cycle", updatedExec.CycleCount)
 
 					// Check if workflow was escalated and needs CEO bead
 					if updatedExec.Status == workflow.ExecutionStatusEscalated && candidate.Context["escalation_bead_created"] != "true" {
-						log.Printf("[Workflow] Creating CEO escalation bead for workflow %s (bead %s)", updatedExec.ID, candidate.ID)
+						workflowLog.InfoContext(ctx, "Creating CEO escalation bead", "workflow_id", updatedExec.ID)
 
 						// Get escalation info from workflow engine
 						title, description, err := d.workflowEngine.GetEscalationInfo(updatedExec)
 						if err != nil {
-							log.Printf("[Workflow] Failed to get escalation info for workflow %s: %v", updatedExec.ID, err)
+							workflowLog.ErrorContext(ctx, "Failed to get escalation info", "workflow_id", updatedExec.ID, "error", err)
 						} else {
 							// Create CEO escalation bead
 							createdBead, err := d.beads.CreateBead(
@@ -967,9 +1131,9 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 								candidate.ProjectID,
 							)
 							if err != nil {
-								log.Printf("[Workflow] Failed to create CEO escalation bead: %v", err)
+								workflowLog.ErrorContext(ctx, "Failed to create CEO escalation bead", "error", err)
 							} else {
-								log.Printf("[Workflow] Created CEO escalation bead %s for workflow %s", createdBead.ID, updatedExec.ID)
+								workflowLog.InfoContext(ctx, "Created CEO escalation bead", "escalation_bead_id", createdBead.ID, "workflow_id", updatedExec.ID)
 
 								// Update the escalation bead with tags and context
 								escalationBeadUpdates := map[string]interface{}{
@@ -982,7 +1146,7 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 									},
 								}
 								if err := d.beads.UpdateBead(createdBead.ID, escalationBeadUpdates); err != nil {
-									log.Printf("[Workflow] Failed to update escalation bead with tags and context: %v", err)
+									workflowLog.WarnContext(ctx, "Failed to update escalation bead with tags and context", "error", err)
 								}
 
 								// Mark original bead as having escalation bead created
@@ -993,7 +1157,7 @@ func (d *Dispatcher) DispatchOnce(ctx context.Context, projectID string) (*Dispa
 									},
 								}
 								if err := d.beads.UpdateBead(candidate.ID, originalUpdates); err != nil {
-									log.Printf("[Workflow] Failed to update original bead with escalation info: %v", err)
+									workflowLog.WarnContext(ctx, "Failed to update original bead with escalation info", "error", err)
 								}
 							}
 						}
@@ -1077,12 +1241,12 @@ func (d *Dispatcher) getOrCreateConversationSession(bead *models.Bead, projectID
 		if err == nil && session != nil {
 			// Check if session is expired
 			if !session.IsExpired() {
-				log.Printf("[Dispatcher] Resuming conversation session %s for bead %s", sessionID, bead.ID)
+				dispatchLog.Info("Resuming conversation session", "session_id", sessionID, "bead_id", bead.ID)
 				return session, nil
 			}
-			log.Printf("[Dispatcher] Conversation session %s expired, creating new session", sessionID)
+			dispatchLog.Info("Conversation session expired, creating new session", "session_id", sessionID, "bead_id", bead.ID)
 		} else {
-			log.Printf("[Dispatcher] Failed to load conversation session %s: %v", sessionID, err)
+			dispatchLog.Warn("Failed to load conversation session", "session_id", sessionID, "bead_id", bead.ID, "error", err)
 		}
 	}
 
@@ -1125,12 +1289,12 @@ func (d *Dispatcher) getOrCreateConversationSession(bead *models.Bead, projectID
 			"context": bead.Context,
 		}
 		if err := d.beads.UpdateBead(bead.ID, updates); err != nil {
-			log.Printf("[Dispatcher] Warning: Failed to update bead %s with session ID: %v", bead.ID, err)
+			dispatchLog.Warn("Failed to update bead with session ID", "bead_id", bead.ID, "error", err)
 			// Don't fail - session is created, just not stored in bead yet
 		}
 	}
 
-	log.Printf("[Dispatcher] Created new conversation session %s for bead %s", newSessionID, bead.ID)
+	dispatchLog.Info("Created new conversation session", "session_id", newSessionID, "bead_id", bead.ID)
 	return session, nil
 }
 
@@ -1182,10 +1346,17 @@ func buildBeadContext(b *models.Bead, p *models.Project) string {
 
 	if len(b.Context) > 0 {
 		for k, v := range b.Context {
+			if v == "" {
+				continue
+			}
 			sb.WriteString(fmt.Sprintf("- %s: %s\n", k, v))
 		}
 	}
 
+	if p != nil && len(p.Subprojects) > 0 {
+		writeSubprojectContext(&sb, p, beadSubproject(b, p))
+	}
+
 	// Directive: act, don't plan
 	sb.WriteString(`
 ## Instructions
@@ -1209,6 +1380,34 @@ CRITICAL RULES:
 	return sb.String()
 }
 
+// beadSubproject returns the monorepo subproject a bead is scoped to through
+// its "subproject" context key, or nil.
+func beadSubproject(b *models.Bead, p *models.Project) *models.Subproject {
+	if b == nil || p == nil || b.Context == nil {
+		return nil
+	}
+	return p.FindSubproject(b.Context["subproject"])
+}
+
+// writeSubprojectContext lists a monorepo's subprojects and, when the bead is
+// scoped to one, tells the agent to stay inside it.
+func writeSubprojectContext(sb *strings.Builder, p *models.Project, current *models.Subproject) {
+	sb.WriteString("\n## Subprojects\n\n")
+	for _, sp := range p.Subprojects {
+		sb.WriteString(fmt.Sprintf("- %s (%s/)", sp.Name, sp.Path))
+		if sp.BuildCommand != "" {
+			sb.WriteString(fmt.Sprintf(" build: `%s`", sp.BuildCommand))
+		}
+		if sp.TestCommand != "" {
+			sb.WriteString(fmt.Sprintf(" test: `%s`", sp.TestCommand))
+		}
+		sb.WriteString("\n")
+	}
+	if current != nil {
+		sb.WriteString(fmt.Sprintf("\nThis bead is scoped to subproject %s. Keep changes under %s/; build_project, run_tests, run_linter and search_text run against it by default.\n", current.Name, current.Path))
+	}
+}
+
 // readProjectFile reads a file from the project work directory, truncated to maxLen.
 func readProjectFile(workDir, filename string, maxLen int) string {
 	path := filepath.Join(workDir, filename)
@@ -1223,6 +1422,145 @@ func readProjectFile(workDir, filename string, maxLen int) string {
 	return content
 }
 
+// preferredProvider returns the active provider a role prefers: the first
+// listed preferred provider that is active, else one serving the preferred
+// model. Returns nil when the role has no active preference.
+func preferredProvider(active []*provider.RegisteredProvider, role *models.AgentRole) *provider.RegisteredProvider {
+	for _, id := range role.PreferredProviders {
+		for _, p := range active {
+			if p.Config != nil && p.Config.ID == id {
+				return p
+			}
+		}
+	}
+	if role.PreferredModel != "" {
+		for _, p := range active {
+			if p.Config != nil && strings.EqualFold(p.Config.Model, role.PreferredModel) {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// experimentVariant routes a bead in an experiment to its variant's
+// provider and returns the experiment and variant, or nils when the bead is
+// in none. A bead
+// whose variant's provider isn't active is excluded from the experiment and
+// routed normally.
+func (d *Dispatcher) experimentVariant(ctx context.Context, bead *models.Bead, ag *models.Agent) (*experiment.Experiment, *experiment.Variant) {
+	d.mu.RLock()
+	experiments := d.experiments
+	d.mu.RUnlock()
+	if experiments == nil {
+		return nil, nil
+	}
+	exp, v := experiments.Assign(bead)
+	if v == nil {
+		return nil, nil
+	}
+	if v.ProviderID == "" && v.Model == "" {
+		return exp, v
+	}
+	p := preferredProvider(d.providers.ListActive(), &models.AgentRole{PreferredModel: v.Model})
+	if v.ProviderID != "" {
+		p = nil
+		if d.providers.IsActive(v.ProviderID) {
+			p, _ = d.providers.Get(v.ProviderID)
+		}
+	}
+	if p == nil || p.Config == nil {
+		dispatchLog.WarnContext(ctx, "Experiment variant has no active provider, routing normally",
+			"experiment_id", exp.ID, "variant", v.Name, "provider_id", v.ProviderID, "model", v.Model)
+		if err := experiments.Exclude(bead.ID); err != nil {
+			dispatchLog.WarnContext(ctx, "Failed to exclude bead from experiment", "experiment_id", exp.ID, "error", err)
+		}
+		return nil, nil
+	}
+	if p.Config.ID != ag.ProviderID {
+		dispatchLog.InfoContext(ctx, "Selected provider for experiment variant",
+			"provider_id", p.Config.ID, "experiment_id", exp.ID, "variant", v.Name, "previous", ag.ProviderID)
+		ag.ProviderID = p.Config.ID
+	}
+	return exp, v
+}
+
+// WorkflowState describes the bead's position in its workflow for the agent
+// prompt, or "" when it has none
+func (d *Dispatcher) WorkflowState(beadID string) string {
+	if d.workflowEngine == nil {
+		return ""
+	}
+	execution, err := d.workflowEngine.GetDatabase().GetWorkflowExecutionByBeadID(beadID)
+	if err != nil || execution == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Workflow %s: %s", execution.WorkflowID, execution.Status)
+	if execution.CycleCount > 0 {
+		fmt.Fprintf(&sb, " (cycle %d)", execution.CycleCount)
+	}
+	node, err := d.workflowEngine.GetCurrentNode(execution.ID)
+	if err != nil || node == nil {
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "\nCurrent step: %s (%s), %d previous attempts", node.NodeKey, node.NodeType, execution.NodeAttemptCount)
+	if node.MaxAttempts > 0 {
+		fmt.Fprintf(&sb, " of %d allowed", node.MaxAttempts)
+	}
+	if node.Instructions != "" {
+		sb.WriteString("\n\n" + node.Instructions)
+	}
+	return sb.String()
+}
+
+// startReview hands a bead sitting at a review node to the review pipeline,
+// at most once per reviewRetryInterval. It returns true when the bead is the
+// pipeline's to handle and must not be dispatched to an agent.
+func (d *Dispatcher) startReview(ctx context.Context, bead *models.Bead, execution *workflow.WorkflowExecution) bool {
+	d.mu.Lock()
+	reviewer := d.reviewer
+	d.mu.Unlock()
+	if reviewer == nil || d.workflowEngine == nil {
+		return false
+	}
+	node, err := d.workflowEngine.GetCurrentNode(execution.ID)
+	if err != nil || node == nil || node.NodeType != workflow.NodeTypeReview {
+		return false
+	}
+
+	d.mu.Lock()
+	if last, ok := d.reviewAttempts[bead.ID]; ok && time.Since(last) < reviewRetryInterval {
+		d.mu.Unlock()
+		return true
+	}
+	d.reviewAttempts[bead.ID] = time.Now()
+	d.mu.Unlock()
+
+	reviewLog.InfoContext(ctx, "Starting review", "bead_id", bead.ID, "workflow_id", execution.ID)
+	go func() {
+		report, err := reviewer.Review(ctx, bead, execution.ID)
+		if err != nil {
+			reviewLog.ErrorContext(ctx, "Review failed", "bead_id", bead.ID, "error", err)
+			return
+		}
+		d.mu.Lock()
+		delete(d.reviewAttempts, bead.ID)
+		d.mu.Unlock()
+		if d.eventBus != nil {
+			if err := d.eventBus.PublishBeadEvent(eventbus.EventTypeBeadStatusChange, bead.ID, bead.ProjectID, map[string]interface{}{
+				"status":         string(bead.Status),
+				"review_verdict": report.Verdict,
+				"review_result":  string(report.Condition()),
+			}); err != nil {
+				reviewLog.WarnContext(ctx, "Failed to publish review event", "bead_id", bead.ID, "error", err)
+			}
+		}
+	}()
+	return true
+}
+
 // ensureBeadHasWorkflow checks if a bead has a workflow execution, and if not, starts one
 func (d *Dispatcher) ensureBeadHasWorkflow(ctx context.Context, bead *models.Bead) (*workflow.WorkflowExecution, error) {
 	if d.workflowEngine == nil {
@@ -1232,7 +1570,7 @@ func (d *Dispatcher) ensureBeadHasWorkflow(ctx context.Context, bead *models.Bea
 	// Check if bead already has a workflow
 	execution, err := d.workflowEngine.GetDatabase().GetWorkflowExecutionByBeadID(bead.ID)
 	if err != nil {
-		log.Printf("[Workflow] Error checking workflow for bead %s: %v", bead.ID, err)
+		workflowLog.ErrorContext(ctx, "Error checking workflow", "bead_id", bead.ID, "error", err)
 		return nil, err
 	}
 
@@ -1265,7 +1603,7 @@ func (d *Dispatcher) ensureBeadHasWorkflow(ctx context.Context, bead *models.Bea
 
 	if isSelfImprovement {
 		workflowType = "self-improvement"
-		log.Printf("[Workflow] Matched bead %s to self-improvement workflow (tags: %v)", bead.ID, bead.Tags)
+		workflowLog.InfoContext(ctx, "Matched bead to self-improvement workflow", "bead_id", bead.ID, "tags", bead.Tags)
 	} else if strings.Contains(title, "feature") || strings.Contains(title, "enhancement") {
 		workflowType = "feature"
 	} else if strings.Contains(title, "ui") || strings.Contains(title, "design") || strings.Contains(title, "css") || strings.Contains(title, "html") {
@@ -1277,18 +1615,18 @@ func (d *Dispatcher) ensureBeadHasWorkflow(ctx context.Context, bead *models.Bea
 	// Get workflow for this type
 	workflows, err := d.workflowEngine.GetDatabase().ListWorkflows(workflowType, bead.ProjectID)
 	if err != nil || len(workflows) == 0 {
-		log.Printf("[Workflow] No workflow found for type %s, bead %s", workflowType, bead.ID)
+		workflowLog.DebugContext(ctx, "No workflow found for type", "type", workflowType, "bead_id", bead.ID)
 		return nil, nil // No workflow available
 	}
 
 	// Start workflow for this bead
 	execution, err = d.workflowEngine.StartWorkflow(bead.ID, workflows[0].ID, bead.ProjectID)
 	if err != nil {
-		log.Printf("[Workflow] Failed to start workflow for bead %s: %v", bead.ID, err)
+		workflowLog.ErrorContext(ctx, "Failed to start workflow", "bead_id", bead.ID, "error", err)
 		return nil, err
 	}
 
-	log.Printf("[Workflow] Started workflow %s for bead %s", workflows[0].Name, bead.ID)
+	workflowLog.InfoContext(ctx, "Started workflow", "workflow", workflows[0].Name, "bead_id", bead.ID)
 	return execution, nil
 }
 
@@ -416,6 +416,24 @@ func TestDispatcher_SetReadinessCheck_WithFunc(t *testing.T) {
 	}
 }
 
+func TestDispatcher_ReadOnlyParks(t *testing.T) {
+	d := NewDispatcher(nil, nil, nil, provider.NewRegistry(), nil)
+	d.SetReadOnlyCheck(func() error {
+		return fmt.Errorf("server is in read-only mode: audit")
+	})
+
+	result, err := d.DispatchOnce(context.Background(), "proj-1")
+	if err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+	if result.Dispatched || result.Error != "server is in read-only mode: audit" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if status := d.GetSystemStatus(); status.State != StatusParked || status.Reason != result.Error {
+		t.Errorf("expected the dispatcher to park with the read-only reason, got %+v", status)
+	}
+}
+
 // --- estimateBeadComplexity edge cases ---
 
 func TestEstimateBeadComplexity_P0Simple(t *testing.T) {
@@ -0,0 +1,55 @@
+package dispatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/experiment"
+	"github.com/jordanhubbard/loom/internal/provider"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestExperimentVariant(t *testing.T) {
+	registry := provider.NewRegistry()
+	for _, cfg := range []*provider.ProviderConfig{
+		{ID: "small", Type: "openai", Model: "small-model", Status: "healthy"},
+		{ID: "large", Type: "openai", Model: "large-model", Status: "healthy"},
+	} {
+		if err := registry.Register(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := NewDispatcher(nil, nil, nil, registry, nil)
+	ag := &models.Agent{ID: "agent-1", ProviderID: "small"}
+	bead := func(id string) *models.Bead { return &models.Bead{ID: id, Type: "bug", CreatedAt: time.Now()} }
+
+	// Without experiments nothing changes
+	if exp, v := d.experimentVariant(context.Background(), bead("bd-0"), ag); exp != nil || v != nil {
+		t.Fatal("expected no variant without experiments")
+	}
+
+	experiments := experiment.NewManager(experiment.Sources{})
+	d.SetExperimenter(experiments)
+	exp, err := experiments.Create(&experiment.Experiment{Name: "larger", Variants: []*experiment.Variant{
+		{Percent: 0, Instructions: "unused"},
+		{Model: "large-model", Instructions: "Write a failing test first.", Percent: 100},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, v := d.experimentVariant(context.Background(), bead("bd-1"), ag)
+	if got != exp || v == nil || v.Name != experiment.VariantB || ag.ProviderID != "large" {
+		t.Fatalf("expected variant B on the large model, got %v on %s", v, ag.ProviderID)
+	}
+
+	// A variant whose provider isn't active drops the bead from the experiment
+	exp.Variants[1].ProviderID = "offline"
+	ag.ProviderID = "small"
+	if _, v := d.experimentVariant(context.Background(), bead("bd-2"), ag); v != nil || ag.ProviderID != "small" {
+		t.Fatalf("expected normal routing, got %v on %s", v, ag.ProviderID)
+	}
+	if _, v := experiments.Assign(bead("bd-2")); v != nil {
+		t.Error("expected the bead to stay out of the experiment")
+	}
+}
@@ -0,0 +1,86 @@
+package dispatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/review"
+	"github.com/jordanhubbard/loom/internal/workflow"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// reviewWorkflowDB serves a single execution for startReview
+type reviewWorkflowDB struct {
+	workflow.Database
+	exec *workflow.WorkflowExecution
+	wf   *workflow.Workflow
+}
+
+func (db *reviewWorkflowDB) GetWorkflowExecution(id string) (*workflow.WorkflowExecution, error) {
+	return db.exec, nil
+}
+
+func (db *reviewWorkflowDB) GetWorkflow(id string) (*workflow.Workflow, error) {
+	return db.wf, nil
+}
+
+type fakeReviewer struct {
+	calls chan string
+}
+
+func (f *fakeReviewer) Review(ctx context.Context, bead *models.Bead, executionID string) (*review.Report, error) {
+	f.calls <- bead.ID
+	return &review.Report{BeadID: bead.ID, Verdict: review.VerdictApprove}, nil
+}
+
+func TestStartReview(t *testing.T) {
+	exec := &workflow.WorkflowExecution{ID: "exec-1", WorkflowID: "wf", CurrentNodeKey: "implement"}
+	db := &reviewWorkflowDB{exec: exec, wf: &workflow.Workflow{ID: "wf", Nodes: []workflow.WorkflowNode{
+		{NodeKey: "implement", NodeType: workflow.NodeTypeTask},
+		{NodeKey: "code_review", NodeType: workflow.NodeTypeReview},
+	}}}
+	d := NewDispatcher(nil, nil, nil, nil, nil)
+	d.SetWorkflowEngine(workflow.NewEngine(db, nil))
+	bead := &models.Bead{ID: "bd-1", ProjectID: "proj"}
+
+	// Without a reviewer review nodes dispatch normally
+	exec.CurrentNodeKey = "code_review"
+	if d.startReview(context.Background(), bead, exec) {
+		t.Fatal("startReview claimed bead without a reviewer")
+	}
+
+	reviewer := &fakeReviewer{calls: make(chan string, 2)}
+	d.SetReviewer(reviewer)
+
+	exec.CurrentNodeKey = "implement"
+	if d.startReview(context.Background(), bead, exec) {
+		t.Error("startReview claimed a bead at a task node")
+	}
+
+	exec.CurrentNodeKey = "code_review"
+	if !d.startReview(context.Background(), bead, exec) {
+		t.Fatal("startReview did not claim a bead at a review node")
+	}
+	select {
+	case id := <-reviewer.calls:
+		if id != "bd-1" {
+			t.Errorf("reviewed %s", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reviewer was not called")
+	}
+
+	// A recent attempt holds the bead back from agents without re-running
+	d.mu.Lock()
+	d.reviewAttempts["bd-1"] = time.Now()
+	d.mu.Unlock()
+	if !d.startReview(context.Background(), bead, exec) {
+		t.Error("startReview released a bead under review")
+	}
+	select {
+	case <-reviewer.calls:
+		t.Error("review re-ran within the retry interval")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,61 @@
+package dispatch
+
+import (
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// LabelRoute sends beads whose labels match Selector to agents of Role
+type LabelRoute struct {
+	Selector beads.LabelSelector `json:"selector"`
+	Role     string              `json:"role"`
+}
+
+// ParseLabelRoutes reads the label routes in the dispatch config
+func ParseLabelRoutes(cfg []config.LabelRouteConfig) ([]LabelRoute, error) {
+	routes := make([]LabelRoute, 0, len(cfg))
+	for _, p := range cfg {
+		if p.Role == "" {
+			return nil, fmt.Errorf("label route %q has no role", p.Selector)
+		}
+		sel, err := beads.ParseLabelSelector(p.Selector)
+		if err != nil {
+			return nil, err
+		}
+		if len(sel) == 0 {
+			return nil, fmt.Errorf("label route to %s has no selector", p.Role)
+		}
+		routes = append(routes, LabelRoute{Selector: sel, Role: p.Role})
+	}
+	return routes, nil
+}
+
+// SetLabelRoutes sets the label routing rules. The first route whose
+// selector matches a bead decides its role.
+func (d *Dispatcher) SetLabelRoutes(routes []LabelRoute) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.labelRoutes = routes
+}
+
+// LabelRoutes returns the label routing rules
+func (d *Dispatcher) LabelRoutes() []LabelRoute {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]LabelRoute(nil), d.labelRoutes...)
+}
+
+// labelRole returns the role a bead's labels route it to, or ""
+func (d *Dispatcher) labelRole(b *models.Bead) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, route := range d.labelRoutes {
+		if route.Selector.Matches(b.Labels) {
+			return route.Role
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package dispatch
+
+import (
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestLabelRoutes(t *testing.T) {
+	routes, err := ParseLabelRoutes([]config.LabelRouteConfig{
+		{Selector: "security", Role: "security-reviewer"},
+		{Selector: "team in (infra,sre)", Role: "devops-engineer"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Dispatcher{}
+	d.SetLabelRoutes(routes)
+
+	tests := []struct {
+		labels map[string]string
+		want   string
+	}{
+		{nil, ""},
+		{map[string]string{"security": ""}, "security-reviewer"},
+		{map[string]string{"security": "", "team": "sre"}, "security-reviewer"},
+		{map[string]string{"team": "sre"}, "devops-engineer"},
+		{map[string]string{"team": "core"}, ""},
+	}
+	for _, tt := range tests {
+		if got := d.labelRole(&models.Bead{Labels: tt.labels}); got != tt.want {
+			t.Errorf("labelRole(%v) = %q, want %q", tt.labels, got, tt.want)
+		}
+	}
+
+	if _, err := ParseLabelRoutes([]config.LabelRouteConfig{{Selector: "security"}}); err == nil {
+		t.Error("expected error for route without a role")
+	}
+	if _, err := ParseLabelRoutes([]config.LabelRouteConfig{{Role: "qa"}}); err == nil {
+		t.Error("expected error for route without a selector")
+	}
+}
@@ -3,12 +3,12 @@ package dispatch
 import (
 	"context"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/features"
 	"github.com/jordanhubbard/loom/internal/memory"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
@@ -18,6 +18,7 @@ import (
 type LessonsProvider struct {
 	db       *database.Database
 	embedder memory.Embedder
+	features features.Gate
 }
 
 // NewLessonsProvider creates a new LessonsProvider backed by the given database.
@@ -39,6 +40,14 @@ func (lp *LessonsProvider) SetEmbedder(e memory.Embedder) {
 	}
 }
 
+// SetFeatures sets the feature flags deciding whether lessons are selected
+// by similarity (semantic_lessons) per project
+func (lp *LessonsProvider) SetFeatures(g features.Gate) {
+	if lp != nil {
+		lp.features = g
+	}
+}
+
 // GetLessonsForPrompt retrieves lessons for a project and formats them as markdown
 // suitable for injection into the system prompt.
 func (lp *LessonsProvider) GetLessonsForPrompt(projectID string) string {
@@ -48,7 +57,7 @@ func (lp *LessonsProvider) GetLessonsForPrompt(projectID string) string {
 
 	lessons, err := lp.db.GetLessonsForProject(projectID, 15, 4000)
 	if err != nil {
-		log.Printf("[LessonsProvider] Failed to get lessons for project %s: %v", projectID, err)
+		lessonsLog.Warn("Failed to get lessons", "project_id", projectID, "error", err)
 		return ""
 	}
 
@@ -82,6 +91,9 @@ func (lp *LessonsProvider) GetRelevantLessons(projectID, taskContext string, top
 	if taskContext == "" || lp.embedder == nil {
 		return lp.GetLessonsForPrompt(projectID)
 	}
+	if lp.features != nil && !lp.features.Enabled(features.SemanticLessons, projectID) {
+		return lp.GetLessonsForPrompt(projectID)
+	}
 
 	if topK <= 0 {
 		topK = 5
@@ -91,7 +103,7 @@ func (lp *LessonsProvider) GetRelevantLessons(projectID, taskContext string, top
 	ctx := context.Background()
 	embeddings, err := lp.embedder.Embed(ctx, []string{taskContext})
 	if err != nil {
-		log.Printf("[LessonsProvider] Embedding failed, falling back to recency: %v", err)
+		lessonsLog.Warn("Embedding failed, falling back to recency", "project_id", projectID, "error", err)
 		return lp.GetLessonsForPrompt(projectID)
 	}
 	if len(embeddings) == 0 || len(embeddings[0]) == 0 {
@@ -103,7 +115,7 @@ func (lp *LessonsProvider) GetRelevantLessons(projectID, taskContext string, top
 	// Search by similarity
 	lessons, err := lp.db.SearchLessonsBySimilarity(projectID, queryEmb, topK)
 	if err != nil {
-		log.Printf("[LessonsProvider] Similarity search failed, falling back to recency: %v", err)
+		lessonsLog.Warn("Similarity search failed, falling back to recency", "project_id", projectID, "error", err)
 		return lp.GetLessonsForPrompt(projectID)
 	}
 
@@ -155,20 +167,20 @@ func (lp *LessonsProvider) RecordLesson(projectID, category, title, detail, bead
 		embeddings, err := lp.embedder.Embed(ctx, []string{text})
 		if err == nil && len(embeddings) > 0 && len(embeddings[0]) > 0 {
 			if err := lp.db.StoreLessonWithEmbedding(lesson, embeddings[0]); err != nil {
-				log.Printf("[LessonsProvider] Failed to record lesson with embedding: %v", err)
+				lessonsLog.Error("Failed to record lesson with embedding", "project_id", projectID, "error", err)
 				return err
 			}
-			log.Printf("[LessonsProvider] Recorded lesson with embedding: [%s] %s", category, title)
+			lessonsLog.Info("Recorded lesson with embedding", "project_id", projectID, "category", category, "title", title)
 			return nil
 		}
 		// Embedding failed — fall through to store without embedding
 	}
 
 	if err := lp.db.CreateLesson(lesson); err != nil {
-		log.Printf("[LessonsProvider] Failed to record lesson: %v", err)
+		lessonsLog.Error("Failed to record lesson", "project_id", projectID, "error", err)
 		return err
 	}
 
-	log.Printf("[LessonsProvider] Recorded lesson: [%s] %s", category, title)
+	lessonsLog.Info("Recorded lesson", "project_id", projectID, "category", category, "title", title)
 	return nil
 }
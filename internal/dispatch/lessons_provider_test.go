@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/features"
 	"github.com/jordanhubbard/loom/internal/memory"
+	"github.com/jordanhubbard/loom/pkg/config"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
@@ -330,6 +332,30 @@ func TestLessonsProvider_GetRelevantLessons_WithEmbeddings(t *testing.T) {
 	}
 }
 
+func TestLessonsProvider_GetRelevantLessons_FlagOff(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	flags, err := features.New(config.FeaturesConfig{Projects: map[string]map[string]bool{"proj-1": {features.SemanticLessons: false}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lp := NewLessonsProvider(db)
+	lp.SetFeatures(flags)
+	if err := lp.RecordLesson("proj-1", "compiler_error", "Missing import", "Always check imports", "bead-1", "agent-1"); err != nil {
+		t.Fatalf("Failed to record lesson: %v", err)
+	}
+
+	// With semantic_lessons off the recency list is used
+	result := lp.GetRelevantLessons("proj-1", "compilation import error", 5)
+	if !strings.Contains(result, "learned from previous work") {
+		t.Errorf("Expected the recency format, got %q", result)
+	}
+}
+
 func TestLessonsProvider_RecordLesson_NilCases(t *testing.T) {
 	// nil provider should be no-op
 	var nilLP *LessonsProvider
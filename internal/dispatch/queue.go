@@ -0,0 +1,278 @@
+package dispatch
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// DefaultAgingInterval is how long a bead waits in the queue to gain one
+// priority level
+const DefaultAgingInterval = 4 * time.Hour
+
+// maxAgingLevels caps how many priority levels waiting can gain, so an old
+// P3 never overtakes a fresh P0
+const maxAgingLevels = 2
+
+// pinnedAtKey is the bead context key holding when a bead was pinned to the
+// head of the queue
+const pinnedAtKey = "dispatch_pinned_at"
+
+// QueuePolicy sets how ready beads are ordered and how much work each role
+// takes on at once
+type QueuePolicy struct {
+	// AgingInterval is how long a bead waits to gain a priority level
+	// (DefaultAgingInterval when 0, no aging when negative)
+	AgingInterval time.Duration
+	// ProjectWeights scale the scores of a project's beads (default 1)
+	ProjectWeights map[string]float64
+	// RoleCapacity caps how many agents of a role work at once; roles
+	// missing or at 0 are unlimited
+	RoleCapacity map[string]int
+}
+
+// QueueEntry is a ready bead's place in the dispatch queue
+type QueueEntry struct {
+	Position     int                 `json:"position"`
+	BeadID       string              `json:"bead_id"`
+	Title        string              `json:"title"`
+	ProjectID    string              `json:"project_id"`
+	Type         string              `json:"type"`
+	Priority     models.BeadPriority `json:"priority"`
+	Score        float64             `json:"score"`
+	WaitingSince time.Time           `json:"waiting_since"`
+	Pinned       bool                `json:"pinned"`
+	PinnedAt     *time.Time          `json:"pinned_at,omitempty"`
+	AssignedTo   string              `json:"assigned_to,omitempty"`
+}
+
+// RoleLoad is how many agents of a role are working against its capacity
+type RoleLoad struct {
+	Role     string `json:"role"`
+	Working  int    `json:"working"`
+	Capacity int    `json:"capacity"`
+}
+
+// SetQueuePolicy sets how ready beads are ordered and role capacities
+func (d *Dispatcher) SetQueuePolicy(policy QueuePolicy) {
+	caps := make(map[string]int, len(policy.RoleCapacity))
+	for role, n := range policy.RoleCapacity {
+		if n > 0 {
+			caps[normalizeRoleName(role)] = n
+		}
+	}
+	policy.RoleCapacity = caps
+	d.mu.Lock()
+	d.queuePolicy = policy
+	d.mu.Unlock()
+}
+
+func (d *Dispatcher) getQueuePolicy() QueuePolicy {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.queuePolicy
+}
+
+// score ranks a bead: its priority level, raised by waiting, times its
+// project's weight. Higher goes first.
+func (p QueuePolicy) score(b *models.Bead, now time.Time) float64 {
+	level := float64(models.BeadPriorityP3 - b.Priority + 1)
+	if level < 1 {
+		level = 1
+	}
+	aging := p.AgingInterval
+	if aging == 0 {
+		aging = DefaultAgingInterval
+	}
+	if aging > 0 {
+		if waited := now.Sub(waitingSince(b)); waited > 0 {
+			level += min(float64(waited)/float64(aging), maxAgingLevels)
+		}
+	}
+	if w, ok := p.ProjectWeights[b.ProjectID]; ok && w > 0 {
+		level *= w
+	}
+	return level
+}
+
+// waitingSince is when a bead joined the queue
+func waitingSince(b *models.Bead) time.Time {
+	if b.CreatedAt.IsZero() {
+		return b.UpdatedAt
+	}
+	return b.CreatedAt
+}
+
+// pinnedAt returns when a bead was pinned to the head of the queue
+func pinnedAt(b *models.Bead) (time.Time, bool) {
+	if b.Context == nil || b.Context[pinnedAtKey] == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, b.Context[pinnedAtKey])
+	return t, err == nil
+}
+
+// orderReady sorts ready beads into dispatch order: pinned beads in the
+// order they were pinned, then by score, then oldest first
+func (p QueuePolicy) orderReady(ready []*models.Bead, now time.Time) {
+	type rank struct {
+		score    float64
+		pinned   bool
+		pinnedAt time.Time
+	}
+	ranks := make(map[*models.Bead]rank, len(ready))
+	for _, b := range ready {
+		if b != nil {
+			r := rank{score: p.score(b, now)}
+			r.pinnedAt, r.pinned = pinnedAt(b)
+			ranks[b] = r
+		}
+	}
+	sort.SliceStable(ready, func(i, j int) bool {
+		a, b := ready[i], ready[j]
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		ra, rb := ranks[a], ranks[b]
+		if ra.pinned != rb.pinned {
+			return ra.pinned
+		}
+		if ra.pinned && !ra.pinnedAt.Equal(rb.pinnedAt) {
+			return ra.pinnedAt.Before(rb.pinnedAt)
+		}
+		if ra.score != rb.score {
+			return ra.score > rb.score
+		}
+		if wa, wb := waitingSince(a), waitingSince(b); !wa.Equal(wb) {
+			return wa.Before(wb)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// dispatchable reports whether the dispatcher ever hands a bead to agents
+func (d *Dispatcher) dispatchable(b *models.Bead) bool {
+	return b != nil && b.Type != "decision" && !d.hasTag(b, "requires-human-config")
+}
+
+// Queue returns a project's ready beads (every project's when projectID is
+// empty) in the order they will be dispatched
+func (d *Dispatcher) Queue(projectID string) ([]QueueEntry, error) {
+	ready, err := d.beads.GetReadyBeads(projectID)
+	if err != nil {
+		return nil, err
+	}
+	policy := d.getQueuePolicy()
+	now := time.Now()
+	policy.orderReady(ready, now)
+
+	entries := make([]QueueEntry, 0, len(ready))
+	for _, b := range ready {
+		if !d.dispatchable(b) {
+			continue
+		}
+		entry := QueueEntry{
+			Position:     len(entries) + 1,
+			BeadID:       b.ID,
+			Title:        b.Title,
+			ProjectID:    b.ProjectID,
+			Type:         b.Type,
+			Priority:     b.Priority,
+			Score:        policy.score(b, now),
+			WaitingSince: waitingSince(b),
+			AssignedTo:   b.AssignedTo,
+		}
+		if t, ok := pinnedAt(b); ok {
+			entry.Pinned = true
+			entry.PinnedAt = &t
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// PinBead moves a ready bead to the head of the queue until it is next
+// dispatched. With an agentID it is also assigned to that agent, so only
+// that agent picks it up.
+func (d *Dispatcher) PinBead(beadID, agentID string) error {
+	bead, err := d.beads.GetBead(beadID)
+	if err != nil {
+		return err
+	}
+	if bead.Status != models.BeadStatusOpen && bead.Status != models.BeadStatusInProgress {
+		return apperr.Validation("bead %s is %s, only open or in-progress beads can be pinned", beadID, bead.Status)
+	}
+	updates := map[string]interface{}{
+		"context": map[string]string{pinnedAtKey: time.Now().UTC().Format(time.RFC3339Nano)},
+	}
+	if agentID != "" {
+		if d.agents == nil {
+			return apperr.NotFound("agent not found: %s", agentID)
+		}
+		if _, err := d.agents.GetAgent(agentID); err != nil {
+			return apperr.NotFound("agent not found: %s", agentID)
+		}
+		updates["assigned_to"] = agentID
+	}
+	return d.beads.UpdateBead(beadID, updates)
+}
+
+// UnpinBead returns a pinned bead to its place by score. An agent it was
+// pinned to keeps it.
+func (d *Dispatcher) UnpinBead(beadID string) error {
+	if _, err := d.beads.GetBead(beadID); err != nil {
+		return err
+	}
+	return d.beads.UpdateBead(beadID, map[string]interface{}{
+		"context": map[string]string{pinnedAtKey: ""},
+	})
+}
+
+// RoleLoads reports how many agents of each capped role are working
+func (d *Dispatcher) RoleLoads() []RoleLoad {
+	caps := d.getQueuePolicy().RoleCapacity
+	if len(caps) == 0 {
+		return []RoleLoad{}
+	}
+	var all []*models.Agent
+	if d.agents != nil {
+		all = d.agents.ListAgents()
+	}
+	working := workingByRole(all)
+	loads := make([]RoleLoad, 0, len(caps))
+	for role, n := range caps {
+		loads = append(loads, RoleLoad{Role: role, Working: working[role], Capacity: n})
+	}
+	sort.Slice(loads, func(i, j int) bool { return loads[i].Role < loads[j].Role })
+	return loads
+}
+
+// workingByRole counts the agents of each role that are working
+func workingByRole(agents []*models.Agent) map[string]int {
+	working := make(map[string]int)
+	for _, a := range agents {
+		if a != nil && a.Status == "working" {
+			working[normalizeRoleName(a.Role)]++
+		}
+	}
+	return working
+}
+
+// withinRoleCapacity drops the idle agents whose role already has as many
+// agents working as its capacity allows
+func withinRoleCapacity(idle, all []*models.Agent, caps map[string]int) []*models.Agent {
+	if len(caps) == 0 {
+		return idle
+	}
+	working := workingByRole(all)
+	eligible := make([]*models.Agent, 0, len(idle))
+	for _, a := range idle {
+		if n, ok := caps[normalizeRoleName(a.Role)]; ok && working[normalizeRoleName(a.Role)] >= n {
+			continue
+		}
+		eligible = append(eligible, a)
+	}
+	return eligible
+}
@@ -0,0 +1,147 @@
+package dispatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func beadIDs(list []*models.Bead) []string {
+	ids := make([]string, len(list))
+	for i, b := range list {
+		ids[i] = b.ID
+	}
+	return ids
+}
+
+func TestQueuePolicy_OrderReady(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	bead := func(id string, p models.BeadPriority, project string, waited time.Duration) *models.Bead {
+		return &models.Bead{ID: id, Priority: p, ProjectID: project, CreatedAt: now.Add(-waited), UpdatedAt: now}
+	}
+
+	tests := []struct {
+		name   string
+		policy QueuePolicy
+		ready  []*models.Bead
+		want   []string
+	}{
+		{
+			name:  "priority then oldest first",
+			ready: []*models.Bead{bead("new-p2", 2, "a", time.Minute), bead("p1", 1, "a", 0), bead("old-p2", 2, "a", time.Hour)},
+			want:  []string{"p1", "old-p2", "new-p2"},
+		},
+		{
+			name:  "waiting raises a bead past fresher ones",
+			ready: []*models.Bead{bead("p1", 1, "a", 0), bead("p2", 2, "a", 6*time.Hour)},
+			want:  []string{"p2", "p1"},
+		},
+		{
+			name:  "aging is capped below a fresh P0",
+			ready: []*models.Bead{bead("p3", 3, "a", 30*24*time.Hour), bead("p0", 0, "a", 0)},
+			want:  []string{"p0", "p3"},
+		},
+		{
+			name:   "aging can be disabled",
+			policy: QueuePolicy{AgingInterval: -1},
+			ready:  []*models.Bead{bead("p1", 1, "a", 0), bead("p2", 2, "a", 6*time.Hour)},
+			want:   []string{"p1", "p2"},
+		},
+		{
+			name:   "project weight",
+			policy: QueuePolicy{AgingInterval: -1, ProjectWeights: map[string]float64{"b": 2}},
+			ready:  []*models.Bead{bead("a-p1", 1, "a", 0), bead("b-p2", 2, "b", 0)},
+			want:   []string{"b-p2", "a-p1"},
+		},
+		{
+			name: "pinned beads first, in pin order",
+			ready: []*models.Bead{
+				bead("p0", 0, "a", 0),
+				{ID: "pin-late", Priority: 3, Context: map[string]string{pinnedAtKey: now.Format(time.RFC3339Nano)}},
+				{ID: "pin-early", Priority: 3, Context: map[string]string{pinnedAtKey: now.Add(-time.Minute).Format(time.RFC3339Nano)}},
+			},
+			want: []string{"pin-early", "pin-late", "p0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.policy.orderReady(tt.ready, now)
+			got := beadIDs(tt.ready)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("order = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithinRoleCapacity(t *testing.T) {
+	idle := []*models.Agent{
+		{ID: "rev-2", Role: "Code Reviewer", Status: "idle"},
+		{ID: "eng-2", Role: "engineer", Status: "idle"},
+	}
+	all := append([]*models.Agent{
+		{ID: "rev-1", Role: "code-reviewer", Status: "working"},
+		{ID: "eng-1", Role: "engineer", Status: "working"},
+	}, idle...)
+
+	got := withinRoleCapacity(idle, all, map[string]int{"code-reviewer": 1})
+	if len(got) != 1 || got[0].ID != "eng-2" {
+		t.Errorf("eligible = %+v, want only eng-2", got)
+	}
+	if got := withinRoleCapacity(idle, all, map[string]int{"code-reviewer": 2}); len(got) != 2 {
+		t.Errorf("eligible = %+v, want both below capacity", got)
+	}
+}
+
+func TestDispatcher_QueueAndPins(t *testing.T) {
+	mgr := beads.NewManager("")
+	mgr.SetBeadsPath(t.TempDir())
+	created := time.Now().Add(-time.Minute)
+	for _, b := range []*models.Bead{
+		{ID: "bd-1", Type: "task", Status: models.BeadStatusOpen, Priority: 1, ProjectID: "p", CreatedAt: created},
+		{ID: "bd-2", Type: "task", Status: models.BeadStatusOpen, Priority: 3, ProjectID: "p", CreatedAt: created},
+		{ID: "bd-3", Type: "decision", Status: models.BeadStatusOpen, Priority: 0, ProjectID: "p", CreatedAt: created},
+		{ID: "bd-4", Type: "task", Status: models.BeadStatusClosed, Priority: 0, ProjectID: "p", CreatedAt: created},
+	} {
+		if err := mgr.ImportBead(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := NewDispatcher(mgr, nil, nil, nil, nil)
+
+	queue, err := d.Queue("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue) != 2 || queue[0].BeadID != "bd-1" || queue[1].BeadID != "bd-2" || queue[1].Position != 2 {
+		t.Fatalf("queue = %+v, want bd-1 then bd-2", queue)
+	}
+
+	if err := d.PinBead("bd-2", ""); err != nil {
+		t.Fatalf("PinBead: %v", err)
+	}
+	queue, _ = d.Queue("p")
+	if queue[0].BeadID != "bd-2" || !queue[0].Pinned || queue[0].PinnedAt == nil {
+		t.Errorf("queue = %+v, want pinned bd-2 first", queue)
+	}
+
+	if err := d.UnpinBead("bd-2"); err != nil {
+		t.Fatalf("UnpinBead: %v", err)
+	}
+	queue, _ = d.Queue("p")
+	if queue[0].BeadID != "bd-1" || queue[1].Pinned {
+		t.Errorf("queue = %+v, want bd-2 back in place", queue)
+	}
+
+	if err := d.PinBead("bd-4", ""); apperr.CodeOf(err) != apperr.CodeValidation {
+		t.Errorf("PinBead of a closed bead = %v, want a validation error", err)
+	}
+	if err := d.PinBead("bd-1", "agent-x"); apperr.CodeOf(err) != apperr.CodeNotFound {
+		t.Errorf("PinBead to an unknown agent = %v, want not found", err)
+	}
+}
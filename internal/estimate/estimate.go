@@ -0,0 +1,662 @@
+// Package estimate predicts what a bead will cost before an agent works on
+// it. A new bead's title and description are embedded and compared with
+// beads that already closed; the token spend, dollar cost and wall-clock
+// time of the most similar ones give the predicted ranges, along with the
+// files they touched. When a bead closes its actuals are recorded next to
+// its prediction, which both adds it to the history later beads are compared
+// with and calibrates how far off the project's estimates have been.
+package estimate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/internal/memory"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Methods an estimate can be based on
+const (
+	MethodSimilar = "similar_beads"   // Closed beads resembling this one
+	MethodProject = "project_history" // The project's closed beads, none of them similar
+	MethodDefault = "default"         // No history yet
+)
+
+// Confidence levels
+const (
+	ConfidenceLow    = "low"
+	ConfidenceMedium = "medium"
+	ConfidenceHigh   = "high"
+)
+
+const (
+	// maxNeighbors is how many similar beads an estimate is based on
+	maxNeighbors = 5
+	// minSimilarity is the least similarity for a closed bead to count
+	minSimilarity = 0.25
+	// maxOutcomes bounds the closed beads compared per estimate
+	maxOutcomes = 500
+	// minProjectOutcomes is how many closed beads a project needs before
+	// its own history is used instead of every project's
+	minProjectOutcomes = 3
+	// minCalibrationSamples is how many scored estimates calibration needs
+	minCalibrationSamples = 3
+	// calibrationWindow is how many recent scored estimates calibrate
+	calibrationWindow = 50
+	// minSpread is how far below and above the expected value a range
+	// reaches at least
+	minSpread = 0.25
+	// maxPaths caps the likely affected paths listed
+	maxPaths = 10
+)
+
+// Range is a prediction with its plausible low and high ends
+type Range struct {
+	Low      float64 `json:"low"`
+	Expected float64 `json:"expected"`
+	High     float64 `json:"high"`
+}
+
+// contains reports whether v falls in the range
+func (r Range) contains(v float64) bool {
+	return v >= r.Low && v <= r.High
+}
+
+// scale multiplies every end of the range by f
+func (r Range) scale(f float64) Range {
+	return Range{Low: r.Low * f, Expected: r.Expected * f, High: r.High * f}
+}
+
+// Estimate is the prediction for one bead, and its actuals once it closed
+type Estimate struct {
+	BeadID      string     `json:"bead_id"`
+	ProjectID   string     `json:"project_id"`
+	Title       string     `json:"title"`
+	EstimatedAt time.Time  `json:"estimated_at"`
+	Method      string     `json:"method"`
+	Confidence  string     `json:"confidence"`
+	Tokens      Range      `json:"tokens"`
+	CostUSD     Range      `json:"cost_usd"`
+	Minutes     Range      `json:"minutes"`
+	Similar     []Neighbor `json:"similar,omitempty"`
+	Paths       []string   `json:"paths,omitempty"` // Files the similar beads changed, most common first
+	// Calibration is the correction applied for how far off the project's
+	// recent estimates were (1 when there is too little history)
+	Calibration Calibration `json:"calibration"`
+	Actual      *Actual     `json:"actual,omitempty"`
+
+	// Embedding is the vector of the bead's text, kept so closed beads can
+	// be compared with new ones
+	Embedding []float32 `json:"-"`
+}
+
+// Neighbor is a closed bead an estimate drew on
+type Neighbor struct {
+	BeadID     string  `json:"bead_id"`
+	Title      string  `json:"title"`
+	Similarity float64 `json:"similarity"`
+	Tokens     float64 `json:"tokens"`
+	CostUSD    float64 `json:"cost_usd"`
+	Minutes    float64 `json:"minutes"`
+}
+
+// Calibration holds the factors estimates are multiplied by
+type Calibration struct {
+	Cost    float64 `json:"cost"`
+	Minutes float64 `json:"minutes"`
+	Samples int     `json:"samples"`
+}
+
+// Actual is what a bead really took
+type Actual struct {
+	ClosedAt time.Time `json:"closed_at"`
+	Tokens   float64   `json:"tokens"`
+	CostUSD  float64   `json:"cost_usd"`
+	Minutes  float64   `json:"minutes"`
+	Requests int64     `json:"requests"`
+	Paths    []string  `json:"paths,omitempty"`
+	// CostRatio and MinutesRatio are actual over expected; 0 when nothing
+	// was expected
+	CostRatio    float64 `json:"cost_ratio"`
+	MinutesRatio float64 `json:"minutes_ratio"`
+}
+
+// Accuracy summarizes how estimates compared with actuals
+type Accuracy struct {
+	ProjectID      string      `json:"project_id,omitempty"`
+	Samples        int         `json:"samples"`
+	CostInRange    float64     `json:"cost_in_range"`    // Fraction of actual costs inside the predicted range
+	MinutesInRange float64     `json:"minutes_in_range"` // Fraction of actual times inside the predicted range
+	CostError      float64     `json:"cost_error"`       // Median absolute error relative to the expected cost
+	MinutesError   float64     `json:"minutes_error"`    // Median absolute error relative to the expected time
+	Calibration    Calibration `json:"calibration"`
+}
+
+// BeadSource looks beads up
+type BeadSource interface {
+	GetBead(id string) (*models.Bead, error)
+}
+
+// CostSource lists logged provider requests
+type CostSource interface {
+	GetLogs(ctx context.Context, filter *analytics.LogFilter) ([]*analytics.RequestLog, error)
+}
+
+// HistorySource returns a bead's recorded events
+type HistorySource interface {
+	History(beadID string) ([]*beads.BeadEvent, error)
+}
+
+// Sources are the subsystems the estimator reads. Without costs, actuals
+// only cover time; without history, time runs from creation and no paths
+// are recorded.
+type Sources struct {
+	Beads   BeadSource
+	Costs   CostSource
+	History HistorySource
+}
+
+// Store keeps estimates
+type Store interface {
+	SaveEstimate(e *Estimate) error
+	// GetEstimate returns nil, nil when the bead has no estimate
+	GetEstimate(beadID string) (*Estimate, error)
+	// ListOutcomes returns estimates that have actuals, most recently closed
+	// first, in one project or every project when projectID is empty
+	ListOutcomes(projectID string, limit int) ([]*Estimate, error)
+}
+
+// Estimator predicts bead costs and learns from how they turned out
+type Estimator struct {
+	sources Sources
+	store   Store
+
+	mu       sync.RWMutex
+	embedder memory.Embedder
+}
+
+// New creates an estimator that keeps estimates in memory until SetStore
+// is called, and compares beads with the hash embedder until SetEmbedder is
+func New(sources Sources) *Estimator {
+	return &Estimator{sources: sources, store: newMemoryStore(), embedder: memory.NewHashEmbedder()}
+}
+
+// SetStore keeps estimates in store
+func (e *Estimator) SetStore(store Store) {
+	if store != nil {
+		e.store = store
+	}
+}
+
+// SetEmbedder replaces the embedder beads are compared with
+func (e *Estimator) SetEmbedder(embedder memory.Embedder) {
+	if embedder == nil {
+		return
+	}
+	e.mu.Lock()
+	e.embedder = embedder
+	e.mu.Unlock()
+}
+
+// Get returns a bead's estimate, or nil if it has none
+func (e *Estimator) Get(beadID string) (*Estimate, error) {
+	return e.store.GetEstimate(beadID)
+}
+
+// Estimate predicts a bead's cost and records the prediction, replacing
+// any earlier one. Actuals already recorded for the bead are kept.
+func (e *Estimator) Estimate(ctx context.Context, bead *models.Bead) (*Estimate, error) {
+	if bead == nil {
+		return nil, fmt.Errorf("bead cannot be nil")
+	}
+	est := &Estimate{
+		BeadID:      bead.ID,
+		ProjectID:   bead.ProjectID,
+		Title:       bead.Title,
+		EstimatedAt: time.Now().UTC(),
+		Embedding:   e.embed(ctx, bead),
+	}
+
+	outcomes, err := e.store.ListOutcomes(bead.ProjectID, maxOutcomes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load closed beads: %w", err)
+	}
+	projectOutcomes := outcomes
+	if len(outcomes) < minProjectOutcomes {
+		if outcomes, err = e.store.ListOutcomes("", maxOutcomes); err != nil {
+			return nil, fmt.Errorf("failed to load closed beads: %w", err)
+		}
+	}
+
+	if neighbors := nearest(est.Embedding, outcomes, bead.ID); len(neighbors) > 0 {
+		est.Method = MethodSimilar
+		est.Similar = neighbors
+		est.Tokens = spread(neighbors, func(n Neighbor) float64 { return n.Tokens })
+		est.CostUSD = spread(neighbors, func(n Neighbor) float64 { return n.CostUSD })
+		est.Minutes = spread(neighbors, func(n Neighbor) float64 { return n.Minutes })
+		est.Confidence = neighborConfidence(neighbors)
+		est.Paths = commonPaths(neighbors, outcomes)
+	} else if len(projectOutcomes) > 0 {
+		var ns []Neighbor
+		for _, o := range projectOutcomes {
+			if o.BeadID != bead.ID {
+				ns = append(ns, outcomeNeighbor(o, 1))
+			}
+		}
+		est.Method = MethodProject
+		est.Confidence = ConfidenceLow
+		est.Tokens = spread(ns, func(n Neighbor) float64 { return n.Tokens })
+		est.CostUSD = spread(ns, func(n Neighbor) float64 { return n.CostUSD })
+		est.Minutes = spread(ns, func(n Neighbor) float64 { return n.Minutes })
+	} else {
+		est.Method = MethodDefault
+		est.Confidence = ConfidenceLow
+		est.Tokens, est.CostUSD, est.Minutes = defaultRanges(bead)
+	}
+
+	est.Calibration = calibration(projectOutcomes, est.Method)
+	est.CostUSD = est.CostUSD.scale(est.Calibration.Cost)
+	est.Tokens = est.Tokens.scale(est.Calibration.Cost)
+	est.Minutes = est.Minutes.scale(est.Calibration.Minutes)
+
+	if prev, err := e.store.GetEstimate(bead.ID); err == nil && prev != nil {
+		est.Actual = prev.Actual
+	}
+	if err := e.store.SaveEstimate(est); err != nil {
+		return nil, fmt.Errorf("failed to save estimate: %w", err)
+	}
+	return est, nil
+}
+
+// EstimateBead estimates a bead by ID
+func (e *Estimator) EstimateBead(ctx context.Context, beadID string) (*Estimate, error) {
+	if e.sources.Beads == nil {
+		return nil, fmt.Errorf("no bead source")
+	}
+	bead, err := e.sources.Beads.GetBead(beadID)
+	if err != nil {
+		return nil, err
+	}
+	return e.Estimate(ctx, bead)
+}
+
+// RecordActual records what a closed bead really took next to its estimate,
+// estimating it first if it never was so it still joins the history
+func (e *Estimator) RecordActual(ctx context.Context, beadID string) (*Estimate, error) {
+	if e.sources.Beads == nil {
+		return nil, fmt.Errorf("no bead source")
+	}
+	bead, err := e.sources.Beads.GetBead(beadID)
+	if err != nil {
+		return nil, err
+	}
+	if bead.Status != models.BeadStatusClosed {
+		return nil, fmt.Errorf("bead %s is not closed", beadID)
+	}
+	est, err := e.store.GetEstimate(beadID)
+	if err != nil {
+		return nil, err
+	}
+	if est == nil {
+		if est, err = e.Estimate(ctx, bead); err != nil {
+			return nil, err
+		}
+	}
+
+	closedAt := bead.UpdatedAt
+	if bead.ClosedAt != nil {
+		closedAt = *bead.ClosedAt
+	}
+	actual := &Actual{ClosedAt: closedAt.UTC()}
+	started := bead.CreatedAt
+	if e.sources.History != nil {
+		if events, err := e.sources.History.History(beadID); err == nil {
+			started, actual.Paths = workStarted(events, bead.CreatedAt), touchedPaths(events)
+		}
+	}
+	if closedAt.After(started) {
+		actual.Minutes = closedAt.Sub(started).Minutes()
+	}
+	if e.sources.Costs != nil {
+		logs, err := e.sources.Costs.GetLogs(ctx, &analytics.LogFilter{StartTime: bead.CreatedAt, EndTime: closedAt})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request logs: %w", err)
+		}
+		for _, l := range logs {
+			if l.Metadata["bead_id"] != beadID {
+				continue
+			}
+			actual.Requests++
+			actual.Tokens += float64(l.TotalTokens)
+			actual.CostUSD += l.CostUSD
+		}
+	}
+	actual.CostRatio = ratio(actual.CostUSD, est.CostUSD.Expected)
+	actual.MinutesRatio = ratio(actual.Minutes, est.Minutes.Expected)
+
+	est.Actual = actual
+	if err := e.store.SaveEstimate(est); err != nil {
+		return nil, fmt.Errorf("failed to save estimate: %w", err)
+	}
+	return est, nil
+}
+
+// Accuracy compares a project's recent estimates with their actuals, or
+// every project's when projectID is empty
+func (e *Estimator) Accuracy(projectID string) (*Accuracy, error) {
+	outcomes, err := e.store.ListOutcomes(projectID, calibrationWindow)
+	if err != nil {
+		return nil, err
+	}
+	acc := &Accuracy{ProjectID: projectID, Calibration: calibration(outcomes, "")}
+	var costErrs, minuteErrs []float64
+	costIn, minutesIn := 0, 0
+	for _, o := range outcomes {
+		acc.Samples++
+		if o.CostUSD.contains(o.Actual.CostUSD) {
+			costIn++
+		}
+		if o.Minutes.contains(o.Actual.Minutes) {
+			minutesIn++
+		}
+		if o.CostUSD.Expected > 0 {
+			costErrs = append(costErrs, math.Abs(o.Actual.CostUSD-o.CostUSD.Expected)/o.CostUSD.Expected)
+		}
+		if o.Minutes.Expected > 0 {
+			minuteErrs = append(minuteErrs, math.Abs(o.Actual.Minutes-o.Minutes.Expected)/o.Minutes.Expected)
+		}
+	}
+	if acc.Samples > 0 {
+		acc.CostInRange = float64(costIn) / float64(acc.Samples)
+		acc.MinutesInRange = float64(minutesIn) / float64(acc.Samples)
+	}
+	acc.CostError = median(costErrs)
+	acc.MinutesError = median(minuteErrs)
+	return acc, nil
+}
+
+func (e *Estimator) embed(ctx context.Context, bead *models.Bead) []float32 {
+	e.mu.RLock()
+	embedder := e.embedder
+	e.mu.RUnlock()
+	vecs, err := embedder.Embed(ctx, []string{bead.Title + "\n" + bead.Description})
+	if err != nil || len(vecs) == 0 {
+		// A provider embedder that is down shouldn't block estimates
+		vecs, _ = memory.NewHashEmbedder().Embed(ctx, []string{bead.Title + "\n" + bead.Description})
+	}
+	if len(vecs) == 0 {
+		return nil
+	}
+	return vecs[0]
+}
+
+// nearest returns the closed beads most similar to vec
+func nearest(vec []float32, outcomes []*Estimate, self string) []Neighbor {
+	if len(vec) == 0 {
+		return nil
+	}
+	var ns []Neighbor
+	for _, o := range outcomes {
+		if o.BeadID == self || len(o.Embedding) != len(vec) {
+			continue
+		}
+		sim := float64(memory.CosineSimilarity(vec, o.Embedding))
+		if sim >= minSimilarity {
+			ns = append(ns, outcomeNeighbor(o, sim))
+		}
+	}
+	sort.SliceStable(ns, func(i, j int) bool { return ns[i].Similarity > ns[j].Similarity })
+	if len(ns) > maxNeighbors {
+		ns = ns[:maxNeighbors]
+	}
+	return ns
+}
+
+func outcomeNeighbor(o *Estimate, sim float64) Neighbor {
+	return Neighbor{
+		BeadID:     o.BeadID,
+		Title:      o.Title,
+		Similarity: math.Round(sim*1000) / 1000,
+		Tokens:     o.Actual.Tokens,
+		CostUSD:    o.Actual.CostUSD,
+		Minutes:    o.Actual.Minutes,
+	}
+}
+
+// spread predicts a range from past values: the similarity-weighted mean,
+// between the 25th and 75th percentiles. A single value is widened to half
+// and double.
+func spread(ns []Neighbor, value func(Neighbor) float64) Range {
+	if len(ns) == 0 {
+		return Range{}
+	}
+	values := make([]float64, len(ns))
+	var sum, weights float64
+	for i, n := range ns {
+		values[i] = value(n)
+		w := n.Similarity
+		if w <= 0 {
+			w = 1
+		}
+		sum += values[i] * w
+		weights += w
+	}
+	r := Range{Expected: sum / weights}
+	if len(values) == 1 {
+		r.Low, r.High = r.Expected/2, r.Expected*2
+		return r
+	}
+	sort.Float64s(values)
+	r.Low, r.High = percentile(values, 0.25), percentile(values, 0.75)
+	r.Low = math.Min(r.Low, r.Expected*(1-minSpread))
+	r.High = math.Max(r.High, r.Expected*(1+minSpread))
+	return r
+}
+
+func neighborConfidence(ns []Neighbor) string {
+	var sim float64
+	for _, n := range ns {
+		sim += n.Similarity
+	}
+	sim /= float64(len(ns))
+	switch {
+	case len(ns) >= 3 && sim >= 0.5:
+		return ConfidenceHigh
+	case len(ns) >= 2:
+		return ConfidenceMedium
+	}
+	return ConfidenceLow
+}
+
+// defaultRanges is the prediction before there is any history: a modest
+// task, or the bead's own estimated time when it has one
+func defaultRanges(bead *models.Bead) (tokens, cost, minutes Range) {
+	tokens = Range{Low: 10000, Expected: 50000, High: 200000}
+	cost = Range{Low: 0.10, Expected: 0.50, High: 2.00}
+	minutes = Range{Low: 10, Expected: 30, High: 120}
+	if bead.Type == "epic" {
+		tokens, cost, minutes = tokens.scale(5), cost.scale(5), minutes.scale(5)
+	}
+	if bead.EstimatedTime > 0 {
+		m := float64(bead.EstimatedTime)
+		minutes = Range{Low: m / 2, Expected: m, High: m * 2}
+	}
+	return tokens, cost, minutes
+}
+
+// calibration is the geometric mean of how far actuals were from the
+// expected values in recent estimates made the same way (any way when
+// method is empty), bounded to a factor of four either way. Each method is
+// off in its own direction: defaults by however far the project is from a
+// typical one, similar beads by far less.
+func calibration(outcomes []*Estimate, method string) Calibration {
+	c := Calibration{Cost: 1, Minutes: 1}
+	var costLogs, minuteLogs []float64
+	n := 0
+	for _, o := range outcomes {
+		if method != "" && o.Method != method {
+			continue
+		}
+		if n++; n > calibrationWindow {
+			break
+		}
+		if o.Actual.CostRatio > 0 {
+			costLogs = append(costLogs, math.Log(o.Actual.CostRatio))
+		}
+		if o.Actual.MinutesRatio > 0 {
+			minuteLogs = append(minuteLogs, math.Log(o.Actual.MinutesRatio))
+		}
+	}
+	if len(costLogs) >= minCalibrationSamples {
+		c.Cost = factor(costLogs)
+	}
+	if len(minuteLogs) >= minCalibrationSamples {
+		c.Minutes = factor(minuteLogs)
+	}
+	if len(costLogs) > len(minuteLogs) {
+		c.Samples = len(costLogs)
+	} else {
+		c.Samples = len(minuteLogs)
+	}
+	return c
+}
+
+func factor(logs []float64) float64 {
+	var sum float64
+	for _, l := range logs {
+		sum += l
+	}
+	f := math.Exp(sum / float64(len(logs)))
+	return math.Round(math.Max(0.25, math.Min(4, f))*1000) / 1000
+}
+
+// commonPaths lists the files the neighbors changed, most common first
+func commonPaths(ns []Neighbor, outcomes []*Estimate) []string {
+	byID := make(map[string]*Estimate, len(outcomes))
+	for _, o := range outcomes {
+		byID[o.BeadID] = o
+	}
+	counts := map[string]int{}
+	for _, n := range ns {
+		if o := byID[n.BeadID]; o != nil && o.Actual != nil {
+			for _, p := range o.Actual.Paths {
+				counts[p]++
+			}
+		}
+	}
+	paths := make([]string, 0, len(counts))
+	for p := range counts {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if counts[paths[i]] != counts[paths[j]] {
+			return counts[paths[i]] > counts[paths[j]]
+		}
+		return paths[i] < paths[j]
+	})
+	if len(paths) > maxPaths {
+		paths = paths[:maxPaths]
+	}
+	return paths
+}
+
+// workStarted is when work on a bead began: its first assignment, or its
+// creation if it never was assigned
+func workStarted(events []*beads.BeadEvent, created time.Time) time.Time {
+	for _, ev := range events {
+		if ev.Type == beads.EventAssigned {
+			return ev.Timestamp
+		}
+	}
+	return created
+}
+
+// touchedPaths lists the files a bead's actions changed
+func touchedPaths(events []*beads.BeadEvent) []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, ev := range events {
+		if ev.Type != beads.EventActionExecuted {
+			continue
+		}
+		p, _ := ev.Data["path"].(string)
+		if p = strings.TrimSpace(p); p != "" && !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func ratio(actual, expected float64) float64 {
+	if expected <= 0 || actual <= 0 {
+		return 0
+	}
+	return actual / expected
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return percentile(sorted, 0.5)
+}
+
+// memoryStore keeps estimates in memory
+type memoryStore struct {
+	mu        sync.RWMutex
+	estimates map[string]*Estimate
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{estimates: make(map[string]*Estimate)}
+}
+
+func (s *memoryStore) SaveEstimate(e *Estimate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.estimates[e.BeadID] = e
+	return nil
+}
+
+func (s *memoryStore) GetEstimate(beadID string) (*Estimate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.estimates[beadID], nil
+}
+
+func (s *memoryStore) ListOutcomes(projectID string, limit int) ([]*Estimate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Estimate
+	for _, e := range s.estimates {
+		if e.Actual != nil && (projectID == "" || e.ProjectID == projectID) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Actual.ClosedAt.After(out[j].Actual.ClosedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,170 @@
+package estimate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeBeads map[string]*models.Bead
+
+func (f fakeBeads) GetBead(id string) (*models.Bead, error) {
+	if b, ok := f[id]; ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("bead not found: %s", id)
+}
+
+type fakeCosts []*analytics.RequestLog
+
+func (f fakeCosts) GetLogs(ctx context.Context, filter *analytics.LogFilter) ([]*analytics.RequestLog, error) {
+	var out []*analytics.RequestLog
+	for _, l := range f {
+		if !l.Timestamp.Before(filter.StartTime) && !l.Timestamp.After(filter.EndTime) {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+type fakeHistory map[string][]*beads.BeadEvent
+
+func (f fakeHistory) History(beadID string) ([]*beads.BeadEvent, error) {
+	return f[beadID], nil
+}
+
+// closeBead closes a bead that took minutes of work after being assigned and
+// spent cost over two requests touching path
+func closeBead(b *models.Bead, start time.Time, minutes, cost float64, path string, costs *fakeCosts, history fakeHistory) {
+	closed := start.Add(time.Duration(minutes * float64(time.Minute)))
+	b.CreatedAt, b.Status, b.ClosedAt = start.Add(-time.Hour), models.BeadStatusClosed, &closed
+	history[b.ID] = []*beads.BeadEvent{
+		{Type: beads.EventCreated, Timestamp: b.CreatedAt},
+		{Type: beads.EventAssigned, Timestamp: start},
+		{Type: beads.EventActionExecuted, Timestamp: start.Add(time.Minute), Data: map[string]interface{}{"path": path}},
+	}
+	for i := 0; i < 2; i++ {
+		*costs = append(*costs, &analytics.RequestLog{Timestamp: start.Add(time.Minute), CostUSD: cost / 2, TotalTokens: int64(cost * 10000),
+			Metadata: map[string]string{"bead_id": b.ID}})
+	}
+}
+
+func TestEstimateLearnsFromClosedBeads(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	bs := fakeBeads{}
+	costs := &fakeCosts{}
+	history := fakeHistory{}
+	e := New(Sources{Beads: bs, Costs: costs, History: history})
+
+	first := &models.Bead{ID: "b0", ProjectID: "p1", Title: "Fix the login form validation", EstimatedTime: 20}
+	est, err := e.Estimate(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.Method != MethodDefault || est.Minutes.Expected != 20 || est.Confidence != ConfidenceLow {
+		t.Errorf("expected a default estimate using the bead's own time, got %+v", est)
+	}
+
+	for i, title := range []string{"Fix the login form validation error", "Fix login form validation on submit", "Fix the login form email validation"} {
+		b := &models.Bead{ID: fmt.Sprintf("b%d", i+1), ProjectID: "p1", Title: title}
+		bs[b.ID] = b
+		closeBead(b, start.Add(time.Duration(i)*24*time.Hour), 30, 1.0, "web/login.go", costs, history)
+		if _, err := e.RecordActual(ctx, b.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unrelated := &models.Bead{ID: "u1", ProjectID: "p1", Title: "Migrate billing database to postgres"}
+	bs[unrelated.ID] = unrelated
+	closeBead(unrelated, start, 600, 20, "billing/db.go", costs, history)
+	if _, err := e.RecordActual(ctx, unrelated.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := e.RecordActual(ctx, "b1")
+	if err != nil || got.Actual == nil || got.Actual.Requests != 2 || got.Actual.CostUSD != 1 || got.Actual.Minutes != 30 ||
+		len(got.Actual.Paths) != 1 || got.Actual.Paths[0] != "web/login.go" {
+		t.Fatalf("RecordActual() = %+v, %v", got.Actual, err)
+	}
+
+	est, err = e.Estimate(ctx, &models.Bead{ID: "n1", ProjectID: "p1", Title: "Fix the login form password validation"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.Method != MethodSimilar {
+		t.Fatalf("expected an estimate from similar beads, got %s", est.Method)
+	}
+	for _, n := range est.Similar {
+		if n.BeadID == "u1" {
+			t.Errorf("expected the unrelated bead to be left out, got %+v", est.Similar)
+		}
+	}
+	if est.CostUSD.Expected > 2 || est.Minutes.Expected > 60 || !est.Minutes.contains(30) {
+		t.Errorf("expected ranges near the login beads, got cost %+v minutes %+v", est.CostUSD, est.Minutes)
+	}
+	if len(est.Paths) == 0 || est.Paths[0] != "web/login.go" {
+		t.Errorf("expected the login file as a likely path, got %v", est.Paths)
+	}
+	if saved, _ := e.Get("n1"); saved != est {
+		t.Error("expected the estimate to be recorded")
+	}
+
+	if _, err := e.RecordActual(ctx, "missing"); err == nil {
+		t.Error("expected an unknown bead to fail")
+	}
+	bs["open"] = &models.Bead{ID: "open", Status: models.BeadStatusOpen}
+	if _, err := e.RecordActual(ctx, "open"); err == nil {
+		t.Error("expected an open bead to be rejected")
+	}
+}
+
+func TestCalibration(t *testing.T) {
+	var outcomes []*Estimate
+	for i := 0; i < 4; i++ {
+		outcomes = append(outcomes, &Estimate{Actual: &Actual{CostRatio: 2, MinutesRatio: 0.5}})
+	}
+	c := calibration(outcomes[:2], "")
+	if c.Cost != 1 || c.Minutes != 1 {
+		t.Errorf("expected no correction from two samples, got %+v", c)
+	}
+	c = calibration(outcomes, "")
+	if c.Cost != 2 || c.Minutes != 0.5 || c.Samples != 4 {
+		t.Errorf("expected estimates to be doubled and halved, got %+v", c)
+	}
+	if c = calibration(outcomes, MethodSimilar); c.Cost != 1 || c.Samples != 0 {
+		t.Errorf("expected estimates made another way to be ignored, got %+v", c)
+	}
+	if f := factor([]float64{math.Log(100), math.Log(100), math.Log(100)}); f != 4 {
+		t.Errorf("expected the factor to be capped at 4, got %v", f)
+	}
+}
+
+func TestAccuracy(t *testing.T) {
+	e := New(Sources{})
+	now := time.Now()
+	for i, actual := range []float64{1, 3} {
+		_ = e.store.SaveEstimate(&Estimate{
+			BeadID:    fmt.Sprintf("b%d", i),
+			ProjectID: "p1",
+			CostUSD:   Range{Low: 0.5, Expected: 1, High: 2},
+			Minutes:   Range{Low: 10, Expected: 20, High: 40},
+			Actual:    &Actual{ClosedAt: now, CostUSD: actual, Minutes: 20},
+		})
+	}
+	acc, err := e.Accuracy("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Samples != 2 || acc.CostInRange != 0.5 || acc.MinutesInRange != 1 || acc.CostError != 1 || acc.MinutesError != 0 {
+		t.Errorf("unexpected accuracy %+v", acc)
+	}
+	if acc, _ := e.Accuracy("p2"); acc.Samples != 0 {
+		t.Errorf("expected no samples for another project, got %+v", acc)
+	}
+}
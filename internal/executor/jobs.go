@@ -0,0 +1,507 @@
+package executor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Background job states
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCanceled  = "canceled"
+	JobTimedOut  = "timed_out"
+)
+
+// Background job defaults and limits
+const (
+	DefaultJobLogLimit    = 1024 * 1024 // Log bytes retained per job; older output is discarded
+	DefaultMaxJobs        = 32
+	DefaultJobRetention   = time.Hour     // How long finished jobs stay queryable
+	DefaultJobMaxLifetime = 4 * time.Hour // Timeout for jobs that do not set one
+	DefaultJobLogPage     = 64 * 1024
+
+	jobCancelGrace  = 5 * time.Second
+	jobReapInterval = time.Minute
+)
+
+// JobConfig bounds background jobs. Zero values use the defaults.
+type JobConfig struct {
+	LogLimit    int
+	MaxJobs     int // Max concurrently running jobs
+	Retention   time.Duration
+	MaxLifetime time.Duration
+}
+
+// JobInfo is a snapshot of a background job's state
+type JobInfo struct {
+	ID          string     `json:"id"`
+	AgentID     string     `json:"agent_id"`
+	BeadID      string     `json:"bead_id"`
+	ProjectID   string     `json:"project_id"`
+	Command     string     `json:"command"`
+	WorkingDir  string     `json:"working_dir"`
+	Status      string     `json:"status"`
+	ExitCode    int        `json:"exit_code"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Duration    int64      `json:"duration_ms"`
+	LogBytes    int64      `json:"log_bytes"` // Total output produced, including discarded bytes
+	Error       string     `json:"error,omitempty"`
+}
+
+// JobLog is a page of a job's combined stdout/stderr
+type JobLog struct {
+	JobID      string `json:"job_id"`
+	Offset     int64  `json:"offset"`      // Offset of the first byte in Data
+	NextOffset int64  `json:"next_offset"` // Pass back to continue reading
+	Data       string `json:"data"`
+	Truncated  bool   `json:"truncated"` // Requested output was discarded by the log limit
+	Status     string `json:"status"`
+	Done       bool   `json:"done"`
+}
+
+type job struct {
+	mu         sync.Mutex
+	info       JobInfo
+	cmd        *exec.Cmd
+	lease      *QuotaLease
+	context    map[string]interface{}
+	logBuf     []byte
+	logStart   int64 // Absolute offset of logBuf[0]
+	limit      int
+	stopStatus string
+	notify     chan struct{} // Closed and replaced whenever output arrives or the job finishes
+	done       chan struct{}
+}
+
+// JobManager runs commands in the background and tracks them by job ID, for
+// dev servers, watch-mode builds and anything longer than a request timeout.
+type JobManager struct {
+	mu       sync.Mutex
+	jobs     map[string]*job
+	config   JobConfig
+	db       *sql.DB
+	quotas   *QuotaManager
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewJobManager creates a job manager. When db is non-nil, finished jobs are
+// recorded in command_logs alongside synchronous commands.
+func NewJobManager(db *sql.DB, cfg JobConfig) *JobManager {
+	if cfg.LogLimit <= 0 {
+		cfg.LogLimit = DefaultJobLogLimit
+	}
+	if cfg.MaxJobs <= 0 {
+		cfg.MaxJobs = DefaultMaxJobs
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultJobRetention
+	}
+	if cfg.MaxLifetime <= 0 {
+		cfg.MaxLifetime = DefaultJobMaxLifetime
+	}
+	m := &JobManager{
+		jobs:   make(map[string]*job),
+		config: cfg,
+		db:     db,
+		stopCh: make(chan struct{}),
+	}
+	go m.reapLoop()
+	return m
+}
+
+// SetQuotaManager enables per-project quota enforcement for jobs
+func (m *JobManager) SetQuotaManager(q *QuotaManager) {
+	m.quotas = q
+}
+
+// StartJob validates and starts a command, returning as soon as it is running.
+// req.Timeout bounds the job's lifetime; it defaults to the manager's MaxLifetime.
+func (m *JobManager) StartJob(ctx context.Context, req ExecuteCommandRequest) (*JobInfo, error) {
+	if req.Command == "" {
+		return nil, fmt.Errorf("command is required")
+	}
+	parts, requiresShell, err := validateCommand(req.Command)
+	if err != nil {
+		return nil, fmt.Errorf("command validation failed: %w", err)
+	}
+	if running := m.countRunning(); running >= m.config.MaxJobs {
+		return nil, fmt.Errorf("job limit reached (%d running)", running)
+	}
+
+	timeout := m.config.MaxLifetime
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Second
+	}
+	workingDir := req.WorkingDir
+	if workingDir == "" {
+		workingDir = "/app/src"
+	}
+
+	lease, err := m.quotas.Acquire(req.ProjectID, workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The job outlives the request, so it is not bound to ctx.
+	var cmd *exec.Cmd
+	if requiresShell {
+		cmd = exec.Command("/bin/sh", "-c", parts[0])
+	} else {
+		cmd = exec.Command(parts[0], parts[1:]...)
+	}
+	cmd.Dir = workingDir
+	cmd.WaitDelay = jobCancelGrace
+	setProcessGroup(cmd)
+
+	j := &job{
+		info: JobInfo{
+			ID:         fmt.Sprintf("job-%s", uuid.New().String()[:8]),
+			AgentID:    req.AgentID,
+			BeadID:     req.BeadID,
+			ProjectID:  req.ProjectID,
+			Command:    req.Command,
+			WorkingDir: workingDir,
+			Status:     JobRunning,
+			ExitCode:   -1,
+		},
+		cmd:     cmd,
+		lease:   lease,
+		context: req.Context,
+		limit:   m.config.LogLimit,
+		notify:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	var out io.Writer = &jobLogWriter{job: j}
+	stdout, stderr := out, out
+	if req.OnOutput != nil {
+		stdout = io.MultiWriter(out, &outputWriter{stream: "stdout", onOutput: req.OnOutput})
+		stderr = io.MultiWriter(out, &outputWriter{stream: "stderr", onOutput: req.OnOutput})
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	j.info.StartedAt = time.Now()
+	if err := cmd.Start(); err != nil {
+		lease.Release(nil)
+		return nil, fmt.Errorf("failed to start job: %w", err)
+	}
+	lease.Watch(cmd)
+
+	m.mu.Lock()
+	m.jobs[j.info.ID] = j
+	m.mu.Unlock()
+
+	timer := time.AfterFunc(timeout, func() { j.stop(JobTimedOut) })
+	go m.wait(j, timer)
+
+	jobLog.Info("Started job", "job_id", j.info.ID, "agent_id", req.AgentID, "bead_id", req.BeadID, "command", req.Command)
+	info := j.snapshot()
+	return &info, nil
+}
+
+// GetJob returns a snapshot of one job
+func (m *JobManager) GetJob(id string) (*JobInfo, error) {
+	j, err := m.get(id)
+	if err != nil {
+		return nil, err
+	}
+	info := j.snapshot()
+	return &info, nil
+}
+
+// ListJobs returns jobs, optionally filtered by bead and status, oldest first
+func (m *JobManager) ListJobs(beadID, status string) []JobInfo {
+	m.mu.Lock()
+	list := make([]*job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		list = append(list, j)
+	}
+	m.mu.Unlock()
+
+	infos := make([]JobInfo, 0, len(list))
+	for _, j := range list {
+		info := j.snapshot()
+		if beadID != "" && info.BeadID != beadID {
+			continue
+		}
+		if status != "" && info.Status != status {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, k int) bool { return infos[i].StartedAt.Before(infos[k].StartedAt) })
+	return infos
+}
+
+// ReadLog returns up to limit bytes of output starting at offset. A negative
+// offset reads the last limit bytes. When no new output is available and the
+// job is still running, it waits up to wait for more.
+func (m *JobManager) ReadLog(ctx context.Context, id string, offset int64, limit int, wait time.Duration) (*JobLog, error) {
+	j, err := m.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultJobLogPage
+	}
+
+	j.mu.Lock()
+	end := j.logStart + int64(len(j.logBuf))
+	if offset < 0 {
+		offset = end - int64(limit)
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	if offset >= end && j.info.Status == JobRunning && wait > 0 {
+		notify := j.notify
+		j.mu.Unlock()
+		timer := time.NewTimer(wait)
+		select {
+		case <-notify:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+		j.mu.Lock()
+		end = j.logStart + int64(len(j.logBuf))
+	}
+	defer j.mu.Unlock()
+
+	page := &JobLog{JobID: id, Status: j.info.Status, Done: j.info.Status != JobRunning}
+	if offset < j.logStart {
+		offset = j.logStart
+		page.Truncated = true
+	}
+	if offset > end {
+		offset = end
+	}
+	stop := offset + int64(limit)
+	if stop > end {
+		stop = end
+	}
+	page.Offset = offset
+	page.NextOffset = stop
+	page.Data = string(j.logBuf[offset-j.logStart : stop-j.logStart])
+	return page, nil
+}
+
+// CancelJob stops a running job, signalling its process group and killing it
+// if it has not exited after a grace period.
+func (m *JobManager) CancelJob(id string) (*JobInfo, error) {
+	j, err := m.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !j.stop(JobCanceled) {
+		return nil, fmt.Errorf("job %s is not running", id)
+	}
+	select {
+	case <-j.done:
+	case <-time.After(2 * jobCancelGrace):
+	}
+	info := j.snapshot()
+	return &info, nil
+}
+
+// Shutdown cancels all running jobs and stops the reaper
+func (m *JobManager) Shutdown() {
+	m.stopOnce.Do(func() { close(m.stopCh) })
+	m.mu.Lock()
+	list := make([]*job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		list = append(list, j)
+	}
+	m.mu.Unlock()
+	for _, j := range list {
+		if j.stop(JobCanceled) {
+			<-j.done
+		}
+	}
+}
+
+func (m *JobManager) get(id string) (*job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("job not found: %s", id)
+	}
+	return j, nil
+}
+
+func (m *JobManager) countRunning() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	running := 0
+	for _, j := range m.jobs {
+		j.mu.Lock()
+		if j.info.Status == JobRunning {
+			running++
+		}
+		j.mu.Unlock()
+	}
+	return running
+}
+
+// wait records the job's outcome once its process exits
+func (m *JobManager) wait(j *job, timer *time.Timer) {
+	err := j.cmd.Wait()
+	timer.Stop()
+	j.lease.Release(j.cmd.ProcessState)
+	if exceeded := j.lease.Exceeded(); exceeded != nil {
+		err = exceeded
+	}
+	completed := time.Now()
+
+	j.mu.Lock()
+	exitCode := 0
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		} else {
+			exitCode = -1
+		}
+	}
+	switch {
+	case j.stopStatus != "":
+		j.info.Status = j.stopStatus
+	case exitCode == 0:
+		j.info.Status = JobSucceeded
+	default:
+		j.info.Status = JobFailed
+	}
+	if err != nil {
+		j.info.Error = err.Error()
+	}
+	j.info.ExitCode = exitCode
+	j.info.CompletedAt = &completed
+	j.info.Duration = completed.Sub(j.info.StartedAt).Milliseconds()
+	info := j.info
+	output := string(j.logBuf)
+	close(j.notify)
+	close(j.done)
+	j.mu.Unlock()
+
+	jobLog.Info("Job finished", "job_id", info.ID, "status", info.Status, "exit_code", info.ExitCode, "duration_ms", info.Duration)
+
+	if m.db != nil {
+		cmdLog := &models.CommandLog{
+			ID:          info.ID,
+			AgentID:     info.AgentID,
+			BeadID:      info.BeadID,
+			ProjectID:   info.ProjectID,
+			Command:     info.Command,
+			WorkingDir:  info.WorkingDir,
+			ExitCode:    info.ExitCode,
+			Stdout:      output,
+			Duration:    info.Duration,
+			Context:     j.context,
+			StartedAt:   info.StartedAt,
+			CompletedAt: completed,
+			CreatedAt:   info.StartedAt,
+		}
+		if err := insertCommandLog(m.db, cmdLog); err != nil {
+			jobLog.Warn("Failed to save command log", "job_id", info.ID, "error", err)
+		}
+	}
+}
+
+func (m *JobManager) reapLoop() {
+	ticker := time.NewTicker(jobReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case now := <-ticker.C:
+			m.reapFinished(now)
+		}
+	}
+}
+
+// reapFinished forgets jobs that finished longer than the retention period ago
+func (m *JobManager) reapFinished(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, j := range m.jobs {
+		j.mu.Lock()
+		expired := j.info.CompletedAt != nil && now.Sub(*j.info.CompletedAt) > m.config.Retention
+		j.mu.Unlock()
+		if expired {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func (j *job) snapshot() JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	info := j.info
+	info.LogBytes = j.logStart + int64(len(j.logBuf))
+	if info.Status == JobRunning {
+		info.Duration = time.Since(info.StartedAt).Milliseconds()
+	}
+	return info
+}
+
+// stop signals the job's process group to exit and records why. It returns
+// false if the job is not running.
+func (j *job) stop(status string) bool {
+	j.mu.Lock()
+	if j.info.Status != JobRunning || j.stopStatus != "" {
+		running := j.info.Status == JobRunning
+		j.mu.Unlock()
+		return running
+	}
+	j.stopStatus = status
+	j.mu.Unlock()
+
+	terminateProcessGroup(j.cmd)
+	time.AfterFunc(jobCancelGrace, func() {
+		select {
+		case <-j.done:
+		default:
+			killProcessGroup(j.cmd)
+		}
+	})
+	return true
+}
+
+// jobLogWriter appends command output to the job's retained log
+type jobLogWriter struct {
+	job *job
+}
+
+func (w *jobLogWriter) Write(p []byte) (int, error) {
+	j := w.job
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.logBuf = append(j.logBuf, p...)
+	if over := len(j.logBuf) - j.limit; over > 0 {
+		j.logBuf = append(j.logBuf[:0], j.logBuf[over:]...)
+		j.logStart += int64(over)
+	}
+	select {
+	case <-j.done:
+	default:
+		close(j.notify)
+		j.notify = make(chan struct{})
+	}
+	return len(p), nil
+}
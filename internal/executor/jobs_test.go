@@ -0,0 +1,132 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func waitForJob(t *testing.T, m *JobManager, id string) *JobInfo {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		info, err := m.GetJob(id)
+		if err != nil {
+			t.Fatalf("GetJob: %v", err)
+		}
+		if info.Status != JobRunning {
+			return info
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func TestJobManagerRunsInBackground(t *testing.T) {
+	m := NewJobManager(nil, JobConfig{})
+	defer m.Shutdown()
+
+	info, err := m.StartJob(context.Background(), ExecuteCommandRequest{
+		BeadID:     "bead-1",
+		Command:    "echo hello job",
+		WorkingDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("StartJob: %v", err)
+	}
+	if !strings.HasPrefix(info.ID, "job-") {
+		t.Errorf("ID = %q", info.ID)
+	}
+
+	final := waitForJob(t, m, info.ID)
+	if final.Status != JobSucceeded || final.ExitCode != 0 || final.CompletedAt == nil {
+		t.Errorf("final = %+v", final)
+	}
+
+	page, err := m.ReadLog(context.Background(), info.ID, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("ReadLog: %v", err)
+	}
+	if page.Data != "hello job\n" || !page.Done || page.NextOffset != int64(len(page.Data)) {
+		t.Errorf("page = %+v", page)
+	}
+
+	if got := m.ListJobs("bead-1", JobSucceeded); len(got) != 1 {
+		t.Errorf("ListJobs = %d, want 1", len(got))
+	}
+}
+
+func TestJobManagerCancel(t *testing.T) {
+	m := NewJobManager(nil, JobConfig{})
+	defer m.Shutdown()
+
+	info, err := m.StartJob(context.Background(), ExecuteCommandRequest{
+		Command:    "tail -f /dev/null",
+		WorkingDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("StartJob: %v", err)
+	}
+
+	canceled, err := m.CancelJob(info.ID)
+	if err != nil {
+		t.Fatalf("CancelJob: %v", err)
+	}
+	if canceled.Status != JobCanceled {
+		t.Errorf("Status = %s, want %s", canceled.Status, JobCanceled)
+	}
+	if _, err := m.CancelJob(info.ID); err == nil {
+		t.Error("expected error canceling a finished job")
+	}
+}
+
+func TestJobManagerTimeout(t *testing.T) {
+	m := NewJobManager(nil, JobConfig{})
+	defer m.Shutdown()
+
+	info, err := m.StartJob(context.Background(), ExecuteCommandRequest{
+		Command:    "tail -f /dev/null",
+		WorkingDir: t.TempDir(),
+		Timeout:    1,
+	})
+	if err != nil {
+		t.Fatalf("StartJob: %v", err)
+	}
+	if final := waitForJob(t, m, info.ID); final.Status != JobTimedOut {
+		t.Errorf("Status = %s, want %s", final.Status, JobTimedOut)
+	}
+}
+
+func TestJobLogWriterLimit(t *testing.T) {
+	j := &job{limit: 4, notify: make(chan struct{}), done: make(chan struct{})}
+	w := &jobLogWriter{job: j}
+	w.Write([]byte("abc"))
+	w.Write([]byte("def"))
+	if string(j.logBuf) != "cdef" || j.logStart != 2 {
+		t.Errorf("logBuf = %q, logStart = %d", j.logBuf, j.logStart)
+	}
+
+	m := &JobManager{jobs: map[string]*job{"job-1": j}}
+	j.info = JobInfo{ID: "job-1", Status: JobSucceeded}
+	page, err := m.ReadLog(context.Background(), "job-1", 0, 10, 0)
+	if err != nil {
+		t.Fatalf("ReadLog: %v", err)
+	}
+	if !page.Truncated || page.Offset != 2 || page.Data != "cdef" {
+		t.Errorf("page = %+v", page)
+	}
+	tail, _ := m.ReadLog(context.Background(), "job-1", -1, 2, 0)
+	if tail.Data != "ef" || tail.Offset != 4 {
+		t.Errorf("tail = %+v", tail)
+	}
+}
+
+func TestJobManagerRejectsDisallowedCommand(t *testing.T) {
+	m := NewJobManager(nil, JobConfig{})
+	defer m.Shutdown()
+	if _, err := m.StartJob(context.Background(), ExecuteCommandRequest{Command: "rm -rf /"}); err == nil {
+		t.Error("expected validation error")
+	}
+}
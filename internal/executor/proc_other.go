@@ -0,0 +1,17 @@
+//go:build !unix
+
+package executor
+
+import "os/exec"
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func terminateProcessGroup(cmd *exec.Cmd) {
+	killProcessGroup(cmd)
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
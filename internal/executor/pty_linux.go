@@ -0,0 +1,91 @@
+//go:build linux
+
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// winsize mirrors struct winsize from <sys/ioctl.h>.
+type winsize struct {
+	Rows   uint16
+	Cols   uint16
+	XPixel uint16
+	YPixel uint16
+}
+
+// startPTY allocates a pseudo-terminal, attaches it to cmd's stdio as the
+// controlling terminal of a new session, and starts cmd. The returned file is
+// the PTY master; the caller owns it.
+func startPTY(cmd *exec.Cmd, rows, cols uint16) (*os.File, error) {
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open /dev/ptmx: %w", err)
+	}
+
+	var unlock int32
+	if err := ioctl(ptmx, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		ptmx.Close()
+		return nil, fmt.Errorf("unlock pty: %w", err)
+	}
+	var ptyNum uint32
+	if err := ioctl(ptmx, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&ptyNum))); err != nil {
+		ptmx.Close()
+		return nil, fmt.Errorf("get pty number: %w", err)
+	}
+
+	tty, err := os.OpenFile("/dev/pts/"+strconv.Itoa(int(ptyNum)), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		ptmx.Close()
+		return nil, fmt.Errorf("open pty slave: %w", err)
+	}
+	defer tty.Close()
+
+	if err := setWinsize(ptmx, rows, cols); err != nil {
+		ptmx.Close()
+		return nil, err
+	}
+
+	cmd.Stdin = tty
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		ptmx.Close()
+		return nil, err
+	}
+	return ptmx, nil
+}
+
+// setWinsize resizes the terminal attached to the PTY master.
+func setWinsize(ptmx *os.File, rows, cols uint16) error {
+	ws := winsize{Rows: rows, Cols: cols}
+	if err := ioctl(ptmx, syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&ws))); err != nil {
+		return fmt.Errorf("set pty size: %w", err)
+	}
+	return nil
+}
+
+// ioctl issues an ioctl on f without switching it to blocking mode, so reads
+// on the master can still be interrupted by Close.
+func ioctl(f *os.File, req, arg uintptr) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, arg)
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package executor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+var errPTYUnsupported = errors.New("interactive sessions are only supported on linux")
+
+func startPTY(cmd *exec.Cmd, rows, cols uint16) (*os.File, error) {
+	return nil, errPTYUnsupported
+}
+
+func setWinsize(ptmx *os.File, rows, cols uint16) error {
+	return errPTYUnsupported
+}
@@ -0,0 +1,349 @@
+package executor
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Quota names reported in QuotaExceededError
+const (
+	QuotaConcurrentCommands = "concurrent_commands"
+	QuotaCPUSeconds         = "cpu_seconds_per_day"
+	QuotaMemory             = "memory_mb"
+	QuotaDisk               = "disk_mb"
+	QuotaWallClock          = "wall_clock_seconds_per_day"
+)
+
+const (
+	quotaDiskCacheTTL   = time.Minute
+	quotaMemoryPoll     = 500 * time.Millisecond
+	quotaNotifyInterval = time.Hour // Minimum gap between notifications for the same project and quota
+	bytesPerMB          = 1024 * 1024
+)
+
+// ProjectQuota limits one project's executor usage. Zero values are unlimited.
+type ProjectQuota struct {
+	MaxConcurrentCommands int           `json:"max_concurrent_commands"`
+	MaxCPUSecondsPerDay   float64       `json:"max_cpu_seconds_per_day"`
+	MaxMemoryMB           int64         `json:"max_memory_mb"` // Per command, including its children
+	MaxDiskMB             int64         `json:"max_disk_mb"`   // Size of the command's working directory
+	MaxWallClockPerDay    time.Duration `json:"max_wall_clock_per_day"`
+}
+
+// QuotaUsage is a project's executor usage for the current day
+type QuotaUsage struct {
+	ProjectID        string  `json:"project_id"`
+	Day              string  `json:"day"`
+	Running          int     `json:"running"`
+	CPUSeconds       float64 `json:"cpu_seconds"`
+	WallClockSeconds float64 `json:"wall_clock_seconds"`
+	PeakMemoryMB     int64   `json:"peak_memory_mb"`
+	DiskMB           int64   `json:"disk_mb"` // Last measured working directory size
+}
+
+// QuotaExceededError is returned when a command would exceed, or did exceed,
+// one of its project's quotas.
+type QuotaExceededError struct {
+	ProjectID string  `json:"project_id"`
+	Quota     string  `json:"quota"`
+	Limit     float64 `json:"limit"`
+	Used      float64 `json:"used"`
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("project %s exceeded executor quota %s (used %g, limit %g)", e.ProjectID, e.Quota, e.Used, e.Limit)
+}
+
+type diskSample struct {
+	mb      int64
+	sampled time.Time
+}
+
+// QuotaManager enforces per-project executor quotas. Commands acquire a lease
+// before starting and release it with their resource usage when they finish.
+// A nil *QuotaManager enforces nothing.
+type QuotaManager struct {
+	mu         sync.Mutex
+	defaults   ProjectQuota
+	overrides  map[string]ProjectQuota
+	usage      map[string]*QuotaUsage
+	disk       map[string]diskSample
+	notified   map[string]time.Time
+	onExceeded func(*QuotaExceededError)
+	now        func() time.Time
+
+	diskWalks singleflight.Group // One walk per directory at a time, outside mu
+}
+
+// NewQuotaManager creates a quota manager applying defaults to every project
+// unless overridden.
+func NewQuotaManager(defaults ProjectQuota, overrides map[string]ProjectQuota) *QuotaManager {
+	o := make(map[string]ProjectQuota, len(overrides))
+	for id, q := range overrides {
+		o[id] = q
+	}
+	return &QuotaManager{
+		defaults:  defaults,
+		overrides: o,
+		usage:     make(map[string]*QuotaUsage),
+		disk:      make(map[string]diskSample),
+		notified:  make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+// SetOnExceeded registers a callback invoked when a project exceeds a quota.
+// Calls are throttled per project and quota.
+func (q *QuotaManager) SetOnExceeded(fn func(*QuotaExceededError)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.onExceeded = fn
+}
+
+// SetProjectQuota overrides the default quota for one project
+func (q *QuotaManager) SetProjectQuota(projectID string, quota ProjectQuota) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.overrides[projectID] = quota
+}
+
+// QuotaFor returns the quota that applies to a project
+func (q *QuotaManager) QuotaFor(projectID string) ProjectQuota {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.quotaLocked(projectID)
+}
+
+// Usage returns a project's usage for the current day
+func (q *QuotaManager) Usage(projectID string) QuotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return *q.usageLocked(projectID)
+}
+
+// QuotaLease tracks one running command against its project's quota
+type QuotaLease struct {
+	manager   *QuotaManager
+	projectID string
+	limit     int64 // Memory limit in bytes, 0 when unlimited
+	started   time.Time
+	once      sync.Once
+	stopWatch chan struct{}
+	exceeded  *QuotaExceededError
+	mu        sync.Mutex
+}
+
+// Acquire admits a command for projectID, failing with *QuotaExceededError
+// when the project is at its concurrency limit or has used its daily budget
+// or disk allowance.
+func (q *QuotaManager) Acquire(projectID, workDir string) (*QuotaLease, error) {
+	if q == nil {
+		return nil, nil
+	}
+
+	quota := q.QuotaFor(projectID)
+	diskMB := int64(-1)
+	if quota.MaxDiskMB > 0 && workDir != "" {
+		diskMB = q.diskUsage(workDir)
+	}
+
+	q.mu.Lock()
+	usage := q.usageLocked(projectID)
+	if diskMB >= 0 {
+		usage.DiskMB = diskMB
+	}
+	var exceeded *QuotaExceededError
+	switch {
+	case quota.MaxConcurrentCommands > 0 && usage.Running >= quota.MaxConcurrentCommands:
+		exceeded = &QuotaExceededError{Quota: QuotaConcurrentCommands, Limit: float64(quota.MaxConcurrentCommands), Used: float64(usage.Running)}
+	case quota.MaxCPUSecondsPerDay > 0 && usage.CPUSeconds >= quota.MaxCPUSecondsPerDay:
+		exceeded = &QuotaExceededError{Quota: QuotaCPUSeconds, Limit: quota.MaxCPUSecondsPerDay, Used: usage.CPUSeconds}
+	case quota.MaxWallClockPerDay > 0 && usage.WallClockSeconds >= quota.MaxWallClockPerDay.Seconds():
+		exceeded = &QuotaExceededError{Quota: QuotaWallClock, Limit: quota.MaxWallClockPerDay.Seconds(), Used: usage.WallClockSeconds}
+	case diskMB > quota.MaxDiskMB:
+		exceeded = &QuotaExceededError{Quota: QuotaDisk, Limit: float64(quota.MaxDiskMB), Used: float64(diskMB)}
+	}
+	if exceeded != nil {
+		q.mu.Unlock()
+		exceeded.ProjectID = projectID
+		q.report(exceeded)
+		return nil, exceeded
+	}
+	usage.Running++
+	q.mu.Unlock()
+
+	return &QuotaLease{
+		manager:   q,
+		projectID: projectID,
+		limit:     quota.MaxMemoryMB * bytesPerMB,
+		started:   q.now(),
+		stopWatch: make(chan struct{}),
+	}, nil
+}
+
+// Watch enforces the memory quota on a started command, killing its process
+// group if it grows past the limit. It is a no-op when memory is unlimited.
+func (l *QuotaLease) Watch(cmd *exec.Cmd) {
+	if l == nil || l.limit <= 0 || cmd.Process == nil {
+		return
+	}
+	pid := cmd.Process.Pid
+	go func() {
+		ticker := time.NewTicker(quotaMemoryPoll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stopWatch:
+				return
+			case <-ticker.C:
+				rss, err := processGroupRSS(pid)
+				if err != nil {
+					return
+				}
+				l.recordPeak(rss)
+				if rss > l.limit {
+					exceeded := &QuotaExceededError{
+						ProjectID: l.projectID,
+						Quota:     QuotaMemory,
+						Limit:     float64(l.limit / bytesPerMB),
+						Used:      float64(rss / bytesPerMB),
+					}
+					l.mu.Lock()
+					l.exceeded = exceeded
+					l.mu.Unlock()
+					killProcessGroup(cmd)
+					l.manager.report(exceeded)
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Exceeded returns the quota the command was killed for, if any
+func (l *QuotaLease) Exceeded() *QuotaExceededError {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.exceeded
+}
+
+// Release ends the lease, charging the command's CPU and wall-clock time to
+// its project. state may be nil if the command never ran.
+func (l *QuotaLease) Release(state *os.ProcessState) {
+	if l == nil {
+		return
+	}
+	l.once.Do(func() {
+		close(l.stopWatch)
+		q := l.manager
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		usage := q.usageLocked(l.projectID)
+		if usage.Running > 0 {
+			usage.Running--
+		}
+		usage.WallClockSeconds += q.now().Sub(l.started).Seconds()
+		if state != nil {
+			usage.CPUSeconds += (state.UserTime() + state.SystemTime()).Seconds()
+			if mb := peakRSS(state) / bytesPerMB; mb > usage.PeakMemoryMB {
+				usage.PeakMemoryMB = mb
+			}
+		}
+	})
+}
+
+func (l *QuotaLease) recordPeak(rss int64) {
+	q := l.manager
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	usage := q.usageLocked(l.projectID)
+	if mb := rss / bytesPerMB; mb > usage.PeakMemoryMB {
+		usage.PeakMemoryMB = mb
+	}
+}
+
+func (q *QuotaManager) quotaLocked(projectID string) ProjectQuota {
+	if quota, ok := q.overrides[projectID]; ok {
+		return quota
+	}
+	return q.defaults
+}
+
+// usageLocked returns the project's usage, starting a fresh day when the date changes
+func (q *QuotaManager) usageLocked(projectID string) *QuotaUsage {
+	day := q.now().Format("2006-01-02")
+	usage, ok := q.usage[projectID]
+	if !ok {
+		usage = &QuotaUsage{ProjectID: projectID, Day: day}
+		q.usage[projectID] = usage
+	}
+	if usage.Day != day {
+		*usage = QuotaUsage{ProjectID: projectID, Day: day, Running: usage.Running, DiskMB: usage.DiskMB}
+	}
+	return usage
+}
+
+// diskUsage returns the size of dir in MB, measured at most once per
+// quotaDiskCacheTTL. The walk runs without holding mu, so a large workdir
+// doesn't hold up other projects' commands.
+func (q *QuotaManager) diskUsage(dir string) int64 {
+	q.mu.Lock()
+	sample, ok := q.disk[dir]
+	fresh := ok && q.now().Sub(sample.sampled) < quotaDiskCacheTTL
+	q.mu.Unlock()
+	if fresh {
+		return sample.mb
+	}
+	mb, _, _ := q.diskWalks.Do(dir, func() (interface{}, error) {
+		mb := measureDisk(dir)
+		q.mu.Lock()
+		q.disk[dir] = diskSample{mb: mb, sampled: q.now()}
+		q.mu.Unlock()
+		return mb, nil
+	})
+	return mb.(int64)
+}
+
+func measureDisk(dir string) int64 {
+	var total int64
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total / bytesPerMB
+}
+
+// report invokes the exceeded callback unless this project and quota were
+// reported recently.
+func (q *QuotaManager) report(err *QuotaExceededError) {
+	q.mu.Lock()
+	key := err.ProjectID + "/" + err.Quota
+	now := q.now()
+	if last, ok := q.notified[key]; ok && now.Sub(last) < quotaNotifyInterval {
+		q.mu.Unlock()
+		return
+	}
+	q.notified[key] = now
+	fn := q.onExceeded
+	q.mu.Unlock()
+	if fn != nil {
+		fn(err)
+	}
+}
@@ -0,0 +1,145 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuotaManagerConcurrency(t *testing.T) {
+	q := NewQuotaManager(ProjectQuota{MaxConcurrentCommands: 1}, nil)
+
+	lease, err := q.Acquire("proj-1", "")
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	_, err = q.Acquire("proj-1", "")
+	var qerr *QuotaExceededError
+	if !errors.As(err, &qerr) || qerr.Quota != QuotaConcurrentCommands || qerr.ProjectID != "proj-1" {
+		t.Fatalf("err = %v, want concurrency quota error", err)
+	}
+	if _, err := q.Acquire("proj-2", ""); err != nil {
+		t.Errorf("other project should not be limited: %v", err)
+	}
+
+	lease.Release(nil)
+	lease.Release(nil)
+	if got := q.Usage("proj-1").Running; got != 0 {
+		t.Errorf("Running = %d after release, want 0", got)
+	}
+	if _, err := q.Acquire("proj-1", ""); err != nil {
+		t.Errorf("Acquire after release: %v", err)
+	}
+}
+
+func TestQuotaManagerDailyWallClock(t *testing.T) {
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	q := NewQuotaManager(ProjectQuota{}, map[string]ProjectQuota{
+		"proj-1": {MaxWallClockPerDay: time.Minute},
+	})
+	q.now = func() time.Time { return now }
+
+	lease, err := q.Acquire("proj-1", "")
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	lease.Release(nil)
+
+	_, err = q.Acquire("proj-1", "")
+	var qerr *QuotaExceededError
+	if !errors.As(err, &qerr) || qerr.Quota != QuotaWallClock || qerr.Used != 120 {
+		t.Fatalf("err = %v, want wall clock quota error", err)
+	}
+
+	now = now.Add(24 * time.Hour)
+	if _, err := q.Acquire("proj-1", ""); err != nil {
+		t.Errorf("budget should reset the next day: %v", err)
+	}
+}
+
+func TestQuotaManagerDisk(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "big"), make([]byte, 2*bytesPerMB), 0644); err != nil {
+		t.Fatal(err)
+	}
+	q := NewQuotaManager(ProjectQuota{MaxDiskMB: 1}, nil)
+	_, err := q.Acquire("proj-1", dir)
+	var qerr *QuotaExceededError
+	if !errors.As(err, &qerr) || qerr.Quota != QuotaDisk || qerr.Used != 2 {
+		t.Fatalf("err = %v, want disk quota error", err)
+	}
+}
+
+func TestQuotaManagerNotificationThrottle(t *testing.T) {
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	q := NewQuotaManager(ProjectQuota{MaxConcurrentCommands: 1}, nil)
+	q.now = func() time.Time { return now }
+	var reported []*QuotaExceededError
+	q.SetOnExceeded(func(err *QuotaExceededError) { reported = append(reported, err) })
+
+	if _, err := q.Acquire("proj-1", ""); err != nil {
+		t.Fatal(err)
+	}
+	q.Acquire("proj-1", "")
+	q.Acquire("proj-1", "")
+	if len(reported) != 1 {
+		t.Fatalf("reported %d times, want 1", len(reported))
+	}
+	now = now.Add(quotaNotifyInterval)
+	q.Acquire("proj-1", "")
+	if len(reported) != 2 {
+		t.Errorf("reported %d times after interval, want 2", len(reported))
+	}
+}
+
+func TestQuotaManagerNilIsUnlimited(t *testing.T) {
+	var q *QuotaManager
+	lease, err := q.Acquire("proj-1", "")
+	if err != nil || lease != nil {
+		t.Fatalf("Acquire = %v, %v", lease, err)
+	}
+	lease.Release(nil)
+	if lease.Exceeded() != nil {
+		t.Error("nil lease reported an exceeded quota")
+	}
+}
+
+func TestJobManagerEnforcesQuota(t *testing.T) {
+	m := NewJobManager(nil, JobConfig{})
+	defer m.Shutdown()
+	m.SetQuotaManager(NewQuotaManager(ProjectQuota{MaxConcurrentCommands: 1}, nil))
+
+	info, err := m.StartJob(context.Background(), ExecuteCommandRequest{
+		ProjectID:  "proj-1",
+		Command:    "tail -f /dev/null",
+		WorkingDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("StartJob: %v", err)
+	}
+	_, err = m.StartJob(context.Background(), ExecuteCommandRequest{
+		ProjectID:  "proj-1",
+		Command:    "echo second",
+		WorkingDir: t.TempDir(),
+	})
+	if err == nil || !strings.Contains(err.Error(), QuotaConcurrentCommands) {
+		t.Fatalf("err = %v, want concurrency quota error", err)
+	}
+
+	if _, err := m.CancelJob(info.ID); err != nil {
+		t.Fatalf("CancelJob: %v", err)
+	}
+	waitForJob(t, m, info.ID)
+	if _, err := m.StartJob(context.Background(), ExecuteCommandRequest{
+		ProjectID:  "proj-1",
+		Command:    "echo third",
+		WorkingDir: t.TempDir(),
+	}); err != nil {
+		t.Errorf("StartJob after cancel: %v", err)
+	}
+}
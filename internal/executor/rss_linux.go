@@ -0,0 +1,64 @@
+//go:build linux
+
+package executor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// processGroupRSS returns the resident memory, in bytes, of every process in
+// the process group led by pgid.
+func processGroupRSS(pgid int) (int64, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0, err
+	}
+	pageSize := int64(os.Getpagesize())
+	var total int64
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		dir := filepath.Join("/proc", entry.Name())
+		stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue
+		}
+		// Fields after the parenthesised command name: state ppid pgrp ...
+		end := bytes.LastIndexByte(stat, ')')
+		if end < 0 {
+			continue
+		}
+		fields := bytes.Fields(stat[end+1:])
+		if len(fields) < 3 {
+			continue
+		}
+		if pgrp, err := strconv.Atoi(string(fields[2])); err != nil || pgrp != pgid {
+			continue
+		}
+		statm, err := os.ReadFile(filepath.Join(dir, "statm"))
+		if err != nil {
+			continue
+		}
+		memFields := bytes.Fields(statm)
+		if len(memFields) < 2 {
+			continue
+		}
+		if pages, err := strconv.ParseInt(string(memFields[1]), 10, 64); err == nil {
+			total += pages * pageSize
+		}
+	}
+	return total, nil
+}
+
+// peakRSS returns the largest resident set of a finished process, in bytes.
+func peakRSS(state *os.ProcessState) int64 {
+	if ru, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return ru.Maxrss * 1024
+	}
+	return 0
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package executor
+
+import (
+	"errors"
+	"os"
+)
+
+func processGroupRSS(pgid int) (int64, error) {
+	return 0, errors.New("memory sampling is only supported on linux")
+}
+
+func peakRSS(state *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,570 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/apperr"
+)
+
+// Interactive session defaults and limits
+const (
+	DefaultSessionIdleTimeout = 10 * time.Minute
+	DefaultSessionMaxLifetime = time.Hour
+	DefaultSessionOutputLimit = 256 * 1024 // Unread output bytes kept per session
+	DefaultMaxSessions        = 16
+	MaxSessionInputBytes      = 64 * 1024
+
+	defaultSessionRows  = 24
+	defaultSessionCols  = 120
+	sessionReapInterval = 15 * time.Second
+	subscriberBuffer    = 64
+)
+
+// interactiveCommands are allowed in sessions in addition to allowedCommands.
+// Sessions exist for tools that need a terminal: database CLIs, debuggers and REPLs.
+var interactiveCommands = map[string]bool{
+	"psql":      true,
+	"mysql":     true,
+	"sqlite3":   true,
+	"redis-cli": true,
+	"mongosh":   true,
+	"dlv":       true,
+	"gdb":       true,
+	"pdb":       true,
+	"irb":       true,
+	"ghci":      true,
+}
+
+// Session event types delivered to subscribers
+const (
+	SessionEventOutput = "output"
+	SessionEventExit   = "exit"
+)
+
+// SessionConfig bounds interactive sessions. Zero values use the defaults.
+type SessionConfig struct {
+	IdleTimeout time.Duration // Close sessions with no input or output for this long
+	MaxLifetime time.Duration // Close sessions older than this regardless of activity
+	OutputLimit int           // Max unread output bytes buffered per session
+	MaxSessions int           // Max concurrently running sessions
+}
+
+// OpenSessionRequest describes an interactive command to start under a PTY
+type OpenSessionRequest struct {
+	AgentID     string `json:"agent_id"`
+	BeadID      string `json:"bead_id"`
+	ProjectID   string `json:"project_id"`
+	Command     string `json:"command"`
+	WorkingDir  string `json:"working_dir"`
+	IdleTimeout int    `json:"idle_timeout_seconds"` // Optional, capped at the manager's idle timeout
+	Rows        uint16 `json:"rows"`
+	Cols        uint16 `json:"cols"`
+}
+
+// SessionInfo is a snapshot of an interactive session's state
+type SessionInfo struct {
+	ID            string    `json:"id"`
+	AgentID       string    `json:"agent_id"`
+	BeadID        string    `json:"bead_id"`
+	ProjectID     string    `json:"project_id"`
+	Command       string    `json:"command"`
+	WorkingDir    string    `json:"working_dir"`
+	StartedAt     time.Time `json:"started_at"`
+	LastActivity  time.Time `json:"last_activity"`
+	Running       bool      `json:"running"`
+	ExitCode      int       `json:"exit_code"`
+	BufferedBytes int       `json:"buffered_bytes"`
+	DroppedBytes  int64     `json:"dropped_bytes"`
+}
+
+// SessionOutput is output drained from a session's unread buffer
+type SessionOutput struct {
+	SessionID string `json:"session_id"`
+	Output    string `json:"output"`
+	Running   bool   `json:"running"`
+	ExitCode  int    `json:"exit_code"`
+	Truncated bool   `json:"truncated"` // Output was dropped because the buffer limit was reached
+}
+
+// SessionEvent is delivered to subscribers as a session produces output or exits
+type SessionEvent struct {
+	SessionID string `json:"session_id"`
+	Type      string `json:"type"`
+	Data      string `json:"data,omitempty"`
+	ExitCode  int    `json:"exit_code,omitempty"`
+}
+
+type session struct {
+	mu          sync.Mutex
+	info        SessionInfo
+	cmd         *exec.Cmd
+	lease       *QuotaLease
+	pty         *os.File
+	buf         []byte
+	limit       int
+	truncated   bool
+	idleTimeout time.Duration
+	notify      chan struct{} // Closed and replaced whenever output arrives or the session exits
+	done        chan struct{}
+	subscribers map[chan SessionEvent]struct{}
+}
+
+// SessionManager runs interactive commands under pseudo-terminals so agents can
+// drive tools that expect a terminal, and lets UI clients watch them live.
+type SessionManager struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+	config   SessionConfig
+	quotas   *QuotaManager
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSessionManager creates a session manager and starts its timeout reaper
+func NewSessionManager(cfg SessionConfig) *SessionManager {
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = DefaultSessionIdleTimeout
+	}
+	if cfg.MaxLifetime <= 0 {
+		cfg.MaxLifetime = DefaultSessionMaxLifetime
+	}
+	if cfg.OutputLimit <= 0 {
+		cfg.OutputLimit = DefaultSessionOutputLimit
+	}
+	if cfg.MaxSessions <= 0 {
+		cfg.MaxSessions = DefaultMaxSessions
+	}
+	m := &SessionManager{
+		sessions: make(map[string]*session),
+		config:   cfg,
+		stopCh:   make(chan struct{}),
+	}
+	go m.reapLoop()
+	return m
+}
+
+// SetQuotaManager enables per-project quota enforcement for sessions
+func (m *SessionManager) SetQuotaManager(q *QuotaManager) {
+	m.quotas = q
+}
+
+// validateSessionCommand checks an interactive command against the allowlists.
+// Sessions are started directly, never through a shell.
+func validateSessionCommand(command string) ([]string, error) {
+	parts := strings.Fields(command)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	for _, meta := range []string{"|", "&&", "||", ";", ">", "<", "&", "`", "$("} {
+		if strings.Contains(command, meta) {
+			return nil, fmt.Errorf("shell operators are not supported in interactive sessions: %s", meta)
+		}
+	}
+	binary := filepath.Base(parts[0])
+	if !allowedCommands[binary] && !interactiveCommands[binary] {
+		return nil, apperr.PolicyDenied("command not allowed in interactive session: %s", binary)
+	}
+	return parts, nil
+}
+
+// OpenSession starts an interactive command under a PTY
+func (m *SessionManager) OpenSession(ctx context.Context, req OpenSessionRequest) (*SessionInfo, error) {
+	parts, err := validateSessionCommand(req.Command)
+	if err != nil {
+		return nil, fmt.Errorf("command validation failed: %w", err)
+	}
+
+	m.mu.Lock()
+	running := 0
+	for _, s := range m.sessions {
+		s.mu.Lock()
+		if s.info.Running {
+			running++
+		}
+		s.mu.Unlock()
+	}
+	m.mu.Unlock()
+	if running >= m.config.MaxSessions {
+		return nil, fmt.Errorf("session limit reached (%d running)", running)
+	}
+
+	workingDir := req.WorkingDir
+	if workingDir == "" {
+		workingDir = "/app/src"
+	}
+	rows, cols := req.Rows, req.Cols
+	if rows == 0 {
+		rows = defaultSessionRows
+	}
+	if cols == 0 {
+		cols = defaultSessionCols
+	}
+	idle := m.config.IdleTimeout
+	if req.IdleTimeout > 0 && time.Duration(req.IdleTimeout)*time.Second < idle {
+		idle = time.Duration(req.IdleTimeout) * time.Second
+	}
+
+	lease, err := m.quotas.Acquire(req.ProjectID, workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The session outlives the request, so it is not bound to ctx.
+	cmd := exec.Command(parts[0], parts[1:]...)
+	cmd.Dir = workingDir
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+
+	ptmx, err := startPTY(cmd, rows, cols)
+	if err != nil {
+		lease.Release(nil)
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	lease.Watch(cmd)
+
+	now := time.Now()
+	s := &session{
+		info: SessionInfo{
+			ID:           fmt.Sprintf("sess-%s", uuid.New().String()[:8]),
+			AgentID:      req.AgentID,
+			BeadID:       req.BeadID,
+			ProjectID:    req.ProjectID,
+			Command:      req.Command,
+			WorkingDir:   workingDir,
+			StartedAt:    now,
+			LastActivity: now,
+			Running:      true,
+			ExitCode:     -1,
+		},
+		cmd:         cmd,
+		lease:       lease,
+		pty:         ptmx,
+		limit:       m.config.OutputLimit,
+		idleTimeout: idle,
+		notify:      make(chan struct{}),
+		done:        make(chan struct{}),
+		subscribers: make(map[chan SessionEvent]struct{}),
+	}
+
+	m.mu.Lock()
+	m.sessions[s.info.ID] = s
+	m.mu.Unlock()
+
+	go s.readLoop()
+
+	sessionLog.Info("Opened session", "session_id", s.info.ID, "agent_id", req.AgentID, "bead_id", req.BeadID, "command", req.Command)
+	info := s.snapshot()
+	return &info, nil
+}
+
+// SendInput writes input to a session's terminal
+func (m *SessionManager) SendInput(id string, input []byte) error {
+	if len(input) > MaxSessionInputBytes {
+		return fmt.Errorf("input exceeds %d bytes", MaxSessionInputBytes)
+	}
+	s, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if !s.info.Running {
+		s.mu.Unlock()
+		return fmt.Errorf("session %s has exited", id)
+	}
+	s.info.LastActivity = time.Now()
+	s.mu.Unlock()
+
+	if _, err := s.pty.Write(input); err != nil {
+		return fmt.Errorf("write to session %s: %w", id, err)
+	}
+	return nil
+}
+
+// ReadOutput drains up to maxBytes of unread output (all of it when maxBytes <= 0).
+// When nothing is buffered it waits up to wait for output or exit.
+func (m *SessionManager) ReadOutput(ctx context.Context, id string, maxBytes int, wait time.Duration) (*SessionOutput, error) {
+	s, err := m.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if len(s.buf) == 0 && s.info.Running && wait > 0 {
+		notify := s.notify
+		s.mu.Unlock()
+		timer := time.NewTimer(wait)
+		select {
+		case <-notify:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+		s.mu.Lock()
+	}
+	defer s.mu.Unlock()
+
+	n := len(s.buf)
+	if maxBytes > 0 && maxBytes < n {
+		n = maxBytes
+	}
+	out := &SessionOutput{
+		SessionID: id,
+		Output:    string(s.buf[:n]),
+		Running:   s.info.Running,
+		ExitCode:  s.info.ExitCode,
+		Truncated: s.truncated,
+	}
+	s.buf = append(s.buf[:0], s.buf[n:]...)
+	s.truncated = false
+	return out, nil
+}
+
+// PeekOutput returns unread output without draining it
+func (m *SessionManager) PeekOutput(id string) (string, error) {
+	s, err := m.get(id)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return string(s.buf), nil
+}
+
+// Resize changes a session's terminal dimensions
+func (m *SessionManager) Resize(id string, rows, cols uint16) error {
+	s, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	return setWinsize(s.pty, rows, cols)
+}
+
+// CloseSession terminates a session and removes it
+func (m *SessionManager) CloseSession(id string) (*SessionInfo, error) {
+	m.mu.Lock()
+	s, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("session not found: %s", id)
+	}
+	s.terminate()
+	info := s.snapshot()
+	sessionLog.Info("Closed session", "session_id", id, "exit_code", info.ExitCode)
+	return &info, nil
+}
+
+// GetSession returns a snapshot of one session
+func (m *SessionManager) GetSession(id string) (*SessionInfo, error) {
+	s, err := m.get(id)
+	if err != nil {
+		return nil, err
+	}
+	info := s.snapshot()
+	return &info, nil
+}
+
+// ListSessions returns snapshots of all sessions, optionally filtered by bead
+func (m *SessionManager) ListSessions(beadID string) []SessionInfo {
+	m.mu.Lock()
+	list := make([]*session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		list = append(list, s)
+	}
+	m.mu.Unlock()
+
+	infos := make([]SessionInfo, 0, len(list))
+	for _, s := range list {
+		info := s.snapshot()
+		if beadID != "" && info.BeadID != beadID {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].StartedAt.Before(infos[j].StartedAt) })
+	return infos
+}
+
+// Subscribe returns a channel of live events for a session. Events are dropped
+// for subscribers that fall behind. The returned function unsubscribes.
+func (m *SessionManager) Subscribe(id string) (<-chan SessionEvent, func(), error) {
+	s, err := m.get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch := make(chan SessionEvent, subscriberBuffer)
+	s.mu.Lock()
+	if !s.info.Running {
+		ch <- SessionEvent{SessionID: id, Type: SessionEventExit, ExitCode: s.info.ExitCode}
+		close(ch)
+		s.mu.Unlock()
+		return ch, func() {}, nil
+	}
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe, nil
+}
+
+// Shutdown terminates all sessions and stops the reaper
+func (m *SessionManager) Shutdown() {
+	m.stopOnce.Do(func() { close(m.stopCh) })
+	m.mu.Lock()
+	ids := make([]string, 0, len(m.sessions))
+	for id := range m.sessions {
+		ids = append(ids, id)
+	}
+	m.mu.Unlock()
+	for _, id := range ids {
+		_, _ = m.CloseSession(id)
+	}
+}
+
+func (m *SessionManager) get(id string) (*session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("session not found: %s", id)
+	}
+	return s, nil
+}
+
+func (m *SessionManager) reapLoop() {
+	ticker := time.NewTicker(sessionReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case now := <-ticker.C:
+			m.reapExpired(now)
+		}
+	}
+}
+
+// reapExpired closes sessions that have been idle or alive too long
+func (m *SessionManager) reapExpired(now time.Time) {
+	m.mu.Lock()
+	var expired []string
+	for id, s := range m.sessions {
+		s.mu.Lock()
+		idle := now.Sub(s.info.LastActivity) > s.idleTimeout
+		tooOld := now.Sub(s.info.StartedAt) > m.config.MaxLifetime
+		s.mu.Unlock()
+		if idle || tooOld {
+			expired = append(expired, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, id := range expired {
+		sessionLog.Warn("Session timed out", "session_id", id)
+		_, _ = m.CloseSession(id)
+	}
+}
+
+func (s *session) snapshot() SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := s.info
+	info.BufferedBytes = len(s.buf)
+	return info
+}
+
+// readLoop copies PTY output into the unread buffer and out to subscribers
+// until the command exits.
+func (s *session) readLoop() {
+	chunk := make([]byte, 4096)
+	for {
+		n, err := s.pty.Read(chunk)
+		if n > 0 {
+			s.appendOutput(chunk[:n])
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	exitCode := 0
+	if err := s.cmd.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		} else {
+			exitCode = -1
+		}
+	}
+	s.pty.Close()
+	s.lease.Release(s.cmd.ProcessState)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info.Running = false
+	s.info.ExitCode = exitCode
+	s.info.LastActivity = time.Now()
+	for ch := range s.subscribers {
+		select {
+		case ch <- SessionEvent{SessionID: s.info.ID, Type: SessionEventExit, ExitCode: exitCode}:
+		default:
+		}
+		close(ch)
+		delete(s.subscribers, ch)
+	}
+	close(s.notify)
+	close(s.done)
+}
+
+func (s *session) appendOutput(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf = append(s.buf, data...)
+	if over := len(s.buf) - s.limit; over > 0 {
+		s.buf = append(s.buf[:0], s.buf[over:]...)
+		s.info.DroppedBytes += int64(over)
+		s.truncated = true
+	}
+	s.info.LastActivity = time.Now()
+
+	event := SessionEvent{SessionID: s.info.ID, Type: SessionEventOutput, Data: string(data)}
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// terminate kills the session's process group and waits for the reader to finish
+func (s *session) terminate() {
+	s.mu.Lock()
+	running := s.info.Running
+	s.mu.Unlock()
+	if running {
+		killProcessGroup(s.cmd)
+	}
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		s.pty.Close()
+		<-s.done
+	}
+}
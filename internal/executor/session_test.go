@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateSessionCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		wantErr bool
+	}{
+		{"psql -h localhost", false},
+		{"python3", false},
+		{"cat", false},
+		{"", true},
+		{"rm -rf /", true},
+		{"psql | tee out", true},
+		{"python3; rm x", true},
+	}
+	for _, tt := range tests {
+		_, err := validateSessionCommand(tt.command)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateSessionCommand(%q) err = %v, wantErr %v", tt.command, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSessionAppendOutputLimit(t *testing.T) {
+	s := &session{limit: 8, notify: make(chan struct{}), subscribers: map[chan SessionEvent]struct{}{}}
+	s.appendOutput([]byte("12345"))
+	s.appendOutput([]byte("67890"))
+	if string(s.buf) != "34567890" {
+		t.Errorf("buf = %q, want oldest bytes dropped", s.buf)
+	}
+	if !s.truncated || s.info.DroppedBytes != 2 {
+		t.Errorf("truncated = %v, dropped = %d", s.truncated, s.info.DroppedBytes)
+	}
+}
+
+func TestSessionManagerInteractive(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("PTY sessions require linux")
+	}
+	m := NewSessionManager(SessionConfig{})
+	defer m.Shutdown()
+
+	info, err := m.OpenSession(context.Background(), OpenSessionRequest{
+		BeadID:     "bead-1",
+		Command:    "cat",
+		WorkingDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Skipf("PTY not available: %v", err)
+	}
+
+	events, unsubscribe, err := m.Subscribe(info.ID)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer unsubscribe()
+
+	if err := m.SendInput(info.ID, []byte("hello session\n")); err != nil {
+		t.Fatalf("SendInput: %v", err)
+	}
+
+	var output strings.Builder
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(output.String(), "hello session") && time.Now().Before(deadline) {
+		out, err := m.ReadOutput(context.Background(), info.ID, 0, 500*time.Millisecond)
+		if err != nil {
+			t.Fatalf("ReadOutput: %v", err)
+		}
+		output.WriteString(out.Output)
+	}
+	if !strings.Contains(output.String(), "hello session") {
+		t.Fatalf("output = %q, want echoed input", output.String())
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Type != SessionEventOutput || ev.SessionID != info.ID {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("subscriber received no output")
+	}
+
+	if got := m.ListSessions("bead-1"); len(got) != 1 {
+		t.Errorf("ListSessions = %d sessions, want 1", len(got))
+	}
+
+	closed, err := m.CloseSession(info.ID)
+	if err != nil {
+		t.Fatalf("CloseSession: %v", err)
+	}
+	if closed.Running {
+		t.Error("session still running after close")
+	}
+	if _, err := m.GetSession(info.ID); err == nil {
+		t.Error("expected closed session to be removed")
+	}
+}
+
+func TestSessionManagerReapsIdle(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("PTY sessions require linux")
+	}
+	m := NewSessionManager(SessionConfig{IdleTimeout: time.Minute})
+	defer m.Shutdown()
+
+	info, err := m.OpenSession(context.Background(), OpenSessionRequest{Command: "cat", WorkingDir: t.TempDir()})
+	if err != nil {
+		t.Skipf("PTY not available: %v", err)
+	}
+	m.reapExpired(time.Now().Add(2 * time.Minute))
+	if _, err := m.GetSession(info.ID); err == nil {
+		t.Error("expected idle session to be reaped")
+	}
+}
@@ -6,16 +6,24 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
+var (
+	shellLog   = logging.Module("shellexecutor")
+	sessionLog = logging.Module("sessionmanager")
+	jobLog     = logging.Module("jobmanager")
+)
+
 // allowedCommands is the allowlist of permitted commands for security
 var allowedCommands = map[string]bool{
 	// Build tools
@@ -69,7 +77,8 @@ var allowedCommands = map[string]bool{
 
 // ShellExecutor provides shell command execution with persistent logging
 type ShellExecutor struct {
-	db *sql.DB
+	db     *sql.DB
+	quotas *QuotaManager
 }
 
 // NewShellExecutor creates a new shell executor
@@ -79,6 +88,11 @@ func NewShellExecutor(db *sql.DB) *ShellExecutor {
 	}
 }
 
+// SetQuotaManager enables per-project quota enforcement for executed commands
+func (e *ShellExecutor) SetQuotaManager(q *QuotaManager) {
+	e.quotas = q
+}
+
 // validateCommand checks if a command is allowed and returns the parsed command parts
 func validateCommand(command string) ([]string, bool, error) {
 	// Empty command check
@@ -113,12 +127,12 @@ func validateCommand(command string) ([]string, bool, error) {
 
 	// Check allowlist
 	if !allowedCommands[binary] {
-		return nil, false, fmt.Errorf("command not allowed: %s (use one of: go, npm, git, pytest, make, docker, or common utilities)", binary)
+		return nil, false, apperr.PolicyDenied("command not allowed: %s (use one of: go, npm, git, pytest, make, docker, or common utilities)", binary)
 	}
 
 	// If requires shell, return original command as single part
 	if requiresShell {
-		log.Printf("[ShellExecutor] Command requires shell interpretation: %s", command)
+		shellLog.Debug("Command requires shell interpretation", "command", command)
 		return []string{command}, true, nil
 	}
 
@@ -135,6 +149,23 @@ type ExecuteCommandRequest struct {
 	WorkingDir string                 `json:"working_dir"`
 	Timeout    int                    `json:"timeout_seconds"` // Optional timeout in seconds (default: 300)
 	Context    map[string]interface{} `json:"context"`
+
+	// OnOutput, when set, receives stdout/stderr chunks as the command produces them.
+	// stream is "stdout" or "stderr". Output is still buffered into the result.
+	OnOutput func(stream string, chunk []byte) `json:"-"`
+}
+
+// outputWriter forwards each write to an OnOutput callback.
+type outputWriter struct {
+	stream   string
+	onOutput func(stream string, chunk []byte)
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	chunk := make([]byte, len(p))
+	copy(chunk, p)
+	w.onOutput(w.stream, chunk)
+	return len(p), nil
 }
 
 // ExecuteCommandResult represents the result of a shell command execution
@@ -175,6 +206,11 @@ func (e *ShellExecutor) ExecuteCommand(ctx context.Context, req ExecuteCommandRe
 		workingDir = "/app/src"
 	}
 
+	lease, err := e.quotas.Acquire(req.ProjectID, workingDir)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create command log entry
 	cmdLog := &models.CommandLog{
 		ID:         fmt.Sprintf("cmd-%s", uuid.New().String()[:8]),
@@ -193,16 +229,16 @@ func (e *ShellExecutor) ExecuteCommand(ctx context.Context, req ExecuteCommandRe
 	defer cancel()
 
 	// Execute command
-	log.Printf("[ShellExecutor] Executing command for agent=%s bead=%s: %s", req.AgentID, req.BeadID, req.Command)
+	shellLog.Info("Executing command", "agent_id", req.AgentID, "bead_id", req.BeadID, "command", req.Command)
 
 	var cmd *exec.Cmd
 	if requiresShell {
 		// Complex command requires shell interpretation (piping, redirection, etc.)
-		log.Printf("[ShellExecutor] Using shell for complex command")
+		shellLog.Debug("Using shell for complex command")
 		cmd = exec.CommandContext(cmdCtx, "/bin/sh", "-c", parts[0])
 	} else {
 		// Simple command - execute directly without shell for security
-		log.Printf("[ShellExecutor] Direct execution (no shell)")
+		shellLog.Debug("Direct execution (no shell)")
 		cmd = exec.CommandContext(cmdCtx, parts[0], parts[1:]...)
 	}
 	cmd.Dir = workingDir
@@ -210,9 +246,27 @@ func (e *ShellExecutor) ExecuteCommand(ctx context.Context, req ExecuteCommandRe
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	if req.OnOutput != nil {
+		cmd.Stdout = io.MultiWriter(&stdout, &outputWriter{stream: "stdout", onOutput: req.OnOutput})
+		cmd.Stderr = io.MultiWriter(&stderr, &outputWriter{stream: "stderr", onOutput: req.OnOutput})
+	}
+
+	setProcessGroup(cmd)
+	// On timeout or cancellation kill everything the command spawned, not
+	// just the direct child
+	cmd.Cancel = func() error {
+		killProcessGroup(cmd)
+		return nil
+	}
+	cmd.WaitDelay = 5 * time.Second
 
 	startTime := time.Now()
-	err = cmd.Run()
+	err = cmd.Start()
+	if err == nil {
+		lease.Watch(cmd)
+		err = cmd.Wait()
+	}
+	lease.Release(cmd.ProcessState)
 	endTime := time.Now()
 	duration := endTime.Sub(startTime).Milliseconds()
 
@@ -233,25 +287,8 @@ func (e *ShellExecutor) ExecuteCommand(ctx context.Context, req ExecuteCommandRe
 	}
 
 	// Save to database
-	contextJSON := ""
-	if cmdLog.Context != nil {
-		if b, err := json.Marshal(cmdLog.Context); err == nil {
-			contextJSON = string(b)
-		}
-	}
-
-	insertQuery := `
-		INSERT INTO command_logs (id, agent_id, bead_id, project_id, command, working_dir, 
-			exit_code, stdout, stderr, duration_ms, started_at, completed_at, context, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-	_, dbErr := e.db.Exec(insertQuery,
-		cmdLog.ID, cmdLog.AgentID, cmdLog.BeadID, cmdLog.ProjectID, cmdLog.Command,
-		cmdLog.WorkingDir, cmdLog.ExitCode, cmdLog.Stdout, cmdLog.Stderr, cmdLog.Duration,
-		cmdLog.StartedAt, cmdLog.CompletedAt, contextJSON, cmdLog.CreatedAt,
-	)
-	if dbErr != nil {
-		log.Printf("[ShellExecutor] Warning: Failed to save command log: %v", dbErr)
+	if dbErr := insertCommandLog(e.db, cmdLog); dbErr != nil {
+		shellLog.Warn("Failed to save command log", "bead_id", req.BeadID, "error", dbErr)
 	}
 
 	// Build result
@@ -271,11 +308,39 @@ func (e *ShellExecutor) ExecuteCommand(ctx context.Context, req ExecuteCommandRe
 		result.Error = err.Error()
 	}
 
-	log.Printf("[ShellExecutor] Command completed: exit_code=%d duration=%dms", cmdLog.ExitCode, duration)
+	shellLog.Info("Command completed", "bead_id", req.BeadID, "exit_code", cmdLog.ExitCode, "duration_ms", duration)
+
+	// A command killed for exceeding its memory quota reports the quota, not the kill signal.
+	if exceeded := lease.Exceeded(); exceeded != nil {
+		result.Error = exceeded.Error()
+		return result, exceeded
+	}
 
 	return result, nil
 }
 
+// insertCommandLog persists a completed command to the command_logs table
+func insertCommandLog(db *sql.DB, cmdLog *models.CommandLog) error {
+	contextJSON := ""
+	if cmdLog.Context != nil {
+		if b, err := json.Marshal(cmdLog.Context); err == nil {
+			contextJSON = string(b)
+		}
+	}
+
+	insertQuery := `
+		INSERT INTO command_logs (id, agent_id, bead_id, project_id, command, working_dir, 
+			exit_code, stdout, stderr, duration_ms, started_at, completed_at, context, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := db.Exec(insertQuery,
+		cmdLog.ID, cmdLog.AgentID, cmdLog.BeadID, cmdLog.ProjectID, cmdLog.Command,
+		cmdLog.WorkingDir, cmdLog.ExitCode, cmdLog.Stdout, cmdLog.Stderr, cmdLog.Duration,
+		cmdLog.StartedAt, cmdLog.CompletedAt, contextJSON, cmdLog.CreatedAt,
+	)
+	return err
+}
+
 // GetCommandLogs retrieves command logs with optional filters
 func (e *ShellExecutor) GetCommandLogs(filters map[string]interface{}, limit int) ([]*models.CommandLog, error) {
 	var logs []*models.CommandLog
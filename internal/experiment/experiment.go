@@ -0,0 +1,519 @@
+// Package experiment runs A/B tests between models and prompts. An
+// experiment names two variants for a category of beads (a bead type,
+// optionally within one project), each a provider or model and optional
+// extra instructions for the agent, and the percentage of beads each
+// receives. The dispatcher routes beads to their variant; when a bead closes
+// or is blocked its outcome is recorded, and reports compare the variants'
+// success rate, cost, review rejections and retries with the statistics to
+// tell a real difference from noise.
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Experiment states
+const (
+	StatusRunning = "running" // New beads are being split between the variants
+	StatusPaused  = "paused"  // No new beads join; assigned beads keep their variant
+	StatusStopped = "stopped" // Finished; assigned beads go back to normal routing
+)
+
+// Variant names
+const (
+	VariantA = "A"
+	VariantB = "B"
+)
+
+// Experiment is an A/B test between two variants
+type Experiment struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Category is the bead type the experiment applies to; empty for every type
+	Category  string     `json:"category,omitempty"`
+	ProjectID string     `json:"project_id,omitempty"` // Empty for every project
+	Variants  []*Variant `json:"variants"`             // A and B
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	// StartedAt is when the experiment last started running; only beads
+	// created after it join, so none are switched mid-flight
+	StartedAt time.Time  `json:"started_at"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+}
+
+// Variant is one arm of an experiment. A bead in it is worked by the
+// provider, or else by an active provider serving the model, with the
+// instructions added to the agent's context.
+type Variant struct {
+	Name         string `json:"name"`
+	ProviderID   string `json:"provider_id,omitempty"`
+	Model        string `json:"model,omitempty"`
+	Instructions string `json:"instructions,omitempty"`
+	// Percent is the share of the category's beads routed to the variant;
+	// the two shares may add up to less than 100, leaving the rest alone
+	Percent int `json:"percent"`
+}
+
+// Variant returns the experiment's variant called name, or nil
+func (e *Experiment) Variant(name string) *Variant {
+	for _, v := range e.Variants {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// matches reports whether a bead belongs to the experiment's category
+func (e *Experiment) matches(bead *models.Bead) bool {
+	if e.Category != "" && !strings.EqualFold(e.Category, bead.Type) {
+		return false
+	}
+	if e.ProjectID != "" && e.ProjectID != bead.ProjectID {
+		return false
+	}
+	return !bead.CreatedAt.Before(e.StartedAt)
+}
+
+// Assignment records which variant a bead was routed to and, once the bead
+// closed or was blocked, how it went
+type Assignment struct {
+	ExperimentID string    `json:"experiment_id"`
+	BeadID       string    `json:"bead_id"`
+	Variant      string    `json:"variant"`
+	AssignedAt   time.Time `json:"assigned_at"`
+	// Excluded beads were routed normally because their variant could not
+	// be served, and don't count towards the results
+	Excluded bool     `json:"excluded,omitempty"`
+	Outcome  *Outcome `json:"outcome,omitempty"`
+}
+
+// Outcome is the metrics of a bead that reached a conclusion. A bead that is
+// reopened and concluded again replaces its outcome.
+type Outcome struct {
+	Success          bool      `json:"success"` // Closed rather than blocked
+	ConcludedAt      time.Time `json:"concluded_at"`
+	CostUSD          float64   `json:"cost_usd"`
+	Tokens           int64     `json:"tokens"`
+	Requests         int64     `json:"requests"`
+	ReviewRejections int       `json:"review_rejections"` // Reviews that requested changes
+	Retries          int       `json:"retries"`           // Dispatches beyond the first
+}
+
+// BeadSource looks beads up
+type BeadSource interface {
+	GetBead(id string) (*models.Bead, error)
+}
+
+// CostSource lists logged provider requests
+type CostSource interface {
+	GetLogs(ctx context.Context, filter *analytics.LogFilter) ([]*analytics.RequestLog, error)
+}
+
+// HistorySource returns a bead's recorded events
+type HistorySource interface {
+	History(beadID string) ([]*beads.BeadEvent, error)
+}
+
+// Sources are the subsystems outcomes are measured from. Without costs an
+// outcome has no spend; without history it has no review rejections.
+type Sources struct {
+	Beads   BeadSource
+	Costs   CostSource
+	History HistorySource
+}
+
+// Store keeps experiments and their assignments
+type Store interface {
+	SaveExperiment(e *Experiment) error
+	// GetExperiment returns nil, nil when there is no experiment with id
+	GetExperiment(id string) (*Experiment, error)
+	// ListExperiments returns experiments oldest first
+	ListExperiments() ([]*Experiment, error)
+	DeleteExperiment(id string) error
+	SaveAssignment(a *Assignment) error
+	// GetAssignment returns nil, nil when the bead was never assigned
+	GetAssignment(beadID string) (*Assignment, error)
+	ListAssignments(experimentID string) ([]*Assignment, error)
+}
+
+// Manager runs experiments
+type Manager struct {
+	sources Sources
+	store   Store
+	mu      sync.Mutex // Serializes assignment so a bead gets one variant
+}
+
+// NewManager creates a manager that keeps experiments in memory until
+// SetStore is called
+func NewManager(sources Sources) *Manager {
+	return &Manager{sources: sources, store: newMemoryStore()}
+}
+
+// SetStore keeps experiments in store
+func (m *Manager) SetStore(store Store) {
+	if store != nil {
+		m.store = store
+	}
+}
+
+// Create validates and starts a new experiment
+func (m *Manager) Create(e *Experiment) (*Experiment, error) {
+	if err := validate(e); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	e.ID = "exp-" + uuid.New().String()[:8]
+	e.CreatedAt = now
+	e.StartedAt = now
+	e.StoppedAt = nil
+	if e.Status == "" {
+		e.Status = StatusRunning
+	}
+	if e.Status == StatusStopped {
+		return nil, apperr.Validation("a new experiment cannot be stopped")
+	}
+	if err := m.store.SaveExperiment(e); err != nil {
+		return nil, fmt.Errorf("failed to save experiment: %w", err)
+	}
+	return e, nil
+}
+
+// Get returns an experiment
+func (m *Manager) Get(id string) (*Experiment, error) {
+	e, err := m.store.GetExperiment(id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, apperr.NotFound("experiment %s not found", id)
+	}
+	return e, nil
+}
+
+// List returns every experiment, oldest first
+func (m *Manager) List() ([]*Experiment, error) {
+	return m.store.ListExperiments()
+}
+
+// SetStatus runs, pauses or stops an experiment. A stopped experiment
+// cannot run again; starting a paused one admits only beads created from
+// then on.
+func (m *Manager) SetStatus(id, status string) (*Experiment, error) {
+	e, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if e.Status == status {
+		return e, nil
+	}
+	switch status {
+	case StatusRunning:
+		if e.Status == StatusStopped {
+			return nil, apperr.Conflict("experiment %s is stopped", id)
+		}
+		e.StartedAt = time.Now().UTC()
+	case StatusPaused:
+		if e.Status == StatusStopped {
+			return nil, apperr.Conflict("experiment %s is stopped", id)
+		}
+	case StatusStopped:
+		now := time.Now().UTC()
+		e.StoppedAt = &now
+	default:
+		return nil, apperr.Validation("status must be running, paused or stopped")
+	}
+	e.Status = status
+	if err := m.store.SaveExperiment(e); err != nil {
+		return nil, fmt.Errorf("failed to save experiment: %w", err)
+	}
+	return e, nil
+}
+
+// Delete removes an experiment and its assignments
+func (m *Manager) Delete(id string) error {
+	if _, err := m.Get(id); err != nil {
+		return err
+	}
+	return m.store.DeleteExperiment(id)
+}
+
+// Assign returns the experiment and variant a bead is routed to, or nil, nil
+// when it is in no running experiment. A bead keeps the variant it first got;
+// new beads are split by a hash of their ID so the split doesn't depend on
+// the order they arrive in.
+func (m *Manager) Assign(bead *models.Bead) (*Experiment, *Variant) {
+	if bead == nil {
+		return nil, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if a, err := m.store.GetAssignment(bead.ID); err == nil && a != nil {
+		if a.Excluded {
+			return nil, nil
+		}
+		e, err := m.store.GetExperiment(a.ExperimentID)
+		if err != nil || e == nil || e.Status == StatusStopped {
+			return nil, nil
+		}
+		return e, e.Variant(a.Variant)
+	}
+
+	experiments, err := m.store.ListExperiments()
+	if err != nil {
+		return nil, nil
+	}
+	for _, e := range experiments {
+		if e.Status != StatusRunning || !e.matches(bead) {
+			continue
+		}
+		v := bucketVariant(e, bead.ID)
+		if v == nil {
+			continue
+		}
+		if err := m.store.SaveAssignment(&Assignment{
+			ExperimentID: e.ID,
+			BeadID:       bead.ID,
+			Variant:      v.Name,
+			AssignedAt:   time.Now().UTC(),
+		}); err != nil {
+			return nil, nil
+		}
+		return e, v
+	}
+	return nil, nil
+}
+
+// Exclude takes a bead out of its experiment for good, for when its variant
+// could not be served and it was routed normally instead
+func (m *Manager) Exclude(beadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, err := m.store.GetAssignment(beadID)
+	if err != nil || a == nil || a.Excluded {
+		return err
+	}
+	a.Excluded = true
+	return m.store.SaveAssignment(a)
+}
+
+// Conclude records the outcome of a bead in an experiment: success when it
+// closed, failure when it was blocked. Beads in no experiment are ignored.
+func (m *Manager) Conclude(ctx context.Context, beadID string, success bool) error {
+	a, err := m.store.GetAssignment(beadID)
+	if err != nil || a == nil || a.Excluded {
+		return err
+	}
+	if m.sources.Beads == nil {
+		return fmt.Errorf("no bead source")
+	}
+	bead, err := m.sources.Beads.GetBead(beadID)
+	if err != nil {
+		return err
+	}
+
+	outcome := &Outcome{Success: success, ConcludedAt: time.Now().UTC()}
+	if n, err := strconv.Atoi(bead.Context["dispatch_count"]); err == nil && n > 1 {
+		outcome.Retries = n - 1
+	}
+	if m.sources.Costs != nil {
+		logs, err := m.sources.Costs.GetLogs(ctx, &analytics.LogFilter{StartTime: a.AssignedAt, EndTime: outcome.ConcludedAt})
+		if err != nil {
+			return fmt.Errorf("failed to read request logs: %w", err)
+		}
+		for _, l := range logs {
+			if l.Metadata["bead_id"] == beadID {
+				outcome.Requests++
+				outcome.Tokens += l.TotalTokens
+				outcome.CostUSD += l.CostUSD
+			}
+		}
+	}
+	if m.sources.History != nil {
+		if events, err := m.sources.History.History(beadID); err == nil {
+			outcome.ReviewRejections = reviewRejections(events)
+		}
+	}
+
+	a.Outcome = outcome
+	return m.store.SaveAssignment(a)
+}
+
+// Assignments returns the beads assigned in an experiment
+func (m *Manager) Assignments(experimentID string) ([]*Assignment, error) {
+	if _, err := m.Get(experimentID); err != nil {
+		return nil, err
+	}
+	return m.store.ListAssignments(experimentID)
+}
+
+// Report summarizes an experiment's outcomes and compares its variants
+func (m *Manager) Report(experimentID string) (*Report, error) {
+	e, err := m.Get(experimentID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := m.store.ListAssignments(experimentID)
+	if err != nil {
+		return nil, err
+	}
+	return buildReport(e, assignments), nil
+}
+
+func validate(e *Experiment) error {
+	if e == nil {
+		return apperr.Validation("experiment cannot be nil")
+	}
+	if strings.TrimSpace(e.Name) == "" {
+		return apperr.Validation("name is required")
+	}
+	if len(e.Variants) != 2 {
+		return apperr.Validation("an experiment needs exactly two variants")
+	}
+	total := 0
+	for i, v := range e.Variants {
+		v.Name = []string{VariantA, VariantB}[i]
+		if v.ProviderID == "" && v.Model == "" && v.Instructions == "" {
+			return apperr.Validation("variant %s needs a provider, model or instructions", v.Name)
+		}
+		if v.Percent < 0 || v.Percent > 100 {
+			return apperr.Validation("variant %s percent must be between 0 and 100", v.Name)
+		}
+		total += v.Percent
+	}
+	if total == 0 {
+		e.Variants[0].Percent, e.Variants[1].Percent = 50, 50
+	} else if total > 100 {
+		return apperr.Validation("variant percentages add up to %d, more than 100", total)
+	}
+	switch e.Status {
+	case "", StatusRunning, StatusPaused, StatusStopped:
+	default:
+		return apperr.Validation("status must be running, paused or stopped")
+	}
+	return nil
+}
+
+// bucketVariant places a bead in one of 100 buckets by hashing it with the
+// experiment, and returns the variant that bucket belongs to
+func bucketVariant(e *Experiment, beadID string) *Variant {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(e.ID + "/" + beadID))
+	bucket := int(h.Sum32() % 100)
+	edge := 0
+	for _, v := range e.Variants {
+		edge += v.Percent
+		if bucket < edge {
+			return v
+		}
+	}
+	return nil
+}
+
+// reviewRejections counts the reviews of a bead that requested changes.
+// Each review rewrites the bead's review context, so a review is a change of
+// reviewed_at and its verdict is the one written with it.
+func reviewRejections(events []*beads.BeadEvent) int {
+	count := 0
+	last := ""
+	for _, ev := range events {
+		ctx, _ := ev.Changes["context"].(map[string]interface{})
+		reviewedAt, _ := ctx["reviewed_at"].(string)
+		if reviewedAt == "" || reviewedAt == last {
+			continue
+		}
+		last = reviewedAt
+		if verdict, _ := ctx["review_verdict"].(string); verdict == "request_changes" {
+			count++
+		}
+	}
+	return count
+}
+
+// memoryStore keeps experiments in memory
+type memoryStore struct {
+	mu          sync.RWMutex
+	experiments map[string]*Experiment
+	assignments map[string]*Assignment
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{experiments: make(map[string]*Experiment), assignments: make(map[string]*Assignment)}
+}
+
+func (s *memoryStore) SaveExperiment(e *Experiment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.experiments[e.ID] = e
+	return nil
+}
+
+func (s *memoryStore) GetExperiment(id string) (*Experiment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.experiments[id], nil
+}
+
+func (s *memoryStore) ListExperiments() ([]*Experiment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Experiment, 0, len(s.experiments))
+	for _, e := range s.experiments {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *memoryStore) DeleteExperiment(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.experiments, id)
+	for beadID, a := range s.assignments {
+		if a.ExperimentID == id {
+			delete(s.assignments, beadID)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) SaveAssignment(a *Assignment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.assignments[a.BeadID] = a
+	return nil
+}
+
+func (s *memoryStore) GetAssignment(beadID string) (*Assignment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.assignments[beadID], nil
+}
+
+func (s *memoryStore) ListAssignments(experimentID string) ([]*Assignment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Assignment
+	for _, a := range s.assignments {
+		if a.ExperimentID == experimentID {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AssignedAt.Before(out[j].AssignedAt) })
+	return out, nil
+}
@@ -0,0 +1,203 @@
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeBeads map[string]*models.Bead
+
+func (f fakeBeads) GetBead(id string) (*models.Bead, error) {
+	if b, ok := f[id]; ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("bead not found: %s", id)
+}
+
+type fakeCosts []*analytics.RequestLog
+
+func (f fakeCosts) GetLogs(ctx context.Context, filter *analytics.LogFilter) ([]*analytics.RequestLog, error) {
+	return f, nil
+}
+
+type fakeHistory map[string][]*beads.BeadEvent
+
+func (f fakeHistory) History(beadID string) ([]*beads.BeadEvent, error) {
+	return f[beadID], nil
+}
+
+func newExperiment(t *testing.T, m *Manager) *Experiment {
+	t.Helper()
+	e, err := m.Create(&Experiment{
+		Name:     "bugs on the larger model",
+		Category: "bug",
+		Variants: []*Variant{{ProviderID: "small"}, {Model: "large-model", Instructions: "Write a failing test first."}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestAssign(t *testing.T) {
+	m := NewManager(Sources{})
+	e := newExperiment(t, m)
+	if e.Variants[0].Name != VariantA || e.Variants[0].Percent != 50 || e.Status != StatusRunning {
+		t.Fatalf("unexpected defaults %+v", e)
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 400; i++ {
+		bead := &models.Bead{ID: fmt.Sprintf("bd-%d", i), Type: "bug", CreatedAt: time.Now()}
+		got, v := m.Assign(bead)
+		if got == nil || v == nil {
+			t.Fatalf("expected bead %s to be assigned", bead.ID)
+		}
+		counts[v.Name]++
+		if _, again := m.Assign(bead); again != v {
+			t.Fatalf("expected bead %s to keep its variant", bead.ID)
+		}
+	}
+	if counts[VariantA] < 150 || counts[VariantB] < 150 {
+		t.Errorf("expected a roughly even split, got %v", counts)
+	}
+
+	if got, _ := m.Assign(&models.Bead{ID: "task", Type: "task", CreatedAt: time.Now()}); got != nil {
+		t.Error("expected another category to be left alone")
+	}
+	if got, _ := m.Assign(&models.Bead{ID: "old", Type: "bug", CreatedAt: e.StartedAt.Add(-time.Minute)}); got != nil {
+		t.Error("expected a bead created before the experiment to be left alone")
+	}
+
+	if err := m.Exclude("bd-0"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Assign(&models.Bead{ID: "bd-0", Type: "bug", CreatedAt: time.Now()}); got != nil {
+		t.Error("expected an excluded bead to be routed normally")
+	}
+	if r, _ := m.Report(e.ID); r.Variants[0].Excluded+r.Variants[1].Excluded != 1 || r.Variants[0].Beads+r.Variants[1].Beads != 399 {
+		t.Errorf("expected the excluded bead to be left out of the results, got %+v %+v", r.Variants[0], r.Variants[1])
+	}
+
+	if _, err := m.SetStatus(e.ID, StatusPaused); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Assign(&models.Bead{ID: "new", Type: "bug", CreatedAt: time.Now()}); got != nil {
+		t.Error("expected a paused experiment to admit no new beads")
+	}
+	if got, _ := m.Assign(&models.Bead{ID: "bd-1", Type: "bug"}); got == nil {
+		t.Error("expected a paused experiment to keep its beads")
+	}
+	if _, err := m.SetStatus(e.ID, StatusStopped); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Assign(&models.Bead{ID: "bd-1", Type: "bug"}); got != nil {
+		t.Error("expected a stopped experiment to release its beads")
+	}
+	if _, err := m.SetStatus(e.ID, StatusRunning); apperr.CodeOf(err) != apperr.CodeConflict {
+		t.Errorf("expected restarting a stopped experiment to conflict, got %v", err)
+	}
+
+	for _, bad := range []*Experiment{
+		{Variants: []*Variant{{Model: "a"}, {Model: "b"}}},
+		{Name: "one", Variants: []*Variant{{Model: "a"}}},
+		{Name: "empty", Variants: []*Variant{{}, {Model: "b"}}},
+		{Name: "over", Variants: []*Variant{{Model: "a", Percent: 60}, {Model: "b", Percent: 60}}},
+	} {
+		if _, err := m.Create(bad); apperr.CodeOf(err) != apperr.CodeValidation {
+			t.Errorf("expected %+v to be rejected, got %v", bad, err)
+		}
+	}
+}
+
+func TestConcludeAndReport(t *testing.T) {
+	bs := fakeBeads{}
+	history := fakeHistory{}
+	var costs fakeCosts
+	m := NewManager(Sources{Beads: bs, Costs: &costs, History: history})
+	e := newExperiment(t, m)
+
+	var a, b int
+	for i := 0; a < MinSamples || b < MinSamples; i++ {
+		bead := &models.Bead{ID: fmt.Sprintf("bd-%d", i), Type: "bug", CreatedAt: time.Now(), Context: map[string]string{"dispatch_count": "1"}}
+		bs[bead.ID] = bead
+		_, v := m.Assign(bead)
+		cost := 1.0 + float64(i%3)*0.1
+		success := true
+		if v.Name == VariantA {
+			a++
+			success = a%2 == 0
+		} else {
+			b++
+			cost *= 3
+			bead.Context["dispatch_count"] = "3"
+			history[bead.ID] = []*beads.BeadEvent{
+				{Changes: map[string]interface{}{"context": map[string]interface{}{"reviewed_at": "t1", "review_verdict": "request_changes"}}},
+				{Changes: map[string]interface{}{"context": map[string]interface{}{"reviewed_at": "t1", "review_verdict": "request_changes", "other": "x"}}},
+				{Changes: map[string]interface{}{"context": map[string]interface{}{"reviewed_at": "t2", "review_verdict": "approve"}}},
+			}
+		}
+		costs = append(costs, &analytics.RequestLog{CostUSD: cost, TotalTokens: 100, Metadata: map[string]string{"bead_id": bead.ID}})
+		if err := m.Conclude(context.Background(), bead.ID, success); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Conclude(context.Background(), "unassigned", true); err != nil {
+		t.Errorf("expected beads in no experiment to be ignored, got %v", err)
+	}
+
+	r, err := m.Report(e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	va, vb := r.Variants[0], r.Variants[1]
+	if va.Concluded != a || va.SuccessRate != float64(a/2)/float64(a) || vb.SuccessRate != 1 {
+		t.Fatalf("unexpected variants %+v %+v", va, vb)
+	}
+	if vb.Retries.Mean != 2 || vb.ReviewRejections.Mean != 1 || va.ReviewRejections.Mean != 0 {
+		t.Errorf("expected B's retries and single rejected review, got %+v %+v", vb.Retries, vb.ReviewRejections)
+	}
+	if va.SuccessInterval[0] >= va.SuccessRate || va.SuccessInterval[1] <= va.SuccessRate {
+		t.Errorf("expected the interval to contain the rate, got %v", va.SuccessInterval)
+	}
+	tests := map[string]*Test{}
+	for _, test := range r.Tests {
+		tests[test.Metric] = test
+	}
+	if !tests[MetricSuccessRate].Significant || !tests[MetricCost].Significant || tests[MetricTokens].Significant {
+		t.Errorf("unexpected tests %+v %+v %+v", tests[MetricSuccessRate], tests[MetricCost], tests[MetricTokens])
+	}
+	if r.Recommendation == "" || r.Recommendation[:16] != "B is better on s" {
+		t.Errorf("unexpected recommendation %q", r.Recommendation)
+	}
+
+	if _, err := m.Report("missing"); apperr.CodeOf(err) != apperr.CodeNotFound {
+		t.Errorf("expected a missing experiment to be not found, got %v", err)
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	// Welch's t-test on two samples with t = 2.46 on 25 degrees of freedom, p ≈ 0.0214
+	p := welchP([]float64{27.5, 21.0, 19.0, 23.6, 17.0, 17.9, 16.9, 20.1, 21.9, 22.6, 23.1, 19.6, 19.0, 21.7, 21.4},
+		[]float64{27.1, 22.0, 20.8, 23.4, 23.4, 23.5, 25.8, 22.0, 24.8, 20.2, 21.9, 22.1, 22.9, 20.5, 24.4})
+	if math.Abs(p-0.0214) > 0.0005 {
+		t.Errorf("welchP = %v, want about 0.0214", p)
+	}
+	if p := twoProportionP(50, 100, 50, 100); p != 1 {
+		t.Errorf("expected equal proportions to give p=1, got %v", p)
+	}
+	if p := twoProportionP(30, 100, 50, 100); math.Abs(p-0.0039) > 0.0005 {
+		t.Errorf("twoProportionP = %v, want about 0.0039", p)
+	}
+	if w := wilson(0, 10); w[0] != 0 || math.Abs(w[1]-0.2775) > 0.001 {
+		t.Errorf("wilson(0, 10) = %v", w)
+	}
+}
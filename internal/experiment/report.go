@@ -0,0 +1,327 @@
+package experiment
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// MinSamples is how many concluded beads each variant needs before a
+	// report recommends anything
+	MinSamples = 20
+	// significance is the p-value below which a difference is reported as real
+	significance = 0.05
+	// z95 is the normal quantile for 95% intervals
+	z95 = 1.959964
+)
+
+// Metrics a report compares
+const (
+	MetricSuccessRate      = "success_rate"
+	MetricCost             = "cost_usd"
+	MetricTokens           = "tokens"
+	MetricReviewRejections = "review_rejections"
+	MetricRetries          = "retries"
+)
+
+// Report summarizes an experiment's variants and compares them
+type Report struct {
+	Experiment  *Experiment       `json:"experiment"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	Variants    []*VariantSummary `json:"variants"`
+	// Tests compare B against A per metric, once both have two concluded
+	// beads
+	Tests          []*Test `json:"tests"`
+	Recommendation string  `json:"recommendation"`
+}
+
+// VariantSummary is one variant's outcomes
+type VariantSummary struct {
+	Name        string  `json:"name"`
+	ProviderID  string  `json:"provider_id,omitempty"`
+	Model       string  `json:"model,omitempty"`
+	Beads       int     `json:"beads"`     // Assigned
+	Excluded    int     `json:"excluded"`  // Routed normally instead
+	Concluded   int     `json:"concluded"` // Closed or blocked
+	Successes   int     `json:"successes"`
+	SuccessRate float64 `json:"success_rate"`
+	// SuccessInterval is the 95% Wilson score interval of the success rate
+	SuccessInterval  [2]float64 `json:"success_interval"`
+	CostUSD          Stat       `json:"cost_usd"`
+	Tokens           Stat       `json:"tokens"`
+	ReviewRejections Stat       `json:"review_rejections"`
+	Retries          Stat       `json:"retries"`
+}
+
+// Stat describes a metric over a variant's concluded beads
+type Stat struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Median float64 `json:"median"`
+}
+
+// Test is the comparison of one metric between the variants
+type Test struct {
+	Metric     string  `json:"metric"`
+	A          float64 `json:"a"`
+	B          float64 `json:"b"`
+	Difference float64 `json:"difference"` // B minus A
+	// PValue is the two-sided probability of a difference at least this big
+	// if the variants were the same: a two-proportion z-test for the success
+	// rate, Welch's t-test for the rest
+	PValue      float64 `json:"p_value"`
+	Significant bool    `json:"significant"`
+}
+
+// higherIsBetter reports whether a larger value of metric is an improvement
+func higherIsBetter(metric string) bool {
+	return metric == MetricSuccessRate
+}
+
+func buildReport(e *Experiment, assignments []*Assignment) *Report {
+	r := &Report{Experiment: e, GeneratedAt: time.Now().UTC(), Tests: []*Test{}}
+	samples := map[string]map[string][]float64{}
+	for _, v := range e.Variants {
+		r.Variants = append(r.Variants, &VariantSummary{Name: v.Name, ProviderID: v.ProviderID, Model: v.Model})
+		samples[v.Name] = map[string][]float64{}
+	}
+	byName := map[string]*VariantSummary{}
+	for _, s := range r.Variants {
+		byName[s.Name] = s
+	}
+
+	for _, a := range assignments {
+		s := byName[a.Variant]
+		if s == nil {
+			continue
+		}
+		if a.Excluded {
+			s.Excluded++
+			continue
+		}
+		s.Beads++
+		if a.Outcome == nil {
+			continue
+		}
+		s.Concluded++
+		m := samples[a.Variant]
+		success := 0.0
+		if a.Outcome.Success {
+			s.Successes++
+			success = 1
+		}
+		m[MetricSuccessRate] = append(m[MetricSuccessRate], success)
+		m[MetricCost] = append(m[MetricCost], a.Outcome.CostUSD)
+		m[MetricTokens] = append(m[MetricTokens], float64(a.Outcome.Tokens))
+		m[MetricReviewRejections] = append(m[MetricReviewRejections], float64(a.Outcome.ReviewRejections))
+		m[MetricRetries] = append(m[MetricRetries], float64(a.Outcome.Retries))
+	}
+
+	for _, s := range r.Variants {
+		m := samples[s.Name]
+		if s.Concluded > 0 {
+			s.SuccessRate = float64(s.Successes) / float64(s.Concluded)
+		}
+		s.SuccessInterval = wilson(s.Successes, s.Concluded)
+		s.CostUSD = describe(m[MetricCost])
+		s.Tokens = describe(m[MetricTokens])
+		s.ReviewRejections = describe(m[MetricReviewRejections])
+		s.Retries = describe(m[MetricRetries])
+	}
+
+	a, b := byName[VariantA], byName[VariantB]
+	if a == nil || b == nil || a.Concluded < 2 || b.Concluded < 2 {
+		r.Recommendation = notEnoughData(concluded(a), concluded(b))
+		return r
+	}
+	sa, sb := samples[VariantA], samples[VariantB]
+	for _, metric := range []string{MetricSuccessRate, MetricCost, MetricTokens, MetricReviewRejections, MetricRetries} {
+		t := &Test{Metric: metric, A: mean(sa[metric]), B: mean(sb[metric])}
+		t.Difference = t.B - t.A
+		if metric == MetricSuccessRate {
+			t.PValue = twoProportionP(a.Successes, a.Concluded, b.Successes, b.Concluded)
+		} else {
+			t.PValue = welchP(sa[metric], sb[metric])
+		}
+		t.Significant = t.PValue < significance
+		r.Tests = append(r.Tests, t)
+	}
+	r.Recommendation = recommend(a, b, r.Tests)
+	return r
+}
+
+func concluded(s *VariantSummary) int {
+	if s == nil {
+		return 0
+	}
+	return s.Concluded
+}
+
+func notEnoughData(a, b int) string {
+	return fmt.Sprintf("Not enough data: %d and %d beads concluded, %d per variant needed.", a, b, MinSamples)
+}
+
+// recommend puts the significant differences into words
+func recommend(a, b *VariantSummary, tests []*Test) string {
+	if a.Concluded < MinSamples || b.Concluded < MinSamples {
+		return notEnoughData(a.Concluded, b.Concluded)
+	}
+	var better, worse []string
+	for _, t := range tests {
+		if !t.Significant {
+			continue
+		}
+		name := strings.ReplaceAll(t.Metric, "_", " ")
+		if (t.Difference > 0) == higherIsBetter(t.Metric) {
+			better = append(better, fmt.Sprintf("%s (p=%.3f)", name, t.PValue))
+		} else {
+			worse = append(worse, fmt.Sprintf("%s (p=%.3f)", name, t.PValue))
+		}
+	}
+	switch {
+	case len(better) == 0 && len(worse) == 0:
+		return "No significant difference between the variants."
+	case len(worse) == 0:
+		return "B is better on " + strings.Join(better, ", ") + "."
+	case len(better) == 0:
+		return "A is better on " + strings.Join(worse, ", ") + "."
+	}
+	return "B is better on " + strings.Join(better, ", ") + " but worse on " + strings.Join(worse, ", ") + "."
+}
+
+func describe(values []float64) Stat {
+	if len(values) == 0 {
+		return Stat{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+	return Stat{Mean: mean(values), StdDev: math.Sqrt(variance(values)), Median: median}
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// variance is the sample variance
+func variance(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := mean(values)
+	var sum float64
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return sum / float64(len(values)-1)
+}
+
+// wilson is the 95% Wilson score interval of a proportion
+func wilson(successes, n int) [2]float64 {
+	if n == 0 {
+		return [2]float64{0, 1}
+	}
+	p := float64(successes) / float64(n)
+	nf := float64(n)
+	z2 := z95 * z95
+	center := (p + z2/(2*nf)) / (1 + z2/nf)
+	margin := z95 * math.Sqrt(p*(1-p)/nf+z2/(4*nf*nf)) / (1 + z2/nf)
+	return [2]float64{math.Max(0, center-margin), math.Min(1, center+margin)}
+}
+
+// twoProportionP is the two-sided p-value of a pooled two-proportion z-test
+func twoProportionP(s1, n1, s2, n2 int) float64 {
+	p1 := float64(s1) / float64(n1)
+	p2 := float64(s2) / float64(n2)
+	pooled := float64(s1+s2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 1
+	}
+	z := math.Abs(p2-p1) / se
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// welchP is the two-sided p-value of Welch's t-test for a difference in means
+func welchP(a, b []float64) float64 {
+	va, vb := variance(a)/float64(len(a)), variance(b)/float64(len(b))
+	diff := math.Abs(mean(b) - mean(a))
+	if va+vb == 0 {
+		if diff == 0 {
+			return 1
+		}
+		return 0
+	}
+	t := diff / math.Sqrt(va+vb)
+	df := (va + vb) * (va + vb) / (va*va/float64(len(a)-1) + vb*vb/float64(len(b)-1))
+	return incompleteBeta(df/2, 0.5, df/(df+t*t))
+}
+
+// incompleteBeta is the regularized incomplete beta function I_x(a, b)
+func incompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaFraction(a, b, x) / a
+	}
+	return 1 - front*betaFraction(b, a, 1-x)/b
+}
+
+// betaFraction evaluates the continued fraction of the incomplete beta
+// function by the modified Lentz method
+func betaFraction(a, b, x float64) float64 {
+	const (
+		maxIterations = 200
+		epsilon       = 1e-12
+		tiny          = 1e-300
+	)
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		mf := float64(m)
+		for _, num := range []float64{
+			mf * (b - mf) * x / ((a + 2*mf - 1) * (a + 2*mf)),
+			-(a + mf) * (a + b + mf) * x / ((a + 2*mf) * (a + 2*mf + 1)),
+		} {
+			d = 1 + num*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + num/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			h *= d * c
+		}
+		if math.Abs(d*c-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,224 @@
+// Package features is the feature flag registry. Experimental code paths ask
+// it whether they are enabled for a project; flags are set in the config,
+// globally or per project, and can be changed at runtime through the API so
+// rollouts can be staged and rolled back without a restart.
+package features
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Known flags
+const (
+	// ParallelActions runs consecutive read-only actions of an envelope
+	// concurrently
+	ParallelActions = "parallel_actions"
+	// SemanticLessons picks the lessons put into prompts by embedding
+	// similarity to the task instead of recency
+	SemanticLessons = "semantic_lessons"
+	// CacheAutoEnable lets the cache optimizer apply the opportunities it
+	// finds without them being picked one by one
+	CacheAutoEnable = "cache_auto_enable"
+)
+
+// Flag describes a feature flag
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Stage       string `json:"stage"` // experimental or beta
+	Default     bool   `json:"default"`
+}
+
+var definitions = []Flag{
+	{Name: ParallelActions, Description: "Run consecutive read-only actions of an envelope concurrently", Stage: "experimental"},
+	{Name: SemanticLessons, Description: "Select prompt lessons by embedding similarity to the task", Stage: "beta", Default: true},
+	{Name: CacheAutoEnable, Description: "Let the cache optimizer apply opportunities automatically", Stage: "experimental"},
+}
+
+// Sources of a flag's state, from weakest to strongest
+const (
+	SourceDefault        = "default"
+	SourceConfig         = "config"
+	SourceRuntime        = "runtime"
+	SourceProjectConfig  = "project_config"
+	SourceProjectRuntime = "project_runtime"
+)
+
+// State is the effective state of a flag, for a project when one was given
+type State struct {
+	Flag
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"`
+}
+
+// Gate answers whether a flag is enabled for a project
+type Gate interface {
+	Enabled(name, projectID string) bool
+}
+
+// Registry holds flag states. A nil *Registry reports every flag at its
+// default.
+type Registry struct {
+	mu             sync.RWMutex
+	flags          map[string]Flag
+	config         map[string]bool
+	runtime        map[string]bool
+	projectConfig  map[string]map[string]bool
+	projectRuntime map[string]map[string]bool
+}
+
+// New creates a registry with the config's states. Unknown flag names in the
+// config are returned as an error alongside the usable registry.
+func New(cfg config.FeaturesConfig) (*Registry, error) {
+	r := &Registry{
+		flags:          make(map[string]Flag, len(definitions)),
+		config:         make(map[string]bool),
+		runtime:        make(map[string]bool),
+		projectConfig:  make(map[string]map[string]bool),
+		projectRuntime: make(map[string]map[string]bool),
+	}
+	for _, f := range definitions {
+		r.flags[f.Name] = f
+	}
+
+	var unknown []string
+	for name, on := range cfg.Flags {
+		if _, ok := r.flags[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		r.config[name] = on
+	}
+	for projectID, flags := range cfg.Projects {
+		for name, on := range flags {
+			if _, ok := r.flags[name]; !ok {
+				unknown = append(unknown, projectID+"/"+name)
+				continue
+			}
+			if r.projectConfig[projectID] == nil {
+				r.projectConfig[projectID] = make(map[string]bool)
+			}
+			r.projectConfig[projectID][name] = on
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return r, fmt.Errorf("unknown feature flags ignored: %v", unknown)
+	}
+	return r, nil
+}
+
+// Enabled reports whether a flag is on for projectID (empty for the global
+// state). Unknown flags are off.
+func (r *Registry) Enabled(name, projectID string) bool {
+	if r == nil {
+		for _, f := range definitions {
+			if f.Name == name {
+				return f.Default
+			}
+		}
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	on, _ := r.resolve(name, projectID)
+	return on
+}
+
+// resolve returns the state of a known flag and where it came from. Caller
+// holds r.mu.
+func (r *Registry) resolve(name, projectID string) (bool, string) {
+	if projectID != "" {
+		if on, ok := r.projectRuntime[projectID][name]; ok {
+			return on, SourceProjectRuntime
+		}
+		if on, ok := r.projectConfig[projectID][name]; ok {
+			return on, SourceProjectConfig
+		}
+	}
+	if on, ok := r.runtime[name]; ok {
+		return on, SourceRuntime
+	}
+	if on, ok := r.config[name]; ok {
+		return on, SourceConfig
+	}
+	return r.flags[name].Default, SourceDefault
+}
+
+// List returns every flag's state for projectID (empty for the global
+// state), sorted by name
+func (r *Registry) List(projectID string) []State {
+	if r == nil {
+		r, _ = New(config.FeaturesConfig{})
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	states := make([]State, 0, len(r.flags))
+	for _, f := range r.flags {
+		on, source := r.resolve(f.Name, projectID)
+		states = append(states, State{Flag: f, Enabled: on, Source: source})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// Set changes a flag at runtime, for projectID or globally when it is empty.
+// A nil enabled removes the runtime override, falling back to the config.
+func (r *Registry) Set(name, projectID string, enabled *bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.flags[name]; !ok {
+		return apperr.NotFound("unknown feature flag: %s", name)
+	}
+	overrides := r.runtime
+	if projectID != "" {
+		if r.projectRuntime[projectID] == nil {
+			r.projectRuntime[projectID] = make(map[string]bool)
+		}
+		overrides = r.projectRuntime[projectID]
+	}
+	if enabled == nil {
+		delete(overrides, name)
+		if projectID != "" && len(overrides) == 0 {
+			delete(r.projectRuntime, projectID)
+		}
+		return nil
+	}
+	overrides[name] = *enabled
+	return nil
+}
+
+// Snapshot returns the global state of every flag and the per-project
+// overrides, for diagnostics
+func (r *Registry) Snapshot() map[string]interface{} {
+	global := make(map[string]bool)
+	for _, s := range r.List("") {
+		global[s.Name] = s.Enabled
+	}
+	snapshot := map[string]interface{}{"flags": global}
+	if r == nil {
+		return snapshot
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	projects := make(map[string]map[string]bool)
+	for _, layer := range []map[string]map[string]bool{r.projectConfig, r.projectRuntime} {
+		for projectID, flags := range layer {
+			if projects[projectID] == nil {
+				projects[projectID] = make(map[string]bool)
+			}
+			for name, on := range flags {
+				projects[projectID][name] = on
+			}
+		}
+	}
+	if len(projects) > 0 {
+		snapshot["projects"] = projects
+	}
+	return snapshot
+}
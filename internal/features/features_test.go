@@ -0,0 +1,98 @@
+package features
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+func TestPrecedence(t *testing.T) {
+	r, err := New(config.FeaturesConfig{
+		Flags:    map[string]bool{ParallelActions: true, "warp_drive": true},
+		Projects: map[string]map[string]bool{"p1": {ParallelActions: false}},
+	})
+	if err == nil {
+		t.Error("expected unknown flags to be reported")
+	}
+	if r == nil {
+		t.Fatal("expected a usable registry alongside the error")
+	}
+
+	if !r.Enabled(ParallelActions, "") || !r.Enabled(ParallelActions, "p2") {
+		t.Error("expected the config to enable the flag globally")
+	}
+	if r.Enabled(ParallelActions, "p1") {
+		t.Error("expected the project config to win")
+	}
+	if !r.Enabled(SemanticLessons, "p1") || r.Enabled(CacheAutoEnable, "") || r.Enabled("warp_drive", "") {
+		t.Error("expected defaults for unset flags and off for unknown ones")
+	}
+
+	off, on := false, true
+	if err := r.Set(ParallelActions, "", &off); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(ParallelActions, "p1", &on); err != nil {
+		t.Fatal(err)
+	}
+	if r.Enabled(ParallelActions, "p2") || !r.Enabled(ParallelActions, "p1") {
+		t.Error("expected runtime changes to override the config")
+	}
+
+	states := r.List("p1")
+	if len(states) != 3 || states[0].Name != CacheAutoEnable {
+		t.Fatalf("expected 3 sorted states, got %+v", states)
+	}
+	for _, s := range states {
+		if s.Name == ParallelActions && (s.Source != SourceProjectRuntime || !s.Enabled) {
+			t.Errorf("unexpected state %+v", s)
+		}
+	}
+
+	// Clearing falls back to the layer below
+	if err := r.Set(ParallelActions, "p1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if r.Enabled(ParallelActions, "p1") {
+		t.Error("expected the project config after clearing the runtime override")
+	}
+	if err := r.Set(ParallelActions, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Enabled(ParallelActions, "") {
+		t.Error("expected the global config after clearing the runtime override")
+	}
+
+	err = r.Set("warp_drive", "", &on)
+	if !errors.Is(err, &apperr.Error{Code: apperr.CodeNotFound}) {
+		t.Errorf("expected not_found for an unknown flag, got %v", err)
+	}
+}
+
+func TestNilRegistryAndSnapshot(t *testing.T) {
+	var nilRegistry *Registry
+	if !nilRegistry.Enabled(SemanticLessons, "p1") || nilRegistry.Enabled(ParallelActions, "") {
+		t.Error("expected a nil registry to report defaults")
+	}
+	if len(nilRegistry.List("")) != len(definitions) {
+		t.Error("expected a nil registry to list every flag")
+	}
+
+	r, err := New(config.FeaturesConfig{Projects: map[string]map[string]bool{"p1": {SemanticLessons: false}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	on := true
+	_ = r.Set(ParallelActions, "p2", &on)
+	snapshot := r.Snapshot()
+	flags := snapshot["flags"].(map[string]bool)
+	if flags[ParallelActions] || !flags[SemanticLessons] {
+		t.Errorf("unexpected global flags %v", flags)
+	}
+	projects := snapshot["projects"].(map[string]map[string]bool)
+	if projects["p1"][SemanticLessons] || !projects["p2"][ParallelActions] {
+		t.Errorf("unexpected project overrides %v", projects)
+	}
+}
@@ -0,0 +1,30 @@
+package files
+
+import (
+	"context"
+	"path/filepath"
+)
+
+type ignoreKey struct{}
+
+// IgnoreFunc reports whether a slash-separated path relative to the project
+// workdir should be left out of tree listings and searches.
+type IgnoreFunc func(relPath string) bool
+
+// WithIgnore returns a context whose tree and search walks skip paths matched
+// by fn, e.g. a monorepo subproject's ignore rules.
+func WithIgnore(ctx context.Context, fn IgnoreFunc) context.Context {
+	return context.WithValue(ctx, ignoreKey{}, fn)
+}
+
+func ignoredPath(ctx context.Context, workDir, path string) bool {
+	fn, ok := ctx.Value(ignoreKey{}).(IgnoreFunc)
+	if !ok || fn == nil {
+		return false
+	}
+	rel, err := filepath.Rel(workDir, path)
+	if err != nil {
+		return false
+	}
+	return fn(filepath.ToSlash(rel))
+}
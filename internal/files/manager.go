@@ -10,6 +10,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
 )
 
 const (
@@ -19,12 +21,17 @@ const (
 	defaultMaxSearchHits = 200
 )
 
+// WorkDirResolver resolves project work directories
 type WorkDirResolver interface {
 	GetProjectWorkDir(projectID string) string
 }
 
 type Manager struct {
 	WorkDirs WorkDirResolver
+	// PatchFuzz is how many context lines at each end of a hunk the fuzzy
+	// patch fallback may ignore. 0 uses DefaultPatchFuzz; negative turns the
+	// fuzzy fallback off.
+	PatchFuzz int
 }
 
 type FileResult struct {
@@ -46,8 +53,10 @@ type SearchMatch struct {
 }
 
 type PatchResult struct {
-	Applied bool   `json:"applied"`
-	Output  string `json:"output,omitempty"`
+	Applied  bool         `json:"applied"`
+	Output   string       `json:"output,omitempty"`
+	Strategy string       `json:"strategy,omitempty"` // strict, 3way or fuzzy
+	Hunks    []HunkResult `json:"hunks,omitempty"`
 }
 
 type WriteResult struct {
@@ -64,12 +73,12 @@ func (m *Manager) ReadFile(ctx context.Context, projectID, relPath string) (*Fil
 	if err != nil {
 		return nil, err
 	}
-	target, err := safeJoin(workDir, relPath)
+	target, err := SafeJoin(workDir, relPath)
 	if err != nil {
 		return nil, err
 	}
 	if isBlockedPath(target) {
-		return nil, fmt.Errorf("path is not allowed")
+		return nil, apperr.PolicyDenied("path is not allowed")
 	}
 	info, err := os.Stat(target)
 	if err != nil {
@@ -106,12 +115,12 @@ func (m *Manager) ReadTree(ctx context.Context, projectID, relPath string, maxDe
 	if relPath == "" {
 		relPath = "."
 	}
-	target, err := safeJoin(workDir, relPath)
+	target, err := SafeJoin(workDir, relPath)
 	if err != nil {
 		return nil, err
 	}
 	if isBlockedPath(target) {
-		return nil, fmt.Errorf("path is not allowed")
+		return nil, apperr.PolicyDenied("path is not allowed")
 	}
 	if maxDepth <= 0 {
 		maxDepth = defaultMaxTreeDepth
@@ -128,7 +137,7 @@ func (m *Manager) ReadTree(ctx context.Context, projectID, relPath string, maxDe
 		if path == target {
 			return nil
 		}
-		if isBlockedPath(path) {
+		if isBlockedPath(path) || ignoredPath(ctx, workDir, path) {
 			if d.IsDir() {
 				return filepath.SkipDir
 			}
@@ -176,12 +185,12 @@ func (m *Manager) SearchText(ctx context.Context, projectID, relPath, query stri
 	if relPath == "" {
 		relPath = "."
 	}
-	target, err := safeJoin(workDir, relPath)
+	target, err := SafeJoin(workDir, relPath)
 	if err != nil {
 		return nil, err
 	}
 	if isBlockedPath(target) {
-		return nil, fmt.Errorf("path is not allowed")
+		return nil, apperr.PolicyDenied("path is not allowed")
 	}
 	if limit <= 0 {
 		limit = defaultMaxSearchHits
@@ -193,12 +202,12 @@ func (m *Manager) SearchText(ctx context.Context, projectID, relPath, query stri
 			return walkErr
 		}
 		if d.IsDir() {
-			if isBlockedPath(path) {
+			if isBlockedPath(path) || (path != target && ignoredPath(ctx, workDir, path)) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-		if isBlockedPath(path) {
+		if isBlockedPath(path) || ignoredPath(ctx, workDir, path) {
 			return nil
 		}
 		info, err := d.Info()
@@ -315,7 +324,7 @@ func (m *Manager) ApplyPatch(ctx context.Context, projectID, patch string) (*Pat
 	// Validate each file path
 	for _, file := range files {
 		// Use safeJoin to validate path is within project
-		fullPath, err := safeJoin(workDir, file)
+		fullPath, err := SafeJoin(workDir, file)
 		if err != nil {
 			return nil, fmt.Errorf("patch modifies unauthorized file: %s (%w)", file, err)
 		}
@@ -335,7 +344,8 @@ func (m *Manager) ApplyPatch(ctx context.Context, projectID, patch string) (*Pat
 		}
 	}
 
-	// First, check if patch is valid without applying it
+	// First, check if patch is valid without applying it; when it isn't,
+	// fall back to a 3-way merge or fuzzy matching
 	checkCmd := exec.CommandContext(ctx, "git", "apply", "--check", "--whitespace=nowarn", "-")
 	checkCmd.Dir = workDir
 	checkCmd.Stdin = strings.NewReader(patch)
@@ -343,10 +353,7 @@ func (m *Manager) ApplyPatch(ctx context.Context, projectID, patch string) (*Pat
 	checkCmd.Stdout = &checkOut
 	checkCmd.Stderr = &checkOut
 	if err := checkCmd.Run(); err != nil {
-		return &PatchResult{
-			Applied: false,
-			Output:  fmt.Sprintf("patch validation failed: %s", strings.TrimSpace(checkOut.String())),
-		}, fmt.Errorf("patch validation failed: %w", err)
+		return m.applyFallback(ctx, workDir, patch, strings.TrimSpace(checkOut.String()))
 	}
 
 	// Now apply the patch
@@ -359,7 +366,12 @@ func (m *Manager) ApplyPatch(ctx context.Context, projectID, patch string) (*Pat
 	if err := cmd.Run(); err != nil {
 		return &PatchResult{Applied: false, Output: strings.TrimSpace(out.String())}, err
 	}
-	return &PatchResult{Applied: true, Output: strings.TrimSpace(out.String())}, nil
+	return &PatchResult{
+		Applied:  true,
+		Output:   strings.TrimSpace(out.String()),
+		Strategy: PatchStrict,
+		Hunks:    hunkReport(parsePatch(patch), HunkClean),
+	}, nil
 }
 
 func (m *Manager) WriteFile(ctx context.Context, projectID, relPath, content string) (*WriteResult, error) {
@@ -370,12 +382,12 @@ func (m *Manager) WriteFile(ctx context.Context, projectID, relPath, content str
 	if err != nil {
 		return nil, err
 	}
-	target, err := safeJoin(workDir, relPath)
+	target, err := SafeJoin(workDir, relPath)
 	if err != nil {
 		return nil, err
 	}
 	if isBlockedPath(target) {
-		return nil, fmt.Errorf("path is not allowed")
+		return nil, apperr.PolicyDenied("path is not allowed")
 	}
 
 	// Ensure parent directory exists
@@ -429,21 +441,21 @@ func (m *Manager) MoveFile(ctx context.Context, projectID, sourceRelPath, target
 	}
 
 	// Validate source path
-	sourcePath, err := safeJoin(workDir, sourceRelPath)
+	sourcePath, err := SafeJoin(workDir, sourceRelPath)
 	if err != nil {
 		return fmt.Errorf("invalid source path: %w", err)
 	}
 	if isBlockedPath(sourcePath) {
-		return fmt.Errorf("source path is not allowed")
+		return apperr.PolicyDenied("source path is not allowed")
 	}
 
 	// Validate target path
-	targetPath, err := safeJoin(workDir, targetRelPath)
+	targetPath, err := SafeJoin(workDir, targetRelPath)
 	if err != nil {
 		return fmt.Errorf("invalid target path: %w", err)
 	}
 	if isBlockedPath(targetPath) {
-		return fmt.Errorf("target path is not allowed")
+		return apperr.PolicyDenied("target path is not allowed")
 	}
 
 	// Check source exists
@@ -477,12 +489,12 @@ func (m *Manager) DeleteFile(ctx context.Context, projectID, relPath string) err
 	}
 
 	// Validate path
-	filePath, err := safeJoin(workDir, relPath)
+	filePath, err := SafeJoin(workDir, relPath)
 	if err != nil {
 		return fmt.Errorf("invalid path: %w", err)
 	}
 	if isBlockedPath(filePath) {
-		return fmt.Errorf("path is not allowed")
+		return apperr.PolicyDenied("path is not allowed")
 	}
 
 	// Check file exists
@@ -518,12 +530,12 @@ func (m *Manager) RenameFile(ctx context.Context, projectID, sourceRelPath, newN
 	}
 
 	// Validate source path
-	sourcePath, err := safeJoin(workDir, sourceRelPath)
+	sourcePath, err := SafeJoin(workDir, sourceRelPath)
 	if err != nil {
 		return fmt.Errorf("invalid source path: %w", err)
 	}
 	if isBlockedPath(sourcePath) {
-		return fmt.Errorf("source path is not allowed")
+		return apperr.PolicyDenied("source path is not allowed")
 	}
 
 	// Check source exists
@@ -534,7 +546,7 @@ func (m *Manager) RenameFile(ctx context.Context, projectID, sourceRelPath, newN
 	// Build target path (same directory, new name)
 	targetPath := filepath.Join(filepath.Dir(sourcePath), newName)
 	if isBlockedPath(targetPath) {
-		return fmt.Errorf("target path is not allowed")
+		return apperr.PolicyDenied("target path is not allowed")
 	}
 
 	// Rename file
@@ -556,7 +568,10 @@ func (m *Manager) resolveWorkDir(projectID string) (string, error) {
 	return filepath.Clean(workDir), nil
 }
 
-func safeJoin(base, rel string) (string, error) {
+// SafeJoin joins a relative path onto base, refusing absolute paths and
+// paths that escape base. Names that merely start with dots, such as
+// "..config", are allowed.
+func SafeJoin(base, rel string) (string, error) {
 	if rel == "" {
 		rel = "."
 	}
@@ -1033,13 +1033,15 @@ func TestSafeJoin(t *testing.T) {
 		{"absolute path", "/base", "/etc/passwd", true},
 		{"path traversal", "/base", "../escape", true},
 		{"double traversal", "/base", "../../escape", true},
+		{"inner traversal", "/base", "sub/../../escape", true},
+		{"dotted name", "/base", "..config", false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := safeJoin(tt.base, tt.rel)
+			result, err := SafeJoin(tt.base, tt.rel)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("safeJoin(%s, %s) error = %v, wantErr %v", tt.base, tt.rel, err, tt.wantErr)
+				t.Errorf("SafeJoin(%s, %s) error = %v, wantErr %v", tt.base, tt.rel, err, tt.wantErr)
 			}
 			if err == nil && result == "" {
 				t.Error("Expected non-empty result")
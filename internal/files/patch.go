@@ -0,0 +1,556 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Patch strategies reported in PatchResult.Strategy
+const (
+	PatchStrict = "strict" // git apply
+	Patch3Way   = "3way"   // git apply --3way against the recorded base blobs
+	PatchFuzzy  = "fuzzy"  // hunk by hunk with offset, whitespace and context tolerance
+)
+
+// Hunk outcomes reported in HunkResult.Status
+const (
+	HunkClean  = "clean"  // applied as written, possibly at an offset
+	HunkFuzzy  = "fuzzy"  // applied ignoring whitespace or some context lines
+	HunkMerged = "merged" // applied by a 3-way merge
+	HunkFailed = "failed"
+)
+
+// DefaultPatchFuzz is how many context lines at each end of a hunk the
+// fuzzy fallback may ignore
+const DefaultPatchFuzz = 2
+
+// HunkResult reports how one hunk of a patch was applied
+type HunkResult struct {
+	File   string `json:"file"`
+	Index  int    `json:"index"` // 1-based within the file
+	Header string `json:"header"`
+	Status string `json:"status"`
+	Line   int    `json:"line,omitempty"`   // 1-based line the hunk was applied at
+	Offset int    `json:"offset,omitempty"` // Lines from where the header placed it
+	Fuzz   int    `json:"fuzz,omitempty"`   // Context lines ignored at each end
+	Reason string `json:"reason,omitempty"`
+	Hunk   string `json:"hunk,omitempty"` // Text of a failed hunk, for repair
+}
+
+type patchHunk struct {
+	header   string
+	oldStart int
+	lines    []string // Prefixed with ' ', '-' or '+'
+}
+
+type patchFile struct {
+	oldPath  string // Empty for a new file
+	newPath  string // Empty for a deletion
+	baseBlob string
+	binary   bool
+	hunks    []patchHunk
+}
+
+func (f *patchFile) path() string {
+	if f.newPath != "" {
+		return f.newPath
+	}
+	return f.oldPath
+}
+
+// parsePatch splits a unified diff into files and hunks. Hunk header line
+// counts are ignored, as git apply --recount does.
+func parsePatch(patch string) []*patchFile {
+	var files []*patchFile
+	var cur *patchFile
+	var hunk *patchHunk
+	headerSeen := false
+	flush := func() {
+		if cur != nil && hunk != nil {
+			cur.hunks = append(cur.hunks, *hunk)
+		}
+		hunk = nil
+	}
+	lines := strings.Split(strings.TrimRight(patch, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			cur = &patchFile{}
+			files = append(files, cur)
+			headerSeen = false
+			if parts := strings.Fields(line); len(parts) >= 4 {
+				cur.oldPath, cur.newPath = patchPath(parts[2]), patchPath(parts[3])
+			}
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			flush()
+			if cur == nil || headerSeen || len(cur.hunks) > 0 {
+				cur = &patchFile{}
+				files = append(files, cur)
+			}
+			headerSeen = true
+			cur.oldPath = patchPath(strings.TrimPrefix(line, "--- "))
+		case strings.HasPrefix(line, "+++ ") && hunk == nil && cur != nil:
+			cur.newPath = patchPath(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "index ") && hunk == nil && cur != nil && len(strings.Fields(line)) > 1:
+			base, _, _ := strings.Cut(strings.Fields(line)[1], "..")
+			if strings.Trim(base, "0") != "" {
+				cur.baseBlob = base
+			}
+		case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+			if cur != nil {
+				cur.binary = true
+			}
+		case strings.HasPrefix(line, "@@ "):
+			flush()
+			if cur == nil {
+				cur = &patchFile{}
+				files = append(files, cur)
+			}
+			hunk = &patchHunk{header: line, oldStart: hunkOldStart(line)}
+		case hunk != nil:
+			switch {
+			case line == "":
+				// Blank context lines often lose their leading space
+				hunk.lines = append(hunk.lines, " ")
+			case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+				hunk.lines = append(hunk.lines, line)
+			case line[0] == '\\':
+				// "\ No newline at end of file"
+			default:
+				flush()
+			}
+		}
+	}
+	flush()
+	return files
+}
+
+// patchPath strips the a/ or b/ prefix and any timestamp from a patch path,
+// returning "" for /dev/null
+func patchPath(field string) string {
+	fields := strings.Fields(field)
+	if len(fields) == 0 || fields[0] == "/dev/null" {
+		return ""
+	}
+	p := fields[0]
+	if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+		p = p[2:]
+	}
+	return p
+}
+
+// hunkOldStart returns the old-file start line of a "@@ -a,b +c,d @@" header
+func hunkOldStart(header string) int {
+	for _, field := range strings.Fields(header) {
+		if strings.HasPrefix(field, "-") {
+			start, _, _ := strings.Cut(field[1:], ",")
+			if n, err := strconv.Atoi(start); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// hunkReport lists every hunk of the patch with one status
+func hunkReport(files []*patchFile, status string) []HunkResult {
+	var results []HunkResult
+	for _, f := range files {
+		for i, h := range f.hunks {
+			results = append(results, HunkResult{File: f.path(), Index: i + 1, Header: h.header, Status: status})
+		}
+	}
+	return results
+}
+
+// applyFallback is tried when a patch doesn't apply as is: a 3-way merge when
+// every file records a base blob the repository has, else the fuzzy applier
+func (m *Manager) applyFallback(ctx context.Context, workDir, patch, checkOutput string) (*PatchResult, error) {
+	files := parsePatch(patch)
+	if canThreeWay(ctx, workDir, files) {
+		if output, err := threeWayApply(ctx, workDir, patch, files); err == nil {
+			return &PatchResult{Applied: true, Strategy: Patch3Way, Output: output, Hunks: hunkReport(files, HunkMerged)}, nil
+		}
+	}
+	if m.PatchFuzz < 0 || len(files) == 0 {
+		return &PatchResult{
+			Applied: false,
+			Output:  fmt.Sprintf("patch validation failed: %s", checkOutput),
+		}, fmt.Errorf("patch validation failed: %s", checkOutput)
+	}
+	fuzz := m.PatchFuzz
+	if fuzz == 0 {
+		fuzz = DefaultPatchFuzz
+	}
+
+	res := &PatchResult{Strategy: PatchFuzzy}
+	applied := 0
+	for _, f := range files {
+		results, err := applyFileFuzzy(workDir, f, fuzz)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range results {
+			if r.Status != HunkFailed {
+				applied++
+			}
+		}
+		res.Hunks = append(res.Hunks, results...)
+	}
+	res.Applied = applied == len(res.Hunks)
+	res.Output = summarizeHunks(res.Hunks)
+	switch {
+	case applied == 0:
+		return res, fmt.Errorf("patch did not apply: all %d hunks failed", len(res.Hunks))
+	case !res.Applied:
+		return res, fmt.Errorf("patch partially applied: %d of %d hunks failed", len(res.Hunks)-applied, len(res.Hunks))
+	}
+	return res, nil
+}
+
+// summarizeHunks describes the hunks that didn't apply cleanly
+func summarizeHunks(hunks []HunkResult) string {
+	counts := map[string]int{}
+	var notes []string
+	for _, h := range hunks {
+		counts[h.Status]++
+		if h.Status == HunkFuzzy || h.Status == HunkFailed {
+			notes = append(notes, fmt.Sprintf("%s hunk %d (%s): %s", h.File, h.Index, h.Status, h.Reason))
+		}
+	}
+	summary := fmt.Sprintf("%d clean, %d fuzzy, %d failed", counts[HunkClean], counts[HunkFuzzy], counts[HunkFailed])
+	if len(notes) == 0 {
+		return summary
+	}
+	return summary + "\n" + strings.Join(notes, "\n")
+}
+
+// canThreeWay reports whether every modified file records a base blob that
+// the repository has
+func canThreeWay(ctx context.Context, workDir string, files []*patchFile) bool {
+	if len(files) == 0 {
+		return false
+	}
+	for _, f := range files {
+		if f.oldPath == "" {
+			continue
+		}
+		if f.baseBlob == "" {
+			return false
+		}
+		cmd := exec.CommandContext(ctx, "git", "cat-file", "-e", f.baseBlob)
+		cmd.Dir = workDir
+		if cmd.Run() != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// threeWayApply runs git apply --3way against a scratch index holding the
+// current working tree files, so neither the real index nor the working
+// tree is touched unless the merge is clean
+func threeWayApply(ctx context.Context, workDir, patch string, files []*patchFile) (string, error) {
+	scratch, err := os.CreateTemp("", "loom-index-*")
+	if err != nil {
+		return "", err
+	}
+	index := scratch.Name()
+	scratch.Close()
+	os.Remove(index)
+	defer os.Remove(index)
+
+	git := func(stdin string, args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+index)
+		if stdin != "" {
+			cmd.Stdin = strings.NewReader(stdin)
+		}
+		var out, stderr bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return out.String(), nil
+	}
+
+	if _, err := git("", "read-tree", "HEAD"); err != nil {
+		return "", err
+	}
+	var existing []string
+	for _, f := range files {
+		if f.oldPath == "" {
+			continue
+		}
+		if fileExists(workDir, f.oldPath) {
+			existing = append(existing, f.oldPath)
+		}
+	}
+	if len(existing) > 0 {
+		if _, err := git("", append([]string{"add", "-f", "--"}, existing...)...); err != nil {
+			return "", err
+		}
+	}
+	if _, err := git(patch, "apply", "--3way", "--cached", "--recount", "--whitespace=nowarn", "-"); err != nil {
+		return "", err
+	}
+
+	for _, f := range files {
+		if f.newPath == "" {
+			target, err := SafeJoin(workDir, f.oldPath)
+			if err != nil {
+				return "", err
+			}
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return "", err
+			}
+			continue
+		}
+		content, err := git("", "show", ":"+f.newPath)
+		if err != nil {
+			return "", err
+		}
+		if err := writeWorkFile(workDir, f.newPath, content); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("merged %d file(s) against their base blobs", len(files)), nil
+}
+
+func fileExists(workDir, relPath string) bool {
+	target, err := SafeJoin(workDir, relPath)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(target)
+	return err == nil
+}
+
+// writeWorkFile writes a patched file, keeping the mode of the one it
+// replaces
+func writeWorkFile(workDir, relPath, content string) error {
+	target, err := SafeJoin(workDir, relPath)
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(target); err == nil {
+		mode = info.Mode().Perm()
+	} else if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, []byte(content), mode)
+}
+
+// applyFileFuzzy applies one file's hunks with the fuzzy applier, writing
+// whatever applied
+func applyFileFuzzy(workDir string, f *patchFile, fuzz int) ([]HunkResult, error) {
+	results := hunkReport([]*patchFile{f}, HunkFailed)
+	fail := func(reason string) []HunkResult {
+		for i := range results {
+			results[i].Reason = reason
+			results[i].Hunk = hunkText(f.hunks[i])
+		}
+		return results
+	}
+	switch {
+	case f.binary:
+		return fail("binary patches need an exact match"), nil
+	case f.oldPath != "" && f.newPath != "" && f.oldPath != f.newPath:
+		return fail("renames need an exact match"), nil
+	}
+
+	var lines []string
+	trailingNewline := true
+	if f.oldPath == "" {
+		if fileExists(workDir, f.newPath) {
+			return fail("file already exists"), nil
+		}
+	} else {
+		target, err := SafeJoin(workDir, f.oldPath)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return fail("file not found"), nil
+		}
+		content := string(data)
+		trailingNewline = content == "" || strings.HasSuffix(content, "\n")
+		if content = strings.TrimSuffix(content, "\n"); content != "" {
+			lines = strings.Split(content, "\n")
+		}
+	}
+
+	shift, offset, from := 0, 0, 0
+	changed := false
+	for i, h := range f.hunks {
+		base := h.oldStart - 1
+		if base < 0 {
+			base = 0
+		}
+		m, ok := matchHunk(lines, h, base+shift+offset, from, fuzz)
+		if !ok {
+			results[i].Reason = "context not found"
+			results[i].Hunk = hunkText(h)
+			continue
+		}
+		replacement := m.replacement(lines, h)
+		lines = append(lines[:m.pos], append(replacement, lines[m.pos+m.length:]...)...)
+		changed = true
+
+		results[i].Line = m.pos + 1
+		results[i].Offset = m.pos - m.lead - (base + shift)
+		offset = results[i].Offset
+		shift += len(replacement) - m.length
+		from = m.pos + len(replacement)
+		if m.fuzz == 0 && !m.loose {
+			results[i].Status = HunkClean
+			continue
+		}
+		results[i].Status = HunkFuzzy
+		results[i].Fuzz = m.fuzz
+		if m.fuzz > 0 {
+			results[i].Reason = fmt.Sprintf("ignored up to %d context line(s) at each end", m.fuzz)
+		} else {
+			results[i].Reason = "whitespace differences ignored"
+		}
+	}
+	if !changed {
+		return results, nil
+	}
+
+	if f.newPath == "" {
+		if len(lines) > 0 {
+			for i := range results {
+				if results[i].Status != HunkFailed {
+					results[i].Status, results[i].Reason = HunkFailed, "lines would remain in the deleted file"
+					results[i].Hunk = hunkText(f.hunks[i])
+				}
+			}
+			return results, nil
+		}
+		target, err := SafeJoin(workDir, f.oldPath)
+		if err != nil {
+			return nil, err
+		}
+		return results, os.Remove(target)
+	}
+	content := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		content += "\n"
+	}
+	return results, writeWorkFile(workDir, f.newPath, content)
+}
+
+func hunkText(h patchHunk) string {
+	return h.header + "\n" + strings.Join(h.lines, "\n")
+}
+
+// hunkMatch is where a hunk's old lines were found
+type hunkMatch struct {
+	pos    int // First matched line
+	length int // Lines matched
+	lead   int // Leading context lines ignored
+	trail  int // Trailing context lines ignored
+	fuzz   int
+	loose  bool // Whitespace ignored
+}
+
+// replacement builds the lines that replace the match: context from the
+// file as it is, additions from the hunk
+func (m hunkMatch) replacement(lines []string, h patchHunk) []string {
+	body := h.lines[m.lead : len(h.lines)-m.trail]
+	var out []string
+	at := m.pos
+	for _, line := range body {
+		switch line[0] {
+		case ' ':
+			out = append(out, lines[at])
+			at++
+		case '-':
+			at++
+		case '+':
+			out = append(out, line[1:])
+		}
+	}
+	return out
+}
+
+// matchHunk finds a hunk's old lines nearest to the expected position and
+// no earlier than from, first exactly, then ignoring whitespace, then
+// ignoring up to fuzz context lines at each end
+func matchHunk(lines []string, h patchHunk, expected, from, fuzz int) (hunkMatch, bool) {
+	leadCtx, trailCtx := 0, 0
+	for _, l := range h.lines {
+		if l[0] != ' ' {
+			break
+		}
+		leadCtx++
+	}
+	for i := len(h.lines) - 1; i >= 0 && h.lines[i][0] == ' '; i-- {
+		trailCtx++
+	}
+	if leadCtx == len(h.lines) {
+		// Context only, nothing to change
+		trailCtx = 0
+	}
+
+	for level := 0; level <= fuzz+1; level++ {
+		m := hunkMatch{loose: level > 0}
+		if level > 1 {
+			m.fuzz = level - 1
+			m.lead, m.trail = min(m.fuzz, leadCtx), min(m.fuzz, trailCtx)
+			if m.lead == 0 && m.trail == 0 {
+				continue
+			}
+		}
+		var old []string
+		for _, l := range h.lines[m.lead : len(h.lines)-m.trail] {
+			if l[0] != '+' {
+				old = append(old, l[1:])
+			}
+		}
+		m.length = len(old)
+		if len(old) == 0 {
+			// Pure addition: insert where the header says
+			m.pos = max(from, min(expected, len(lines)))
+			return m, true
+		}
+		want := expected + m.lead
+		for d := 0; ; d++ {
+			below, above := want-d, want+d
+			if below < from && above+len(old) > len(lines) {
+				break
+			}
+			for _, pos := range []int{below, above} {
+				if pos >= from && pos+len(old) <= len(lines) && linesEqual(lines[pos:pos+len(old)], old, m.loose) {
+					m.pos = pos
+					return m, true
+				}
+			}
+		}
+	}
+	return hunkMatch{}, false
+}
+
+func linesEqual(a, b []string, loose bool) bool {
+	for i := range a {
+		if a[i] == b[i] {
+			continue
+		}
+		if !loose || strings.Join(strings.Fields(a[i]), " ") != strings.Join(strings.Fields(b[i]), " ") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func numberedLines(from, to int) string {
+	var sb strings.Builder
+	for i := from; i <= to; i++ {
+		fmt.Fprintf(&sb, "line %d\n", i)
+	}
+	return sb.String()
+}
+
+func readTestFile(t *testing.T, dir, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, rel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestApplyPatch_FuzzyFallback(t *testing.T) {
+	dir := initSnapshotRepo(t)
+	// Three lines were added above what the patch was written against, and
+	// line 12 was reindented
+	content := "header a\nheader b\nheader c\n" + strings.Replace(numberedLines(1, 30), "line 12\n", "  line 12\n", 1)
+	writeTestFile(t, dir, "notes.txt", content)
+
+	patch := `--- a/notes.txt
++++ b/notes.txt
+@@ -2,3 +2,3 @@
+ line 2
+-line 3
++line three
+ line 4
+@@ -11,3 +11,3 @@
+ line 11
+-line 12
++line twelve
+ line 13
+@@ -20,3 +20,3 @@
+ line 20
+-line 99
++line ninety-nine
+ line 22
+`
+	mgr := NewManager(staticResolver{dir: dir})
+	res, err := mgr.ApplyPatch(context.Background(), "proj-1", patch)
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 hunks failed") {
+		t.Fatalf("expected a partial application, got %v", err)
+	}
+	if res == nil || res.Strategy != PatchFuzzy || res.Applied || len(res.Hunks) != 3 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	clean, fuzzy, failed := res.Hunks[0], res.Hunks[1], res.Hunks[2]
+	if clean.Status != HunkClean || clean.Offset != 3 || clean.Line != 5 {
+		t.Errorf("expected hunk 1 to apply cleanly 3 lines down, got %+v", clean)
+	}
+	if fuzzy.Status != HunkFuzzy || fuzzy.Reason == "" {
+		t.Errorf("expected hunk 2 to apply fuzzily, got %+v", fuzzy)
+	}
+	if failed.Status != HunkFailed || !strings.Contains(failed.Hunk, "-line 99") {
+		t.Errorf("expected hunk 3 to fail with its text, got %+v", failed)
+	}
+
+	got := readTestFile(t, dir, "notes.txt")
+	if !strings.Contains(got, "line 2\nline three\nline 4\n") || !strings.Contains(got, "line 11\nline twelve\nline 13\n") {
+		t.Errorf("expected the first two hunks to be applied, got:\n%s", got)
+	}
+	if !strings.Contains(got, "line 20\nline 21\nline 22\n") {
+		t.Errorf("expected the failed hunk to leave the file alone, got:\n%s", got)
+	}
+}
+
+func TestApplyPatch_FuzzyContext(t *testing.T) {
+	dir := initSnapshotRepo(t)
+	writeTestFile(t, dir, "notes.txt", strings.Replace(numberedLines(1, 10), "line 3\n", "line 3 (edited)\n", 1))
+
+	patch := `--- a/notes.txt
++++ b/notes.txt
+@@ -3,5 +3,5 @@
+ line 3
+ line 4
+-line 5
++line five
+ line 6
+ line 7
+`
+	mgr := NewManager(staticResolver{dir: dir})
+	res, err := mgr.ApplyPatch(context.Background(), "proj-1", patch)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if !res.Applied || res.Hunks[0].Status != HunkFuzzy || res.Hunks[0].Fuzz != 1 {
+		t.Errorf("expected one fuzzy hunk ignoring a context line, got %+v", res)
+	}
+	if got := readTestFile(t, dir, "notes.txt"); !strings.Contains(got, "line 3 (edited)\nline 4\nline five\n") {
+		t.Errorf("unexpected content:\n%s", got)
+	}
+
+	mgr.PatchFuzz = -1
+	writeTestFile(t, dir, "notes.txt", strings.Replace(numberedLines(1, 10), "line 3\n", "line 3 (edited)\n", 1))
+	if res, err := mgr.ApplyPatch(context.Background(), "proj-1", patch); err == nil || res.Applied {
+		t.Errorf("expected the patch to fail with fuzzy matching off, got %+v", res)
+	}
+}
+
+func TestApplyPatch_ThreeWay(t *testing.T) {
+	dir := initSnapshotRepo(t)
+	writeTestFile(t, dir, "notes.txt", numberedLines(1, 20))
+	gitOutput(t, dir, "add", "notes.txt")
+	gitOutput(t, dir, "commit", "-q", "-m", "notes")
+
+	writeTestFile(t, dir, "notes.txt", strings.Replace(numberedLines(1, 20), "line 10\n", "line ten\n", 1))
+	patch := gitOutput(t, dir, "diff", "--full-index", "notes.txt")
+	gitOutput(t, dir, "checkout", "--", "notes.txt")
+
+	// A local edit inside the hunk's context makes git apply refuse it
+	writeTestFile(t, dir, "notes.txt", strings.Replace(numberedLines(1, 20), "line 7\n", "line seven\n", 1))
+
+	mgr := NewManager(staticResolver{dir: dir})
+	res, err := mgr.ApplyPatch(context.Background(), "proj-1", patch)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if res.Strategy != Patch3Way || !res.Applied || len(res.Hunks) != 1 || res.Hunks[0].Status != HunkMerged {
+		t.Errorf("expected a 3-way merge, got %+v", res)
+	}
+	if got := readTestFile(t, dir, "notes.txt"); !strings.Contains(got, "line seven\n") || !strings.Contains(got, "line ten\n") {
+		t.Errorf("expected both edits, got:\n%s", got)
+	}
+	if status := gitOutput(t, dir, "status", "--porcelain", "notes.txt"); strings.TrimSpace(status) != "M notes.txt" {
+		t.Errorf("expected the index to be left alone, got %q", status)
+	}
+}
+
+func TestParsePatch(t *testing.T) {
+	patch := `diff --git a/old.go b/old.go
+deleted file mode 100644
+index 1234567..0000000
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package old
+-
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1 @@
++package new
+--- a/main.go
++++ b/main.go
+@@ -1 +1,2 @@
+ package main
+
++--- not a header
+`
+	files := parsePatch(patch)
+	if len(files) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(files))
+	}
+	if files[0].newPath != "" || files[0].baseBlob != "1234567" || len(files[0].hunks[0].lines) != 2 {
+		t.Errorf("unexpected deletion %+v", files[0])
+	}
+	if files[1].oldPath != "" || files[1].newPath != "new.go" {
+		t.Errorf("unexpected creation %+v", files[1])
+	}
+	if lines := files[2].hunks[0].lines; len(lines) != 3 || lines[1] != " " || lines[2] != "+--- not a header" {
+		t.Errorf("unexpected hunk lines %q", lines)
+	}
+}
@@ -0,0 +1,351 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Snapshots are commits of the whole workdir (tracked and untracked files,
+// minus ignored ones) kept under their own ref namespace so they never show up
+// on a branch or get pushed. They are built through a temporary index, so
+// taking or restoring one leaves the real index and HEAD untouched.
+const (
+	snapshotRefPrefix   = "refs/loom/snapshots/"
+	defaultMaxSnapshots = 50
+	snapshotAuthorName  = "Loom"
+	snapshotAuthorEmail = "loom@localhost"
+)
+
+type Snapshot struct {
+	ID        string    `json:"id"`
+	ProjectID string    `json:"project_id"`
+	Label     string    `json:"label"`
+	Commit    string    `json:"commit"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SnapshotChange struct {
+	Status string `json:"status"` // git name-status letter: A, M, D, T
+	Path   string `json:"path"`
+}
+
+// SnapshotDiff describes how the current workdir differs from a snapshot
+type SnapshotDiff struct {
+	SnapshotID string           `json:"snapshot_id"`
+	Changes    []SnapshotChange `json:"changes"`
+	Diff       string           `json:"diff"`
+	Truncated  bool             `json:"truncated"`
+}
+
+type SnapshotRestore struct {
+	SnapshotID string   `json:"snapshot_id"`
+	BackupID   string   `json:"backup_id"` // Snapshot of the state that was overwritten
+	Removed    []string `json:"removed,omitempty"`
+}
+
+// CreateSnapshot records the current state of the project workdir. The oldest
+// snapshots are pruned once a project has more than defaultMaxSnapshots.
+func (m *Manager) CreateSnapshot(ctx context.Context, projectID, label string) (*Snapshot, error) {
+	workDir, err := m.resolveWorkDir(projectID)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := createSnapshot(ctx, workDir, label)
+	if err != nil {
+		return nil, err
+	}
+	snap.ProjectID = projectID
+	pruneSnapshots(ctx, workDir, defaultMaxSnapshots)
+	return snap, nil
+}
+
+// ListSnapshots returns a project's snapshots, newest first
+func (m *Manager) ListSnapshots(ctx context.Context, projectID string) ([]Snapshot, error) {
+	workDir, err := m.resolveWorkDir(projectID)
+	if err != nil {
+		return nil, err
+	}
+	snaps, err := listSnapshots(ctx, workDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range snaps {
+		snaps[i].ProjectID = projectID
+	}
+	return snaps, nil
+}
+
+// DiffSnapshot compares a snapshot with the current workdir
+func (m *Manager) DiffSnapshot(ctx context.Context, projectID, snapshotID string) (*SnapshotDiff, error) {
+	workDir, err := m.resolveWorkDir(projectID)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := resolveSnapshot(ctx, workDir, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := workTree(ctx, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	nameStatus, err := runGit(ctx, workDir, nil, "diff", "--name-status", "--no-renames", commit, current)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := runGit(ctx, workDir, nil, "diff", "--no-renames", commit, current)
+	if err != nil {
+		return nil, err
+	}
+	result := &SnapshotDiff{SnapshotID: snapshotID, Changes: parseNameStatus(nameStatus)}
+	if len(diff) > defaultMaxFileBytes {
+		diff = diff[:defaultMaxFileBytes]
+		result.Truncated = true
+	}
+	result.Diff = diff
+	return result, nil
+}
+
+// RestoreSnapshot resets the workdir to a snapshot. The state being replaced
+// is snapshotted first so the restore itself can be undone. Ignored files are
+// left alone.
+func (m *Manager) RestoreSnapshot(ctx context.Context, projectID, snapshotID string) (*SnapshotRestore, error) {
+	workDir, err := m.resolveWorkDir(projectID)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := resolveSnapshot(ctx, workDir, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := createSnapshot(ctx, workDir, "before restoring "+snapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot current state: %w", err)
+	}
+
+	// Files created since the snapshot are not in its tree, so remove them explicitly
+	added, err := runGit(ctx, workDir, nil, "diff", "--name-only", "--no-renames", "--diff-filter=A", commit, backup.Commit)
+	if err != nil {
+		return nil, err
+	}
+	result := &SnapshotRestore{SnapshotID: snapshotID, BackupID: backup.ID}
+	for _, rel := range strings.Split(strings.TrimSpace(added), "\n") {
+		if rel == "" {
+			continue
+		}
+		target, err := SafeJoin(workDir, rel)
+		if err != nil || isBlockedPath(target) {
+			continue
+		}
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove %s: %w", rel, err)
+		}
+		result.Removed = append(result.Removed, rel)
+		// Drop directories left empty; os.Remove refuses non-empty ones
+		for dir := filepath.Dir(target); dir != workDir && strings.HasPrefix(dir, workDir); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+
+	err = withTempIndex(ctx, workDir, false, func(env []string) error {
+		if _, err := runGit(ctx, workDir, env, "read-tree", commit); err != nil {
+			return err
+		}
+		_, err := runGit(ctx, workDir, env, "checkout-index", "--all", "--force")
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+	pruneSnapshots(ctx, workDir, defaultMaxSnapshots)
+	return result, nil
+}
+
+// DeleteSnapshot removes a snapshot
+func (m *Manager) DeleteSnapshot(ctx context.Context, projectID, snapshotID string) error {
+	workDir, err := m.resolveWorkDir(projectID)
+	if err != nil {
+		return err
+	}
+	if _, err := resolveSnapshot(ctx, workDir, snapshotID); err != nil {
+		return err
+	}
+	_, err = runGit(ctx, workDir, nil, "update-ref", "-d", snapshotRefPrefix+snapshotID)
+	return err
+}
+
+func createSnapshot(ctx context.Context, workDir, label string) (*Snapshot, error) {
+	tree, err := workTree(ctx, workDir)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if strings.TrimSpace(label) == "" {
+		label = "snapshot " + now.Format(time.RFC3339)
+	}
+
+	args := []string{"commit-tree", tree, "-m", label}
+	if head, err := runGit(ctx, workDir, nil, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		args = append(args, "-p", strings.TrimSpace(head))
+	}
+	env := []string{
+		"GIT_AUTHOR_NAME=" + snapshotAuthorName,
+		"GIT_AUTHOR_EMAIL=" + snapshotAuthorEmail,
+		"GIT_COMMITTER_NAME=" + snapshotAuthorName,
+		"GIT_COMMITTER_EMAIL=" + snapshotAuthorEmail,
+	}
+	out, err := runGit(ctx, workDir, env, args...)
+	if err != nil {
+		return nil, err
+	}
+	commit := strings.TrimSpace(out)
+
+	id := "snap-" + strconv.FormatInt(now.UnixNano(), 36)
+	if _, err := runGit(ctx, workDir, nil, "update-ref", snapshotRefPrefix+id, commit); err != nil {
+		return nil, err
+	}
+	return &Snapshot{ID: id, Label: label, Commit: commit, CreatedAt: now}, nil
+}
+
+func listSnapshots(ctx context.Context, workDir string) ([]Snapshot, error) {
+	out, err := runGit(ctx, workDir, nil, "for-each-ref", "--format=%(refname:strip=3)%09%(objectname)%09%(creatordate:unix)%09%(contents:subject)", snapshotRefPrefix)
+	if err != nil {
+		return nil, err
+	}
+	snaps := []Snapshot{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[2], 10, 64)
+		snaps = append(snaps, Snapshot{
+			ID:        fields[0],
+			Commit:    fields[1],
+			CreatedAt: time.Unix(unix, 0).UTC(),
+			Label:     fields[3],
+		})
+	}
+	// creatordate only has second resolution; IDs are time-ordered at nanosecond resolution
+	sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].ID > snaps[j].ID })
+	return snaps, nil
+}
+
+func pruneSnapshots(ctx context.Context, workDir string, keep int) {
+	snaps, err := listSnapshots(ctx, workDir)
+	if err != nil || len(snaps) <= keep {
+		return
+	}
+	for _, snap := range snaps[keep:] {
+		_, _ = runGit(ctx, workDir, nil, "update-ref", "-d", snapshotRefPrefix+snap.ID)
+	}
+}
+
+func resolveSnapshot(ctx context.Context, workDir, snapshotID string) (string, error) {
+	if snapshotID == "" || strings.ContainsAny(snapshotID, "/\\ .~^:?*[") {
+		return "", fmt.Errorf("invalid snapshot id: %q", snapshotID)
+	}
+	out, err := runGit(ctx, workDir, nil, "rev-parse", "--verify", "--quiet", snapshotRefPrefix+snapshotID)
+	if err != nil {
+		return "", fmt.Errorf("snapshot not found: %s", snapshotID)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// workTree writes the current workdir contents to a git tree object
+func workTree(ctx context.Context, workDir string) (string, error) {
+	var tree string
+	err := withTempIndex(ctx, workDir, true, func(env []string) error {
+		if _, err := runGit(ctx, workDir, env, "add", "--all", "--", "."); err != nil {
+			return err
+		}
+		out, err := runGit(ctx, workDir, env, "write-tree")
+		tree = strings.TrimSpace(out)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to snapshot workdir: %w", err)
+	}
+	return tree, nil
+}
+
+// withTempIndex runs fn with GIT_INDEX_FILE pointing at a scratch index. When
+// seed is set the scratch index starts as a copy of the real one, which lets
+// git reuse its cached file stats instead of rehashing every file.
+func withTempIndex(ctx context.Context, workDir string, seed bool, fn func(env []string) error) error {
+	tmp, err := os.CreateTemp("", "loom-snapshot-index-*")
+	if err != nil {
+		return err
+	}
+	path := tmp.Name()
+	tmp.Close()
+	defer os.Remove(path)
+
+	copied := false
+	if seed {
+		if indexPath, err := runGit(ctx, workDir, nil, "rev-parse", "--path-format=absolute", "--git-path", "index"); err == nil {
+			copied = copyFile(strings.TrimSpace(indexPath), path) == nil
+		}
+	}
+	if !copied {
+		// git rejects an empty index file but happily creates a missing one
+		os.Remove(path)
+	}
+	return fn([]string{"GIT_INDEX_FILE=" + path})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func parseNameStatus(out string) []SnapshotChange {
+	changes := []SnapshotChange{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		status, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		changes = append(changes, SnapshotChange{Status: status, Path: path})
+	}
+	return changes
+}
+
+func runGit(ctx context.Context, workDir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = workDir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
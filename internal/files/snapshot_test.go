@@ -0,0 +1,165 @@
+package files
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func initSnapshotRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	writeTestFile(t, dir, "main.go", "package main\n")
+	writeTestFile(t, dir, ".gitignore", "build/\n")
+	cmd := exec.Command("git", "add", "-A")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v\n%s", err, out)
+	}
+	cmd = exec.Command("git", "commit", "-q", "-m", "init")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+	return dir
+}
+
+func writeTestFile(t *testing.T, dir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	dir := initSnapshotRepo(t)
+	mgr := NewManager(staticResolver{dir: dir})
+	ctx := context.Background()
+
+	writeTestFile(t, dir, "notes.txt", "draft\n")
+	statusBefore := gitOutput(t, dir, "status", "--porcelain")
+
+	snap, err := mgr.CreateSnapshot(ctx, "proj-1", "before refactor")
+	if err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	if snap.Label != "before refactor" || snap.ProjectID != "proj-1" || snap.Commit == "" {
+		t.Errorf("snapshot = %+v", snap)
+	}
+	if got := gitOutput(t, dir, "status", "--porcelain"); got != statusBefore {
+		t.Errorf("snapshot changed git status:\nbefore %q\nafter  %q", statusBefore, got)
+	}
+
+	writeTestFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+	writeTestFile(t, dir, "extra/new.go", "package extra\n")
+	if err := os.Remove(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "build/out.bin", "ignored")
+
+	diff, err := mgr.DiffSnapshot(ctx, "proj-1", snap.ID)
+	if err != nil {
+		t.Fatalf("DiffSnapshot: %v", err)
+	}
+	want := map[string]string{"main.go": "M", "extra/new.go": "A", "notes.txt": "D"}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("changes = %+v", diff.Changes)
+	}
+	for _, c := range diff.Changes {
+		if want[c.Path] != c.Status {
+			t.Errorf("change %s = %s, want %s", c.Path, c.Status, want[c.Path])
+		}
+	}
+
+	restore, err := mgr.RestoreSnapshot(ctx, "proj-1", snap.ID)
+	if err != nil {
+		t.Fatalf("RestoreSnapshot: %v", err)
+	}
+	if len(restore.Removed) != 1 || restore.Removed[0] != "extra/new.go" || restore.BackupID == "" {
+		t.Errorf("restore = %+v", restore)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "extra")); !os.IsNotExist(err) {
+		t.Errorf("empty directory left behind: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(data) != "package main\n" {
+		t.Errorf("main.go = %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "notes.txt")); string(data) != "draft\n" {
+		t.Errorf("notes.txt = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "build/out.bin")); err != nil {
+		t.Errorf("ignored file should survive restore: %v", err)
+	}
+
+	snaps, err := mgr.ListSnapshots(ctx, "proj-1")
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	if len(snaps) != 2 || snaps[0].ID != restore.BackupID || snaps[1].ID != snap.ID {
+		t.Errorf("snapshots = %+v", snaps)
+	}
+
+	if err := mgr.DeleteSnapshot(ctx, "proj-1", snap.ID); err != nil {
+		t.Fatalf("DeleteSnapshot: %v", err)
+	}
+	if _, err := mgr.DiffSnapshot(ctx, "proj-1", snap.ID); err == nil {
+		t.Error("expected error diffing a deleted snapshot")
+	}
+}
+
+func TestSnapshotRejectsInvalidID(t *testing.T) {
+	dir := initSnapshotRepo(t)
+	mgr := NewManager(staticResolver{dir: dir})
+	if _, err := mgr.RestoreSnapshot(context.Background(), "proj-1", "../heads/main"); err == nil {
+		t.Error("expected invalid snapshot id error")
+	}
+}
+
+func TestSnapshotPrune(t *testing.T) {
+	dir := initSnapshotRepo(t)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := createSnapshot(ctx, dir, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pruneSnapshots(ctx, dir, 2)
+	snaps, err := listSnapshots(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 {
+		t.Errorf("kept %d snapshots, want 2", len(snaps))
+	}
+}
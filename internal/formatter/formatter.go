@@ -0,0 +1,146 @@
+// Package formatter runs source formatters over file contents so callers can
+// write the result back through the file manager and report what changed.
+package formatter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+)
+
+// Supported formatters
+const (
+	Gofmt     = "gofmt"
+	Goimports = "goimports"
+	Prettier  = "prettier"
+	Black     = "black"
+	Rustfmt   = "rustfmt"
+)
+
+const defaultTimeout = 60 * time.Second
+
+// Manager formats files inside project workdirs
+type Manager struct {
+	WorkDirs files.WorkDirResolver
+	Timeout  time.Duration
+
+	runner toolrun.Runner
+}
+
+// NewManager creates a formatter manager
+func NewManager(resolver files.WorkDirResolver) *Manager {
+	return &Manager{WorkDirs: resolver, Timeout: defaultTimeout}
+}
+
+// ToolFor returns the default formatter for a path, or "" if there is none
+func ToolFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return Gofmt
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".css", ".scss", ".json":
+		return Prettier
+	case ".py":
+		return Black
+	case ".rs":
+		return Rustfmt
+	}
+	return ""
+}
+
+// Format runs tool over content, as if it were the file at path, and returns
+// the formatted content. The file itself is not touched.
+func (m *Manager) Format(ctx context.Context, projectID, path, content, tool string) (string, error) {
+	workDir, err := m.resolveWorkDir(projectID)
+	if err != nil {
+		return "", err
+	}
+	name, args, err := m.command(workDir, path, tool)
+	if err != nil {
+		return "", err
+	}
+
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout, stderr, err := m.runner.Exec(ctx, toolrun.Command{Dir: workDir, Stdin: []byte(content), Name: name, Args: args})
+	if err != nil {
+		msg := strings.TrimSpace(string(stderr))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("%s failed on %s: %s", tool, path, msg)
+	}
+	return string(stdout), nil
+}
+
+// ChangedFiles lists files modified, added or untracked relative to HEAD
+func (m *Manager) ChangedFiles(ctx context.Context, projectID string) ([]string, error) {
+	workDir, err := m.resolveWorkDir(projectID)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := m.runner.Exec(ctx, toolrun.Command{Dir: workDir, Name: "git",
+		Args: []string{"status", "--porcelain", "-z", "--untracked-files=all"}})
+	if err != nil {
+		return nil, fmt.Errorf("git status failed: %w", err)
+	}
+
+	var paths []string
+	entries := strings.Split(string(out), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		status, path := entry[:2], entry[3:]
+		if status[0] == 'R' || status[0] == 'C' {
+			i++ // The source path of a rename or copy follows
+		}
+		if status[0] == 'D' || status[1] == 'D' {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func (m *Manager) command(workDir, path, tool string) (string, []string, error) {
+	switch tool {
+	case Gofmt:
+		return "gofmt", nil, nil
+	case Goimports:
+		return "goimports", []string{"-srcdir", filepath.Join(workDir, filepath.Dir(path))}, nil
+	case Prettier:
+		bin := filepath.Join(workDir, "node_modules", ".bin", "prettier")
+		if _, err := os.Stat(bin); err != nil {
+			bin = "prettier"
+		}
+		return bin, []string{"--stdin-filepath", path}, nil
+	case Black:
+		return "black", []string{"-q", "--stdin-filename", path, "-"}, nil
+	case Rustfmt:
+		return "rustfmt", []string{"--edition", "2021"}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported formatter %q (use gofmt, goimports, prettier, black or rustfmt)", tool)
+}
+
+func (m *Manager) resolveWorkDir(projectID string) (string, error) {
+	if m.WorkDirs == nil {
+		return "", fmt.Errorf("workdir resolver not configured")
+	}
+	workDir := m.WorkDirs.GetProjectWorkDir(projectID)
+	if workDir == "" {
+		return "", fmt.Errorf("project workdir not found")
+	}
+	return filepath.Clean(workDir), nil
+}
@@ -0,0 +1,102 @@
+package formatter
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/toolrun"
+)
+
+func TestToolFor(t *testing.T) {
+	for path, want := range map[string]string{
+		"main.go":       Gofmt,
+		"web/App.tsx":   Prettier,
+		"style.SCSS":    Prettier,
+		"app/models.py": Black,
+		"src/lib.rs":    Rustfmt,
+		"README.md":     "",
+		"Makefile":      "",
+	} {
+		if got := ToolFor(path); got != want {
+			t.Errorf("ToolFor(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestFormatGofmt(t *testing.T) {
+	if _, err := exec.LookPath("gofmt"); err != nil {
+		t.Skip("gofmt not available")
+	}
+	m := NewManager(toolrun.StaticWorkDir(t.TempDir()))
+	out, err := m.Format(context.Background(), "p", "main.go", "package main\nfunc main(){\nx:=1\n_ = x}\n", Gofmt)
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	want := "package main\n\nfunc main() {\n\tx := 1\n\t_ = x\n}\n"
+	if out != want {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	if _, err := m.Format(context.Background(), "p", "main.go", "package main\nfunc {", Gofmt); err == nil || !strings.Contains(err.Error(), "gofmt failed on main.go") {
+		t.Fatalf("expected syntax error to be reported, got %v", err)
+	}
+	if _, err := m.Format(context.Background(), "p", "main.go", "", "clang-format"); err == nil {
+		t.Fatal("expected unsupported formatter error")
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write("a.go", "package a\n")
+	write("gone.go", "package a\n")
+	git("add", ".")
+	git("-c", "user.name=t", "-c", "user.email=t@t", "commit", "-qm", "init")
+
+	write("a.go", "package a\n\nvar x = 1\n")
+	write("pkg/new.go", "package pkg\n")
+	if err := os.Remove(filepath.Join(dir, "gone.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewManager(toolrun.StaticWorkDir(dir)).ChangedFiles(context.Background(), "p")
+	if err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	if want := []string{"a.go", "pkg/new.go"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ChangedFiles = %v, want %v", got, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	diff, added, removed := Diff("main.go", "a\nb\nc\n", "a\nB\nc\nd\n")
+	if added != 2 || removed != 1 {
+		t.Fatalf("added/removed = %d/%d, want 2/1", added, removed)
+	}
+	if !strings.Contains(diff, "--- a/main.go") || !strings.Contains(diff, "+B") {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+	if diff, _, _ := Diff("main.go", "same", "same"); diff != "" {
+		t.Fatalf("expected no diff for identical content, got %q", diff)
+	}
+}
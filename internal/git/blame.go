@@ -0,0 +1,224 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxBlameLines caps how many lines a blame covers when no end line is given
+const MaxBlameLines = 400
+
+// uncommittedSHA is what git blame reports for lines not yet committed
+const uncommittedSHA = "0000000000000000000000000000000000000000"
+
+// BlameRequest selects the lines of a file to annotate. Lines are 1-based
+// and inclusive; a zero StartLine means the top of the file and a zero
+// EndLine MaxBlameLines lines from the start.
+type BlameRequest struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+}
+
+// BlameLine is the provenance of one line
+type BlameLine struct {
+	Line        int       `json:"line"`
+	SHA         string    `json:"sha"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"author_email,omitempty"`
+	AuthorTime  time.Time `json:"author_time"`
+	AgeDays     int       `json:"age_days"`
+	Summary     string    `json:"summary"`
+	BeadID      string    `json:"bead_id,omitempty"` // From the commit's Bead trailer
+	Uncommitted bool      `json:"uncommitted,omitempty"`
+	Text        string    `json:"text"`
+}
+
+// Blame is the line-by-line provenance of a file range
+type Blame struct {
+	Path  string       `json:"path"`
+	Lines []*BlameLine `json:"lines"`
+	Beads []string     `json:"beads"` // Beads that introduced the lines, in order of appearance
+}
+
+// BlameArgs validates req and returns the git arguments that annotate it
+func BlameArgs(req BlameRequest) ([]string, error) {
+	if req.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	if err := ValidateRepoPath(req.Path); err != nil {
+		return nil, err
+	}
+	if req.StartLine < 0 || req.EndLine < 0 || (req.EndLine > 0 && req.EndLine < req.StartLine) {
+		return nil, fmt.Errorf("invalid line range %d-%d", req.StartLine, req.EndLine)
+	}
+	start := req.StartLine
+	if start == 0 {
+		start = 1
+	}
+	lines := fmt.Sprintf("%d,+%d", start, MaxBlameLines)
+	if req.EndLine > 0 {
+		lines = fmt.Sprintf("%d,%d", start, req.EndLine)
+	}
+	return []string{"-c", "core.quotePath=false", "blame", "--line-porcelain", "-L", lines, "--", path.Clean(req.Path)}, nil
+}
+
+// ParseBlame parses git blame --line-porcelain output. Ages are measured
+// from now.
+func ParseBlame(output string, now time.Time) []*BlameLine {
+	lines := []*BlameLine{}
+	var line *BlameLine
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "\t") {
+			if line != nil {
+				line.Text = text[1:]
+				lines = append(lines, line)
+				line = nil
+			}
+			continue
+		}
+		if line == nil {
+			// Header: <sha> <original line> <final line> [<group size>]
+			fields := strings.Fields(text)
+			if len(fields) < 3 || len(fields[0]) < 40 {
+				continue
+			}
+			line = &BlameLine{SHA: fields[0], Line: atoi(fields[2]), Uncommitted: fields[0] == uncommittedSHA}
+			continue
+		}
+		key, value, _ := strings.Cut(text, " ")
+		switch key {
+		case "author":
+			line.Author = value
+		case "author-mail":
+			line.AuthorEmail = strings.Trim(value, "<>")
+		case "author-time":
+			if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+				line.AuthorTime = time.Unix(sec, 0).UTC()
+				line.AgeDays = int(now.Sub(line.AuthorTime).Hours() / 24)
+			}
+		case "summary":
+			line.Summary = value
+		}
+	}
+	return lines
+}
+
+// Blame annotates a file range with the commit, author, age and bead that
+// last changed each line
+func (s *GitService) Blame(ctx context.Context, req BlameRequest) (*Blame, error) {
+	args, err := BlameArgs(req)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.projectPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git blame failed: %w%s", err, stderrOf(err))
+	}
+
+	blame := &Blame{Path: path.Clean(req.Path), Lines: ParseBlame(string(output), time.Now()), Beads: []string{}}
+	beads, err := s.commitBeads(ctx, blame.Lines)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, l := range blame.Lines {
+		l.BeadID = beads[l.SHA]
+		if l.BeadID != "" && !seen[l.BeadID] {
+			seen[l.BeadID] = true
+			blame.Beads = append(blame.Beads, l.BeadID)
+		}
+	}
+	return blame, nil
+}
+
+// commitBeads maps the commits behind lines to the beads in their trailers
+func (s *GitService) commitBeads(ctx context.Context, lines []*BlameLine) (map[string]string, error) {
+	beads := map[string]string{}
+	args := []string{"show", "--no-patch", "--format=%H%x1f%B%x1e"}
+	for _, l := range lines {
+		if _, ok := beads[l.SHA]; ok || l.Uncommitted {
+			continue
+		}
+		beads[l.SHA] = ""
+		args = append(args, l.SHA)
+	}
+	if len(beads) == 0 {
+		return beads, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.projectPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show failed: %w%s", err, stderrOf(err))
+	}
+	for _, entry := range strings.Split(string(output), "\x1e") {
+		sha, body, ok := strings.Cut(strings.TrimSpace(entry), "\x1f")
+		if ok {
+			beads[sha] = ParseCommitMetadata(body).BeadID
+		}
+	}
+	return beads, nil
+}
+
+// Format renders the blame with consecutive lines from the same commit
+// grouped under one heading
+func (b *Blame) Format() string {
+	var sb strings.Builder
+	if len(b.Lines) == 0 {
+		fmt.Fprintf(&sb, "%s: no lines\n", b.Path)
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "%s lines %d-%d", b.Path, b.Lines[0].Line, b.Lines[len(b.Lines)-1].Line)
+	if len(b.Beads) > 0 {
+		fmt.Fprintf(&sb, " (beads: %s)", strings.Join(b.Beads, ", "))
+	}
+	sb.WriteByte('\n')
+
+	var prev string
+	for _, l := range b.Lines {
+		if l.SHA != prev {
+			prev = l.SHA
+			if l.Uncommitted {
+				sb.WriteString("\nuncommitted changes\n")
+			} else {
+				fmt.Fprintf(&sb, "\n%s %s, %s", l.SHA[:12], l.Author, formatAge(l.AgeDays))
+				if l.BeadID != "" {
+					fmt.Fprintf(&sb, " [%s]", l.BeadID)
+				}
+				fmt.Fprintf(&sb, ": %s\n", l.Summary)
+			}
+		}
+		fmt.Fprintf(&sb, "%5d| %s\n", l.Line, l.Text)
+	}
+	return sb.String()
+}
+
+// formatAge renders an age in days the way a reader would say it
+func formatAge(days int) string {
+	switch {
+	case days < 1:
+		return "today"
+	case days == 1:
+		return "1 day ago"
+	case days < 60:
+		return fmt.Sprintf("%d days ago", days)
+	case days < 730:
+		return fmt.Sprintf("%d months ago", days/30)
+	default:
+		return fmt.Sprintf("%d years ago", days/365)
+	}
+}
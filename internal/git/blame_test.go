@@ -0,0 +1,104 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const samplePorcelain = `d3fab6e2ecf3229595bfe92b8b1be26dd839d8bd 1 1 1
+author Ada
+author-mail <ada@example.com>
+author-time 1700000000
+author-tz +0000
+summary Add parser
+filename f.go
+	package f
+0000000000000000000000000000000000000000 3 2 1
+author Not Committed Yet
+author-mail <not.committed.yet>
+author-time 1700864000
+author-tz +0000
+summary Version of f.go from f.go
+filename f.go
+	// wip
+`
+
+func TestParseBlame(t *testing.T) {
+	now := time.Unix(1700864000, 0)
+	lines := ParseBlame(samplePorcelain, now)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	l := lines[0]
+	if l.Line != 1 || l.Author != "Ada" || l.AuthorEmail != "ada@example.com" || l.AgeDays != 10 || l.Summary != "Add parser" || l.Text != "package f" || l.Uncommitted {
+		t.Errorf("unexpected first line %+v", l)
+	}
+	if l := lines[1]; l.Line != 2 || !l.Uncommitted || l.AgeDays != 0 || l.Text != "// wip" {
+		t.Errorf("unexpected uncommitted line %+v", l)
+	}
+
+	lines[0].BeadID = "loom-1"
+	text := (&Blame{Path: "f.go", Lines: lines, Beads: []string{"loom-1"}}).Format()
+	for _, want := range []string{"f.go lines 1-2 (beads: loom-1)", "d3fab6e2ecf3 Ada, 10 days ago [loom-1]: Add parser", "    1| package f", "uncommitted changes"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Format() is missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestBlameArgs(t *testing.T) {
+	args, err := BlameArgs(BlameRequest{Path: "a/./b.go", StartLine: 5, EndLine: 9})
+	if err != nil || !strings.HasSuffix(strings.Join(args, " "), "-L 5,9 -- a/b.go") {
+		t.Errorf("BlameArgs() = %v, %v", args, err)
+	}
+	args, _ = BlameArgs(BlameRequest{Path: "b.go"})
+	if !strings.Contains(strings.Join(args, " "), "-L 1,+400") {
+		t.Errorf("expected the default range to be capped, got %v", args)
+	}
+	for _, bad := range []BlameRequest{{}, {Path: "../x"}, {Path: "b.go", StartLine: 9, EndLine: 5}, {Path: "b.go", StartLine: -1}} {
+		if _, err := BlameArgs(bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestBlame(t *testing.T) {
+	dir, cleanup := setupTestGitRepo(t)
+	defer cleanup()
+	svc := createTestGitService(t, dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Test Repo\nMore\nWork in progress\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := execGit(dir, "commit", "-am", "Add more\n\nBead: loom-42\nAgent: agent-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Test Repo\nMore\nDone\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	blame, err := svc.Blame(context.Background(), BlameRequest{Path: "README.md", StartLine: 1, EndLine: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blame.Lines) != 3 || len(blame.Beads) != 1 || blame.Beads[0] != "loom-42" {
+		t.Fatalf("unexpected blame %+v", blame)
+	}
+	if l := blame.Lines[0]; l.BeadID != "" || l.Summary != "Initial commit" || l.Author != "Loom Test" {
+		t.Errorf("unexpected first line %+v", l)
+	}
+	if l := blame.Lines[1]; l.BeadID != "loom-42" || l.Text != "More" || l.AgeDays != 0 {
+		t.Errorf("unexpected second line %+v", l)
+	}
+	if l := blame.Lines[2]; !l.Uncommitted || l.BeadID != "" {
+		t.Errorf("unexpected uncommitted line %+v", l)
+	}
+
+	if _, err := svc.Blame(context.Background(), BlameRequest{Path: "README.md", StartLine: 10}); err == nil {
+		t.Error("expected a range past the end of the file to fail")
+	}
+}
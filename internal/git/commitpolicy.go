@@ -0,0 +1,226 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// DefaultMaxSubjectLength is the longest commit subject kept untruncated
+const DefaultMaxSubjectLength = 72
+
+// DefaultCommitTypes are the conventional commit types allowed by default
+var DefaultCommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// Commit policy rules, as reported in violations
+const (
+	RuleEmptySubject  = "empty_subject"
+	RuleConventional  = "conventional"
+	RuleSubjectLength = "subject_length"
+	RuleBeadTrailer   = "bead_trailer"
+)
+
+// CommitPolicyViolation is one way a commit message breaks the policy
+type CommitPolicyViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// CommitPolicyError is returned when a commit message breaks the policy in
+// a way that could not be fixed
+type CommitPolicyError struct {
+	Violations []CommitPolicyViolation
+}
+
+func (e *CommitPolicyError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Message
+	}
+	return "commit message rejected by policy: " + strings.Join(msgs, "; ")
+}
+
+// CommitPolicy checks and fixes agent commit messages
+type CommitPolicy struct {
+	conventional bool
+	types        []string
+	maxSubject   int // No limit when not positive
+	requireBead  bool
+	autoFix      bool
+}
+
+var commitTypePattern = regexp.MustCompile(`^[a-z]+$`)
+
+// conventionalSubject matches "type(scope)!: description"
+var conventionalSubject = regexp.MustCompile(`^([A-Za-z]+)(\([^()]*\))?(!)?:\s*(.*)$`)
+
+// typeAliases are common misspellings of conventional types
+var typeAliases = map[string]string{
+	"feature": "feat", "features": "feat", "bugfix": "fix", "hotfix": "fix",
+	"doc": "docs", "tests": "test", "refactoring": "refactor", "performance": "perf",
+}
+
+// verbTypes infer a conventional type from the verb a plain subject starts with
+var verbTypes = map[string]string{
+	"add": "feat", "adds": "feat", "added": "feat", "implement": "feat", "implements": "feat", "implemented": "feat",
+	"introduce": "feat", "introduces": "feat", "support": "feat", "supports": "feat",
+	"fix": "fix", "fixes": "fix", "fixed": "fix", "resolve": "fix", "resolves": "fix", "resolved": "fix",
+	"refactor": "refactor", "refactors": "refactor", "refactored": "refactor", "simplify": "refactor", "simplifies": "refactor",
+	"document": "docs", "documents": "docs", "documented": "docs", "docs": "docs",
+	"test": "test", "tests": "test", "revert": "revert", "reverts": "revert", "reverted": "revert",
+}
+
+// DefaultCommitPolicy truncates long subjects and nothing more
+func DefaultCommitPolicy() *CommitPolicy {
+	return &CommitPolicy{types: DefaultCommitTypes, maxSubject: DefaultMaxSubjectLength, autoFix: true}
+}
+
+// NewCommitPolicy builds the policy a config describes
+func NewCommitPolicy(cfg config.CommitPolicyConfig) (*CommitPolicy, error) {
+	p := DefaultCommitPolicy()
+	p.conventional = cfg.Conventional
+	p.requireBead = cfg.RequireBeadTrailer
+	p.autoFix = !cfg.DisableAutoFix
+	if cfg.MaxSubjectLength != 0 {
+		p.maxSubject = cfg.MaxSubjectLength
+	}
+	if len(cfg.Types) > 0 {
+		p.types = nil
+		for _, t := range cfg.Types {
+			if !commitTypePattern.MatchString(t) {
+				return nil, fmt.Errorf("invalid commit type %q: types are lowercase words", t)
+			}
+			p.types = append(p.types, t)
+		}
+	}
+	return p, nil
+}
+
+func (p *CommitPolicy) allowed(commitType string) bool {
+	for _, t := range p.types {
+		if t == commitType {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply checks a commit message for the given bead against the policy. It
+// returns the message with unambiguous violations fixed and a description
+// of each fix, or a *CommitPolicyError listing what could not be fixed.
+// The Bead trailer is appended whenever the bead is known and the message
+// lacks one.
+func (p *CommitPolicy) Apply(message, beadID string) (string, []string, error) {
+	var fixes []string
+	var violations []CommitPolicyViolation
+	violate := func(rule, format string, args ...interface{}) {
+		violations = append(violations, CommitPolicyViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	subject, body, _ := strings.Cut(strings.TrimRight(message, " \t\n"), "\n")
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		if !p.autoFix {
+			violate(RuleEmptySubject, "the subject line is empty")
+			return "", nil, &CommitPolicyError{Violations: violations}
+		}
+		subject = "Update from agent"
+		if p.conventional {
+			subject = "chore: update from agent"
+		}
+		fixes = append(fixes, "filled in the empty subject")
+	}
+
+	if p.conventional {
+		fixed, fix, err := p.conventionalize(subject)
+		switch {
+		case err != "":
+			violate(RuleConventional, "%s", err)
+		case fix != "":
+			subject = fixed
+			fixes = append(fixes, fix)
+		}
+	}
+
+	if n := utf8.RuneCountInString(subject); p.maxSubject > 0 && n > p.maxSubject {
+		if p.autoFix {
+			subject = string([]rune(subject)[:max(p.maxSubject-3, 1)]) + "..."
+			fixes = append(fixes, fmt.Sprintf("truncated the subject to %d characters", p.maxSubject))
+		} else {
+			violate(RuleSubjectLength, "the subject is %d characters, longer than %d", n, p.maxSubject)
+		}
+	}
+
+	message = subject
+	if body != "" {
+		message += "\n" + body
+	}
+	if ParseCommitMetadata(message).BeadID == "" {
+		if beadID != "" {
+			message += fmt.Sprintf("\n\nBead: %s", beadID)
+		} else if p.requireBead {
+			violate(RuleBeadTrailer, "the commit has no Bead trailer and no bead to attribute it to")
+		}
+	}
+
+	if len(violations) > 0 {
+		return "", nil, &CommitPolicyError{Violations: violations}
+	}
+	return message, fixes, nil
+}
+
+// conventionalize returns subject in conventional commit form with a
+// description of the fix made, or why it could not be made
+func (p *CommitPolicy) conventionalize(subject string) (string, string, string) {
+	if m := conventionalSubject.FindStringSubmatch(subject); m != nil {
+		commitType, scope, breaking, description := m[1], m[2], m[3], m[4]
+		if description == "" {
+			return "", "", "the subject has a type but no description"
+		}
+		want := strings.ToLower(commitType)
+		if alias, ok := typeAliases[want]; ok && !p.allowed(want) {
+			want = alias
+		}
+		if !p.allowed(want) {
+			return "", "", fmt.Sprintf("commit type %q is not one of %s", commitType, strings.Join(p.types, ", "))
+		}
+		fixed := want + scope + breaking + ": " + description
+		if fixed == subject {
+			return subject, "", ""
+		}
+		if !p.autoFix {
+			return "", "", fmt.Sprintf("the subject should read %q", fixed)
+		}
+		return fixed, fmt.Sprintf("normalized the commit type to %q", want), ""
+	}
+
+	word, rest, _ := strings.Cut(subject, " ")
+	commitType, ok := verbTypes[strings.ToLower(word)]
+	if !ok || !p.allowed(commitType) {
+		return "", "", fmt.Sprintf("the subject must start with a conventional type (%s), as in \"fix: handle empty input\"", strings.Join(p.types, ", "))
+	}
+	// "Fix login crash" reads "fix: login crash", "Add retries" "feat: add retries"
+	description := subject
+	if strings.HasPrefix(strings.ToLower(word), commitType) && rest != "" {
+		description = rest
+	}
+	fixed := commitType + ": " + lowerFirst(description)
+	if !p.autoFix {
+		return "", "", fmt.Sprintf("the subject must start with a conventional type; it should read %q", fixed)
+	}
+	return fixed, fmt.Sprintf("added the %q commit type", commitType), ""
+}
+
+// lowerFirst lowercases the first letter of s unless it starts an acronym
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	next, _ := utf8.DecodeRuneInString(s[size:])
+	if unicode.IsUpper(next) {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[size:]
+}
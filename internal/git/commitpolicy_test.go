@@ -0,0 +1,113 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+func TestCommitPolicyAutoFix(t *testing.T) {
+	policy, err := NewCommitPolicy(config.CommitPolicyConfig{Conventional: true, MaxSubjectLength: 40, RequireBeadTrailer: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		message, want string
+	}{
+		{"fix(auth): handle empty password", "fix(auth): handle empty password"},
+		{"Feat!: drop the v1 API", "feat!: drop the v1 API"},
+		{"feature: add dark mode", "feat: add dark mode"},
+		{"Fix login crash on Safari", "fix: login crash on Safari"},
+		{"Add retries to the CI poller", "feat: add retries to the CI poller"},
+		{"Document CLI flags\n\nDetails.", "docs: document CLI flags\n\nDetails."},
+		{"fix: " + strings.Repeat("x", 50), "fix: " + strings.Repeat("x", 32) + "..."},
+		{"", "chore: update from agent"},
+	}
+	for _, c := range cases {
+		got, _, err := policy.Apply(c.message, "loom-1")
+		if err != nil {
+			t.Errorf("Apply(%q) failed: %v", c.message, err)
+			continue
+		}
+		if want := c.want + "\n\nBead: loom-1"; got != want {
+			t.Errorf("Apply(%q) = %q, want %q", c.message, got, want)
+		}
+	}
+
+	got, fixes, err := policy.Apply("Fixes crash\n\nBead: loom-7", "loom-1")
+	if err != nil || got != "fix: crash\n\nBead: loom-7" || len(fixes) != 1 {
+		t.Errorf("Apply() with a trailer = %q, %v, %v", got, fixes, err)
+	}
+}
+
+func TestCommitPolicyRejects(t *testing.T) {
+	policy, err := NewCommitPolicy(config.CommitPolicyConfig{Conventional: true, Types: []string{"feat", "fix"}, RequireBeadTrailer: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"Update the README":        RuleConventional,
+		"docs: update the README":  RuleConventional,
+		"fix: ":                    RuleConventional,
+		"fix: handle empty input":  RuleBeadTrailer,
+		"Refactor the parser more": RuleConventional,
+	}
+	for message, rule := range cases {
+		_, _, err := policy.Apply(message, "")
+		var policyErr *CommitPolicyError
+		if !errors.As(err, &policyErr) || policyErr.Violations[0].Rule != rule {
+			t.Errorf("Apply(%q) = %v, want a %s violation", message, err, rule)
+		}
+	}
+
+	strict, _ := NewCommitPolicy(config.CommitPolicyConfig{Conventional: true, DisableAutoFix: true})
+	_, _, err = strict.Apply("Fix: "+strings.Repeat("x", 80), "loom-1")
+	var policyErr *CommitPolicyError
+	if !errors.As(err, &policyErr) || len(policyErr.Violations) != 2 || policyErr.Violations[1].Rule != RuleSubjectLength {
+		t.Fatalf("expected type and length violations, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `should read "fix: xxx`) {
+		t.Errorf("expected the fix to be suggested, got %q", err)
+	}
+
+	if _, err := NewCommitPolicy(config.CommitPolicyConfig{Types: []string{"Feat"}}); err == nil {
+		t.Error("expected an invalid type to be rejected")
+	}
+}
+
+func TestDefaultCommitPolicy(t *testing.T) {
+	got, _, err := DefaultCommitPolicy().Apply("Whatever the agent wrote", "")
+	if err != nil || got != "Whatever the agent wrote" {
+		t.Errorf("default policy changed the message: %q, %v", got, err)
+	}
+}
+
+func TestCommitWithPolicy(t *testing.T) {
+	dir, cleanup := setupTestGitRepo(t)
+	defer cleanup()
+	svc := createTestGitService(t, dir)
+	policy, _ := NewCommitPolicy(config.CommitPolicyConfig{Conventional: true})
+	svc.SetCommitPolicy(policy)
+
+	if err := os.WriteFile(filepath.Join(dir, "feature.go"), []byte("package feature\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := svc.Commit(context.Background(), CommitRequest{BeadID: "loom-1", Message: "Update things", Files: []string{"feature.go"}})
+	var policyErr *CommitPolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected a policy rejection, got %v", err)
+	}
+
+	result, err := svc.Commit(context.Background(), CommitRequest{BeadID: "loom-1", AgentID: "agent-1", Message: "Add feature module", Files: []string{"feature.go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Message != "feat: add feature module\n\nBead: loom-1\nAgent: agent-1" || len(result.PolicyFixes) != 1 {
+		t.Errorf("unexpected commit %q with fixes %v", result.Message, result.PolicyFixes)
+	}
+}
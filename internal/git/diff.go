@@ -0,0 +1,386 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultDiffContext is how many unchanged lines surround each hunk
+const DefaultDiffContext = 3
+
+// Kinds of diff lines
+const (
+	LineContext = "context"
+	LineAdded   = "added"
+	LineDeleted = "deleted"
+)
+
+// Statuses of a file in a diff
+const (
+	FileAdded    = "added"
+	FileDeleted  = "deleted"
+	FileModified = "modified"
+	FileRenamed  = "renamed"
+	FileCopied   = "copied"
+)
+
+// RevisionDiffRequest selects what to compare. From defaults to HEAD; an
+// empty To compares against the working tree. Path limits the diff to a
+// file or directory.
+type RevisionDiffRequest struct {
+	From    string `json:"from"`
+	To      string `json:"to,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Context int    `json:"context,omitempty"` // Default DefaultDiffContext
+}
+
+// RevisionDiff is a structured diff between two revisions
+type RevisionDiff struct {
+	From      string      `json:"from"`
+	To        string      `json:"to"` // "working tree" when comparing against it
+	Path      string      `json:"path,omitempty"`
+	Files     []*FileDiff `json:"files"`
+	Additions int         `json:"additions"`
+	Deletions int         `json:"deletions"`
+}
+
+// FileDiff is the change to one file
+type FileDiff struct {
+	OldPath   string  `json:"old_path,omitempty"` // Empty for added files
+	NewPath   string  `json:"new_path,omitempty"` // Empty for deleted files
+	Status    string  `json:"status"`
+	Binary    bool    `json:"binary,omitempty"`
+	Additions int     `json:"additions"`
+	Deletions int     `json:"deletions"`
+	Hunks     []*Hunk `json:"hunks"`
+}
+
+// Path is the file's path after the change, or before it for deletions
+func (f *FileDiff) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// Hunk is one contiguous change with its surrounding context
+type Hunk struct {
+	OldStart int         `json:"old_start"`
+	OldLines int         `json:"old_lines"`
+	NewStart int         `json:"new_start"`
+	NewLines int         `json:"new_lines"`
+	Section  string      `json:"section,omitempty"` // Enclosing function or heading git found
+	Lines    []*DiffLine `json:"lines"`
+}
+
+// Header is the hunk's unified diff header
+func (h *Hunk) Header() string {
+	header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	if h.Section != "" {
+		header += " " + h.Section
+	}
+	return header
+}
+
+// DiffLine is one line of a hunk. OldLine and NewLine are its line numbers
+// on each side, zero on the side it is absent from.
+type DiffLine struct {
+	Kind      string `json:"kind"`
+	OldLine   int    `json:"old_line,omitempty"`
+	NewLine   int    `json:"new_line,omitempty"`
+	Text      string `json:"text"`
+	NoNewline bool   `json:"no_newline,omitempty"` // Last line of a file without a trailing newline
+}
+
+// refPattern accepts branch names, SHAs and revision expressions such as
+// HEAD~2 or origin/main^
+var refPattern = regexp.MustCompile(`^[A-Za-z0-9._/@{}^~:+-]+$`)
+
+// ValidateRef rejects refs that could be read as git options or that hold
+// characters no ref uses
+func ValidateRef(ref string) error {
+	if !refPattern.MatchString(ref) || strings.HasPrefix(ref, "-") || strings.Contains(ref, "..") {
+		return fmt.Errorf("invalid revision %q", ref)
+	}
+	return nil
+}
+
+// ValidateRepoPath rejects paths that leave the repository
+func ValidateRepoPath(p string) error {
+	if p == "" {
+		return nil
+	}
+	clean := path.Clean(p)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || strings.HasPrefix(p, "-") {
+		return fmt.Errorf("path %q is outside the repository", p)
+	}
+	return nil
+}
+
+// RevisionDiffArgs validates req, fills in its defaults and returns the git
+// arguments that produce its diff
+func RevisionDiffArgs(req *RevisionDiffRequest) ([]string, error) {
+	if req.From == "" {
+		req.From = "HEAD"
+	}
+	if req.Context <= 0 {
+		req.Context = DefaultDiffContext
+	}
+	if err := ValidateRef(req.From); err != nil {
+		return nil, err
+	}
+	args := []string{"-c", "core.quotePath=false", "diff", "--no-color", "--no-ext-diff", "--find-renames",
+		fmt.Sprintf("--unified=%d", req.Context), req.From}
+	if req.To != "" {
+		if err := ValidateRef(req.To); err != nil {
+			return nil, err
+		}
+		args = append(args, req.To)
+	}
+	if err := ValidateRepoPath(req.Path); err != nil {
+		return nil, err
+	}
+	args = append(args, "--")
+	if req.Path != "" {
+		args = append(args, path.Clean(req.Path))
+	}
+	return args, nil
+}
+
+// NewRevisionDiff parses the output of the command RevisionDiffArgs built
+// for req
+func NewRevisionDiff(req RevisionDiffRequest, output string) *RevisionDiff {
+	d := &RevisionDiff{From: req.From, To: req.To, Path: req.Path, Files: ParseDiff(output)}
+	if d.To == "" {
+		d.To = "working tree"
+	}
+	for _, f := range d.Files {
+		d.Additions += f.Additions
+		d.Deletions += f.Deletions
+	}
+	return d
+}
+
+// DiffRevisions returns the structured diff between two revisions
+func (s *GitService) DiffRevisions(ctx context.Context, req RevisionDiffRequest) (*RevisionDiff, error) {
+	args, err := RevisionDiffArgs(&req)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.projectPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w%s", err, stderrOf(err))
+	}
+	return NewRevisionDiff(req, string(output)), nil
+}
+
+// stderrOf returns the stderr of a failed command, for error messages
+func stderrOf(err error) string {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return "\nOutput: " + strings.TrimSpace(string(exitErr.Stderr))
+	}
+	return ""
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// ParseDiff parses unified diff output of git diff into files and hunks
+func ParseDiff(output string) []*FileDiff {
+	files := []*FileDiff{}
+	var file *FileDiff
+	var hunk *Hunk
+	oldLine, newLine := 0, 0
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "diff --git ") {
+			oldPath, newPath := splitDiffHeader(strings.TrimPrefix(line, "diff --git "))
+			file = &FileDiff{OldPath: oldPath, NewPath: newPath, Status: FileModified, Hunks: []*Hunk{}}
+			files = append(files, file)
+			hunk = nil
+			continue
+		}
+		if file == nil {
+			continue
+		}
+		if hunk != nil {
+			switch {
+			case strings.HasPrefix(line, " "):
+				hunk.Lines = append(hunk.Lines, &DiffLine{Kind: LineContext, OldLine: oldLine, NewLine: newLine, Text: line[1:]})
+				oldLine++
+				newLine++
+				continue
+			case strings.HasPrefix(line, "+"):
+				hunk.Lines = append(hunk.Lines, &DiffLine{Kind: LineAdded, NewLine: newLine, Text: line[1:]})
+				file.Additions++
+				newLine++
+				continue
+			case strings.HasPrefix(line, "-"):
+				hunk.Lines = append(hunk.Lines, &DiffLine{Kind: LineDeleted, OldLine: oldLine, Text: line[1:]})
+				file.Deletions++
+				oldLine++
+				continue
+			case strings.HasPrefix(line, `\`):
+				if n := len(hunk.Lines); n > 0 {
+					hunk.Lines[n-1].NoNewline = true
+				}
+				continue
+			case line == "":
+				// Some tools strip the space from empty context lines
+				hunk.Lines = append(hunk.Lines, &DiffLine{Kind: LineContext, OldLine: oldLine, NewLine: newLine})
+				oldLine++
+				newLine++
+				continue
+			}
+		}
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			hunk = &Hunk{
+				OldStart: atoi(m[1]),
+				OldLines: countOrOne(m[2]),
+				NewStart: atoi(m[3]),
+				NewLines: countOrOne(m[4]),
+				Section:  m[5],
+				Lines:    []*DiffLine{},
+			}
+			file.Hunks = append(file.Hunks, hunk)
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "new file mode"):
+			file.Status = FileAdded
+		case strings.HasPrefix(line, "deleted file mode"):
+			file.Status = FileDeleted
+		case strings.HasPrefix(line, "rename from "):
+			file.Status, file.OldPath = FileRenamed, unquotePath(strings.TrimPrefix(line, "rename from "))
+		case strings.HasPrefix(line, "rename to "):
+			file.NewPath = unquotePath(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "copy from "):
+			file.Status, file.OldPath = FileCopied, unquotePath(strings.TrimPrefix(line, "copy from "))
+		case strings.HasPrefix(line, "copy to "):
+			file.NewPath = unquotePath(strings.TrimPrefix(line, "copy to "))
+		case strings.HasPrefix(line, "Binary files "), line == "GIT binary patch":
+			file.Binary = true
+		case strings.HasPrefix(line, "--- "):
+			if p := strings.TrimPrefix(line, "--- "); p != "/dev/null" {
+				file.OldPath = strings.TrimPrefix(unquotePath(p), "a/")
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if p := strings.TrimPrefix(line, "+++ "); p != "/dev/null" {
+				file.NewPath = strings.TrimPrefix(unquotePath(p), "b/")
+			}
+		}
+	}
+
+	for _, f := range files {
+		switch f.Status {
+		case FileAdded:
+			f.OldPath = ""
+		case FileDeleted:
+			f.NewPath = ""
+		}
+	}
+	return files
+}
+
+// splitDiffHeader splits "a/<old> b/<new>" from a diff --git line. The
+// paths are equal unless the file was renamed, which git also reports on
+// rename lines, so the split only needs to be right for equal paths.
+func splitDiffHeader(header string) (string, string) {
+	if strings.HasPrefix(header, `"`) {
+		if old, rest, ok := cutQuoted(header); ok {
+			return strings.TrimPrefix(old, "a/"), strings.TrimPrefix(unquotePath(strings.TrimSpace(rest)), "b/")
+		}
+	}
+	rest := strings.TrimPrefix(header, "a/")
+	if n := (len(rest) - 3) / 2; n > 0 && len(rest) == 2*n+3 && rest[n:n+3] == " b/" && rest[:n] == rest[n+3:] {
+		return rest[:n], rest[:n]
+	}
+	if i := strings.Index(rest, " b/"); i >= 0 {
+		return rest[:i], unquotePath(rest[i+3:])
+	}
+	return rest, rest
+}
+
+// cutQuoted splits a leading C-quoted string from s
+func cutQuoted(s string) (string, string, bool) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			unquoted, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", false
+			}
+			return unquoted, s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// unquotePath undoes git's quoting of paths with unusual characters
+func unquotePath(p string) string {
+	if strings.HasPrefix(p, `"`) {
+		if unquoted, err := strconv.Unquote(p); err == nil {
+			return unquoted
+		}
+	}
+	return p
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// countOrOne reads a hunk line count, which git omits when it is one
+func countOrOne(s string) int {
+	if s == "" {
+		return 1
+	}
+	return atoi(s)
+}
+
+// Format renders the diff as unified diff text with each file's hunks
+// numbered, so a reader can refer to "hunk 2 of foo.go"
+func (d *RevisionDiff) Format() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s..%s: %d file(s), +%d -%d\n", d.From, d.To, len(d.Files), d.Additions, d.Deletions)
+	for _, f := range d.Files {
+		name := f.Path()
+		if f.Status == FileRenamed || f.Status == FileCopied {
+			name = f.OldPath + " -> " + f.NewPath
+		}
+		fmt.Fprintf(&sb, "\n%s (%s, +%d -%d)\n", name, f.Status, f.Additions, f.Deletions)
+		if f.Binary {
+			sb.WriteString("Binary file\n")
+		}
+		for i, h := range f.Hunks {
+			fmt.Fprintf(&sb, "Hunk %d: %s\n", i+1, h.Header())
+			for _, l := range h.Lines {
+				switch l.Kind {
+				case LineAdded:
+					sb.WriteByte('+')
+				case LineDeleted:
+					sb.WriteByte('-')
+				default:
+					sb.WriteByte(' ')
+				}
+				sb.WriteString(l.Text)
+				sb.WriteByte('\n')
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,149 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,5 @@ package main
+ import "fmt"
+-func old() {}
++func newer() {}
++func extra() {}
+
+ func main() {}
+@@ -10 +11 @@ func main() {}
+-x
+\ No newline at end of file
++y
+diff --git a/docs/new file.md b/docs/new file.md
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/docs/new file.md
+@@ -0,0 +1 @@
++hello
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/old/name.go b/new/name.go
+similarity index 90%
+rename from old/name.go
+rename to new/name.go
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParseDiff(t *testing.T) {
+	files := ParseDiff(sampleDiff)
+	if len(files) != 5 {
+		t.Fatalf("expected 5 files, got %d", len(files))
+	}
+
+	main := files[0]
+	if main.Path() != "main.go" || main.Status != FileModified || main.Additions != 3 || main.Deletions != 2 || len(main.Hunks) != 2 {
+		t.Fatalf("unexpected main.go diff %+v", main)
+	}
+	h := main.Hunks[0]
+	if h.OldStart != 1 || h.OldLines != 4 || h.NewStart != 1 || h.NewLines != 5 || h.Section != "package main" || len(h.Lines) != 6 {
+		t.Fatalf("unexpected first hunk %+v", h)
+	}
+	if l := h.Lines[1]; l.Kind != LineDeleted || l.OldLine != 2 || l.NewLine != 0 || l.Text != "func old() {}" {
+		t.Errorf("unexpected deleted line %+v", l)
+	}
+	if l := h.Lines[3]; l.Kind != LineAdded || l.NewLine != 3 || l.Text != "func extra() {}" {
+		t.Errorf("unexpected added line %+v", l)
+	}
+	if l := h.Lines[5]; l.Kind != LineContext || l.OldLine != 4 || l.NewLine != 5 {
+		t.Errorf("unexpected context line %+v", l)
+	}
+	h2 := main.Hunks[1]
+	if h2.OldLines != 1 || h2.NewLines != 1 || !h2.Lines[0].NoNewline || h2.Lines[1].NoNewline {
+		t.Errorf("unexpected second hunk %+v", h2)
+	}
+
+	if f := files[1]; f.Status != FileAdded || f.OldPath != "" || f.NewPath != "docs/new file.md" || f.Additions != 1 {
+		t.Errorf("unexpected added file %+v", f)
+	}
+	if f := files[2]; f.Status != FileDeleted || f.NewPath != "" || f.Path() != "gone.txt" || f.Deletions != 1 {
+		t.Errorf("unexpected deleted file %+v", f)
+	}
+	if f := files[3]; f.Status != FileRenamed || f.OldPath != "old/name.go" || f.NewPath != "new/name.go" || len(f.Hunks) != 0 {
+		t.Errorf("unexpected renamed file %+v", f)
+	}
+	if f := files[4]; !f.Binary || f.Path() != "logo.png" {
+		t.Errorf("unexpected binary file %+v", f)
+	}
+
+	text := NewRevisionDiff(RevisionDiffRequest{From: "HEAD"}, sampleDiff).Format()
+	for _, want := range []string{"HEAD..working tree: 5 file(s), +4 -3", "Hunk 2: @@ -10,1 +11,1 @@ func main() {}", "old/name.go -> new/name.go (renamed"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Format() is missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestRevisionDiffArgs(t *testing.T) {
+	req := RevisionDiffRequest{To: "feature/x", Path: "internal/./git"}
+	args, err := RevisionDiffArgs(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(args, " ")
+	if !strings.HasSuffix(got, "--unified=3 HEAD feature/x -- internal/git") {
+		t.Errorf("unexpected args %q", got)
+	}
+
+	for _, bad := range []RevisionDiffRequest{
+		{From: "--output=/tmp/x"},
+		{From: "main..evil"},
+		{To: "a b"},
+		{Path: "../secrets"},
+		{Path: "/etc/passwd"},
+	} {
+		if _, err := RevisionDiffArgs(&bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestDiffRevisions(t *testing.T) {
+	dir, cleanup := setupTestGitRepo(t)
+	defer cleanup()
+	svc := createTestGitService(t, dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Test Repo\nMore\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := svc.DiffRevisions(context.Background(), RevisionDiffRequest{Path: "README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Files) != 1 || diff.Additions != 1 || diff.To != "working tree" {
+		t.Fatalf("unexpected working tree diff %+v", diff)
+	}
+	if l := diff.Files[0].Hunks[0].Lines[1]; l.Kind != LineAdded || l.NewLine != 2 || l.Text != "More" {
+		t.Errorf("unexpected added line %+v", l)
+	}
+
+	if err := execGit(dir, "commit", "-am", "More"); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = svc.DiffRevisions(context.Background(), RevisionDiffRequest{From: "HEAD~1", To: "HEAD"})
+	if err != nil || len(diff.Files) != 1 || diff.Files[0].Path() != "README.md" {
+		t.Fatalf("DiffRevisions(HEAD~1, HEAD) = %+v, %v", diff, err)
+	}
+	if _, err := svc.DiffRevisions(context.Background(), RevisionDiffRequest{From: "no-such-branch"}); err == nil {
+		t.Error("expected an unknown revision to fail")
+	}
+}
@@ -2,12 +2,15 @@ package git
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/jordanhubbard/loom/internal/secretscan"
 )
 
 // setupTestGitRepo creates a temporary git repository for testing.
@@ -1734,6 +1737,45 @@ func TestGitServiceCommitWithSecrets(t *testing.T) {
 	}
 }
 
+func TestGitServicePushBlocksUnpushedSecrets(t *testing.T) {
+	dir, cleanup := setupTestGitRepo(t)
+	defer cleanup()
+
+	svc := createTestGitService(t, dir)
+	ctx := context.Background()
+
+	// A commit made outside the gate still can't be pushed
+	key := "AKIA" + "IOSFODNN7EXAMPLE"
+	if err := os.WriteFile(filepath.Join(dir, "aws.go"), []byte("package aws\n\nconst id = \""+key+"\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if err := execGit(dir, "add", "aws.go"); err != nil {
+		t.Fatal(err)
+	}
+	if err := execGit(dir, "commit", "-m", "Add AWS client"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := svc.Push(ctx, PushRequest{BeadID: "bead-secret"})
+	var blocked *secretscan.BlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("expected the push to be blocked, got %v", err)
+	}
+	if blocked.Operation != "push" || len(blocked.Findings) != 1 {
+		t.Fatalf("unexpected findings %+v", blocked)
+	}
+	f := blocked.Findings[0]
+	if f.RuleID != "aws-access-key-id" || f.File != "aws.go" || f.Line != 3 || f.Commit == "" || strings.Contains(f.Match, key) {
+		t.Errorf("unexpected finding %+v", f)
+	}
+
+	// Turning the gate off lets the push reach the remote step
+	svc.SetSecretScanner(nil)
+	if _, err := svc.Push(ctx, PushRequest{BeadID: "bead-secret"}); errors.As(err, &blocked) {
+		t.Errorf("expected no secrets gate, got %v", err)
+	}
+}
+
 func TestGitServiceLogFormatParsing(t *testing.T) {
 	dir, cleanup := setupTestGitRepo(t)
 	defer cleanup()
@@ -0,0 +1,439 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Version bumps
+const (
+	BumpMajor = "major"
+	BumpMinor = "minor"
+	BumpPatch = "patch"
+)
+
+// versionFile locates the version in a file: the second group of the first
+// match is the version, the others what surrounds it
+type versionFile struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// versionFiles are the version files bump_version knows, in the order the
+// current version is read from
+var versionFiles = []versionFile{
+	{"VERSION", regexp.MustCompile(`^(\s*)(\S+)()`)},
+	{"package.json", regexp.MustCompile(`(?m)^(\s*"version"\s*:\s*")([^"]+)(")`)},
+	{"pyproject.toml", regexp.MustCompile(`(?m)^(version\s*=\s*")([^"]+)(")`)},
+	{"Cargo.toml", regexp.MustCompile(`(?m)^(version\s*=\s*")([^"]+)(")`)},
+	{"Chart.yaml", regexp.MustCompile(`(?m)^(version:\s*)(\S+)()`)},
+}
+
+var semverPattern = regexp.MustCompile(`^(v?)(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// NextVersion bumps a semantic version. A prerelease is released by the
+// bump that leads to it, so 2.0.0-rc.1 bumped major is 2.0.0.
+func NextVersion(current, bump string) (string, error) {
+	m := semverPattern.FindStringSubmatch(strings.TrimSpace(current))
+	if m == nil {
+		return "", fmt.Errorf("%q is not a semantic version", current)
+	}
+	major, minor, patch := atoi(m[2]), atoi(m[3]), atoi(m[4])
+	pre := m[5] != ""
+	switch bump {
+	case BumpMajor:
+		if !pre || minor != 0 || patch != 0 {
+			major++
+		}
+		minor, patch = 0, 0
+	case BumpMinor:
+		if !pre || patch != 0 {
+			minor++
+		}
+		patch = 0
+	case BumpPatch:
+		if !pre {
+			patch++
+		}
+	default:
+		return "", fmt.Errorf("unknown bump %q: use major, minor or patch", bump)
+	}
+	return fmt.Sprintf("%s%d.%d.%d", m[1], major, minor, patch), nil
+}
+
+// BumpVersionRequest defines parameters for bumping a project's version
+type BumpVersionRequest struct {
+	Bump    string   // major, minor or patch
+	Version string   // Explicit version, instead of Bump
+	Files   []string // Version files (default: the known ones present)
+	BeadID  string   // Bead ID for audit trail
+}
+
+// BumpVersionResult contains version bump results
+type BumpVersionResult struct {
+	Previous string   `json:"previous"`
+	Version  string   `json:"version"`
+	Tag      string   `json:"tag"` // Tag for the release
+	Files    []string `json:"files"`
+}
+
+// BumpVersion writes a new version into the project's version files. The
+// current version is read from the first of them; the change is left
+// uncommitted.
+func (s *GitService) BumpVersion(ctx context.Context, req BumpVersionRequest) (*BumpVersionResult, error) {
+	if (req.Bump == "") == (req.Version == "") {
+		return nil, fmt.Errorf("exactly one of bump or version is required")
+	}
+	if req.Version != "" && !semverPattern.MatchString(req.Version) {
+		return nil, fmt.Errorf("%q is not a semantic version", req.Version)
+	}
+
+	files := req.Files
+	if len(files) == 0 {
+		for _, vf := range versionFiles {
+			if _, err := os.Stat(filepath.Join(s.projectPath, vf.name)); err == nil {
+				files = append(files, vf.name)
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no version file found; looked for %s", knownVersionFiles())
+		}
+	}
+
+	type edit struct {
+		file    string
+		pattern *regexp.Regexp
+		content []byte
+		current string
+	}
+	edits := make([]edit, 0, len(files))
+	for _, f := range files {
+		if err := ValidateRepoPath(f); err != nil || f == "" {
+			return nil, fmt.Errorf("invalid version file %q", f)
+		}
+		pattern := versionPattern(path.Base(f))
+		if pattern == nil {
+			return nil, fmt.Errorf("unsupported version file %s; supported are %s", f, knownVersionFiles())
+		}
+		content, err := os.ReadFile(filepath.Join(s.projectPath, path.Clean(f)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f, err)
+		}
+		m := pattern.FindSubmatch(content)
+		if m == nil {
+			return nil, fmt.Errorf("no version found in %s", f)
+		}
+		edits = append(edits, edit{file: path.Clean(f), pattern: pattern, content: content, current: string(m[2])})
+	}
+
+	result := &BumpVersionResult{Previous: edits[0].current, Version: req.Version}
+	if result.Version == "" {
+		next, err := NextVersion(result.Previous, req.Bump)
+		if err != nil {
+			return nil, err
+		}
+		result.Version = next
+	}
+	result.Tag = versionTag(result.Version)
+
+	// Each file keeps its own convention for a leading "v"
+	bare := strings.TrimPrefix(result.Version, "v")
+	for _, e := range edits {
+		version := bare
+		if strings.HasPrefix(e.current, "v") {
+			version = "v" + bare
+		}
+		loc := e.pattern.FindSubmatchIndex(e.content)
+		updated := append(append(append([]byte{}, e.content[:loc[4]]...), version...), e.content[loc[5]:]...)
+		if err := os.WriteFile(filepath.Join(s.projectPath, e.file), updated, 0644); err != nil {
+			s.auditLogger.LogOperation("bump_version", req.BeadID, e.file, false, err)
+			return nil, fmt.Errorf("failed to write %s: %w", e.file, err)
+		}
+		result.Files = append(result.Files, e.file)
+	}
+
+	s.auditLogger.LogOperation("bump_version", req.BeadID, result.Previous+" -> "+result.Version, true, nil)
+	return result, nil
+}
+
+func versionPattern(name string) *regexp.Regexp {
+	for _, vf := range versionFiles {
+		if vf.name == name {
+			return vf.pattern
+		}
+	}
+	return nil
+}
+
+func knownVersionFiles() string {
+	names := make([]string, len(versionFiles))
+	for i, vf := range versionFiles {
+		names[i] = vf.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// TagRequest defines parameters for creating an annotated tag
+type TagRequest struct {
+	Name    string // Tag name, e.g. v1.2.0
+	Message string // Annotation (default "Release <name>")
+	Ref     string // Commit to tag (default HEAD)
+	BeadID  string // Bead ID for the annotation's trailer and audit trail
+}
+
+// TagResult contains tag creation results
+type TagResult struct {
+	Name      string `json:"name"`
+	CommitSHA string `json:"commit_sha"` // Commit the tag points at
+}
+
+// CreateTag creates an annotated tag. Push publishes it along with the
+// commits it points at.
+func (s *GitService) CreateTag(ctx context.Context, req TagRequest) (*TagResult, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("tag name is required")
+	}
+	if err := ValidateRef(req.Name); err != nil {
+		return nil, err
+	}
+	check := exec.CommandContext(ctx, "git", "check-ref-format", "refs/tags/"+req.Name)
+	if err := check.Run(); err != nil {
+		return nil, fmt.Errorf("invalid tag name %q", req.Name)
+	}
+	ref := req.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := ValidateRef(ref); err != nil {
+		return nil, err
+	}
+
+	message := req.Message
+	if message == "" {
+		message = "Release " + req.Name
+	}
+	if req.BeadID != "" && ParseCommitMetadata(message).BeadID == "" {
+		message += fmt.Sprintf("\n\nBead: %s", req.BeadID)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "tag", "-a", req.Name, "-m", message, ref)
+	cmd.Dir = s.projectPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		s.auditLogger.LogOperation("tag", req.BeadID, req.Name, false, err)
+		return nil, fmt.Errorf("git tag failed: %w\nOutput: %s", err, output)
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "rev-list", "-n", "1", req.Name)
+	cmd.Dir = s.projectPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve tag %s: %w", req.Name, err)
+	}
+	s.auditLogger.LogOperation("tag", req.BeadID, req.Name, true, nil)
+	return &TagResult{Name: req.Name, CommitSHA: strings.TrimSpace(string(output))}, nil
+}
+
+// LatestTag returns the most recent tag reachable from ref, or "" when
+// there is none
+func (s *GitService) LatestTag(ctx context.Context, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	cmd := exec.CommandContext(ctx, "git", "describe", "--tags", "--abbrev=0", ref)
+	cmd.Dir = s.projectPath
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && strings.Contains(string(exitErr.Stderr), "No names found") {
+			return "", nil
+		}
+		return "", fmt.Errorf("git describe failed: %w%s", err, stderrOf(err))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ChangelogRequest selects the commits a changelog covers
+type ChangelogRequest struct {
+	From    string // Exclusive start (default: the latest tag, or the whole history)
+	To      string // Inclusive end (default HEAD)
+	Version string // Heading of the release (default "Unreleased")
+}
+
+// ChangelogEntry is one commit in a changelog
+type ChangelogEntry struct {
+	SHA      string `json:"sha"`
+	Type     string `json:"type,omitempty"` // Conventional commit type, when there is one
+	Scope    string `json:"scope,omitempty"`
+	Subject  string `json:"subject"`
+	BeadID   string `json:"bead_id,omitempty"`
+	Breaking bool   `json:"breaking,omitempty"`
+}
+
+// Changelog lists the commits of a release
+type Changelog struct {
+	From    string           `json:"from,omitempty"`
+	To      string           `json:"to"`
+	Version string           `json:"version"`
+	Date    string           `json:"date"`
+	Entries []ChangelogEntry `json:"entries"`
+	Beads   []string         `json:"beads"` // Beads the release delivers
+}
+
+// changelogSections orders the sections of a changelog by commit type;
+// other types go under "Other Changes"
+var changelogSections = []struct{ title, commitType string }{
+	{"Features", "feat"},
+	{"Bug Fixes", "fix"},
+	{"Performance", "perf"},
+	{"Documentation", "docs"},
+}
+
+// Changelog collects the commits between two revisions, skipping merges
+func (s *GitService) Changelog(ctx context.Context, req ChangelogRequest) (*Changelog, error) {
+	if req.To == "" {
+		req.To = "HEAD"
+	}
+	if err := ValidateRef(req.To); err != nil {
+		return nil, err
+	}
+	if req.From == "" {
+		tag, err := s.LatestTag(ctx, req.To)
+		if err != nil {
+			return nil, err
+		}
+		req.From = tag
+	}
+	revisions := req.To
+	if req.From != "" {
+		if err := ValidateRef(req.From); err != nil {
+			return nil, err
+		}
+		revisions = req.From + ".." + req.To
+	}
+	if req.Version == "" {
+		req.Version = "Unreleased"
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "log", "--no-merges", "--format=%H%x1f%B%x1e", revisions, "--")
+	cmd.Dir = s.projectPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w%s", err, stderrOf(err))
+	}
+
+	log := &Changelog{From: req.From, To: req.To, Version: req.Version, Date: time.Now().UTC().Format("2006-01-02"),
+		Entries: []ChangelogEntry{}, Beads: []string{}}
+	seen := map[string]bool{}
+	for _, record := range strings.Split(string(output), "\x1e") {
+		sha, body, ok := strings.Cut(strings.TrimSpace(record), "\x1f")
+		if !ok {
+			continue
+		}
+		meta := ParseCommitMetadata(body)
+		entry := ChangelogEntry{SHA: sha, Subject: strings.TrimSpace(meta.Subject), BeadID: meta.BeadID,
+			Breaking: strings.Contains(body, "BREAKING CHANGE:")}
+		if m := conventionalSubject.FindStringSubmatch(entry.Subject); m != nil && m[4] != "" {
+			entry.Type = strings.ToLower(m[1])
+			entry.Scope = strings.Trim(m[2], "()")
+			entry.Breaking = entry.Breaking || m[3] == "!"
+			entry.Subject = m[4]
+		}
+		log.Entries = append(log.Entries, entry)
+		if entry.BeadID != "" && !seen[entry.BeadID] {
+			seen[entry.BeadID] = true
+			log.Beads = append(log.Beads, entry.BeadID)
+		}
+	}
+	return log, nil
+}
+
+// Markdown renders the changelog as a release section, grouped by commit
+// type with breaking changes first
+func (c *Changelog) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## %s (%s)\n", c.Version, c.Date)
+	if len(c.Entries) == 0 {
+		sb.WriteString("\nNo changes.\n")
+		return sb.String()
+	}
+
+	section := func(title string, include func(ChangelogEntry) bool) {
+		var lines []string
+		for _, e := range c.Entries {
+			if include(e) {
+				lines = append(lines, e.markdown())
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&sb, "\n### %s\n\n%s\n", title, strings.Join(lines, "\n"))
+		}
+	}
+	section("Breaking Changes", func(e ChangelogEntry) bool { return e.Breaking })
+	known := map[string]bool{}
+	for _, s := range changelogSections {
+		commitType := s.commitType
+		known[commitType] = true
+		section(s.title, func(e ChangelogEntry) bool { return !e.Breaking && e.Type == commitType })
+	}
+	section("Other Changes", func(e ChangelogEntry) bool { return !e.Breaking && !known[e.Type] })
+	return sb.String()
+}
+
+func (e ChangelogEntry) markdown() string {
+	line := "- "
+	if e.Scope != "" {
+		line += "**" + e.Scope + ":** "
+	}
+	line += e.Subject
+	if e.BeadID != "" {
+		line += " (" + e.BeadID + ")"
+	}
+	sha := e.SHA
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	return line + " " + sha
+}
+
+// WriteChangelog adds the changelog's section to the top of a changelog
+// file, below its title, creating the file if needed
+func (s *GitService) WriteChangelog(file string, c *Changelog) error {
+	if err := ValidateRepoPath(file); err != nil || file == "" {
+		return fmt.Errorf("invalid changelog path %q", file)
+	}
+	full := filepath.Join(s.projectPath, path.Clean(file))
+	existing, err := os.ReadFile(full)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	title, rest := "# Changelog\n", string(existing)
+	if strings.HasPrefix(rest, "# ") {
+		first, remainder, _ := strings.Cut(rest, "\n")
+		title, rest = first+"\n", remainder
+	}
+	content := title + "\n" + c.Markdown()
+	if rest = strings.TrimLeft(rest, "\n"); rest != "" {
+		content += "\n" + rest
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(full, []byte(content), 0644)
+}
+
+// versionTag is the tag for a version: v-prefixed like most tags
+func versionTag(version string) string {
+	if strings.HasPrefix(version, "v") {
+		return version
+	}
+	return "v" + version
+}
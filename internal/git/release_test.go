@@ -0,0 +1,148 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNextVersion(t *testing.T) {
+	cases := []struct{ current, bump, want string }{
+		{"1.2.3", BumpPatch, "1.2.4"},
+		{"v1.2.3", BumpMinor, "v1.3.0"},
+		{"1.2.3+build.5", BumpMajor, "2.0.0"},
+		{"2.0.0-rc.1", BumpMajor, "2.0.0"},
+		{"1.3.0-beta", BumpMinor, "1.3.0"},
+		{"1.3.0-beta", BumpMajor, "2.0.0"},
+		{"1.2.4-rc.2", BumpPatch, "1.2.4"},
+	}
+	for _, c := range cases {
+		if got, err := NextVersion(c.current, c.bump); err != nil || got != c.want {
+			t.Errorf("NextVersion(%q, %s) = %q, %v; want %q", c.current, c.bump, got, err, c.want)
+		}
+	}
+	if _, err := NextVersion("1.2", BumpPatch); err == nil {
+		t.Error("expected a non-semver version to be rejected")
+	}
+	if _, err := NextVersion("1.2.3", "huge"); err == nil {
+		t.Error("expected an unknown bump to be rejected")
+	}
+}
+
+func TestBumpVersion(t *testing.T) {
+	dir, cleanup := setupTestGitRepo(t)
+	defer cleanup()
+	svc := createTestGitService(t, dir)
+
+	if _, err := svc.BumpVersion(context.Background(), BumpVersionRequest{Bump: BumpPatch}); err == nil {
+		t.Error("expected a project without version files to fail")
+	}
+
+	pkg := "{\n  \"name\": \"app\",\n  \"version\": \"1.4.2\",\n  \"dependencies\": {\"x\": {\"version\": \"9.9.9\"}}\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "VERSION"), []byte("v1.4.2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(pkg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := svc.BumpVersion(context.Background(), BumpVersionRequest{Bump: BumpMinor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Previous != "v1.4.2" || result.Version != "v1.5.0" || result.Tag != "v1.5.0" || len(result.Files) != 2 {
+		t.Errorf("unexpected bump %+v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "VERSION")); string(data) != "v1.5.0\n" {
+		t.Errorf("VERSION = %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "package.json")); string(data) != strings.Replace(pkg, "1.4.2", "1.5.0", 1) {
+		t.Errorf("package.json = %q", data)
+	}
+
+	result, err = svc.BumpVersion(context.Background(), BumpVersionRequest{Version: "2.0.0-rc.1", Files: []string{"package.json"}})
+	if err != nil || result.Tag != "v2.0.0-rc.1" || len(result.Files) != 1 {
+		t.Errorf("explicit version bump = %+v, %v", result, err)
+	}
+	for _, bad := range []BumpVersionRequest{{}, {Bump: BumpMajor, Version: "3.0.0"}, {Version: "three"}, {Bump: BumpMajor, Files: []string{"../VERSION"}}, {Bump: BumpMajor, Files: []string{"setup.py"}}} {
+		if _, err := svc.BumpVersion(context.Background(), bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestReleaseWorkflow(t *testing.T) {
+	dir, cleanup := setupTestGitRepo(t)
+	defer cleanup()
+	svc := createTestGitService(t, dir)
+	ctx := context.Background()
+
+	if tag, err := svc.LatestTag(ctx, ""); err != nil || tag != "" {
+		t.Fatalf("LatestTag() without tags = %q, %v", tag, err)
+	}
+	if _, err := svc.CreateTag(ctx, TagRequest{Name: "v1.0.0", BeadID: "loom-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CreateTag(ctx, TagRequest{Name: "v1.0.0"}); err == nil {
+		t.Error("expected an existing tag to be refused")
+	}
+	for _, bad := range []string{"", "-d", "v1..0", "v1.0.0.lock"} {
+		if _, err := svc.CreateTag(ctx, TagRequest{Name: bad}); err == nil {
+			t.Errorf("expected tag name %q to be rejected", bad)
+		}
+	}
+
+	commits := []string{
+		"feat(api): add bulk export\n\nBead: loom-2",
+		"fix: handle empty input\n\nBead: loom-3",
+		"refactor!: drop the v1 client\n\nBead: loom-2",
+		"Tidy the README",
+	}
+	for i, message := range commits {
+		if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte(strings.Repeat("x\n", i+2)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := execGit(dir, "commit", "-am", message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log, err := svc.Changelog(ctx, ChangelogRequest{Version: "v1.1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.From != "v1.0.0" || len(log.Entries) != 4 || strings.Join(log.Beads, ",") != "loom-2,loom-3" {
+		t.Fatalf("unexpected changelog %+v", log)
+	}
+	md := log.Markdown()
+	for _, want := range []string{"## v1.1.0 (", "### Breaking Changes\n\n- drop the v1 client (loom-2)", "### Features\n\n- **api:** add bulk export (loom-2)", "### Bug Fixes\n\n- handle empty input (loom-3)", "### Other Changes\n\n- Tidy the README "} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() is missing %q:\n%s", want, md)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "CHANGELOG.md"), []byte("# Release Notes\n\n## v1.0.0 (2026-01-01)\n\n- First\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.WriteChangelog("CHANGELOG.md", log); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	if !strings.HasPrefix(string(data), "# Release Notes\n\n## v1.1.0") || !strings.HasSuffix(string(data), "\n## v1.0.0 (2026-01-01)\n\n- First\n") {
+		t.Errorf("unexpected CHANGELOG.md:\n%s", data)
+	}
+
+	tag, err := svc.CreateTag(ctx, TagRequest{Name: "v1.1.0", Message: "Release v1.1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, _ := svc.getLastCommitSHA(ctx)
+	if tag.CommitSHA != head {
+		t.Errorf("tag points at %s, want HEAD %s", tag.CommitSHA, head)
+	}
+	if latest, _ := svc.LatestTag(ctx, ""); latest != "v1.1.0" {
+		t.Errorf("LatestTag() = %q", latest)
+	}
+}
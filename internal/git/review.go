@@ -0,0 +1,104 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// CommitDiff returns the patch introduced by a single commit
+func (s *GitService) CommitDiff(ctx context.Context, sha string) (string, error) {
+	if sha == "" || strings.HasPrefix(sha, "-") {
+		return "", fmt.Errorf("invalid commit SHA: %q", sha)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "show", "--format=commit %H%n%s%n", "--patch", sha)
+	cmd.Dir = s.projectPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git show failed: %w\nOutput: %s", err, output)
+	}
+
+	s.auditLogger.LogOperation("commit_diff", "", sha, true, nil)
+	return string(output), nil
+}
+
+// FindBeadPR returns the number of the open pull request whose head branch
+// belongs to the bead, or 0 when there is none
+func (s *GitService) FindBeadPR(ctx context.Context, beadID string) (int, error) {
+	if !isGhCLIAvailable() {
+		return 0, fmt.Errorf("gh CLI not found (install from https://cli.github.com)")
+	}
+
+	cmd := exec.CommandContext(ctx, "gh", "pr", "list", "--state", "open", "--json", "number,headRefName")
+	cmd.Dir = s.projectPath
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("gh pr list failed: %w", err)
+	}
+
+	var prs []prHead
+	if err := json.Unmarshal(output, &prs); err != nil {
+		return 0, fmt.Errorf("failed to parse gh pr list output: %w", err)
+	}
+	return matchBeadPR(prs, s.branchPrefix, beadID), nil
+}
+
+// prHead is the subset of gh pr list output used to locate a bead's PR
+type prHead struct {
+	Number      int    `json:"number"`
+	HeadRefName string `json:"headRefName"`
+}
+
+// matchBeadPR picks the PR whose head branch was generated for the bead
+// ({prefix}{bead-id}/{description})
+func matchBeadPR(prs []prHead, prefix, beadID string) int {
+	want := prefix + beadID + "/"
+	for _, pr := range prs {
+		if strings.HasPrefix(pr.HeadRefName, want) {
+			return pr.Number
+		}
+	}
+	return 0
+}
+
+// PRCommentRequest defines parameters for commenting on a pull request
+type PRCommentRequest struct {
+	BeadID string // Bead ID for audit trail
+	Number int    // PR number
+	Body   string // Comment body (markdown)
+}
+
+// CommentPR posts a general comment on a pull request using gh CLI
+func (s *GitService) CommentPR(ctx context.Context, req PRCommentRequest) error {
+	startTime := time.Now()
+	var resultErr error
+	defer func() {
+		s.auditLogger.LogOperationWithDuration("comment_pr", req.BeadID, fmt.Sprintf("#%d", req.Number), resultErr == nil, resultErr, time.Since(startTime))
+	}()
+
+	if req.Number <= 0 {
+		resultErr = fmt.Errorf("PR number is required")
+		return resultErr
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		resultErr = fmt.Errorf("comment body is required")
+		return resultErr
+	}
+	if !isGhCLIAvailable() {
+		resultErr = fmt.Errorf("gh CLI not found (install from https://cli.github.com)")
+		return resultErr
+	}
+
+	cmd := exec.CommandContext(ctx, "gh", "pr", "comment", fmt.Sprintf("%d", req.Number), "--body", req.Body)
+	cmd.Dir = s.projectPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		resultErr = fmt.Errorf("gh pr comment failed: %w\nOutput: %s", err, string(output))
+		return resultErr
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitServiceCommitDiff(t *testing.T) {
+	dir, cleanup := setupTestGitRepo(t)
+	defer cleanup()
+
+	svc := createTestGitService(t, dir)
+	ctx := context.Background()
+
+	if err := os.WriteFile(filepath.Join(dir, "feature.txt"), []byte("hello review\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := execGit(dir, "add", "feature.txt"); err != nil {
+		t.Fatalf("failed to stage: %v", err)
+	}
+	if err := execGit(dir, "commit", "-m", "Add feature\n\nBead: bd-1"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	sha, err := svc.getLastCommitSHA(ctx)
+	if err != nil {
+		t.Fatalf("getLastCommitSHA: %v", err)
+	}
+
+	diff, err := svc.CommitDiff(ctx, sha)
+	if err != nil {
+		t.Fatalf("CommitDiff: %v", err)
+	}
+	if !strings.Contains(diff, "commit "+sha) || !strings.Contains(diff, "+hello review") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+
+	if _, err := svc.CommitDiff(ctx, "--output=/tmp/x"); err == nil {
+		t.Error("expected option-like SHA to be rejected")
+	}
+}
+
+func TestMatchBeadPR(t *testing.T) {
+	prs := []prHead{
+		{Number: 7, HeadRefName: "agent/bd-10/other"},
+		{Number: 8, HeadRefName: "feature/bd-1"},
+		{Number: 9, HeadRefName: "agent/bd-1/add-feature"},
+	}
+	if got := matchBeadPR(prs, "agent/", "bd-1"); got != 9 {
+		t.Errorf("matchBeadPR = %d, want 9", got)
+	}
+	if got := matchBeadPR(prs, "agent/", "bd-2"); got != 0 {
+		t.Errorf("matchBeadPR = %d, want 0", got)
+	}
+}
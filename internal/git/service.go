@@ -10,6 +10,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/jordanhubbard/loom/internal/secretscan"
 )
 
 // GitService provides safe git operations for agents
@@ -19,6 +21,9 @@ type GitService struct {
 	projectKeyDir string // Base directory for per-project SSH keys
 	branchPrefix  string // Configurable branch prefix (default: "agent/")
 	auditLogger   *AuditLogger
+	secrets       *secretscan.Scanner // Secrets gate; nil uses the built-in rules
+	secretsOff    bool
+	commitPolicy  *CommitPolicy // Commit message convention; nil uses DefaultCommitPolicy
 }
 
 // NewGitService creates a new git service instance.
@@ -56,6 +61,29 @@ func (s *GitService) SetBranchPrefix(prefix string) {
 	}
 }
 
+// SetSecretScanner replaces the scanner run before commits and pushes; nil
+// turns the secrets gate off
+func (s *GitService) SetSecretScanner(scanner *secretscan.Scanner) {
+	s.secrets = scanner
+	s.secretsOff = scanner == nil
+}
+
+// SetCommitPolicy sets the convention commit messages are checked against
+func (s *GitService) SetCommitPolicy(policy *CommitPolicy) {
+	s.commitPolicy = policy
+}
+
+// secretScanner returns the scanner for the secrets gate, nil when it is off
+func (s *GitService) secretScanner() *secretscan.Scanner {
+	if s.secretsOff {
+		return nil
+	}
+	if s.secrets == nil {
+		return secretscan.Default()
+	}
+	return s.secrets
+}
+
 // CreateBranchRequest defines parameters for branch creation
 type CreateBranchRequest struct {
 	BeadID      string // Bead ID for branch naming
@@ -131,11 +159,13 @@ type CommitRequest struct {
 
 // CommitResult contains commit creation results
 type CommitResult struct {
-	CommitSHA    string   `json:"commit_sha"`    // Commit hash
-	FilesChanged int      `json:"files_changed"` // Number of files changed
-	Insertions   int      `json:"insertions"`    // Lines added
-	Deletions    int      `json:"deletions"`     // Lines removed
-	Files        []string `json:"files"`         // List of changed files
+	CommitSHA    string   `json:"commit_sha"`             // Commit hash
+	FilesChanged int      `json:"files_changed"`          // Number of files changed
+	Insertions   int      `json:"insertions"`             // Lines added
+	Deletions    int      `json:"deletions"`              // Lines removed
+	Files        []string `json:"files"`                  // List of changed files
+	Message      string   `json:"message"`                // Commit message as committed
+	PolicyFixes  []string `json:"policy_fixes,omitempty"` // Fixes the commit policy made to the message
 }
 
 // Commit creates a new commit with proper attribution
@@ -143,8 +173,18 @@ func (s *GitService) Commit(ctx context.Context, req CommitRequest) (*CommitResu
 	startTime := time.Now()
 
 	// Auto-inject bead and agent metadata into commit message.
-	// Agents provide the summary; we append the trailers.
-	req.Message = ensureCommitMetadata(req.Message, req.BeadID, req.AgentID)
+	// Agents provide the summary; we append the trailers. The commit
+	// policy fixes what it can and rejects the rest.
+	policy := s.commitPolicy
+	if policy == nil {
+		policy = DefaultCommitPolicy()
+	}
+	message, fixes, err := ensureCommitMetadata(policy, req.Message, req.BeadID, req.AgentID)
+	if err != nil {
+		s.auditLogger.LogOperation("commit", req.BeadID, "", false, err)
+		return nil, err
+	}
+	req.Message = message
 
 	// Stage files
 	if err := s.stageFiles(ctx, req.Files, req.AllowAll); err != nil {
@@ -152,7 +192,8 @@ func (s *GitService) Commit(ctx context.Context, req CommitRequest) (*CommitResu
 		return nil, fmt.Errorf("failed to stage files: %w", err)
 	}
 
-	// Check for secrets
+	// Check for secrets; the wrapped *secretscan.BlockedError carries the
+	// findings
 	if err := s.checkForSecrets(ctx); err != nil {
 		s.auditLogger.LogOperation("commit", req.BeadID, "", false, err)
 		return nil, fmt.Errorf("secret detected: %w", err)
@@ -179,6 +220,8 @@ func (s *GitService) Commit(ctx context.Context, req CommitRequest) (*CommitResu
 		return nil, fmt.Errorf("failed to get commit stats: %w", err)
 	}
 
+	stats.Message, stats.PolicyFixes = req.Message, fixes
+
 	s.auditLogger.LogOperationWithDuration("commit", req.BeadID, commitSHA, true, nil, time.Since(startTime))
 
 	return stats, nil
@@ -227,14 +270,20 @@ func (s *GitService) Push(ctx context.Context, req PushRequest) (*PushResult, er
 		return nil, fmt.Errorf("force push is not allowed")
 	}
 
+	// Secrets gate: refuse to publish commits that add credentials
+	if err := s.checkUnpushedForSecrets(ctx, branch); err != nil {
+		s.auditLogger.LogOperation("push", req.BeadID, branch, false, err)
+		return nil, fmt.Errorf("secret detected: %w", err)
+	}
+
 	// Configure SSH
 	if err := s.configureSSH(); err != nil {
 		s.auditLogger.LogOperation("push", req.BeadID, branch, false, err)
 		return nil, fmt.Errorf("failed to configure SSH: %w", err)
 	}
 
-	// Build git push command
-	args := []string{"push"}
+	// Build git push command; annotated tags on the pushed commits go too
+	args := []string{"push", "--follow-tags"}
 	if req.SetUpstream {
 		args = append(args, "-u")
 	}
@@ -347,33 +396,41 @@ func (s *GitService) stageFiles(ctx context.Context, files []string, allowAll bo
 	return nil
 }
 
-// checkForSecrets scans staged files for potential secrets
+// checkForSecrets scans the lines added by the staged changes for
+// potential secrets
 func (s *GitService) checkForSecrets(ctx context.Context) error {
-	// Get list of staged files
-	cmd := exec.CommandContext(ctx, "git", "diff", "--staged", "--name-only")
+	scanner := s.secretScanner()
+	if scanner == nil {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, "git", "diff", "--staged", "-U0", "--no-color", "--no-ext-diff")
 	cmd.Dir = s.projectPath
-	output, err := cmd.CombinedOutput()
+	output, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("failed to get staged files: %w", err)
+		return fmt.Errorf("failed to get staged changes: %w", err)
 	}
-
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, file := range files {
-		if file == "" {
-			continue
-		}
-
-		filePath := filepath.Join(s.projectPath, file)
-		content, err := os.ReadFile(filePath)
-		if err != nil {
-			continue // Skip files that can't be read
-		}
-
-		if hasSecrets(content) {
-			return fmt.Errorf("potential secret detected in %s", file)
-		}
+	if findings := scanner.ScanDiff(string(output)); len(findings) > 0 {
+		return &secretscan.BlockedError{Operation: "commit", Findings: findings}
 	}
+	return nil
+}
 
+// checkUnpushedForSecrets scans the lines added by commits on branch that
+// no remote has yet
+func (s *GitService) checkUnpushedForSecrets(ctx context.Context, branch string) error {
+	scanner := s.secretScanner()
+	if scanner == nil {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, "git", "log", "-p", "-U0", "--no-color", "--no-ext-diff", "--format=commit %H", branch, "--not", "--remotes")
+	cmd.Dir = s.projectPath
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to list unpushed changes: %w", err)
+	}
+	if findings := scanner.ScanDiff(string(output)); len(findings) > 0 {
+		return &secretscan.BlockedError{Operation: "push", Findings: findings}
+	}
 	return nil
 }
 
@@ -515,20 +572,6 @@ var (
 		"^release/.*",
 		"^hotfix/.*",
 	}
-
-	secretPatterns = []*regexp.Regexp{
-		// Match high-entropy API keys (20+ alphanumeric chars with mixed case/digits)
-		regexp.MustCompile(`(?i)api[_-]?key[_-]?[:=]\s*['"][a-zA-Z0-9]{20,}['"]`),
-		regexp.MustCompile(`(?i)secret[_-]?key[_-]?[:=]\s*['"][a-zA-Z0-9]{20,}['"]`),
-		regexp.MustCompile(`(?i)token[_-]?[:=]\s*['"][a-zA-Z0-9]{20,}['"]`),
-		// AWS access key IDs have a known format
-		regexp.MustCompile(`(?i)aws[_-]?access[_-]?key[_-]?id\s*[:=]\s*['"]AKIA[0-9A-Z]{16}['"]`),
-		// Private key blocks
-		regexp.MustCompile(`-----BEGIN (RSA|DSA|EC|OPENSSH) PRIVATE KEY-----`),
-		// Note: password patterns removed — too many false positives on default/placeholder
-		// passwords in source code (e.g., "loom-default-password"). Real password leaks are
-		// better caught by the API key and token patterns above.
-	}
 )
 
 // validateBranchNameWithPrefix validates branch name with a configurable prefix
@@ -549,33 +592,18 @@ func validateBranchNameWithPrefix(branchName, prefix string) error {
 	return nil
 }
 
-// ensureCommitMetadata auto-appends bead/agent trailers if not present,
-// and truncates the summary line if too long. Agents just provide the
-// human-readable summary; we handle the metadata.
-// ensureCommitMetadata auto-appends bead/agent trailers if not present,
-// and truncates the summary line if too long. Agents just provide the
-// human-readable summary; we handle the metadata.
-func ensureCommitMetadata(message, beadID, agentID string) string {
-	if message == "" {
-		message = "Update from agent"
-	}
-
-	// Truncate first line to 72 chars if needed
-	lines := strings.SplitN(message, "\n", 2)
-	if len(lines[0]) > 72 {
-		lines[0] = lines[0][:69] + "..."
-	}
-	message = strings.Join(lines, "\n")
-
-	// Append trailers if not already present
-	if beadID != "" && !strings.Contains(message, beadID) {
-		message += fmt.Sprintf("\n\nBead: %s", beadID)
+// ensureCommitMetadata applies the commit policy and auto-appends bead/agent
+// trailers if not present. Agents just provide the human-readable summary;
+// we handle the metadata.
+func ensureCommitMetadata(policy *CommitPolicy, message, beadID, agentID string) (string, []string, error) {
+	message, fixes, err := policy.Apply(message, beadID)
+	if err != nil {
+		return "", nil, err
 	}
 	if agentID != "" && !strings.Contains(message, "Agent:") {
 		message += fmt.Sprintf("\nAgent: %s", agentID)
 	}
-
-	return message
+	return message, fixes, nil
 }
 
 // isProtectedBranch checks if a branch is protected
@@ -591,12 +619,7 @@ func isProtectedBranch(branchName string) bool {
 
 // hasSecrets checks if content contains potential secrets
 func hasSecrets(content []byte) bool {
-	for _, pattern := range secretPatterns {
-		if pattern.Match(content) {
-			return true
-		}
-	}
-	return false
+	return len(secretscan.Default().ScanContent("", string(content))) > 0
 }
 
 // slugify converts a string to a URL-safe slug
@@ -325,7 +325,10 @@ func TestEnsureCommitMetadata(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := ensureCommitMetadata(tt.message, tt.beadID, tt.agentID)
+			result, _, err := ensureCommitMetadata(DefaultCommitPolicy(), tt.message, tt.beadID, tt.agentID)
+			if err != nil {
+				t.Fatal(err)
+			}
 			tt.checkFn(t, result)
 		})
 	}
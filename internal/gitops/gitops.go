@@ -2,8 +2,8 @@ package gitops
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -11,11 +11,15 @@ import (
 	"time"
 
 	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/git"
 	"github.com/jordanhubbard/loom/internal/keymanager"
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/observability"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
+var gitopsLog = logging.Module("gitops")
+
 // Manager handles git operations for managed projects
 type Manager struct {
 	baseWorkDir   string                    // Base directory for all project clones (e.g., /app/src)
@@ -564,6 +568,41 @@ func (m *Manager) Diff(ctx context.Context, projectID string) (string, error) {
 	return strings.TrimSpace(output), nil
 }
 
+// DiffRevisions returns the structured diff of a project between two
+// revisions, or a revision and the working tree.
+func (m *Manager) DiffRevisions(ctx context.Context, projectID string, req git.RevisionDiffRequest) (*git.RevisionDiff, error) {
+	workDir := m.GetProjectWorkDir(projectID)
+	if _, err := os.Stat(filepath.Join(workDir, ".git")); os.IsNotExist(err) {
+		return nil, fmt.Errorf("project %s not cloned", projectID)
+	}
+	args, err := git.RevisionDiffArgs(&req)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = workDir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		logGitError("git.diff_revisions.error", &models.Project{ID: projectID}, map[string]interface{}{
+			"work_dir":    workDir,
+			"from":        req.From,
+			"to":          req.To,
+			"duration_ms": time.Since(start).Milliseconds(),
+		}, err)
+		return nil, fmt.Errorf("git diff failed: %w\nOutput: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	logGitEvent("git.diff_revisions", &models.Project{ID: projectID}, map[string]interface{}{
+		"work_dir":    workDir,
+		"from":        req.From,
+		"to":          req.To,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+	return git.NewRevisionDiff(req, string(output)), nil
+}
+
 // GetCurrentCommit returns the current commit SHA
 func (m *Manager) GetCurrentCommit(workDir string) (string, error) {
 	cmd := exec.Command("git", "rev-parse", "HEAD")
@@ -666,8 +705,27 @@ func (m *Manager) configureAuth(cmd *exec.Cmd, project *models.Project) error {
 		return nil
 
 	case models.GitAuthToken:
-		// For HTTPS with token, we could inject into URL or use credential helper
-		// This is a simplified approach - production would use credential helper
+		// Without a stored credential, fall back to whatever credential helper
+		// the host has configured.
+		if project.GitCredentialID == "" {
+			return nil
+		}
+		token, err := m.credential(project.GitCredentialID)
+		if err != nil {
+			return err
+		}
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		// Pass the token as an HTTP header via environment-only git config so
+		// it never lands in the remote URL, .git/config or the process list.
+		header := "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte("x-access-token:"+token))
+		cmd.Env = append(cmd.Env,
+			"GIT_TERMINAL_PROMPT=0",
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0="+header,
+		)
 		return nil
 
 	case models.GitAuthBasic:
@@ -816,6 +874,58 @@ func (m *Manager) EnsureProjectSSHKey(projectID string) (string, error) {
 	return publicKey, nil
 }
 
+// ImportProjectSSHKey installs a private key held by the key manager as the
+// project's deploy key, replacing any generated one, and returns its public key.
+func (m *Manager) ImportProjectSSHKey(projectID, credentialID string) (string, error) {
+	if err := validateProjectID(projectID); err != nil {
+		return "", fmt.Errorf("invalid project ID: %w", err)
+	}
+	privateKey, err := m.credential(credentialID)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(privateKey, "\n") {
+		privateKey += "\n"
+	}
+
+	keyDir := m.projectKeyDirForProject(projectID)
+	if err := os.MkdirAll(keyDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create project ssh directory: %w", err)
+	}
+	privatePath := m.projectPrivateKeyPath(projectID)
+	publicPath := m.projectPublicKeyPath(projectID)
+	if err := os.WriteFile(privatePath, []byte(privateKey), 0600); err != nil {
+		return "", fmt.Errorf("failed to write ssh key: %w", err)
+	}
+	if err := m.writePublicKeyFromPrivate(privatePath, publicPath); err != nil {
+		_ = os.Remove(privatePath)
+		return "", err
+	}
+	keyBytes, err := os.ReadFile(publicPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read public key: %w", err)
+	}
+	logGitEvent("git.ssh_key.imported", &models.Project{ID: projectID}, map[string]interface{}{
+		"credential_id": credentialID,
+	})
+	return strings.TrimSpace(string(keyBytes)), nil
+}
+
+// credential reads a secret (token or private key) from the key manager
+func (m *Manager) credential(credentialID string) (string, error) {
+	if m.keyManager == nil {
+		return "", fmt.Errorf("credential %s requires the key manager, which is not configured", credentialID)
+	}
+	if !m.keyManager.IsUnlocked() {
+		return "", fmt.Errorf("credential %s unavailable: key manager is locked", credentialID)
+	}
+	secret, err := m.keyManager.GetKey(credentialID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credential %s: %w", credentialID, err)
+	}
+	return secret, nil
+}
+
 // GetProjectPublicKey returns the project's public SSH key, creating it if needed.
 func (m *Manager) GetProjectPublicKey(projectID string) (string, error) {
 	return m.EnsureProjectSSHKey(projectID)
@@ -898,14 +1008,14 @@ func (m *Manager) restoreKeyFromDB(projectID string) bool {
 	// Decrypt private key from KeyManager
 	privateKeyData, err := m.keyManager.GetKey(cred.KeyID)
 	if err != nil {
-		log.Printf("[gitops] Failed to decrypt SSH key from DB for project %s: %v", projectID, err)
+		gitopsLog.Error("Failed to decrypt SSH key from DB", "project_id", projectID, "error", err)
 		return false
 	}
 
 	// Write to filesystem
 	keyDir := m.projectKeyDirForProject(projectID)
 	if err := os.MkdirAll(keyDir, 0700); err != nil {
-		log.Printf("[gitops] Failed to create SSH key directory for project %s: %v", projectID, err)
+		gitopsLog.Error("Failed to create SSH key directory", "project_id", projectID, "error", err)
 		return false
 	}
 
@@ -913,11 +1023,11 @@ func (m *Manager) restoreKeyFromDB(projectID string) bool {
 	publicPath := m.projectPublicKeyPath(projectID)
 
 	if err := os.WriteFile(privatePath, []byte(privateKeyData), 0600); err != nil {
-		log.Printf("[gitops] Failed to write private key for project %s: %v", projectID, err)
+		gitopsLog.Error("Failed to write private key", "project_id", projectID, "error", err)
 		return false
 	}
 	if err := os.WriteFile(publicPath, []byte(cred.PublicKey), 0644); err != nil {
-		log.Printf("[gitops] Failed to write public key for project %s: %v", projectID, err)
+		gitopsLog.Error("Failed to write public key", "project_id", projectID, "error", err)
 		return false
 	}
 
@@ -935,21 +1045,21 @@ func (m *Manager) storeKeyInDBWithRotation(projectID, publicKey string, rotatedA
 		return
 	}
 	if !m.keyManager.IsUnlocked() {
-		log.Printf("[gitops] Cannot store SSH key in DB: key manager is locked")
+		gitopsLog.Warn("Cannot store SSH key in DB: key manager is locked", "project_id", projectID)
 		return
 	}
 
 	privatePath := m.projectPrivateKeyPath(projectID)
 	privateKeyBytes, err := os.ReadFile(privatePath)
 	if err != nil {
-		log.Printf("[gitops] Failed to read private key for DB storage (project %s): %v", projectID, err)
+		gitopsLog.Error("Failed to read private key for DB storage", "project_id", projectID, "error", err)
 		return
 	}
 
 	// Store encrypted private key via KeyManager
 	keyID := fmt.Sprintf("ssh-%s", projectID)
 	if err := m.keyManager.StoreKey(keyID, fmt.Sprintf("SSH key for %s", projectID), "Auto-generated project deploy key", string(privateKeyBytes)); err != nil {
-		log.Printf("[gitops] Failed to encrypt SSH key for project %s: %v", projectID, err)
+		gitopsLog.Error("Failed to encrypt SSH key", "project_id", projectID, "error", err)
 		return
 	}
 
@@ -969,7 +1079,7 @@ func (m *Manager) storeKeyInDBWithRotation(projectID, publicKey string, rotatedA
 	}
 
 	if err := m.db.UpsertCredential(cred); err != nil {
-		log.Printf("[gitops] Failed to store credential in DB for project %s: %v", projectID, err)
+		gitopsLog.Error("Failed to store credential in DB", "project_id", projectID, "error", err)
 		return
 	}
 
@@ -1000,12 +1110,12 @@ func (m *Manager) BackfillSSHCredentials(projects []*models.Project) {
 		// Read public key
 		publicKey, err := m.GetProjectPublicKey(p.ID)
 		if err != nil {
-			log.Printf("[gitops] Backfill: failed to read public key for project %s: %v", p.ID, err)
+			gitopsLog.Warn("Backfill: failed to read public key", "project_id", p.ID, "error", err)
 			continue
 		}
 
 		m.storeKeyInDB(p.ID, publicKey)
-		log.Printf("[gitops] Backfill: stored SSH key for project %s in database", p.ID)
+		gitopsLog.Info("Backfill: stored SSH key in database", "project_id", p.ID)
 	}
 }
 
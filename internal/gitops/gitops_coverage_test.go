@@ -2,6 +2,7 @@ package gitops
 
 import (
 	"context"
+	"encoding/base64"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/keymanager"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
@@ -487,6 +489,53 @@ func TestConfigureAuth_AuthToken(t *testing.T) {
 	}
 }
 
+// TestConfigureAuth_AuthTokenCredential injects a key manager token as an HTTP header.
+func TestConfigureAuth_AuthTokenCredential(t *testing.T) {
+	tmpDir := t.TempDir()
+	km := keymanager.NewKeyManager(filepath.Join(tmpDir, "keystore.json"))
+	mgr, err := NewManager(tmpDir, filepath.Join(tmpDir, "keys"), nil, km)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	project := &models.Project{
+		ID:              "test-project",
+		GitRepo:         "https://github.com/example/repo.git",
+		GitAuthMethod:   models.GitAuthToken,
+		GitCredentialID: "gh-token",
+	}
+
+	if err := mgr.configureAuth(createDummyCmd(), project); err == nil {
+		t.Error("expected error while the key manager is locked")
+	}
+
+	if err := km.Unlock("test-password"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := km.StoreKey("gh-token", "GitHub token", "", "s3cret"); err != nil {
+		t.Fatalf("StoreKey failed: %v", err)
+	}
+	cmd := createDummyCmd()
+	if err := mgr.configureAuth(cmd, project); err != nil {
+		t.Fatalf("configureAuth failed: %v", err)
+	}
+	want := "GIT_CONFIG_VALUE_0=Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte("x-access-token:s3cret"))
+	found := false
+	for _, env := range cmd.Env {
+		if env == want {
+			found = true
+		}
+		if strings.Contains(env, "s3cret") {
+			t.Errorf("token leaked in plain text: %s", env)
+		}
+	}
+	if !found {
+		t.Errorf("auth header not set in %v", cmd.Env)
+	}
+	if strings.Contains(strings.Join(cmd.Args, " "), "s3cret") {
+		t.Error("token leaked into command arguments")
+	}
+}
+
 // TestConfigureAuth_AuthBasic covers the "basic" auth method.
 func TestConfigureAuth_AuthBasic(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -0,0 +1,317 @@
+// Package goedit makes common structural edits to Go source files: adding
+// an import, inserting a function after a declaration and adding a struct
+// field. Edits are located with go/ast, spliced into the original text so
+// comments survive, and the result is gofmt'd; an edit that wouldn't leave
+// the file syntactically valid is refused.
+package goedit
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// source is a parsed file along with the text it came from
+type source struct {
+	src  []byte
+	fset *token.FileSet
+	file *ast.File
+}
+
+func parse(src []byte) (*source, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("file does not parse, fix it first: %w", err)
+	}
+	return &source{src: src, fset: fset, file: f}, nil
+}
+
+func (s *source) offset(p token.Pos) int {
+	return s.fset.File(p).Offset(p)
+}
+
+// splice inserts text at offset and formats the result
+func (s *source) splice(at int, text string) ([]byte, error) {
+	return s.replace(at, at, text)
+}
+
+// replace swaps src[from:to] for text and formats the result
+func (s *source) replace(from, to int, text string) ([]byte, error) {
+	out := make([]byte, 0, len(s.src)+len(text))
+	out = append(out, s.src[:from]...)
+	out = append(out, text...)
+	out = append(out, s.src[to:]...)
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("edit would not produce valid Go: %w", err)
+	}
+	return formatted, nil
+}
+
+// AddImport imports importPath, under name when it isn't empty. A file that
+// already has the import is returned unchanged.
+func AddImport(src []byte, importPath, name string) ([]byte, error) {
+	if importPath == "" {
+		return nil, fmt.Errorf("import path is required")
+	}
+	s, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	for _, imp := range s.file.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p != importPath {
+			continue
+		}
+		existing := ""
+		if imp.Name != nil {
+			existing = imp.Name.Name
+		}
+		if existing == name {
+			return src, nil
+		}
+		return nil, fmt.Errorf("%s is already imported as %q", importPath, existing)
+	}
+
+	spec := strconv.Quote(importPath)
+	if name != "" {
+		spec = name + " " + spec
+	}
+	for _, decl := range s.file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		if gd.Lparen.IsValid() {
+			return s.splice(s.offset(gd.Rparen), "\t"+spec+"\n")
+		}
+		// A single unparenthesized import becomes a block
+		start, end := s.offset(gd.Pos()), s.offset(gd.End())
+		existing := string(s.src[s.offset(gd.Specs[0].Pos()):end])
+		return s.replace(start, end, "import (\n\t"+existing+"\n\t"+spec+"\n)")
+	}
+	return s.splice(s.offset(s.file.Name.End()), "\n\nimport "+spec+"\n")
+}
+
+// InsertFunctionAfter inserts function declarations after the declaration
+// named anchor: a function, a method as Type.Method, or a type. An empty
+// anchor appends to the end of the file.
+func InsertFunctionAfter(src []byte, anchor, decls string) ([]byte, error) {
+	s, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	funcs, err := parseFuncs(decls)
+	if err != nil {
+		return nil, err
+	}
+	declared := make(map[string]bool)
+	for _, decl := range s.file.Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok {
+			declared[funcKey(fd)] = true
+		}
+	}
+	for _, fd := range funcs {
+		if key := funcKey(fd); declared[key] && fd.Name.Name != "init" {
+			return nil, fmt.Errorf("%s is already declared", key)
+		}
+	}
+
+	text := strings.TrimSpace(decls)
+	if anchor == "" {
+		return s.splice(len(s.src), "\n"+text+"\n")
+	}
+	decl, err := s.findDecl(anchor)
+	if err != nil {
+		return nil, err
+	}
+	// After the rest of the anchor's last line, so a trailing comment stays
+	// with it
+	at := s.offset(decl.End())
+	if nl := strings.IndexByte(string(s.src[at:]), '\n'); nl >= 0 {
+		at += nl
+	} else {
+		at = len(s.src)
+	}
+	return s.splice(at, "\n\n"+text+"\n")
+}
+
+// parseFuncs parses source holding only function declarations
+func parseFuncs(decls string) ([]*ast.FuncDecl, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package p\n\n"+decls, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("function does not parse: %w", err)
+	}
+	if len(f.Decls) == 0 {
+		return nil, fmt.Errorf("no function declaration given")
+	}
+	var funcs []*ast.FuncDecl
+	for _, decl := range f.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			return nil, fmt.Errorf("only function declarations can be inserted; use add_import for imports")
+		}
+		funcs = append(funcs, fd)
+	}
+	return funcs, nil
+}
+
+// funcKey names a function, or a method as Type.Method
+func funcKey(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+	return receiverType(fd.Recv.List[0].Type) + "." + fd.Name.Name
+}
+
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// findDecl returns the function, method or type declaration named name. A
+// bare method name matches when only one type has the method.
+func (s *source) findDecl(name string) (ast.Decl, error) {
+	var methods []ast.Decl
+	for _, decl := range s.file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if funcKey(d) == name {
+				return d, nil
+			}
+			if d.Recv != nil && d.Name.Name == name {
+				methods = append(methods, d)
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if ts := spec.(*ast.TypeSpec); ts.Name.Name == name {
+					return d, nil
+				}
+			}
+		}
+	}
+	switch len(methods) {
+	case 1:
+		return methods[0], nil
+	case 0:
+		return nil, fmt.Errorf("no function, method or type named %s", name)
+	}
+	return nil, fmt.Errorf("several types have a method %s; name it as Type.%s", name, name)
+}
+
+// AddStructField appends field declarations, written as they would appear
+// inside the struct, to the struct type named structName
+func AddStructField(src []byte, structName, fields string) ([]byte, error) {
+	s, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	st, err := s.findStruct(structName)
+	if err != nil {
+		return nil, err
+	}
+	added, err := parseFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool)
+	for _, field := range st.Fields.List {
+		for _, name := range fieldNames(field) {
+			existing[name] = true
+		}
+	}
+	for _, field := range added {
+		for _, name := range fieldNames(field) {
+			if existing[name] {
+				return nil, fmt.Errorf("%s already has a field %s", structName, name)
+			}
+		}
+	}
+
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(fields), "\n") {
+		sb.WriteString("\t" + strings.TrimSpace(line) + "\n")
+	}
+	closing := s.offset(st.Fields.Closing)
+	lineStart := strings.LastIndexByte(string(s.src[:closing]), '\n') + 1
+	if strings.TrimSpace(string(s.src[lineStart:closing])) == "" {
+		return s.splice(lineStart, sb.String())
+	}
+	return s.splice(closing, "\n"+sb.String())
+}
+
+func (s *source) findStruct(name string) (*ast.StructType, error) {
+	for _, decl := range s.file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if ts.Name.Name != name {
+				continue
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				return nil, fmt.Errorf("%s is not a struct type", name)
+			}
+			return st, nil
+		}
+	}
+	return nil, fmt.Errorf("no struct type named %s", name)
+}
+
+// parseFields parses field declarations as written inside a struct
+func parseFields(fields string) ([]*ast.Field, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package p\n\ntype _ struct {\n"+fields+"\n}\n", 0)
+	if err != nil {
+		return nil, fmt.Errorf("field does not parse: %w", err)
+	}
+	if len(f.Decls) != 1 {
+		return nil, fmt.Errorf("only field declarations can be added")
+	}
+	st := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	if len(st.Fields.List) == 0 {
+		return nil, fmt.Errorf("no field declaration given")
+	}
+	return st.Fields.List, nil
+}
+
+// fieldNames returns a field's names, or the type name of an embedded field
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) == 0 {
+		if name := receiverType(field.Type); name != "" {
+			return []string{name}
+		}
+		if sel, ok := field.Type.(*ast.SelectorExpr); ok {
+			return []string{sel.Sel.Name}
+		}
+		if star, ok := field.Type.(*ast.StarExpr); ok {
+			if sel, ok := star.X.(*ast.SelectorExpr); ok {
+				return []string{sel.Sel.Name}
+			}
+		}
+		return nil
+	}
+	names := make([]string, 0, len(field.Names))
+	for _, n := range field.Names {
+		names = append(names, n.Name)
+	}
+	return names
+}
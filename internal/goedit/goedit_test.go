@@ -0,0 +1,121 @@
+package goedit
+
+import (
+	"strings"
+	"testing"
+)
+
+const sample = `package sample
+
+import "fmt"
+
+// Server serves things
+type Server struct {
+	Name string // shown in logs
+}
+
+func (s *Server) Start() error { return nil } // starts it
+
+func helper() {
+	fmt.Println("hi")
+}
+`
+
+func TestAddImport(t *testing.T) {
+	out, err := AddImport([]byte(sample), "strings", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "import (\n\t\"fmt\"\n\t\"strings\"\n)") {
+		t.Errorf("expected a sorted import block, got:\n%s", out)
+	}
+
+	again, err := AddImport(out, "os", "stdos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(again), "stdos \"os\"") || !strings.Contains(string(again), "// Server serves things") {
+		t.Errorf("expected the aliased import and comments to survive, got:\n%s", again)
+	}
+
+	if same, err := AddImport([]byte(sample), "fmt", ""); err != nil || string(same) != sample {
+		t.Errorf("expected an existing import to be left alone, got %v", err)
+	}
+	if _, err := AddImport([]byte(sample), "fmt", "f"); err == nil {
+		t.Error("expected a conflicting alias to be rejected")
+	}
+
+	bare, err := AddImport([]byte("package bare\n\nvar x = 1\n"), "errors", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(bare), "package bare\n\nimport \"errors\"\n") {
+		t.Errorf("expected an import after the package clause, got:\n%s", bare)
+	}
+}
+
+func TestInsertFunctionAfter(t *testing.T) {
+	out, err := InsertFunctionAfter([]byte(sample), "Server.Start", "func (s *Server) Stop() error {\nreturn nil\n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+	start := strings.Index(got, "// starts it")
+	stop := strings.Index(got, "func (s *Server) Stop() error {\n\treturn nil\n}")
+	if start < 0 || stop < start || stop > strings.Index(got, "func helper") {
+		t.Errorf("expected Stop right after Start, gofmt'd, got:\n%s", got)
+	}
+
+	if _, err := InsertFunctionAfter([]byte(sample), "Start", "func other() {}"); err != nil {
+		t.Errorf("expected a bare method name to match, got %v", err)
+	}
+	if out, err := InsertFunctionAfter([]byte(sample), "", "func last() {}"); err != nil || !strings.HasSuffix(string(out), "func last() {}\n") {
+		t.Errorf("expected an empty anchor to append, got %v:\n%s", err, out)
+	}
+
+	for name, tt := range map[string]struct{ anchor, decl string }{
+		"duplicate":   {"helper", "func helper() {}"},
+		"no anchor":   {"missing", "func x() {}"},
+		"not a func":  {"helper", "var y = 2"},
+		"bad syntax":  {"helper", "func z( {"},
+		"import":      {"helper", `import "os"`},
+		"unbalanced":  {"helper", "func a() {}\n}"},
+		"empty input": {"helper", ""},
+	} {
+		if _, err := InsertFunctionAfter([]byte(sample), tt.anchor, tt.decl); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestAddStructField(t *testing.T) {
+	out, err := AddStructField([]byte(sample), "Server", "Port int `json:\"port\"`")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "\tName string // shown in logs\n\tPort int    `json:\"port\"`\n}") {
+		t.Errorf("expected an aligned field at the end of the struct, got:\n%s", out)
+	}
+
+	inline, err := AddStructField([]byte("package p\n\ntype Empty struct{}\n"), "Empty", "A, B int")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(inline), "type Empty struct {\n\tA, B int\n}") {
+		t.Errorf("expected a single-line struct to be expanded, got:\n%s", inline)
+	}
+
+	for name, tt := range map[string]struct{ typ, field string }{
+		"duplicate":  {"Server", "Name string"},
+		"not struct": {"Missing", "X int"},
+		"bad field":  {"Server", "X ="},
+		"escape":     {"Server", "X int\n}\nfunc evil() {"},
+	} {
+		if _, err := AddStructField([]byte(sample), tt.typ, tt.field); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := AddStructField([]byte("package p\n\nfunc broken( {\n"), "T", "X int"); err == nil {
+		t.Error("expected a file that doesn't parse to be refused")
+	}
+}
@@ -3,14 +3,17 @@ package graceful
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
+
+	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var shutdownLog = logging.Module("shutdown")
+
 // ShutdownManager handles graceful shutdown of the application.
 type ShutdownManager struct {
 	shutdownTimeout time.Duration
@@ -50,7 +53,7 @@ func (sm *ShutdownManager) WaitForShutdown() error {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	sig := <-sigChan
-	log.Printf("[INFO] Received signal: %v, starting graceful shutdown...", sig)
+	shutdownLog.Info("Received signal, starting graceful shutdown", "signal", sig.String())
 
 	return sm.Shutdown()
 }
@@ -70,17 +73,17 @@ func (sm *ShutdownManager) Shutdown() error {
 
 		// Execute callbacks in reverse order (LIFO)
 		for i := len(callbacks) - 1; i >= 0; i-- {
-			log.Printf("[INFO] Executing shutdown callback %d/%d", len(callbacks)-i, len(callbacks))
+			shutdownLog.Info("Executing shutdown callback", "callback", len(callbacks)-i, "total", len(callbacks))
 
 			if err := callbacks[i](ctx); err != nil {
-				log.Printf("[ERROR] Shutdown callback failed: %v", err)
+				shutdownLog.Error("Shutdown callback failed", "error", err)
 				if shutdownErr == nil {
 					shutdownErr = err
 				}
 			}
 		}
 
-		log.Println("[INFO] Graceful shutdown complete")
+		shutdownLog.Info("Graceful shutdown complete")
 	})
 
 	return shutdownErr
@@ -127,7 +130,7 @@ func (sm *StartupManager) RegisterGate(name string, check func(ctx context.Conte
 // WaitUntilReady waits for all readiness gates to pass.
 // Returns an error if any gate fails or times out.
 func (sm *StartupManager) WaitUntilReady(ctx context.Context) error {
-	log.Println("[INFO] Starting readiness checks...")
+	shutdownLog.Info("Starting readiness checks")
 
 	sm.mu.RLock()
 	gates := make([]ReadyGate, len(sm.readyGates))
@@ -135,7 +138,7 @@ func (sm *StartupManager) WaitUntilReady(ctx context.Context) error {
 	sm.mu.RUnlock()
 
 	for i, gate := range gates {
-		log.Printf("[INFO] Checking readiness gate %d/%d: %s", i+1, len(gates), gate.Name)
+		shutdownLog.Info("Checking readiness gate", "gate", gate.Name, "index", i+1, "total", len(gates))
 
 		gateCtx, cancel := context.WithTimeout(ctx, gate.Timeout)
 		defer cancel()
@@ -148,14 +151,14 @@ func (sm *StartupManager) WaitUntilReady(ctx context.Context) error {
 			return fmt.Errorf("readiness gate '%s' failed after %v: %w", gate.Name, duration, err)
 		}
 
-		log.Printf("[INFO] Readiness gate '%s' passed in %v", gate.Name, duration)
+		shutdownLog.Info("Readiness gate passed", "gate", gate.Name, "duration", duration)
 	}
 
 	sm.mu.Lock()
 	sm.ready = true
 	sm.mu.Unlock()
 
-	log.Println("[INFO] All readiness gates passed - application is ready!")
+	shutdownLog.Info("All readiness gates passed, application is ready")
 	return nil
 }
 
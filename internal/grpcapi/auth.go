@@ -0,0 +1,125 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticator validates the credentials agents send, the same ones the
+// REST API accepts, and looks up the owners of API keys
+type Authenticator interface {
+	ValidateToken(token string) (*auth.Claims, error)
+	ValidateAPIKey(key string) (string, []string, error)
+	GetUser(userID string) (*auth.User, error)
+}
+
+// agentRoles are the roles that may act as agents: send heartbeats and
+// execute actions. Other callers may only subscribe to events.
+var agentRoles = map[string]bool{"admin": true, "service": true}
+
+// Identity is the authenticated caller of an RPC
+type Identity struct {
+	UserID   string
+	Username string
+	Role     string
+}
+
+type identityKey struct{}
+
+// IdentityFromContext returns the caller of the RPC ctx belongs to
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// authenticate resolves the caller from the "authorization: Bearer <jwt>"
+// or "x-api-key" metadata, else from a verified client certificate. With
+// auth disabled every caller is admin, as on the REST API.
+func (s *server) authenticate(ctx context.Context) (context.Context, error) {
+	if s.opts.Auth == nil {
+		return context.WithValue(ctx, identityKey{}, Identity{UserID: "admin", Username: "admin", Role: "admin"}), nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		token, ok := strings.CutPrefix(values[0], "Bearer ")
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata format")
+		}
+		claims, err := s.opts.Auth.ValidateToken(token)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+		}
+		return context.WithValue(ctx, identityKey{}, Identity{UserID: claims.UserID, Username: claims.Username, Role: claims.Role}), nil
+	}
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		userID, _, err := s.opts.Auth.ValidateAPIKey(values[0])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		// A key acts with its owner's role
+		id := Identity{UserID: userID}
+		if user, err := s.opts.Auth.GetUser(userID); err == nil {
+			id.Username, id.Role = user.Username, user.Role
+		}
+		return context.WithValue(ctx, identityKey{}, id), nil
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if name := auth.CertificateIdentity(&info.State); name != "" {
+				role := s.opts.ClientCertRole
+				if role == "" {
+					role = "service"
+				}
+				return context.WithValue(ctx, identityKey{}, Identity{UserID: "cert:" + name, Username: name, Role: role}), nil
+			}
+		}
+	}
+	return nil, status.Error(codes.Unauthenticated, "missing credentials")
+}
+
+// authorizeAgent refuses callers whose role may not act as an agent
+func authorizeAgent(ctx context.Context) error {
+	id, ok := IdentityFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	if !agentRoles[id.Role] {
+		return status.Errorf(codes.PermissionDenied, "role %q may not act as an agent", id.Role)
+	}
+	return nil
+}
+
+func (s *server) unaryAuth(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *server) streamAuth(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authedStream carries the caller's identity in its context
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,30 @@
+package grpcapi
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// CodecName is the content subtype of the agent runtime service: messages
+// are JSON, so agents share the REST API's types and need no generated
+// code. Clients select it with grpc.CallContentSubtype(CodecName).
+const CodecName = "json"
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return CodecName
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
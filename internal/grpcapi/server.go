@@ -0,0 +1,172 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
+	"github.com/jordanhubbard/loom/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ActionExecutor runs action envelopes (the REST API's action router)
+type ActionExecutor interface {
+	Execute(ctx context.Context, env *actions.ActionEnvelope, actx actions.ActionContext) ([]actions.Result, error)
+}
+
+// EventSource publishes events to subscribers (the event bus)
+type EventSource interface {
+	Subscribe(subscriberID string, filter func(*eventbus.Event) bool) *eventbus.Subscriber
+	Unsubscribe(subscriberID string)
+}
+
+// AgentRegistry looks up agents and records their liveness (the agent
+// manager)
+type AgentRegistry interface {
+	GetAgent(id string) (*models.Agent, error)
+	UpdateHeartbeat(id string) error
+}
+
+// Runtime is what the service serves; a nil member makes its RPCs
+// return Unavailable
+type Runtime struct {
+	Actions ActionExecutor
+	Events  EventSource
+	Agents  AgentRegistry
+}
+
+// Options configures authentication
+type Options struct {
+	Auth           Authenticator // Nil disables authentication
+	ClientCertRole string        // Role of callers authenticated by client certificate; default "service"
+}
+
+type server struct {
+	rt   Runtime
+	opts Options
+}
+
+// NewServer creates a gRPC server with the agent runtime service and its
+// authentication registered
+func NewServer(rt Runtime, opts Options, serverOpts ...grpc.ServerOption) *grpc.Server {
+	s := &server{rt: rt, opts: opts}
+	serverOpts = append(serverOpts,
+		grpc.ChainUnaryInterceptor(s.unaryAuth),
+		grpc.ChainStreamInterceptor(s.streamAuth),
+	)
+	gs := grpc.NewServer(serverOpts...)
+	RegisterAgentRuntimeServer(gs, s)
+	return gs
+}
+
+// Heartbeat records that an agent is alive
+func (s *server) Heartbeat(ctx context.Context, in *HeartbeatRequest) (*HeartbeatResponse, error) {
+	if err := authorizeAgent(ctx); err != nil {
+		return nil, err
+	}
+	if s.rt.Agents == nil {
+		return nil, status.Error(codes.Unavailable, "agent manager not available")
+	}
+	if in.AgentID == "" {
+		return nil, status.Error(codes.InvalidArgument, "agent_id is required")
+	}
+	if err := s.rt.Agents.UpdateHeartbeat(in.AgentID); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &HeartbeatResponse{AgentID: in.AgentID, ReceivedAt: time.Now()}, nil
+}
+
+// ExecuteActions runs each envelope received, in order, and answers with its
+// results. A request for an unknown agent, a bad envelope or a failed
+// execution is reported in the response and the stream carries on.
+func (s *server) ExecuteActions(stream grpc.BidiStreamingServer[ActionRequest, ActionResponse]) error {
+	ctx := stream.Context()
+	if err := authorizeAgent(ctx); err != nil {
+		return err
+	}
+	if s.rt.Actions == nil {
+		return status.Error(codes.Unavailable, "action router not available")
+	}
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		resp := &ActionResponse{RequestID: req.RequestID}
+		if err := s.checkAgent(req.AgentID); err != nil {
+			resp.Error = err.Error()
+		} else if env, err := actions.DecodeLenient(req.Envelope); err != nil {
+			resp.Error = fmt.Sprintf("action parse failed: %v", err)
+		} else {
+			projectID := req.ProjectID
+			if projectID == "" {
+				projectID = "loom-self"
+			}
+			resp.Results, err = s.rt.Actions.Execute(ctx, env, actions.ActionContext{
+				AgentID:   req.AgentID,
+				BeadID:    req.BeadID,
+				ProjectID: projectID,
+			})
+			if err != nil {
+				resp.Error = err.Error()
+			}
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// checkAgent requires a request to name a registered agent
+func (s *server) checkAgent(agentID string) error {
+	if agentID == "" {
+		return errors.New("agent_id is required")
+	}
+	if s.rt.Agents == nil {
+		return nil
+	}
+	_, err := s.rt.Agents.GetAgent(agentID)
+	return err
+}
+
+// SubscribeEvents streams events matching the filter until the client
+// goes away
+func (s *server) SubscribeEvents(filter *EventFilter, stream grpc.ServerStreamingServer[eventbus.Event]) error {
+	if s.rt.Events == nil {
+		return status.Error(codes.Unavailable, "event bus not available")
+	}
+	subscriberID := "grpc-" + uuid.New().String()
+	sub := s.rt.Events.Subscribe(subscriberID, func(event *eventbus.Event) bool {
+		if filter.ProjectID != "" && event.ProjectID != filter.ProjectID {
+			return false
+		}
+		return filter.Type == "" || string(event.Type) == filter.Type
+	})
+	defer s.rt.Events.Unsubscribe(subscriberID)
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.Channel:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
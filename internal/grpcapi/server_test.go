@@ -0,0 +1,280 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/auth"
+	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
+	"github.com/jordanhubbard/loom/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeRuntime struct {
+	mu         sync.Mutex
+	heartbeats []string
+	executed   []actions.ActionContext
+	subs       map[string]*eventbus.Subscriber
+	identities []Identity
+}
+
+func (f *fakeRuntime) GetAgent(id string) (*models.Agent, error) {
+	if id == "missing" {
+		return nil, errors.New("agent not found: missing")
+	}
+	return &models.Agent{ID: id}, nil
+}
+
+func (f *fakeRuntime) UpdateHeartbeat(id string) error {
+	if id == "missing" {
+		return errors.New("agent not found: missing")
+	}
+	f.mu.Lock()
+	f.heartbeats = append(f.heartbeats, id)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeRuntime) Execute(ctx context.Context, env *actions.ActionEnvelope, actx actions.ActionContext) ([]actions.Result, error) {
+	id, _ := IdentityFromContext(ctx)
+	f.mu.Lock()
+	f.executed = append(f.executed, actx)
+	f.identities = append(f.identities, id)
+	f.mu.Unlock()
+	var results []actions.Result
+	for _, a := range env.Actions {
+		results = append(results, actions.Result{ActionType: a.Type, Status: "executed"})
+	}
+	return results, nil
+}
+
+func (f *fakeRuntime) Subscribe(id string, filter func(*eventbus.Event) bool) *eventbus.Subscriber {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sub := &eventbus.Subscriber{ID: id, Channel: make(chan *eventbus.Event, 4), Filter: filter}
+	f.subs[id] = sub
+	return sub
+}
+
+func (f *fakeRuntime) Unsubscribe(id string) {
+	f.mu.Lock()
+	delete(f.subs, id)
+	f.mu.Unlock()
+}
+
+func (f *fakeRuntime) publish(event *eventbus.Event) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delivered := 0
+	for _, sub := range f.subs {
+		if sub.Filter == nil || sub.Filter(event) {
+			sub.Channel <- event
+			delivered++
+		}
+	}
+	return delivered
+}
+
+func (f *fakeRuntime) subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs)
+}
+
+type fakeAuth struct{}
+
+func (fakeAuth) ValidateToken(token string) (*auth.Claims, error) {
+	switch token {
+	case "good-token":
+		return &auth.Claims{UserID: "u1", Username: "agent-runner", Role: "service"}, nil
+	case "user-token":
+		return &auth.Claims{UserID: "u2", Username: "alice", Role: "user"}, nil
+	}
+	return nil, errors.New("bad token")
+}
+
+func (fakeAuth) ValidateAPIKey(key string) (string, []string, error) {
+	if key != "good-key" {
+		return "", nil, errors.New("bad key")
+	}
+	return "u2", []string{"*:*"}, nil
+}
+
+func (fakeAuth) GetUser(userID string) (*auth.User, error) {
+	if userID != "u2" {
+		return nil, errors.New("user not found")
+	}
+	return &auth.User{ID: "u2", Username: "alice", Role: "user"}, nil
+}
+
+func startServer(t *testing.T, rt Runtime, opts Options) *Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(rt, opts)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewClient(conn)
+}
+
+func TestHeartbeat(t *testing.T) {
+	rt := &fakeRuntime{subs: map[string]*eventbus.Subscriber{}}
+	client := startServer(t, Runtime{Agents: rt}, Options{})
+	ctx := context.Background()
+
+	resp, err := client.Heartbeat(ctx, &HeartbeatRequest{AgentID: "agent-1"})
+	if err != nil || resp.AgentID != "agent-1" || resp.ReceivedAt.IsZero() {
+		t.Fatalf("unexpected heartbeat response %+v, %v", resp, err)
+	}
+	if _, err := client.Heartbeat(ctx, &HeartbeatRequest{AgentID: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+	if _, err := client.Heartbeat(ctx, &HeartbeatRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestExecuteActionsStream(t *testing.T) {
+	rt := &fakeRuntime{subs: map[string]*eventbus.Subscriber{}}
+	client := startServer(t, Runtime{Actions: rt, Agents: rt}, Options{Auth: fakeAuth{}})
+
+	if _, err := client.Heartbeat(context.Background(), &HeartbeatRequest{AgentID: "a"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected unauthenticated calls to be refused, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer good-token")
+	stream, err := client.ExecuteActions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := []*ActionRequest{
+		{RequestID: "1", AgentID: "agent-1", Envelope: []byte(`{"actions":[{"type":"done","reason":"ok"}]}`)},
+		{RequestID: "2", AgentID: "agent-1", Envelope: []byte(`{"actions":[]}`)},
+		{RequestID: "3", AgentID: "missing", Envelope: []byte(`{"actions":[{"type":"done","reason":"ok"}]}`)},
+		{RequestID: "4", Envelope: []byte(`{"actions":[{"type":"done","reason":"ok"}]}`)},
+	}
+	for _, req := range requests {
+		if err := stream.Send(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = stream.CloseSend()
+
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.RequestID != "1" || first.Error != "" || len(first.Results) != 1 || first.Results[0].ActionType != "done" {
+		t.Errorf("unexpected first response %+v", first)
+	}
+	second, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RequestID != "2" || second.Error == "" {
+		t.Errorf("expected a parse error for the second request, got %+v", second)
+	}
+	for _, want := range []string{"agent not found: missing", "agent_id is required"} {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error != want || len(resp.Results) != 0 {
+			t.Errorf("expected %q for request %s, got %+v", want, resp.RequestID, resp)
+		}
+	}
+
+	if len(rt.executed) != 1 || rt.executed[0].ProjectID != "loom-self" || rt.executed[0].AgentID != "agent-1" {
+		t.Errorf("unexpected action context %+v", rt.executed)
+	}
+	if rt.identities[0].UserID != "u1" || rt.identities[0].Role != "service" {
+		t.Errorf("expected the token identity, got %+v", rt.identities[0])
+	}
+}
+
+func TestAgentRolesOnly(t *testing.T) {
+	rt := &fakeRuntime{subs: map[string]*eventbus.Subscriber{}}
+	client := startServer(t, Runtime{Actions: rt, Agents: rt}, Options{Auth: fakeAuth{}})
+
+	// A user's token, and a user's API key whatever it claims, may not act
+	// as an agent
+	for _, md := range [][]string{
+		{"authorization", "Bearer user-token"},
+		{"x-api-key", "good-key", "x-role", "admin"},
+	} {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), md...)
+		if _, err := client.Heartbeat(ctx, &HeartbeatRequest{AgentID: "agent-1"}); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%v: expected PermissionDenied for a heartbeat, got %v", md, err)
+		}
+		stream, err := client.ExecuteActions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stream.Recv(); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%v: expected PermissionDenied for actions, got %v", md, err)
+		}
+	}
+	if len(rt.heartbeats) != 0 || len(rt.executed) != 0 {
+		t.Errorf("nothing may run for a non-agent role, got heartbeats %v actions %v", rt.heartbeats, rt.executed)
+	}
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	rt := &fakeRuntime{subs: map[string]*eventbus.Subscriber{}}
+	client := startServer(t, Runtime{Events: rt}, Options{Auth: fakeAuth{}})
+
+	ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "good-key"))
+	defer cancel()
+	stream, err := client.SubscribeEvents(ctx, &EventFilter{ProjectID: "p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for rt.subscribers() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription never registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if rt.publish(&eventbus.Event{ID: "skip", ProjectID: "p2"}) != 0 {
+		t.Error("expected other projects' events to be filtered out")
+	}
+	rt.publish(&eventbus.Event{ID: "e1", Type: eventbus.EventTypeAgentHeartbeat, ProjectID: "p1"})
+	event, err := stream.Recv()
+	if err != nil || event.ID != "e1" {
+		t.Fatalf("expected event e1, got %+v, %v", event, err)
+	}
+
+	cancel()
+	for rt.subscribers() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription not removed after the client went away")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestUnavailableRuntime(t *testing.T) {
+	client := startServer(t, Runtime{}, Options{})
+	if _, err := client.Heartbeat(context.Background(), &HeartbeatRequest{AgentID: "a"}); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable, got %v", err)
+	}
+}
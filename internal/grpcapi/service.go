@@ -0,0 +1,159 @@
+// Package grpcapi serves the agent runtime over gRPC: action execution and
+// event streams for agents that talk to the orchestrator often enough to
+// want a persistent binary connection. It shares the REST API's action
+// router, event bus, agent manager and authentication.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
+	"google.golang.org/grpc"
+)
+
+// ServiceName is the fully qualified gRPC service name
+const ServiceName = "loom.v1.AgentRuntime"
+
+// Full method names
+const (
+	MethodHeartbeat       = "/" + ServiceName + "/Heartbeat"
+	MethodExecuteActions  = "/" + ServiceName + "/ExecuteActions"
+	MethodSubscribeEvents = "/" + ServiceName + "/SubscribeEvents"
+)
+
+// HeartbeatRequest reports that an agent is alive
+type HeartbeatRequest struct {
+	AgentID string `json:"agent_id"`
+}
+
+// HeartbeatResponse acknowledges a heartbeat
+type HeartbeatResponse struct {
+	AgentID    string    `json:"agent_id"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// ActionRequest submits an action envelope for execution. RequestID is
+// echoed in the response so a client can pipeline requests on one stream.
+type ActionRequest struct {
+	RequestID string          `json:"request_id,omitempty"`
+	AgentID   string          `json:"agent_id,omitempty"`
+	BeadID    string          `json:"bead_id,omitempty"`
+	ProjectID string          `json:"project_id,omitempty"`
+	Envelope  json.RawMessage `json:"envelope"`
+}
+
+// ActionResponse carries the results of one action request
+type ActionResponse struct {
+	RequestID string           `json:"request_id,omitempty"`
+	Results   []actions.Result `json:"results,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// EventFilter selects the events a subscription receives; empty fields
+// match everything
+type EventFilter struct {
+	ProjectID string `json:"project_id,omitempty"`
+	Type      string `json:"type,omitempty"`
+}
+
+// AgentRuntimeServer is the agent runtime service
+type AgentRuntimeServer interface {
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	ExecuteActions(grpc.BidiStreamingServer[ActionRequest, ActionResponse]) error
+	SubscribeEvents(*EventFilter, grpc.ServerStreamingServer[eventbus.Event]) error
+}
+
+// RegisterAgentRuntimeServer registers srv on s
+func RegisterAgentRuntimeServer(s grpc.ServiceRegistrar, srv AgentRuntimeServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*AgentRuntimeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Heartbeat", Handler: heartbeatHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "ExecuteActions", Handler: executeActionsHandler, ServerStreams: true, ClientStreams: true},
+		{StreamName: "SubscribeEvents", Handler: subscribeEventsHandler, ServerStreams: true},
+	},
+	Metadata: "internal/grpcapi/service.go",
+}
+
+func heartbeatHandler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentRuntimeServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: MethodHeartbeat}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(AgentRuntimeServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func executeActionsHandler(srv any, stream grpc.ServerStream) error {
+	return srv.(AgentRuntimeServer).ExecuteActions(&grpc.GenericServerStream[ActionRequest, ActionResponse]{ServerStream: stream})
+}
+
+func subscribeEventsHandler(srv any, stream grpc.ServerStream) error {
+	in := new(EventFilter)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(AgentRuntimeServer).SubscribeEvents(in, &grpc.GenericServerStream[EventFilter, eventbus.Event]{ServerStream: stream})
+}
+
+// Client calls the agent runtime service
+type Client struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewClient creates a client on cc
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{cc: cc}
+}
+
+func callOptions(opts []grpc.CallOption) []grpc.CallOption {
+	return append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+}
+
+// Heartbeat reports that an agent is alive
+func (c *Client) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	out := new(HeartbeatResponse)
+	if err := c.cc.Invoke(ctx, MethodHeartbeat, in, out, callOptions(opts)...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExecuteActions opens a stream of action requests and their responses
+func (c *Client) ExecuteActions(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ActionRequest, ActionResponse], error) {
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0], MethodExecuteActions, callOptions(opts)...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpc.GenericClientStream[ActionRequest, ActionResponse]{ClientStream: stream}, nil
+}
+
+// SubscribeEvents streams events matching in
+func (c *Client) SubscribeEvents(ctx context.Context, in *EventFilter, opts ...grpc.CallOption) (grpc.ServerStreamingClient[eventbus.Event], error) {
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[1], MethodSubscribeEvents, callOptions(opts)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return &grpc.GenericClientStream[EventFilter, eventbus.Event]{ClientStream: stream}, nil
+}
@@ -2,9 +2,12 @@ package hotreload
 
 import (
 	"fmt"
-	"log"
+
+	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var hotReloadLog = logging.Module("hotreload")
+
 // Manager coordinates file watching and WebSocket server
 type Manager struct {
 	watcher *Watcher
@@ -15,7 +18,7 @@ type Manager struct {
 // NewManager creates a new hot-reload manager
 func NewManager(enabled bool, watchDirs []string, patterns []string) (*Manager, error) {
 	if !enabled {
-		log.Println("[HotReload] Disabled")
+		hotReloadLog.Info("Hot reload disabled")
 		return &Manager{enabled: false}, nil
 	}
 
@@ -28,14 +31,14 @@ func NewManager(enabled bool, watchDirs []string, patterns []string) (*Manager,
 	// Watch directories
 	for _, dir := range watchDirs {
 		if err := watcher.Watch(dir); err != nil {
-			log.Printf("[HotReload] Failed to watch %s: %v", dir, err)
+			hotReloadLog.Warn("Failed to watch directory", "path", dir, "error", err)
 		}
 	}
 
 	// Create WebSocket server
 	server := NewServer(watcher)
 
-	log.Printf("[HotReload] Enabled - watching %d directories", len(watchDirs))
+	hotReloadLog.Info("Hot reload enabled", "directories", len(watchDirs))
 
 	return &Manager{
 		watcher: watcher,
@@ -65,7 +68,7 @@ func (m *Manager) Close() error {
 
 	if m.server != nil {
 		if err := m.server.Close(); err != nil {
-			log.Printf("[HotReload] Error closing server: %v", err)
+			hotReloadLog.Error("Error closing server", "error", err)
 		}
 	}
 
@@ -75,6 +78,6 @@ func (m *Manager) Close() error {
 		}
 	}
 
-	log.Println("[HotReload] Shut down")
+	hotReloadLog.Info("Hot reload shut down")
 	return nil
 }
@@ -2,7 +2,6 @@ package hotreload
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"sync"
 
@@ -46,7 +45,7 @@ func NewServer(watcher *Watcher) *Server {
 func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("[HotReload] Failed to upgrade connection: %v", err)
+		hotReloadLog.Warn("Failed to upgrade connection", "error", err)
 		return
 	}
 
@@ -56,7 +55,7 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	clientCount := len(s.clients)
 	s.mu.Unlock()
 
-	log.Printf("[HotReload] Client connected (total: %d)", clientCount)
+	hotReloadLog.Info("Client connected", "clients", clientCount)
 
 	// Send initial connection message
 	msg := map[string]interface{}{
@@ -64,7 +63,7 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		"message": "Hot-reload enabled",
 	}
 	if err := conn.WriteJSON(msg); err != nil {
-		log.Printf("[HotReload] Failed to send welcome message: %v", err)
+		hotReloadLog.Warn("Failed to send welcome message", "error", err)
 	}
 
 	// Keep connection alive and handle client messages
@@ -81,7 +80,7 @@ func (s *Server) handleClient(conn *websocket.Conn) {
 		s.mu.Unlock()
 
 		conn.Close()
-		log.Printf("[HotReload] Client disconnected (remaining: %d)", clientCount)
+		hotReloadLog.Info("Client disconnected", "clients", clientCount)
 	}()
 
 	for {
@@ -89,7 +88,7 @@ func (s *Server) handleClient(conn *websocket.Conn) {
 		_, _, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("[HotReload] Unexpected close: %v", err)
+				hotReloadLog.Warn("Unexpected close", "error", err)
 			}
 			break
 		}
@@ -136,12 +135,12 @@ func (s *Server) broadcastLoop() {
 		// Broadcast to all clients
 		for _, client := range clients {
 			if err := client.WriteJSON(msg); err != nil {
-				log.Printf("[HotReload] Failed to send to client: %v", err)
+				hotReloadLog.Warn("Failed to send to client", "error", err)
 				// Client will be removed by handleClient goroutine
 			}
 		}
 
-		log.Printf("[HotReload] Broadcasted change to %d clients", len(clients))
+		hotReloadLog.Debug("Broadcasted change", "clients", len(clients))
 	}
 }
 
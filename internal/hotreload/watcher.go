@@ -2,7 +2,6 @@ package hotreload
 
 import (
 	"context"
-	"log"
 	"os"
 	"path/filepath"
 	"sync"
@@ -58,9 +57,9 @@ func (w *Watcher) Watch(dir string) error {
 		if info.IsDir() {
 			// Watch directory
 			if err := w.fsWatcher.Add(path); err != nil {
-				log.Printf("[HotReload] Failed to watch %s: %v", path, err)
+				hotReloadLog.Warn("Failed to watch directory", "path", path, "error", err)
 			} else {
-				log.Printf("[HotReload] Watching %s", path)
+				hotReloadLog.Debug("Watching directory", "path", path)
 			}
 		}
 		return nil
@@ -145,7 +144,7 @@ func (w *Watcher) eventLoop() {
 			if !ok {
 				return
 			}
-			log.Printf("[HotReload] Watcher error: %v", err)
+			hotReloadLog.Error("Watcher error", "error", err)
 		}
 	}
 }
@@ -176,7 +175,7 @@ func (w *Watcher) notifyListeners(event fsnotify.Event) {
 		Timestamp: time.Now(),
 	}
 
-	log.Printf("[HotReload] File changed: %s (%s)", changeEvent.Path, changeEvent.Operation)
+	hotReloadLog.Info("File changed", "path", changeEvent.Path, "operation", changeEvent.Operation)
 
 	w.mu.RLock()
 	defer w.mu.RUnlock()
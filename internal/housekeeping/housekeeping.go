@@ -0,0 +1,279 @@
+// Package housekeeping runs the server's in-process maintenance tasks, such
+// as expiring file locks and resetting stuck agents, each on its own
+// interval. Every task keeps a record of its runs (when it last ran, how
+// long it took, what it did or why it failed, and when it runs next) so
+// operators can tell whether cleanup is actually happening. Tasks can be
+// run on demand and paused.
+package housekeeping
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/logging"
+)
+
+const (
+	// tickInterval is how often due tasks are checked
+	tickInterval = 15 * time.Second
+	// DefaultInterval is the interval of tasks registered without one
+	DefaultInterval = time.Minute
+	// StallAfter is how long a started runner may go without checking due
+	// tasks before liveness checks report it stuck
+	StallAfter = 4 * tickInterval
+	// maxResultLen caps the stored summary of a run
+	maxResultLen = 1024
+)
+
+var housekeepingLog = logging.Module("maintenance")
+
+// Func runs a task once and returns a short summary of what it did
+type Func func(ctx context.Context) (string, error)
+
+// Status is a task's configuration and the record of its runs
+type Status struct {
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	Interval       string     `json:"interval"`
+	Paused         bool       `json:"paused"`
+	Running        bool       `json:"running"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+	LastRun        *time.Time `json:"last_run,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastResult     string     `json:"last_result,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextRun        *time.Time `json:"next_run,omitempty"` // Unset while paused
+}
+
+type task struct {
+	name        string
+	description string
+	interval    time.Duration
+	fn          Func
+
+	paused       bool
+	running      bool
+	runs         int64
+	failures     int64
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastResult   string
+	lastError    string
+	nextRun      time.Time
+}
+
+// Runner runs registered tasks when they are due
+type Runner struct {
+	mu       sync.Mutex
+	tasks    map[string]*task
+	order    []string
+	lastTick time.Time
+	now      func() time.Time
+}
+
+// New creates a runner with no tasks
+func New() *Runner {
+	return &Runner{tasks: make(map[string]*task), now: time.Now}
+}
+
+// Register adds a task run every interval, first one interval from now.
+// Registering a name again replaces the task.
+func (r *Runner) Register(name, description string, interval time.Duration, fn Func) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tasks[name]; !ok {
+		r.order = append(r.order, name)
+	}
+	r.tasks[name] = &task{
+		name:        name,
+		description: description,
+		interval:    interval,
+		fn:          fn,
+		nextRun:     r.now().Add(interval),
+	}
+}
+
+// List returns every task, in registration order
+func (r *Runner) List() []Status {
+	if r == nil {
+		return []Status{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]Status, 0, len(r.order))
+	for _, name := range r.order {
+		list = append(list, r.tasks[name].status())
+	}
+	return list
+}
+
+// Get returns a task
+func (r *Runner) Get(name string) (Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tasks[name]
+	if !ok {
+		return Status{}, apperr.NotFound("maintenance task not found: %s", name)
+	}
+	return t.status(), nil
+}
+
+// SetPaused pauses or resumes a task. A paused task only runs when
+// triggered; a resumed one runs at its next scheduled time, at once if
+// that has passed.
+func (r *Runner) SetPaused(name string, paused bool) (Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tasks[name]
+	if !ok {
+		return Status{}, apperr.NotFound("maintenance task not found: %s", name)
+	}
+	t.paused = paused
+	return t.status(), nil
+}
+
+// Trigger runs a task now, paused or not, and returns it once the run is
+// over
+func (r *Runner) Trigger(ctx context.Context, name string) (Status, error) {
+	r.mu.Lock()
+	t, ok := r.tasks[name]
+	switch {
+	case !ok:
+		r.mu.Unlock()
+		return Status{}, apperr.NotFound("maintenance task not found: %s", name)
+	case t.running:
+		r.mu.Unlock()
+		return Status{}, apperr.Conflict("maintenance task %s is already running", name)
+	}
+	t.running = true
+	r.mu.Unlock()
+
+	r.run(ctx, t)
+	return r.Get(name)
+}
+
+// RunDue runs every task that is due and not paused, one after another,
+// and returns how many ran
+func (r *Runner) RunDue(ctx context.Context) int {
+	r.mu.Lock()
+	now := r.now()
+	r.lastTick = now
+	var due []*task
+	for _, name := range r.order {
+		t := r.tasks[name]
+		if !t.paused && !t.running && !t.nextRun.After(now) {
+			t.running = true
+			due = append(due, t)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, t := range due {
+		if ctx.Err() != nil {
+			r.mu.Lock()
+			t.running = false
+			r.mu.Unlock()
+			continue
+		}
+		r.run(ctx, t)
+	}
+	return len(due)
+}
+
+// LastTick reports when due tasks were last checked, for liveness checks
+func (r *Runner) LastTick() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastTick
+}
+
+// Start runs due tasks until ctx is cancelled
+func (r *Runner) Start(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	r.RunDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RunDue(ctx)
+		}
+	}
+}
+
+// run executes a task, which must already be marked running, and records
+// the run
+func (r *Runner) run(ctx context.Context, t *task) {
+	start := r.now()
+	result, err := safeRun(ctx, t.fn)
+	end := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t.running = false
+	t.runs++
+	t.lastRun = start
+	t.lastDuration = end.Sub(start)
+	t.lastResult = truncate(result)
+	t.lastError = ""
+	if err != nil {
+		t.failures++
+		t.lastError = truncate(err.Error())
+		housekeepingLog.Error("Maintenance task failed", "task", t.name, "error", err)
+	}
+	// Skip runs missed while this one was late or long rather than running
+	// them back to back
+	next := t.nextRun
+	for !next.After(end) {
+		next = next.Add(t.interval)
+	}
+	t.nextRun = next
+}
+
+func (t *task) status() Status {
+	s := Status{
+		Name:           t.name,
+		Description:    t.description,
+		Interval:       t.interval.String(),
+		Paused:         t.paused,
+		Running:        t.running,
+		Runs:           t.runs,
+		Failures:       t.failures,
+		LastDurationMs: t.lastDuration.Milliseconds(),
+		LastResult:     t.lastResult,
+		LastError:      t.lastError,
+	}
+	if !t.lastRun.IsZero() {
+		last := t.lastRun
+		s.LastRun = &last
+	}
+	if !t.paused {
+		next := t.nextRun
+		s.NextRun = &next
+	}
+	return s
+}
+
+func safeRun(ctx context.Context, fn Func) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+func truncate(s string) string {
+	if len(s) > maxResultLen {
+		return s[:maxResultLen] + "..."
+	}
+	return s
+}
@@ -0,0 +1,113 @@
+package housekeeping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestRunner() (*Runner, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	r := New()
+	r.now = clock.now
+	return r, clock
+}
+
+func TestRunDueRecordsRuns(t *testing.T) {
+	r, clock := newTestRunner()
+	calls := 0
+	r.Register("locks", "Expire file locks", time.Minute, func(ctx context.Context) (string, error) {
+		calls++
+		return fmt.Sprintf("released %d locks", calls), nil
+	})
+	r.Register("refresh", "Refresh beads", 5*time.Minute, func(ctx context.Context) (string, error) {
+		return "", errors.New("dolt unavailable")
+	})
+
+	if n := r.RunDue(context.Background()); n != 0 {
+		t.Fatalf("expected nothing due before the first interval, ran %d", n)
+	}
+	clock.t = clock.t.Add(5 * time.Minute)
+	if n := r.RunDue(context.Background()); n != 2 {
+		t.Fatalf("expected both tasks to run, ran %d", n)
+	}
+
+	list := r.List()
+	if len(list) != 2 || list[0].Name != "locks" || list[1].Name != "refresh" {
+		t.Fatalf("expected the tasks in registration order, got %+v", list)
+	}
+	locks := list[0]
+	if locks.Runs != 1 || locks.LastResult != "released 1 locks" || locks.LastRun == nil || !locks.LastRun.Equal(clock.t) {
+		t.Errorf("unexpected status %+v", locks)
+	}
+	// Missed runs are skipped, not made up
+	if want := clock.t.Add(time.Minute); locks.NextRun == nil || !locks.NextRun.Equal(want) {
+		t.Errorf("next run = %v, want %v", locks.NextRun, want)
+	}
+	refresh := list[1]
+	if refresh.Failures != 1 || refresh.LastError != "dolt unavailable" {
+		t.Errorf("expected the failure to be recorded, got %+v", refresh)
+	}
+	if r.LastTick() != clock.t {
+		t.Error("expected RunDue to record the tick")
+	}
+}
+
+func TestPauseAndTrigger(t *testing.T) {
+	r, clock := newTestRunner()
+	calls := 0
+	r.Register("agents", "Reset stuck agents", time.Minute, func(ctx context.Context) (string, error) {
+		calls++
+		return "", nil
+	})
+
+	status, err := r.SetPaused("agents", true)
+	if err != nil || !status.Paused || status.NextRun != nil {
+		t.Fatalf("unexpected pause result %+v, %v", status, err)
+	}
+	clock.t = clock.t.Add(time.Hour)
+	r.RunDue(context.Background())
+	if calls != 0 {
+		t.Fatal("a paused task must not run on schedule")
+	}
+
+	status, err = r.Trigger(context.Background(), "agents")
+	if err != nil || calls != 1 || status.Runs != 1 {
+		t.Fatalf("expected a triggered run while paused, got %+v, %v", status, err)
+	}
+
+	if _, err := r.SetPaused("agents", false); err != nil {
+		t.Fatal(err)
+	}
+	clock.t = clock.t.Add(time.Minute)
+	r.RunDue(context.Background())
+	if calls != 2 {
+		t.Errorf("expected the resumed task to run, calls = %d", calls)
+	}
+
+	if _, err := r.Trigger(context.Background(), "missing"); !errors.Is(err, &apperr.Error{Code: apperr.CodeNotFound}) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestRunRecoversPanics(t *testing.T) {
+	r, _ := newTestRunner()
+	r.Register("boom", "Panics", time.Minute, func(ctx context.Context) (string, error) {
+		panic("bad state")
+	})
+	status, err := r.Trigger(context.Background(), "boom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Failures != 1 || status.LastError != "panic: bad state" || status.Running {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
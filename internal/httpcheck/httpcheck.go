@@ -0,0 +1,375 @@
+// Package httpcheck probes an HTTP endpoint and checks its response against
+// expected status codes, body text and JSON values, retrying with backoff
+// until the checks pass, so agents can verify a service they just changed.
+package httpcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout        = 30 * time.Second
+	defaultRequestTimeout = 10 * time.Second
+	defaultBackoff        = 500 * time.Millisecond
+	maxBackoff            = 10 * time.Second
+	// MaxRetries caps the retries of one check
+	MaxRetries = 10
+	// maxBodyBytes caps the response body read and checked
+	maxBodyBytes = 1 << 20
+	// maxReportedBody caps the response body returned in a result
+	maxReportedBody = 4000
+)
+
+// Request describes a check
+type Request struct {
+	URL          string
+	Method       string                 // Default GET
+	Headers      map[string]string      // Request headers
+	Body         string                 // Request body
+	ExpectStatus []int                  // Accepted statuses; default any 2xx
+	ExpectBody   string                 // Text the body must contain
+	ExpectJSON   map[string]interface{} // Dotted path (e.g. data.items.0.id) to the value it must equal
+	Retries      int                    // Retries after a failed attempt, with exponential backoff
+	Backoff      time.Duration          // Delay before the first retry; default 500ms
+	Timeout      time.Duration          // Whole check, all attempts included; default 30s
+}
+
+// Assertion is the outcome of one expectation
+type Assertion struct {
+	Check    string      `json:"check"` // status, body or json:<path>
+	Expected interface{} `json:"expected"`
+	Actual   interface{} `json:"actual,omitempty"`
+	Passed   bool        `json:"passed"`
+	Message  string      `json:"message,omitempty"`
+}
+
+// Attempt is one request of a check
+type Attempt struct {
+	Status   int    `json:"status,omitempty"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"` // Request error, or the first failed assertion
+}
+
+// Result is the outcome of a check: the attempts made and the response and
+// assertions of the last one
+type Result struct {
+	URL           string            `json:"url"`
+	Method        string            `json:"method"`
+	Passed        bool              `json:"passed"`
+	Status        int               `json:"status,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Body          string            `json:"body,omitempty"`
+	BodyTruncated bool              `json:"body_truncated,omitempty"`
+	Assertions    []Assertion       `json:"assertions"`
+	Attempts      []Attempt         `json:"attempts"`
+	Error         string            `json:"error,omitempty"`
+	Duration      string            `json:"duration"`
+}
+
+// Checker runs checks with an HTTP client
+type Checker struct {
+	Client *http.Client
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// NewChecker creates a checker that does not follow redirects, so redirect
+// statuses can be asserted
+func NewChecker() *Checker {
+	return &Checker{
+		Client: &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		sleep: sleep,
+	}
+}
+
+// Validate checks a request before it is sent
+func Validate(req Request) error {
+	u, err := url.Parse(req.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid URL %q", req.URL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q (use http or https)", u.Scheme)
+	}
+	if req.Method != "" && !validMethod(req.Method) {
+		return fmt.Errorf("unsupported method %q", req.Method)
+	}
+	if req.Retries < 0 || req.Retries > MaxRetries {
+		return fmt.Errorf("retries must be between 0 and %d", MaxRetries)
+	}
+	for _, status := range req.ExpectStatus {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("invalid expected status %d", status)
+		}
+	}
+	return nil
+}
+
+// Run performs the check, retrying failed attempts until one passes, the
+// retries run out or the timeout expires. A failed check is reported in the
+// result; an error means the request is invalid.
+func (c *Checker) Run(ctx context.Context, req Request) (*Result, error) {
+	if err := Validate(req); err != nil {
+		return nil, err
+	}
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	backoff := req.Backoff
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+	wait := c.sleep
+	if wait == nil {
+		wait = sleep
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := &Result{URL: req.URL, Method: method, Attempts: []Attempt{}, Assertions: []Assertion{}}
+	for attempt := 0; ; attempt++ {
+		c.attempt(ctx, method, req, result)
+		if result.Passed || attempt >= req.Retries {
+			break
+		}
+		if err := wait(ctx, backoff); err != nil {
+			result.Error = "timed out after " + time.Since(start).Round(time.Millisecond).String()
+			break
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	return result, nil
+}
+
+// attempt sends one request and records its response and assertions
+func (c *Checker) attempt(ctx context.Context, method string, req Request, result *Result) {
+	start := time.Now()
+	attempt := Attempt{}
+	defer func() {
+		attempt.Duration = time.Since(start).Round(time.Millisecond).String()
+		result.Attempts = append(result.Attempts, attempt)
+	}()
+
+	reqCtx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+	defer cancel()
+	var body io.Reader
+	if req.Body != "" {
+		body = strings.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(reqCtx, method, req.URL, body)
+	if err != nil {
+		attempt.Error = err.Error()
+		result.Error = attempt.Error
+		return
+	}
+	for _, name := range sortedKeys(req.Headers) {
+		if strings.EqualFold(name, "Host") {
+			httpReq.Host = req.Headers[name]
+			continue
+		}
+		httpReq.Header.Set(name, req.Headers[name])
+	}
+	if req.Body != "" && httpReq.Header.Get("Content-Type") == "" && json.Valid([]byte(req.Body)) {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		attempt.Error = err.Error()
+		result.Error = attempt.Error
+		result.Passed = false
+		return
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes+1))
+	if err != nil {
+		attempt.Error = "reading body: " + err.Error()
+		result.Error = attempt.Error
+		result.Passed = false
+		return
+	}
+	if len(data) > maxBodyBytes {
+		data = data[:maxBodyBytes]
+	}
+
+	attempt.Status = resp.StatusCode
+	result.Status = resp.StatusCode
+	result.Error = ""
+	result.Headers = map[string]string{}
+	for _, name := range []string{"Content-Type", "Content-Length", "Location", "Cache-Control"} {
+		if v := resp.Header.Get(name); v != "" {
+			result.Headers[name] = v
+		}
+	}
+	result.Body = string(data)
+	result.BodyTruncated = len(result.Body) > maxReportedBody
+	if result.BodyTruncated {
+		result.Body = result.Body[:maxReportedBody]
+	}
+
+	result.Assertions = Assert(req, resp.StatusCode, data)
+	result.Passed = true
+	for _, a := range result.Assertions {
+		if !a.Passed {
+			result.Passed = false
+			if attempt.Error == "" {
+				attempt.Error = a.Message
+			}
+		}
+	}
+}
+
+// Assert checks a response status and body against the request's
+// expectations
+func Assert(req Request, status int, body []byte) []Assertion {
+	var assertions []Assertion
+
+	statusOK := false
+	var expected interface{} = "2xx"
+	if len(req.ExpectStatus) > 0 {
+		expected = req.ExpectStatus
+		for _, s := range req.ExpectStatus {
+			statusOK = statusOK || s == status
+		}
+	} else {
+		statusOK = status >= 200 && status < 300
+	}
+	a := Assertion{Check: "status", Expected: expected, Actual: status, Passed: statusOK}
+	if !statusOK {
+		a.Message = fmt.Sprintf("status %d, expected %v", status, expected)
+	}
+	assertions = append(assertions, a)
+
+	if req.ExpectBody != "" {
+		a := Assertion{Check: "body", Expected: req.ExpectBody, Passed: bytes.Contains(body, []byte(req.ExpectBody))}
+		if !a.Passed {
+			a.Message = fmt.Sprintf("body does not contain %q", req.ExpectBody)
+		}
+		assertions = append(assertions, a)
+	}
+
+	if len(req.ExpectJSON) > 0 {
+		var doc interface{}
+		jsonErr := json.Unmarshal(body, &doc)
+		for _, path := range sortedKeys(req.ExpectJSON) {
+			want := normalize(req.ExpectJSON[path])
+			a := Assertion{Check: "json:" + path, Expected: want}
+			switch got, err := Lookup(doc, path); {
+			case jsonErr != nil:
+				a.Message = "response is not JSON: " + jsonErr.Error()
+			case err != nil:
+				a.Message = err.Error()
+			default:
+				a.Actual = got
+				a.Passed = reflect.DeepEqual(got, want)
+				if !a.Passed {
+					a.Message = fmt.Sprintf("%s is %s, expected %s", path, compact(got), compact(want))
+				}
+			}
+			assertions = append(assertions, a)
+		}
+	}
+	return assertions
+}
+
+// Lookup resolves a dotted path in a decoded JSON document. Numeric segments
+// index arrays; an empty path or "$" is the document itself.
+func Lookup(doc interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return doc, nil
+	}
+	current := doc
+	for i, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("%s not found", strings.Join(strings.Split(path, ".")[:i+1], "."))
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("%s: no element %q in an array of %d", path, segment, len(node))
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("%s: %s is not an object or array", path, strings.Join(strings.Split(path, ".")[:i], "."))
+		}
+	}
+	return current, nil
+}
+
+// normalize converts an expected value to the types encoding/json decodes
+// into, so it compares equal to the decoded response
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+func compact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func validMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,147 @@
+package httpcheck
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func noSleep(ctx context.Context, d time.Duration) error { return ctx.Err() }
+
+func TestRunRetriesUntilHealthy(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"status":"ok","checks":[{"name":"db","up":true}],"version":2}`)
+	}))
+	defer srv.Close()
+
+	c := NewChecker()
+	var delays []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	result, err := c.Run(context.Background(), Request{
+		URL:        srv.URL + "/healthz",
+		Retries:    5,
+		ExpectBody: `"ok"`,
+		ExpectJSON: map[string]interface{}{"status": "ok", "checks.0.up": true, "version": 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Passed || result.Status != 200 || len(result.Attempts) != 3 || result.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result.Attempts[0].Status != 503 || !strings.Contains(result.Attempts[0].Error, "status 503") {
+		t.Errorf("unexpected first attempt %+v", result.Attempts[0])
+	}
+	if len(delays) != 2 || delays[0] != defaultBackoff || delays[1] != 2*defaultBackoff {
+		t.Errorf("expected exponential backoff, got %v", delays)
+	}
+	if len(result.Assertions) != 5 {
+		t.Errorf("expected status, body and 3 json assertions, got %+v", result.Assertions)
+	}
+}
+
+func TestRunReportsFailedAssertions(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"id":7,"items":[]}`)
+	}))
+	defer srv.Close()
+
+	c := NewChecker()
+	c.sleep = noSleep
+	result, err := c.Run(context.Background(), Request{
+		URL:          srv.URL + "/items",
+		Method:       "post",
+		Headers:      map[string]string{"Authorization": "Bearer t"},
+		Body:         `{"name":"x"}`,
+		ExpectStatus: []int{201},
+		ExpectJSON:   map[string]interface{}{"id": 8, "items.0": "x", "missing.key": 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != http.MethodPost || got.Header.Get("Authorization") != "Bearer t" || got.Header.Get("Content-Type") != "application/json" || gotBody != `{"name":"x"}` {
+		t.Errorf("unexpected request %s %v %q", got.Method, got.Header, gotBody)
+	}
+	if result.Passed || len(result.Attempts) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	messages := map[string]string{}
+	for _, a := range result.Assertions {
+		messages[a.Check] = a.Message
+		if a.Check == "status" && !a.Passed {
+			t.Errorf("status 201 should pass: %+v", a)
+		}
+	}
+	for check, want := range map[string]string{
+		"json:id":          "id is 7, expected 8",
+		"json:items.0":     `no element "0" in an array of 0`,
+		"json:missing.key": "missing not found",
+	} {
+		if !strings.Contains(messages[check], want) {
+			t.Errorf("%s message = %q, want %q", check, messages[check], want)
+		}
+	}
+}
+
+func TestRunConnectionError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	addr := srv.URL
+	srv.Close()
+
+	c := NewChecker()
+	c.sleep = noSleep
+	result, err := c.Run(context.Background(), Request{URL: addr, Retries: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Passed || len(result.Attempts) != 3 || result.Error == "" || result.Status != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, bad := range []Request{
+		{URL: "file:///etc/passwd"},
+		{URL: "not a url"},
+		{URL: "http://svc", Method: "TRACE"},
+		{URL: "http://svc", Retries: MaxRetries + 1},
+		{URL: "http://svc", ExpectStatus: []int{42}},
+	} {
+		if err := Validate(bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	doc := map[string]interface{}{"a": []interface{}{map[string]interface{}{"b": "c"}}}
+	if v, err := Lookup(doc, "$.a.0.b"); err != nil || v != "c" {
+		t.Errorf("Lookup() = %v, %v", v, err)
+	}
+	if v, _ := Lookup(doc, ""); v == nil {
+		t.Error("empty path should return the document")
+	}
+	if _, err := Lookup(doc, "a.0.b.c"); err == nil {
+		t.Error("expected indexing a string to fail")
+	}
+}
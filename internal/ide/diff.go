@@ -0,0 +1,179 @@
+package ide
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Hunk is one hunk of a file's working tree diff
+type Hunk struct {
+	ID       string   `json:"id"`     // Derived from the hunk's lines, so it survives edits elsewhere in the file
+	Header   string   `json:"header"` // The @@ line
+	OldStart int      `json:"old_start"`
+	OldLines int      `json:"old_lines"`
+	NewStart int      `json:"new_start"`
+	NewLines int      `json:"new_lines"`
+	Lines    []string `json:"lines"`
+}
+
+// FileDiff is a file's uncommitted changes split into hunks
+type FileDiff struct {
+	Path    string `json:"path"`
+	Diff    string `json:"diff"`
+	Hunks   []Hunk `json:"hunks"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+
+	header string // diff --git, --- and +++ lines, needed to apply a single hunk
+}
+
+// ParseDiff splits the unified diff of a single file into its hunks
+func ParseDiff(path, diff string) *FileDiff {
+	fd := &FileDiff{Path: path, Diff: diff}
+	var header []string
+	var current *Hunk
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		if strings.HasPrefix(line, "@@") {
+			if current != nil {
+				fd.Hunks = append(fd.Hunks, finishHunk(*current))
+			}
+			current = &Hunk{Header: line}
+			current.OldStart, current.OldLines, current.NewStart, current.NewLines = parseHunkHeader(line)
+			continue
+		}
+		if current == nil {
+			if line != "" {
+				header = append(header, line)
+			}
+			continue
+		}
+		current.Lines = append(current.Lines, line)
+		switch {
+		case strings.HasPrefix(line, "+"):
+			fd.Added++
+		case strings.HasPrefix(line, "-"):
+			fd.Removed++
+		}
+	}
+	if current != nil {
+		fd.Hunks = append(fd.Hunks, finishHunk(*current))
+	}
+	if len(header) > 0 {
+		fd.header = strings.Join(header, "\n") + "\n"
+	}
+	return fd
+}
+
+func finishHunk(h Hunk) Hunk {
+	sum := sha1.Sum([]byte(strings.Join(h.Lines, "\n")))
+	h.ID = hex.EncodeToString(sum[:6])
+	return h
+}
+
+// parseHunkHeader reads "@@ -a,b +c,d @@"; omitted counts are 1
+func parseHunkHeader(line string) (oldStart, oldLines, newStart, newLines int) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return
+	}
+	oldStart, oldLines = parseRange(strings.TrimPrefix(fields[1], "-"))
+	newStart, newLines = parseRange(strings.TrimPrefix(fields[2], "+"))
+	return
+}
+
+func parseRange(s string) (start, count int) {
+	count = 1
+	if i := strings.IndexByte(s, ','); i >= 0 {
+		count, _ = strconv.Atoi(s[i+1:])
+		s = s[:i]
+	}
+	start, _ = strconv.Atoi(s)
+	return
+}
+
+// Hunk returns the hunk with the given ID
+func (fd *FileDiff) Hunk(id string) (Hunk, bool) {
+	for _, h := range fd.Hunks {
+		if h.ID == id {
+			return h, true
+		}
+	}
+	return Hunk{}, false
+}
+
+// hunkPatch is a patch holding only the given hunk of the file
+func (fd *FileDiff) hunkPatch(h Hunk) string {
+	var b strings.Builder
+	b.WriteString(fd.header)
+	b.WriteString(h.Header)
+	b.WriteByte('\n')
+	for _, line := range h.Lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// diffFile returns the uncommitted changes of a file in a git work tree.
+// Files git does not track yet are diffed against an empty file.
+func diffFile(ctx context.Context, workDir, path string) (*FileDiff, error) {
+	out, err := runGit(ctx, workDir, nil, "diff", "--no-color", "--no-ext-diff", "HEAD", "--", path)
+	if err != nil {
+		// Repositories without commits have no HEAD; compare with the index
+		out, err = runGit(ctx, workDir, nil, "diff", "--no-color", "--no-ext-diff", "--", path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if out == "" && !tracked(ctx, workDir, path) {
+		if _, statErr := os.Stat(filepath.Join(workDir, path)); statErr == nil {
+			// --no-index exits 1 when the files differ
+			out, err = runGit(ctx, workDir, nil, "diff", "--no-color", "--no-ext-diff", "--no-index", "--", os.DevNull, path)
+			var exitErr *exec.ExitError
+			if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+				return nil, err
+			}
+		}
+	}
+	return ParseDiff(path, out), nil
+}
+
+func tracked(ctx context.Context, workDir, path string) bool {
+	_, err := runGit(ctx, workDir, nil, "ls-files", "--error-unmatch", "--", path)
+	return err == nil
+}
+
+// revertHunk reverse-applies a single hunk to the work tree
+func revertHunk(ctx context.Context, workDir string, fd *FileDiff, h Hunk) error {
+	if _, err := runGit(ctx, workDir, strings.NewReader(fd.hunkPatch(h)), "apply", "--reverse", "--whitespace=nowarn", "-"); err != nil {
+		return fmt.Errorf("revert hunk: %w", err)
+	}
+	return nil
+}
+
+func runGit(ctx context.Context, workDir string, stdin *strings.Reader, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = workDir
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.String(), fmt.Errorf("%w: %s", err, msg)
+		}
+		return stdout.String(), err
+	}
+	return stdout.String(), nil
+}
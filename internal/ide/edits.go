@@ -0,0 +1,51 @@
+package ide
+
+import (
+	"path"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+)
+
+// EditedPaths returns the project-relative files an executed action changed,
+// or nil for actions that do not edit files
+func EditedPaths(action actions.Action) []string {
+	switch action.Type {
+	case actions.ActionWriteFile, actions.ActionEditCode, actions.ActionMultiEdit, actions.ActionDeleteFile,
+		actions.ActionAddImport, actions.ActionInsertFunctionAfter, actions.ActionAddStructField:
+		if action.Path != "" {
+			return []string{cleanPath(action.Path)}
+		}
+	case actions.ActionMoveFile:
+		return nonEmpty(cleanPath(action.SourcePath), cleanPath(action.TargetPath))
+	case actions.ActionRenameFile:
+		if action.SourcePath != "" && action.NewName != "" {
+			source := cleanPath(action.SourcePath)
+			return nonEmpty(source, cleanPath(path.Join(path.Dir(source), action.NewName)))
+		}
+	case actions.ActionApplyPatch:
+		return actions.PatchPaths(action.Patch)
+	}
+	return nil
+}
+
+func cleanPath(p string) string {
+	if p == "" {
+		return ""
+	}
+	p = path.Clean(strings.TrimPrefix(p, "./"))
+	if p == "." {
+		return ""
+	}
+	return p
+}
+
+func nonEmpty(paths ...string) []string {
+	var out []string
+	for _, p := range paths {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,227 @@
+package ide
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type recordingPublisher struct{ events []*eventbus.Event }
+
+func (p *recordingPublisher) Publish(event *eventbus.Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+type memoryConversations struct{ conv *models.ConversationContext }
+
+func (m *memoryConversations) GetConversationContextByBeadID(beadID string) (*models.ConversationContext, error) {
+	return m.conv, nil
+}
+
+func (m *memoryConversations) UpdateConversationContext(ctx *models.ConversationContext) error {
+	m.conv = ctx
+	return nil
+}
+
+const original = "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve\n"
+
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	writeFile(t, dir, "list.txt", original)
+	git("add", ".")
+	git("commit", "-qm", "initial")
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTrackerRecordEditAndReject(t *testing.T) {
+	dir := newRepo(t)
+	events := &recordingPublisher{}
+	conversations := &memoryConversations{conv: &models.ConversationContext{SessionID: "s1", BeadID: "bd-1"}}
+	tracker := NewTracker(toolrun.StaticWorkDir(dir), events, conversations)
+	ctx := context.Background()
+
+	edited := strings.Replace(strings.Replace(original, "two\n", "TWO\n", 1), "eleven\n", "ELEVEN\n", 1)
+	writeFile(t, dir, "list.txt", edited)
+	writeFile(t, dir, "new.txt", "fresh\n")
+	tracker.RecordEdit(ctx, Edit{ProjectID: "p1", BeadID: "bd-1", AgentID: "a1", ActionType: actions.ActionWriteFile, Paths: []string{"list.txt", "new.txt"}})
+
+	state, ok := tracker.File("bd-1", "list.txt")
+	if !ok || len(state.Hunks) != 2 || state.Added != 2 || state.Removed != 2 {
+		t.Fatalf("expected two hunks, got %+v", state)
+	}
+	if created, ok := tracker.File("bd-1", "new.txt"); !ok || created.Added != 1 {
+		t.Errorf("expected the untracked file to be diffed, got %+v", created)
+	}
+	if len(events.events) != 2 || events.events[0].Type != eventbus.EventTypeFileChanged || events.events[0].Data["path"] != "list.txt" {
+		t.Errorf("unexpected events %+v", events.events)
+	}
+	if editing := tracker.Editing("p1", "list.txt"); len(editing) != 1 || editing[0].BeadID != "bd-1" {
+		t.Errorf("unexpected editing beads %+v", editing)
+	}
+
+	rejected := state.Hunks[1]
+	if !strings.Contains(strings.Join(rejected.Lines, "\n"), "+ELEVEN") {
+		t.Fatalf("expected the second hunk to change eleven, got %v", rejected.Lines)
+	}
+	after, err := tracker.ApplyFeedback(ctx, Feedback{BeadID: "bd-1", Path: "list.txt", HunkID: rejected.ID, Verdict: VerdictReject, Comment: "keep eleven lowercase"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after.Hunks) != 1 || after.Hunks[0].ID != state.Hunks[0].ID {
+		t.Errorf("expected only the first hunk to remain, got %+v", after.Hunks)
+	}
+	if content := readFile(t, dir, "list.txt"); !strings.Contains(content, "TWO\n") || !strings.Contains(content, "\neleven\n") {
+		t.Errorf("expected only the rejected hunk to be reverted:\n%s", content)
+	}
+	msgs := conversations.conv.Messages
+	if len(msgs) != 1 || msgs[0].Role != "user" || !strings.Contains(msgs[0].Content, "rejected this change to list.txt") || !strings.Contains(msgs[0].Content, "keep eleven lowercase") {
+		t.Errorf("unexpected agent feedback %+v", msgs)
+	}
+	last := events.events[len(events.events)-2:]
+	if last[0].Type != eventbus.EventTypeFileFeedback || last[0].Data["verdict"] != VerdictReject || last[1].Type != eventbus.EventTypeFileChanged {
+		t.Errorf("expected feedback and change events, got %+v", last)
+	}
+
+	if _, err := tracker.ApplyFeedback(ctx, Feedback{BeadID: "bd-1", Path: "list.txt", HunkID: rejected.ID, Verdict: VerdictReject}); err != ErrHunkNotFound {
+		t.Errorf("expected a stale hunk to be reported, got %v", err)
+	}
+	if _, err := tracker.ApplyFeedback(ctx, Feedback{BeadID: "bd-1", Path: "other.txt", HunkID: "x", Verdict: VerdictReject}); err != ErrFileNotTracked {
+		t.Errorf("expected an untracked file to be reported, got %v", err)
+	}
+	if _, err := tracker.ApplyFeedback(ctx, Feedback{BeadID: "bd-1", Path: "list.txt", HunkID: state.Hunks[0].ID, Verdict: VerdictComment}); err == nil {
+		t.Error("expected a comment without text to be rejected")
+	}
+
+	// Undoing the remaining change stops tracking the file
+	writeFile(t, dir, "list.txt", original)
+	tracker.RecordEdit(ctx, Edit{ProjectID: "p1", BeadID: "bd-1", Paths: []string{"list.txt"}})
+	if _, ok := tracker.File("bd-1", "list.txt"); ok {
+		t.Error("expected a clean file to stop being tracked")
+	}
+}
+
+func TestTrackerFilesOrder(t *testing.T) {
+	dir := newRepo(t)
+	tracker := NewTracker(toolrun.StaticWorkDir(dir), nil, nil)
+	now := time.Unix(1000, 0)
+	tracker.now = func() time.Time { return now }
+
+	writeFile(t, dir, "a.txt", "a\n")
+	tracker.RecordEdit(context.Background(), Edit{ProjectID: "p1", BeadID: "bd-1", Paths: []string{"a.txt"}})
+	now = now.Add(time.Second)
+	writeFile(t, dir, "b.txt", "b\n")
+	tracker.RecordEdit(context.Background(), Edit{ProjectID: "p1", BeadID: "bd-1", Paths: []string{"b.txt"}})
+
+	files := tracker.Files("bd-1")
+	if len(files) != 2 || files[0].Path != "b.txt" || files[1].Path != "a.txt" {
+		t.Errorf("expected most recent first, got %+v", files)
+	}
+}
+
+func TestParseDiff(t *testing.T) {
+	diff := "diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -1 +1,2 @@ func main\n-old\n+new\n+more\n@@ -10,3 +11,3 @@\n a\n-b\n+c\n"
+	fd := ParseDiff("x", diff)
+	if len(fd.Hunks) != 2 || fd.Added != 3 || fd.Removed != 2 {
+		t.Fatalf("unexpected parse %+v", fd)
+	}
+	h := fd.Hunks[0]
+	if h.OldStart != 1 || h.OldLines != 1 || h.NewStart != 1 || h.NewLines != 2 || len(h.Lines) != 3 {
+		t.Errorf("unexpected first hunk %+v", h)
+	}
+	if got := fd.hunkPatch(fd.Hunks[1]); got != "diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -10,3 +11,3 @@\n a\n-b\n+c\n" {
+		t.Errorf("unexpected hunk patch:\n%s", got)
+	}
+	if fd.Hunks[0].ID == fd.Hunks[1].ID {
+		t.Error("expected distinct hunk IDs")
+	}
+}
+
+func TestEditedPaths(t *testing.T) {
+	cases := []struct {
+		action actions.Action
+		want   []string
+	}{
+		{actions.Action{Type: actions.ActionWriteFile, Path: "./src/main.go"}, []string{"src/main.go"}},
+		{actions.Action{Type: actions.ActionMoveFile, SourcePath: "a.go", TargetPath: "pkg/a.go"}, []string{"a.go", "pkg/a.go"}},
+		{actions.Action{Type: actions.ActionRenameFile, SourcePath: "pkg/a.go", NewName: "b.go"}, []string{"pkg/a.go", "pkg/b.go"}},
+		{actions.Action{Type: actions.ActionApplyPatch, Patch: "--- a/x.go\n+++ b/x.go\n@@ -1 +1 @@\n-a\n+b\n--- /dev/null\n+++ b/y.go\t2024-01-01\n"}, []string{"x.go", "y.go"}},
+		{actions.Action{Type: actions.ActionReadFile, Path: "x.go"}, nil},
+	}
+	for _, c := range cases {
+		if got := EditedPaths(c.action); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.action.Type, c.want, got)
+		}
+	}
+}
+
+func TestPairings(t *testing.T) {
+	p := NewPairings()
+	now := time.Unix(1000, 0)
+	p.now = func() time.Time { return now }
+
+	pairing, secret := p.Start("vscode")
+	if pairing.Status != PairingPending || len(pairing.Code) != 9 || secret == "" {
+		t.Fatalf("unexpected pairing %+v", pairing)
+	}
+	if _, err := p.Poll(pairing.ID, "wrong"); err != ErrPairingNotFound {
+		t.Errorf("expected a wrong secret to be refused, got %v", err)
+	}
+	if polled, err := p.Poll(pairing.ID, secret); err != nil || polled.Status != PairingPending {
+		t.Errorf("expected pending, got %+v, %v", polled, err)
+	}
+
+	if _, err := p.Approve(strings.ToLower(strings.ReplaceAll(pairing.Code, "-", "")), "u1"); err != nil {
+		t.Fatalf("expected the code to match loosely, got %v", err)
+	}
+	if claimed, err := p.Poll(pairing.ID, secret); err != nil || claimed.Status != PairingApproved || claimed.UserID != "u1" {
+		t.Errorf("expected the approved pairing, got %+v, %v", claimed, err)
+	}
+	if _, err := p.Poll(pairing.ID, secret); err != ErrPairingClaimed {
+		t.Errorf("expected a second claim to be refused, got %v", err)
+	}
+
+	expiring, _ := p.Start("vim")
+	now = now.Add(DefaultPairingTTL + time.Second)
+	if _, err := p.Approve(expiring.Code, "u1"); err != ErrPairingNotFound {
+		t.Errorf("expected an expired pairing to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package ide
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pairing statuses
+const (
+	PairingPending  = "pending"
+	PairingApproved = "approved"
+	PairingClaimed  = "claimed"
+)
+
+const (
+	// DefaultPairingTTL is how long an editor has to be approved
+	DefaultPairingTTL = 10 * time.Minute
+	// codeAlphabet leaves out characters that are easy to misread
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	// ErrPairingNotFound is returned for unknown or expired pairings
+	ErrPairingNotFound = errors.New("pairing not found or expired")
+	// ErrPairingClaimed is returned once an approved pairing's credential
+	// has been handed out
+	ErrPairingClaimed = errors.New("pairing already claimed")
+)
+
+// Pairing is an editor waiting to be linked to a user account. The editor
+// shows Code to its user, who approves it from an authenticated session;
+// the editor polls with Secret until it can claim its credential.
+type Pairing struct {
+	ID        string    `json:"pairing_id"`
+	Code      string    `json:"code"`
+	Client    string    `json:"client"`
+	Status    string    `json:"status"`
+	UserID    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	secret string
+}
+
+// Pairings is the in-memory handshake state for local editors
+type Pairings struct {
+	TTL time.Duration
+
+	mu    sync.Mutex
+	items map[string]*Pairing
+	now   func() time.Time
+}
+
+// NewPairings creates an empty pairing store
+func NewPairings() *Pairings {
+	return &Pairings{TTL: DefaultPairingTTL, items: make(map[string]*Pairing), now: time.Now}
+}
+
+// Start opens a pairing for an editor and returns it with the secret the
+// editor polls with
+func (p *Pairings) Start(client string) (Pairing, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expireLocked()
+
+	now := p.now()
+	pairing := &Pairing{
+		ID:        randomHex(8),
+		Code:      pairingCode(),
+		Client:    client,
+		Status:    PairingPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(p.TTL),
+		secret:    randomHex(32),
+	}
+	p.items[pairing.ID] = pairing
+	return *pairing, pairing.secret
+}
+
+// Approve links the pending pairing with the given code to a user
+func (p *Pairings) Approve(code, userID string) (Pairing, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expireLocked()
+
+	code = normalizeCode(code)
+	for _, pairing := range p.items {
+		if pairing.Status == PairingPending && normalizeCode(pairing.Code) == code {
+			pairing.Status = PairingApproved
+			pairing.UserID = userID
+			return *pairing, nil
+		}
+	}
+	return Pairing{}, ErrPairingNotFound
+}
+
+// Poll reports a pairing's status to the editor that started it. The first
+// poll after approval claims the pairing; only that poll returns it with
+// the approving user's ID set.
+func (p *Pairings) Poll(id, secret string) (Pairing, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expireLocked()
+
+	pairing, ok := p.items[id]
+	if !ok || subtle.ConstantTimeCompare([]byte(pairing.secret), []byte(secret)) != 1 {
+		return Pairing{}, ErrPairingNotFound
+	}
+	switch pairing.Status {
+	case PairingClaimed:
+		return Pairing{}, ErrPairingClaimed
+	case PairingApproved:
+		claimed := *pairing
+		pairing.Status = PairingClaimed
+		return claimed, nil
+	}
+	return *pairing, nil
+}
+
+func (p *Pairings) expireLocked() {
+	now := p.now()
+	for id, pairing := range p.items {
+		if now.After(pairing.ExpiresAt) {
+			delete(p.items, id)
+		}
+	}
+}
+
+// pairingCode returns a code like "K7QD-9MWX"
+func pairingCode() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	code := make([]byte, 0, 9)
+	for i, b := range buf {
+		if i == 4 {
+			code = append(code, '-')
+		}
+		code = append(code, codeAlphabet[int(b)%len(codeAlphabet)])
+	}
+	return string(code)
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
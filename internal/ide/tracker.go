@@ -0,0 +1,296 @@
+// Package ide backs editor plugins. It follows the files agents edit for each
+// bead and publishes their live diffs on the event bus, applies quick human
+// feedback on individual hunks, and pairs local editors with a user account.
+package ide
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var ideLog = logging.Module("ide")
+
+// Feedback verdicts
+const (
+	VerdictReject  = "reject"
+	VerdictComment = "comment"
+)
+
+var (
+	// ErrFileNotTracked is returned for files no agent has edited for the bead
+	ErrFileNotTracked = errors.New("file has no agent edits for this bead")
+	// ErrHunkNotFound is returned when the hunk is no longer in the file's diff
+	ErrHunkNotFound = errors.New("hunk not found in the current diff")
+)
+
+// Publisher delivers editor events to subscribers
+type Publisher interface {
+	Publish(event *eventbus.Event) error
+}
+
+// ConversationStore appends feedback to a bead's agent conversation
+type ConversationStore interface {
+	GetConversationContextByBeadID(beadID string) (*models.ConversationContext, error)
+	UpdateConversationContext(ctx *models.ConversationContext) error
+}
+
+// Edit describes an agent action that changed files
+type Edit struct {
+	ProjectID  string
+	BeadID     string
+	AgentID    string
+	ActionType string
+	Paths      []string
+}
+
+// FileState is the current diff of a file an agent edited for a bead
+type FileState struct {
+	ProjectID  string    `json:"project_id"`
+	BeadID     string    `json:"bead_id"`
+	AgentID    string    `json:"agent_id,omitempty"`
+	ActionType string    `json:"action_type,omitempty"` // Last action that changed the file
+	UpdatedAt  time.Time `json:"updated_at"`
+	*FileDiff
+}
+
+// Feedback is a human verdict on one hunk of an agent's edit
+type Feedback struct {
+	BeadID  string `json:"bead_id"`
+	Path    string `json:"path"`
+	HunkID  string `json:"hunk_id"`
+	Verdict string `json:"verdict"` // reject (revert the hunk) or comment
+	Comment string `json:"comment,omitempty"`
+	UserID  string `json:"user_id,omitempty"`
+}
+
+// Tracker follows the files agents edit for each bead
+type Tracker struct {
+	WorkDirs      files.WorkDirResolver
+	Events        Publisher
+	Conversations ConversationStore
+
+	mu    sync.Mutex
+	beads map[string]map[string]*FileState // bead ID -> path -> state
+	now   func() time.Time
+}
+
+// NewTracker creates a tracker. Events and conversations may be nil.
+func NewTracker(workDirs files.WorkDirResolver, events Publisher, conversations ConversationStore) *Tracker {
+	return &Tracker{
+		WorkDirs:      workDirs,
+		Events:        events,
+		Conversations: conversations,
+		beads:         make(map[string]map[string]*FileState),
+		now:           time.Now,
+	}
+}
+
+// RecordEdit refreshes the diffs of the edited files and publishes them.
+// Files whose changes were all undone stop being tracked.
+func (t *Tracker) RecordEdit(ctx context.Context, edit Edit) {
+	if t == nil || edit.BeadID == "" || edit.ProjectID == "" || t.WorkDirs == nil {
+		return
+	}
+	workDir := t.WorkDirs.GetProjectWorkDir(edit.ProjectID)
+	for _, path := range edit.Paths {
+		fd, err := diffFile(ctx, workDir, path)
+		if err != nil {
+			ideLog.Warn("Failed to diff file", "path", path, "bead_id", edit.BeadID, "error", err)
+			continue
+		}
+		state := &FileState{
+			ProjectID:  edit.ProjectID,
+			BeadID:     edit.BeadID,
+			AgentID:    edit.AgentID,
+			ActionType: edit.ActionType,
+			UpdatedAt:  t.now(),
+			FileDiff:   fd,
+		}
+		t.store(state)
+		t.publish(eventbus.EventTypeFileChanged, state, nil)
+	}
+}
+
+func (t *Tracker) store(state *FileState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	files := t.beads[state.BeadID]
+	if len(state.Hunks) == 0 {
+		delete(files, state.Path)
+		if len(files) == 0 {
+			delete(t.beads, state.BeadID)
+		}
+		return
+	}
+	if files == nil {
+		files = make(map[string]*FileState)
+		t.beads[state.BeadID] = files
+	}
+	files[state.Path] = state
+}
+
+// Files returns the files with agent edits for a bead, most recently
+// changed first
+func (t *Tracker) Files(beadID string) []FileState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]FileState, 0, len(t.beads[beadID]))
+	for _, state := range t.beads[beadID] {
+		out = append(out, *state)
+	}
+	sortByRecency(out)
+	return out
+}
+
+// File returns the current state of one file edited for a bead
+func (t *Tracker) File(beadID, path string) (FileState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.beads[beadID][path]
+	if !ok {
+		return FileState{}, false
+	}
+	return *state, true
+}
+
+// Editing returns the beads with agent edits to a file of a project, most
+// recently changed first
+func (t *Tracker) Editing(projectID, path string) []FileState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []FileState
+	for _, files := range t.beads {
+		if state, ok := files[path]; ok && state.ProjectID == projectID {
+			out = append(out, *state)
+		}
+	}
+	sortByRecency(out)
+	return out
+}
+
+func sortByRecency(states []FileState) {
+	sort.Slice(states, func(i, j int) bool {
+		if !states[i].UpdatedAt.Equal(states[j].UpdatedAt) {
+			return states[i].UpdatedAt.After(states[j].UpdatedAt)
+		}
+		return states[i].Path < states[j].Path
+	})
+}
+
+// ApplyFeedback records a human verdict on a hunk. A rejected hunk is
+// reverted in the work tree. Either way the agent working on the bead is
+// told through its conversation, and the file's new state is returned.
+func (t *Tracker) ApplyFeedback(ctx context.Context, fb Feedback) (*FileState, error) {
+	switch fb.Verdict {
+	case VerdictReject:
+	case VerdictComment:
+		if strings.TrimSpace(fb.Comment) == "" {
+			return nil, fmt.Errorf("comment is required")
+		}
+	default:
+		return nil, fmt.Errorf("unknown verdict %q (want %s or %s)", fb.Verdict, VerdictReject, VerdictComment)
+	}
+
+	state, ok := t.File(fb.BeadID, fb.Path)
+	if !ok {
+		return nil, ErrFileNotTracked
+	}
+	workDir := t.WorkDirs.GetProjectWorkDir(state.ProjectID)
+	fd, err := diffFile(ctx, workDir, fb.Path)
+	if err != nil {
+		return nil, err
+	}
+	hunk, ok := fd.Hunk(fb.HunkID)
+	if !ok {
+		return nil, ErrHunkNotFound
+	}
+
+	if fb.Verdict == VerdictReject {
+		if err := revertHunk(ctx, workDir, fd, hunk); err != nil {
+			return nil, err
+		}
+		if fd, err = diffFile(ctx, workDir, fb.Path); err != nil {
+			return nil, err
+		}
+	}
+	state.FileDiff = fd
+	state.UpdatedAt = t.now()
+	t.store(&state)
+
+	t.notifyAgent(fb, hunk)
+	t.publish(eventbus.EventTypeFileFeedback, &state, map[string]interface{}{
+		"hunk_id": fb.HunkID,
+		"verdict": fb.Verdict,
+		"comment": fb.Comment,
+		"user_id": fb.UserID,
+	})
+	t.publish(eventbus.EventTypeFileChanged, &state, nil)
+	return &state, nil
+}
+
+// notifyAgent appends the feedback to the bead's conversation so the agent
+// sees it on its next turn
+func (t *Tracker) notifyAgent(fb Feedback, hunk Hunk) {
+	if t.Conversations == nil {
+		return
+	}
+	conv, err := t.Conversations.GetConversationContextByBeadID(fb.BeadID)
+	if err != nil || conv == nil {
+		return
+	}
+	var b strings.Builder
+	if fb.Verdict == VerdictReject {
+		fmt.Fprintf(&b, "A reviewer rejected this change to %s and it was reverted in the working tree:\n", fb.Path)
+	} else {
+		fmt.Fprintf(&b, "A reviewer commented on this change to %s:\n", fb.Path)
+	}
+	fmt.Fprintf(&b, "```diff\n%s\n%s\n```\n", hunk.Header, strings.Join(hunk.Lines, "\n"))
+	if fb.Comment != "" {
+		fmt.Fprintf(&b, "Reviewer: %s\n", fb.Comment)
+	}
+	if fb.Verdict == VerdictReject {
+		b.WriteString("Do not reapply the change without addressing the feedback.")
+	}
+	message := b.String()
+	conv.AddMessage("user", message, len(message)/4)
+	if err := t.Conversations.UpdateConversationContext(conv); err != nil {
+		ideLog.Error("Failed to append feedback to conversation", "session_id", conv.SessionID, "error", err)
+	}
+}
+
+func (t *Tracker) publish(eventType eventbus.EventType, state *FileState, extra map[string]interface{}) {
+	if t.Events == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"bead_id":     state.BeadID,
+		"agent_id":    state.AgentID,
+		"path":        state.Path,
+		"action_type": state.ActionType,
+		"diff":        state.Diff,
+		"hunks":       state.Hunks,
+		"added":       state.Added,
+		"removed":     state.Removed,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	if err := t.Events.Publish(&eventbus.Event{
+		Type:      eventType,
+		Source:    "ide",
+		ProjectID: state.ProjectID,
+		Data:      data,
+	}); err != nil {
+		ideLog.Warn("Failed to publish event", "event_type", eventType, "path", state.Path, "error", err)
+	}
+}
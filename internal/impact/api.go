@@ -0,0 +1,91 @@
+package impact
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/repomap"
+)
+
+// apiChanges compares the public declarations of the changed files with
+// those at base. Test files and files in languages the repository map
+// doesn't cover are skipped; a file missing at base counts as added, and
+// one missing from the checkout as removed.
+func apiChanges(ctx context.Context, workDir, base string, files []string) []APIChange {
+	var changes []APIChange
+	for _, f := range files {
+		if isTestFile(f) || repomap.Language(f) == "" {
+			continue
+		}
+		before, _ := git(ctx, workDir, "show", base+":"+f)
+		after, _ := os.ReadFile(filepath.Join(workDir, filepath.FromSlash(f)))
+		changes = append(changes, diffSymbols(f, repomap.FileSymbols(f, []byte(before)), repomap.FileSymbols(f, after))...)
+	}
+	return changes
+}
+
+// diffSymbols lists the declarations added, removed or changed between two
+// versions of a file
+func diffSymbols(file string, before, after []repomap.Symbol) []APIChange {
+	old := make(map[string]string, len(before))
+	for _, s := range before {
+		old[s.Name] = s.String()
+	}
+	var changes []APIChange
+	seen := make(map[string]bool, len(after))
+	for _, s := range after {
+		seen[s.Name] = true
+		decl := s.String()
+		switch prev, ok := old[s.Name]; {
+		case !ok:
+			changes = append(changes, APIChange{Path: file, Symbol: s.Name, Change: "added", After: decl})
+		case prev != decl:
+			changes = append(changes, APIChange{Path: file, Symbol: s.Name, Change: "changed", Before: prev, After: decl})
+		}
+	}
+	for _, s := range before {
+		if !seen[s.Name] {
+			seen[s.Name] = true
+			changes = append(changes, APIChange{Path: file, Symbol: s.Name, Change: "removed", Before: s.String()})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Symbol < changes[j].Symbol })
+	return changes
+}
+
+// changedFiles lists the files differing from base, tracked or untracked
+func changedFiles(ctx context.Context, workDir, base string) ([]string, error) {
+	diff, err := git(ctx, workDir, "diff", "--name-only", "--no-renames", base, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(ctx, workDir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, line := range strings.Split(diff+untracked, "\n") {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+func git(ctx context.Context, workDir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = workDir
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,275 @@
+package impact
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxFiles caps how many files a scan of the checkout visits
+const maxFiles = 20000
+
+// skipDirs hold dependencies, fixtures or build output rather than the
+// project's own code
+var skipDirs = map[string]bool{
+	"vendor": true, "node_modules": true, "testdata": true, "dist": true,
+	"build": true, "target": true, "__pycache__": true, "venv": true,
+}
+
+// goPackage is a directory of Go files and the import paths they use
+type goPackage struct {
+	imports     map[string]bool
+	testImports map[string]bool
+	hasTests    bool
+}
+
+// tree is the dependency structure of a checkout: Go packages linked by
+// their imports, and other languages' test files by the name of the file
+// they test
+type tree struct {
+	goPackages map[string]*goPackage
+	// dependents maps a Go package directory to those importing it, and
+	// testDependents to those whose tests import it
+	dependents     map[string][]string
+	testDependents map[string][]string
+	// testFiles maps the stem of a file under test, e.g. "parser" for
+	// test_parser.py or parser.spec.ts, to its test files
+	testFiles map[string][]string
+	truncated bool
+}
+
+// scan walks the checkout under root and links its packages
+func scan(ctx context.Context, root string) (*tree, error) {
+	t := &tree{
+		goPackages:     make(map[string]*goPackage),
+		dependents:     make(map[string][]string),
+		testDependents: make(map[string][]string),
+		testFiles:      make(map[string][]string),
+	}
+	modules := make(map[string]string) // directory -> module path
+	files := 0
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || skipDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if files++; files > maxFiles {
+			t.truncated = true
+			return filepath.SkipAll
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		dir := path.Dir(rel)
+		switch {
+		case name == "go.mod":
+			if module := modulePath(p); module != "" {
+				modules[dir] = module
+			}
+		case strings.HasSuffix(name, ".go"):
+			t.addGoFile(p, dir, strings.HasSuffix(name, "_test.go"))
+		case isTestFile(rel):
+			stem := testSubject(name)
+			t.testFiles[stem] = append(t.testFiles[stem], rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	t.link(modules)
+	return t, nil
+}
+
+// addGoFile records the imports of a Go file
+func (t *tree) addGoFile(file, dir string, test bool) {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+	if err != nil {
+		return
+	}
+	pkg := t.goPackages[dir]
+	if pkg == nil {
+		pkg = &goPackage{imports: make(map[string]bool), testImports: make(map[string]bool)}
+		t.goPackages[dir] = pkg
+	}
+	pkg.hasTests = pkg.hasTests || test
+	for _, imp := range f.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if test {
+			pkg.testImports[importPath] = true
+		} else {
+			pkg.imports[importPath] = true
+		}
+	}
+}
+
+// link resolves import paths to package directories by the module each
+// directory belongs to, and inverts the imports
+func (t *tree) link(modules map[string]string) {
+	dirs := make(map[string]string) // import path -> directory
+	for dir := range t.goPackages {
+		if importPath := moduleImportPath(modules, dir); importPath != "" {
+			dirs[importPath] = dir
+		}
+	}
+	for dir, pkg := range t.goPackages {
+		for importPath := range pkg.imports {
+			if target, ok := dirs[importPath]; ok && target != dir {
+				t.dependents[target] = append(t.dependents[target], dir)
+			}
+		}
+		for importPath := range pkg.testImports {
+			if target, ok := dirs[importPath]; ok && target != dir && !pkg.imports[importPath] {
+				t.testDependents[target] = append(t.testDependents[target], dir)
+			}
+		}
+	}
+	for _, m := range []map[string][]string{t.dependents, t.testDependents} {
+		for _, list := range m {
+			sort.Strings(list)
+		}
+	}
+}
+
+// expand adds the packages depending on the affected ones and returns the
+// tests covering them all
+func (t *tree) expand(affected *affectedSet, changedFiles []string) []string {
+	queue := make([]string, 0, len(affected.packages))
+	for dir := range affected.packages {
+		queue = append(queue, dir)
+	}
+	sort.Strings(queue)
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		distance := affected.packages[dir].Distance + 1
+		for _, dependent := range t.dependents[dir] {
+			if affected.add(dependent, "go", "imports "+dir, distance) {
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	tests := make(map[string]bool)
+	for _, f := range affected.tests {
+		tests[f] = true
+	}
+	for dir, p := range affected.packages {
+		if pkg := t.goPackages[dir]; pkg != nil && pkg.hasTests {
+			tests[goTarget(dir)] = true
+		}
+		for _, dependent := range t.testDependents[dir] {
+			affected.add(dependent, "go", "tests import "+dir, p.Distance+1)
+			tests[goTarget(dependent)] = true
+		}
+	}
+	for _, f := range changedFiles {
+		if strings.HasSuffix(f, ".go") {
+			continue
+		}
+		if isTestFile(f) {
+			tests[f] = true
+			continue
+		}
+		name := path.Base(f)
+		stem := strings.TrimSuffix(name, path.Ext(name))
+		for _, test := range t.testFiles[stem] {
+			affected.add(path.Dir(test), "", "tests "+f, 1)
+			tests[test] = true
+		}
+	}
+
+	list := make([]string, 0, len(tests))
+	for test := range tests {
+		list = append(list, test)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// goTarget is the go test pattern of a package directory
+func goTarget(dir string) string {
+	if dir == "." {
+		return "."
+	}
+	return "./" + dir
+}
+
+// moduleImportPath derives a directory's import path from the nearest
+// enclosing module
+func moduleImportPath(modules map[string]string, dir string) string {
+	for d := dir; ; d = path.Dir(d) {
+		if module, ok := modules[d]; ok {
+			if d == dir {
+				return module
+			}
+			rel := strings.TrimPrefix(dir, d+"/")
+			if d == "." {
+				rel = dir
+			}
+			return module + "/" + rel
+		}
+		if d == "." {
+			return ""
+		}
+	}
+}
+
+// modulePath reads the module directive of a go.mod file
+func modulePath(file string) string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// isTestFile reports whether a path names a test file: Go _test.go files,
+// pytest's test_*.py and *_test.py, and JavaScript and TypeScript
+// *.test.* and *.spec.* files or files under __tests__
+func isTestFile(p string) bool {
+	name := path.Base(p)
+	switch path.Ext(name) {
+	case ".go":
+		return strings.HasSuffix(name, "_test.go")
+	case ".py":
+		return strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test.py")
+	case ".js", ".jsx", ".mjs", ".ts", ".tsx":
+		return strings.Contains(name, ".test.") || strings.Contains(name, ".spec.") || strings.Contains(p, "__tests__/")
+	}
+	return false
+}
+
+// testSubject is the stem of the file a test file tests, e.g. "parser"
+// for test_parser.py, parser_test.py or parser.spec.ts
+func testSubject(name string) string {
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	stem = strings.TrimSuffix(strings.TrimSuffix(stem, ".test"), ".spec")
+	return strings.TrimSuffix(strings.TrimPrefix(stem, "test_"), "_test")
+}
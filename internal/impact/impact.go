@@ -0,0 +1,311 @@
+// Package impact estimates what a change to a project affects: the
+// packages that depend on the changed files, the tests worth running, and
+// the public declarations added, removed or changed. Go packages are
+// followed through their imports; other languages through test file naming
+// and, when a language server is available, references to the changed
+// symbols. Agents and the workflow use the report to scope verification
+// instead of running the whole suite.
+package impact
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/repomap"
+)
+
+// Request describes a change
+type Request struct {
+	// Files are the changed paths relative to the checkout. When Files and
+	// Symbols are both empty, the files differing from Base are used,
+	// including untracked ones.
+	Files []string `json:"files,omitempty"`
+	// Symbols are changed declarations, e.g. "New" or "Store.Get"; the
+	// files declaring them join the change
+	Symbols []string `json:"symbols,omitempty"`
+	// Base is the revision public declarations are compared with (default
+	// HEAD)
+	Base string `json:"base,omitempty"`
+	// References finds the references to changed symbols; optional
+	References ReferenceFinder `json:"-"`
+}
+
+// ReferenceFinder looks up references to a symbol, as the LSP operator of
+// the action router does
+type ReferenceFinder interface {
+	FindReferences(ctx context.Context, file string, line, column int, symbol string) (map[string]interface{}, error)
+}
+
+// Package is a package the change affects
+type Package struct {
+	Dir      string `json:"dir"`
+	Language string `json:"language,omitempty"`
+	// Reason is "changed", or what links the package to the change, e.g.
+	// "imports internal/store"
+	Reason string `json:"reason"`
+	// Distance is 0 for changed packages, else the hops from one
+	Distance int `json:"distance"`
+}
+
+// APIChange is a public declaration added, removed or changed
+type APIChange struct {
+	Path   string `json:"path"`
+	Symbol string `json:"symbol"`
+	Change string `json:"change"` // added, removed or changed
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Report is the estimated impact of a change
+type Report struct {
+	ProjectID    string   `json:"project_id"`
+	Base         string   `json:"base"`
+	ChangedFiles []string `json:"changed_files"`
+	// Packages are the affected packages, changed ones first and then by
+	// distance
+	Packages []*Package `json:"packages"`
+	// Tests are the test targets to run: Go packages as "./internal/store"
+	// (or "." for the root), and test files of other languages by path
+	Tests      []string    `json:"tests"`
+	APIChanges []APIChange `json:"api_changes,omitempty"`
+	// Breaking is set when a public declaration was removed or changed
+	Breaking bool     `json:"breaking"`
+	Notes    []string `json:"notes,omitempty"`
+}
+
+// GoTestCommand returns the go test command covering the report's Go test
+// targets, or "" when there are none
+func (r *Report) GoTestCommand() string {
+	var pkgs []string
+	for _, t := range r.Tests {
+		if t == "." || strings.HasPrefix(t, "./") {
+			pkgs = append(pkgs, t)
+		}
+	}
+	if len(pkgs) == 0 {
+		return ""
+	}
+	return "go test " + strings.Join(pkgs, " ")
+}
+
+// Analyzer estimates the impact of changes to project checkouts
+type Analyzer struct {
+	workDirs files.WorkDirResolver
+	maps     *repomap.Generator
+}
+
+// NewAnalyzer creates an analyzer. maps, when set, resolves changed
+// symbols to the files declaring them.
+func NewAnalyzer(workDirs files.WorkDirResolver, maps *repomap.Generator) *Analyzer {
+	return &Analyzer{workDirs: workDirs, maps: maps}
+}
+
+// Analyze reports the packages, tests and public declarations a change to
+// the project's checkout affects
+func (a *Analyzer) Analyze(ctx context.Context, projectID string, req Request) (*Report, error) {
+	workDir := a.workDirs.GetProjectWorkDir(projectID)
+	if info, err := os.Stat(workDir); err != nil || !info.IsDir() {
+		return nil, apperr.NotFound("project %s has no checkout", projectID)
+	}
+	base := req.Base
+	if base == "" {
+		base = "HEAD"
+	}
+	if strings.HasPrefix(base, "-") {
+		return nil, apperr.Validation("invalid base revision %q", base)
+	}
+	report := &Report{ProjectID: projectID, Base: base, Packages: []*Package{}, Tests: []string{}}
+
+	changed := make(map[string]bool)
+	for _, f := range req.Files {
+		rel, err := cleanPath(f)
+		if err != nil {
+			return nil, err
+		}
+		changed[rel] = true
+	}
+	symbolFiles := a.declaringFiles(ctx, projectID, req.Symbols, report)
+	for _, files := range symbolFiles {
+		for _, f := range files {
+			changed[f] = true
+		}
+	}
+	if len(req.Files) == 0 && len(req.Symbols) == 0 {
+		files, err := changedFiles(ctx, workDir, base)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			changed[f] = true
+		}
+	}
+	for f := range changed {
+		report.ChangedFiles = append(report.ChangedFiles, f)
+	}
+	sort.Strings(report.ChangedFiles)
+	if len(report.ChangedFiles) == 0 {
+		report.Notes = append(report.Notes, "no changed files")
+		return report, nil
+	}
+
+	tree, err := scan(ctx, workDir)
+	if err != nil {
+		return nil, err
+	}
+	if tree.truncated {
+		report.Notes = append(report.Notes, fmt.Sprintf("only the first %d files of the checkout were scanned", maxFiles))
+	}
+	report.APIChanges = apiChanges(ctx, workDir, base, report.ChangedFiles)
+	for _, c := range report.APIChanges {
+		report.Breaking = report.Breaking || c.Change != "added"
+	}
+
+	affected := newAffected()
+	for _, f := range report.ChangedFiles {
+		affected.add(path.Dir(f), repomap.Language(f), "changed", 0)
+	}
+	if req.References != nil {
+		a.followReferences(ctx, workDir, req.References, symbolFiles, report, affected)
+	}
+	report.Tests = tree.expand(affected, report.ChangedFiles)
+	report.Packages = affected.sorted()
+	return report, nil
+}
+
+// declaringFiles finds the files declaring each symbol in the project's
+// repository map, noting symbols it can't place
+func (a *Analyzer) declaringFiles(ctx context.Context, projectID string, symbols []string, report *Report) map[string][]string {
+	found := make(map[string][]string)
+	if len(symbols) == 0 {
+		return found
+	}
+	if a.maps == nil {
+		report.Notes = append(report.Notes, "no repository map to locate symbols in")
+		return found
+	}
+	m, err := a.maps.Get(ctx, projectID, false)
+	if err != nil {
+		report.Notes = append(report.Notes, "repository map unavailable: "+err.Error())
+		return found
+	}
+	for _, sym := range symbols {
+		for _, p := range m.Packages {
+			for _, f := range p.Files {
+				for _, s := range f.Symbols {
+					if s.Name == sym || strings.HasSuffix(s.Name, "."+sym) {
+						found[sym] = append(found[sym], f.Path)
+						break
+					}
+				}
+			}
+		}
+		if len(found[sym]) == 0 {
+			report.Notes = append(report.Notes, fmt.Sprintf("symbol %s is not a public declaration in the repository map", sym))
+		}
+	}
+	return found
+}
+
+// followReferences marks the packages referencing changed symbols, both
+// those asked about and the public declarations the change altered or
+// removed. Absolute reference paths are taken relative to the checkout; a
+// reference finder that fails is not asked again.
+func (a *Analyzer) followReferences(ctx context.Context, workDir string, refs ReferenceFinder, symbolFiles map[string][]string, report *Report, affected *affectedSet) {
+	type lookup struct{ file, symbol string }
+	var lookups []lookup
+	for sym, files := range symbolFiles {
+		for _, f := range files {
+			lookups = append(lookups, lookup{f, sym})
+		}
+	}
+	for _, c := range report.APIChanges {
+		if c.Change != "added" {
+			lookups = append(lookups, lookup{c.Path, c.Symbol})
+		}
+	}
+	sort.Slice(lookups, func(i, j int) bool {
+		return lookups[i].file+lookups[i].symbol < lookups[j].file+lookups[j].symbol
+	})
+
+	for _, l := range lookups {
+		name := l.symbol
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			name = name[i+1:]
+		}
+		result, err := refs.FindReferences(ctx, l.file, 0, 0, name)
+		if err != nil {
+			report.Notes = append(report.Notes, "references unavailable: "+err.Error())
+			return
+		}
+		locations, _ := result["references"].([]map[string]interface{})
+		for _, loc := range locations {
+			file, _ := loc["file"].(string)
+			if filepath.IsAbs(file) {
+				file, _ = filepath.Rel(workDir, file)
+			}
+			rel, err := cleanPath(file)
+			if err != nil || rel == l.file {
+				continue
+			}
+			affected.add(path.Dir(rel), repomap.Language(rel), "references "+l.symbol, 1)
+			if isTestFile(rel) {
+				affected.tests = append(affected.tests, rel)
+			}
+		}
+	}
+}
+
+// cleanPath normalizes a path relative to the checkout, rejecting ones
+// that leave it
+func cleanPath(p string) (string, error) {
+	rel := path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	if rel == "." || strings.HasPrefix(rel, "/") || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", apperr.Validation("invalid path %q: paths are relative to the checkout", p)
+	}
+	return rel, nil
+}
+
+// affectedSet collects affected packages, keeping the shortest distance to
+// each
+type affectedSet struct {
+	packages map[string]*Package
+	tests    []string
+}
+
+func newAffected() *affectedSet {
+	return &affectedSet{packages: make(map[string]*Package)}
+}
+
+// add records a package, reporting whether it was new or got closer
+func (s *affectedSet) add(dir, lang, reason string, distance int) bool {
+	if p := s.packages[dir]; p != nil {
+		if p.Distance <= distance {
+			return false
+		}
+		p.Reason, p.Distance = reason, distance
+		return true
+	}
+	s.packages[dir] = &Package{Dir: dir, Language: lang, Reason: reason, Distance: distance}
+	return true
+}
+
+func (s *affectedSet) sorted() []*Package {
+	packages := make([]*Package, 0, len(s.packages))
+	for _, p := range s.packages {
+		packages = append(packages, p)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Distance != packages[j].Distance {
+			return packages[i].Distance < packages[j].Distance
+		}
+		return packages[i].Dir < packages[j].Dir
+	})
+	return packages
+}
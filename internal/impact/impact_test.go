@@ -0,0 +1,168 @@
+package impact
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/repomap"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+)
+
+type fakeCommits struct{}
+
+func (fakeCommits) GetCurrentCommit(string) (string, error) { return "", nil }
+
+type fakeRefs struct{ asked []string }
+
+func (f *fakeRefs) FindReferences(ctx context.Context, file string, line, column int, symbol string) (map[string]interface{}, error) {
+	f.asked = append(f.asked, file+":"+symbol)
+	return map[string]interface{}{
+		"references": []map[string]interface{}{{"file": "web/parser.ts", "line": 3}, {"file": file, "line": 1}},
+		"count":      2,
+	}, nil
+}
+
+var project = map[string]string{
+	"go.mod":              "module example.com/app\n\ngo 1.22\n",
+	"store/store.go":      "package store\n\ntype Store struct{}\n\nfunc New(path string) *Store { return &Store{} }\n\nfunc (s *Store) Close() error { return nil }\n",
+	"store/store_test.go": "package store\n\nimport \"testing\"\n\nfunc TestNew(t *testing.T) {}\n",
+	"api/api.go":          "package api\n\nimport \"example.com/app/store\"\n\nvar S = store.New(\"x\")\n",
+	"api/api_test.go":     "package api\n\nimport \"testing\"\n\nfunc TestAPI(t *testing.T) {}\n",
+	"cmd/app/main.go":     "package main\n\nimport _ \"example.com/app/api\"\n\nfunc main() {}\n",
+	"e2e/e2e_test.go":     "package e2e_test\n\nimport (\n\t\"testing\"\n\n\t\"example.com/app/store\"\n)\n\nfunc TestE2E(t *testing.T) { store.New(\"\") }\n",
+	"web/parser.ts":       "export function parse(s: string) {}\n",
+	"web/parser.test.ts":  "import { parse } from './parser'\n",
+	"scripts/sync.py":     "def main():\n    pass\n",
+	"tests/test_sync.py":  "def test_main():\n    pass\n",
+}
+
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	for name, content := range project {
+		writeFile(t, dir, name, content)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"commit", "-qm", "initial"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	full := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func packages(r *Report) string {
+	var parts []string
+	for _, p := range r.Packages {
+		parts = append(parts, p.Dir+"="+p.Reason)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestAnalyze_GoChange(t *testing.T) {
+	dir := gitRepo(t)
+	writeFile(t, dir, "store/store.go", "package store\n\ntype Store struct{}\n\nfunc New(path string, size int) *Store { return &Store{} }\n\nfunc Open() *Store { return nil }\n")
+
+	r, err := NewAnalyzer(toolrun.StaticWorkDir(dir), nil).Analyze(context.Background(), "p1", Request{})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if got := strings.Join(r.ChangedFiles, ","); got != "store/store.go" {
+		t.Errorf("changed files = %s", got)
+	}
+	want := "store=changed; api=imports store; e2e=tests import store; cmd/app=imports api"
+	if got := packages(r); got != want {
+		t.Errorf("packages:\n got %s\nwant %s", got, want)
+	}
+	if got := r.GoTestCommand(); got != "go test ./api ./e2e ./store" {
+		t.Errorf("test command = %q", got)
+	}
+
+	var changes []string
+	for _, c := range r.APIChanges {
+		changes = append(changes, c.Change+" "+c.Symbol)
+	}
+	if got := strings.Join(changes, ", "); got != "changed New, added Open, removed Store.Close" {
+		t.Errorf("api changes = %s", got)
+	}
+	if !r.Breaking {
+		t.Error("a changed signature should be breaking")
+	}
+}
+
+func TestAnalyze_OtherLanguages(t *testing.T) {
+	dir := gitRepo(t)
+	r, err := NewAnalyzer(toolrun.StaticWorkDir(dir), nil).Analyze(context.Background(), "p1", Request{Files: []string{"web/parser.ts", "./scripts/sync.py"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(r.Tests, ","); got != "tests/test_sync.py,web/parser.test.ts" {
+		t.Errorf("tests = %s", got)
+	}
+	if got := packages(r); got != "scripts=changed; web=changed; tests=tests scripts/sync.py" {
+		t.Errorf("packages = %s", got)
+	}
+	if len(r.APIChanges) != 0 || r.Breaking {
+		t.Errorf("unchanged files reported api changes: %+v", r.APIChanges)
+	}
+
+	if _, err := NewAnalyzer(toolrun.StaticWorkDir(dir), nil).Analyze(context.Background(), "p1", Request{Files: []string{"../etc/passwd"}}); apperr.CodeOf(err) != apperr.CodeValidation {
+		t.Errorf("expected a validation error for a path outside the checkout, got %v", err)
+	}
+}
+
+func TestAnalyze_SymbolsAndReferences(t *testing.T) {
+	dir := gitRepo(t)
+	refs := &fakeRefs{}
+	a := NewAnalyzer(toolrun.StaticWorkDir(dir), repomap.NewGenerator(toolrun.StaticWorkDir(dir), fakeCommits{}))
+	r, err := a.Analyze(context.Background(), "p1", Request{Symbols: []string{"Close", "Missing"}, References: refs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(r.ChangedFiles, ","); got != "store/store.go" {
+		t.Errorf("changed files = %s", got)
+	}
+	if got := strings.Join(refs.asked, ","); got != "store/store.go:Close" {
+		t.Errorf("references asked for %s", got)
+	}
+	if !strings.Contains(packages(r), "web=references Close") || !strings.Contains(strings.Join(r.Tests, ","), "./store") {
+		t.Errorf("packages = %s, tests = %v", packages(r), r.Tests)
+	}
+	if len(r.Notes) != 1 || !strings.Contains(r.Notes[0], "Missing") {
+		t.Errorf("notes = %v", r.Notes)
+	}
+}
+
+func TestIsTestFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"store_test.go": true, "store.go": false, "test_sync.py": true, "sync_test.py": true, "sync.py": false,
+		"parser.test.ts": true, "parser.spec.jsx": true, "src/__tests__/parser.js": true, "parser.ts": false,
+	} {
+		if got := isTestFile(name); got != want {
+			t.Errorf("isTestFile(%s) = %v", name, got)
+		}
+	}
+	if got := testSubject("parser.spec.ts"); got != "parser" {
+		t.Errorf("testSubject = %s", got)
+	}
+}
@@ -0,0 +1,750 @@
+// Package k8s deploys a bead's build of a project into its own ephemeral
+// Kubernetes namespace, from plain manifests or a Helm chart, reports rollout
+// status, pod health and logs into the bead's context, and deletes the
+// namespace when the bead closes.
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var k8sLog = logging.Module("k8s")
+
+// Deployment modes
+const (
+	ModeManifests = "manifests"
+	ModeHelm      = "helm"
+)
+
+// Bead context keys written by deployments
+const (
+	ContextNamespace = "staging_namespace"
+	ContextStatus    = "staging_status" // ready, failed or torn_down
+	ContextReport    = "staging_report"
+	ContextUpdatedAt = "staging_updated_at"
+)
+
+// Staging statuses
+const (
+	StatusReady    = "ready"
+	StatusFailed   = "failed"
+	StatusTornDown = "torn_down"
+)
+
+const (
+	defaultNamespacePrefix = "loom-"
+	defaultRolloutTimeout  = 5 * time.Minute
+	defaultLogTailLines    = 50
+	// maxLoggedPods caps the pods whose logs are collected
+	maxLoggedPods = 5
+	// maxReportChars bounds the report kept in the bead's context, and
+	// maxReportLogChars the logs of each pod in it
+	maxReportChars    = 4000
+	maxReportLogChars = 1000
+	// maxOutputChars bounds the kubectl apply or helm output kept
+	maxOutputChars = 4000
+	// teardownTimeout bounds deleting a namespace when a bead closes
+	teardownTimeout = time.Minute
+)
+
+var nameSanitizer = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// BeadStore reads and updates beads
+type BeadStore interface {
+	GetBead(id string) (*models.Bead, error)
+	UpdateBead(id string, updates map[string]interface{}) error
+}
+
+// DeployOptions customizes a deployment
+type DeployOptions struct {
+	Image   string        // Image to deploy; defaults to the project's staging image
+	Timeout time.Duration // Shortens the configured rollout timeout
+}
+
+// Rollout is the rollout status of one workload
+type Rollout struct {
+	Resource string `json:"resource"` // e.g. deployment.apps/web
+	Ready    bool   `json:"ready"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Pod summarizes a pod's health
+type Pod struct {
+	Name     string `json:"name"`
+	Phase    string `json:"phase"`
+	Ready    string `json:"ready"` // Ready containers, e.g. 1/2
+	Restarts int    `json:"restarts"`
+	Reason   string `json:"reason,omitempty"` // Why a container is waiting or terminated, e.g. CrashLoopBackOff
+}
+
+// Deployment is the state of a bead's staging namespace
+type Deployment struct {
+	Namespace string            `json:"namespace"`
+	Mode      string            `json:"mode"`
+	Release   string            `json:"release,omitempty"` // Helm release
+	Image     string            `json:"image,omitempty"`
+	Success   bool              `json:"success"`
+	Rollouts  []Rollout         `json:"rollouts"`
+	Pods      []Pod             `json:"pods"`
+	Logs      map[string]string `json:"logs,omitempty"`   // Pod name to its recent log lines
+	Output    string            `json:"output,omitempty"` // kubectl apply or helm output
+	Error     string            `json:"error,omitempty"`
+	Duration  string            `json:"duration"`
+}
+
+// Manager deploys beads into staging namespaces
+type Manager struct {
+	WorkDirs        files.WorkDirResolver
+	Beads           BeadStore
+	Kubeconfig      string
+	Context         string
+	NamespacePrefix string
+	RolloutTimeout  time.Duration
+	LogTailLines    int
+
+	mu       sync.RWMutex
+	projects map[string]config.StagingConfig
+
+	runner toolrun.Runner
+	now    func() time.Time
+}
+
+// NewManager creates a staging manager for the configured projects, or
+// returns nil when Kubernetes integration is disabled. beads may be nil.
+func NewManager(cfg config.KubernetesConfig, projects []config.ProjectConfig, resolver files.WorkDirResolver, beads BeadStore) *Manager {
+	if !cfg.Enabled {
+		return nil
+	}
+	m := &Manager{
+		WorkDirs:        resolver,
+		Beads:           beads,
+		Kubeconfig:      cfg.Kubeconfig,
+		Context:         cfg.Context,
+		NamespacePrefix: cfg.NamespacePrefix,
+		RolloutTimeout:  cfg.RolloutTimeout,
+		LogTailLines:    cfg.LogTailLines,
+		projects:        make(map[string]config.StagingConfig),
+		now:             time.Now,
+	}
+	if m.NamespacePrefix == "" {
+		m.NamespacePrefix = defaultNamespacePrefix
+	}
+	if m.RolloutTimeout <= 0 {
+		m.RolloutTimeout = defaultRolloutTimeout
+	}
+	if m.LogTailLines <= 0 {
+		m.LogTailLines = defaultLogTailLines
+	}
+	for _, p := range projects {
+		m.SetStaging(p.ID, p.Staging)
+	}
+	return m
+}
+
+// SetStaging replaces a project's staging configuration; nil removes it
+func (m *Manager) SetStaging(projectID string, staging *config.StagingConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if staging == nil {
+		delete(m.projects, projectID)
+		return
+	}
+	m.projects[projectID] = *staging
+}
+
+// Namespace returns the staging namespace of a bead: the prefix, project and
+// bead ID as a DNS label of at most 63 characters
+func (m *Manager) Namespace(projectID, beadID string) string {
+	bead := sanitizeName(beadID)
+	project := sanitizeName(projectID)
+	prefix := sanitizeName(m.NamespacePrefix)
+	if prefix != "" {
+		prefix += "-"
+	}
+	if room := 63 - len(prefix) - len(bead) - 1; len(project) > room {
+		project = strings.TrimRight(project[:max(room, 0)], "-")
+	}
+	name := prefix + bead
+	if project != "" {
+		name = prefix + project + "-" + bead
+	}
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.Trim(name, "-")
+}
+
+// Deploy creates the bead's namespace if needed, applies the project's
+// manifests or installs its chart there, waits for workloads to roll out and
+// reports pod health and logs. A failed rollout is reported in the
+// deployment, which is also recorded in the bead's context; an error means
+// nothing could be deployed.
+func (m *Manager) Deploy(ctx context.Context, projectID, beadID string, opts DeployOptions) (*Deployment, error) {
+	staging, workDir, err := m.resolve(projectID, beadID)
+	if err != nil {
+		return nil, err
+	}
+	mode := ModeManifests
+	if staging.Chart != "" {
+		mode = ModeHelm
+		if _, err := m.tool("helm"); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkPaths(workDir, staging); err != nil {
+		return nil, err
+	}
+	image := opts.Image
+	if image == "" {
+		image = strings.ReplaceAll(staging.Image, "${LOOM_BEAD}", beadID)
+	}
+
+	start := m.now()
+	ns := m.Namespace(projectID, beadID)
+	timeout := m.RolloutTimeout
+	if opts.Timeout > 0 && opts.Timeout < timeout {
+		timeout = opts.Timeout
+	}
+	deployCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dep := &Deployment{Namespace: ns, Mode: mode, Image: image}
+	if err := m.ensureNamespace(deployCtx, ns, projectID, beadID); err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	var deployErr error
+	if mode == ModeHelm {
+		dep.Release = releaseName(projectID)
+		out, deployErr = m.helmInstall(deployCtx, workDir, ns, dep.Release, image, staging, timeout)
+	} else {
+		out, deployErr = m.applyManifests(deployCtx, workDir, ns, projectID, beadID, image, staging)
+	}
+	dep.Output = tail(strings.TrimSpace(string(out)), maxOutputChars)
+	if deployErr != nil {
+		dep.Error = deployErr.Error()
+	} else {
+		dep.Rollouts = m.rollouts(deployCtx, ns)
+	}
+	m.inspect(context.WithoutCancel(ctx), dep)
+
+	dep.Success = deployErr == nil
+	for _, r := range dep.Rollouts {
+		dep.Success = dep.Success && r.Ready
+	}
+	dep.Duration = m.now().Sub(start).Round(time.Millisecond).String()
+	m.record(beadID, dep)
+	return dep, nil
+}
+
+// Status reports the rollout, pod health and logs of a bead's staging
+// namespace without redeploying it, and records them in the bead's context
+func (m *Manager) Status(ctx context.Context, projectID, beadID string) (*Deployment, error) {
+	staging, _, err := m.resolve(projectID, beadID)
+	if err != nil {
+		return nil, err
+	}
+	start := m.now()
+	ns := m.Namespace(projectID, beadID)
+	if _, stderr, err := m.kubectl(ctx, "", nil, "get", "namespace", ns, "-o", "name"); err != nil {
+		return nil, fmt.Errorf("no staging deployment for bead %s: %s", beadID, errorOutput(stderr, err))
+	}
+	dep := &Deployment{Namespace: ns, Mode: ModeManifests}
+	if staging.Chart != "" {
+		dep.Mode, dep.Release = ModeHelm, releaseName(projectID)
+	}
+	dep.Rollouts = m.rolloutStatus(ctx, ns, "--watch=false")
+	m.inspect(ctx, dep)
+	dep.Success = true
+	for _, r := range dep.Rollouts {
+		dep.Success = dep.Success && r.Ready
+	}
+	dep.Duration = m.now().Sub(start).Round(time.Millisecond).String()
+	m.record(beadID, dep)
+	return dep, nil
+}
+
+// Teardown deletes a bead's staging namespace, and everything in it
+func (m *Manager) Teardown(ctx context.Context, projectID, beadID string) error {
+	if _, err := m.tool("kubectl"); err != nil {
+		return err
+	}
+	ns := m.Namespace(projectID, beadID)
+	if _, stderr, err := m.kubectl(ctx, "", nil, "delete", "namespace", ns, "--ignore-not-found", "--wait=false"); err != nil {
+		return fmt.Errorf("kubectl delete namespace %s: %s", ns, errorOutput(stderr, err))
+	}
+	if m.Beads != nil {
+		if err := m.Beads.UpdateBead(beadID, map[string]interface{}{"context": map[string]string{
+			ContextStatus:    StatusTornDown,
+			ContextUpdatedAt: m.now().UTC().Format(time.RFC3339),
+		}}); err != nil {
+			k8sLog.Error("Failed to record staging teardown", "bead_id", beadID, "namespace", ns, "error", err)
+		}
+	}
+	return nil
+}
+
+// BeadClosed tears down the staging namespace of a closed bead. It is a
+// no-op for beads that were never deployed or were already torn down.
+func (m *Manager) BeadClosed(ctx context.Context, beadID string) error {
+	if m.Beads == nil {
+		return nil
+	}
+	bead, err := m.Beads.GetBead(beadID)
+	if err != nil {
+		return err
+	}
+	if bead.Context[ContextNamespace] == "" || bead.Context[ContextStatus] == StatusTornDown {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, teardownTimeout)
+	defer cancel()
+	return m.Teardown(ctx, bead.ProjectID, beadID)
+}
+
+// Summary describes a deployment for the bead's context
+func (d *Deployment) Summary() string {
+	var sb strings.Builder
+	status := StatusReady
+	if !d.Success {
+		status = StatusFailed
+	}
+	sb.WriteString(fmt.Sprintf("Staging namespace %s (%s", d.Namespace, d.Mode))
+	if d.Release != "" {
+		sb.WriteString(", release " + d.Release)
+	}
+	if d.Image != "" {
+		sb.WriteString(", image " + d.Image)
+	}
+	sb.WriteString("): " + status + "\n")
+	if d.Error != "" {
+		sb.WriteString("Error: " + d.Error + "\n")
+	}
+	for _, r := range d.Rollouts {
+		state := "ready"
+		if !r.Ready {
+			state = "not ready"
+		}
+		line := fmt.Sprintf("- %s: %s", r.Resource, state)
+		if r.Message != "" && !r.Ready {
+			line += " (" + r.Message + ")"
+		}
+		sb.WriteString(line + "\n")
+	}
+	for _, p := range d.Pods {
+		line := fmt.Sprintf("- pod %s: %s, %s ready, %d restarts", p.Name, p.Phase, p.Ready, p.Restarts)
+		if p.Reason != "" {
+			line += ", " + p.Reason
+		}
+		sb.WriteString(line + "\n")
+	}
+	for _, name := range sortedKeys(d.Logs) {
+		sb.WriteString(fmt.Sprintf("Logs of %s:\n%s\n", name, tail(d.Logs[name], maxReportLogChars)))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func (m *Manager) resolve(projectID, beadID string) (config.StagingConfig, string, error) {
+	if beadID == "" {
+		return config.StagingConfig{}, "", fmt.Errorf("staging deployments need a bead")
+	}
+	m.mu.RLock()
+	staging, ok := m.projects[projectID]
+	m.mu.RUnlock()
+	if !ok {
+		return staging, "", fmt.Errorf("project %s has no staging configuration", projectID)
+	}
+	if (staging.Manifests == "") == (staging.Chart == "") {
+		return staging, "", fmt.Errorf("project %s staging needs exactly one of manifests or chart", projectID)
+	}
+	if _, err := m.tool("kubectl"); err != nil {
+		return staging, "", err
+	}
+	if m.WorkDirs == nil {
+		return staging, "", fmt.Errorf("workdir resolver not configured")
+	}
+	workDir := m.WorkDirs.GetProjectWorkDir(projectID)
+	if workDir == "" {
+		return staging, "", fmt.Errorf("project workdir not found")
+	}
+	return staging, filepath.Clean(workDir), nil
+}
+
+func (m *Manager) ensureNamespace(ctx context.Context, ns, projectID, beadID string) error {
+	if _, stderr, err := m.kubectl(ctx, "", nil, "create", "namespace", ns); err != nil && !strings.Contains(string(stderr), "AlreadyExists") {
+		return fmt.Errorf("kubectl create namespace %s: %s", ns, errorOutput(stderr, err))
+	}
+	labels := []string{"label", "namespace", ns, "--overwrite", "app.kubernetes.io/managed-by=loom",
+		"loom.project=" + sanitizeName(projectID), "loom.bead=" + sanitizeName(beadID)}
+	if _, stderr, err := m.kubectl(ctx, "", nil, labels...); err != nil {
+		return fmt.Errorf("kubectl label namespace %s: %s", ns, errorOutput(stderr, err))
+	}
+	return nil
+}
+
+// applyManifests substitutes the LOOM_ variables into the project's
+// manifests and applies them to the namespace
+func (m *Manager) applyManifests(ctx context.Context, workDir, ns, projectID, beadID, image string, staging config.StagingConfig) ([]byte, error) {
+	files, err := manifestFiles(workDir, staging.Manifests)
+	if err != nil {
+		return nil, err
+	}
+	vars := strings.NewReplacer("${LOOM_IMAGE}", image, "${LOOM_NAMESPACE}", ns, "${LOOM_BEAD}", beadID, "${LOOM_PROJECT}", projectID)
+	var docs []string
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, vars.Replace(string(data)))
+	}
+	stdout, stderr, err := m.kubectl(ctx, workDir, []byte(strings.Join(docs, "\n---\n")), "apply", "-n", ns, "-f", "-")
+	out := append(stdout, stderr...)
+	if err != nil {
+		return out, fmt.Errorf("kubectl apply: %s", errorOutput(stderr, err))
+	}
+	return out, nil
+}
+
+// helmInstall installs or upgrades the project's chart in the namespace and
+// waits for it to become ready
+func (m *Manager) helmInstall(ctx context.Context, workDir, ns, release, image string, staging config.StagingConfig, timeout time.Duration) ([]byte, error) {
+	chart, err := files.SafeJoin(workDir, staging.Chart)
+	if err != nil {
+		return nil, fmt.Errorf("chart %q: %w", staging.Chart, err)
+	}
+	args := []string{"upgrade", "--install", release, chart, "--namespace", ns, "--wait", "--timeout", timeout.String()}
+	if m.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", m.Kubeconfig)
+	}
+	if m.Context != "" {
+		args = append(args, "--kube-context", m.Context)
+	}
+	for _, f := range staging.ValuesFiles {
+		path, err := files.SafeJoin(workDir, f)
+		if err != nil {
+			return nil, fmt.Errorf("values file %q: %w", f, err)
+		}
+		args = append(args, "-f", path)
+	}
+	for _, key := range sortedKeys(staging.Values) {
+		args = append(args, "--set", key+"="+staging.Values[key])
+	}
+	if image != "" {
+		if staging.ImageValue != "" {
+			args = append(args, "--set", staging.ImageValue+"="+image)
+		} else {
+			repo, tag := splitImage(image)
+			args = append(args, "--set", "image.repository="+repo)
+			if tag != "" {
+				args = append(args, "--set", "image.tag="+tag)
+			}
+		}
+	}
+	stdout, stderr, err := m.runner.Exec(ctx, toolrun.Command{Dir: workDir, Name: "helm", Args: args})
+	out := append(stdout, stderr...)
+	if err != nil {
+		return out, fmt.Errorf("helm upgrade: %s", errorOutput(stderr, err))
+	}
+	return out, nil
+}
+
+// rollouts waits for each workload in the namespace to finish rolling out,
+// until ctx ends
+func (m *Manager) rollouts(ctx context.Context, ns string) []Rollout {
+	wait := m.RolloutTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		wait = time.Until(deadline)
+	}
+	return m.rolloutStatus(ctx, ns, fmt.Sprintf("--timeout=%ds", max(int(wait.Seconds()), 1)))
+}
+
+// rolloutStatus runs `kubectl rollout status` with flag for each workload
+// in the namespace. With --watch=false it reports the current state, where a
+// workload still rolling out is "Waiting for ..." rather than an error.
+func (m *Manager) rolloutStatus(ctx context.Context, ns, flag string) []Rollout {
+	rollouts := []Rollout{}
+	out, _, err := m.kubectl(ctx, "", nil, "get", "deployments,statefulsets,daemonsets", "-n", ns, "-o", "name")
+	if err != nil {
+		return rollouts
+	}
+	for _, resource := range strings.Fields(string(out)) {
+		stdout, stderr, err := m.kubectl(ctx, "", nil, "rollout", "status", resource, "-n", ns, flag)
+		msg := lastLine(string(stdout))
+		r := Rollout{Resource: resource, Ready: err == nil && !strings.HasPrefix(msg, "Waiting for")}
+		if !r.Ready {
+			r.Message = msg
+			if r.Message == "" || err != nil && !strings.HasPrefix(msg, "Waiting for") {
+				r.Message = lastLine(errorOutput(stderr, err))
+			}
+		}
+		rollouts = append(rollouts, r)
+	}
+	return rollouts
+}
+
+// inspect fills in the namespace's pods and the logs of up to maxLoggedPods
+// of them, unhealthy pods first
+func (m *Manager) inspect(ctx context.Context, dep *Deployment) {
+	dep.Pods = []Pod{}
+	out, _, err := m.kubectl(ctx, "", nil, "get", "pods", "-n", dep.Namespace, "-o", "json")
+	if err != nil {
+		return
+	}
+	pods, err := parsePods(out)
+	if err != nil {
+		return
+	}
+	dep.Pods = pods
+
+	ordered := append([]Pod(nil), pods...)
+	sort.SliceStable(ordered, func(i, j int) bool { return !healthy(ordered[i]) && healthy(ordered[j]) })
+	for i, p := range ordered {
+		if i >= maxLoggedPods {
+			break
+		}
+		stdout, _, err := m.kubectl(ctx, "", nil, "logs", p.Name, "-n", dep.Namespace, "--all-containers", "--prefix", fmt.Sprintf("--tail=%d", m.LogTailLines))
+		if err != nil || len(bytes.TrimSpace(stdout)) == 0 {
+			continue
+		}
+		if dep.Logs == nil {
+			dep.Logs = map[string]string{}
+		}
+		dep.Logs[p.Name] = strings.TrimRight(string(stdout), "\n")
+	}
+}
+
+// record writes a deployment's namespace, status and summary into the
+// bead's context
+func (m *Manager) record(beadID string, dep *Deployment) {
+	if m.Beads == nil {
+		return
+	}
+	status := StatusReady
+	if !dep.Success {
+		status = StatusFailed
+	}
+	if err := m.Beads.UpdateBead(beadID, map[string]interface{}{"context": map[string]string{
+		ContextNamespace: dep.Namespace,
+		ContextStatus:    status,
+		ContextReport:    truncate(dep.Summary(), maxReportChars),
+		ContextUpdatedAt: m.now().UTC().Format(time.RFC3339),
+	}}); err != nil {
+		k8sLog.Error("Failed to record staging deployment", "bead_id", beadID, "namespace", dep.Namespace, "error", err)
+	}
+}
+
+func (m *Manager) tool(name string) (string, error) {
+	path, err := m.runner.Find(name)
+	if err != nil {
+		return "", fmt.Errorf("%s is not installed", name)
+	}
+	return path, nil
+}
+
+func (m *Manager) kubectl(ctx context.Context, dir string, stdin []byte, args ...string) ([]byte, []byte, error) {
+	var global []string
+	if m.Kubeconfig != "" {
+		global = append(global, "--kubeconfig", m.Kubeconfig)
+	}
+	if m.Context != "" {
+		global = append(global, "--context", m.Context)
+	}
+	return m.runner.Exec(ctx, toolrun.Command{Dir: dir, Stdin: stdin, Name: "kubectl", Args: append(global, args...)})
+}
+
+// parsePods summarizes `kubectl get pods -o json` output
+func parsePods(out []byte) ([]Pod, error) {
+	type containerState struct {
+		Waiting *struct {
+			Reason string `json:"reason"`
+		} `json:"waiting"`
+		Terminated *struct {
+			Reason   string `json:"reason"`
+			ExitCode int    `json:"exitCode"`
+		} `json:"terminated"`
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Status struct {
+				Phase             string `json:"phase"`
+				Reason            string `json:"reason"`
+				ContainerStatuses []struct {
+					Ready        bool           `json:"ready"`
+					RestartCount int            `json:"restartCount"`
+					State        containerState `json:"state"`
+					LastState    containerState `json:"lastState"`
+				} `json:"containerStatuses"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("invalid kubectl output: %w", err)
+	}
+	pods := make([]Pod, 0, len(list.Items))
+	for _, item := range list.Items {
+		p := Pod{Name: item.Metadata.Name, Phase: item.Status.Phase, Reason: item.Status.Reason}
+		ready := 0
+		for _, cs := range item.Status.ContainerStatuses {
+			if cs.Ready {
+				ready++
+			}
+			p.Restarts += cs.RestartCount
+			switch {
+			case cs.State.Waiting != nil && cs.State.Waiting.Reason != "":
+				p.Reason = cs.State.Waiting.Reason
+			case cs.State.Terminated != nil && cs.State.Terminated.Reason != "":
+				p.Reason = fmt.Sprintf("%s (exit code %d)", cs.State.Terminated.Reason, cs.State.Terminated.ExitCode)
+			}
+			if p.Reason == "" && cs.LastState.Terminated != nil && cs.LastState.Terminated.Reason != "" {
+				p.Reason = "last terminated: " + cs.LastState.Terminated.Reason
+			}
+		}
+		p.Ready = fmt.Sprintf("%d/%d", ready, len(item.Status.ContainerStatuses))
+		pods = append(pods, p)
+	}
+	return pods, nil
+}
+
+func healthy(p Pod) bool {
+	return (p.Phase == "Running" || p.Phase == "Succeeded") && p.Reason == "" && p.Restarts == 0
+}
+
+// checkPaths refuses staging manifests, charts and values files that are
+// missing or outside the project, before anything is deployed
+func checkPaths(workDir string, staging config.StagingConfig) error {
+	if staging.Manifests != "" {
+		_, err := manifestFiles(workDir, staging.Manifests)
+		return err
+	}
+	for _, p := range append([]string{staging.Chart}, staging.ValuesFiles...) {
+		path, err := files.SafeJoin(workDir, p)
+		if err != nil {
+			return fmt.Errorf("chart file %q: %w", p, err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("%q not found", p)
+		}
+	}
+	return nil
+}
+
+// manifestFiles lists the YAML and JSON manifests at path, a file or a
+// directory, in name order
+func manifestFiles(workDir, path string) ([]string, error) {
+	full, err := files.SafeJoin(workDir, path)
+	if err != nil {
+		return nil, fmt.Errorf("manifests %q: %w", path, err)
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, fmt.Errorf("manifests %q not found", path)
+	}
+	if !info.IsDir() {
+		return []string{full}, nil
+	}
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".yaml", ".yml", ".json":
+			if !e.IsDir() {
+				files = append(files, filepath.Join(full, e.Name()))
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no manifests in %q", path)
+	}
+	return files, nil
+}
+
+// releaseName names a project's Helm release; each namespace holds one
+func releaseName(projectID string) string {
+	name := sanitizeName(projectID)
+	if len(name) > 53 {
+		name = strings.TrimRight(name[:53], "-")
+	}
+	if name == "" {
+		name = "app"
+	}
+	return name
+}
+
+// splitImage splits an image reference into its repository and tag
+func splitImage(image string) (string, string) {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, ""
+}
+
+func sanitizeName(s string) string {
+	return strings.Trim(nameSanitizer.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+func errorOutput(stderr []byte, err error) string {
+	if msg := strings.TrimSpace(string(stderr)); msg != "" {
+		return msg
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timed out"
+	}
+	return err.Error()
+}
+
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndex(s, "\n"); i >= 0 {
+		return strings.TrimSpace(s[i+1:])
+	}
+	return s
+}
+
+// tail keeps the end of s, where failures are reported
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return "..." + s[len(s)-n:]
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "... (truncated)"
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
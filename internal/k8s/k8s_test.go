@@ -0,0 +1,247 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type memBeads map[string]*models.Bead
+
+func (b memBeads) GetBead(id string) (*models.Bead, error) {
+	if bead, ok := b[id]; ok {
+		return bead, nil
+	}
+	return nil, errors.New("bead not found")
+}
+
+func (b memBeads) UpdateBead(id string, updates map[string]interface{}) error {
+	bead, ok := b[id]
+	if !ok {
+		return errors.New("bead not found")
+	}
+	if bead.Context == nil {
+		bead.Context = map[string]string{}
+	}
+	for k, v := range updates["context"].(map[string]string) {
+		bead.Context[k] = v
+	}
+	return nil
+}
+
+// fakeCluster records commands and answers them by subcommand
+type fakeCluster struct {
+	toolrun.Fake
+	stdin    string
+	handlers map[string]func(args []string) ([]byte, []byte, error)
+}
+
+func (f *fakeCluster) handle(c toolrun.Command) ([]byte, []byte, error) {
+	if c.Stdin != nil {
+		f.stdin = string(c.Stdin)
+	}
+	name, args := c.Name, c.Args
+	key := name + " " + args[0]
+	if name == "kubectl" && args[0] == "--context" {
+		args = args[2:]
+		key = name + " " + args[0]
+	}
+	if h, ok := f.handlers[key]; ok {
+		return h(args)
+	}
+	return nil, nil, nil
+}
+
+const podsJSON = `{"items":[
+	{"metadata":{"name":"web-1"},"status":{"phase":"Running","containerStatuses":[{"ready":true,"restartCount":0,"state":{"running":{}}}]}},
+	{"metadata":{"name":"worker-1"},"status":{"phase":"Running","containerStatuses":[{"ready":false,"restartCount":4,"state":{"waiting":{"reason":"CrashLoopBackOff"}},"lastState":{"terminated":{"reason":"Error","exitCode":1}}}]}}
+]}`
+
+func fakeManager(t *testing.T, staging *config.StagingConfig) (*Manager, *fakeCluster, memBeads, string) {
+	t.Helper()
+	dir := t.TempDir()
+	beads := memBeads{"loom-7": {ID: "loom-7", ProjectID: "web-app"}}
+	m := NewManager(config.KubernetesConfig{Enabled: true, Context: "staging", RolloutTimeout: time.Minute},
+		[]config.ProjectConfig{{ID: "web-app", Staging: staging}}, toolrun.StaticWorkDir(dir), beads)
+	f := &fakeCluster{handlers: map[string]func([]string) ([]byte, []byte, error){
+		"kubectl get": func(args []string) ([]byte, []byte, error) {
+			if args[1] == "pods" {
+				return []byte(podsJSON), nil, nil
+			}
+			return []byte("deployment.apps/web\ndeployment.apps/worker\n"), nil, nil
+		},
+		"kubectl rollout": func(args []string) ([]byte, []byte, error) {
+			if args[2] == "deployment.apps/worker" {
+				return []byte("Waiting for deployment \"worker\" rollout to finish: 0 of 1 updated replicas are available...\n"),
+					[]byte("error: timed out waiting for the condition\n"), errors.New("exit status 1")
+			}
+			return []byte("deployment \"web\" successfully rolled out\n"), nil, nil
+		},
+		"kubectl logs": func(args []string) ([]byte, []byte, error) {
+			return []byte("[pod/" + args[1] + "/app] started\n"), nil, nil
+		},
+	}}
+	f.Handle = f.handle
+	m.runner = f.Runner()
+	return m, f, beads, dir
+}
+
+func TestNewManagerDisabled(t *testing.T) {
+	if m := NewManager(config.KubernetesConfig{}, nil, toolrun.StaticWorkDir("/tmp"), nil); m != nil {
+		t.Error("expected a disabled manager to be nil")
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	m := &Manager{NamespacePrefix: "loom-"}
+	if ns := m.Namespace("Web_App", "loom-7"); ns != "loom-web-app-loom-7" {
+		t.Errorf("Namespace() = %q", ns)
+	}
+	ns := m.Namespace(strings.Repeat("project", 20), "loom-7")
+	if len(ns) > 63 || !strings.HasSuffix(ns, "-loom-7") || !strings.HasPrefix(ns, "loom-project") {
+		t.Errorf("long Namespace() = %q", ns)
+	}
+}
+
+func TestDeployManifests(t *testing.T) {
+	m, f, beads, dir := fakeManager(t, &config.StagingConfig{Manifests: "deploy", Image: "registry.local/web:${LOOM_BEAD}"})
+	if err := os.MkdirAll(filepath.Join(dir, "deploy"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"a-deployment.yaml": "image: ${LOOM_IMAGE}\nnamespace: ${LOOM_NAMESPACE}\n",
+		"b-service.yml":     "kind: Service\n",
+		"README.md":         "not a manifest",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, "deploy", name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dep, err := m.Deploy(context.Background(), "web-app", "loom-7", DeployOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dep.Success || dep.Namespace != "loom-web-app-loom-7" || dep.Mode != ModeManifests || dep.Image != "registry.local/web:loom-7" {
+		t.Errorf("unexpected deployment %+v", dep)
+	}
+	if f.stdin != "image: registry.local/web:loom-7\nnamespace: loom-web-app-loom-7\n\n---\nkind: Service\n" {
+		t.Errorf("unexpected manifests applied: %q", f.stdin)
+	}
+	if len(dep.Rollouts) != 2 || !dep.Rollouts[0].Ready || dep.Rollouts[1].Ready || !strings.HasPrefix(dep.Rollouts[1].Message, "Waiting for deployment") {
+		t.Errorf("unexpected rollouts %+v", dep.Rollouts)
+	}
+	if len(dep.Pods) != 2 || dep.Pods[1].Reason != "CrashLoopBackOff" || dep.Pods[1].Restarts != 4 || dep.Pods[1].Ready != "0/1" {
+		t.Errorf("unexpected pods %+v", dep.Pods)
+	}
+	if dep.Logs["worker-1"] != "[pod/worker-1/app] started" {
+		t.Errorf("unexpected logs %v", dep.Logs)
+	}
+	if f.Called("kubectl --context staging create namespace loom-web-app-loom-7") == "" {
+		t.Errorf("namespace was not created in the configured context: %v", f.Calls)
+	}
+
+	ctx := beads["loom-7"].Context
+	if ctx[ContextNamespace] != "loom-web-app-loom-7" || ctx[ContextStatus] != StatusFailed ||
+		!strings.Contains(ctx[ContextReport], "deployment.apps/worker: not ready") || !strings.Contains(ctx[ContextReport], "CrashLoopBackOff") {
+		t.Errorf("deployment was not recorded on the bead: %v", ctx)
+	}
+}
+
+func TestDeployHelm(t *testing.T) {
+	m, f, _, dir := fakeManager(t, &config.StagingConfig{Chart: "chart", Values: map[string]string{"replicaCount": "1"}})
+	f.handlers["kubectl rollout"] = func(args []string) ([]byte, []byte, error) { return []byte("successfully rolled out\n"), nil, nil }
+	if err := os.MkdirAll(filepath.Join(dir, "chart"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	dep, err := m.Deploy(context.Background(), "web-app", "loom-7", DeployOptions{Image: "registry.local:5000/web:abc", Timeout: 30 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dep.Success || dep.Release != "web-app" || dep.Mode != ModeHelm {
+		t.Errorf("unexpected deployment %+v", dep)
+	}
+	helm := f.Called("helm upgrade")
+	for _, want := range []string{
+		"--install web-app " + filepath.Join(dir, "chart") + " --namespace loom-web-app-loom-7 --wait --timeout 30s",
+		"--kube-context staging",
+		"--set replicaCount=1 --set image.repository=registry.local:5000/web --set image.tag=abc",
+	} {
+		if !strings.Contains(helm, want) {
+			t.Errorf("helm args %q are missing %q", helm, want)
+		}
+	}
+}
+
+func TestDeployErrors(t *testing.T) {
+	m, f, _, dir := fakeManager(t, &config.StagingConfig{Manifests: "../outside"})
+	if _, err := m.Deploy(context.Background(), "web-app", "loom-7", DeployOptions{}); err == nil || !strings.Contains(err.Error(), "escapes project workdir") {
+		t.Errorf("expected manifests outside the project to be refused, got %v", err)
+	}
+	if _, err := m.Deploy(context.Background(), "other", "loom-7", DeployOptions{}); err == nil {
+		t.Error("expected a project without staging to be refused")
+	}
+	if _, err := m.Deploy(context.Background(), "web-app", "", DeployOptions{}); err == nil {
+		t.Error("expected a deployment without a bead to be refused")
+	}
+	m.SetStaging("web-app", &config.StagingConfig{Manifests: "deploy", Chart: "chart"})
+	if _, err := m.Deploy(context.Background(), "web-app", "loom-7", DeployOptions{}); err == nil {
+		t.Error("expected manifests and chart together to be refused")
+	}
+
+	f.handlers["kubectl create"] = func(args []string) ([]byte, []byte, error) {
+		return nil, []byte("error: You must be logged in to the server (Unauthorized)"), errors.New("exit status 1")
+	}
+	m.SetStaging("web-app", &config.StagingConfig{Manifests: "app.yaml"})
+	if _, err := m.Deploy(context.Background(), "web-app", "loom-7", DeployOptions{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected missing manifests to be refused, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("kind: Pod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Deploy(context.Background(), "web-app", "loom-7", DeployOptions{}); err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Errorf("expected the cluster error, got %v", err)
+	}
+}
+
+func TestBeadClosedTearsDown(t *testing.T) {
+	m, f, beads, _ := fakeManager(t, &config.StagingConfig{Manifests: "deploy"})
+	if err := m.BeadClosed(context.Background(), "loom-7"); err != nil || f.Called("kubectl --context staging delete") != "" {
+		t.Fatalf("an undeployed bead should not be torn down: %v %v", err, f.Calls)
+	}
+
+	beads["loom-7"].Context = map[string]string{ContextNamespace: "loom-web-app-loom-7", ContextStatus: StatusReady}
+	if err := m.BeadClosed(context.Background(), "loom-7"); err != nil {
+		t.Fatal(err)
+	}
+	if f.Called("kubectl --context staging delete namespace loom-web-app-loom-7 --ignore-not-found --wait=false") == "" {
+		t.Errorf("namespace was not deleted: %v", f.Calls)
+	}
+	if beads["loom-7"].Context[ContextStatus] != StatusTornDown {
+		t.Errorf("teardown was not recorded: %v", beads["loom-7"].Context)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	m, f, beads, _ := fakeManager(t, &config.StagingConfig{Manifests: "deploy"})
+	dep, err := m.Status(context.Background(), "web-app", "loom-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dep.Success || len(dep.Pods) != 2 || beads["loom-7"].Context[ContextStatus] != StatusFailed {
+		t.Errorf("unexpected status %+v", dep)
+	}
+	if !strings.Contains(f.Called("kubectl --context staging rollout status deployment.apps/web"), "--watch=false") {
+		t.Errorf("status should not wait for rollouts: %v", f.Calls)
+	}
+}
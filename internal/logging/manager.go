@@ -2,15 +2,18 @@ package logging
 
 import (
 	"container/ring"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
-	"strings"
+	"log/slog"
 	"sync"
 	"time"
 )
 
+var managerLog = Module("logging")
+
 const (
 	// MaxBufferSize is the maximum number of log entries to keep in memory
 	MaxBufferSize = 10000
@@ -53,7 +56,7 @@ func NewManager(db *sql.DB) *Manager {
 
 	// Initialize database schema
 	if err := m.initSchema(); err != nil {
-		log.Printf("Warning: Failed to initialize logging schema: %v", err)
+		managerLog.Warn("Failed to initialize logging schema", "error", err)
 	}
 
 	return m
@@ -98,7 +101,7 @@ func (m *Manager) initSchema() error {
 
 	for _, indexSQL := range indexes {
 		if _, err := m.db.Exec(indexSQL); err != nil {
-			log.Printf("Warning: Failed to create index: %v", err)
+			managerLog.Warn("Failed to create index", "error", err)
 		}
 	}
 
@@ -168,7 +171,8 @@ func (m *Manager) persistLog(entry LogEntry) {
 	`, entry.ID, entry.Timestamp, entry.Level, entry.Source, entry.Message, metadataJSON, agentID, beadID, projectID, providerID)
 
 	if err != nil {
-		log.Printf("Failed to persist log entry: %v", err)
+		// Straight to the output: through the manager this would persist again
+		slog.New(current.Load().out).Error("Failed to persist log entry", "module", "logging", "error", err)
 	}
 }
 
@@ -242,6 +246,42 @@ func (m *Manager) Query(limit int, levelFilter, sourceFilter, agentID, beadID, p
 		return m.GetRecent(limit, levelFilter, sourceFilter, agentID, beadID, projectID, since, until), nil
 	}
 
+	query, args := logsQuery(levelFilter, sourceFilter, agentID, beadID, projectID, since, until)
+	query += " ORDER BY timestamp DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	logs := make([]LogEntry, 0)
+	err := m.scanLogs(context.Background(), query, args, func(entry LogEntry) error {
+		logs = append(logs, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// Each calls fn with each stored entry matching the filters, oldest first,
+// without loading them all. It stops at the first error fn returns.
+func (m *Manager) Each(ctx context.Context, levelFilter, sourceFilter, agentID, beadID, projectID string, since, until time.Time, fn func(LogEntry) error) error {
+	if m.db == nil {
+		recent := m.GetRecent(MaxBufferSize, levelFilter, sourceFilter, agentID, beadID, projectID, since, until)
+		for i := len(recent) - 1; i >= 0; i-- {
+			if err := fn(recent[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	query, args := logsQuery(levelFilter, sourceFilter, agentID, beadID, projectID, since, until)
+	return m.scanLogs(ctx, query+" ORDER BY timestamp ASC", args, fn)
+}
+
+// logsQuery selects the stored entries matching the filters
+func logsQuery(levelFilter, sourceFilter, agentID, beadID, projectID string, since, until time.Time) (string, []interface{}) {
 	query := `SELECT id, timestamp, level, source, message, metadata_json FROM logs WHERE 1=1`
 	args := make([]interface{}, 0)
 
@@ -273,39 +313,38 @@ func (m *Manager) Query(limit int, levelFilter, sourceFilter, agentID, beadID, p
 		query += " AND project_id = ?"
 		args = append(args, projectID)
 	}
+	return query, args
+}
 
-	query += " ORDER BY timestamp DESC"
-	if limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, limit)
-	}
-
-	rows, err := m.db.Query(query, args...)
+// scanLogs runs a logsQuery and calls fn with each entry
+func (m *Manager) scanLogs(ctx context.Context, query string, args []interface{}, fn func(LogEntry) error) error {
+	rows, err := m.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query logs: %w", err)
+		return fmt.Errorf("failed to query logs: %w", err)
 	}
 	defer rows.Close()
 
-	logs := make([]LogEntry, 0)
 	for rows.Next() {
 		var entry LogEntry
 		var metadataJSON *string
 
 		err := rows.Scan(&entry.ID, &entry.Timestamp, &entry.Level, &entry.Source, &entry.Message, &metadataJSON)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan log entry: %w", err)
+			return fmt.Errorf("failed to scan log entry: %w", err)
 		}
 
 		if metadataJSON != nil && *metadataJSON != "" {
 			if err := json.Unmarshal([]byte(*metadataJSON), &entry.Metadata); err != nil {
-				log.Printf("Warning: Failed to unmarshal log metadata: %v", err)
+				managerLog.Warn("Failed to unmarshal log metadata", "error", err)
 			}
 		}
 
-		logs = append(logs, entry)
+		if err := fn(entry); err != nil {
+			return err
+		}
 	}
 
-	return logs, nil
+	return rows.Err()
 }
 
 func getMetaString(meta map[string]interface{}, key string) string {
@@ -345,49 +384,11 @@ func (m *Manager) Error(source, message string, metadata map[string]interface{})
 	m.Log(LogLevelError, source, message, metadata)
 }
 
-// logInterceptWriter implements io.Writer so that Go's standard log package
-// output is captured and routed through the logging manager.
-type logInterceptWriter struct {
-	manager *Manager
-}
-
-// Write implements io.Writer. It parses "[Component] message" format from
-// standard log.Printf calls and routes them into the structured log system.
-func (w *logInterceptWriter) Write(p []byte) (n int, err error) {
-	msg := strings.TrimSpace(string(p))
-	// Strip the default log prefix (date/time) if present
-	// Standard log format: "2006/01/02 15:04:05 message"
-	if len(msg) > 20 && msg[4] == '/' && msg[7] == '/' && msg[10] == ' ' {
-		msg = strings.TrimSpace(msg[20:])
-	}
-
-	level := LogLevelInfo
-	source := "system"
-
-	// Detect level from content
-	lowerMsg := strings.ToLower(msg)
-	if strings.Contains(lowerMsg, "error") || strings.Contains(lowerMsg, "fail") {
-		level = LogLevelError
-	} else if strings.Contains(lowerMsg, "warn") {
-		level = LogLevelWarn
-	}
-
-	// Parse [Source] prefix: "[Dispatcher] message" → source=dispatcher
-	if len(msg) > 2 && msg[0] == '[' {
-		end := strings.Index(msg, "]")
-		if end > 1 {
-			source = strings.ToLower(msg[1:end])
-			msg = strings.TrimSpace(msg[end+1:])
-		}
-	}
-
-	w.manager.Log(level, source, msg, nil)
-	return len(p), nil
-}
-
-// InstallLogInterceptor redirects Go's standard log package through this manager.
-// Call this once at startup after creating the manager.
+// InstallLogInterceptor sends structured log records, including Go's
+// standard log package output, to this manager. Call this once at startup
+// after creating the manager.
 func (m *Manager) InstallLogInterceptor() {
-	log.SetOutput(&logInterceptWriter{manager: m})
-	log.SetFlags(0) // We handle timestamps ourselves
+	SetManager(m)
+	log.SetOutput(bridgeWriter{})
+	log.SetFlags(0) // Timestamps come from the structured logger
 }
@@ -0,0 +1,451 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Correlation fields carried in a context and added to every line logged
+// with it
+const (
+	FieldRequestID = "request_id"
+	FieldProjectID = "project_id"
+	FieldBeadID    = "bead_id"
+	FieldAgentID   = "agent_id"
+)
+
+// Options configures the process-wide structured logger
+type Options struct {
+	Level   string            // debug, info (default), warn or error
+	Format  string            // "text" (default) or "json"
+	Modules map[string]string // Per-module level overrides
+	Output  io.Writer         // Default os.Stderr
+}
+
+// LevelSettings are the current base level and per-module overrides
+type LevelSettings struct {
+	Level   string            `json:"level"`
+	Format  string            `json:"format"`
+	Modules map[string]string `json:"modules"`
+}
+
+// sink is where records end up: the configured output handler and,
+// once attached, the log manager behind the logs API
+type sink struct {
+	out     slog.Handler
+	format  string
+	manager *Manager
+}
+
+var (
+	current atomic.Pointer[sink]
+	levels  = &moduleLevels{base: slog.LevelInfo, modules: map[string]slog.Level{}}
+)
+
+func init() {
+	current.Store(&sink{out: newOutput(os.Stderr, "text"), format: "text"})
+}
+
+func newOutput(w io.Writer, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug} // Levels are checked by Handler
+	if format == "json" {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// Setup configures the structured logger and routes the standard log
+// package through it, so "[Module] message" lines become records of that
+// module
+func Setup(opts Options) error {
+	format := strings.ToLower(opts.Format)
+	switch format {
+	case "":
+		format = "text"
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown log format %q", opts.Format)
+	}
+	base := slog.LevelInfo
+	if opts.Level != "" {
+		var err error
+		if base, err = ParseLevel(opts.Level); err != nil {
+			return err
+		}
+	}
+	modules := make(map[string]slog.Level, len(opts.Modules))
+	for module, level := range opts.Modules {
+		l, err := ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("module %s: %w", module, err)
+		}
+		modules[strings.ToLower(module)] = l
+	}
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+
+	levels.set(base, modules)
+	prev := current.Load()
+	current.Store(&sink{out: newOutput(out, format), format: format, manager: prev.manager})
+
+	slog.SetDefault(slog.New(&Handler{}))
+	// After SetDefault so the bridge, not slog's own, receives log output
+	log.SetOutput(bridgeWriter{})
+	log.SetFlags(0)
+	return nil
+}
+
+// SetManager sends every record to m as well, for the logs API and stream
+func SetManager(m *Manager) {
+	prev := current.Load()
+	current.Store(&sink{out: prev.out, format: prev.format, manager: m})
+}
+
+// Module returns the logger of a named module. Its level follows the
+// module's override, or the base level.
+func Module(name string) *slog.Logger {
+	return slog.New(&Handler{module: strings.ToLower(name)})
+}
+
+// ParseLevel parses debug, info, warn (or warning) and error
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case LogLevelDebug:
+		return slog.LevelDebug, nil
+	case LogLevelInfo:
+		return slog.LevelInfo, nil
+	case LogLevelWarn, "warning":
+		return slog.LevelWarn, nil
+	case LogLevelError:
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+func levelName(l slog.Level) string {
+	switch {
+	case l >= slog.LevelError:
+		return LogLevelError
+	case l >= slog.LevelWarn:
+		return LogLevelWarn
+	case l >= slog.LevelInfo:
+		return LogLevelInfo
+	}
+	return LogLevelDebug
+}
+
+// SetLevel changes the base level at runtime
+func SetLevel(level string) error {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	levels.mu.Lock()
+	levels.base = l
+	levels.mu.Unlock()
+	return nil
+}
+
+// SetModuleLevel overrides one module's level at runtime; an empty level
+// removes the override
+func SetModuleLevel(module, level string) error {
+	module = strings.ToLower(strings.TrimSpace(module))
+	if module == "" {
+		return fmt.Errorf("module is required")
+	}
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	if level == "" {
+		delete(levels.modules, module)
+		return nil
+	}
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	levels.modules[module] = l
+	return nil
+}
+
+// Levels returns the current level settings
+func Levels() LevelSettings {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	settings := LevelSettings{
+		Level:   levelName(levels.base),
+		Format:  current.Load().format,
+		Modules: make(map[string]string, len(levels.modules)),
+	}
+	for module, l := range levels.modules {
+		settings.Modules[module] = levelName(l)
+	}
+	return settings
+}
+
+type moduleLevels struct {
+	mu      sync.RWMutex
+	base    slog.Level
+	modules map[string]slog.Level
+}
+
+func (m *moduleLevels) set(base slog.Level, modules map[string]slog.Level) {
+	m.mu.Lock()
+	m.base = base
+	m.modules = modules
+	m.mu.Unlock()
+}
+
+func (m *moduleLevels) enabled(module string, l slog.Level) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if min, ok := m.modules[module]; ok {
+		return l >= min
+	}
+	return l >= m.base
+}
+
+type fieldsKey struct{}
+
+// WithFields returns a context carrying correlation fields (key, value
+// pairs such as FieldBeadID, id), added to every line logged with it.
+// Empty values are ignored.
+func WithFields(ctx context.Context, kv ...string) context.Context {
+	prev, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	fields := append([]slog.Attr(nil), prev...)
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] == "" {
+			continue
+		}
+		replaced := false
+		for j := range fields {
+			if fields[j].Key == kv[i] {
+				fields[j] = slog.String(kv[i], kv[i+1])
+				replaced = true
+			}
+		}
+		if !replaced {
+			fields = append(fields, slog.String(kv[i], kv[i+1]))
+		}
+	}
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// Field returns a correlation field of ctx
+func Field(ctx context.Context, key string) string {
+	fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	for _, f := range fields {
+		if f.Key == key {
+			return f.Value.String()
+		}
+	}
+	return ""
+}
+
+// Handler is the slog handler behind Module and the default logger. It
+// checks module levels, adds correlation fields from the context and
+// writes to the current sink, so loggers created before Setup follow it.
+type Handler struct {
+	module string
+	attrs  []slog.Attr
+	groups []string
+}
+
+// Enabled implements slog.Handler
+func (h *Handler) Enabled(ctx context.Context, l slog.Level) bool {
+	return levels.enabled(h.module, l)
+}
+
+// WithAttrs implements slog.Handler
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	if len(h.groups) == 0 {
+		// A module attr selects the module rather than being repeated
+		kept := make([]slog.Attr, 0, len(attrs))
+		for _, a := range attrs {
+			if a.Key == "module" {
+				next.module = strings.ToLower(a.Value.String())
+				continue
+			}
+			kept = append(kept, a)
+		}
+		attrs = kept
+	}
+	next.attrs = append(append([]slog.Attr(nil), h.attrs...), groupAttrs(h.groups, attrs)...)
+	return &next
+}
+
+// WithGroup implements slog.Handler
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.groups = append(append([]string(nil), h.groups...), name)
+	return &next
+}
+
+// Handle implements slog.Handler
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	s := current.Load()
+
+	var own []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		own = append(own, a)
+		return true
+	})
+	own = append(append([]slog.Attr(nil), h.attrs...), groupAttrs(h.groups, own)...)
+
+	var attrs []slog.Attr
+	if h.module != "" {
+		attrs = append(attrs, slog.String("module", h.module))
+	}
+	if ctx != nil {
+		// Correlation fields, unless the line sets them itself
+		fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+		for _, f := range fields {
+			if !hasKey(own, f.Key) {
+				attrs = append(attrs, f)
+			}
+		}
+	}
+	attrs = append(attrs, own...)
+
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	out.AddAttrs(attrs...)
+	err := s.out.Handle(ctx, out)
+
+	if s.manager != nil {
+		source := h.module
+		if source == "" {
+			source = "system"
+		}
+		var metadata map[string]interface{}
+		if len(attrs) > 0 {
+			metadata = make(map[string]interface{}, len(attrs))
+			for _, a := range attrs {
+				if a.Key != "module" {
+					metadata[a.Key] = attrValue(a.Value)
+				}
+			}
+		}
+		s.manager.Log(levelName(r.Level), source, r.Message, metadata)
+	}
+	return err
+}
+
+func hasKey(attrs []slog.Attr, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Attrs turns a field map into attributes sorted by key
+func Attrs(fields map[string]interface{}) []slog.Attr {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, fields[k]))
+	}
+	return attrs
+}
+
+// groupAttrs nests attrs under the open groups
+func groupAttrs(groups []string, attrs []slog.Attr) []slog.Attr {
+	if len(groups) == 0 || len(attrs) == 0 {
+		return attrs
+	}
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
+	g := slog.Group(groups[len(groups)-1], args...)
+	for i := len(groups) - 2; i >= 0; i-- {
+		g = slog.Group(groups[i], g)
+	}
+	return []slog.Attr{g}
+}
+
+func attrValue(v slog.Value) interface{} {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := make(map[string]interface{})
+		for _, a := range v.Group() {
+			group[a.Key] = attrValue(a.Value)
+		}
+		return group
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+		return v.Any()
+	}
+	return v.Any()
+}
+
+// bridgeWriter receives standard log package output. A "[Module]" prefix
+// selects the module; the level is guessed from the text.
+type bridgeWriter struct{}
+
+func (bridgeWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		module, level, msg := parseStdLine(line)
+		if msg == "" {
+			continue
+		}
+		logger := Module(module)
+		if logger.Enabled(context.Background(), level) {
+			logger.Log(context.Background(), level, msg)
+		}
+	}
+	return len(p), nil
+}
+
+// parseStdLine splits a standard log line into module, level and message
+func parseStdLine(line string) (string, slog.Level, string) {
+	msg := strings.TrimSpace(line)
+	// Strip a date/time prefix ("2006/01/02 15:04:05 message")
+	if len(msg) > 20 && msg[4] == '/' && msg[7] == '/' && msg[10] == ' ' {
+		msg = strings.TrimSpace(msg[20:])
+	}
+
+	module := ""
+	if len(msg) > 2 && msg[0] == '[' {
+		if end := strings.Index(msg, "]"); end > 1 {
+			module = strings.ToLower(msg[1:end])
+			msg = strings.TrimSpace(msg[end+1:])
+		}
+	}
+
+	level := slog.LevelInfo
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.HasPrefix(lower, "debug"):
+		level = slog.LevelDebug
+	case strings.Contains(lower, "error") || strings.Contains(lower, "fail") || strings.HasPrefix(lower, "critical"):
+		level = slog.LevelError
+	case strings.Contains(lower, "warn"):
+		level = slog.LevelWarn
+	}
+	if module == "" {
+		module = "system"
+	}
+	return module, level, msg
+}
@@ -0,0 +1,170 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupJSON(t *testing.T, opts Options) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	opts.Format = "json"
+	opts.Output = &buf
+	if err := Setup(opts); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	t.Cleanup(func() {
+		SetManager(nil)
+		_ = Setup(Options{})
+	})
+	return &buf
+}
+
+func jsonLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("line is not JSON: %q", line)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestModuleLoggerWithCorrelation(t *testing.T) {
+	buf := setupJSON(t, Options{})
+	logger := Module("Dispatcher")
+
+	ctx := WithFields(context.Background(), FieldRequestID, "req-1", FieldBeadID, "bd-1", FieldAgentID, "")
+	ctx = WithFields(ctx, FieldBeadID, "bd-2")
+	logger.InfoContext(ctx, "Assigning bead", "error", errors.New("boom"))
+	logger.DebugContext(ctx, "hidden at info")
+
+	lines := jsonLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d: %s", len(lines), buf)
+	}
+	line := lines[0]
+	if line["module"] != "dispatcher" || line["msg"] != "Assigning bead" || line["level"] != "INFO" {
+		t.Errorf("unexpected line %v", line)
+	}
+	if line["request_id"] != "req-1" || line["bead_id"] != "bd-2" || line["error"] != "boom" {
+		t.Errorf("expected correlation fields, got %v", line)
+	}
+	if _, ok := line["agent_id"]; ok {
+		t.Errorf("expected empty fields to be skipped, got %v", line)
+	}
+	if Field(ctx, FieldBeadID) != "bd-2" {
+		t.Errorf("expected the later value to win, got %q", Field(ctx, FieldBeadID))
+	}
+
+	// A field set on the line itself is not repeated from the context
+	buf.Reset()
+	logger.InfoContext(ctx, "Explicit", "bead_id", "bd-3")
+	if got := strings.Count(buf.String(), `"bead_id"`); got != 1 {
+		t.Errorf("expected bead_id once, got %d: %s", got, buf)
+	}
+}
+
+func TestModuleLevels(t *testing.T) {
+	buf := setupJSON(t, Options{Level: "warn", Modules: map[string]string{"worker": "debug"}})
+
+	Module("worker").Debug("worker debug")
+	Module("dispatcher").Info("dispatcher info")
+	Module("dispatcher").Warn("dispatcher warn")
+	if got := buf.String(); !strings.Contains(got, "worker debug") || strings.Contains(got, "dispatcher info") || !strings.Contains(got, "dispatcher warn") {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	// Runtime changes apply to existing loggers
+	dispatcher := Module("dispatcher")
+	if err := SetModuleLevel("dispatcher", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if !dispatcher.Enabled(context.Background(), -4) {
+		t.Error("expected debug to be enabled for dispatcher")
+	}
+	if err := SetModuleLevel("dispatcher", ""); err != nil {
+		t.Fatal(err)
+	}
+	if dispatcher.Enabled(context.Background(), 0) {
+		t.Error("expected the override to be removed")
+	}
+	if err := SetLevel("loud"); err == nil {
+		t.Error("expected an unknown level to be rejected")
+	}
+
+	settings := Levels()
+	if settings.Level != "warn" || settings.Format != "json" || settings.Modules["worker"] != "debug" {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+	if err := Setup(Options{Format: "xml"}); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
+
+func TestStandardLogBridge(t *testing.T) {
+	buf := setupJSON(t, Options{})
+	m := NewManager(nil)
+	SetManager(m)
+
+	log.Printf("[Worker] Failed to update conversation: %v", "db closed")
+	log.Printf("plain message")
+
+	lines := jsonLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %s", len(lines), buf)
+	}
+	if lines[0]["module"] != "worker" || lines[0]["level"] != "ERROR" || lines[0]["msg"] != "Failed to update conversation: db closed" {
+		t.Errorf("unexpected bridged line %v", lines[0])
+	}
+	if lines[1]["module"] != "system" || lines[1]["level"] != "INFO" {
+		t.Errorf("unexpected bridged line %v", lines[1])
+	}
+
+	// Records reach the manager with their fields as metadata
+	Module("actions").InfoContext(WithFields(context.Background(), FieldBeadID, "bd-9"), "action executed", "action_type", "run_tests")
+	deadline := time.Now().Add(time.Second)
+	for {
+		recent := m.GetRecent(10, "", "actions", "", "bd-9", "", time.Time{}, time.Time{})
+		if len(recent) == 1 {
+			if recent[0].Metadata["action_type"] != "run_tests" {
+				t.Errorf("unexpected metadata %v", recent[0].Metadata)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the manager to receive the record, got %v", recent)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestParseStdLine(t *testing.T) {
+	tests := []struct {
+		line, module, msg string
+		level             string
+	}{
+		{"2024/01/02 15:04:05 [Dispatcher] Parked", "dispatcher", "Parked", "info"},
+		{"[Commit] WARNING: lock timed out", "commit", "WARNING: lock timed out", "warn"},
+		{"Debug: heartbeat", "system", "Debug: heartbeat", "debug"},
+		{"CRITICAL disk full", "system", "CRITICAL disk full", "error"},
+	}
+	for _, tt := range tests {
+		module, level, msg := parseStdLine(tt.line)
+		if module != tt.module || msg != tt.msg || levelName(level) != tt.level {
+			t.Errorf("parseStdLine(%q) = %s %s %q", tt.line, module, levelName(level), msg)
+		}
+	}
+}
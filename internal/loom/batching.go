@@ -0,0 +1,45 @@
+package loom
+
+import (
+	"context"
+
+	"github.com/jordanhubbard/loom/internal/memory"
+	"github.com/jordanhubbard/loom/internal/provider"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// providerEmbedder embeds text with a registered provider, through the
+// request batcher when batching is on
+type providerEmbedder struct {
+	registry   *provider.Registry
+	batcher    *provider.Batcher
+	providerID string
+	model      string
+}
+
+// Embed returns one embedding per text
+func (e providerEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.batcher != nil {
+		return e.batcher.Embed(ctx, e.providerID, e.model, texts)
+	}
+	resp, err := e.registry.SendEmbeddings(ctx, e.providerID, &provider.EmbeddingRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Embeddings, nil
+}
+
+// newEmbedder returns the embedder for lessons and bead estimates: the
+// configured embedding provider with the hash embedder as fallback, or nil
+// to keep the hash embedder
+func newEmbedder(registry *provider.Registry, batcher *provider.Batcher, cfg config.RequestBatchingConfig) memory.Embedder {
+	if cfg.EmbeddingProvider == "" {
+		return nil
+	}
+	return memory.NewFallbackEmbedder(providerEmbedder{
+		registry:   registry,
+		batcher:    batcher,
+		providerID: cfg.EmbeddingProvider,
+		model:      cfg.EmbeddingModel,
+	})
+}
@@ -236,12 +236,13 @@ func (a *Loom) ReloadFromDatabase(ctx context.Context) error {
 			continue
 		}
 		_ = a.providerRegistry.Register(&provider.ProviderConfig{
-			ID:       p.ID,
-			Name:     p.Name,
-			Type:     p.Type,
-			Endpoint: normalizeProviderEndpoint(p.Endpoint),
-			APIKey:   "",
-			Model:    p.Model,
+			ID:        p.ID,
+			Name:      p.Name,
+			Type:      p.Type,
+			Endpoint:  normalizeProviderEndpoint(p.Endpoint),
+			Endpoints: p.Endpoints,
+			APIKey:    "",
+			Model:     p.Model,
 		})
 	}
 
@@ -0,0 +1,80 @@
+package loom
+
+import (
+	"context"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/activity"
+	"github.com/jordanhubbard/loom/internal/digest"
+	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
+)
+
+// maxDigestPullRequests caps the merged pull requests one digest reads
+const maxDigestPullRequests = 500
+
+// activityPullRequests finds merged pull requests in the activity feed,
+// where GitHub webhooks record closed pull requests
+type activityPullRequests struct {
+	activities *activity.Manager
+}
+
+// MergedPullRequests returns the project's pull requests merged in
+// [since, until]
+func (s activityPullRequests) MergedPullRequests(ctx context.Context, projectID string, since, until time.Time) ([]*digest.PullRequest, error) {
+	list, err := s.activities.GetActivities(activity.ActivityFilters{
+		ProjectIDs: []string{projectID},
+		EventType:  string(eventbus.EventTypePullRequestClosed),
+		Since:      since,
+		Until:      until,
+		Limit:      maxDigestPullRequests,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var prs []*digest.PullRequest
+	for _, a := range list {
+		if a.ProjectID != projectID || a.Action != "merged" {
+			continue
+		}
+		pr := &digest.PullRequest{Title: a.ResourceTitle, MergedAt: a.Timestamp}
+		if n, ok := a.Metadata["pr_number"].(float64); ok {
+			pr.Number = int(n)
+		}
+		pr.URL, _ = a.Metadata["pr_url"].(string)
+		pr.Author, _ = a.Metadata["author"].(string)
+		prs = append(prs, pr)
+	}
+	return prs, nil
+}
+
+// digestProviderID picks the provider digest summaries are written with:
+// the best-scoring active one
+func (a *Loom) digestProviderID() string {
+	if a.providerRegistry == nil {
+		return ""
+	}
+	if active := a.providerRegistry.ListActive(); len(active) > 0 {
+		return active[0].Config.ID
+	}
+	return ""
+}
+
+// publishDigest announces a generated digest, so the activity feed,
+// notifications and the OpenClaw bridge deliver it
+func (a *Loom) publishDigest(r *digest.Report) {
+	if a.eventBus == nil {
+		return
+	}
+	_ = a.eventBus.Publish(&eventbus.Event{
+		Type:      eventbus.EventTypeDigestGenerated,
+		Source:    "digest",
+		ProjectID: r.ProjectID,
+		Data: map[string]interface{}{
+			"digest_id": r.ID,
+			"title":     r.Title(),
+			"headline":  r.Headline(),
+			"period":    r.Period,
+			"markdown":  digest.Markdown(r),
+		},
+	})
+}
@@ -0,0 +1,30 @@
+package loom
+
+import (
+	"context"
+
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// estimateBead predicts a new bead's cost and time so it is on record
+// before any agent starts on it
+func (a *Loom) estimateBead(bead *models.Bead) {
+	if a.estimator == nil {
+		return
+	}
+	if _, err := a.estimator.Estimate(context.Background(), bead); err != nil {
+		logging.Module("estimate").Warn("Failed to estimate bead", "bead_id", bead.ID, "error", err)
+	}
+}
+
+// recordBeadActual records what a closed bead really cost next to its
+// estimate, so later estimates learn from it
+func (a *Loom) recordBeadActual(beadID string) {
+	if a.estimator == nil {
+		return
+	}
+	if _, err := a.estimator.RecordActual(context.Background(), beadID); err != nil {
+		logging.Module("estimate").Warn("Failed to record bead actuals", "bead_id", beadID, "error", err)
+	}
+}
@@ -0,0 +1,18 @@
+package loom
+
+import (
+	"context"
+
+	"github.com/jordanhubbard/loom/internal/logging"
+)
+
+// concludeExperiment records how a bead in an A/B experiment turned out:
+// success when it closed, failure when it was blocked
+func (a *Loom) concludeExperiment(beadID string, success bool) {
+	if a.experiments == nil {
+		return
+	}
+	if err := a.experiments.Conclude(context.Background(), beadID, success); err != nil {
+		logging.Module("experiment").Warn("Failed to record experiment outcome", "bead_id", beadID, "error", err)
+	}
+}
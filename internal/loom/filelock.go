@@ -5,9 +5,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/apperr"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
+// defaultFileLockTimeout applies when agents.file_lock_timeout isn't set
+const defaultFileLockTimeout = 10 * time.Minute
+
 // FileLockManager manages file locks to prevent merge conflicts
 type FileLockManager struct {
 	locks   map[string]*models.FileLock // key: projectID:filePath
@@ -17,6 +21,9 @@ type FileLockManager struct {
 
 // NewFileLockManager creates a new file lock manager
 func NewFileLockManager(timeout time.Duration) *FileLockManager {
+	if timeout <= 0 {
+		timeout = defaultFileLockTimeout
+	}
 	return &FileLockManager{
 		locks:   make(map[string]*models.FileLock),
 		timeout: timeout,
@@ -28,7 +35,9 @@ func (m *FileLockManager) lockKey(projectID, filePath string) string {
 	return fmt.Sprintf("%s:%s", projectID, filePath)
 }
 
-// AcquireLock attempts to acquire a lock on a file
+// AcquireLock attempts to acquire a lock on a file. A lock the agent
+// already holds is renewed. A lock held by anyone else is reported
+// as a conflict whose details name the holder and when the lock expires.
 func (m *FileLockManager) AcquireLock(projectID, filePath, agentID, beadID string) (*models.FileLock, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -39,7 +48,11 @@ func (m *FileLockManager) AcquireLock(projectID, filePath, agentID, beadID strin
 	if lock, exists := m.locks[key]; exists {
 		// Check if lock has expired
 		if lock.ExpiresAt.IsZero() || time.Now().Before(lock.ExpiresAt) {
-			return nil, fmt.Errorf("file already locked by agent %s", lock.AgentID)
+			if lock.AgentID == agentID {
+				lock.ExpiresAt = time.Now().Add(m.timeout)
+				return lock, nil
+			}
+			return nil, lockConflict(lock)
 		}
 		// Lock expired, can proceed
 		delete(m.locks, key)
@@ -82,6 +95,55 @@ func (m *FileLockManager) ReleaseLock(projectID, filePath, agentID string) error
 	return nil
 }
 
+// lockConflict describes a lock another agent holds
+func lockConflict(lock *models.FileLock) error {
+	retryAfter := int(time.Until(lock.ExpiresAt).Seconds()) + 1
+	return apperr.Conflict("file %s already locked by agent %s until %s", lock.FilePath, lock.AgentID, lock.ExpiresAt.Format(time.RFC3339)).
+		WithDetail("error_type", "file_locked").
+		WithDetail("path", lock.FilePath).
+		WithDetail("project_id", lock.ProjectID).
+		WithDetail("held_by_agent", lock.AgentID).
+		WithDetail("held_by_bead", lock.BeadID).
+		WithDetail("locked_at", lock.LockedAt).
+		WithDetail("expires_at", lock.ExpiresAt).
+		WithDetail("retry_after_seconds", retryAfter)
+}
+
+// ForceReleaseLock releases a file lock whoever holds it and returns the
+// released lock
+func (m *FileLockManager) ForceReleaseLock(projectID, filePath string) (*models.FileLock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := m.lockKey(projectID, filePath)
+	lock, exists := m.locks[key]
+	if !exists {
+		return nil, apperr.NotFound("no lock found for file: %s", filePath)
+	}
+	delete(m.locks, key)
+
+	return lock, nil
+}
+
+// ReleaseBeadLocks releases all locks taken for a bead
+func (m *FileLockManager) ReleaseBeadLocks(beadID string) int {
+	if beadID == "" {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	released := 0
+	for key, lock := range m.locks {
+		if lock.BeadID == beadID {
+			delete(m.locks, key)
+			released++
+		}
+	}
+
+	return released
+}
+
 // IsLocked checks if a file is currently locked
 func (m *FileLockManager) IsLocked(projectID, filePath string) bool {
 	m.mu.RLock()
@@ -0,0 +1,42 @@
+package loom
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/apperr"
+)
+
+func TestLockFiles(t *testing.T) {
+	a, tmp := newTestLoom(t)
+	defer os.RemoveAll(tmp)
+
+	ctx := context.Background()
+	first := actions.ActionContext{AgentID: "agent-1", BeadID: "bead-1", ProjectID: "loom"}
+	second := actions.ActionContext{AgentID: "agent-2", BeadID: "bead-2", ProjectID: "loom"}
+
+	if err := a.LockFiles(ctx, first, []string{"a.go"}); err != nil {
+		t.Fatal(err)
+	}
+	// b.go is free but a.go isn't, so neither is taken
+	err := a.LockFiles(ctx, second, []string{"b.go", "a.go"})
+	if apperr.CodeOf(err) != apperr.CodeConflict || apperr.DetailsOf(err)["held_by_bead"] != "bead-1" {
+		t.Fatalf("expected a conflict naming bead-1, got %v", err)
+	}
+	if a.fileLockManager.IsLocked("loom", "b.go") {
+		t.Error("expected the lock taken before the conflict to be dropped")
+	}
+
+	_, _ = a.GetCoEditStore().Open("loom", "c.go", "web-1", func() (string, error) { return "", nil })
+	err = a.LockFiles(ctx, first, []string{"c.go"})
+	if apperr.DetailsOf(err)["error_type"] != "file_co_edited" {
+		t.Errorf("expected a co-edited file to be refused, got %v", err)
+	}
+
+	a.releaseBeadLocks("bead-1")
+	if err := a.LockFiles(ctx, second, []string{"a.go"}); err != nil {
+		t.Errorf("expected the closed bead's lock to be gone, got %v", err)
+	}
+}
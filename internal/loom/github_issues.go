@@ -0,0 +1,27 @@
+package loom
+
+import (
+	"context"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/logging"
+)
+
+// githubCommentTimeout bounds reporting a closed bead back to its issue
+const githubCommentTimeout = time.Minute
+
+// reportToGitHubIssue comments on the GitHub issue a closed bead was
+// imported from, when its import asked for that. It runs in the background
+// so closing a bead never waits on GitHub.
+func (a *Loom) reportToGitHubIssue(beadID string) {
+	if a.githubImporter == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), githubCommentTimeout)
+		defer cancel()
+		if err := a.githubImporter.OnClosed(ctx, beadID); err != nil {
+			logging.Module("tracker").Warn("Failed to report closed bead to its GitHub issue", "bead_id", beadID, "error", err)
+		}
+	}()
+}
@@ -2,32 +2,61 @@ package loom
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jordanhubbard/loom/internal/actions"
 	"github.com/jordanhubbard/loom/internal/activity"
 	"github.com/jordanhubbard/loom/internal/agent"
 	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/attachments"
+	"github.com/jordanhubbard/loom/internal/backup"
 	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/internal/benchmarks"
+	"github.com/jordanhubbard/loom/internal/browser"
+	"github.com/jordanhubbard/loom/internal/ci"
+	"github.com/jordanhubbard/loom/internal/collaboration"
 	"github.com/jordanhubbard/loom/internal/comments"
+	"github.com/jordanhubbard/loom/internal/containers"
+	"github.com/jordanhubbard/loom/internal/contextpack"
+	"github.com/jordanhubbard/loom/internal/customfields"
 	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/dashboard"
+	"github.com/jordanhubbard/loom/internal/digest"
+	"github.com/jordanhubbard/loom/internal/estimate"
+	"github.com/jordanhubbard/loom/internal/experiment"
 	"github.com/jordanhubbard/loom/internal/decision"
+	"github.com/jordanhubbard/loom/internal/delegation"
+	"github.com/jordanhubbard/loom/internal/dependencies"
 	"github.com/jordanhubbard/loom/internal/dispatch"
 	"github.com/jordanhubbard/loom/internal/executor"
+	"github.com/jordanhubbard/loom/internal/features"
 	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/formatter"
+	"github.com/jordanhubbard/loom/internal/git"
 	"github.com/jordanhubbard/loom/internal/gitops"
+	"github.com/jordanhubbard/loom/internal/housekeeping"
+	"github.com/jordanhubbard/loom/internal/httpcheck"
+	"github.com/jordanhubbard/loom/internal/ide"
+	"github.com/jordanhubbard/loom/internal/impact"
+	"github.com/jordanhubbard/loom/internal/k8s"
 	"github.com/jordanhubbard/loom/internal/keymanager"
 	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/internal/maintenance"
+	"github.com/jordanhubbard/loom/internal/mcp"
+	"github.com/jordanhubbard/loom/internal/messaging"
 	"github.com/jordanhubbard/loom/internal/metrics"
 	"github.com/jordanhubbard/loom/internal/modelcatalog"
 	internalmodels "github.com/jordanhubbard/loom/internal/models"
@@ -37,21 +66,49 @@ import (
 	"github.com/jordanhubbard/loom/internal/openclaw"
 	"github.com/jordanhubbard/loom/internal/orgchart"
 	"github.com/jordanhubbard/loom/internal/patterns"
+	"github.com/jordanhubbard/loom/internal/policy"
+	"github.com/jordanhubbard/loom/internal/pricing"
 	"github.com/jordanhubbard/loom/internal/persona"
+	"github.com/jordanhubbard/loom/internal/profiling"
 	"github.com/jordanhubbard/loom/internal/project"
 	"github.com/jordanhubbard/loom/internal/provider"
+	"github.com/jordanhubbard/loom/internal/readonly"
+	"github.com/jordanhubbard/loom/internal/repomap"
+	"github.com/jordanhubbard/loom/internal/retention"
+	"github.com/jordanhubbard/loom/internal/review"
+	"github.com/jordanhubbard/loom/internal/roles"
 	"github.com/jordanhubbard/loom/internal/routing"
+	"github.com/jordanhubbard/loom/internal/search"
+	"github.com/jordanhubbard/loom/internal/scheduler"
+	"github.com/jordanhubbard/loom/internal/secretscan"
+	"github.com/jordanhubbard/loom/internal/securityscan"
+	"github.com/jordanhubbard/loom/internal/sla"
 	"github.com/jordanhubbard/loom/internal/temporal"
+	"github.com/jordanhubbard/loom/internal/testdb"
+	"github.com/jordanhubbard/loom/internal/testselect"
+	"github.com/jordanhubbard/loom/internal/tracker"
+	"github.com/jordanhubbard/loom/internal/transcribe"
 	temporalactivities "github.com/jordanhubbard/loom/internal/temporal/activities"
 	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
 	"github.com/jordanhubbard/loom/internal/temporal/workflows"
+	"github.com/jordanhubbard/loom/internal/warehouse"
 	"github.com/jordanhubbard/loom/internal/workflow"
+	"github.com/jordanhubbard/loom/internal/workingset"
 	"github.com/jordanhubbard/loom/pkg/config"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
 const readinessCacheTTL = 2 * time.Minute
 
+var (
+	loomLog         = logging.Module("loom")
+	providerLog     = logging.Module("providers")
+	actionLog       = logging.Module("actions")
+	autoFixLog      = logging.Module("autofix")
+	maintenanceLog  = logging.Module("maintenance")
+	dispatchLoopLog = logging.Module("dispatchloop")
+)
+
 type projectReadinessState struct {
 	ready     bool
 	issues    []string
@@ -77,23 +134,71 @@ type Loom struct {
 	modelCatalog        *modelcatalog.Catalog
 	gitopsManager       *gitops.Manager
 	shellExecutor       *executor.ShellExecutor
+	sessionManager      *executor.SessionManager
+	jobManager          *executor.JobManager
+	quotaManager        *executor.QuotaManager
 	logManager          *logging.Manager
 	activityManager     *activity.Manager
 	notificationManager *notifications.Manager
 	commentsManager     *comments.Manager
+	contextStore        *collaboration.ContextStore
+	coEditStore         *collaboration.CoEditStore
 	motivationRegistry  *motivation.Registry
 	motivationEngine    *motivation.Engine
 	idleDetector        *motivation.IdleDetector
 	workflowEngine      *workflow.Engine
 	patternManager      *patterns.Manager
+	securityScanner     *securityscan.Manager
+	dependencyManager   *dependencies.Manager
+	stagingManager      *k8s.Manager
+	testDatabases       *testdb.Manager
+	profiler            *profiling.Manager
+	scheduler           *scheduler.Scheduler
+	maintenance         *maintenance.Generator
+	customFields        *customfields.Registry
+	ciManager           *ci.Manager
+	tracker             *tracker.Manager
+	githubImporter      *tracker.GitHubImporter
+	workingSets         *workingset.Builder
+	repoMaps            *repomap.Generator
+	impactAnalyzer      *impact.Analyzer
+	backups             *backup.Manager
+	retention           *retention.Manager
+	sla                 *sla.Manager
+	searchIndexer       *search.Indexer
+	dashboard           *dashboard.Builder
+	digests             *digest.Generator
+	estimator           *estimate.Estimator
+	batcher             *provider.Batcher
+	attachments         *attachments.Manager
+	transcriber         transcribe.Transcriber
+	experiments         *experiment.Manager
+	pricing             *pricing.Service
+	analyticsQueue      *analytics.QueuedStorage
+	analyticsMirror     *warehouse.Mirror
+	mcpManager          *mcp.Manager
+	ideTracker          *ide.Tracker
+	idePairings         *ide.Pairings
+	roleRegistry        *roles.Registry
+	messageBus          *messaging.AgentMessageBus
+	delegations         *delegation.Manager
 	metrics             *metrics.Metrics
 	keyManager          *keymanager.KeyManager
+	features            *features.Registry
+	readOnly            *readonly.Switch
+	housekeeping        *housekeeping.Runner
+	policies            *policy.Engine
+	agentSpend          spendSource
+	approvalsMu         sync.Mutex
+	actionApprovals     map[string]string // Approval key to decision ID
 	doltCoordinator     *beads.DoltCoordinator
 	openclawClient      *openclaw.Client
 	openclawBridge      *openclaw.Bridge
 	readinessMu         sync.Mutex
 	readinessCache      map[string]projectReadinessState
 	readinessFailures   map[string]time.Time
+	dispatchTick        atomic.Int64 // Unix nanoseconds of the dispatch loop's last pass
+	dispatchInterval    atomic.Int64
 }
 
 // New creates a new Loom instance
@@ -104,6 +209,18 @@ func New(cfg *config.Config) (*Loom, error) {
 	}
 
 	providerRegistry := provider.NewRegistry()
+	if rec := cfg.ProviderRecording; rec.Mode != "" {
+		vcr, err := provider.NewVCR(rec.Mode, rec.Cassette)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize provider recording: %w", err)
+		}
+		providerRegistry.SetVCR(vcr)
+		loomLog.Info("Provider traffic recording", "mode", rec.Mode, "cassette", rec.Cassette)
+	}
+	if qcfg := provider.WithDefaults(cfg.ProviderQueue); qcfg.MaxWait > 0 {
+		providerRegistry.SetRequestQueue(provider.NewRequestQueue(provider.QueueConfigFrom(qcfg)))
+	}
+	providerRegistry.SetHedging(cfg.ProviderHedging)
 
 	// Initialize Temporal manager if configured
 	var temporalMgr *temporal.Manager
@@ -167,7 +284,7 @@ func New(cfg *config.Config) (*Loom, error) {
 			specs = append(specs, spec)
 		}
 		modelCatalog.Replace(specs)
-		log.Printf("[ModelCatalog] Loaded %d preferred models from config.yaml", len(specs))
+		loomLog.Info("Loaded preferred models from config.yaml", "count", len(specs))
 	}
 	// Database can override config (for runtime updates via API)
 	if db != nil {
@@ -175,7 +292,7 @@ func New(cfg *config.Config) (*Loom, error) {
 			var specs []internalmodels.ModelSpec
 			if err := json.Unmarshal([]byte(raw), &specs); err == nil && len(specs) > 0 {
 				modelCatalog.Replace(specs)
-				log.Printf("[ModelCatalog] Overrode with %d models from database", len(specs))
+				loomLog.Info("Overrode preferred models from database", "count", len(specs))
 			}
 		}
 	}
@@ -201,7 +318,7 @@ func New(cfg *config.Config) (*Loom, error) {
 		if p.IsSticky && cwd != "" {
 			if _, err := os.Stat(filepath.Join(cwd, ".git")); err == nil {
 				gitopsMgr.SetProjectWorkDir(p.ID, cwd)
-				log.Printf("[Loom] Project %s workdir set to source tree: %s", p.ID, cwd)
+				loomLog.Info("Project workdir set to source tree", "project_id", p.ID, "workdir", cwd)
 			}
 		}
 	}
@@ -213,11 +330,29 @@ func New(cfg *config.Config) (*Loom, error) {
 		agentMgr.GetWorkerPool().SetDatabase(db)
 	}
 
-	// Initialize shell executor if database is available
+	// Initialize shell executor if database is available. Background jobs work
+	// without a database; finished jobs are logged when one is available.
 	var shellExec *executor.ShellExecutor
+	var jobMgr *executor.JobManager
 	if db != nil {
 		shellExec = executor.NewShellExecutor(db.DB())
+		jobMgr = executor.NewJobManager(db.DB(), executor.JobConfig{})
+	} else {
+		jobMgr = executor.NewJobManager(nil, executor.JobConfig{})
 	}
+	sessionMgr := executor.NewSessionManager(executor.SessionConfig{})
+
+	// Per-project executor quotas apply to one-shot commands, jobs and sessions alike
+	projectQuotas := make(map[string]executor.ProjectQuota, len(cfg.Executor.ProjectQuotas))
+	for id, q := range cfg.Executor.ProjectQuotas {
+		projectQuotas[id] = executorQuota(q)
+	}
+	quotaMgr := executor.NewQuotaManager(executorQuota(cfg.Executor.DefaultQuota), projectQuotas)
+	if shellExec != nil {
+		shellExec.SetQuotaManager(quotaMgr)
+	}
+	jobMgr.SetQuotaManager(quotaMgr)
+	sessionMgr.SetQuotaManager(quotaMgr)
 	var logMgr *logging.Manager
 	if db != nil {
 		logMgr = logging.NewManager(db.DB())
@@ -247,12 +382,64 @@ func New(cfg *config.Config) (*Loom, error) {
 
 	// Initialize pattern manager and analytics logger if database is available
 	var patternMgr *patterns.Manager
+	var requestLogs retention.RequestLogPruner
+	var costs dashboard.CostSource
+	var requestHistory digest.CostSource
+	var spend spendSource
+	var pricingSvc *pricing.Service
+	var analyticsQueue *analytics.QueuedStorage
+	var analyticsMirror *warehouse.Mirror
 	if db != nil {
 		analyticsStorage, err := analytics.NewDatabaseStorage(db.DB())
 		if err == nil && analyticsStorage != nil {
+			requestLogs = analyticsStorage
+			costs = analyticsStorage
+			requestHistory = analyticsStorage
+			spend = analyticsStorage
 			patternMgr = patterns.NewManager(analyticsStorage, nil)
-			// Wire analytics logger to WorkerManager so LLM completions are logged
-			agentMgr.SetAnalyticsLogger(analytics.NewLogger(analyticsStorage, analytics.DefaultPrivacyConfig()))
+			if reports, err := patterns.NewDatabaseReportStore(db.DB()); err != nil {
+				loomLog.Warn("Pattern report history disabled", "error", err)
+			} else {
+				pcfg := patterns.WithDefaults(cfg.Patterns)
+				patternMgr.SetReportStore(reports, pcfg.ComparePeriod, patterns.ReportRetention(pcfg))
+			}
+			// Wire analytics logger to WorkerManager so LLM completions are logged,
+			// feeding each one to the online anomaly detector as it is saved
+			var logStorage analytics.Storage = analyticsStorage
+			if !cfg.Analytics.SyncWrites {
+				spillDir := cfg.Analytics.SpillDir
+				if spillDir == "" {
+					spillDir = analytics.DefaultSpillDir
+				}
+				analyticsQueue, err = analytics.NewQueuedStorage(analyticsStorage, analytics.QueueConfig{
+					QueueSize:     cfg.Analytics.QueueSize,
+					BatchSize:     cfg.Analytics.BatchSize,
+					FlushInterval: cfg.Analytics.FlushInterval,
+					SpillDir:      spillDir,
+					MaxSpillBytes: cfg.Analytics.MaxSpillBytes,
+				})
+				if err != nil {
+					loomLog.Warn("Request log queue disabled, writing synchronously", "error", err)
+				} else {
+					logStorage = analyticsQueue
+				}
+			}
+			requestLogger := analytics.NewLogger(logStorage, analytics.DefaultPrivacyConfig())
+			requestLogger.AddObserver(patternMgr.Observe)
+			if sink, err := warehouse.NewSink(cfg.Analytics.Sink); err != nil {
+				loomLog.Warn("Analytics sink disabled", "error", err)
+			} else if sink != nil {
+				analyticsMirror = warehouse.NewMirror(sink, warehouse.MirrorConfigFrom(cfg.Analytics.Sink))
+				requestLogger.AddObserver(analyticsMirror.ObserveLog)
+				patternMgr.SetReportHook(analyticsMirror.ObserveReport)
+			}
+			if svc, err := newPricingService(db.DB(), analyticsStorage, providerRegistry, cfg.Pricing); err != nil {
+				loomLog.Warn("Request pricing disabled", "error", err)
+			} else {
+				pricingSvc = svc
+				requestLogger.SetPricer(svc)
+			}
+			agentMgr.SetAnalyticsLogger(requestLogger)
 		}
 	}
 
@@ -286,57 +473,425 @@ func New(cfg *config.Config) (*Loom, error) {
 		modelCatalog:        modelCatalog,
 		gitopsManager:       gitopsMgr,
 		shellExecutor:       shellExec,
+		sessionManager:      sessionMgr,
+		jobManager:          jobMgr,
+		quotaManager:        quotaMgr,
 		logManager:          logMgr,
 		activityManager:     activityMgr,
 		notificationManager: notificationMgr,
 		commentsManager:     commentsMgr,
+		contextStore:        collaboration.NewContextStore(),
+		coEditStore:         collaboration.NewCoEditStore(),
 		motivationRegistry:  motivationRegistry,
 		idleDetector:        idleDetector,
 		workflowEngine:      workflowEngine,
 		patternManager:      patternMgr,
+		pricing:             pricingSvc,
+		analyticsQueue:      analyticsQueue,
+		analyticsMirror:     analyticsMirror,
 		metrics:             metrics.NewMetrics(),
 		doltCoordinator:     doltCoord,
 		openclawClient:      ocClient,
 		openclawBridge:      ocBridge,
+		agentSpend:          spend,
+		actionApprovals:     make(map[string]string),
+	}
+
+	autoSnapshotBytes := cfg.Git.AutoSnapshotPatchBytes
+	if autoSnapshotBytes == 0 {
+		autoSnapshotBytes = actions.DefaultAutoSnapshotPatchBytes
+	}
+	fileMgr := files.NewManager(gitopsMgr)
+	fileMgr.PatchFuzz = cfg.Git.PatchFuzz
+	var scanStore securityscan.Store
+	if db != nil {
+		scanStore = db
+	}
+	arb.securityScanner = securityscan.NewManager(gitopsMgr, scanStore, arb)
+	arb.securityScanner.AutoCreateBeads = cfg.Security.ScanCreateBeads
+	arb.dependencyManager = dependencies.NewManager(gitopsMgr, arb, arb.beadsManager)
+	arb.stagingManager = k8s.NewManager(cfg.Kubernetes, cfg.Projects, gitopsMgr, arb.beadsManager)
+	arb.testDatabases = testdb.NewManager(gitopsMgr, cfg.TestDatabases)
+
+	var scheduleStore scheduler.Store
+	if db != nil {
+		scheduleStore = db
+	}
+	flags, err := features.New(cfg.Features)
+	if err != nil {
+		loomLog.Warn("Invalid feature flag config", "error", err)
+	}
+	arb.features = flags
+	for _, state := range flags.List("") {
+		if state.Source != features.SourceDefault {
+			loomLog.Info("Feature flag set", "flag", state.Name, "enabled", state.Enabled)
+		}
+	}
+
+	arb.readOnly = readonly.New(cfg.ReadOnly)
+	if cfg.ReadOnly.Enabled {
+		loomLog.Warn("Starting in read-only mode", "reason", cfg.ReadOnly.Reason)
+	}
+
+	if cfg.Policy.Dir != "" {
+		engine, err := policy.NewEngine(cfg.Policy.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load action policies: %w", err)
+		}
+		arb.policies = engine
+		loomLog.Info("Action policies loaded", "dir", cfg.Policy.Dir, "policies", len(engine.Policies()))
+	}
+
+	digestSources := digest.Sources{
+		Beads:      arb.beadsManager,
+		Costs:      requestHistory,
+		Summarizer: digest.NewLLMSummarizer(providerRegistry, arb.digestProviderID),
+	}
+	if patternMgr != nil {
+		digestSources.Anomalies = patternMgr
+	}
+	if activityMgr != nil {
+		digestSources.PullRequests = activityPullRequests{activityMgr}
+	}
+	arb.digests = digest.NewGenerator(digestSources)
+	if db != nil {
+		arb.digests.SetStore(db)
+	}
+	arb.digests.SetDeliveryHook(arb.publishDigest)
+
+	arb.batcher = provider.NewBatcher(providerRegistry, cfg.RequestBatching)
+	embedder := newEmbedder(providerRegistry, arb.batcher, cfg.RequestBatching)
+
+	arb.estimator = estimate.New(estimate.Sources{
+		Beads:   arb.beadsManager,
+		Costs:   requestHistory,
+		History: arb.beadsManager,
+	})
+	if db != nil {
+		arb.estimator.SetStore(db)
+	}
+	if embedder != nil {
+		arb.estimator.SetEmbedder(embedder)
+	}
+
+	arb.attachments = attachments.NewManager(fileMgr)
+	if db != nil {
+		arb.attachments.SetStore(db)
+	}
+	if arb.transcriber, err = transcribe.New(cfg.Transcription); err != nil {
+		loomLog.Warn("Voice intake disabled", "error", err)
+	}
+
+	arb.experiments = experiment.NewManager(experiment.Sources{
+		Beads:   arb.beadsManager,
+		Costs:   requestHistory,
+		History: arb.beadsManager,
+	})
+	if db != nil {
+		arb.experiments.SetStore(db)
+	}
+
+	arb.scheduler = scheduler.New(scheduleStore)
+	arb.scheduler.SetReadOnlyCheck(arb.readOnly.Check)
+	arb.scheduler.Register(dependencies.ScheduleKind, arb.dependencyManager.ScheduleHandler())
+	arb.scheduler.Register(digest.ScheduleKind, arb.digests.ScheduleHandler())
+	arb.maintenance = maintenance.NewGenerator(arb, arb.beadsManager, cfg.Maintenance)
+	arb.scheduler.Register(maintenance.ScheduleKind, arb.maintenance.ScheduleHandler())
+	if err := arb.scheduler.Load(); err != nil {
+		loomLog.Warn("Failed to load schedules", "error", err)
+	}
+
+	var fieldStore customfields.Store
+	if db != nil {
+		fieldStore = db
+	}
+	arb.customFields = customfields.New(fieldStore)
+	if err := arb.customFields.Load(); err != nil {
+		loomLog.Warn("Failed to load custom field definitions", "error", err)
+	}
+
+	var conversationStore ci.ConversationStore
+	if db != nil {
+		conversationStore = db
+	}
+	arb.ciManager = ci.NewManager(arb.beadsManager, conversationStore, arb.projectManager)
+	if cfg.CI.GitHubToken != "" || cfg.CI.GitHubAPIURL != "" {
+		arb.ciManager.AddConnector(ci.NewGitHubConnector(cfg.CI.GitHubAPIURL, cfg.CI.GitHubToken))
+	}
+	if cfg.CI.GitLabToken != "" || cfg.CI.GitLabURL != "" {
+		arb.ciManager.AddConnector(ci.NewGitLabConnector(cfg.CI.GitLabURL, cfg.CI.GitLabToken))
+	}
+	if cfg.CI.JenkinsURL != "" {
+		arb.ciManager.AddConnector(ci.NewJenkinsConnector(cfg.CI.JenkinsURL, cfg.CI.JenkinsUser, cfg.CI.JenkinsToken))
+	}
+
+	var trackerComments tracker.CommentStore
+	if arb.commentsManager != nil {
+		trackerComments = arb.commentsManager
+	}
+	arb.tracker = tracker.NewManager(arb.beadsManager, trackerComments, cfg.Tracker)
+	arb.tracker.SetReadOnlyCheck(arb.readOnly.Check)
+	githubCfg := cfg.Tracker.GitHub
+	if githubCfg.Token == "" {
+		githubCfg.Token = cfg.CI.GitHubToken
+	}
+	if githubCfg.APIURL == "" {
+		githubCfg.APIURL = cfg.CI.GitHubAPIURL
+	}
+	arb.githubImporter = tracker.NewGitHubImporter(arb, arb.beadsManager, githubCfg)
+	if cfg.Tracker.Jira.URL != "" {
+		arb.tracker.AddConnector(tracker.NewJiraConnector(cfg.Tracker.Jira.URL, cfg.Tracker.Jira.User, cfg.Tracker.Jira.Token), cfg.Tracker.Jira.Statuses)
+	}
+	if cfg.Tracker.Linear.APIKey != "" {
+		arb.tracker.AddConnector(tracker.NewLinearConnector(cfg.Tracker.Linear.APIURL, cfg.Tracker.Linear.APIKey), cfg.Tracker.Linear.Statuses)
+	}
+
+	if cfg.Backup.Enabled && db != nil {
+		store, err := backup.NewStore(cfg.Backup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize backups: %w", err)
+		}
+		arb.backups = backup.NewManager(store, backup.Source{
+			DatabaseType: db.Type(),
+			DB:           db.DB(),
+			PostgresDSN:  cfg.Database.DSN,
+			KeyStorePath: backup.WithDefaults(cfg.Backup).KeyStorePath,
+		}, backup.RetentionFrom(cfg.Backup))
+	}
+
+	dashSources := dashboard.Sources{Agents: agentMgr, Beads: arb.beadsManager, Costs: costs}
+	if patternMgr != nil {
+		dashSources.Anomalies = patternMgr
+	}
+	arb.dashboard = dashboard.NewBuilder(dashSources)
+
+	// Full-text search needs SQLite's FTS4 module
+	if db != nil && db.Type() == "sqlite" {
+		if idx, err := search.NewIndex(db.DB()); err != nil {
+			loomLog.Warn("Search disabled", "error", err)
+		} else {
+			arb.searchIndexer = search.NewIndexer(idx, arb.beadsManager, db)
+			arb.searchIndexer.SetBeadText(arb.customFields.SearchText)
+		}
+	}
+
+	if cfg.Retention.Enabled && db != nil {
+		rcfg := retention.WithDefaults(cfg.Retention, cfg.Beads.CompactOldDays)
+		arb.retention = retention.NewManager(arb.beadsManager, db, requestLogs, rcfg.ArchiveDir, retention.PolicyFrom(rcfg))
+		arb.retention.SetReadOnlyCheck(arb.readOnly.Check)
+	}
+
+	var outputStore actions.OutputStore
+	if db != nil {
+		outputStore = db
+	}
+
+	var roleStore roles.Store
+	if db != nil {
+		roleStore = db
+	}
+	arb.roleRegistry = roles.NewRegistry(roleStore, agentMgr)
+	if err := arb.roleRegistry.Load(); err != nil {
+		loomLog.Warn("Failed to load agent roles", "error", err)
+	}
+
+	arb.messageBus = messaging.NewAgentMessageBus(eb)
+	if db != nil {
+		arb.messageBus.SetStore(db)
+	}
+	directory := &agentDirectory{agents: agentMgr, roles: arb.roleRegistry}
+	messageSender := messaging.NewActionMessageSender(arb.messageBus, directory)
+	arb.delegations = delegation.NewManager(arb.beadsManager, directory, conversationStore)
+	arb.delegations.Notifier = messageSender
+
+	gitRouter := actions.NewProjectGitRouter(gitopsMgr)
+	secretScanner, err := secretscan.New(cfg.Git.SecretScan)
+	if err != nil {
+		return nil, fmt.Errorf("invalid git.secret_scan config: %w", err)
+	}
+	if secretScanner == nil {
+		loomLog.Warn("Secret scanning is disabled for agent commits and pushes")
+	}
+	gitRouter.SetSecretScanner(secretScanner)
+	commitPolicy, err := git.NewCommitPolicy(cfg.Git.CommitPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid git.commit_policy config: %w", err)
 	}
+	gitRouter.SetCommitPolicy(commitPolicy)
+	arb.mcpManager = mcp.NewManager(gitopsMgr, cfg.Projects)
 
+	var idePublisher ide.Publisher
+	if eb != nil {
+		idePublisher = eb
+	}
+	arb.ideTracker = ide.NewTracker(gitopsMgr, idePublisher, conversationStore)
+	arb.repoMaps = repomap.NewGenerator(gitopsMgr, gitopsMgr)
+	if cfg.RepoMap.Summarize {
+		arb.repoMaps.SetSummarizer(repomap.NewLLMSummarizer(providerRegistry, arb.digestProviderID))
+	}
+	arb.impactAnalyzer = impact.NewAnalyzer(gitopsMgr, arb.repoMaps)
+	arb.idePairings = ide.NewPairings()
+	arb.contextStore.SetPresenceTTL(cfg.Agents.PresenceTTL)
+	arb.contextStore.SetEventBufferSize(cfg.Agents.EventBufferSize)
+	if db != nil {
+		arb.contextStore.SetStore(db)
+		arb.beadsManager.SetEventStore(db)
+	}
 	actionRouter := &actions.Router{
-		Beads:     arb,
-		Closer:    arb,
-		Escalator: arb,
-		Commands:  arb,
-		Files:     files.NewManager(gitopsMgr),
-		Git:       actions.NewProjectGitRouter(gitopsMgr),
-		Logger:    arb,
-		Workflow:  arb,
-		BeadType:  "task",
-		DefaultP0: true,
+		Beads:        arb,
+		Closer:       arb,
+		Delegations:  arb.delegations,
+		Escalator:    arb,
+		Commands:     arb,
+		Sessions:     arb.sessionManager,
+		Jobs:         arb.jobManager,
+		Files:        fileMgr,
+		Formatter:    formatter.NewManager(gitopsMgr),
+		Security:     arb.securityScanner,
+		Dependencies: arb.dependencyManager,
+		HTTPChecks:   httpcheck.NewChecker(),
+		CI:           arb.ciManager,
+		Outputs:      outputStore,
+		Roles:        arb.roleRegistry,
+		Git:          gitRouter,
+		Logger:       arb,
+		Workflow:     arb,
+		Progress:     arb,
+		MessageBus:   messageSender,
+		Inbox:        messageSender,
+		Snapshots:    fileMgr,
+		Projects:     arb.projectManager,
+		MCP:          arb.mcpManager,
+		Images:       arb.attachments,
+		Impact:       arb.impactAnalyzer,
+		Features:     arb.features,
+		PolicyFacts:  arb,
+		Approvals:    arb,
+		Locks:        arb,
+		ReadOnly:     arb.readOnly,
+		BeadType:     "task",
+		DefaultP0:    true,
+		SecretBeads:  cfg.Git.SecretScan.CreateBeads,
+
+		AutoSnapshotPatchBytes: autoSnapshotBytes,
+		Timeouts:               cfg.Executor.ActionTimeouts,
+		DefaultTimeout:         cfg.Executor.DefaultActionTimeout,
+	}
+	if arb.policies != nil {
+		actionRouter.Policies = arb.policies
+	}
+	if containerMgr := containers.NewManager(gitopsMgr, cfg.Containers); containerMgr != nil {
+		actionRouter.Containers = containerMgr
+	}
+	if browserMgr := browser.NewManager(gitopsMgr, cfg.Browser); browserMgr != nil {
+		actionRouter.Browser = browserMgr
+	}
+	if arb.stagingManager != nil {
+		actionRouter.Staging = arb.stagingManager
+	}
+	if arb.testDatabases != nil {
+		actionRouter.Databases = arb.testDatabases
+	}
+	var benchStore benchmarks.Store
+	if db != nil {
+		benchStore = db
+	}
+	if benchMgr := benchmarks.NewManager(gitopsMgr, benchStore, cfg.Benchmarks); benchMgr != nil {
+		actionRouter.Benchmarks = benchMgr
+		if workflowEngine != nil {
+			workflowEngine.AddGate(benchMgr)
+		}
+	}
+	var profileStore profiling.Store
+	if db != nil {
+		profileStore = db
+	}
+	if arb.profiler = profiling.NewManager(gitopsMgr, profileStore, cfg.Profiling); arb.profiler != nil {
+		actionRouter.Profiler = arb.profiler
+	}
+	if selector := testselect.NewSelector(arb.impactAnalyzer, cfg.TestSelection); selector != nil {
+		actionRouter.Selector = selector
+		if workflowEngine != nil {
+			workflowEngine.AddGate(selector)
+		}
 	}
 	arb.actionRouter = actionRouter
+
+	quotaMgr.SetOnExceeded(arb.publishQuotaExceeded)
+	if pcfg := patterns.WithDefaults(cfg.Patterns); patternMgr != nil && pcfg.AlertInterval >= 0 {
+		alerter := patterns.NewAlerter(pcfg.AlertMinSeverity, pcfg.AlertDedupWindow, arb.alertAnomaly)
+		patternMgr.SetAnomalyHook(func(anomalies []*patterns.PatternAnomaly) { alerter.HandleAnomalies(anomalies) })
+	}
 	agentMgr.SetActionRouter(actionRouter)
+	agentMgr.SetRoleRegistry(arb.roleRegistry)
+	agentMgr.SetImageSource(arb.attachments)
 
 	// Enable multi-turn action loop
 	agentMgr.SetActionLoopEnabled(true)
 	agentMgr.SetMaxLoopIterations(25) // Increased from 15 to give agents more room for complex tasks
+	agentMgr.SetRepairAttempts(cfg.Agents.RepairAttempts)
+	agentMgr.SetMaxCorrections(cfg.Agents.CorrectionAttempts)
+	if priorities, err := contextpack.ParsePriorities(cfg.Agents.ContextPriorities); err != nil {
+		loomLog.Warn("Ignoring agents.context_priorities", "error", err)
+	} else {
+		agentMgr.SetContextPriorities(priorities)
+	}
 	if db != nil {
 		agentMgr.SetDatabase(db)
 		lessonsProvider := dispatch.NewLessonsProvider(db)
 		if lessonsProvider != nil {
+			lessonsProvider.SetFeatures(arb.features)
+			if embedder != nil {
+				lessonsProvider.SetEmbedder(embedder)
+			}
 			agentMgr.SetLessonsProvider(lessonsProvider)
 		}
 	}
 
+	arb.housekeeping = housekeeping.New()
+	arb.registerMaintenanceTasks()
+
 	arb.dispatcher = dispatch.NewDispatcher(arb.beadsManager, arb.projectManager, arb.agentManager, arb.providerRegistry, eb)
 	arb.readinessCache = make(map[string]projectReadinessState)
 	arb.readinessFailures = make(map[string]time.Time)
 	arb.dispatcher.SetReadinessCheck(arb.CheckProjectReadiness)
+	arb.dispatcher.SetReadOnlyCheck(arb.readOnly.Check)
 	arb.dispatcher.SetReadinessMode(dispatch.ReadinessMode(cfg.Readiness.Mode))
 	arb.dispatcher.SetMaxDispatchHops(cfg.Dispatch.MaxHops)
+	arb.dispatcher.SetQueuePolicy(dispatch.QueuePolicy{
+		AgingInterval:  cfg.Dispatch.Queue.AgingInterval,
+		ProjectWeights: cfg.Dispatch.Queue.ProjectWeights,
+		RoleCapacity:   cfg.Dispatch.Queue.RoleCapacity,
+	})
+	if routes, err := dispatch.ParseLabelRoutes(cfg.Dispatch.LabelRoutes); err != nil {
+		loomLog.Warn("Ignoring dispatch label routes", "error", err)
+	} else {
+		arb.dispatcher.SetLabelRoutes(routes)
+	}
+	arb.sla = sla.NewManager(arb.beadsManager, arb, sla.PolicyFrom(cfg.SLA))
+	arb.sla.SetNotifier(arb.publishSLATransition)
+	arb.sla.SetReadOnlyCheck(arb.readOnly.Check)
 	arb.dispatcher.SetEscalator(arb)
+	arb.dispatcher.SetRoleResolver(arb.roleRegistry)
+	arb.dispatcher.SetExperimenter(arb.experiments)
+	if workflowEngine != nil {
+		arb.dispatcher.SetReviewer(review.NewPipeline(gitRouter, agentMgr, arb.roleRegistry, arb.providerRegistry, workflowEngine, arb.beadsManager))
+	}
 	// Enable conversation context support for multi-turn conversations
 	if db != nil {
 		arb.dispatcher.SetDatabase(db)
 	}
+	if cfg.RepoMap.InjectPrompt {
+		arb.dispatcher.SetRepoMapper(arb)
+	}
+	arb.workingSets = workingset.New(workingset.Sources{
+		Beads:     arb.beadsManager,
+		History:   arb.beadsManager,
+		Files:     fileMgr,
+		Edits:     arb.ideTracker,
+		Questions: arb.decisionManager,
+		Workflow:  arb.dispatcher,
+	})
 
 	// Setup provider metrics tracking
 	arb.setupProviderMetrics()
@@ -427,6 +982,7 @@ func (a *Loom) Initialize(ctx context.Context) error {
 					IsPerpetual:     p.IsPerpetual,
 					IsSticky:        p.IsSticky,
 					Context:         p.Context,
+					Subprojects:     p.Subprojects,
 					Status:          models.ProjectStatusOpen,
 				}
 				_ = a.database.UpsertProject(proj)
@@ -447,6 +1003,7 @@ func (a *Loom) Initialize(ctx context.Context) error {
 					IsPerpetual:     p.IsPerpetual,
 					IsSticky:        p.IsSticky,
 					Context:         p.Context,
+					Subprojects:     p.Subprojects,
 					Status:          models.ProjectStatusOpen,
 				}
 				_ = a.database.UpsertProject(proj)
@@ -467,6 +1024,7 @@ func (a *Loom) Initialize(ctx context.Context) error {
 				IsPerpetual:     p.IsPerpetual,
 				IsSticky:        p.IsSticky,
 				Context:         p.Context,
+				Subprojects:     p.Subprojects,
 				Status:          models.ProjectStatusOpen,
 			})
 		}
@@ -481,6 +1039,12 @@ func (a *Loom) Initialize(ctx context.Context) error {
 		copy := *p
 		copy.BeadsPath = normalizeBeadsPath(copy.BeadsPath)
 		copy.GitAuthMethod = normalizeGitAuthMethod(copy.GitRepo, copy.GitAuthMethod)
+		if subprojects, err := project.NormalizeSubprojects(copy.Subprojects); err != nil {
+			loomLog.Warn("Ignoring invalid subprojects", "project_id", copy.ID, "error", err)
+			copy.Subprojects = nil
+		} else {
+			copy.Subprojects = subprojects
+		}
 		projectValues = append(projectValues, copy)
 	}
 	if len(projectValues) == 0 && len(a.config.Projects) > 0 {
@@ -497,6 +1061,7 @@ func (a *Loom) Initialize(ctx context.Context) error {
 				IsPerpetual:     p.IsPerpetual,
 				IsSticky:        p.IsSticky,
 				Context:         p.Context,
+				Subprojects:     p.Subprojects,
 				Status:          models.ProjectStatusOpen,
 			})
 		}
@@ -557,9 +1122,9 @@ func (a *Loom) Initialize(ctx context.Context) error {
 			if p.GitAuthMethod == models.GitAuthSSH {
 				pubKey, err := a.gitopsManager.EnsureProjectSSHKey(p.ID)
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to ensure SSH key for %s: %v\n", p.ID, err)
+					loomLog.Warn("Failed to ensure SSH key", "project_id", p.ID, "error", err)
 				} else {
-					fmt.Printf("Project %s SSH public key:\n%s\n", p.ID, pubKey)
+					loomLog.Info("Project SSH public key", "project_id", p.ID, "public_key", pubKey)
 				}
 			}
 
@@ -583,7 +1148,7 @@ func (a *Loom) Initialize(ctx context.Context) error {
 			if out, err := checkCmd.CombinedOutput(); err != nil {
 				outStr := strings.TrimSpace(string(out))
 				if strings.Contains(outStr, "does not have any commits") || strings.Contains(outStr, "unknown revision") {
-					fmt.Printf("Project %s has empty repo (prior clone failed), re-cloning...\n", p.ID)
+					loomLog.Info("Project has an empty repo from a failed clone, re-cloning", "project_id", p.ID)
 					// Remove the broken repo so CloneProject can start fresh
 					os.RemoveAll(workDir)
 					needsClone = true
@@ -593,38 +1158,29 @@ func (a *Loom) Initialize(ctx context.Context) error {
 
 		if needsClone {
 			// Clone the repository
-			fmt.Printf("Cloning project %s from %s...\n", p.ID, p.GitRepo)
+			loomLog.Info("Cloning project", "project_id", p.ID, "git_repo", p.GitRepo)
 			if err := a.gitopsManager.CloneProject(ctx, p); err != nil {
 				errStr := err.Error()
-				fmt.Fprintf(os.Stderr, "Warning: Failed to clone project %s: %v\n", p.ID, err)
+				loomLog.Warn("Failed to clone project", "project_id", p.ID, "error", err)
 
 				// If SSH auth failed, show the deploy key that needs to be registered
 				if p.GitAuthMethod == models.GitAuthSSH && strings.Contains(errStr, "Permission denied") {
 					if pubKey, keyErr := a.gitopsManager.EnsureProjectSSHKey(p.ID); keyErr == nil {
-						fmt.Fprintf(os.Stderr, "\n"+
-							"╔══════════════════════════════════════════════════════════════════╗\n"+
-							"║  DEPLOY KEY NOT REGISTERED                                      ║\n"+
-							"║                                                                  ║\n"+
-							"║  Add this deploy key to your git remote:                         ║\n"+
-							"║  %s\n"+
-							"║                                                                  ║\n"+
-							"║  For GitHub: Settings → Deploy Keys → Add deploy key             ║\n"+
-							"║  Enable 'Allow write access' if agents need to push.             ║\n"+
-							"╚══════════════════════════════════════════════════════════════════╝\n\n",
-							pubKey)
+						loomLog.Warn("Deploy key not registered: add it to the git remote (GitHub: Settings → Deploy Keys, with write access if agents push)",
+							"project_id", p.ID, "public_key", pubKey)
 					}
 				}
 				continue
 			}
-			fmt.Printf("Successfully cloned project %s\n", p.ID)
+			loomLog.Info("Cloned project", "project_id", p.ID)
 		} else {
 			// Pull latest changes
-			fmt.Printf("Pulling latest changes for project %s...\n", p.ID)
+			loomLog.Info("Pulling latest changes", "project_id", p.ID)
 			if err := a.gitopsManager.PullProject(ctx, p); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to pull project %s: %v\n", p.ID, err)
+				loomLog.Warn("Failed to pull project", "project_id", p.ID, "error", err)
 				// Continue anyway with existing checkout
 			} else {
-				fmt.Printf("Successfully pulled project %s\n", p.ID)
+				loomLog.Info("Pulled project", "project_id", p.ID)
 			}
 		}
 
@@ -652,10 +1208,10 @@ func (a *Loom) Initialize(ctx context.Context) error {
 					if out, err := initCmd.CombinedOutput(); err != nil {
 						outStr := strings.TrimSpace(string(out))
 						if !strings.Contains(outStr, "already initialized") {
-							fmt.Fprintf(os.Stderr, "Warning: bd init failed for %s: %v (%s)\n", p.ID, err, outStr)
+							loomLog.Warn("bd init failed", "project_id", p.ID, "error", err, "output", outStr)
 						}
 					} else {
-						fmt.Printf("Initialized beads database for project %s\n", p.ID)
+						loomLog.Info("Initialized beads database", "project_id", p.ID)
 					}
 				}
 			}
@@ -680,15 +1236,15 @@ func (a *Loom) Initialize(ctx context.Context) error {
 			// Start per-project Dolt instance if using dolt backend
 			if a.doltCoordinator != nil {
 				if _, err := a.doltCoordinator.EnsureInstance(ctx, p.ID, beadsPath); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to start Dolt instance for %s: %v\n", p.ID, err)
+					loomLog.Warn("Failed to start Dolt instance", "project_id", p.ID, "error", err)
 				}
 			}
 
 			// Federation sync after loading beads
 			if a.config.Beads.Federation.Enabled && a.config.Beads.Federation.AutoSync {
-				fmt.Printf("Syncing federation peers for project %s...\n", p.ID)
+				loomLog.Info("Syncing federation peers", "project_id", p.ID)
 				if err := a.beadsManager.SyncFederation(ctx, &a.config.Beads.Federation); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Federation sync failed for %s: %v\n", p.ID, err)
+					loomLog.Warn("Federation sync failed", "project_id", p.ID, "error", err)
 				}
 				// Reload beads after sync to pick up remote changes
 				_ = a.beadsManager.LoadBeadsFromFilesystem(p.ID, beadsPath)
@@ -712,15 +1268,15 @@ func (a *Loom) Initialize(ctx context.Context) error {
 			// Start per-project Dolt instance for local projects too
 			if a.doltCoordinator != nil {
 				if _, err := a.doltCoordinator.EnsureInstance(ctx, p.ID, p.BeadsPath); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Failed to start Dolt instance for %s: %v\n", p.ID, err)
+					loomLog.Warn("Failed to start Dolt instance", "project_id", p.ID, "error", err)
 				}
 			}
 
 			// Federation sync after loading beads
 			if a.config.Beads.Federation.Enabled && a.config.Beads.Federation.AutoSync {
-				fmt.Printf("Syncing federation peers for project %s...\n", p.ID)
+				loomLog.Info("Syncing federation peers", "project_id", p.ID)
 				if err := a.beadsManager.SyncFederation(ctx, &a.config.Beads.Federation); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: Federation sync failed for %s: %v\n", p.ID, err)
+					loomLog.Warn("Federation sync failed", "project_id", p.ID, "error", err)
 				}
 				// Reload beads after sync to pick up remote changes
 				_ = a.beadsManager.LoadBeadsFromFilesystem(p.ID, p.BeadsPath)
@@ -744,7 +1300,7 @@ func (a *Loom) Initialize(ctx context.Context) error {
 					providerID = strings.ReplaceAll(strings.ToLower(cfgProvider.Name), " ", "-")
 				}
 				if providerID == "" {
-					log.Printf("Skipping provider seed without id or name: endpoint=%s", cfgProvider.Endpoint)
+					providerLog.Warn("Skipping provider seed without id or name", "endpoint", cfgProvider.Endpoint)
 					continue
 				}
 				seed := &internalmodels.Provider{
@@ -752,12 +1308,13 @@ func (a *Loom) Initialize(ctx context.Context) error {
 					Name:        cfgProvider.Name,
 					Type:        cfgProvider.Type,
 					Endpoint:    cfgProvider.Endpoint,
+					Endpoints:   cfgProvider.Endpoints,
 					Model:       cfgProvider.Model,
 					RequiresKey: cfgProvider.APIKey != "",
 					Status:      "pending",
 				}
 				if _, regErr := a.RegisterProvider(ctx, seed); regErr != nil {
-					log.Printf("Failed to seed provider %s: %v", providerID, regErr)
+					providerLog.Error("Failed to seed provider", "provider_id", providerID, "error", regErr)
 				}
 			}
 			providers, err = a.database.ListProviders()
@@ -778,6 +1335,7 @@ func (a *Loom) Initialize(ctx context.Context) error {
 				Name:                   p.Name,
 				Type:                   p.Type,
 				Endpoint:               normalizeProviderEndpoint(p.Endpoint),
+				Endpoints:              p.Endpoints,
 				APIKey:                 "",
 				Model:                  selected,
 				ConfiguredModel:        p.ConfiguredModel,
@@ -786,6 +1344,7 @@ func (a *Loom) Initialize(ctx context.Context) error {
 				Status:                 p.Status,
 				LastHeartbeatAt:        p.LastHeartbeatAt,
 				LastHeartbeatLatencyMs: p.LastHeartbeatLatencyMs,
+				SupportsVision:         p.SupportsVision,
 			})
 		}
 
@@ -797,9 +1356,9 @@ func (a *Loom) Initialize(ctx context.Context) error {
 			}
 		}
 		if healthyCount > 0 {
-			log.Printf("[Loom] %d providers already healthy, dispatch ready", healthyCount)
+			providerLog.Info("Providers already healthy, dispatch ready", "healthy", healthyCount)
 		} else {
-			log.Printf("[Loom] No providers healthy yet — heartbeat will activate them shortly")
+			providerLog.Info("No providers healthy yet, heartbeat will activate them shortly")
 		}
 
 		// Restore agents from database (best-effort).
@@ -832,13 +1391,13 @@ func (a *Loom) Initialize(ctx context.Context) error {
 
 	// Ensure all projects are persisted to the database before creating agents (to avoid FK constraint failures)
 	if a.database != nil {
-		log.Printf("Persisting %d project(s) to database before agent creation", len(projectValues))
+		loomLog.Info("Persisting projects to database before agent creation", "count", len(projectValues))
 		for i := range projectValues {
 			p := &projectValues[i]
 			if err := a.database.UpsertProject((*models.Project)(p)); err != nil {
-				log.Printf("Warning: Failed to persist project %s: %v", p.ID, err)
+				loomLog.Warn("Failed to persist project", "project_id", p.ID, "error", err)
 			} else {
-				log.Printf("Successfully persisted project %s to database", p.ID)
+				loomLog.Debug("Persisted project to database", "project_id", p.ID)
 			}
 		}
 	}
@@ -857,7 +1416,7 @@ func (a *Loom) Initialize(ctx context.Context) error {
 	healthyProviders := a.providerRegistry.ListActive()
 	for _, provider := range healthyProviders {
 		if provider != nil && provider.Config != nil {
-			log.Printf("Attaching healthy provider %s to paused agents on startup", provider.Config.ID)
+			providerLog.Info("Attaching healthy provider to paused agents on startup", "provider_id", provider.Config.ID)
 			a.attachProviderToPausedAgents(ctx, provider.Config.ID)
 		}
 	}
@@ -886,9 +1445,9 @@ func (a *Loom) Initialize(ctx context.Context) error {
 	// Register default motivations for all agent roles
 	if a.motivationRegistry != nil {
 		if err := motivation.RegisterDefaults(a.motivationRegistry); err != nil {
-			log.Printf("Warning: Failed to register default motivations: %v", err)
+			loomLog.Warn("Failed to register default motivations", "error", err)
 		} else {
-			log.Printf("Registered %d default motivations", a.motivationRegistry.Count())
+			loomLog.Info("Registered default motivations", "count", a.motivationRegistry.Count())
 		}
 	}
 
@@ -897,12 +1456,12 @@ func (a *Loom) Initialize(ctx context.Context) error {
 	// (idle detection, deadline monitoring, budget thresholds, etc.)
 	if a.motivationEngine != nil {
 		if err := a.motivationEngine.Start(ctx); err != nil {
-			log.Printf("[Loom] Warning: Failed to start motivation engine: %v", err)
+			loomLog.Warn("Failed to start motivation engine", "error", err)
 		} else {
-			log.Printf("[Loom] Motivation engine started successfully")
+			loomLog.Info("Motivation engine started")
 		}
 	} else {
-		log.Printf("[Loom] Warning: Motivation engine not initialized")
+		loomLog.Warn("Motivation engine not initialized")
 	}
 
 	// FIX #4: Ensure at least one project has beads for work to flow
@@ -923,7 +1482,7 @@ func (a *Loom) Initialize(ctx context.Context) error {
 	// If no beads exist and we have at least one project, create a sample diagnostic bead
 	if !hasBeads && len(allProjects) > 0 {
 		proj := allProjects[0]
-		log.Printf("[Loom] No beads found - creating sample diagnostic bead for project %s", proj.ID)
+		loomLog.Info("No beads found, creating sample diagnostic bead", "project_id", proj.ID)
 
 		bead, err := a.beadsManager.CreateBead(
 			"System diagnostic check",
@@ -933,34 +1492,34 @@ func (a *Loom) Initialize(ctx context.Context) error {
 			proj.ID,
 		)
 		if err != nil {
-			log.Printf("[Loom] Failed to create sample diagnostic bead: %v", err)
+			loomLog.Error("Failed to create sample diagnostic bead", "error", err)
 		} else {
-			log.Printf("[Loom] Created sample diagnostic bead: %s", bead.ID)
+			loomLog.Info("Created sample diagnostic bead", "bead_id", bead.ID)
 		}
 	} else if len(allProjects) == 0 {
-		log.Printf("[Loom] Warning: No projects configured - no work can be dispatched")
+		loomLog.Warn("No projects configured, no work can be dispatched")
 	} else {
-		log.Printf("[Loom] Found existing beads across projects - work flow should be operational")
+		loomLog.Info("Found existing beads across projects, work flow should be operational")
 	}
 
 	// Load default workflows
 	if a.database != nil && a.workflowEngine != nil {
 		workflowsDir := "./workflows/defaults"
 		if _, err := os.Stat(workflowsDir); err == nil {
-			log.Printf("Loading default workflows from %s", workflowsDir)
+			loomLog.Info("Loading default workflows", "dir", workflowsDir)
 			if err := workflow.InstallDefaultWorkflows(a.database, workflowsDir); err != nil {
-				log.Printf("Warning: Failed to load default workflows: %v", err)
+				loomLog.Warn("Failed to load default workflows", "error", err)
 			} else {
-				log.Printf("Successfully loaded default workflows")
+				loomLog.Info("Loaded default workflows")
 			}
 		} else {
-			log.Printf("Default workflows directory not found: %s", workflowsDir)
+			loomLog.Info("Default workflows directory not found", "dir", workflowsDir)
 		}
 
 		// Set workflow engine in dispatcher for workflow-aware routing
 		if a.dispatcher != nil {
 			a.dispatcher.SetWorkflowEngine(a.workflowEngine)
-			log.Printf("Workflow engine connected to dispatcher")
+			loomLog.Info("Workflow engine connected to dispatcher")
 		}
 	}
 
@@ -1013,7 +1572,7 @@ func (a *Loom) kickstartOpenBeads(ctx context.Context) {
 			if a.temporalManager != nil {
 				if err := a.temporalManager.StartBeadWorkflow(ctx, b.ID, p.ID, b.Title, b.Description, int(b.Priority), b.Type); err != nil {
 					// Log error but continue with other beads
-					fmt.Printf("Warning: failed to kickstart bead workflow %s: %v\n", b.ID, err)
+					loomLog.Warn("Failed to kickstart bead workflow", "bead_id", b.ID, "error", err)
 					continue
 				}
 			}
@@ -1023,19 +1582,31 @@ func (a *Loom) kickstartOpenBeads(ctx context.Context) {
 	}
 
 	if totalKickstarted > 0 {
-		fmt.Printf("Kickstarted %d open bead(s) across %d project(s)\n", totalKickstarted, len(projects))
+		loomLog.Info("Kickstarted open beads", "beads", totalKickstarted, "projects", len(projects))
 	}
 }
 
 // Shutdown gracefully shuts down loom
 func (a *Loom) Shutdown() {
 	a.agentManager.StopAll()
+	if a.sessionManager != nil {
+		a.sessionManager.Shutdown()
+	}
+	if a.jobManager != nil {
+		a.jobManager.Shutdown()
+	}
 	if a.openclawBridge != nil {
 		a.openclawBridge.Close()
 	}
+	if a.mcpManager != nil {
+		a.mcpManager.Close()
+	}
 	if a.doltCoordinator != nil {
 		a.doltCoordinator.Shutdown()
 	}
+	if a.messageBus != nil {
+		a.messageBus.Close()
+	}
 	if a.temporalManager != nil {
 		a.temporalManager.Stop()
 	}
@@ -1045,6 +1616,23 @@ func (a *Loom) Shutdown() {
 			a.eventBus.Close()
 		}
 	}
+	if a.testDatabases != nil {
+		a.testDatabases.Shutdown()
+	}
+	if a.analyticsQueue != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := a.analyticsQueue.Close(ctx); err != nil {
+			loomLog.Warn("Request logs lost on shutdown", "error", err)
+		}
+		cancel()
+	}
+	if a.analyticsMirror != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := a.analyticsMirror.Close(ctx); err != nil {
+			loomLog.Warn("Analytics mirror incomplete on shutdown", "error", err)
+		}
+		cancel()
+	}
 	if a.database != nil {
 		_ = a.database.Close()
 	}
@@ -1089,10 +1677,91 @@ func (a *Loom) LogAction(ctx context.Context, actx actions.ActionContext, action
 	for k, v := range result.Metadata {
 		metadata[k] = v
 	}
-	if a.logManager != nil {
-		a.logManager.Log(logging.LogLevelInfo, "actions", "action executed", metadata)
+	actionLog.LogAttrs(ctx, slog.LevelInfo, "action executed", logging.Attrs(metadata)...)
+
+	if result.Status == "executed" && a.ideTracker != nil {
+		if paths := ide.EditedPaths(action); len(paths) > 0 {
+			a.ideTracker.RecordEdit(ctx, ide.Edit{
+				ProjectID:  actx.ProjectID,
+				BeadID:     actx.BeadID,
+				AgentID:    actx.AgentID,
+				ActionType: action.Type,
+				Paths:      paths,
+			})
+		}
 	}
-	observability.Info("agent.action", metadata)
+}
+
+// ReportActionProgress satisfies actions.ProgressReporter. Start and completion
+// events are recorded in the bead's collaboration activity log and keep the
+// agent's presence current, and completions go in the bead's history;
+// command output chunks are streamed to subscribers without being persisted.
+func (a *Loom) ReportActionProgress(ctx context.Context, actx actions.ActionContext, event actions.ProgressEvent) {
+	if a.contextStore == nil || actx.BeadID == "" {
+		return
+	}
+	data := map[string]interface{}{
+		"index":       event.Index,
+		"total":       event.Total,
+		"action_type": event.ActionType,
+		"phase":       event.Phase,
+		"timestamp":   event.Timestamp,
+	}
+	if event.Path != "" {
+		data["path"] = event.Path
+	}
+	if event.Phase == actions.ProgressOutput {
+		data["stream"] = event.Stream
+		data["chunk"] = event.Chunk
+		a.contextStore.PublishEvent(ctx, actx.BeadID, actx.AgentID, "action_progress", data)
+		return
+	}
+
+	if event.Phase == actions.ProgressCompleted && a.beadsManager != nil {
+		_ = a.beadsManager.RecordEvent(actx.BeadID, beads.EventActionExecuted, actx.AgentID, map[string]interface{}{
+			"action_type": event.ActionType,
+			"status":      event.Status,
+			"message":     event.Message,
+			"path":        event.Path,
+			"duration_ms": event.DurationMs,
+		})
+	}
+
+	if _, err := a.contextStore.GetOrCreate(ctx, actx.BeadID, actx.ProjectID); err != nil {
+		return
+	}
+	a.updatePresence(ctx, actx, event)
+	description := fmt.Sprintf("Action %d/%d %s started", event.Index+1, event.Total, event.ActionType)
+	if event.Phase == actions.ProgressCompleted {
+		data["status"] = event.Status
+		data["message"] = event.Message
+		data["duration_ms"] = event.DurationMs
+		description = fmt.Sprintf("Action %d/%d %s %s", event.Index+1, event.Total, event.ActionType, event.Status)
+	}
+	_ = a.contextStore.AddActivity(ctx, actx.BeadID, actx.AgentID, "action_"+event.Phase, description, data)
+}
+
+// updatePresence keeps the agent's presence on the bead current: typing
+// while a file-writing action runs, otherwise active, focused on the file
+// the action named
+func (a *Loom) updatePresence(ctx context.Context, actx actions.ActionContext, event actions.ProgressEvent) {
+	if actx.AgentID == "" {
+		return
+	}
+	p := collaboration.Presence{AgentID: actx.AgentID, Status: collaboration.PresenceActive, Phase: event.ActionType}
+	if event.Phase == actions.ProgressStarted {
+		p.File = event.Path
+		if actions.WritesFiles(event.ActionType) {
+			p.Status = collaboration.PresenceTyping
+		}
+	} else {
+		for _, current := range a.contextStore.ActivePresence(ctx, actx.BeadID) {
+			if current.AgentID == actx.AgentID {
+				p.File = current.File
+			}
+		}
+	}
+	_ = a.contextStore.Heartbeat(ctx, actx.BeadID, actx.ProjectID, p)
 }
 
 // GetCommandLogs retrieves command logs with filters
@@ -1120,6 +1789,12 @@ func (a *Loom) GetProviderRegistry() *provider.Registry {
 	return a.providerRegistry
 }
 
+// GetBatcher returns the provider request batcher, or nil when request
+// batching is disabled
+func (a *Loom) GetBatcher() *provider.Batcher {
+	return a.batcher
+}
+
 func (a *Loom) GetActionRouter() *actions.Router {
 	return a.actionRouter
 }
@@ -1128,6 +1803,183 @@ func (a *Loom) GetGitOpsManager() *gitops.Manager {
 	return a.gitopsManager
 }
 
+// GetSecurityScanner returns the security scan manager
+func (a *Loom) GetSecurityScanner() *securityscan.Manager {
+	return a.securityScanner
+}
+
+// GetMaintenance returns the recurring maintenance bead generator
+func (a *Loom) GetMaintenance() *maintenance.Generator {
+	return a.maintenance
+}
+
+// GetCustomFields returns the per-project bead custom field registry
+func (a *Loom) GetCustomFields() *customfields.Registry {
+	return a.customFields
+}
+
+// GetDependencyManager returns the dependency manager
+func (a *Loom) GetDependencyManager() *dependencies.Manager {
+	return a.dependencyManager
+}
+
+// GetFeatures returns the feature flag registry
+func (a *Loom) GetFeatures() *features.Registry {
+	return a.features
+}
+
+// GetReadOnly returns the server-wide read-only switch
+func (a *Loom) GetReadOnly() *readonly.Switch {
+	return a.readOnly
+}
+
+// GetPolicies returns the action policy engine, nil when policies are off
+func (a *Loom) GetPolicies() *policy.Engine {
+	return a.policies
+}
+
+// GetScheduler returns the recurring job scheduler
+func (a *Loom) GetScheduler() *scheduler.Scheduler {
+	return a.scheduler
+}
+
+// GetCIManager returns the CI status tracker
+func (a *Loom) GetCIManager() *ci.Manager {
+	return a.ciManager
+}
+
+// GetTracker returns the issue tracker sync manager
+func (a *Loom) GetTracker() *tracker.Manager {
+	return a.tracker
+}
+
+// GetGitHubImporter returns the GitHub issue importer
+func (a *Loom) GetGitHubImporter() *tracker.GitHubImporter {
+	return a.githubImporter
+}
+
+// GetWorkingSets returns the builder of agent working sets
+func (a *Loom) GetWorkingSets() *workingset.Builder {
+	return a.workingSets
+}
+
+// GetRepoMaps returns the repository map generator
+func (a *Loom) GetRepoMaps() *repomap.Generator {
+	return a.repoMaps
+}
+
+// GetImpactAnalyzer returns the analyzer of what changes to project
+// checkouts affect
+func (a *Loom) GetImpactAnalyzer() *impact.Analyzer {
+	return a.impactAnalyzer
+}
+
+// GetBackupManager returns the backup manager, or nil when backups are disabled
+func (a *Loom) GetBackupManager() *backup.Manager {
+	return a.backups
+}
+
+// GetRetentionManager returns the retention manager, or nil when retention
+// is disabled
+func (a *Loom) GetRetentionManager() *retention.Manager {
+	return a.retention
+}
+
+// GetSLAManager returns the bead deadline manager
+func (a *Loom) GetSLAManager() *sla.Manager {
+	return a.sla
+}
+
+// GetSearchIndex returns the full-text search index, or nil without SQLite
+func (a *Loom) GetSearchIndex() *search.Index {
+	if a.searchIndexer == nil {
+		return nil
+	}
+	return a.searchIndexer.Index()
+}
+
+// GetDashboard returns the builder for the Web UI dashboard views
+func (a *Loom) GetDashboard() *dashboard.Builder {
+	return a.dashboard
+}
+
+// GetDigests returns the project digest generator
+func (a *Loom) GetDigests() *digest.Generator {
+	return a.digests
+}
+
+// GetEstimator returns the bead cost and time estimator
+func (a *Loom) GetEstimator() *estimate.Estimator {
+	return a.estimator
+}
+
+// GetAttachments returns the manager of images and audio attached to beads
+func (a *Loom) GetAttachments() *attachments.Manager {
+	return a.attachments
+}
+
+// GetProfiler returns the manager of profiles captured for beads, or nil
+// when profiling is disabled
+func (a *Loom) GetProfiler() *profiling.Manager {
+	return a.profiler
+}
+
+// GetAnalyticsQueue returns the queue request logs are written through, or
+// nil when they are written synchronously
+func (a *Loom) GetAnalyticsQueue() *analytics.QueuedStorage {
+	return a.analyticsQueue
+}
+
+// GetAnalyticsMirror returns the mirror copying request logs and pattern
+// reports to an external store, or nil when no sink is configured
+func (a *Loom) GetAnalyticsMirror() *warehouse.Mirror {
+	return a.analyticsMirror
+}
+
+// GetExperiments returns the model A/B experiment manager
+func (a *Loom) GetExperiments() *experiment.Manager {
+	return a.experiments
+}
+
+// GetPricing returns the request pricing service, or nil without a database
+func (a *Loom) GetPricing() *pricing.Service {
+	return a.pricing
+}
+
+// newPricingService loads stored pricing rates, adds configured ones not yet
+// stored, and falls back to each provider's flat cost per million tokens
+func newPricingService(db *sql.DB, logs pricing.LogRepricer, providers *provider.Registry, cfg config.PricingConfig) (*pricing.Service, error) {
+	store, err := pricing.NewDatabaseStore(db)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	svc, err := pricing.NewService(ctx, store, logs)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := svc.Seed(ctx, cfg.Rates); err != nil {
+		return nil, err
+	}
+	svc.SetFallback(func(providerID string) float64 {
+		if p, err := providers.Get(providerID); err == nil && p.Config != nil {
+			return p.Config.CostPerMToken
+		}
+		return 0
+	})
+	return svc, nil
+}
+
+// GetRoleRegistry returns the agent role registry
+func (a *Loom) GetRoleRegistry() *roles.Registry {
+	return a.roleRegistry
+}
+
+// GetMessageBus returns the agent-to-agent message bus
+func (a *Loom) GetMessageBus() *messaging.AgentMessageBus {
+	return a.messageBus
+}
+
 // SetKeyManager sets the key manager for encrypted credential storage.
 // This must be called after Loom is created (since KeyManager is initialized separately in main).
 func (a *Loom) SetKeyManager(km *keymanager.KeyManager) {
@@ -1197,6 +2049,135 @@ func (a *Loom) GetWorkflowEngine() *workflow.Engine {
 	return a.workflowEngine
 }
 
+// GetSessionManager returns the interactive PTY session manager
+func (a *Loom) GetSessionManager() *executor.SessionManager {
+	return a.sessionManager
+}
+
+// GetJobManager returns the background job manager
+func (a *Loom) GetJobManager() *executor.JobManager {
+	return a.jobManager
+}
+
+// GetQuotaManager returns the per-project executor quota manager
+func (a *Loom) GetQuotaManager() *executor.QuotaManager {
+	return a.quotaManager
+}
+
+// GetMCPManager returns the manager of the projects' MCP tool servers
+func (a *Loom) GetMCPManager() *mcp.Manager {
+	return a.mcpManager
+}
+
+// GetIDETracker returns the tracker of agent file edits served to editors
+func (a *Loom) GetIDETracker() *ide.Tracker {
+	return a.ideTracker
+}
+
+// GetIDEPairings returns the handshake state of local editors
+func (a *Loom) GetIDEPairings() *ide.Pairings {
+	return a.idePairings
+}
+
+// publishQuotaExceeded notifies subscribers that a project ran into an executor quota
+func (a *Loom) publishQuotaExceeded(qerr *executor.QuotaExceededError) {
+	logging.Module("executor").Warn("Quota exceeded", "project_id", qerr.ProjectID, "error", qerr)
+	if a.eventBus == nil {
+		return
+	}
+	_ = a.eventBus.Publish(&eventbus.Event{
+		Type:      eventbus.EventTypeExecutorQuotaExceeded,
+		Source:    "executor",
+		ProjectID: qerr.ProjectID,
+		Data: map[string]interface{}{
+			"project_id": qerr.ProjectID,
+			"quota":      qerr.Quota,
+			"limit":      qerr.Limit,
+			"used":       qerr.Used,
+		},
+	})
+}
+
+// alertAnomaly files an ops bead for a high-severity usage anomaly and
+// publishes it, so the activity feed and notifications pick it up
+func (a *Loom) alertAnomaly(an *patterns.PatternAnomaly) {
+	logging.Module("patterns").Warn("Usage anomaly", "severity", an.Severity, "description", an.Description)
+
+	priority := models.BeadPriorityP1
+	if an.Severity == "critical" {
+		priority = models.BeadPriorityP0
+	}
+	subject := an.Type
+	if an.ProviderID != "" || an.ModelName != "" {
+		subject = fmt.Sprintf("%s on %s/%s", an.Type, an.ProviderID, an.ModelName)
+	}
+
+	beadID := ""
+	projectID := patterns.WithDefaults(a.config.Patterns).AlertProjectID
+	description := fmt.Sprintf("%s\n\nSeverity: %s\nBaseline: %.4f\nActual: %.4f\nDeviation: %.1f std devs\nOccurred: %s\nDetected: %s\nAnomaly ID: %s",
+		an.Description, an.Severity, an.Baseline, an.Actual, an.Deviation,
+		an.OccurredAt.UTC().Format(time.RFC3339), an.DetectedAt.UTC().Format(time.RFC3339), an.ID)
+	if a.readOnly.Enabled() {
+		// Filing is a write; the event below still reports the anomaly
+		logging.Module("patterns").Info("Read-only, not filing an ops bead for the anomaly", "anomaly_id", an.ID)
+	} else if bead, err := a.CreateBead("[ops] Usage anomaly: "+subject, description, priority, "task", projectID); err != nil {
+		logging.Module("patterns").Error("Failed to file ops bead for anomaly", "anomaly_id", an.ID, "error", err)
+	} else {
+		beadID = bead.ID
+		_, _ = a.UpdateBead(bead.ID, map[string]interface{}{
+			"tags": []string{"ops", "anomaly", an.Type},
+			"context": map[string]string{
+				"anomaly_id":       an.ID,
+				"anomaly_type":     an.Type,
+				"anomaly_severity": an.Severity,
+				"anomaly_key":      patterns.AnomalyKey(an),
+			},
+		})
+	}
+
+	if a.eventBus == nil {
+		return
+	}
+	_ = a.eventBus.Publish(&eventbus.Event{
+		Type:      eventbus.EventTypePatternAnomaly,
+		Source:    "patterns",
+		ProjectID: projectID,
+		Data: map[string]interface{}{
+			"anomaly_id":  an.ID,
+			"type":        an.Type,
+			"severity":    an.Severity,
+			"description": an.Description,
+			"baseline":    an.Baseline,
+			"actual":      an.Actual,
+			"deviation":   an.Deviation,
+			"provider_id": an.ProviderID,
+			"model_name":  an.ModelName,
+			"bead_id":     beadID,
+			"priority":    fmt.Sprintf("P%d", priority),
+		},
+	})
+}
+
+func executorQuota(q config.ExecutorQuota) executor.ProjectQuota {
+	return executor.ProjectQuota{
+		MaxConcurrentCommands: q.MaxConcurrentCommands,
+		MaxCPUSecondsPerDay:   q.MaxCPUSecondsPerDay,
+		MaxMemoryMB:           q.MaxMemoryMB,
+		MaxDiskMB:             q.MaxDiskMB,
+		MaxWallClockPerDay:    q.MaxWallClockPerDay,
+	}
+}
+
+// GetContextStore returns the shared bead collaboration context store
+func (a *Loom) GetContextStore() *collaboration.ContextStore {
+	return a.contextStore
+}
+
+// GetCoEditStore returns the shared editing buffers of co-edited files
+func (a *Loom) GetCoEditStore() *collaboration.CoEditStore {
+	return a.coEditStore
+}
+
 // GetActivityManager returns the activity manager
 func (a *Loom) GetActivityManager() *activity.Manager {
 	return a.activityManager
@@ -1505,7 +2486,7 @@ func (a *Loom) maybeFileReadinessBead(project *models.Project, issues []string,
 		project.ID,
 	)
 	if err != nil {
-		log.Printf("failed to auto-file readiness bead for %s: %v", project.ID, err)
+		loomLog.Error("Failed to auto-file readiness bead", "project_id", project.ID, "error", err)
 		return
 	}
 
@@ -1832,9 +2813,9 @@ func (a *Loom) CreateAgent(ctx context.Context, name, personaName, projectID, ro
 	// Persist agent to the configuration database
 	if a.database != nil {
 		if err := a.database.UpsertAgent(agent); err != nil {
-			log.Printf("Warning: Failed to persist agent %s to database: %v", agent.ID, err)
+			loomLog.Warn("Failed to persist agent to database", "agent_id", agent.ID, "error", err)
 		} else {
-			log.Printf("Persisted agent %s (%s) to database with status: %s", agent.ID, agent.Name, agent.Status)
+			loomLog.Debug("Persisted agent to database", "agent_id", agent.ID, "agent", agent.Name, "status", agent.Status)
 		}
 	}
 
@@ -1877,7 +2858,7 @@ func (a *Loom) SpawnAgent(ctx context.Context, name, personaName, projectID stri
 	if a.temporalManager != nil {
 		if err := a.temporalManager.StartAgentWorkflow(ctx, agent.ID, projectID, personaName, name); err != nil {
 			// Log error but don't fail agent creation
-			fmt.Printf("Warning: failed to start agent workflow: %v\n", err)
+			loomLog.Warn("Failed to start agent workflow", "agent_id", agent.ID, "error", err)
 		}
 	}
 
@@ -1922,7 +2903,7 @@ func (a *Loom) ListProviders() ([]*internalmodels.Provider, error) {
 }
 
 func (a *Loom) RegisterProvider(ctx context.Context, p *internalmodels.Provider, apiKeys ...string) (*internalmodels.Provider, error) {
-	log.Printf("RegisterProvider called for: %s (type: %s, endpoint: %s)", p.ID, p.Type, p.Endpoint)
+	providerLog.Info("Registering provider", "provider_id", p.ID, "type", p.Type, "endpoint", p.Endpoint)
 	if a.database == nil {
 		return nil, fmt.Errorf("database not configured")
 	}
@@ -1942,6 +2923,9 @@ func (a *Loom) RegisterProvider(ctx context.Context, p *internalmodels.Provider,
 	// OpenAI default normalization for compatibility.
 	if p.Type != "ollama" {
 		p.Endpoint = normalizeProviderEndpoint(p.Endpoint)
+		for i, e := range p.Endpoints {
+			p.Endpoints[i] = normalizeProviderEndpoint(e)
+		}
 	}
 	p.LastHeartbeatError = ""
 	if p.ConfiguredModel == "" {
@@ -1969,6 +2953,7 @@ func (a *Loom) RegisterProvider(ctx context.Context, p *internalmodels.Provider,
 		Name:                   p.Name,
 		Type:                   p.Type,
 		Endpoint:               p.Endpoint,
+		Endpoints:              p.Endpoints,
 		APIKey:                 regAPIKey,
 		Model:                  p.SelectedModel,
 		ConfiguredModel:        p.ConfiguredModel,
@@ -1977,6 +2962,7 @@ func (a *Loom) RegisterProvider(ctx context.Context, p *internalmodels.Provider,
 		Status:                 p.Status,
 		LastHeartbeatAt:        p.LastHeartbeatAt,
 		LastHeartbeatLatencyMs: p.LastHeartbeatLatencyMs,
+		SupportsVision:         p.SupportsVision,
 	})
 	if a.eventBus != nil {
 		_ = a.eventBus.Publish(&eventbus.Event{
@@ -1994,7 +2980,7 @@ func (a *Loom) RegisterProvider(ctx context.Context, p *internalmodels.Provider,
 	_ = a.ensureProviderHeartbeat(ctx, p.ID)
 
 	// Immediately attempt to get models from the provider to validate and update status
-	log.Printf("Launching health check goroutine for provider: %s", p.ID)
+	providerLog.Debug("Launching health check", "provider_id", p.ID)
 	go a.checkProviderHealthAndActivate(p.ID)
 
 	return p, nil
@@ -2021,6 +3007,9 @@ func (a *Loom) UpdateProvider(ctx context.Context, p *internalmodels.Provider) (
 	}
 	if p.Type != "ollama" {
 		p.Endpoint = normalizeProviderEndpoint(p.Endpoint)
+		for i, e := range p.Endpoints {
+			p.Endpoints[i] = normalizeProviderEndpoint(e)
+		}
 	}
 	// If the operator edits a provider, we treat it as needing re-validation.
 	p.LastHeartbeatError = ""
@@ -2044,6 +3033,7 @@ func (a *Loom) UpdateProvider(ctx context.Context, p *internalmodels.Provider) (
 		Name:                   p.Name,
 		Type:                   p.Type,
 		Endpoint:               p.Endpoint,
+		Endpoints:              p.Endpoints,
 		Model:                  p.SelectedModel,
 		ConfiguredModel:        p.ConfiguredModel,
 		SelectedModel:          p.SelectedModel,
@@ -2051,6 +3041,7 @@ func (a *Loom) UpdateProvider(ctx context.Context, p *internalmodels.Provider) (
 		Status:                 p.Status,
 		LastHeartbeatAt:        p.LastHeartbeatAt,
 		LastHeartbeatLatencyMs: p.LastHeartbeatLatencyMs,
+		SupportsVision:         p.SupportsVision,
 	})
 	if a.eventBus != nil {
 		_ = a.eventBus.Publish(&eventbus.Event{
@@ -2133,7 +3124,7 @@ func (a *Loom) RunReplQuery(ctx context.Context, message string) (*ReplResult, e
 	bead, err := a.beadsManager.CreateBead(beadTitle, cleanMessage, models.BeadPriorityP0, "task", "loom-self")
 	if err != nil {
 		// If bead creation fails, continue anyway but log it
-		log.Printf("Warning: Failed to create CEO query bead: %v", err)
+		loomLog.Warn("Failed to create CEO query bead", "error", err)
 	}
 
 	var beadID string
@@ -2169,6 +3160,7 @@ func (a *Loom) RunReplQuery(ctx context.Context, message string) (*ReplResult, e
 		Message:      cleanMessage,
 		Temperature:  0.2,
 		MaxTokens:    1200,
+		Hedge:        true,
 	}
 
 	result, err := a.temporalManager.RunProviderQueryWorkflow(ctx, input)
@@ -2194,7 +3186,19 @@ func (a *Loom) RunReplQuery(ctx context.Context, message string) (*ReplResult, e
 			BeadID:    beadID,
 			ProjectID: "loom-self",
 		}
-		env, parseErr := actions.DecodeLenient([]byte(result.Response))
+		reprompt := func(ctx context.Context, feedback string) (string, error) {
+			retryInput := input
+			retryInput.Message = fmt.Sprintf("%s\n\n%s", cleanMessage, feedback)
+			retry, retryErr := a.temporalManager.RunProviderQueryWorkflow(ctx, retryInput)
+			if retryErr != nil {
+				return "", retryErr
+			}
+			result.TokensUsed += retry.TokensUsed
+			return retry.Response, nil
+		}
+		correction := actions.DecodeWithCorrection(ctx, result.Response, actions.MaxCorrectionAttempts(a.config.Agents.CorrectionAttempts), reprompt)
+		result.Response = correction.Raw
+		env, parseErr := correction.Envelope, correction.Err
 		if parseErr != nil {
 			actionResult := a.actionRouter.AutoFileParseFailure(ctx, actx, parseErr, result.Response)
 			actionResults = []actions.Result{actionResult}
@@ -2486,11 +3490,13 @@ func (a *Loom) NegotiateProviderModel(ctx context.Context, providerID string) (*
 		Name:            providerRecord.Name,
 		Type:            providerRecord.Type,
 		Endpoint:        providerRecord.Endpoint,
+		Endpoints:       providerRecord.Endpoints,
 		Model:           providerRecord.SelectedModel,
 		ConfiguredModel: providerRecord.ConfiguredModel,
 		SelectedModel:   providerRecord.SelectedModel,
 		SelectedGPU:     providerRecord.SelectedGPU,
 		Status:          "active",
+		SupportsVision:  providerRecord.SupportsVision,
 	})
 	if a.eventBus != nil {
 		_ = a.eventBus.Publish(&eventbus.Event{
@@ -2555,6 +3561,107 @@ func (a *Loom) ReleaseFileAccess(projectID, filePath, agentID string) error {
 	return a.fileLockManager.ReleaseLock(projectID, filePath, agentID)
 }
 
+// ForceReleaseFileAccess releases a file lock whoever holds it, for an
+// operator clearing a lock left by a stuck agent. The holder's bead is told.
+func (a *Loom) ForceReleaseFileAccess(projectID, filePath, releasedBy string) (*models.FileLock, error) {
+	lock, err := a.fileLockManager.ForceReleaseLock(projectID, filePath)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{
+		"path":          lock.FilePath,
+		"project_id":    lock.ProjectID,
+		"held_by_agent": lock.AgentID,
+		"held_by_bead":  lock.BeadID,
+		"released_by":   releasedBy,
+	}
+	a.publishFileLockEvent(eventbus.EventTypeFileLockForceRelease, lock.ProjectID, data)
+	if a.contextStore != nil && lock.BeadID != "" {
+		ctx := context.Background()
+		if _, err := a.contextStore.GetOrCreate(ctx, lock.BeadID, lock.ProjectID); err == nil {
+			_ = a.contextStore.AddActivity(ctx, lock.BeadID, releasedBy, "file_lock_released",
+				fmt.Sprintf("Lock on %s held by agent %s was force-released", lock.FilePath, lock.AgentID), data)
+		}
+	}
+	return lock, nil
+}
+
+// LockFiles satisfies actions.FileLocker. Every path is locked for the
+// agent or none is; on contention the locks taken by this call are
+// dropped again and both beads are told who is waiting on whom. Files open
+// in a shared editing buffer aren't written behind its back.
+func (a *Loom) LockFiles(ctx context.Context, actx actions.ActionContext, paths []string) error {
+	if a.coEditStore != nil {
+		for _, p := range paths {
+			if _, open := a.coEditStore.Text(actx.ProjectID, p); open {
+				// Writing the file would overwrite the shared buffer's edits
+				return apperr.Conflict("file %s is being co-edited; edit it through the shared buffer", p).
+					WithDetail("error_type", "file_co_edited").
+					WithDetail("path", p)
+			}
+		}
+	}
+	if a.fileLockManager == nil {
+		return nil
+	}
+	var taken []string
+	for _, p := range paths {
+		held := a.fileLockManager.IsLocked(actx.ProjectID, p)
+		if _, err := a.fileLockManager.AcquireLock(actx.ProjectID, p, actx.AgentID, actx.BeadID); err != nil {
+			for _, t := range taken {
+				_ = a.fileLockManager.ReleaseLock(actx.ProjectID, t, actx.AgentID)
+			}
+			a.reportLockContention(ctx, actx, err)
+			return err
+		}
+		if !held {
+			taken = append(taken, p)
+		}
+	}
+	return nil
+}
+
+// reportLockContention publishes a lock conflict and records it in the
+// collaboration activity of the waiting bead and the holding bead
+func (a *Loom) reportLockContention(ctx context.Context, actx actions.ActionContext, err error) {
+	details := apperr.DetailsOf(err)
+	if details == nil {
+		return
+	}
+	data := make(map[string]interface{}, len(details)+2)
+	for k, v := range details {
+		data[k] = v
+	}
+	data["requested_by_agent"] = actx.AgentID
+	data["requested_by_bead"] = actx.BeadID
+	a.publishFileLockEvent(eventbus.EventTypeFileLockContention, actx.ProjectID, data)
+	if a.contextStore == nil {
+		return
+	}
+	description := fmt.Sprintf("Agent %s is waiting on %v, locked by agent %v", actx.AgentID, details["path"], details["held_by_agent"])
+	holderBead, _ := details["held_by_bead"].(string)
+	for _, beadID := range []string{actx.BeadID, holderBead} {
+		if beadID == "" {
+			continue
+		}
+		if _, err := a.contextStore.GetOrCreate(ctx, beadID, actx.ProjectID); err == nil {
+			_ = a.contextStore.AddActivity(ctx, beadID, actx.AgentID, "file_lock_contention", description, data)
+		}
+	}
+}
+
+func (a *Loom) publishFileLockEvent(eventType eventbus.EventType, projectID string, data map[string]interface{}) {
+	if a.eventBus == nil {
+		return
+	}
+	_ = a.eventBus.Publish(&eventbus.Event{
+		Type:      eventType,
+		Source:    "file-locks",
+		ProjectID: projectID,
+		Data:      data,
+	})
+}
+
 // findDefaultAssignee returns the ID of the best default triage agent for a project.
 // Preference order: CTO > Engineering Manager > any agent assigned to the project.
 func (a *Loom) findDefaultAssignee(projectID string) string {
@@ -2621,12 +3728,13 @@ func (a *Loom) CreateBead(title, description string, priority models.BeadPriorit
 			if updateErr := a.beadsManager.UpdateBead(bead.ID, map[string]interface{}{
 				"assigned_to": defaultAgent,
 			}); updateErr != nil {
-				log.Printf("[Loom] Warning: failed to auto-assign bead %s to %s: %v", bead.ID, defaultAgent, updateErr)
+				loomLog.Warn("Failed to auto-assign bead", "bead_id", bead.ID, "agent", defaultAgent, "error", updateErr)
 			} else {
-				log.Printf("[Loom] Auto-assigned new bead %s to default agent %s", bead.ID, defaultAgent)
+				loomLog.Info("Auto-assigned new bead to default agent", "bead_id", bead.ID, "agent", defaultAgent)
 			}
 		}
 	}
+	a.estimateBead(bead)
 
 	if a.eventBus != nil {
 		_ = a.eventBus.PublishBeadEvent(eventbus.EventTypeBeadCreated, bead.ID, projectID, map[string]interface{}{
@@ -2642,7 +3750,7 @@ func (a *Loom) CreateBead(title, description string, priority models.BeadPriorit
 		ctx := context.Background()
 		if err := a.temporalManager.StartBeadWorkflow(ctx, bead.ID, projectID, title, description, int(priority), beadType); err != nil {
 			// Log error but don't fail bead creation
-			fmt.Printf("Warning: failed to start bead workflow: %v\n", err)
+			loomLog.Warn("Failed to start bead workflow", "bead_id", bead.ID, "error", err)
 		}
 	}
 
@@ -2678,6 +3786,13 @@ func (a *Loom) CloseBead(beadID, reason string) error {
 			"reason": reason,
 		})
 	}
+	a.reportDelegation(beadID)
+	a.releaseBeadLocks(beadID)
+	a.teardownStaging(beadID)
+	a.dropTestDatabase(beadID)
+	a.recordBeadActual(beadID)
+	a.concludeExperiment(beadID, true)
+	a.reportToGitHubIssue(beadID)
 
 	// Auto-create apply-fix bead if this was an approved code fix proposal
 	if strings.Contains(strings.ToLower(bead.Title), "code fix approval") &&
@@ -2685,7 +3800,7 @@ func (a *Loom) CloseBead(beadID, reason string) error {
 		strings.Contains(strings.ToLower(reason), "approve") {
 
 		if err := a.createApplyFixBead(bead, reason); err != nil {
-			log.Printf("[AutoFix] Failed to create apply-fix bead for %s: %v", beadID, err)
+			autoFixLog.Error("Failed to create apply-fix bead", "bead_id", beadID, "error", err)
 			// Don't fail the close operation if apply-fix creation fails
 		}
 	}
@@ -2784,12 +3899,12 @@ func (a *Loom) createApplyFixBead(approvalBead *models.Bead, closeReason string)
 	}
 
 	if err := a.beadsManager.UpdateBead(bead.ID, updates); err != nil {
-		log.Printf("[AutoFix] Failed to update apply-fix bead %s: %v", bead.ID, err)
+		autoFixLog.Error("Failed to update apply-fix bead", "bead_id", bead.ID, "error", err)
 		// Don't fail - bead is created, just missing some metadata
 	}
 
-	log.Printf("[AutoFix] Created apply-fix bead %s for approved proposal %s (original bug: %s)",
-		bead.ID, approvalBead.ID, originalBugID)
+	autoFixLog.Info("Created apply-fix bead for approved proposal",
+		"bead_id", bead.ID, "approval_bead_id", approvalBead.ID, "original_bug_id", originalBugID)
 
 	return nil
 }
@@ -2864,7 +3979,7 @@ func (a *Loom) CreateDecisionBead(question, parentBeadID, requesterID string, op
 		ctx := context.Background()
 		if err := a.temporalManager.StartDecisionWorkflow(ctx, decision.ID, projectID, question, requesterID, options); err != nil {
 			// Log error but don't fail decision creation
-			fmt.Printf("Warning: failed to start decision workflow: %v\n", err)
+			loomLog.Warn("Failed to start decision workflow", "decision_id", decision.ID, "error", err)
 		}
 	}
 
@@ -3079,7 +4194,21 @@ func (a *Loom) UpdateBead(beadID string, updates map[string]interface{}) (*model
 	if err := a.beadsManager.UpdateBead(beadID, updates); err != nil {
 		return nil, err
 	}
+	return a.beadUpdated(beadID, updates)
+}
+
+// UpdateBeadIfVersion updates a bead only if it is still at the version the
+// caller last read. Conflicts are returned as *beads.ConflictError.
+func (a *Loom) UpdateBeadIfVersion(beadID string, version int64, updates map[string]interface{}) (*models.Bead, error) {
+	if err := a.beadsManager.UpdateBeadIfVersion(beadID, version, updates); err != nil {
+		return nil, err
+	}
+	return a.beadUpdated(beadID, updates)
+}
 
+// beadUpdated publishes the events for an applied bead update and returns
+// the updated bead
+func (a *Loom) beadUpdated(beadID string, updates map[string]interface{}) (*models.Bead, error) {
 	bead, err := a.beadsManager.GetBead(beadID)
 	if err != nil {
 		return nil, err
@@ -3100,10 +4229,63 @@ func (a *Loom) UpdateBead(beadID string, updates map[string]interface{}) (*model
 			})
 		}
 	}
+	if status, ok := updates["status"].(models.BeadStatus); ok && status == models.BeadStatusClosed {
+		a.reportDelegation(beadID)
+		a.releaseBeadLocks(beadID)
+		a.teardownStaging(beadID)
+		a.dropTestDatabase(beadID)
+		a.recordBeadActual(beadID)
+		a.concludeExperiment(beadID, true)
+		a.reportToGitHubIssue(beadID)
+	}
+	if status, ok := updates["status"].(models.BeadStatus); ok && status == models.BeadStatusBlocked {
+		a.concludeExperiment(beadID, false)
+	}
 
 	return bead, nil
 }
 
+// reportDelegation feeds a closed delegated bead's results back to the bead
+// it was delegated from
+func (a *Loom) reportDelegation(beadID string) {
+	if a.delegations == nil {
+		return
+	}
+	if err := a.delegations.BeadClosed(context.Background(), beadID); err != nil {
+		logging.Module("delegation").Error("Failed to report closed bead to its parent", "bead_id", beadID, "error", err)
+	}
+}
+
+// releaseBeadLocks drops the file locks a closed bead's agents still hold
+func (a *Loom) releaseBeadLocks(beadID string) {
+	if a.fileLockManager == nil {
+		return
+	}
+	a.fileLockManager.ReleaseBeadLocks(beadID)
+}
+
+// dropTestDatabase drops a closed bead's test database in the background,
+// so closing the bead does not wait on a container being removed
+func (a *Loom) dropTestDatabase(beadID string) {
+	if a.testDatabases == nil {
+		return
+	}
+	go a.testDatabases.BeadClosed(beadID)
+}
+
+// teardownStaging deletes a closed bead's staging namespace in the
+// background, so closing the bead does not wait on the cluster
+func (a *Loom) teardownStaging(beadID string) {
+	if a.stagingManager == nil {
+		return
+	}
+	go func() {
+		if err := a.stagingManager.BeadClosed(context.Background(), beadID); err != nil {
+			logging.Module("k8s").Error("Failed to tear down staging namespace", "bead_id", beadID, "error", err)
+		}
+	}()
+}
+
 // GetReadyBeads returns beads that are ready to work on
 func (a *Loom) GetReadyBeads(projectID string) ([]*models.Bead, error) {
 	return a.beadsManager.GetReadyBeads(projectID)
@@ -3124,87 +4306,156 @@ func (a *Loom) GetGitopsManager() *gitops.Manager {
 	return a.gitopsManager
 }
 
-// StartMaintenanceLoop starts background maintenance tasks
+// StartMaintenanceLoop runs the background maintenance tasks until ctx is
+// cancelled
 func (a *Loom) StartMaintenanceLoop(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+	if a == nil || a.housekeeping == nil {
+		return
+	}
+	a.housekeeping.Start(ctx)
+}
 
-	var lastFederationSync time.Time
+// StartScheduler runs due recurring jobs until ctx is cancelled
+func (a *Loom) StartScheduler(ctx context.Context) {
+	if a == nil || a.scheduler == nil {
+		return
+	}
+	a.scheduler.Start(ctx)
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			// Clean expired file locks
-			cleaned := a.fileLockManager.CleanExpiredLocks()
-			if cleaned > 0 {
-				// Log: cleaned N expired locks
-				_ = cleaned
-			}
+// StartPolicyWatcher reloads changed action policies until ctx is cancelled
+func (a *Loom) StartPolicyWatcher(ctx context.Context) {
+	if a == nil || a.policies == nil {
+		return
+	}
+	a.policies.Watch(ctx, a.config.Policy.ReloadInterval)
+}
 
-			// Check for stale agents (no heartbeat in 2x interval)
-			staleThreshold := time.Now().Add(-2 * a.config.Agents.HeartbeatInterval)
-			for _, agent := range a.agentManager.ListAgents() {
-				if agent.LastActive.Before(staleThreshold) {
-					// Log: agent stale, releasing locks
-					_ = a.fileLockManager.ReleaseAgentLocks(agent.ID)
-				}
-			}
+// StartCIMonitor polls CI for branches pushed by agents until ctx is
+// cancelled. A negative ci.poll_interval leaves CI to webhooks.
+func (a *Loom) StartCIMonitor(ctx context.Context) {
+	if a == nil || a.ciManager == nil || a.config.CI.PollInterval < 0 {
+		return
+	}
+	a.ciManager.Start(ctx, a.config.CI.PollInterval)
+}
 
-			// FIX #5: Reset agents stuck in working state for > 5 minutes
-			resetCount := a.agentManager.ResetStuckAgents(5 * time.Minute)
-			if resetCount > 0 {
-				log.Printf("[Maintenance] Reset %d stuck agents", resetCount)
-			}
+// StartTrackerSync syncs beads linked to tracker issues until ctx is
+// cancelled. A negative tracker.interval leaves syncing to the API.
+func (a *Loom) StartTrackerSync(ctx context.Context) {
+	if a == nil || a.tracker == nil || len(a.tracker.Providers()) == 0 || a.config.Tracker.Interval < 0 {
+		return
+	}
+	a.tracker.Start(ctx, a.config.Tracker.Interval)
+}
 
-			// NOTE: Stuck bead resolution is handled by the Ralph Loop
-			// (LoomHeartbeatActivity). CEO escalation is only available via
-			// explicit CLI/REPL commands.
+// StartBackups takes scheduled backups until ctx is cancelled
+func (a *Loom) StartBackups(ctx context.Context) {
+	if a == nil || a.backups == nil {
+		return
+	}
+	a.backups.Start(ctx, backup.WithDefaults(a.config.Backup).Interval)
+}
 
-			// Refresh bead cache from Dolt to pick up beads created externally
-			for _, p := range a.projectManager.ListProjects() {
-				if p.BeadsPath != "" {
-					beadsRoot := p.BeadsPath
-					if p.WorkDir != "" {
-						beadsRoot = filepath.Join(p.WorkDir, p.BeadsPath)
-					}
-					if err := a.beadsManager.RefreshBeads(p.ID, beadsRoot); err != nil {
-						log.Printf("[Maintenance] Bead refresh failed for %s: %v", p.ID, err)
-					}
-				}
-			}
+// StartRetention archives closed beads and prunes logs until ctx is
+// cancelled
+func (a *Loom) StartRetention(ctx context.Context) {
+	if a == nil || a.retention == nil {
+		return
+	}
+	a.retention.Start(ctx, retention.WithDefaults(a.config.Retention, a.config.Beads.CompactOldDays).Interval)
+}
 
-			// Periodic federation sync
-			if a.config.Beads.Federation.Enabled && a.config.Beads.Federation.SyncInterval > 0 {
-				if time.Since(lastFederationSync) >= a.config.Beads.Federation.SyncInterval {
-					if err := a.beadsManager.SyncFederation(ctx, &a.config.Beads.Federation); err != nil {
-						log.Printf("[Federation] Periodic sync failed: %v", err)
-					}
-					lastFederationSync = time.Now()
-				}
-			}
-		}
+// StartSLA evaluates bead deadlines until ctx is cancelled
+func (a *Loom) StartSLA(ctx context.Context) {
+	if a == nil || a.sla == nil {
+		return
+	}
+	interval := sla.WithDefaults(a.config.SLA).Interval
+	if interval < 0 {
+		return
 	}
+	a.sla.Start(ctx, interval)
+}
+
+// publishSLATransition announces a bead becoming at risk or breaching its
+// due date
+func (a *Loom) publishSLATransition(t sla.Transition) {
+	if a.eventBus == nil {
+		return
+	}
+	eventType := eventbus.EventTypeDeadlineApproaching
+	if t.To == sla.StateBreached {
+		eventType = eventbus.EventTypeDeadlinePassed
+	}
+	_ = a.eventBus.Publish(&eventbus.Event{
+		Type:      eventType,
+		Source:    "sla",
+		ProjectID: t.ProjectID,
+		Data: map[string]interface{}{
+			"bead_id":  t.BeadID,
+			"title":    t.Title,
+			"priority": t.Priority,
+			"state":    t.To,
+			"due_date": t.DueDate,
+			"action":   t.Action,
+		},
+	})
+}
+
+// StartSearchIndexer keeps the search index current until ctx is cancelled
+func (a *Loom) StartSearchIndexer(ctx context.Context) {
+	if a == nil || a.searchIndexer == nil {
+		return
+	}
+	a.searchIndexer.Start(ctx, 30*time.Second)
+}
+
+// StartPatternSnapshots stores a pattern report on the configured schedule
+// until ctx is cancelled
+func (a *Loom) StartPatternSnapshots(ctx context.Context) {
+	if a == nil || a.patternManager == nil || !a.patternManager.HasReportStore() {
+		return
+	}
+	interval := patterns.WithDefaults(a.config.Patterns).SnapshotInterval
+	if interval < 0 {
+		return
+	}
+	a.patternManager.StartSnapshots(ctx, interval)
+}
+
+// StartAnomalyAlerts checks usage patterns for anomalies on the configured
+// schedule until ctx is cancelled
+func (a *Loom) StartAnomalyAlerts(ctx context.Context) {
+	if a == nil || a.patternManager == nil {
+		return
+	}
+	interval := patterns.WithDefaults(a.config.Patterns).AlertInterval
+	if interval < 0 {
+		return
+	}
+	a.patternManager.StartAnomalyChecks(ctx, interval)
 }
 
 // StartDispatchLoop runs a periodic dispatcher that fills all idle agents with work.
 func (a *Loom) StartDispatchLoop(ctx context.Context, interval time.Duration) {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("[DispatchLoop] PANIC recovered: %v", r)
+			dispatchLoopLog.Error("Panic recovered", "panic", r)
 		}
 	}()
 
 	if a == nil || a.dispatcher == nil {
-		log.Printf("[DispatchLoop] No dispatcher configured, skipping")
+		dispatchLoopLog.Warn("No dispatcher configured, skipping")
 		return
 	}
 	if interval <= 0 {
 		interval = 10 * time.Second
 	}
 
-	log.Printf("[DispatchLoop] Starting with %s interval", interval)
+	dispatchLoopLog.Info("Starting", "interval", interval)
+	a.dispatchInterval.Store(int64(interval))
+	a.dispatchTick.Store(time.Now().UnixNano())
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
@@ -3219,26 +4470,37 @@ func (a *Loom) StartDispatchLoop(ctx context.Context, interval time.Duration) {
 					break
 				}
 			}
+			a.dispatchTick.Store(time.Now().UnixNano())
 		}
 	}
 }
 
+// DispatchLoopStatus reports when the dispatch loop last finished a pass and
+// its interval, for liveness checks. Both are zero until the loop starts.
+func (a *Loom) DispatchLoopStatus() (time.Time, time.Duration) {
+	tick := a.dispatchTick.Load()
+	if tick == 0 {
+		return time.Time{}, 0
+	}
+	return time.Unix(0, tick), time.Duration(a.dispatchInterval.Load())
+}
+
 // checkProviderHealthAndActivate checks if a newly registered provider has models available
 // and immediately activates it if so, without waiting for the heartbeat workflow
 func (a *Loom) checkProviderHealthAndActivate(providerID string) {
 	time.Sleep(300 * time.Millisecond)
-	log.Printf("Checking health for provider: %s", providerID)
+	providerLog.Debug("Checking provider health", "provider_id", providerID)
 	models, err := a.GetProviderModels(context.Background(), providerID)
 	if err != nil {
-		log.Printf("Provider %s health check failed: %v", providerID, err)
+		providerLog.Warn("Provider health check failed", "provider_id", providerID, "error", err)
 		return
 	}
 	if len(models) == 0 {
-		log.Printf("Provider %s returned no models", providerID)
+		providerLog.Warn("Provider returned no models", "provider_id", providerID)
 		return
 	}
 
-	log.Printf("Provider %s is healthy, activating (models: %d)", providerID, len(models))
+	providerLog.Info("Provider is healthy, activating", "provider_id", providerID, "models", len(models))
 	// Update provider status to active in both database and registry
 	if dbProvider, err := a.database.GetProvider(providerID); err == nil && dbProvider != nil {
 		dbProvider.Status = "active"
@@ -3249,6 +4511,7 @@ func (a *Loom) checkProviderHealthAndActivate(providerID string) {
 			Name:                   dbProvider.Name,
 			Type:                   dbProvider.Type,
 			Endpoint:               dbProvider.Endpoint,
+			Endpoints:              dbProvider.Endpoints,
 			Model:                  dbProvider.SelectedModel,
 			ConfiguredModel:        dbProvider.ConfiguredModel,
 			SelectedModel:          dbProvider.SelectedModel,
@@ -3256,8 +4519,9 @@ func (a *Loom) checkProviderHealthAndActivate(providerID string) {
 			Status:                 "active",
 			LastHeartbeatAt:        dbProvider.LastHeartbeatAt,
 			LastHeartbeatLatencyMs: dbProvider.LastHeartbeatLatencyMs,
+			SupportsVision:         dbProvider.SupportsVision,
 		})
-		log.Printf("Provider %s activated successfully", providerID)
+		providerLog.Info("Provider activated", "provider_id", providerID)
 	}
 
 	// Attach newly active provider to paused agents (best-effort)
@@ -3296,13 +4560,13 @@ func (a *Loom) ResumeAgentsWaitingForProvider(ctx context.Context, providerID st
 
 		// Write-through: Update database first
 		if err := a.database.UpsertAgent(agent); err != nil {
-			fmt.Printf("Warning: failed to resume agent %s: %v\n", agent.ID, err)
+			loomLog.Warn("Failed to resume agent", "agent_id", agent.ID, "error", err)
 			continue
 		}
 
 		// Write-through: Update in-memory cache
 		if err := a.agentManager.UpdateAgentStatus(agent.ID, "idle"); err != nil {
-			fmt.Printf("Warning: failed to update agent %s status in memory: %v\n", agent.ID, err)
+			loomLog.Warn("Failed to update agent status in memory", "agent_id", agent.ID, "error", err)
 		}
 	}
 
@@ -3326,11 +4590,11 @@ func (a *Loom) attachProviderToPausedAgents(ctx context.Context, providerID stri
 
 	agents, err := a.database.ListAgents()
 	if err != nil {
-		log.Printf("Failed to list agents for provider attachment: %v", err)
+		providerLog.Error("Failed to list agents for provider attachment", "error", err)
 		return
 	}
 
-	log.Printf("Found %d agent(s) to check for provider %s attachment", len(agents), providerID)
+	providerLog.Debug("Checking agents for provider attachment", "agents", len(agents), "provider_id", providerID)
 	attachedCount := 0
 	updatedCount := 0
 	skippedCount := 0
@@ -3344,13 +4608,13 @@ func (a *Loom) attachProviderToPausedAgents(ctx context.Context, providerID stri
 			// Check if current provider is healthy
 			if a.providerRegistry.IsActive(ag.ProviderID) {
 				// Current provider is healthy - skip this agent
-				log.Printf("Skipping agent %s (%s) - already has healthy provider %s (status: %s)", ag.ID, ag.Name, ag.ProviderID, ag.Status)
+				providerLog.Debug("Skipping agent with a healthy provider", "agent_id", ag.ID, "agent", ag.Name, "provider_id", ag.ProviderID, "status", ag.Status)
 				skippedCount++
 				continue
 			}
 
 			// Current provider is unhealthy/failed - upgrade to new healthy provider
-			log.Printf("Agent %s (%s) has unhealthy provider %s - upgrading to healthy provider %s", ag.ID, ag.Name, ag.ProviderID, providerID)
+			providerLog.Info("Upgrading agent from unhealthy provider", "agent_id", ag.ID, "agent", ag.Name, "previous", ag.ProviderID, "provider_id", providerID)
 
 			// If agent is paused, also update status to idle
 			if ag.Status == "paused" {
@@ -3363,7 +4627,7 @@ func (a *Loom) attachProviderToPausedAgents(ctx context.Context, providerID stri
 		if ag.Persona == nil && ag.PersonaName != "" {
 			persona, err := a.personaManager.LoadPersona(ag.PersonaName)
 			if err != nil {
-				log.Printf("Failed to load persona %s for agent %s: %v", ag.PersonaName, ag.ID, err)
+				providerLog.Error("Failed to load persona", "persona", ag.PersonaName, "agent_id", ag.ID, "error", err)
 				continue
 			}
 			ag.Persona = persona
@@ -3376,13 +4640,13 @@ func (a *Loom) attachProviderToPausedAgents(ctx context.Context, providerID stri
 
 		// Write-through cache: Update database first (source of truth)
 		if err := a.database.UpsertAgent(ag); err != nil {
-			log.Printf("Failed to upsert agent %s with provider %s: %v", ag.ID, providerID, err)
+			providerLog.Error("Failed to upsert agent", "agent_id", ag.ID, "provider_id", providerID, "error", err)
 			continue
 		}
 
 		// Write-through cache: Update in-memory cache (RestoreAgentWorker handles both new and existing agents)
 		if _, err := a.agentManager.RestoreAgentWorker(ctx, ag); err != nil {
-			log.Printf("Failed to restore/update agent worker %s: %v", ag.ID, err)
+			providerLog.Error("Failed to restore or update agent worker", "agent_id", ag.ID, "error", err)
 			continue
 		}
 
@@ -3390,10 +4654,10 @@ func (a *Loom) attachProviderToPausedAgents(ctx context.Context, providerID stri
 			_ = a.projectManager.AddAgentToProject(ag.ProjectID, ag.ID)
 		}
 		attachedCount++
-		log.Printf("Successfully attached provider %s to agent %s (%s)", providerID, ag.ID, ag.Name)
+		providerLog.Info("Attached provider to agent", "provider_id", providerID, "agent_id", ag.ID, "agent", ag.Name)
 	}
 	if attachedCount > 0 || updatedCount > 0 {
-		log.Printf("Provider %s: attached to %d agent(s), updated status for %d agent(s), skipped %d agent(s)",
-			providerID, attachedCount, updatedCount, skippedCount)
+		providerLog.Info("Provider attachment done", "provider_id", providerID,
+			"attached", attachedCount, "updated", updatedCount, "skipped", skippedCount)
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/apperr"
 	"github.com/jordanhubbard/loom/internal/executor"
 	internalmodels "github.com/jordanhubbard/loom/internal/models"
 	"github.com/jordanhubbard/loom/internal/provider"
@@ -763,6 +764,45 @@ func TestFileLockManager_ExtendLock(t *testing.T) {
 	}
 }
 
+func TestFileLockManager_Contention(t *testing.T) {
+	flm := NewFileLockManager(5 * time.Minute)
+
+	first, _ := flm.AcquireLock("proj1", "a.go", "agent-1", "bead-1")
+	expires := first.ExpiresAt
+	time.Sleep(time.Millisecond)
+	renewed, err := flm.AcquireLock("proj1", "a.go", "agent-1", "bead-1")
+	if err != nil || !renewed.ExpiresAt.After(expires) {
+		t.Fatalf("expected the holder to renew its lock, got %v", err)
+	}
+
+	_, err = flm.AcquireLock("proj1", "a.go", "agent-2", "bead-2")
+	if apperr.CodeOf(err) != apperr.CodeConflict {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	details := apperr.DetailsOf(err)
+	if details["held_by_agent"] != "agent-1" || details["held_by_bead"] != "bead-1" || details["path"] != "a.go" {
+		t.Errorf("unexpected conflict details %v", details)
+	}
+	if retry, _ := details["retry_after_seconds"].(int); retry <= 0 || retry > 301 {
+		t.Errorf("unexpected retry_after_seconds %v", details["retry_after_seconds"])
+	}
+
+	lock, err := flm.ForceReleaseLock("proj1", "a.go")
+	if err != nil || lock.AgentID != "agent-1" {
+		t.Fatalf("ForceReleaseLock() = %v, %v", lock, err)
+	}
+	if _, err := flm.ForceReleaseLock("proj1", "a.go"); apperr.CodeOf(err) != apperr.CodeNotFound {
+		t.Errorf("expected not found for a released lock, got %v", err)
+	}
+
+	_, _ = flm.AcquireLock("proj1", "a.go", "agent-2", "bead-2")
+	_, _ = flm.AcquireLock("proj1", "b.go", "agent-2", "bead-2")
+	_, _ = flm.AcquireLock("proj1", "c.go", "agent-3", "bead-3")
+	if n := flm.ReleaseBeadLocks("bead-2"); n != 2 || len(flm.ListLocks()) != 1 {
+		t.Errorf("ReleaseBeadLocks() = %d, %d locks left", n, len(flm.ListLocks()))
+	}
+}
+
 func TestFileLockManager_ConcurrentAccess(t *testing.T) {
 	flm := NewFileLockManager(5 * time.Minute)
 	var wg sync.WaitGroup
@@ -0,0 +1,93 @@
+package loom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/housekeeping"
+)
+
+// stuckAgentTimeout is how long an agent may stay working before the
+// maintenance loop resets it
+const stuckAgentTimeout = 5 * time.Minute
+
+// registerMaintenanceTasks registers the tasks of the maintenance loop
+func (a *Loom) registerMaintenanceTasks() {
+	a.housekeeping.Register("file_locks", "Release expired file locks and the locks of agents without a recent heartbeat",
+		time.Minute, a.cleanFileLocks)
+	a.housekeeping.Register("stuck_agents", "Reset agents stuck in the working state",
+		time.Minute, a.resetStuckAgents)
+	// Stuck bead resolution is handled by the Ralph Loop
+	// (LoomHeartbeatActivity). CEO escalation is only available via
+	// explicit CLI/REPL commands.
+	a.housekeeping.Register("bead_refresh", "Reload bead caches from Dolt to pick up beads created externally",
+		time.Minute, a.refreshBeadCaches)
+	if a.config.Beads.Federation.Enabled && a.config.Beads.Federation.SyncInterval > 0 {
+		a.housekeeping.Register("federation_sync", "Sync beads with federated peers",
+			a.config.Beads.Federation.SyncInterval, a.syncFederation)
+	}
+}
+
+func (a *Loom) cleanFileLocks(ctx context.Context) (string, error) {
+	expired := a.fileLockManager.CleanExpiredLocks()
+	stale := 0
+	var errs []error
+	staleThreshold := time.Now().Add(-2 * a.config.Agents.HeartbeatInterval)
+	for _, agent := range a.agentManager.ListAgents() {
+		if agent.LastActive.Before(staleThreshold) {
+			if err := a.fileLockManager.ReleaseAgentLocks(agent.ID); err != nil {
+				errs = append(errs, fmt.Errorf("agent %s: %w", agent.ID, err))
+				continue
+			}
+			stale++
+		}
+	}
+	return fmt.Sprintf("released %d expired locks, locks of %d stale agents", expired, stale), errors.Join(errs...)
+}
+
+func (a *Loom) resetStuckAgents(ctx context.Context) (string, error) {
+	count := a.agentManager.ResetStuckAgents(stuckAgentTimeout)
+	if count > 0 {
+		maintenanceLog.Info("Reset stuck agents", "count", count)
+	}
+	return fmt.Sprintf("reset %d agents", count), nil
+}
+
+func (a *Loom) refreshBeadCaches(ctx context.Context) (string, error) {
+	refreshed := 0
+	var errs []error
+	for _, p := range a.projectManager.ListProjects() {
+		if p.BeadsPath == "" {
+			continue
+		}
+		beadsRoot := p.BeadsPath
+		if p.WorkDir != "" {
+			beadsRoot = filepath.Join(p.WorkDir, p.BeadsPath)
+		}
+		if err := a.beadsManager.RefreshBeads(p.ID, beadsRoot); err != nil {
+			maintenanceLog.Error("Bead refresh failed", "project_id", p.ID, "error", err)
+			errs = append(errs, fmt.Errorf("project %s: %w", p.ID, err))
+			continue
+		}
+		refreshed++
+	}
+	return fmt.Sprintf("refreshed %d projects", refreshed), errors.Join(errs...)
+}
+
+func (a *Loom) syncFederation(ctx context.Context) (string, error) {
+	if a.readOnly.Enabled() {
+		return "skipped: read-only", nil
+	}
+	if err := a.beadsManager.SyncFederation(ctx, &a.config.Beads.Federation); err != nil {
+		return "", err
+	}
+	return "synced", nil
+}
+
+// GetHousekeeping returns the runner of the maintenance loop's tasks
+func (a *Loom) GetHousekeeping() *housekeeping.Runner {
+	return a.housekeeping
+}
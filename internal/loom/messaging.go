@@ -0,0 +1,75 @@
+package loom
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/agent"
+	"github.com/jordanhubbard/loom/internal/messaging"
+	"github.com/jordanhubbard/loom/internal/roles"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// agentDirectory resolves message recipients for the agent message bus
+type agentDirectory struct {
+	agents *agent.WorkerManager
+	roles  *roles.Registry
+}
+
+// FindAgentByRole returns an agent holding the role, preferring agents on the
+// sender's project and idle agents over busy ones
+func (d *agentDirectory) FindAgentByRole(ctx context.Context, role string) (string, error) {
+	var candidates []*models.Agent
+	projectID := actions.ProjectIDFromContext(ctx)
+	if projectID != "" {
+		candidates = d.matching(d.agents.ListAgentsByProject(projectID), role)
+	}
+	if len(candidates) == 0 {
+		candidates = d.matching(d.agents.ListAgents(), role)
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no agent found with role %s", role)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Status == "idle" && candidates[j].Status != "idle"
+	})
+	return candidates[0].ID, nil
+}
+
+func (d *agentDirectory) matching(agents []*models.Agent, role string) []*models.Agent {
+	want := models.NormalizeRoleName(role)
+	var matched []*models.Agent
+	for _, a := range agents {
+		if a == nil {
+			continue
+		}
+		if models.NormalizeRoleName(a.Role) == want || models.NormalizeRoleName(a.PersonaName) == want {
+			matched = append(matched, a)
+			continue
+		}
+		if r := d.roles.RoleForAgent(a); r != nil && r.Matches(role) {
+			matched = append(matched, a)
+		}
+	}
+	return matched
+}
+
+// ListAgents returns every registered agent
+func (d *agentDirectory) ListAgents(ctx context.Context) ([]messaging.AgentInfo, error) {
+	agents := d.agents.ListAgents()
+	infos := make([]messaging.AgentInfo, 0, len(agents))
+	for _, a := range agents {
+		if a == nil {
+			continue
+		}
+		infos = append(infos, messaging.AgentInfo{
+			AgentID:     a.ID,
+			PersonaType: a.PersonaName,
+			Status:      a.Status,
+		})
+	}
+	return infos, nil
+}
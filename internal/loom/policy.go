@@ -0,0 +1,139 @@
+package loom
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/policy"
+	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// spendSource totals logged provider requests
+type spendSource interface {
+	GetLogStats(ctx context.Context, filter *analytics.LogFilter) (*analytics.LogStats, error)
+}
+
+// AgentRole returns the role name policies match an agent against: its
+// registered role when it maps to one, else the role it was created with
+func (a *Loom) AgentRole(agentID string) string {
+	if a.agentManager == nil {
+		return ""
+	}
+	agent, err := a.agentManager.GetAgent(agentID)
+	if err != nil || agent == nil {
+		return ""
+	}
+	if role := a.roleRegistry.RoleForAgent(agent); role != nil {
+		return role.Name
+	}
+	return agent.Role
+}
+
+// AgentSpendToday returns the agent's logged provider spend since midnight
+// UTC, or 0 when analytics are unavailable
+func (a *Loom) AgentSpendToday(ctx context.Context, agentID string) float64 {
+	if a.agentSpend == nil || a.agentManager == nil {
+		return 0
+	}
+	agent, err := a.agentManager.GetAgent(agentID)
+	if err != nil || agent == nil {
+		return 0
+	}
+	stats, err := a.agentSpend.GetLogStats(ctx, &analytics.LogFilter{
+		UserID:    "agent:" + agent.Name,
+		StartTime: time.Now().UTC().Truncate(24 * time.Hour),
+		EndTime:   time.Now().UTC(),
+	})
+	if err != nil || stats == nil {
+		return 0
+	}
+	return stats.TotalCostUSD
+}
+
+// RequestActionApproval files a decision asking to approve an action a
+// policy holds back, or reports the state of the one already filed for
+// the same action. An approval covers one execution.
+func (a *Loom) RequestActionApproval(ctx context.Context, actx actions.ActionContext, action actions.Action, decision policy.Decision) (actions.ApprovalStatus, string, error) {
+	key := approvalKey(actx, action)
+
+	a.approvalsMu.Lock()
+	defer a.approvalsMu.Unlock()
+	if decisionID, ok := a.actionApprovals[key]; ok {
+		if d, err := a.decisionManager.GetDecision(decisionID); err == nil && d != nil {
+			if d.DecidedAt == nil {
+				return actions.ApprovalPending, decisionID, nil
+			}
+			delete(a.actionApprovals, key)
+			if strings.EqualFold(strings.TrimSpace(d.Decision), "approve") {
+				return actions.ApprovalGranted, decisionID, nil
+			}
+			return actions.ApprovalDenied, decisionID, nil
+		}
+		delete(a.actionApprovals, key)
+	}
+
+	question := fmt.Sprintf("Approve %s by agent %s on bead %s?\n\nPolicy: %s\n\n%s\n\nChoose: approve | deny",
+		action.Type, actx.AgentID, actx.BeadID, decision.Reason, describeAction(action))
+	d, err := a.decisionManager.CreateDecision(question, actx.BeadID, actx.AgentID, []string{"approve", "deny"}, "", models.BeadPriorityP1, actx.ProjectID)
+	if err != nil {
+		return "", "", err
+	}
+	if d.Context == nil {
+		d.Context = make(map[string]string)
+	}
+	d.Context["approval_for"] = "action"
+	d.Context["action_type"] = action.Type
+	d.Context["policy_scope"] = decision.Scope
+	d.Context["policy_rule"] = decision.Rule
+	a.actionApprovals[key] = d.ID
+
+	if a.eventBus != nil {
+		_ = a.eventBus.Publish(&eventbus.Event{
+			Type:      eventbus.EventTypeDecisionCreated,
+			Source:    "action-policy",
+			ProjectID: actx.ProjectID,
+			Data: map[string]interface{}{
+				"decision_id": d.ID,
+				"bead_id":     actx.BeadID,
+				"agent_id":    actx.AgentID,
+				"action_type": action.Type,
+				"policy_rule": decision.Rule,
+			},
+		})
+	}
+	loomLog.InfoContext(ctx, "Action held for approval", "decision_id", d.ID, "action_type", action.Type,
+		"bead_id", actx.BeadID, "agent_id", actx.AgentID, "policy_rule", decision.Rule)
+	return actions.ApprovalPending, d.ID, nil
+}
+
+// approvalKey identifies an action for approval purposes, so a retry finds
+// the decision filed for it
+func approvalKey(actx actions.ActionContext, action actions.Action) string {
+	return strings.Join([]string{
+		actx.ProjectID, actx.AgentID, actx.BeadID, action.Type,
+		strings.Join(actions.TouchedPaths(action), ","), action.Command, action.Input, action.Branch,
+	}, "\x00")
+}
+
+// describeAction summarizes what an action would do for an approver
+func describeAction(action actions.Action) string {
+	var lines []string
+	if action.Command != "" {
+		lines = append(lines, "Command: "+action.Command)
+	}
+	if paths := actions.TouchedPaths(action); len(paths) > 0 {
+		lines = append(lines, "Paths: "+strings.Join(paths, ", "))
+	}
+	if action.Branch != "" {
+		lines = append(lines, "Branch: "+action.Branch)
+	}
+	if len(lines) == 0 {
+		return "No further details."
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,51 @@
+package loom
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/policy"
+)
+
+func TestRequestActionApproval(t *testing.T) {
+	a, tmp := newTestLoom(t)
+	defer os.RemoveAll(tmp)
+
+	ctx := context.Background()
+	actx := actions.ActionContext{AgentID: "agent-1", BeadID: "bead-1", ProjectID: "loom"}
+	action := actions.Action{Type: actions.ActionRunCommand, Command: "rm -rf build"}
+	decision := policy.Decision{Effect: policy.RequireApproval, Scope: policy.GlobalScope, Rule: "destructive", Reason: "destructive command"}
+
+	status, id, err := a.RequestActionApproval(ctx, actx, action, decision)
+	if err != nil || status != actions.ApprovalPending || id == "" {
+		t.Fatalf("expected a pending approval, got %s %q %v", status, id, err)
+	}
+	if _, again, _ := a.RequestActionApproval(ctx, actx, action, decision); again != id {
+		t.Errorf("expected a retry to find decision %s, got %s", id, again)
+	}
+	other := actions.Action{Type: actions.ActionRunCommand, Command: "rm -rf dist"}
+	if _, otherID, _ := a.RequestActionApproval(ctx, actx, other, decision); otherID == id {
+		t.Error("expected a different command to need its own approval")
+	}
+
+	if err := a.decisionManager.MakeDecision(id, "user-admin", "approve", "ok"); err != nil {
+		t.Fatal(err)
+	}
+	if status, _, _ := a.RequestActionApproval(ctx, actx, action, decision); status != actions.ApprovalGranted {
+		t.Errorf("expected the approval to be granted, got %s", status)
+	}
+	// The approval covered one execution
+	status, next, _ := a.RequestActionApproval(ctx, actx, action, decision)
+	if status != actions.ApprovalPending || next == id {
+		t.Errorf("expected a new request after the approval was used, got %s %s", status, next)
+	}
+
+	if err := a.decisionManager.MakeDecision(next, "user-admin", "deny", "no"); err != nil {
+		t.Fatal(err)
+	}
+	if status, _, _ := a.RequestActionApproval(ctx, actx, action, decision); status != actions.ApprovalDenied {
+		t.Errorf("expected the approval to be denied, got %s", status)
+	}
+}
@@ -0,0 +1,259 @@
+package loom
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/toolchain"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Provisioning statuses
+const (
+	ProvisionReady       = "ready"
+	ProvisionCloneFailed = "clone_failed" // Project is registered; fix access and retry
+)
+
+// scpLikeGitURL matches user@host:path remotes
+var scpLikeGitURL = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^\s]+$`)
+
+// ProvisionRequest registers a project from an existing git repository
+type ProvisionRequest struct {
+	GitURL       string               `json:"git_url"`
+	Name         string               `json:"name,omitempty"`          // Defaults to the repository name
+	Branch       string               `json:"branch,omitempty"`        // Defaults to the remote's default branch
+	AuthMethod   models.GitAuthMethod `json:"auth_method,omitempty"`   // Inferred from the URL when empty
+	CredentialID string               `json:"credential_id,omitempty"` // Key manager entry holding a deploy key (ssh) or token (token)
+	SkipSetup    bool                 `json:"skip_setup_beads,omitempty"`
+}
+
+// ProvisionResult reports the outcome of provisioning. A clone failure still
+// leaves the project registered so access can be fixed (e.g. by adding the
+// deploy key) and provisioning retried.
+type ProvisionResult struct {
+	ProjectID  string             `json:"project_id"`
+	Status     string             `json:"status"`
+	Branch     string             `json:"branch,omitempty"`
+	WorkDir    string             `json:"work_dir,omitempty"`
+	Stack      *toolchain.Profile `json:"stack,omitempty"`
+	SetupBeads []string           `json:"setup_bead_ids,omitempty"`
+	PublicKey  string             `json:"public_key,omitempty"` // Deploy key to grant when using ssh auth
+	Error      string             `json:"error,omitempty"`
+}
+
+// ProvisionProject clones a repository into a managed workdir, registers it
+// as a project, detects its stack and files setup beads.
+func (a *Loom) ProvisionProject(ctx context.Context, req ProvisionRequest) (*ProvisionResult, error) {
+	if a.gitopsManager == nil {
+		return nil, fmt.Errorf("git operations are not configured")
+	}
+	gitURL := strings.TrimSpace(req.GitURL)
+	if err := validateGitURL(gitURL); err != nil {
+		return nil, err
+	}
+	authMethod := req.AuthMethod
+	if authMethod == "" {
+		authMethod = inferGitAuthMethod(gitURL, req.CredentialID)
+	}
+	switch authMethod {
+	case models.GitAuthNone, models.GitAuthSSH, models.GitAuthToken:
+	default:
+		return nil, fmt.Errorf("unsupported auth_method %q (use none, ssh or token)", authMethod)
+	}
+	if authMethod == models.GitAuthToken && req.CredentialID == "" {
+		return nil, fmt.Errorf("credential_id is required for token auth")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = repoNameFromURL(gitURL)
+	}
+
+	p, err := a.CreateProject(name, gitURL, req.Branch, "", map[string]string{"provisioned_from": gitURL})
+	if err != nil {
+		return nil, err
+	}
+	p.GitAuthMethod = authMethod
+	p.GitCredentialID = req.CredentialID
+
+	if authMethod == models.GitAuthSSH && req.CredentialID != "" {
+		if _, err := a.gitopsManager.ImportProjectSSHKey(p.ID, req.CredentialID); err != nil {
+			_ = a.DeleteProject(p.ID)
+			return nil, err
+		}
+	}
+
+	return a.completeProvisioning(ctx, p, !req.SkipSetup), nil
+}
+
+// RetryProvisioning re-attempts the clone for a project whose provisioning failed
+func (a *Loom) RetryProvisioning(ctx context.Context, projectID string) (*ProvisionResult, error) {
+	if a.gitopsManager == nil {
+		return nil, fmt.Errorf("git operations are not configured")
+	}
+	p, err := a.projectManager.GetProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Context["provision_status"] != ProvisionCloneFailed {
+		return nil, fmt.Errorf("project %s has no failed provisioning to retry", projectID)
+	}
+	// A failed init+fetch leaves a repository without commits behind; clear it
+	// so the clone starts fresh.
+	gitDir := filepath.Join(a.gitopsManager.GetProjectWorkDir(p.ID), ".git")
+	if _, err := os.Stat(gitDir); err == nil {
+		cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "HEAD")
+		cmd.Dir = filepath.Dir(gitDir)
+		if cmd.Run() != nil {
+			_ = os.RemoveAll(gitDir)
+		}
+	}
+	return a.completeProvisioning(ctx, p, p.Context["provision_setup_beads"] != "false"), nil
+}
+
+func (a *Loom) completeProvisioning(ctx context.Context, p *models.Project, setupBeads bool) *ProvisionResult {
+	result := &ProvisionResult{ProjectID: p.ID}
+	projectContext := copyContext(p.Context)
+	projectContext["provision_setup_beads"] = fmt.Sprintf("%t", setupBeads)
+
+	if err := a.gitopsManager.CloneProject(ctx, p); err != nil {
+		result.Status = ProvisionCloneFailed
+		result.Error = err.Error()
+		if p.GitAuthMethod == models.GitAuthSSH {
+			if pubKey, keyErr := a.gitopsManager.EnsureProjectSSHKey(p.ID); keyErr == nil {
+				result.PublicKey = pubKey
+			}
+		}
+		projectContext["provision_status"] = ProvisionCloneFailed
+		_ = a.projectManager.UpdateProject(p.ID, map[string]interface{}{"context": projectContext})
+		a.PersistProject(p.ID)
+		return result
+	}
+
+	result.WorkDir = p.WorkDir
+	if p.Branch == "" {
+		cmd := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+		cmd.Dir = p.WorkDir
+		if out, err := cmd.Output(); err == nil {
+			_ = a.projectManager.UpdateProject(p.ID, map[string]interface{}{"branch": strings.TrimSpace(string(out))})
+		}
+	}
+	result.Branch = p.Branch
+
+	stack := toolchain.Detect(p.WorkDir)
+	result.Stack = &stack
+	for key, value := range map[string]string{
+		"language":        stack.Language,
+		"build_system":    stack.BuildSystem,
+		"install_command": stack.InstallCommand,
+		"build_command":   stack.BuildCommand,
+		"test_command":    stack.TestCommand,
+		"lint_command":    stack.LintCommand,
+		"format_command":  stack.FormatCommand,
+	} {
+		if value != "" {
+			projectContext[key] = value
+		}
+	}
+	projectContext["provision_status"] = ProvisionReady
+	_ = a.projectManager.UpdateProject(p.ID, map[string]interface{}{"context": projectContext})
+	a.PersistProject(p.ID)
+
+	if setupBeads {
+		result.SetupBeads = a.createSetupBeads(p.ID, stack)
+	}
+	result.Status = ProvisionReady
+	return result
+}
+
+// createSetupBeads files the first tasks for a freshly provisioned project
+func (a *Loom) createSetupBeads(projectID string, stack toolchain.Profile) []string {
+	detected := "No build system was detected at the repository root; inspect the repository to find out"
+	if stack.Language != "" {
+		detected = fmt.Sprintf("Detected %s (%s)", stack.BuildSystem, stack.Language)
+	} else if stack.BuildSystem != "" {
+		detected = "Detected " + stack.BuildSystem
+	}
+
+	install := fmt.Sprintf("%s how dependencies are installed, then install them and fix any setup problems.", detected)
+	if stack.InstallCommand != "" {
+		install = fmt.Sprintf("%s. Install dependencies with `%s` and fix any setup problems.", detected, stack.InstallCommand)
+	}
+	tests := fmt.Sprintf("%s how the test suite is run, then run it and record the baseline.", detected)
+	if stack.TestCommand != "" {
+		tests = fmt.Sprintf("%s. Run the test suite with `%s` and record which tests pass or fail as the baseline.", detected, stack.TestCommand)
+	}
+
+	var ids []string
+	for _, setup := range []struct{ title, description string }{
+		{"Install project dependencies", install},
+		{"Run the test suite and record the baseline", tests},
+	} {
+		bead, err := a.CreateBead(setup.title, setup.description, models.BeadPriorityP1, "task", projectID)
+		if err != nil {
+			loomLog.Warn("Failed to create setup bead", "project_id", projectID, "error", err)
+			continue
+		}
+		ids = append(ids, bead.ID)
+	}
+	return ids
+}
+
+func validateGitURL(gitURL string) error {
+	if gitURL == "" {
+		return fmt.Errorf("git_url is required")
+	}
+	if strings.HasPrefix(gitURL, "-") || strings.ContainsAny(gitURL, " \t\n") {
+		return fmt.Errorf("invalid git_url")
+	}
+	if scpLikeGitURL.MatchString(gitURL) {
+		return nil
+	}
+	u, err := url.Parse(gitURL)
+	if err != nil {
+		return fmt.Errorf("invalid git_url: %w", err)
+	}
+	// Local paths and unauthenticated transports would let callers clone
+	// anything on the server's disk or network
+	if (u.Scheme != "https" && u.Scheme != "ssh") || u.Host == "" {
+		return fmt.Errorf("unsupported git_url %q (use https://, ssh:// or user@host:path)", gitURL)
+	}
+	return nil
+}
+
+func inferGitAuthMethod(gitURL, credentialID string) models.GitAuthMethod {
+	if strings.HasPrefix(gitURL, "ssh://") || scpLikeGitURL.MatchString(gitURL) {
+		return models.GitAuthSSH
+	}
+	if credentialID != "" && strings.HasPrefix(gitURL, "https://") {
+		return models.GitAuthToken
+	}
+	return models.GitAuthNone
+}
+
+func repoNameFromURL(gitURL string) string {
+	if scpLikeGitURL.MatchString(gitURL) {
+		gitURL = gitURL[strings.Index(gitURL, ":")+1:]
+	} else if u, err := url.Parse(gitURL); err == nil {
+		gitURL = u.Path
+	}
+	name := strings.TrimSuffix(path.Base(strings.TrimRight(gitURL, "/")), ".git")
+	if name == "" || name == "." || name == "/" {
+		return "project"
+	}
+	return name
+}
+
+func copyContext(ctx map[string]string) map[string]string {
+	out := make(map[string]string, len(ctx)+8)
+	for k, v := range ctx {
+		out[k] = v
+	}
+	return out
+}
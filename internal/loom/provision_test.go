@@ -0,0 +1,145 @@
+package loom
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func makeSourceRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/demo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "add", "-A"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return dir
+}
+
+// localGitURL returns an https URL that git fetches from dir, since
+// provisioning only accepts remote repositories
+func localGitURL(t *testing.T, dir string) string {
+	t.Helper()
+	gitURL := "https://git.example.test/" + filepath.Base(dir)
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "url.file://"+dir+".insteadOf")
+	t.Setenv("GIT_CONFIG_VALUE_0", gitURL)
+	return gitURL
+}
+
+func TestProvisionProjectFromGitURL(t *testing.T) {
+	a, tmp := newTestLoom(t)
+	defer os.RemoveAll(tmp)
+	src := makeSourceRepo(t)
+
+	res, err := a.ProvisionProject(context.Background(), ProvisionRequest{GitURL: localGitURL(t, src)})
+	if err != nil {
+		t.Fatalf("ProvisionProject: %v", err)
+	}
+	if res.Status != ProvisionReady || res.Error != "" {
+		t.Fatalf("result = %+v", res)
+	}
+	if res.Branch != "main" || res.Stack == nil || res.Stack.Language != "go" || len(res.SetupBeads) != 2 {
+		t.Errorf("result = %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join(res.WorkDir, "go.mod")); err != nil {
+		t.Errorf("repository not cloned into %s: %v", res.WorkDir, err)
+	}
+
+	p, err := a.GetProjectManager().GetProject(res.ProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != filepath.Base(src) || p.GitAuthMethod != models.GitAuthNone {
+		t.Errorf("project = %+v", p)
+	}
+	if p.Context["test_command"] != "go test ./..." || p.Context["provision_status"] != ProvisionReady {
+		t.Errorf("context = %v", p.Context)
+	}
+	if _, err := a.RetryProvisioning(context.Background(), p.ID); err == nil {
+		t.Error("expected retry of a ready project to fail")
+	}
+}
+
+func TestProvisionProjectCloneFailure(t *testing.T) {
+	a, tmp := newTestLoom(t)
+	defer os.RemoveAll(tmp)
+
+	res, err := a.ProvisionProject(context.Background(), ProvisionRequest{
+		GitURL:    localGitURL(t, filepath.Join(tmp, "missing")),
+		Name:      "missing",
+		SkipSetup: true,
+	})
+	if err != nil {
+		t.Fatalf("ProvisionProject: %v", err)
+	}
+	if res.Status != ProvisionCloneFailed || res.Error == "" {
+		t.Fatalf("result = %+v", res)
+	}
+	retry, err := a.RetryProvisioning(context.Background(), res.ProjectID)
+	if err != nil {
+		t.Fatalf("RetryProvisioning: %v", err)
+	}
+	if retry.Status != ProvisionCloneFailed {
+		t.Errorf("retry = %+v", retry)
+	}
+}
+
+func TestProvisionProjectValidation(t *testing.T) {
+	a, tmp := newTestLoom(t)
+	defer os.RemoveAll(tmp)
+
+	for _, req := range []ProvisionRequest{
+		{GitURL: ""},
+		{GitURL: "--upload-pack=evil"},
+		{GitURL: "ftp://example.com/repo.git"},
+		{GitURL: "file:///etc/loom"},
+		{GitURL: "git://example.com/repo.git"},
+		{GitURL: "http://example.com/repo.git"},
+		{GitURL: "/srv/repos/secret.git"},
+		{GitURL: "https://example.com/repo.git", AuthMethod: models.GitAuthToken},
+		{GitURL: "https://example.com/repo.git", AuthMethod: models.GitAuthBasic},
+	} {
+		if _, err := a.ProvisionProject(context.Background(), req); err == nil {
+			t.Errorf("ProvisionProject(%+v) should fail", req)
+		}
+	}
+}
+
+func TestGitURLHelpers(t *testing.T) {
+	tests := []struct {
+		url, credential string
+		name            string
+		auth            models.GitAuthMethod
+	}{
+		{"git@github.com:acme/widgets.git", "", "widgets", models.GitAuthSSH},
+		{"ssh://git@example.com/acme/tools", "", "tools", models.GitAuthSSH},
+		{"https://github.com/acme/site.git", "", "site", models.GitAuthNone},
+		{"https://github.com/acme/site.git/", "gh-token", "site", models.GitAuthToken},
+	}
+	for _, tt := range tests {
+		if got := repoNameFromURL(tt.url); got != tt.name {
+			t.Errorf("repoNameFromURL(%q) = %q, want %q", tt.url, got, tt.name)
+		}
+		if got := inferGitAuthMethod(tt.url, tt.credential); got != tt.auth {
+			t.Errorf("inferGitAuthMethod(%q) = %q, want %q", tt.url, got, tt.auth)
+		}
+	}
+}
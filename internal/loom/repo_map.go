@@ -0,0 +1,25 @@
+package loom
+
+import (
+	"context"
+
+	"github.com/jordanhubbard/loom/internal/repomap"
+)
+
+// RepoMapBlock satisfies dispatch.RepoMapper: the project's repository map
+// rendered for a task's context within the configured budget, or "" when
+// the project has no checkout to map
+func (a *Loom) RepoMapBlock(ctx context.Context, projectID string) string {
+	if a.repoMaps == nil {
+		return ""
+	}
+	m, err := a.repoMaps.Get(ctx, projectID, false)
+	if err != nil {
+		return ""
+	}
+	budget := a.config.RepoMap.PromptBudget
+	if budget <= 0 {
+		budget = repomap.DefaultBudget
+	}
+	return repomap.Markdown(m, budget)
+}
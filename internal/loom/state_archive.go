@@ -0,0 +1,340 @@
+package loom
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	internalmodels "github.com/jordanhubbard/loom/internal/models"
+	"github.com/jordanhubbard/loom/internal/project"
+	"github.com/jordanhubbard/loom/internal/workflow"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// StateArchiveVersion is the archive format written by ExportStateArchive.
+// Imports accept any version up to it.
+const StateArchiveVersion = 1
+
+// Conflict resolution for ImportStateArchive, applied when an archived
+// entity's ID already exists on this instance
+const (
+	ImportSkip      = "skip"      // keep the existing entity
+	ImportOverwrite = "overwrite" // replace it with the archived one
+	ImportFail      = "fail"      // abort the whole import before writing
+)
+
+// StateArchive is a portable dump of orchestrator state for moving work
+// between instances. Secrets and references to them are never included:
+// providers and projects must be re-keyed after import.
+type StateArchive struct {
+	Version    int                           `json:"version"`
+	ExportedAt time.Time                     `json:"exported_at"`
+	Providers  []*internalmodels.Provider    `json:"providers"`
+	Projects   []*models.Project             `json:"projects"`
+	Beads      []*models.Bead                `json:"beads"`
+	Workflows  []*workflow.Workflow          `json:"workflows"`
+	Roles      []*models.AgentRole           `json:"roles"`
+	Schedules  []*models.Schedule            `json:"schedules"`
+	Fields     []*models.BeadFieldDefinition `json:"fields,omitempty"`
+}
+
+// ImportOptions controls how an archive is applied
+type ImportOptions struct {
+	OnConflict string `json:"on_conflict"` // skip (default), overwrite or fail
+	DryRun     bool   `json:"dry_run"`     // report what would happen without writing
+}
+
+// ImportCounts tallies the outcome for one kind of entity
+type ImportCounts struct {
+	Created     int `json:"created"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+}
+
+// ImportReport describes the outcome of an import
+type ImportReport struct {
+	DryRun    bool                     `json:"dry_run"`
+	Counts    map[string]*ImportCounts `json:"counts"`
+	Conflicts []string                 `json:"conflicts,omitempty"` // "kind/id" of entities that already existed
+}
+
+// archiveItem is one archived entity planned for import
+type archiveItem struct {
+	kind   string
+	id     string
+	exists bool
+	apply  func() error
+}
+
+// ExportStateArchive dumps providers, projects, beads, workflows, roles,
+// schedules and custom field definitions into a versioned archive
+func (a *Loom) ExportStateArchive(ctx context.Context) (*StateArchive, error) {
+	archive := &StateArchive{
+		Version:    StateArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+	}
+
+	if a.database != nil {
+		providers, err := a.database.ListProviders()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list providers: %w", err)
+		}
+		for _, p := range providers {
+			if p != nil {
+				archive.Providers = append(archive.Providers, stripProviderSecrets(p))
+			}
+		}
+		workflows, err := a.database.ListWorkflows("", "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list workflows: %w", err)
+		}
+		archive.Workflows = workflows
+	}
+
+	for _, p := range a.projectManager.ListProjects() {
+		if p != nil {
+			archive.Projects = append(archive.Projects, stripProjectSecrets(p))
+		}
+	}
+
+	beadList, err := a.beadsManager.ListBeads(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list beads: %w", err)
+	}
+	sort.Slice(beadList, func(i, j int) bool { return beadList[i].ID < beadList[j].ID })
+	archive.Beads = beadList
+
+	if a.roleRegistry != nil {
+		archive.Roles = a.roleRegistry.List()
+	}
+	if a.scheduler != nil {
+		archive.Schedules = a.scheduler.List("", "")
+	}
+	if a.customFields != nil {
+		archive.Fields = a.customFields.List("")
+	}
+	return archive, nil
+}
+
+// ImportStateArchive applies an archive exported by ExportStateArchive. All
+// conflicts are found before anything is written, so ImportFail and DryRun
+// leave this instance untouched.
+func (a *Loom) ImportStateArchive(ctx context.Context, archive *StateArchive, opts ImportOptions) (*ImportReport, error) {
+	if archive == nil {
+		return nil, fmt.Errorf("archive cannot be nil")
+	}
+	if archive.Version < 1 || archive.Version > StateArchiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d (this instance reads up to %d)", archive.Version, StateArchiveVersion)
+	}
+	switch opts.OnConflict {
+	case "":
+		opts.OnConflict = ImportSkip
+	case ImportSkip, ImportOverwrite, ImportFail:
+	default:
+		return nil, fmt.Errorf("unknown conflict option %q (use %s, %s or %s)", opts.OnConflict, ImportSkip, ImportOverwrite, ImportFail)
+	}
+
+	items, err := a.planImport(ctx, archive)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, Counts: map[string]*ImportCounts{}}
+	for _, item := range items {
+		if report.Counts[item.kind] == nil {
+			report.Counts[item.kind] = &ImportCounts{}
+		}
+		if item.exists {
+			report.Conflicts = append(report.Conflicts, item.kind+"/"+item.id)
+		}
+	}
+	if opts.OnConflict == ImportFail && len(report.Conflicts) > 0 {
+		return report, fmt.Errorf("import aborted: %d entities already exist", len(report.Conflicts))
+	}
+
+	for _, item := range items {
+		counts := report.Counts[item.kind]
+		switch {
+		case item.exists && opts.OnConflict == ImportSkip:
+			counts.Skipped++
+			continue
+		case item.exists:
+			counts.Overwritten++
+		default:
+			counts.Created++
+		}
+		if opts.DryRun {
+			continue
+		}
+		if err := item.apply(); err != nil {
+			return report, fmt.Errorf("failed to import %s %s: %w", item.kind, item.id, err)
+		}
+	}
+	return report, nil
+}
+
+// planImport lists the archived entities in dependency order: providers,
+// projects and field definitions before the beads, workflows and schedules
+// that refer to them
+func (a *Loom) planImport(ctx context.Context, archive *StateArchive) ([]archiveItem, error) {
+	var items []archiveItem
+
+	if len(archive.Providers) > 0 && a.database == nil {
+		return nil, fmt.Errorf("database not configured: cannot import providers")
+	}
+	for _, p := range archive.Providers {
+		if p == nil || p.ID == "" {
+			continue
+		}
+		p := stripProviderSecrets(p)
+		_, err := a.database.GetProvider(p.ID)
+		items = append(items, archiveItem{kind: "provider", id: p.ID, exists: err == nil, apply: func() error {
+			// Imported providers need their keys re-entered before use
+			p.Status = "pending"
+			_, err := a.RegisterProvider(ctx, p)
+			return err
+		}})
+	}
+
+	for _, p := range archive.Projects {
+		if p == nil || p.ID == "" {
+			continue
+		}
+		p := stripProjectSecrets(p)
+		// Normalized and validated as on creation, before anything is written
+		p.BeadsPath = normalizeBeadsPath(p.BeadsPath)
+		p.GitAuthMethod = normalizeGitAuthMethod(p.GitRepo, p.GitAuthMethod)
+		subprojects, err := project.NormalizeSubprojects(p.Subprojects)
+		if err != nil {
+			return nil, fmt.Errorf("invalid project %s: %w", p.ID, err)
+		}
+		p.Subprojects = subprojects
+		_, err = a.projectManager.GetProject(p.ID)
+		items = append(items, archiveItem{kind: "project", id: p.ID, exists: err == nil, apply: func() error {
+			return a.importProject(p)
+		}})
+	}
+
+	if len(archive.Fields) > 0 && a.customFields == nil {
+		return nil, fmt.Errorf("custom fields not available: cannot import field definitions")
+	}
+	for _, def := range archive.Fields {
+		if def == nil || def.Name == "" {
+			continue
+		}
+		id := def.ProjectID + "/" + def.Name
+		items = append(items, archiveItem{kind: "field", id: id, exists: a.customFields.Get(def.ProjectID, def.Name) != nil, apply: func() error {
+			_, err := a.customFields.Define(def)
+			return err
+		}})
+	}
+
+	for _, b := range archive.Beads {
+		if b == nil || b.ID == "" {
+			continue
+		}
+		_, err := a.beadsManager.GetBead(b.ID)
+		items = append(items, archiveItem{kind: "bead", id: b.ID, exists: err == nil, apply: func() error {
+			return a.beadsManager.ImportBead(b)
+		}})
+	}
+
+	if len(archive.Workflows) > 0 && a.database == nil {
+		return nil, fmt.Errorf("database not configured: cannot import workflows")
+	}
+	for _, wf := range archive.Workflows {
+		if wf == nil || wf.ID == "" {
+			continue
+		}
+		existing, err := a.database.GetWorkflow(wf.ID)
+		items = append(items, archiveItem{kind: "workflow", id: wf.ID, exists: err == nil && existing != nil, apply: func() error {
+			return a.importWorkflow(wf)
+		}})
+	}
+
+	if len(archive.Roles) > 0 && a.roleRegistry == nil {
+		return nil, fmt.Errorf("role registry not available: cannot import roles")
+	}
+	for _, role := range archive.Roles {
+		if role == nil || role.Name == "" {
+			continue
+		}
+		_, err := a.roleRegistry.Get(role.Name)
+		exists := err == nil
+		items = append(items, archiveItem{kind: "role", id: role.Name, exists: exists, apply: func() error {
+			if exists {
+				return a.roleRegistry.Update(role)
+			}
+			return a.roleRegistry.Create(role)
+		}})
+	}
+
+	if len(archive.Schedules) > 0 && a.scheduler == nil {
+		return nil, fmt.Errorf("scheduler not available: cannot import schedules")
+	}
+	for _, sched := range archive.Schedules {
+		if sched == nil || sched.ID == "" {
+			continue
+		}
+		_, err := a.scheduler.Get(sched.ID)
+		items = append(items, archiveItem{kind: "schedule", id: sched.ID, exists: err == nil, apply: func() error {
+			_, err := a.scheduler.Upsert(sched)
+			return err
+		}})
+	}
+
+	return items, nil
+}
+
+func (a *Loom) importProject(p *models.Project) error {
+	if err := a.projectManager.LoadProjects([]models.Project{*p}); err != nil {
+		return err
+	}
+	if p.BeadPrefix != "" {
+		a.beadsManager.SetProjectPrefix(p.ID, p.BeadPrefix)
+	}
+	if a.database != nil {
+		return a.database.UpsertProject(p)
+	}
+	return nil
+}
+
+func (a *Loom) importWorkflow(wf *workflow.Workflow) error {
+	if err := a.database.UpsertWorkflow(wf); err != nil {
+		return err
+	}
+	for i := range wf.Nodes {
+		if err := a.database.UpsertWorkflowNode(&wf.Nodes[i]); err != nil {
+			return err
+		}
+	}
+	for i := range wf.Edges {
+		if err := a.database.UpsertWorkflowEdge(&wf.Edges[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stripProviderSecrets copies a provider without its key reference or any
+// credentials embedded in the endpoint URL
+func stripProviderSecrets(p *internalmodels.Provider) *internalmodels.Provider {
+	c := *p
+	c.KeyID = ""
+	if u, err := url.Parse(c.Endpoint); err == nil && u.User != nil {
+		u.User = nil
+		c.Endpoint = u.String()
+	}
+	return &c
+}
+
+// stripProjectSecrets copies a project without its git credential reference
+// or the agents assigned on the source instance
+func stripProjectSecrets(p *models.Project) *models.Project {
+	c := *p
+	c.GitCredentialID = ""
+	c.Agents = nil
+	return &c
+}
@@ -0,0 +1,96 @@
+package loom
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/attachments"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/internal/transcribe"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// VoiceBeadRequest files a bead from a voice memo
+type VoiceBeadRequest struct {
+	ProjectID string
+	Type      string
+	Priority  models.BeadPriority
+	Name      string // File name of the recording
+	Audio     []byte
+}
+
+// VoiceBead is a bead filed from a voice memo, with the stored recording
+type VoiceBead struct {
+	Bead  *models.Bead       `json:"bead"`
+	Audio *attachments.Audio `json:"audio"`
+}
+
+// VoiceIntakeEnabled reports whether a transcription backend is configured
+func (a *Loom) VoiceIntakeEnabled() bool {
+	return a.transcriber != nil
+}
+
+// CreateVoiceBead transcribes a voice memo and files a bead from it: the
+// first sentence is the title and the transcript the description. The
+// recording is kept on the bead with its transcript.
+func (a *Loom) CreateVoiceBead(ctx context.Context, req VoiceBeadRequest) (*VoiceBead, error) {
+	if a.transcriber == nil {
+		return nil, apperr.New(apperr.CodeUnavailable, "voice intake is not configured")
+	}
+	if req.ProjectID == "" {
+		return nil, apperr.Validation("project_id is required")
+	}
+	if _, err := a.projectManager.GetProject(req.ProjectID); err != nil {
+		return nil, apperr.NotFound("project %s not found", req.ProjectID)
+	}
+	// Check the recording before paying for its transcription
+	mediaType, err := attachments.AudioType(req.Audio)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript, err := a.transcriber.Transcribe(ctx, transcribe.Audio{Name: req.Name, MediaType: mediaType, Data: req.Audio})
+	if err != nil {
+		return nil, apperr.Provider(err, "transcription failed")
+	}
+	if transcript.Text == "" {
+		return nil, apperr.Validation("no speech was recognized in the recording")
+	}
+
+	if req.Type == "" {
+		req.Type = "task"
+	}
+	description := fmt.Sprintf("%s\n\n---\n*Transcribed from a voice memo by %s.*", transcript.Text, a.transcriber.Name())
+	bead, err := a.CreateBead(transcribe.Title(transcript.Text), description, req.Priority, req.Type, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	audio, err := a.attachments.AttachAudio(&attachments.Audio{
+		BeadID:        bead.ID,
+		Name:          req.Name,
+		Data:          req.Audio,
+		Transcript:    transcript.Text,
+		TranscribedBy: a.transcriber.Name(),
+		Language:      transcript.Language,
+		Duration:      transcript.Duration,
+		Source:        attachments.SourceAPI,
+	})
+	if err != nil {
+		// The bead stands on its transcript; only the recording is lost
+		logging.Module("voice").Warn("Failed to store voice memo", "bead_id", bead.ID, "error", err)
+		return &VoiceBead{Bead: bead}, nil
+	}
+
+	updates := map[string]interface{}{
+		"tags":    append(append([]string{}, bead.Tags...), "voice"),
+		"context": map[string]string{"source": "voice", "audio_id": audio.ID},
+	}
+	if updated, err := a.UpdateBead(bead.ID, updates); err != nil {
+		logging.Module("voice").Warn("Failed to tag voice bead", "bead_id", bead.ID, "error", err)
+	} else {
+		bead = updated
+	}
+	return &VoiceBead{Bead: bead, Audio: audio}, nil
+}
@@ -3,6 +3,7 @@ package lsp
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 )
 
 // Location represents a code location with file, line, and column
@@ -23,7 +24,7 @@ type Symbol struct {
 // LSPService provides code navigation capabilities using language servers
 type LSPService struct {
 	projectPath string
-	servers     map[string]*LanguageServer // language -> server
+	servers     map[string]*LanguageServer // language@root -> server
 }
 
 // LanguageServer represents a language server process
@@ -31,6 +32,7 @@ type LanguageServer struct {
 	Language string
 	Command  string
 	Args     []string
+	RootPath string // Workspace root the server was started in
 	// Process management fields would go here
 }
 
@@ -48,7 +50,7 @@ func (s *LSPService) FindReferences(ctx context.Context, req FindReferencesReque
 	language := detectLanguage(req.File)
 
 	// Ensure language server is running
-	if err := s.ensureServer(language); err != nil {
+	if err := s.ensureServer(language, req.Root); err != nil {
 		return nil, fmt.Errorf("failed to start language server: %w", err)
 	}
 
@@ -66,7 +68,7 @@ func (s *LSPService) FindReferences(ctx context.Context, req FindReferencesReque
 func (s *LSPService) GoToDefinition(ctx context.Context, req GoToDefinitionRequest) (*Location, error) {
 	language := detectLanguage(req.File)
 
-	if err := s.ensureServer(language); err != nil {
+	if err := s.ensureServer(language, req.Root); err != nil {
 		return nil, fmt.Errorf("failed to start language server: %w", err)
 	}
 
@@ -83,7 +85,7 @@ func (s *LSPService) GoToDefinition(ctx context.Context, req GoToDefinitionReque
 func (s *LSPService) FindImplementations(ctx context.Context, req FindImplementationsRequest) ([]Location, error) {
 	language := detectLanguage(req.File)
 
-	if err := s.ensureServer(language); err != nil {
+	if err := s.ensureServer(language, req.Root); err != nil {
 		return nil, fmt.Errorf("failed to start language server: %w", err)
 	}
 
@@ -96,19 +98,27 @@ func (s *LSPService) FindImplementations(ctx context.Context, req FindImplementa
 	return locations, nil
 }
 
-// ensureServer ensures a language server is running for the given language
-func (s *LSPService) ensureServer(language string) error {
-	if _, exists := s.servers[language]; exists {
+// ensureServer ensures a language server is running for the given language.
+// Monorepo subprojects get their own server rooted at the subproject so it
+// only indexes that part of the tree.
+func (s *LSPService) ensureServer(language, root string) error {
+	key := language + "@" + root
+	if _, exists := s.servers[key]; exists {
 		return nil // Already running
 	}
 
+	rootPath := s.projectPath
+	if root != "" {
+		rootPath = filepath.Join(s.projectPath, root)
+	}
+
 	// Start language server based on language
-	server, err := startLanguageServer(language, s.projectPath)
+	server, err := startLanguageServer(language, rootPath)
 	if err != nil {
 		return err
 	}
 
-	s.servers[language] = server
+	s.servers[key] = server
 	return nil
 }
 
@@ -161,6 +171,7 @@ func startLanguageServer(language, projectPath string) (*LanguageServer, error)
 		Language: language,
 		Command:  command,
 		Args:     args,
+		RootPath: projectPath,
 	}
 
 	// In a full implementation, start the process here
@@ -207,6 +218,7 @@ type FindReferencesRequest struct {
 	Line   int    // Line number (1-indexed)
 	Column int    // Column number (1-indexed)
 	Symbol string // Optional: symbol name if known
+	Root   string // Optional: workspace root relative to the project (monorepo subproject)
 }
 
 // GoToDefinitionRequest defines parameters for go-to-definition
@@ -215,6 +227,7 @@ type GoToDefinitionRequest struct {
 	Line   int    // Line number (1-indexed)
 	Column int    // Column number (1-indexed)
 	Symbol string // Optional: symbol name if known
+	Root   string // Optional: workspace root relative to the project (monorepo subproject)
 }
 
 // FindImplementationsRequest defines parameters for finding implementations
@@ -223,6 +236,7 @@ type FindImplementationsRequest struct {
 	Line   int    // Line number (1-indexed)
 	Column int    // Column number (1-indexed)
 	Symbol string // Optional: symbol name if known
+	Root   string // Optional: workspace root relative to the project (monorepo subproject)
 }
 
 // Close closes all language servers
@@ -0,0 +1,237 @@
+// Package maintenance files recurring maintenance beads, such as a weekly
+// dependency audit or a quarterly key rotation, from templates. Each
+// project schedule creates one bead per occurrence, skips the occurrence
+// while the previous bead is still open, and links every bead to the one
+// before it so the history of a chore can be followed.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/scheduler"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// ScheduleKind is the scheduler kind for recurring maintenance beads
+const ScheduleKind = "maintenance_bead"
+
+// Bead context keys linking instances of a schedule
+const (
+	ContextSchedule = "maintenance_schedule_id"
+	ContextTemplate = "maintenance_template"
+	ContextInstance = "maintenance_instance" // 1 for the first bead, then counting up
+	ContextPrevious = "maintenance_previous"
+)
+
+// Template describes the bead filed for each occurrence of a chore.
+// "{date}" in the title or description is replaced with the occurrence's
+// date.
+type Template struct {
+	Name        string              `json:"name"`
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Type        string              `json:"type"`
+	Priority    models.BeadPriority `json:"priority"`
+	Tags        []string            `json:"tags,omitempty"`
+	Interval    time.Duration       `json:"interval"` // Default time between beads
+}
+
+// Builtin are the templates available without configuration
+var Builtin = []Template{
+	{
+		Name:        "dependency_audit",
+		Title:       "Weekly dependency audit ({date})",
+		Description: "Review outdated and vulnerable dependencies, update what is safe to update and file beads for the rest.",
+		Type:        "task",
+		Priority:    models.BeadPriorityP2,
+		Tags:        []string{"maintenance", "dependencies"},
+		Interval:    7 * 24 * time.Hour,
+	},
+	{
+		Name:        "security_scan",
+		Title:       "Monthly security scan ({date})",
+		Description: "Run the security scanners over the project, triage the findings and file beads for real issues.",
+		Type:        "task",
+		Priority:    models.BeadPriorityP1,
+		Tags:        []string{"maintenance", "security"},
+		Interval:    30 * 24 * time.Hour,
+	},
+	{
+		Name:        "key_rotation",
+		Title:       "Quarterly key rotation ({date})",
+		Description: "Rotate deploy keys, API tokens and other credentials the project uses, and revoke the old ones.",
+		Type:        "task",
+		Priority:    models.BeadPriorityP1,
+		Tags:        []string{"maintenance", "security"},
+		Interval:    91 * 24 * time.Hour,
+	},
+}
+
+// BeadCreator files beads
+type BeadCreator interface {
+	CreateBead(title, description string, priority models.BeadPriority, beadType, projectID string) (*models.Bead, error)
+}
+
+// BeadStore lists and updates beads
+type BeadStore interface {
+	ListBeads(filters map[string]interface{}) ([]*models.Bead, error)
+	UpdateBead(id string, updates map[string]interface{}) error
+}
+
+// Generator files maintenance beads for schedules
+type Generator struct {
+	creator   BeadCreator
+	beads     BeadStore
+	templates map[string]Template
+	now       func() time.Time
+}
+
+// NewGenerator creates a generator with the built-in templates and those
+// in cfg, which replace built-ins of the same name
+func NewGenerator(creator BeadCreator, beads BeadStore, cfg config.MaintenanceConfig) *Generator {
+	g := &Generator{
+		creator:   creator,
+		beads:     beads,
+		templates: make(map[string]Template),
+		now:       time.Now,
+	}
+	for _, t := range Builtin {
+		g.templates[t.Name] = t
+	}
+	for name, t := range cfg.Templates {
+		tmpl := Template{
+			Name:        name,
+			Title:       t.Title,
+			Description: t.Description,
+			Type:        t.Type,
+			Priority:    models.BeadPriorityP2,
+			Tags:        t.Tags,
+			Interval:    t.Interval,
+		}
+		if tmpl.Title == "" {
+			tmpl.Title = name + " ({date})"
+		}
+		if tmpl.Type == "" {
+			tmpl.Type = "task"
+		}
+		if t.Priority != nil && *t.Priority >= int(models.BeadPriorityP0) && *t.Priority <= int(models.BeadPriorityP3) {
+			tmpl.Priority = models.BeadPriority(*t.Priority)
+		}
+		if tmpl.Interval < scheduler.MinInterval {
+			tmpl.Interval = 7 * 24 * time.Hour
+		}
+		g.templates[name] = tmpl
+	}
+	return g
+}
+
+// Templates returns the available templates by name
+func (g *Generator) Templates() []Template {
+	list := make([]Template, 0, len(g.templates))
+	for _, t := range g.templates {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Template returns a template by name
+func (g *Generator) Template(name string) (Template, bool) {
+	t, ok := g.templates[name]
+	return t, ok
+}
+
+// ScheduleID is the ID of a project's schedule for a template
+func ScheduleID(projectID, template string) string {
+	return "maintenance-" + template + "-" + projectID
+}
+
+// Instances returns the beads filed for a schedule, newest first
+func (g *Generator) Instances(projectID, scheduleID string) ([]*models.Bead, error) {
+	list, err := g.beads.ListBeads(map[string]interface{}{"project_id": projectID})
+	if err != nil {
+		return nil, err
+	}
+	instances := []*models.Bead{}
+	for _, b := range list {
+		if b != nil && b.Context[ContextSchedule] == scheduleID {
+			instances = append(instances, b)
+		}
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].CreatedAt.After(instances[j].CreatedAt) })
+	return instances, nil
+}
+
+// Generate files the next bead for a schedule and returns it with the
+// instance before it. No bead is filed while the previous one is open.
+func (g *Generator) Generate(s *models.Schedule) (*models.Bead, *models.Bead, error) {
+	if s.ProjectID == "" {
+		return nil, nil, fmt.Errorf("maintenance schedule has no project")
+	}
+	tmpl, ok := g.templates[s.Params["template"]]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown maintenance template %q", s.Params["template"])
+	}
+	instances, err := g.Instances(s.ProjectID, s.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var previous *models.Bead
+	if len(instances) > 0 {
+		previous = instances[0]
+		if previous.Status != models.BeadStatusClosed {
+			return nil, previous, nil
+		}
+	}
+
+	date := g.now().UTC().Format("2006-01-02")
+	title := strings.ReplaceAll(tmpl.Title, "{date}", date)
+	description := strings.ReplaceAll(tmpl.Description, "{date}", date)
+	bead, err := g.creator.CreateBead(title, description, tmpl.Priority, tmpl.Type, s.ProjectID)
+	if err != nil {
+		return nil, previous, err
+	}
+	ctxUpdates := map[string]string{
+		ContextSchedule: s.ID,
+		ContextTemplate: tmpl.Name,
+		ContextInstance: fmt.Sprintf("%d", len(instances)+1),
+	}
+	updates := map[string]interface{}{"context": ctxUpdates}
+	if len(tmpl.Tags) > 0 {
+		updates["tags"] = append([]string(nil), tmpl.Tags...)
+	}
+	if previous != nil {
+		ctxUpdates[ContextPrevious] = previous.ID
+		updates["related_to"] = []string{previous.ID}
+	}
+	if err := g.beads.UpdateBead(bead.ID, updates); err != nil {
+		return bead, previous, fmt.Errorf("filed %s but failed to link it: %w", bead.ID, err)
+	}
+	if previous != nil {
+		related := append(append([]string(nil), previous.RelatedTo...), bead.ID)
+		if err := g.beads.UpdateBead(previous.ID, map[string]interface{}{"related_to": related}); err != nil {
+			return bead, previous, fmt.Errorf("filed %s but failed to link %s to it: %w", bead.ID, previous.ID, err)
+		}
+	}
+	return bead, previous, nil
+}
+
+// ScheduleHandler files a maintenance bead for a schedule. Params:
+// template, the name of the template to file from.
+func (g *Generator) ScheduleHandler() scheduler.Handler {
+	return func(ctx context.Context, s *models.Schedule) (string, error) {
+		bead, previous, err := g.Generate(s)
+		if err != nil {
+			return "", err
+		}
+		if bead == nil {
+			return fmt.Sprintf("skipped: %s is still open", previous.ID), nil
+		}
+		return "filed " + bead.ID, nil
+	}
+}
@@ -0,0 +1,119 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type memBeads struct {
+	beads map[string]*models.Bead
+	next  int
+	clock time.Time
+}
+
+func (m *memBeads) CreateBead(title, description string, priority models.BeadPriority, beadType, projectID string) (*models.Bead, error) {
+	m.next++
+	m.clock = m.clock.Add(time.Minute)
+	b := &models.Bead{ID: fmt.Sprintf("bd-%d", m.next), Title: title, Description: description,
+		Priority: priority, Type: beadType, ProjectID: projectID, Status: models.BeadStatusOpen, CreatedAt: m.clock}
+	m.beads[b.ID] = b
+	return b, nil
+}
+
+func (m *memBeads) ListBeads(filters map[string]interface{}) ([]*models.Bead, error) {
+	var list []*models.Bead
+	for _, b := range m.beads {
+		if p, ok := filters["project_id"].(string); ok && b.ProjectID != p {
+			continue
+		}
+		list = append(list, b)
+	}
+	return list, nil
+}
+
+func (m *memBeads) UpdateBead(id string, updates map[string]interface{}) error {
+	b := m.beads[id]
+	if ctx, ok := updates["context"].(map[string]string); ok {
+		if b.Context == nil {
+			b.Context = map[string]string{}
+		}
+		for k, v := range ctx {
+			b.Context[k] = v
+		}
+	}
+	if tags, ok := updates["tags"].([]string); ok {
+		b.Tags = tags
+	}
+	if related, ok := updates["related_to"].([]string); ok {
+		b.RelatedTo = related
+	}
+	return nil
+}
+
+func TestGenerateSkipsOpenAndLinksInstances(t *testing.T) {
+	store := &memBeads{beads: map[string]*models.Bead{}, clock: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)}
+	g := NewGenerator(store, store, config.MaintenanceConfig{})
+	g.now = func() time.Time { return store.clock }
+	sched := &models.Schedule{ID: ScheduleID("proj", "dependency_audit"), ProjectID: "proj",
+		Params: map[string]string{"template": "dependency_audit"}}
+	handler := g.ScheduleHandler()
+
+	result, err := handler(context.Background(), sched)
+	if err != nil || result != "filed bd-1" {
+		t.Fatalf("first run = %q, %v", result, err)
+	}
+	first := store.beads["bd-1"]
+	if first.Title != "Weekly dependency audit (2026-01-05)" || first.Context[ContextInstance] != "1" || len(first.Tags) == 0 {
+		t.Errorf("first bead = %+v", first)
+	}
+
+	result, err = handler(context.Background(), sched)
+	if err != nil || !strings.HasPrefix(result, "skipped") || len(store.beads) != 1 {
+		t.Fatalf("run with open instance = %q, %v (%d beads)", result, err, len(store.beads))
+	}
+
+	first.Status = models.BeadStatusClosed
+	if _, err := handler(context.Background(), sched); err != nil {
+		t.Fatal(err)
+	}
+	second := store.beads["bd-2"]
+	if second == nil || second.Context[ContextPrevious] != "bd-1" || second.Context[ContextInstance] != "2" {
+		t.Fatalf("second bead = %+v", second)
+	}
+	if len(second.RelatedTo) != 1 || second.RelatedTo[0] != "bd-1" || len(first.RelatedTo) != 1 || first.RelatedTo[0] != "bd-2" {
+		t.Errorf("instances not linked: %v / %v", first.RelatedTo, second.RelatedTo)
+	}
+
+	instances, err := g.Instances("proj", sched.ID)
+	if err != nil || len(instances) != 2 || instances[0].ID != "bd-2" {
+		t.Errorf("Instances = %v, %v", instances, err)
+	}
+}
+
+func TestNewGeneratorConfigTemplates(t *testing.T) {
+	p0, bad := 0, 7
+	g := NewGenerator(nil, nil, config.MaintenanceConfig{Templates: map[string]config.MaintenanceTemplate{
+		"key_rotation":  {Title: "Rotate keys", Priority: &p0, Interval: 30 * 24 * time.Hour},
+		"license_check": {Priority: &bad},
+	}})
+	if tmpl, _ := g.Template("key_rotation"); tmpl.Title != "Rotate keys" || tmpl.Priority != models.BeadPriorityP0 || tmpl.Interval != 30*24*time.Hour {
+		t.Errorf("key_rotation not replaced: %+v", tmpl)
+	}
+	tmpl, ok := g.Template("license_check")
+	if !ok || tmpl.Type != "task" || tmpl.Priority != models.BeadPriorityP2 || tmpl.Interval != 7*24*time.Hour {
+		t.Errorf("license_check defaults = %+v", tmpl)
+	}
+	if len(g.Templates()) != len(Builtin)+1 {
+		t.Errorf("Templates() = %d", len(g.Templates()))
+	}
+
+	if _, _, err := g.Generate(&models.Schedule{ProjectID: "p", Params: map[string]string{"template": "nope"}}); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
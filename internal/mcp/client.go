@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ServerConfig describes how to reach a server: a command to run (stdio)
+// or a URL (streamable HTTP)
+type ServerConfig struct {
+	Name    string
+	Command string
+	Args    []string
+	Env     map[string]string
+	Dir     string // Working directory of the command
+	URL     string
+	Headers map[string]string
+}
+
+// Client is a connection to one server
+type Client struct {
+	name   string
+	t      transport
+	nextID atomic.Int64
+
+	// ServerName and ServerVersion are what the server reported
+	ServerName    string
+	ServerVersion string
+}
+
+// Connect starts or dials the server and performs the initialize handshake
+func Connect(ctx context.Context, cfg ServerConfig) (*Client, error) {
+	var t transport
+	switch {
+	case cfg.URL != "":
+		t = newHTTPTransport(cfg.URL, cfg.Headers)
+	case cfg.Command != "":
+		st, err := newStdioTransport(cfg.Command, cfg.Args, cfg.Env, cfg.Dir)
+		if err != nil {
+			return nil, err
+		}
+		t = st
+	default:
+		return nil, fmt.Errorf("mcp server %s needs a command or a url", cfg.Name)
+	}
+
+	c := &Client{name: cfg.Name, t: t}
+	var init initializeResult
+	err := c.call(ctx, "initialize", initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo:      implementation{Name: "loom", Version: "1.0"},
+	}, &init)
+	if err == nil {
+		err = t.notify(ctx, &rpcRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+	}
+	if err != nil {
+		_ = t.close()
+		return nil, fmt.Errorf("initialize mcp server %s: %w", cfg.Name, err)
+	}
+	c.ServerName = init.ServerInfo.Name
+	c.ServerVersion = init.ServerInfo.Version
+	return c, nil
+}
+
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	id := c.nextID.Add(1)
+	resp, err := c.t.call(ctx, &rpcRequest{JSONRPC: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// ListTools returns every tool the server offers
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page listToolsResult
+		if err := c.call(ctx, "tools/list", params, &page); err != nil {
+			return nil, err
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool calls a tool. A failure the tool reports comes back as a result
+// with IsError set, not as an error.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*CallResult, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	var result CallResult
+	if err := c.call(ctx, "tools/call", map[string]interface{}{"name": name, "arguments": args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close ends the session and stops a stdio server
+func (c *Client) Close() error {
+	err := c.t.close()
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		// Killed on purpose
+		return nil
+	}
+	return err
+}
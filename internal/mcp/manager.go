@@ -0,0 +1,263 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// DefaultTimeout bounds a connection or call when the server config
+// doesn't say
+const DefaultTimeout = 60 * time.Second
+
+// ServerTools is the tool list of one server, or why it couldn't be read
+type ServerTools struct {
+	Server string `json:"server"`
+	Tools  []Tool `json:"tools"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Manager connects to the MCP servers configured for each project, on
+// first use, and keeps their tool lists. A connection that fails mid-call
+// is dropped and re-established on the next use. Command servers run in
+// the project's workdir.
+type Manager struct {
+	WorkDirs files.WorkDirResolver
+
+	mu      sync.Mutex
+	servers map[string]map[string]config.MCPServerConfig // project -> name -> config
+	clients map[string]*Client                           // project|name
+	tools   map[string][]Tool                            // project|name
+}
+
+// NewManager creates a manager for the servers of the configured projects
+func NewManager(resolver files.WorkDirResolver, projects []config.ProjectConfig) *Manager {
+	m := &Manager{
+		WorkDirs: resolver,
+		servers:  make(map[string]map[string]config.MCPServerConfig),
+		clients:  make(map[string]*Client),
+		tools:    make(map[string][]Tool),
+	}
+	for _, p := range projects {
+		m.SetServers(p.ID, p.MCPServers)
+	}
+	return m
+}
+
+// SetServers replaces a project's servers, closing connections to the old
+// ones
+func (m *Manager) SetServers(projectID string, servers []config.MCPServerConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name := range m.servers[projectID] {
+		m.dropLocked(projectID + "|" + name)
+	}
+	if len(servers) == 0 {
+		delete(m.servers, projectID)
+		return
+	}
+	byName := make(map[string]config.MCPServerConfig, len(servers))
+	for _, s := range servers {
+		byName[s.Name] = s
+	}
+	m.servers[projectID] = byName
+}
+
+// Servers returns the names of a project's servers
+func (m *Manager) Servers(projectID string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.servers[projectID]))
+	for name := range m.servers[projectID] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *Manager) server(projectID, name string) (config.MCPServerConfig, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cfg, ok := m.servers[projectID][name]
+	return cfg, ok
+}
+
+func timeout(cfg config.MCPServerConfig) time.Duration {
+	if cfg.Timeout > 0 {
+		return cfg.Timeout
+	}
+	return DefaultTimeout
+}
+
+// client returns the connection to a server, connecting if needed
+func (m *Manager) client(ctx context.Context, projectID string, cfg config.MCPServerConfig) (*Client, error) {
+	key := projectID + "|" + cfg.Name
+	m.mu.Lock()
+	c := m.clients[key]
+	m.mu.Unlock()
+	if c != nil {
+		return c, nil
+	}
+
+	var dir string
+	if cfg.Command != "" {
+		var err error
+		if dir, err = m.workDir(projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout(cfg))
+	defer cancel()
+	c, err := Connect(ctx, ServerConfig{
+		Name:    cfg.Name,
+		Command: cfg.Command,
+		Args:    cfg.Args,
+		Env:     cfg.Env,
+		Dir:     dir,
+		URL:     cfg.URL,
+		Headers: cfg.Headers,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing := m.clients[key]; existing != nil {
+		// Another caller connected first
+		_ = c.Close()
+		return existing, nil
+	}
+	m.clients[key] = c
+	return c, nil
+}
+
+func (m *Manager) workDir(projectID string) (string, error) {
+	if m.WorkDirs == nil {
+		return "", fmt.Errorf("workdir resolver not configured")
+	}
+	workDir := m.WorkDirs.GetProjectWorkDir(projectID)
+	if workDir == "" {
+		return "", fmt.Errorf("project workdir not found")
+	}
+	return filepath.Clean(workDir), nil
+}
+
+func (m *Manager) drop(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dropLocked(key)
+}
+
+func (m *Manager) dropLocked(key string) {
+	if c := m.clients[key]; c != nil {
+		if err := c.Close(); err != nil {
+			logging.Module("mcp").Warn("Failed to close client", "client", key, "error", err)
+		}
+	}
+	delete(m.clients, key)
+	delete(m.tools, key)
+}
+
+// Tools returns one server's tools, listing them on first use
+func (m *Manager) Tools(ctx context.Context, projectID, server string) ([]Tool, error) {
+	cfg, ok := m.server(projectID, server)
+	if !ok {
+		return nil, fmt.Errorf("no mcp server %q configured for project %s", server, projectID)
+	}
+	key := projectID + "|" + server
+	m.mu.Lock()
+	tools, cached := m.tools[key]
+	m.mu.Unlock()
+	if cached {
+		return tools, nil
+	}
+
+	c, err := m.client(ctx, projectID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout(cfg))
+	defer cancel()
+	tools, err = c.ListTools(ctx)
+	if err != nil {
+		m.drop(key)
+		return nil, err
+	}
+	m.mu.Lock()
+	m.tools[key] = tools
+	m.mu.Unlock()
+	return tools, nil
+}
+
+// ListTools returns the tools of every server of a project; a server that
+// can't be reached is reported with its error
+func (m *Manager) ListTools(ctx context.Context, projectID string) []ServerTools {
+	var out []ServerTools
+	for _, name := range m.Servers(projectID) {
+		st := ServerTools{Server: name}
+		tools, err := m.Tools(ctx, projectID, name)
+		if err != nil {
+			st.Error = err.Error()
+		}
+		st.Tools = tools
+		out = append(out, st)
+	}
+	return out
+}
+
+// CallTool validates the arguments against the tool's input schema and
+// calls it
+func (m *Manager) CallTool(ctx context.Context, projectID, server, tool string, args map[string]interface{}) (*CallResult, error) {
+	tools, err := m.Tools(ctx, projectID, server)
+	if err != nil {
+		return nil, err
+	}
+	var found *Tool
+	for i := range tools {
+		if tools[i].Name == tool {
+			found = &tools[i]
+			break
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("mcp server %s has no tool %q", server, tool)
+	}
+	if err := ValidateArguments(found.InputSchema, args); err != nil {
+		return nil, err
+	}
+
+	cfg, _ := m.server(projectID, server)
+	c, err := m.client(ctx, projectID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout(cfg))
+	defer cancel()
+	result, err := c.CallTool(ctx, tool, args)
+	if err != nil {
+		if _, isRPC := err.(*RPCError); !isRPC {
+			// The connection may be broken; start over next time
+			m.drop(projectID + "|" + server)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// Close closes every connection
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.clients {
+		m.dropLocked(key)
+	}
+}
@@ -0,0 +1,255 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+var echoSchema = json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"},"times":{"type":"integer"}},"required":["text"]}`)
+
+// handle answers one request like a small server with an echo tool
+func handle(req rpcRequest) *rpcResponse {
+	if req.ID == nil {
+		return nil
+	}
+	resp := &rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	var result interface{}
+	switch req.Method {
+	case "initialize":
+		result = initializeResult{ProtocolVersion: ProtocolVersion, ServerInfo: implementation{Name: "echo", Version: "0.1"}}
+	case "tools/list":
+		params, _ := req.Params.(map[string]interface{})
+		if params["cursor"] == nil {
+			result = listToolsResult{Tools: []Tool{{Name: "echo", Description: "Echo text", InputSchema: echoSchema}}, NextCursor: "2"}
+		} else {
+			result = listToolsResult{Tools: []Tool{{Name: "fail"}}}
+		}
+	case "tools/call":
+		params := req.Params.(map[string]interface{})
+		args, _ := params["arguments"].(map[string]interface{})
+		if params["name"] == "fail" {
+			result = CallResult{Content: []Content{{Type: "text", Text: "tool failed"}}, IsError: true}
+		} else {
+			text := fmt.Sprint(args["text"])
+			if text == "$PWD" {
+				text, _ = os.Getwd()
+			}
+			result = CallResult{Content: []Content{{Type: "text", Text: text}}}
+		}
+	default:
+		resp.Error = &RPCError{Code: -32601, Message: "method not found"}
+		return resp
+	}
+	resp.Result, _ = json.Marshal(result)
+	return resp
+}
+
+func newHTTPServer(t *testing.T, sse bool) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			return
+		}
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Method != "initialize" && r.Header.Get("Mcp-Session-Id") != "s1" {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Mcp-Session-Id", "s1")
+		resp := handle(req)
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		data, _ := json.Marshal(resp)
+		if sse {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("MCP_HELPER_PROCESS") != "1" {
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req rpcRequest
+		if json.Unmarshal(scanner.Bytes(), &req) != nil {
+			continue
+		}
+		if resp := handle(req); resp != nil {
+			data, _ := json.Marshal(resp)
+			fmt.Println("server log line")
+			fmt.Println(string(data))
+		}
+	}
+	os.Exit(0)
+}
+
+func exerciseClient(t *testing.T, cfg ServerConfig) {
+	t.Helper()
+	ctx := context.Background()
+	c, err := Connect(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.ServerName != "echo" {
+		t.Errorf("expected server name echo, got %q", c.ServerName)
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil || len(tools) != 2 || tools[0].Name != "echo" || tools[1].Name != "fail" {
+		t.Fatalf("expected both pages of tools, got %+v, %v", tools, err)
+	}
+	result, err := c.CallTool(ctx, "echo", map[string]interface{}{"text": "hi"})
+	if err != nil || result.IsError || result.Text() != "hi" {
+		t.Fatalf("unexpected call result %+v, %v", result, err)
+	}
+	if err := c.call(ctx, "resources/list", nil, nil); err == nil || !strings.Contains(err.Error(), "method not found") {
+		t.Errorf("expected the server's error, got %v", err)
+	}
+}
+
+func TestClientHTTP(t *testing.T) {
+	exerciseClient(t, ServerConfig{Name: "json", URL: newHTTPServer(t, false).URL})
+}
+
+func TestClientHTTPEventStream(t *testing.T) {
+	exerciseClient(t, ServerConfig{Name: "sse", URL: newHTTPServer(t, true).URL})
+}
+
+func TestClientStdio(t *testing.T) {
+	exerciseClient(t, ServerConfig{
+		Name:    "stdio",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=TestHelperProcess"},
+		Env:     map[string]string{"MCP_HELPER_PROCESS": "1"},
+	})
+}
+
+func TestManagerCallTool(t *testing.T) {
+	srv := newHTTPServer(t, false)
+	m := NewManager(nil, []config.ProjectConfig{{ID: "p1", MCPServers: []config.MCPServerConfig{{Name: "echo", URL: srv.URL}}}})
+	defer m.Close()
+	ctx := context.Background()
+
+	listed := m.ListTools(ctx, "p1")
+	if len(listed) != 1 || listed[0].Error != "" || len(listed[0].Tools) != 2 {
+		t.Fatalf("unexpected tool list %+v", listed)
+	}
+	if len(m.ListTools(ctx, "other")) != 0 {
+		t.Error("expected no servers for an unconfigured project")
+	}
+
+	result, err := m.CallTool(ctx, "p1", "echo", "echo", map[string]interface{}{"text": "hello"})
+	if err != nil || result.Text() != "hello" {
+		t.Fatalf("unexpected result %+v, %v", result, err)
+	}
+	if _, err := m.CallTool(ctx, "p1", "echo", "echo", map[string]interface{}{"times": 2}); err == nil || !strings.Contains(err.Error(), "arguments.text is required") {
+		t.Errorf("expected missing text to be rejected, got %v", err)
+	}
+	if _, err := m.CallTool(ctx, "p1", "echo", "missing", nil); err == nil {
+		t.Error("expected an unknown tool to be rejected")
+	}
+	if _, err := m.CallTool(ctx, "p1", "nope", "echo", nil); err == nil {
+		t.Error("expected an unknown server to be rejected")
+	}
+	if result, err := m.CallTool(ctx, "p1", "echo", "fail", nil); err != nil || !result.IsError {
+		t.Errorf("expected a tool error result, got %+v, %v", result, err)
+	}
+
+	// An unreachable server is reported, not fatal
+	m.SetServers("p2", []config.MCPServerConfig{{Name: "down", URL: "http://127.0.0.1:1"}})
+	if listed := m.ListTools(ctx, "p2"); len(listed) != 1 || listed[0].Error == "" {
+		t.Errorf("expected an error for the unreachable server, got %+v", listed)
+	}
+}
+
+func TestManagerStdioWorkDir(t *testing.T) {
+	dir := t.TempDir()
+	projects := []config.ProjectConfig{{ID: "p1", MCPServers: []config.MCPServerConfig{{
+		Name:    "stdio",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=TestHelperProcess"},
+		Env:     map[string]string{"MCP_HELPER_PROCESS": "1"},
+	}}}}
+	ctx := context.Background()
+
+	m := NewManager(toolrun.StaticWorkDir(dir), projects)
+	defer m.Close()
+	result, err := m.CallTool(ctx, "p1", "stdio", "echo", map[string]interface{}{"text": "$PWD"})
+	if err != nil || result.Text() != dir {
+		t.Fatalf("expected the server to run in %s, got %+v, %v", dir, result, err)
+	}
+
+	unresolved := NewManager(toolrun.StaticWorkDir(""), projects)
+	defer unresolved.Close()
+	if _, err := unresolved.Tools(ctx, "p1", "stdio"); err == nil || !strings.Contains(err.Error(), "workdir not found") {
+		t.Errorf("expected a command server without a workdir to be refused, got %v", err)
+	}
+}
+
+func TestValidateArguments(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"query": {"type": "string"},
+			"limit": {"type": "integer"},
+			"ratio": {"type": "number"},
+			"mode": {"enum": ["fast", "full"]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"filter": {"type": "object", "properties": {"active": {"type": "boolean"}}, "required": ["active"]}
+		},
+		"required": ["query"],
+		"additionalProperties": false
+	}`)
+
+	valid := map[string]interface{}{
+		"query": "x", "limit": float64(3), "ratio": float64(1), "mode": "fast",
+		"tags": []interface{}{"a"}, "filter": map[string]interface{}{"active": true},
+	}
+	if err := ValidateArguments(schema, valid); err != nil {
+		t.Errorf("expected valid arguments, got %v", err)
+	}
+
+	cases := map[string]map[string]interface{}{
+		"arguments.query is required":              {},
+		"arguments.limit must be integer":          {"query": "x", "limit": 1.5},
+		"arguments.mode must be one of":            {"query": "x", "mode": "slow"},
+		"arguments.tags[1] must be string":         {"query": "x", "tags": []interface{}{"a", float64(2)}},
+		"arguments.filter.active is required":      {"query": "x", "filter": map[string]interface{}{}},
+		"arguments.extra is not allowed":           {"query": "x", "extra": true},
+		"arguments.query must be string, got null": {"query": nil},
+	}
+	for want, args := range cases {
+		if err := ValidateArguments(schema, args); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q, got %v", want, err)
+		}
+	}
+
+	if err := ValidateArguments(nil, map[string]interface{}{"anything": 1}); err != nil {
+		t.Errorf("expected no schema to accept anything, got %v", err)
+	}
+}
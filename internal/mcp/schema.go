@@ -0,0 +1,147 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// schema is the subset of JSON Schema tool inputs are checked against
+type schema struct {
+	Type                 interface{}        `json:"type,omitempty"` // string or list of strings
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+}
+
+// ValidateArguments checks tool arguments against the tool's input schema:
+// required properties, property types (recursively through objects and
+// arrays), enums and, when the schema forbids them, unknown properties.
+// Schema features beyond these are not checked; the server has the final
+// word.
+func ValidateArguments(inputSchema json.RawMessage, args map[string]interface{}) error {
+	if len(inputSchema) == 0 {
+		return nil
+	}
+	var s schema
+	if err := json.Unmarshal(inputSchema, &s); err != nil {
+		return fmt.Errorf("invalid input schema: %w", err)
+	}
+	var value interface{} = args
+	if args == nil {
+		value = map[string]interface{}{}
+	}
+	var problems []string
+	s.check("arguments", value, &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid arguments: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s *schema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var out []string
+		for _, v := range t {
+			if str, ok := v.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func (s *schema) check(path string, value interface{}, problems *[]string) {
+	if types := s.types(); len(types) > 0 && !matchesAny(types, value) {
+		*problems = append(*problems, fmt.Sprintf("%s must be %s, got %s", path, strings.Join(types, " or "), jsonType(value)))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		*problems = append(*problems, fmt.Sprintf("%s must be one of %v", path, s.Enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s.%s is required", path, name))
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.check(path+"."+name, v[name], problems)
+			} else if allowed, ok := s.AdditionalProperties.(bool); ok && !allowed {
+				*problems = append(*problems, fmt.Sprintf("%s.%s is not allowed", path, name))
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.check(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+	}
+}
+
+func matchesAny(types []string, value interface{}) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON type of a decoded value; whole numbers are
+// "integer"
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case int, int64:
+		return "integer"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	encoded, _ := json.Marshal(value)
+	for _, e := range enum {
+		if candidate, _ := json.Marshal(e); string(candidate) == string(encoded) {
+			return true
+		}
+	}
+	return false
+}
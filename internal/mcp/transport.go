@@ -0,0 +1,265 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// transport carries JSON-RPC messages to a server
+type transport interface {
+	// call sends a request and waits for its response
+	call(ctx context.Context, req *rpcRequest) (*rpcResponse, error)
+	// notify sends a notification, which has no response
+	notify(ctx context.Context, req *rpcRequest) error
+	close() error
+}
+
+// stdioTransport talks to a server process over its stdin and stdout, one
+// JSON message per line
+type stdioTransport struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[int64]chan *rpcResponse
+	done    chan struct{}
+	err     error
+}
+
+func newStdioTransport(command string, args []string, env map[string]string, dir string) (*stdioTransport, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", command, err)
+	}
+	t := &stdioTransport{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan *rpcResponse),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop(stdout)
+	return t, nil
+}
+
+func (t *stdioTransport) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var resp rpcResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil || resp.ID == nil {
+			// Server logs, notifications and requests are ignored
+			continue
+		}
+		t.mu.Lock()
+		ch := t.pending[*resp.ID]
+		delete(t.pending, *resp.ID)
+		t.mu.Unlock()
+		if ch != nil {
+			ch <- &resp
+		}
+	}
+	t.mu.Lock()
+	t.err = errors.New("mcp server exited")
+	if err := scanner.Err(); err != nil {
+		t.err = fmt.Errorf("mcp server output: %w", err)
+	}
+	t.mu.Unlock()
+	close(t.done)
+}
+
+func (t *stdioTransport) write(req *rpcRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (t *stdioTransport) call(ctx context.Context, req *rpcRequest) (*rpcResponse, error) {
+	ch := make(chan *rpcResponse, 1)
+	t.mu.Lock()
+	if t.err != nil {
+		t.mu.Unlock()
+		return nil, t.err
+	}
+	t.pending[*req.ID] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, *req.ID)
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(req); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-t.done:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return nil, t.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) notify(_ context.Context, req *rpcRequest) error {
+	return t.write(req)
+}
+
+func (t *stdioTransport) close() error {
+	_ = t.stdin.Close()
+	if t.cmd.Process != nil {
+		_ = t.cmd.Process.Kill()
+	}
+	return t.cmd.Wait()
+}
+
+// httpTransport talks to a streamable HTTP server: each message is POSTed
+// and the response comes back as JSON or as an event stream
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+func newHTTPTransport(url string, headers map[string]string) *httpTransport {
+	return &httpTransport{url: url, headers: headers, client: &http.Client{}}
+}
+
+func (t *httpTransport) post(ctx context.Context, req *rpcRequest) (*http.Response, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	for k, v := range t.headers {
+		httpReq.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		httpReq.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	t.mu.Unlock()
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, fmt.Errorf("mcp server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) call(ctx context.Context, req *rpcRequest) (*rpcResponse, error) {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var out rpcResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, fmt.Errorf("decode mcp response: %w", err)
+		}
+		return &out, nil
+	}
+
+	// Read events until the response to this request arrives
+	reader := bufio.NewReader(resp.Body)
+	var data strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		if (line == "" || err != nil) && data.Len() > 0 {
+			var out rpcResponse
+			if json.Unmarshal([]byte(data.String()), &out) == nil && out.ID != nil && *out.ID == *req.ID {
+				return &out, nil
+			}
+			data.Reset()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("mcp event stream ended without a response: %w", err)
+		}
+	}
+}
+
+func (t *httpTransport) notify(ctx context.Context, req *rpcRequest) error {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+	// Tell the server the session is over; failures don't matter
+	req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	if resp, err := t.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+	return nil
+}
@@ -3,14 +3,16 @@ package memory
 import (
 	"context"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
+var extractorLog = logging.Module("extractor")
+
 // LessonStore is the subset of database.Database that the extractor needs.
 type LessonStore interface {
 	StoreLessonWithEmbedding(lesson *models.Lesson, embedding []float32) error
@@ -76,18 +78,18 @@ func (e *Extractor) ExtractFromLoop(projectID, beadID string, entries []ActionEn
 			embeddings, err := e.embedder.Embed(ctx, []string{text})
 			if err == nil && len(embeddings) > 0 && len(embeddings[0]) > 0 {
 				if err := e.store.StoreLessonWithEmbedding(lesson, embeddings[0]); err != nil {
-					log.Printf("[Extractor] Failed to store lesson with embedding: %v", err)
+					extractorLog.Error("Failed to store lesson with embedding", "error", err)
 				} else {
-					log.Printf("[Extractor] Extracted lesson: %s", l.title)
+					extractorLog.Info("Extracted lesson", "title", l.title)
 				}
 				continue
 			}
 		}
 
 		if err := e.store.CreateLesson(lesson); err != nil {
-			log.Printf("[Extractor] Failed to store lesson: %v", err)
+			extractorLog.Error("Failed to store lesson", "error", err)
 		} else {
-			log.Printf("[Extractor] Extracted lesson (no embedding): %s", l.title)
+			extractorLog.Info("Extracted lesson without embedding", "title", l.title)
 		}
 	}
 }
@@ -132,7 +134,7 @@ func extractTestPatterns(entries []ActionEntry) []extractedLesson {
 func extractEditPatterns(entries []ActionEntry) []extractedLesson {
 	pathFailures := make(map[string]int)
 	for _, e := range entries {
-		if (e.ActionType == "apply_patch" || e.ActionType == "edit_code") && e.Status == "error" {
+		if (e.ActionType == "apply_patch" || e.ActionType == "edit_code" || e.ActionType == "multi_edit") && e.Status == "error" {
 			if e.Path != "" {
 				pathFailures[e.Path]++
 			}
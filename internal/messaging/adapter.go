@@ -79,6 +79,9 @@ func (s *ActionMessageSender) SendMessage(ctx context.Context, fromAgentID, toAg
 		Context:          context,
 		Payload:          payload,
 	}
+	if replyTo, ok := payload["in_reply_to"].(string); ok {
+		msg.InReplyTo = replyTo
+	}
 
 	if err := s.bus.Send(ctx, msg); err != nil {
 		return "", fmt.Errorf("failed to send message: %w", err)
@@ -95,3 +98,32 @@ func (s *ActionMessageSender) FindAgentByRole(ctx context.Context, role string)
 
 	return s.agentRegistry.FindAgentByRole(ctx, role)
 }
+
+// ReadInbox returns an agent's unacknowledged messages, optionally limited to
+// one bead's thread, and acknowledges them
+func (s *ActionMessageSender) ReadInbox(ctx context.Context, agentID, beadID string, limit int) ([]map[string]interface{}, error) {
+	messages, err := s.bus.Inbox(agentID, beadID, true, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(messages))
+	for _, msg := range messages {
+		if _, err := s.bus.Acknowledge(msg.MessageID, agentID); err != nil {
+			return nil, fmt.Errorf("failed to acknowledge message %s: %w", msg.MessageID, err)
+		}
+		result = append(result, map[string]interface{}{
+			"message_id":        msg.MessageID,
+			"type":              string(msg.Type),
+			"from_agent_id":     msg.FromAgentID,
+			"subject":           msg.Subject,
+			"body":              msg.Body,
+			"payload":           msg.Payload,
+			"bead_id":           msg.BeadID,
+			"in_reply_to":       msg.InReplyTo,
+			"requires_response": msg.RequiresResponse,
+			"timestamp":         msg.Timestamp,
+		})
+	}
+	return result, nil
+}
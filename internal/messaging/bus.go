@@ -48,12 +48,23 @@ type AgentMessage struct {
 	RequiresResponse bool                   `json:"requires_response"`
 	InReplyTo        string                 `json:"in_reply_to,omitempty"`
 	Context          map[string]interface{} `json:"context,omitempty"`
+	BeadID           string                 `json:"bead_id,omitempty"` // Thread the message belongs to
 	Timestamp        time.Time              `json:"timestamp"`
-	Status           string                 `json:"status,omitempty"` // sent, delivered, read, failed
+	Status           string                 `json:"status,omitempty"` // sent, delivered, read, acknowledged, failed
 	DeliveredAt      *time.Time             `json:"delivered_at,omitempty"`
 	ReadAt           *time.Time             `json:"read_at,omitempty"`
+	AcknowledgedAt   *time.Time             `json:"acknowledged_at,omitempty"`
 }
 
+// Message delivery statuses
+const (
+	StatusSent         = "sent"
+	StatusDelivered    = "delivered"
+	StatusRead         = "read"
+	StatusAcknowledged = "acknowledged"
+	StatusFailed       = "failed"
+)
+
 // MessageFilter defines subscription filters
 type MessageFilter struct {
 	MessageTypes []MessageType
@@ -61,6 +72,7 @@ type MessageFilter struct {
 	ToAgentID    string // Only messages to this agent
 	Topics       []string
 	MinPriority  Priority
+	BeadID       string // Only messages in this bead's thread
 }
 
 // Subscription represents a message subscription
@@ -78,6 +90,7 @@ type AgentMessageBus struct {
 	subscriptions map[string]*Subscription
 	history       map[string][]*AgentMessage // agent_id -> messages
 	historyMu     sync.RWMutex
+	store         Store // Optional persistence for inboxes
 	subsMu        sync.RWMutex
 	maxHistory    int
 }
@@ -124,7 +137,20 @@ func (mb *AgentMessageBus) Send(ctx context.Context, msg *AgentMessage) error {
 		msg.Priority = PriorityNormal
 	}
 
-	msg.Status = "sent"
+	if msg.BeadID == "" {
+		if beadID, ok := msg.Context["bead_id"].(string); ok {
+			msg.BeadID = beadID
+		}
+	}
+
+	msg.Status = StatusSent
+
+	// Persist before publishing so the inbox never misses a message
+	if store := mb.getStore(); store != nil {
+		if err := store.SaveAgentMessage(msg); err != nil {
+			return fmt.Errorf("failed to persist message: %w", err)
+		}
+	}
 
 	// Store in history
 	mb.addToHistory(msg)
@@ -410,6 +436,10 @@ func (mb *AgentMessageBus) matchesFilter(msg *AgentMessage, filter MessageFilter
 		}
 	}
 
+	if filter.BeadID != "" && msg.BeadID != filter.BeadID {
+		return false
+	}
+
 	// Check priority filter
 	if filter.MinPriority != "" {
 		priorityOrder := map[Priority]int{
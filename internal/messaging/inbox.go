@@ -0,0 +1,216 @@
+package messaging
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Store persists agent messages so inboxes and threads survive restarts
+type Store interface {
+	SaveAgentMessage(msg *AgentMessage) error
+	GetAgentMessage(id string) (*AgentMessage, error)
+	ListAgentMessages(query MessageQuery) ([]*AgentMessage, error)
+	UpdateAgentMessageStatus(id, status string, at time.Time) error
+}
+
+// MessageQuery selects messages. Empty fields match everything; results are
+// the most recent Limit matches, oldest first.
+type MessageQuery struct {
+	ToAgentID      string // Addressed to this agent, directly or as a consensus recipient
+	FromAgentID    string // Sent by this agent
+	Participant    string // Sent by or addressed to this agent
+	BeadID         string // In this bead's thread
+	Unacknowledged bool   // Not yet acknowledged by the recipient
+	Limit          int
+}
+
+// DefaultInboxLimit caps inbox and thread queries without an explicit limit
+const DefaultInboxLimit = 100
+
+// AddressedTo reports whether the agent is a recipient of the message
+func (msg *AgentMessage) AddressedTo(agentID string) bool {
+	if agentID == "" {
+		return false
+	}
+	if msg.ToAgentID == agentID {
+		return true
+	}
+	for _, id := range msg.ToAgentIDs {
+		if id == agentID {
+			return true
+		}
+	}
+	return false
+}
+
+func (q MessageQuery) matches(msg *AgentMessage) bool {
+	if q.ToAgentID != "" && !msg.AddressedTo(q.ToAgentID) {
+		return false
+	}
+	if q.FromAgentID != "" && msg.FromAgentID != q.FromAgentID {
+		return false
+	}
+	if q.Participant != "" && msg.FromAgentID != q.Participant && !msg.AddressedTo(q.Participant) {
+		return false
+	}
+	if q.BeadID != "" && msg.BeadID != q.BeadID {
+		return false
+	}
+	if q.Unacknowledged && msg.Status == StatusAcknowledged {
+		return false
+	}
+	return true
+}
+
+// SetStore enables message persistence
+func (mb *AgentMessageBus) SetStore(store Store) {
+	mb.historyMu.Lock()
+	defer mb.historyMu.Unlock()
+	mb.store = store
+}
+
+func (mb *AgentMessageBus) getStore() Store {
+	mb.historyMu.RLock()
+	defer mb.historyMu.RUnlock()
+	return mb.store
+}
+
+// Messages returns the messages matching the query without changing their
+// status. Without a store it searches the in-memory history.
+func (mb *AgentMessageBus) Messages(query MessageQuery) ([]*AgentMessage, error) {
+	if query.Limit <= 0 {
+		query.Limit = DefaultInboxLimit
+	}
+	if store := mb.getStore(); store != nil {
+		return store.ListAgentMessages(query)
+	}
+
+	mb.historyMu.RLock()
+	seen := make(map[string]bool)
+	var matched []*AgentMessage
+	for _, messages := range mb.history {
+		for _, msg := range messages {
+			if seen[msg.MessageID] || !query.matches(msg) {
+				continue
+			}
+			seen[msg.MessageID] = true
+			c := *msg
+			matched = append(matched, &c)
+		}
+	}
+	mb.historyMu.RUnlock()
+
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Timestamp.Before(matched[j].Timestamp) })
+	if len(matched) > query.Limit {
+		matched = matched[len(matched)-query.Limit:]
+	}
+	return matched, nil
+}
+
+// Inbox returns the messages addressed to an agent, optionally limited to a
+// bead's thread and to unacknowledged ones. Messages fetched for the first
+// time are marked delivered.
+func (mb *AgentMessageBus) Inbox(agentID, beadID string, unacknowledgedOnly bool, limit int) ([]*AgentMessage, error) {
+	if agentID == "" {
+		return nil, fmt.Errorf("agent_id is required")
+	}
+	messages, err := mb.Messages(MessageQuery{
+		ToAgentID:      agentID,
+		BeadID:         beadID,
+		Unacknowledged: unacknowledgedOnly,
+		Limit:          limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, msg := range messages {
+		if msg.Status != StatusSent {
+			continue
+		}
+		if err := mb.setStatus(msg.MessageID, StatusDelivered, now); err != nil {
+			return nil, err
+		}
+		applyStatus(msg, StatusDelivered, now)
+	}
+	return messages, nil
+}
+
+// GetMessage returns a message by ID
+func (mb *AgentMessageBus) GetMessage(id string) (*AgentMessage, error) {
+	if store := mb.getStore(); store != nil {
+		return store.GetAgentMessage(id)
+	}
+
+	mb.historyMu.RLock()
+	defer mb.historyMu.RUnlock()
+	for _, messages := range mb.history {
+		for _, msg := range messages {
+			if msg.MessageID == id {
+				c := *msg
+				return &c, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("message not found: %s", id)
+}
+
+// Acknowledge records that a recipient has handled a message
+func (mb *AgentMessageBus) Acknowledge(messageID, agentID string) (*AgentMessage, error) {
+	msg, err := mb.GetMessage(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if !msg.AddressedTo(agentID) {
+		return nil, fmt.Errorf("message %s is not addressed to agent %s", messageID, agentID)
+	}
+	if msg.Status == StatusAcknowledged {
+		return msg, nil
+	}
+
+	now := time.Now()
+	if err := mb.setStatus(messageID, StatusAcknowledged, now); err != nil {
+		return nil, err
+	}
+	applyStatus(msg, StatusAcknowledged, now)
+	return msg, nil
+}
+
+// setStatus updates a message's status in the store and in-memory history
+func (mb *AgentMessageBus) setStatus(id, status string, at time.Time) error {
+	if store := mb.getStore(); store != nil {
+		if err := store.UpdateAgentMessageStatus(id, status, at); err != nil {
+			return err
+		}
+	}
+
+	mb.historyMu.Lock()
+	defer mb.historyMu.Unlock()
+	for _, messages := range mb.history {
+		for _, msg := range messages {
+			if msg.MessageID == id {
+				applyStatus(msg, status, at)
+			}
+		}
+	}
+	return nil
+}
+
+func applyStatus(msg *AgentMessage, status string, at time.Time) {
+	msg.Status = status
+	switch status {
+	case StatusDelivered:
+		if msg.DeliveredAt == nil {
+			msg.DeliveredAt = &at
+		}
+	case StatusRead:
+		msg.ReadAt = &at
+	case StatusAcknowledged:
+		if msg.DeliveredAt == nil {
+			msg.DeliveredAt = &at
+		}
+		msg.AcknowledgedAt = &at
+	}
+}
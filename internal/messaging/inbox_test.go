@@ -0,0 +1,192 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore implements Store for testing, keeping copies like a database would
+type memoryStore struct {
+	mu       sync.Mutex
+	messages []*AgentMessage
+	saveErr  error
+}
+
+func (s *memoryStore) SaveAgentMessage(msg *AgentMessage) error {
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := *msg
+	s.messages = append(s.messages, &c)
+	return nil
+}
+
+func (s *memoryStore) GetAgentMessage(id string) (*AgentMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, msg := range s.messages {
+		if msg.MessageID == id {
+			c := *msg
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("message not found: %s", id)
+}
+
+func (s *memoryStore) ListAgentMessages(q MessageQuery) ([]*AgentMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*AgentMessage
+	for _, msg := range s.messages {
+		if q.matches(msg) {
+			c := *msg
+			out = append(out, &c)
+		}
+	}
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[len(out)-q.Limit:]
+	}
+	return out, nil
+}
+
+func (s *memoryStore) UpdateAgentMessageStatus(id, status string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, msg := range s.messages {
+		if msg.MessageID == id {
+			applyStatus(msg, status, at)
+			return nil
+		}
+	}
+	return fmt.Errorf("message not found: %s", id)
+}
+
+func sendTestMessage(t *testing.T, bus *AgentMessageBus, from, to, beadID string) *AgentMessage {
+	t.Helper()
+	msg := &AgentMessage{
+		Type:        MessageTypeDirect,
+		FromAgentID: from,
+		ToAgentID:   to,
+		Body:        fmt.Sprintf("%s -> %s", from, to),
+		Context:     map[string]interface{}{"bead_id": beadID},
+	}
+	require.NoError(t, bus.Send(context.Background(), msg))
+	return msg
+}
+
+func TestSend_PersistsAndThreadsByBead(t *testing.T) {
+	bus := setupTestBus(t)
+	defer bus.Close()
+	store := &memoryStore{}
+	bus.SetStore(store)
+
+	msg := sendTestMessage(t, bus, "agent-1", "agent-2", "bd-1")
+
+	assert.Equal(t, "bd-1", msg.BeadID)
+	require.Len(t, store.messages, 1)
+	assert.Equal(t, StatusSent, store.messages[0].Status)
+	assert.Equal(t, "bd-1", store.messages[0].BeadID)
+}
+
+func TestSend_StoreErrorFailsSend(t *testing.T) {
+	bus := setupTestBus(t)
+	defer bus.Close()
+	bus.SetStore(&memoryStore{saveErr: fmt.Errorf("disk full")})
+
+	err := bus.Send(context.Background(), &AgentMessage{Type: MessageTypeDirect, FromAgentID: "a", ToAgentID: "b"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+	assert.Empty(t, bus.GetHistory("b", 0))
+}
+
+func TestInbox_DeliversAndAcknowledges(t *testing.T) {
+	for name, store := range map[string]Store{"memory": nil, "store": &memoryStore{}} {
+		t.Run(name, func(t *testing.T) {
+			bus := setupTestBus(t)
+			defer bus.Close()
+			if store != nil {
+				bus.SetStore(store)
+			}
+
+			first := sendTestMessage(t, bus, "agent-1", "agent-2", "bd-1")
+			sendTestMessage(t, bus, "agent-3", "agent-2", "bd-2")
+			sendTestMessage(t, bus, "agent-2", "agent-1", "bd-1")
+
+			inbox, err := bus.Inbox("agent-2", "", true, 0)
+			require.NoError(t, err)
+			require.Len(t, inbox, 2)
+			assert.Equal(t, first.MessageID, inbox[0].MessageID)
+			assert.Equal(t, StatusDelivered, inbox[0].Status)
+			assert.NotNil(t, inbox[0].DeliveredAt)
+
+			_, err = bus.Acknowledge(first.MessageID, "agent-3")
+			assert.Error(t, err, "only a recipient may acknowledge")
+
+			acked, err := bus.Acknowledge(first.MessageID, "agent-2")
+			require.NoError(t, err)
+			assert.Equal(t, StatusAcknowledged, acked.Status)
+			assert.NotNil(t, acked.AcknowledgedAt)
+
+			pending, err := bus.Inbox("agent-2", "", true, 0)
+			require.NoError(t, err)
+			require.Len(t, pending, 1)
+			assert.Equal(t, "bd-2", pending[0].BeadID)
+
+			thread, err := bus.Messages(MessageQuery{BeadID: "bd-1"})
+			require.NoError(t, err)
+			assert.Len(t, thread, 2)
+
+			_, err = bus.Inbox("", "", false, 0)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestInbox_ConsensusRecipients(t *testing.T) {
+	bus := setupTestBus(t)
+	defer bus.Close()
+	bus.SetStore(&memoryStore{})
+
+	msg := &AgentMessage{
+		Type:        MessageTypeConsensusRequest,
+		FromAgentID: "lead",
+		ToAgentIDs:  []string{"agent-1", "agent-2"},
+		Subject:     "vote",
+	}
+	require.NoError(t, bus.Send(context.Background(), msg))
+
+	inbox, err := bus.Inbox("agent-2", "", false, 0)
+	require.NoError(t, err)
+	require.Len(t, inbox, 1)
+	assert.True(t, inbox[0].AddressedTo("agent-1"))
+	assert.False(t, inbox[0].AddressedTo("lead"))
+}
+
+func TestReadInbox_AcknowledgesMessages(t *testing.T) {
+	bus := setupAdapterTestBus(t)
+	defer bus.Close()
+	sender := NewActionMessageSender(bus, nil)
+
+	id, err := sender.SendMessage(context.Background(), "agent-1", "agent-2", "question", "Which DB?", "sqlite?",
+		map[string]interface{}{"bead_id": "bd-1", "in_reply_to": "msg-0"})
+	require.NoError(t, err)
+
+	messages, err := sender.ReadInbox(context.Background(), "agent-2", "bd-1", 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, id, messages[0]["message_id"])
+	assert.Equal(t, "msg-0", messages[0]["in_reply_to"])
+	assert.Equal(t, true, messages[0]["requires_response"])
+
+	messages, err = sender.ReadInbox(context.Background(), "agent-2", "bd-1", 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
@@ -35,6 +35,12 @@ type Metrics struct {
 	WorkflowDuration   *prometheus.HistogramVec
 	WorkflowErrors     *prometheus.CounterVec
 
+	// Action metrics
+	ActionCorrections        *prometheus.CounterVec
+	ActionCorrectionAttempts prometheus.Histogram
+	ActionRepairs            *prometheus.CounterVec
+	ActionTimeouts           *prometheus.CounterVec
+
 	// System metrics
 	DatabaseConnections prometheus.Gauge
 	CacheHits           prometheus.Counter
@@ -42,6 +48,18 @@ type Metrics struct {
 	EventsPublished     *prometheus.CounterVec
 	HTTPRequestsTotal   *prometheus.CounterVec
 	HTTPRequestDuration *prometheus.HistogramVec
+
+	// Analytics ingestion metrics
+	AnalyticsQueueDepth  prometheus.Gauge
+	AnalyticsSpillBytes  prometheus.Gauge
+	AnalyticsLogsSpilled prometheus.Counter
+	AnalyticsLogsDropped *prometheus.CounterVec
+	AnalyticsWriteErrors prometheus.Counter
+
+	// Bead context stream metrics
+	ContextStreamSubscribers     prometheus.Gauge
+	ContextStreamSlowDisconnects prometheus.Counter
+	ContextUpdatesDropped        prometheus.Counter
 }
 
 var (
@@ -198,6 +216,36 @@ func NewMetrics() *Metrics {
 				[]string{"workflow_type", "error_type"},
 			),
 
+			// Action metrics
+			ActionCorrections: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "loom_action_corrections_total",
+					Help: "Malformed action responses re-prompted for correction, by outcome",
+				},
+				[]string{"outcome"}, // corrected, exhausted, reprompt_error
+			),
+			ActionCorrectionAttempts: promauto.NewHistogram(
+				prometheus.HistogramOpts{
+					Name:    "loom_action_correction_attempts",
+					Help:    "Re-prompts issued per malformed action response",
+					Buckets: prometheus.LinearBuckets(1, 1, 5),
+				},
+			),
+			ActionRepairs: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "loom_action_repairs_total",
+					Help: "Malformed action responses recovered by JSON repair, by strategy",
+				},
+				[]string{"strategy"}, // single_quotes, trailing_commas, unquoted_keys, duplicate_envelope
+			),
+			ActionTimeouts: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "loom_action_timeouts_total",
+					Help: "Actions that exceeded their configured timeout, by action type",
+				},
+				[]string{"action_type"},
+			),
+
 			// System metrics
 			DatabaseConnections: promauto.NewGauge(
 				prometheus.GaugeOpts{
@@ -239,6 +287,57 @@ func NewMetrics() *Metrics {
 				},
 				[]string{"method", "path"},
 			),
+			// Analytics ingestion metrics
+			AnalyticsQueueDepth: promauto.NewGauge(
+				prometheus.GaugeOpts{
+					Name: "loom_analytics_queue_depth",
+					Help: "Request logs queued in memory for analytics storage",
+				},
+			),
+			AnalyticsSpillBytes: promauto.NewGauge(
+				prometheus.GaugeOpts{
+					Name: "loom_analytics_spill_bytes",
+					Help: "Bytes of request logs spilled to disk awaiting replay",
+				},
+			),
+			AnalyticsLogsSpilled: promauto.NewCounter(
+				prometheus.CounterOpts{
+					Name: "loom_analytics_logs_spilled_total",
+					Help: "Request logs spilled to disk because the memory queue was full",
+				},
+			),
+			AnalyticsLogsDropped: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "loom_analytics_logs_dropped_total",
+					Help: "Request logs lost before reaching analytics storage, by reason",
+				},
+				[]string{"reason"}, // spill_full, spill_error, closed
+			),
+			AnalyticsWriteErrors: promauto.NewCounter(
+				prometheus.CounterOpts{
+					Name: "loom_analytics_write_errors_total",
+					Help: "Failed batch writes of request logs, retried later",
+				},
+			),
+			// Bead context stream metrics
+			ContextStreamSubscribers: promauto.NewGauge(
+				prometheus.GaugeOpts{
+					Name: "loom_context_stream_subscribers",
+					Help: "Subscribers to bead context updates",
+				},
+			),
+			ContextStreamSlowDisconnects: promauto.NewCounter(
+				prometheus.CounterOpts{
+					Name: "loom_context_stream_slow_disconnects_total",
+					Help: "Bead context subscribers disconnected because their buffer stayed full",
+				},
+			),
+			ContextUpdatesDropped: promauto.NewCounter(
+				prometheus.CounterOpts{
+					Name: "loom_context_updates_dropped_total",
+					Help: "Bead context updates dropped because the broadcast queue was full",
+				},
+			),
 		}
 	})
 
@@ -263,6 +362,22 @@ func (m *Metrics) RecordBeadTransition(projectID, fromStatus, toStatus string) {
 	m.BeadTransitions.WithLabelValues(projectID, fromStatus, toStatus).Inc()
 }
 
+// RecordActionCorrection records the outcome of an action response correction loop
+func (m *Metrics) RecordActionCorrection(outcome string, attempts int) {
+	m.ActionCorrections.WithLabelValues(outcome).Inc()
+	m.ActionCorrectionAttempts.Observe(float64(attempts))
+}
+
+// RecordActionRepair records a JSON repair strategy needed to decode an action response
+func (m *Metrics) RecordActionRepair(strategy string) {
+	m.ActionRepairs.WithLabelValues(strategy).Inc()
+}
+
+// RecordActionTimeout records an action that exceeded its timeout
+func (m *Metrics) RecordActionTimeout(actionType string) {
+	m.ActionTimeouts.WithLabelValues(actionType).Inc()
+}
+
 // RecordHTTPRequest records an HTTP request
 func (m *Metrics) RecordHTTPRequest(method, path, status string, duration float64) {
 	m.HTTPRequestsTotal.WithLabelValues(method, path, status).Inc()
@@ -32,6 +32,10 @@ type Provider struct {
 	LastHeartbeatLatencyMs int64           `json:"last_heartbeat_latency_ms"`
 	LastHeartbeatError     string          `json:"last_heartbeat_error"`
 
+	// Additional endpoints serving the same model; requests are load
+	// balanced across them and Endpoint
+	Endpoints []string `json:"endpoints,omitempty"`
+
 	// Cost and capability metadata for routing
 	CostPerMToken     float64  `json:"cost_per_mtoken"`    // Cost per million tokens ($)
 	ContextWindow     int      `json:"context_window"`     // Maximum context window size
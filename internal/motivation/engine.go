@@ -3,11 +3,14 @@ package motivation
 import (
 	"context"
 	"fmt"
-	"log"
 	"sync"
 	"time"
+
+	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var motivationLog = logging.Module("motivation")
+
 // Engine evaluates and fires motivations based on system state
 type Engine struct {
 	registry      *Registry
@@ -141,7 +144,7 @@ func (e *Engine) Start(ctx context.Context) error {
 	e.stopCh = make(chan struct{})
 	e.mu.Unlock()
 
-	log.Printf("Motivation engine started with interval %v", e.config.EvaluationInterval)
+	motivationLog.Info("Motivation engine started", "interval", e.config.EvaluationInterval)
 
 	ticker := time.NewTicker(e.config.EvaluationInterval)
 	defer ticker.Stop()
@@ -194,19 +197,19 @@ func (e *Engine) tick(ctx context.Context) {
 	triggered := 0
 	for _, m := range motivations {
 		if triggered >= e.config.MaxTriggersPerTick {
-			log.Printf("Max triggers per tick (%d) reached, deferring remaining", e.config.MaxTriggersPerTick)
+			motivationLog.Info("Max triggers per tick reached, deferring remaining", "max_triggers", e.config.MaxTriggersPerTick)
 			break
 		}
 
 		shouldFire, triggerData, err := e.evaluate(ctx, m)
 		if err != nil {
-			log.Printf("Error evaluating motivation %s: %v", m.ID, err)
+			motivationLog.Error("Error evaluating motivation", "motivation_id", m.ID, "error", err)
 			continue
 		}
 
 		if shouldFire {
 			if err := e.fire(ctx, m, triggerData); err != nil {
-				log.Printf("Error firing motivation %s: %v", m.ID, err)
+				motivationLog.Error("Error firing motivation", "motivation_id", m.ID, "error", err)
 			} else {
 				triggered++
 			}
@@ -289,14 +292,14 @@ func (e *Engine) fire(ctx context.Context, m *Motivation, triggerData map[string
 		if m.AgentID != "" {
 			// Wake specific agent
 			if err := e.actionHandler.WakeAgent(m.AgentID, m); err != nil {
-				log.Printf("Failed to wake agent %s: %v", m.AgentID, err)
+				motivationLog.Warn("Failed to wake agent", "agent_id", m.AgentID, "error", err)
 			} else {
 				trigger.AgentWoken = m.AgentID
 			}
 		} else if m.AgentRole != "" {
 			// Wake agents by role
 			if err := e.actionHandler.WakeAgentsByRole(m.AgentRole, m); err != nil {
-				log.Printf("Failed to wake agents by role %s: %v", m.AgentRole, err)
+				motivationLog.Warn("Failed to wake agents by role", "role", m.AgentRole, "error", err)
 			}
 		}
 	}
@@ -304,14 +307,14 @@ func (e *Engine) fire(ctx context.Context, m *Motivation, triggerData map[string
 	// Publish the trigger event
 	if e.actionHandler != nil {
 		if err := e.actionHandler.PublishMotivationFired(trigger); err != nil {
-			log.Printf("Failed to publish motivation fired event: %v", err)
+			motivationLog.Warn("Failed to publish motivation fired event", "motivation_id", m.ID, "error", err)
 		}
 	}
 
 	// Record in registry
 	e.registry.RecordTrigger(trigger)
 
-	log.Printf("Motivation fired: %s (%s) -> agent_role=%s", m.Name, m.ID, m.AgentRole)
+	motivationLog.Info("Motivation fired", "motivation", m.Name, "motivation_id", m.ID, "agent_role", m.AgentRole)
 	return nil
 }
 
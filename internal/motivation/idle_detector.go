@@ -1,7 +1,6 @@
 package motivation
 
 import (
-	"log"
 	"sync"
 	"time"
 )
@@ -311,6 +310,6 @@ func (d *IdleDetector) UpdateConfig(config *IdleConfig) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	d.config = config
-	log.Printf("Idle detector config updated: system=%v, project=%v, agent=%v",
-		config.SystemIdleThreshold, config.ProjectIdleThreshold, config.AgentIdleThreshold)
+	motivationLog.Info("Idle detector config updated", "system", config.SystemIdleThreshold,
+		"project", config.ProjectIdleThreshold, "agent", config.AgentIdleThreshold)
 }
@@ -2,7 +2,6 @@ package motivation
 
 import (
 	"context"
-	"log"
 )
 
 // MotivationActivityInput contains input for the motivation evaluation activity
@@ -48,7 +47,7 @@ func (a *MotivationActivity) EvaluateMotivations(ctx context.Context, input Moti
 	triggered, err := a.engine.Tick(ctx)
 	if err != nil {
 		result.Errors = append(result.Errors, err.Error())
-		log.Printf("Motivation evaluation error on beat %d: %v", input.BeatCount, err)
+		motivationLog.Error("Motivation evaluation error", "beat", input.BeatCount, "error", err)
 	}
 
 	result.MotivationsFired = triggered
@@ -60,8 +59,8 @@ func (a *MotivationActivity) EvaluateMotivations(ctx context.Context, input Moti
 	}
 
 	if triggered > 0 {
-		log.Printf("Motivation activity: beat=%d, evaluated=%d, fired=%d",
-			input.BeatCount, result.MotivationsEvaluated, triggered)
+		motivationLog.Info("Motivations fired", "beat", input.BeatCount, "evaluated", result.MotivationsEvaluated,
+			"fired", triggered)
 	}
 
 	return result, nil
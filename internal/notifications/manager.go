@@ -3,15 +3,17 @@ package notifications
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jordanhubbard/loom/internal/activity"
 	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var notificationsLog = logging.Module("notifications")
+
 // Manager handles notification logic
 type Manager struct {
 	db            *database.Database
@@ -40,7 +42,7 @@ func (m *Manager) subscribeToActivities() {
 
 	for activity := range activityChan {
 		if err := m.ProcessActivity(activity); err != nil {
-			log.Printf("Failed to process activity for notifications: %v", err)
+			notificationsLog.Error("Failed to process activity for notifications", "error", err)
 		}
 	}
 }
@@ -63,7 +65,7 @@ func (m *Manager) ProcessActivity(activity *activity.Activity) error {
 		// Get user preferences
 		prefs, err := m.GetPreferences(user.ID)
 		if err != nil {
-			log.Printf("Failed to get preferences for user %s: %v", user.ID, err)
+			notificationsLog.Warn("Failed to get preferences", "user_id", user.ID, "error", err)
 			continue
 		}
 
@@ -74,7 +76,7 @@ func (m *Manager) ProcessActivity(activity *activity.Activity) error {
 
 		// Create notification
 		if err := m.CreateNotification(notification); err != nil {
-			log.Printf("Failed to create notification for user %s: %v", user.ID, err)
+			notificationsLog.Error("Failed to create notification", "user_id", user.ID, "error", err)
 			continue
 		}
 
@@ -167,6 +169,33 @@ func (m *Manager) formatNotification(activity *activity.Activity, userID string)
 		}
 	}
 
+	// Executor quota exhaustion blocks agent work on the project
+	if activity.EventType == "executor.quota_exceeded" {
+		title = "Executor Quota Exceeded"
+		message = fmt.Sprintf("Project %s exceeded its %s quota", activity.ProjectID, activity.ResourceTitle)
+		link = fmt.Sprintf("/projects/%s", activity.ProjectID)
+		return
+	}
+
+	// High-severity usage anomalies (cost, latency or error spikes)
+	if activity.EventType == "pattern.anomaly_detected" {
+		title = "Usage Anomaly Detected"
+		message = activity.ResourceTitle
+		link = "/analytics"
+		if activity.BeadID != "" {
+			link = fmt.Sprintf("/beads/%s", activity.BeadID)
+		}
+		return
+	}
+
+	// Scheduled project digests
+	if activity.EventType == "digest.generated" {
+		title = activity.ResourceTitle
+		message, _ = activity.Metadata["headline"].(string)
+		link = fmt.Sprintf("/api/v1/digests/%s", activity.ResourceID)
+		return
+	}
+
 	// Check for system errors
 	if activity.EventType == "provider.deleted" || activity.EventType == "workflow.failed" {
 		title = "System Alert"
@@ -196,11 +225,11 @@ func (m *Manager) determinePriority(activity *activity.Activity) string {
 
 	// Determine priority based on event type
 	switch activity.EventType {
-	case "bead.assigned", "decision.created":
+	case "bead.assigned", "decision.created", "executor.quota_exceeded":
 		return PriorityHigh
 	case "workflow.failed", "provider.deleted":
 		return PriorityCritical
-	case "bead.created", "agent.spawned":
+	case "bead.created", "agent.spawned", "digest.generated":
 		return PriorityNormal
 	default:
 		return PriorityLow
@@ -1,13 +1,16 @@
 package observability
 
 import (
-	"encoding/json"
-	"log"
-	"time"
+	"context"
+	"log/slog"
+
+	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var eventLog = logging.Module("events")
+
 func Info(event string, fields map[string]interface{}) {
-	logEvent("info", event, fields)
+	logEvent(slog.LevelInfo, event, fields)
 }
 
 func Error(event string, fields map[string]interface{}, err error) {
@@ -15,30 +18,16 @@ func Error(event string, fields map[string]interface{}, err error) {
 	if err != nil {
 		payload["error"] = err.Error()
 	}
-	logEvent("error", event, payload)
+	logEvent(slog.LevelError, event, payload)
 }
 
-func logEvent(level, event string, fields map[string]interface{}) {
-	payload := cloneFields(fields)
-	payload["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
-	payload["level"] = level
-	payload["event"] = event
-	raw, err := json.Marshal(payload)
-	if err != nil {
-		fallback := map[string]interface{}{
-			"ts":    time.Now().UTC().Format(time.RFC3339Nano),
-			"level": "error",
-			"event": "log.marshal_failed",
-			"error": err.Error(),
-		}
-		if fields != nil {
-			fallback["event_payload"] = fields
-		}
-		fallbackRaw, _ := json.Marshal(fallback)
-		log.Print(string(fallbackRaw))
+func logEvent(level slog.Level, event string, fields map[string]interface{}) {
+	ctx := context.Background()
+	if !eventLog.Enabled(ctx, level) {
 		return
 	}
-	log.Print(string(raw))
+	attrs := append([]slog.Attr{slog.String("event", event)}, logging.Attrs(fields)...)
+	eventLog.LogAttrs(ctx, level, event, attrs...)
 }
 
 func cloneFields(fields map[string]interface{}) map[string]interface{} {
@@ -3,13 +3,15 @@ package openclaw
 import (
 	"context"
 	"fmt"
-	"log"
 	"strings"
 
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
 	"github.com/jordanhubbard/loom/pkg/config"
 )
 
+var openClawLog = logging.Module("openclaw")
+
 // Bridge subscribes to the EventBus and forwards relevant events to the
 // OpenClaw messaging gateway so that humans (CEO, on-call) receive
 // notifications about P0 decisions and escalations.
@@ -44,12 +46,13 @@ func NewBridge(client *Client, eb *eventbus.EventBus, cfg *config.OpenClawConfig
 		done:            make(chan struct{}),
 	}
 
-	// Subscribe to decision and motivation events.
+	// Subscribe to decision, motivation and digest events.
 	b.subscriber = eb.Subscribe("openclaw-bridge", func(e *eventbus.Event) bool {
 		switch e.Type {
 		case eventbus.EventTypeDecisionCreated,
 			eventbus.EventTypeDecisionResolved,
-			eventbus.EventTypeMotivationFired:
+			eventbus.EventTypeMotivationFired,
+			eventbus.EventTypeDigestGenerated:
 			return true
 		}
 		return false
@@ -112,12 +115,12 @@ func (b *Bridge) handleEvent(ctx context.Context, event *eventbus.Event) {
 
 	resp, err := b.client.SendMessageWithRetry(ctx, req)
 	if err != nil {
-		log.Printf("[OpenClaw] Failed to send message for %s: %v", event.Type, err)
+		openClawLog.Error("Failed to send message", "event_type", event.Type, "error", err)
 		b.publishStatus(eventbus.EventTypeOpenClawMessageFailed, event, err.Error())
 		return
 	}
 
-	log.Printf("[OpenClaw] Message sent for %s (message_id=%s)", event.Type, resp.MessageID)
+	openClawLog.Info("Message sent", "event_type", event.Type, "message_id", resp.MessageID)
 	b.publishStatus(eventbus.EventTypeOpenClawMessageSent, event, resp.MessageID)
 }
 
@@ -181,6 +184,13 @@ func (b *Bridge) formatMessage(event *eventbus.Event) (message, sessionKey, prio
 		reason, _ := data["reason"].(string)
 		msg := fmt.Sprintf("Motivation fired: %s\nReason: %s", name, reason)
 		return msg, "", ""
+
+	case eventbus.EventTypeDigestGenerated:
+		if b.escalationsOnly {
+			return "", "", ""
+		}
+		markdown, _ := data["markdown"].(string)
+		return markdown, "", ""
 	}
 
 	return "", "", ""
@@ -0,0 +1,149 @@
+package patterns
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/logging"
+)
+
+var patternsLog = logging.Module("patterns")
+
+// severityRank orders anomaly severities from least to most severe
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// Alerter turns detected anomalies into alerts. Only anomalies at or above
+// the minimum severity that occurred within the dedup window are considered;
+// of those, the worst per key (type, provider and model) is alerted, and a key
+// already alerted within the window is suppressed.
+type Alerter struct {
+	minSeverity string
+	window      time.Duration
+	onAlert     func(*PatternAnomaly)
+
+	mu        sync.Mutex
+	lastAlert map[string]time.Time
+	now       func() time.Time
+}
+
+// NewAlerter creates an alerter calling onAlert for each alert
+func NewAlerter(minSeverity string, window time.Duration, onAlert func(*PatternAnomaly)) *Alerter {
+	if severityRank[minSeverity] == 0 {
+		minSeverity = "high"
+	}
+	return &Alerter{
+		minSeverity: minSeverity,
+		window:      window,
+		onAlert:     onAlert,
+		lastAlert:   make(map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+// AnomalyKey identifies repeats of the same anomaly
+func AnomalyKey(a *PatternAnomaly) string {
+	return a.Type + "|" + a.ProviderID + "|" + a.ModelName
+}
+
+// HandleAnomalies alerts on new anomalies and returns the ones alerted
+func (al *Alerter) HandleAnomalies(anomalies []*PatternAnomaly) []*PatternAnomaly {
+	now := al.now()
+	worst := map[string]*PatternAnomaly{}
+	var order []string
+	for _, a := range anomalies {
+		if severityRank[a.Severity] < severityRank[al.minSeverity] {
+			continue
+		}
+		if !a.OccurredAt.IsZero() && now.Sub(a.OccurredAt) > al.window {
+			continue
+		}
+		key := AnomalyKey(a)
+		if prev, ok := worst[key]; !ok {
+			order = append(order, key)
+			worst[key] = a
+		} else if a.Deviation > prev.Deviation {
+			worst[key] = a
+		}
+	}
+
+	var alerted []*PatternAnomaly
+	al.mu.Lock()
+	for key, at := range al.lastAlert {
+		if now.Sub(at) >= al.window {
+			delete(al.lastAlert, key)
+		}
+	}
+	for _, key := range order {
+		if _, recent := al.lastAlert[key]; recent {
+			continue
+		}
+		al.lastAlert[key] = now
+		alerted = append(alerted, worst[key])
+	}
+	al.mu.Unlock()
+
+	if al.onAlert != nil {
+		for _, a := range alerted {
+			al.onAlert(a)
+		}
+	}
+	return alerted
+}
+
+// SetAnomalyHook registers a function called with the anomalies of every
+// analysis the manager runs
+func (m *Manager) SetAnomalyHook(hook func([]*PatternAnomaly)) {
+	m.anomalyHook = hook
+}
+
+// analyze runs pattern analysis and passes its anomalies to the hook
+func (m *Manager) analyze(ctx context.Context) (*PatternReport, error) {
+	report, err := m.patternAnalyzer.AnalyzePatterns(ctx, m.config)
+	if err != nil {
+		return nil, err
+	}
+	if m.anomalyHook != nil && len(report.Anomalies) > 0 {
+		m.anomalyHook(report.Anomalies)
+	}
+	return report, nil
+}
+
+// Observe feeds a logged request to the online detector. Anomalies go to
+// the anomaly hook on their own goroutine, so logging isn't held up by
+// alerting.
+func (m *Manager) Observe(log *analytics.RequestLog) {
+	found := m.stream.Observe(log)
+	if m.anomalyHook != nil && len(found) > 0 {
+		go m.anomalyHook(found)
+	}
+}
+
+// LiveAnomalies returns up to limit anomalies flagged by the online
+// detector, newest first
+func (m *Manager) LiveAnomalies(limit int) []*PatternAnomaly {
+	return m.stream.Recent(limit)
+}
+
+// StreamStats returns the online detector's per provider-model baselines
+func (m *Manager) StreamStats() []*StreamStats {
+	return m.stream.Stats()
+}
+
+// StartAnomalyChecks analyzes patterns every interval until ctx is
+// cancelled, so the anomaly hook fires without anyone requesting a report
+func (m *Manager) StartAnomalyChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.analyze(ctx); err != nil {
+				patternsLog.Error("Anomaly check failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package patterns
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+)
+
+func TestAlerterFiltersAndDeduplicates(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	var alerts []*PatternAnomaly
+	al := NewAlerter("high", time.Hour, func(a *PatternAnomaly) { alerts = append(alerts, a) })
+	al.now = func() time.Time { return now }
+
+	batch := []*PatternAnomaly{
+		{ID: "a1", Type: "cost-spike", Severity: "high", Deviation: 3.2, ProviderID: "openai", ModelName: "gpt-4o", OccurredAt: now.Add(-5 * time.Minute)},
+		{ID: "a2", Type: "cost-spike", Severity: "critical", Deviation: 5.1, ProviderID: "openai", ModelName: "gpt-4o", OccurredAt: now.Add(-2 * time.Minute)},
+		{ID: "a3", Type: "latency-spike", Severity: "medium", Deviation: 2.5, OccurredAt: now},
+		{ID: "a4", Type: "latency-spike", Severity: "critical", Deviation: 4.5, OccurredAt: now.Add(-3 * time.Hour)},
+		{ID: "a5", Type: "cost-spike", Severity: "high", Deviation: 3.0, ProviderID: "anthropic", ModelName: "claude", OccurredAt: now},
+	}
+	got := al.HandleAnomalies(batch)
+	if len(got) != 2 || got[0].ID != "a2" || got[1].ID != "a5" {
+		t.Fatalf("expected the worst openai spike and the anthropic spike, got %+v", got)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected onAlert twice, got %d", len(alerts))
+	}
+
+	// The same anomalies seen again within the window are suppressed
+	now = now.Add(30 * time.Minute)
+	repeat := []*PatternAnomaly{
+		{ID: "b1", Type: "cost-spike", Severity: "critical", Deviation: 6, ProviderID: "openai", ModelName: "gpt-4o", OccurredAt: now},
+	}
+	if got := al.HandleAnomalies(repeat); len(got) != 0 {
+		t.Fatalf("expected repeat to be suppressed, got %+v", got)
+	}
+
+	// After the window a new occurrence alerts again
+	now = now.Add(time.Hour)
+	repeat[0].OccurredAt = now
+	if got := al.HandleAnomalies(repeat); len(got) != 1 {
+		t.Fatalf("expected a new alert after the window, got %+v", got)
+	}
+}
+
+func TestManagerAnomalyHook(t *testing.T) {
+	storage := &MockStorage{}
+	for i := 0; i < 20; i++ {
+		storage.logs = append(storage.logs, &analytics.RequestLog{ID: "n", Timestamp: time.Now(), CostUSD: 0.01, LatencyMs: 100})
+	}
+	storage.logs = append(storage.logs, &analytics.RequestLog{ID: "spike", Timestamp: time.Now(), ProviderID: "openai", ModelName: "gpt-4o", CostUSD: 5, LatencyMs: 100})
+
+	m := NewManager(storage, nil)
+	var seen []*PatternAnomaly
+	m.SetAnomalyHook(func(a []*PatternAnomaly) { seen = append(seen, a...) })
+
+	if _, err := m.GetAnomalies(context.Background()); err != nil {
+		t.Fatalf("GetAnomalies failed: %v", err)
+	}
+	if len(seen) == 0 {
+		t.Fatal("expected the hook to receive the cost spike")
+	}
+	if seen[0].ProviderID != "openai" || seen[0].ModelName != "gpt-4o" || seen[0].OccurredAt.IsZero() {
+		t.Errorf("expected anomaly to carry its request's provider, model and time, got %+v", seen[0])
+	}
+}
@@ -28,7 +28,13 @@ func NewAnalyzer(storage analytics.Storage, config *AnalysisConfig) *Analyzer {
 	}
 }
 
-// AnalyzePatterns performs comprehensive pattern analysis
+// maxRollupSpikes caps the requests read per spike type when anomalies are
+// detected from rollups
+const maxRollupSpikes = 1000
+
+// AnalyzePatterns performs comprehensive pattern analysis. Windows of at
+// least config.RollupWindow are read from hourly rollups when the storage
+// keeps them; shorter windows read the logs.
 func (a *Analyzer) AnalyzePatterns(ctx context.Context, config *AnalysisConfig) (*PatternReport, error) {
 	if config == nil {
 		config = a.config
@@ -42,16 +48,41 @@ func (a *Analyzer) AnalyzePatterns(ctx context.Context, config *AnalysisConfig)
 		Limit:     100000, // Analyze up to 100K requests
 	}
 
-	// Get stats for summary
-	stats, err := a.storage.GetLogStats(ctx, filter)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get stats: %w", err)
-	}
+	var (
+		rollups       []*analytics.Rollup
+		anomalies     []*PatternAnomaly
+		totalRequests int64
+		totalCost     float64
+	)
+	if rs, ok := a.storage.(analytics.RollupStorage); ok && config.RollupWindow > 0 && config.TimeWindow >= config.RollupWindow {
+		var err error
+		rollups, err = rs.GetRollups(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rollups: %w", err)
+		}
+		for _, r := range rollups {
+			totalRequests += r.Requests
+			totalCost += r.CostUSD
+		}
+		anomalies, err = a.detectRollupAnomalies(ctx, rollups, filter, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect anomalies: %w", err)
+		}
+	} else {
+		// Get stats for summary
+		stats, err := a.storage.GetLogStats(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stats: %w", err)
+		}
 
-	// Get detailed logs for clustering
-	logs, err := a.storage.GetLogs(ctx, filter)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get logs: %w", err)
+		// Get detailed logs for clustering
+		logs, err := a.storage.GetLogs(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get logs: %w", err)
+		}
+		rollups = analytics.RollupLogs(logs)
+		totalRequests, totalCost = stats.TotalRequests, stats.TotalCostUSD
+		anomalies = a.detectAnomalies(logs, config)
 	}
 
 	var allPatterns []*UsagePattern
@@ -59,23 +90,23 @@ func (a *Analyzer) AnalyzePatterns(ctx context.Context, config *AnalysisConfig)
 
 	if config.EnableClustering {
 		// Apply all clustering strategies
-		providerModelPatterns := a.clusterByProviderModel(logs, config)
+		providerModelPatterns := a.clusterByProviderModel(rollups, config)
 		allPatterns = append(allPatterns, providerModelPatterns...)
 		clusterSummaries["provider-model"] = a.summarizeCluster(providerModelPatterns)
 
-		userPatterns := a.clusterByUser(logs, config)
+		userPatterns := a.clusterByUser(rollups, config)
 		allPatterns = append(allPatterns, userPatterns...)
 		clusterSummaries["user"] = a.summarizeCluster(userPatterns)
 
-		costPatterns := a.clusterByCost(logs, config)
+		costPatterns := a.clusterByCost(rollups, config)
 		allPatterns = append(allPatterns, costPatterns...)
 		clusterSummaries["cost-band"] = a.summarizeCluster(costPatterns)
 
-		temporalPatterns := a.clusterByTime(logs, config)
+		temporalPatterns := a.clusterByTime(rollups, config)
 		allPatterns = append(allPatterns, temporalPatterns...)
 		clusterSummaries["temporal"] = a.summarizeCluster(temporalPatterns)
 
-		latencyPatterns := a.clusterByLatency(logs, config)
+		latencyPatterns := a.clusterByLatency(rollups, config)
 		allPatterns = append(allPatterns, latencyPatterns...)
 		clusterSummaries["latency"] = a.summarizeCluster(latencyPatterns)
 	}
@@ -88,17 +119,14 @@ func (a *Analyzer) AnalyzePatterns(ctx context.Context, config *AnalysisConfig)
 	// Identify expensive patterns (for recommendations)
 	expensivePatterns := a.identifyExpensivePatterns(allPatterns, config)
 
-	// Detect anomalies
-	anomalies := a.detectAnomalies(logs, config)
-
 	// Generate recommendations based on expensive patterns
 	recommendations := a.generateRecommendations(expensivePatterns)
 
 	return &PatternReport{
 		AnalyzedAt:       time.Now(),
 		TimeWindow:       config.TimeWindow,
-		TotalRequests:    stats.TotalRequests,
-		TotalCost:        stats.TotalCostUSD,
+		TotalRequests:    totalRequests,
+		TotalCost:        totalCost,
 		Patterns:         allPatterns, // Return all patterns, not just expensive ones
 		Anomalies:        anomalies,
 		ClusterSummaries: clusterSummaries,
@@ -106,70 +134,78 @@ func (a *Analyzer) AnalyzePatterns(ctx context.Context, config *AnalysisConfig)
 	}, nil
 }
 
-// clusterByProviderModel groups requests by provider and model
-func (a *Analyzer) clusterByProviderModel(logs []*analytics.RequestLog, config *AnalysisConfig) []*UsagePattern {
-	clusters := make(map[string]*UsagePattern)
-
-	for _, log := range logs {
-		key := fmt.Sprintf("%s:%s", log.ProviderID, log.ModelName)
-
-		if cluster, exists := clusters[key]; exists {
-			cluster.RequestCount++
-			cluster.TotalCost += log.CostUSD
-			cluster.TotalTokens += int64(log.TotalTokens)
-			cluster.AvgLatency = (cluster.AvgLatency*float64(cluster.RequestCount-1) + float64(log.LatencyMs)) / float64(cluster.RequestCount)
-
-			if log.ErrorMessage != "" {
-				cluster.ErrorRate = (cluster.ErrorRate*float64(cluster.RequestCount-1) + 1) / float64(cluster.RequestCount)
-			} else {
-				cluster.ErrorRate = cluster.ErrorRate * float64(cluster.RequestCount-1) / float64(cluster.RequestCount)
-			}
-
-			if log.Timestamp.After(cluster.LastSeen) {
-				cluster.LastSeen = log.Timestamp
-			}
-			if log.Timestamp.Before(cluster.FirstSeen) {
-				cluster.FirstSeen = log.Timestamp
-			}
-		} else {
-			errorRate := 0.0
-			if log.ErrorMessage != "" {
-				errorRate = 1.0
-			}
+// rollupCluster accumulates the rollups of one pattern
+type rollupCluster struct {
+	pattern   *UsagePattern
+	first     *analytics.Rollup // The first rollup of the pattern, for its key's fields
+	latencyMs int64
+	errors    int64
+}
 
-			clusters[key] = &UsagePattern{
-				ID:           uuid.New().String(),
-				Type:         "provider-model",
-				GroupKey:     key,
-				ProviderID:   log.ProviderID,
-				ModelName:    log.ModelName,
-				RequestCount: 1,
-				TotalCost:    log.CostUSD,
-				TotalTokens:  int64(log.TotalTokens),
-				AvgLatency:   float64(log.LatencyMs),
-				ErrorRate:    errorRate,
-				FirstSeen:    log.Timestamp,
-				LastSeen:     log.Timestamp,
-				AvgTokens:    int64(log.TotalTokens),
-			}
+// clusterRollups groups rollups by key into patterns of the given type and
+// computes their averages and request frequency
+func clusterRollups(rollups []*analytics.Rollup, patternType string, key func(*analytics.Rollup) string) []*rollupCluster {
+	clusters := make(map[string]*rollupCluster)
+	var ordered []*rollupCluster
+
+	for _, r := range rollups {
+		k := key(r)
+		c, exists := clusters[k]
+		if !exists {
+			c = &rollupCluster{first: r, pattern: &UsagePattern{
+				ID:        uuid.New().String(),
+				Type:      patternType,
+				GroupKey:  k,
+				FirstSeen: r.FirstSeen,
+				LastSeen:  r.LastSeen,
+			}}
+			clusters[k] = c
+			ordered = append(ordered, c)
+		}
+		p := c.pattern
+		p.RequestCount += r.Requests
+		p.TotalCost += r.CostUSD
+		p.TotalTokens += r.Tokens
+		c.latencyMs += r.LatencyMs
+		c.errors += r.Errors
+		if r.FirstSeen.Before(p.FirstSeen) {
+			p.FirstSeen = r.FirstSeen
+		}
+		if r.LastSeen.After(p.LastSeen) {
+			p.LastSeen = r.LastSeen
 		}
 	}
 
-	// Calculate derived metrics
-	patterns := make([]*UsagePattern, 0, len(clusters))
-	for _, pattern := range clusters {
-		pattern.AvgCost = pattern.TotalCost / float64(pattern.RequestCount)
-		pattern.AvgTokens = pattern.TotalTokens / pattern.RequestCount
+	for _, c := range ordered {
+		p := c.pattern
+		p.AvgCost = p.TotalCost / float64(p.RequestCount)
+		p.AvgLatency = float64(c.latencyMs) / float64(p.RequestCount)
 
 		// Calculate request frequency (requests per day)
-		duration := pattern.LastSeen.Sub(pattern.FirstSeen)
+		duration := p.LastSeen.Sub(p.FirstSeen)
 		if duration > 0 {
 			daysSpan := duration.Hours() / 24
 			if daysSpan < 1 {
 				daysSpan = 1
 			}
-			pattern.RequestFrequency = float64(pattern.RequestCount) / daysSpan
+			p.RequestFrequency = float64(p.RequestCount) / daysSpan
 		}
+	}
+	return ordered
+}
+
+// clusterByProviderModel groups requests by provider and model
+func (a *Analyzer) clusterByProviderModel(rollups []*analytics.Rollup, config *AnalysisConfig) []*UsagePattern {
+	clusters := clusterRollups(rollups, "provider-model", func(r *analytics.Rollup) string {
+		return fmt.Sprintf("%s:%s", r.ProviderID, r.ModelName)
+	})
+
+	patterns := make([]*UsagePattern, 0, len(clusters))
+	for _, c := range clusters {
+		pattern := c.pattern
+		pattern.ProviderID, pattern.ModelName = c.first.ProviderID, c.first.ModelName
+		pattern.AvgTokens = pattern.TotalTokens / pattern.RequestCount
+		pattern.ErrorRate = float64(c.errors) / float64(pattern.RequestCount)
 
 		// Filter by minimum thresholds
 		if pattern.RequestCount >= int64(config.MinRequests) && pattern.TotalCost >= config.MinCostUSD {
@@ -181,53 +217,13 @@ func (a *Analyzer) clusterByProviderModel(logs []*analytics.RequestLog, config *
 }
 
 // clusterByUser groups requests by user
-func (a *Analyzer) clusterByUser(logs []*analytics.RequestLog, config *AnalysisConfig) []*UsagePattern {
-	clusters := make(map[string]*UsagePattern)
-
-	for _, log := range logs {
-		key := log.UserID
-
-		if cluster, exists := clusters[key]; exists {
-			cluster.RequestCount++
-			cluster.TotalCost += log.CostUSD
-			cluster.TotalTokens += int64(log.TotalTokens)
-			cluster.AvgLatency = (cluster.AvgLatency*float64(cluster.RequestCount-1) + float64(log.LatencyMs)) / float64(cluster.RequestCount)
-
-			if log.Timestamp.After(cluster.LastSeen) {
-				cluster.LastSeen = log.Timestamp
-			}
-			if log.Timestamp.Before(cluster.FirstSeen) {
-				cluster.FirstSeen = log.Timestamp
-			}
-		} else {
-			clusters[key] = &UsagePattern{
-				ID:           uuid.New().String(),
-				Type:         "user",
-				GroupKey:     key,
-				UserID:       key,
-				RequestCount: 1,
-				TotalCost:    log.CostUSD,
-				TotalTokens:  int64(log.TotalTokens),
-				AvgLatency:   float64(log.LatencyMs),
-				FirstSeen:    log.Timestamp,
-				LastSeen:     log.Timestamp,
-			}
-		}
-	}
+func (a *Analyzer) clusterByUser(rollups []*analytics.Rollup, config *AnalysisConfig) []*UsagePattern {
+	clusters := clusterRollups(rollups, "user", func(r *analytics.Rollup) string { return r.UserID })
 
 	patterns := make([]*UsagePattern, 0, len(clusters))
-	for _, pattern := range clusters {
-		pattern.AvgCost = pattern.TotalCost / float64(pattern.RequestCount)
-
-		duration := pattern.LastSeen.Sub(pattern.FirstSeen)
-		if duration > 0 {
-			daysSpan := duration.Hours() / 24
-			if daysSpan < 1 {
-				daysSpan = 1
-			}
-			pattern.RequestFrequency = float64(pattern.RequestCount) / daysSpan
-		}
-
+	for _, c := range clusters {
+		pattern := c.pattern
+		pattern.UserID = pattern.GroupKey
 		if pattern.RequestCount >= int64(config.MinRequests) {
 			patterns = append(patterns, pattern)
 		}
@@ -237,200 +233,50 @@ func (a *Analyzer) clusterByUser(logs []*analytics.RequestLog, config *AnalysisC
 }
 
 // clusterByCost groups requests by cost bands
-func (a *Analyzer) clusterByCost(logs []*analytics.RequestLog, config *AnalysisConfig) []*UsagePattern {
-	clusters := make(map[string]*UsagePattern)
-
-	getCostBand := func(cost float64) string {
-		switch {
-		case cost < 0.01:
-			return "<$0.01"
-		case cost < 0.10:
-			return "$0.01-$0.10"
-		case cost < 1.00:
-			return "$0.10-$1.00"
-		default:
-			return ">$1.00"
-		}
-	}
-
-	for _, log := range logs {
-		key := getCostBand(log.CostUSD)
-
-		if cluster, exists := clusters[key]; exists {
-			cluster.RequestCount++
-			cluster.TotalCost += log.CostUSD
-			cluster.TotalTokens += int64(log.TotalTokens)
-			cluster.AvgLatency = (cluster.AvgLatency*float64(cluster.RequestCount-1) + float64(log.LatencyMs)) / float64(cluster.RequestCount)
-
-			if log.Timestamp.After(cluster.LastSeen) {
-				cluster.LastSeen = log.Timestamp
-			}
-			if log.Timestamp.Before(cluster.FirstSeen) {
-				cluster.FirstSeen = log.Timestamp
-			}
-		} else {
-			clusters[key] = &UsagePattern{
-				ID:           uuid.New().String(),
-				Type:         "cost-band",
-				GroupKey:     key,
-				CostBand:     key,
-				RequestCount: 1,
-				TotalCost:    log.CostUSD,
-				TotalTokens:  int64(log.TotalTokens),
-				AvgLatency:   float64(log.LatencyMs),
-				FirstSeen:    log.Timestamp,
-				LastSeen:     log.Timestamp,
-			}
-		}
-	}
+func (a *Analyzer) clusterByCost(rollups []*analytics.Rollup, config *AnalysisConfig) []*UsagePattern {
+	clusters := clusterRollups(rollups, "cost-band", func(r *analytics.Rollup) string { return r.CostBand })
 
 	patterns := make([]*UsagePattern, 0, len(clusters))
-	for _, pattern := range clusters {
-		pattern.AvgCost = pattern.TotalCost / float64(pattern.RequestCount)
-
-		duration := pattern.LastSeen.Sub(pattern.FirstSeen)
-		if duration > 0 {
-			daysSpan := duration.Hours() / 24
-			if daysSpan < 1 {
-				daysSpan = 1
-			}
-			pattern.RequestFrequency = float64(pattern.RequestCount) / daysSpan
-		}
-
-		patterns = append(patterns, pattern)
+	for _, c := range clusters {
+		c.pattern.CostBand = c.pattern.GroupKey
+		patterns = append(patterns, c.pattern)
 	}
 
 	return patterns
 }
 
-// clusterByTime groups requests by temporal windows
-func (a *Analyzer) clusterByTime(logs []*analytics.RequestLog, config *AnalysisConfig) []*UsagePattern {
-	clusters := make(map[string]*UsagePattern)
-
-	for _, log := range logs {
-		hour := log.Timestamp.Hour()
-		var timeWindow string
+// clusterByTime groups requests by temporal windows of the day, in UTC
+func (a *Analyzer) clusterByTime(rollups []*analytics.Rollup, config *AnalysisConfig) []*UsagePattern {
+	clusters := clusterRollups(rollups, "temporal", func(r *analytics.Rollup) string {
+		hour := r.Hour.Hour()
 		switch {
 		case hour >= 0 && hour < 6:
-			timeWindow = "00:00-06:00"
+			return "00:00-06:00"
 		case hour >= 6 && hour < 12:
-			timeWindow = "06:00-12:00"
+			return "06:00-12:00"
 		case hour >= 12 && hour < 18:
-			timeWindow = "12:00-18:00"
+			return "12:00-18:00"
 		default:
-			timeWindow = "18:00-00:00"
-		}
-
-		key := timeWindow
-
-		if cluster, exists := clusters[key]; exists {
-			cluster.RequestCount++
-			cluster.TotalCost += log.CostUSD
-			cluster.TotalTokens += int64(log.TotalTokens)
-			cluster.AvgLatency = (cluster.AvgLatency*float64(cluster.RequestCount-1) + float64(log.LatencyMs)) / float64(cluster.RequestCount)
-
-			if log.Timestamp.After(cluster.LastSeen) {
-				cluster.LastSeen = log.Timestamp
-			}
-			if log.Timestamp.Before(cluster.FirstSeen) {
-				cluster.FirstSeen = log.Timestamp
-			}
-		} else {
-			clusters[key] = &UsagePattern{
-				ID:           uuid.New().String(),
-				Type:         "temporal",
-				GroupKey:     key,
-				RequestCount: 1,
-				TotalCost:    log.CostUSD,
-				TotalTokens:  int64(log.TotalTokens),
-				AvgLatency:   float64(log.LatencyMs),
-				FirstSeen:    log.Timestamp,
-				LastSeen:     log.Timestamp,
-			}
+			return "18:00-00:00"
 		}
-	}
+	})
 
 	patterns := make([]*UsagePattern, 0, len(clusters))
-	for _, pattern := range clusters {
-		pattern.AvgCost = pattern.TotalCost / float64(pattern.RequestCount)
-
-		duration := pattern.LastSeen.Sub(pattern.FirstSeen)
-		if duration > 0 {
-			daysSpan := duration.Hours() / 24
-			if daysSpan < 1 {
-				daysSpan = 1
-			}
-			pattern.RequestFrequency = float64(pattern.RequestCount) / daysSpan
-		}
-
-		patterns = append(patterns, pattern)
+	for _, c := range clusters {
+		patterns = append(patterns, c.pattern)
 	}
 
 	return patterns
 }
 
 // clusterByLatency groups requests by latency bands
-func (a *Analyzer) clusterByLatency(logs []*analytics.RequestLog, config *AnalysisConfig) []*UsagePattern {
-	clusters := make(map[string]*UsagePattern)
-
-	getLatencyBand := func(latencyMs int64) string {
-		switch {
-		case latencyMs < 100:
-			return "<100ms"
-		case latencyMs < 500:
-			return "100-500ms"
-		case latencyMs < 2000:
-			return "500-2000ms"
-		default:
-			return ">2000ms"
-		}
-	}
-
-	for _, log := range logs {
-		key := getLatencyBand(log.LatencyMs)
-
-		if cluster, exists := clusters[key]; exists {
-			cluster.RequestCount++
-			cluster.TotalCost += log.CostUSD
-			cluster.TotalTokens += int64(log.TotalTokens)
-			cluster.AvgLatency = (cluster.AvgLatency*float64(cluster.RequestCount-1) + float64(log.LatencyMs)) / float64(cluster.RequestCount)
-
-			if log.Timestamp.After(cluster.LastSeen) {
-				cluster.LastSeen = log.Timestamp
-			}
-			if log.Timestamp.Before(cluster.FirstSeen) {
-				cluster.FirstSeen = log.Timestamp
-			}
-		} else {
-			clusters[key] = &UsagePattern{
-				ID:           uuid.New().String(),
-				Type:         "latency",
-				GroupKey:     key,
-				LatencyBand:  key,
-				RequestCount: 1,
-				TotalCost:    log.CostUSD,
-				TotalTokens:  int64(log.TotalTokens),
-				AvgLatency:   float64(log.LatencyMs),
-				FirstSeen:    log.Timestamp,
-				LastSeen:     log.Timestamp,
-			}
-		}
-	}
+func (a *Analyzer) clusterByLatency(rollups []*analytics.Rollup, config *AnalysisConfig) []*UsagePattern {
+	clusters := clusterRollups(rollups, "latency", func(r *analytics.Rollup) string { return r.LatencyBand })
 
 	patterns := make([]*UsagePattern, 0, len(clusters))
-	for _, pattern := range clusters {
-		pattern.AvgCost = pattern.TotalCost / float64(pattern.RequestCount)
-
-		duration := pattern.LastSeen.Sub(pattern.FirstSeen)
-		if duration > 0 {
-			daysSpan := duration.Hours() / 24
-			if daysSpan < 1 {
-				daysSpan = 1
-			}
-			pattern.RequestFrequency = float64(pattern.RequestCount) / daysSpan
-		}
-
-		patterns = append(patterns, pattern)
+	for _, c := range clusters {
+		c.pattern.LatencyBand = c.pattern.GroupKey
+		patterns = append(patterns, c.pattern)
 	}
 
 	return patterns
@@ -498,17 +344,7 @@ func (a *Analyzer) detectAnomalies(logs []*analytics.RequestLog, config *Analysi
 	for _, log := range logs {
 		deviation := math.Abs(log.CostUSD-costMean) / costStdDev
 		if deviation >= config.AnomalyThreshold {
-			severity := getSeverity(deviation)
-			anomalies = append(anomalies, &PatternAnomaly{
-				ID:          uuid.New().String(),
-				Type:        "cost-spike",
-				Description: fmt.Sprintf("Unusually high cost: $%.4f (%.1f std devs from mean)", log.CostUSD, deviation),
-				Severity:    severity,
-				DetectedAt:  time.Now(),
-				Baseline:    costMean,
-				Actual:      log.CostUSD,
-				Deviation:   deviation,
-			})
+			anomalies = append(anomalies, costSpike(log, costMean, deviation))
 		}
 	}
 
@@ -517,38 +353,134 @@ func (a *Analyzer) detectAnomalies(logs []*analytics.RequestLog, config *Analysi
 	for _, log := range logs {
 		deviation := math.Abs(float64(log.LatencyMs)-latencyMean) / latencyStdDev
 		if deviation >= config.AnomalyThreshold {
-			severity := getSeverity(deviation)
-			anomalies = append(anomalies, &PatternAnomaly{
-				ID:          uuid.New().String(),
-				Type:        "latency-spike",
-				Description: fmt.Sprintf("Unusually high latency: %dms (%.1f std devs from mean)", log.LatencyMs, deviation),
-				Severity:    severity,
-				DetectedAt:  time.Now(),
-				Baseline:    latencyMean,
-				Actual:      float64(log.LatencyMs),
-				Deviation:   deviation,
-			})
+			anomalies = append(anomalies, latencySpike(log, latencyMean, deviation))
 		}
 	}
 
 	// Error rate anomaly
 	errorRate := float64(errorCount) / float64(len(logs))
 	if errorRate > 0.05 { // More than 5% error rate
-		anomalies = append(anomalies, &PatternAnomaly{
-			ID:          uuid.New().String(),
-			Type:        "error-spike",
-			Description: fmt.Sprintf("High error rate: %.1f%%", errorRate*100),
-			Severity:    getSeverity(errorRate * 20), // Scale to severity
-			DetectedAt:  time.Now(),
-			Baseline:    0.01, // Assume 1% baseline
-			Actual:      errorRate,
-			Deviation:   errorRate / 0.01,
-		})
+		anomalies = append(anomalies, errorSpike(errorRate))
 	}
 
 	return anomalies
 }
 
+// detectRollupAnomalies finds the anomalies of a window read from rollups.
+// The baselines come from the rollups' sums, and only requests far enough
+// above them are read, up to maxRollupSpikes of each kind.
+func (a *Analyzer) detectRollupAnomalies(ctx context.Context, rollups []*analytics.Rollup, filter *analytics.LogFilter, config *AnalysisConfig) ([]*PatternAnomaly, error) {
+	var requests, errors, latencyMs int64
+	var cost, costSquares, latencySquares float64
+	for _, r := range rollups {
+		requests += r.Requests
+		errors += r.Errors
+		cost += r.CostUSD
+		costSquares += r.CostSquares
+		latencyMs += r.LatencyMs
+		latencySquares += r.LatencySquares
+	}
+	if requests == 0 {
+		return nil, nil
+	}
+
+	var anomalies []*PatternAnomaly
+
+	// Cost anomalies
+	costMean, costStdDev := rollupStats(requests, cost, costSquares)
+	if costStdDev > 0 {
+		logs, err := a.storage.GetLogs(ctx, &analytics.LogFilter{
+			StartTime:  filter.StartTime,
+			EndTime:    filter.EndTime,
+			MinCostUSD: costMean + config.AnomalyThreshold*costStdDev,
+			Limit:      maxRollupSpikes,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			deviation := math.Abs(log.CostUSD-costMean) / costStdDev
+			if deviation >= config.AnomalyThreshold {
+				anomalies = append(anomalies, costSpike(log, costMean, deviation))
+			}
+		}
+	}
+
+	// Latency anomalies
+	latencyMean, latencyStdDev := rollupStats(requests, float64(latencyMs), latencySquares)
+	if latencyStdDev > 0 {
+		logs, err := a.storage.GetLogs(ctx, &analytics.LogFilter{
+			StartTime:    filter.StartTime,
+			EndTime:      filter.EndTime,
+			MinLatencyMs: int64(math.Ceil(latencyMean + config.AnomalyThreshold*latencyStdDev)),
+			Limit:        maxRollupSpikes,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			deviation := math.Abs(float64(log.LatencyMs)-latencyMean) / latencyStdDev
+			if deviation >= config.AnomalyThreshold {
+				anomalies = append(anomalies, latencySpike(log, latencyMean, deviation))
+			}
+		}
+	}
+
+	// Error rate anomaly
+	errorRate := float64(errors) / float64(requests)
+	if errorRate > 0.05 { // More than 5% error rate
+		anomalies = append(anomalies, errorSpike(errorRate))
+	}
+
+	return anomalies, nil
+}
+
+func costSpike(log *analytics.RequestLog, mean, deviation float64) *PatternAnomaly {
+	return &PatternAnomaly{
+		ID:          uuid.New().String(),
+		Type:        "cost-spike",
+		Description: fmt.Sprintf("Unusually high cost: $%.4f (%.1f std devs from mean)", log.CostUSD, deviation),
+		Severity:    getSeverity(deviation),
+		DetectedAt:  time.Now(),
+		Baseline:    mean,
+		Actual:      log.CostUSD,
+		Deviation:   deviation,
+		OccurredAt:  log.Timestamp,
+		ProviderID:  log.ProviderID,
+		ModelName:   log.ModelName,
+	}
+}
+
+func latencySpike(log *analytics.RequestLog, mean, deviation float64) *PatternAnomaly {
+	return &PatternAnomaly{
+		ID:          uuid.New().String(),
+		Type:        "latency-spike",
+		Description: fmt.Sprintf("Unusually high latency: %dms (%.1f std devs from mean)", log.LatencyMs, deviation),
+		Severity:    getSeverity(deviation),
+		DetectedAt:  time.Now(),
+		Baseline:    mean,
+		Actual:      float64(log.LatencyMs),
+		Deviation:   deviation,
+		OccurredAt:  log.Timestamp,
+		ProviderID:  log.ProviderID,
+		ModelName:   log.ModelName,
+	}
+}
+
+func errorSpike(errorRate float64) *PatternAnomaly {
+	return &PatternAnomaly{
+		ID:          uuid.New().String(),
+		Type:        "error-spike",
+		Description: fmt.Sprintf("High error rate: %.1f%%", errorRate*100),
+		Severity:    getSeverity(errorRate * 20), // Scale to severity
+		DetectedAt:  time.Now(),
+		Baseline:    0.01, // Assume 1% baseline
+		Actual:      errorRate,
+		Deviation:   errorRate / 0.01,
+		OccurredAt:  time.Now(),
+	}
+}
+
 // generateRecommendations creates high-level recommendations
 func (a *Analyzer) generateRecommendations(patterns []*UsagePattern) []string {
 	var recommendations []string
@@ -630,6 +562,17 @@ func calculateStats(values []float64) (mean, stdDev float64) {
 	return mean, stdDev
 }
 
+// rollupStats is calculateStats for n values given their sum and the sum of
+// their squares
+func rollupStats(n int64, sum, squares float64) (mean, stdDev float64) {
+	mean = sum / float64(n)
+	variance := squares/float64(n) - mean*mean
+	if variance < 0 { // Rounding
+		variance = 0
+	}
+	return mean, math.Sqrt(variance)
+}
+
 func getSeverity(deviation float64) string {
 	switch {
 	case deviation >= 4.0:
@@ -2,10 +2,16 @@ package patterns
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/jordanhubbard/loom/internal/analytics"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 // MockStorage is a mock implementation of analytics.Storage for testing
@@ -197,3 +203,78 @@ func TestOptimizerRecommendations(t *testing.T) {
 		t.Error("Expected optimization recommendation for expensive GPT-4 pattern")
 	}
 }
+
+func TestAnalyzerRollupsMatchLogs(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	storage, err := analytics.NewDatabaseStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	for i := 0; i < 60; i++ {
+		log := &analytics.RequestLog{
+			ID:          fmt.Sprintf("log-%d", i),
+			Timestamp:   now.Add(-time.Duration(i) * 97 * time.Minute),
+			UserID:      []string{"alice", "bob", "carol"}[i%3],
+			ProviderID:  []string{"anthropic", "openai"}[i%2],
+			ModelName:   "model",
+			TotalTokens: 1000,
+			LatencyMs:   300 + int64(i%4)*10,
+			CostUSD:     0.02,
+		}
+		if i == 7 {
+			log.CostUSD, log.LatencyMs = 3.0, 9000
+		}
+		if err := storage.SaveLog(ctx, log); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := DefaultAnalysisConfig()
+	config.MinRequests = 1
+	config.MinCostUSD = 0
+	fromRollups, err := NewAnalyzer(storage, config).AnalyzePatterns(ctx, config)
+	if err != nil {
+		t.Fatalf("AnalyzePatterns from rollups: %v", err)
+	}
+	raw := *config
+	raw.RollupWindow = 0
+	fromLogs, err := NewAnalyzer(storage, &raw).AnalyzePatterns(ctx, &raw)
+	if err != nil {
+		t.Fatalf("AnalyzePatterns from logs: %v", err)
+	}
+
+	if fromRollups.TotalRequests != 60 || fromRollups.TotalRequests != fromLogs.TotalRequests {
+		t.Errorf("total requests = %d from rollups, %d from logs", fromRollups.TotalRequests, fromLogs.TotalRequests)
+	}
+	if math.Abs(fromRollups.TotalCost-fromLogs.TotalCost) > 1e-9 {
+		t.Errorf("total cost = %f from rollups, %f from logs", fromRollups.TotalCost, fromLogs.TotalCost)
+	}
+	patterns := func(r *PatternReport) map[string]string {
+		m := map[string]string{}
+		for _, p := range r.Patterns {
+			m[p.Type+"/"+p.GroupKey] = fmt.Sprintf("%d/%.4f/%.1f/%.1f", p.RequestCount, p.TotalCost, p.AvgLatency, p.RequestFrequency)
+		}
+		return m
+	}
+	if got, want := patterns(fromRollups), patterns(fromLogs); !reflect.DeepEqual(got, want) {
+		t.Errorf("patterns from rollups = %v\nfrom logs = %v", got, want)
+	}
+	anomalies := func(r *PatternReport) []string {
+		var s []string
+		for _, a := range r.Anomalies {
+			s = append(s, a.Description)
+		}
+		sort.Strings(s)
+		return s
+	}
+	if got, want := anomalies(fromRollups), anomalies(fromLogs); len(want) == 0 || !reflect.DeepEqual(got, want) {
+		t.Errorf("anomalies from rollups = %v, from logs = %v", got, want)
+	}
+}
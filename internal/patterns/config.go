@@ -0,0 +1,53 @@
+package patterns
+
+import (
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Defaults for settings left empty in the patterns config
+const (
+	DefaultSnapshotInterval    = 24 * time.Hour
+	DefaultComparePeriod       = 7 * 24 * time.Hour
+	DefaultReportRetentionDays = 90
+	DefaultAlertInterval       = 15 * time.Minute
+	DefaultAlertMinSeverity    = "high"
+	DefaultAlertDedupWindow    = time.Hour
+	DefaultAlertProjectID      = "loom-self"
+)
+
+// WithDefaults fills in unset report history and alert settings
+func WithDefaults(cfg config.PatternsConfig) config.PatternsConfig {
+	if cfg.SnapshotInterval == 0 {
+		cfg.SnapshotInterval = DefaultSnapshotInterval
+	}
+	if cfg.ComparePeriod <= 0 {
+		cfg.ComparePeriod = DefaultComparePeriod
+	}
+	if cfg.ReportRetentionDays == 0 {
+		cfg.ReportRetentionDays = DefaultReportRetentionDays
+	}
+	if cfg.AlertInterval == 0 {
+		cfg.AlertInterval = DefaultAlertInterval
+	}
+	if cfg.AlertMinSeverity == "" {
+		cfg.AlertMinSeverity = DefaultAlertMinSeverity
+	}
+	if cfg.AlertDedupWindow <= 0 {
+		cfg.AlertDedupWindow = DefaultAlertDedupWindow
+	}
+	if cfg.AlertProjectID == "" {
+		cfg.AlertProjectID = DefaultAlertProjectID
+	}
+	return cfg
+}
+
+// ReportRetention converts the configured retention to a duration; zero
+// keeps reports forever
+func ReportRetention(cfg config.PatternsConfig) time.Duration {
+	if cfg.ReportRetentionDays <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.ReportRetentionDays) * 24 * time.Hour
+}
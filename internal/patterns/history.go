@@ -0,0 +1,388 @@
+package patterns
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// trendThreshold is the relative cost change below which a pattern counts as flat
+const trendThreshold = 0.10
+
+// StoredReport is a pattern report kept for later comparison, with the trends
+// computed against its baseline when it was taken
+type StoredReport struct {
+	ID     string         `json:"id"`
+	Report *PatternReport `json:"report"`
+	Trends *TrendReport   `json:"trends,omitempty"`
+}
+
+// ReportSummary describes a stored report without its patterns
+type ReportSummary struct {
+	ID            string    `json:"id"`
+	AnalyzedAt    time.Time `json:"analyzed_at"`
+	TotalRequests int64     `json:"total_requests"`
+	TotalCost     float64   `json:"total_cost"`
+	PatternCount  int       `json:"pattern_count"`
+	AnomalyCount  int       `json:"anomaly_count"`
+}
+
+// TrendReport compares a report against an earlier baseline
+type TrendReport struct {
+	BaselineID      string          `json:"baseline_id"`
+	BaselineAt      time.Time       `json:"baseline_at"`
+	CurrentAt       time.Time       `json:"current_at"`
+	TotalCostDelta  float64         `json:"total_cost_delta"`
+	TotalCostChange float64         `json:"total_cost_change"` // fraction of baseline, 0 when the baseline was 0
+	Patterns        []*PatternTrend `json:"patterns"`
+	NewAnomalyTypes []string        `json:"new_anomaly_types,omitempty"`
+	Recommendations []string        `json:"recommendations,omitempty"`
+}
+
+// PatternTrend is one pattern's cost movement between two reports
+type PatternTrend struct {
+	Type         string  `json:"type"`
+	GroupKey     string  `json:"group_key"`
+	Direction    string  `json:"direction"` // "up", "down", "flat", "new", "gone"
+	PreviousCost float64 `json:"previous_cost"`
+	CurrentCost  float64 `json:"current_cost"`
+	CostDelta    float64 `json:"cost_delta"`
+	CostChange   float64 `json:"cost_change"` // fraction of previous cost
+}
+
+// ReportStore persists pattern reports
+type ReportStore interface {
+	SaveReport(ctx context.Context, report *StoredReport) error
+	GetReport(ctx context.Context, id string) (*StoredReport, error)
+	ListReports(ctx context.Context, limit int) ([]*ReportSummary, error)
+	// LatestReportBefore returns the newest report analyzed at or before t,
+	// or nil if there is none
+	LatestReportBefore(ctx context.Context, t time.Time) (*StoredReport, error)
+	DeleteReportsBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+// DatabaseReportStore keeps reports as JSON in the pattern_reports table
+type DatabaseReportStore struct {
+	db *sql.DB
+}
+
+// NewDatabaseReportStore creates a report store, creating its table if needed
+func NewDatabaseReportStore(db *sql.DB) (*DatabaseReportStore, error) {
+	s := &DatabaseReportStore{db: db}
+	if err := s.initSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize pattern reports: %w", err)
+	}
+	return s, nil
+}
+
+func (s *DatabaseReportStore) initSchema() error {
+	_, err := s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS pattern_reports (
+		id TEXT PRIMARY KEY,
+		analyzed_at DATETIME NOT NULL,
+		total_requests INTEGER NOT NULL,
+		total_cost REAL NOT NULL,
+		pattern_count INTEGER NOT NULL,
+		anomaly_count INTEGER NOT NULL,
+		report_json TEXT NOT NULL,
+		trends_json TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_pattern_reports_analyzed_at ON pattern_reports(analyzed_at);
+	`)
+	return err
+}
+
+// SaveReport stores a report
+func (s *DatabaseReportStore) SaveReport(ctx context.Context, stored *StoredReport) error {
+	reportJSON, err := json.Marshal(stored.Report)
+	if err != nil {
+		return err
+	}
+	var trendsJSON []byte
+	if stored.Trends != nil {
+		if trendsJSON, err = json.Marshal(stored.Trends); err != nil {
+			return err
+		}
+	}
+	r := stored.Report
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO pattern_reports (id, analyzed_at, total_requests, total_cost, pattern_count, anomaly_count, report_json, trends_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		stored.ID, r.AnalyzedAt.UTC(), r.TotalRequests, r.TotalCost, len(r.Patterns), len(r.Anomalies),
+		string(reportJSON), string(trendsJSON))
+	return err
+}
+
+// GetReport returns a stored report, or nil if it does not exist
+func (s *DatabaseReportStore) GetReport(ctx context.Context, id string) (*StoredReport, error) {
+	return s.scanReport(s.db.QueryRowContext(ctx,
+		`SELECT id, report_json, trends_json FROM pattern_reports WHERE id = ?`, id))
+}
+
+// LatestReportBefore returns the newest report analyzed at or before t
+func (s *DatabaseReportStore) LatestReportBefore(ctx context.Context, t time.Time) (*StoredReport, error) {
+	return s.scanReport(s.db.QueryRowContext(ctx,
+		`SELECT id, report_json, trends_json FROM pattern_reports WHERE analyzed_at <= ? ORDER BY analyzed_at DESC LIMIT 1`, t.UTC()))
+}
+
+func (s *DatabaseReportStore) scanReport(row *sql.Row) (*StoredReport, error) {
+	var id, reportJSON string
+	var trendsJSON sql.NullString
+	if err := row.Scan(&id, &reportJSON, &trendsJSON); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	stored := &StoredReport{ID: id}
+	if err := json.Unmarshal([]byte(reportJSON), &stored.Report); err != nil {
+		return nil, fmt.Errorf("failed to decode report %s: %w", id, err)
+	}
+	if trendsJSON.String != "" {
+		if err := json.Unmarshal([]byte(trendsJSON.String), &stored.Trends); err != nil {
+			return nil, fmt.Errorf("failed to decode trends for report %s: %w", id, err)
+		}
+	}
+	return stored, nil
+}
+
+// ListReports returns report summaries, newest first
+func (s *DatabaseReportStore) ListReports(ctx context.Context, limit int) ([]*ReportSummary, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, analyzed_at, total_requests, total_cost, pattern_count, anomaly_count
+		FROM pattern_reports ORDER BY analyzed_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []*ReportSummary
+	for rows.Next() {
+		sum := &ReportSummary{}
+		if err := rows.Scan(&sum.ID, &sum.AnalyzedAt, &sum.TotalRequests, &sum.TotalCost, &sum.PatternCount, &sum.AnomalyCount); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, sum)
+	}
+	return summaries, rows.Err()
+}
+
+// DeleteReportsBefore removes reports analyzed before t
+func (s *DatabaseReportStore) DeleteReportsBefore(ctx context.Context, t time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM pattern_reports WHERE analyzed_at < ?`, t.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CompareReports computes how cost per pattern and the anomaly mix moved
+// from baseline to current. Temporal patterns are keyed by time window, so
+// they never line up across reports and are left out.
+func CompareReports(baselineID string, baseline, current *PatternReport) *TrendReport {
+	trends := &TrendReport{
+		BaselineID:     baselineID,
+		BaselineAt:     baseline.AnalyzedAt,
+		CurrentAt:      current.AnalyzedAt,
+		TotalCostDelta: current.TotalCost - baseline.TotalCost,
+		Patterns:       []*PatternTrend{},
+	}
+	if baseline.TotalCost > 0 {
+		trends.TotalCostChange = trends.TotalCostDelta / baseline.TotalCost
+	}
+
+	key := func(p *UsagePattern) string { return p.Type + "\x00" + p.GroupKey }
+	previous := map[string]*UsagePattern{}
+	for _, p := range baseline.Patterns {
+		if p.Type != "temporal" {
+			previous[key(p)] = p
+		}
+	}
+	for _, p := range current.Patterns {
+		if p.Type == "temporal" {
+			continue
+		}
+		t := &PatternTrend{Type: p.Type, GroupKey: p.GroupKey, CurrentCost: p.TotalCost}
+		if prev, ok := previous[key(p)]; ok {
+			delete(previous, key(p))
+			t.PreviousCost = prev.TotalCost
+			t.CostDelta = p.TotalCost - prev.TotalCost
+			t.Direction = "flat"
+			if prev.TotalCost > 0 {
+				t.CostChange = t.CostDelta / prev.TotalCost
+				if t.CostChange >= trendThreshold {
+					t.Direction = "up"
+				} else if t.CostChange <= -trendThreshold {
+					t.Direction = "down"
+				}
+			} else if p.TotalCost > 0 {
+				t.Direction = "up"
+			}
+		} else {
+			t.Direction = "new"
+			t.CostDelta = p.TotalCost
+		}
+		trends.Patterns = append(trends.Patterns, t)
+	}
+	for _, p := range previous {
+		trends.Patterns = append(trends.Patterns, &PatternTrend{
+			Type:         p.Type,
+			GroupKey:     p.GroupKey,
+			Direction:    "gone",
+			PreviousCost: p.TotalCost,
+			CostDelta:    -p.TotalCost,
+			CostChange:   -1,
+		})
+	}
+	sort.Slice(trends.Patterns, func(i, j int) bool {
+		return math.Abs(trends.Patterns[i].CostDelta) > math.Abs(trends.Patterns[j].CostDelta)
+	})
+
+	seen := map[string]bool{}
+	for _, a := range baseline.Anomalies {
+		seen[a.Type] = true
+	}
+	for _, a := range current.Anomalies {
+		if !seen[a.Type] {
+			seen[a.Type] = true
+			trends.NewAnomalyTypes = append(trends.NewAnomalyTypes, a.Type)
+		}
+	}
+	sort.Strings(trends.NewAnomalyTypes)
+
+	trends.Recommendations = trendRecommendations(trends)
+	return trends
+}
+
+// trendRecommendations turns the largest movements into advice
+func trendRecommendations(trends *TrendReport) []string {
+	var recs []string
+	for _, t := range trends.Patterns {
+		if len(recs) >= 5 {
+			break
+		}
+		switch {
+		case t.Direction == "up" && t.CostDelta >= 1:
+			recs = append(recs, fmt.Sprintf("Cost for %s %s rose %.0f%% ($%.2f → $%.2f) since %s; review whether the increase is expected",
+				t.Type, t.GroupKey, t.CostChange*100, t.PreviousCost, t.CurrentCost, trends.BaselineAt.Format("2006-01-02")))
+		case t.Direction == "new" && t.CostDelta >= 1:
+			recs = append(recs, fmt.Sprintf("New %s pattern %s cost $%.2f since %s",
+				t.Type, t.GroupKey, t.CurrentCost, trends.BaselineAt.Format("2006-01-02")))
+		}
+	}
+	for _, typ := range trends.NewAnomalyTypes {
+		recs = append(recs, fmt.Sprintf("New anomaly type %s appeared; it was not present in the baseline", typ))
+	}
+	return recs
+}
+
+// SetReportStore enables report history. Without a store, Snapshot and the
+// history methods return an error.
+func (m *Manager) SetReportStore(store ReportStore, comparePeriod, keep time.Duration) {
+	m.reports = store
+	m.comparePeriod = comparePeriod
+	m.keepReports = keep
+}
+
+// SetReportHook registers a function called with every report Snapshot
+// stores
+func (m *Manager) SetReportHook(hook func(*StoredReport)) {
+	m.reportHook = hook
+}
+
+// HasReportStore reports whether report history is enabled
+func (m *Manager) HasReportStore() bool {
+	return m.reports != nil
+}
+
+// Snapshot analyzes patterns, compares the result against the newest stored
+// report at least one compare period old, and stores both. Trend
+// recommendations are added to the report's own recommendations. Reports
+// older than the keep period are pruned.
+func (m *Manager) Snapshot(ctx context.Context) (*StoredReport, error) {
+	if m.reports == nil {
+		return nil, fmt.Errorf("pattern report history not enabled")
+	}
+	report, err := m.analyze(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored := &StoredReport{ID: uuid.New().String(), Report: report}
+
+	baseline, err := m.reports.LatestReportBefore(ctx, report.AnalyzedAt.Add(-m.comparePeriod))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load baseline report: %w", err)
+	}
+	if baseline != nil {
+		stored.Trends = CompareReports(baseline.ID, baseline.Report, report)
+		report.Recommendations = append(report.Recommendations, stored.Trends.Recommendations...)
+	}
+
+	if err := m.reports.SaveReport(ctx, stored); err != nil {
+		return nil, fmt.Errorf("failed to save report: %w", err)
+	}
+	if m.reportHook != nil {
+		m.reportHook(stored)
+	}
+	if m.keepReports > 0 {
+		if _, err := m.reports.DeleteReportsBefore(ctx, report.AnalyzedAt.Add(-m.keepReports)); err != nil {
+			return stored, fmt.Errorf("failed to prune reports: %w", err)
+		}
+	}
+	return stored, nil
+}
+
+// StartSnapshots takes a snapshot every interval until ctx is cancelled
+func (m *Manager) StartSnapshots(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.Snapshot(ctx); err != nil {
+				patternsLog.Error("Snapshot failed", "error", err)
+			}
+		}
+	}
+}
+
+// ListReports returns stored report summaries, newest first
+func (m *Manager) ListReports(ctx context.Context, limit int) ([]*ReportSummary, error) {
+	if m.reports == nil {
+		return nil, fmt.Errorf("pattern report history not enabled")
+	}
+	return m.reports.ListReports(ctx, limit)
+}
+
+// GetReport returns a stored report, or nil if it does not exist
+func (m *Manager) GetReport(ctx context.Context, id string) (*StoredReport, error) {
+	if m.reports == nil {
+		return nil, fmt.Errorf("pattern report history not enabled")
+	}
+	return m.reports.GetReport(ctx, id)
+}
+
+// LatestTrends returns the trends of the newest stored report, or nil if no
+// report has a baseline yet
+func (m *Manager) LatestTrends(ctx context.Context) (*TrendReport, error) {
+	if m.reports == nil {
+		return nil, fmt.Errorf("pattern report history not enabled")
+	}
+	latest, err := m.reports.LatestReportBefore(ctx, time.Now())
+	if err != nil || latest == nil {
+		return nil, err
+	}
+	return latest.Trends, nil
+}
@@ -0,0 +1,124 @@
+package patterns
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestCompareReports(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	baseline := &PatternReport{
+		AnalyzedAt: now.Add(-week),
+		TotalCost:  20,
+		Patterns: []*UsagePattern{
+			{Type: "provider-model", GroupKey: "openai:gpt-4o", TotalCost: 10},
+			{Type: "user", GroupKey: "alice", TotalCost: 5},
+			{Type: "user", GroupKey: "bob", TotalCost: 5},
+			{Type: "temporal", GroupKey: "2026-03-03 00:00", TotalCost: 20},
+		},
+		Anomalies: []*PatternAnomaly{{Type: "cost-spike"}},
+	}
+	current := &PatternReport{
+		AnalyzedAt: now,
+		TotalCost:  30,
+		Patterns: []*UsagePattern{
+			{Type: "provider-model", GroupKey: "openai:gpt-4o", TotalCost: 18},
+			{Type: "user", GroupKey: "alice", TotalCost: 5.2},
+			{Type: "user", GroupKey: "carol", TotalCost: 3},
+			{Type: "temporal", GroupKey: "2026-03-10 00:00", TotalCost: 30},
+		},
+		Anomalies: []*PatternAnomaly{{Type: "cost-spike"}, {Type: "latency-spike"}},
+	}
+
+	trends := CompareReports("base", baseline, current)
+
+	if trends.TotalCostDelta != 10 || trends.TotalCostChange != 0.5 {
+		t.Errorf("unexpected totals: delta %v change %v", trends.TotalCostDelta, trends.TotalCostChange)
+	}
+	got := map[string]string{}
+	for _, p := range trends.Patterns {
+		got[p.GroupKey] = p.Direction
+	}
+	want := map[string]string{"openai:gpt-4o": "up", "alice": "flat", "carol": "new", "bob": "gone"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d pattern trends (temporal excluded), got %v", len(want), got)
+	}
+	for k, dir := range want {
+		if got[k] != dir {
+			t.Errorf("%s: expected %s, got %s", k, dir, got[k])
+		}
+	}
+	if trends.Patterns[0].GroupKey != "openai:gpt-4o" {
+		t.Errorf("expected largest movement first, got %s", trends.Patterns[0].GroupKey)
+	}
+	if len(trends.NewAnomalyTypes) != 1 || trends.NewAnomalyTypes[0] != "latency-spike" {
+		t.Errorf("unexpected new anomaly types: %v", trends.NewAnomalyTypes)
+	}
+	if len(trends.Recommendations) != 3 {
+		t.Errorf("expected recommendations for the rise, the new pattern and the new anomaly, got %v", trends.Recommendations)
+	}
+}
+
+func TestSnapshotStoresReportsWithTrends(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	store, err := NewDatabaseReportStore(db)
+	if err != nil {
+		t.Fatalf("NewDatabaseReportStore failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// A baseline from last week, then a snapshot of the current logs
+	old := &StoredReport{ID: "old", Report: &PatternReport{AnalyzedAt: time.Now().Add(-8 * 24 * time.Hour), TotalCost: 1}}
+	if err := store.SaveReport(ctx, old); err != nil {
+		t.Fatalf("SaveReport failed: %v", err)
+	}
+	ancient := &StoredReport{ID: "ancient", Report: &PatternReport{AnalyzedAt: time.Now().Add(-200 * 24 * time.Hour)}}
+	if err := store.SaveReport(ctx, ancient); err != nil {
+		t.Fatalf("SaveReport failed: %v", err)
+	}
+
+	m := NewManager(&MockStorage{}, nil)
+	if _, err := m.Snapshot(ctx); err == nil {
+		t.Fatal("expected Snapshot to fail without a report store")
+	}
+	m.SetReportStore(store, 7*24*time.Hour, 90*24*time.Hour)
+
+	stored, err := m.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	if stored.Trends == nil || stored.Trends.BaselineID != "old" {
+		t.Fatalf("expected trends against the week-old report, got %+v", stored.Trends)
+	}
+
+	reports, err := m.ListReports(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 || reports[0].ID != stored.ID {
+		t.Fatalf("expected the new report first and the ancient one pruned, got %+v", reports)
+	}
+
+	got, err := m.GetReport(ctx, stored.ID)
+	if err != nil || got == nil || got.Trends == nil {
+		t.Fatalf("GetReport = %+v, %v", got, err)
+	}
+	trends, err := m.LatestTrends(ctx)
+	if err != nil || trends == nil || trends.BaselineID != "old" {
+		t.Fatalf("LatestTrends = %+v, %v", trends, err)
+	}
+	if missing, err := m.GetReport(ctx, "nope"); err != nil || missing != nil {
+		t.Errorf("expected nil for a missing report, got %+v, %v", missing, err)
+	}
+}
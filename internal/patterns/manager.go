@@ -2,6 +2,7 @@ package patterns
 
 import (
 	"context"
+	"time"
 
 	"github.com/jordanhubbard/loom/internal/analytics"
 	"github.com/jordanhubbard/loom/internal/cache"
@@ -15,6 +16,14 @@ type Manager struct {
 	optimizer       *Optimizer
 	promptOptimizer *PromptOptimizer
 	config          *AnalysisConfig
+
+	reports       ReportStore
+	comparePeriod time.Duration
+	keepReports   time.Duration
+	reportHook    func(*StoredReport)
+
+	anomalyHook func([]*PatternAnomaly)
+	stream      *StreamingDetector
 }
 
 // NewManager creates a new pattern manager
@@ -30,13 +39,14 @@ func NewManager(storage analytics.Storage, config *AnalysisConfig) *Manager {
 		optimizer:       NewOptimizer(config),
 		promptOptimizer: NewPromptOptimizer(storage, nil),
 		config:          config,
+		stream:          NewStreamingDetector(config.AnomalyThreshold),
 	}
 }
 
 // AnalyzeAll performs comprehensive analysis across all dimensions
 func (m *Manager) AnalyzeAll(ctx context.Context) (*ComprehensiveReport, error) {
 	// Run pattern analysis
-	patternReport, err := m.patternAnalyzer.AnalyzePatterns(ctx, m.config)
+	patternReport, err := m.analyze(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -80,12 +90,12 @@ func (m *Manager) AnalyzeAll(ctx context.Context) (*ComprehensiveReport, error)
 
 // AnalyzePatterns runs only pattern analysis
 func (m *Manager) AnalyzePatterns(ctx context.Context) (*PatternReport, error) {
-	return m.patternAnalyzer.AnalyzePatterns(ctx, m.config)
+	return m.analyze(ctx)
 }
 
 // GetOptimizations gets optimization recommendations for patterns
 func (m *Manager) GetOptimizations(ctx context.Context) ([]*Optimization, error) {
-	patternReport, err := m.patternAnalyzer.AnalyzePatterns(ctx, m.config)
+	patternReport, err := m.analyze(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -95,7 +105,7 @@ func (m *Manager) GetOptimizations(ctx context.Context) ([]*Optimization, error)
 
 // GetExpensivePatterns returns the most expensive patterns
 func (m *Manager) GetExpensivePatterns(ctx context.Context, limit int) ([]*UsagePattern, error) {
-	patternReport, err := m.patternAnalyzer.AnalyzePatterns(ctx, m.config)
+	patternReport, err := m.analyze(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -110,7 +120,7 @@ func (m *Manager) GetExpensivePatterns(ctx context.Context, limit int) ([]*Usage
 
 // GetAnomalies returns detected anomalies
 func (m *Manager) GetAnomalies(ctx context.Context) ([]*PatternAnomaly, error) {
-	patternReport, err := m.patternAnalyzer.AnalyzePatterns(ctx, m.config)
+	patternReport, err := m.analyze(ctx)
 	if err != nil {
 		return nil, err
 	}
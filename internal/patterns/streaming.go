@@ -0,0 +1,221 @@
+package patterns
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/analytics"
+)
+
+// Online detection tuning
+const (
+	// streamAlpha weights each new request in the fast moving averages;
+	// roughly the last 1/streamAlpha requests dominate, so the baseline
+	// follows regime changes instead of averaging over the whole window
+	streamAlpha = 0.05
+	// streamSlowAlpha tracks the long-run error rate the fast rate is
+	// compared against
+	streamSlowAlpha = 0.005
+	// streamWarmup is how many requests a provider-model needs before it
+	// is evaluated
+	streamWarmup = 20
+	// streamMinRelSD floors the standard deviation at this fraction of the
+	// mean, so a perfectly steady series doesn't flag tiny wobbles
+	streamMinRelSD = 0.05
+	// streamRecent is how many anomalies the detector keeps for the API
+	streamRecent = 200
+)
+
+// ewma is an exponentially weighted mean and variance
+type ewma struct {
+	mean, variance float64
+}
+
+func (e *ewma) update(x, alpha float64) {
+	diff := x - e.mean
+	incr := alpha * diff
+	e.mean += incr
+	e.variance = (1 - alpha) * (e.variance + diff*incr)
+}
+
+// deviation returns how many floored standard deviations x is above the mean
+func (e *ewma) deviation(x float64) float64 {
+	sd := math.Max(math.Sqrt(e.variance), streamMinRelSD*math.Abs(e.mean))
+	if sd == 0 {
+		return 0
+	}
+	return (x - e.mean) / sd
+}
+
+// streamState is the running baseline of one provider-model
+type streamState struct {
+	count      int64
+	cost       ewma
+	latency    ewma
+	errorFast  float64
+	errorSlow  float64
+	errorSpike bool // an error spike is in progress; cleared once the rate recovers
+	lastSeen   time.Time
+}
+
+// StreamStats is the current baseline of one provider-model
+type StreamStats struct {
+	ProviderID    string    `json:"provider_id"`
+	ModelName     string    `json:"model_name"`
+	Requests      int64     `json:"requests"`
+	MeanCost      float64   `json:"mean_cost"`
+	MeanLatencyMs float64   `json:"mean_latency_ms"`
+	ErrorRate     float64   `json:"error_rate"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// StreamingDetector flags anomalies as request logs arrive. Each
+// provider-model keeps exponentially weighted baselines of cost, latency and
+// error rate, so evaluating a request is O(1) and a spike is flagged as soon
+// as it is logged. Cost and latency spikes are measured against the
+// baseline before the request is folded in; an error spike is raised once
+// when the recent error rate climbs well above the long-run rate and again
+// only after it has recovered.
+type StreamingDetector struct {
+	threshold float64
+
+	mu     sync.Mutex
+	states map[string]*streamState
+	recent []*PatternAnomaly
+}
+
+// NewStreamingDetector creates a detector flagging deviations of at least
+// threshold standard deviations
+func NewStreamingDetector(threshold float64) *StreamingDetector {
+	if threshold <= 0 {
+		threshold = 3
+	}
+	return &StreamingDetector{
+		threshold: threshold,
+		states:    make(map[string]*streamState),
+	}
+}
+
+// Observe folds a request into its provider-model baseline and reports any
+// anomalies it represents
+func (d *StreamingDetector) Observe(log *analytics.RequestLog) []*PatternAnomaly {
+	d.mu.Lock()
+	key := log.ProviderID + "|" + log.ModelName
+	st, ok := d.states[key]
+	if !ok {
+		st = &streamState{}
+		d.states[key] = st
+	}
+
+	var found []*PatternAnomaly
+	flag := func(typ, desc string, baseline, actual, deviation float64, severity string) {
+		found = append(found, &PatternAnomaly{
+			ID:          uuid.New().String(),
+			Type:        typ,
+			Description: desc,
+			Severity:    severity,
+			DetectedAt:  time.Now(),
+			Baseline:    baseline,
+			Actual:      actual,
+			Deviation:   deviation,
+			OccurredAt:  log.Timestamp,
+			ProviderID:  log.ProviderID,
+			ModelName:   log.ModelName,
+		})
+	}
+
+	failed := 0.0
+	if log.ErrorMessage != "" || log.StatusCode >= 500 {
+		failed = 1
+	}
+	latency := float64(log.LatencyMs)
+
+	if st.count >= streamWarmup {
+		if dev := st.cost.deviation(log.CostUSD); dev >= d.threshold {
+			flag("cost-spike", fmt.Sprintf("Cost $%.4f on %s/%s is %.1f std devs above its recent mean of $%.4f",
+				log.CostUSD, log.ProviderID, log.ModelName, dev, st.cost.mean), st.cost.mean, log.CostUSD, dev, getSeverity(dev))
+		}
+		if dev := st.latency.deviation(latency); dev >= d.threshold {
+			flag("latency-spike", fmt.Sprintf("Latency %dms on %s/%s is %.1f std devs above its recent mean of %.0fms",
+				log.LatencyMs, log.ProviderID, log.ModelName, dev, st.latency.mean), st.latency.mean, latency, dev, getSeverity(dev))
+		}
+	}
+
+	if st.count == 0 {
+		// Start from the first request rather than zero, which would
+		// inflate the variance for a long time
+		st.cost.mean = log.CostUSD
+		st.latency.mean = latency
+	}
+	st.count++
+	st.lastSeen = log.Timestamp
+	st.cost.update(log.CostUSD, streamAlpha)
+	st.latency.update(latency, streamAlpha)
+	st.errorFast += streamAlpha * (failed - st.errorFast)
+	st.errorSlow += streamSlowAlpha * (failed - st.errorSlow)
+
+	if st.count >= streamWarmup {
+		limit := math.Max(0.05, 3*st.errorSlow)
+		switch {
+		case !st.errorSpike && st.errorFast > limit:
+			st.errorSpike = true
+			ratio := st.errorFast / math.Max(st.errorSlow, 0.01)
+			flag("error-spike", fmt.Sprintf("Error rate on %s/%s climbed to %.1f%% (long-run %.1f%%)",
+				log.ProviderID, log.ModelName, st.errorFast*100, st.errorSlow*100), st.errorSlow, st.errorFast, ratio, getSeverity(st.errorFast*20))
+		case st.errorSpike && st.errorFast < limit/2:
+			st.errorSpike = false
+		}
+	}
+
+	d.recent = append(d.recent, found...)
+	if over := len(d.recent) - streamRecent; over > 0 {
+		d.recent = append([]*PatternAnomaly(nil), d.recent[over:]...)
+	}
+	d.mu.Unlock()
+	return found
+}
+
+// Recent returns up to limit of the latest anomalies, newest first
+func (d *StreamingDetector) Recent(limit int) []*PatternAnomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if limit <= 0 || limit > len(d.recent) {
+		limit = len(d.recent)
+	}
+	out := make([]*PatternAnomaly, 0, limit)
+	for i := len(d.recent) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, d.recent[i])
+	}
+	return out
+}
+
+// Stats returns the current baseline of every provider-model seen
+func (d *StreamingDetector) Stats() []*StreamStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]*StreamStats, 0, len(d.states))
+	for key, st := range d.states {
+		provider, model, _ := strings.Cut(key, "|")
+		out = append(out, &StreamStats{
+			ProviderID:    provider,
+			ModelName:     model,
+			Requests:      st.count,
+			MeanCost:      st.cost.mean,
+			MeanLatencyMs: st.latency.mean,
+			ErrorRate:     st.errorFast,
+			LastSeen:      st.lastSeen,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ProviderID != out[j].ProviderID {
+			return out[i].ProviderID < out[j].ProviderID
+		}
+		return out[i].ModelName < out[j].ModelName
+	})
+	return out
+}
@@ -0,0 +1,141 @@
+package patterns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+)
+
+func streamLog(cost float64, latencyMs int64, failed bool) *analytics.RequestLog {
+	l := &analytics.RequestLog{
+		Timestamp:  time.Now(),
+		ProviderID: "openai",
+		ModelName:  "gpt-4o",
+		CostUSD:    cost,
+		LatencyMs:  latencyMs,
+		StatusCode: 200,
+	}
+	if failed {
+		l.StatusCode = 502
+	}
+	return l
+}
+
+func TestStreamingDetectorFlagsCostSpike(t *testing.T) {
+	d := NewStreamingDetector(3)
+
+	// Nothing is flagged while the baseline warms up, however odd
+	for _, cost := range []float64{0.01, 5, 0.01} {
+		if got := d.Observe(streamLog(cost, 100, false)); len(got) != 0 {
+			t.Fatalf("expected no anomalies during warmup, got %+v", got)
+		}
+	}
+	d = NewStreamingDetector(3)
+	for i := 0; i < 41; i++ {
+		cost := 0.01 + float64(i%3)*0.001
+		if got := d.Observe(streamLog(cost, 100, false)); len(got) != 0 {
+			t.Fatalf("request %d: unexpected anomalies %+v", i, got)
+		}
+	}
+
+	got := d.Observe(streamLog(1.5, 100, false))
+	if len(got) != 1 || got[0].Type != "cost-spike" {
+		t.Fatalf("expected a cost spike, got %+v", got)
+	}
+	if got[0].ProviderID != "openai" || got[0].ModelName != "gpt-4o" || got[0].Severity != "critical" {
+		t.Errorf("unexpected anomaly %+v", got[0])
+	}
+	if recent := d.Recent(10); len(recent) == 0 || recent[0].ID != got[0].ID {
+		t.Errorf("expected the spike to be the most recent anomaly, got %+v", recent)
+	}
+	stats := d.Stats()
+	if len(stats) != 1 || stats[0].Requests != 42 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestStreamingDetectorAdaptsToRegimeChange(t *testing.T) {
+	d := NewStreamingDetector(3)
+	for i := 0; i < 100; i++ {
+		d.Observe(streamLog(0.01, 100+int64(i%5), false))
+	}
+
+	// Latency moves to a new level: flagged at first, then the baseline
+	// follows and the new level stops being anomalous
+	flagged := 0
+	for i := 0; i < 200; i++ {
+		for _, a := range d.Observe(streamLog(0.01, 400+int64(i%5), false)) {
+			if a.Type == "latency-spike" {
+				flagged++
+			}
+		}
+	}
+	if flagged == 0 {
+		t.Fatal("expected the shift to be flagged")
+	}
+	if flagged > 20 {
+		t.Errorf("expected the baseline to adapt, still flagged %d times", flagged)
+	}
+	if got := d.Observe(streamLog(0.01, 402, false)); len(got) != 0 {
+		t.Errorf("expected the new level to be normal, got %+v", got)
+	}
+}
+
+func TestStreamingDetectorErrorSpikeFiresOnce(t *testing.T) {
+	d := NewStreamingDetector(3)
+	for i := 0; i < 100; i++ {
+		d.Observe(streamLog(0.01, 100, false))
+	}
+
+	spikes := 0
+	for i := 0; i < 30; i++ {
+		for _, a := range d.Observe(streamLog(0.01, 100, i%2 == 0)) {
+			if a.Type == "error-spike" {
+				spikes++
+			}
+		}
+	}
+	if spikes != 1 {
+		t.Fatalf("expected one error spike while errors persist, got %d", spikes)
+	}
+
+	// Once the rate recovers a new burst is reported again
+	for i := 0; i < 100; i++ {
+		d.Observe(streamLog(0.01, 100, false))
+	}
+	spikes = 0
+	for i := 0; i < 30; i++ {
+		for _, a := range d.Observe(streamLog(0.01, 100, true)) {
+			if a.Type == "error-spike" {
+				spikes++
+			}
+		}
+	}
+	if spikes != 1 {
+		t.Fatalf("expected a second error spike after recovery, got %d", spikes)
+	}
+}
+
+func TestManagerObserveFiresAnomalyHook(t *testing.T) {
+	m := NewManager(&MockStorage{}, nil)
+	seen := make(chan *PatternAnomaly, 1)
+	m.SetAnomalyHook(func(a []*PatternAnomaly) { seen <- a[0] })
+
+	for i := 0; i < 30; i++ {
+		m.Observe(streamLog(0.01, 100, false))
+	}
+	m.Observe(streamLog(2, 100, false))
+
+	select {
+	case a := <-seen:
+		if a.Type != "cost-spike" {
+			t.Errorf("expected a cost spike, got %+v", a)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the hook to be called")
+	}
+	if len(m.LiveAnomalies(0)) != 1 {
+		t.Errorf("expected one live anomaly, got %+v", m.LiveAnomalies(0))
+	}
+}
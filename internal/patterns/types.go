@@ -49,7 +49,10 @@ type PatternAnomaly struct {
 	Pattern     *UsagePattern `json:"pattern,omitempty"`
 	Baseline    float64       `json:"baseline"`
 	Actual      float64       `json:"actual"`
-	Deviation   float64       `json:"deviation"` // standard deviations from baseline
+	Deviation   float64       `json:"deviation"`             // standard deviations from baseline
+	OccurredAt  time.Time     `json:"occurred_at,omitempty"` // when the anomalous request was made
+	ProviderID  string        `json:"provider_id,omitempty"`
+	ModelName   string        `json:"model_name,omitempty"`
 }
 
 // PatternReport contains the results of pattern analysis
@@ -75,6 +78,7 @@ type AnalysisConfig struct {
 	EnableSubstitutions bool          `json:"enable_substitutions"`
 	EnableRateLimiting  bool          `json:"enable_rate_limiting"`
 	RateLimitThreshold  float64       `json:"rate_limit_threshold"` // Requests per day
+	RollupWindow        time.Duration `json:"rollup_window"`        // Windows at least this long read hourly rollups; 0 always reads logs
 }
 
 // DefaultAnalysisConfig returns default configuration
@@ -89,6 +93,7 @@ func DefaultAnalysisConfig() *AnalysisConfig {
 		EnableSubstitutions: true,
 		EnableRateLimiting:  true,
 		RateLimitThreshold:  1000, // 1000 req/day
+		RollupWindow:        24 * time.Hour,
 	}
 }
 
@@ -8,10 +8,13 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/pkg/plugin"
 	"gopkg.in/yaml.v3"
 )
 
+var pluginLog = logging.Module("plugin")
+
 // Loader manages loading and registration of plugins.
 type Loader struct {
 	pluginsDir string
@@ -101,7 +104,7 @@ func (l *Loader) DiscoverPlugins(ctx context.Context) ([]*PluginManifest, error)
 		manifest, err := l.loadManifest(path)
 		if err != nil {
 			// Log error but continue discovery
-			fmt.Fprintf(os.Stderr, "[WARN] Failed to load plugin manifest %s: %v\n", path, err)
+			pluginLog.Warn("Failed to load plugin manifest", "path", path, "error", err)
 			return nil
 		}
 
@@ -260,8 +263,7 @@ func (l *Loader) LoadAll(ctx context.Context) (int, error) {
 		}
 
 		if err := l.LoadPlugin(ctx, manifest); err != nil {
-			fmt.Fprintf(os.Stderr, "[ERROR] Failed to load plugin %s: %v\n",
-				manifest.Metadata.Name, err)
+			pluginLog.Error("Failed to load plugin", "plugin", manifest.Metadata.Name, "error", err)
 			continue
 		}
 
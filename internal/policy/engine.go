@@ -0,0 +1,210 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jordanhubbard/loom/internal/logging"
+)
+
+// DefaultReloadInterval is how often the policy directory is checked for
+// changes when no interval is configured
+const DefaultReloadInterval = 30 * time.Second
+
+var policyLog = logging.Module("policy")
+
+// Engine holds the policies of a directory, re-reading them when their
+// files change. A reload that fails keeps the previous policies in force,
+// so a half-edited file never leaves actions unguarded. A nil *Engine
+// allows everything.
+type Engine struct {
+	dir string
+
+	mu       sync.RWMutex
+	global   *Policy
+	projects map[string]*Policy
+	modTimes map[string]time.Time
+	loadedAt time.Time
+}
+
+// NewEngine loads the policies in dir
+func NewEngine(dir string) (*Engine, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("policy directory is required")
+	}
+	e := &Engine{dir: dir, projects: make(map[string]*Policy)}
+	if _, err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// policyFiles returns the YAML files in the directory with their
+// modification times
+func (e *Engine) policyFiles() (map[string]time.Time, error) {
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	files := make(map[string]time.Time)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("policy: %w", err)
+		}
+		files[filepath.Join(e.dir, entry.Name())] = info.ModTime()
+	}
+	return files, nil
+}
+
+// Reload re-reads the policies if any file was added, changed or removed
+// since the last load, and reports whether it did
+func (e *Engine) Reload() (bool, error) {
+	modTimes, err := e.policyFiles()
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.RLock()
+	changed := e.loadedAt.IsZero() || len(modTimes) != len(e.modTimes)
+	for f, t := range modTimes {
+		if prev, ok := e.modTimes[f]; !ok || !prev.Equal(t) {
+			changed = true
+		}
+	}
+	e.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	var global *Policy
+	projects := make(map[string]*Policy)
+	for f := range modTimes {
+		p, err := loadPolicy(f)
+		if err != nil {
+			return false, err
+		}
+		if p.Scope == GlobalScope {
+			global = p
+		} else {
+			projects[p.Scope] = p
+		}
+	}
+
+	e.mu.Lock()
+	e.global = global
+	e.projects = projects
+	e.modTimes = modTimes
+	e.loadedAt = time.Now()
+	e.mu.Unlock()
+	return true, nil
+}
+
+// loadPolicy reads one policy file; its name without the extension is the
+// scope
+func loadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	p := &Policy{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("policy %s: %w", file, err)
+	}
+	if err := p.compile(); err != nil {
+		return nil, fmt.Errorf("policy %s: %w", file, err)
+	}
+	p.File = file
+	p.Scope = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	return p, nil
+}
+
+// Watch checks the directory every interval until ctx is cancelled
+func (e *Engine) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if reloaded, err := e.Reload(); err != nil {
+				policyLog.Error("Policy reload failed, keeping the current policies", "error", err)
+			} else if reloaded {
+				policyLog.Info("Reloaded policies", "dir", e.dir)
+			}
+		}
+	}
+}
+
+// Evaluate decides an input against the global policy and the project's
+// policy, returning the stricter decision. Inputs no policy covers are
+// allowed.
+func (e *Engine) Evaluate(in Input) Decision {
+	if e == nil {
+		return Decision{Effect: Allow, Reason: "no policies configured"}
+	}
+	if in.Time.IsZero() {
+		in.Time = time.Now()
+	}
+	e.mu.RLock()
+	global, project := e.global, e.projects[in.ProjectID]
+	e.mu.RUnlock()
+
+	switch {
+	case global != nil && project != nil:
+		return stricter(global.decide(in), project.decide(in))
+	case global != nil:
+		return global.decide(in)
+	case project != nil:
+		return project.decide(in)
+	}
+	return Decision{Effect: Allow, Reason: "no policy applies"}
+}
+
+// Policies returns the loaded policies, global first, then projects by ID
+func (e *Engine) Policies() []*Policy {
+	if e == nil {
+		return nil
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var policies []*Policy
+	if e.global != nil {
+		policies = append(policies, e.global)
+	}
+	ids := make([]string, 0, len(e.projects))
+	for id := range e.projects {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		policies = append(policies, e.projects[id])
+	}
+	return policies
+}
+
+// LoadedAt returns when the policies were last (re)loaded
+func (e *Engine) LoadedAt() time.Time {
+	if e == nil {
+		return time.Time{}
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.loadedAt
+}
@@ -0,0 +1,261 @@
+// Package policy decides whether agent actions may run. Policies are YAML
+// rule lists kept in a directory: global.yaml applies to every project and
+// <project_id>.yaml to one project. The files are re-read when they change,
+// so policies can be tightened or relaxed without a restart.
+//
+// Within a file the first matching rule decides, and the file's default
+// applies when none match. When both a global and a project policy exist,
+// the stricter of their decisions wins, so a project policy can add
+// restrictions but never lift global ones.
+package policy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Effect is what a policy decides for an action
+type Effect string
+
+const (
+	Allow           Effect = "allow"
+	Deny            Effect = "deny"
+	RequireApproval Effect = "require_approval"
+)
+
+// strictness orders effects so the stricter of two decisions can be picked
+var strictness = map[Effect]int{Allow: 0, RequireApproval: 1, Deny: 2}
+
+// GlobalScope is the scope of decisions made by global.yaml
+const GlobalScope = "global"
+
+// Input is what a policy decides on
+type Input struct {
+	ProjectID  string    `json:"project_id"`
+	AgentID    string    `json:"agent_id,omitempty"`
+	BeadID     string    `json:"bead_id,omitempty"`
+	Role       string    `json:"role,omitempty"`
+	ActionType string    `json:"action_type"`
+	Paths      []string  `json:"paths,omitempty"`   // Files the action reads or changes
+	Command    string    `json:"command,omitempty"` // Shell command or session input
+	Time       time.Time `json:"time"`
+	CostUSD    float64   `json:"cost_usd"` // The agent's provider spend today
+}
+
+// Rule matches an input when every condition it sets holds; unset
+// conditions match anything
+type Rule struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description" json:"description,omitempty"`
+	Effect      Effect   `yaml:"effect" json:"effect"`
+	Roles       []string `yaml:"roles" json:"roles,omitempty"`
+	Actions     []string `yaml:"actions" json:"actions,omitempty"`
+	Paths       []string `yaml:"paths" json:"paths,omitempty"`       // Globs matched against any touched path; ** spans directories
+	Commands    []string `yaml:"commands" json:"commands,omitempty"` // Regular expressions searched in the command
+	Hours       string   `yaml:"hours" json:"hours,omitempty"`       // Server-local "HH:MM-HH:MM"; may wrap midnight
+	Days        []string `yaml:"days" json:"days,omitempty"`         // mon, tue, ... sun
+	MinCostUSD  float64  `yaml:"min_cost_usd" json:"min_cost_usd,omitempty"`
+
+	commands []*regexp.Regexp
+	from, to int // Hours as minutes since midnight
+	days     map[time.Weekday]bool
+}
+
+// Policy is the rules of one file
+type Policy struct {
+	Scope   string `yaml:"-" json:"scope"` // GlobalScope or the project ID
+	File    string `yaml:"-" json:"file"`
+	Default Effect `yaml:"default" json:"default"` // Default allow
+	Rules   []Rule `yaml:"rules" json:"rules"`
+}
+
+// Decision is the outcome of evaluating an input
+type Decision struct {
+	Effect Effect `json:"effect"`
+	Scope  string `json:"scope,omitempty"` // Policy that decided; empty when none applies
+	Rule   string `json:"rule,omitempty"`  // Matching rule; empty for a policy default
+	Reason string `json:"reason"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// compile validates the policy and prepares its rules for matching
+func (p *Policy) compile() error {
+	if p.Default == "" {
+		p.Default = Allow
+	}
+	if _, ok := strictness[p.Default]; !ok {
+		return fmt.Errorf("unknown default effect %q", p.Default)
+	}
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if _, ok := strictness[rule.Effect]; !ok {
+			return fmt.Errorf("rule %s: unknown effect %q (want allow, deny or require_approval)", rule.Name, rule.Effect)
+		}
+		for _, expr := range rule.Commands {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("rule %s: invalid command pattern: %w", rule.Name, err)
+			}
+			rule.commands = append(rule.commands, re)
+		}
+		for _, glob := range rule.Paths {
+			if _, err := path.Match(strings.ReplaceAll(glob, "**", "*"), ""); err != nil {
+				return fmt.Errorf("rule %s: invalid path glob %q", rule.Name, glob)
+			}
+		}
+		if rule.Hours != "" {
+			from, to, err := parseHours(rule.Hours)
+			if err != nil {
+				return fmt.Errorf("rule %s: %w", rule.Name, err)
+			}
+			rule.from, rule.to = from, to
+		}
+		if len(rule.Days) > 0 {
+			rule.days = make(map[time.Weekday]bool, len(rule.Days))
+			for _, day := range rule.Days {
+				wd, ok := weekdays[strings.ToLower(day)[:min(3, len(day))]]
+				if !ok {
+					return fmt.Errorf("rule %s: unknown day %q", rule.Name, day)
+				}
+				rule.days[wd] = true
+			}
+		}
+	}
+	return nil
+}
+
+// decide returns the decision of the first matching rule, or the default
+func (p *Policy) decide(in Input) Decision {
+	for _, rule := range p.Rules {
+		if rule.matches(in) {
+			reason := rule.Description
+			if reason == "" {
+				reason = fmt.Sprintf("matched %s policy rule %s", p.Scope, rule.Name)
+			}
+			return Decision{Effect: rule.Effect, Scope: p.Scope, Rule: rule.Name, Reason: reason}
+		}
+	}
+	return Decision{Effect: p.Default, Scope: p.Scope, Reason: fmt.Sprintf("%s policy default", p.Scope)}
+}
+
+func (r *Rule) matches(in Input) bool {
+	if len(r.Roles) > 0 && !containsFold(r.Roles, in.Role) {
+		return false
+	}
+	if len(r.Actions) > 0 && !containsFold(r.Actions, in.ActionType) {
+		return false
+	}
+	if len(r.Paths) > 0 && !r.matchesPaths(in.Paths) {
+		return false
+	}
+	if len(r.commands) > 0 {
+		matched := false
+		for _, re := range r.commands {
+			if in.Command != "" && re.MatchString(in.Command) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if r.Hours != "" {
+		minute := in.Time.Hour()*60 + in.Time.Minute()
+		inWindow := minute >= r.from && minute < r.to
+		if r.from > r.to {
+			inWindow = minute >= r.from || minute < r.to
+		}
+		if !inWindow {
+			return false
+		}
+	}
+	if r.days != nil && !r.days[in.Time.Weekday()] {
+		return false
+	}
+	return in.CostUSD >= r.MinCostUSD
+}
+
+func (r *Rule) matchesPaths(paths []string) bool {
+	for _, p := range paths {
+		p = path.Clean(strings.TrimPrefix(p, "./"))
+		for _, glob := range r.Paths {
+			if matchGlob(glob, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated path against a glob where **
+// matches any number of directories
+func matchGlob(glob, name string) bool {
+	return matchSegments(strings.Split(glob, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(glob, name []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(glob[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], name[0]); !ok {
+			return false
+		}
+		glob, name = glob[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// parseHours parses "HH:MM-HH:MM" into minutes since midnight
+func parseHours(window string) (int, int, error) {
+	start, end, ok := strings.Cut(window, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid hours %q (want HH:MM-HH:MM)", window)
+	}
+	var bounds [2]int
+	for i, s := range []string{start, end} {
+		t, err := time.Parse("15:04", strings.TrimSpace(s))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid hours %q (want HH:MM-HH:MM)", window)
+		}
+		bounds[i] = t.Hour()*60 + t.Minute()
+	}
+	return bounds[0], bounds[1], nil
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// stricter returns the stricter of the global and project decisions; the
+// project's, being more specific, on ties
+func stricter(global, project Decision) Decision {
+	if strictness[global.Effect] > strictness[project.Effect] {
+		return global
+	}
+	return project
+}
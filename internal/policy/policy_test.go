@@ -0,0 +1,156 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePolicy(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const globalPolicy = `
+rules:
+  - name: protect-secrets
+    description: Secrets files are off limits
+    effect: deny
+    paths: ["**/.env", "secrets/**"]
+  - name: destructive-commands
+    effect: require_approval
+    actions: [run_command]
+    commands: ['rm\s+-rf', 'git\s+push\s+--force']
+  - name: after-hours-pushes
+    effect: require_approval
+    actions: [git_push]
+    hours: "18:00-08:00"
+  - name: big-spenders
+    effect: require_approval
+    min_cost_usd: 50
+`
+
+func TestEvaluate(t *testing.T) {
+	dir := t.TempDir()
+	writePolicy(t, dir, "global.yaml", globalPolicy)
+	writePolicy(t, dir, "proj-1.yaml", `
+default: deny
+rules:
+  - name: reviewers-read
+    effect: allow
+    roles: [Code Reviewer]
+    actions: [read_file, read_tree]
+  - name: anyone-else
+    effect: allow
+    roles: [engineer]
+  - name: ignored
+    effect: allow
+    paths: ["**/.env"]
+`)
+	e, err := NewEngine(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noon := time.Date(2026, 10, 14, 12, 0, 0, 0, time.Local)
+	night := time.Date(2026, 10, 14, 23, 30, 0, 0, time.Local)
+	tests := []struct {
+		name string
+		in   Input
+		want Effect
+		rule string
+	}{
+		{"global default", Input{ProjectID: "other", ActionType: "write_file", Time: noon}, Allow, ""},
+		{"path glob", Input{ProjectID: "other", ActionType: "read_file", Paths: []string{"config/.env"}, Time: noon}, Deny, "protect-secrets"},
+		{"nested path glob", Input{ProjectID: "other", ActionType: "write_file", Paths: []string{"./secrets/prod/key.pem"}, Time: noon}, Deny, "protect-secrets"},
+		{"command", Input{ProjectID: "other", ActionType: "run_command", Command: "cd x && rm -rf build", Time: noon}, RequireApproval, "destructive-commands"},
+		{"harmless command", Input{ProjectID: "other", ActionType: "run_command", Command: "go test ./...", Time: noon}, Allow, ""},
+		{"hours wrap midnight", Input{ProjectID: "other", ActionType: "git_push", Time: night}, RequireApproval, "after-hours-pushes"},
+		{"hours outside window", Input{ProjectID: "other", ActionType: "git_push", Time: noon}, Allow, ""},
+		{"cost", Input{ProjectID: "other", ActionType: "read_file", CostUSD: 75, Time: noon}, RequireApproval, "big-spenders"},
+		{"project role", Input{ProjectID: "proj-1", Role: "code reviewer", ActionType: "read_file", Time: noon}, Allow, "reviewers-read"},
+		{"project default", Input{ProjectID: "proj-1", Role: "code reviewer", ActionType: "write_file", Time: noon}, Deny, ""},
+		{"project cannot lift global deny", Input{ProjectID: "proj-1", Role: "engineer", ActionType: "read_file", Paths: []string{".env"}, Time: noon}, Deny, "protect-secrets"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := e.Evaluate(tt.in)
+			if d.Effect != tt.want || d.Rule != tt.rule {
+				t.Errorf("Evaluate() = %+v, want %s by %q", d, tt.want, tt.rule)
+			}
+		})
+	}
+
+	if policies := e.Policies(); len(policies) != 2 || policies[0].Scope != GlobalScope || policies[1].Scope != "proj-1" {
+		t.Errorf("unexpected policies %+v", policies)
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writePolicy(t, dir, "global.yaml", "rules:\n  - {name: no-writes, effect: deny, actions: [write_file]}\n")
+	e, err := NewEngine(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := Input{ActionType: "write_file"}
+	if d := e.Evaluate(in); d.Effect != Deny {
+		t.Fatalf("expected deny, got %+v", d)
+	}
+	if reloaded, err := e.Reload(); reloaded || err != nil {
+		t.Errorf("expected no reload without changes, got %v %v", reloaded, err)
+	}
+
+	// A broken edit keeps the previous policies
+	writePolicy(t, dir, "global.yaml", "rules:\n  - {name: bad, effect: maybe}\n")
+	bumpModTime(t, filepath.Join(dir, "global.yaml"))
+	if _, err := e.Reload(); err == nil {
+		t.Error("expected an invalid effect to fail the reload")
+	}
+	if d := e.Evaluate(in); d.Effect != Deny {
+		t.Errorf("expected the previous policy to stay in force, got %+v", d)
+	}
+
+	// Removing the file lifts the policy
+	if err := os.Remove(filepath.Join(dir, "global.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := e.Reload(); !reloaded || err != nil {
+		t.Fatalf("expected a reload after removal, got %v %v", reloaded, err)
+	}
+	if d := e.Evaluate(in); d.Effect != Allow {
+		t.Errorf("expected allow without policies, got %+v", d)
+	}
+
+	var nilEngine *Engine
+	if d := nilEngine.Evaluate(in); d.Effect != Allow {
+		t.Errorf("expected a nil engine to allow, got %+v", d)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, content := range []string{
+		"default: sometimes\n",
+		"rules:\n  - {effect: deny, commands: ['(']}\n",
+		"rules:\n  - {effect: deny, hours: '9-5'}\n",
+		"rules:\n  - {effect: deny, days: [someday]}\n",
+		"rules:\n  - {effect: deny, paths: ['[']}\n",
+	} {
+		dir := t.TempDir()
+		writePolicy(t, dir, "global.yaml", content)
+		if _, err := NewEngine(dir); err == nil {
+			t.Errorf("expected %q to be rejected", content)
+		}
+	}
+}
+
+func bumpModTime(t *testing.T, file string) {
+	t.Helper()
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,151 @@
+package pricing
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/pkg/config"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestTableLookup(t *testing.T) {
+	table := NewTable([]*Rate{
+		{ID: "openai-flat", ProviderID: "openai", InputPerMTok: 1, OutputPerMTok: 1},
+		{ID: "gpt4o-v1", ProviderID: "openai", ModelName: "gpt-4o", InputPerMTok: 5, OutputPerMTok: 15, EffectiveFrom: day("2026-01-01")},
+		{ID: "gpt4o-v2", ProviderID: "openai", ModelName: "gpt-4o", InputPerMTok: 2.5, OutputPerMTok: 10, EffectiveFrom: day("2026-03-01")},
+		{ID: "any-claude", ModelName: "claude-sonnet", InputPerMTok: 3, OutputPerMTok: 15},
+	})
+
+	tests := []struct {
+		provider, model string
+		at              time.Time
+		want            string
+	}{
+		{"openai", "gpt-4o", day("2026-02-15"), "gpt4o-v1"},
+		{"openai", "GPT-4o", day("2026-03-02"), "gpt4o-v2"},
+		{"openai", "gpt-4o", day("2025-12-01"), "openai-flat"},
+		{"openai", "gpt-4o-mini", day("2026-03-02"), "openai-flat"},
+		{"bedrock", "claude-sonnet", day("2026-03-02"), "any-claude"},
+		{"bedrock", "llama", day("2026-03-02"), ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if r := table.Lookup(tt.provider, tt.model, tt.at); r != nil {
+			got = r.ID
+		}
+		if got != tt.want {
+			t.Errorf("Lookup(%s, %s, %s) = %q, want %q", tt.provider, tt.model, tt.at.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestRateCost(t *testing.T) {
+	r := &Rate{InputPerMTok: 2, OutputPerMTok: 10}
+	split := &analytics.RequestLog{PromptTokens: 1_000_000, CompletionTokens: 500_000, TotalTokens: 1_500_000}
+	if got := r.Cost(split); got != 7 {
+		t.Errorf("split cost = %v, want 7", got)
+	}
+	total := &analytics.RequestLog{TotalTokens: 1_000_000}
+	if got := r.Cost(total); got != 6 {
+		t.Errorf("total-only cost = %v, want 6", got)
+	}
+}
+
+func TestServiceSeedPriceAndRecalculate(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+
+	logs, err := analytics.NewDatabaseStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewDatabaseStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc, err := NewService(ctx, store, logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seeds := []config.PricingRate{{ProviderID: "openai", ModelName: "gpt-4o", InputPerMTok: 5, OutputPerMTok: 15, EffectiveFrom: "2026-01-01"}}
+	for i := 0; i < 2; i++ {
+		if _, err := svc.Seed(ctx, seeds); err != nil {
+			t.Fatalf("Seed failed: %v", err)
+		}
+	}
+	if len(svc.Rates()) != 1 {
+		t.Fatalf("expected seeding to be idempotent, got %d rates", len(svc.Rates()))
+	}
+
+	// Costs computed in the logging path
+	logger := analytics.NewLogger(logs, nil)
+	logger.SetPricer(svc)
+	svc.SetFallback(func(providerID string) float64 {
+		if providerID == "local" {
+			return 0.5
+		}
+		return 0
+	})
+	entries := []*analytics.RequestLog{
+		{UserID: "u", Method: "POST", Path: "/x", ProviderID: "openai", ModelName: "gpt-4o", Timestamp: day("2026-02-01"), PromptTokens: 1000, CompletionTokens: 1000, TotalTokens: 2000},
+		{UserID: "u", Method: "POST", Path: "/x", ProviderID: "openai", ModelName: "gpt-4o", Timestamp: day("2026-03-05"), PromptTokens: 1000, CompletionTokens: 1000, TotalTokens: 2000},
+		{UserID: "u", Method: "POST", Path: "/x", ProviderID: "local", ModelName: "llama", Timestamp: day("2026-03-05"), TotalTokens: 2_000_000},
+	}
+	for _, e := range entries {
+		if err := logger.LogRequest(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entries[0].CostUSD != 0.02 || entries[0].Metadata[analytics.PricingRateKey] == "" {
+		t.Errorf("expected gpt-4o priced at the seeded rate, got %v %v", entries[0].CostUSD, entries[0].Metadata)
+	}
+	if entries[2].CostUSD != 1 {
+		t.Errorf("expected the provider fallback, got %v", entries[2].CostUSD)
+	}
+
+	// The price dropped on March 1st but the rate wasn't added until later
+	if _, err := svc.AddRate(ctx, &Rate{ProviderID: "openai", ModelName: "gpt-4o", InputPerMTok: 2.5, OutputPerMTok: 10, EffectiveFrom: day("2026-03-01")}); err != nil {
+		t.Fatal(err)
+	}
+	dry, err := svc.Recalculate(ctx, RecalcOptions{ProviderID: "openai", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dry.Scanned != 2 || dry.Repriced != 1 || dry.NewCostUSD >= dry.OldCostUSD {
+		t.Fatalf("unexpected dry run %+v", dry)
+	}
+
+	result, err := svc.Recalculate(ctx, RecalcOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Scanned != 3 || result.Repriced != 1 || result.Unpriced != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	stored, err := logs.GetLogs(ctx, &analytics.LogFilter{ModelName: "gpt-4o", StartTime: day("2026-03-01")})
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("GetLogs = %v, %v", stored, err)
+	}
+	if stored[0].CostUSD != 0.0125 {
+		t.Errorf("expected the March request re-priced to $0.0125, got %v", stored[0].CostUSD)
+	}
+
+	again, err := svc.Recalculate(ctx, RecalcOptions{})
+	if err != nil || again.Repriced != 0 {
+		t.Errorf("expected a second run to change nothing, got %+v, %v", again, err)
+	}
+}
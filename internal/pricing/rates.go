@@ -0,0 +1,102 @@
+// Package pricing keeps versioned per-model token prices and uses them to
+// cost request logs, both as they are written and retroactively when a rate
+// turns out to have been wrong.
+package pricing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+)
+
+// Rate is a price per million tokens for a provider and model, in effect
+// from EffectiveFrom until a later rate for the same provider and model
+// takes over. An empty ProviderID matches any provider and an empty
+// ModelName any model of the provider.
+type Rate struct {
+	ID            string    `json:"id"`
+	ProviderID    string    `json:"provider_id,omitempty"`
+	ModelName     string    `json:"model_name,omitempty"`
+	InputPerMTok  float64   `json:"input_per_mtok"`
+	OutputPerMTok float64   `json:"output_per_mtok"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Validate checks that the rate can price anything
+func (r *Rate) Validate() error {
+	if r.ProviderID == "" && r.ModelName == "" {
+		return fmt.Errorf("rate needs a provider, a model or both")
+	}
+	if r.InputPerMTok < 0 || r.OutputPerMTok < 0 {
+		return fmt.Errorf("prices must not be negative")
+	}
+	return nil
+}
+
+// Cost prices a request. Prompt and completion tokens are charged at their
+// own rates; when a log only has a total, it is charged at the mean of the
+// two.
+func (r *Rate) Cost(log *analytics.RequestLog) float64 {
+	if log.PromptTokens > 0 || log.CompletionTokens > 0 {
+		return (float64(log.PromptTokens)*r.InputPerMTok + float64(log.CompletionTokens)*r.OutputPerMTok) / 1e6
+	}
+	return float64(log.TotalTokens) * (r.InputPerMTok + r.OutputPerMTok) / 2 / 1e6
+}
+
+// specificity ranks how closely a rate targets a provider and model; a
+// model-specific rate beats a provider-wide one
+func (r *Rate) specificity() int {
+	n := 0
+	if r.ModelName != "" {
+		n += 2
+	}
+	if r.ProviderID != "" {
+		n++
+	}
+	return n
+}
+
+func (r *Rate) matches(providerID, modelName string) bool {
+	return (r.ProviderID == "" || r.ProviderID == providerID) &&
+		(r.ModelName == "" || strings.EqualFold(r.ModelName, modelName))
+}
+
+// Table is an immutable set of rates
+type Table struct {
+	rates []*Rate
+}
+
+// NewTable creates a table of rates
+func NewTable(rates []*Rate) *Table {
+	sorted := append([]*Rate(nil), rates...)
+	// Newest first, so the first match at a given specificity is the one in effect
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].EffectiveFrom.After(sorted[j].EffectiveFrom)
+	})
+	return &Table{rates: sorted}
+}
+
+// Lookup returns the rate in effect for a provider and model at t: the most
+// specific matching rate, and of those the latest effective at or before t
+func (t *Table) Lookup(providerID, modelName string, at time.Time) *Rate {
+	var best *Rate
+	for _, r := range t.rates {
+		if r.EffectiveFrom.After(at) || !r.matches(providerID, modelName) {
+			continue
+		}
+		if best == nil || r.specificity() > best.specificity() {
+			best = r
+		}
+	}
+	return best
+}
+
+// Rates returns the table's rates, newest first
+func (t *Table) Rates() []*Rate {
+	return append([]*Rate(nil), t.rates...)
+}
@@ -0,0 +1,248 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// recalcPageSize is how many logs are re-priced per query
+const recalcPageSize = 500
+
+// LogRepricer reads request logs and rewrites their cost
+type LogRepricer interface {
+	GetLogs(ctx context.Context, filter *analytics.LogFilter) ([]*analytics.RequestLog, error)
+	UpdateLogCost(ctx context.Context, id string, costUSD float64, metadata map[string]string) error
+}
+
+// Service prices request logs from stored rates. It implements
+// analytics.Pricer for the logging path.
+type Service struct {
+	store Store
+	logs  LogRepricer
+
+	mu       sync.RWMutex
+	table    *Table
+	fallback func(providerID string) float64
+}
+
+// NewService creates a pricing service and loads its rates. logs may be nil,
+// in which case Recalculate is unavailable.
+func NewService(ctx context.Context, store Store, logs LogRepricer) (*Service, error) {
+	s := &Service{store: store, logs: logs, table: NewTable(nil)}
+	if err := s.reload(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetFallback sets a per-million-token price used for providers with no
+// matching rate, typically the provider's configured cost
+func (s *Service) SetFallback(fallback func(providerID string) float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = fallback
+}
+
+func (s *Service) reload(ctx context.Context) error {
+	rates, err := s.store.ListRates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load pricing rates: %w", err)
+	}
+	table := NewTable(rates)
+	s.mu.Lock()
+	s.table = table
+	s.mu.Unlock()
+	return nil
+}
+
+// Rates returns every rate, newest first
+func (s *Service) Rates() []*Rate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.table.Rates()
+}
+
+// Lookup returns the rate in effect for a provider and model at t, or nil
+func (s *Service) Lookup(providerID, modelName string, at time.Time) *Rate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.table.Lookup(providerID, modelName, at)
+}
+
+// AddRate validates and stores a new rate. Rates are never edited: a
+// correction is a new rate, and Recalculate applies it to past logs.
+func (s *Service) AddRate(ctx context.Context, r *Rate) (*Rate, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	r.ID = uuid.New().String()
+	r.CreatedAt = time.Now().UTC()
+	r.EffectiveFrom = r.EffectiveFrom.UTC()
+	if err := s.store.AddRate(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, s.reload(ctx)
+}
+
+// Seed adds configured rates that aren't stored yet, matching on provider,
+// model and effective date, and returns how many were added
+func (s *Service) Seed(ctx context.Context, seeds []config.PricingRate) (int, error) {
+	added := 0
+	for _, seed := range seeds {
+		effective, err := ParseEffective(seed.EffectiveFrom)
+		if err != nil {
+			return added, fmt.Errorf("pricing rate %s/%s: %w", seed.ProviderID, seed.ModelName, err)
+		}
+		if s.hasRate(seed.ProviderID, seed.ModelName, effective) {
+			continue
+		}
+		if _, err := s.AddRate(ctx, &Rate{
+			ProviderID:    seed.ProviderID,
+			ModelName:     seed.ModelName,
+			InputPerMTok:  seed.InputPerMTok,
+			OutputPerMTok: seed.OutputPerMTok,
+			EffectiveFrom: effective,
+			Note:          "config",
+		}); err != nil {
+			return added, fmt.Errorf("pricing rate %s/%s: %w", seed.ProviderID, seed.ModelName, err)
+		}
+		added++
+	}
+	return added, nil
+}
+
+func (s *Service) hasRate(providerID, modelName string, effective time.Time) bool {
+	for _, r := range s.Rates() {
+		if r.ProviderID == providerID && r.ModelName == modelName && r.EffectiveFrom.Equal(effective) {
+			return true
+		}
+	}
+	return false
+}
+
+// Price prices a request at the rate in effect when it was made, falling
+// back to the provider's flat price
+func (s *Service) Price(log *analytics.RequestLog) (float64, string, bool) {
+	at := log.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	s.mu.RLock()
+	rate := s.table.Lookup(log.ProviderID, log.ModelName, at)
+	fallback := s.fallback
+	s.mu.RUnlock()
+
+	if rate != nil {
+		return rate.Cost(log), rate.ID, true
+	}
+	if fallback != nil {
+		if perMTok := fallback(log.ProviderID); perMTok > 0 {
+			return analytics.CalculateCost(perMTok, log.TotalTokens), "", true
+		}
+	}
+	return 0, "", false
+}
+
+// RecalcOptions selects the logs to re-price
+type RecalcOptions struct {
+	ProviderID string    `json:"provider_id,omitempty"`
+	ModelName  string    `json:"model_name,omitempty"`
+	Since      time.Time `json:"since,omitempty"`
+	Until      time.Time `json:"until,omitempty"` // Default now
+	DryRun     bool      `json:"dry_run,omitempty"`
+}
+
+// RecalcResult summarizes a re-pricing run. Costs are totals over the logs
+// scanned, before and after.
+type RecalcResult struct {
+	Scanned    int64   `json:"scanned"`
+	Repriced   int64   `json:"repriced"`
+	Unpriced   int64   `json:"unpriced"` // no rate matched; cost left as is
+	OldCostUSD float64 `json:"old_cost_usd"`
+	NewCostUSD float64 `json:"new_cost_usd"`
+	DryRun     bool    `json:"dry_run"`
+}
+
+// Recalculate re-prices stored logs with the rates in effect when each was
+// made. Only logs a rate matches are changed; the provider fallback isn't
+// applied, since it reflects today's price rather than the historical one.
+func (s *Service) Recalculate(ctx context.Context, opts RecalcOptions) (*RecalcResult, error) {
+	if s.logs == nil {
+		return nil, fmt.Errorf("request logs not available")
+	}
+	if opts.Until.IsZero() {
+		// Pin the end so logs written during the run don't shift the pages
+		opts.Until = time.Now()
+	}
+	filter := &analytics.LogFilter{
+		ProviderID: opts.ProviderID,
+		ModelName:  opts.ModelName,
+		StartTime:  opts.Since,
+		EndTime:    opts.Until,
+		Limit:      recalcPageSize,
+	}
+
+	result := &RecalcResult{DryRun: opts.DryRun}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		logs, err := s.logs.GetLogs(ctx, filter)
+		if err != nil {
+			return result, fmt.Errorf("failed to read request logs: %w", err)
+		}
+		for _, log := range logs {
+			result.Scanned++
+			result.OldCostUSD += log.CostUSD
+			rate := s.Lookup(log.ProviderID, log.ModelName, log.Timestamp)
+			if rate == nil {
+				result.Unpriced++
+				result.NewCostUSD += log.CostUSD
+				continue
+			}
+			cost := rate.Cost(log)
+			result.NewCostUSD += cost
+			if math.Abs(cost-log.CostUSD) < 1e-12 && log.Metadata[analytics.PricingRateKey] == rate.ID {
+				continue
+			}
+			result.Repriced++
+			if opts.DryRun {
+				continue
+			}
+			if log.Metadata == nil {
+				log.Metadata = map[string]string{}
+			}
+			log.Metadata[analytics.PricingRateKey] = rate.ID
+			if err := s.logs.UpdateLogCost(ctx, log.ID, cost, log.Metadata); err != nil {
+				return result, fmt.Errorf("failed to update log %s: %w", log.ID, err)
+			}
+		}
+		if len(logs) < recalcPageSize {
+			return result, nil
+		}
+		filter.Offset += recalcPageSize
+	}
+}
+
+// ParseEffective parses an effective date: empty for always, YYYY-MM-DD
+// (midnight UTC) or RFC3339
+func ParseEffective(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid effective date %q: use YYYY-MM-DD or RFC3339", v)
+	}
+	return t.UTC(), nil
+}
@@ -0,0 +1,79 @@
+package pricing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Store persists rates
+type Store interface {
+	AddRate(ctx context.Context, r *Rate) error
+	ListRates(ctx context.Context) ([]*Rate, error)
+}
+
+// DatabaseStore keeps rates in the pricing_rates table
+type DatabaseStore struct {
+	db *sql.DB
+}
+
+// NewDatabaseStore creates a rate store, creating its table if needed
+func NewDatabaseStore(db *sql.DB) (*DatabaseStore, error) {
+	s := &DatabaseStore{db: db}
+	if err := s.initSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize pricing rates: %w", err)
+	}
+	return s, nil
+}
+
+func (s *DatabaseStore) initSchema() error {
+	_, err := s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS pricing_rates (
+		id TEXT PRIMARY KEY,
+		provider_id TEXT NOT NULL DEFAULT '',
+		model_name TEXT NOT NULL DEFAULT '',
+		input_per_mtok REAL NOT NULL,
+		output_per_mtok REAL NOT NULL,
+		effective_from DATETIME NOT NULL,
+		note TEXT,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_pricing_rates_model ON pricing_rates(provider_id, model_name);
+	`)
+	return err
+}
+
+// AddRate stores a rate
+func (s *DatabaseStore) AddRate(ctx context.Context, r *Rate) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO pricing_rates (id, provider_id, model_name, input_per_mtok, output_per_mtok, effective_from, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ID, r.ProviderID, r.ModelName, r.InputPerMTok, r.OutputPerMTok, r.EffectiveFrom.UTC(), r.Note, r.CreatedAt.UTC())
+	return err
+}
+
+// ListRates returns every stored rate
+func (s *DatabaseStore) ListRates(ctx context.Context) ([]*Rate, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, provider_id, model_name, input_per_mtok, output_per_mtok, effective_from, COALESCE(note, ''), created_at
+		FROM pricing_rates ORDER BY effective_from DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []*Rate
+	for rows.Next() {
+		r := &Rate{}
+		var effective, created time.Time
+		if err := rows.Scan(&r.ID, &r.ProviderID, &r.ModelName, &r.InputPerMTok, &r.OutputPerMTok, &effective, &r.Note, &created); err != nil {
+			return nil, err
+		}
+		r.EffectiveFrom = effective.UTC()
+		r.CreatedAt = created.UTC()
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
@@ -0,0 +1,49 @@
+package profiling
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// topLine matches a row of go tool pprof -top:
+//
+//	0.50s 45.45% 45.45%      0.60s 54.55%  main.parse
+var topLine = regexp.MustCompile(`^\s*(\S+)\s+([\d.]+)%\s+[\d.]+%\s+(\S+)\s+([\d.]+)%\s+(.+)$`)
+
+// parseTop reads the total sample value and the rows of go tool pprof -top
+// output
+func parseTop(out []byte) (string, []models.ProfileEntry) {
+	total := ""
+	var entries []models.ProfileEntry
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Showing nodes accounting for 1.10s, 100% of 1.10s total
+		if rest, ok := strings.CutPrefix(line, "Showing nodes accounting for "); ok {
+			if _, after, ok := strings.Cut(rest, " of "); ok {
+				total = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(after), "total"))
+			}
+			continue
+		}
+		m := topLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		flatPct, _ := strconv.ParseFloat(m[2], 64)
+		cumPct, _ := strconv.ParseFloat(m[4], 64)
+		entries = append(entries, models.ProfileEntry{
+			Function:    strings.TrimSpace(m[5]),
+			Flat:        m[1],
+			FlatPercent: flatPct,
+			Cum:         m[3],
+			CumPercent:  cumPct,
+		})
+	}
+	return total, entries
+}
@@ -0,0 +1,389 @@
+// Package profiling runs a project's Go test or binary under the CPU and
+// heap profilers, keeps the pprof files as artifacts of the bead they were
+// captured for, and summarizes each one's top functions so performance work
+// starts from data.
+package profiling
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var profilingLog = logging.Module("profiling")
+
+const (
+	defaultTimeout = 10 * time.Minute
+	defaultTop     = 15
+	maxTop         = 100
+	// maxProfileBytes caps the pprof files kept; larger ones are reported
+	// and dropped
+	maxProfileBytes = 32 << 20
+	// maxOutput caps the command output kept in a result, from the end
+	maxOutput = 16 * 1024
+	// pprofTimeout bounds each go tool pprof summary
+	pprofTimeout = time.Minute
+)
+
+// Placeholders in a command's arguments for the paths its profiles are
+// written to
+const (
+	CPUPlaceholder  = "{cpu}"
+	HeapPlaceholder = "{heap}"
+)
+
+// Store persists profiles
+type Store interface {
+	SaveProfile(p *models.Profile) error
+	GetProfile(id string) (*models.Profile, error)
+	ListProfiles(beadID string) ([]*models.Profile, error)
+}
+
+// Options selects what a run profiles
+type Options struct {
+	Path        string        // Go package directory, relative to the repository root, or where the command runs
+	TestPattern string        // go test -run regexp (default ., or none when only Bench is set)
+	Bench       string        // go test -bench regexp; benchmarks usually make better profiles than tests
+	Command     []string      // A binary and its arguments, profiled instead of go test; {cpu} and {heap} become the profile paths
+	Kinds       []string      // cpu and/or heap (default both)
+	Top         int           // Functions in each summary (default the manager's)
+	Timeout     time.Duration // Default the manager's
+}
+
+// Result is the outcome of a run. A failed test or command is reported in
+// Error; profiles it managed to write are still kept.
+type Result struct {
+	Success  bool              `json:"success"`
+	Target   string            `json:"target"`
+	Profiles []*models.Profile `json:"profiles,omitempty"`
+	Notes    []string          `json:"notes,omitempty"` // Profiles missing or without a summary, and why
+	Output   string            `json:"output,omitempty"`
+	Duration time.Duration     `json:"duration"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// Manager profiles code inside project workdirs
+type Manager struct {
+	WorkDirs files.WorkDirResolver
+	Timeout  time.Duration
+	Top      int
+
+	store Store
+
+	runner toolrun.Runner
+}
+
+// NewManager creates a profiling manager, or returns nil when profiling is
+// disabled in cfg. Profiles are kept in memory when store is nil.
+func NewManager(resolver files.WorkDirResolver, store Store, cfg config.ProfilingConfig) *Manager {
+	if cfg.Disabled {
+		return nil
+	}
+	if store == nil {
+		store = newMemoryStore()
+	}
+	m := &Manager{
+		WorkDirs: resolver,
+		Timeout:  cfg.Timeout,
+		Top:      cfg.Top,
+		store:    store,
+	}
+	if m.Timeout <= 0 {
+		m.Timeout = defaultTimeout
+	}
+	if m.Top <= 0 {
+		m.Top = defaultTop
+	}
+	return m
+}
+
+// Run profiles the project's test package or command, summarizes and stores
+// the profiles. An error means the run could not start.
+func (m *Manager) Run(ctx context.Context, projectID, beadID string, opts Options) (*Result, error) {
+	if m.WorkDirs == nil {
+		return nil, fmt.Errorf("workdir resolver not configured")
+	}
+	workDir := m.WorkDirs.GetProjectWorkDir(projectID)
+	if workDir == "" {
+		return nil, fmt.Errorf("project workdir not found")
+	}
+	workDir = filepath.Clean(workDir)
+	path := models.CleanSubprojectPath(opts.Path)
+	dir := filepath.Join(workDir, filepath.FromSlash(path))
+	if rel, err := filepath.Rel(workDir, dir); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("path %q is outside the project", opts.Path)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("path %q is not a directory", opts.Path)
+	}
+
+	kinds, err := profileKinds(opts.Kinds)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Command) > 0 {
+		if !placeholders(opts.Command, kinds) {
+			return nil, fmt.Errorf("command must take the profile path: pass %s or %s where it expects the output file", CPUPlaceholder, HeapPlaceholder)
+		}
+	} else if _, err := m.runner.Find("go"); err != nil {
+		return nil, fmt.Errorf("go is not installed")
+	}
+	if opts.Top <= 0 {
+		opts.Top = m.Top
+	}
+	opts.Top = min(opts.Top, maxTop)
+	if opts.Timeout <= 0 {
+		opts.Timeout = m.Timeout
+	}
+
+	tmp, err := os.MkdirTemp("", "loom-profile-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	files := map[string]string{}
+	for _, kind := range kinds {
+		files[kind] = filepath.Join(tmp, kind+".pprof")
+	}
+
+	start := time.Now()
+	result := &Result{}
+	var binary string
+	runCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	var stdout, stderr []byte
+	var name string
+	if len(opts.Command) > 0 {
+		name = opts.Command[0]
+		result.Target = strings.Join(opts.Command, " ")
+		args := make([]string, 0, len(opts.Command)-1)
+		for _, arg := range opts.Command[1:] {
+			arg = strings.ReplaceAll(arg, CPUPlaceholder, files[models.ProfileCPU])
+			arg = strings.ReplaceAll(arg, HeapPlaceholder, files[models.ProfileHeap])
+			args = append(args, arg)
+		}
+		stdout, stderr, err = m.runner.Exec(runCtx, toolrun.Command{Dir: dir, Name: name, Args: args})
+	} else {
+		name = "go test"
+		result.Target = "./" + path
+		if path == "" {
+			result.Target = "."
+		}
+		binary = filepath.Join(tmp, "profile.test")
+		stdout, stderr, err = m.runner.Exec(runCtx, toolrun.Command{Dir: dir, Name: "go", Args: goTestArgs(opts, binary, files)})
+	}
+	result.Output = toolrun.Tail(stdout, stderr, maxOutput)
+	if err != nil {
+		result.Error = commandError(runCtx, name, err, opts.Timeout).Error()
+	} else {
+		result.Success = true
+	}
+	cancel()
+
+	for _, kind := range kinds {
+		data, err := os.ReadFile(files[kind])
+		switch {
+		case err != nil || len(data) == 0:
+			result.Notes = append(result.Notes, fmt.Sprintf("no %s profile was written", kind))
+			continue
+		case len(data) > maxProfileBytes:
+			result.Notes = append(result.Notes, fmt.Sprintf("the %s profile is %d bytes, over the %d byte limit", kind, len(data), maxProfileBytes))
+			continue
+		}
+		p := &models.Profile{
+			ID:        "prof-" + uuid.New().String()[:8],
+			ProjectID: projectID,
+			BeadID:    beadID,
+			Kind:      kind,
+			Target:    result.Target,
+			Name:      kind + ".pprof",
+			Size:      len(data),
+			CreatedAt: time.Now(),
+			Data:      data,
+		}
+		if err := m.summarize(ctx, dir, binary, files[kind], p, opts.Top); err != nil {
+			result.Notes = append(result.Notes, fmt.Sprintf("summarizing the %s profile failed: %v", kind, err))
+		}
+		if err := m.store.SaveProfile(p); err != nil {
+			profilingLog.Error("Failed to save profile", "project_id", projectID, "bead_id", beadID, "kind", kind, "error", err)
+			result.Notes = append(result.Notes, fmt.Sprintf("saving the %s profile failed: %v", kind, err))
+			continue
+		}
+		result.Profiles = append(result.Profiles, p)
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// Get returns a profile with its data
+func (m *Manager) Get(id string) (*models.Profile, error) {
+	p, err := m.store.GetProfile(id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, apperr.NotFound("profile %s not found", id)
+	}
+	return p, nil
+}
+
+// List returns a bead's profiles without their data, oldest first
+func (m *Manager) List(beadID string) ([]*models.Profile, error) {
+	return m.store.ListProfiles(beadID)
+}
+
+// summarize fills in the profile's top functions with go tool pprof. Heap
+// profiles are ranked by bytes allocated over the run rather than the few
+// still in use when it ended.
+func (m *Manager) summarize(ctx context.Context, dir, binary, file string, p *models.Profile, top int) error {
+	if _, err := m.runner.Find("go"); err != nil {
+		return fmt.Errorf("go is not installed")
+	}
+	ctx, cancel := context.WithTimeout(ctx, pprofTimeout)
+	defer cancel()
+	args := []string{"tool", "pprof", "-top", "-nodecount=" + strconv.Itoa(top)}
+	if p.Kind == models.ProfileHeap {
+		args = append(args, "-sample_index=alloc_space")
+	}
+	if binary != "" {
+		if _, err := os.Stat(binary); err == nil {
+			args = append(args, binary)
+		}
+	}
+	args = append(args, file)
+	stdout, stderr, err := m.runner.Exec(ctx, toolrun.Command{Dir: dir, Name: "go", Args: args})
+	if err != nil {
+		if msg := strings.TrimSpace(string(stderr)); msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return err
+	}
+	p.Total, p.Top = parseTop(stdout)
+	return nil
+}
+
+// goTestArgs builds the go test command writing the requested profiles. The
+// test binary is kept next to them so pprof can symbolize.
+func goTestArgs(opts Options, binary string, files map[string]string) []string {
+	run := opts.TestPattern
+	if run == "" {
+		run = "."
+		if opts.Bench != "" {
+			run = "^$"
+		}
+	}
+	args := []string{"test", "-run", run, "-count", "1", "-o", binary}
+	if opts.Bench != "" {
+		args = append(args, "-bench", opts.Bench, "-benchmem")
+	}
+	if file, ok := files[models.ProfileCPU]; ok {
+		args = append(args, "-cpuprofile", file)
+	}
+	if file, ok := files[models.ProfileHeap]; ok {
+		args = append(args, "-memprofile", file)
+	}
+	return append(args, "-timeout", opts.Timeout.String(), ".")
+}
+
+// profileKinds validates the requested kinds, defaulting to both
+func profileKinds(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return []string{models.ProfileCPU, models.ProfileHeap}, nil
+	}
+	seen := map[string]bool{}
+	for _, kind := range requested {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		switch kind {
+		case "mem", "memory":
+			kind = models.ProfileHeap
+		case models.ProfileCPU, models.ProfileHeap:
+		default:
+			return nil, fmt.Errorf("unsupported profile %q (use cpu or heap)", kind)
+		}
+		seen[kind] = true
+	}
+	kinds := make([]string, 0, len(seen))
+	for kind := range seen {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds, nil
+}
+
+// placeholders reports whether the command takes the path of any of kinds
+func placeholders(command []string, kinds []string) bool {
+	for _, kind := range kinds {
+		placeholder := CPUPlaceholder
+		if kind == models.ProfileHeap {
+			placeholder = HeapPlaceholder
+		}
+		for _, arg := range command[1:] {
+			if strings.Contains(arg, placeholder) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func commandError(ctx context.Context, name string, err error, timeout time.Duration) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s timed out after %s", name, timeout)
+	}
+	return fmt.Errorf("%s failed: %v", name, err)
+}
+
+// memoryStore keeps profiles until restart when there is no database
+type memoryStore struct {
+	mu       sync.RWMutex
+	profiles []*models.Profile
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{}
+}
+
+func (s *memoryStore) SaveProfile(p *models.Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles = append(s.profiles, p)
+	return nil
+}
+
+func (s *memoryStore) GetProfile(id string) (*models.Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, p := range s.profiles {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) ListProfiles(beadID string) ([]*models.Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*models.Profile
+	for _, p := range s.profiles {
+		if p.BeadID == beadID {
+			listed := *p
+			listed.Data = nil
+			out = append(out, &listed)
+		}
+	}
+	return out, nil
+}
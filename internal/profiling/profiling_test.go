@@ -0,0 +1,154 @@
+package profiling
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+const cpuTop = `File: profile.test
+Type: cpu
+Duration: 1.21s, Total samples = 1.10s (90.91%)
+Showing nodes accounting for 1.10s, 100% of 1.10s total
+      flat  flat%   sum%        cum   cum%
+     0.50s 45.45% 45.45%      0.60s 54.55%  example.com/app.parse
+     0.30s 27.27% 72.73%      0.30s 27.27%  runtime.memmove
+`
+
+const heapTop = `Type: alloc_space
+Showing nodes accounting for 512.50kB, 100% of 512.50kB total
+      flat  flat%   sum%        cum   cum%
+  512.50kB   100%   100%   512.50kB   100%  example.com/app.(*Buffer).grow
+`
+
+// fakeGo writes the profiles go test is asked for and answers go tool pprof
+type fakeGo struct {
+	toolrun.Fake
+	testErr error
+}
+
+func (f *fakeGo) handle(c toolrun.Command) ([]byte, []byte, error) {
+	args := c.Args
+	if len(args) > 1 && args[0] == "tool" {
+		if strings.Contains(strings.Join(args, " "), "alloc_space") {
+			return []byte(heapTop), nil, nil
+		}
+		return []byte(cpuTop), nil, nil
+	}
+	for i, arg := range args {
+		if (arg == "-cpuprofile" || arg == "-memprofile") && i+1 < len(args) {
+			_ = os.WriteFile(args[i+1], []byte("pprof"), 0644)
+		}
+		if strings.HasPrefix(arg, "--cpu=") {
+			_ = os.WriteFile(strings.TrimPrefix(arg, "--cpu="), []byte("pprof"), 0644)
+		}
+	}
+	return []byte("PASS\n"), nil, f.testErr
+}
+
+func newTestManager(t *testing.T, runner *fakeGo) (*Manager, string) {
+	t.Helper()
+	dir := t.TempDir()
+	m := NewManager(toolrun.StaticWorkDir(dir), nil, config.ProfilingConfig{})
+	runner.Handle = runner.handle
+	m.runner = runner.Runner()
+	return m, dir
+}
+
+func TestRunGoTest(t *testing.T) {
+	runner := &fakeGo{}
+	m, dir := newTestManager(t, runner)
+	if err := os.Mkdir(dir+"/store", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := m.Run(context.Background(), "proj-1", "bd-1", Options{Path: "store", Bench: "Put"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !result.Success || result.Target != "./store" || len(result.Profiles) != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+	test := runner.Calls[0].String()
+	for _, want := range []string{"go test -run ^$ ", "-bench Put -benchmem", "-cpuprofile ", "-memprofile ", "-o "} {
+		if !strings.Contains(test, want) {
+			t.Errorf("go test call %q lacks %q", test, want)
+		}
+	}
+
+	cpu, heap := result.Profiles[0], result.Profiles[1]
+	if cpu.Kind != models.ProfileCPU || cpu.Total != "1.10s" || len(cpu.Top) != 2 || cpu.Top[0].Function != "example.com/app.parse" || cpu.Top[0].CumPercent != 54.55 {
+		t.Errorf("cpu profile = %+v", cpu)
+	}
+	if heap.Kind != models.ProfileHeap || heap.Total != "512.50kB" || len(heap.Top) != 1 || heap.Top[0].Flat != "512.50kB" {
+		t.Errorf("heap profile = %+v", heap)
+	}
+
+	listed, err := m.List("bd-1")
+	if err != nil || len(listed) != 2 || listed[0].Data != nil {
+		t.Fatalf("List = %+v, %v", listed, err)
+	}
+	got, err := m.Get(cpu.ID)
+	if err != nil || string(got.Data) != "pprof" {
+		t.Errorf("Get = %+v, %v", got, err)
+	}
+	if _, err := m.Get("prof-missing"); err == nil {
+		t.Error("Get(missing) succeeded")
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	runner := &fakeGo{testErr: errors.New("exit status 1")}
+	m, _ := newTestManager(t, runner)
+
+	result, err := m.Run(context.Background(), "proj-1", "bd-1", Options{
+		Command: []string{"./bin/server", "--cpu={cpu}", "--heap={heap}"},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, "./bin/server failed") {
+		t.Errorf("result = %+v, want the command's failure", result)
+	}
+	// The command wrote a CPU profile before failing; the missing heap
+	// profile is noted
+	if len(result.Profiles) != 1 || result.Profiles[0].Kind != models.ProfileCPU {
+		t.Fatalf("profiles = %+v", result.Profiles)
+	}
+	if len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "no heap profile") {
+		t.Errorf("notes = %v", result.Notes)
+	}
+	if strings.Contains(runner.Calls[0].String(), "{cpu}") {
+		t.Errorf("placeholder not replaced: %s", runner.Calls[0].String())
+	}
+}
+
+func TestRunValidation(t *testing.T) {
+	m, _ := newTestManager(t, &fakeGo{})
+	ctx := context.Background()
+
+	if _, err := m.Run(ctx, "proj-1", "", Options{Path: "../elsewhere"}); err == nil {
+		t.Error("path outside the project accepted")
+	}
+	if _, err := m.Run(ctx, "proj-1", "", Options{Kinds: []string{"block"}}); err == nil {
+		t.Error("unsupported profile kind accepted")
+	}
+	if _, err := m.Run(ctx, "proj-1", "", Options{Command: []string{"./server"}}); err == nil {
+		t.Error("command without a profile placeholder accepted")
+	}
+	if _, err := m.Run(ctx, "proj-1", "", Options{Command: []string{"./server", "-memprofile", "{heap}"}, Kinds: []string{"cpu"}}); err == nil {
+		t.Error("command without a placeholder for the requested kind accepted")
+	}
+}
+
+func TestNewManagerDisabled(t *testing.T) {
+	if m := NewManager(toolrun.StaticWorkDir("/tmp"), nil, config.ProfilingConfig{Disabled: true}); m != nil {
+		t.Error("NewManager returned a manager when disabled")
+	}
+}
@@ -9,8 +9,11 @@ import (
 	"time"
 
 	"github.com/jordanhubbard/loom/internal/gitops"
+	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var bootstrapLog = logging.Module("bootstrap")
+
 // BootstrapRequest contains the parameters for bootstrapping a new project
 type BootstrapRequest struct {
 	GitHubURL string `json:"github_url"`
@@ -111,7 +114,7 @@ func (bs *BootstrapService) Bootstrap(ctx context.Context, req BootstrapRequest)
 	if bs.gitopsManager != nil {
 		pubKey, keyErr := bs.gitopsManager.EnsureProjectSSHKey(project.ID)
 		if keyErr != nil {
-			fmt.Printf("Warning: Failed to generate SSH key for project %s: %v\n", project.ID, keyErr)
+			bootstrapLog.Warn("Failed to generate SSH key", "project_id", project.ID, "error", keyErr)
 		} else {
 			publicKey = pubKey
 			setupInstructions = fmt.Sprintf(
@@ -127,7 +130,7 @@ func (bs *BootstrapService) Bootstrap(ctx context.Context, req BootstrapRequest)
 	initialBeadID, err := bs.createPMExpandPRDBead(ctx, projectPath, project.ID)
 	if err != nil {
 		// Log warning but don't fail bootstrap
-		fmt.Printf("Warning: Failed to create PM bead: %v\n", err)
+		bootstrapLog.Warn("Failed to create PM bead", "project_id", project.ID, "error", err)
 	}
 
 	return &BootstrapResult{
@@ -129,6 +129,13 @@ func (m *Manager) UpdateProject(id string, updates map[string]interface{}) error
 	if gitStrategy, ok := updates["git_strategy"].(string); ok {
 		project.GitStrategy = models.GitStrategy(gitStrategy)
 	}
+	if subprojects, ok := updates["subprojects"].([]models.Subproject); ok {
+		normalized, err := NormalizeSubprojects(subprojects)
+		if err != nil {
+			return err
+		}
+		project.Subprojects = normalized
+	}
 
 	project.UpdatedAt = time.Now()
 
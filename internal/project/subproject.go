@@ -0,0 +1,46 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// NormalizeSubprojects validates monorepo subproject definitions and cleans
+// their paths. Names default to the path and must be unique, as must paths.
+func NormalizeSubprojects(subprojects []models.Subproject) ([]models.Subproject, error) {
+	out := make([]models.Subproject, 0, len(subprojects))
+	names := map[string]struct{}{}
+	paths := map[string]struct{}{}
+	for _, sp := range subprojects {
+		raw := strings.TrimSpace(sp.Path)
+		if strings.HasPrefix(raw, "/") {
+			return nil, fmt.Errorf("subproject path %q must be relative to the repository root", sp.Path)
+		}
+		sp.Path = models.CleanSubprojectPath(raw)
+		if sp.Path == "" {
+			return nil, fmt.Errorf("subproject %q needs a path below the repository root", sp.Name)
+		}
+		if sp.Path == ".." || strings.HasPrefix(sp.Path, "../") {
+			return nil, fmt.Errorf("subproject path %q escapes the repository", raw)
+		}
+		if lsp := models.CleanSubprojectPath(sp.LSPRoot); lsp == ".." || strings.HasPrefix(lsp, "../") || strings.HasPrefix(strings.TrimSpace(sp.LSPRoot), "/") {
+			return nil, fmt.Errorf("subproject %q lsp_root must stay inside its path", sp.Path)
+		}
+		sp.Name = strings.TrimSpace(sp.Name)
+		if sp.Name == "" {
+			sp.Name = sp.Path
+		}
+		if _, dup := names[sp.Name]; dup {
+			return nil, fmt.Errorf("duplicate subproject name %q", sp.Name)
+		}
+		if _, dup := paths[sp.Path]; dup {
+			return nil, fmt.Errorf("duplicate subproject path %q", sp.Path)
+		}
+		names[sp.Name] = struct{}{}
+		paths[sp.Path] = struct{}{}
+		out = append(out, sp)
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+func TestNormalizeSubprojects(t *testing.T) {
+	got, err := NormalizeSubprojects([]models.Subproject{
+		{Path: "./services/api/", TestCommand: "go test ./..."},
+		{Name: "web", Path: "web"},
+	})
+	if err != nil {
+		t.Fatalf("NormalizeSubprojects: %v", err)
+	}
+	if got[0].Path != "services/api" || got[0].Name != "services/api" {
+		t.Fatalf("expected cleaned path used as name, got %+v", got[0])
+	}
+	if got[1].Name != "web" {
+		t.Fatalf("expected explicit name kept, got %+v", got[1])
+	}
+}
+
+func TestNormalizeSubprojectsRejectsInvalid(t *testing.T) {
+	cases := []struct {
+		subprojects []models.Subproject
+		wantErr     string
+	}{
+		{[]models.Subproject{{Path: "/srv/api"}}, "must be relative"},
+		{[]models.Subproject{{Path: "."}}, "needs a path"},
+		{[]models.Subproject{{Path: "../other"}}, "escapes"},
+		{[]models.Subproject{{Path: "web", LSPRoot: "../api"}}, "lsp_root"},
+		{[]models.Subproject{{Name: "api", Path: "a"}, {Name: "api", Path: "b"}}, "duplicate subproject name"},
+		{[]models.Subproject{{Name: "a", Path: "svc"}, {Name: "b", Path: "svc/"}}, "duplicate subproject path"},
+	}
+	for _, tc := range cases {
+		_, err := NormalizeSubprojects(tc.subprojects)
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("NormalizeSubprojects(%+v) error = %v, want %q", tc.subprojects, err, tc.wantErr)
+		}
+	}
+}
+
+func TestUpdateProjectSubprojects(t *testing.T) {
+	m := NewManager()
+	p, _ := m.CreateProject("mono", "", "main", "", nil)
+	if err := m.UpdateProject(p.ID, map[string]interface{}{"subprojects": []models.Subproject{{Path: "services/api/"}}}); err != nil {
+		t.Fatalf("UpdateProject: %v", err)
+	}
+	if len(p.Subprojects) != 1 || p.Subprojects[0].Path != "services/api" {
+		t.Fatalf("subprojects not applied: %+v", p.Subprojects)
+	}
+	if err := m.UpdateProject(p.ID, map[string]interface{}{"subprojects": []models.Subproject{{Path: "../x"}}}); err == nil {
+		t.Fatal("expected invalid subprojects to be rejected")
+	}
+	if len(p.Subprojects) != 1 {
+		t.Fatalf("invalid update should leave subprojects alone: %+v", p.Subprojects)
+	}
+}
@@ -0,0 +1,168 @@
+// Package promptfrag de-duplicates large content repeated within a prompt.
+//
+// Action results often carry the same content more than once: two actions
+// in one envelope read the same file, or a later iteration reads it again.
+// Intern replaces each large fenced block in a message with a reference to
+// a content-addressed fragment. Expand, called right before the prompt is
+// sent to the provider, puts the first reference to each fragment back in
+// full and turns the others into a short note, so the content is sent and
+// tokenized once per request. Token counts of fragments and messages are
+// cached so repacking the prompt on every iteration doesn't count the same
+// content again.
+package promptfrag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jordanhubbard/loom/internal/provider"
+)
+
+// MinFragmentBytes is the smallest fenced block worth replacing with a
+// reference
+const MinFragmentBytes = 512
+
+// maxCachedCounts bounds the token cache; it starts over when full
+const maxCachedCounts = 4096
+
+// DuplicateNote replaces repeated content when a prompt is expanded
+const DuplicateNote = "```\n(identical to the content shown earlier in this conversation; omitted)\n```"
+
+var (
+	// fencePattern matches a fenced block, with its fences
+	fencePattern = regexp.MustCompile("(?s)```[^\n`]*\n.*?\n```")
+	// refPattern matches a fragment reference
+	refPattern = regexp.MustCompile(`\[\[fragment:([0-9a-f]{16})\]\]`)
+)
+
+// Stats reports what de-duplication saved
+type Stats struct {
+	Fragments   int   `json:"fragments"`    // Distinct fragments stored
+	Duplicates  int64 `json:"duplicates"`   // Repeated fragments sent as a note instead
+	TokensSaved int64 `json:"tokens_saved"` // Prompt tokens the notes saved, across requests
+	CacheHits   int64 `json:"cache_hits"`
+	CacheMisses int64 `json:"cache_misses"`
+}
+
+// Store holds the fragments of one conversation
+type Store struct {
+	count func(string) int
+
+	mu        sync.Mutex
+	fragments map[string]*fragment
+	counts    map[string]int // Token cache, by text
+	stats     Stats
+}
+
+type fragment struct {
+	content string
+	tokens  int
+}
+
+// NewStore creates a store counting tokens with count
+func NewStore(count func(string) int) *Store {
+	return &Store{
+		count:     count,
+		fragments: make(map[string]*fragment),
+		counts:    make(map[string]int),
+	}
+}
+
+// Intern returns text with each fenced block of at least MinFragmentBytes
+// replaced by a reference to its fragment. A nil store returns text as is.
+func (s *Store) Intern(text string) string {
+	if s == nil || len(text) < MinFragmentBytes {
+		return text
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fencePattern.ReplaceAllStringFunc(text, func(block string) string {
+		if len(block) < MinFragmentBytes {
+			return block
+		}
+		sum := sha256.Sum256([]byte(block))
+		id := hex.EncodeToString(sum[:8])
+		if _, ok := s.fragments[id]; !ok {
+			s.fragments[id] = &fragment{content: block, tokens: s.count(block)}
+			s.stats.Fragments++
+		}
+		return "[[fragment:" + id + "]]"
+	})
+}
+
+// Tokens counts the tokens text will take once expanded, counting every
+// reference as its full fragment. Counts are cached.
+func (s *Store) Tokens(text string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n, ok := s.counts[text]; ok {
+		s.stats.CacheHits++
+		return n
+	}
+	s.stats.CacheMisses++
+	n := 0
+	literal := refPattern.ReplaceAllStringFunc(text, func(ref string) string {
+		if f := s.fragments[ref[len("[[fragment:"):len(ref)-2]]; f != nil {
+			n += f.tokens
+			return ""
+		}
+		return ref
+	})
+	n += s.count(literal)
+	if len(s.counts) >= maxCachedCounts {
+		s.counts = make(map[string]int)
+	}
+	s.counts[text] = n
+	return n
+}
+
+// Expand returns messages ready to send: the first reference to each
+// fragment is replaced with its content and later ones with DuplicateNote.
+// The messages passed in are left untouched. A nil store returns messages
+// as is.
+func (s *Store) Expand(messages []provider.ChatMessage) []provider.ChatMessage {
+	if s == nil {
+		return messages
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	noteTokens := s.count(DuplicateNote)
+	seen := make(map[string]bool)
+	expanded := make([]provider.ChatMessage, len(messages))
+	for i, m := range messages {
+		expanded[i] = m
+		if !strings.Contains(m.Content, "[[fragment:") {
+			continue
+		}
+		expanded[i].Content = refPattern.ReplaceAllStringFunc(m.Content, func(ref string) string {
+			id := ref[len("[[fragment:") : len(ref)-2]
+			f := s.fragments[id]
+			if f == nil {
+				return ref
+			}
+			if !seen[id] {
+				seen[id] = true
+				return f.content
+			}
+			s.stats.Duplicates++
+			if saved := f.tokens - noteTokens; saved > 0 {
+				s.stats.TokensSaved += int64(saved)
+			}
+			return DuplicateNote
+		})
+	}
+	return expanded
+}
+
+// Stats reports the fragments stored and what de-duplication saved
+func (s *Store) Stats() Stats {
+	if s == nil {
+		return Stats{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
@@ -0,0 +1,93 @@
+package promptfrag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/provider"
+)
+
+func countTokens(s string) int {
+	return len(s) / 4
+}
+
+func fileResult(path, content string) string {
+	return "### read_file — success\n**File:** `" + path + "`\n```\n" + content + "\n```\n"
+}
+
+func TestExpandSendsRepeatedContentOnce(t *testing.T) {
+	s := NewStore(countTokens)
+	content := strings.Repeat("func main() {}\n", 60)
+	envelope := fileResult("main.go", content) + "\n---\n\n" + fileResult("main.go", content)
+
+	interned := s.Intern(envelope)
+	if strings.Contains(interned, "func main") {
+		t.Fatal("expected the file content to be replaced by references")
+	}
+	if stats := s.Stats(); stats.Fragments != 1 {
+		t.Fatalf("expected one fragment for the repeated content, got %+v", stats)
+	}
+
+	messages := []provider.ChatMessage{
+		{Role: "system", Content: "system prompt"},
+		{Role: "user", Content: interned},
+		{Role: "user", Content: s.Intern(fileResult("main.go", content))},
+	}
+	expanded := s.Expand(messages)
+	all := expanded[1].Content + expanded[2].Content
+	if n := strings.Count(all, content); n != 1 {
+		t.Fatalf("expected the content to be sent once, got %d copies", n)
+	}
+	if !strings.HasPrefix(expanded[1].Content, fileResult("main.go", content)) {
+		t.Error("expected the first reference to expand to the original block")
+	}
+	if n := strings.Count(all, DuplicateNote); n != 2 {
+		t.Errorf("expected two duplicate notes, got %d", n)
+	}
+	if messages[1].Content != interned {
+		t.Error("Expand must not modify the messages passed in")
+	}
+	if stats := s.Stats(); stats.Duplicates != 2 || stats.TokensSaved == 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestExpandRestoresContentAfterFirstMessageIsDropped(t *testing.T) {
+	s := NewStore(countTokens)
+	content := strings.Repeat("x := 1\n", 100)
+	s.Intern(fileResult("a.go", content))
+	second := s.Intern(fileResult("a.go", content))
+
+	// Packing dropped the first message; the second now carries the content
+	expanded := s.Expand([]provider.ChatMessage{{Role: "user", Content: second}})
+	if expanded[0].Content != fileResult("a.go", content) {
+		t.Fatalf("expected the content back in full, got %q", expanded[0].Content)
+	}
+}
+
+func TestInternLeavesSmallBlocksInline(t *testing.T) {
+	s := NewStore(countTokens)
+	text := strings.Repeat("output line\n", 50) + fileResult("small.go", "package small")
+	if got := s.Intern(text); got != text {
+		t.Errorf("expected small blocks to stay inline, got %q", got)
+	}
+	var nilStore *Store
+	if got := nilStore.Intern(text); got != text {
+		t.Error("expected a nil store to leave text as is")
+	}
+}
+
+func TestTokensCountsFragmentsAndCaches(t *testing.T) {
+	s := NewStore(countTokens)
+	content := strings.Repeat("abcd", 200)
+	text := fileResult("f.txt", content)
+	interned := s.Intern(text)
+
+	if got, want := s.Tokens(interned), countTokens(text); got < want-1 || got > want+1 {
+		t.Errorf("Tokens = %d, want about %d", got, want)
+	}
+	s.Tokens(interned)
+	if stats := s.Stats(); stats.CacheHits != 1 || stats.CacheMisses != 1 {
+		t.Errorf("expected the second count to come from the cache: %+v", stats)
+	}
+}
@@ -0,0 +1,290 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// balancerAlpha weights the newest sample in the rolling latency and
+	// error rate, as the registry does for provider latency
+	balancerAlpha = 0.2
+	// ejectAfter consecutive failures take an endpoint out of rotation
+	ejectAfter = 3
+	// ejectFor is how long an ejected endpoint sits out
+	ejectFor = 30 * time.Second
+	// sessionTTL is how long an idle streaming session stays pinned
+	sessionTTL = 10 * time.Minute
+	// failurePenaltyMs is what an endpoint failing every request costs, as
+	// rolling latency; fast failures must not make an endpoint look good
+	failurePenaltyMs = 10000
+)
+
+type sessionKey struct{}
+
+// WithSessionKey marks streaming requests made with ctx as part of a
+// session, e.g. a bead or a pair conversation. A balanced provider keeps a
+// session's streams on the same endpoint while it stays healthy.
+func WithSessionKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, key)
+}
+
+func sessionFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(sessionKey{}).(string)
+	return key
+}
+
+// EndpointStats reports how one endpoint of a provider is doing
+type EndpointStats struct {
+	Endpoint     string     `json:"endpoint"`
+	Healthy      bool       `json:"healthy"`
+	AvgLatencyMs float64    `json:"avg_latency_ms"`
+	ErrorRate    float64    `json:"error_rate"`
+	InFlight     int        `json:"in_flight"`
+	Requests     int64      `json:"requests"`
+	Failures     int64      `json:"failures"`
+	Sessions     int        `json:"sessions"`
+	EjectedUntil *time.Time `json:"ejected_until,omitempty"`
+}
+
+// Balancer spreads a provider's requests across equivalent endpoints
+// serving the same model. Each request goes to the endpoint with the best
+// rolling latency and error rate, weighed by the requests already in
+// flight on it; endpoints that fail repeatedly sit out for a while. A
+// failed request is retried once on another endpoint, and streams in a
+// session (see WithSessionKey) stick to one endpoint.
+type Balancer struct {
+	mu        sync.Mutex
+	endpoints []*endpoint
+	sessions  map[string]*session
+
+	now func() time.Time
+}
+
+type endpoint struct {
+	url      string
+	protocol Protocol
+
+	latencyMs    float64
+	errorRate    float64
+	inFlight     int
+	requests     int64
+	failures     int64
+	consecutive  int
+	ejectedUntil time.Time
+}
+
+type session struct {
+	endpoint *endpoint
+	lastUsed time.Time
+}
+
+// NewBalancer balances across protocols, each talking to the endpoint of
+// the same index in urls
+func NewBalancer(urls []string, protocols []Protocol) *Balancer {
+	b := &Balancer{sessions: make(map[string]*session), now: time.Now}
+	for i, p := range protocols {
+		b.endpoints = append(b.endpoints, &endpoint{url: urls[i], protocol: p})
+	}
+	return b
+}
+
+// CreateChatCompletion sends the request to the healthiest endpoint
+func (b *Balancer) CreateChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	var resp *ChatCompletionResponse
+	err := b.do(ctx, "", func(p Protocol) (bool, error) {
+		var err error
+		resp, err = p.CreateChatCompletion(ctx, req)
+		return true, err
+	})
+	return resp, err
+}
+
+// CreateChatCompletionStream streams from the session's endpoint, or the
+// healthiest one
+func (b *Balancer) CreateChatCompletionStream(ctx context.Context, req *ChatCompletionRequest, handler StreamHandler) error {
+	return b.do(ctx, sessionFromContext(ctx), func(p Protocol) (bool, error) {
+		sp, ok := p.(StreamingProtocol)
+		if !ok {
+			return true, errors.New("endpoint does not support streaming")
+		}
+		started := false
+		err := sp.CreateChatCompletionStream(ctx, req, func(chunk *StreamChunk) error {
+			started = true
+			return handler(chunk)
+		})
+		// A stream that delivered chunks can't be replayed elsewhere
+		return !started, err
+	})
+}
+
+// GetModels lists the models of the healthiest endpoint
+func (b *Balancer) GetModels(ctx context.Context) ([]Model, error) {
+	var models []Model
+	err := b.do(ctx, "", func(p Protocol) (bool, error) {
+		var err error
+		models, err = p.GetModels(ctx)
+		return true, err
+	})
+	return models, err
+}
+
+// do runs call on the picked endpoint and records the outcome. A failure
+// is retried once on another endpoint when call says it can be and the
+// caller hasn't given up.
+func (b *Balancer) do(ctx context.Context, sessionID string, call func(Protocol) (bool, error)) error {
+	var tried *endpoint
+	for {
+		e := b.acquire(sessionID, tried)
+		start := b.now()
+		retryable, err := call(e.protocol)
+		b.release(e, b.now().Sub(start), err, ctx.Err() != nil)
+		if err == nil || tried != nil || !retryable || ctx.Err() != nil || len(b.endpoints) == 1 {
+			return err
+		}
+		tried = e
+	}
+}
+
+// acquire picks the endpoint for a request, skipping exclude, and counts
+// the request as in flight on it
+func (b *Balancer) acquire(sessionID string, exclude *endpoint) *endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	for id, s := range b.sessions {
+		if now.Sub(s.lastUsed) > sessionTTL {
+			delete(b.sessions, id)
+		}
+	}
+
+	var e *endpoint
+	if s := b.sessions[sessionID]; s != nil && s.endpoint != exclude && !s.endpoint.ejected(now) {
+		e = s.endpoint
+	} else {
+		e = b.pick(now, exclude)
+	}
+	if sessionID != "" {
+		b.sessions[sessionID] = &session{endpoint: e, lastUsed: now}
+	}
+	e.inFlight++
+	return e
+}
+
+// pick returns the endpoint with the lowest cost. Endpoints without
+// requests yet cost nothing, so each one gets tried, and an endpoint back
+// from ejection starts afresh so it gets probed. When every endpoint is
+// ejected the one due back soonest is used. Callers hold b.mu.
+func (b *Balancer) pick(now time.Time, exclude *endpoint) *endpoint {
+	var best, soonest *endpoint
+	var bestCost float64
+	for _, e := range b.endpoints {
+		if e == exclude && len(b.endpoints) > 1 {
+			continue
+		}
+		if e.ejected(now) {
+			if soonest == nil || e.ejectedUntil.Before(soonest.ejectedUntil) {
+				soonest = e
+			}
+			continue
+		}
+		if !e.ejectedUntil.IsZero() {
+			e.ejectedUntil, e.latencyMs, e.errorRate = time.Time{}, 0, 0
+		}
+		if cost := e.cost(); best == nil || cost < bestCost {
+			best, bestCost = e, cost
+		}
+	}
+	if best == nil {
+		return soonest
+	}
+	return best
+}
+
+// release records a finished request. Requests the caller cancelled say
+// nothing about the endpoint, so they only leave the in-flight count.
+func (b *Balancer) release(e *endpoint, elapsed time.Duration, err error, cancelled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e.inFlight--
+	if cancelled {
+		return
+	}
+	e.requests++
+	failed := 0.0
+	if err != nil {
+		failed = 1
+		e.failures++
+		e.consecutive++
+		if e.consecutive >= ejectAfter {
+			e.ejectedUntil = b.now().Add(ejectFor)
+			e.consecutive = 0
+		}
+	} else {
+		e.consecutive = 0
+		latency := float64(elapsed.Milliseconds())
+		if e.latencyMs == 0 {
+			e.latencyMs = latency
+		} else {
+			e.latencyMs = (1-balancerAlpha)*e.latencyMs + balancerAlpha*latency
+		}
+	}
+	e.errorRate = (1-balancerAlpha)*e.errorRate + balancerAlpha*failed
+}
+
+func (e *endpoint) ejected(now time.Time) bool {
+	return now.Before(e.ejectedUntil)
+}
+
+// cost ranks endpoints: rolling latency scaled up by the requests already
+// in flight on it, plus a penalty for its error rate
+func (e *endpoint) cost() float64 {
+	if e.requests == 0 {
+		return float64(e.inFlight)
+	}
+	return (e.latencyMs+1)*float64(1+e.inFlight) + e.errorRate*failurePenaltyMs
+}
+
+// Stats reports each endpoint's rolling latency, error rate and load
+func (b *Balancer) Stats() []EndpointStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	sessions := make(map[*endpoint]int)
+	for _, s := range b.sessions {
+		sessions[s.endpoint]++
+	}
+	stats := make([]EndpointStats, 0, len(b.endpoints))
+	for _, e := range b.endpoints {
+		st := EndpointStats{
+			Endpoint:     e.url,
+			Healthy:      !e.ejected(now),
+			AvgLatencyMs: e.latencyMs,
+			ErrorRate:    e.errorRate,
+			InFlight:     e.inFlight,
+			Requests:     e.requests,
+			Failures:     e.failures,
+			Sessions:     sessions[e],
+		}
+		if !st.Healthy {
+			until := e.ejectedUntil
+			st.EjectedUntil = &until
+		}
+		stats = append(stats, st)
+	}
+	return stats
+}
+
+func (b *Balancer) wrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	for _, e := range b.endpoints {
+		if ts, ok := e.protocol.(transportSetter); ok {
+			ts.wrapTransport(wrap)
+		}
+	}
+}
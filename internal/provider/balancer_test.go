@@ -0,0 +1,203 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeEndpoint is a protocol whose latency and failures the test controls
+type fakeEndpoint struct {
+	name  string
+	delay time.Duration
+	fail  bool
+	calls int
+}
+
+func (f *fakeEndpoint) CreateChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	f.calls++
+	time.Sleep(f.delay)
+	if f.fail {
+		return nil, errors.New(f.name + " down")
+	}
+	return &ChatCompletionResponse{ID: f.name}, nil
+}
+
+func (f *fakeEndpoint) GetModels(ctx context.Context) ([]Model, error) {
+	return []Model{{ID: f.name}}, nil
+}
+
+func (f *fakeEndpoint) CreateChatCompletionStream(ctx context.Context, req *ChatCompletionRequest, handler StreamHandler) error {
+	f.calls++
+	if f.fail {
+		return errors.New(f.name + " down")
+	}
+	return handler(&StreamChunk{ID: f.name})
+}
+
+func testBalancer(endpoints ...*fakeEndpoint) *Balancer {
+	urls := make([]string, len(endpoints))
+	protocols := make([]Protocol, len(endpoints))
+	for i, e := range endpoints {
+		urls[i], protocols[i] = e.name, e
+	}
+	return NewBalancer(urls, protocols)
+}
+
+func TestBalancerPrefersFasterEndpoint(t *testing.T) {
+	slow := &fakeEndpoint{name: "slow", delay: 20 * time.Millisecond}
+	fast := &fakeEndpoint{name: "fast"}
+	b := testBalancer(slow, fast)
+
+	for i := 0; i < 10; i++ {
+		if _, err := b.CreateChatCompletion(context.Background(), &ChatCompletionRequest{}); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if slow.calls != 1 {
+		t.Errorf("slow endpoint got %d requests, want only its first probe", slow.calls)
+	}
+	if fast.calls != 9 {
+		t.Errorf("fast endpoint got %d requests, want 9", fast.calls)
+	}
+}
+
+func TestBalancerFailsOver(t *testing.T) {
+	bad := &fakeEndpoint{name: "bad", fail: true}
+	good := &fakeEndpoint{name: "good", delay: 5 * time.Millisecond}
+	b := testBalancer(bad, good)
+
+	resp, err := b.CreateChatCompletion(context.Background(), &ChatCompletionRequest{})
+	if err != nil || resp.ID != "good" {
+		t.Fatalf("expected failover to good endpoint, got %v, %v", resp, err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := b.CreateChatCompletion(context.Background(), &ChatCompletionRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bad.calls != 1 {
+		t.Errorf("failing endpoint got %d requests, want 1", bad.calls)
+	}
+}
+
+func TestBalancerEjectsAndProbesEndpoint(t *testing.T) {
+	flaky := &fakeEndpoint{name: "flaky", fail: true}
+	b := testBalancer(flaky)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < ejectAfter; i++ {
+		_, _ = b.CreateChatCompletion(context.Background(), &ChatCompletionRequest{})
+	}
+	stats := b.Stats()
+	if stats[0].Healthy || stats[0].EjectedUntil == nil {
+		t.Fatalf("expected endpoint to be ejected: %+v", stats[0])
+	}
+
+	flaky.fail = false
+	now = now.Add(ejectFor)
+	if _, err := b.CreateChatCompletion(context.Background(), &ChatCompletionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if stats := b.Stats(); !stats[0].Healthy || stats[0].ErrorRate != 0 {
+		t.Errorf("expected endpoint back in rotation with a fresh error rate: %+v", stats[0])
+	}
+}
+
+func TestBalancerReturnsErrorWhenAllFail(t *testing.T) {
+	b := testBalancer(&fakeEndpoint{name: "a", fail: true}, &fakeEndpoint{name: "b", fail: true})
+	if _, err := b.CreateChatCompletion(context.Background(), &ChatCompletionRequest{}); err == nil {
+		t.Fatal("expected an error when every endpoint fails")
+	}
+}
+
+func TestBalancerStickySessions(t *testing.T) {
+	a := &fakeEndpoint{name: "a"}
+	c := &fakeEndpoint{name: "c"}
+	b := testBalancer(a, c)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	ctx := WithSessionKey(context.Background(), "bead-1")
+	var first string
+	for i := 0; i < 5; i++ {
+		var got string
+		err := b.CreateChatCompletionStream(ctx, &ChatCompletionRequest{}, func(chunk *StreamChunk) error {
+			got = chunk.ID
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == "" {
+			first = got
+		} else if got != first {
+			t.Fatalf("stream %d went to %s, want sticky endpoint %s", i, got, first)
+		}
+	}
+
+	// An idle session is released
+	now = now.Add(sessionTTL + time.Second)
+	b.acquire("other", nil).inFlight--
+	sessions := 0
+	for _, st := range b.Stats() {
+		sessions += st.Sessions
+	}
+	if sessions != 1 {
+		t.Errorf("expected only the new session to remain, got %+v", b.Stats())
+	}
+}
+
+func TestBalancerStickySessionMovesOffFailedEndpoint(t *testing.T) {
+	a := &fakeEndpoint{name: "a"}
+	c := &fakeEndpoint{name: "c"}
+	b := testBalancer(a, c)
+	ctx := WithSessionKey(context.Background(), "conv")
+
+	var pinned string
+	_ = b.CreateChatCompletionStream(ctx, &ChatCompletionRequest{}, func(chunk *StreamChunk) error {
+		pinned = chunk.ID
+		return nil
+	})
+	failing, other := a, c
+	if pinned == "c" {
+		failing, other = c, a
+	}
+	failing.fail = true
+
+	var got string
+	err := b.CreateChatCompletionStream(ctx, &ChatCompletionRequest{}, func(chunk *StreamChunk) error {
+		got = chunk.ID
+		return nil
+	})
+	if err != nil || got != other.name {
+		t.Fatalf("expected stream to fail over to %s, got %q, %v", other.name, got, err)
+	}
+}
+
+func TestRegistryBalancesProviderEndpoints(t *testing.T) {
+	r := NewRegistry()
+	err := r.Register(&ProviderConfig{ID: "p", Type: "mock", Status: "active", Endpoint: "mock://a", Endpoints: []string{"mock://b", "mock://a", " "}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := r.EndpointStats("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Endpoint != "mock://a" || stats[1].Endpoint != "mock://b" {
+		t.Fatalf("unexpected endpoints: %+v", stats)
+	}
+	if _, err := r.SendChatCompletion(context.Background(), "p", &ChatCompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "hi"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Register(&ProviderConfig{ID: "single", Type: "mock", Endpoint: "mock://c"}); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := r.EndpointStats("single"); len(stats) != 1 || stats[0].Endpoint != "mock://c" {
+		t.Fatalf("unexpected single endpoint stats: %+v", stats)
+	}
+}
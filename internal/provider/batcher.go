@@ -0,0 +1,203 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Batching defaults
+const (
+	DefaultBatchWindow = 10 * time.Millisecond
+	DefaultMaxBatch    = 64
+)
+
+// batchTimeout bounds a batch's provider call; a batch outlives any one
+// caller, so it doesn't run on a caller's context
+const batchTimeout = 2 * time.Minute
+
+// BatchStats reports how much batching has saved
+type BatchStats struct {
+	Enabled     bool    `json:"enabled"`
+	Requests    int64   `json:"requests"`    // Requests submitted to the batcher
+	Passthrough int64   `json:"passthrough"` // Requests sent on their own: a full batch of texts already
+	Batched     int64   `json:"batched"`     // Requests sent as part of a batch
+	Batches     int64   `json:"batches"`     // Provider calls the batched requests took
+	Texts       int64   `json:"texts"`       // Texts embedded
+	Failures    int64   `json:"failures"`    // Batches the provider failed
+	CallsSaved  int64   `json:"calls_saved"` // Provider calls avoided by batching
+	AvgBatch    float64 `json:"avg_batch"`   // Requests per batch
+}
+
+// Batcher collects embedding requests for the same provider and model
+// arriving within a short window and sends them as one embeddings call.
+// Callers block until their own result is back, as if they had sent the
+// request alone.
+type Batcher struct {
+	registry *Registry
+	window   time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	pending map[string]*batch
+	stats   BatchStats
+}
+
+// batch is a set of requests waiting to go out together
+type batch struct {
+	key        string
+	providerID string
+	model      string
+	size       int // Texts
+	items      []*batchItem
+	timer      *time.Timer
+}
+
+// batchItem is one caller's request in a batch
+type batchItem struct {
+	texts []string
+
+	done       chan struct{}
+	embeddings [][]float32
+	err        error
+}
+
+// NewBatcher creates a batcher sending through registry, or returns nil
+// when batching is disabled
+func NewBatcher(registry *Registry, cfg config.RequestBatchingConfig) *Batcher {
+	if !cfg.Enabled || registry == nil {
+		return nil
+	}
+	b := &Batcher{
+		registry: registry,
+		window:   cfg.Window,
+		maxBatch: cfg.MaxBatch,
+		pending:  make(map[string]*batch),
+	}
+	if b.window <= 0 {
+		b.window = DefaultBatchWindow
+	}
+	if b.maxBatch <= 0 {
+		b.maxBatch = DefaultMaxBatch
+	}
+	return b
+}
+
+// Embed embeds texts with a provider, in one call with other embedding
+// requests for the same provider and model arriving within the window
+func (b *Batcher) Embed(ctx context.Context, providerID, model string, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if len(texts) >= b.maxBatch {
+		b.count(func(s *BatchStats) { s.Requests++; s.Passthrough++; s.Texts += int64(len(texts)) })
+		resp, err := b.registry.SendEmbeddings(ctx, providerID, &EmbeddingRequest{Model: model, Input: texts})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Embeddings, nil
+	}
+	item := &batchItem{texts: texts, done: make(chan struct{})}
+	b.add(providerID+"\x00"+model, providerID, model, item)
+	select {
+	case <-item.done:
+		return item.embeddings, item.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// add puts an item in the batch for key, starting the batch's window when
+// it is the first, and sends the batch at once when it is full
+func (b *Batcher) add(key, providerID, model string, item *batchItem) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	size := len(item.texts)
+	b.stats.Requests++
+	b.stats.Texts += int64(size)
+	bt := b.pending[key]
+	if bt != nil && bt.size+size > b.maxBatch {
+		b.flushLocked(bt)
+		bt = nil
+	}
+	if bt == nil {
+		bt = &batch{key: key, providerID: providerID, model: model}
+		b.pending[key] = bt
+		bt.timer = time.AfterFunc(b.window, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if b.pending[key] == bt {
+				b.flushLocked(bt)
+			}
+		})
+	}
+	bt.items = append(bt.items, item)
+	bt.size += size
+	if bt.size >= b.maxBatch {
+		b.flushLocked(bt)
+	}
+}
+
+// flushLocked takes a batch out of the pending set and sends it in the
+// background. Callers hold b.mu.
+func (b *Batcher) flushLocked(bt *batch) {
+	bt.timer.Stop()
+	delete(b.pending, bt.key)
+	b.stats.Batched += int64(len(bt.items))
+	b.stats.Batches++
+	go b.sendEmbeddings(bt)
+}
+
+// sendEmbeddings embeds every item's texts in one call and hands each item
+// its share of the embeddings
+func (b *Batcher) sendEmbeddings(bt *batch) {
+	ctx, cancel := context.WithTimeout(context.Background(), batchTimeout)
+	defer cancel()
+	var texts []string
+	for _, item := range bt.items {
+		texts = append(texts, item.texts...)
+	}
+	resp, err := b.registry.SendEmbeddings(ctx, bt.providerID, &EmbeddingRequest{Model: bt.model, Input: texts})
+	if err == nil && len(resp.Embeddings) != len(texts) {
+		err = fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+	if err != nil {
+		b.count(func(s *BatchStats) { s.Failures++ })
+	}
+	offset := 0
+	for _, item := range bt.items {
+		if err != nil {
+			item.err = err
+		} else {
+			item.embeddings = resp.Embeddings[offset : offset+len(item.texts)]
+			offset += len(item.texts)
+		}
+		close(item.done)
+	}
+}
+
+func (b *Batcher) count(update func(*BatchStats)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	update(&b.stats)
+}
+
+// Stats reports how many requests were batched and the provider calls
+// that saved
+func (b *Batcher) Stats() BatchStats {
+	if b == nil {
+		return BatchStats{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := b.stats
+	stats.Enabled = true
+	stats.CallsSaved = stats.Batched - stats.Batches
+	if stats.Batches > 0 {
+		stats.AvgBatch = float64(stats.Batched) / float64(stats.Batches)
+	}
+	return stats
+}
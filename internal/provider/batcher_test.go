@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// countingMock counts the provider calls a mock provider receives
+type countingMock struct {
+	*MockProvider
+	mu     sync.Mutex
+	embeds int
+	texts  int
+}
+
+func (m *countingMock) CreateEmbeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	m.mu.Lock()
+	m.embeds++
+	m.texts += len(req.Input)
+	m.mu.Unlock()
+	return m.MockProvider.CreateEmbeddings(ctx, req)
+}
+
+func batchRegistry(protocol Protocol) *Registry {
+	r := NewRegistry()
+	r.providers["p"] = &RegisteredProvider{
+		Config:   &ProviderConfig{ID: "p", Status: "active", Model: "m"},
+		Protocol: protocol,
+	}
+	return r
+}
+
+func TestNewBatcherDisabled(t *testing.T) {
+	if b := NewBatcher(NewRegistry(), config.RequestBatchingConfig{}); b != nil {
+		t.Fatal("expected no batcher when batching is disabled")
+	}
+	var b *Batcher
+	if stats := b.Stats(); stats.Enabled {
+		t.Errorf("expected a nil batcher to report batching disabled: %+v", stats)
+	}
+}
+
+func TestBatcherMergesEmbeddingRequests(t *testing.T) {
+	mock := &countingMock{MockProvider: NewMockProvider()}
+	b := NewBatcher(batchRegistry(mock), config.RequestBatchingConfig{Enabled: true, Window: 50 * time.Millisecond})
+
+	texts := [][]string{{"alpha"}, {"beta", "gamma"}, {"delta"}}
+	results := make([][][]float32, len(texts))
+	var wg sync.WaitGroup
+	for i := range texts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			vecs, err := b.Embed(context.Background(), "p", "", texts[i])
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = vecs
+		}(i)
+	}
+	wg.Wait()
+
+	if mock.embeds != 1 || mock.texts != 4 {
+		t.Fatalf("expected one provider call for 4 texts, got %d calls for %d texts", mock.embeds, mock.texts)
+	}
+	// Each caller gets the embeddings of its own texts
+	for i, vecs := range results {
+		want, _ := NewMockProvider().CreateEmbeddings(context.Background(), &EmbeddingRequest{Input: texts[i]})
+		if len(vecs) != len(texts[i]) {
+			t.Fatalf("caller %d got %d embeddings, want %d", i, len(vecs), len(texts[i]))
+		}
+		for j := range vecs {
+			for k := range vecs[j] {
+				if vecs[j][k] != want.Embeddings[j][k] {
+					t.Fatalf("caller %d got another caller's embedding for text %d", i, j)
+				}
+			}
+		}
+	}
+	if stats := b.Stats(); stats.Batches != 1 || stats.Batched != 3 || stats.CallsSaved != 2 || stats.Texts != 4 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestBatcherFlushesFullBatch(t *testing.T) {
+	mock := &countingMock{MockProvider: NewMockProvider()}
+	b := NewBatcher(batchRegistry(mock), config.RequestBatchingConfig{Enabled: true, Window: time.Minute, MaxBatch: 2})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := b.Embed(context.Background(), "p", "m", []string{"text"}); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a full batch should be sent without waiting out the window")
+	}
+}
+
+func TestBatcherWithoutEmbeddingSupport(t *testing.T) {
+	endpoint := &fakeEndpoint{name: "plain"}
+	b := NewBatcher(batchRegistry(endpoint), config.RequestBatchingConfig{Enabled: true, Window: 10 * time.Millisecond})
+
+	if _, err := b.Embed(context.Background(), "p", "m", []string{"text"}); err == nil {
+		t.Error("expected an error from a provider without embeddings")
+	}
+}
@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Defaults for settings left empty in the provider queue config
+const (
+	DefaultQueueMaxWait    = 2 * time.Minute
+	DefaultQueueMaxRetries = 5
+	DefaultQueueBackoff    = 5 * time.Second
+	DefaultQueueMaxBackoff = time.Minute
+)
+
+// WithDefaults fills in unset provider queue settings
+func WithDefaults(cfg config.ProviderQueueConfig) config.ProviderQueueConfig {
+	if cfg.MaxWait == 0 {
+		cfg.MaxWait = DefaultQueueMaxWait
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = DefaultQueueMaxRetries
+	}
+	if cfg.DefaultBackoff <= 0 {
+		cfg.DefaultBackoff = DefaultQueueBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultQueueMaxBackoff
+	}
+	return cfg
+}
+
+// QueueConfigFrom converts the provider queue config
+func QueueConfigFrom(cfg config.ProviderQueueConfig) QueueConfig {
+	return QueueConfig{
+		MaxWait:           cfg.MaxWait,
+		MaxRetries:        cfg.MaxRetries,
+		DefaultBackoff:    cfg.DefaultBackoff,
+		MaxBackoff:        cfg.MaxBackoff,
+		RequestsPerMinute: cfg.RequestsPerMinute,
+	}
+}
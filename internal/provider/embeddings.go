@@ -0,0 +1,232 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// mockEmbeddingDimensions is the size of the mock provider's embeddings
+const mockEmbeddingDimensions = 64
+
+// EmbeddingRequest asks for the embeddings of one or more texts
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbeddingResponse holds one embedding per input, in input order
+type EmbeddingResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+	Usage      struct {
+		PromptTokens int `json:"prompt_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// EmbeddingProtocol is implemented by protocols that can embed text. Every
+// call embeds all of the request's inputs at once.
+type EmbeddingProtocol interface {
+	CreateEmbeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error)
+}
+
+// CreateEmbeddings embeds the inputs with the OpenAI embeddings API
+func (p *OpenAIProvider) CreateEmbeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	url := fmt.Sprintf("%s/embeddings", p.endpoint)
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.apiKey))
+	}
+
+	respBody, err := doEmbeddingRequest(p.client, httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var openaiResp struct {
+		Model string `json:"model"`
+		Data  []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+			TotalTokens  int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := unmarshalJSON(respBody, &openaiResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(openaiResp.Data) != len(req.Input) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(req.Input), len(openaiResp.Data))
+	}
+
+	resp := &EmbeddingResponse{Model: openaiResp.Model, Embeddings: make([][]float32, len(req.Input))}
+	for i, d := range openaiResp.Data {
+		// Results are normally in input order, but the index is authoritative
+		idx := d.Index
+		if idx < 0 || idx >= len(req.Input) {
+			idx = i
+		}
+		resp.Embeddings[idx] = d.Embedding
+	}
+	resp.Usage.PromptTokens = openaiResp.Usage.PromptTokens
+	resp.Usage.TotalTokens = openaiResp.Usage.TotalTokens
+	return resp, nil
+}
+
+// CreateEmbeddings embeds the inputs with Ollama's /api/embed
+func (p *OllamaProvider) CreateEmbeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	url := fmt.Sprintf("%s/api/embed", p.endpoint)
+	model := strings.TrimSpace(req.Model)
+	if model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	body, err := json.Marshal(EmbeddingRequest{Model: model, Input: req.Input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	respBody, err := doEmbeddingRequest(p.client, httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var ollamaResp struct {
+		Model           string      `json:"model"`
+		Embeddings      [][]float32 `json:"embeddings"`
+		PromptEvalCount int         `json:"prompt_eval_count"`
+	}
+	if err := json.Unmarshal(respBody, &ollamaResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(ollamaResp.Embeddings) != len(req.Input) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(req.Input), len(ollamaResp.Embeddings))
+	}
+
+	resp := &EmbeddingResponse{Model: ollamaResp.Model, Embeddings: ollamaResp.Embeddings}
+	resp.Usage.PromptTokens = ollamaResp.PromptEvalCount
+	resp.Usage.TotalTokens = ollamaResp.PromptEvalCount
+	return resp, nil
+}
+
+// doEmbeddingRequest sends an embedding request and returns the body of a
+// successful response
+func doEmbeddingRequest(client *http.Client, httpReq *http.Request) ([]byte, error) {
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &RateLimitError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), Body: string(respBody)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(respBody))
+	}
+	return respBody, nil
+}
+
+// CreateEmbeddings returns a deterministic embedding of each input, so the
+// same text always embeds the same way
+func (p *MockProvider) CreateEmbeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	resp := &EmbeddingResponse{Model: req.Model, Embeddings: make([][]float32, len(req.Input))}
+	for i, text := range req.Input {
+		vec := make([]float32, mockEmbeddingDimensions)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			vec[h.Sum32()%mockEmbeddingDimensions]++
+		}
+		var norm float64
+		for _, v := range vec {
+			norm += float64(v) * float64(v)
+		}
+		if norm > 0 {
+			for j := range vec {
+				vec[j] = float32(float64(vec[j]) / math.Sqrt(norm))
+			}
+		}
+		resp.Embeddings[i] = vec
+		resp.Usage.PromptTokens += len(text)
+	}
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens
+	return resp, nil
+}
+
+// CreateEmbeddings embeds the inputs on the healthiest endpoint
+func (b *Balancer) CreateEmbeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	var resp *EmbeddingResponse
+	err := b.do(ctx, "", func(p Protocol) (bool, error) {
+		ep, ok := p.(EmbeddingProtocol)
+		if !ok {
+			return false, fmt.Errorf("endpoint does not support embeddings")
+		}
+		var err error
+		resp, err = ep.CreateEmbeddings(ctx, req)
+		return true, err
+	})
+	return resp, err
+}
+
+// SendEmbeddings embeds texts with a provider, using the provider's model
+// when the request names none
+func (r *Registry) SendEmbeddings(ctx context.Context, providerID string, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	start := time.Now()
+	registered, err := r.Get(providerID)
+	if err != nil {
+		return nil, err
+	}
+	if registered.Config != nil && !isProviderHealthy(registered.Config.Status) {
+		return nil, fmt.Errorf("provider %s is disabled", providerID)
+	}
+	ep, ok := registered.Protocol.(EmbeddingProtocol)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support embeddings", providerID)
+	}
+	if req.Model == "" && registered.Config != nil {
+		req.Model = registered.Config.Model
+	}
+
+	resp, err := ep.CreateEmbeddings(ctx, req)
+
+	r.mu.RLock()
+	callback := r.metricsCallback
+	r.mu.RUnlock()
+	if callback != nil {
+		var tokens int64
+		if resp != nil {
+			tokens = int64(resp.Usage.TotalTokens)
+		}
+		callback(providerID, err == nil, time.Since(start).Milliseconds(), tokens)
+	}
+	return resp, err
+}
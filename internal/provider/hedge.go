@@ -0,0 +1,223 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Hedging defaults
+const (
+	DefaultHedgeDelay     = 2 * time.Second
+	DefaultHedgeMaxTokens = 2048
+)
+
+// HedgeStats reports how hedged requests have fared
+type HedgeStats struct {
+	Enabled   bool  `json:"enabled"`
+	Requests  int64 `json:"requests"`   // Requests eligible for hedging
+	Hedged    int64 `json:"hedged"`     // Requests a second provider was sent
+	HedgeWins int64 `json:"hedge_wins"` // Hedged requests the second provider answered first
+	Failures  int64 `json:"failures"`   // Requests no provider answered
+	// WinRate is the share of hedged requests the second provider won
+	WinRate float64 `json:"win_rate"`
+	// ExtraTokens were spent on the losing send of hedged requests; a send
+	// cancelled or failed before it answered counts its estimated prompt
+	ExtraTokens  int64   `json:"extra_tokens"`
+	ExtraCostUSD float64 `json:"extra_cost_usd"`
+}
+
+// hedger holds the hedging settings and counts outcomes
+type hedger struct {
+	delay     time.Duration
+	maxTokens int
+
+	mu    sync.Mutex
+	stats HedgeStats
+}
+
+// hedgeAttempt is the outcome of one of a hedged request's sends
+type hedgeAttempt struct {
+	hedge bool
+	resp  *ChatCompletionResponse
+	err   error
+}
+
+// SetHedging turns hedged requests on or off (see SendHedgedChatCompletion)
+func (r *Registry) SetHedging(cfg config.ProviderHedgingConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !cfg.Enabled {
+		r.hedge = nil
+		return
+	}
+	h := &hedger{delay: cfg.Delay, maxTokens: cfg.MaxTokens}
+	if h.delay <= 0 {
+		h.delay = DefaultHedgeDelay
+	}
+	if h.maxTokens <= 0 {
+		h.maxTokens = DefaultHedgeMaxTokens
+	}
+	r.hedge = h
+}
+
+// HedgeStats returns the hedge win rate and extra cost so far
+func (r *Registry) HedgeStats() HedgeStats {
+	r.mu.RLock()
+	h := r.hedge
+	r.mu.RUnlock()
+	if h == nil {
+		return HedgeStats{}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats := h.stats
+	stats.Enabled = true
+	if stats.Hedged > 0 {
+		stats.WinRate = float64(stats.HedgeWins) / float64(stats.Hedged)
+	}
+	return stats
+}
+
+// SendHedgedChatCompletion sends a short, latency-sensitive request. With
+// hedging enabled, if the provider hasn't answered within the hedge delay,
+// or fails before then, the request also goes to the next best active
+// provider with that provider's model. The first successful response wins
+// and the other request is cancelled. Requests without a max_tokens limit,
+// or with one above the hedging limit, are sent as usual.
+func (r *Registry) SendHedgedChatCompletion(ctx context.Context, providerID string, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	r.mu.RLock()
+	h := r.hedge
+	r.mu.RUnlock()
+	if h == nil || req.MaxTokens <= 0 || req.MaxTokens > h.maxTokens {
+		return r.SendChatCompletion(ctx, providerID, req)
+	}
+	h.mu.Lock()
+	h.stats.Requests++
+	h.mu.Unlock()
+	backupID := r.hedgeBackup(providerID)
+	if backupID == "" {
+		return r.SendChatCompletion(ctx, providerID, req)
+	}
+
+	primaryCtx, cancelPrimary := context.WithCancel(ctx)
+	defer cancelPrimary()
+	hedgeCtx, cancelHedge := context.WithCancel(ctx)
+	defer cancelHedge()
+	results := make(chan hedgeAttempt, 2)
+	send := func(ctx context.Context, id string, req ChatCompletionRequest, hedge bool) {
+		resp, err := r.sendChatCompletion(ctx, id, &req, true)
+		results <- hedgeAttempt{hedge: hedge, resp: resp, err: err}
+	}
+	go send(primaryCtx, providerID, *req, false)
+
+	backupReq := *req
+	backupReq.Model = ""
+	timer := time.NewTimer(h.delay)
+	defer timer.Stop()
+	pending, hedged := 1, false
+	startHedge := func() {
+		hedged = true
+		pending++
+		h.mu.Lock()
+		h.stats.Hedged++
+		h.mu.Unlock()
+		go send(hedgeCtx, backupID, backupReq, true)
+	}
+
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			if !hedged {
+				startHedge()
+			}
+		case a := <-results:
+			pending--
+			if a.err == nil {
+				cancelPrimary()
+				cancelHedge()
+				h.mu.Lock()
+				if a.hedge {
+					h.stats.HedgeWins++
+				}
+				h.mu.Unlock()
+				if hedged {
+					loserID := backupID
+					if a.hedge {
+						loserID = providerID
+					}
+					r.chargeLoser(h, req, loserID, pending > 0, results)
+				}
+				return a.resp, nil
+			}
+			if firstErr == nil {
+				firstErr = a.err
+			}
+			if !hedged {
+				startHedge()
+				continue
+			}
+			if pending == 0 {
+				r.chargeLoser(h, req, backupID, false, results)
+				h.mu.Lock()
+				h.stats.Failures++
+				h.mu.Unlock()
+				return nil, firstErr
+			}
+		}
+	}
+}
+
+// chargeLoser counts what the losing send of a hedged request cost: its
+// tokens if it completed, else its estimated prompt. A send still in
+// flight is waited for in the background.
+func (r *Registry) chargeLoser(h *hedger, req *ChatCompletionRequest, loserID string, inFlight bool, results <-chan hedgeAttempt) {
+	charge := func(resp *ChatCompletionResponse) {
+		tokens := int64(estimatePromptTokens(req))
+		if resp != nil && resp.Usage.TotalTokens > 0 {
+			tokens = int64(resp.Usage.TotalTokens)
+		}
+		var costPerMToken float64
+		if p, err := r.Get(loserID); err == nil && p.Config != nil {
+			r.mu.RLock()
+			costPerMToken = p.Config.CostPerMToken
+			r.mu.RUnlock()
+		}
+		h.mu.Lock()
+		h.stats.ExtraTokens += tokens
+		h.stats.ExtraCostUSD += float64(tokens) * costPerMToken / 1e6
+		h.mu.Unlock()
+	}
+	if !inFlight {
+		charge(nil)
+		return
+	}
+	go func() {
+		a := <-results
+		charge(a.resp)
+	}()
+}
+
+// hedgeBackup picks the provider a hedge goes to: the best active one
+// other than the primary
+func (r *Registry) hedgeBackup(primaryID string) string {
+	for _, p := range r.ListActive() {
+		if p.Config.ID != primaryID {
+			return p.Config.ID
+		}
+	}
+	return ""
+}
+
+// estimatePromptTokens approximates a request's prompt size (~4 characters
+// per token)
+func estimatePromptTokens(req *ChatCompletionRequest) int {
+	n := 0
+	for _, m := range req.Messages {
+		n += len(m.Content)
+	}
+	return n / 4
+}
@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+func hedgeRegistry(primary, backup *fakeEndpoint) *Registry {
+	r := NewRegistry()
+	r.SetHedging(config.ProviderHedgingConfig{Enabled: true, Delay: 20 * time.Millisecond})
+	r.providers["primary"] = &RegisteredProvider{
+		Config:   &ProviderConfig{ID: "primary", Status: "active", Model: "m1", CostPerMToken: 1000},
+		Protocol: primary,
+	}
+	r.providers["backup"] = &RegisteredProvider{
+		Config:   &ProviderConfig{ID: "backup", Status: "active", Model: "m2"},
+		Protocol: backup,
+	}
+	return r
+}
+
+func shortRequest() *ChatCompletionRequest {
+	return &ChatCompletionRequest{
+		Model:     "m1",
+		MaxTokens: 100,
+		Messages:  []ChatMessage{{Role: "user", Content: "which action comes next? answer in one word please"}},
+	}
+}
+
+func TestHedgeNotSentWhenPrimaryIsFast(t *testing.T) {
+	primary := &fakeEndpoint{name: "primary"}
+	backup := &fakeEndpoint{name: "backup"}
+	r := hedgeRegistry(primary, backup)
+
+	resp, err := r.SendHedgedChatCompletion(context.Background(), "primary", shortRequest())
+	if err != nil || resp.ID != "primary" {
+		t.Fatalf("expected the primary's response, got %v, %v", resp, err)
+	}
+	if backup.calls != 0 {
+		t.Errorf("backup got %d requests, want none", backup.calls)
+	}
+	if stats := r.HedgeStats(); stats.Requests != 1 || stats.Hedged != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestHedgeWinsWhenPrimaryIsSlow(t *testing.T) {
+	primary := &fakeEndpoint{name: "primary", delay: 200 * time.Millisecond}
+	backup := &fakeEndpoint{name: "backup"}
+	r := hedgeRegistry(primary, backup)
+
+	start := time.Now()
+	resp, err := r.SendHedgedChatCompletion(context.Background(), "primary", shortRequest())
+	if err != nil || resp.ID != "backup" {
+		t.Fatalf("expected the hedge's response, got %v, %v", resp, err)
+	}
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+		t.Errorf("hedged request took %s, want it not to wait for the slow primary", elapsed)
+	}
+
+	// The losing primary is charged once it returns
+	deadline := time.Now().Add(2 * time.Second)
+	for r.HedgeStats().ExtraTokens == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stats := r.HedgeStats()
+	if stats.Hedged != 1 || stats.HedgeWins != 1 || stats.WinRate != 1 {
+		t.Errorf("unexpected hedge counts: %+v", stats)
+	}
+	if stats.ExtraTokens == 0 || stats.ExtraCostUSD == 0 {
+		t.Errorf("expected the losing primary's cost to be counted: %+v", stats)
+	}
+}
+
+func TestHedgeSentAtOnceWhenPrimaryFails(t *testing.T) {
+	primary := &fakeEndpoint{name: "primary", fail: true}
+	backup := &fakeEndpoint{name: "backup"}
+	r := hedgeRegistry(primary, backup)
+	r.SetHedging(config.ProviderHedgingConfig{Enabled: true, Delay: time.Minute})
+
+	resp, err := r.SendHedgedChatCompletion(context.Background(), "primary", shortRequest())
+	if err != nil || resp.ID != "backup" {
+		t.Fatalf("expected the hedge's response, got %v, %v", resp, err)
+	}
+}
+
+func TestHedgeFailsWhenBothFail(t *testing.T) {
+	r := hedgeRegistry(&fakeEndpoint{name: "primary", fail: true}, &fakeEndpoint{name: "backup", fail: true})
+	if _, err := r.SendHedgedChatCompletion(context.Background(), "primary", shortRequest()); err == nil {
+		t.Fatal("expected an error when both providers fail")
+	}
+	if stats := r.HedgeStats(); stats.Failures != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestHedgeSkipsLongAndUnhedgedRequests(t *testing.T) {
+	primary := &fakeEndpoint{name: "primary", delay: 50 * time.Millisecond}
+	backup := &fakeEndpoint{name: "backup"}
+	r := hedgeRegistry(primary, backup)
+
+	long := shortRequest()
+	long.MaxTokens = DefaultHedgeMaxTokens + 1
+	if resp, err := r.SendHedgedChatCompletion(context.Background(), "primary", long); err != nil || resp.ID != "primary" {
+		t.Fatalf("expected a long request to go to the primary only, got %v, %v", resp, err)
+	}
+
+	r.SetHedging(config.ProviderHedgingConfig{})
+	if resp, err := r.SendHedgedChatCompletion(context.Background(), "primary", shortRequest()); err != nil || resp.ID != "primary" {
+		t.Fatalf("expected no hedging when disabled, got %v, %v", resp, err)
+	}
+	if backup.calls != 0 {
+		t.Errorf("backup got %d requests, want none", backup.calls)
+	}
+	if stats := r.HedgeStats(); stats.Enabled {
+		t.Errorf("expected hedging to be reported disabled: %+v", stats)
+	}
+}
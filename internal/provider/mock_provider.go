@@ -2,19 +2,60 @@ package provider
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"time"
 )
 
 // MockProvider is an in-memory provider that returns canned responses.
 // It is useful for local development and smoke-testing when no real model endpoint is available.
-type MockProvider struct{}
+// With a MockScript it serves scripted completions instead of echoing, so
+// orchestration tests and demos can run fully offline.
+type MockProvider struct {
+	mu     sync.Mutex
+	script *MockScript
+	state  *mockState
+}
 
 func NewMockProvider() *MockProvider {
 	return &MockProvider{}
 }
 
-// CreateChatCompletion returns a static echo response.
+// NewScriptedMockProvider creates a mock provider that serves the script
+func NewScriptedMockProvider(script *MockScript) *MockProvider {
+	if script == nil {
+		return NewMockProvider()
+	}
+	return &MockProvider{script: script, state: newMockState(script)}
+}
+
+// newMockProviderForEndpoint loads a script when the endpoint names one
+// ("file://path" or a .json/.yaml/.yml path) and echoes otherwise
+func newMockProviderForEndpoint(endpoint string) (*MockProvider, error) {
+	path := strings.TrimPrefix(endpoint, "file://")
+	lower := strings.ToLower(path)
+	if path == endpoint && !strings.HasSuffix(lower, ".json") && !strings.HasSuffix(lower, ".yaml") && !strings.HasSuffix(lower, ".yml") {
+		return NewMockProvider(), nil
+	}
+	script, err := LoadMockScript(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewScriptedMockProvider(script), nil
+}
+
+// CreateChatCompletion returns the scripted response, or a static echo response.
 func (p *MockProvider) CreateChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	if p.script != nil {
+		scripted, ok, err := p.scriptedContent(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return mockCompletion(req, scripted, promptLength(req)), nil
+		}
+	}
+
 	// Build a short echo message from the last user content.
 	content := "mock response"
 	if len(req.Messages) > 0 {
@@ -23,7 +64,10 @@ func (p *MockProvider) CreateChatCompletion(ctx context.Context, req *ChatComple
 			content = "mock response"
 		}
 	}
+	return mockCompletion(req, "[mock] "+content, len(content)), nil
+}
 
+func mockCompletion(req *ChatCompletionRequest, content string, promptTokens int) *ChatCompletionResponse {
 	resp := &ChatCompletionResponse{
 		ID:      "mock-completion",
 		Object:  "chat.completion",
@@ -38,20 +82,35 @@ func (p *MockProvider) CreateChatCompletion(ctx context.Context, req *ChatComple
 				Index: 0,
 				Message: ChatMessage{
 					Role:    "assistant",
-					Content: "[mock] " + content,
+					Content: content,
 				},
 				Finish: "stop",
 			},
 		},
 	}
-	resp.Usage.PromptTokens = len(content)
-	resp.Usage.CompletionTokens = len(resp.Choices[0].Message.Content)
+	resp.Usage.PromptTokens = promptTokens
+	resp.Usage.CompletionTokens = len(content)
 	resp.Usage.TotalTokens = resp.Usage.PromptTokens + resp.Usage.CompletionTokens
-	return resp, nil
+	return resp
 }
 
-// GetModels returns a single mock model.
+func promptLength(req *ChatCompletionRequest) int {
+	n := 0
+	for _, m := range req.Messages {
+		n += len(m.Content)
+	}
+	return n
+}
+
+// GetModels returns the scripted models, or a single mock model.
 func (p *MockProvider) GetModels(ctx context.Context) ([]Model, error) {
+	if p.script != nil && len(p.script.Models) > 0 {
+		models := make([]Model, 0, len(p.script.Models))
+		for _, id := range p.script.Models {
+			models = append(models, Model{ID: id, Object: "model", Created: time.Now().Unix(), OwnedBy: "mock"})
+		}
+		return models, nil
+	}
 	return []Model{
 		{
 			ID:      "mock-model",
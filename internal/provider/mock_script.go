@@ -0,0 +1,265 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MockScript scripts the completions served by a mock provider. Responses
+// are consumed in order: each request takes the first response whose match
+// rules accept it and that still has uses left. Default answers requests
+// that nothing else matches; without it the provider echoes the prompt.
+type MockScript struct {
+	Models    []string       `json:"models,omitempty" yaml:"models,omitempty"`
+	Responses []MockResponse `json:"responses" yaml:"responses"`
+	Default   *MockResponse  `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// LatencyMs and JitterMs delay every response unless it sets its own
+	LatencyMs int `json:"latency_ms,omitempty" yaml:"latency_ms,omitempty"`
+	JitterMs  int `json:"jitter_ms,omitempty" yaml:"jitter_ms,omitempty"`
+	// ErrorRate fails this fraction of requests with ErrorStatus (default 503)
+	ErrorRate   float64 `json:"error_rate,omitempty" yaml:"error_rate,omitempty"`
+	ErrorStatus int     `json:"error_status,omitempty" yaml:"error_status,omitempty"`
+	// Seed makes latency jitter and error injection reproducible
+	Seed int64 `json:"seed,omitempty" yaml:"seed,omitempty"`
+	// ChunkDelayMs paces streamed chunks
+	ChunkDelayMs int `json:"chunk_delay_ms,omitempty" yaml:"chunk_delay_ms,omitempty"`
+}
+
+// MockResponse is one scripted completion or failure
+type MockResponse struct {
+	// Match is a substring and MatchRegex a regular expression tested
+	// against the last message; Model restricts the response to a model
+	Match      string `json:"match,omitempty" yaml:"match,omitempty"`
+	MatchRegex string `json:"match_regex,omitempty" yaml:"match_regex,omitempty"`
+	Model      string `json:"model,omitempty" yaml:"model,omitempty"`
+
+	// Content is a text/template rendered with MockRequestData
+	Content string `json:"content,omitempty" yaml:"content,omitempty"`
+	// File loads the content template from a file relative to the script
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+
+	// Error fails the request; StatusCode reports it as an HTTP error the
+	// way the OpenAI-compatible client does
+	Error      string `json:"error,omitempty" yaml:"error,omitempty"`
+	StatusCode int    `json:"status_code,omitempty" yaml:"status_code,omitempty"`
+
+	LatencyMs int `json:"latency_ms,omitempty" yaml:"latency_ms,omitempty"`
+	// Times is how often the response may be served (default 1); Repeat
+	// serves it indefinitely
+	Times  int  `json:"times,omitempty" yaml:"times,omitempty"`
+	Repeat bool `json:"repeat,omitempty" yaml:"repeat,omitempty"`
+
+	re   *regexp.Regexp
+	tmpl *template.Template
+}
+
+// MockRequestData is the data available to response templates
+type MockRequestData struct {
+	Model       string
+	Turn        int // 1-based request count
+	LastMessage string
+	System      string
+	Messages    []ChatMessage
+}
+
+// LoadMockScript reads a JSON or YAML mock script
+func LoadMockScript(path string) (*MockScript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock script: %w", err)
+	}
+	var script MockScript
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &script)
+	default:
+		err = json.Unmarshal(data, &script)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mock script %s: %w", path, err)
+	}
+	if err := script.compile(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("invalid mock script %s: %w", path, err)
+	}
+	return &script, nil
+}
+
+// compile parses match expressions and templates, loading content files
+// relative to dir
+func (s *MockScript) compile(dir string) error {
+	for i := range s.Responses {
+		if err := s.Responses[i].compile(dir); err != nil {
+			return fmt.Errorf("response %d: %w", i, err)
+		}
+	}
+	if s.Default != nil {
+		if err := s.Default.compile(dir); err != nil {
+			return fmt.Errorf("default: %w", err)
+		}
+	}
+	if s.ErrorRate < 0 || s.ErrorRate > 1 {
+		return fmt.Errorf("error_rate must be between 0 and 1")
+	}
+	return nil
+}
+
+func (r *MockResponse) compile(dir string) error {
+	if r.MatchRegex != "" {
+		re, err := regexp.Compile(r.MatchRegex)
+		if err != nil {
+			return fmt.Errorf("invalid match_regex: %w", err)
+		}
+		r.re = re
+	}
+	if r.File != "" {
+		path := r.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read response file: %w", err)
+		}
+		r.Content = string(data)
+	}
+	tmpl, err := template.New("response").Parse(r.Content)
+	if err != nil {
+		return fmt.Errorf("invalid content template: %w", err)
+	}
+	r.tmpl = tmpl
+	return nil
+}
+
+func (r *MockResponse) matches(model, last string) bool {
+	if r.Model != "" && r.Model != model {
+		return false
+	}
+	if r.Match != "" && !strings.Contains(last, r.Match) {
+		return false
+	}
+	return r.re == nil || r.re.MatchString(last)
+}
+
+func (r *MockResponse) render(data MockRequestData) (string, error) {
+	if r.tmpl == nil {
+		return r.Content, nil
+	}
+	var sb strings.Builder
+	if err := r.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("mock response template failed: %w", err)
+	}
+	return sb.String(), nil
+}
+
+func (r *MockResponse) err() error {
+	if r.StatusCode == 0 {
+		return fmt.Errorf("%s", r.Error)
+	}
+	if r.StatusCode == http.StatusBadRequest && isContextLengthError(r.Error) {
+		return &ContextLengthError{StatusCode: r.StatusCode, Body: r.Error}
+	}
+	return fmt.Errorf("unexpected status code %d: %s", r.StatusCode, r.Error)
+}
+
+// mockState tracks script consumption for one provider
+type mockState struct {
+	turn int
+	used []int
+	rng  *rand.Rand
+}
+
+func newMockState(script *MockScript) *mockState {
+	seed := script.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &mockState{used: make([]int, len(script.Responses)), rng: rand.New(rand.NewSource(seed))}
+}
+
+// next picks the scripted response for a request, or nil to echo
+func (p *MockProvider) next(req *ChatCompletionRequest) (*MockResponse, MockRequestData, time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	script, state := p.script, p.state
+	state.turn++
+	data := MockRequestData{Model: req.Model, Turn: state.turn, Messages: req.Messages}
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			data.System = m.Content
+		}
+	}
+	if len(req.Messages) > 0 {
+		data.LastMessage = req.Messages[len(req.Messages)-1].Content
+	}
+
+	latency := time.Duration(script.LatencyMs) * time.Millisecond
+	if script.JitterMs > 0 {
+		latency += time.Duration(state.rng.Intn(script.JitterMs+1)) * time.Millisecond
+	}
+	if script.ErrorRate > 0 && state.rng.Float64() < script.ErrorRate {
+		status := script.ErrorStatus
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		return nil, data, latency, fmt.Errorf("unexpected status code %d: mock injected error", status)
+	}
+
+	var resp *MockResponse
+	for i := range script.Responses {
+		r := &script.Responses[i]
+		times := r.Times
+		if times == 0 {
+			times = 1
+		}
+		if (!r.Repeat && state.used[i] >= times) || !r.matches(req.Model, data.LastMessage) {
+			continue
+		}
+		state.used[i]++
+		resp = r
+		break
+	}
+	if resp == nil {
+		resp = script.Default
+	}
+	if resp != nil && resp.LatencyMs > 0 {
+		latency = time.Duration(resp.LatencyMs) * time.Millisecond
+	}
+	return resp, data, latency, nil
+}
+
+// scriptedContent resolves a request against the script, applying latency
+// and injected errors. ok is false when the provider should echo instead.
+func (p *MockProvider) scriptedContent(ctx context.Context, req *ChatCompletionRequest) (content string, ok bool, err error) {
+	resp, data, latency, err := p.next(req)
+	if latency > 0 {
+		select {
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		case <-time.After(latency):
+		}
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if resp == nil {
+		return "", false, nil
+	}
+	if resp.Error != "" || resp.StatusCode != 0 {
+		return "", false, resp.err()
+	}
+	content, err = resp.render(data)
+	return content, err == nil, err
+}
@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeMockScript(t *testing.T, name, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func mockChat(t *testing.T, p *MockProvider, msg string) (string, error) {
+	t.Helper()
+	resp, err := p.CreateChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model:    "mock-model",
+		Messages: []ChatMessage{{Role: "system", Content: "be brief"}, {Role: "user", Content: msg}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+func TestMockScript_ServesResponsesInOrder(t *testing.T) {
+	path := writeMockScript(t, "script.yaml", `
+models: [scripted-model]
+responses:
+  - match: plan
+    content: '{"actions":[{"type":"read_file","path":"main.go"}]}'
+  - content: "turn {{.Turn}}: {{.LastMessage}}"
+    times: 2
+  - match_regex: "^fail"
+    error: rate limited
+    status_code: 429
+    repeat: true
+default:
+  content: "default ({{.System}})"
+`)
+	script, err := LoadMockScript(path)
+	if err != nil {
+		t.Fatalf("LoadMockScript: %v", err)
+	}
+	p := NewScriptedMockProvider(script)
+
+	want := []string{
+		`{"actions":[{"type":"read_file","path":"main.go"}]}`,
+		"turn 2: plan again",
+		"turn 3: hello",
+		"default (be brief)",
+	}
+	for i, msg := range []string{"make a plan", "plan again", "hello", "hello"} {
+		got, err := mockChat(t, p, msg)
+		if err != nil || got != want[i] {
+			t.Errorf("request %d: got %q, %v; want %q", i, got, err, want[i])
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := mockChat(t, p, "fail please"); err == nil || !strings.Contains(err.Error(), "status code 429") {
+			t.Errorf("expected injected 429, got %v", err)
+		}
+	}
+
+	models, _ := p.GetModels(context.Background())
+	if len(models) != 1 || models[0].ID != "scripted-model" {
+		t.Errorf("unexpected models: %+v", models)
+	}
+}
+
+func TestMockScript_ContextLengthError(t *testing.T) {
+	p := NewScriptedMockProvider(&MockScript{Responses: []MockResponse{
+		{Error: "maximum context length is 8192 tokens", StatusCode: 400},
+	}})
+	_, err := mockChat(t, p, "long prompt")
+	var cle *ContextLengthError
+	if !errors.As(err, &cle) {
+		t.Fatalf("expected ContextLengthError, got %v", err)
+	}
+
+	// Without a default the provider falls back to echoing
+	if got, err := mockChat(t, p, "again"); err != nil || got != "[mock] again" {
+		t.Errorf("expected echo fallback, got %q, %v", got, err)
+	}
+}
+
+func TestMockScript_ErrorRateIsSeeded(t *testing.T) {
+	run := func() []bool {
+		p := NewScriptedMockProvider(&MockScript{ErrorRate: 0.5, Seed: 42, Default: &MockResponse{Content: "ok"}})
+		var failed []bool
+		for i := 0; i < 20; i++ {
+			_, err := mockChat(t, p, "x")
+			failed = append(failed, err != nil)
+		}
+		return failed
+	}
+	first, second := run(), run()
+	failures := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("error injection not reproducible at request %d", i)
+		}
+		if first[i] {
+			failures++
+		}
+	}
+	if failures == 0 || failures == len(first) {
+		t.Errorf("expected some but not all requests to fail, got %d/%d", failures, len(first))
+	}
+}
+
+func TestMockScript_LatencyHonoursContext(t *testing.T) {
+	p := NewScriptedMockProvider(&MockScript{LatencyMs: 5000, Default: &MockResponse{Content: "slow"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := p.CreateChatCompletion(ctx, &ChatCompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "hi"}}})
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+		t.Errorf("expected deadline exceeded promptly, got %v after %v", err, time.Since(start))
+	}
+}
+
+func TestMockScript_StreamAndFileContent(t *testing.T) {
+	path := writeMockScript(t, "script.json", `{"responses": [{"file": "reply.txt"}]}`)
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "reply.txt"), []byte("streamed {{.Model}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script, err := LoadMockScript(path)
+	if err != nil {
+		t.Fatalf("LoadMockScript: %v", err)
+	}
+	p := NewScriptedMockProvider(script)
+
+	var sb strings.Builder
+	err = p.CreateChatCompletionStream(context.Background(), &ChatCompletionRequest{Model: "m1"}, func(chunk *StreamChunk) error {
+		sb.WriteString(chunk.Choices[0].Delta.Content)
+		return nil
+	})
+	if err != nil || sb.String() != "streamed m1" {
+		t.Errorf("got %q, %v", sb.String(), err)
+	}
+}
+
+func TestMockScript_InvalidScript(t *testing.T) {
+	path := writeMockScript(t, "bad.json", `{"responses": [{"match_regex": "("}]}`)
+	if _, err := LoadMockScript(path); err == nil {
+		t.Error("expected invalid regex to fail")
+	}
+}
+
+func TestRegistry_MockEndpointLoadsScript(t *testing.T) {
+	path := writeMockScript(t, "script.yml", "default:\n  content: scripted\n")
+	r := NewRegistry()
+	if err := r.Register(&ProviderConfig{ID: "mock-1", Type: "mock", Endpoint: "file://" + path}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	p, _ := r.Get("mock-1")
+	resp, err := p.Protocol.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "hi"}}})
+	if err != nil || resp.Choices[0].Message.Content != "scripted" {
+		t.Errorf("unexpected response: %+v, %v", resp, err)
+	}
+
+	if err := r.Register(&ProviderConfig{ID: "mock-2", Type: "mock", Endpoint: "file:///does/not/exist.yaml"}); err == nil {
+		t.Error("expected missing script to fail registration")
+	}
+	if err := r.Register(&ProviderConfig{ID: "mock-3", Type: "mock", Endpoint: "http://localhost:1"}); err != nil {
+		t.Errorf("non-script endpoint should echo: %v", err)
+	}
+}
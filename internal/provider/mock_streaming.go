@@ -19,6 +19,17 @@ func (p *MockProvider) CreateChatCompletionStream(ctx context.Context, req *Chat
 	}
 
 	fullContent := "[mock streaming] " + content
+	delay := 50 * time.Millisecond
+	if p.script != nil {
+		scripted, ok, err := p.scriptedContent(ctx, req)
+		if err != nil {
+			return err
+		}
+		if ok {
+			fullContent = scripted
+		}
+		delay = time.Duration(p.script.ChunkDelayMs) * time.Millisecond
+	}
 
 	// Simulate streaming by sending content word-by-word
 	words := []rune(fullContent)
@@ -80,7 +91,7 @@ func (p *MockProvider) CreateChatCompletionStream(ctx context.Context, req *Chat
 		}
 
 		// Simulate network delay
-		time.Sleep(50 * time.Millisecond)
+		time.Sleep(delay)
 	}
 
 	return nil
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Content part types
+const (
+	ContentPartText     = "text"
+	ContentPartImageURL = "image_url"
+)
+
+// ImageTokens is a rough prompt cost of one image, for token budgeting
+const ImageTokens = 800
+
+// ContentPart is one part of a multimodal message
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL points at an image: a remote URL or a base64 data URL
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"` // "low", "high" or "auto"
+}
+
+// ImagePart is a content part showing the image at url
+func ImagePart(url string) ContentPart {
+	return ContentPart{Type: ContentPartImageURL, ImageURL: &ImageURL{URL: url}}
+}
+
+// DataURL encodes an image as a base64 data URL
+func DataURL(mediaType string, data []byte) string {
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// ParseDataURL returns the media type and base64 payload of a data URL
+func ParseDataURL(url string) (mediaType, payload string, ok bool) {
+	rest, found := strings.CutPrefix(url, "data:")
+	if !found {
+		return "", "", false
+	}
+	header, payload, found := strings.Cut(rest, ",")
+	if !found || !strings.HasSuffix(header, ";base64") {
+		return "", "", false
+	}
+	return strings.TrimSuffix(header, ";base64"), payload, true
+}
+
+// MarshalJSON sends Content as a plain string, or as the first text part of
+// a content array when the message has Parts
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Parts) == 0 {
+		return json.Marshal(struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}{m.Role, m.Content})
+	}
+	parts := make([]ContentPart, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		parts = append(parts, ContentPart{Type: ContentPartText, Text: m.Content})
+	}
+	parts = append(parts, m.Parts...)
+	return json.Marshal(struct {
+		Role    string        `json:"role"`
+		Content []ContentPart `json:"content"`
+	}{m.Role, parts})
+}
+
+// UnmarshalJSON accepts string content or a content array, whose text parts
+// are joined into Content
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = ChatMessage{Role: raw.Role}
+	if len(raw.Content) == 0 || string(raw.Content) == "null" {
+		return nil
+	}
+	if raw.Content[0] == '"' {
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+	var parts []ContentPart
+	if err := json.Unmarshal(raw.Content, &parts); err != nil {
+		return fmt.Errorf("message content is neither a string nor content parts: %w", err)
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Type == ContentPartText {
+			texts = append(texts, p.Text)
+		} else {
+			m.Parts = append(m.Parts, p)
+		}
+	}
+	m.Content = strings.Join(texts, "\n")
+	return nil
+}
+
+// HasImages reports whether any message carries an image
+func HasImages(messages []ChatMessage) bool {
+	for _, m := range messages {
+		for _, p := range m.Parts {
+			if p.Type == ContentPartImageURL {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withoutImages replaces image parts with a note, for providers that only
+// accept text
+func withoutImages(messages []ChatMessage) []ChatMessage {
+	out := make([]ChatMessage, len(messages))
+	for i, m := range messages {
+		images := 0
+		var kept []ContentPart
+		for _, p := range m.Parts {
+			if p.Type == ContentPartImageURL {
+				images++
+				continue
+			}
+			kept = append(kept, p)
+		}
+		m.Parts = kept
+		if images > 0 {
+			m.Content = strings.TrimRight(m.Content, "\n") +
+				fmt.Sprintf("\n\n[%d image(s) omitted: this provider does not accept images]", images)
+		}
+		out[i] = m
+	}
+	return out
+}
+
+// visionModelHints are model name fragments of common multimodal models
+var visionModelHints = []string{
+	"gpt-4o", "gpt-4.1", "gpt-4-turbo", "gpt-4-vision", "gpt-5", "o3", "o4",
+	"claude-3", "claude-sonnet-4", "claude-opus-4", "gemini",
+	"llava", "bakllava", "moondream", "pixtral", "gemma3", "minicpm-v",
+	"vision", "-vl", "qwen2.5vl",
+}
+
+// AcceptsImages reports whether the provider takes image input: it is
+// configured with SupportsVision or its model is a known multimodal one
+func (c *ProviderConfig) AcceptsImages() bool {
+	if c.SupportsVision {
+		return true
+	}
+	model := strings.ToLower(c.Model)
+	for _, hint := range visionModelHints {
+		if strings.Contains(model, hint) {
+			return true
+		}
+	}
+	return false
+}
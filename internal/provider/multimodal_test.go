@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChatMessageJSON(t *testing.T) {
+	plain, _ := json.Marshal(ChatMessage{Role: "user", Content: "hi"})
+	if string(plain) != `{"role":"user","content":"hi"}` {
+		t.Errorf("plain message = %s", plain)
+	}
+
+	msg := ChatMessage{Role: "user", Content: "what is wrong here?", Parts: []ContentPart{ImagePart(DataURL("image/png", []byte("png")))}}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":"user","content":[{"type":"text","text":"what is wrong here?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}}]}`
+	if string(data) != want {
+		t.Errorf("multimodal message =\n%s\nwant\n%s", data, want)
+	}
+
+	var back ChatMessage
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Content != msg.Content || len(back.Parts) != 1 || back.Parts[0].ImageURL.URL != msg.Parts[0].ImageURL.URL {
+		t.Errorf("round trip = %+v", back)
+	}
+	if err := json.Unmarshal([]byte(`{"role":"assistant","content":null}`), &back); err != nil || back.Content != "" || back.Parts != nil {
+		t.Errorf("null content = %+v, %v", back, err)
+	}
+}
+
+func TestParseDataURL(t *testing.T) {
+	mediaType, payload, ok := ParseDataURL("data:image/jpeg;base64,AAAA")
+	if !ok || mediaType != "image/jpeg" || payload != "AAAA" {
+		t.Errorf("ParseDataURL() = %q, %q, %v", mediaType, payload, ok)
+	}
+	for _, url := range []string{"https://example.com/a.png", "data:text/plain,hello"} {
+		if _, _, ok := ParseDataURL(url); ok {
+			t.Errorf("expected %q not to parse", url)
+		}
+	}
+}
+
+func TestRegisteredProvider_StripsImagesWithoutVision(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []json.RawMessage `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, string(body.Messages[0]))
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	req := &ChatCompletionRequest{Model: "m", Messages: []ChatMessage{
+		{Role: "user", Content: "look", Parts: []ContentPart{ImagePart("https://example.com/shot.png")}},
+	}}
+	for _, cfg := range []*ProviderConfig{
+		{ID: "text", Model: "qwen2.5-coder-32b"},
+		{ID: "vision", Model: "qwen2.5-coder-32b", SupportsVision: true},
+		{ID: "known", Model: "gpt-4o-mini"},
+	} {
+		p := &RegisteredProvider{Config: cfg, Protocol: NewOpenAIProvider(server.URL, "")}
+		if _, err := p.ChatCompletion(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Contains(bodies[0], "image_url") || !strings.Contains(bodies[0], "1 image(s) omitted") {
+		t.Errorf("expected the image replaced with a note, got %s", bodies[0])
+	}
+	for _, body := range bodies[1:] {
+		if !strings.Contains(body, "image_url") {
+			t.Errorf("expected the image sent, got %s", body)
+		}
+	}
+	if len(req.Messages[0].Parts) != 1 {
+		t.Error("expected the caller's messages to be left alone")
+	}
+}
+
+func TestOllamaProvider_Images(t *testing.T) {
+	var got struct {
+		Messages []ollamaMessage `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"model":"llava","message":{"role":"assistant","content":"a cat"},"done":true}`))
+	}))
+	defer server.Close()
+
+	p := NewOllamaProvider(server.URL)
+	_, err := p.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: "llava", Messages: []ChatMessage{{
+		Role:    "user",
+		Content: "describe",
+		Parts:   []ContentPart{ImagePart(DataURL("image/png", []byte("png"))), ImagePart("https://example.com/b.png")},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := got.Messages[0]
+	if len(m.Images) != 1 || m.Images[0] != "cG5n" {
+		t.Errorf("expected the inline image as base64, got %v", m.Images)
+	}
+	if !strings.Contains(m.Content, "[image: https://example.com/b.png]") {
+		t.Errorf("expected the remote image referenced in the text, got %q", m.Content)
+	}
+}
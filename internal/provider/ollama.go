@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,6 +17,9 @@ import (
 type OllamaProvider struct {
 	endpoint string
 	client   *http.Client
+	// noSchema is set once the server rejects a schema format (Ollama
+	// before 0.5 only accepts "json")
+	noSchema atomic.Bool
 }
 
 func NewOllamaProvider(endpoint string) *OllamaProvider {
@@ -74,32 +78,25 @@ func (p *OllamaProvider) CreateChatCompletion(ctx context.Context, req *ChatComp
 	if model == "" {
 		return nil, fmt.Errorf("model is required")
 	}
+	if wantsSchema(req) && p.noSchema.Load() {
+		req = withoutSchema(req)
+	}
 
 	ollamaReq := struct {
-		Model    string `json:"model"`
-		Messages []struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		} `json:"messages"`
-		Stream  bool   `json:"stream"`
-		Format  string `json:"format,omitempty"`
-		Options struct {
+		Model    string          `json:"model"`
+		Messages []ollamaMessage `json:"messages"`
+		Stream   bool            `json:"stream"`
+		Format   json.RawMessage `json:"format,omitempty"`
+		Options  struct {
 			Temperature float64 `json:"temperature,omitempty"`
 		} `json:"options,omitempty"`
 	}{
-		Model:  model,
-		Stream: false,
+		Model:    model,
+		Messages: ollamaMessages(req.Messages),
+		Stream:   false,
 	}
 	ollamaReq.Options.Temperature = req.Temperature
-	if req.ResponseFormat != nil && req.ResponseFormat.Type == "json_object" {
-		ollamaReq.Format = "json"
-	}
-	for _, msg := range req.Messages {
-		ollamaReq.Messages = append(ollamaReq.Messages, struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		}{Role: msg.Role, Content: msg.Content})
-	}
+	ollamaReq.Format = ollamaFormat(req.ResponseFormat)
 
 	body, err := json.Marshal(ollamaReq)
 	if err != nil {
@@ -127,6 +124,10 @@ func (p *OllamaProvider) CreateChatCompletion(ctx context.Context, req *ChatComp
 		if resp.StatusCode == http.StatusBadRequest && isContextLengthError(bodyStr) {
 			return nil, &ContextLengthError{StatusCode: resp.StatusCode, Body: bodyStr}
 		}
+		if wantsSchema(req) && resp.StatusCode == http.StatusBadRequest && strings.Contains(strings.ToLower(bodyStr), "format") {
+			p.noSchema.Store(true)
+			return p.CreateChatCompletion(ctx, withoutSchema(req))
+		}
 		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, bodyStr)
 	}
 
@@ -155,3 +156,54 @@ func (p *OllamaProvider) CreateChatCompletion(ctx context.Context, req *ChatComp
 
 	return completion, nil
 }
+
+// ollamaFormat maps a response format onto Ollama's format field: a JSON
+// schema, which Ollama compiles into a llama.cpp grammar, or "json"
+func ollamaFormat(rf *ResponseFormat) json.RawMessage {
+	if rf == nil {
+		return nil
+	}
+	switch rf.Type {
+	case ResponseFormatJSONSchema:
+		if rf.JSONSchema != nil && rf.JSONSchema.Schema != nil {
+			if schema, err := json.Marshal(rf.JSONSchema.Schema); err == nil {
+				return schema
+			}
+		}
+		return json.RawMessage(`"json"`)
+	case ResponseFormatJSONObject:
+		return json.RawMessage(`"json"`)
+	}
+	return nil
+}
+
+// ollamaMessage is a chat message in Ollama's format, which carries images
+// as base64 payloads beside the text
+type ollamaMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"`
+}
+
+// ollamaMessages converts messages, inlining images Ollama can't fetch
+// itself (remote URLs) as text references
+func ollamaMessages(messages []ChatMessage) []ollamaMessage {
+	out := make([]ollamaMessage, 0, len(messages))
+	for _, msg := range messages {
+		om := ollamaMessage{Role: msg.Role, Content: msg.Content}
+		for _, p := range msg.Parts {
+			switch {
+			case p.Type == ContentPartText:
+				om.Content += "\n" + p.Text
+			case p.Type == ContentPartImageURL && p.ImageURL != nil:
+				if _, payload, ok := ParseDataURL(p.ImageURL.URL); ok {
+					om.Images = append(om.Images, payload)
+				} else {
+					om.Content += "\n[image: " + p.ImageURL.URL + "]"
+				}
+			}
+		}
+		out = append(out, om)
+	}
+	return out
+}
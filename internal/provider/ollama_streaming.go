@@ -21,28 +21,19 @@ func (p *OllamaProvider) CreateChatCompletionStream(ctx context.Context, req *Ch
 
 	// Build Ollama request with streaming enabled
 	ollamaReq := struct {
-		Model    string `json:"model"`
-		Messages []struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		} `json:"messages"`
-		Stream  bool `json:"stream"`
-		Options struct {
+		Model    string          `json:"model"`
+		Messages []ollamaMessage `json:"messages"`
+		Stream   bool            `json:"stream"`
+		Options  struct {
 			Temperature float64 `json:"temperature,omitempty"`
 		} `json:"options,omitempty"`
 	}{
-		Model:  req.Model,
-		Stream: true, // Enable streaming
+		Model:    req.Model,
+		Messages: ollamaMessages(req.Messages),
+		Stream:   true, // Enable streaming
 	}
 	ollamaReq.Options.Temperature = req.Temperature
 
-	for _, msg := range req.Messages {
-		ollamaReq.Messages = append(ollamaReq.Messages, struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		}{Role: msg.Role, Content: msg.Content})
-	}
-
 	body, err := json.Marshal(ollamaReq)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -23,6 +25,40 @@ func (e *ContextLengthError) Error() string {
 	return fmt.Sprintf("context length exceeded (HTTP %d): %s", e.StatusCode, e.Body)
 }
 
+// RateLimitError is returned when the provider rejects a request with HTTP
+// 429. RetryAfter is the wait the provider asked for, or zero if it didn't.
+type RateLimitError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited (HTTP %d, retry after %s): %s", e.StatusCode, e.RetryAfter, e.Body)
+	}
+	return fmt.Sprintf("rate limited (HTTP %d): %s", e.StatusCode, e.Body)
+}
+
+// parseRetryAfter reads a Retry-After header, given either as seconds or as
+// an HTTP date
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
 // isContextLengthError checks whether a provider error body indicates the
 // prompt exceeded the model's context window.
 func isContextLengthError(body string) bool {
@@ -66,13 +102,62 @@ type StreamingProtocol interface {
 type ChatMessage struct {
 	Role    string `json:"role"`    // system, user, assistant
 	Content string `json:"content"` // message content
+	// Parts follow Content in a multimodal message, e.g. images for
+	// providers with vision (see ProviderConfig.AcceptsImages)
+	Parts []ContentPart `json:"-"`
 }
 
+// Response format types
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
 // ResponseFormat specifies the output format for the LLM response.
 // Setting Type to "json_object" enables constrained JSON decoding in
 // vLLM and OpenAI-compatible APIs, guaranteeing valid JSON output.
+// "json_schema" goes further and constrains generation to JSONSchema
+// (OpenAI structured outputs, vLLM guided decoding, Ollama's grammar-backed
+// format); providers that reject it fall back to "json_object".
 type ResponseFormat struct {
-	Type string `json:"type"` // "text" (default) or "json_object"
+	Type       string      `json:"type"` // "text" (default), "json_object" or "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema is the schema a "json_schema" response must match
+type JSONSchema struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	Strict bool                   `json:"strict,omitempty"`
+}
+
+// SchemaFormat is a response format constrained to schema
+func SchemaFormat(name string, schema map[string]interface{}) *ResponseFormat {
+	return &ResponseFormat{Type: ResponseFormatJSONSchema, JSONSchema: &JSONSchema{Name: name, Schema: schema}}
+}
+
+// wantsSchema reports whether req asks for schema-constrained output
+func wantsSchema(req *ChatCompletionRequest) bool {
+	return req.ResponseFormat != nil && req.ResponseFormat.Type == ResponseFormatJSONSchema
+}
+
+// withoutSchema is a copy of req asking for plain JSON instead of a schema
+func withoutSchema(req *ChatCompletionRequest) *ChatCompletionRequest {
+	downgraded := *req
+	downgraded.ResponseFormat = &ResponseFormat{Type: ResponseFormatJSONObject}
+	return &downgraded
+}
+
+// isSchemaRejection reports whether an error response says the server
+// doesn't support schema-constrained output
+func isSchemaRejection(statusCode int, body string) bool {
+	if statusCode != http.StatusBadRequest && statusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	lower := strings.ToLower(body)
+	return strings.Contains(lower, "json_schema") || strings.Contains(lower, "response_format") ||
+		(strings.Contains(lower, "format") && strings.Contains(lower, "schema"))
 }
 
 // ChatCompletionRequest represents a chat completion request
@@ -118,6 +203,9 @@ type OpenAIProvider struct {
 	apiKey          string
 	client          *http.Client
 	streamingClient *http.Client // Separate client for streaming (no timeout)
+	// noSchema is set once the server rejects a json_schema response format,
+	// so later requests go straight to json_object
+	noSchema atomic.Bool
 }
 
 // NewOpenAIProvider creates a new OpenAI-compatible provider
@@ -143,6 +231,9 @@ func NewOpenAIProvider(endpoint, apiKey string) *OpenAIProvider {
 // CreateChatCompletion sends a chat completion request
 func (p *OpenAIProvider) CreateChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
 	url := fmt.Sprintf("%s/chat/completions", p.endpoint)
+	if wantsSchema(req) && p.noSchema.Load() {
+		req = withoutSchema(req)
+	}
 
 	// Marshal request body
 	body, err := json.Marshal(req)
@@ -181,6 +272,13 @@ func (p *OpenAIProvider) CreateChatCompletion(ctx context.Context, req *ChatComp
 		if resp.StatusCode == http.StatusBadRequest && isContextLengthError(bodyStr) {
 			return nil, &ContextLengthError{StatusCode: resp.StatusCode, Body: bodyStr}
 		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, &RateLimitError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), Body: bodyStr}
+		}
+		if wantsSchema(req) && isSchemaRejection(resp.StatusCode, bodyStr) {
+			p.noSchema.Store(true)
+			return p.CreateChatCompletion(ctx, withoutSchema(req))
+		}
 		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, bodyStr)
 	}
 
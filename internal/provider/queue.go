@@ -0,0 +1,350 @@
+package provider
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultPriority is the priority of requests with none in their context,
+// matching a P2 bead
+const DefaultPriority = 2
+
+// ErrQueueTimeout is returned when a request waits in a provider's queue
+// longer than the queue's maximum wait
+var ErrQueueTimeout = errors.New("timed out waiting for provider rate limit")
+
+type priorityKey struct{}
+
+// WithPriority marks requests made with ctx with a bead priority; lower is
+// more urgent, so P0 bead actions go first when a provider is rate limited
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the priority set by WithPriority, or
+// DefaultPriority
+func PriorityFromContext(ctx context.Context) int {
+	if p, ok := ctx.Value(priorityKey{}).(int); ok {
+		return p
+	}
+	return DefaultPriority
+}
+
+// QueueConfig configures a RequestQueue
+type QueueConfig struct {
+	MaxWait           time.Duration  // Longest a request waits in a queue, across retries
+	MaxRetries        int            // Rate-limited retries per request
+	DefaultBackoff    time.Duration  // Wait after a 429 without Retry-After; doubles on repeats
+	MaxBackoff        time.Duration  // Cap on the doubled backoff
+	RequestsPerMinute map[string]int // Optional per-provider limits, spacing requests evenly
+}
+
+// QueueStatus describes one provider's queue
+type QueueStatus struct {
+	ProviderID        string        `json:"provider_id"`
+	Depth             int           `json:"depth"`
+	DepthByPriority   map[int]int   `json:"depth_by_priority,omitempty"`
+	RateLimited       bool          `json:"rate_limited"`
+	BlockedUntil      time.Time     `json:"blocked_until,omitempty"`
+	RequestsPerMinute int           `json:"requests_per_minute,omitempty"`
+	ExpectedWait      time.Duration `json:"expected_wait"`
+	ExpectedWaitMs    int64         `json:"expected_wait_ms"`
+	Priority          int           `json:"priority"` // the priority ExpectedWait is for
+	RateLimitedTotal  int64         `json:"rate_limited_total"`
+}
+
+// RequestQueue holds requests to rate-limited providers. While a provider
+// is healthy requests pass straight through. Once it answers 429, or a
+// configured requests-per-minute limit is reached, requests wait in a
+// per-provider priority queue until the provider's Retry-After has passed,
+// then are released one at a time, most urgent first, until the queue
+// drains.
+type RequestQueue struct {
+	cfg QueueConfig
+	now func() time.Time
+
+	mu     sync.Mutex
+	queues map[string]*providerQueue
+	seq    uint64
+}
+
+type providerQueue struct {
+	id           string
+	interval     time.Duration // between request starts, from RequestsPerMinute
+	blockedUntil time.Time
+	backoff      time.Duration
+	lastStart    time.Time
+	probing      bool // a released waiter is in flight; release the next when it finishes
+	waiting      waiterHeap
+	timer        *time.Timer
+	timerAt      time.Time
+	avgService   time.Duration
+	limitedTotal int64
+}
+
+type waiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+	index    int
+}
+
+// NewRequestQueue creates a request queue
+func NewRequestQueue(cfg QueueConfig) *RequestQueue {
+	return &RequestQueue{cfg: cfg, now: time.Now, queues: make(map[string]*providerQueue)}
+}
+
+// queue returns a provider's queue. Callers hold q.mu.
+func (q *RequestQueue) queue(providerID string) *providerQueue {
+	pq, ok := q.queues[providerID]
+	if !ok {
+		pq = &providerQueue{id: providerID}
+		if rpm := q.cfg.RequestsPerMinute[providerID]; rpm > 0 {
+			pq.interval = time.Minute / time.Duration(rpm)
+		}
+		q.queues[providerID] = pq
+	}
+	return pq
+}
+
+// Do runs fn for a provider, waiting its turn while the provider is rate
+// limited and retrying fn when it fails with a RateLimitError
+func (q *RequestQueue) Do(ctx context.Context, providerID string, priority int, fn func(context.Context) error) error {
+	deadline := q.now().Add(q.cfg.MaxWait)
+	q.mu.Lock()
+	q.seq++
+	seq := q.seq
+	q.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		queued, err := q.acquire(ctx, providerID, priority, seq, deadline)
+		if err != nil {
+			return err
+		}
+		start := q.now()
+		err = fn(ctx)
+		var rl *RateLimitError
+		limited := errors.As(err, &rl)
+		var retryAfter time.Duration
+		if limited {
+			retryAfter = rl.RetryAfter
+		}
+		q.release(providerID, queued, limited, retryAfter, q.now().Sub(start))
+		if !limited || attempt >= q.cfg.MaxRetries {
+			return err
+		}
+	}
+}
+
+// acquire returns once the request may run, reporting whether it waited in
+// the queue
+func (q *RequestQueue) acquire(ctx context.Context, providerID string, priority int, seq uint64, deadline time.Time) (bool, error) {
+	q.mu.Lock()
+	pq := q.queue(providerID)
+	now := q.now()
+	if len(pq.waiting) == 0 && !pq.probing && !now.Before(pq.nextStart()) {
+		pq.lastStart = now
+		q.mu.Unlock()
+		return false, nil
+	}
+	w := &waiter{priority: priority, seq: seq, ready: make(chan struct{})}
+	heap.Push(&pq.waiting, w)
+	q.schedule(pq)
+	q.mu.Unlock()
+
+	timeout := time.NewTimer(deadline.Sub(now))
+	defer timeout.Stop()
+	select {
+	case <-w.ready:
+		return true, nil
+	case <-ctx.Done():
+		q.abandon(pq, w)
+		return false, ctx.Err()
+	case <-timeout.C:
+		q.abandon(pq, w)
+		return false, fmt.Errorf("provider %s: %w", providerID, ErrQueueTimeout)
+	}
+}
+
+// abandon removes a waiter that gave up, passing its turn on if it had
+// already been released
+func (q *RequestQueue) abandon(pq *providerQueue, w *waiter) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if w.index >= 0 {
+		heap.Remove(&pq.waiting, w.index)
+		return
+	}
+	pq.probing = false
+	q.schedule(pq)
+}
+
+// release records the outcome of a request and releases the next waiter
+func (q *RequestQueue) release(providerID string, queued, limited bool, retryAfter, took time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pq := q.queue(providerID)
+	if queued {
+		pq.probing = false
+	}
+	now := q.now()
+	if limited {
+		pq.limitedTotal++
+		wait := retryAfter
+		if wait <= 0 {
+			if pq.backoff == 0 {
+				pq.backoff = q.cfg.DefaultBackoff
+			} else {
+				pq.backoff = min(pq.backoff*2, q.cfg.MaxBackoff)
+			}
+			wait = pq.backoff
+		}
+		if until := now.Add(wait); until.After(pq.blockedUntil) {
+			pq.blockedUntil = until
+		}
+	} else {
+		pq.backoff = 0
+		if pq.avgService == 0 {
+			pq.avgService = took
+		} else {
+			pq.avgService = (pq.avgService*4 + took) / 5
+		}
+	}
+	q.schedule(pq)
+}
+
+// nextStart is the earliest a request may start
+func (pq *providerQueue) nextStart() time.Time {
+	next := pq.blockedUntil
+	if pq.interval > 0 {
+		if t := pq.lastStart.Add(pq.interval); t.After(next) {
+			next = t
+		}
+	}
+	return next
+}
+
+// schedule releases the most urgent waiter if the provider is available,
+// or arms a timer for when it will be. Callers hold q.mu.
+func (q *RequestQueue) schedule(pq *providerQueue) {
+	if len(pq.waiting) == 0 || pq.probing {
+		return
+	}
+	now := q.now()
+	at := pq.nextStart()
+	if at.After(now) {
+		if pq.timer != nil && !pq.timerAt.After(at) {
+			return
+		}
+		if pq.timer != nil {
+			pq.timer.Stop()
+		}
+		pq.timerAt = at
+		pq.timer = time.AfterFunc(at.Sub(now), func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			pq.timer = nil
+			q.schedule(pq)
+		})
+		return
+	}
+	w := heap.Pop(&pq.waiting).(*waiter)
+	pq.probing = true
+	pq.lastStart = now
+	close(w.ready)
+}
+
+// Status returns a provider's queue, with the wait a new request of the
+// given priority can expect
+func (q *RequestQueue) Status(providerID string, priority int) *QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.status(q.queue(providerID), priority)
+}
+
+// Statuses returns every provider's queue, for requests of DefaultPriority
+func (q *RequestQueue) Statuses() []*QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]*QueueStatus, 0, len(q.queues))
+	for _, pq := range q.queues {
+		out = append(out, q.status(pq, DefaultPriority))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ProviderID < out[j].ProviderID })
+	return out
+}
+
+// status describes a queue. The expected wait is the time until the
+// provider is available plus one request's time, or the configured spacing
+// if longer, for every waiter that would go first. Callers hold q.mu.
+func (q *RequestQueue) status(pq *providerQueue, priority int) *QueueStatus {
+	now := q.now()
+	st := &QueueStatus{
+		ProviderID:       pq.id,
+		Depth:            len(pq.waiting),
+		RateLimited:      pq.blockedUntil.After(now),
+		Priority:         priority,
+		RateLimitedTotal: pq.limitedTotal,
+	}
+	if st.RateLimited {
+		st.BlockedUntil = pq.blockedUntil
+	}
+	if pq.interval > 0 {
+		st.RequestsPerMinute = int(time.Minute / pq.interval)
+	}
+	ahead := 0
+	if len(pq.waiting) > 0 {
+		st.DepthByPriority = make(map[int]int)
+		for _, w := range pq.waiting {
+			st.DepthByPriority[w.priority]++
+			if w.priority <= priority {
+				ahead++
+			}
+		}
+	}
+	if pq.probing {
+		ahead++
+	}
+	if next := pq.nextStart(); next.After(now) {
+		st.ExpectedWait = next.Sub(now)
+	}
+	per := max(pq.avgService, pq.interval)
+	st.ExpectedWait += time.Duration(ahead) * per
+	st.ExpectedWaitMs = st.ExpectedWait.Milliseconds()
+	return st
+}
+
+// waiterHeap orders waiters by priority, then arrival
+type waiterHeap []*waiter
+
+func (h waiterHeap) Len() int { return len(h) }
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority < h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *waiterHeap) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+func (h *waiterHeap) Pop() any {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*h = old[:n-1]
+	return w
+}
@@ -0,0 +1,156 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testQueue(maxWait time.Duration) *RequestQueue {
+	return NewRequestQueue(QueueConfig{
+		MaxWait:        maxWait,
+		MaxRetries:     3,
+		DefaultBackoff: 20 * time.Millisecond,
+		MaxBackoff:     100 * time.Millisecond,
+	})
+}
+
+func waitForDepth(t *testing.T, q *RequestQueue, providerID string, depth int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for q.Status(providerID, DefaultPriority).Depth != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue never reached depth %d", depth)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func TestRequestQueueRetriesAfterRateLimit(t *testing.T) {
+	q := testQueue(time.Second)
+	calls := 0
+	start := time.Now()
+	err := q.Do(context.Background(), "p", DefaultPriority, func(context.Context) error {
+		calls++
+		if calls == 1 {
+			return &RateLimitError{StatusCode: 429, RetryAfter: 50 * time.Millisecond}
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Fatalf("expected success on the second call, got %v after %d calls", err, calls)
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Errorf("expected the retry to honour Retry-After, waited %v", waited)
+	}
+
+	// Other errors are returned without retrying
+	boom := errors.New("boom")
+	if err := q.Do(context.Background(), "p", DefaultPriority, func(context.Context) error { return boom }); err != boom {
+		t.Errorf("expected the error to pass through, got %v", err)
+	}
+}
+
+func TestRequestQueueReleasesMostUrgentFirst(t *testing.T) {
+	q := testQueue(2 * time.Second)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	run := func(priority int, first error) {
+		defer wg.Done()
+		calls := 0
+		_ = q.Do(ctx, "p", priority, func(context.Context) error {
+			calls++
+			if calls == 1 && first != nil {
+				return first
+			}
+			mu.Lock()
+			order = append(order, priority)
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	// Rate limit the provider; the limited request waits for its retry
+	wg.Add(1)
+	go run(DefaultPriority, &RateLimitError{StatusCode: 429, RetryAfter: 150 * time.Millisecond})
+	waitForDepth(t, q, "p", 1)
+	if st := q.Status("p", 0); !st.RateLimited {
+		t.Fatalf("expected the provider to be rate limited, got %+v", st)
+	}
+
+	for i, priority := range []int{3, 1, 0} {
+		wg.Add(1)
+		go run(priority, nil)
+		waitForDepth(t, q, "p", i+2)
+	}
+
+	st := q.Status("p", 1)
+	if st.DepthByPriority[0] != 1 || st.ExpectedWait <= 0 {
+		t.Errorf("unexpected status %+v", st)
+	}
+	wg.Wait()
+	if len(order) != 4 || order[0] != 0 || order[1] != 1 || order[2] != 2 || order[3] != 3 {
+		t.Errorf("expected P0, P1, P2 then P3, got %v", order)
+	}
+}
+
+func TestRequestQueueGivesUpAfterMaxWait(t *testing.T) {
+	q := testQueue(50 * time.Millisecond)
+	err := q.Do(context.Background(), "p", DefaultPriority, func(context.Context) error {
+		return &RateLimitError{StatusCode: 429, RetryAfter: time.Second}
+	})
+	if !errors.Is(err, ErrQueueTimeout) {
+		t.Fatalf("expected ErrQueueTimeout, got %v", err)
+	}
+	if st := q.Status("p", DefaultPriority); st.Depth != 0 {
+		t.Errorf("expected the abandoned request to leave the queue, got depth %d", st.Depth)
+	}
+}
+
+func TestRequestQueueSpacesConfiguredLimit(t *testing.T) {
+	q := NewRequestQueue(QueueConfig{MaxWait: time.Second, RequestsPerMinute: map[string]int{"p": 1200}}) // one per 50ms
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := q.Do(context.Background(), "p", DefaultPriority, func(context.Context) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected requests spaced 50ms apart, took %v", elapsed)
+	}
+}
+
+func TestOpenAIProviderRateLimitError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":"slow down"}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewOpenAIProvider(srv.URL, "").CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: "m"})
+	var rl *RateLimitError
+	if !errors.As(err, &rl) || rl.RetryAfter != 7*time.Second {
+		t.Fatalf("expected a RateLimitError with a 7s Retry-After, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if got := parseRetryAfter("30", now); got != 30*time.Second {
+		t.Errorf("seconds: got %v", got)
+	}
+	if got := parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now); got != time.Minute {
+		t.Errorf("date: got %v", got)
+	}
+	if got := parseRetryAfter("soon", now); got != 0 {
+		t.Errorf("garbage: got %v", got)
+	}
+}
@@ -3,19 +3,23 @@ package provider
 import (
 	"context"
 	"fmt"
-	"log"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var registryLog = logging.Module("registry")
+
 // ProviderConfig represents the configuration for a provider
 type ProviderConfig struct {
 	ID                     string    `json:"id"`
 	Name                   string    `json:"name"`
 	Type                   string    `json:"type"` // openai, anthropic, local, etc.
 	Endpoint               string    `json:"endpoint"`
+	Endpoints              []string  `json:"endpoints,omitempty"` // Additional endpoints serving the same model
 	APIKey                 string    `json:"api_key,omitempty"`
 	Model                  string    `json:"model"` // effective model to use
 	ConfiguredModel        string    `json:"configured_model,omitempty"`
@@ -26,6 +30,7 @@ type ProviderConfig struct {
 	LastHeartbeatLatencyMs int64     `json:"last_heartbeat_latency_ms,omitempty"`
 	CapabilityScore        float64   `json:"capability_score,omitempty"` // Dynamic composite score from Scorer
 	ContextWindow          int       `json:"context_window,omitempty"`
+	SupportsVision         bool      `json:"supports_vision,omitempty"` // Accepts image content parts
 
 	// Model metadata for scoring
 	ModelParamsB    float64 `json:"model_params_b,omitempty"`     // Total model parameters in billions
@@ -45,12 +50,39 @@ type Registry struct {
 	metricsCallback MetricsCallback
 	rrCounter       uint64  // Round-robin counter for equal-priority providers
 	scorer          *Scorer // Dynamic provider scoring
+	vcr             *VCR    // Records or replays provider HTTP traffic when set
+	queue           *RequestQueue
+	hedge           *hedger // Hedges short latency-sensitive requests when set
 }
 
 // RegisteredProvider wraps a provider with its configuration and protocol
 type RegisteredProvider struct {
 	Config   *ProviderConfig
 	Protocol Protocol
+
+	queue *RequestQueue
+}
+
+// ChatCompletion sends a chat completion request, through the registry's
+// rate-limit queue when it has one. The request's priority comes from ctx
+// (see WithPriority). Images are replaced with a note for providers that
+// don't accept them.
+func (p *RegisteredProvider) ChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	if p.Config != nil && !p.Config.AcceptsImages() && HasImages(req.Messages) {
+		textOnly := *req
+		textOnly.Messages = withoutImages(req.Messages)
+		req = &textOnly
+	}
+	if p.queue == nil || p.Config == nil {
+		return p.Protocol.CreateChatCompletion(ctx, req)
+	}
+	var resp *ChatCompletionResponse
+	err := p.queue.Do(ctx, p.Config.ID, PriorityFromContext(ctx), func(ctx context.Context) error {
+		var err error
+		resp, err = p.Protocol.CreateChatCompletion(ctx, req)
+		return err
+	})
+	return resp, err
 }
 
 // NewRegistry creates a new provider registry
@@ -81,24 +113,18 @@ func (r *Registry) Register(config *ProviderConfig) error {
 		return fmt.Errorf("provider %s already registered", config.ID)
 	}
 
-	// Create protocol based on provider type
-	var protocol Protocol
-	switch config.Type {
-	case "openai", "anthropic", "local", "custom", "vllm":
-		// All use OpenAI-compatible protocol
-		protocol = NewOpenAIProvider(config.Endpoint, config.APIKey)
-	case "ollama":
-		protocol = NewOllamaProvider(config.Endpoint)
-	case "mock":
-		protocol = NewMockProvider()
-	default:
-		return fmt.Errorf("unsupported provider type: %s", config.Type)
+	protocol, err := newProtocol(config)
+	if err != nil {
+		return err
 	}
 
+	r.attachVCR(config, protocol)
+
 	// Register provider
 	r.providers[config.ID] = &RegisteredProvider{
 		Config:   config,
 		Protocol: protocol,
+		queue:    r.queue,
 	}
 
 	return nil
@@ -112,22 +138,126 @@ func (r *Registry) Upsert(config *ProviderConfig) error {
 		config.Status = "pending"
 	}
 
-	var protocol Protocol
-	switch config.Type {
-	case "openai", "anthropic", "local", "custom", "vllm":
-		protocol = NewOpenAIProvider(config.Endpoint, config.APIKey)
-	case "ollama":
-		protocol = NewOllamaProvider(config.Endpoint)
-	case "mock":
-		protocol = NewMockProvider()
-	default:
-		return fmt.Errorf("unsupported provider type: %s", config.Type)
+	protocol, err := newProtocol(config)
+	if err != nil {
+		return err
 	}
 
-	r.providers[config.ID] = &RegisteredProvider{Config: config, Protocol: protocol}
+	r.attachVCR(config, protocol)
+	r.providers[config.ID] = &RegisteredProvider{Config: config, Protocol: protocol, queue: r.queue}
 	return nil
 }
 
+// newProtocol creates the protocol for a provider's type, balanced across
+// its endpoints when it has more than one
+func newProtocol(config *ProviderConfig) (Protocol, error) {
+	urls := config.AllEndpoints()
+	protocols := make([]Protocol, 0, len(urls))
+	for _, endpoint := range urls {
+		var protocol Protocol
+		switch config.Type {
+		case "openai", "anthropic", "local", "custom", "vllm":
+			// All use OpenAI-compatible protocol
+			protocol = NewOpenAIProvider(endpoint, config.APIKey)
+		case "ollama":
+			protocol = NewOllamaProvider(endpoint)
+		case "mock":
+			mock, err := newMockProviderForEndpoint(endpoint)
+			if err != nil {
+				return nil, err
+			}
+			protocol = mock
+		default:
+			return nil, fmt.Errorf("unsupported provider type: %s", config.Type)
+		}
+		protocols = append(protocols, protocol)
+	}
+	if len(protocols) == 1 {
+		return protocols[0], nil
+	}
+	return NewBalancer(urls, protocols), nil
+}
+
+// AllEndpoints returns the provider's endpoint followed by its additional
+// endpoints, without duplicates
+func (c *ProviderConfig) AllEndpoints() []string {
+	urls := []string{c.Endpoint}
+	seen := map[string]bool{c.Endpoint: true}
+	for _, e := range c.Endpoints {
+		if e = strings.TrimSpace(e); e != "" && !seen[e] {
+			seen[e] = true
+			urls = append(urls, e)
+		}
+	}
+	return urls
+}
+
+// EndpointStats reports the load and health of each of a provider's
+// endpoints. A provider with a single endpoint reports its request metrics.
+func (r *Registry) EndpointStats(providerID string) ([]EndpointStats, error) {
+	p, err := r.Get(providerID)
+	if err != nil {
+		return nil, err
+	}
+	if b, ok := p.Protocol.(*Balancer); ok {
+		return b.Stats(), nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	st := EndpointStats{
+		Endpoint:     p.Config.Endpoint,
+		Healthy:      isProviderHealthy(p.Config.Status),
+		AvgLatencyMs: p.Config.AvgLatencyMs,
+		Requests:     p.Config.TotalRequests,
+		Failures:     p.Config.TotalRequests - p.Config.SuccessRequests,
+	}
+	if st.Requests > 0 {
+		st.ErrorRate = float64(st.Failures) / float64(st.Requests)
+	}
+	return []EndpointStats{st}, nil
+}
+
+// SetVCR records or replays the HTTP traffic of every provider registered
+// from now on, as well as those already registered
+func (r *Registry) SetVCR(vcr *VCR) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.vcr = vcr
+	for _, p := range r.providers {
+		r.attachVCR(p.Config, p.Protocol)
+	}
+}
+
+// SetRequestQueue queues requests to rate-limited providers, for providers
+// registered from now on as well as those already registered
+func (r *Registry) SetRequestQueue(q *RequestQueue) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queue = q
+	for _, p := range r.providers {
+		p.queue = q
+	}
+}
+
+// RequestQueue returns the rate-limit queue, or nil if requests aren't queued
+func (r *Registry) RequestQueue() *RequestQueue {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.queue
+}
+
+// attachVCR wraps an HTTP-based protocol's transport. Callers hold r.mu.
+func (r *Registry) attachVCR(config *ProviderConfig, protocol Protocol) {
+	ts, ok := protocol.(transportSetter)
+	if r.vcr == nil || !ok {
+		return
+	}
+	if config != nil {
+		r.vcr.AddSecret(config.APIKey)
+	}
+	ts.wrapTransport(r.vcr.Wrap)
+}
+
 // Unregister removes a provider from the registry
 func (r *Registry) Unregister(providerID string) error {
 	r.mu.Lock()
@@ -284,6 +414,13 @@ func (r *Registry) SendChatCompletionStream(ctx context.Context, providerID stri
 
 // SendChatCompletion sends a chat completion request to a provider
 func (r *Registry) SendChatCompletion(ctx context.Context, providerID string, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	return r.sendChatCompletion(ctx, providerID, req, false)
+}
+
+// sendChatCompletion sends a request and records its metrics. For a hedged
+// send, cancellation by the winning send says nothing about the provider,
+// so a cancelled send isn't recorded.
+func (r *Registry) sendChatCompletion(ctx context.Context, providerID string, req *ChatCompletionRequest, hedged bool) (*ChatCompletionResponse, error) {
 	startTime := time.Now()
 
 	provider, err := r.Get(providerID)
@@ -300,16 +437,16 @@ func (r *Registry) SendChatCompletion(ctx context.Context, providerID string, re
 	}
 
 	// Make the request
-	resp, err := provider.Protocol.CreateChatCompletion(ctx, req)
+	resp, err := provider.ChatCompletion(ctx, req)
 
 	// If model not found (404), the vLLM server may have restarted with a
 	// different model. Rediscover available models and retry once.
 	if err != nil && (strings.Contains(err.Error(), "status code 404") || strings.Contains(err.Error(), "not found")) {
-		log.Printf("[Registry] Model %q returned 404 on provider %s — rediscovering models", req.Model, providerID)
+		registryLog.Warn("Model not found on provider, rediscovering models", "model", req.Model, "provider_id", providerID)
 		models, modelErr := provider.Protocol.GetModels(ctx)
 		if modelErr == nil && len(models) > 0 {
 			newModel := models[0].ID
-			log.Printf("[Registry] Provider %s model changed: %q → %q", providerID, req.Model, newModel)
+			registryLog.Info("Provider model changed", "provider_id", providerID, "from", req.Model, "to", newModel)
 			r.mu.Lock()
 			if p, ok := r.providers[providerID]; ok && p.Config != nil {
 				p.Config.Model = newModel
@@ -317,11 +454,14 @@ func (r *Registry) SendChatCompletion(ctx context.Context, providerID string, re
 			}
 			r.mu.Unlock()
 			req.Model = newModel
-			resp, err = provider.Protocol.CreateChatCompletion(ctx, req)
+			resp, err = provider.ChatCompletion(ctx, req)
 		}
 	}
 
 	// Record metrics
+	if hedged && err != nil && ctx.Err() != nil {
+		return resp, err
+	}
 	latencyMs := time.Since(startTime).Milliseconds()
 	success := err == nil
 	totalTokens := int64(0)
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // StreamChunk represents a chunk in a streaming response
@@ -33,6 +34,9 @@ type StreamHandler func(chunk *StreamChunk) error
 func (p *OpenAIProvider) CreateChatCompletionStream(ctx context.Context, req *ChatCompletionRequest, handler StreamHandler) error {
 	// Ensure stream is enabled
 	req.Stream = true
+	if wantsSchema(req) && p.noSchema.Load() {
+		req = withoutSchema(req)
+	}
 
 	url := fmt.Sprintf("%s/chat/completions", p.endpoint)
 
@@ -79,6 +83,9 @@ func (p *OpenAIProvider) CreateChatCompletionStream(ctx context.Context, req *Ch
 		if resp.StatusCode == http.StatusBadRequest && isContextLengthError(bodyStr) {
 			return &ContextLengthError{StatusCode: resp.StatusCode, Body: bodyStr}
 		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return &RateLimitError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), Body: bodyStr}
+		}
 		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, bodyStr)
 	}
 
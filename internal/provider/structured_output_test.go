@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+var testSchema = map[string]interface{}{
+	"type":       "object",
+	"properties": map[string]interface{}{"action": map[string]interface{}{"type": "string"}},
+	"required":   []string{"action"},
+}
+
+func TestOpenAIProvider_SchemaFormat(t *testing.T) {
+	var got []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"action\":\"done\"}"}}]}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider(server.URL, "")
+	req := &ChatCompletionRequest{Model: "m", ResponseFormat: SchemaFormat("action", testSchema)}
+	if _, err := p.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	rf := got[0]["response_format"].(map[string]interface{})
+	if rf["type"] != "json_schema" {
+		t.Fatalf("response_format = %v", rf)
+	}
+	schema := rf["json_schema"].(map[string]interface{})
+	if schema["name"] != "action" || schema["schema"].(map[string]interface{})["type"] != "object" {
+		t.Errorf("unexpected json_schema %v", schema)
+	}
+}
+
+func TestOpenAIProvider_SchemaRejectedFallsBack(t *testing.T) {
+	var mu sync.Mutex
+	var formats []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResponseFormat ResponseFormat `json:"response_format"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		formats = append(formats, body.ResponseFormat.Type)
+		mu.Unlock()
+		if body.ResponseFormat.Type == ResponseFormatJSONSchema {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"response_format type json_schema is not supported"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{}"}}]}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider(server.URL, "")
+	for i := 0; i < 2; i++ {
+		req := &ChatCompletionRequest{Model: "m", ResponseFormat: SchemaFormat("action", testSchema)}
+		if _, err := p.CreateChatCompletion(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+		if req.ResponseFormat.Type != ResponseFormatJSONSchema {
+			t.Error("expected the caller's request to be left alone")
+		}
+	}
+	// The first call is rejected and retried; the second skips the schema
+	want := []string{"json_schema", "json_object", "json_object"}
+	if len(formats) != len(want) {
+		t.Fatalf("formats = %v, want %v", formats, want)
+	}
+	for i := range want {
+		if formats[i] != want[i] {
+			t.Fatalf("formats = %v, want %v", formats, want)
+		}
+	}
+}
+
+func TestOpenAIProvider_OtherBadRequestNotRetried(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"unknown model"}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider(server.URL, "")
+	req := &ChatCompletionRequest{Model: "m", ResponseFormat: SchemaFormat("action", testSchema)}
+	if _, err := p.CreateChatCompletion(context.Background(), req); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 || p.noSchema.Load() {
+		t.Errorf("expected one call and schemas still enabled, got %d calls", calls)
+	}
+}
+
+func TestOllamaProvider_SchemaFormat(t *testing.T) {
+	var formats []json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Format json.RawMessage `json:"format"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		formats = append(formats, body.Format)
+		if len(body.Format) > 0 && body.Format[0] == '{' && len(formats) > 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid format: expected \"json\""}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"model":"m","message":{"role":"assistant","content":"{}"},"done":true}`))
+	}))
+	defer server.Close()
+
+	p := NewOllamaProvider(server.URL)
+	ctx := context.Background()
+	if _, err := p.CreateChatCompletion(ctx, &ChatCompletionRequest{Model: "m", ResponseFormat: SchemaFormat("action", testSchema)}); err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(formats[0], &schema); err != nil || schema["type"] != "object" {
+		t.Fatalf("expected the schema as the format, got %s", formats[0])
+	}
+	if _, err := p.CreateChatCompletion(ctx, &ChatCompletionRequest{Model: "m", ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject}}); err != nil {
+		t.Fatal(err)
+	}
+	if string(formats[1]) != `"json"` {
+		t.Errorf("expected json_object to map to \"json\", got %s", formats[1])
+	}
+
+	// A server that rejects schemas gets "json" from then on
+	if _, err := p.CreateChatCompletion(ctx, &ChatCompletionRequest{Model: "m", ResponseFormat: SchemaFormat("action", testSchema)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(formats) != 4 || string(formats[3]) != `"json"` || !p.noSchema.Load() {
+		t.Errorf("expected a fallback to \"json\", got %d requests", len(formats))
+	}
+}
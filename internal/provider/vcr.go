@@ -0,0 +1,298 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VCR modes
+const (
+	VCRRecord = "record"
+	VCRReplay = "replay"
+)
+
+// redactedValue replaces secrets in recorded requests and responses
+const redactedValue = "REDACTED"
+
+// secretPatterns match credentials that may appear in provider traffic
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-[A-Za-z0-9_\-]{8,}`),
+	regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/\-]+=*`),
+	regexp.MustCompile(`(?i)("(?:api_key|apikey|access_token|authorization|password|secret)"\s*:\s*")[^"]*(")`),
+}
+
+// secretQueryParams are URL parameters whose values are redacted
+var secretQueryParams = []string{"key", "api_key", "apikey", "token", "access_token"}
+
+// Interaction is one recorded provider request and its response. Headers
+// are not recorded, so credentials sent as headers never reach the cassette.
+type Interaction struct {
+	Key          string    `json:"key"`
+	Method       string    `json:"method"`
+	URL          string    `json:"url"`
+	RequestBody  string    `json:"request_body,omitempty"`
+	StatusCode   int       `json:"status_code"`
+	ContentType  string    `json:"content_type,omitempty"`
+	ResponseBody string    `json:"response_body"`
+	RecordedAt   time.Time `json:"recorded_at"`
+}
+
+// Cassette is the file format of a VCR recording
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// VCR records provider HTTP traffic to a cassette file or replays it. In
+// record mode requests go to the provider and each sanitized exchange is
+// appended to the cassette. In replay mode requests are answered from the
+// cassette in recorded order and never reach the network.
+type VCR struct {
+	mode string
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+	secrets  []string
+}
+
+// NewVCR opens a cassette for recording or replay. Recording appends to an
+// existing cassette; replay requires one.
+func NewVCR(mode, path string) (*VCR, error) {
+	if mode != VCRRecord && mode != VCRReplay {
+		return nil, fmt.Errorf("unsupported VCR mode: %s", mode)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("VCR cassette path is required")
+	}
+	v := &VCR{mode: mode, path: path}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &v.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+	case os.IsNotExist(err) && mode == VCRRecord:
+	default:
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	v.used = make([]bool, len(v.cassette.Interactions))
+	return v, nil
+}
+
+// Mode returns the VCR mode
+func (v *VCR) Mode() string {
+	return v.mode
+}
+
+// AddSecret redacts a literal value, such as a provider API key, from
+// everything recorded
+func (v *VCR) AddSecret(secret string) {
+	if len(secret) < 4 {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, s := range v.secrets {
+		if s == secret {
+			return
+		}
+	}
+	v.secrets = append(v.secrets, secret)
+}
+
+// Interactions returns a copy of the cassette contents
+func (v *VCR) Interactions() []Interaction {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return append([]Interaction(nil), v.cassette.Interactions...)
+}
+
+// Wrap returns a transport that records through, or replays instead of, next
+func (v *VCR) Wrap(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &vcrTransport{vcr: v, next: next}
+}
+
+type vcrTransport struct {
+	vcr  *VCR
+	next http.RoundTripper
+}
+
+func (t *vcrTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	v := t.vcr
+	reqURL, reqBody := v.sanitizeURL(req.URL), v.sanitize(string(body))
+	key := interactionKey(req.Method, req.URL.Path, reqBody)
+
+	if v.mode == VCRReplay {
+		in, err := v.take(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s %s)", err, req.Method, reqURL)
+		}
+		return in.response(req), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if err := v.record(Interaction{
+		Key:          key,
+		Method:       req.Method,
+		URL:          reqURL,
+		RequestBody:  reqBody,
+		StatusCode:   resp.StatusCode,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ResponseBody: v.sanitize(string(respBody)),
+		RecordedAt:   time.Now().UTC(),
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// take returns the first unused interaction recorded for the key
+func (v *VCR) take(key string) (*Interaction, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i := range v.cassette.Interactions {
+		if !v.used[i] && v.cassette.Interactions[i].Key == key {
+			v.used[i] = true
+			in := v.cassette.Interactions[i]
+			return &in, nil
+		}
+	}
+	return nil, fmt.Errorf("no recorded interaction matches request")
+}
+
+// record appends an interaction and rewrites the cassette so a crash loses
+// at most the exchange in flight
+func (v *VCR) record(in Interaction) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.cassette.Interactions = append(v.cassette.Interactions, in)
+	v.used = append(v.used, true)
+
+	data, err := json.MarshalIndent(v.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(v.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return os.Rename(tmp, v.path)
+}
+
+func (in *Interaction) response(req *http.Request) *http.Response {
+	header := http.Header{}
+	if in.ContentType != "" {
+		header.Set("Content-Type", in.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+		StatusCode:    in.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(in.ResponseBody)),
+		ContentLength: int64(len(in.ResponseBody)),
+		Request:       req,
+	}
+}
+
+// sanitize redacts secrets from a request or response body
+func (v *VCR) sanitize(s string) string {
+	v.mu.Lock()
+	secrets := v.secrets
+	v.mu.Unlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redactedValue)
+	}
+	for _, re := range secretPatterns {
+		if re.NumSubexp() == 2 {
+			s = re.ReplaceAllString(s, "${1}"+redactedValue+"${2}")
+		} else {
+			s = re.ReplaceAllString(s, redactedValue)
+		}
+	}
+	return s
+}
+
+func (v *VCR) sanitizeURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	q := c.Query()
+	for _, name := range secretQueryParams {
+		if q.Has(name) {
+			q.Set(name, redactedValue)
+		}
+	}
+	c.RawQuery = q.Encode()
+	return v.sanitize(c.String())
+}
+
+// interactionKey identifies a request independently of the host and of JSON
+// key order, so recordings replay against any endpoint
+func interactionKey(method, path, body string) string {
+	var parsed interface{}
+	if json.Unmarshal([]byte(body), &parsed) == nil {
+		if canonical, err := json.Marshal(parsed); err == nil {
+			body = string(canonical)
+		}
+	}
+	sum := sha256.Sum256([]byte(method + " " + path + "\n" + body))
+	return hex.EncodeToString(sum[:12])
+}
+
+// transportSetter is implemented by HTTP-based protocols so a VCR can wrap
+// their clients
+type transportSetter interface {
+	wrapTransport(wrap func(http.RoundTripper) http.RoundTripper)
+}
+
+func (p *OpenAIProvider) wrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	p.client.Transport = wrap(p.client.Transport)
+	if p.streamingClient != nil {
+		p.streamingClient.Transport = wrap(p.streamingClient.Transport)
+	}
+}
+
+func (p *OllamaProvider) wrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	p.client.Transport = wrap(p.client.Transport)
+}
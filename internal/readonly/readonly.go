@@ -0,0 +1,95 @@
+// Package readonly is the server-wide read-only switch. While it is on, the
+// API rejects mutating requests, the action router refuses actions that
+// change anything and the dispatcher hands out no work; reads, event
+// streams and analytics carry on. It is set in the config and can be
+// flipped at runtime by an admin, for incident response, migrations and
+// audits.
+package readonly
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Status is the state of the switch
+type Status struct {
+	Enabled bool       `json:"enabled"`
+	Reason  string     `json:"reason,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+	By      string     `json:"by,omitempty"` // "config", or the user who turned it on
+}
+
+// Switch holds the read-only state. A nil switch is never read-only.
+type Switch struct {
+	mu     sync.RWMutex
+	status Status
+	now    func() time.Time
+}
+
+// New creates a switch, on when the config says so
+func New(cfg config.ReadOnlyConfig) *Switch {
+	s := &Switch{now: time.Now}
+	if cfg.Enabled {
+		s.Set(true, cfg.Reason, "config")
+	}
+	return s
+}
+
+// Enabled reports whether the server is read-only
+func (s *Switch) Enabled() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status.Enabled
+}
+
+// Status returns the current state
+func (s *Switch) Status() Status {
+	if s == nil {
+		return Status{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Set turns read-only mode on or off and returns the new state. Turning it
+// on again updates the reason but keeps the time it started.
+func (s *Switch) Set(enabled bool, reason, by string) Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !enabled {
+		s.status = Status{}
+		return s.status
+	}
+	since := s.status.Since
+	if since == nil {
+		now := s.now().UTC()
+		since = &now
+	}
+	s.status = Status{Enabled: true, Reason: reason, Since: since, By: by}
+	return s.status
+}
+
+// Check returns the error to reject a mutation with while the server is
+// read-only, or nil
+func (s *Switch) Check() error {
+	status := s.Status()
+	if !status.Enabled {
+		return nil
+	}
+	msg := "server is in read-only mode"
+	if status.Reason != "" {
+		msg += ": " + status.Reason
+	}
+	err := apperr.New(apperr.CodeReadOnly, "%s", msg)
+	if status.Reason != "" {
+		err = err.WithDetail("reason", status.Reason)
+	}
+	return err.WithDetail("since", status.Since)
+}
@@ -0,0 +1,56 @@
+package readonly
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	s := New(config.ReadOnlyConfig{Enabled: true, Reason: "database migration"})
+	status := s.Status()
+	if !status.Enabled || status.Reason != "database migration" || status.By != "config" || status.Since == nil {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if New(config.ReadOnlyConfig{}).Enabled() {
+		t.Error("expected the switch to be off by default")
+	}
+	var nilSwitch *Switch
+	if nilSwitch.Enabled() || nilSwitch.Check() != nil {
+		t.Error("expected a nil switch to never be read-only")
+	}
+}
+
+func TestSetAndCheck(t *testing.T) {
+	s := New(config.ReadOnlyConfig{})
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return start }
+
+	if err := s.Check(); err != nil {
+		t.Fatalf("expected no error while writable, got %v", err)
+	}
+	s.Set(true, "incident 42", "admin-1")
+	err := s.Check()
+	if !errors.Is(err, &apperr.Error{Code: apperr.CodeReadOnly}) {
+		t.Fatalf("expected a read_only error, got %v", err)
+	}
+	if apperr.DetailsOf(err)["reason"] != "incident 42" {
+		t.Errorf("expected the reason in the details, got %v", apperr.DetailsOf(err))
+	}
+
+	// Turning it on again keeps the start time
+	s.now = func() time.Time { return start.Add(time.Hour) }
+	if status := s.Set(true, "incident 42, audit", "admin-2"); !status.Since.Equal(start) || status.By != "admin-2" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if status := s.Set(false, "", "admin-1"); status.Enabled || status.Since != nil {
+		t.Errorf("expected the state to be cleared, got %+v", status)
+	}
+	if err := s.Check(); err != nil {
+		t.Errorf("expected no error once turned off, got %v", err)
+	}
+}
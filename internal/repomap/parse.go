@@ -0,0 +1,252 @@
+package repomap
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// languages maps file extensions to the language whose declarations are
+// extracted
+var languages = map[string]string{
+	".go":   "go",
+	".py":   "python",
+	".js":   "javascript",
+	".jsx":  "javascript",
+	".mjs":  "javascript",
+	".ts":   "typescript",
+	".tsx":  "typescript",
+	".rs":   "rust",
+	".java": "java",
+	".kt":   "kotlin",
+	".rb":   "ruby",
+}
+
+// declPatterns find top-level declarations in languages parsed without an
+// AST. The first group is the kind, the second the name.
+var declPatterns = map[string]*regexp.Regexp{
+	"python":     regexp.MustCompile(`^(class|def|async def)\s+([A-Za-z_]\w*)`),
+	"javascript": regexp.MustCompile(`^export\s+(?:default\s+)?(?:async\s+)?(class|function\*?|const|let|interface|type|enum)\s+([A-Za-z_$][\w$]*)`),
+	"typescript": regexp.MustCompile(`^export\s+(?:default\s+)?(?:abstract\s+)?(?:async\s+)?(class|function\*?|const|let|interface|type|enum)\s+([A-Za-z_$][\w$]*)`),
+	"rust":       regexp.MustCompile(`^pub\s+(?:async\s+)?(fn|struct|enum|trait|type|const|mod)\s+([A-Za-z_]\w*)`),
+	"java":       regexp.MustCompile(`^public\s+(?:(?:abstract|final|static|sealed)\s+)*(class|interface|enum|record)\s+([A-Za-z_]\w*)`),
+	"kotlin":     regexp.MustCompile(`^(?:(?:data|sealed|abstract|open|enum)\s+)*(class|interface|object|fun)\s+([A-Za-z_]\w*)`),
+	"ruby":       regexp.MustCompile(`^(class|module|def)\s+([A-Za-z_][\w:.]*)`),
+}
+
+// FileSymbols returns the declarations a map lists for a source file: the
+// exported ones for Go, the top-level ones for other languages. Files in
+// unsupported languages, and Go files that don't parse, have none.
+func FileSymbols(path string, src []byte) []Symbol {
+	switch lang := Language(path); lang {
+	case "":
+		return nil
+	case "go":
+		_, _, symbols, _ := parseGo(path, src)
+		return symbols
+	default:
+		_, symbols := parseOther(lang, src)
+		return symbols
+	}
+}
+
+// parseGo extracts a Go file's package, doc comment and exported
+// declarations
+func parseGo(path string, src []byte) (pkg, doc string, symbols []Symbol, ok bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return "", "", nil, false
+	}
+	if f.Doc != nil {
+		doc = firstSentence(strings.TrimPrefix(f.Doc.Text(), "Package "+f.Name.Name+" "))
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() || d.Recv != nil && !exportedReceiver(d.Recv) {
+				continue
+			}
+			symbols = append(symbols, Symbol{Kind: funcKind(d), Name: funcName(d), Signature: signature(d)})
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if !s.Name.IsExported() {
+						continue
+					}
+					kind := "type"
+					switch s.Type.(type) {
+					case *ast.InterfaceType:
+						kind = "interface"
+					case *ast.StructType:
+						kind = "struct"
+					}
+					symbols = append(symbols, Symbol{Kind: kind, Name: s.Name.Name})
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							symbols = append(symbols, Symbol{Kind: d.Tok.String(), Name: name.Name})
+						}
+					}
+				}
+			}
+		}
+	}
+	return f.Name.Name, doc, symbols, true
+}
+
+func exportedReceiver(recv *ast.FieldList) bool {
+	if len(recv.List) == 0 {
+		return false
+	}
+	return ast.IsExported(receiverType(recv.List[0].Type))
+}
+
+// receiverType names a method's receiver type without pointer or type
+// parameters
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+func funcKind(d *ast.FuncDecl) string {
+	if d.Recv != nil {
+		return "method"
+	}
+	return "func"
+}
+
+func funcName(d *ast.FuncDecl) string {
+	if d.Recv != nil && len(d.Recv.List) > 0 {
+		return receiverType(d.Recv.List[0].Type) + "." + d.Name.Name
+	}
+	return d.Name.Name
+}
+
+// signature renders a function's parameter and result types compactly,
+// e.g. "(context.Context, string) (*Bead, error)"
+func signature(d *ast.FuncDecl) string {
+	sig := "(" + fieldTypes(d.Type.Params) + ")"
+	if d.Type.Results.NumFields() == 1 {
+		sig += " " + fieldTypes(d.Type.Results)
+	} else if d.Type.Results.NumFields() > 1 {
+		sig += " (" + fieldTypes(d.Type.Results) + ")"
+	}
+	return sig
+}
+
+// fieldTypes lists the types of a parameter or result list, once per name
+func fieldTypes(fields *ast.FieldList) string {
+	if fields == nil {
+		return ""
+	}
+	var parts []string
+	for _, f := range fields.List {
+		typ := typeString(f.Type)
+		for i := 0; i < len(f.Names) || i == 0; i++ {
+			parts = append(parts, typ)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// typeString renders a type expression, abbreviating function and inline
+// struct types
+func typeString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return "*" + typeString(t.X)
+	case *ast.SelectorExpr:
+		return typeString(t.X) + "." + t.Sel.Name
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + typeString(t.Elt)
+		}
+		return "[...]" + typeString(t.Elt)
+	case *ast.MapType:
+		return "map[" + typeString(t.Key) + "]" + typeString(t.Value)
+	case *ast.Ellipsis:
+		return "..." + typeString(t.Elt)
+	case *ast.ChanType:
+		return "chan " + typeString(t.Value)
+	case *ast.InterfaceType:
+		return "interface{}"
+	case *ast.StructType:
+		return "struct{...}"
+	case *ast.FuncType:
+		return "func(...)"
+	case *ast.IndexExpr:
+		return typeString(t.X) + "[" + typeString(t.Index) + "]"
+	case *ast.IndexListExpr:
+		return typeString(t.X) + "[...]"
+	}
+	return "?"
+}
+
+// parseOther extracts top-level declarations with the language's pattern,
+// and a leading comment as the file's purpose
+func parseOther(language string, src []byte) (doc string, symbols []Symbol) {
+	pattern := declPatterns[language]
+	lines := strings.Split(string(src), "\n")
+	doc = leadingComment(lines)
+	if pattern == nil {
+		return doc, nil
+	}
+	for _, line := range lines {
+		if m := pattern.FindStringSubmatch(line); m != nil {
+			symbols = append(symbols, Symbol{Kind: strings.TrimSuffix(m[1], "*"), Name: m[2]})
+		}
+	}
+	return doc, symbols
+}
+
+// leadingComment returns the first sentence of the comment or docstring
+// opening a file, skipping a shebang and blank lines
+func leadingComment(lines []string) string {
+	var text []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#!") || line == "" && len(text) == 0 {
+			continue
+		}
+		stripped := strings.TrimLeft(line, `/#*" `)
+		if line == "" || stripped == line {
+			break
+		}
+		text = append(text, strings.TrimRight(stripped, `*/" `))
+	}
+	return firstSentence(strings.Join(text, " "))
+}
+
+// firstSentence cuts text to its first sentence, at most 160 bytes
+func firstSentence(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if i := strings.Index(text, ". "); i >= 0 {
+		text = text[:i+1]
+	}
+	if len(text) > 160 {
+		text = text[:157] + "..."
+	}
+	return text
+}
+
+// Language names the language of a source file, or "" for files a map
+// doesn't cover
+func Language(path string) string {
+	return languages[strings.ToLower(filepath.Ext(path))]
+}
@@ -0,0 +1,101 @@
+package repomap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/contextpack"
+)
+
+// DefaultBudget is the token budget of the prompt block when none is given
+const DefaultBudget = 1500
+
+// Markdown renders the map as a prompt block within budget tokens; 0 or
+// less renders everything. Packages are listed in directory order with
+// their files and declarations; once the budget runs short, packages are
+// reduced to their file names and then left out.
+func Markdown(m *Map, budget int) string {
+	var sb strings.Builder
+	sb.WriteString("## Repository Map\n\n")
+	commit := ""
+	if m.Commit != "" {
+		commit = fmt.Sprintf(" at %.12s", m.Commit)
+	}
+	fmt.Fprintf(&sb, "%d packages, %d source files%s.\n", len(m.Packages), m.Files, commit)
+
+	remaining := budget - contextpack.EstimateTokens(sb.String())
+	for i, p := range m.Packages {
+		block := renderPackage(p, true)
+		if budget > 0 && contextpack.EstimateTokens(block) > remaining {
+			block = renderPackage(p, false)
+		}
+		if budget > 0 && contextpack.EstimateTokens(block) > remaining-8 {
+			fmt.Fprintf(&sb, "\n... %d more packages\n", len(m.Packages)-i)
+			break
+		}
+		sb.WriteString(block)
+		remaining -= contextpack.EstimateTokens(block)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// renderPackage renders a package with its files' declarations, or only
+// its file names when detailed is false
+func renderPackage(p *Package, detailed bool) string {
+	var sb strings.Builder
+	sb.WriteString("\n" + p.Dir)
+	if p.Name != "" && p.Name != lastElem(p.Dir) {
+		fmt.Fprintf(&sb, " (package %s)", p.Name)
+	}
+	if purpose := p.purpose(); purpose != "" {
+		sb.WriteString(": " + purpose)
+	}
+	sb.WriteString("\n")
+	if !detailed {
+		names := make([]string, len(p.Files))
+		for i, f := range p.Files {
+			names[i] = lastElem(f.Path)
+		}
+		sb.WriteString("- " + strings.Join(names, ", ") + "\n")
+		return sb.String()
+	}
+	for _, f := range p.Files {
+		sb.WriteString("- " + lastElem(f.Path))
+		if f.Purpose != "" {
+			sb.WriteString(" — " + f.Purpose)
+		}
+		if len(f.Symbols) > 0 {
+			decls := make([]string, len(f.Symbols))
+			for i, s := range f.Symbols {
+				decls[i] = s.String()
+			}
+			sb.WriteString(": " + strings.Join(decls, "; "))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// String renders a symbol as a short declaration, e.g. "func New(dir
+// string) *Store" or "struct Store"
+func (s Symbol) String() string {
+	if s.Kind == "method" {
+		return s.Name + s.Signature
+	}
+	return s.Kind + " " + s.Name + s.Signature
+}
+
+// purpose is the package's documented purpose, else its summary
+func (p *Package) purpose() string {
+	if p.Purpose != "" {
+		return p.Purpose
+	}
+	return p.Summary
+}
+
+func lastElem(path string) string {
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
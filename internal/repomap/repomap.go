@@ -0,0 +1,286 @@
+// Package repomap builds compressed structural summaries of project
+// repositories: their packages, the key types and functions in each, and
+// what each file is for, so agents can orient themselves without reading
+// the tree. Go is parsed to an AST, other languages by their declaration
+// syntax. Maps are cached per project and rebuilt when the checkout's
+// commit changes.
+package repomap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/files"
+)
+
+const (
+	// maxFiles caps how many source files one map covers
+	maxFiles = 3000
+	// maxFileSize skips generated and vendored blobs
+	maxFileSize = 256 << 10
+)
+
+// skipDirs are directories that hold dependencies, fixtures or build
+// output rather than the project's own code
+var skipDirs = map[string]bool{
+	"vendor": true, "node_modules": true, "testdata": true, "dist": true,
+	"build": true, "target": true, "__pycache__": true, "venv": true,
+}
+
+// Symbol is a declaration worth knowing about
+type Symbol struct {
+	Kind      string `json:"kind"` // func, method, struct, interface, type, const, var, class, ...
+	Name      string `json:"name"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// File is one source file of a package
+type File struct {
+	Path    string   `json:"path"`
+	Purpose string   `json:"purpose,omitempty"`
+	Symbols []Symbol `json:"symbols,omitempty"`
+}
+
+// Package is a directory of source files
+type Package struct {
+	Dir      string `json:"dir"`
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	// Purpose is the package's doc comment, when it has one
+	Purpose string `json:"purpose,omitempty"`
+	// Summary is an LLM-written purpose for packages without a doc comment
+	Summary string `json:"summary,omitempty"`
+	Files   []File `json:"files"`
+}
+
+// Map is the structural summary of a repository at a commit
+type Map struct {
+	ProjectID   string     `json:"project_id"`
+	Commit      string     `json:"commit,omitempty"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	Packages    []*Package `json:"packages"`
+	Files       int        `json:"files"`
+	Symbols     int        `json:"symbols"`
+	// Truncated is set when the repository had more source files than a map
+	// covers
+	Truncated  bool `json:"truncated,omitempty"`
+	Summarized bool `json:"summarized,omitempty"`
+}
+
+// CommitResolver reports the commit a checkout is at
+type CommitResolver interface {
+	GetCurrentCommit(workDir string) (string, error)
+}
+
+// Summarizer writes purposes for packages, keyed by directory
+type Summarizer interface {
+	Summarize(ctx context.Context, m *Map) (map[string]string, error)
+}
+
+// Generator builds and caches repository maps
+type Generator struct {
+	workDirs   files.WorkDirResolver
+	commits    CommitResolver
+	summarizer Summarizer
+
+	mu          sync.Mutex
+	cache       map[string]*Map // project ID -> latest map
+	summarizing map[string]bool
+	now         func() time.Time
+}
+
+// NewGenerator creates a generator
+func NewGenerator(workDirs files.WorkDirResolver, commits CommitResolver) *Generator {
+	return &Generator{
+		workDirs:    workDirs,
+		commits:     commits,
+		cache:       make(map[string]*Map),
+		summarizing: make(map[string]bool),
+		now:         time.Now,
+	}
+}
+
+// SetSummarizer has new maps' undocumented packages summarized in the
+// background; the cached map gains the summaries when they arrive
+func (g *Generator) SetSummarizer(s Summarizer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.summarizer = s
+}
+
+// Get returns the project's map, building it when the cached one is for a
+// different commit or refresh is set. Checkouts whose commit can't be read
+// are rebuilt every time.
+func (g *Generator) Get(ctx context.Context, projectID string, refresh bool) (*Map, error) {
+	workDir := g.workDirs.GetProjectWorkDir(projectID)
+	if info, err := os.Stat(workDir); err != nil || !info.IsDir() {
+		return nil, apperr.NotFound("project %s has no checkout", projectID)
+	}
+	commit, _ := g.commits.GetCurrentCommit(workDir)
+
+	g.mu.Lock()
+	cached := g.cache[projectID]
+	g.mu.Unlock()
+	if !refresh && cached != nil && commit != "" && cached.Commit == commit {
+		return cached, nil
+	}
+
+	m, err := Build(ctx, workDir)
+	if err != nil {
+		return nil, err
+	}
+	m.ProjectID = projectID
+	m.Commit = commit
+	m.GeneratedAt = g.now().UTC()
+
+	g.mu.Lock()
+	g.cache[projectID] = m
+	summarizer := g.summarizer
+	g.mu.Unlock()
+	if summarizer != nil {
+		g.summarize(projectID, m, summarizer)
+	}
+	return m, nil
+}
+
+// Cached returns the project's last map without building one
+func (g *Generator) Cached(projectID string) *Map {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.cache[projectID]
+}
+
+// Invalidate drops the project's cached map
+func (g *Generator) Invalidate(projectID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.cache, projectID)
+}
+
+// summarize asks for purposes of the map's undocumented packages and swaps
+// a summarized copy into the cache, unless a newer map replaced it
+func (g *Generator) summarize(projectID string, m *Map, summarizer Summarizer) {
+	undocumented := false
+	for _, p := range m.Packages {
+		undocumented = undocumented || p.Purpose == ""
+	}
+	g.mu.Lock()
+	if !undocumented || g.summarizing[projectID] {
+		g.mu.Unlock()
+		return
+	}
+	g.summarizing[projectID] = true
+	g.mu.Unlock()
+
+	go func() {
+		defer func() {
+			g.mu.Lock()
+			delete(g.summarizing, projectID)
+			g.mu.Unlock()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		purposes, err := summarizer.Summarize(ctx, m)
+		if err != nil || len(purposes) == 0 {
+			return
+		}
+		summarized := *m
+		summarized.Summarized = true
+		summarized.Packages = make([]*Package, len(m.Packages))
+		for i, p := range m.Packages {
+			c := *p
+			if c.Purpose == "" {
+				c.Summary = purposes[c.Dir]
+			}
+			summarized.Packages[i] = &c
+		}
+		g.mu.Lock()
+		if g.cache[projectID] == m {
+			g.cache[projectID] = &summarized
+		}
+		g.mu.Unlock()
+	}()
+}
+
+// Build maps the source tree under root
+func Build(ctx context.Context, root string) (*Map, error) {
+	m := &Map{Packages: []*Package{}}
+	packages := make(map[string]*Package)
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || skipDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		lang := Language(name)
+		if lang == "" || strings.HasSuffix(name, "_test.go") || strings.HasSuffix(name, ".min.js") || strings.HasSuffix(name, ".d.ts") {
+			return nil
+		}
+		if m.Files == maxFiles {
+			m.Truncated = true
+			return filepath.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		dir := filepath.ToSlash(filepath.Dir(rel))
+
+		p := packages[dir]
+		if p == nil {
+			p = &Package{Dir: dir, Name: filepath.Base(dir), Language: lang}
+			if dir == "." {
+				p.Name = filepath.Base(root)
+			}
+			packages[dir] = p
+		}
+		file := File{Path: rel}
+		if lang == "go" {
+			pkg, doc, symbols, ok := parseGo(rel, src)
+			if !ok {
+				return nil
+			}
+			p.Name, file.Symbols = pkg, symbols
+			if doc != "" && (p.Purpose == "" || name == "doc.go") {
+				p.Purpose = doc
+			}
+		} else {
+			file.Purpose, file.Symbols = parseOther(lang, src)
+		}
+		p.Files = append(p.Files, file)
+		m.Files++
+		m.Symbols += len(file.Symbols)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to map %s: %w", root, err)
+	}
+
+	for _, p := range packages {
+		sort.Slice(p.Files, func(i, j int) bool { return p.Files[i].Path < p.Files[j].Path })
+		m.Packages = append(m.Packages, p)
+	}
+	sort.Slice(m.Packages, func(i, j int) bool { return m.Packages[i].Dir < m.Packages[j].Dir })
+	return m, nil
+}
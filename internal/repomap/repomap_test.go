@@ -0,0 +1,195 @@
+package repomap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/toolrun"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+var tree = map[string]string{
+	"main.go":               "package main\n\nfunc main() {}\n",
+	"internal/store/doc.go": "// Package store persists widgets. It uses SQLite.\npackage store\n",
+	"internal/store/store.go": `package store
+
+import "context"
+
+// Store keeps widgets
+type Store struct{ path string }
+
+type Finder interface{ Find(id string) (*Widget, error) }
+
+const DefaultPath = "widgets.db"
+
+func New(path string) *Store { return &Store{path: path} }
+
+func (s *Store) Get(ctx context.Context, id string, fresh bool) (*Widget, error) { return nil, nil }
+
+func (s *Store) close() {}
+
+func helper() {}
+`,
+	"internal/store/store_test.go": "package store\n\nfunc TestX() {}\n",
+	"web/app.ts":                   "// Widget dashboard entry point.\nexport class App {}\nexport function render(el: Element) {}\nconst internal = 1\n",
+	"scripts/sync.py":              "#!/usr/bin/env python3\n\"\"\"Sync widgets from the upstream catalog.\"\"\"\n\nclass Syncer:\n    def run(self):\n        pass\n\ndef main():\n    pass\n",
+	"node_modules/dep/index.js":    "export function dep() {}\n",
+	".git/HEAD":                    "ref: refs/heads/main\n",
+}
+
+func TestBuild(t *testing.T) {
+	m, err := Build(context.Background(), writeTree(t, tree))
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	var dirs []string
+	for _, p := range m.Packages {
+		dirs = append(dirs, p.Dir)
+	}
+	if got := strings.Join(dirs, ","); got != ".,internal/store,scripts,web" {
+		t.Fatalf("packages = %s", got)
+	}
+	if m.Files != 5 {
+		t.Errorf("files = %d, want 5 (tests and dependencies skipped)", m.Files)
+	}
+
+	store := m.Packages[1]
+	if store.Name != "store" || store.Purpose != "persists widgets." {
+		t.Errorf("store package = %q: %q", store.Name, store.Purpose)
+	}
+	var decls []string
+	for _, s := range store.Files[1].Symbols {
+		decls = append(decls, s.String())
+	}
+	want := "struct Store; interface Finder; const DefaultPath; func New(string) *Store; Store.Get(context.Context, string, bool) (*Widget, error)"
+	if got := strings.Join(decls, "; "); got != want {
+		t.Errorf("store.go declarations:\n got %s\nwant %s", got, want)
+	}
+
+	py := m.Packages[2].Files[0]
+	if py.Purpose != "Sync widgets from the upstream catalog." || len(py.Symbols) != 2 || py.Symbols[0].Name != "Syncer" {
+		t.Errorf("python file = %+v", py)
+	}
+	ts := m.Packages[3].Files[0]
+	if ts.Purpose != "Widget dashboard entry point." || len(ts.Symbols) != 2 || ts.Symbols[1].String() != "function render" {
+		t.Errorf("typescript file = %+v", ts)
+	}
+}
+
+func TestMarkdown_Budget(t *testing.T) {
+	m, err := Build(context.Background(), writeTree(t, tree))
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := Markdown(m, 0)
+	if !strings.Contains(full, "internal/store: persists widgets.") || !strings.Contains(full, "func New(string) *Store") {
+		t.Errorf("full map:\n%s", full)
+	}
+
+	short := Markdown(m, 60)
+	if len(short) >= len(full) || !strings.Contains(short, "more packages") {
+		t.Errorf("budgeted map:\n%s", short)
+	}
+}
+
+type fakeCommits struct{ commit string }
+
+func (f *fakeCommits) GetCurrentCommit(string) (string, error) { return f.commit, nil }
+
+type fakeSummarizer struct{}
+
+func (fakeSummarizer) Summarize(ctx context.Context, m *Map) (map[string]string, error) {
+	return map[string]string{"scripts": "Operational scripts.", "internal/store": "ignored: documented"}, nil
+}
+
+func TestGenerator_Cache(t *testing.T) {
+	commits := &fakeCommits{commit: "aaa"}
+	g := NewGenerator(toolrun.StaticWorkDir(writeTree(t, tree)), commits)
+	ctx := context.Background()
+
+	first, err := g.Get(ctx, "p1", false)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if again, _ := g.Get(ctx, "p1", false); again != first {
+		t.Error("same commit should be served from the cache")
+	}
+	if refreshed, _ := g.Get(ctx, "p1", true); refreshed == first {
+		t.Error("refresh should rebuild")
+	}
+	commits.commit = "bbb"
+	m, _ := g.Get(ctx, "p1", false)
+	if m.Commit != "bbb" || g.Cached("p1") != m {
+		t.Errorf("new commit not rebuilt: %+v", m)
+	}
+	g.Invalidate("p1")
+	if g.Cached("p1") != nil {
+		t.Error("invalidate left the map cached")
+	}
+
+	if _, err := NewGenerator(toolrun.StaticWorkDir(filepath.Join(t.TempDir(), "missing")), commits).Get(ctx, "p2", false); err == nil {
+		t.Error("expected an error for a project with no checkout")
+	}
+}
+
+func TestGenerator_Summarize(t *testing.T) {
+	g := NewGenerator(toolrun.StaticWorkDir(writeTree(t, tree)), &fakeCommits{commit: "aaa"})
+	g.SetSummarizer(fakeSummarizer{})
+	m, err := g.Get(context.Background(), "p1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Summarized {
+		t.Error("the map should be served before summaries arrive")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for g.Cached("p1") == m && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	summarized := g.Cached("p1")
+	if !summarized.Summarized {
+		t.Fatal("summaries never arrived")
+	}
+	for _, p := range summarized.Packages {
+		switch p.Dir {
+		case "scripts":
+			if p.Summary != "Operational scripts." {
+				t.Errorf("scripts summary = %q", p.Summary)
+			}
+		case "internal/store":
+			if p.Summary != "" {
+				t.Errorf("documented package summarized: %q", p.Summary)
+			}
+		}
+	}
+	if m.Packages[2].Summary != "" {
+		t.Error("the original map was modified")
+	}
+}
+
+func TestParsePurposes(t *testing.T) {
+	packages := []*Package{{Dir: "cmd/tool"}, {Dir: "lib"}}
+	got := parsePurposes("- `cmd/tool`: Command-line entry point. Parses flags.\nlib: Shared helpers\nother: not asked\nnonsense", packages)
+	if len(got) != 2 || got["cmd/tool"] != "Command-line entry point." || got["lib"] != "Shared helpers" {
+		t.Errorf("purposes = %v", got)
+	}
+}
@@ -0,0 +1,89 @@
+package repomap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/provider"
+)
+
+// summaryPrompt asks for one line per undocumented package
+const summaryPrompt = `Below is the structure of some packages in a code repository: each package directory followed by its files and their declarations. For each package, say in one short phrase what it is for. Answer with exactly one line per package in the form "<directory>: <purpose>" and nothing else. Do not guess beyond what the names suggest.`
+
+// summaryInputBudget bounds the structure sent for summarizing, in tokens
+const summaryInputBudget = 6000
+
+// Completer sends chat completions to a provider
+type Completer interface {
+	SendChatCompletion(ctx context.Context, providerID string, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error)
+}
+
+// LLMSummarizer summarizes packages with a chat completion
+type LLMSummarizer struct {
+	llm        Completer
+	providerID func() string
+}
+
+// NewLLMSummarizer creates a summarizer sending completions to the provider
+// providerID returns at the time of each summary
+func NewLLMSummarizer(llm Completer, providerID func() string) *LLMSummarizer {
+	return &LLMSummarizer{llm: llm, providerID: providerID}
+}
+
+// Summarize asks the provider what the map's undocumented packages are for
+func (s *LLMSummarizer) Summarize(ctx context.Context, m *Map) (map[string]string, error) {
+	providerID := ""
+	if s.providerID != nil {
+		providerID = s.providerID()
+	}
+	if providerID == "" {
+		return nil, fmt.Errorf("no active provider to summarize with")
+	}
+
+	undocumented := &Map{Commit: m.Commit}
+	for _, p := range m.Packages {
+		if p.Purpose == "" {
+			undocumented.Packages = append(undocumented.Packages, p)
+			undocumented.Files += len(p.Files)
+		}
+	}
+	if len(undocumented.Packages) == 0 {
+		return nil, nil
+	}
+
+	resp, err := s.llm.SendChatCompletion(ctx, providerID, &provider.ChatCompletionRequest{
+		Messages: []provider.ChatMessage{
+			{Role: "system", Content: summaryPrompt},
+			{Role: "user", Content: Markdown(undocumented, summaryInputBudget)},
+		},
+		Temperature: 0.2,
+		MaxTokens:   20 * len(undocumented.Packages),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("summary failed: %w", err)
+	}
+	if resp == nil || len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("provider %s returned no summary", providerID)
+	}
+	return parsePurposes(resp.Choices[0].Message.Content, undocumented.Packages), nil
+}
+
+// parsePurposes reads "<directory>: <purpose>" lines, keeping those for
+// the packages asked about
+func parsePurposes(text string, packages []*Package) map[string]string {
+	known := make(map[string]bool, len(packages))
+	for _, p := range packages {
+		known[p.Dir] = true
+	}
+	purposes := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimLeft(strings.TrimSpace(line), "-* ")
+		dir, purpose, ok := strings.Cut(line, ": ")
+		dir = strings.Trim(dir, "`")
+		if ok && known[dir] && strings.TrimSpace(purpose) != "" {
+			purposes[dir] = firstSentence(purpose)
+		}
+	}
+	return purposes
+}
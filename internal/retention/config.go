@@ -0,0 +1,49 @@
+package retention
+
+import (
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Defaults for settings left empty in the retention config
+const (
+	DefaultInterval       = time.Hour
+	DefaultArchiveDir     = "./archive"
+	DefaultClosedBeadDays = 90
+	DefaultRequestLogDays = 30
+)
+
+// WithDefaults fills in unset retention settings. Closed beads fall back
+// to beads.compact_old_days before the built-in default.
+func WithDefaults(cfg config.RetentionConfig, compactOldDays int) config.RetentionConfig {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.ArchiveDir == "" {
+		cfg.ArchiveDir = DefaultArchiveDir
+	}
+	if cfg.ClosedBeadDays == 0 {
+		cfg.ClosedBeadDays = compactOldDays
+	}
+	if cfg.ClosedBeadDays == 0 {
+		cfg.ClosedBeadDays = DefaultClosedBeadDays
+	}
+	if cfg.RequestLogDays == 0 {
+		cfg.RequestLogDays = DefaultRequestLogDays
+	}
+	return cfg
+}
+
+// PolicyFrom converts a retention config to a policy. Negative day counts
+// keep that data forever.
+func PolicyFrom(cfg config.RetentionConfig) Policy {
+	var p Policy
+	if cfg.ClosedBeadDays > 0 {
+		p.ClosedBeadAge = time.Duration(cfg.ClosedBeadDays) * 24 * time.Hour
+	}
+	if cfg.RequestLogDays > 0 {
+		p.RequestLogAge = time.Duration(cfg.RequestLogDays) * 24 * time.Hour
+	}
+	return p
+}
@@ -0,0 +1,356 @@
+// Package retention keeps the database from growing without bound. Closed
+// beads past their retention age are written, with their conversations,
+// command logs and action outputs, to compressed per-bead archives and then
+// dropped from the database and bead cache; analytics request logs past
+// their window are pruned.
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var retentionLog = logging.Module("retention")
+
+// ArchiveVersion is the format written to bead archives
+const ArchiveVersion = 1
+
+// BeadStore lists beads and drops archived ones from the active set
+type BeadStore interface {
+	ListBeads(filters map[string]interface{}) ([]*models.Bead, error)
+	EvictBead(id string)
+}
+
+// LogStore reads and deletes the logs recorded for beads
+type LogStore interface {
+	GetBeadLogs(beadID string) (*database.BeadLogs, error)
+	DeleteBeadLogs(beadID string) (int64, error)
+	StorageStats() (*database.StorageStats, error)
+}
+
+// RequestLogPruner deletes analytics request logs
+type RequestLogPruner interface {
+	DeleteOldLogs(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Policy sets how long data is kept. Zero keeps it forever.
+type Policy struct {
+	ClosedBeadAge time.Duration // closed beads are archived this long after closing
+	RequestLogAge time.Duration // request logs are deleted past this age
+}
+
+// Archive is the content of one archived bead
+type Archive struct {
+	Version       int                           `json:"version"`
+	ArchivedAt    time.Time                     `json:"archived_at"`
+	Bead          *models.Bead                  `json:"bead"`
+	Conversations []*models.ConversationContext `json:"conversations,omitempty"`
+	CommandLogs   []*models.CommandLog          `json:"command_logs,omitempty"`
+	ActionOutputs []ArchivedOutput              `json:"action_outputs,omitempty"`
+}
+
+// ArchivedOutput is an action output with its content, which the API
+// otherwise never serializes
+type ArchivedOutput struct {
+	*models.ActionOutput
+	Content string `json:"content"`
+}
+
+// Report describes one retention pass
+type Report struct {
+	RanAt              time.Time `json:"ran_at"`
+	BeadsArchived      int       `json:"beads_archived"`
+	LogRowsDeleted     int64     `json:"log_rows_deleted"`
+	RequestLogsDeleted int64     `json:"request_logs_deleted"`
+	Errors             []string  `json:"errors,omitempty"`
+}
+
+// Usage reports storage held by the database and the archive
+type Usage struct {
+	Database      *database.StorageStats `json:"database"`
+	ArchiveDir    string                 `json:"archive_dir"`
+	ArchiveBytes  int64                  `json:"archive_bytes"`
+	ArchivedBeads int                    `json:"archived_beads"`
+	Policy        Policy                 `json:"policy"`
+	LastRun       *Report                `json:"last_run,omitempty"`
+}
+
+// Manager applies a retention policy
+type Manager struct {
+	beads       BeadStore
+	logs        LogStore
+	requestLogs RequestLogPruner
+	dir         string
+	policy      Policy
+	readOnly    func() error
+
+	mu      sync.Mutex // serializes passes
+	lastRun *Report
+	now     func() time.Time
+}
+
+// NewManager creates a retention manager archiving into dir. requestLogs
+// may be nil when analytics logging is unavailable.
+func NewManager(beads BeadStore, logs LogStore, requestLogs RequestLogPruner, dir string, policy Policy) *Manager {
+	return &Manager{
+		beads:       beads,
+		logs:        logs,
+		requestLogs: requestLogs,
+		dir:         dir,
+		policy:      policy,
+		now:         time.Now,
+	}
+}
+
+// SetReadOnlyCheck installs a check run before each pass; while it returns
+// an error passes are refused with it and nothing is archived or deleted
+func (m *Manager) SetReadOnlyCheck(check func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readOnly = check
+}
+
+// Start applies the policy every interval until ctx is cancelled
+func (m *Manager) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := m.Run(ctx)
+			if apperr.CodeOf(err) == apperr.CodeReadOnly {
+				continue
+			}
+			if err != nil {
+				retentionLog.Error("Pass failed", "error", err)
+				continue
+			}
+			if report.BeadsArchived > 0 || report.RequestLogsDeleted > 0 {
+				retentionLog.Info("Retention pass done", "beads_archived", report.BeadsArchived,
+					"log_rows_deleted", report.LogRowsDeleted, "request_logs_deleted", report.RequestLogsDeleted)
+			}
+			for _, e := range report.Errors {
+				retentionLog.Warn("Retention error", "error", e)
+			}
+		}
+	}
+}
+
+// Run applies the policy once. Failures on individual beads are collected
+// in the report rather than stopping the pass.
+func (m *Manager) Run(ctx context.Context) (*Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.readOnly != nil {
+		if err := m.readOnly(); err != nil {
+			return nil, err
+		}
+	}
+
+	now := m.now()
+	report := &Report{RanAt: now}
+
+	if m.policy.ClosedBeadAge > 0 {
+		closed, err := m.beads.ListBeads(map[string]interface{}{"status": models.BeadStatusClosed})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list closed beads: %w", err)
+		}
+		cutoff := now.Add(-m.policy.ClosedBeadAge)
+		for _, bead := range closed {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			if !closedAt(bead).Before(cutoff) {
+				continue
+			}
+			deleted, err := m.archiveBead(bead, now)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("bead %s: %v", bead.ID, err))
+				continue
+			}
+			report.BeadsArchived++
+			report.LogRowsDeleted += deleted
+		}
+	}
+
+	if m.policy.RequestLogAge > 0 && m.requestLogs != nil {
+		n, err := m.requestLogs.DeleteOldLogs(ctx, now.Add(-m.policy.RequestLogAge))
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("request logs: %v", err))
+		}
+		report.RequestLogsDeleted = n
+	}
+
+	m.lastRun = report
+	return report, nil
+}
+
+// archiveBead writes a bead and its logs to the archive, then deletes the
+// logs and evicts the bead. An existing archive is never overwritten, so a
+// bead reloaded after a restart is evicted again without losing its logs.
+func (m *Manager) archiveBead(bead *models.Bead, now time.Time) (int64, error) {
+	path, err := m.archivePath(bead.ProjectID, bead.ID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		logs, err := m.logs.GetBeadLogs(bead.ID)
+		if err != nil {
+			return 0, err
+		}
+		archive := &Archive{
+			Version:       ArchiveVersion,
+			ArchivedAt:    now.UTC(),
+			Bead:          bead,
+			Conversations: logs.Conversations,
+			CommandLogs:   logs.CommandLogs,
+		}
+		for _, out := range logs.ActionOutputs {
+			archive.ActionOutputs = append(archive.ActionOutputs, ArchivedOutput{ActionOutput: out, Content: out.Content})
+		}
+		if err := writeArchive(path, archive); err != nil {
+			return 0, err
+		}
+	} else if err != nil {
+		return 0, err
+	}
+
+	deleted, err := m.logs.DeleteBeadLogs(bead.ID)
+	if err != nil {
+		return 0, err
+	}
+	m.beads.EvictBead(bead.ID)
+	return deleted, nil
+}
+
+// LoadArchive reads an archived bead
+func (m *Manager) LoadArchive(beadID string) (*Archive, error) {
+	if !validName(beadID) {
+		return nil, fmt.Errorf("invalid bead ID %q", beadID)
+	}
+	matches, _ := filepath.Glob(filepath.Join(m.dir, "*", beadID+".json.gz"))
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("archive not found: %s", beadID)
+	}
+	f, err := os.Open(matches[0])
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt archive for %s: %w", beadID, err)
+	}
+	defer gz.Close()
+	var archive Archive
+	if err := json.NewDecoder(gz).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("corrupt archive for %s: %w", beadID, err)
+	}
+	return &archive, nil
+}
+
+// Usage reports database size, row counts and archive size
+func (m *Manager) Usage() (*Usage, error) {
+	stats, err := m.logs.StorageStats()
+	if err != nil {
+		return nil, err
+	}
+	usage := &Usage{Database: stats, ArchiveDir: m.dir, Policy: m.policy}
+	err = filepath.WalkDir(m.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		usage.ArchiveBytes += info.Size()
+		if strings.HasSuffix(path, ".json.gz") {
+			usage.ArchivedBeads++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	usage.LastRun = m.lastRun
+	m.mu.Unlock()
+	return usage, nil
+}
+
+func (m *Manager) archivePath(projectID, beadID string) (string, error) {
+	if projectID == "" {
+		projectID = "_unassigned"
+	}
+	if !validName(projectID) || !validName(beadID) {
+		return "", fmt.Errorf("cannot archive bead %q of project %q: unsafe name", beadID, projectID)
+	}
+	return filepath.Join(m.dir, projectID, beadID+".json.gz"), nil
+}
+
+func validName(s string) bool {
+	return s != "" && s == filepath.Base(s) && !strings.HasPrefix(s, ".")
+}
+
+// closedAt returns when a bead closed, falling back to its last update for
+// beads closed before ClosedAt was recorded
+func closedAt(bead *models.Bead) time.Time {
+	if bead.ClosedAt != nil {
+		return *bead.ClosedAt
+	}
+	return bead.UpdatedAt
+}
+
+// writeArchive writes atomically so a crash never leaves a partial archive
+// that would block a later retry
+func writeArchive(path string, archive *Archive) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(gz).Encode(archive); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,149 @@
+package retention
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/readonly"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeBeads struct {
+	beads   map[string]*models.Bead
+	evicted []string
+}
+
+func (f *fakeBeads) ListBeads(filters map[string]interface{}) ([]*models.Bead, error) {
+	var out []*models.Bead
+	for _, b := range f.beads {
+		if status, ok := filters["status"].(models.BeadStatus); ok && b.Status != status {
+			continue
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+func (f *fakeBeads) EvictBead(id string) {
+	delete(f.beads, id)
+	f.evicted = append(f.evicted, id)
+}
+
+type fakeRequestLogs struct{ before time.Time }
+
+func (f *fakeRequestLogs) DeleteOldLogs(ctx context.Context, before time.Time) (int64, error) {
+	f.before = before
+	return 3, nil
+}
+
+func TestRunArchivesOldClosedBeads(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "loom.db"))
+	if err != nil {
+		t.Fatalf("database.New: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-100 * 24 * time.Hour)
+	recent := now.Add(-24 * time.Hour)
+	beads := &fakeBeads{beads: map[string]*models.Bead{
+		"bd-old":    {ID: "bd-old", ProjectID: "proj", Status: models.BeadStatusClosed, ClosedAt: &old},
+		"bd-recent": {ID: "bd-recent", ProjectID: "proj", Status: models.BeadStatusClosed, ClosedAt: &recent},
+		"bd-open":   {ID: "bd-open", ProjectID: "proj", Status: models.BeadStatusOpen, UpdatedAt: old},
+	}}
+
+	conv := models.NewConversationContext("s-1", "bd-old", "proj", time.Hour)
+	conv.AddMessage("user", "fix the build", 3)
+	if err := db.CreateConversationContext(conv); err != nil {
+		t.Fatalf("CreateConversationContext: %v", err)
+	}
+	if err := db.SaveActionOutput(&models.ActionOutput{ID: "out-1", ProjectID: "proj", BeadID: "bd-old",
+		ActionType: "run_command", Field: "stdout", Content: "PASS", CreatedAt: old}); err != nil {
+		t.Fatalf("SaveActionOutput: %v", err)
+	}
+	if err := db.SaveActionOutput(&models.ActionOutput{ID: "out-2", ProjectID: "proj", BeadID: "bd-recent",
+		ActionType: "run_command", Field: "stdout", Content: "keep", CreatedAt: recent}); err != nil {
+		t.Fatalf("SaveActionOutput: %v", err)
+	}
+
+	requestLogs := &fakeRequestLogs{}
+	m := NewManager(beads, db, requestLogs, t.TempDir(), Policy{ClosedBeadAge: 90 * 24 * time.Hour, RequestLogAge: 30 * 24 * time.Hour})
+	m.now = func() time.Time { return now }
+
+	report, err := m.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.BeadsArchived != 1 || report.LogRowsDeleted != 2 || report.RequestLogsDeleted != 3 || len(report.Errors) > 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(beads.evicted) != 1 || beads.evicted[0] != "bd-old" {
+		t.Errorf("evicted = %v", beads.evicted)
+	}
+	if !requestLogs.before.Equal(now.Add(-30 * 24 * time.Hour)) {
+		t.Errorf("request logs pruned before %v", requestLogs.before)
+	}
+
+	archive, err := m.LoadArchive("bd-old")
+	if err != nil {
+		t.Fatalf("LoadArchive: %v", err)
+	}
+	if archive.Bead.ID != "bd-old" || len(archive.Conversations) != 1 || len(archive.ActionOutputs) != 1 || archive.ActionOutputs[0].Content != "PASS" {
+		t.Errorf("archive incomplete: %+v", archive)
+	}
+	if logs, _ := db.GetBeadLogs("bd-old"); len(logs.Conversations)+len(logs.ActionOutputs) != 0 {
+		t.Errorf("logs not deleted: %+v", logs)
+	}
+	if logs, _ := db.GetBeadLogs("bd-recent"); len(logs.ActionOutputs) != 1 {
+		t.Errorf("recent bead's logs were touched: %+v", logs)
+	}
+
+	// A bead reloaded after a restart is evicted again without clobbering
+	// its archive
+	beads.beads["bd-old"] = &models.Bead{ID: "bd-old", ProjectID: "proj", Status: models.BeadStatusClosed, ClosedAt: &old}
+	if _, err := m.Run(context.Background()); err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if archive, _ := m.LoadArchive("bd-old"); archive == nil || len(archive.ActionOutputs) != 1 {
+		t.Errorf("archive overwritten on second pass: %+v", archive)
+	}
+
+	usage, err := m.Usage()
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if usage.ArchivedBeads != 1 || usage.ArchiveBytes == 0 || usage.Database.SizeBytes == 0 || usage.Database.Rows["action_outputs"] != 1 {
+		t.Errorf("unexpected usage: %+v %+v", usage, usage.Database)
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	cfg := WithDefaults(config.RetentionConfig{}, 45)
+	if cfg.ClosedBeadDays != 45 || cfg.RequestLogDays != DefaultRequestLogDays || cfg.ArchiveDir != DefaultArchiveDir {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if p := PolicyFrom(WithDefaults(config.RetentionConfig{ClosedBeadDays: -1, RequestLogDays: -1}, 45)); p.ClosedBeadAge != 0 || p.RequestLogAge != 0 {
+		t.Errorf("negative days should keep data forever: %+v", p)
+	}
+}
+
+func TestRunRefusedWhileReadOnly(t *testing.T) {
+	old := time.Now().Add(-100 * 24 * time.Hour)
+	beads := &fakeBeads{beads: map[string]*models.Bead{
+		"bd-old": {ID: "bd-old", ProjectID: "proj", Status: models.BeadStatusClosed, ClosedAt: &old},
+	}}
+	m := NewManager(beads, nil, nil, t.TempDir(), Policy{ClosedBeadAge: 24 * time.Hour})
+	m.SetReadOnlyCheck(readonly.New(config.ReadOnlyConfig{Enabled: true}).Check)
+
+	if _, err := m.Run(context.Background()); apperr.CodeOf(err) != apperr.CodeReadOnly {
+		t.Fatalf("expected a read_only error, got %v", err)
+	}
+	if len(beads.evicted) != 0 {
+		t.Errorf("archived %v while read-only", beads.evicted)
+	}
+}
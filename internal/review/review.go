@@ -0,0 +1,429 @@
+// Package review is the built-in code review pipeline. When a bead reaches a
+// review node in its workflow, the pipeline collects the diff of the bead's
+// commits, asks a reviewer-role agent to grade it against a structured
+// rubric, posts the findings on the bead's pull request and advances the
+// workflow with the approved or rejected condition.
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/git"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/internal/provider"
+	"github.com/jordanhubbard/loom/internal/roles"
+	"github.com/jordanhubbard/loom/internal/workflow"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var reviewLog = logging.Module("review")
+
+// ReviewerRole is the role registry entry whose agents review diffs
+const ReviewerRole = "reviewer"
+
+// DefaultMaxDiffChars caps the diff sent to the reviewer (~15k tokens)
+const DefaultMaxDiffChars = 60000
+
+// Verdicts a reviewer can return
+const (
+	VerdictApprove        = "approve"
+	VerdictRequestChanges = "request_changes"
+)
+
+// Finding severities, most severe first
+const (
+	SeverityBlocker = "blocker"
+	SeverityMajor   = "major"
+	SeverityMinor   = "minor"
+	SeverityNit     = "nit"
+)
+
+var severityRank = map[string]int{
+	SeverityBlocker: 0,
+	SeverityMajor:   1,
+	SeverityMinor:   2,
+	SeverityNit:     3,
+}
+
+// Rubric is the review instruction given to the reviewer agent
+const Rubric = `Review the diff below against this rubric:
+1. Correctness: does the change do what the bead asks, without logic errors or regressions?
+2. Security: injection, leaked secrets, unsafe input handling, missing permission checks
+3. Tests: is new behaviour covered, and do existing tests still mean something?
+4. Maintainability: naming, structure, duplication, consistency with the surrounding code
+5. Performance: needless work, unbounded loops or queries, avoidable allocations
+
+Rate every finding by severity:
+- blocker: must be fixed before merge (bugs, security holes, broken builds)
+- major: should be fixed before merge
+- minor: worth fixing, does not block
+- nit: style or preference
+
+Respond with a single JSON object and nothing else:
+{"verdict": "approve" or "request_changes", "summary": "one paragraph", "findings": [{"severity": "blocker|major|minor|nit", "category": "correctness|security|tests|maintainability|performance", "file": "path", "line": 0, "message": "what is wrong", "suggestion": "how to fix it"}]}
+Request changes whenever there is a blocker or major finding.`
+
+// GitOperator is the subset of git operations the pipeline needs. Calls are
+// made with the bead's project ID in the context (see actions.WithProjectID).
+type GitOperator interface {
+	GetBeadCommits(ctx context.Context, beadID string) (map[string]interface{}, error)
+	CommitDiff(ctx context.Context, sha string) (string, error)
+	FindBeadPR(ctx context.Context, beadID string) (int, error)
+	CommentPR(ctx context.Context, beadID string, prNumber int, body string) error
+}
+
+// AgentLister lists the agents working on a project
+type AgentLister interface {
+	ListAgentsByProject(projectID string) []*models.Agent
+}
+
+// RoleResolver maps agents to their role in the role registry
+type RoleResolver interface {
+	RoleForAgent(agent *models.Agent) *models.AgentRole
+}
+
+// Completer sends chat completions to a provider
+type Completer interface {
+	SendChatCompletion(ctx context.Context, providerID string, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error)
+}
+
+// WorkflowAdvancer moves a workflow execution along an edge
+type WorkflowAdvancer interface {
+	AdvanceWorkflow(executionID string, condition workflow.EdgeCondition, agentID string, resultData map[string]string) error
+}
+
+// BeadUpdater records review results on the bead
+type BeadUpdater interface {
+	UpdateBead(id string, updates map[string]interface{}) error
+}
+
+// Finding is a single issue raised by the reviewer
+type Finding struct {
+	Severity   string `json:"severity"`
+	Category   string `json:"category,omitempty"`
+	File       string `json:"file,omitempty"`
+	Line       int    `json:"line,omitempty"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// Blocking reports whether the finding must be fixed before merge
+func (f Finding) Blocking() bool {
+	return f.Severity == SeverityBlocker || f.Severity == SeverityMajor
+}
+
+// Report is the outcome of reviewing a bead
+type Report struct {
+	BeadID     string    `json:"bead_id"`
+	ReviewerID string    `json:"reviewer_id"`
+	Commits    []string  `json:"commits"`
+	Verdict    string    `json:"verdict"`
+	Summary    string    `json:"summary"`
+	Findings   []Finding `json:"findings"`
+	PRNumber   int       `json:"pr_number,omitempty"`
+	Truncated  bool      `json:"truncated,omitempty"`
+}
+
+// Approved reports whether the change may proceed: the reviewer approved
+// and raised nothing blocking
+func (r *Report) Approved() bool {
+	if r.Verdict != VerdictApprove {
+		return false
+	}
+	for _, f := range r.Findings {
+		if f.Blocking() {
+			return false
+		}
+	}
+	return true
+}
+
+// Condition is the workflow edge condition the report resolves to
+func (r *Report) Condition() workflow.EdgeCondition {
+	if r.Approved() {
+		return workflow.EdgeConditionApproved
+	}
+	return workflow.EdgeConditionRejected
+}
+
+// Markdown renders the report as a pull request comment
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+	if r.Approved() {
+		sb.WriteString("## Automated review: approved\n\n")
+	} else {
+		sb.WriteString("## Automated review: changes requested\n\n")
+	}
+	if r.Summary != "" {
+		sb.WriteString(r.Summary + "\n\n")
+	}
+	if len(r.Findings) == 0 {
+		sb.WriteString("No findings.\n")
+	} else {
+		sb.WriteString("| Severity | Category | Location | Finding |\n|---|---|---|---|\n")
+		for _, f := range r.Findings {
+			location := f.File
+			if location != "" && f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", f.File, f.Line)
+			}
+			message := f.Message
+			if f.Suggestion != "" {
+				message += " Suggestion: " + f.Suggestion
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", f.Severity, f.Category, tableCell(location), tableCell(message))
+		}
+	}
+	fmt.Fprintf(&sb, "\nReviewed %d commit(s) for bead %s", len(r.Commits), r.BeadID)
+	if r.Truncated {
+		sb.WriteString(" (diff truncated)")
+	}
+	sb.WriteString(".\n")
+	return sb.String()
+}
+
+func tableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ParseReport extracts the reviewer's JSON verdict from its response,
+// tolerating code fences or prose around the object
+func ParseReport(response string) (*Report, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("review response contains no JSON object")
+	}
+	var report Report
+	if err := json.Unmarshal([]byte(response[start:end+1]), &report); err != nil {
+		return nil, fmt.Errorf("failed to parse review response: %w", err)
+	}
+
+	report.Verdict = strings.ToLower(strings.TrimSpace(report.Verdict))
+	switch report.Verdict {
+	case VerdictApprove, VerdictRequestChanges:
+	case "approved":
+		report.Verdict = VerdictApprove
+	case "reject", "rejected", "changes_requested":
+		report.Verdict = VerdictRequestChanges
+	default:
+		return nil, fmt.Errorf("invalid review verdict: %q", report.Verdict)
+	}
+	for i := range report.Findings {
+		sev := strings.ToLower(strings.TrimSpace(report.Findings[i].Severity))
+		if _, ok := severityRank[sev]; !ok {
+			sev = SeverityMinor
+		}
+		report.Findings[i].Severity = sev
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return severityRank[report.Findings[i].Severity] < severityRank[report.Findings[j].Severity]
+	})
+	return &report, nil
+}
+
+// Pipeline reviews beads that reach a review node
+type Pipeline struct {
+	git          GitOperator
+	agents       AgentLister
+	roles        RoleResolver
+	llm          Completer
+	workflow     WorkflowAdvancer
+	beads        BeadUpdater
+	maxDiffChars int
+}
+
+// NewPipeline creates a review pipeline
+func NewPipeline(git GitOperator, agents AgentLister, roles RoleResolver, llm Completer, wf WorkflowAdvancer, beads BeadUpdater) *Pipeline {
+	return &Pipeline{
+		git:          git,
+		agents:       agents,
+		roles:        roles,
+		llm:          llm,
+		workflow:     wf,
+		beads:        beads,
+		maxDiffChars: DefaultMaxDiffChars,
+	}
+}
+
+// Review runs the pipeline for a bead sitting at a review node of the given
+// workflow execution. Failing to comment on the PR is logged but does not
+// fail the review; any other error leaves the workflow where it is.
+func (p *Pipeline) Review(ctx context.Context, bead *models.Bead, executionID string) (*Report, error) {
+	if bead == nil {
+		return nil, fmt.Errorf("bead cannot be nil")
+	}
+	ctx = actions.WithProjectID(ctx, bead.ProjectID)
+
+	report, err := p.review(ctx, bead)
+	if err != nil {
+		p.updateBead(bead.ID, map[string]string{
+			"review_error":        err.Error(),
+			"review_attempted_at": time.Now().UTC().Format(time.RFC3339),
+		})
+		return nil, err
+	}
+
+	if prNumber, err := p.git.FindBeadPR(ctx, bead.ID); err != nil {
+		reviewLog.Warn("Could not look up PR", "bead_id", bead.ID, "error", err)
+	} else if prNumber > 0 {
+		report.PRNumber = prNumber
+		if err := p.git.CommentPR(ctx, bead.ID, prNumber, report.Markdown()); err != nil {
+			reviewLog.Error("Failed to comment on PR", "pr", prNumber, "bead_id", bead.ID, "error", err)
+		}
+	}
+
+	findings, _ := json.Marshal(report.Findings)
+	p.updateBead(bead.ID, map[string]string{
+		"review_verdict":  report.Verdict,
+		"review_summary":  report.Summary,
+		"review_findings": string(findings),
+		"review_agent_id": report.ReviewerID,
+		"review_pr":       fmt.Sprintf("%d", report.PRNumber),
+		"review_error":    "",
+		"reviewed_at":     time.Now().UTC().Format(time.RFC3339),
+	})
+
+	resultData := map[string]string{
+		"agent_id": report.ReviewerID,
+		"verdict":  report.Verdict,
+		"findings": fmt.Sprintf("%d", len(report.Findings)),
+		"summary":  report.Summary,
+	}
+	if err := p.workflow.AdvanceWorkflow(executionID, report.Condition(), report.ReviewerID, resultData); err != nil {
+		return report, fmt.Errorf("failed to advance workflow: %w", err)
+	}
+	reviewLog.Info("Bead reviewed", "bead_id", bead.ID, "reviewer", report.ReviewerID, "condition", report.Condition(), "findings", len(report.Findings))
+	return report, nil
+}
+
+func (p *Pipeline) review(ctx context.Context, bead *models.Bead) (*Report, error) {
+	reviewer, role := p.pickReviewer(bead.ProjectID)
+	if reviewer == nil {
+		return nil, fmt.Errorf("no reviewer agent with a provider for project %s", bead.ProjectID)
+	}
+
+	shas, diff, truncated, err := p.collectDiff(ctx, bead.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	system := roles.RenderPrompt(role, reviewer, bead.ProjectID)
+	if system == "" {
+		system = fmt.Sprintf("You are %s, a code reviewer.", reviewer.Name)
+	}
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Bead %s: %s\n\n", bead.ID, bead.Title)
+	if bead.Description != "" {
+		prompt.WriteString(bead.Description + "\n\n")
+	}
+	prompt.WriteString(Rubric + "\n\n")
+	if truncated {
+		prompt.WriteString("(The diff was truncated to fit; review what is shown.)\n")
+	}
+	prompt.WriteString("```diff\n" + diff + "\n```\n")
+
+	resp, err := p.llm.SendChatCompletion(ctx, reviewer.ProviderID, &provider.ChatCompletionRequest{
+		Messages: []provider.ChatMessage{
+			{Role: "system", Content: system},
+			{Role: "user", Content: prompt.String()},
+		},
+		Temperature:    0.2,
+		ResponseFormat: &provider.ResponseFormat{Type: "json_object"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reviewer %s failed: %w", reviewer.ID, err)
+	}
+	if resp == nil || len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("reviewer %s returned no response", reviewer.ID)
+	}
+
+	report, err := ParseReport(resp.Choices[0].Message.Content)
+	if err != nil {
+		return nil, err
+	}
+	report.BeadID = bead.ID
+	report.ReviewerID = reviewer.ID
+	report.Commits = shas
+	report.Truncated = truncated
+	return report, nil
+}
+
+// pickReviewer returns a reviewer-role agent on the project that has a
+// provider, preferring idle ones
+func (p *Pipeline) pickReviewer(projectID string) (*models.Agent, *models.AgentRole) {
+	if p.agents == nil || p.roles == nil {
+		return nil, nil
+	}
+	var agents []*models.Agent
+	for _, agent := range p.agents.ListAgentsByProject(projectID) {
+		if agent != nil && agent.ProviderID != "" {
+			agents = append(agents, agent)
+		}
+	}
+	sort.SliceStable(agents, func(i, j int) bool {
+		if (agents[i].Status == "idle") != (agents[j].Status == "idle") {
+			return agents[i].Status == "idle"
+		}
+		return agents[i].ID < agents[j].ID
+	})
+	for _, agent := range agents {
+		if role := p.roles.RoleForAgent(agent); role != nil && role.Name == ReviewerRole {
+			return agent, role
+		}
+	}
+	return nil, nil
+}
+
+// collectDiff concatenates the patches of the bead's commits, oldest first
+func (p *Pipeline) collectDiff(ctx context.Context, beadID string) ([]string, string, bool, error) {
+	result, err := p.git.GetBeadCommits(ctx, beadID)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to get bead commits: %w", err)
+	}
+	commits, _ := result["commits"].([]git.CommitMetadata)
+	if len(commits) == 0 {
+		return nil, "", false, fmt.Errorf("no commits found for bead %s", beadID)
+	}
+
+	// git log lists newest first
+	shas := make([]string, 0, len(commits))
+	for i := len(commits) - 1; i >= 0; i-- {
+		shas = append(shas, commits[i].SHA)
+	}
+
+	var sb strings.Builder
+	for _, sha := range shas {
+		diff, err := p.git.CommitDiff(ctx, sha)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("failed to diff commit %s: %w", sha, err)
+		}
+		sb.WriteString(diff)
+		if !strings.HasSuffix(diff, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+
+	diff := sb.String()
+	truncated := false
+	if p.maxDiffChars > 0 && len(diff) > p.maxDiffChars {
+		diff = diff[:p.maxDiffChars]
+		truncated = true
+	}
+	return shas, diff, truncated, nil
+}
+
+func (p *Pipeline) updateBead(beadID string, ctxUpdates map[string]string) {
+	if p.beads == nil {
+		return
+	}
+	if err := p.beads.UpdateBead(beadID, map[string]interface{}{"context": ctxUpdates}); err != nil {
+		reviewLog.Error("Failed to update bead", "bead_id", beadID, "error", err)
+	}
+}
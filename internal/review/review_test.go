@@ -0,0 +1,213 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/git"
+	"github.com/jordanhubbard/loom/internal/provider"
+	"github.com/jordanhubbard/loom/internal/workflow"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeGit struct {
+	commits   []git.CommitMetadata
+	diffs     map[string]string
+	pr        int
+	comments  []string
+	projectID string
+}
+
+func (f *fakeGit) GetBeadCommits(ctx context.Context, beadID string) (map[string]interface{}, error) {
+	f.projectID = actions.ProjectIDFromContext(ctx)
+	return map[string]interface{}{"commits": f.commits, "count": len(f.commits), "bead_id": beadID}, nil
+}
+
+func (f *fakeGit) CommitDiff(ctx context.Context, sha string) (string, error) {
+	d, ok := f.diffs[sha]
+	if !ok {
+		return "", errors.New("unknown commit")
+	}
+	return d, nil
+}
+
+func (f *fakeGit) FindBeadPR(ctx context.Context, beadID string) (int, error) {
+	return f.pr, nil
+}
+
+func (f *fakeGit) CommentPR(ctx context.Context, beadID string, prNumber int, body string) error {
+	f.comments = append(f.comments, body)
+	return nil
+}
+
+type fakeAgents []*models.Agent
+
+func (a fakeAgents) ListAgentsByProject(projectID string) []*models.Agent {
+	return a
+}
+
+type fakeRoles struct{}
+
+func (fakeRoles) RoleForAgent(agent *models.Agent) *models.AgentRole {
+	if agent.Role == "Code Reviewer" {
+		return &models.AgentRole{Name: ReviewerRole, DeniedActions: []string{"git_push"}}
+	}
+	return &models.AgentRole{Name: "coder"}
+}
+
+type fakeLLM struct {
+	response   string
+	providerID string
+	prompt     string
+}
+
+func (f *fakeLLM) SendChatCompletion(ctx context.Context, providerID string, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	f.providerID = providerID
+	f.prompt = req.Messages[len(req.Messages)-1].Content
+	resp := &provider.ChatCompletionResponse{}
+	resp.Choices = append(resp.Choices, struct {
+		Index   int                  `json:"index"`
+		Message provider.ChatMessage `json:"message"`
+		Finish  string               `json:"finish_reason"`
+	}{Message: provider.ChatMessage{Role: "assistant", Content: f.response}})
+	return resp, nil
+}
+
+type fakeWorkflow struct {
+	executionID string
+	condition   workflow.EdgeCondition
+	agentID     string
+}
+
+func (f *fakeWorkflow) AdvanceWorkflow(executionID string, condition workflow.EdgeCondition, agentID string, resultData map[string]string) error {
+	f.executionID, f.condition, f.agentID = executionID, condition, agentID
+	return nil
+}
+
+type fakeBeads struct {
+	context map[string]string
+}
+
+func (f *fakeBeads) UpdateBead(id string, updates map[string]interface{}) error {
+	if f.context == nil {
+		f.context = map[string]string{}
+	}
+	for k, v := range updates["context"].(map[string]string) {
+		f.context[k] = v
+	}
+	return nil
+}
+
+func newTestPipeline(response string) (*Pipeline, *fakeGit, *fakeLLM, *fakeWorkflow, *fakeBeads) {
+	g := &fakeGit{
+		// Newest first, as git log returns them
+		commits: []git.CommitMetadata{{SHA: "bbb"}, {SHA: "aaa"}},
+		diffs:   map[string]string{"aaa": "commit aaa\n+first", "bbb": "commit bbb\n+second"},
+		pr:      42,
+	}
+	agents := fakeAgents{
+		{ID: "eng", Name: "Engineer", Role: "Engineering Manager", ProviderID: "p1", Status: "idle"},
+		{ID: "rev-busy", Name: "Busy Reviewer", Role: "Code Reviewer", ProviderID: "p2", Status: "working"},
+		{ID: "rev", Name: "Rita", Role: "Code Reviewer", ProviderID: "p3", Status: "idle"},
+	}
+	llm := &fakeLLM{response: response}
+	wf := &fakeWorkflow{}
+	b := &fakeBeads{}
+	return NewPipeline(g, agents, fakeRoles{}, llm, wf, b), g, llm, wf, b
+}
+
+func TestPipelineReviewRejects(t *testing.T) {
+	response := "```json\n" + `{"verdict": "approve", "summary": "Mostly fine",
+		"findings": [
+			{"severity": "nit", "message": "rename x"},
+			{"severity": "MAJOR", "category": "tests", "file": "a.go", "line": 3, "message": "no test | here", "suggestion": "add one"}
+		]}` + "\n```"
+	p, g, llm, wf, b := newTestPipeline(response)
+	bead := &models.Bead{ID: "bd-1", Title: "Add thing", ProjectID: "proj"}
+
+	report, err := p.Review(context.Background(), bead, "exec-1")
+	if err != nil {
+		t.Fatalf("Review: %v", err)
+	}
+
+	if llm.providerID != "p3" || report.ReviewerID != "rev" {
+		t.Errorf("expected idle reviewer rev on p3, got %s on %s", report.ReviewerID, llm.providerID)
+	}
+	if g.projectID != "proj" {
+		t.Errorf("git calls not scoped to project: %q", g.projectID)
+	}
+	if strings.Index(llm.prompt, "+first") > strings.Index(llm.prompt, "+second") || !strings.Contains(llm.prompt, "rubric") {
+		t.Errorf("unexpected prompt:\n%s", llm.prompt)
+	}
+	// A major finding overrides an approve verdict
+	if wf.condition != workflow.EdgeConditionRejected || wf.executionID != "exec-1" || wf.agentID != "rev" {
+		t.Errorf("workflow advanced with %+v", wf)
+	}
+	if report.Findings[0].Severity != SeverityMajor {
+		t.Errorf("findings not sorted by severity: %+v", report.Findings)
+	}
+	if len(g.comments) != 1 || !strings.Contains(g.comments[0], "changes requested") ||
+		!strings.Contains(g.comments[0], "a.go:3") || !strings.Contains(g.comments[0], `no test \| here`) {
+		t.Errorf("unexpected PR comments: %v", g.comments)
+	}
+	if b.context["review_verdict"] != VerdictApprove || b.context["review_pr"] != "42" || b.context["review_findings"] == "" {
+		t.Errorf("unexpected bead context: %v", b.context)
+	}
+}
+
+func TestPipelineReviewApprovesWithoutPR(t *testing.T) {
+	p, g, _, wf, _ := newTestPipeline(`{"verdict": "approved", "summary": "LGTM", "findings": [{"severity": "minor", "message": "typo"}]}`)
+	g.pr = 0
+
+	report, err := p.Review(context.Background(), &models.Bead{ID: "bd-2", ProjectID: "proj"}, "exec-2")
+	if err != nil {
+		t.Fatalf("Review: %v", err)
+	}
+	if !report.Approved() || wf.condition != workflow.EdgeConditionApproved {
+		t.Errorf("expected approval, got %+v / %s", report, wf.condition)
+	}
+	if len(g.comments) != 0 {
+		t.Errorf("commented without a PR: %v", g.comments)
+	}
+}
+
+func TestPipelineReviewErrors(t *testing.T) {
+	p, g, _, wf, b := newTestPipeline(`{"verdict": "approve"}`)
+	g.commits = nil
+	if _, err := p.Review(context.Background(), &models.Bead{ID: "bd-3", ProjectID: "proj"}, "exec-3"); err == nil {
+		t.Fatal("expected error without commits")
+	}
+	if wf.condition != "" || !strings.Contains(b.context["review_error"], "no commits") {
+		t.Errorf("workflow advanced or error not recorded: %s %v", wf.condition, b.context)
+	}
+
+	p, _, _, wf, _ = newTestPipeline(`{"verdict": "maybe"}`)
+	if _, err := p.Review(context.Background(), &models.Bead{ID: "bd-4", ProjectID: "proj"}, "exec-4"); err == nil {
+		t.Error("expected error for invalid verdict")
+	}
+	if wf.condition != "" {
+		t.Errorf("workflow advanced on invalid verdict: %s", wf.condition)
+	}
+
+	p, _, _, _, _ = newTestPipeline(`{"verdict": "approve"}`)
+	p.agents = fakeAgents{{ID: "eng", Role: "Engineering Manager", ProviderID: "p1"}}
+	if _, err := p.Review(context.Background(), &models.Bead{ID: "bd-5", ProjectID: "proj"}, "exec-5"); err == nil ||
+		!strings.Contains(err.Error(), "no reviewer") {
+		t.Errorf("expected no reviewer error, got %v", err)
+	}
+}
+
+func TestCollectDiffTruncates(t *testing.T) {
+	p, _, _, _, _ := newTestPipeline("")
+	p.maxDiffChars = 12
+	shas, diff, truncated, err := p.collectDiff(context.Background(), "bd-1")
+	if err != nil {
+		t.Fatalf("collectDiff: %v", err)
+	}
+	if len(shas) != 2 || shas[0] != "aaa" || !truncated || len(diff) != 12 {
+		t.Errorf("shas=%v truncated=%v diff=%q", shas, truncated, diff)
+	}
+}
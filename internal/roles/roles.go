@@ -0,0 +1,290 @@
+// Package roles is the agent role registry. Each role (architect, coder,
+// reviewer, tester, CEO, or a custom one) carries its own system prompt
+// template, the action types it may execute, and model/provider
+// preferences. The action router consults the registry so that, for
+// example, a reviewer agent cannot git_push.
+package roles
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Store persists role definitions
+type Store interface {
+	UpsertAgentRole(role *models.AgentRole) error
+	ListAgentRoles() ([]*models.AgentRole, error)
+	DeleteAgentRole(name string) error
+}
+
+// AgentLookup finds agents by ID
+type AgentLookup interface {
+	GetAgent(id string) (*models.Agent, error)
+}
+
+// PermissionError reports an action a role may not execute
+type PermissionError struct {
+	Role       string
+	ActionType string
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("action %s is not permitted for role %s", e.ActionType, e.Role)
+}
+
+// Registry holds the role definitions
+type Registry struct {
+	mu     sync.RWMutex
+	store  Store
+	agents AgentLookup
+	roles  map[string]*models.AgentRole
+	now    func() time.Time
+}
+
+// NewRegistry creates a registry. store may be nil, in which case roles only
+// live in memory; agents may be nil if only RoleForAgent is used.
+func NewRegistry(store Store, agents AgentLookup) *Registry {
+	return &Registry{
+		store:  store,
+		agents: agents,
+		roles:  make(map[string]*models.AgentRole),
+		now:    time.Now,
+	}
+}
+
+// Load reads the stored roles, seeding the built-in roles when there are none
+func (r *Registry) Load() error {
+	var stored []*models.AgentRole
+	if r.store != nil {
+		var err error
+		if stored, err = r.store.ListAgentRoles(); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(stored) == 0 {
+		now := r.now().UTC()
+		for _, role := range models.DefaultAgentRoles() {
+			role.CreatedAt, role.UpdatedAt = now, now
+			if r.store != nil {
+				if err := r.store.UpsertAgentRole(role); err != nil {
+					return err
+				}
+			}
+			stored = append(stored, role)
+		}
+	}
+	r.roles = make(map[string]*models.AgentRole, len(stored))
+	for _, role := range stored {
+		r.roles[role.Name] = role
+	}
+	return nil
+}
+
+// List returns every role ordered by name
+func (r *Registry) List() []*models.AgentRole {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*models.AgentRole, 0, len(r.roles))
+	for _, role := range r.roles {
+		c := *role
+		list = append(list, &c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Get returns a role by name
+func (r *Registry) Get(name string) (*models.AgentRole, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, fmt.Errorf("agent role not found: %s", name)
+	}
+	c := *role
+	return &c, nil
+}
+
+// Create adds a new role
+func (r *Registry) Create(role *models.AgentRole) error {
+	if err := validate(role); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.roles[role.Name]; exists {
+		return fmt.Errorf("agent role already exists: %s", role.Name)
+	}
+	now := r.now().UTC()
+	role.CreatedAt, role.UpdatedAt = now, now
+	role.BuiltIn = false
+	return r.save(role)
+}
+
+// Update replaces an existing role's definition
+func (r *Registry) Update(role *models.AgentRole) error {
+	if err := validate(role); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.roles[role.Name]
+	if !ok {
+		return fmt.Errorf("agent role not found: %s", role.Name)
+	}
+	role.CreatedAt = existing.CreatedAt
+	role.BuiltIn = existing.BuiltIn
+	role.UpdatedAt = r.now().UTC()
+	return r.save(role)
+}
+
+// Delete removes a role; agents that matched it become unrestricted
+func (r *Registry) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.roles[name]; !ok {
+		return fmt.Errorf("agent role not found: %s", name)
+	}
+	if r.store != nil {
+		if err := r.store.DeleteAgentRole(name); err != nil {
+			return err
+		}
+	}
+	delete(r.roles, name)
+	return nil
+}
+
+// save persists a role; callers hold the lock
+func (r *Registry) save(role *models.AgentRole) error {
+	if r.store != nil {
+		if err := r.store.UpsertAgentRole(role); err != nil {
+			return err
+		}
+	}
+	c := *role
+	r.roles[role.Name] = &c
+	return nil
+}
+
+func validate(role *models.AgentRole) error {
+	if role == nil {
+		return fmt.Errorf("agent role cannot be nil")
+	}
+	role.Name = models.NormalizeRoleName(role.Name)
+	if role.Name == "" {
+		return fmt.Errorf("agent role name is required")
+	}
+	if role.SystemPrompt != "" {
+		if _, err := template.New(role.Name).Parse(role.SystemPrompt); err != nil {
+			return fmt.Errorf("invalid system prompt template: %w", err)
+		}
+	}
+	return nil
+}
+
+// RoleForAgent returns the role an agent's role or persona name maps to, or
+// nil when it matches none
+func (r *Registry) RoleForAgent(agent *models.Agent) *models.AgentRole {
+	if r == nil || agent == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Exact role names win over aliases
+	for _, name := range []string{agent.Role, agent.PersonaName} {
+		if role, ok := r.roles[models.NormalizeRoleName(name)]; ok {
+			c := *role
+			return &c
+		}
+	}
+	names := make([]string, 0, len(r.roles))
+	for name := range r.roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, candidate := range []string{agent.Role, agent.PersonaName} {
+		for _, name := range names {
+			if r.roles[name].Matches(candidate) {
+				c := *r.roles[name]
+				return &c
+			}
+		}
+	}
+	return nil
+}
+
+// CheckAction returns a PermissionError when the agent's role may not
+// execute the action type
+func (r *Registry) CheckAction(agentID, actionType string) error {
+	if r == nil || r.agents == nil || agentID == "" {
+		return nil
+	}
+	agent, err := r.agents.GetAgent(agentID)
+	if err != nil {
+		return nil
+	}
+	role := r.RoleForAgent(agent)
+	if role == nil || role.Allows(actionType) {
+		return nil
+	}
+	return &PermissionError{Role: role.Name, ActionType: actionType}
+}
+
+// promptData is what role system prompt templates can reference
+type promptData struct {
+	Role      string
+	AgentName string
+	ProjectID string
+}
+
+// RenderPrompt renders the role section of an agent's system prompt: the
+// role's template followed by the actions it is restricted to
+func RenderPrompt(role *models.AgentRole, agent *models.Agent, projectID string) string {
+	if role == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("# Role: " + role.Name + "\n")
+	if role.SystemPrompt != "" {
+		data := promptData{Role: role.Name, ProjectID: projectID}
+		if agent != nil {
+			data.AgentName = agent.Name
+		}
+		var buf bytes.Buffer
+		tmpl, err := template.New(role.Name).Parse(role.SystemPrompt)
+		if err == nil {
+			err = tmpl.Execute(&buf, data)
+		}
+		if err != nil {
+			logging.Module("roles").Warn("Failed to render prompt", "role", role.Name, "error", err)
+			buf.Reset()
+			buf.WriteString(role.SystemPrompt)
+		}
+		sb.WriteString(strings.TrimSpace(buf.String()) + "\n")
+	}
+	if len(role.AllowedActions) > 0 {
+		allowed := make([]string, 0, len(role.AllowedActions))
+		for _, a := range role.AllowedActions {
+			if role.Allows(a) {
+				allowed = append(allowed, a)
+			}
+		}
+		sb.WriteString("Allowed actions: " + strings.Join(allowed, ", ") + "\n")
+	}
+	if len(role.DeniedActions) > 0 {
+		sb.WriteString("Not allowed: " + strings.Join(role.DeniedActions, ", ") + "\n")
+	}
+	return sb.String()
+}
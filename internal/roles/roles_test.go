@@ -0,0 +1,162 @@
+package roles
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type memStore struct {
+	roles map[string]*models.AgentRole
+}
+
+func (m *memStore) UpsertAgentRole(role *models.AgentRole) error {
+	if m.roles == nil {
+		m.roles = map[string]*models.AgentRole{}
+	}
+	c := *role
+	m.roles[role.Name] = &c
+	return nil
+}
+
+func (m *memStore) ListAgentRoles() ([]*models.AgentRole, error) {
+	var list []*models.AgentRole
+	for _, r := range m.roles {
+		list = append(list, r)
+	}
+	return list, nil
+}
+
+func (m *memStore) DeleteAgentRole(name string) error {
+	delete(m.roles, name)
+	return nil
+}
+
+type agentMap map[string]*models.Agent
+
+func (a agentMap) GetAgent(id string) (*models.Agent, error) {
+	if ag, ok := a[id]; ok {
+		return ag, nil
+	}
+	return nil, fmt.Errorf("agent not found: %s", id)
+}
+
+func TestRegistrySeedsDefaults(t *testing.T) {
+	store := &memStore{}
+	reg := NewRegistry(store, nil)
+	if err := reg.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for _, name := range []string{"architect", "ceo", "coder", "reviewer", "tester"} {
+		if _, err := reg.Get(name); err != nil {
+			t.Errorf("missing built-in role %s", name)
+		}
+	}
+	if len(store.roles) != 5 {
+		t.Errorf("expected defaults persisted, got %d", len(store.roles))
+	}
+
+	// A deleted built-in role stays deleted on reload
+	if err := reg.Delete("architect"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	reg = NewRegistry(store, nil)
+	if err := reg.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, err := reg.Get("architect"); err == nil {
+		t.Error("deleted role was re-seeded")
+	}
+}
+
+func TestRegistryCRUD(t *testing.T) {
+	reg := NewRegistry(&memStore{}, nil)
+	if err := reg.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	role := &models.AgentRole{Name: "Doc Writer", AllowedActions: []string{"read_file", "write_file"}}
+	if err := reg.Create(role); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if role.Name != "doc-writer" || role.CreatedAt.IsZero() {
+		t.Errorf("created role = %+v", role)
+	}
+	if err := reg.Create(&models.AgentRole{Name: "doc-writer"}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected duplicate error, got %v", err)
+	}
+	if err := reg.Create(&models.AgentRole{Name: "bad", SystemPrompt: "{{.Oops"}); err == nil {
+		t.Error("expected invalid template error")
+	}
+
+	if err := reg.Update(&models.AgentRole{Name: "reviewer", DeniedActions: []string{"git_push"}}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, _ := reg.Get("reviewer")
+	if !got.BuiltIn || len(got.DeniedActions) != 1 {
+		t.Errorf("updated role = %+v", got)
+	}
+	if err := reg.Update(&models.AgentRole{Name: "nobody"}); err == nil {
+		t.Error("expected not found updating unknown role")
+	}
+}
+
+func TestCheckAction(t *testing.T) {
+	agents := agentMap{
+		"rev":  {ID: "rev", Name: "Reviewer", Role: "Code Reviewer", PersonaName: "default/code-reviewer"},
+		"eng":  {ID: "eng", Name: "Engineer", Role: "Engineering Manager", PersonaName: "default/engineering-manager"},
+		"qa":   {ID: "qa", Name: "QA", Role: "QA", PersonaName: "default/qa-engineer"},
+		"ceo":  {ID: "ceo", Name: "Boss", Role: "CEO", PersonaName: "default/ceo"},
+		"misc": {ID: "misc", Name: "Bot", Role: "housekeeping-bot", PersonaName: "default/housekeeping-bot"},
+	}
+	reg := NewRegistry(nil, agents)
+	if err := reg.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	cases := []struct {
+		agent, action string
+		allowed       bool
+	}{
+		{"rev", "git_push", false},
+		{"rev", "apply_patch", false},
+		{"rev", "read_file", true},
+		{"eng", "git_push", true},
+		{"eng", "approve_bead", false},
+		{"qa", "run_tests", true},
+		{"qa", "git_push", false},
+		{"ceo", "approve_bead", true},
+		{"ceo", "write_file", false},
+		{"misc", "git_push", true},
+		{"unknown", "git_push", true},
+	}
+	for _, c := range cases {
+		err := reg.CheckAction(c.agent, c.action)
+		if (err == nil) != c.allowed {
+			t.Errorf("%s %s: err = %v, want allowed=%v", c.agent, c.action, err, c.allowed)
+		}
+		var perr *PermissionError
+		if err != nil && !errors.As(err, &perr) {
+			t.Errorf("expected PermissionError, got %T", err)
+		}
+	}
+}
+
+func TestRenderPrompt(t *testing.T) {
+	reg := NewRegistry(nil, nil)
+	if err := reg.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	agent := &models.Agent{Name: "Rita", Role: "Code Reviewer"}
+	prompt := RenderPrompt(reg.RoleForAgent(agent), agent, "proj-1")
+	if !strings.Contains(prompt, "# Role: reviewer") || !strings.Contains(prompt, "You are Rita") ||
+		!strings.Contains(prompt, "Not allowed:") || !strings.Contains(prompt, "git_push") {
+		t.Errorf("unexpected prompt:\n%s", prompt)
+	}
+	if RenderPrompt(nil, agent, "proj-1") != "" {
+		t.Error("expected empty prompt without a role")
+	}
+}
@@ -0,0 +1,343 @@
+// Package scheduler runs persisted recurring jobs, such as weekly dependency
+// audits, on behalf of other subsystems. Subsystems register a handler per
+// schedule kind; schedules themselves are created through the API and
+// survive restarts via the Store.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var schedulerLog = logging.Module("scheduler")
+
+const (
+	// MinInterval is the shortest allowed time between runs
+	MinInterval = time.Minute
+	// tickInterval is how often due schedules are checked
+	tickInterval = 30 * time.Second
+	// StallAfter is how long a started scheduler may go without checking
+	// due schedules before liveness checks report it stuck
+	StallAfter = 4 * tickInterval
+	// maxResultLen caps the stored summary of a run
+	maxResultLen = 1024
+)
+
+// Handler runs one occurrence of a schedule and returns a short summary
+type Handler func(ctx context.Context, s *models.Schedule) (string, error)
+
+// Store persists schedules
+type Store interface {
+	UpsertSchedule(s *models.Schedule) error
+	ListSchedules() ([]*models.Schedule, error)
+	DeleteSchedule(id string) error
+}
+
+// Scheduler runs registered handlers for due schedules
+type Scheduler struct {
+	mu        sync.Mutex
+	store     Store
+	handlers  map[string]Handler
+	schedules map[string]*models.Schedule
+	running   map[string]bool
+	lastTick  time.Time
+	readOnly  func() error
+	now       func() time.Time
+}
+
+// New creates a scheduler. store may be nil, in which case schedules only
+// live in memory.
+func New(store Store) *Scheduler {
+	return &Scheduler{
+		store:     store,
+		handlers:  make(map[string]Handler),
+		schedules: make(map[string]*models.Schedule),
+		running:   make(map[string]bool),
+		now:       time.Now,
+	}
+}
+
+// Register installs the handler for a schedule kind
+func (s *Scheduler) Register(kind string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[kind] = h
+}
+
+// SetReadOnlyCheck installs a check run before due schedules are picked;
+// while it returns an error nothing runs on schedule, and overdue schedules
+// run once it clears
+func (s *Scheduler) SetReadOnlyCheck(check func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readOnly = check
+}
+
+// Load reads persisted schedules into memory
+func (s *Scheduler) Load() error {
+	if s.store == nil {
+		return nil
+	}
+	list, err := s.store.ListSchedules()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sched := range list {
+		s.schedules[sched.ID] = sched
+	}
+	return nil
+}
+
+// Upsert creates or replaces a schedule. A new schedule first runs one
+// interval from now unless NextRunAt is already set.
+func (s *Scheduler) Upsert(sched *models.Schedule) (*models.Schedule, error) {
+	if sched == nil {
+		return nil, fmt.Errorf("schedule cannot be nil")
+	}
+	if sched.Interval() < MinInterval {
+		return nil, fmt.Errorf("schedule interval must be at least %s", MinInterval)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.handlers[sched.Kind]; !ok {
+		return nil, fmt.Errorf("unknown schedule kind %q", sched.Kind)
+	}
+
+	now := s.now()
+	if sched.ID == "" {
+		sched.ID = "sched-" + uuid.New().String()[:8]
+	}
+	if existing, ok := s.schedules[sched.ID]; ok {
+		sched.CreatedAt = existing.CreatedAt
+		if sched.LastRunAt == nil {
+			sched.LastRunAt = existing.LastRunAt
+			sched.LastResult = existing.LastResult
+			sched.LastError = existing.LastError
+		}
+	}
+	if sched.CreatedAt.IsZero() {
+		sched.CreatedAt = now
+	}
+	if sched.NextRunAt.IsZero() {
+		sched.NextRunAt = now.Add(sched.Interval())
+	}
+	sched.UpdatedAt = now
+
+	if s.store != nil {
+		if err := s.store.UpsertSchedule(sched); err != nil {
+			return nil, fmt.Errorf("failed to save schedule: %w", err)
+		}
+	}
+	s.schedules[sched.ID] = copySchedule(sched)
+	return copySchedule(sched), nil
+}
+
+// Get returns a schedule by ID
+func (s *Scheduler) Get(id string) (*models.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sched, ok := s.schedules[id]
+	if !ok {
+		return nil, fmt.Errorf("schedule not found: %s", id)
+	}
+	return copySchedule(sched), nil
+}
+
+// List returns schedules matching kind and projectID (empty matches all),
+// ordered by next run.
+func (s *Scheduler) List(kind, projectID string) []*models.Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]*models.Schedule, 0, len(s.schedules))
+	for _, sched := range s.schedules {
+		if (kind == "" || sched.Kind == kind) && (projectID == "" || sched.ProjectID == projectID) {
+			list = append(list, copySchedule(sched))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].NextRunAt.Equal(list[j].NextRunAt) {
+			return list[i].NextRunAt.Before(list[j].NextRunAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Delete removes a schedule
+func (s *Scheduler) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.schedules[id]; !ok {
+		return fmt.Errorf("schedule not found: %s", id)
+	}
+	if s.store != nil {
+		if err := s.store.DeleteSchedule(id); err != nil {
+			return err
+		}
+	}
+	delete(s.schedules, id)
+	return nil
+}
+
+// RunNow runs a schedule immediately, regardless of whether it is due or
+// enabled, and returns the updated schedule.
+func (s *Scheduler) RunNow(ctx context.Context, id string) (*models.Schedule, error) {
+	s.mu.Lock()
+	sched, ok := s.schedules[id]
+	if ok && s.running[id] {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("schedule %s is already running", id)
+	}
+	if ok {
+		s.running[id] = true
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("schedule not found: %s", id)
+	}
+	s.run(ctx, sched)
+	return s.Get(id)
+}
+
+// RunDue runs every enabled schedule whose next run time has passed and
+// returns how many ran.
+func (s *Scheduler) RunDue(ctx context.Context) int {
+	s.mu.Lock()
+	now := s.now()
+	s.lastTick = now
+	if s.readOnly != nil && s.readOnly() != nil {
+		s.mu.Unlock()
+		return 0
+	}
+	var due []*models.Schedule
+	for id, sched := range s.schedules {
+		if sched.Enabled && !s.running[id] && !sched.NextRunAt.After(now) {
+			s.running[id] = true
+			due = append(due, sched)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].NextRunAt.Before(due[j].NextRunAt) })
+	for _, sched := range due {
+		if ctx.Err() != nil {
+			s.mu.Lock()
+			delete(s.running, sched.ID)
+			s.mu.Unlock()
+			continue
+		}
+		s.run(ctx, sched)
+	}
+	return len(due)
+}
+
+// LastTick reports when due schedules were last checked, for liveness checks
+func (s *Scheduler) LastTick() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastTick
+}
+
+// Start checks for due schedules until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	s.RunDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunDue(ctx)
+		}
+	}
+}
+
+// run executes one occurrence of sched, which must already be marked running
+func (s *Scheduler) run(ctx context.Context, sched *models.Schedule) {
+	s.mu.Lock()
+	handler := s.handlers[sched.Kind]
+	snapshot := copySchedule(sched)
+	s.mu.Unlock()
+
+	var (
+		result string
+		err    error
+	)
+	if handler == nil {
+		err = fmt.Errorf("no handler registered for kind %q", sched.Kind)
+	} else {
+		result, err = safeRun(ctx, handler, snapshot)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, sched.ID)
+	// The schedule may have been replaced or deleted while it ran
+	current, ok := s.schedules[sched.ID]
+	if !ok {
+		return
+	}
+	now := s.now()
+	current.LastRunAt = &now
+	current.LastResult = truncate(result)
+	current.LastError = ""
+	if err != nil {
+		current.LastError = truncate(err.Error())
+		schedulerLog.Error("Scheduled task failed", "schedule", current.Name, "schedule_id", current.ID, "error", err)
+	}
+	// Skip occurrences missed while the process was down rather than
+	// running them back to back.
+	next := current.NextRunAt
+	for !next.After(now) {
+		next = next.Add(current.Interval())
+	}
+	current.NextRunAt = next
+	current.UpdatedAt = now
+	if s.store != nil {
+		if err := s.store.UpsertSchedule(current); err != nil {
+			schedulerLog.Error("Failed to save schedule", "schedule_id", current.ID, "error", err)
+		}
+	}
+}
+
+func safeRun(ctx context.Context, h Handler, sched *models.Schedule) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, sched)
+}
+
+func copySchedule(sched *models.Schedule) *models.Schedule {
+	c := *sched
+	if sched.Params != nil {
+		c.Params = make(map[string]string, len(sched.Params))
+		for k, v := range sched.Params {
+			c.Params[k] = v
+		}
+	}
+	if sched.LastRunAt != nil {
+		t := *sched.LastRunAt
+		c.LastRunAt = &t
+	}
+	return &c
+}
+
+func truncate(s string) string {
+	if len(s) > maxResultLen {
+		return s[:maxResultLen] + "..."
+	}
+	return s
+}
@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type memStore struct {
+	saved   map[string]*models.Schedule
+	deleted []string
+}
+
+func (m *memStore) UpsertSchedule(s *models.Schedule) error {
+	c := *s
+	m.saved[s.ID] = &c
+	return nil
+}
+
+func (m *memStore) ListSchedules() ([]*models.Schedule, error) {
+	var list []*models.Schedule
+	for _, s := range m.saved {
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+func (m *memStore) DeleteSchedule(id string) error {
+	m.deleted = append(m.deleted, id)
+	delete(m.saved, id)
+	return nil
+}
+
+func newTestScheduler(start time.Time) (*Scheduler, *memStore, *time.Time) {
+	store := &memStore{saved: map[string]*models.Schedule{}}
+	s := New(store)
+	clock := start
+	s.now = func() time.Time { return clock }
+	return s, store, &clock
+}
+
+func TestUpsertValidates(t *testing.T) {
+	s, _, _ := newTestScheduler(time.Now())
+	s.Register("audit", func(ctx context.Context, sched *models.Schedule) (string, error) { return "", nil })
+
+	if _, err := s.Upsert(&models.Schedule{Kind: "unknown", IntervalSeconds: 3600}); err == nil {
+		t.Error("expected error for unregistered kind")
+	}
+	if _, err := s.Upsert(&models.Schedule{Kind: "audit", IntervalSeconds: 5}); err == nil {
+		t.Error("expected error for too-short interval")
+	}
+}
+
+func TestRunDueAdvancesAndPersists(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	s, store, clock := newTestScheduler(start)
+	var runs []string
+	s.Register("audit", func(ctx context.Context, sched *models.Schedule) (string, error) {
+		runs = append(runs, sched.Params["path"])
+		if len(runs) == 2 {
+			return "", errors.New("boom")
+		}
+		return "ok", nil
+	})
+
+	sched, err := s.Upsert(&models.Schedule{Name: "weekly", Kind: "audit", IntervalSeconds: 3600, Enabled: true,
+		Params: map[string]string{"path": "web"}})
+	if err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if !sched.NextRunAt.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected first run one interval out, got %v", sched.NextRunAt)
+	}
+	if n := s.RunDue(context.Background()); n != 0 {
+		t.Fatalf("nothing should be due yet, ran %d", n)
+	}
+
+	// Missed occurrences are skipped rather than replayed
+	*clock = start.Add(3*time.Hour + time.Minute)
+	if n := s.RunDue(context.Background()); n != 1 {
+		t.Fatalf("expected one run, got %d", n)
+	}
+	got, _ := s.Get(sched.ID)
+	if got.LastResult != "ok" || got.LastRunAt == nil || !got.NextRunAt.Equal(start.Add(4*time.Hour)) {
+		t.Errorf("unexpected schedule after run: %+v", got)
+	}
+	if store.saved[sched.ID].LastResult != "ok" {
+		t.Error("run result not persisted")
+	}
+
+	got, err = s.RunNow(context.Background(), sched.ID)
+	if err != nil {
+		t.Fatalf("RunNow: %v", err)
+	}
+	if got.LastError != "boom" || len(runs) != 2 || runs[0] != "web" {
+		t.Errorf("unexpected state after failed run: %+v, runs=%v", got, runs)
+	}
+}
+
+func TestDisabledSchedulesDoNotRunAndLoadRestores(t *testing.T) {
+	start := time.Now()
+	s, store, clock := newTestScheduler(start)
+	ran := 0
+	s.Register("audit", func(ctx context.Context, sched *models.Schedule) (string, error) { ran++; return "", nil })
+
+	sched, _ := s.Upsert(&models.Schedule{Kind: "audit", IntervalSeconds: 60, ProjectID: "p1"})
+	*clock = start.Add(time.Hour)
+	s.RunDue(context.Background())
+	if ran != 0 {
+		t.Errorf("disabled schedule ran %d times", ran)
+	}
+
+	restored := New(store)
+	if err := restored.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if list := restored.List("audit", "p1"); len(list) != 1 || list[0].ID != sched.ID {
+		t.Errorf("expected restored schedule, got %+v", list)
+	}
+	if list := restored.List("", "p2"); len(list) != 0 {
+		t.Errorf("expected no schedules for p2, got %+v", list)
+	}
+
+	if err := s.Delete(sched.ID); err != nil || len(store.deleted) != 1 {
+		t.Errorf("Delete: %v (deleted %v)", err, store.deleted)
+	}
+	if _, err := s.Get(sched.ID); err == nil {
+		t.Error("expected deleted schedule to be gone")
+	}
+}
+
+func TestRunDueWaitsWhileReadOnly(t *testing.T) {
+	start := time.Now()
+	s, _, clock := newTestScheduler(start)
+	ran := 0
+	s.Register("audit", func(ctx context.Context, sched *models.Schedule) (string, error) { ran++; return "", nil })
+	if _, err := s.Upsert(&models.Schedule{Kind: "audit", IntervalSeconds: 60, Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	readOnly := errors.New("server is in read-only mode")
+	s.SetReadOnlyCheck(func() error { return readOnly })
+
+	*clock = start.Add(time.Hour)
+	if n := s.RunDue(context.Background()); n != 0 || ran != 0 {
+		t.Fatalf("expected nothing to run while read-only, ran %d", ran)
+	}
+	if !s.LastTick().Equal(*clock) {
+		t.Error("expected the tick to be recorded while read-only")
+	}
+
+	readOnly = nil
+	if n := s.RunDue(context.Background()); n != 1 || ran != 1 {
+		t.Errorf("expected the overdue schedule to run once read-only mode is off, ran %d", ran)
+	}
+}
@@ -0,0 +1,426 @@
+// Package search indexes bead titles and descriptions, conversation
+// transcripts, action outputs and command logs in a SQLite FTS4 index and
+// answers ranked full-text queries with highlighted snippets.
+package search
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Document kinds
+const (
+	KindBead         = "bead"
+	KindConversation = "conversation"
+	KindActionOutput = "action_output"
+	KindCommand      = "command"
+)
+
+// Snippet highlight markers
+const (
+	HighlightStart = "**"
+	HighlightEnd   = "**"
+)
+
+const (
+	// maxBodyBytes bounds the text indexed per document
+	maxBodyBytes = 256 << 10
+	// maxCandidates bounds the matches ranked per query
+	maxCandidates = 2000
+	timeFormat    = "2006-01-02 15:04:05"
+)
+
+// Document is one searchable item
+type Document struct {
+	ID        string
+	Kind      string
+	ProjectID string
+	AgentID   string
+	BeadID    string
+	Title     string
+	Body      string
+	CreatedAt time.Time
+}
+
+// Query filters and pages a search
+type Query struct {
+	Text      string
+	ProjectID string
+	AgentID   string
+	Kinds     []string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+	Offset    int
+}
+
+// Result is a ranked match
+type Result struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	ProjectID string    `json:"project_id,omitempty"`
+	AgentID   string    `json:"agent_id,omitempty"`
+	BeadID    string    `json:"bead_id,omitempty"`
+	Title     string    `json:"title"`
+	Snippet   string    `json:"snippet"`
+	Score     float64   `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ErrEmptyQuery is returned when a query has no searchable terms
+var ErrEmptyQuery = errors.New("query has no searchable terms")
+
+// Index is a full-text index stored alongside the rest of Loom's data.
+// Document metadata lives in search_docs; search_fts holds the indexed text
+// under the same rowid.
+type Index struct {
+	db *sql.DB
+}
+
+// NewIndex creates the index tables if needed. It requires SQLite.
+func NewIndex(db *sql.DB) (*Index, error) {
+	idx := &Index{db: db}
+	if err := idx.initSchema(); err != nil {
+		return nil, fmt.Errorf("failed to initialize search index: %w", err)
+	}
+	return idx, nil
+}
+
+func (idx *Index) initSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS search_docs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		doc_id TEXT NOT NULL UNIQUE,
+		kind TEXT NOT NULL,
+		project_id TEXT NOT NULL DEFAULT '',
+		agent_id TEXT NOT NULL DEFAULT '',
+		bead_id TEXT NOT NULL DEFAULT '',
+		title TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_search_docs_project ON search_docs(project_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_search_docs_bead ON search_docs(bead_id);
+
+	CREATE VIRTUAL TABLE IF NOT EXISTS search_fts USING fts4(title, body, tokenize=porter);
+
+	CREATE TABLE IF NOT EXISTS search_watermarks (
+		source TEXT PRIMARY KEY,
+		position_at TEXT NOT NULL,
+		position_id TEXT NOT NULL DEFAULT ''
+	);
+	`
+	_, err := idx.db.Exec(schema)
+	return err
+}
+
+// Upsert adds or replaces documents
+func (idx *Index) Upsert(ctx context.Context, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	tx, err := idx.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, doc := range docs {
+		if doc.ID == "" || doc.Kind == "" {
+			continue
+		}
+		body := doc.Body
+		if len(body) > maxBodyBytes {
+			n := maxBodyBytes
+			for n > 0 && !utf8.RuneStart(body[n]) {
+				n--
+			}
+			body = body[:n]
+		}
+		created := doc.CreatedAt.UTC().Format(timeFormat)
+
+		var rowID int64
+		err := tx.QueryRowContext(ctx, `SELECT id FROM search_docs WHERE doc_id = ?`, doc.ID).Scan(&rowID)
+		switch {
+		case err == sql.ErrNoRows:
+			res, err := tx.ExecContext(ctx, `
+				INSERT INTO search_docs (doc_id, kind, project_id, agent_id, bead_id, title, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				doc.ID, doc.Kind, doc.ProjectID, doc.AgentID, doc.BeadID, doc.Title, created)
+			if err != nil {
+				return err
+			}
+			if rowID, err = res.LastInsertId(); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE search_docs SET kind = ?, project_id = ?, agent_id = ?, bead_id = ?, title = ?, created_at = ?
+				WHERE id = ?`,
+				doc.Kind, doc.ProjectID, doc.AgentID, doc.BeadID, doc.Title, created, rowID); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM search_fts WHERE docid = ?`, rowID); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO search_fts (docid, title, body) VALUES (?, ?, ?)`, rowID, doc.Title, body); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Delete removes a document
+func (idx *Index) Delete(ctx context.Context, docID string) error {
+	tx, err := idx.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var rowID int64
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM search_docs WHERE doc_id = ?`, docID).Scan(&rowID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM search_fts WHERE docid = ?`, rowID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM search_docs WHERE id = ?`, rowID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Search returns matches ranked by BM25, titles weighted above bodies
+func (idx *Index) Search(ctx context.Context, q Query) ([]Result, error) {
+	match := matchExpression(q.Text)
+	if match == "" {
+		return nil, ErrEmptyQuery
+	}
+	if q.Limit <= 0 {
+		q.Limit = 20
+	}
+	if q.Limit > 100 {
+		q.Limit = 100
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+
+	where := []string{"search_fts MATCH ?"}
+	args := []interface{}{match}
+	if q.ProjectID != "" {
+		where = append(where, "d.project_id = ?")
+		args = append(args, q.ProjectID)
+	}
+	if q.AgentID != "" {
+		where = append(where, "d.agent_id = ?")
+		args = append(args, q.AgentID)
+	}
+	if len(q.Kinds) > 0 {
+		where = append(where, "d.kind IN (?"+strings.Repeat(", ?", len(q.Kinds)-1)+")")
+		for _, k := range q.Kinds {
+			args = append(args, k)
+		}
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "d.created_at >= ?")
+		args = append(args, q.Since.UTC().Format(timeFormat))
+	}
+	if !q.Until.IsZero() {
+		where = append(where, "d.created_at < ?")
+		args = append(args, q.Until.UTC().Format(timeFormat))
+	}
+	args = append(args, maxCandidates)
+
+	rows, err := idx.db.QueryContext(ctx, `
+		SELECT d.doc_id, d.kind, d.project_id, d.agent_id, d.bead_id, d.title, d.created_at,
+			snippet(search_fts, '`+HighlightStart+`', '`+HighlightEnd+`', '…', -1, 24),
+			matchinfo(search_fts, 'pcnalx')
+		FROM search_fts JOIN search_docs d ON d.id = search_fts.docid
+		WHERE `+strings.Join(where, " AND ")+`
+		LIMIT ?`, args...)
+	if err != nil {
+		if strings.Contains(err.Error(), "malformed MATCH") {
+			return nil, fmt.Errorf("invalid search query: %w", err)
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		var r Result
+		var created string
+		var info []byte
+		if err := rows.Scan(&r.ID, &r.Type, &r.ProjectID, &r.AgentID, &r.BeadID, &r.Title, &created, &r.Snippet, &info); err != nil {
+			return nil, err
+		}
+		r.CreatedAt, _ = time.Parse(timeFormat, created)
+		r.Score = bm25(info, []float64{2.0, 1.0})
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Ties go to the newer document
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].CreatedAt.After(results[j].CreatedAt)
+	})
+	if q.Offset >= len(results) {
+		return []Result{}, nil
+	}
+	results = results[q.Offset:]
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+// Count returns the number of indexed documents by kind
+func (idx *Index) Count(ctx context.Context) (map[string]int64, error) {
+	rows, err := idx.db.QueryContext(ctx, `SELECT kind, COUNT(*) FROM search_docs GROUP BY kind`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := map[string]int64{}
+	for rows.Next() {
+		var kind string
+		var n int64
+		if err := rows.Scan(&kind, &n); err != nil {
+			return nil, err
+		}
+		counts[kind] = n
+	}
+	return counts, rows.Err()
+}
+
+// watermark returns how far a source has been indexed
+func (idx *Index) watermark(ctx context.Context, source string) (time.Time, string, error) {
+	var at, id string
+	err := idx.db.QueryRowContext(ctx, `SELECT position_at, position_id FROM search_watermarks WHERE source = ?`, source).Scan(&at, &id)
+	if err == sql.ErrNoRows {
+		return time.Time{}, "", nil
+	}
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	t, err := time.Parse(time.RFC3339Nano, at)
+	return t, id, err
+}
+
+func (idx *Index) setWatermark(ctx context.Context, source string, at time.Time, id string) error {
+	_, err := idx.db.ExecContext(ctx, `
+		INSERT INTO search_watermarks (source, position_at, position_id) VALUES (?, ?, ?)
+		ON CONFLICT(source) DO UPDATE SET position_at = excluded.position_at, position_id = excluded.position_id`,
+		source, at.Format(time.RFC3339Nano), id)
+	return err
+}
+
+// matchExpression turns user input into an FTS4 query: each word or
+// "quoted phrase" must match, a trailing * matches a prefix, and FTS
+// operators in the input are treated as text
+func matchExpression(text string) string {
+	var terms []string
+	for _, tok := range tokenize(text) {
+		prefix := !tok.phrase && strings.HasSuffix(tok.text, "*")
+		words := strings.FieldsFunc(tok.text, func(r rune) bool {
+			return !(r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 127)
+		})
+		if len(words) == 0 {
+			continue
+		}
+		term := `"` + strings.Join(words, " ") + `"`
+		if prefix && len(words) == 1 {
+			term = words[0] + "*"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
+
+type token struct {
+	text   string
+	phrase bool
+}
+
+func tokenize(text string) []token {
+	var tokens []token
+	for {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return tokens
+		}
+		if text[0] == '"' {
+			end := strings.IndexByte(text[1:], '"')
+			if end < 0 {
+				tokens = append(tokens, token{text: text[1:], phrase: true})
+				return tokens
+			}
+			tokens = append(tokens, token{text: text[1 : end+1], phrase: true})
+			text = text[end+2:]
+			continue
+		}
+		end := strings.IndexAny(text, " \t\n\"")
+		if end < 0 {
+			end = len(text)
+		}
+		tokens = append(tokens, token{text: text[:end]})
+		text = text[end:]
+	}
+}
+
+// bm25 scores a row from its FTS4 matchinfo 'pcnalx' blob, weighting each
+// column
+func bm25(info []byte, weights []float64) float64 {
+	const k1, b = 1.2, 0.75
+	n := len(info) / 4
+	if n < 3 {
+		return 0
+	}
+	v := func(i int) float64 {
+		if i >= n {
+			return 0
+		}
+		return float64(binary.NativeEndian.Uint32(info[i*4:]))
+	}
+	phrases, cols := int(v(0)), int(v(1))
+	docs := v(2)
+	avgOff, lenOff, hitOff := 3, 3+cols, 3+2*cols
+
+	var score float64
+	for p := 0; p < phrases; p++ {
+		for c := 0; c < cols && c < len(weights); c++ {
+			base := hitOff + 3*(p*cols+c)
+			tf, df := v(base), v(base+2)
+			if tf == 0 {
+				continue
+			}
+			idf := math.Log(1 + (docs-df+0.5)/(df+0.5))
+			avg := v(avgOff + c)
+			if avg == 0 {
+				avg = 1
+			}
+			norm := tf + k1*(1-b+b*v(lenOff+c)/avg)
+			score += weights[c] * idf * tf * (k1 + 1) / norm
+		}
+	}
+	return score
+}
@@ -0,0 +1,280 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var searchLog = logging.Module("search")
+
+// batchSize is how many rows the indexer reads from a source at a time
+const batchSize = 200
+
+// BeadSource lists beads to index
+type BeadSource interface {
+	ListBeads(filters map[string]interface{}) ([]*models.Bead, error)
+}
+
+// LogSource pages through logged activity in (time, id) order
+type LogSource interface {
+	ListConversationsUpdatedSince(after time.Time, afterID string, limit int) ([]*models.ConversationContext, error)
+	ListActionOutputsSince(after time.Time, afterID string, limit int) ([]*models.ActionOutput, error)
+	ListCommandLogsSince(after time.Time, afterID string, limit int) ([]*models.CommandLog, error)
+}
+
+// Indexer keeps an Index current by pulling changes from beads and logs.
+// Each log source resumes from a stored watermark, so a restart picks up
+// where the last pass stopped. Documents stay indexed after retention archives
+// their rows, so old work remains findable.
+type Indexer struct {
+	index *Index
+	beads BeadSource
+	logs  LogSource
+
+	beadText func(*models.Bead) string // extra searchable text for a bead
+
+	mu        sync.Mutex // serializes passes
+	beadsSeen map[string]beadState
+}
+
+type beadState struct {
+	version   int64
+	updatedAt time.Time
+}
+
+// NewIndexer creates an indexer feeding index
+func NewIndexer(index *Index, beads BeadSource, logs LogSource) *Indexer {
+	return &Indexer{index: index, beads: beads, logs: logs}
+}
+
+// SetBeadText adds text from fn, such as custom field values, to each
+// indexed bead's body
+func (ix *Indexer) SetBeadText(fn func(*models.Bead) string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.beadText = fn
+}
+
+// Index returns the index being fed
+func (ix *Indexer) Index() *Index {
+	return ix.index
+}
+
+// Start indexes immediately and then every interval until ctx is cancelled
+func (ix *Indexer) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := ix.Sync(ctx); err != nil {
+			searchLog.Error("Indexing failed", "error", err)
+		} else if n > 0 {
+			searchLog.Info("Indexed documents", "count", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync indexes everything changed since the last pass and returns the
+// number of documents written
+func (ix *Indexer) Sync(ctx context.Context) (int, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	total := 0
+	steps := []struct {
+		source string
+		sync   func(context.Context) (int, error)
+	}{
+		{KindBead, ix.syncBeads},
+		{KindConversation, ix.syncConversations},
+		{KindActionOutput, ix.syncActionOutputs},
+		{KindCommand, ix.syncCommands},
+	}
+	for _, step := range steps {
+		if step.source != KindBead && ix.logs == nil {
+			continue
+		}
+		n, err := step.sync(ctx)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", step.source, err)
+		}
+	}
+	return total, nil
+}
+
+// syncBeads indexes beads whose version or update time changed since the
+// last pass. The bead cache is in memory, so every bead is indexed once
+// after a restart.
+func (ix *Indexer) syncBeads(ctx context.Context) (int, error) {
+	if ix.beads == nil {
+		return 0, nil
+	}
+	beads, err := ix.beads.ListBeads(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var docs []Document
+	seen := make(map[string]beadState, len(beads))
+	for _, b := range beads {
+		state := beadState{version: b.Version, updatedAt: b.UpdatedAt}
+		seen[b.ID] = state
+		if prev, ok := ix.beadsSeen[b.ID]; ok && prev == state {
+			continue
+		}
+		doc := BeadDocument(b)
+		if ix.beadText != nil {
+			if extra := ix.beadText(b); extra != "" {
+				doc.Body += "\n" + extra
+			}
+		}
+		docs = append(docs, doc)
+	}
+	if err := ix.index.Upsert(ctx, docs...); err != nil {
+		return 0, err
+	}
+	ix.beadsSeen = seen
+	return len(docs), nil
+}
+
+// pageSource indexes a log source batch by batch from its watermark
+func (ix *Indexer) pageSource(ctx context.Context, source string, fetch func(after time.Time, afterID string) ([]Document, time.Time, string, error)) (int, error) {
+	after, afterID, err := ix.index.watermark(ctx, source)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for {
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+		docs, lastAt, lastID, err := fetch(after, afterID)
+		if err != nil {
+			return total, err
+		}
+		if len(docs) == 0 {
+			return total, nil
+		}
+		if err := ix.index.Upsert(ctx, docs...); err != nil {
+			return total, err
+		}
+		if err := ix.index.setWatermark(ctx, source, lastAt, lastID); err != nil {
+			return total, err
+		}
+		total += len(docs)
+		after, afterID = lastAt, lastID
+		if len(docs) < batchSize {
+			return total, nil
+		}
+	}
+}
+
+func (ix *Indexer) syncConversations(ctx context.Context) (int, error) {
+	return ix.pageSource(ctx, KindConversation, func(after time.Time, afterID string) ([]Document, time.Time, string, error) {
+		convs, err := ix.logs.ListConversationsUpdatedSince(after, afterID, batchSize)
+		if err != nil || len(convs) == 0 {
+			return nil, after, afterID, err
+		}
+		docs := make([]Document, 0, len(convs))
+		for _, c := range convs {
+			docs = append(docs, ConversationDocument(c))
+		}
+		last := convs[len(convs)-1]
+		return docs, last.UpdatedAt, last.SessionID, nil
+	})
+}
+
+func (ix *Indexer) syncActionOutputs(ctx context.Context) (int, error) {
+	return ix.pageSource(ctx, KindActionOutput, func(after time.Time, afterID string) ([]Document, time.Time, string, error) {
+		outputs, err := ix.logs.ListActionOutputsSince(after, afterID, batchSize)
+		if err != nil || len(outputs) == 0 {
+			return nil, after, afterID, err
+		}
+		docs := make([]Document, 0, len(outputs))
+		for _, o := range outputs {
+			docs = append(docs, Document{
+				ID:        KindActionOutput + ":" + o.ID,
+				Kind:      KindActionOutput,
+				ProjectID: o.ProjectID,
+				AgentID:   o.AgentID,
+				BeadID:    o.BeadID,
+				Title:     o.ActionType + " " + o.Field,
+				Body:      o.Content,
+				CreatedAt: o.CreatedAt,
+			})
+		}
+		last := outputs[len(outputs)-1]
+		return docs, last.CreatedAt, last.ID, nil
+	})
+}
+
+func (ix *Indexer) syncCommands(ctx context.Context) (int, error) {
+	return ix.pageSource(ctx, KindCommand, func(after time.Time, afterID string) ([]Document, time.Time, string, error) {
+		cmds, err := ix.logs.ListCommandLogsSince(after, afterID, batchSize)
+		if err != nil || len(cmds) == 0 {
+			return nil, after, afterID, err
+		}
+		docs := make([]Document, 0, len(cmds))
+		for _, c := range cmds {
+			docs = append(docs, Document{
+				ID:        KindCommand + ":" + c.ID,
+				Kind:      KindCommand,
+				ProjectID: c.ProjectID,
+				AgentID:   c.AgentID,
+				BeadID:    c.BeadID,
+				Title:     c.Command,
+				Body:      c.Stdout + "\n" + c.Stderr,
+				CreatedAt: c.CreatedAt,
+			})
+		}
+		last := cmds[len(cmds)-1]
+		return docs, last.CreatedAt, last.ID, nil
+	})
+}
+
+// BeadDocument indexes a bead's title and description
+func BeadDocument(b *models.Bead) Document {
+	return Document{
+		ID:        KindBead + ":" + b.ID,
+		Kind:      KindBead,
+		ProjectID: b.ProjectID,
+		AgentID:   b.AssignedTo,
+		BeadID:    b.ID,
+		Title:     b.Title,
+		Body:      b.Description,
+		CreatedAt: b.CreatedAt,
+	}
+}
+
+// ConversationDocument indexes a conversation transcript. Its agent comes
+// from the conversation's agent_id metadata when recorded.
+func ConversationDocument(c *models.ConversationContext) Document {
+	var body strings.Builder
+	for _, m := range c.Messages {
+		body.WriteString(m.Role)
+		body.WriteString(": ")
+		body.WriteString(m.Content)
+		body.WriteString("\n")
+	}
+	return Document{
+		ID:        KindConversation + ":" + c.SessionID,
+		Kind:      KindConversation,
+		ProjectID: c.ProjectID,
+		AgentID:   c.Metadata["agent_id"],
+		BeadID:    c.BeadID,
+		Title:     "Conversation for " + c.BeadID,
+		Body:      body.String(),
+		CreatedAt: c.CreatedAt,
+	}
+}
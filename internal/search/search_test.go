@@ -0,0 +1,118 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeBeads []*models.Bead
+
+func (f fakeBeads) ListBeads(filters map[string]interface{}) ([]*models.Bead, error) {
+	return f, nil
+}
+
+func newTestIndexer(t *testing.T, beads fakeBeads) (*Indexer, *database.Database) {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "loom.db"))
+	if err != nil {
+		t.Fatalf("database.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	idx, err := NewIndex(db.DB())
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	return NewIndexer(idx, beads, db), db
+}
+
+func TestSyncAndSearch(t *testing.T) {
+	march := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	beads := fakeBeads{
+		{ID: "bd-1", ProjectID: "proj-a", AssignedTo: "agent-eng", Title: "Fix retry logic in the provider client",
+			Description: "Requests fail permanently after one timeout", CreatedAt: march, UpdatedAt: march},
+		{ID: "bd-2", ProjectID: "proj-b", Title: "Update the README", Description: "Mention retries briefly",
+			CreatedAt: march.AddDate(0, 1, 0), UpdatedAt: march.AddDate(0, 1, 0)},
+	}
+	ix, db := newTestIndexer(t, beads)
+	ctx := context.Background()
+
+	conv := models.NewConversationContext("s-1", "bd-1", "proj-a", time.Hour)
+	conv.Metadata["agent_id"] = "agent-eng"
+	conv.CreatedAt, conv.UpdatedAt = march, march
+	conv.AddMessage("assistant", "I changed the retrying backoff in client.go to use jitter", 12)
+	if err := db.CreateConversationContext(conv); err != nil {
+		t.Fatalf("CreateConversationContext: %v", err)
+	}
+	if err := db.SaveActionOutput(&models.ActionOutput{ID: "out-1", ProjectID: "proj-a", BeadID: "bd-1", AgentID: "agent-eng",
+		ActionType: "run_tests", Field: "stdout", Content: "ok  \tclient\t0.3s\nPASS TestRetryBackoff", CreatedAt: march}); err != nil {
+		t.Fatalf("SaveActionOutput: %v", err)
+	}
+
+	n, err := ix.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("indexed %d documents, want 4", n)
+	}
+	// Nothing changed: logs resume from their watermarks
+	if n, err := ix.Sync(ctx); err != nil || n != 0 {
+		t.Errorf("second Sync indexed %d (%v)", n, err)
+	}
+
+	// Porter stemming matches retry, retries and retrying; the title match
+	// ranks first
+	results, err := ix.Index().Search(ctx, Query{Text: "retry"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 3 || results[0].ID != "bead:bd-1" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if !strings.Contains(results[0].Snippet, HighlightStart+"retry"+HighlightEnd) {
+		t.Errorf("snippet not highlighted: %q", results[0].Snippet)
+	}
+
+	filtered, err := ix.Index().Search(ctx, Query{Text: "retry", ProjectID: "proj-a", AgentID: "agent-eng", Kinds: []string{KindConversation}})
+	if err != nil || len(filtered) != 1 || filtered[0].BeadID != "bd-1" {
+		t.Errorf("filtered search = %+v, %v", filtered, err)
+	}
+	dated, err := ix.Index().Search(ctx, Query{Text: "retry", Since: march.AddDate(0, 0, 15)})
+	if err != nil || len(dated) != 1 || dated[0].ID != "bead:bd-2" {
+		t.Errorf("date filtered search = %+v, %v", dated, err)
+	}
+
+	// Updated documents replace their old text
+	beads[0].Title = "Fix backoff"
+	beads[0].Description = "Done"
+	beads[0].Version++
+	if _, err := ix.Sync(ctx); err != nil {
+		t.Fatalf("Sync after update: %v", err)
+	}
+	if results, _ := ix.Index().Search(ctx, Query{Text: "retry", Kinds: []string{KindBead}}); len(results) != 1 {
+		t.Errorf("stale bead text still indexed: %+v", results)
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	cases := map[string]string{
+		`retry logic`:          `"retry" "logic"`,
+		`"retry logic" client`: `"retry logic" "client"`,
+		`retr*`:                `retr*`,
+		`foo-bar OR NEAR(x)`:   `"foo bar" "OR" "NEAR x"`,
+		`"unbalanced`:          `"unbalanced"`,
+		`*** --`:               ``,
+		`client.go:42`:         `"client go 42"`,
+	}
+	for in, want := range cases {
+		if got := matchExpression(in); got != want {
+			t.Errorf("matchExpression(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
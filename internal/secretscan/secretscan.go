@@ -0,0 +1,278 @@
+// Package secretscan finds credentials in content agents are about to
+// commit or push: known token formats, private keys, key-like assignments
+// and high-entropy string literals. It scans only added lines, so secrets
+// already in the history don't block unrelated work.
+package secretscan
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// DefaultEntropyThreshold is the Shannon entropy, in bits per character,
+// above which a string literal is reported
+const DefaultEntropyThreshold = 4.5
+
+// AllowMarker on a line suppresses findings for it, for test fixtures and
+// documented example keys
+const AllowMarker = "secretscan:allow"
+
+// EntropyRuleID identifies findings from the entropy check
+const EntropyRuleID = "high-entropy-string"
+
+// minEntropyLength is the shortest string literal checked for entropy
+const minEntropyLength = 24
+
+type rule struct {
+	id          string
+	description string
+	re          *regexp.Regexp
+}
+
+var builtinRules = []rule{
+	{"private-key", "Private key block", regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`)},
+	{"aws-access-key-id", "AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-access-key", "AWS secret access key", regexp.MustCompile(`(?i)aws.{0,20}secret.{0,20}[:=]\s*['"]?[0-9a-zA-Z/+]{40}\b`)},
+	{"github-token", "GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"gitlab-token", "GitLab personal access token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"slack-token", "Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"llm-api-key", "OpenAI or Anthropic API key", regexp.MustCompile(`\bsk-(ant-|proj-)?[A-Za-z0-9_-]{32,}`)},
+	{"google-api-key", "Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"stripe-key", "Stripe live key", regexp.MustCompile(`\b(sk|rk)_live_[0-9a-zA-Z]{24,}\b`)},
+	{"generic-api-key", "Credential assigned to a key-like name", regexp.MustCompile(`(?i)(api[_-]?key|secret[_-]?key|token)[_-]?\s*[:=]\s*['"][a-zA-Z0-9]{20,}['"]`)},
+}
+
+// defaultIgnorePaths are lockfiles full of hashes that look like secrets
+var defaultIgnorePaths = []string{"go.sum", "*.lock", "package-lock.json", "pnpm-lock.yaml"}
+
+var literalPattern = regexp.MustCompile("[\"'`]([A-Za-z0-9+/=_.~-]{24,})[\"'`]")
+
+// Finding is a potential secret
+type Finding struct {
+	RuleID      string `json:"rule_id"`
+	Description string `json:"description"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Match       string `json:"match"` // Redacted
+	Commit      string `json:"commit,omitempty"`
+}
+
+// Scanner checks content against its rules. A nil *Scanner finds nothing,
+// which is how scanning is turned off.
+type Scanner struct {
+	rules   []rule
+	entropy float64 // Negative disables the entropy check
+	allow   []*regexp.Regexp
+	ignore  []string
+}
+
+// Default returns a scanner with the built-in rules
+func Default() *Scanner {
+	return &Scanner{rules: builtinRules, entropy: DefaultEntropyThreshold, ignore: defaultIgnorePaths}
+}
+
+// New builds a scanner from the config, or returns nil when scanning is
+// disabled
+func New(cfg config.SecretScanConfig) (*Scanner, error) {
+	if cfg.Disabled {
+		return nil, nil
+	}
+	disabled := make(map[string]bool, len(cfg.DisableRules))
+	for _, id := range cfg.DisableRules {
+		disabled[id] = true
+	}
+	s := &Scanner{entropy: cfg.EntropyThreshold, ignore: append(append([]string{}, defaultIgnorePaths...), cfg.IgnorePaths...)}
+	if s.entropy == 0 {
+		s.entropy = DefaultEntropyThreshold
+	}
+	if disabled[EntropyRuleID] {
+		s.entropy = -1
+	}
+	for _, r := range builtinRules {
+		if !disabled[r.id] {
+			s.rules = append(s.rules, r)
+		}
+	}
+	for _, r := range cfg.Rules {
+		if r.ID == "" || r.Pattern == "" {
+			return nil, fmt.Errorf("secret scan rules need an id and a pattern")
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("secret scan rule %s: %w", r.ID, err)
+		}
+		s.rules = append(s.rules, rule{id: r.ID, description: r.Description, re: re})
+	}
+	for _, expr := range cfg.AllowPatterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("secret scan allow pattern %q: %w", expr, err)
+		}
+		s.allow = append(s.allow, re)
+	}
+	for _, glob := range s.ignore {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("secret scan ignore path %q: %w", glob, err)
+		}
+	}
+	return s, nil
+}
+
+// ScanContent scans every line of a file
+func (s *Scanner) ScanContent(file, content string) []Finding {
+	if s == nil || s.ignored(file) {
+		return nil
+	}
+	var findings []Finding
+	for i, line := range strings.Split(content, "\n") {
+		findings = append(findings, s.scanLine(line, Finding{File: file, Line: i + 1})...)
+	}
+	return findings
+}
+
+// ScanDiff scans the added lines of a unified diff, as produced by git diff
+// or git log -p. Commits are attributed from "commit <sha>" lines.
+func (s *Scanner) ScanDiff(diff string) []Finding {
+	if s == nil {
+		return nil
+	}
+	var findings []Finding
+	var commit, file string
+	line := 0
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "commit ") && len(strings.Fields(text)) == 2:
+			commit, file = strings.Fields(text)[1], ""
+		case strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			if file == "/dev/null" {
+				file = ""
+			}
+		case strings.HasPrefix(text, "@@ "):
+			line = hunkStart(text)
+		case strings.HasPrefix(text, "+"):
+			if file != "" && !s.ignored(file) {
+				findings = append(findings, s.scanLine(text[1:], Finding{File: file, Line: line, Commit: commit})...)
+			}
+			line++
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	return findings
+}
+
+// hunkStart returns the first new-file line of a "@@ -a,b +c,d @@" header
+func hunkStart(header string) int {
+	for _, field := range strings.Fields(header) {
+		if strings.HasPrefix(field, "+") {
+			start, _, _ := strings.Cut(field[1:], ",")
+			n, err := strconv.Atoi(start)
+			if err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// scanLine returns the findings on one line, filled in from at
+func (s *Scanner) scanLine(line string, at Finding) []Finding {
+	if strings.Contains(line, AllowMarker) {
+		return nil
+	}
+	var findings []Finding
+	for _, r := range s.rules {
+		for _, match := range r.re.FindAllString(line, -1) {
+			if s.allowed(match) {
+				continue
+			}
+			f := at
+			f.RuleID, f.Description, f.Match = r.id, r.description, redact(match)
+			findings = append(findings, f)
+		}
+	}
+	if len(findings) > 0 || s.entropy < 0 {
+		return findings
+	}
+	for _, m := range literalPattern.FindAllStringSubmatch(line, -1) {
+		literal := m[1]
+		if !strings.ContainsAny(literal, "0123456789") || s.allowed(literal) || entropy(literal) < s.entropy {
+			continue
+		}
+		f := at
+		f.RuleID, f.Description, f.Match = EntropyRuleID, "High-entropy string literal", redact(literal)
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+func (s *Scanner) allowed(match string) bool {
+	for _, re := range s.allow {
+		if re.MatchString(match) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scanner) ignored(file string) bool {
+	for _, glob := range s.ignore {
+		if ok, _ := path.Match(glob, file); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, path.Base(file)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len([]rune(s)))
+	var bits float64
+	for _, c := range counts {
+		p := float64(c) / n
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// redact keeps enough of a match to find it again without repeating it
+func redact(match string) string {
+	if len(match) <= 8 {
+		return strings.Repeat("*", len(match))
+	}
+	return match[:4] + strings.Repeat("*", min(len(match)-4, 12))
+}
+
+// BlockedError is returned when a commit or push is refused because of
+// findings
+type BlockedError struct {
+	Operation string // commit or push
+	Findings  []Finding
+}
+
+func (e *BlockedError) Error() string {
+	const listed = 5
+	var where []string
+	for i, f := range e.Findings {
+		if i == listed {
+			where = append(where, fmt.Sprintf("and %d more", len(e.Findings)-listed))
+			break
+		}
+		where = append(where, fmt.Sprintf("%s at %s:%d", f.RuleID, f.File, f.Line))
+	}
+	return fmt.Sprintf("%s blocked: %d potential secret(s) found: %s", e.Operation, len(e.Findings), strings.Join(where, ", "))
+}
@@ -0,0 +1,124 @@
+package secretscan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Fixtures are assembled at runtime so this file doesn't trip the scanner
+var (
+	awsKey     = "AKIA" + "IOSFODNN7EXAMPLE"
+	githubPAT  = "ghp_" + strings.Repeat("a1B2", 9)
+	randomB64  = "q9Zx" + "T2vLm8Rk4PwYc7HsN3bJf6Ud1Ge5Ao0="
+	privateKey = "-----BEGIN " + "OPENSSH PRIVATE KEY-----"
+)
+
+func TestScanContent(t *testing.T) {
+	s := Default()
+	tests := []struct {
+		name, content, rule string
+	}{
+		{"aws", `id := "` + awsKey + `"`, "aws-access-key-id"},
+		{"github", "token: " + githubPAT, "github-token"},
+		{"private key", privateKey, "private-key"},
+		{"entropy", `cfg.Secret = "` + randomB64 + `"`, EntropyRuleID},
+		{"generic", `api_key = "` + "abcdefghijklmnopqrstuvwxyz" + `"`, "generic-api-key"},
+		{"clean", `fmt.Println("hello, world")`, ""},
+		{"hex digest", `sum := "` + strings.Repeat("0123456789abcdef", 4) + `"`, ""},
+		{"allow marker", `id := "` + awsKey + `" // ` + AllowMarker, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := s.ScanContent("main.go", "package main\n"+tt.content)
+			if tt.rule == "" {
+				if len(findings) != 0 {
+					t.Errorf("expected no findings, got %+v", findings)
+				}
+				return
+			}
+			if len(findings) != 1 || findings[0].RuleID != tt.rule || findings[0].Line != 2 {
+				t.Fatalf("expected one %s finding on line 2, got %+v", tt.rule, findings)
+			}
+			if !strings.Contains(findings[0].Match, "*") {
+				t.Errorf("expected the match to be redacted, got %q", findings[0].Match)
+			}
+		})
+	}
+
+	if findings := s.ScanContent("go.sum", `h1:`+randomB64); findings != nil {
+		t.Errorf("expected lockfiles to be ignored, got %+v", findings)
+	}
+	var off *Scanner
+	if off.ScanContent("main.go", awsKey) != nil || off.ScanDiff("+"+awsKey) != nil {
+		t.Error("expected a nil scanner to find nothing")
+	}
+}
+
+func TestScanDiff(t *testing.T) {
+	diff := strings.Join([]string{
+		"commit 1111111111111111111111111111111111111111",
+		"diff --git a/config.go b/config.go",
+		"--- a/config.go",
+		"+++ b/config.go",
+		"@@ -10,0 +11,2 @@ func load() {",
+		"+	region := \"us-east-1\"",
+		"+	key := \"" + awsKey + "\"",
+		"@@ -20 +22 @@",
+		"-	old := \"" + awsKey + "\"",
+		"+	fine := true",
+		"diff --git a/gone.go b/gone.go",
+		"--- a/gone.go",
+		"+++ /dev/null",
+		"@@ -1 +0,0 @@",
+		"-" + privateKey,
+	}, "\n")
+	findings := Default().ScanDiff(diff)
+	if len(findings) != 1 {
+		t.Fatalf("expected only the added key, got %+v", findings)
+	}
+	f := findings[0]
+	if f.File != "config.go" || f.Line != 12 || f.Commit != strings.Repeat("1", 40) {
+		t.Errorf("unexpected finding %+v", f)
+	}
+
+	err := &BlockedError{Operation: "commit", Findings: findings}
+	if !strings.Contains(err.Error(), "aws-access-key-id at config.go:12") {
+		t.Errorf("unexpected error %q", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(config.SecretScanConfig{
+		Rules:         []config.SecretScanRule{{ID: "internal-token", Pattern: `itk_[a-z0-9]{16}`}},
+		DisableRules:  []string{"aws-access-key-id", EntropyRuleID},
+		AllowPatterns: []string{`^ghp_a1B2`},
+		IgnorePaths:   []string{"testdata/*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := strings.Join([]string{awsKey, `x := "` + randomB64 + `"`, githubPAT, "itk_" + strings.Repeat("z9", 8)}, "\n")
+	findings := s.ScanContent("main.go", content)
+	if len(findings) != 1 || findings[0].RuleID != "internal-token" {
+		t.Errorf("expected only the custom rule to fire, got %+v", findings)
+	}
+	if findings := s.ScanContent("testdata/keys.txt", "itk_"+strings.Repeat("z9", 8)); findings != nil {
+		t.Errorf("expected ignored paths to be skipped, got %+v", findings)
+	}
+
+	if s, err := New(config.SecretScanConfig{Disabled: true}); s != nil || err != nil {
+		t.Errorf("expected a disabled config to give a nil scanner, got %v %v", s, err)
+	}
+	for _, cfg := range []config.SecretScanConfig{
+		{Rules: []config.SecretScanRule{{ID: "bad", Pattern: "("}}},
+		{Rules: []config.SecretScanRule{{Pattern: "x"}}},
+		{AllowPatterns: []string{"["}},
+		{IgnorePaths: []string{"["}},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
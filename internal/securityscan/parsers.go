@@ -0,0 +1,208 @@
+package securityscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// normalizeSeverity maps scanner-specific severity names onto the shared scale
+func normalizeSeverity(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "critical":
+		return models.SeverityCritical
+	case "high", "error":
+		return models.SeverityHigh
+	case "medium", "moderate", "warning":
+		return models.SeverityMedium
+	case "low":
+		return models.SeverityLow
+	}
+	return models.SeverityInfo
+}
+
+// relPath makes a scanner-reported path relative to the repository root.
+// Absolute paths are taken relative to workDir; relative ones to the scanned
+// directory, which is scanPath inside the repository.
+func relPath(workDir, scanPath, file string) string {
+	if file == "" {
+		return ""
+	}
+	if filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(workDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(filepath.Join(scanPath, file))
+}
+
+// parseGosec parses `gosec -fmt=json` output
+func parseGosec(out []byte, workDir, scanPath string) ([]models.SecurityFinding, error) {
+	var report struct {
+		Issues []struct {
+			Severity string `json:"severity"`
+			RuleID   string `json:"rule_id"`
+			Details  string `json:"details"`
+			File     string `json:"file"`
+			Line     string `json:"line"` // "12" or a range such as "12-14"
+		} `json:"Issues"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("invalid gosec output: %w", err)
+	}
+	findings := make([]models.SecurityFinding, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		line, _ := strconv.Atoi(strings.SplitN(issue.Line, "-", 2)[0])
+		findings = append(findings, models.SecurityFinding{
+			Tool:     Gosec,
+			RuleID:   issue.RuleID,
+			Severity: normalizeSeverity(issue.Severity),
+			File:     relPath(workDir, scanPath, issue.File),
+			Line:     line,
+			Message:  issue.Details,
+		})
+	}
+	return findings, nil
+}
+
+// parseSemgrep parses `semgrep scan --json` output
+func parseSemgrep(out []byte, workDir, scanPath string) ([]models.SecurityFinding, error) {
+	var report struct {
+		Results []struct {
+			CheckID string `json:"check_id"`
+			Path    string `json:"path"`
+			Start   struct {
+				Line int `json:"line"`
+			} `json:"start"`
+			Extra struct {
+				Message  string `json:"message"`
+				Severity string `json:"severity"`
+			} `json:"extra"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("invalid semgrep output: %w", err)
+	}
+	findings := make([]models.SecurityFinding, 0, len(report.Results))
+	for _, res := range report.Results {
+		findings = append(findings, models.SecurityFinding{
+			Tool:     Semgrep,
+			RuleID:   res.CheckID,
+			Severity: normalizeSeverity(res.Extra.Severity),
+			File:     relPath(workDir, scanPath, res.Path),
+			Line:     res.Start.Line,
+			Message:  strings.TrimSpace(res.Extra.Message),
+		})
+	}
+	return findings, nil
+}
+
+// parseNpmAudit parses `npm audit --json` output (npm 7 and later). Each
+// vulnerable package becomes one finding against package.json.
+func parseNpmAudit(out []byte, workDir, scanPath string) ([]models.SecurityFinding, error) {
+	var report struct {
+		Vulnerabilities map[string]struct {
+			Name     string            `json:"name"`
+			Severity string            `json:"severity"`
+			Range    string            `json:"range"`
+			Via      []json.RawMessage `json:"via"` // Advisory objects or names of vulnerable dependencies
+		} `json:"vulnerabilities"`
+		Error *struct {
+			Summary string `json:"summary"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("invalid npm audit output: %w", err)
+	}
+	if report.Error != nil {
+		return nil, fmt.Errorf("npm audit: %s", report.Error.Summary)
+	}
+
+	names := make([]string, 0, len(report.Vulnerabilities))
+	for name := range report.Vulnerabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	findings := make([]models.SecurityFinding, 0, len(names))
+	for _, name := range names {
+		vuln := report.Vulnerabilities[name]
+		var titles, deps []string
+		for _, raw := range vuln.Via {
+			var advisory struct {
+				Title string `json:"title"`
+			}
+			var dep string
+			if json.Unmarshal(raw, &dep) == nil {
+				deps = append(deps, dep)
+			} else if json.Unmarshal(raw, &advisory) == nil && advisory.Title != "" {
+				titles = append(titles, advisory.Title)
+			}
+		}
+		message := strings.Join(titles, "; ")
+		if message == "" && len(deps) > 0 {
+			message = "depends on vulnerable " + strings.Join(deps, ", ")
+		}
+		if vuln.Range != "" {
+			message = fmt.Sprintf("%s %s: %s", name, vuln.Range, message)
+		}
+		findings = append(findings, models.SecurityFinding{
+			Tool:     NpmAudit,
+			RuleID:   name,
+			Severity: normalizeSeverity(vuln.Severity),
+			File:     relPath(workDir, scanPath, "package.json"),
+			Message:  message,
+		})
+	}
+	return findings, nil
+}
+
+// parseTrivy parses `trivy config --format json` output, keeping failed
+// misconfiguration checks.
+func parseTrivy(out []byte, workDir, scanPath string) ([]models.SecurityFinding, error) {
+	var report struct {
+		Results []struct {
+			Target            string `json:"Target"`
+			Misconfigurations []struct {
+				ID            string `json:"ID"`
+				Title         string `json:"Title"`
+				Message       string `json:"Message"`
+				Severity      string `json:"Severity"`
+				Status        string `json:"Status"`
+				CauseMetadata struct {
+					StartLine int `json:"StartLine"`
+				} `json:"CauseMetadata"`
+			} `json:"Misconfigurations"`
+		} `json:"Results"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("invalid trivy output: %w", err)
+	}
+	var findings []models.SecurityFinding
+	for _, res := range report.Results {
+		for _, m := range res.Misconfigurations {
+			if m.Status != "" && m.Status != "FAIL" {
+				continue
+			}
+			message := m.Title
+			if m.Message != "" {
+				message = m.Message
+			}
+			findings = append(findings, models.SecurityFinding{
+				Tool:     Trivy,
+				RuleID:   m.ID,
+				Severity: normalizeSeverity(m.Severity),
+				File:     relPath(workDir, scanPath, res.Target),
+				Line:     m.CauseMetadata.StartLine,
+				Message:  message,
+			})
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,381 @@
+// Package securityscan runs static analysis and dependency scanners over a
+// project workdir, normalizes their findings, persists each scan and can file
+// beads for new high-severity findings.
+package securityscan
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Supported scanners
+const (
+	Gosec    = "gosec"
+	Semgrep  = "semgrep"
+	NpmAudit = "npm-audit"
+	Trivy    = "trivy"
+)
+
+const (
+	defaultTimeout = 10 * time.Minute
+	// maxBeadsPerScan keeps one noisy scan from flooding the queue
+	maxBeadsPerScan = 10
+	// maxErrorOutput caps scanner stderr kept in a scan's errors
+	maxErrorOutput = 2048
+)
+
+// Store persists scans
+type Store interface {
+	SaveSecurityScan(scan *models.SecurityScan) error
+	ListSecurityScans(projectID string, limit int) ([]*models.SecurityScan, error)
+	GetSecurityScan(id string) (*models.SecurityScan, error)
+}
+
+// BeadCreator files beads for findings
+type BeadCreator interface {
+	CreateBead(title, description string, priority models.BeadPriority, beadType, projectID string) (*models.Bead, error)
+}
+
+// Options selects what a scan covers
+type Options struct {
+	Tools       []string // Scanners to run; empty runs every applicable installed scanner
+	Path        string   // Directory to scan, relative to the repository root
+	CreateBeads bool     // File beads for new high and critical findings
+}
+
+// Manager runs scanners inside project workdirs
+type Manager struct {
+	WorkDirs files.WorkDirResolver
+	Store    Store
+	Beads    BeadCreator
+	Timeout  time.Duration
+	// AutoCreateBeads files beads for new high-severity findings on every scan
+	AutoCreateBeads bool
+
+	runner toolrun.Runner
+}
+
+// NewManager creates a security scan manager. store and beads may be nil.
+func NewManager(resolver files.WorkDirResolver, store Store, beads BeadCreator) *Manager {
+	return &Manager{
+		WorkDirs: resolver,
+		Store:    store,
+		Beads:    beads,
+		Timeout:  defaultTimeout,
+	}
+}
+
+// Scan runs the selected scanners and persists the combined result. Scanner
+// failures are recorded per tool rather than failing the whole scan; an
+// error is returned only when nothing could be scanned.
+func (m *Manager) Scan(ctx context.Context, projectID string, opts Options) (*models.SecurityScan, error) {
+	if m.WorkDirs == nil {
+		return nil, fmt.Errorf("workdir resolver not configured")
+	}
+	workDir := m.WorkDirs.GetProjectWorkDir(projectID)
+	if workDir == "" {
+		return nil, fmt.Errorf("project workdir not found")
+	}
+	workDir = filepath.Clean(workDir)
+	scanPath := models.CleanSubprojectPath(opts.Path)
+	dir := filepath.Join(workDir, filepath.FromSlash(scanPath))
+	if rel, err := filepath.Rel(workDir, dir); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("scan path %q is outside the project", opts.Path)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("scan path %q is not a directory", opts.Path)
+	}
+
+	scan := &models.SecurityScan{
+		ID:        "scan-" + uuid.New().String()[:8],
+		ProjectID: projectID,
+		Path:      scanPath,
+		Findings:  []models.SecurityFinding{},
+		Summary:   map[string]int{},
+		StartedAt: time.Now(),
+	}
+	tools, err := m.selectTools(dir, opts.Tools, scan)
+	if err != nil {
+		return nil, err
+	}
+	if len(tools) == 0 {
+		return nil, fmt.Errorf("no applicable security scanners installed (skipped: %s)", joinReasons(scan.Skipped))
+	}
+
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	for _, tool := range tools {
+		findings, err := m.runTool(ctx, timeout, tool, workDir, dir, scanPath)
+		if err != nil {
+			if scan.Errors == nil {
+				scan.Errors = map[string]string{}
+			}
+			scan.Errors[tool] = err.Error()
+			continue
+		}
+		scan.Tools = append(scan.Tools, tool)
+		scan.Findings = append(scan.Findings, findings...)
+	}
+	if len(scan.Tools) == 0 {
+		return nil, fmt.Errorf("all security scanners failed: %s", joinReasons(scan.Errors))
+	}
+
+	sort.SliceStable(scan.Findings, func(i, j int) bool {
+		a, b := scan.Findings[i], scan.Findings[j]
+		if ra, rb := models.SeverityRank(a.Severity), models.SeverityRank(b.Severity); ra != rb {
+			return ra < rb
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	m.markNew(scan)
+	for _, f := range scan.Findings {
+		scan.Summary[f.Severity]++
+	}
+	if opts.CreateBeads || m.AutoCreateBeads {
+		m.fileBeads(scan)
+	}
+	scan.CompletedAt = time.Now()
+
+	if m.Store != nil {
+		if err := m.Store.SaveSecurityScan(scan); err != nil {
+			logging.Module("securityscan").Error("Failed to save scan", "scan_id", scan.ID, "project_id", projectID, "error", err)
+		}
+	}
+	return scan, nil
+}
+
+// ListScans returns a project's scans, newest first
+func (m *Manager) ListScans(projectID string, limit int) ([]*models.SecurityScan, error) {
+	if m.Store == nil {
+		return []*models.SecurityScan{}, nil
+	}
+	return m.Store.ListSecurityScans(projectID, limit)
+}
+
+// GetScan returns a single scan
+func (m *Manager) GetScan(id string) (*models.SecurityScan, error) {
+	if m.Store == nil {
+		return nil, fmt.Errorf("scan not found: %s", id)
+	}
+	return m.Store.GetSecurityScan(id)
+}
+
+// selectTools validates requested tools, or picks the scanners that apply to
+// dir when none were requested. Applicable scanners that aren't installed
+// are recorded in scan.Skipped.
+func (m *Manager) selectTools(dir string, requested []string, scan *models.SecurityScan) ([]string, error) {
+	skip := func(tool, reason string) {
+		if scan.Skipped == nil {
+			scan.Skipped = map[string]string{}
+		}
+		scan.Skipped[tool] = reason
+	}
+
+	if len(requested) > 0 {
+		var tools []string
+		for _, tool := range requested {
+			tool = strings.ToLower(strings.TrimSpace(tool))
+			if tool == "npm_audit" || tool == "npm" {
+				tool = NpmAudit
+			}
+			bin, ok := binaries[tool]
+			if !ok {
+				return nil, fmt.Errorf("unsupported security scanner %q (use gosec, semgrep, npm-audit or trivy)", tool)
+			}
+			if _, err := m.runner.Find(bin); err != nil {
+				return nil, fmt.Errorf("%s is not installed", bin)
+			}
+			tools = append(tools, tool)
+		}
+		return tools, nil
+	}
+
+	var tools []string
+	for _, tool := range []string{Gosec, Semgrep, NpmAudit, Trivy} {
+		if !applies(dir, tool) {
+			continue
+		}
+		if _, err := m.runner.Find(binaries[tool]); err != nil {
+			skip(tool, binaries[tool]+" not installed")
+			continue
+		}
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
+
+// binaries maps each scanner to the executable it needs
+var binaries = map[string]string{
+	Gosec:    "gosec",
+	Semgrep:  "semgrep",
+	NpmAudit: "npm",
+	Trivy:    "trivy",
+}
+
+// applies reports whether a scanner is worth running by default in dir.
+// Semgrep only runs by default when the project ships its own rules, since
+// its registry rules need network access.
+func applies(dir, tool string) bool {
+	switch tool {
+	case Gosec:
+		return exists(dir, "go.mod")
+	case Semgrep:
+		return semgrepConfig(dir) != ""
+	case NpmAudit:
+		return exists(dir, "package-lock.json")
+	case Trivy:
+		return len(dockerfiles(dir)) > 0
+	}
+	return false
+}
+
+func (m *Manager) runTool(ctx context.Context, timeout time.Duration, tool, workDir, dir, scanPath string) ([]models.SecurityFinding, error) {
+	var (
+		name  string
+		args  []string
+		parse func([]byte, string, string) ([]models.SecurityFinding, error)
+	)
+	switch tool {
+	case Gosec:
+		name, args, parse = "gosec", []string{"-fmt=json", "-quiet", "./..."}, parseGosec
+	case Semgrep:
+		config := semgrepConfig(dir)
+		if config == "" {
+			config = "auto"
+		}
+		name, args, parse = "semgrep", []string{"scan", "--json", "--quiet", "--config", config}, parseSemgrep
+	case NpmAudit:
+		name, args, parse = "npm", []string{"audit", "--json"}, parseNpmAudit
+	case Trivy:
+		name, args, parse = "trivy", []string{"config", "--format", "json", "--quiet", "."}, parseTrivy
+	default:
+		return nil, fmt.Errorf("unsupported security scanner %q", tool)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// Scanners exit non-zero when they find issues, so judge the run by
+	// whether it produced a report rather than by its exit status.
+	stdout, stderr, runErr := m.runner.Exec(ctx, toolrun.Command{Dir: dir, Name: name, Args: args})
+	if len(bytes.TrimSpace(stdout)) == 0 {
+		msg := strings.TrimSpace(string(stderr))
+		if len(msg) > maxErrorOutput {
+			msg = msg[:maxErrorOutput] + "..."
+		}
+		if msg == "" && runErr != nil {
+			msg = runErr.Error()
+		}
+		if msg == "" {
+			msg = "no output"
+		}
+		return nil, fmt.Errorf("%s: %s", name, msg)
+	}
+	return parse(stdout, workDir, scanPath)
+}
+
+// markNew flags findings missing from the project's previous scan of the same
+// path. Every finding of a first scan is new.
+func (m *Manager) markNew(scan *models.SecurityScan) {
+	seen := map[string]bool{}
+	if m.Store != nil {
+		previous, err := m.Store.ListSecurityScans(scan.ProjectID, 20)
+		if err == nil {
+			for _, prev := range previous {
+				if prev.Path != scan.Path {
+					continue
+				}
+				for _, f := range prev.Findings {
+					seen[f.Fingerprint()] = true
+				}
+				break
+			}
+		}
+	}
+	for i := range scan.Findings {
+		scan.Findings[i].New = !seen[scan.Findings[i].Fingerprint()]
+	}
+}
+
+// fileBeads files a bead for each new high or critical finding, up to
+// maxBeadsPerScan per scan.
+func (m *Manager) fileBeads(scan *models.SecurityScan) {
+	if m.Beads == nil {
+		return
+	}
+	for _, f := range scan.Findings {
+		if len(scan.BeadIDs) >= maxBeadsPerScan {
+			return
+		}
+		if !f.New || models.SeverityRank(f.Severity) > models.SeverityRank(models.SeverityHigh) {
+			continue
+		}
+		location := f.File
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		title := fmt.Sprintf("[security] %s %s in %s", f.Tool, f.RuleID, location)
+		description := fmt.Sprintf("%s reported a %s severity finding.\n\nRule: %s\nLocation: %s\nScan: %s\n\n%s",
+			f.Tool, f.Severity, f.RuleID, location, scan.ID, f.Message)
+		priority := models.BeadPriorityP1
+		if f.Severity == models.SeverityCritical {
+			priority = models.BeadPriorityP0
+		}
+		bead, err := m.Beads.CreateBead(title, description, priority, "bug", scan.ProjectID)
+		if err != nil || bead == nil {
+			continue
+		}
+		scan.BeadIDs = append(scan.BeadIDs, bead.ID)
+	}
+}
+
+func semgrepConfig(dir string) string {
+	for _, name := range []string{".semgrep.yml", ".semgrep.yaml", ".semgrep"} {
+		if exists(dir, name) {
+			return name
+		}
+	}
+	return ""
+}
+
+func dockerfiles(dir string) []string {
+	var found []string
+	for _, pattern := range []string{"Dockerfile", "Dockerfile.*", "*.Dockerfile", "*.dockerfile", "Containerfile"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		found = append(found, matches...)
+	}
+	return found
+}
+
+func exists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+func joinReasons(reasons map[string]string) string {
+	if len(reasons) == 0 {
+		return "none"
+	}
+	parts := make([]string, 0, len(reasons))
+	for tool, reason := range reasons {
+		parts = append(parts, tool+": "+reason)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "; ")
+}
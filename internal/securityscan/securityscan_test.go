@@ -0,0 +1,206 @@
+package securityscan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type memStore struct {
+	scans []*models.SecurityScan
+}
+
+func (s *memStore) SaveSecurityScan(scan *models.SecurityScan) error {
+	s.scans = append([]*models.SecurityScan{scan}, s.scans...)
+	return nil
+}
+
+func (s *memStore) ListSecurityScans(projectID string, limit int) ([]*models.SecurityScan, error) {
+	return s.scans, nil
+}
+
+func (s *memStore) GetSecurityScan(id string) (*models.SecurityScan, error) {
+	for _, scan := range s.scans {
+		if scan.ID == id {
+			return scan, nil
+		}
+	}
+	return nil, fmt.Errorf("scan not found: %s", id)
+}
+
+type recordingBeads struct {
+	titles     []string
+	priorities []models.BeadPriority
+}
+
+func (b *recordingBeads) CreateBead(title, description string, priority models.BeadPriority, beadType, projectID string) (*models.Bead, error) {
+	b.titles = append(b.titles, title)
+	b.priorities = append(b.priorities, priority)
+	return &models.Bead{ID: fmt.Sprintf("bd-%d", len(b.titles))}, nil
+}
+
+const gosecOutput = `{"Issues":[
+	{"severity":"MEDIUM","rule_id":"G304","details":"Potential file inclusion via variable","file":"%[1]s/svc/read.go","line":"20-22"},
+	{"severity":"HIGH","rule_id":"G101","details":"Potential hardcoded credentials","file":"%[1]s/svc/config.go","line":"12"}
+]}`
+
+// fakeManager returns a manager whose scanners are all installed and print
+// canned reports.
+func fakeManager(workDir string, outputs map[string]string) *Manager {
+	m := NewManager(toolrun.StaticWorkDir(workDir), &memStore{}, &recordingBeads{})
+	m.runner = (&toolrun.Fake{Handle: func(c toolrun.Command) ([]byte, []byte, error) {
+		out, ok := outputs[c.Name]
+		if !ok {
+			return nil, []byte(c.Name + ": crashed"), fmt.Errorf("exit status 2")
+		}
+		// Scanners exit 1 when they report findings
+		return []byte(out), nil, fmt.Errorf("exit status 1")
+	}}).Runner()
+	return m
+}
+
+func TestParseGosec(t *testing.T) {
+	findings, err := parseGosec([]byte(fmt.Sprintf(gosecOutput, "/repo")), "/repo", "")
+	if err != nil {
+		t.Fatalf("parseGosec: %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(findings))
+	}
+	f := findings[0]
+	if f.Tool != Gosec || f.RuleID != "G304" || f.Severity != models.SeverityMedium || f.File != "svc/read.go" || f.Line != 20 {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
+
+func TestParseSemgrep(t *testing.T) {
+	out := `{"results":[{"check_id":"python.lang.security.eval","path":"app/main.py","start":{"line":4},
+		"extra":{"message":"Detected eval ","severity":"ERROR"}}],"errors":[]}`
+	findings, err := parseSemgrep([]byte(out), "/repo", "services/py")
+	if err != nil {
+		t.Fatalf("parseSemgrep: %v", err)
+	}
+	want := models.SecurityFinding{Tool: Semgrep, RuleID: "python.lang.security.eval", Severity: models.SeverityHigh,
+		File: "services/py/app/main.py", Line: 4, Message: "Detected eval"}
+	if len(findings) != 1 || findings[0] != want {
+		t.Errorf("got %+v, want %+v", findings, want)
+	}
+}
+
+func TestParseNpmAudit(t *testing.T) {
+	out := `{"vulnerabilities":{
+		"minimist":{"name":"minimist","severity":"critical","range":"<1.2.6","via":[{"title":"Prototype Pollution in minimist"}]},
+		"mkdirp":{"name":"mkdirp","severity":"moderate","range":"0.4.1 - 0.5.1","via":["minimist"]}
+	}}`
+	findings, err := parseNpmAudit([]byte(out), "/repo", "web")
+	if err != nil {
+		t.Fatalf("parseNpmAudit: %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if f := findings[0]; f.RuleID != "minimist" || f.Severity != models.SeverityCritical || f.File != "web/package.json" ||
+		f.Message != "minimist <1.2.6: Prototype Pollution in minimist" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f := findings[1]; f.Severity != models.SeverityMedium || f.Message != "mkdirp 0.4.1 - 0.5.1: depends on vulnerable minimist" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+
+	if _, err := parseNpmAudit([]byte(`{"error":{"code":"ENOLOCK","summary":"This command requires an existing lockfile."}}`), "/repo", ""); err == nil {
+		t.Error("expected npm audit error to be reported")
+	}
+}
+
+func TestParseTrivy(t *testing.T) {
+	out := `{"Results":[{"Target":"Dockerfile","Misconfigurations":[
+		{"ID":"DS002","Title":"Image user should not be 'root'","Message":"Specify at least 1 USER command","Severity":"HIGH","Status":"FAIL","CauseMetadata":{"StartLine":1}},
+		{"ID":"DS001","Title":"':latest' tag used","Severity":"MEDIUM","Status":"PASS"}
+	]}]}`
+	findings, err := parseTrivy([]byte(out), "/repo", "")
+	if err != nil {
+		t.Fatalf("parseTrivy: %v", err)
+	}
+	if len(findings) != 1 || findings[0].RuleID != "DS002" || findings[0].Line != 1 || findings[0].Message != "Specify at least 1 USER command" {
+		t.Errorf("unexpected findings: %+v", findings)
+	}
+}
+
+func TestScanSelectsApplicableToolsAndRecordsFailures(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "Dockerfile"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := fakeManager(dir, map[string]string{"gosec": fmt.Sprintf(gosecOutput, dir)})
+
+	scan, err := m.Scan(context.Background(), "p", Options{})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(scan.Tools) != 1 || scan.Tools[0] != Gosec {
+		t.Errorf("expected only gosec to succeed, got %v", scan.Tools)
+	}
+	if scan.Errors[Trivy] == "" {
+		t.Errorf("expected trivy failure to be recorded, got %v", scan.Errors)
+	}
+	if _, ran := scan.Errors[NpmAudit]; ran {
+		t.Error("npm audit should not run without package-lock.json")
+	}
+	// Highest severity first
+	if scan.Findings[0].RuleID != "G101" || scan.Summary[models.SeverityHigh] != 1 || scan.Summary[models.SeverityMedium] != 1 {
+		t.Errorf("unexpected findings/summary: %+v %v", scan.Findings, scan.Summary)
+	}
+	if len(m.Store.(*memStore).scans) != 1 {
+		t.Error("expected scan to be persisted")
+	}
+}
+
+func TestScanRejectsMissingRequestedTool(t *testing.T) {
+	m := fakeManager(t.TempDir(), nil)
+	m.runner = (&toolrun.Fake{Installed: []string{}}).Runner()
+	if _, err := m.Scan(context.Background(), "p", Options{Tools: []string{"semgrep"}}); err == nil {
+		t.Error("expected error for uninstalled scanner")
+	}
+	if _, err := m.Scan(context.Background(), "p", Options{Tools: []string{"bandit"}}); err == nil {
+		t.Error("expected error for unsupported scanner")
+	}
+	if _, err := m.Scan(context.Background(), "p", Options{Path: "../elsewhere"}); err == nil {
+		t.Error("expected error for path outside the project")
+	}
+}
+
+func TestScanMarksNewFindingsAndFilesBeads(t *testing.T) {
+	dir := t.TempDir()
+	m := fakeManager(dir, map[string]string{"gosec": fmt.Sprintf(gosecOutput, dir)})
+	beads := m.Beads.(*recordingBeads)
+
+	first, err := m.Scan(context.Background(), "p", Options{Tools: []string{Gosec}, CreateBeads: true})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if !first.Findings[0].New || !first.Findings[1].New {
+		t.Error("every finding of a first scan should be new")
+	}
+	// Only the high-severity finding gets a bead
+	if len(first.BeadIDs) != 1 || len(beads.titles) != 1 || beads.priorities[0] != models.BeadPriorityP1 {
+		t.Fatalf("expected one P1 bead, got %v %v", beads.titles, beads.priorities)
+	}
+
+	second, err := m.Scan(context.Background(), "p", Options{Tools: []string{Gosec}, CreateBeads: true})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if second.Findings[0].New {
+		t.Error("finding reported by the previous scan should not be new")
+	}
+	if len(second.BeadIDs) != 0 || len(beads.titles) != 1 {
+		t.Errorf("no beads should be filed for known findings, got %v", beads.titles)
+	}
+}
@@ -0,0 +1,86 @@
+package sla
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Compliance is a project's standing against its bead deadlines
+type Compliance struct {
+	ProjectID string `json:"project_id"`
+	// Beads closed since the report's start by their due date, or after it
+	Met    int `json:"met"`
+	Missed int `json:"missed"`
+	// Open beads
+	OnTrack  int `json:"on_track"`
+	AtRisk   int `json:"at_risk"`
+	Breached int `json:"breached"`
+	// Rate is the share of met beads among closed and breached ones, nil
+	// while there are none
+	Rate *float64 `json:"rate,omitempty"`
+	// MeanOverdue is how late missed beads closed on average
+	MeanOverdue string `json:"mean_overdue,omitempty"`
+}
+
+// Compliance reports each project's beads with due dates: those closed
+// since since and those still open. projectID narrows it to one project.
+func (m *Manager) Compliance(projectID string, since time.Time) ([]Compliance, error) {
+	filters := map[string]interface{}{}
+	if projectID != "" {
+		filters["project_id"] = projectID
+	}
+	list, err := m.beads.ListBeads(filters)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	byProject := make(map[string]*Compliance)
+	overdue := make(map[string]time.Duration)
+	for _, b := range list {
+		if b == nil {
+			continue
+		}
+		state, ok := m.policy.Evaluate(b, now)
+		if !ok {
+			continue
+		}
+		if b.Status == models.BeadStatusClosed && (b.ClosedAt == nil || b.ClosedAt.Before(since)) {
+			continue
+		}
+		c := byProject[b.ProjectID]
+		if c == nil {
+			c = &Compliance{ProjectID: b.ProjectID}
+			byProject[b.ProjectID] = c
+		}
+		switch state {
+		case StateMet:
+			c.Met++
+		case StateMissed:
+			c.Missed++
+			due, _ := m.policy.DueDate(b)
+			overdue[b.ProjectID] += b.ClosedAt.Sub(due)
+		case StateOnTrack:
+			c.OnTrack++
+		case StateAtRisk:
+			c.AtRisk++
+		case StateBreached:
+			c.Breached++
+		}
+	}
+
+	report := make([]Compliance, 0, len(byProject))
+	for id, c := range byProject {
+		if total := c.Met + c.Missed + c.Breached; total > 0 {
+			rate := float64(c.Met) / float64(total)
+			c.Rate = &rate
+		}
+		if c.Missed > 0 {
+			c.MeanOverdue = (overdue[id] / time.Duration(c.Missed)).Round(time.Second).String()
+		}
+		report = append(report, *c)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].ProjectID < report[j].ProjectID })
+	return report, nil
+}
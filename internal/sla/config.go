@@ -0,0 +1,59 @@
+package sla
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Defaults for settings left empty in the SLA config
+const (
+	DefaultInterval       = 5 * time.Minute
+	DefaultAtRiskFraction = 0.25
+	DefaultOnBreach       = ActionBump
+)
+
+// WithDefaults fills in unset SLA settings
+func WithDefaults(cfg config.SLAConfig) config.SLAConfig {
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.AtRiskFraction <= 0 || cfg.AtRiskFraction >= 1 {
+		cfg.AtRiskFraction = DefaultAtRiskFraction
+	}
+	switch cfg.OnBreach {
+	case ActionBump, ActionEscalate, ActionNone:
+	default:
+		cfg.OnBreach = DefaultOnBreach
+	}
+	return cfg
+}
+
+// PolicyFrom converts an SLA config to a policy. Targets with keys other
+// than p0..p3 or non-positive durations are ignored.
+func PolicyFrom(cfg config.SLAConfig) Policy {
+	cfg = WithDefaults(cfg)
+	p := Policy{
+		Targets:        make(map[models.BeadPriority]time.Duration),
+		AtRiskFraction: cfg.AtRiskFraction,
+		OnBreach:       cfg.OnBreach,
+	}
+	for key, d := range cfg.Targets {
+		if d <= 0 {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "p0":
+			p.Targets[models.BeadPriorityP0] = d
+		case "p1":
+			p.Targets[models.BeadPriorityP1] = d
+		case "p2":
+			p.Targets[models.BeadPriorityP2] = d
+		case "p3":
+			p.Targets[models.BeadPriorityP3] = d
+		}
+	}
+	return p
+}
@@ -0,0 +1,358 @@
+// Package sla tracks bead deadlines. Each open bead with a due date, set
+// on the bead or derived from a per-priority target, is evaluated on an
+// interval and flagged on track, at risk or breached in its context.
+// Breaches raise the bead's priority or escalate it, and closed beads feed
+// per-project compliance reports.
+package sla
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var slaLog = logging.Module("sla")
+
+// State is where a bead stands against its due date
+type State string
+
+const (
+	StateOnTrack  State = "on_track"
+	StateAtRisk   State = "at_risk"
+	StateBreached State = "breached"
+	StateMet      State = "met"    // Closed by its due date
+	StateMissed   State = "missed" // Closed after its due date
+)
+
+// Breach actions
+const (
+	ActionBump     = "bump"     // Raise the bead one priority level
+	ActionEscalate = "escalate" // Escalate the bead to the CEO
+	ActionNone     = "none"
+)
+
+// Bead context keys written by evaluation
+const (
+	contextState       = "sla_state"
+	contextAtRiskAt    = "sla_at_risk_at"
+	contextBreachedAt  = "sla_breached_at"
+	contextBumpedFrom  = "sla_bumped_from"
+	contextEscalatedTo = "sla_escalation_decision_id"
+)
+
+// BeadStore lists and updates beads
+type BeadStore interface {
+	ListBeads(filters map[string]interface{}) ([]*models.Bead, error)
+	UpdateBead(id string, updates map[string]interface{}) error
+}
+
+// Escalator hands a breached bead to the CEO
+type Escalator interface {
+	EscalateBeadToCEO(beadID, reason, returnedTo string) (*models.DecisionBead, error)
+}
+
+// Policy sets default due dates and how breaches are handled
+type Policy struct {
+	// Targets give beads of a priority without a due date one this long
+	// after they were created
+	Targets map[models.BeadPriority]time.Duration
+	// AtRiskFraction flags a bead at risk once this fraction of the time
+	// between its creation and due date is left
+	AtRiskFraction float64
+	// OnBreach is ActionBump, ActionEscalate or ActionNone
+	OnBreach string
+}
+
+// Transition is a bead's move to at risk or breached
+type Transition struct {
+	BeadID    string              `json:"bead_id"`
+	ProjectID string              `json:"project_id"`
+	Title     string              `json:"title"`
+	Priority  models.BeadPriority `json:"priority"`
+	From      State               `json:"from"`
+	To        State               `json:"to"`
+	DueDate   time.Time           `json:"due_date"`
+	Action    string              `json:"action,omitempty"` // What was done on breach
+}
+
+// Report describes one evaluation pass
+type Report struct {
+	RanAt       time.Time    `json:"ran_at"`
+	Evaluated   int          `json:"evaluated"`
+	DueDatesSet int          `json:"due_dates_set"`
+	Transitions []Transition `json:"transitions,omitempty"`
+	Errors      []string     `json:"errors,omitempty"`
+}
+
+// Manager evaluates bead deadlines
+type Manager struct {
+	beads     BeadStore
+	escalator Escalator
+	policy    Policy
+	notify    func(Transition)
+	readOnly  func() error
+
+	mu      sync.Mutex // serializes passes
+	lastRun *Report
+	now     func() time.Time
+}
+
+// NewManager creates an SLA manager. escalator may be nil, in which case
+// breaches set to escalate are only flagged.
+func NewManager(beads BeadStore, escalator Escalator, policy Policy) *Manager {
+	return &Manager{
+		beads:     beads,
+		escalator: escalator,
+		policy:    policy,
+		now:       time.Now,
+	}
+}
+
+// SetNotifier installs a callback for beads becoming at risk or breached
+func (m *Manager) SetNotifier(fn func(Transition)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notify = fn
+}
+
+// SetReadOnlyCheck installs a check run before each pass; while it returns
+// an error passes are refused with it and nothing is flagged or bumped
+func (m *Manager) SetReadOnlyCheck(check func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readOnly = check
+}
+
+// Policy returns the manager's policy
+func (m *Manager) Policy() Policy {
+	return m.policy
+}
+
+// LastRun returns the latest pass, or nil before the first
+func (m *Manager) LastRun() *Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastRun
+}
+
+// Start evaluates beads every interval until ctx is cancelled
+func (m *Manager) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := m.Run(ctx)
+			if apperr.CodeOf(err) == apperr.CodeReadOnly {
+				continue
+			}
+			if err != nil {
+				slaLog.Error("Evaluation failed", "error", err)
+				continue
+			}
+			for _, e := range report.Errors {
+				slaLog.Warn(e)
+			}
+		}
+	}
+}
+
+// DueDate returns a bead's due date: its own, or its priority's target
+// after creation
+func (p Policy) DueDate(b *models.Bead) (time.Time, bool) {
+	if b.DueDate != nil && !b.DueDate.IsZero() {
+		return *b.DueDate, true
+	}
+	if target, ok := p.Targets[b.Priority]; ok && target > 0 && !b.CreatedAt.IsZero() {
+		return b.CreatedAt.Add(target), true
+	}
+	return time.Time{}, false
+}
+
+// Evaluate returns where a bead stands against its due date at now, and
+// false when it has none
+func (p Policy) Evaluate(b *models.Bead, now time.Time) (State, bool) {
+	due, ok := p.DueDate(b)
+	if !ok {
+		return "", false
+	}
+	if b.Status == models.BeadStatusClosed {
+		closed := now
+		if b.ClosedAt != nil {
+			closed = *b.ClosedAt
+		}
+		if closed.After(due) {
+			return StateMissed, true
+		}
+		return StateMet, true
+	}
+	if now.After(due) {
+		return StateBreached, true
+	}
+	if !b.CreatedAt.IsZero() && b.CreatedAt.Before(due) {
+		window := due.Sub(b.CreatedAt)
+		if due.Sub(now) <= time.Duration(float64(window)*p.AtRiskFraction) {
+			return StateAtRisk, true
+		}
+	}
+	return StateOnTrack, true
+}
+
+// Run evaluates every open bead once. Beads given a due date by their
+// priority's target keep it, so a later priority bump doesn't move it.
+// Failures on individual beads are collected in the report.
+func (m *Manager) Run(ctx context.Context) (*Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.readOnly != nil {
+		if err := m.readOnly(); err != nil {
+			return nil, err
+		}
+	}
+
+	now := m.now()
+	report := &Report{RanAt: now}
+	list, err := m.beads.ListBeads(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range list {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if b == nil || b.Status == models.BeadStatusClosed {
+			continue
+		}
+		state, ok := m.policy.Evaluate(b, now)
+		if !ok {
+			continue
+		}
+		report.Evaluated++
+
+		updates := make(map[string]interface{})
+		if b.DueDate == nil {
+			due, _ := m.policy.DueDate(b)
+			updates["due_date"] = &due
+			report.DueDatesSet++
+		}
+		from := State(b.Context[contextState])
+		if from == "" {
+			from = StateOnTrack
+		}
+		ctxUpdates := map[string]string{}
+		if state != from {
+			ctxUpdates[contextState] = string(state)
+		}
+		var transition *Transition
+		if state != from && (state == StateAtRisk || state == StateBreached) {
+			due, _ := m.policy.DueDate(b)
+			transition = &Transition{BeadID: b.ID, ProjectID: b.ProjectID, Title: b.Title,
+				Priority: b.Priority, From: from, To: state, DueDate: due}
+			key := contextAtRiskAt
+			if state == StateBreached {
+				key = contextBreachedAt
+				transition.Action = m.breach(b, updates, ctxUpdates)
+			}
+			ctxUpdates[key] = now.UTC().Format(time.RFC3339)
+		}
+		if len(ctxUpdates) > 0 {
+			updates["context"] = ctxUpdates
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if err := m.beads.UpdateBead(b.ID, updates); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("bead %s: %v", b.ID, err))
+			continue
+		}
+		if transition != nil {
+			report.Transitions = append(report.Transitions, *transition)
+			slaLog.Info("Bead deadline", "bead_id", b.ID, "state", transition.To, "due", transition.DueDate, "action", transition.Action)
+			if m.notify != nil {
+				m.notify(*transition)
+			}
+		}
+	}
+	m.lastRun = report
+	return report, nil
+}
+
+// breach applies the breach action to a bead's updates and returns what
+// was done
+func (m *Manager) breach(b *models.Bead, updates map[string]interface{}, ctxUpdates map[string]string) string {
+	switch m.policy.OnBreach {
+	case ActionNone:
+		return ""
+	case ActionEscalate:
+		if m.escalator == nil || b.Context[contextEscalatedTo] != "" {
+			return ""
+		}
+		due, _ := m.policy.DueDate(b)
+		reason := fmt.Sprintf("SLA breached: due %s", due.UTC().Format(time.RFC3339))
+		decision, err := m.escalator.EscalateBeadToCEO(b.ID, reason, b.AssignedTo)
+		if err != nil {
+			slaLog.Warn("Failed to escalate breached bead", "bead_id", b.ID, "error", err)
+			return ""
+		}
+		if decision != nil && decision.Bead != nil {
+			ctxUpdates[contextEscalatedTo] = decision.Bead.ID
+		}
+		return ActionEscalate
+	default:
+		if b.Priority <= models.BeadPriorityP0 {
+			return ""
+		}
+		updates["priority"] = b.Priority - 1
+		ctxUpdates[contextBumpedFrom] = fmt.Sprintf("P%d", b.Priority)
+		return ActionBump
+	}
+}
+
+// Flagged returns open beads at risk or breached, most overdue first.
+// projectID and state narrow the list when set.
+func (m *Manager) Flagged(projectID string, state State) ([]BeadStatus, error) {
+	filters := map[string]interface{}{}
+	if projectID != "" {
+		filters["project_id"] = projectID
+	}
+	list, err := m.beads.ListBeads(filters)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	flagged := []BeadStatus{}
+	for _, b := range list {
+		if b == nil || b.Status == models.BeadStatusClosed {
+			continue
+		}
+		s, ok := m.policy.Evaluate(b, now)
+		if !ok || s == StateOnTrack || (state != "" && s != state) {
+			continue
+		}
+		due, _ := m.policy.DueDate(b)
+		flagged = append(flagged, BeadStatus{BeadID: b.ID, ProjectID: b.ProjectID, Title: b.Title,
+			Priority: b.Priority, AssignedTo: b.AssignedTo, State: s, DueDate: due, Remaining: due.Sub(now).Round(time.Second).String()})
+	}
+	sort.Slice(flagged, func(i, j int) bool { return flagged[i].DueDate.Before(flagged[j].DueDate) })
+	return flagged, nil
+}
+
+// BeadStatus is an open bead's standing against its due date
+type BeadStatus struct {
+	BeadID     string              `json:"bead_id"`
+	ProjectID  string              `json:"project_id"`
+	Title      string              `json:"title"`
+	Priority   models.BeadPriority `json:"priority"`
+	AssignedTo string              `json:"assigned_to,omitempty"`
+	State      State               `json:"state"`
+	DueDate    time.Time           `json:"due_date"`
+	Remaining  string              `json:"remaining"` // Negative once breached
+}
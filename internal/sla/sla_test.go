@@ -0,0 +1,184 @@
+package sla
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/internal/readonly"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeEscalator struct{ escalated []string }
+
+func (f *fakeEscalator) EscalateBeadToCEO(beadID, reason, returnedTo string) (*models.DecisionBead, error) {
+	f.escalated = append(f.escalated, beadID)
+	return &models.DecisionBead{Bead: &models.Bead{ID: "dec-" + beadID}}, nil
+}
+
+func newStore(t *testing.T, list ...*models.Bead) *beads.Manager {
+	t.Helper()
+	mgr := beads.NewManager("")
+	mgr.SetBeadsPath(t.TempDir())
+	for _, b := range list {
+		if err := mgr.ImportBead(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return mgr
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	policy := Policy{Targets: map[models.BeadPriority]time.Duration{1: 10 * time.Hour}, AtRiskFraction: 0.25}
+	due := now.Add(time.Hour)
+	late := now.Add(2 * time.Hour)
+
+	tests := []struct {
+		name string
+		bead *models.Bead
+		want State
+	}{
+		{"no due date or target", &models.Bead{Priority: 2, CreatedAt: now}, ""},
+		{"target on track", &models.Bead{Priority: 1, CreatedAt: now.Add(-time.Hour)}, StateOnTrack},
+		{"target at risk", &models.Bead{Priority: 1, CreatedAt: now.Add(-8 * time.Hour)}, StateAtRisk},
+		{"target breached", &models.Bead{Priority: 1, CreatedAt: now.Add(-11 * time.Hour)}, StateBreached},
+		{"own due date wins", &models.Bead{Priority: 1, CreatedAt: now.Add(-11 * time.Hour), DueDate: &due}, StateAtRisk},
+		{"closed in time", &models.Bead{Status: models.BeadStatusClosed, DueDate: &due, ClosedAt: &now}, StateMet},
+		{"closed late", &models.Bead{Status: models.BeadStatusClosed, DueDate: &due, ClosedAt: &late}, StateMissed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := policy.Evaluate(tt.bead, now)
+			if ok != (tt.want != "") || got != tt.want {
+				t.Errorf("Evaluate = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_RunFlagsAndBumps(t *testing.T) {
+	now := time.Now()
+	store := newStore(t,
+		&models.Bead{ID: "bd-ok", Status: models.BeadStatusOpen, Priority: 2, ProjectID: "p", CreatedAt: now},
+		&models.Bead{ID: "bd-risk", Status: models.BeadStatusOpen, Priority: 2, ProjectID: "p", CreatedAt: now.Add(-9 * time.Hour)},
+		&models.Bead{ID: "bd-late", Status: models.BeadStatusOpen, Priority: 2, ProjectID: "p", CreatedAt: now.Add(-11 * time.Hour)},
+		&models.Bead{ID: "bd-none", Status: models.BeadStatusOpen, Priority: 3, ProjectID: "p", CreatedAt: now.Add(-48 * time.Hour)},
+	)
+	m := NewManager(store, nil, PolicyFrom(config.SLAConfig{Targets: map[string]time.Duration{"P2": 10 * time.Hour}}))
+	var notified []Transition
+	m.SetNotifier(func(tr Transition) { notified = append(notified, tr) })
+
+	report, err := m.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Evaluated != 3 || report.DueDatesSet != 3 || len(report.Transitions) != 2 || len(notified) != 2 {
+		t.Fatalf("report = %+v, notified %d", report, len(notified))
+	}
+
+	late, _ := store.GetBead("bd-late")
+	if late.Priority != 1 || late.Context[contextBumpedFrom] != "P2" || late.Context[contextState] != string(StateBreached) {
+		t.Errorf("breached bead = priority %d context %v, want bumped to P1", late.Priority, late.Context)
+	}
+	if late.DueDate == nil || !late.DueDate.Equal(late.CreatedAt.Add(10*time.Hour)) {
+		t.Errorf("due date = %v, want frozen at creation + 10h", late.DueDate)
+	}
+	risk, _ := store.GetBead("bd-risk")
+	if risk.Priority != 2 || risk.Context[contextState] != string(StateAtRisk) {
+		t.Errorf("at-risk bead = priority %d context %v", risk.Priority, risk.Context)
+	}
+
+	// A second pass finds nothing new, and the bumped bead keeps its due date
+	report, err = m.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Transitions) != 0 || report.DueDatesSet != 0 {
+		t.Errorf("second pass = %+v, want no changes", report)
+	}
+}
+
+func TestManager_RunEscalatesOnce(t *testing.T) {
+	due := time.Now().Add(-time.Minute)
+	store := newStore(t, &models.Bead{ID: "bd-1", Status: models.BeadStatusOpen, Priority: 1, ProjectID: "p", CreatedAt: due.Add(-time.Hour), DueDate: &due})
+	esc := &fakeEscalator{}
+	m := NewManager(store, esc, PolicyFrom(config.SLAConfig{OnBreach: ActionEscalate}))
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(esc.escalated) != 1 {
+		t.Fatalf("escalated %v, want bd-1 once", esc.escalated)
+	}
+	b, _ := store.GetBead("bd-1")
+	if b.Priority != 1 || b.Context[contextEscalatedTo] != "dec-bd-1" {
+		t.Errorf("bead = priority %d context %v", b.Priority, b.Context)
+	}
+}
+
+func TestManager_ComplianceAndFlagged(t *testing.T) {
+	now := time.Now()
+	due := now.Add(-time.Hour)
+	early, late, old := due.Add(-time.Minute), due.Add(30*time.Minute), now.Add(-60*24*time.Hour)
+	soon := now.Add(time.Minute)
+	store := newStore(t,
+		&models.Bead{ID: "a-met", Status: models.BeadStatusClosed, ProjectID: "a", DueDate: &due, ClosedAt: &early},
+		&models.Bead{ID: "a-missed", Status: models.BeadStatusClosed, ProjectID: "a", DueDate: &due, ClosedAt: &late},
+		&models.Bead{ID: "a-old", Status: models.BeadStatusClosed, ProjectID: "a", DueDate: &old, ClosedAt: &old},
+		&models.Bead{ID: "a-breached", Status: models.BeadStatusOpen, ProjectID: "a", CreatedAt: due.Add(-time.Hour), DueDate: &due},
+		&models.Bead{ID: "b-risk", Status: models.BeadStatusOpen, ProjectID: "b", CreatedAt: now.Add(-time.Hour), DueDate: &soon},
+	)
+	m := NewManager(store, nil, PolicyFrom(config.SLAConfig{}))
+
+	report, err := m.Compliance("", now.Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 2 {
+		t.Fatalf("report = %+v, want projects a and b", report)
+	}
+	a := report[0]
+	if a.Met != 1 || a.Missed != 1 || a.Breached != 1 || a.Rate == nil || *a.Rate < 0.33 || *a.Rate > 0.34 || a.MeanOverdue != "30m0s" {
+		t.Errorf("project a = %+v", a)
+	}
+	if b := report[1]; b.AtRisk != 1 || b.Rate != nil {
+		t.Errorf("project b = %+v, want one at-risk bead and no rate", b)
+	}
+
+	flagged, err := m.Flagged("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flagged) != 2 || flagged[0].BeadID != "a-breached" || flagged[1].State != StateAtRisk {
+		t.Errorf("flagged = %+v, want a-breached then b-risk", flagged)
+	}
+	if flagged, _ := m.Flagged("b", StateBreached); len(flagged) != 0 {
+		t.Errorf("flagged = %+v, want none breached in b", flagged)
+	}
+}
+
+func TestManager_RunRefusedWhileReadOnly(t *testing.T) {
+	now := time.Now()
+	store := newStore(t, &models.Bead{ID: "bd-late", Status: models.BeadStatusOpen, Priority: 2, ProjectID: "p", CreatedAt: now.Add(-11 * time.Hour)})
+	m := NewManager(store, nil, PolicyFrom(config.SLAConfig{Targets: map[string]time.Duration{"P2": 10 * time.Hour}}))
+	gate := readonly.New(config.ReadOnlyConfig{Enabled: true, Reason: "audit"})
+	m.SetReadOnlyCheck(gate.Check)
+
+	if _, err := m.Run(context.Background()); apperr.CodeOf(err) != apperr.CodeReadOnly {
+		t.Fatalf("expected a read_only error, got %v", err)
+	}
+	if b, _ := store.GetBead("bd-late"); b.Priority != 2 || b.DueDate != nil {
+		t.Errorf("bead = priority %d due %v, want untouched while read-only", b.Priority, b.DueDate)
+	}
+
+	gate.Set(false, "", "admin")
+	if report, err := m.Run(context.Background()); err != nil || report.Evaluated != 1 {
+		t.Errorf("expected a pass once read-only mode is off, got %+v, %v", report, err)
+	}
+}
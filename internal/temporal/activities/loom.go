@@ -3,7 +3,6 @@ package activities
 import (
 	"context"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
@@ -11,9 +10,12 @@ import (
 	"github.com/jordanhubbard/loom/internal/beads"
 	"github.com/jordanhubbard/loom/internal/database"
 	"github.com/jordanhubbard/loom/internal/dispatch"
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
+var ralphLog = logging.Module("ralph")
+
 const maxDispatchesPerBeat = 50
 
 // LoomActivities supplies activities for the Ralph Loop heartbeat.
@@ -38,18 +40,18 @@ func NewLoomActivities(db *database.Database, d *dispatch.Dispatcher, b *beads.M
 // dispatchable work by calling DispatchOnce in a tight loop.
 func (a *LoomActivities) LoomHeartbeatActivity(ctx context.Context, beatCount int) error {
 	start := time.Now()
-	log.Printf("[Ralph] Beat %d: starting (dispatcher=%v agentMgr=%v beadsMgr=%v)", beatCount, a.dispatcher != nil, a.agentMgr != nil, a.beadsMgr != nil)
+	ralphLog.Debug("Beat starting", "beat", beatCount, "dispatcher", a.dispatcher != nil, "agent_manager", a.agentMgr != nil, "beads_manager", a.beadsMgr != nil)
 
 	// Phase 1: Reset agents stuck in "working" state for too long
 	agentsReset := 0
 	if a.agentMgr != nil {
 		agentsReset = a.agentMgr.ResetStuckAgents(5 * time.Minute)
 	}
-	log.Printf("[Ralph] Beat %d: phase1 done (agentsReset=%d, elapsed=%v)", beatCount, agentsReset, time.Since(start).Round(time.Millisecond))
+	ralphLog.Debug("Beat phase 1 done", "beat", beatCount, "agents_reset", agentsReset, "elapsed", time.Since(start).Round(time.Millisecond))
 
 	// Phase 2: Auto-block beads stuck in dispatch loops
 	stuckResolved := a.resolveStuckBeads()
-	log.Printf("[Ralph] Beat %d: phase2 done (stuckResolved=%d, elapsed=%v)", beatCount, stuckResolved, time.Since(start).Round(time.Millisecond))
+	ralphLog.Debug("Beat phase 2 done", "beat", beatCount, "stuck_resolved", stuckResolved, "elapsed", time.Since(start).Round(time.Millisecond))
 
 	// Phase 3: Drain all dispatchable work
 	dispatched := 0
@@ -57,7 +59,7 @@ func (a *LoomActivities) LoomHeartbeatActivity(ctx context.Context, beatCount in
 		for i := 0; i < maxDispatchesPerBeat; i++ {
 			result, err := a.dispatcher.DispatchOnce(ctx, "")
 			if err != nil {
-				log.Printf("[Ralph] Beat %d: dispatch error on iteration %d: %v", beatCount, i+1, err)
+				ralphLog.Error("Dispatch error", "beat", beatCount, "iteration", i+1, "error", err)
 				break
 			}
 			if result == nil || !result.Dispatched {
@@ -68,8 +70,8 @@ func (a *LoomActivities) LoomHeartbeatActivity(ctx context.Context, beatCount in
 	}
 
 	elapsed := time.Since(start)
-	log.Printf("[Ralph] Beat %d: dispatched=%d stuck_resolved=%d agents_reset=%d elapsed=%v",
-		beatCount, dispatched, stuckResolved, agentsReset, elapsed.Round(time.Millisecond))
+	ralphLog.Info("Beat done", "beat", beatCount, "dispatched", dispatched, "stuck_resolved", stuckResolved,
+		"agents_reset", agentsReset, "elapsed", elapsed.Round(time.Millisecond))
 
 	return nil
 }
@@ -121,10 +123,10 @@ func (a *LoomActivities) resolveStuckBeads() int {
 			},
 		}
 		if err := a.beadsMgr.UpdateBead(b.ID, updates); err != nil {
-			log.Printf("[Ralph] Failed to auto-block stuck bead %s: %v", b.ID, err)
+			ralphLog.Error("Failed to auto-block stuck bead", "bead_id", b.ID, "error", err)
 			continue
 		}
-		log.Printf("[Ralph] Auto-blocked stuck bead %s: %s (reassigned to %s)", b.ID, reason, triageAgent)
+		ralphLog.Warn("Auto-blocked stuck bead", "bead_id", b.ID, "reason", reason, "triage_agent", triageAgent)
 		resolved++
 	}
 	return resolved
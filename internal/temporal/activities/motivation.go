@@ -3,13 +3,15 @@ package activities
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/motivation"
 	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
 )
 
+var motivationLog = logging.Module("motivation")
+
 // MotivationActivities provides Temporal activities for motivation operations
 type MotivationActivities struct {
 	engine   *motivation.Engine
@@ -64,7 +66,7 @@ func (a *MotivationActivities) EvaluateMotivationsActivity(ctx context.Context,
 	triggered, err := a.engine.Tick(ctx)
 	if err != nil {
 		result.Errors = append(result.Errors, err.Error())
-		log.Printf("Motivation evaluation error on beat %d: %v", input.BeatCount, err)
+		motivationLog.Error("Motivation evaluation error", "beat", input.BeatCount, "error", err)
 	}
 
 	result.MotivationsFired = triggered
@@ -79,8 +81,8 @@ func (a *MotivationActivities) EvaluateMotivationsActivity(ctx context.Context,
 	}
 
 	if triggered > 0 {
-		log.Printf("Motivation activity: beat=%d, evaluated=%d, fired=%d, names=%v",
-			input.BeatCount, result.MotivationsEvaluated, triggered, result.FiredMotivationNames)
+		motivationLog.Info("Motivations fired", "beat", input.BeatCount, "evaluated", result.MotivationsEvaluated,
+			"fired", triggered, "names", result.FiredMotivationNames)
 	}
 
 	return result, nil
@@ -38,6 +38,7 @@ type ProviderQueryInput struct {
 	Message      string  `json:"message"`
 	Temperature  float64 `json:"temperature"`
 	MaxTokens    int     `json:"max_tokens"`
+	Hedge        bool    `json:"hedge,omitempty"` // Hedge to a second provider when enabled
 }
 
 // ProviderQueryResult represents the response from a provider query.
@@ -161,7 +162,12 @@ func (a *ProviderActivities) ProviderQueryActivity(ctx context.Context, input Pr
 	}
 
 	start := time.Now()
-	resp, err := regProvider.Protocol.CreateChatCompletion(ctx, req)
+	var resp *provider.ChatCompletionResponse
+	if input.Hedge {
+		resp, err = a.registry.SendHedgedChatCompletion(ctx, input.ProviderID, req)
+	} else {
+		resp, err = regProvider.ChatCompletion(ctx, req)
+	}
 	latencyMs := time.Since(start).Milliseconds()
 	if err != nil {
 		return nil, err
@@ -237,6 +243,7 @@ func (a *ProviderActivities) syncRegistry(record *internalmodels.Provider) {
 		Name:                   record.Name,
 		Type:                   record.Type,
 		Endpoint:               record.Endpoint,
+		Endpoints:              record.Endpoints,
 		APIKey:                 apiKey,
 		Model:                  selected,
 		ConfiguredModel:        record.ConfiguredModel,
@@ -249,6 +256,7 @@ func (a *ProviderActivities) syncRegistry(record *internalmodels.Provider) {
 		ContextWindow:          record.ContextWindow,
 		ModelParamsB:           modelParamsB,
 		CostPerMToken:          record.CostPerMToken,
+		SupportsVision:         record.SupportsVision,
 	}
 
 	_ = a.registry.Upsert(cfg)
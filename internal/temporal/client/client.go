@@ -3,14 +3,16 @@ package client
 import (
 	"context"
 	"fmt"
-	"log"
 
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/converter"
 
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/pkg/config"
 )
 
+var temporalLog = logging.Module("temporal")
+
 // Client wraps the Temporal client with loom-specific functionality
 type Client struct {
 	temporal  client.Client
@@ -37,7 +39,7 @@ func New(cfg *config.TemporalConfig) (*Client, error) {
 		return nil, fmt.Errorf("failed to create temporal client: %w", err)
 	}
 
-	log.Printf("Connected to Temporal server at %s (namespace: %s)", cfg.Host, cfg.Namespace)
+	temporalLog.Info("Connected to Temporal server", "host", cfg.Host, "namespace", cfg.Namespace)
 
 	return &Client{
 		temporal:  c,
@@ -98,21 +100,22 @@ func (c *Client) GetWorkflow(ctx context.Context, workflowID, runID string) clie
 	return c.temporal.GetWorkflow(ctx, workflowID, runID)
 }
 
-// temporalLogger implements Temporal's Logger interface
+// temporalLogger implements Temporal's Logger interface on the temporal
+// module logger
 type temporalLogger struct{}
 
 func (l *temporalLogger) Debug(msg string, keyvals ...interface{}) {
-	log.Printf("[Temporal DEBUG] %s %v", msg, keyvals)
+	temporalLog.Debug(msg, keyvals...)
 }
 
 func (l *temporalLogger) Info(msg string, keyvals ...interface{}) {
-	log.Printf("[Temporal INFO] %s %v", msg, keyvals)
+	temporalLog.Info(msg, keyvals...)
 }
 
 func (l *temporalLogger) Warn(msg string, keyvals ...interface{}) {
-	log.Printf("[Temporal WARN] %s %v", msg, keyvals)
+	temporalLog.Warn(msg, keyvals...)
 }
 
 func (l *temporalLogger) Error(msg string, keyvals ...interface{}) {
-	log.Printf("[Temporal ERROR] %s %v", msg, keyvals)
+	temporalLog.Error(msg, keyvals...)
 }
@@ -3,7 +3,6 @@ package temporal
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"regexp"
 	"strings"
 	"time"
@@ -33,7 +32,7 @@ func ParseTemporalDSL(text string) ([]TemporalInstruction, string, error) {
 		block := match[1]
 		parsed, err := parseTemporalBlock(block)
 		if err != nil {
-			log.Printf("Error parsing temporal block: %v", err)
+			temporalLog.Warn("Error parsing temporal block", "error", err)
 			continue
 		}
 		instructions = append(instructions, parsed...)
@@ -64,7 +63,7 @@ func parseTemporalBlock(block string) ([]TemporalInstruction, error) {
 
 		instr, err := parseTemporalInstruction(entry)
 		if err != nil {
-			log.Printf("Error parsing instruction: %v", err)
+			temporalLog.Warn("Error parsing instruction", "error", err)
 			continue
 		}
 
@@ -123,7 +122,7 @@ func parseTemporalInstruction(text string) (*TemporalInstruction, error) {
 		switch strings.ToUpper(key) {
 		case "INPUT":
 			if err := parseJSONInput(value, instr.Input); err != nil {
-				log.Printf("Error parsing INPUT: %v", err)
+				temporalLog.Warn("Error parsing INPUT", "error", err)
 			}
 
 		case "TIMEOUT":
@@ -153,7 +152,7 @@ func parseTemporalInstruction(text string) (*TemporalInstruction, error) {
 
 		case "DATA":
 			if err := parseJSONInput(value, instr.SignalData); err != nil {
-				log.Printf("Error parsing DATA: %v", err)
+				temporalLog.Warn("Error parsing DATA", "error", err)
 			}
 
 		case "WORKFLOW_ID":
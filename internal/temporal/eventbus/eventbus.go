@@ -45,11 +45,31 @@ const (
 	EventTypeDeadlinePassed      EventType = "deadline.passed"
 	EventTypeSystemIdle          EventType = "system.idle"
 
+	// Executor events
+	EventTypeExecutorQuotaExceeded EventType = "executor.quota_exceeded"
+
+	// Usage pattern events
+	EventTypePatternAnomaly EventType = "pattern.anomaly_detected"
+
+	// Reporting events
+	EventTypeDigestGenerated EventType = "digest.generated"
+
+	// External events from repository webhooks
+	EventTypePullRequestClosed EventType = "external.github_pr_closed"
+
 	// OpenClaw messaging gateway events
 	EventTypeOpenClawMessageSent     EventType = "openclaw.message_sent"
 	EventTypeOpenClawMessageFailed   EventType = "openclaw.message_failed"
 	EventTypeOpenClawMessageReceived EventType = "openclaw.message_received"
 	EventTypeOpenClawReplyProcessed  EventType = "openclaw.reply_processed"
+
+	// Editor integration events
+	EventTypeFileChanged  EventType = "file.changed"
+	EventTypeFileFeedback EventType = "file.feedback"
+
+	// File lock events
+	EventTypeFileLockContention   EventType = "file.lock_contention"
+	EventTypeFileLockForceRelease EventType = "file.lock_force_released"
 )
 
 // Event represents a system event
@@ -3,7 +3,6 @@ package temporal
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/jordanhubbard/loom/internal/observability"
@@ -11,6 +10,7 @@ import (
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/temporal/activities"
 	temporalclient "github.com/jordanhubbard/loom/internal/temporal/client"
 	"github.com/jordanhubbard/loom/internal/temporal/eventbus"
@@ -18,6 +18,8 @@ import (
 	"github.com/jordanhubbard/loom/pkg/config"
 )
 
+var temporalLog = logging.Module("temporal")
+
 // Manager manages Temporal integration for loom
 type Manager struct {
 	client   *temporalclient.Client
@@ -44,7 +46,7 @@ func NewManager(cfg *config.TemporalConfig) (*Manager, error) {
 	var eventBus *eventbus.EventBus
 	if cfg.EnableEventBus {
 		eventBus = eventbus.NewEventBus(client, cfg)
-		log.Println("Temporal event bus initialized")
+		temporalLog.Info("Temporal event bus initialized")
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -68,7 +70,7 @@ func NewManager(cfg *config.TemporalConfig) (*Manager, error) {
 		w.RegisterActivity(activities)
 	}
 
-	log.Printf("Temporal worker registered for task queue: %s", cfg.TaskQueue)
+	temporalLog.Info("Temporal worker registered", "task_queue", cfg.TaskQueue)
 
 	return &Manager{
 		client:   client,
@@ -92,22 +94,22 @@ func (m *Manager) RegisterWorkflow(wf interface{}) {
 
 // Start starts the Temporal worker
 func (m *Manager) Start() error {
-	log.Println("Starting Temporal worker...")
+	temporalLog.Info("Starting Temporal worker")
 
 	// Start worker in a goroutine
 	go func() {
 		if err := m.worker.Run(worker.InterruptCh()); err != nil {
-			log.Printf("Temporal worker error: %v", err)
+			temporalLog.Error("Temporal worker error", "error", err)
 		}
 	}()
 
-	log.Println("Temporal worker started successfully")
+	temporalLog.Info("Temporal worker started")
 	return nil
 }
 
 // Stop stops the Temporal manager
 func (m *Manager) Stop() {
-	log.Println("Stopping Temporal manager...")
+	temporalLog.Info("Stopping Temporal manager")
 
 	m.cancel()
 
@@ -123,7 +125,7 @@ func (m *Manager) Stop() {
 		m.client.Close()
 	}
 
-	log.Println("Temporal manager stopped")
+	temporalLog.Info("Temporal manager stopped")
 }
 
 // GetClient returns the Temporal client
@@ -169,7 +171,7 @@ func (m *Manager) StartAgentWorkflow(ctx context.Context, agentID, projectID, pe
 		return fmt.Errorf("failed to start agent workflow: %w", err)
 	}
 
-	log.Printf("Started agent workflow for agent %s", agentID)
+	temporalLog.Info("Started agent workflow", "agent_id", agentID)
 	observability.Info("temporal.workflow_started", map[string]interface{}{
 		"workflow":    "agent_lifecycle",
 		"agent_id":    agentID,
@@ -214,7 +216,7 @@ func (m *Manager) StartBeadWorkflow(ctx context.Context, beadID, projectID, titl
 		return fmt.Errorf("failed to start bead workflow: %w", err)
 	}
 
-	log.Printf("Started bead workflow for bead %s", beadID)
+	temporalLog.Info("Started bead workflow", "bead_id", beadID)
 	observability.Info("temporal.workflow_started", map[string]interface{}{
 		"workflow":    "bead_processing",
 		"bead_id":     beadID,
@@ -259,7 +261,7 @@ func (m *Manager) StartDecisionWorkflow(ctx context.Context, decisionID, project
 		return fmt.Errorf("failed to start decision workflow: %w", err)
 	}
 
-	log.Printf("Started decision workflow for decision %s", decisionID)
+	temporalLog.Info("Started decision workflow", "decision_id", decisionID)
 	observability.Info("temporal.workflow_started", map[string]interface{}{
 		"workflow":    "decision",
 		"decision_id": decisionID,
@@ -304,9 +306,9 @@ func (m *Manager) StartDispatcherWorkflow(ctx context.Context, projectID string,
 	}
 
 	if projectID == "" {
-		log.Printf("Started dispatcher workflow for ALL projects")
+		temporalLog.Info("Started dispatcher workflow for all projects")
 	} else {
-		log.Printf("Started dispatcher workflow for project %s", projectID)
+		temporalLog.Info("Started dispatcher workflow", "project_id", projectID)
 	}
 	observability.Info("temporal.workflow_started", map[string]interface{}{
 		"workflow":    "dispatcher",
@@ -350,7 +352,7 @@ func (m *Manager) StartProviderHeartbeatWorkflow(ctx context.Context, providerID
 		return fmt.Errorf("failed to start provider heartbeat workflow: %w", err)
 	}
 
-	log.Printf("Started provider heartbeat workflow for %s", providerID)
+	temporalLog.Info("Started provider heartbeat workflow", "provider_id", providerID)
 	observability.Info("temporal.workflow_started", map[string]interface{}{
 		"workflow":    "provider_heartbeat",
 		"provider_id": providerID,
@@ -394,7 +396,7 @@ func (m *Manager) StartLoomHeartbeatWorkflow(ctx context.Context, interval time.
 		return fmt.Errorf("failed to start loom heartbeat workflow: %w", err)
 	}
 
-	log.Printf("Started Loom master heartbeat workflow with %v interval", interval)
+	temporalLog.Info("Started Loom master heartbeat workflow", "interval", interval)
 	observability.Info("temporal.workflow_started", map[string]interface{}{
 		"workflow":    "loom_heartbeat",
 		"duration_ms": time.Since(start).Milliseconds(),
@@ -561,7 +563,7 @@ func (m *Manager) ParseTemporalInstructions(text string) ([]TemporalInstruction,
 	// Validate all instructions
 	for _, instr := range instructions {
 		if err := ValidateInstruction(instr); err != nil {
-			log.Printf("Instruction validation failed: %v", err)
+			temporalLog.Warn("Instruction validation failed", "error", err)
 		}
 	}
 
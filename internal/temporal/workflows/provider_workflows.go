@@ -49,6 +49,8 @@ type ProviderQueryWorkflowInput struct {
 	Message      string
 	Temperature  float64
 	MaxTokens    int
+	// Hedge sends the query to a second provider too if the first is slow
+	Hedge bool
 }
 
 // ProviderQueryWorkflow runs a direct provider query through Temporal.
@@ -68,6 +70,7 @@ func ProviderQueryWorkflow(ctx workflow.Context, input ProviderQueryWorkflowInpu
 		Message:      input.Message,
 		Temperature:  input.Temperature,
 		MaxTokens:    input.MaxTokens,
+		Hedge:        input.Hedge,
 	}).Get(ctx, &result)
 
 	return result, err
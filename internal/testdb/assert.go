@@ -0,0 +1,163 @@
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// runAssertion runs an assertion's query and checks its rows
+func runAssertion(ctx context.Context, db *sql.DB, a Assertion) AssertionResult {
+	result := AssertionResult{Name: a.Name, Query: a.Query}
+	ctx, cancel := context.WithTimeout(ctx, statementTimeout)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, a.Query)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Columns = columns
+	var all []map[string]interface{}
+	for rows.Next() {
+		if result.RowCount >= maxScanRows {
+			result.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			result.Message = err.Error()
+			return result
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, c := range columns {
+			row[c] = normalize(values[i])
+		}
+		result.RowCount++
+		if len(all) < max(MaxRows, len(a.ExpectRows)) {
+			all = append(all, row)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Rows = all
+	if len(result.Rows) > MaxRows {
+		result.Rows = result.Rows[:MaxRows]
+	}
+	result.Truncated = result.Truncated || result.RowCount > len(result.Rows)
+
+	result.Passed = true
+	switch {
+	case a.ExpectCount != nil && result.RowCount != *a.ExpectCount:
+		result.Passed = false
+		result.Message = fmt.Sprintf("query returned %d rows, expected %d", result.RowCount, *a.ExpectCount)
+	case a.ExpectValue != nil:
+		want := normalize(a.ExpectValue)
+		if len(all) == 0 || len(columns) == 0 {
+			result.Passed = false
+			result.Message = fmt.Sprintf("query returned no rows, expected %s", compact(want))
+		} else if got := all[0][columns[0]]; !equal(got, want) {
+			result.Passed = false
+			result.Message = fmt.Sprintf("%s is %s, expected %s", columns[0], compact(got), compact(want))
+		}
+	case a.ExpectRows != nil:
+		if result.RowCount != len(a.ExpectRows) {
+			result.Passed = false
+			result.Message = fmt.Sprintf("query returned %d rows, expected %d", result.RowCount, len(a.ExpectRows))
+			break
+		}
+		for i, want := range a.ExpectRows {
+			for _, column := range sortedKeys(want) {
+				got, ok := all[i][column]
+				if !ok {
+					result.Passed = false
+					result.Message = fmt.Sprintf("row %d has no column %s", i+1, column)
+					return result
+				}
+				if w := normalize(want[column]); !equal(got, w) {
+					result.Passed = false
+					result.Message = fmt.Sprintf("row %d %s is %s, expected %s", i+1, column, compact(got), compact(w))
+					return result
+				}
+			}
+		}
+	}
+	return result
+}
+
+// normalize converts a scanned or expected value to the types encoding/json
+// decodes into, so values from either side compare equal
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		v = string(t)
+	case time.Time:
+		v = t.UTC().Format(time.RFC3339Nano)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return fmt.Sprint(v)
+	}
+	return out
+}
+
+// equal compares normalized values, matching booleans to the 0 and 1
+// SQLite stores them as and numbers to the text drivers return for numerics
+func equal(got, want interface{}) bool {
+	if reflect.DeepEqual(got, want) {
+		return true
+	}
+	switch w := want.(type) {
+	case bool:
+		if g, ok := got.(float64); ok {
+			return (g != 0) == w
+		}
+	case float64:
+		if g, ok := got.(string); ok {
+			var f float64
+			if _, err := fmt.Sscan(g, &f); err == nil {
+				return f == w
+			}
+		}
+	}
+	return false
+}
+
+func compact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,183 @@
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fixtureFiles lists a fixture file, or the fixture files of a directory by
+// name
+func fixtureFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("not found")
+	}
+	if !info.IsDir() {
+		if !isFixture(path) {
+			return nil, fmt.Errorf("unsupported fixture type %q (use .sql, .yaml, .yml or .json)", filepath.Ext(path))
+		}
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && isFixture(e.Name()) {
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func isFixture(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".sql", ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// loadFixture loads one fixture file in tx and returns the rows it inserted
+// from YAML or JSON
+func loadFixture(ctx context.Context, tx *sql.Tx, engine, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".sql") {
+		if strings.TrimSpace(string(data)) == "" {
+			return 0, nil
+		}
+		_, err := tx.ExecContext(ctx, string(data))
+		return 0, err
+	}
+
+	tables, err := parseFixture(data)
+	if err != nil {
+		return 0, err
+	}
+	inserted := 0
+	for _, t := range tables {
+		for i, row := range t.rows {
+			if err := insertRow(ctx, tx, engine, t.name, row); err != nil {
+				return inserted, fmt.Errorf("%s row %d: %w", t.name, i+1, err)
+			}
+			inserted++
+		}
+	}
+	return inserted, nil
+}
+
+// fixtureTable is the rows of one table, columns in document order
+type fixtureTable struct {
+	name string
+	rows [][]fixtureValue
+}
+
+type fixtureValue struct {
+	column string
+	value  interface{}
+}
+
+// parseFixture reads a YAML or JSON document mapping table names to lists of
+// rows, keeping the document's order of tables and columns so rows load
+// before the rows that reference them
+func parseFixture(data []byte) ([]fixtureTable, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("fixtures must map table names to lists of rows")
+	}
+	var tables []fixtureTable
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name, rows := root.Content[i].Value, root.Content[i+1]
+		if !identPattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid table name %q", name)
+		}
+		if rows.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("%s: expected a list of rows", name)
+		}
+		t := fixtureTable{name: name}
+		for j, rowNode := range rows.Content {
+			if rowNode.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("%s row %d: expected column: value pairs", name, j+1)
+			}
+			var row []fixtureValue
+			for k := 0; k+1 < len(rowNode.Content); k += 2 {
+				column := rowNode.Content[k].Value
+				if !identPattern.MatchString(column) || strings.Contains(column, ".") {
+					return nil, fmt.Errorf("%s row %d: invalid column name %q", name, j+1, column)
+				}
+				var value interface{}
+				if err := rowNode.Content[k+1].Decode(&value); err != nil {
+					return nil, fmt.Errorf("%s row %d: %s: %w", name, j+1, column, err)
+				}
+				row = append(row, fixtureValue{column: column, value: sqlValue(value)})
+			}
+			t.rows = append(t.rows, row)
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// sqlValue converts a decoded fixture value to a driver value: nested lists
+// and objects become JSON text, for JSON columns
+func sqlValue(v interface{}) interface{} {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+	return v
+}
+
+func insertRow(ctx context.Context, tx *sql.Tx, engine, table string, row []fixtureValue) error {
+	if len(row) == 0 {
+		_, err := tx.ExecContext(ctx, "INSERT INTO "+quoteIdent(table)+" DEFAULT VALUES")
+		return err
+	}
+	columns := make([]string, len(row))
+	placeholders := make([]string, len(row))
+	args := make([]interface{}, len(row))
+	for i, v := range row {
+		columns[i] = quoteIdent(v.column)
+		placeholders[i] = "?"
+		if engine == Postgres {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		}
+		args[i] = v.value
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdent(table), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// quoteIdent quotes a validated, possibly schema-qualified identifier
+func quoteIdent(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = `"` + p + `"`
+	}
+	return strings.Join(parts, ".")
+}
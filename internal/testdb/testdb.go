@@ -0,0 +1,817 @@
+// Package testdb gives beads throwaway databases — a SQLite file or a
+// Postgres container — to run a project's migrations against, load fixtures
+// into and check with SQL assertions, so schema changes are validated
+// before they ship. Each bead has at most one database, dropped when the
+// bead closes or sits idle.
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Engines
+const (
+	SQLite   = "sqlite"
+	Postgres = "postgres"
+)
+
+const (
+	defaultPostgresImage = "postgres:16-alpine"
+	defaultStartTimeout  = time.Minute
+	defaultIdleTimeout   = 2 * time.Hour
+	// commandTimeout bounds a migration command
+	commandTimeout = 5 * time.Minute
+	// statementTimeout bounds one migration, fixture file or query
+	statementTimeout = time.Minute
+	// maxOutputLines caps the migration command output kept in a result
+	maxOutputLines = 200
+	// MaxRows caps the rows an assertion returns
+	MaxRows = 100
+	// maxScanRows caps the rows an assertion counts
+	maxScanRows = 10000
+
+	postgresUser   = "loom"
+	postgresDB     = "loom"
+	postgresMemory = "512m"
+)
+
+var (
+	identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+	nameCleaner  = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// Database is a bead's throwaway database
+type Database struct {
+	BeadID     string    `json:"bead_id"`
+	ProjectID  string    `json:"project_id"`
+	Engine     string    `json:"engine"`
+	URL        string    `json:"url"`                 // DATABASE_URL for migration tools
+	Path       string    `json:"path,omitempty"`      // SQLite file
+	Container  string    `json:"container,omitempty"` // Postgres container
+	Migrations []string  `json:"migrations"`          // Applied migration files, in order
+	CreatedAt  time.Time `json:"created_at"`
+
+	db       *sql.DB
+	dir      string
+	runtime  string
+	lastUsed time.Time
+}
+
+// Env is the environment migration commands run with
+func (d *Database) Env() []string {
+	env := []string{"DATABASE_URL=" + d.URL, "LOOM_DB_ENGINE=" + d.Engine}
+	if d.Engine == SQLite {
+		return append(env, "DATABASE_PATH="+d.Path)
+	}
+	return env
+}
+
+// MigrateOptions selects the migrations to run: SQL files under Path, or a
+// Command (the project's migration tool) run with DATABASE_URL set
+type MigrateOptions struct {
+	Path    string
+	Command string
+	Down    bool // Revert the applied SQL migrations, newest first
+}
+
+// Migration is the outcome of one migration file
+type Migration struct {
+	Name     string `json:"name"`
+	Applied  bool   `json:"applied"`
+	Skipped  bool   `json:"skipped,omitempty"` // Already applied, or nothing to run in this direction
+	Duration string `json:"duration,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Column describes a table column
+type Column struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// Table describes a table of the schema
+type Table struct {
+	Name    string   `json:"name"`
+	Columns []Column `json:"columns"`
+}
+
+// MigrateResult is the outcome of a migration run and the schema after it
+type MigrateResult struct {
+	Success    bool        `json:"success"`
+	Migrations []Migration `json:"migrations,omitempty"`
+	Output     []string    `json:"output,omitempty"` // Command output
+	Schema     []Table     `json:"schema"`
+	Duration   string      `json:"duration"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// FixtureFile is the outcome of loading one fixture file
+type FixtureFile struct {
+	Path  string `json:"path"`
+	Rows  int    `json:"rows,omitempty"` // Rows inserted from YAML or JSON
+	Error string `json:"error,omitempty"`
+}
+
+// FixtureResult is the outcome of loading fixtures. Fixtures load in one
+// transaction, so a failure loads none of them.
+type FixtureResult struct {
+	Success bool          `json:"success"`
+	Files   []FixtureFile `json:"files"`
+	Rows    int           `json:"rows"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Assertion is a query and what its result must be. Without expectations the
+// query's rows are only returned.
+type Assertion struct {
+	Name        string                   `json:"name,omitempty"`
+	Query       string                   `json:"query"`
+	ExpectCount *int                     `json:"expect_count,omitempty"` // Number of rows
+	ExpectRows  []map[string]interface{} `json:"expect_rows,omitempty"`  // Rows in order; only the columns given are compared
+	ExpectValue interface{}              `json:"expect_value,omitempty"` // First column of the first row
+}
+
+// AssertionResult is the outcome of one assertion
+type AssertionResult struct {
+	Name      string                   `json:"name,omitempty"`
+	Query     string                   `json:"query"`
+	Passed    bool                     `json:"passed"`
+	Columns   []string                 `json:"columns,omitempty"`
+	Rows      []map[string]interface{} `json:"rows,omitempty"`
+	RowCount  int                      `json:"row_count"`
+	Truncated bool                     `json:"truncated,omitempty"`
+	Message   string                   `json:"message,omitempty"` // Why it failed
+}
+
+// Manager keeps the beads' throwaway databases
+type Manager struct {
+	WorkDirs      files.WorkDirResolver
+	Runtime       string
+	PostgresImage string
+	StartTimeout  time.Duration
+	IdleTimeout   time.Duration
+
+	mu  sync.Mutex
+	dbs map[string]*Database
+
+	runner toolrun.Runner
+	open   func(driver, dsn string) (*sql.DB, error)
+	now    func() time.Time
+}
+
+// NewManager creates a test database manager, or returns nil when test
+// databases are disabled in cfg.
+func NewManager(resolver files.WorkDirResolver, cfg config.TestDatabasesConfig) *Manager {
+	if cfg.Disabled {
+		return nil
+	}
+	m := &Manager{
+		WorkDirs:      resolver,
+		Runtime:       strings.ToLower(strings.TrimSpace(cfg.Runtime)),
+		PostgresImage: cfg.PostgresImage,
+		StartTimeout:  cfg.StartTimeout,
+		IdleTimeout:   cfg.IdleTimeout,
+		dbs:           make(map[string]*Database),
+		open:          sql.Open,
+		now:           time.Now,
+	}
+	if m.PostgresImage == "" {
+		m.PostgresImage = defaultPostgresImage
+	}
+	if m.StartTimeout <= 0 {
+		m.StartTimeout = defaultStartTimeout
+	}
+	if m.IdleTimeout <= 0 {
+		m.IdleTimeout = defaultIdleTimeout
+	}
+	return m
+}
+
+// Create creates a bead's database, dropping the one it had
+func (m *Manager) Create(ctx context.Context, projectID, beadID, engine string) (*Database, error) {
+	if beadID == "" {
+		return nil, fmt.Errorf("test databases belong to a bead")
+	}
+	engine = strings.ToLower(strings.TrimSpace(engine))
+	if engine == "" || engine == "sqlite3" {
+		engine = SQLite
+	}
+	if engine == "postgresql" {
+		engine = Postgres
+	}
+	if engine != SQLite && engine != Postgres {
+		return nil, fmt.Errorf("unsupported engine %q (use sqlite or postgres)", engine)
+	}
+	m.dropIdle()
+	if err := m.Drop(ctx, beadID); err != nil && !errors.Is(err, ErrNoDatabase) {
+		return nil, err
+	}
+
+	var d *Database
+	var err error
+	if engine == SQLite {
+		d, err = m.createSQLite()
+	} else {
+		d, err = m.createPostgres(ctx, beadID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.BeadID, d.ProjectID, d.Engine = beadID, projectID, engine
+	d.Migrations = []string{}
+	d.CreatedAt = m.clock()
+	d.lastUsed = d.CreatedAt
+
+	m.mu.Lock()
+	m.dbs[beadID] = d
+	m.mu.Unlock()
+	return d, nil
+}
+
+func (m *Manager) createSQLite() (*Database, error) {
+	dir, err := os.MkdirTemp("", "loom-testdb-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating database directory: %w", err)
+	}
+	path := filepath.Join(dir, "test.db")
+	db, err := m.openDB("sqlite3", "file:"+path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err == nil {
+		err = db.Ping()
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("creating sqlite database: %w", err)
+	}
+	// One connection, so every statement sees the same session
+	db.SetMaxOpenConns(1)
+	return &Database{URL: "sqlite://" + path, Path: path, db: db, dir: dir}, nil
+}
+
+func (m *Manager) createPostgres(ctx context.Context, beadID string) (*Database, error) {
+	runtime, err := m.runtime()
+	if err != nil {
+		return nil, err
+	}
+	password := strings.ReplaceAll(uuid.NewString(), "-", "")
+	name := "loom-db-" + strings.Trim(nameCleaner.ReplaceAllString(strings.ToLower(beadID), "-"), "-") + "-" + password[:8]
+	_, stderr, err := m.exec(ctx, "", nil, runtime, "run", "-d", "--rm",
+		"--name", name,
+		"--label", "loom.bead="+beadID,
+		"--memory", postgresMemory,
+		"-e", "POSTGRES_USER="+postgresUser,
+		"-e", "POSTGRES_PASSWORD="+password,
+		"-e", "POSTGRES_DB="+postgresDB,
+		"-p", "127.0.0.1::5432",
+		m.PostgresImage)
+	if err != nil {
+		return nil, fmt.Errorf("starting postgres: %s", errorOutput(stderr, err))
+	}
+	d := &Database{Container: name, runtime: runtime}
+	fail := func(err error) (*Database, error) {
+		m.removeContainer(d)
+		return nil, err
+	}
+
+	stdout, stderr, err := m.exec(ctx, "", nil, runtime, "port", name, "5432/tcp")
+	if err != nil {
+		return fail(fmt.Errorf("finding postgres port: %s", errorOutput(stderr, err)))
+	}
+	addr := firstLine(stdout)
+	if addr == "" {
+		return fail(fmt.Errorf("postgres port is not published"))
+	}
+	d.URL = fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable", postgresUser, password, addr, postgresDB)
+
+	db, err := m.openDB("postgres", d.URL)
+	if err != nil {
+		return fail(err)
+	}
+	d.db = db
+	startCtx, cancel := context.WithTimeout(ctx, m.StartTimeout)
+	defer cancel()
+	for {
+		err = db.PingContext(startCtx)
+		if err == nil {
+			return d, nil
+		}
+		select {
+		case <-startCtx.Done():
+			logs, _, _ := m.exec(context.Background(), "", nil, runtime, "logs", "--tail", "20", name)
+			return fail(fmt.Errorf("postgres did not accept connections within %s: %v\n%s", m.StartTimeout, err, strings.TrimSpace(string(logs))))
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// ErrNoDatabase is returned for a bead without a database
+var ErrNoDatabase = errors.New("bead has no test database; create one with db_create")
+
+// Get returns a bead's database
+func (m *Manager) Get(beadID string) (*Database, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.dbs[beadID]
+	if !ok {
+		return nil, ErrNoDatabase
+	}
+	d.lastUsed = m.clock()
+	return d, nil
+}
+
+// Migrate runs migrations against a bead's database and reports the schema
+// after them. A failed migration is reported in the result; an error means
+// the migrations could not be run.
+func (m *Manager) Migrate(ctx context.Context, beadID string, opts MigrateOptions) (*MigrateResult, error) {
+	d, err := m.Get(beadID)
+	if err != nil {
+		return nil, err
+	}
+	if (opts.Path == "") == (opts.Command == "") {
+		return nil, fmt.Errorf("give either a migrations path or a migration command")
+	}
+	workDir, err := m.workDir(d.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result := &MigrateResult{Success: true}
+	if opts.Command != "" {
+		cmdCtx, cancel := context.WithTimeout(ctx, commandTimeout)
+		stdout, stderr, runErr := m.exec(cmdCtx, workDir, append(os.Environ(), d.Env()...), "sh", "-c", opts.Command)
+		cancel()
+		lines := splitLines(append(append([]byte{}, stdout...), stderr...))
+		if len(lines) > maxOutputLines {
+			lines = lines[len(lines)-maxOutputLines:]
+		}
+		result.Output = lines
+		if runErr != nil {
+			result.Success = false
+			result.Error = "migration command failed: " + runErr.Error()
+		}
+	} else {
+		dir, err := files.SafeJoin(workDir, opts.Path)
+		if err != nil {
+			return nil, fmt.Errorf("migrations %q: %w", opts.Path, err)
+		}
+		files, err := migrationFiles(dir, opts.Down)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no .sql migrations found in %s", opts.Path)
+		}
+		m.applyMigrations(ctx, d, dir, files, opts.Down, result)
+	}
+
+	schema, err := Schema(ctx, d)
+	if err != nil && result.Error == "" {
+		result.Error = "reading schema: " + err.Error()
+	}
+	result.Schema = schema
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	return result, nil
+}
+
+// applyMigrations runs each migration in its own transaction, stopping at
+// the first failure. Up migrations already applied are skipped; down
+// migrations revert only applied ones.
+func (m *Manager) applyMigrations(ctx context.Context, d *Database, dir string, files []string, down bool, result *MigrateResult) {
+	applied := map[string]bool{}
+	for _, name := range d.Migrations {
+		applied[name] = true
+	}
+	for _, file := range files {
+		id := migrationID(file)
+		mig := Migration{Name: file}
+		if applied[id] != down {
+			mig.Skipped = true
+			result.Migrations = append(result.Migrations, mig)
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		statements, ok := section(file, string(data), down)
+		if err == nil && !ok {
+			// Nothing to run in this direction, such as a plain file on the
+			// way down
+			mig.Skipped = true
+			result.Migrations = append(result.Migrations, mig)
+			continue
+		}
+		if err != nil {
+			mig.Error = err.Error()
+		} else {
+			start := time.Now()
+			err = execTx(ctx, d.db, statements)
+			mig.Duration = time.Since(start).Round(time.Millisecond).String()
+			if err != nil {
+				mig.Error = err.Error()
+			}
+		}
+		if mig.Error != "" {
+			result.Success = false
+			result.Error = fmt.Sprintf("migration %s failed: %s", file, mig.Error)
+			result.Migrations = append(result.Migrations, mig)
+			return
+		}
+		mig.Applied = true
+		result.Migrations = append(result.Migrations, mig)
+		m.mu.Lock()
+		if down {
+			d.Migrations = remove(d.Migrations, id)
+		} else {
+			d.Migrations = append(d.Migrations, id)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// LoadFixtures loads fixture files, or the fixture files of directories,
+// into a bead's database in one transaction. .sql files are executed;
+// .yaml, .yml and .json files map table names to lists of rows, inserted in
+// document order.
+func (m *Manager) LoadFixtures(ctx context.Context, beadID string, paths []string) (*FixtureResult, error) {
+	d, err := m.Get(beadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no fixture files given")
+	}
+	workDir, err := m.workDir(d.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []string
+	for _, p := range paths {
+		abs, err := files.SafeJoin(workDir, p)
+		if err != nil {
+			return nil, fmt.Errorf("fixtures %q: %w", p, err)
+		}
+		found, err := fixtureFiles(abs)
+		if err != nil {
+			return nil, fmt.Errorf("fixtures %s: %w", p, err)
+		}
+		fixtures = append(fixtures, found...)
+	}
+	if len(fixtures) == 0 {
+		return nil, fmt.Errorf("no .sql, .yaml, .yml or .json fixtures found")
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, statementTimeout)
+	defer cancel()
+	tx, err := d.db.BeginTx(loadCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &FixtureResult{Success: true}
+	for _, file := range fixtures {
+		rel, _ := filepath.Rel(workDir, file)
+		f := FixtureFile{Path: filepath.ToSlash(rel)}
+		f.Rows, err = loadFixture(loadCtx, tx, d.Engine, file)
+		if err != nil {
+			f.Error = err.Error()
+			result.Success = false
+			result.Error = fmt.Sprintf("fixture %s failed: %s", f.Path, f.Error)
+			result.Files = append(result.Files, f)
+			_ = tx.Rollback()
+			return result, nil
+		}
+		result.Rows += f.Rows
+		result.Files = append(result.Files, f)
+	}
+	if err := tx.Commit(); err != nil {
+		result.Success = false
+		result.Error = "committing fixtures: " + err.Error()
+	}
+	return result, nil
+}
+
+// Assert runs queries against a bead's database and checks their results
+func (m *Manager) Assert(ctx context.Context, beadID string, assertions []Assertion) ([]AssertionResult, error) {
+	d, err := m.Get(beadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(assertions) == 0 {
+		return nil, fmt.Errorf("no assertions given")
+	}
+	var results []AssertionResult
+	for i, a := range assertions {
+		if strings.TrimSpace(a.Query) == "" {
+			return nil, fmt.Errorf("assertion %d has no query", i+1)
+		}
+		results = append(results, runAssertion(ctx, d.db, a))
+	}
+	return results, nil
+}
+
+// Drop drops a bead's database
+func (m *Manager) Drop(ctx context.Context, beadID string) error {
+	m.mu.Lock()
+	d, ok := m.dbs[beadID]
+	delete(m.dbs, beadID)
+	m.mu.Unlock()
+	if !ok {
+		return ErrNoDatabase
+	}
+	m.destroy(d)
+	return nil
+}
+
+// BeadClosed drops a closed bead's database, if it has one
+func (m *Manager) BeadClosed(beadID string) {
+	_ = m.Drop(context.Background(), beadID)
+}
+
+// Shutdown drops every database
+func (m *Manager) Shutdown() {
+	m.mu.Lock()
+	dbs := m.dbs
+	m.dbs = make(map[string]*Database)
+	m.mu.Unlock()
+	for _, d := range dbs {
+		m.destroy(d)
+	}
+}
+
+// dropIdle drops the databases unused for longer than IdleTimeout
+func (m *Manager) dropIdle() {
+	cutoff := m.clock().Add(-m.IdleTimeout)
+	m.mu.Lock()
+	var idle []*Database
+	for beadID, d := range m.dbs {
+		if d.lastUsed.Before(cutoff) {
+			idle = append(idle, d)
+			delete(m.dbs, beadID)
+		}
+	}
+	m.mu.Unlock()
+	for _, d := range idle {
+		m.destroy(d)
+	}
+}
+
+func (m *Manager) destroy(d *Database) {
+	if d.db != nil {
+		_ = d.db.Close()
+	}
+	if d.dir != "" {
+		_ = os.RemoveAll(d.dir)
+	}
+	if d.Container != "" {
+		m.removeContainer(d)
+	}
+}
+
+func (m *Manager) removeContainer(d *Database) {
+	if d.db != nil {
+		_ = d.db.Close()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, _, _ = m.exec(ctx, "", nil, d.runtime, "rm", "-f", d.Container)
+}
+
+func (m *Manager) runtime() (string, error) {
+	if m.Runtime != "" {
+		if m.Runtime != "docker" && m.Runtime != "podman" {
+			return "", fmt.Errorf("unsupported container runtime %q (use docker or podman)", m.Runtime)
+		}
+		if _, err := m.runner.Find(m.Runtime); err != nil {
+			return "", fmt.Errorf("%s is not installed", m.Runtime)
+		}
+		return m.Runtime, nil
+	}
+	for _, rt := range []string{"docker", "podman"} {
+		if _, err := m.runner.Find(rt); err == nil {
+			return rt, nil
+		}
+	}
+	return "", fmt.Errorf("postgres test databases need docker or podman; use engine sqlite instead")
+}
+
+func (m *Manager) workDir(projectID string) (string, error) {
+	if m.WorkDirs == nil {
+		return "", fmt.Errorf("workdir resolver not configured")
+	}
+	workDir := m.WorkDirs.GetProjectWorkDir(projectID)
+	if workDir == "" {
+		return "", fmt.Errorf("project workdir not found")
+	}
+	return filepath.Clean(workDir), nil
+}
+
+func (m *Manager) exec(ctx context.Context, dir string, env []string, name string, args ...string) ([]byte, []byte, error) {
+	return m.runner.Exec(ctx, toolrun.Command{Dir: dir, Env: env, Name: name, Args: args})
+}
+
+func (m *Manager) openDB(driver, dsn string) (*sql.DB, error) {
+	open := m.open
+	if open == nil {
+		open = sql.Open
+	}
+	return open(driver, dsn)
+}
+
+func (m *Manager) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+// Schema lists the tables of a database with their columns
+func Schema(ctx context.Context, d *Database) ([]Table, error) {
+	ctx, cancel := context.WithTimeout(ctx, statementTimeout)
+	defer cancel()
+	tables := []Table{}
+	if d.Engine == SQLite {
+		names, err := queryStrings(ctx, d.db, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			rows, err := d.db.QueryContext(ctx, `SELECT name, type, "notnull" FROM pragma_table_info(?)`, name)
+			if err != nil {
+				return nil, err
+			}
+			t := Table{Name: name}
+			for rows.Next() {
+				var c Column
+				var notNull bool
+				if err := rows.Scan(&c.Name, &c.Type, &notNull); err != nil {
+					rows.Close()
+					return nil, err
+				}
+				c.Nullable = !notNull
+				t.Columns = append(t.Columns, c)
+			}
+			rows.Close()
+			tables = append(tables, t)
+		}
+		return tables, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, `SELECT table_name, column_name, data_type, is_nullable = 'YES'
+		FROM information_schema.columns WHERE table_schema = 'public'
+		ORDER BY table_name, ordinal_position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table string
+		var c Column
+		if err := rows.Scan(&table, &c.Name, &c.Type, &c.Nullable); err != nil {
+			return nil, err
+		}
+		if len(tables) == 0 || tables[len(tables)-1].Name != table {
+			tables = append(tables, Table{Name: table})
+		}
+		tables[len(tables)-1].Columns = append(tables[len(tables)-1].Columns, c)
+	}
+	return tables, rows.Err()
+}
+
+// migrationFiles lists the migrations of a directory in the order they run:
+// *.up.sql and plain *.sql files by name, or *.down.sql files (and plain
+// files with goose Down sections) newest first
+func migrationFiles(dir string, down bool) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		isDown := strings.HasSuffix(name, ".down.sql")
+		isUp := strings.HasSuffix(name, ".up.sql")
+		if (down && !isUp) || (!down && !isDown) {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	if down {
+		sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	}
+	return files, nil
+}
+
+// migrationID names a migration independently of its direction, so the up
+// and down files of one migration match
+func migrationID(file string) string {
+	file = strings.TrimSuffix(file, ".sql")
+	return strings.TrimSuffix(strings.TrimSuffix(file, ".up"), ".down")
+}
+
+// section returns the Up or Down section of a goose-style migration. A file
+// without sections is all up migration, unless it is a .down.sql file.
+func section(file, sqlText string, down bool) (string, bool) {
+	upAt := strings.Index(sqlText, "-- +goose Up")
+	downAt := strings.Index(sqlText, "-- +goose Down")
+	switch {
+	case upAt < 0 && downAt < 0:
+		return sqlText, down == strings.HasSuffix(file, ".down.sql")
+	case down:
+		if downAt < 0 {
+			return "", false
+		}
+		if upAt > downAt {
+			return sqlText[downAt:upAt], true
+		}
+		return sqlText[downAt:], true
+	case upAt < 0:
+		return "", false
+	case downAt > upAt:
+		return sqlText[upAt:downAt], true
+	default:
+		return sqlText[upAt:], true
+	}
+}
+
+func execTx(ctx context.Context, db *sql.DB, statements string) error {
+	ctx, cancel := context.WithTimeout(ctx, statementTimeout)
+	defer cancel()
+	if strings.TrimSpace(statements) == "" {
+		return nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func queryStrings(ctx context.Context, db *sql.DB, query string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func remove(list []string, item string) []string {
+	out := list[:0]
+	for _, s := range list {
+		if s != item {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func splitLines(data []byte) []string {
+	text := strings.TrimRight(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+func firstLine(data []byte) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+	return strings.TrimSpace(line)
+}
+
+func errorOutput(stderr []byte, err error) string {
+	if msg := strings.TrimSpace(string(stderr)); msg != "" {
+		return msg
+	}
+	return err.Error()
+}
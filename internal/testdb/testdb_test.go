@@ -0,0 +1,294 @@
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/toolrun"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func intp(n int) *int { return &n }
+
+func TestNewManagerDisabled(t *testing.T) {
+	if m := NewManager(toolrun.StaticWorkDir("/tmp"), config.TestDatabasesConfig{Disabled: true}); m != nil {
+		t.Error("expected a disabled manager to be nil")
+	}
+}
+
+func TestSQLiteMigrateFixturesAssert(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"db/migrations/001_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE, admin BOOLEAN NOT NULL DEFAULT 0);",
+		"db/migrations/001_users.down.sql": "DROP TABLE users;",
+		"db/migrations/002_posts.sql": `-- +goose Up
+CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id), title TEXT, meta TEXT);
+-- +goose Down
+DROP TABLE posts;`,
+		"db/fixtures/01_users.yaml": "users:\n  - {id: 1, email: ada@example.com, admin: true}\n  - {id: 2, email: bob@example.com}\n",
+		"db/fixtures/02_posts.json": `{"posts": [{"id": 10, "user_id": 1, "title": "Hello", "meta": {"tags": ["a"]}}]}`,
+		"db/fixtures/03_more.sql":   "INSERT INTO posts (id, user_id, title) VALUES (11, 2, 'Second');",
+		"db/fixtures/README.md":     "ignored",
+	})
+	m := NewManager(toolrun.StaticWorkDir(dir), config.TestDatabasesConfig{})
+	ctx := context.Background()
+
+	d, err := m.Create(ctx, "web", "b1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Engine != SQLite || !strings.HasPrefix(d.URL, "sqlite://") {
+		t.Errorf("unexpected database %+v", d)
+	}
+
+	mig, err := m.Migrate(ctx, "b1", MigrateOptions{Path: "db/migrations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mig.Success || len(mig.Migrations) != 2 || len(mig.Schema) != 2 || mig.Schema[0].Name != "posts" {
+		t.Fatalf("unexpected migration result %+v", mig)
+	}
+	if users := mig.Schema[1]; len(users.Columns) != 3 || users.Columns[1].Name != "email" || users.Columns[1].Nullable {
+		t.Errorf("unexpected users table %+v", users)
+	}
+	if again, _ := m.Migrate(ctx, "b1", MigrateOptions{Path: "db/migrations"}); !again.Success || !again.Migrations[0].Skipped || !again.Migrations[1].Skipped {
+		t.Errorf("expected applied migrations to be skipped, got %+v", again.Migrations)
+	}
+
+	fix, err := m.LoadFixtures(ctx, "b1", []string{"db/fixtures"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fix.Success || len(fix.Files) != 3 || fix.Rows != 3 {
+		t.Fatalf("unexpected fixture result %+v", fix)
+	}
+
+	results, err := m.Assert(ctx, "b1", []Assertion{
+		{Name: "two users", Query: "SELECT * FROM users", ExpectCount: intp(2)},
+		{Query: "SELECT email, admin FROM users ORDER BY id", ExpectRows: []map[string]interface{}{{"email": "ada@example.com", "admin": true}, {"admin": false}}},
+		{Query: "SELECT meta FROM posts WHERE id = 10", ExpectValue: `{"tags":["a"]}`},
+		{Query: "SELECT count(*) FROM posts", ExpectValue: 3},
+		{Query: "SELECT title FROM posts WHERE id = 99", ExpectValue: "x"},
+		{Query: "SELECT nope FROM posts"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, true, true, false, false, false} {
+		if results[i].Passed != want {
+			t.Errorf("assertion %d passed = %v, want %v: %+v", i, results[i].Passed, want, results[i])
+		}
+	}
+	if results[3].Message != "count(*) is 2, expected 3" || !strings.Contains(results[4].Message, "no rows") || !strings.Contains(results[5].Message, "no such column") {
+		t.Errorf("unexpected messages %q, %q, %q", results[3].Message, results[4].Message, results[5].Message)
+	}
+
+	down, err := m.Migrate(ctx, "b1", MigrateOptions{Path: "db/migrations", Down: true})
+	if err != nil || !down.Success || len(down.Schema) != 0 {
+		t.Fatalf("unexpected down migration %+v, %v", down, err)
+	}
+	if d, _ := m.Get("b1"); len(d.Migrations) != 0 {
+		t.Errorf("reverted migrations are still recorded: %v", d.Migrations)
+	}
+
+	path := d.Path
+	if err := m.Drop(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("database file was not removed: %v", err)
+	}
+	if _, err := m.Assert(ctx, "b1", []Assertion{{Query: "SELECT 1"}}); !errors.Is(err, ErrNoDatabase) {
+		t.Errorf("expected ErrNoDatabase after drop, got %v", err)
+	}
+}
+
+func TestFailuresRollBack(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"migrations/1_ok.sql":      "CREATE TABLE a (id INTEGER PRIMARY KEY);",
+		"migrations/2_broken.sql":  "CREATE TABLE b (id INTEGER PRIMARY KEY); CREATE TABLE a (id INTEGER);",
+		"migrations/3_never.sql":   "CREATE TABLE c (id INTEGER);",
+		"fixtures/1.yaml":          "a:\n  - id: 1\n",
+		"fixtures/2.yaml":          "a:\n  - id: 1\n",
+		"fixtures/bad-name.yaml":   "\"a; DROP TABLE a\":\n  - id: 2\n",
+		"fixtures/not-a-list.json": `{"a": {"id": 3}}`,
+	})
+	m := NewManager(toolrun.StaticWorkDir(dir), config.TestDatabasesConfig{})
+	ctx := context.Background()
+	if _, err := m.Create(ctx, "web", "b1", "sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Shutdown()
+
+	mig, err := m.Migrate(ctx, "b1", MigrateOptions{Path: "migrations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mig.Success || len(mig.Migrations) != 2 || mig.Migrations[1].Error == "" || !strings.Contains(mig.Error, "2_broken.sql") {
+		t.Errorf("unexpected migration result %+v", mig)
+	}
+	if len(mig.Schema) != 1 || mig.Schema[0].Name != "a" {
+		t.Errorf("the failed migration was not rolled back: %+v", mig.Schema)
+	}
+
+	fix, err := m.LoadFixtures(ctx, "b1", []string{"fixtures/1.yaml", "fixtures/2.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fix.Success || len(fix.Files) != 2 || !strings.Contains(fix.Files[1].Error, "UNIQUE") {
+		t.Errorf("unexpected fixture result %+v", fix)
+	}
+	results, _ := m.Assert(ctx, "b1", []Assertion{{Query: "SELECT * FROM a", ExpectCount: intp(0)}})
+	if !results[0].Passed {
+		t.Errorf("failed fixtures were not rolled back: %+v", results[0])
+	}
+	for _, bad := range []string{"fixtures/bad-name.yaml", "fixtures/not-a-list.json", "../outside.sql", "fixtures/missing.yaml"} {
+		if fix, err := m.LoadFixtures(ctx, "b1", []string{bad}); err == nil && fix.Success {
+			t.Errorf("expected %s to fail", bad)
+		}
+	}
+	if _, err := m.Migrate(ctx, "b1", MigrateOptions{Path: "../etc"}); err == nil {
+		t.Error("expected migrations outside the project to be refused")
+	}
+	if _, err := m.Create(ctx, "web", "b1", "oracle"); err == nil {
+		t.Error("expected an unsupported engine to be refused")
+	}
+	if _, err := m.Create(ctx, "web", "", "sqlite"); err == nil {
+		t.Error("expected a database without a bead to be refused")
+	}
+}
+
+func TestMigrateCommand(t *testing.T) {
+	m := NewManager(toolrun.StaticWorkDir(t.TempDir()), config.TestDatabasesConfig{})
+	ctx := context.Background()
+	d, err := m.Create(ctx, "web", "b1", "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Shutdown()
+	f := &toolrun.Fake{Handle: func(c toolrun.Command) ([]byte, []byte, error) {
+		return []byte("applying 0001_init\n"), []byte("error: syntax error at line 3\n"), errors.New("exit status 1")
+	}}
+	m.runner = f.Runner()
+	mig, err := m.Migrate(ctx, "b1", MigrateOptions{Command: "make migrate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mig.Success || len(mig.Output) != 2 || !strings.Contains(mig.Error, "exit status 1") {
+		t.Errorf("unexpected result %+v", mig)
+	}
+	if env := f.Calls[0].Env; !containsString(env, "DATABASE_URL="+d.URL) || !containsString(env, "DATABASE_PATH="+d.Path) {
+		t.Errorf("database environment not set")
+	}
+	if _, err := m.Migrate(ctx, "b1", MigrateOptions{Command: "make migrate", Path: "db"}); err == nil {
+		t.Error("expected a path and a command together to be refused")
+	}
+}
+
+func TestPostgresContainer(t *testing.T) {
+	m := NewManager(toolrun.StaticWorkDir(t.TempDir()), config.TestDatabasesConfig{StartTimeout: time.Second})
+	f := &toolrun.Fake{
+		Installed: []string{"podman"},
+		Handle: func(c toolrun.Command) ([]byte, []byte, error) {
+			if c.Args[0] == "port" {
+				return []byte("127.0.0.1:49153\n"), nil, nil
+			}
+			return []byte("c0ffee\n"), nil, nil
+		},
+	}
+	m.runner = f.Runner()
+	var dsn string
+	m.open = func(driver, url string) (*sql.DB, error) {
+		dsn = url
+		return sql.Open("sqlite3", ":memory:")
+	}
+
+	d, err := m.Create(context.Background(), "web", "Loom_7", "postgresql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Engine != Postgres || !strings.HasPrefix(d.Container, "loom-db-loom-7-") || dsn != d.URL ||
+		!strings.HasPrefix(d.URL, "postgres://loom:") || !strings.HasSuffix(d.URL, "@127.0.0.1:49153/loom?sslmode=disable") {
+		t.Errorf("unexpected database %+v", d)
+	}
+	if run := f.Calls[0].String(); !strings.Contains(run, "podman run -d --rm --name "+d.Container) || !strings.Contains(run, "-p 127.0.0.1::5432 postgres:16-alpine") {
+		t.Errorf("unexpected run %q", run)
+	}
+
+	m.BeadClosed("Loom_7")
+	if last := f.Calls[len(f.Calls)-1].String(); last != "podman rm -f "+d.Container {
+		t.Errorf("container was not removed: %q", last)
+	}
+
+	m.runner = (&toolrun.Fake{Installed: []string{}}).Runner()
+	if _, err := m.Create(context.Background(), "web", "b2", Postgres); err == nil || !strings.Contains(err.Error(), "docker or podman") {
+		t.Errorf("expected a missing runtime to be reported, got %v", err)
+	}
+}
+
+func TestIdleDatabasesDropped(t *testing.T) {
+	m := NewManager(toolrun.StaticWorkDir(t.TempDir()), config.TestDatabasesConfig{IdleTimeout: time.Hour})
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+	old, err := m.Create(ctx, "web", "b1", "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := m.Create(ctx, "web", "b2", "sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Shutdown()
+	if _, err := m.Get("b1"); !errors.Is(err, ErrNoDatabase) {
+		t.Errorf("idle database was kept: %v", err)
+	}
+	if _, err := os.Stat(old.Path); !os.IsNotExist(err) {
+		t.Errorf("idle database file was not removed: %v", err)
+	}
+}
+
+func TestSection(t *testing.T) {
+	goose := "-- +goose Up\nCREATE TABLE t (id int);\n-- +goose Down\nDROP TABLE t;\n"
+	if s, ok := section("1.sql", goose, false); !ok || strings.Contains(s, "DROP") {
+		t.Errorf("up section = %q, %v", s, ok)
+	}
+	if s, ok := section("1.sql", goose, true); !ok || !strings.Contains(s, "DROP") || strings.Contains(s, "CREATE") {
+		t.Errorf("down section = %q, %v", s, ok)
+	}
+	if _, ok := section("1.sql", "CREATE TABLE t (id int);", true); ok {
+		t.Error("a plain file has no down migration")
+	}
+	if _, ok := section("1.down.sql", "DROP TABLE t;", true); !ok {
+		t.Error("a .down.sql file is a down migration")
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
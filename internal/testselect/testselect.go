@@ -0,0 +1,287 @@
+// Package testselect picks the tests worth running for a change. It maps
+// the changed files to Go test packages through the import graph, to jest
+// --findRelatedTests for JavaScript and TypeScript, and to the pytest files
+// covering the changed Python modules. Every so often it asks for the full
+// suite instead, and as a workflow gate it holds beads whose last passing
+// run covered only the selected tests.
+package testselect
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/impact"
+	"github.com/jordanhubbard/loom/internal/workflow"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Test modes
+const (
+	ModeSmart = "smart"
+	ModeFull  = "full"
+)
+
+// Frameworks the selector builds commands for
+const (
+	Go     = "go"
+	Jest   = "jest"
+	Pytest = "pytest"
+)
+
+const (
+	defaultFullEvery    = 10
+	defaultFullInterval = 24 * time.Hour
+)
+
+// manifests change how the whole project builds or collects its tests, so
+// changing one runs the full suite
+var manifests = map[string]bool{
+	"go.mod": true, "go.sum": true, "go.work": true,
+	"package.json": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true, "tsconfig.json": true,
+	"pyproject.toml": true, "setup.py": true, "setup.cfg": true, "requirements.txt": true, "pytest.ini": true, "tox.ini": true, "conftest.py": true,
+	"Makefile": true,
+}
+
+// Analyzer estimates which tests cover a change
+type Analyzer interface {
+	Analyze(ctx context.Context, projectID string, req impact.Request) (*impact.Report, error)
+}
+
+// Selection is the tests chosen for a change
+type Selection struct {
+	Mode string `json:"mode"`
+	// Reason says why the full suite runs instead of selected tests
+	Reason string `json:"reason,omitempty"`
+	// Command runs the selected tests from the checkout root; empty in full
+	// mode, or when no tests cover the change
+	Command      string   `json:"command,omitempty"`
+	Targets      []string `json:"targets"`
+	ChangedFiles []string `json:"changed_files,omitempty"`
+}
+
+// Selector chooses tests for changes and tracks when each project last ran
+// its full suite
+type Selector struct {
+	analyzer     Analyzer
+	fullEvery    int
+	fullInterval time.Duration
+	requireFull  bool
+
+	mu       sync.Mutex
+	seq      int
+	projects map[string]*projectRuns
+	beads    map[string]*beadRuns
+
+	now func() time.Time
+}
+
+// projectRuns counts a project's smart runs since its last full one
+type projectRuns struct {
+	periodStart time.Time
+	smartRuns   int
+}
+
+// beadRuns orders a bead's last smart run and last passing full run
+type beadRuns struct {
+	smart, fullPassed int
+}
+
+// NewSelector creates a selector, or returns nil when test selection is
+// disabled in cfg
+func NewSelector(analyzer Analyzer, cfg config.TestSelectionConfig) *Selector {
+	if cfg.Disabled || analyzer == nil {
+		return nil
+	}
+	s := &Selector{
+		analyzer:     analyzer,
+		fullEvery:    cfg.FullEvery,
+		fullInterval: cfg.FullInterval,
+		requireFull:  cfg.RequireFull,
+		projects:     make(map[string]*projectRuns),
+		beads:        make(map[string]*beadRuns),
+		now:          time.Now,
+	}
+	if s.fullEvery <= 0 {
+		s.fullEvery = defaultFullEvery
+	}
+	if s.fullInterval <= 0 {
+		s.fullInterval = defaultFullInterval
+	}
+	return s
+}
+
+// Select picks the tests covering files, or the changes since HEAD when
+// files is empty. framework limits the command to one of go, jest or
+// pytest. The full suite is chosen when a safety run is due, when the
+// change can't be analyzed or when it touches a build manifest.
+func (s *Selector) Select(ctx context.Context, projectID string, files []string, framework string) *Selection {
+	framework = strings.ToLower(strings.TrimSpace(framework))
+	switch framework {
+	case "", Go, Jest, Pytest:
+	default:
+		return &Selection{Mode: ModeFull, Reason: fmt.Sprintf("tests can't be selected for framework %s", framework), Targets: []string{}}
+	}
+	if reason := s.fullDue(projectID); reason != "" {
+		return &Selection{Mode: ModeFull, Reason: reason, Targets: []string{}}
+	}
+
+	report, err := s.analyzer.Analyze(ctx, projectID, impact.Request{Files: files})
+	if err != nil {
+		return &Selection{Mode: ModeFull, Reason: "the change could not be analyzed: " + err.Error(), Targets: []string{}}
+	}
+	sel := &Selection{Mode: ModeSmart, Targets: []string{}, ChangedFiles: report.ChangedFiles}
+	if len(report.ChangedFiles) == 0 {
+		sel.Mode, sel.Reason = ModeFull, "no changed files to select tests for"
+		return sel
+	}
+	for _, f := range report.ChangedFiles {
+		if manifests[path.Base(f)] || strings.HasPrefix(path.Base(f), "jest.config.") {
+			sel.Mode, sel.Reason = ModeFull, f+" affects the whole project"
+			return sel
+		}
+	}
+
+	var commands []string
+	if framework == "" || framework == Go {
+		var pkgs []string
+		for _, t := range report.Tests {
+			if t == "." || strings.HasPrefix(t, "./") {
+				pkgs = append(pkgs, t)
+			}
+		}
+		if len(pkgs) > 0 {
+			sel.Targets = append(sel.Targets, pkgs...)
+			commands = append(commands, "go test "+quoteAll(pkgs))
+		}
+	}
+	if framework == "" || framework == Jest {
+		var sources []string
+		for _, f := range report.ChangedFiles {
+			if isJavaScript(f) {
+				sources = append(sources, f)
+			}
+		}
+		if len(sources) > 0 {
+			sel.Targets = append(sel.Targets, sources...)
+			commands = append(commands, "npx jest --findRelatedTests "+quoteAll(sources))
+		}
+	}
+	if framework == "" || framework == Pytest {
+		var tests []string
+		for _, t := range report.Tests {
+			if strings.HasSuffix(t, ".py") {
+				tests = append(tests, t)
+			}
+		}
+		if len(tests) > 0 {
+			sel.Targets = append(sel.Targets, tests...)
+			commands = append(commands, "python -m pytest "+quoteAll(tests))
+		}
+	}
+	sel.Command = strings.Join(commands, " && ")
+	return sel
+}
+
+// fullDue returns why the project's next run should be the full suite, or
+// "" when selected tests will do. The first selection starts the count.
+func (s *Selector) fullDue(projectID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.project(projectID)
+	if p.smartRuns >= s.fullEvery {
+		return fmt.Sprintf("the full suite runs after every %d selected runs", s.fullEvery)
+	}
+	if p.smartRuns > 0 && s.now().Sub(p.periodStart) >= s.fullInterval {
+		return fmt.Sprintf("the full suite hasn't run for %s", s.fullInterval)
+	}
+	return ""
+}
+
+// Record notes a test run of the project for bead. A full run, passing or
+// not, starts a new safety period; a passing one clears the bead's gate.
+func (s *Selector) Record(projectID, beadID, mode string, success bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	p := s.project(projectID)
+	var b *beadRuns
+	if beadID != "" {
+		if b = s.beads[beadID]; b == nil {
+			b = &beadRuns{}
+			s.beads[beadID] = b
+		}
+	}
+	if mode == ModeSmart {
+		p.smartRuns++
+		if b != nil {
+			b.smart = s.seq
+		}
+		return
+	}
+	p.periodStart, p.smartRuns = s.now(), 0
+	if b != nil && success {
+		b.fullPassed = s.seq
+	}
+}
+
+func (s *Selector) project(projectID string) *projectRuns {
+	p := s.projects[projectID]
+	if p == nil {
+		p = &projectRuns{periodStart: s.now()}
+		s.projects[projectID] = p
+	}
+	return p
+}
+
+// CheckGate implements workflow.Gate. With require_full it holds a bead at
+// commit nodes, or at any node whose full_test_gate metadata is "true",
+// while its last smart run came after its last passing full run; "false"
+// turns the gate off.
+func (s *Selector) CheckGate(exec *workflow.WorkflowExecution, node *workflow.WorkflowNode) string {
+	switch node.Metadata["full_test_gate"] {
+	case "true":
+	case "false":
+		return ""
+	default:
+		if !s.requireFull || node.NodeType != workflow.NodeTypeCommit {
+			return ""
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.beads[exec.BeadID]
+	if b == nil || b.smart <= b.fullPassed {
+		return ""
+	}
+	return "test gate: run_tests last ran only the tests selected for the change; run run_tests with test_mode full and make it pass"
+}
+
+// isJavaScript reports whether jest can relate tests to a file
+func isJavaScript(f string) bool {
+	switch path.Ext(f) {
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts":
+		return true
+	}
+	return false
+}
+
+// quoteAll joins paths into shell arguments, quoting those that need it
+func quoteAll(args []string) string {
+	sorted := append([]string(nil), args...)
+	sort.Strings(sorted)
+	quoted := make([]string, len(sorted))
+	for i, a := range sorted {
+		if strings.ContainsFunc(a, func(r rune) bool {
+			return !(r == '/' || r == '.' || r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+		}) {
+			a = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+		quoted[i] = a
+	}
+	return strings.Join(quoted, " ")
+}
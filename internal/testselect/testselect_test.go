@@ -0,0 +1,115 @@
+package testselect
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/impact"
+	"github.com/jordanhubbard/loom/internal/workflow"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+type fakeAnalyzer struct {
+	report *impact.Report
+	err    error
+}
+
+func (f *fakeAnalyzer) Analyze(context.Context, string, impact.Request) (*impact.Report, error) {
+	return f.report, f.err
+}
+
+func TestSelect(t *testing.T) {
+	analyzer := &fakeAnalyzer{report: &impact.Report{
+		ChangedFiles: []string{"src/my parser.ts", "store/store.go", "tools/sync.py"},
+		Tests:        []string{"./api", "./store", "src/parser.test.ts", "tests/test_sync.py"},
+	}}
+	s := NewSelector(analyzer, config.TestSelectionConfig{})
+
+	sel := s.Select(context.Background(), "p", nil, "")
+	want := "go test ./api ./store && npx jest --findRelatedTests 'src/my parser.ts' && python -m pytest tests/test_sync.py"
+	if sel.Mode != ModeSmart || sel.Command != want {
+		t.Fatalf("got %s %q, want %q", sel.Mode, sel.Command, want)
+	}
+	if sel = s.Select(context.Background(), "p", nil, "pytest"); sel.Command != "python -m pytest tests/test_sync.py" {
+		t.Errorf("expected only pytest, got %q", sel.Command)
+	}
+	if sel = s.Select(context.Background(), "p", nil, "npm"); sel.Mode != ModeFull {
+		t.Errorf("expected the full suite for npm, got %s", sel.Mode)
+	}
+
+	analyzer.report = &impact.Report{ChangedFiles: []string{"go.mod", "store/store.go"}, Tests: []string{"./store"}}
+	if sel = s.Select(context.Background(), "p", nil, ""); sel.Mode != ModeFull || !strings.Contains(sel.Reason, "go.mod") {
+		t.Errorf("expected a manifest change to run the full suite, got %s: %s", sel.Mode, sel.Reason)
+	}
+	analyzer.err = errors.New("no checkout")
+	if sel = s.Select(context.Background(), "p", nil, ""); sel.Mode != ModeFull {
+		t.Errorf("expected the full suite when analysis fails, got %s", sel.Mode)
+	}
+}
+
+func TestSafetyRuns(t *testing.T) {
+	analyzer := &fakeAnalyzer{report: &impact.Report{ChangedFiles: []string{"store/store.go"}, Tests: []string{"./store"}}}
+	s := NewSelector(analyzer, config.TestSelectionConfig{FullEvery: 2, FullInterval: time.Hour})
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if sel := s.Select(context.Background(), "p", nil, ""); sel.Mode != ModeSmart {
+			t.Fatalf("run %d: expected smart, got %s", i, sel.Mode)
+		}
+		s.Record("p", "b", ModeSmart, true)
+	}
+	if sel := s.Select(context.Background(), "p", nil, ""); sel.Mode != ModeFull {
+		t.Fatalf("expected a safety run after 2 smart runs, got %s", sel.Mode)
+	}
+	s.Record("p", "b", ModeFull, false)
+	if sel := s.Select(context.Background(), "p", nil, ""); sel.Mode != ModeSmart {
+		t.Fatalf("expected a full run to restart the count, got %s", sel.Mode)
+	}
+
+	s.Record("p", "b", ModeSmart, true)
+	now = now.Add(2 * time.Hour)
+	if sel := s.Select(context.Background(), "p", nil, ""); sel.Mode != ModeFull || !strings.Contains(sel.Reason, "1h0m0s") {
+		t.Fatalf("expected a safety run after the interval, got %s: %s", sel.Mode, sel.Reason)
+	}
+	if sel := s.Select(context.Background(), "other", nil, ""); sel.Mode != ModeSmart {
+		t.Errorf("projects are counted separately, got %s", sel.Mode)
+	}
+}
+
+func TestCheckGate(t *testing.T) {
+	s := NewSelector(&fakeAnalyzer{}, config.TestSelectionConfig{RequireFull: true})
+	exec := &workflow.WorkflowExecution{BeadID: "b"}
+	commit := &workflow.WorkflowNode{NodeType: workflow.NodeTypeCommit}
+	verify := &workflow.WorkflowNode{NodeType: workflow.NodeTypeVerify}
+
+	if reason := s.CheckGate(exec, commit); reason != "" {
+		t.Fatalf("a bead without runs passes, got %q", reason)
+	}
+	s.Record("p", "b", ModeSmart, true)
+	if reason := s.CheckGate(exec, commit); !strings.Contains(reason, "test_mode full") {
+		t.Fatalf("expected a hold after a smart run, got %q", reason)
+	}
+	if reason := s.CheckGate(exec, verify); reason != "" {
+		t.Errorf("verify nodes aren't gated by default, got %q", reason)
+	}
+	verify.Metadata = map[string]string{"full_test_gate": "true"}
+	if reason := s.CheckGate(exec, verify); reason == "" {
+		t.Error("expected full_test_gate to gate the node")
+	}
+	s.Record("p", "b", ModeFull, false)
+	if reason := s.CheckGate(exec, commit); reason == "" {
+		t.Error("a failing full run doesn't clear the gate")
+	}
+	s.Record("p", "b", ModeFull, true)
+	if reason := s.CheckGate(exec, commit); reason != "" {
+		t.Errorf("expected a passing full run to clear the gate, got %q", reason)
+	}
+
+	if NewSelector(&fakeAnalyzer{}, config.TestSelectionConfig{Disabled: true}) != nil {
+		t.Error("expected no selector when disabled")
+	}
+}
@@ -0,0 +1,41 @@
+package throttle
+
+import "github.com/jordanhubbard/loom/pkg/config"
+
+// Defaults for settings left empty in the API throttle config
+const (
+	DefaultRequestsPerMinute = 600
+	DefaultBurst             = 100
+)
+
+// WithDefaults fills in unset API throttle settings
+func WithDefaults(cfg config.APIThrottleConfig) config.APIThrottleConfig {
+	if cfg.RequestsPerMinute == 0 {
+		cfg.RequestsPerMinute = DefaultRequestsPerMinute
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = DefaultBurst
+	}
+	return cfg
+}
+
+// PolicyFrom converts the API throttle config; a role without a rate or
+// burst gets the default one
+func PolicyFrom(cfg config.APIThrottleConfig) Policy {
+	p := Policy{
+		Default: Limit{PerMinute: cfg.RequestsPerMinute, Burst: cfg.Burst},
+		Roles:   make(map[string]Limit, len(cfg.Roles)),
+	}
+	for role, l := range cfg.Roles {
+		perMinute := l.RequestsPerMinute
+		if perMinute == 0 {
+			perMinute = cfg.RequestsPerMinute
+		}
+		burst := l.Burst
+		if burst <= 0 {
+			burst = cfg.Burst
+		}
+		p.Roles[role] = Limit{PerMinute: perMinute, Burst: burst}
+	}
+	return p
+}
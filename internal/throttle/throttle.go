@@ -0,0 +1,144 @@
+// Package throttle limits request rates per caller with token buckets.
+package throttle
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// idleSweep is how often buckets left full by idle callers are dropped
+const idleSweep = 5 * time.Minute
+
+// Limit is a sustained request rate with a burst allowance
+type Limit struct {
+	PerMinute int // Negative is unlimited
+	Burst     int
+}
+
+// Unlimited reports whether the limit lets every request through
+func (l Limit) Unlimited() bool {
+	return l.PerMinute < 0
+}
+
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return 1
+}
+
+// perSecond is the refill rate
+func (l Limit) perSecond() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// Policy picks the limit for a caller's role
+type Policy struct {
+	Default Limit
+	Roles   map[string]Limit
+}
+
+// For returns the limit of role, or the default
+func (p Policy) For(role string) Limit {
+	if l, ok := p.Roles[role]; ok {
+		return l
+	}
+	return p.Default
+}
+
+// Decision is the outcome of one request against its bucket
+type Decision struct {
+	Allowed    bool
+	Limit      int           // Requests per minute
+	Remaining  int           // Requests that could be made right now
+	RetryAfter time.Duration // Until the next request is allowed, when refused
+	Reset      time.Duration // Until the bucket is full again
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter keeps a token bucket per key. A bucket starts full, spends a
+// token per request and refills continuously; a request finding it empty
+// is refused.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewLimiter creates an empty limiter
+func NewLimiter() *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow spends a token of key's bucket under limit
+func (l *Limiter) Allow(key string, limit Limit) Decision {
+	if limit.Unlimited() {
+		return Decision{Allowed: true, Limit: -1, Remaining: -1}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	capacity, rate := limit.capacity(), limit.perSecond()
+	if l.lastSweep.IsZero() {
+		l.lastSweep = now
+	} else if now.Sub(l.lastSweep) >= idleSweep {
+		l.sweep(now, rate, capacity)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+		b.last = now
+	}
+
+	d := Decision{Limit: limit.PerMinute}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = secondsToDuration((1-b.tokens)/rate, rate)
+	}
+	d.Remaining = int(b.tokens)
+	d.Reset = secondsToDuration((capacity-b.tokens)/rate, rate)
+	return d
+}
+
+// sweep drops buckets that have refilled since their last request, which
+// behave the same as a missing bucket. Buckets are assumed to share the
+// current limit, which at worst drops a bucket a little early.
+func (l *Limiter) sweep(now time.Time, rate, capacity float64) {
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*rate >= capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Size returns how many callers have a bucket
+func (l *Limiter) Size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+func secondsToDuration(seconds, rate float64) time.Duration {
+	if rate <= 0 {
+		// A zero rate never refills
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
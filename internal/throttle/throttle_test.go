@@ -0,0 +1,73 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+func TestLimiterAllowsBurstThenRefills(t *testing.T) {
+	l := NewLimiter()
+	now := time.Unix(1_700_000_000, 0)
+	l.now = func() time.Time { return now }
+	limit := Limit{PerMinute: 60, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if d := l.Allow("a", limit); !d.Allowed || d.Remaining != 2-i {
+			t.Fatalf("request %d: %+v", i, d)
+		}
+	}
+	d := l.Allow("a", limit)
+	if d.Allowed || d.RetryAfter != time.Second || d.Reset != 3*time.Second {
+		t.Fatalf("expected refusal for 1s, got %+v", d)
+	}
+	if d := l.Allow("b", limit); !d.Allowed {
+		t.Error("expected other callers to have their own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if d := l.Allow("a", limit); !d.Allowed {
+		t.Errorf("expected a token after 1s, got %+v", d)
+	}
+
+	now = now.Add(idleSweep)
+	l.Allow("c", limit)
+	if size := l.Size(); size != 1 {
+		t.Errorf("expected refilled buckets to be swept, %d left", size)
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	l := NewLimiter()
+	for i := 0; i < 10; i++ {
+		if d := l.Allow("a", Limit{PerMinute: -1}); !d.Allowed {
+			t.Fatal("expected unlimited requests to be allowed")
+		}
+	}
+	if l.Size() != 0 {
+		t.Error("expected no bucket for an unlimited caller")
+	}
+}
+
+func TestPolicyFrom(t *testing.T) {
+	p := PolicyFrom(WithDefaults(config.APIThrottleConfig{
+		Roles: map[string]config.APIThrottleLimit{
+			"admin":   {RequestsPerMinute: -1},
+			"service": {RequestsPerMinute: 6000, Burst: 500},
+			"viewer":  {Burst: 10},
+		},
+	}))
+	if p.For("user") != (Limit{PerMinute: DefaultRequestsPerMinute, Burst: DefaultBurst}) {
+		t.Errorf("unexpected default limit %+v", p.For("user"))
+	}
+	if !p.For("admin").Unlimited() {
+		t.Error("expected admin to be unlimited")
+	}
+	if p.For("service") != (Limit{PerMinute: 6000, Burst: 500}) {
+		t.Errorf("unexpected service limit %+v", p.For("service"))
+	}
+	if p.For("viewer") != (Limit{PerMinute: DefaultRequestsPerMinute, Burst: 10}) {
+		t.Errorf("unexpected viewer limit %+v", p.For("viewer"))
+	}
+}
@@ -0,0 +1,191 @@
+// Package tlsserver provides the TLS configuration of the HTTPS listener:
+// a certificate reloaded from disk when it is rotated, and optional client
+// certificate verification for mutual TLS.
+package tlsserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+var tlsLog = logging.Module("tls")
+
+// DefaultReloadInterval is how often certificate files are checked for
+// rotation when the config doesn't say
+const DefaultReloadInterval = time.Minute
+
+// Client authentication modes
+const (
+	ClientAuthRequest = "request" // verify a client certificate if one is sent
+	ClientAuthRequire = "require" // refuse connections without a valid client certificate
+)
+
+// Options locates the server certificate and, for mutual TLS, the CA bundle
+// client certificates must chain to
+type Options struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string // Empty disables client certificates
+	ClientAuth   string // ClientAuthRequest or ClientAuthRequire (default)
+}
+
+// OptionsFrom reads the TLS options from the server and security config.
+// Client certificates are verified against security.ca_file when PKI is
+// enabled.
+func OptionsFrom(server config.ServerConfig, security config.SecurityConfig) Options {
+	opts := Options{
+		CertFile:   server.TLSCertFile,
+		KeyFile:    server.TLSKeyFile,
+		ClientAuth: server.TLSClientAuth,
+	}
+	if security.PKIEnabled {
+		opts.ClientCAFile = security.CAFile
+	}
+	return opts
+}
+
+// Reloader serves the current certificate and client CA pool, re-reading
+// them when their files change. A reload that fails keeps the previous
+// files in use, so a half-written rotation never takes the listener down.
+type Reloader struct {
+	opts       Options
+	clientAuth tls.ClientAuthType
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+}
+
+// NewReloader loads the certificate (and client CAs) for the first time
+func NewReloader(opts Options) (*Reloader, error) {
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, fmt.Errorf("tls_cert_file and tls_key_file are required for HTTPS")
+	}
+	r := &Reloader{opts: opts, clientAuth: tls.NoClientCert}
+	if opts.ClientCAFile != "" {
+		switch opts.ClientAuth {
+		case ClientAuthRequest:
+			r.clientAuth = tls.VerifyClientCertIfGiven
+		case "", ClientAuthRequire:
+			r.clientAuth = tls.RequireAndVerifyClientCert
+		default:
+			return nil, fmt.Errorf("unknown tls_client_auth %q (want %q or %q)", opts.ClientAuth, ClientAuthRequest, ClientAuthRequire)
+		}
+	}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// files returns the files the reloader reads
+func (r *Reloader) files() []string {
+	files := []string{r.opts.CertFile, r.opts.KeyFile}
+	if r.opts.ClientCAFile != "" {
+		files = append(files, r.opts.ClientCAFile)
+	}
+	return files
+}
+
+// Reload re-reads the files if any changed since the last load, and
+// reports whether it did
+func (r *Reloader) Reload() (bool, error) {
+	modTimes := make(map[string]time.Time)
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return false, fmt.Errorf("tls: %w", err)
+		}
+		modTimes[f] = info.ModTime()
+	}
+
+	r.mu.RLock()
+	changed := r.cert == nil
+	for f, t := range modTimes {
+		if !r.modTimes[f].Equal(t) {
+			changed = true
+		}
+	}
+	r.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return false, fmt.Errorf("tls: load certificate: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return false, fmt.Errorf("tls: read client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return false, fmt.Errorf("tls: no certificates in client CA file %s", r.opts.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = pool
+	r.modTimes = modTimes
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Watch checks the files every interval until ctx is cancelled
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if reloaded, err := r.Reload(); err != nil {
+				tlsLog.Error("Reload failed, keeping the current certificate", "error", err)
+			} else if reloaded {
+				tlsLog.Info("Reloaded certificate", "cert_file", r.opts.CertFile)
+			}
+		}
+	}
+}
+
+// GetCertificate returns the latest certificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// TLSConfig returns a server config that always presents the latest
+// certificate and verifies clients against the latest CA pool
+func (r *Reloader) TLSConfig() *tls.Config {
+	base := &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: r.GetCertificate}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		return &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{*r.cert},
+			ClientAuth:   r.clientAuth,
+			ClientCAs:    r.clientCAs,
+			NextProtos:   []string{"h2", "http/1.1"},
+		}, nil
+	}
+	return base
+}
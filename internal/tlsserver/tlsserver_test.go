@@ -0,0 +1,169 @@
+package tlsserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/auth"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func issue(t *testing.T, cn string, serial int64, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, c.pem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestMutualTLSIdentity(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, "test-ca", 1, nil, true)
+	certFile, keyFile, caFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.crt")
+	issue(t, "loom", 2, ca, false).write(t, certFile, keyFile)
+	if err := os.WriteFile(caFile, ca.pem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReloader(Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(w, auth.ClientCertIdentity(req))
+	}))
+	srv.TLS = r.TLSConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+	}
+
+	resp, err := client(issue(t, "agent-7", 3, ca, false).tlsCertificate()).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "agent-7" {
+		t.Errorf("expected identity agent-7, got %q", body)
+	}
+
+	if _, err := client().Get(srv.URL); err == nil {
+		t.Error("expected a connection without a client certificate to be refused")
+	}
+	stranger := issue(t, "other-ca", 4, nil, true)
+	if _, err := client(issue(t, "intruder", 5, stranger, false).tlsCertificate()).Get(srv.URL); err == nil {
+		t.Error("expected a certificate from another CA to be refused")
+	}
+}
+
+func TestReloaderPicksUpRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	issue(t, "old", 10, nil, false).write(t, certFile, keyFile)
+
+	r, err := NewReloader(Options{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := r.Reload(); err != nil || reloaded {
+		t.Fatalf("expected no reload without changes, got %v %v", reloaded, err)
+	}
+
+	// A half-written rotation keeps the current certificate
+	later := time.Now().Add(time.Minute)
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chtimes(certFile, later, later)
+	if _, err := r.Reload(); err == nil {
+		t.Error("expected a broken certificate to fail to load")
+	}
+	if cert, _ := r.GetCertificate(nil); cert == nil {
+		t.Fatal("expected the old certificate to stay in use")
+	}
+
+	issue(t, "new", 11, nil, false).write(t, certFile, keyFile)
+	later = later.Add(time.Minute)
+	_ = os.Chtimes(certFile, later, later)
+	_ = os.Chtimes(keyFile, later, later)
+	if reloaded, err := r.Reload(); err != nil || !reloaded {
+		t.Fatalf("expected the rotated certificate to load, got %v %v", reloaded, err)
+	}
+	cert, _ := r.GetCertificate(nil)
+	leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+	if leaf.Subject.CommonName != "new" {
+		t.Errorf("expected the new certificate, got %s", leaf.Subject.CommonName)
+	}
+}
+
+func TestNewReloaderRejectsBadOptions(t *testing.T) {
+	if _, err := NewReloader(Options{}); err == nil {
+		t.Error("expected missing files to be rejected")
+	}
+	if _, err := NewReloader(Options{CertFile: "a", KeyFile: "b", ClientCAFile: "c", ClientAuth: "maybe"}); err == nil {
+		t.Error("expected an unknown client auth mode to be rejected")
+	}
+}
@@ -0,0 +1,259 @@
+// Package toolchain detects a project's language and the commands used to
+// build, test, lint and format it from the marker files in its workdir.
+package toolchain
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Profile describes a workdir's toolchain. Empty commands mean nothing
+// suitable was detected.
+type Profile struct {
+	Language       string `json:"language,omitempty"`
+	BuildSystem    string `json:"build_system,omitempty"`
+	InstallCommand string `json:"install_command,omitempty"`
+	BuildCommand   string `json:"build_command,omitempty"`
+	TestCommand    string `json:"test_command,omitempty"`
+	LintCommand    string `json:"lint_command,omitempty"`
+	FormatCommand  string `json:"format_command,omitempty"`
+}
+
+// Command kinds accepted by Profile.Command
+const (
+	Build  = "build"
+	Test   = "test"
+	Lint   = "lint"
+	Format = "format"
+)
+
+// Command returns the profile's command of the given kind
+func (p Profile) Command(kind string) string {
+	switch kind {
+	case Build:
+		return p.BuildCommand
+	case Test:
+		return p.TestCommand
+	case Lint:
+		return p.LintCommand
+	case Format:
+		return p.FormatCommand
+	}
+	return ""
+}
+
+// Empty reports whether nothing was detected
+func (p Profile) Empty() bool {
+	return p == Profile{}
+}
+
+// makeTarget matches a Makefile rule name at the start of a line
+var makeTarget = regexp.MustCompile(`^([A-Za-z0-9_.-]+)\s*:([^=]|$)`)
+
+// Detect inspects marker files in workDir. The first language manifest wins,
+// so polyglot repositories report the stack of their most specific manifest;
+// Makefile targets fill in any commands the language defaults leave empty.
+func Detect(workDir string) Profile {
+	d := detector{dir: workDir}
+	p := d.language()
+	if d.has("Makefile") {
+		if p.BuildSystem == "" {
+			p.BuildSystem = "make"
+		}
+		targets := d.makeTargets()
+		for kind, names := range map[string][]string{
+			Build:  {"build", "all"},
+			Test:   {"test", "check"},
+			Lint:   {"lint", "vet"},
+			Format: {"fmt", "format"},
+		} {
+			if p.Command(kind) != "" {
+				continue
+			}
+			for _, name := range names {
+				if targets[name] {
+					p.set(kind, "make "+name)
+					break
+				}
+			}
+		}
+	}
+	return p
+}
+
+func (p *Profile) set(kind, command string) {
+	switch kind {
+	case Build:
+		p.BuildCommand = command
+	case Test:
+		p.TestCommand = command
+	case Lint:
+		p.LintCommand = command
+	case Format:
+		p.FormatCommand = command
+	}
+}
+
+type detector struct {
+	dir string
+}
+
+func (d detector) has(name string) bool {
+	_, err := os.Stat(filepath.Join(d.dir, name))
+	return err == nil
+}
+
+func (d detector) read(name string) string {
+	data, err := os.ReadFile(filepath.Join(d.dir, name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func (d detector) language() Profile {
+	switch {
+	case d.has("go.mod"):
+		lint := "go vet ./..."
+		if d.has(".golangci.yml") || d.has(".golangci.yaml") || d.has(".golangci.toml") {
+			lint = "golangci-lint run ./..."
+		}
+		return Profile{
+			Language:       "go",
+			BuildSystem:    "go",
+			InstallCommand: "go mod download",
+			BuildCommand:   "go build ./...",
+			TestCommand:    "go test ./...",
+			LintCommand:    lint,
+			FormatCommand:  "gofmt -w .",
+		}
+	case d.has("Cargo.toml"):
+		return Profile{
+			Language:       "rust",
+			BuildSystem:    "cargo",
+			InstallCommand: "cargo fetch",
+			BuildCommand:   "cargo build",
+			TestCommand:    "cargo test",
+			LintCommand:    "cargo clippy",
+			FormatCommand:  "cargo fmt",
+		}
+	case d.has("package.json"):
+		return d.node()
+	case d.has("pyproject.toml") || d.has("requirements.txt"):
+		return d.python()
+	case d.has("pom.xml"):
+		return Profile{
+			Language:       "java",
+			BuildSystem:    "maven",
+			InstallCommand: "mvn -B dependency:resolve",
+			BuildCommand:   "mvn -B package -DskipTests",
+			TestCommand:    "mvn -B test",
+		}
+	case d.has("build.gradle") || d.has("build.gradle.kts"):
+		gradle := "gradle"
+		if d.has("gradlew") {
+			gradle = "./gradlew"
+		}
+		return Profile{
+			Language:       "java",
+			BuildSystem:    "gradle",
+			InstallCommand: gradle + " dependencies",
+			BuildCommand:   gradle + " build -x test",
+			TestCommand:    gradle + " test",
+		}
+	case d.has("Gemfile"):
+		p := Profile{Language: "ruby", BuildSystem: "bundler", InstallCommand: "bundle install", TestCommand: "bundle exec rake test"}
+		if d.has(".rubocop.yml") {
+			p.LintCommand = "bundle exec rubocop"
+			p.FormatCommand = "bundle exec rubocop -a"
+		}
+		return p
+	}
+	return Profile{}
+}
+
+func (d detector) node() Profile {
+	language := "javascript"
+	if d.has("tsconfig.json") {
+		language = "typescript"
+	}
+	p := Profile{Language: language}
+	run := "npm run "
+	switch {
+	case d.has("pnpm-lock.yaml"):
+		p.BuildSystem, p.InstallCommand, p.TestCommand, run = "pnpm", "pnpm install --frozen-lockfile", "pnpm test", "pnpm run "
+	case d.has("yarn.lock"):
+		p.BuildSystem, p.InstallCommand, p.TestCommand, run = "yarn", "yarn install --frozen-lockfile", "yarn test", "yarn run "
+	case d.has("package-lock.json"):
+		p.BuildSystem, p.InstallCommand, p.TestCommand = "npm", "npm ci", "npm test"
+	default:
+		p.BuildSystem, p.InstallCommand, p.TestCommand = "npm", "npm install", "npm test"
+	}
+
+	var pkg struct {
+		Scripts         map[string]string `json:"scripts"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	_ = json.Unmarshal([]byte(d.read("package.json")), &pkg)
+	if _, ok := pkg.Scripts["build"]; ok {
+		p.BuildCommand = run + "build"
+	}
+	if _, ok := pkg.Scripts["lint"]; ok {
+		p.LintCommand = run + "lint"
+	} else if _, ok := pkg.DevDependencies["eslint"]; ok {
+		p.LintCommand = "npx eslint ."
+	}
+	for _, script := range []string{"format", "fmt"} {
+		if _, ok := pkg.Scripts[script]; ok {
+			p.FormatCommand = run + script
+			break
+		}
+	}
+	if _, ok := pkg.DevDependencies["prettier"]; ok && p.FormatCommand == "" {
+		p.FormatCommand = "npx prettier --write ."
+	}
+	return p
+}
+
+func (d detector) python() Profile {
+	pyproject := d.read("pyproject.toml")
+	prefix := ""
+	p := Profile{Language: "python", BuildSystem: "pip"}
+	switch {
+	case pyproject != "" && d.has("poetry.lock"):
+		p.BuildSystem, p.InstallCommand, p.BuildCommand = "poetry", "poetry install", "poetry build"
+		prefix = "poetry run "
+	case pyproject != "":
+		p.InstallCommand, p.BuildCommand = "pip install -e .", "python -m build"
+	default:
+		p.InstallCommand = "pip install -r requirements.txt"
+	}
+	p.TestCommand = prefix + "pytest"
+
+	switch {
+	case strings.Contains(pyproject, "[tool.ruff") || d.has("ruff.toml") || d.has(".ruff.toml"):
+		p.LintCommand = prefix + "ruff check ."
+		p.FormatCommand = prefix + "ruff format ."
+	case d.has(".flake8") || strings.Contains(d.read("setup.cfg"), "[flake8]"):
+		p.LintCommand = prefix + "flake8"
+	}
+	if strings.Contains(pyproject, "[tool.black") {
+		p.FormatCommand = prefix + "black ."
+	}
+	return p
+}
+
+func (d detector) makeTargets() map[string]bool {
+	targets := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(d.read("Makefile")))
+	for scanner.Scan() {
+		if m := makeTarget.FindStringSubmatch(scanner.Text()); m != nil {
+			targets[m[1]] = true
+		}
+	}
+	return targets
+}
@@ -0,0 +1,88 @@
+package toolchain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  Profile
+	}{
+		{
+			name:  "go with golangci config",
+			files: map[string]string{"go.mod": "module x", ".golangci.yml": ""},
+			want: Profile{Language: "go", BuildSystem: "go", InstallCommand: "go mod download", BuildCommand: "go build ./...",
+				TestCommand: "go test ./...", LintCommand: "golangci-lint run ./...", FormatCommand: "gofmt -w ."},
+		},
+		{
+			name: "typescript with yarn scripts",
+			files: map[string]string{
+				"package.json":  `{"scripts":{"build":"tsc","lint":"eslint src"},"devDependencies":{"prettier":"^3"}}`,
+				"tsconfig.json": "{}",
+				"yarn.lock":     "",
+			},
+			want: Profile{Language: "typescript", BuildSystem: "yarn", InstallCommand: "yarn install --frozen-lockfile", BuildCommand: "yarn run build",
+				TestCommand: "yarn test", LintCommand: "yarn run lint", FormatCommand: "npx prettier --write ."},
+		},
+		{
+			name:  "poetry with ruff",
+			files: map[string]string{"pyproject.toml": "[tool.ruff]\nline-length = 100\n", "poetry.lock": ""},
+			want: Profile{Language: "python", BuildSystem: "poetry", InstallCommand: "poetry install", BuildCommand: "poetry build",
+				TestCommand: "poetry run pytest", LintCommand: "poetry run ruff check .", FormatCommand: "poetry run ruff format ."},
+		},
+		{
+			name:  "rust",
+			files: map[string]string{"Cargo.toml": ""},
+			want: Profile{Language: "rust", BuildSystem: "cargo", InstallCommand: "cargo fetch", BuildCommand: "cargo build",
+				TestCommand: "cargo test", LintCommand: "cargo clippy", FormatCommand: "cargo fmt"},
+		},
+		{
+			name:  "maven gaps filled from Makefile",
+			files: map[string]string{"pom.xml": "", "Makefile": "lint:\n\tmvn checkstyle:check\nfmt:\n\tmvn spotless:apply\n"},
+			want: Profile{Language: "java", BuildSystem: "maven", InstallCommand: "mvn -B dependency:resolve", BuildCommand: "mvn -B package -DskipTests",
+				TestCommand: "mvn -B test", LintCommand: "make lint", FormatCommand: "make fmt"},
+		},
+		{
+			name:  "Makefile only",
+			files: map[string]string{"Makefile": "VERSION := 1\nbuild: deps\n\tcc main.c\ntest:\n\t./run-tests\n"},
+			want:  Profile{BuildSystem: "make", BuildCommand: "make build", TestCommand: "make test"},
+		},
+		{
+			name:  "nothing detected",
+			files: map[string]string{"README.md": ""},
+			want:  Profile{},
+		},
+	}
+	for _, tt := range tests {
+		if got := Detect(writeFiles(t, tt.files)); got != tt.want {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProfileCommand(t *testing.T) {
+	p := Profile{BuildCommand: "b", TestCommand: "t", LintCommand: "l", FormatCommand: "f"}
+	for kind, want := range map[string]string{Build: "b", Test: "t", Lint: "l", Format: "f", "deploy": ""} {
+		if got := p.Command(kind); got != want {
+			t.Errorf("Command(%q) = %q, want %q", kind, got, want)
+		}
+	}
+	if !(Profile{}).Empty() || p.Empty() {
+		t.Error("Empty() mismatch")
+	}
+}
@@ -0,0 +1,66 @@
+package toolrun
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// StaticWorkDir resolves every project to one directory, for tests
+type StaticWorkDir string
+
+// GetProjectWorkDir returns the directory
+func (d StaticWorkDir) GetProjectWorkDir(projectID string) string { return string(d) }
+
+// Fake stands in for real programs in tests. It records every command it
+// runs and answers it with Handle.
+type Fake struct {
+	// Installed lists the programs found on PATH; nil finds every program
+	Installed []string
+	// Handle answers a command; nil answers every command with no output
+	Handle func(c Command) (stdout, stderr []byte, err error)
+
+	mu    sync.Mutex
+	Calls []Command
+}
+
+// Runner returns a runner using the fake
+func (f *Fake) Runner() Runner {
+	return Runner{LookPath: f.lookPath, Run: f.run}
+}
+
+// Called returns the first command run that starts with prefix, or "" if
+// there is none
+func (f *Fake) Called(prefix string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.Calls {
+		if s := c.String(); strings.HasPrefix(s, prefix) {
+			return s
+		}
+	}
+	return ""
+}
+
+func (f *Fake) lookPath(file string) (string, error) {
+	if f.Installed == nil {
+		return "/usr/bin/" + file, nil
+	}
+	for _, name := range f.Installed {
+		if name == file {
+			return "/usr/bin/" + file, nil
+		}
+	}
+	return "", errors.New(file + " not found")
+}
+
+func (f *Fake) run(ctx context.Context, c Command) ([]byte, []byte, error) {
+	f.mu.Lock()
+	f.Calls = append(f.Calls, c)
+	f.mu.Unlock()
+	if f.Handle == nil {
+		return nil, nil, nil
+	}
+	return f.Handle(c)
+}
@@ -0,0 +1,81 @@
+// Package toolrun runs the external programs behind tool actions, such as
+// security scanners, container runtimes and profilers. Managers run them
+// through a Runner, whose zero value uses the real programs, so tests can
+// swap in fakes.
+package toolrun
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Command is a program to run
+type Command struct {
+	Dir   string
+	Env   []string // Replaces the process environment when set
+	Stdin []byte   // Fed to the program when set
+	Name  string
+	Args  []string
+}
+
+// String is the program and its arguments, space separated
+func (c Command) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Runner finds and runs programs. A nil field uses the real ones.
+type Runner struct {
+	LookPath func(file string) (string, error)
+	Run      func(ctx context.Context, c Command) (stdout, stderr []byte, err error)
+}
+
+// Find looks a program up on PATH
+func (r Runner) Find(file string) (string, error) {
+	if r.LookPath == nil {
+		return exec.LookPath(file)
+	}
+	return r.LookPath(file)
+}
+
+// Exec runs c and returns what it wrote to stdout and stderr
+func (r Runner) Exec(ctx context.Context, c Command) ([]byte, []byte, error) {
+	if r.Run == nil {
+		return Run(ctx, c)
+	}
+	return r.Run(ctx, c)
+}
+
+// Limit applies a requested timeout, which may only shorten the configured one
+func Limit(requested, configured time.Duration) time.Duration {
+	if requested > 0 && requested < configured {
+		return requested
+	}
+	return configured
+}
+
+// Tail joins a program's output and keeps its last max bytes
+func Tail(stdout, stderr []byte, max int) string {
+	out := strings.TrimSpace(string(stdout) + "\n" + string(stderr))
+	if len(out) > max {
+		out = "..." + out[len(out)-max:]
+	}
+	return out
+}
+
+// Run runs c with os/exec
+func Run(ctx context.Context, c Command) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.Env = c.Env
+	if c.Stdin != nil {
+		cmd.Stdin = bytes.NewReader(c.Stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
@@ -0,0 +1,79 @@
+package toolrun
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not installed")
+	}
+	dir := t.TempDir()
+	stdout, stderr, err := Runner{}.Exec(context.Background(), Command{
+		Dir:   dir,
+		Env:   []string{"GREETING=hello"},
+		Stdin: []byte("world"),
+		Name:  "sh",
+		Args:  []string{"-c", `read -r in; echo "$GREETING $in $(pwd)"; echo oops >&2; exit 3`},
+	})
+	if got := strings.TrimSpace(string(stdout)); !strings.HasPrefix(got, "hello world ") || !strings.HasSuffix(got, dir) {
+		t.Errorf("unexpected stdout %q", got)
+	}
+	if string(stderr) != "oops\n" {
+		t.Errorf("unexpected stderr %q", stderr)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("expected exit status 3, got %v", err)
+	}
+}
+
+func TestLimitAndTail(t *testing.T) {
+	if got := Limit(time.Second, time.Minute); got != time.Second {
+		t.Errorf("a shorter request should apply, got %v", got)
+	}
+	if got := Limit(time.Hour, time.Minute); got != time.Minute {
+		t.Errorf("a longer request should be capped, got %v", got)
+	}
+	if got := Limit(0, time.Minute); got != time.Minute {
+		t.Errorf("no request should use the configured timeout, got %v", got)
+	}
+	if got := Tail([]byte("ok"), []byte("warn\n"), 100); got != "ok\nwarn" {
+		t.Errorf("Tail = %q", got)
+	}
+	if got := Tail([]byte("0123456789"), nil, 4); got != "...6789" {
+		t.Errorf("Tail = %q", got)
+	}
+}
+
+func TestFake(t *testing.T) {
+	f := &Fake{
+		Installed: []string{"git"},
+		Handle: func(c Command) ([]byte, []byte, error) {
+			return []byte(c.Args[0]), nil, nil
+		},
+	}
+	r := f.Runner()
+	if path, err := r.Find("git"); err != nil || path != "/usr/bin/git" {
+		t.Errorf("expected git to be found, got %q, %v", path, err)
+	}
+	if _, err := r.Find("hg"); err == nil {
+		t.Error("expected hg not to be found")
+	}
+
+	out, _, err := r.Exec(context.Background(), Command{Name: "git", Args: []string{"status", "--short"}})
+	if err != nil || string(out) != "status" {
+		t.Errorf("unexpected run %q, %v", out, err)
+	}
+	if got := f.Called("git status"); got != "git status --short" {
+		t.Errorf("Called = %q", got)
+	}
+	if got := f.Called("git log"); got != "" {
+		t.Errorf("expected no git log, got %q", got)
+	}
+}
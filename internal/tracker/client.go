@@ -0,0 +1,77 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+	// maxResponseBytes caps how much of a response is read
+	maxResponseBytes = 4 << 20
+)
+
+var errNotFound = errors.New("issue not found")
+
+// apiClient performs authenticated JSON requests against a tracker API
+type apiClient struct {
+	client    *http.Client
+	authorize func(req *http.Request)
+}
+
+func newAPIClient(authorize func(req *http.Request)) apiClient {
+	return apiClient{client: &http.Client{Timeout: requestTimeout}, authorize: authorize}
+}
+
+// do sends in as the JSON body, when not nil, and decodes the response
+// into out, when not nil
+func (c apiClient) do(ctx context.Context, method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s", method, url, resp.Status)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", url, err)
+	}
+	return nil
+}
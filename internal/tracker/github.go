@@ -0,0 +1,363 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// Bead context keys tying a bead to the GitHub issue it was imported from
+const (
+	ContextGitHubIssue    = "github_issue"     // owner/repo#123
+	ContextGitHubIssueURL = "github_issue_url" // The issue's web page
+	contextCommentOnClose = "github_comment_on_close"
+	contextCommentedAt    = "github_issue_commented_at"
+)
+
+// DefaultImportLimit caps how many issues one import files
+const DefaultImportLimit = 100
+
+// defaultPriorityLabels map common GitHub priority labels onto bead
+// priorities
+var defaultPriorityLabels = map[string]int{
+	"p0": 0, "critical": 0, "priority: critical": 0, "priority/critical": 0,
+	"p1": 1, "high": 1, "priority: high": 1, "priority/high": 1,
+	"p2": 2, "medium": 2, "priority: medium": 2, "priority/medium": 2,
+	"p3": 3, "low": 3, "priority: low": 3, "priority/low": 3,
+}
+
+// BeadCreator files beads for imported issues
+type BeadCreator interface {
+	CreateBead(title, description string, priority models.BeadPriority, beadType, projectID string) (*models.Bead, error)
+}
+
+// ImportRequest selects the GitHub issues to file as beads
+type ImportRequest struct {
+	ProjectID      string   `json:"project_id"`
+	Repository     string   `json:"repository"` // owner/repo
+	Labels         []string `json:"labels,omitempty"`
+	Milestone      string   `json:"milestone,omitempty"` // Title or number; "*" for any, "none" for none
+	State          string   `json:"state,omitempty"`     // open (default), closed or all
+	Limit          int      `json:"limit,omitempty"`     // Default 100
+	CommentOnClose bool     `json:"comment_on_close,omitempty"`
+	DryRun         bool     `json:"dry_run,omitempty"`
+}
+
+// ImportedIssue is one issue an import considered
+type ImportedIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	BeadID string `json:"bead_id,omitempty"`
+}
+
+// ImportResult describes what an import filed
+type ImportResult struct {
+	Created []ImportedIssue `json:"created"`
+	Skipped []ImportedIssue `json:"skipped"` // Already imported
+}
+
+// GitHubImporter files beads for GitHub issues and reports back on the
+// issue when the bead closes
+type GitHubImporter struct {
+	baseURL        string
+	api            apiClient
+	creator        BeadCreator
+	beads          BeadStore
+	priorityLabels map[string]int
+	now            func() time.Time
+}
+
+// NewGitHubImporter creates a GitHub issue importer. An empty APIURL uses
+// api.github.com.
+func NewGitHubImporter(creator BeadCreator, beads BeadStore, cfg config.GitHubTrackerConfig) *GitHubImporter {
+	baseURL := cfg.APIURL
+	if baseURL == "" {
+		baseURL = defaultGitHubAPIURL
+	}
+	priorities := make(map[string]int, len(defaultPriorityLabels)+len(cfg.PriorityLabels))
+	for k, v := range defaultPriorityLabels {
+		priorities[k] = v
+	}
+	for k, v := range cfg.PriorityLabels {
+		priorities[strings.ToLower(k)] = v
+	}
+	token := cfg.Token
+	return &GitHubImporter{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		api: newAPIClient(func(req *http.Request) {
+			req.Header.Set("Accept", "application/vnd.github+json")
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}),
+		creator:        creator,
+		beads:          beads,
+		priorityLabels: priorities,
+		now:            time.Now,
+	}
+}
+
+type githubIssue struct {
+	Number      int       `json:"number"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"html_url"`
+	PullRequest *struct{} `json:"pull_request"` // Set when the issue is a pull request
+	Labels      []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+}
+
+// Import files a bead for each matching issue not imported before
+func (g *GitHubImporter) Import(ctx context.Context, req ImportRequest) (*ImportResult, error) {
+	if req.ProjectID == "" {
+		return nil, apperr.Validation("project_id is required")
+	}
+	if !strings.Contains(req.Repository, "/") {
+		return nil, apperr.Validation("repository must be owner/repo")
+	}
+	switch req.State {
+	case "":
+		req.State = "open"
+	case "open", "closed", "all":
+	default:
+		return nil, apperr.Validation("state must be open, closed or all")
+	}
+	if req.Limit <= 0 {
+		req.Limit = DefaultImportLimit
+	}
+
+	issues, err := g.listIssues(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	imported, err := g.importedIssues(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Created: []ImportedIssue{}, Skipped: []ImportedIssue{}}
+	for _, issue := range issues {
+		ref := fmt.Sprintf("%s#%d", req.Repository, issue.Number)
+		item := ImportedIssue{Number: issue.Number, Title: issue.Title, URL: issue.HTMLURL}
+		if beadID, ok := imported[ref]; ok {
+			item.BeadID = beadID
+			result.Skipped = append(result.Skipped, item)
+			continue
+		}
+		if req.DryRun {
+			result.Created = append(result.Created, item)
+			continue
+		}
+		beadID, err := g.file(req, ref, issue)
+		if err != nil {
+			return result, fmt.Errorf("failed to import %s: %w", ref, err)
+		}
+		item.BeadID = beadID
+		result.Created = append(result.Created, item)
+	}
+	return result, nil
+}
+
+// listIssues pages through the repository's matching issues, leaving out
+// pull requests
+func (g *GitHubImporter) listIssues(ctx context.Context, req ImportRequest) ([]githubIssue, error) {
+	q := url.Values{"state": {req.State}, "per_page": {"100"}}
+	if len(req.Labels) > 0 {
+		q.Set("labels", strings.Join(req.Labels, ","))
+	}
+	if req.Milestone != "" {
+		milestone, err := g.milestone(ctx, req.Repository, req.Milestone)
+		if err != nil {
+			return nil, err
+		}
+		q.Set("milestone", milestone)
+	}
+
+	var issues []githubIssue
+	for page := 1; len(issues) < req.Limit; page++ {
+		q.Set("page", strconv.Itoa(page))
+		var batch []githubIssue
+		if err := g.api.do(ctx, http.MethodGet, fmt.Sprintf("%s/repos/%s/issues?%s", g.baseURL, req.Repository, q.Encode()), nil, &batch); err != nil {
+			if err == errNotFound {
+				return nil, apperr.NotFound("repository %s not found", req.Repository)
+			}
+			return nil, err
+		}
+		for _, issue := range batch {
+			if issue.PullRequest == nil && len(issues) < req.Limit {
+				issues = append(issues, issue)
+			}
+		}
+		if len(batch) < 100 {
+			break
+		}
+	}
+	return issues, nil
+}
+
+// milestone resolves a milestone title to the number the issues API filters
+// by; numbers, "*" and "none" pass through
+func (g *GitHubImporter) milestone(ctx context.Context, repository, milestone string) (string, error) {
+	if _, err := strconv.Atoi(milestone); err == nil || milestone == "*" || milestone == "none" {
+		return milestone, nil
+	}
+	var list []struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+	}
+	if err := g.api.do(ctx, http.MethodGet, fmt.Sprintf("%s/repos/%s/milestones?state=all&per_page=100", g.baseURL, repository), nil, &list); err != nil {
+		return "", err
+	}
+	for _, m := range list {
+		if strings.EqualFold(m.Title, milestone) {
+			return strconv.Itoa(m.Number), nil
+		}
+	}
+	return "", apperr.NotFound("milestone %q not found in %s", milestone, repository)
+}
+
+// importedIssues maps the issues the project's beads came from to the beads
+func (g *GitHubImporter) importedIssues(projectID string) (map[string]string, error) {
+	list, err := g.beads.ListBeads(map[string]interface{}{"project_id": projectID})
+	if err != nil {
+		return nil, err
+	}
+	imported := make(map[string]string)
+	for _, b := range list {
+		if ref := b.Context[ContextGitHubIssue]; ref != "" {
+			imported[ref] = b.ID
+		}
+	}
+	return imported, nil
+}
+
+// file creates the bead for one issue
+func (g *GitHubImporter) file(req ImportRequest, ref string, issue githubIssue) (string, error) {
+	priority, beadType := models.BeadPriorityP2, "task"
+	labels := make(map[string]string)
+	for _, l := range issue.Labels {
+		name := strings.ToLower(strings.TrimSpace(l.Name))
+		if p, ok := g.priorityLabels[name]; ok && p >= 0 && p <= 3 {
+			priority = models.BeadPriority(p)
+		}
+		if name == "bug" {
+			beadType = "bug"
+		}
+		if key, value, ok := beadLabel(name); ok {
+			labels[key] = value
+		}
+	}
+
+	description := strings.TrimSpace(issue.Body)
+	if description != "" {
+		description += "\n\n"
+	}
+	description += "Imported from " + issue.HTMLURL
+
+	bead, err := g.creator.CreateBead(issue.Title, description, priority, beadType, req.ProjectID)
+	if err != nil {
+		return "", err
+	}
+	ctxUpdates := map[string]string{
+		ContextGitHubIssue:    ref,
+		ContextGitHubIssueURL: issue.HTMLURL,
+	}
+	if req.CommentOnClose {
+		ctxUpdates[contextCommentOnClose] = "true"
+	}
+	updates := map[string]interface{}{"context": ctxUpdates}
+	if len(labels) > 0 {
+		updates["labels"] = labels
+	}
+	if err := g.beads.UpdateBead(bead.ID, updates); err != nil {
+		return bead.ID, err
+	}
+	return bead.ID, nil
+}
+
+var labelUnsafe = regexp.MustCompile(`[\s,()!=]+`)
+
+// beadLabel turns a GitHub label into a bead label: "priority: high"
+// becomes priority=high and "good first issue" good-first-issue
+func beadLabel(name string) (string, string, bool) {
+	key, value, _ := strings.Cut(name, ":")
+	key = strings.Trim(labelUnsafe.ReplaceAllString(strings.TrimSpace(key), "-"), "-")
+	value = strings.Trim(labelUnsafe.ReplaceAllString(strings.TrimSpace(value), "-"), "-")
+	label := key
+	if value != "" {
+		label += "=" + value
+	}
+	k, v, err := beads.ParseLabel(label)
+	return k, v, err == nil
+}
+
+// OnClosed comments on the GitHub issue a closed bead was imported from,
+// when the import asked for it, linking the bead's pull request if one can
+// be found. Each bead comments once.
+func (g *GitHubImporter) OnClosed(ctx context.Context, beadID string) error {
+	bead, err := g.beads.GetBead(beadID)
+	if err != nil {
+		return err
+	}
+	ref := bead.Context[ContextGitHubIssue]
+	if ref == "" || bead.Context[contextCommentOnClose] != "true" || bead.Context[contextCommentedAt] != "" {
+		return nil
+	}
+	repository, number, ok := strings.Cut(ref, "#")
+	if !ok {
+		return fmt.Errorf("invalid issue reference %q", ref)
+	}
+
+	body := fmt.Sprintf("Closed in loom as bead %s.", bead.ID)
+	if prURL := g.pullRequestFor(ctx, bead, repository); prURL != "" {
+		body = fmt.Sprintf("Resolved by %s (loom bead %s).", prURL, bead.ID)
+	}
+	if reason := bead.Context["close_reason"]; reason != "" {
+		body += "\n\n" + reason
+	}
+	if err := g.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/repos/%s/issues/%s/comments", g.baseURL, repository, number), map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("failed to comment on %s: %w", ref, err)
+	}
+	return g.beads.UpdateBead(beadID, map[string]interface{}{
+		"context": map[string]string{contextCommentedAt: g.now().UTC().Format(time.RFC3339)},
+	})
+}
+
+// pullRequestFor finds the pull request for a bead: the pr_url recorded on
+// it, else the newest pull request from one of its agent branches
+func (g *GitHubImporter) pullRequestFor(ctx context.Context, bead *models.Bead, repository string) string {
+	if prURL := bead.Context["pr_url"]; prURL != "" {
+		return prURL
+	}
+	var pulls []struct {
+		HTMLURL string `json:"html_url"`
+		Head    struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/pulls?state=all&sort=updated&direction=desc&per_page=100", g.baseURL, repository)
+	if err := g.api.do(ctx, http.MethodGet, endpoint, nil, &pulls); err != nil {
+		return ""
+	}
+	prefix := "agent/" + bead.ID
+	for _, pr := range pulls {
+		if pr.Head.Ref == prefix || strings.HasPrefix(pr.Head.Ref, prefix+"/") {
+			return pr.HTMLURL
+		}
+	}
+	return ""
+}
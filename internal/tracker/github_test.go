@@ -0,0 +1,123 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeCreator struct {
+	beads *fakeBeads
+}
+
+func (f *fakeCreator) CreateBead(title, description string, priority models.BeadPriority, beadType, projectID string) (*models.Bead, error) {
+	id := fmt.Sprintf("bd-%d", len(f.beads.beads)+1)
+	f.beads.beads[id] = &models.Bead{ID: id, Title: title, Description: description, Priority: priority, Type: beadType, ProjectID: projectID, Status: models.BeadStatusOpen}
+	return f.beads.beads[id], nil
+}
+
+func TestGitHubImporter(t *testing.T) {
+	var query string
+	var commented string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/repos/acme/app/milestones":
+			_, _ = w.Write([]byte(`[{"number":7,"title":"v2.0"}]`))
+		case r.URL.Path == "/repos/acme/app/issues":
+			query = r.URL.RawQuery
+			_, _ = w.Write([]byte(`[
+				{"number":1,"title":"Crash on login","body":"Stack trace attached","html_url":"https://github.com/acme/app/issues/1","labels":[{"name":"bug"},{"name":"priority: high"},{"name":"good first issue"}]},
+				{"number":2,"title":"A pull request","html_url":"https://github.com/acme/app/pull/2","pull_request":{}},
+				{"number":3,"title":"Docs","html_url":"https://github.com/acme/app/issues/3","labels":[{"name":"docs"}]}
+			]`))
+		case r.URL.Path == "/repos/acme/app/pulls":
+			_, _ = w.Write([]byte(`[{"html_url":"https://github.com/acme/app/pull/9","head":{"ref":"agent/bd-1/fix-login"}}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/app/issues/1/comments":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			commented = body["body"]
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	store := &fakeBeads{beads: map[string]*models.Bead{}}
+	g := NewGitHubImporter(&fakeCreator{beads: store}, store, config.GitHubTrackerConfig{APIURL: srv.URL, Token: "tok"})
+	ctx := context.Background()
+	req := ImportRequest{ProjectID: "p1", Repository: "acme/app", Labels: []string{"bug"}, Milestone: "v2.0", CommentOnClose: true}
+
+	result, err := g.Import(ctx, req)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if !strings.Contains(query, "milestone=7") || !strings.Contains(query, "labels=bug") {
+		t.Errorf("unexpected issue query %q", query)
+	}
+	if len(result.Created) != 2 {
+		t.Fatalf("expected 2 beads (pull request skipped), got %+v", result)
+	}
+	bead := store.beads[result.Created[0].BeadID]
+	if bead.Type != "bug" || bead.Priority != models.BeadPriorityP1 || bead.Context[ContextGitHubIssue] != "acme/app#1" {
+		t.Errorf("unexpected bead: %+v", bead)
+	}
+	if bead.Labels["priority"] != "high" || bead.Labels["good-first-issue"] != "" || len(bead.Labels) != 3 {
+		t.Errorf("unexpected labels: %v", bead.Labels)
+	}
+	if !strings.Contains(bead.Description, "Imported from https://github.com/acme/app/issues/1") {
+		t.Errorf("description lacks back-reference: %q", bead.Description)
+	}
+
+	// A second import skips issues already filed
+	if result, err = g.Import(ctx, req); err != nil || len(result.Created) != 0 || len(result.Skipped) != 2 {
+		t.Errorf("re-import = %+v, %v", result, err)
+	}
+
+	// Closing reports back once, linking the bead's pull request
+	store.beads["bd-1"].Status = models.BeadStatusClosed
+	if err := g.OnClosed(ctx, "bd-1"); err != nil {
+		t.Fatalf("OnClosed: %v", err)
+	}
+	if !strings.Contains(commented, "https://github.com/acme/app/pull/9") {
+		t.Errorf("comment = %q", commented)
+	}
+	commented = ""
+	if err := g.OnClosed(ctx, "bd-1"); err != nil || commented != "" {
+		t.Errorf("second OnClosed commented again: %q, %v", commented, err)
+	}
+
+	if _, err := g.Import(ctx, ImportRequest{ProjectID: "p1", Repository: "acme/app", Milestone: "v9"}); err == nil {
+		t.Error("expected an error for an unknown milestone")
+	}
+}
+
+func TestBeadLabel(t *testing.T) {
+	for name, want := range map[string]string{
+		"bug":              "bug",
+		"priority: high":   "priority=high",
+		"good first issue": "good-first-issue",
+		"area/api":         "area/api",
+		"needs (triage)":   "needs-triage",
+	} {
+		k, v, ok := beadLabel(name)
+		got := k
+		if v != "" {
+			got += "=" + v
+		}
+		if !ok || got != want {
+			t.Errorf("beadLabel(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+}
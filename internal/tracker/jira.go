@@ -0,0 +1,143 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// jiraTimeLayout is how Jira REST timestamps are written
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// JiraConnector talks to the Jira REST API (v2, which takes plain-text
+// comment bodies on both Cloud and Server)
+type JiraConnector struct {
+	baseURL string
+	api     apiClient
+}
+
+// NewJiraConnector creates a Jira connector. With a user the token is an
+// API token for basic auth; without one it is sent as a bearer token
+// (a Server personal access token).
+func NewJiraConnector(baseURL, user, token string) *JiraConnector {
+	return &JiraConnector{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		api: newAPIClient(func(req *http.Request) {
+			switch {
+			case user != "":
+				req.SetBasicAuth(user, token)
+			case token != "":
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}),
+	}
+}
+
+// Name implements Connector
+func (j *JiraConnector) Name() string { return ProviderJira }
+
+func (j *JiraConnector) issueURL(key, suffix string) string {
+	return fmt.Sprintf("%s/rest/api/2/issue/%s%s", j.baseURL, url.PathEscape(key), suffix)
+}
+
+// GetIssue implements Connector
+func (j *JiraConnector) GetIssue(ctx context.Context, key string) (*Issue, error) {
+	var resp struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary     string   `json:"summary"`
+			Description string   `json:"description"`
+			Labels      []string `json:"labels"`
+			Updated     string   `json:"updated"`
+			Status      struct {
+				Name           string `json:"name"`
+				StatusCategory struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	if err := j.api.do(ctx, http.MethodGet, j.issueURL(key, "?fields=summary,description,labels,updated,status"), nil, &resp); err != nil {
+		return nil, err
+	}
+	updated, _ := time.Parse(jiraTimeLayout, resp.Fields.Updated)
+	return &Issue{
+		Provider:    ProviderJira,
+		Key:         resp.Key,
+		URL:         j.baseURL + "/browse/" + resp.Key,
+		Title:       resp.Fields.Summary,
+		Description: resp.Fields.Description,
+		Status:      resp.Fields.Status.Name,
+		Category:    jiraCategory(resp.Fields.Status.StatusCategory.Key),
+		Labels:      resp.Fields.Labels,
+		UpdatedAt:   updated,
+	}, nil
+}
+
+// SetStatus implements Connector by applying the issue's transition that
+// leads to status
+func (j *JiraConnector) SetStatus(ctx context.Context, key, status string) error {
+	var resp struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := j.api.do(ctx, http.MethodGet, j.issueURL(key, "/transitions"), nil, &resp); err != nil {
+		return err
+	}
+	for _, t := range resp.Transitions {
+		if strings.EqualFold(t.To.Name, status) || strings.EqualFold(t.Name, status) {
+			body := map[string]interface{}{"transition": map[string]string{"id": t.ID}}
+			return j.api.do(ctx, http.MethodPost, j.issueURL(key, "/transitions"), body, nil)
+		}
+	}
+	return fmt.Errorf("no transition to %q is available", status)
+}
+
+// Comments implements Connector
+func (j *JiraConnector) Comments(ctx context.Context, key string) ([]Comment, error) {
+	var resp struct {
+		Comments []struct {
+			ID     string `json:"id"`
+			Body   string `json:"body"`
+			Author struct {
+				DisplayName string `json:"displayName"`
+			} `json:"author"`
+			Created string `json:"created"`
+		} `json:"comments"`
+	}
+	if err := j.api.do(ctx, http.MethodGet, j.issueURL(key, "/comment?maxResults=1000"), nil, &resp); err != nil {
+		return nil, err
+	}
+	list := make([]Comment, 0, len(resp.Comments))
+	for _, c := range resp.Comments {
+		created, _ := time.Parse(jiraTimeLayout, c.Created)
+		list = append(list, Comment{ID: c.ID, Author: c.Author.DisplayName, Body: c.Body, CreatedAt: created})
+	}
+	return list, nil
+}
+
+// AddComment implements Connector
+func (j *JiraConnector) AddComment(ctx context.Context, key, body string) error {
+	return j.api.do(ctx, http.MethodPost, j.issueURL(key, "/comment"), map[string]string{"body": body}, nil)
+}
+
+// jiraCategory maps a Jira status category key onto a category
+func jiraCategory(key string) string {
+	switch key {
+	case "new":
+		return CategoryTodo
+	case "indeterminate":
+		return CategoryInProgress
+	case "done":
+		return CategoryDone
+	}
+	return ""
+}
@@ -0,0 +1,194 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultLinearAPIURL = "https://api.linear.app/graphql"
+
+// LinearConnector talks to the Linear GraphQL API
+type LinearConnector struct {
+	apiURL string
+	api    apiClient
+}
+
+// NewLinearConnector creates a Linear connector. An empty apiURL uses
+// api.linear.app.
+func NewLinearConnector(apiURL, apiKey string) *LinearConnector {
+	if apiURL == "" {
+		apiURL = defaultLinearAPIURL
+	}
+	return &LinearConnector{
+		apiURL: apiURL,
+		api: newAPIClient(func(req *http.Request) {
+			req.Header.Set("Authorization", apiKey)
+		}),
+	}
+}
+
+// Name implements Connector
+func (l *LinearConnector) Name() string { return ProviderLinear }
+
+// query runs a GraphQL query and decodes its data into out
+func (l *LinearConnector) query(ctx context.Context, query string, vars map[string]interface{}, out interface{}) error {
+	var resp struct {
+		Data   interface{} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	resp.Data = out
+	if err := l.api.do(ctx, http.MethodPost, l.apiURL, map[string]interface{}{"query": query, "variables": vars}, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		if strings.Contains(strings.ToLower(resp.Errors[0].Message), "not found") {
+			return errNotFound
+		}
+		return fmt.Errorf("linear: %s", resp.Errors[0].Message)
+	}
+	return nil
+}
+
+type linearIssue struct {
+	ID          string `json:"id"`
+	Identifier  string `json:"identifier"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	UpdatedAt   string `json:"updatedAt"`
+	State       struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"state"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Team struct {
+		States struct {
+			Nodes []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"nodes"`
+		} `json:"states"`
+	} `json:"team"`
+	Comments struct {
+		Nodes []struct {
+			ID        string `json:"id"`
+			Body      string `json:"body"`
+			CreatedAt string `json:"createdAt"`
+			User      *struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		} `json:"nodes"`
+	} `json:"comments"`
+}
+
+const linearIssueQuery = `query($id: String!) {
+  issue(id: $id) {
+    id identifier title description url updatedAt
+    state { name type }
+    labels { nodes { name } }
+    team { states { nodes { id name } } }
+    comments(first: 250) { nodes { id body createdAt user { name } } }
+  }
+}`
+
+func (l *LinearConnector) issue(ctx context.Context, key string) (*linearIssue, error) {
+	var data struct {
+		Issue *linearIssue `json:"issue"`
+	}
+	if err := l.query(ctx, linearIssueQuery, map[string]interface{}{"id": key}, &data); err != nil {
+		return nil, err
+	}
+	if data.Issue == nil {
+		return nil, errNotFound
+	}
+	return data.Issue, nil
+}
+
+// GetIssue implements Connector
+func (l *LinearConnector) GetIssue(ctx context.Context, key string) (*Issue, error) {
+	li, err := l.issue(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	updated, _ := time.Parse(time.RFC3339, li.UpdatedAt)
+	issue := &Issue{
+		Provider:    ProviderLinear,
+		Key:         li.Identifier,
+		URL:         li.URL,
+		Title:       li.Title,
+		Description: li.Description,
+		Status:      li.State.Name,
+		Category:    linearCategory(li.State.Type),
+		UpdatedAt:   updated,
+	}
+	for _, label := range li.Labels.Nodes {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	return issue, nil
+}
+
+// SetStatus implements Connector by moving the issue to its team's state
+// named status
+func (l *LinearConnector) SetStatus(ctx context.Context, key, status string) error {
+	li, err := l.issue(ctx, key)
+	if err != nil {
+		return err
+	}
+	for _, s := range li.Team.States.Nodes {
+		if strings.EqualFold(s.Name, status) {
+			const mutation = `mutation($id: String!, $state: String!) { issueUpdate(id: $id, input: {stateId: $state}) { success } }`
+			return l.query(ctx, mutation, map[string]interface{}{"id": li.ID, "state": s.ID}, nil)
+		}
+	}
+	return fmt.Errorf("team has no state %q", status)
+}
+
+// Comments implements Connector
+func (l *LinearConnector) Comments(ctx context.Context, key string) ([]Comment, error) {
+	li, err := l.issue(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Comment, 0, len(li.Comments.Nodes))
+	for _, c := range li.Comments.Nodes {
+		created, _ := time.Parse(time.RFC3339, c.CreatedAt)
+		author := ""
+		if c.User != nil {
+			author = c.User.Name
+		}
+		list = append(list, Comment{ID: c.ID, Author: author, Body: c.Body, CreatedAt: created})
+	}
+	return list, nil
+}
+
+// AddComment implements Connector
+func (l *LinearConnector) AddComment(ctx context.Context, key, body string) error {
+	li, err := l.issue(ctx, key)
+	if err != nil {
+		return err
+	}
+	const mutation = `mutation($id: String!, $body: String!) { commentCreate(input: {issueId: $id, body: $body}) { success } }`
+	return l.query(ctx, mutation, map[string]interface{}{"id": li.ID, "body": body}, nil)
+}
+
+// linearCategory maps a Linear workflow state type onto a category
+func linearCategory(stateType string) string {
+	switch stateType {
+	case "triage", "backlog", "unstarted":
+		return CategoryTodo
+	case "started":
+		return CategoryInProgress
+	case "completed", "canceled":
+		return CategoryDone
+	}
+	return ""
+}
@@ -0,0 +1,556 @@
+// Package tracker keeps beads in step with issues in an external tracker
+// such as Jira or Linear. A linked bead and its issue exchange status
+// changes and comments in both directions; when both sides changed status
+// since the last sync, a conflict rule decides which wins. GitHub issues can
+// also be imported as beads, which report back on the issue when they close.
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/comments"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var trackerLog = logging.Module("tracker")
+
+// Supported trackers
+const (
+	ProviderJira   = "jira"
+	ProviderLinear = "linear"
+)
+
+// Status categories trackers group their workflow states into
+const (
+	CategoryTodo       = "todo"
+	CategoryInProgress = "in_progress"
+	CategoryDone       = "done"
+)
+
+// Conflict rules for when a bead and its issue both changed status
+const (
+	ConflictNewest  = "newest"
+	ConflictTracker = "tracker"
+	ConflictLoom    = "loom"
+)
+
+// DefaultInterval is how often linked beads are synced
+const DefaultInterval = 5 * time.Minute
+
+// Bead context keys holding a link and its sync state
+const (
+	ContextProvider   = "tracker_provider"
+	ContextKey        = "tracker_key"
+	ContextURL        = "tracker_url"
+	contextStatus     = "tracker_status"      // Issue status at the last sync
+	contextBeadStatus = "tracker_bead_status" // Bead status at the last sync
+	contextPulledAt   = "tracker_comments_pulled_at"
+	contextPushedAt   = "tracker_comments_pushed_at"
+	contextSyncedAt   = "tracker_synced_at"
+)
+
+// commentMarker starts comments loom posts to a tracker, so they aren't
+// pulled back
+const commentMarker = "[loom]"
+
+// trackerAuthorPrefix starts the author ID of comments pulled from a
+// tracker, so they aren't pushed back
+const trackerAuthorPrefix = "tracker:"
+
+// Issue is a tracker issue as loom sees it
+type Issue struct {
+	Provider    string    `json:"provider"`
+	Key         string    `json:"key"` // e.g. PROJ-123 or ENG-42
+	URL         string    `json:"url"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Status      string    `json:"status"`   // The tracker's own status name
+	Category    string    `json:"category"` // todo, in_progress or done
+	Labels      []string  `json:"labels,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Comment is a comment on a tracker issue
+type Comment struct {
+	ID        string    `json:"id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Connector talks to one tracker
+type Connector interface {
+	Name() string
+	GetIssue(ctx context.Context, key string) (*Issue, error)
+	// SetStatus moves an issue to the named status
+	SetStatus(ctx context.Context, key, status string) error
+	Comments(ctx context.Context, key string) ([]Comment, error)
+	AddComment(ctx context.Context, key, body string) error
+}
+
+// BeadStore reads and updates linked beads
+type BeadStore interface {
+	GetBead(id string) (*models.Bead, error)
+	ListBeads(filters map[string]interface{}) ([]*models.Bead, error)
+	UpdateBead(id string, updates map[string]interface{}) error
+}
+
+// CommentStore reads and writes bead comments
+type CommentStore interface {
+	GetComments(beadID string) ([]*comments.Comment, error)
+	CreateComment(beadID, authorID, authorUsername, content, parentID string) (*comments.Comment, error)
+}
+
+// Link is a bead's tie to a tracker issue
+type Link struct {
+	BeadID   string     `json:"bead_id"`
+	Provider string     `json:"provider"`
+	Key      string     `json:"key"`
+	URL      string     `json:"url,omitempty"`
+	Status   string     `json:"status,omitempty"` // Issue status at the last sync
+	SyncedAt *time.Time `json:"synced_at,omitempty"`
+}
+
+// SyncResult describes what one sync of a linked bead did
+type SyncResult struct {
+	BeadID         string `json:"bead_id"`
+	Provider       string `json:"provider"`
+	Key            string `json:"key"`
+	Pulled         string `json:"pulled,omitempty"`   // Bead status taken from the issue
+	Pushed         string `json:"pushed,omitempty"`   // Issue status set from the bead
+	Conflict       string `json:"conflict,omitempty"` // Side that won when both changed
+	CommentsPulled int    `json:"comments_pulled"`
+	CommentsPushed int    `json:"comments_pushed"`
+	Error          string `json:"error,omitempty"`
+}
+
+type connection struct {
+	conn     Connector
+	statuses config.TrackerStatusMap
+}
+
+// Manager syncs linked beads with their tracker issues
+type Manager struct {
+	beads    BeadStore
+	comments CommentStore
+	conflict string
+
+	mu          sync.Mutex
+	connections map[string]connection
+	readOnly    func() error
+	syncMu      sync.Mutex // serializes syncs so a bead isn't synced twice at once
+	now         func() time.Time
+}
+
+// NewManager creates a tracker sync manager. comments may be nil, in which
+// case only status is synced.
+func NewManager(beads BeadStore, comments CommentStore, cfg config.TrackerConfig) *Manager {
+	conflict := cfg.Conflict
+	switch conflict {
+	case ConflictNewest, ConflictTracker, ConflictLoom:
+	default:
+		conflict = ConflictNewest
+	}
+	return &Manager{
+		beads:       beads,
+		comments:    comments,
+		conflict:    conflict,
+		connections: make(map[string]connection),
+		now:         time.Now,
+	}
+}
+
+// AddConnector registers a tracker connector with its status mapping
+func (m *Manager) AddConnector(c Connector, statuses config.TrackerStatusMap) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connections[c.Name()] = connection{conn: c, statuses: statuses}
+}
+
+// SetReadOnlyCheck installs a check run before syncing every linked bead;
+// while it returns an error SyncAll is refused with it
+func (m *Manager) SetReadOnlyCheck(check func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readOnly = check
+}
+
+// Providers returns the configured trackers
+func (m *Manager) Providers() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.connections))
+	for name := range m.connections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *Manager) connection(provider string) (connection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.connections[provider]
+	if !ok {
+		return connection{}, apperr.Validation("tracker %q is not configured", provider)
+	}
+	return c, nil
+}
+
+// Lookup fetches an issue from a tracker
+func (m *Manager) Lookup(ctx context.Context, provider, key string) (*Issue, error) {
+	c, err := m.connection(provider)
+	if err != nil {
+		return nil, err
+	}
+	issue, err := c.conn.GetIssue(ctx, key)
+	if err == errNotFound {
+		return nil, apperr.NotFound("%s issue %s not found", provider, key)
+	}
+	return issue, err
+}
+
+// Link ties a bead to a tracker issue. The bead adopts the issue's status
+// and existing comments on the first sync; comments already on the bead
+// stay local.
+func (m *Manager) Link(ctx context.Context, beadID, provider, key string) (*Link, error) {
+	bead, err := m.beads.GetBead(beadID)
+	if err != nil {
+		return nil, apperr.NotFound("bead not found: %s", beadID)
+	}
+	issue, err := m.Lookup(ctx, provider, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.ensureUnlinked(issue, beadID); err != nil {
+		return nil, err
+	}
+	ctxUpdates := map[string]string{
+		ContextProvider:   provider,
+		ContextKey:        issue.Key,
+		ContextURL:        issue.URL,
+		contextStatus:     "",
+		contextBeadStatus: string(bead.Status),
+		contextPulledAt:   "",
+		contextPushedAt:   m.now().UTC().Format(time.RFC3339Nano),
+		contextSyncedAt:   "",
+	}
+	if err := m.beads.UpdateBead(beadID, map[string]interface{}{"context": ctxUpdates}); err != nil {
+		return nil, err
+	}
+	return &Link{BeadID: beadID, Provider: provider, Key: issue.Key, URL: issue.URL}, nil
+}
+
+// ensureUnlinked rejects linking an issue another bead is already linked to
+func (m *Manager) ensureUnlinked(issue *Issue, beadID string) error {
+	linked, err := m.linkedBeads()
+	if err != nil {
+		return err
+	}
+	for _, b := range linked {
+		if b.ID != beadID && b.Context[ContextProvider] == issue.Provider && b.Context[ContextKey] == issue.Key {
+			return apperr.Conflict("%s issue %s is already linked to bead %s", issue.Provider, issue.Key, b.ID)
+		}
+	}
+	return nil
+}
+
+// Unlink removes a bead's tie to its issue. Neither side is changed.
+func (m *Manager) Unlink(beadID string) error {
+	bead, err := m.beads.GetBead(beadID)
+	if err != nil {
+		return apperr.NotFound("bead not found: %s", beadID)
+	}
+	if LinkOf(bead) == nil {
+		return apperr.NotFound("bead %s is not linked to a tracker issue", beadID)
+	}
+	ctxUpdates := make(map[string]string)
+	for _, k := range []string{ContextProvider, ContextKey, ContextURL, contextStatus, contextBeadStatus, contextPulledAt, contextPushedAt, contextSyncedAt} {
+		ctxUpdates[k] = ""
+	}
+	return m.beads.UpdateBead(beadID, map[string]interface{}{"context": ctxUpdates})
+}
+
+// LinkOf returns a bead's tracker link, or nil when it has none
+func LinkOf(b *models.Bead) *Link {
+	if b == nil || b.Context[ContextProvider] == "" || b.Context[ContextKey] == "" {
+		return nil
+	}
+	link := &Link{
+		BeadID:   b.ID,
+		Provider: b.Context[ContextProvider],
+		Key:      b.Context[ContextKey],
+		URL:      b.Context[ContextURL],
+		Status:   b.Context[contextStatus],
+	}
+	if t, err := time.Parse(time.RFC3339Nano, b.Context[contextSyncedAt]); err == nil {
+		link.SyncedAt = &t
+	}
+	return link
+}
+
+func (m *Manager) linkedBeads() ([]*models.Bead, error) {
+	all, err := m.beads.ListBeads(nil)
+	if err != nil {
+		return nil, err
+	}
+	var linked []*models.Bead
+	for _, b := range all {
+		if LinkOf(b) != nil {
+			linked = append(linked, b)
+		}
+	}
+	sort.Slice(linked, func(i, j int) bool { return linked[i].ID < linked[j].ID })
+	return linked, nil
+}
+
+// SyncAll syncs every linked bead. Failures are reported per bead.
+func (m *Manager) SyncAll(ctx context.Context) ([]*SyncResult, error) {
+	m.mu.Lock()
+	readOnly := m.readOnly
+	m.mu.Unlock()
+	if readOnly != nil {
+		if err := readOnly(); err != nil {
+			return nil, err
+		}
+	}
+	linked, err := m.linkedBeads()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*SyncResult, 0, len(linked))
+	for _, b := range linked {
+		if ctx.Err() != nil {
+			break
+		}
+		result, err := m.Sync(ctx, b.ID)
+		if err != nil {
+			link := LinkOf(b)
+			result = &SyncResult{BeadID: b.ID, Provider: link.Provider, Key: link.Key, Error: err.Error()}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Start syncs linked beads every interval until ctx is cancelled
+func (m *Manager) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			results, err := m.SyncAll(ctx)
+			if apperr.CodeOf(err) == apperr.CodeReadOnly {
+				continue
+			}
+			if err != nil {
+				trackerLog.Error("Sync failed", "error", err)
+				continue
+			}
+			for _, r := range results {
+				if r.Error != "" {
+					trackerLog.Warn("Bead sync failed", "bead_id", r.BeadID, "provider", r.Provider, "key", r.Key, "error", r.Error)
+				}
+			}
+		}
+	}
+}
+
+// Sync exchanges status changes and new comments between a bead and its
+// issue
+func (m *Manager) Sync(ctx context.Context, beadID string) (*SyncResult, error) {
+	m.syncMu.Lock()
+	defer m.syncMu.Unlock()
+
+	bead, err := m.beads.GetBead(beadID)
+	if err != nil {
+		return nil, apperr.NotFound("bead not found: %s", beadID)
+	}
+	link := LinkOf(bead)
+	if link == nil {
+		return nil, apperr.Validation("bead %s is not linked to a tracker issue", beadID)
+	}
+	c, err := m.connection(link.Provider)
+	if err != nil {
+		return nil, err
+	}
+	issue, err := c.conn.GetIssue(ctx, link.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s issue %s: %w", link.Provider, link.Key, err)
+	}
+	result := &SyncResult{BeadID: beadID, Provider: link.Provider, Key: link.Key}
+
+	// Status: each side changed if it moved since the last sync
+	issueStatus := inbound(c.statuses, issue)
+	remoteChanged := issue.Status != bead.Context[contextStatus] && issueStatus != "" && issueStatus != bead.Status
+	localChanged := string(bead.Status) != bead.Context[contextBeadStatus]
+	pull := remoteChanged
+	if remoteChanged && localChanged {
+		pull = m.trackerWins(bead, issue)
+		result.Conflict = ConflictLoom
+		if pull {
+			result.Conflict = ConflictTracker
+		}
+	}
+	beadStatus := bead.Status
+	updates := make(map[string]interface{})
+	switch {
+	case pull:
+		beadStatus = issueStatus
+		updates["status"] = issueStatus
+		result.Pulled = string(issueStatus)
+	case localChanged && issueStatus != bead.Status:
+		if name := outbound(c.statuses, link.Provider, bead.Status); name != "" && !strings.EqualFold(name, issue.Status) {
+			if err := c.conn.SetStatus(ctx, link.Key, name); err != nil {
+				return nil, fmt.Errorf("failed to move %s issue %s to %q: %w", link.Provider, link.Key, name, err)
+			}
+			issue.Status = name
+			result.Pushed = name
+		}
+	}
+
+	ctxUpdates := map[string]string{
+		contextStatus:     issue.Status,
+		contextBeadStatus: string(beadStatus),
+		ContextURL:        issue.URL,
+		contextSyncedAt:   m.now().UTC().Format(time.RFC3339Nano),
+	}
+	if m.comments != nil {
+		if err := m.syncComments(ctx, c.conn, bead, link, ctxUpdates, result); err != nil {
+			result.Error = err.Error()
+		}
+	}
+	updates["context"] = ctxUpdates
+	if err := m.beads.UpdateBead(beadID, updates); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// trackerWins applies the conflict rule to a bead and issue that both
+// changed status
+func (m *Manager) trackerWins(bead *models.Bead, issue *Issue) bool {
+	switch m.conflict {
+	case ConflictTracker:
+		return true
+	case ConflictLoom:
+		return false
+	default:
+		return issue.UpdatedAt.After(bead.UpdatedAt)
+	}
+}
+
+// syncComments pulls issue comments newer than the last pull and pushes
+// bead comments newer than the last push, skipping each side's copies of
+// the other's
+func (m *Manager) syncComments(ctx context.Context, conn Connector, bead *models.Bead, link *Link, ctxUpdates map[string]string, result *SyncResult) error {
+	pulledAt, _ := time.Parse(time.RFC3339Nano, bead.Context[contextPulledAt])
+	pushedAt, _ := time.Parse(time.RFC3339Nano, bead.Context[contextPushedAt])
+
+	remote, err := conn.Comments(ctx, link.Key)
+	if err != nil {
+		return fmt.Errorf("failed to fetch comments: %w", err)
+	}
+	sort.Slice(remote, func(i, j int) bool { return remote[i].CreatedAt.Before(remote[j].CreatedAt) })
+	for _, rc := range remote {
+		if !rc.CreatedAt.After(pulledAt) {
+			continue
+		}
+		if !strings.HasPrefix(rc.Body, commentMarker) {
+			if _, err := m.comments.CreateComment(bead.ID, trackerAuthorPrefix+link.Provider, rc.Author, rc.Body, ""); err != nil {
+				return fmt.Errorf("failed to save comment %s: %w", rc.ID, err)
+			}
+			result.CommentsPulled++
+		}
+		pulledAt = rc.CreatedAt
+		ctxUpdates[contextPulledAt] = pulledAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	local, err := m.comments.GetComments(bead.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read bead comments: %w", err)
+	}
+	flat := flatten(local)
+	sort.Slice(flat, func(i, j int) bool { return flat[i].CreatedAt.Before(flat[j].CreatedAt) })
+	for _, lc := range flat {
+		if !lc.CreatedAt.After(pushedAt) {
+			continue
+		}
+		if !strings.HasPrefix(lc.AuthorID, trackerAuthorPrefix) {
+			body := fmt.Sprintf("%s %s: %s", commentMarker, lc.AuthorUsername, lc.Content)
+			if err := conn.AddComment(ctx, link.Key, body); err != nil {
+				return fmt.Errorf("failed to post comment %s: %w", lc.ID, err)
+			}
+			result.CommentsPushed++
+		}
+		pushedAt = lc.CreatedAt
+		ctxUpdates[contextPushedAt] = pushedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return nil
+}
+
+func flatten(list []*comments.Comment) []*comments.Comment {
+	var out []*comments.Comment
+	for _, c := range list {
+		out = append(out, c)
+		out = append(out, flatten(c.Replies)...)
+	}
+	return out
+}
+
+// inbound maps an issue's status onto a bead status: by name through the
+// configured map, else by category. "" means the status has no bead
+// equivalent and is left alone.
+func inbound(statuses config.TrackerStatusMap, issue *Issue) models.BeadStatus {
+	for name, status := range statuses.Inbound {
+		if strings.EqualFold(name, issue.Status) {
+			return models.BeadStatus(status)
+		}
+	}
+	switch issue.Category {
+	case CategoryTodo:
+		return models.BeadStatusOpen
+	case CategoryInProgress:
+		return models.BeadStatusInProgress
+	case CategoryDone:
+		return models.BeadStatusClosed
+	}
+	return ""
+}
+
+// defaultOutbound are the issue statuses bead statuses move issues to when
+// the config doesn't say. Blocked beads leave their issue alone.
+var defaultOutbound = map[string]map[models.BeadStatus]string{
+	ProviderJira: {
+		models.BeadStatusOpen:       "To Do",
+		models.BeadStatusInProgress: "In Progress",
+		models.BeadStatusClosed:     "Done",
+	},
+	ProviderLinear: {
+		models.BeadStatusOpen:       "Todo",
+		models.BeadStatusInProgress: "In Progress",
+		models.BeadStatusClosed:     "Done",
+	},
+}
+
+// outbound maps a bead status onto the issue status to move to, or ""
+func outbound(statuses config.TrackerStatusMap, provider string, status models.BeadStatus) string {
+	if name, ok := statuses.Outbound[string(status)]; ok {
+		return name
+	}
+	return defaultOutbound[provider][status]
+}
@@ -0,0 +1,312 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/apperr"
+	"github.com/jordanhubbard/loom/internal/comments"
+	"github.com/jordanhubbard/loom/internal/readonly"
+	"github.com/jordanhubbard/loom/pkg/config"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeBeads struct {
+	beads map[string]*models.Bead
+}
+
+func (f *fakeBeads) GetBead(id string) (*models.Bead, error) {
+	b, ok := f.beads[id]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	c := *b
+	c.Context = make(map[string]string)
+	for k, v := range b.Context {
+		c.Context[k] = v
+	}
+	return &c, nil
+}
+
+func (f *fakeBeads) ListBeads(map[string]interface{}) ([]*models.Bead, error) {
+	var list []*models.Bead
+	for _, b := range f.beads {
+		list = append(list, b)
+	}
+	return list, nil
+}
+
+func (f *fakeBeads) UpdateBead(id string, updates map[string]interface{}) error {
+	b := f.beads[id]
+	if status, ok := updates["status"].(models.BeadStatus); ok {
+		b.Status = status
+	}
+	if ctx, ok := updates["context"].(map[string]string); ok {
+		if b.Context == nil {
+			b.Context = make(map[string]string)
+		}
+		for k, v := range ctx {
+			b.Context[k] = v
+		}
+	}
+	if labels, ok := updates["labels"].(map[string]string); ok {
+		b.Labels = labels
+	}
+	b.UpdatedAt = time.Now()
+	return nil
+}
+
+type fakeComments struct {
+	list []*comments.Comment
+}
+
+func (f *fakeComments) GetComments(beadID string) ([]*comments.Comment, error) {
+	return f.list, nil
+}
+
+func (f *fakeComments) CreateComment(beadID, authorID, authorUsername, content, parentID string) (*comments.Comment, error) {
+	c := &comments.Comment{ID: fmt.Sprint(len(f.list)), BeadID: beadID, AuthorID: authorID, AuthorUsername: authorUsername, Content: content, CreatedAt: time.Now()}
+	f.list = append(f.list, c)
+	return c, nil
+}
+
+type fakeConnector struct {
+	issue    Issue
+	comments []Comment
+	moves    []string
+	posted   []string
+}
+
+func (f *fakeConnector) Name() string { return ProviderJira }
+
+func (f *fakeConnector) GetIssue(ctx context.Context, key string) (*Issue, error) {
+	if key != f.issue.Key {
+		return nil, errNotFound
+	}
+	c := f.issue
+	return &c, nil
+}
+
+func (f *fakeConnector) SetStatus(ctx context.Context, key, status string) error {
+	f.moves = append(f.moves, status)
+	f.issue.Status = status
+	return nil
+}
+
+func (f *fakeConnector) Comments(ctx context.Context, key string) ([]Comment, error) {
+	return f.comments, nil
+}
+
+func (f *fakeConnector) AddComment(ctx context.Context, key, body string) error {
+	f.posted = append(f.posted, body)
+	f.comments = append(f.comments, Comment{ID: "posted", Body: body, CreatedAt: time.Now()})
+	return nil
+}
+
+func setup(t *testing.T, conflict string) (*Manager, *fakeBeads, *fakeComments, *fakeConnector) {
+	t.Helper()
+	beads := &fakeBeads{beads: map[string]*models.Bead{
+		"bd-1": {ID: "bd-1", Status: models.BeadStatusOpen, UpdatedAt: time.Now().Add(-time.Hour)},
+	}}
+	store := &fakeComments{}
+	conn := &fakeConnector{issue: Issue{Provider: ProviderJira, Key: "PROJ-1", Status: "In Progress", Category: CategoryInProgress, UpdatedAt: time.Now().Add(-2 * time.Hour)}}
+	m := NewManager(beads, store, config.TrackerConfig{Conflict: conflict})
+	m.AddConnector(conn, config.TrackerStatusMap{})
+	if _, err := m.Link(context.Background(), "bd-1", ProviderJira, "PROJ-1"); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	return m, beads, store, conn
+}
+
+func TestSync_Status(t *testing.T) {
+	m, beads, _, conn := setup(t, "")
+	ctx := context.Background()
+
+	// The first sync adopts the issue's status
+	result, err := m.Sync(ctx, "bd-1")
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if result.Pulled != "in_progress" || beads.beads["bd-1"].Status != models.BeadStatusInProgress {
+		t.Errorf("expected status pulled, got %+v", result)
+	}
+
+	// A bead change is pushed
+	beads.beads["bd-1"].Status = models.BeadStatusClosed
+	if result, _ = m.Sync(ctx, "bd-1"); result.Pushed != "Done" || conn.issue.Status != "Done" {
+		t.Errorf("expected status pushed, got %+v", result)
+	}
+
+	// Nothing changed, nothing happens
+	if result, _ = m.Sync(ctx, "bd-1"); result.Pulled != "" || result.Pushed != "" {
+		t.Errorf("expected no change, got %+v", result)
+	}
+	if len(conn.moves) != 1 {
+		t.Errorf("expected one transition, got %v", conn.moves)
+	}
+}
+
+func TestSync_Conflict(t *testing.T) {
+	for _, tt := range []struct {
+		rule   string
+		issue  time.Duration // age of the issue's change
+		winner string
+	}{
+		{ConflictNewest, time.Minute, ConflictTracker},
+		{ConflictNewest, 3 * time.Hour, ConflictLoom},
+		{ConflictLoom, time.Minute, ConflictLoom},
+		{ConflictTracker, 3 * time.Hour, ConflictTracker},
+	} {
+		m, beads, _, conn := setup(t, tt.rule)
+		ctx := context.Background()
+		if _, err := m.Sync(ctx, "bd-1"); err != nil {
+			t.Fatal(err)
+		}
+		beads.beads["bd-1"].Status = models.BeadStatusBlocked
+		beads.beads["bd-1"].UpdatedAt = time.Now().Add(-time.Hour)
+		conn.issue.Status, conn.issue.Category = "Done", CategoryDone
+		conn.issue.UpdatedAt = time.Now().Add(-tt.issue)
+
+		result, err := m.Sync(ctx, "bd-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Conflict != tt.winner {
+			t.Errorf("%s/%v: winner = %q, want %q", tt.rule, tt.issue, result.Conflict, tt.winner)
+		}
+		wantStatus := models.BeadStatusBlocked
+		if tt.winner == ConflictTracker {
+			wantStatus = models.BeadStatusClosed
+		}
+		if got := beads.beads["bd-1"].Status; got != wantStatus {
+			t.Errorf("%s/%v: bead status = %s, want %s", tt.rule, tt.issue, got, wantStatus)
+		}
+	}
+}
+
+func TestSync_Comments(t *testing.T) {
+	m, beads, store, conn := setup(t, "")
+	ctx := context.Background()
+	conn.comments = []Comment{{ID: "1", Author: "Pat", Body: "Customer can reproduce", CreatedAt: time.Now().Add(-time.Minute)}}
+
+	result, err := m.Sync(ctx, "bd-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CommentsPulled != 1 || len(store.list) != 1 || store.list[0].AuthorUsername != "Pat" {
+		t.Fatalf("expected the issue comment pulled, got %+v", result)
+	}
+
+	time.Sleep(time.Millisecond)
+	_, _ = store.CreateComment("bd-1", "agent-1", "coder", "Fixed in #12", "")
+	if result, _ = m.Sync(ctx, "bd-1"); result.CommentsPushed != 1 || result.CommentsPulled != 0 {
+		t.Errorf("expected one push and no echo, got %+v", result)
+	}
+	if len(conn.posted) != 1 || !strings.Contains(conn.posted[0], "Fixed in #12") {
+		t.Errorf("posted = %v", conn.posted)
+	}
+
+	// Neither side's copies come back
+	if result, _ = m.Sync(ctx, "bd-1"); result.CommentsPushed != 0 || result.CommentsPulled != 0 {
+		t.Errorf("expected nothing to sync, got %+v", result)
+	}
+	if beads.beads["bd-1"].Context[contextSyncedAt] == "" {
+		t.Error("sync time not recorded")
+	}
+}
+
+func TestLink(t *testing.T) {
+	m, beads, _, _ := setup(t, "")
+	ctx := context.Background()
+	beads.beads["bd-2"] = &models.Bead{ID: "bd-2", Status: models.BeadStatusOpen}
+
+	if _, err := m.Link(ctx, "bd-2", ProviderJira, "PROJ-1"); err == nil {
+		t.Error("linking an issue twice should fail")
+	}
+	if _, err := m.Link(ctx, "bd-2", ProviderJira, "PROJ-404"); err == nil {
+		t.Error("linking a missing issue should fail")
+	}
+	if _, err := m.Link(ctx, "bd-2", ProviderLinear, "ENG-1"); err == nil {
+		t.Error("linking to an unconfigured tracker should fail")
+	}
+	if err := m.Unlink("bd-1"); err != nil {
+		t.Fatalf("Unlink: %v", err)
+	}
+	if LinkOf(beads.beads["bd-1"]) != nil {
+		t.Error("bead still linked")
+	}
+	if _, err := m.Sync(ctx, "bd-1"); err == nil {
+		t.Error("syncing an unlinked bead should fail")
+	}
+}
+
+func TestJiraConnector(t *testing.T) {
+	var transitioned string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "me@example.com" || pass != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/issue/PROJ-1":
+			_, _ = w.Write([]byte(`{"key":"PROJ-1","fields":{"summary":"Login fails","labels":["sso"],"updated":"2026-01-02T15:04:05.000+0000","status":{"name":"In Review","statusCategory":{"key":"indeterminate"}}}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/issue/PROJ-1/transitions":
+			_, _ = w.Write([]byte(`{"transitions":[{"id":"31","name":"Finish","to":{"name":"Done"}}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue/PROJ-1/transitions":
+			var body struct {
+				Transition struct {
+					ID string `json:"id"`
+				} `json:"transition"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			transitioned = body.Transition.ID
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	j := NewJiraConnector(srv.URL, "me@example.com", "tok")
+	ctx := context.Background()
+	issue, err := j.GetIssue(ctx, "PROJ-1")
+	if err != nil {
+		t.Fatalf("GetIssue: %v", err)
+	}
+	if issue.Title != "Login fails" || issue.Category != CategoryInProgress || issue.URL != srv.URL+"/browse/PROJ-1" || issue.UpdatedAt.IsZero() {
+		t.Errorf("unexpected issue: %+v", issue)
+	}
+	if err := j.SetStatus(ctx, "PROJ-1", "done"); err != nil || transitioned != "31" {
+		t.Errorf("SetStatus: %v (transition %q)", err, transitioned)
+	}
+	if err := j.SetStatus(ctx, "PROJ-1", "Won't Do"); err == nil {
+		t.Error("expected an error for a status with no transition")
+	}
+	if _, err := j.GetIssue(ctx, "PROJ-2"); err != errNotFound {
+		t.Errorf("GetIssue(missing) = %v, want errNotFound", err)
+	}
+}
+
+func TestSyncAll_ReadOnly(t *testing.T) {
+	m, beads, _, conn := setup(t, "")
+	gate := readonly.New(config.ReadOnlyConfig{Enabled: true})
+	m.SetReadOnlyCheck(gate.Check)
+
+	if _, err := m.SyncAll(context.Background()); apperr.CodeOf(err) != apperr.CodeReadOnly {
+		t.Fatalf("expected a read_only error, got %v", err)
+	}
+	if beads.beads["bd-1"].Status != models.BeadStatusOpen || len(conn.moves) != 0 {
+		t.Error("nothing may be synced while the server is read-only")
+	}
+
+	gate.Set(false, "", "admin")
+	if results, err := m.SyncAll(context.Background()); err != nil || len(results) != 1 {
+		t.Errorf("expected a sync once read-only mode is off, got %v, %v", results, err)
+	}
+}
@@ -0,0 +1,44 @@
+package transcribe
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// Providers a transcription config may name
+const (
+	ProviderOpenAI     = "openai"
+	ProviderWhisperCPP = "whisper_cpp"
+)
+
+// DefaultTimeout bounds one transcription
+const DefaultTimeout = 2 * time.Minute
+
+// New creates the transcriber cfg describes, or nil when transcription is
+// not configured
+func New(cfg config.TranscriptionConfig) (Transcriber, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case ProviderOpenAI:
+		return NewOpenAI(cfg.Endpoint, cfg.APIKey, cfg.Model, cfg.Language, client), nil
+	case ProviderWhisperCPP:
+		if cfg.Endpoint != "" {
+			return NewWhisperServer(cfg.Endpoint, cfg.Language, client), nil
+		}
+		if cfg.Command == "" || cfg.ModelPath == "" {
+			return nil, fmt.Errorf("whisper_cpp transcription needs an endpoint, or a command and model_path")
+		}
+		return NewWhisperCommand(cfg.Command, cfg.ModelPath, cfg.Language), nil
+	}
+	return nil, fmt.Errorf("unknown transcription provider %q", cfg.Provider)
+}
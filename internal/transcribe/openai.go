@@ -0,0 +1,131 @@
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultOpenAIEndpoint is the OpenAI API base URL
+	DefaultOpenAIEndpoint = "https://api.openai.com/v1"
+	// DefaultOpenAIModel is the OpenAI transcription model
+	DefaultOpenAIModel = "whisper-1"
+)
+
+// OpenAI transcribes through an OpenAI-compatible /audio/transcriptions
+// endpoint
+type OpenAI struct {
+	endpoint string
+	apiKey   string
+	model    string
+	language string
+	client   *http.Client
+}
+
+// NewOpenAI creates a transcriber for the API at endpoint. language is an
+// ISO-639-1 hint; empty lets the model detect it.
+func NewOpenAI(endpoint, apiKey, model, language string, client *http.Client) *OpenAI {
+	if endpoint == "" {
+		endpoint = DefaultOpenAIEndpoint
+	}
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OpenAI{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		apiKey:   apiKey,
+		model:    model,
+		language: language,
+		client:   client,
+	}
+}
+
+// Name is "openai:<model>"
+func (o *OpenAI) Name() string {
+	return "openai:" + o.model
+}
+
+// Transcribe uploads the audio and returns its text
+func (o *OpenAI) Transcribe(ctx context.Context, audio Audio) (*Transcript, error) {
+	fields := map[string]string{"model": o.model, "response_format": "json"}
+	// Only whisper models report language and duration
+	if strings.HasPrefix(o.model, "whisper") {
+		fields["response_format"] = "verbose_json"
+	}
+	if o.language != "" {
+		fields["language"] = o.language
+	}
+	body, contentType, err := multipartBody("file", audio, fields)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint+"/audio/transcriptions", body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transcription request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	return doTranscription(o.client, req)
+}
+
+// multipartBody encodes audio as the form file field, with fields beside it
+func multipartBody(field string, audio Audio, fields map[string]string) (io.Reader, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		if err := w.WriteField(k, v); err != nil {
+			return nil, "", fmt.Errorf("failed to encode %s: %w", k, err)
+		}
+	}
+	part, err := w.CreateFormFile(field, filename(audio))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode audio: %w", err)
+	}
+	if _, err := part.Write(audio.Data); err != nil {
+		return nil, "", fmt.Errorf("failed to encode audio: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to encode audio: %w", err)
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
+// doTranscription sends a transcription request and decodes the JSON
+// response, which both OpenAI and whisper.cpp shape as {"text": ...}
+func doTranscription(client *http.Client, req *http.Request) (*Transcript, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcription: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("transcription failed: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var t Transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to decode transcription: %w", err)
+	}
+	var extra struct {
+		Duration float64 `json:"duration"`
+	}
+	if json.Unmarshal(data, &extra) == nil {
+		t.Duration = extra.Duration
+	}
+	t.Text = strings.TrimSpace(t.Text)
+	return &t, nil
+}
@@ -0,0 +1,86 @@
+// Package transcribe turns speech into text, so beads can be filed from
+// voice memos. Backends are the OpenAI audio transcription API (and
+// compatible servers) and whisper.cpp, as a server or a local command.
+package transcribe
+
+import (
+	"context"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTitleRunes is the longest bead title taken from a transcript
+const MaxTitleRunes = 80
+
+// Audio is a recording to transcribe
+type Audio struct {
+	Name      string
+	MediaType string
+	Data      []byte
+}
+
+// Transcript is the text of a recording
+type Transcript struct {
+	Text     string  `json:"text"`
+	Language string  `json:"language,omitempty"`
+	Duration float64 `json:"duration_seconds,omitempty"`
+}
+
+// Transcriber turns audio into text
+type Transcriber interface {
+	// Name identifies the backend and model, for the record
+	Name() string
+	Transcribe(ctx context.Context, audio Audio) (*Transcript, error)
+}
+
+// extensions are the file extensions transcription backends expect for
+// each audio type; OpenAI picks the decoder by extension
+var extensions = map[string]string{
+	"audio/mpeg": ".mp3",
+	"audio/wav":  ".wav",
+	"audio/ogg":  ".ogg",
+	"audio/webm": ".webm",
+	"audio/mp4":  ".m4a",
+	"audio/flac": ".flac",
+}
+
+// filename is the audio's name with the extension of its media type
+func filename(audio Audio) string {
+	name := path.Base(audio.Name)
+	if name == "." || name == "/" {
+		name = "audio"
+	}
+	ext, ok := extensions[audio.MediaType]
+	if !ok || strings.EqualFold(path.Ext(name), ext) {
+		return name
+	}
+	return strings.TrimSuffix(name, path.Ext(name)) + ext
+}
+
+// Title makes a bead title from a transcript: its first sentence, cut at a
+// word boundary when longer than MaxTitleRunes
+func Title(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	// A sentence ends at punctuation followed by a space, not at the dot in "1.2"
+	for i := 1; i < len(text); i++ {
+		if strings.IndexByte(".!?", text[i-1]) >= 0 && text[i] == ' ' {
+			text = text[:i-1]
+			break
+		}
+	}
+	text = strings.TrimRight(text, ".!?")
+	if utf8.RuneCountInString(text) <= MaxTitleRunes {
+		return text
+	}
+	runes := []rune(text)[:MaxTitleRunes]
+	cut := len(runes)
+	for cut > MaxTitleRunes/2 && !unicode.IsSpace(runes[cut-1]) {
+		cut--
+	}
+	if cut <= MaxTitleRunes/2 {
+		cut = len(runes)
+	}
+	return strings.TrimSpace(string(runes[:cut])) + "..."
+}
@@ -0,0 +1,131 @@
+package transcribe
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+func TestTitle(t *testing.T) {
+	cases := map[string]string{
+		"Fix the login page. It crashes on Safari.":  "Fix the login page",
+		"  add   dark mode  ":                        "add dark mode",
+		"Why does the export time out?":              "Why does the export time out",
+		strings.Repeat("word ", 30):                  strings.TrimSpace(strings.Repeat("word ", 16)) + "...",
+		strings.Repeat("x", 100):                     strings.Repeat("x", MaxTitleRunes) + "...",
+		"":                                           "",
+		"Version 1.2 broke the build, please revert": "Version 1.2 broke the build, please revert",
+	}
+	for in, want := range cases {
+		if got := Title(in); got != want {
+			t.Errorf("Title(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFilename(t *testing.T) {
+	cases := []struct {
+		audio Audio
+		want  string
+	}{
+		{Audio{Name: "memo", MediaType: "audio/mpeg"}, "memo.mp3"},
+		{Audio{Name: "memo.MP3", MediaType: "audio/mpeg"}, "memo.MP3"},
+		{Audio{Name: "recording.bin", MediaType: "audio/mp4"}, "recording.m4a"},
+		{Audio{Name: "../../etc/memo.wav", MediaType: "audio/wav"}, "memo.wav"},
+		{Audio{MediaType: "audio/ogg"}, "audio.ogg"},
+	}
+	for _, c := range cases {
+		if got := filename(c.audio); got != c.want {
+			t.Errorf("filename(%+v) = %q, want %q", c.audio, got, c.want)
+		}
+	}
+}
+
+func TestOpenAI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("model") != "whisper-1" || r.FormValue("response_format") != "verbose_json" || r.FormValue("language") != "en" {
+			http.Error(w, "bad fields", http.StatusBadRequest)
+			return
+		}
+		f, header, err := r.FormFile("file")
+		if err != nil || header.Filename != "memo.mp3" {
+			http.Error(w, "bad file", http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(f)
+		if string(data) != "ID3audio" {
+			http.Error(w, "bad data", http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, `{"text":" Fix the login page. ","language":"english","duration":2.5}`)
+	}))
+	defer srv.Close()
+
+	tr := NewOpenAI(srv.URL+"/v1/", "sk-test", "", "en", nil)
+	got, err := tr.Transcribe(context.Background(), Audio{Name: "memo", MediaType: "audio/mpeg", Data: []byte("ID3audio")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != "Fix the login page." || got.Language != "english" || got.Duration != 2.5 {
+		t.Errorf("unexpected transcript %+v", got)
+	}
+	if tr.Name() != "openai:whisper-1" {
+		t.Errorf("Name() = %q", tr.Name())
+	}
+
+	if _, err := NewOpenAI(srv.URL+"/v1", "wrong", "", "", nil).Transcribe(context.Background(), Audio{Data: []byte("x")}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expected the error status to be reported, got %v", err)
+	}
+}
+
+func TestWhisperServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/inference" || r.FormValue("response_format") != "json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if _, _, err := r.FormFile("file"); err != nil {
+			http.Error(w, "no file", http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, `{"text":"\n Add dark mode.\n"}`)
+	}))
+	defer srv.Close()
+
+	tr, err := New(config.TranscriptionConfig{Provider: ProviderWhisperCPP, Endpoint: srv.URL, Language: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tr.Transcribe(context.Background(), Audio{Name: "memo.wav", MediaType: "audio/wav", Data: []byte("RIFF")})
+	if err != nil || got.Text != "Add dark mode." || got.Language != "en" {
+		t.Errorf("Transcribe() = %+v, %v", got, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if tr, err := New(config.TranscriptionConfig{}); tr != nil || err != nil {
+		t.Errorf("New(empty) = %v, %v; want disabled", tr, err)
+	}
+	if tr, err := New(config.TranscriptionConfig{Provider: ProviderOpenAI}); err != nil || tr.Name() != "openai:whisper-1" {
+		t.Errorf("New(openai) = %v, %v", tr, err)
+	}
+	if _, err := New(config.TranscriptionConfig{Provider: ProviderWhisperCPP}); err == nil {
+		t.Error("expected whisper_cpp without an endpoint or command to be rejected")
+	}
+	tr, err := New(config.TranscriptionConfig{Provider: ProviderWhisperCPP, Command: "whisper-cli", ModelPath: "/models/ggml-base.en.bin"})
+	if err != nil || tr.Name() != "whisper_cpp:ggml-base.en.bin" {
+		t.Errorf("New(whisper_cpp command) = %v, %v", tr, err)
+	}
+	if _, err := New(config.TranscriptionConfig{Provider: "dictaphone"}); err == nil {
+		t.Error("expected an unknown provider to be rejected")
+	}
+}
@@ -0,0 +1,106 @@
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// WhisperServer transcribes through a whisper.cpp server's /inference
+// endpoint. Start the server with --convert to accept formats other than
+// 16 kHz WAV.
+type WhisperServer struct {
+	endpoint string
+	language string
+	client   *http.Client
+}
+
+// NewWhisperServer creates a transcriber for the whisper.cpp server at
+// endpoint
+func NewWhisperServer(endpoint, language string, client *http.Client) *WhisperServer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WhisperServer{endpoint: strings.TrimRight(endpoint, "/"), language: language, client: client}
+}
+
+// Name is "whisper_cpp"
+func (w *WhisperServer) Name() string {
+	return "whisper_cpp"
+}
+
+// Transcribe uploads the audio and returns its text
+func (w *WhisperServer) Transcribe(ctx context.Context, audio Audio) (*Transcript, error) {
+	fields := map[string]string{"response_format": "json", "temperature": "0"}
+	if w.language != "" {
+		fields["language"] = w.language
+	}
+	body, contentType, err := multipartBody("file", audio, fields)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint+"/inference", body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transcription request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	t, err := doTranscription(w.client, req)
+	if err != nil {
+		return nil, err
+	}
+	if t.Language == "" {
+		t.Language = w.language
+	}
+	return t, nil
+}
+
+// WhisperCommand transcribes by running the whisper.cpp command line tool
+// on a temporary copy of the audio
+type WhisperCommand struct {
+	command   string
+	modelPath string
+	language  string
+}
+
+// NewWhisperCommand creates a transcriber that runs command (such as
+// whisper-cli) with the ggml model at modelPath
+func NewWhisperCommand(command, modelPath, language string) *WhisperCommand {
+	return &WhisperCommand{command: command, modelPath: modelPath, language: language}
+}
+
+// Name is "whisper_cpp:<model file>"
+func (w *WhisperCommand) Name() string {
+	return "whisper_cpp:" + filepath.Base(w.modelPath)
+}
+
+// Transcribe runs the command and returns what it prints
+func (w *WhisperCommand) Transcribe(ctx context.Context, audio Audio) (*Transcript, error) {
+	dir, err := os.MkdirTemp("", "loom-transcribe-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, filename(audio))
+	if err := os.WriteFile(file, audio.Data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write audio: %w", err)
+	}
+
+	language := w.language
+	if language == "" {
+		language = "auto"
+	}
+	// -nt drops timestamps and -np progress output, leaving just the text
+	cmd := exec.CommandContext(ctx, w.command, "-m", w.modelPath, "-f", file, "-l", language, "-nt", "-np")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", filepath.Base(w.command), err, strings.TrimSpace(stderr.String()))
+	}
+	return &Transcript{Text: strings.Join(strings.Fields(stdout.String()), " "), Language: w.language}, nil
+}
@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// LogEntry is one entry of the server's log stream
+type LogEntry struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Level     string                 `json:"level"`
+	Source    string                 `json:"source"`
+	Message   string                 `json:"message"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Client talks to a running server's REST API
+type Client struct {
+	BaseURL string
+	APIKey  string
+	Token   string // JWT; used when no API key is set
+	HTTP    *http.Client
+}
+
+// NewClient creates a client for the server at baseURL
+func NewClient(baseURL, apiKey, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		Token:   token,
+		HTTP:    &http.Client{},
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	} else if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return req, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}, header ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func responseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var body struct {
+		Error string `json:"error"`
+	}
+	msg := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		msg = body.Error
+	}
+	if msg == "" {
+		msg = resp.Status
+	}
+	return fmt.Errorf("%s (HTTP %d)", msg, resp.StatusCode)
+}
+
+// Beads lists beads, optionally for one project
+func (c *Client) Beads(ctx context.Context, projectID string) ([]models.Bead, error) {
+	path := "/api/v1/beads"
+	if projectID != "" {
+		path += "?project_id=" + url.QueryEscape(projectID)
+	}
+	var beads []models.Bead
+	err := c.do(ctx, http.MethodGet, path, nil, &beads)
+	return beads, err
+}
+
+// Agents lists agents
+func (c *Client) Agents(ctx context.Context) ([]models.Agent, error) {
+	var agents []models.Agent
+	err := c.do(ctx, http.MethodGet, "/api/v1/agents", nil, &agents)
+	return agents, err
+}
+
+// CloseBead closes a bead regardless of concurrent edits
+func (c *Client) CloseBead(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPatch, "/api/v1/beads/"+url.PathEscape(id),
+		map[string]string{"status": string(models.BeadStatusClosed)}, nil, "If-Match", "*")
+}
+
+// EscalateBead hands a bead to the CEO as a decision
+func (c *Client) EscalateBead(ctx context.Context, id, reason string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/beads/"+url.PathEscape(id)+"/escalate",
+		map[string]string{"reason": reason}, nil)
+}
+
+// ApproveDecision approves a decision bead
+func (c *Client) ApproveDecision(ctx context.Context, id, rationale string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/decisions/"+url.PathEscape(id)+"/decide",
+		map[string]string{"decision": "approve", "rationale": rationale}, nil)
+}
+
+// StreamLogs follows the server's log stream, calling fn for each entry,
+// until ctx ends or the stream breaks
+func (c *Client) StreamLogs(ctx context.Context, projectID string, fn func(LogEntry)) error {
+	path := "/api/v1/logs/stream"
+	if projectID != "" {
+		path += "?project_id=" + url.QueryEscape(projectID)
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		var entry LogEntry
+		if json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &entry) == nil {
+			fn(entry)
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return io.EOF
+}
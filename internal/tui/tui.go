@@ -0,0 +1,317 @@
+// Package tui is a terminal dashboard for operators working over SSH: a live
+// bead list, agent activity and the streaming action log of a running
+// server, with quick actions on the selected bead.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+	"golang.org/x/term"
+)
+
+const (
+	// DefaultRefresh is how often beads and agents are reloaded
+	DefaultRefresh = 5 * time.Second
+	// streamRetry is the pause before reconnecting a broken log stream
+	streamRetry = 3 * time.Second
+)
+
+// Options configures the dashboard
+type Options struct {
+	ProjectID string
+	Refresh   time.Duration
+}
+
+// API is the part of the server API the dashboard uses
+type API interface {
+	Beads(ctx context.Context, projectID string) ([]models.Bead, error)
+	Agents(ctx context.Context) ([]models.Agent, error)
+	CloseBead(ctx context.Context, id string) error
+	EscalateBead(ctx context.Context, id, reason string) error
+	ApproveDecision(ctx context.Context, id, rationale string) error
+	StreamLogs(ctx context.Context, projectID string, fn func(LogEntry)) error
+}
+
+// dashboard holds the state shared by the input, refresh and stream loops
+type dashboard struct {
+	api  API
+	opts Options
+
+	mu     sync.Mutex
+	state  *State
+	redraw chan struct{}
+}
+
+func newDashboard(api API, server string, opts Options) *dashboard {
+	if opts.Refresh <= 0 {
+		opts.Refresh = DefaultRefresh
+	}
+	return &dashboard{
+		api:    api,
+		opts:   opts,
+		state:  &State{Server: server, ProjectID: opts.ProjectID},
+		redraw: make(chan struct{}, 1),
+	}
+}
+
+// Run shows the dashboard on the terminal until the user quits or ctx ends
+func Run(ctx context.Context, api API, server string, opts Options, in *os.File, out io.Writer) error {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("tui needs an interactive terminal")
+	}
+	saved, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("enter raw mode: %w", err)
+	}
+	defer term.Restore(fd, saved)
+
+	// Alternate screen, hidden cursor
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	d := newDashboard(api, server, opts)
+	keys := make(chan string, 16)
+	go readKeys(in, keys)
+	go d.streamLogs(ctx)
+	go d.refresh(ctx)
+
+	refresh := time.NewTicker(d.opts.Refresh)
+	defer refresh.Stop()
+	clock := time.NewTicker(time.Second) // Picks up terminal resizes too
+	defer clock.Stop()
+
+	for {
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+		d.draw(out, width, height)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case key, ok := <-keys:
+			if !ok || d.handleKey(ctx, key) {
+				return nil
+			}
+		case <-refresh.C:
+			go d.refresh(ctx)
+		case <-clock.C:
+		case <-d.redraw:
+		}
+	}
+}
+
+func (d *dashboard) draw(out io.Writer, width, height int) {
+	d.mu.Lock()
+	lines := d.state.Render(width, height)
+	d.mu.Unlock()
+	fmt.Fprint(out, "\x1b[H"+strings.Join(lines, "\x1b[K\r\n")+"\x1b[K\x1b[J")
+}
+
+func (d *dashboard) requestRedraw() {
+	select {
+	case d.redraw <- struct{}{}:
+	default:
+	}
+}
+
+// refresh reloads beads and agents
+func (d *dashboard) refresh(ctx context.Context) {
+	beads, beadErr := d.api.Beads(ctx, d.opts.ProjectID)
+	agents, agentErr := d.api.Agents(ctx)
+
+	d.mu.Lock()
+	if beadErr == nil {
+		d.state.Beads = beads
+		d.state.clampSelection()
+	}
+	if agentErr == nil {
+		d.state.Agents = agents
+	}
+	switch {
+	case beadErr != nil:
+		d.state.Message = "Refresh failed: " + beadErr.Error()
+	case agentErr != nil:
+		d.state.Message = "Refresh failed: " + agentErr.Error()
+	default:
+		d.state.Updated = time.Now()
+		if strings.HasPrefix(d.state.Message, "Refresh failed") {
+			d.state.Message = ""
+		}
+	}
+	d.mu.Unlock()
+	d.requestRedraw()
+}
+
+// streamLogs follows the action log, reconnecting until ctx ends
+func (d *dashboard) streamLogs(ctx context.Context) {
+	for ctx.Err() == nil {
+		_ = d.api.StreamLogs(ctx, d.opts.ProjectID, func(entry LogEntry) {
+			d.mu.Lock()
+			d.state.Streaming = true
+			d.state.AddLog(entry)
+			d.mu.Unlock()
+			d.requestRedraw()
+		})
+		d.mu.Lock()
+		d.state.Streaming = false
+		d.mu.Unlock()
+		d.requestRedraw()
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(streamRetry):
+		}
+	}
+}
+
+// handleKey applies one key press and reports whether to quit
+func (d *dashboard) handleKey(ctx context.Context, key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.state
+
+	if s.Pending != nil {
+		pending := s.Pending
+		s.Pending = nil
+		if key == "y" || key == "Y" {
+			s.Message = pending.Label + " " + pending.BeadID + "…"
+			go d.perform(ctx, *pending)
+		} else {
+			s.Message = "Cancelled"
+		}
+		return false
+	}
+
+	switch key {
+	case "q", "ctrl-c":
+		return true
+	case "up", "k":
+		s.Move(-1)
+	case "down", "j":
+		s.Move(1)
+	case "pgup":
+		s.Move(-10)
+	case "pgdn":
+		s.Move(10)
+	case "home", "g":
+		s.Selected = 0
+	case "end", "G":
+		s.Selected = len(s.Visible()) - 1
+		s.clampSelection()
+	case "f":
+		s.Filter = (s.Filter + 1) % len(statusFilters)
+		s.Selected = 0
+	case "r":
+		s.Message = "Refreshing…"
+		go d.refresh(ctx)
+	case "a", "e", "c":
+		bead, ok := s.SelectedBead()
+		if !ok {
+			s.Message = "No bead selected"
+			return false
+		}
+		switch key {
+		case "a":
+			if bead.Type != "decision" {
+				s.Message = "Only decision beads can be approved"
+				return false
+			}
+			s.Pending = &pendingAction{Key: 'a', Label: "Approve", BeadID: bead.ID}
+		case "e":
+			s.Pending = &pendingAction{Key: 'e', Label: "Escalate", BeadID: bead.ID}
+		case "c":
+			s.Pending = &pendingAction{Key: 'c', Label: "Close", BeadID: bead.ID}
+		}
+	}
+	return false
+}
+
+// perform runs a confirmed quick action and reports its outcome
+func (d *dashboard) perform(ctx context.Context, action pendingAction) {
+	var err error
+	switch action.Key {
+	case 'a':
+		err = d.api.ApproveDecision(ctx, action.BeadID, "Approved from the terminal dashboard")
+	case 'e':
+		err = d.api.EscalateBead(ctx, action.BeadID, "Escalated from the terminal dashboard")
+	case 'c':
+		err = d.api.CloseBead(ctx, action.BeadID)
+	}
+
+	d.mu.Lock()
+	if err != nil {
+		d.state.Message = fmt.Sprintf("%s %s failed: %v", action.Label, action.BeadID, err)
+	} else {
+		d.state.Message = fmt.Sprintf("%s %s done", action.Label, action.BeadID)
+	}
+	d.mu.Unlock()
+	d.refresh(ctx)
+}
+
+// readKeys turns raw terminal input into key names until in closes
+func readKeys(in io.Reader, keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			for _, key := range parseKeys(buf[:n]) {
+				keys <- key
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+var escapeKeys = map[string]string{
+	"\x1b[A": "up", "\x1b[B": "down", "\x1bOA": "up", "\x1bOB": "down",
+	"\x1b[5~": "pgup", "\x1b[6~": "pgdn",
+	"\x1b[H": "home", "\x1b[F": "end", "\x1b[1~": "home", "\x1b[4~": "end",
+}
+
+// parseKeys splits a chunk of input into key names: arrow and paging
+// escape sequences, ctrl-c, and single characters
+func parseKeys(data []byte) []string {
+	var keys []string
+	for len(data) > 0 {
+		if data[0] == 0x1b {
+			matched := false
+			for seq, name := range escapeKeys {
+				if strings.HasPrefix(string(data), seq) {
+					keys = append(keys, name)
+					data = data[len(seq):]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				// Unknown sequence or a lone escape: drop the rest
+				return keys
+			}
+			continue
+		}
+		if data[0] == 0x03 {
+			keys = append(keys, "ctrl-c")
+		} else {
+			keys = append(keys, string(data[0]))
+		}
+		data = data[1:]
+	}
+	return keys
+}
@@ -0,0 +1,235 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+var _ API = (*Client)(nil)
+
+type fakeAPI struct {
+	mu     sync.Mutex
+	beads  []models.Bead
+	agents []models.Agent
+	calls  []string
+	done   chan struct{}
+}
+
+func (f *fakeAPI) Beads(ctx context.Context, projectID string) ([]models.Bead, error) {
+	return f.beads, nil
+}
+
+func (f *fakeAPI) Agents(ctx context.Context) ([]models.Agent, error) { return f.agents, nil }
+
+func (f *fakeAPI) record(call string) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+	f.done <- struct{}{}
+	return nil
+}
+
+func (f *fakeAPI) CloseBead(ctx context.Context, id string) error { return f.record("close " + id) }
+
+func (f *fakeAPI) EscalateBead(ctx context.Context, id, reason string) error {
+	return f.record("escalate " + id)
+}
+
+func (f *fakeAPI) ApproveDecision(ctx context.Context, id, rationale string) error {
+	return f.record("approve " + id)
+}
+
+func (f *fakeAPI) StreamLogs(ctx context.Context, projectID string, fn func(LogEntry)) error {
+	return nil
+}
+
+func sampleBeads() []models.Bead {
+	return []models.Bead{
+		{ID: "bd-closed", Title: "Done", Status: models.BeadStatusClosed, Priority: 0},
+		{ID: "bd-low", Title: "Polish docs", Status: models.BeadStatusOpen, Priority: 3},
+		{ID: "bd-urgent", Title: "Fix outage", Status: models.BeadStatusInProgress, Priority: 0, AssignedTo: "agent-1"},
+		{ID: "bd-decide", Type: "decision", Title: "Pick a database", Status: models.BeadStatusBlocked, Priority: 1},
+	}
+}
+
+func visibleIDs(s *State) []string {
+	var ids []string
+	for _, b := range s.Visible() {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func TestStateVisibleAndFilter(t *testing.T) {
+	s := &State{Beads: sampleBeads()}
+	if got := visibleIDs(s); !reflect.DeepEqual(got, []string{"bd-urgent", "bd-decide", "bd-low"}) {
+		t.Errorf("expected active beads by priority, got %v", got)
+	}
+	s.Filter = 1 // open
+	if got := visibleIDs(s); !reflect.DeepEqual(got, []string{"bd-low"}) {
+		t.Errorf("expected open beads, got %v", got)
+	}
+	s.Filter = len(statusFilters) - 1 // all
+	if got := visibleIDs(s); len(got) != 4 {
+		t.Errorf("expected all beads, got %v", got)
+	}
+
+	s.Filter = 0
+	s.Move(10)
+	if bead, _ := s.SelectedBead(); bead.ID != "bd-low" {
+		t.Errorf("expected the selection to stop at the last bead, got %s", bead.ID)
+	}
+	s.Move(-10)
+	if bead, _ := s.SelectedBead(); bead.ID != "bd-urgent" {
+		t.Errorf("expected the selection to stop at the first bead, got %s", bead.ID)
+	}
+}
+
+func TestStateRender(t *testing.T) {
+	s := &State{
+		Server: "http://localhost:8080",
+		Beads:  sampleBeads(),
+		Agents: []models.Agent{{Name: "coder", Status: "working", CurrentBead: "bd-urgent"}, {Name: "qa", Status: "idle"}},
+	}
+	s.AddLog(LogEntry{Timestamp: time.Now(), Level: "info", Source: "actions", Message: "action executed",
+		Metadata: map[string]interface{}{"action_type": "run_tests", "status": "executed", "bead_id": "bd-urgent", "agent_id": "coder"}})
+
+	lines := s.Render(140, 20)
+	if len(lines) != 20 {
+		t.Fatalf("expected 20 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if w := visibleWidth(line); w != 140 {
+			t.Errorf("line %d is %d columns wide: %q", i, w, line)
+		}
+	}
+	screen := strings.Join(lines, "\n")
+	for _, want := range []string{"agents 1/2 working", "Fix outage", "coder → bd-urgent", "run_tests coder", "q quit"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("expected %q on screen:\n%s", want, screen)
+		}
+	}
+	if !strings.Contains(lines[2], ansiReverse) {
+		t.Errorf("expected the first bead to be highlighted: %q", lines[2])
+	}
+
+	s.Pending = &pendingAction{Label: "Close", BeadID: "bd-urgent"}
+	if footer := s.Render(140, 20)[19]; !strings.Contains(footer, "Close bd-urgent? (y/n)") {
+		t.Errorf("expected a confirmation prompt, got %q", footer)
+	}
+	if small := s.Render(10, 5); len(small) != 1 {
+		t.Errorf("expected a notice on a tiny terminal, got %v", small)
+	}
+}
+
+func TestAddLogKeepsRecent(t *testing.T) {
+	s := &State{}
+	for i := 0; i < maxLogLines+10; i++ {
+		s.AddLog(LogEntry{Message: fmt.Sprint(i)})
+	}
+	if len(s.Logs) != maxLogLines || s.Logs[0].Message != "10" {
+		t.Errorf("expected the oldest entries to be dropped, got %d starting at %s", len(s.Logs), s.Logs[0].Message)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("j\x1b[A\x1b[6~q\x03"))
+	want := []string{"j", "up", "pgdn", "q", "ctrl-c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := parseKeys([]byte("a\x1b[Z")); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("expected unknown sequences to be dropped, got %v", got)
+	}
+}
+
+func TestDashboardQuickActions(t *testing.T) {
+	api := &fakeAPI{beads: sampleBeads(), done: make(chan struct{}, 1)}
+	d := newDashboard(api, "test", Options{})
+	ctx := context.Background()
+	d.refresh(ctx)
+
+	// Approve needs a decision bead
+	d.handleKey(ctx, "a")
+	if d.state.Pending != nil || d.state.Message != "Only decision beads can be approved" {
+		t.Errorf("expected approval to be refused, got %+v %q", d.state.Pending, d.state.Message)
+	}
+
+	// Cancelled actions do nothing
+	d.handleKey(ctx, "c")
+	d.handleKey(ctx, "n")
+	if d.state.Message != "Cancelled" || len(api.calls) != 0 {
+		t.Errorf("expected the close to be cancelled, got %q %v", d.state.Message, api.calls)
+	}
+
+	d.handleKey(ctx, "down")
+	d.handleKey(ctx, "a")
+	d.handleKey(ctx, "y")
+	<-api.done
+	d.handleKey(ctx, "e")
+	d.handleKey(ctx, "y")
+	<-api.done
+
+	api.mu.Lock()
+	calls := append([]string(nil), api.calls...)
+	api.mu.Unlock()
+	if !reflect.DeepEqual(calls, []string{"approve bd-decide", "escalate bd-decide"}) {
+		t.Errorf("unexpected calls %v", calls)
+	}
+	if !d.handleKey(ctx, "q") {
+		t.Error("expected q to quit")
+	}
+}
+
+func TestClient(t *testing.T) {
+	var gotKey, gotIfMatch, gotStatus string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("X-API-Key")
+		switch {
+		case r.URL.Path == "/api/v1/beads" && r.URL.Query().Get("project_id") == "p1":
+			_ = json.NewEncoder(w).Encode([]models.Bead{{ID: "bd-1"}})
+		case r.URL.Path == "/api/v1/beads/bd-1" && r.Method == http.MethodPatch:
+			gotIfMatch = r.Header.Get("If-Match")
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			gotStatus = body["status"]
+			_, _ = w.Write([]byte(`{}`))
+		case r.URL.Path == "/api/v1/logs/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, ": heartbeat\n\nevent: log\ndata: {\"level\":\"info\",\"message\":\"action executed\"}\n\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"Bead not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL+"/", "key-1", "")
+	ctx := context.Background()
+	beads, err := c.Beads(ctx, "p1")
+	if err != nil || len(beads) != 1 || gotKey != "key-1" {
+		t.Fatalf("unexpected beads %v, %v (key %q)", beads, err, gotKey)
+	}
+	if err := c.CloseBead(ctx, "bd-1"); err != nil || gotIfMatch != "*" || gotStatus != "closed" {
+		t.Errorf("unexpected close: %v, If-Match %q, status %q", err, gotIfMatch, gotStatus)
+	}
+	if err := c.EscalateBead(ctx, "missing", "x"); err == nil || !strings.Contains(err.Error(), "Bead not found (HTTP 404)") {
+		t.Errorf("expected the server's error, got %v", err)
+	}
+
+	var entries []LogEntry
+	_ = c.StreamLogs(ctx, "", func(e LogEntry) { entries = append(entries, e) })
+	if len(entries) != 1 || entries[0].Message != "action executed" {
+		t.Errorf("unexpected log entries %+v", entries)
+	}
+}
@@ -0,0 +1,331 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// Status filters cycled with "f"
+var statusFilters = []string{"active", "open", "in_progress", "blocked", "all"}
+
+const (
+	maxLogLines = 500
+
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiReverse = "\x1b[7m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// pendingAction is a quick action waiting for confirmation
+type pendingAction struct {
+	Key    rune
+	Label  string
+	BeadID string
+}
+
+// State is everything the dashboard shows
+type State struct {
+	Server    string
+	ProjectID string
+	Beads     []models.Bead
+	Agents    []models.Agent
+	Logs      []LogEntry
+	Selected  int    // Index into Visible()
+	Filter    int    // Index into statusFilters
+	Message   string // Result of the last action or refresh error
+	Pending   *pendingAction
+	Updated   time.Time
+	Streaming bool
+}
+
+// Visible returns the beads shown under the current filter, by priority
+// then most recently updated
+func (s *State) Visible() []models.Bead {
+	filter := statusFilters[s.Filter]
+	var out []models.Bead
+	for _, b := range s.Beads {
+		switch filter {
+		case "all":
+		case "active":
+			if b.Status == models.BeadStatusClosed {
+				continue
+			}
+		default:
+			if string(b.Status) != filter {
+				continue
+			}
+		}
+		out = append(out, b)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Priority != out[j].Priority {
+			return out[i].Priority < out[j].Priority
+		}
+		return out[i].UpdatedAt.After(out[j].UpdatedAt)
+	})
+	return out
+}
+
+// SelectedBead returns the highlighted bead, if any
+func (s *State) SelectedBead() (models.Bead, bool) {
+	visible := s.Visible()
+	if s.Selected < 0 || s.Selected >= len(visible) {
+		return models.Bead{}, false
+	}
+	return visible[s.Selected], true
+}
+
+// Move changes the selection by delta, staying in range
+func (s *State) Move(delta int) {
+	s.Selected += delta
+	s.clampSelection()
+}
+
+func (s *State) clampSelection() {
+	n := len(s.Visible())
+	if s.Selected >= n {
+		s.Selected = n - 1
+	}
+	if s.Selected < 0 {
+		s.Selected = 0
+	}
+}
+
+// AddLog appends a log entry, keeping the most recent maxLogLines
+func (s *State) AddLog(entry LogEntry) {
+	s.Logs = append(s.Logs, entry)
+	if over := len(s.Logs) - maxLogLines; over > 0 {
+		s.Logs = append(s.Logs[:0:0], s.Logs[over:]...)
+	}
+}
+
+// Render draws the dashboard as height lines of at most width columns
+func (s *State) Render(width, height int) []string {
+	if width < 20 || height < 8 {
+		return []string{truncate("Terminal too small", width)}
+	}
+	lines := make([]string, 0, height)
+	lines = append(lines, s.header(width))
+
+	body := height - 2
+	logRows := body / 3
+	if logRows < 3 {
+		logRows = 3
+	}
+	topRows := body - logRows
+
+	agentWidth := width / 3
+	if agentWidth > 48 {
+		agentWidth = 48
+	}
+	beadWidth := width - agentWidth - 1
+	beadLines := s.beadPane(beadWidth, topRows)
+	agentLines := s.agentPane(agentWidth, topRows)
+	for i := 0; i < topRows; i++ {
+		lines = append(lines, beadLines[i]+ansiDim+"│"+ansiReset+agentLines[i])
+	}
+	lines = append(lines, s.logPane(width, logRows)...)
+	lines = append(lines, s.footer(width))
+	return lines
+}
+
+func (s *State) header(width int) string {
+	counts := map[models.BeadStatus]int{}
+	for _, b := range s.Beads {
+		counts[b.Status]++
+	}
+	working := 0
+	for _, a := range s.Agents {
+		if a.Status == "working" {
+			working++
+		}
+	}
+	scope := "all projects"
+	if s.ProjectID != "" {
+		scope = s.ProjectID
+	}
+	live := "log stream down"
+	if s.Streaming {
+		live = "live"
+	}
+	text := fmt.Sprintf(" Loom %s · %s · beads %d open %d in progress %d blocked · agents %d/%d working · %s · %s ",
+		s.Server, scope, counts[models.BeadStatusOpen], counts[models.BeadStatusInProgress], counts[models.BeadStatusBlocked],
+		working, len(s.Agents), live, s.Updated.Format("15:04:05"))
+	return ansiReverse + pad(truncate(text, width), width) + ansiReset
+}
+
+func (s *State) beadPane(width, rows int) []string {
+	visible := s.Visible()
+	lines := []string{ansiBold + pad(fmt.Sprintf(" Beads (%s, %d)", statusFilters[s.Filter], len(visible)), width) + ansiReset}
+
+	// Keep the selection in view
+	listRows := rows - 1
+	start := 0
+	if s.Selected >= listRows {
+		start = s.Selected - listRows + 1
+	}
+	for i := start; i < len(visible) && len(lines) < rows; i++ {
+		b := visible[i]
+		assignee := b.AssignedTo
+		if assignee == "" {
+			assignee = "-"
+		}
+		text := pad(truncate(fmt.Sprintf(" P%d %-11s %-12s %s · %s", b.Priority, b.Status, truncate(b.ID, 12), strings.ReplaceAll(b.Title, "\n", " "), assignee), width), width)
+		if i == s.Selected {
+			text = ansiReverse + text
+		} else {
+			text = statusColor(string(b.Status)) + text
+		}
+		lines = append(lines, text+ansiReset)
+	}
+	return fill(lines, width, rows)
+}
+
+func (s *State) agentPane(width, rows int) []string {
+	agents := append([]models.Agent(nil), s.Agents...)
+	sort.SliceStable(agents, func(i, j int) bool {
+		if (agents[i].Status == "working") != (agents[j].Status == "working") {
+			return agents[i].Status == "working"
+		}
+		return agents[i].Name < agents[j].Name
+	})
+	lines := []string{ansiBold + pad(fmt.Sprintf(" Agents (%d)", len(agents)), width) + ansiReset}
+	for _, a := range agents {
+		if len(lines) >= rows {
+			break
+		}
+		text := fmt.Sprintf(" %-9s %s", a.Status, a.Name)
+		if a.CurrentBead != "" {
+			text += " → " + a.CurrentBead
+		}
+		lines = append(lines, statusColor(a.Status)+pad(truncate(text, width), width)+ansiReset)
+	}
+	return fill(lines, width, rows)
+}
+
+func (s *State) logPane(width, rows int) []string {
+	lines := []string{ansiBold + pad(" Action log", width) + ansiReset}
+	logs := s.Logs
+	if n := rows - 1; len(logs) > n {
+		logs = logs[len(logs)-n:]
+	}
+	for _, entry := range logs {
+		lines = append(lines, logColor(entry)+pad(truncate(formatLog(entry), width), width)+ansiReset)
+	}
+	return fill(lines, width, rows)
+}
+
+func (s *State) footer(width int) string {
+	if s.Pending != nil {
+		return ansiYellow + ansiBold + pad(fmt.Sprintf(" %s %s? (y/n)", s.Pending.Label, s.Pending.BeadID), width) + ansiReset
+	}
+	help := " ↑/↓ select · a approve · e escalate · c close · f filter · r refresh · q quit"
+	if s.Message != "" {
+		help = " " + s.Message + " ·" + help
+	}
+	return pad(truncate(help, width), width)
+}
+
+// formatLog renders action log entries from their metadata, other entries
+// by their message
+func formatLog(entry LogEntry) string {
+	meta := func(key string) string {
+		if v, ok := entry.Metadata[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+	ts := entry.Timestamp.Local().Format("15:04:05")
+	if action := meta("action_type"); action != "" {
+		text := fmt.Sprintf(" %s %-8s %-12s %s %s", ts, meta("status"), meta("bead_id"), action, meta("agent_id"))
+		if msg := meta("message"); msg != "" {
+			text += ": " + msg
+		}
+		return strings.ReplaceAll(text, "\n", " ")
+	}
+	return strings.ReplaceAll(fmt.Sprintf(" %s %-8s %s: %s", ts, entry.Level, entry.Source, entry.Message), "\n", " ")
+}
+
+func statusColor(status string) string {
+	switch status {
+	case "in_progress", "working":
+		return ansiGreen
+	case "blocked", "deciding":
+		return ansiYellow
+	case "closed", "paused":
+		return ansiDim
+	}
+	return ""
+}
+
+func logColor(entry LogEntry) string {
+	if status, _ := entry.Metadata["status"].(string); status == "error" || entry.Level == "error" {
+		return ansiRed
+	}
+	if entry.Level == "warn" || entry.Level == "warning" {
+		return ansiYellow
+	}
+	if _, ok := entry.Metadata["action_type"]; ok {
+		return ansiCyan
+	}
+	return ""
+}
+
+// truncate cuts s to at most width runes
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	if width == 1 {
+		return string(runes[:1])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// pad extends s with spaces to width visible runes, ignoring escape codes
+func pad(s string, width int) string {
+	n := visibleWidth(s)
+	if n >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+func visibleWidth(s string) int {
+	n := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case inEscape:
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				inEscape = false
+			}
+		case r == '\x1b':
+			inEscape = true
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+func fill(lines []string, width, rows int) []string {
+	for len(lines) < rows {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	return lines[:rows]
+}
@@ -0,0 +1,240 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	bigQueryEndpoint = "https://bigquery.googleapis.com"
+	bigQueryScope    = "https://www.googleapis.com/auth/bigquery.insertdata"
+	googleTokenURL   = "https://oauth2.googleapis.com/token"
+	// tokenEarlyRefresh renews an access token this long before it expires
+	tokenEarlyRefresh = time.Minute
+)
+
+// BigQueryConfig locates a BigQuery dataset. Requests are authorized with
+// AccessToken when set, otherwise with a service account key read from
+// CredentialsFile or GOOGLE_APPLICATION_CREDENTIALS.
+type BigQueryConfig struct {
+	Project         string
+	Dataset         string
+	CredentialsFile string
+	AccessToken     string
+	Endpoint        string // Default https://bigquery.googleapis.com
+}
+
+// serviceAccountKey is the part of a service account key file used to
+// request access tokens
+type serviceAccountKey struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// BigQuerySink streams rows with the tabledata.insertAll API. Each row's ID
+// is sent as its insertId, which BigQuery uses to drop rows delivered
+// twice. Columns the table lacks are ignored.
+type BigQuerySink struct {
+	cfg    BigQueryConfig
+	key    *serviceAccountKey
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewBigQuerySink creates a sink for the configured dataset
+func NewBigQuerySink(cfg BigQueryConfig) (*BigQuerySink, error) {
+	if cfg.Project == "" || cfg.Dataset == "" {
+		return nil, fmt.Errorf("bigquery project and dataset are required")
+	}
+	if !identifierRe.MatchString(cfg.Dataset) {
+		return nil, fmt.Errorf("invalid bigquery dataset name %q", cfg.Dataset)
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = bigQueryEndpoint
+	}
+	s := &BigQuerySink{cfg: cfg, client: &http.Client{Timeout: 30 * time.Second}, now: time.Now}
+	if cfg.AccessToken != "" {
+		return s, nil
+	}
+
+	path := cfg.CredentialsFile
+	if path == "" {
+		path = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	if path == "" {
+		return nil, fmt.Errorf("bigquery credentials are required")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bigquery credentials: %w", err)
+	}
+	var key serviceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil || key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, fmt.Errorf("%s is not a service account key", path)
+	}
+	if _, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(key.PrivateKey)); err != nil {
+		return nil, fmt.Errorf("invalid service account private key: %w", err)
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = googleTokenURL
+	}
+	s.key = &key
+	return s, nil
+}
+
+// Name returns "bigquery"
+func (s *BigQuerySink) Name() string {
+	return "bigquery"
+}
+
+type insertAllRow struct {
+	InsertID string                 `json:"insertId,omitempty"`
+	JSON     map[string]interface{} `json:"json"`
+}
+
+type insertAllResponse struct {
+	InsertErrors []struct {
+		Index  int `json:"index"`
+		Errors []struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"insertErrors"`
+}
+
+// Insert adds rows to a table
+func (s *BigQuerySink) Insert(ctx context.Context, table string, rows []Row) error {
+	if !identifierRe.MatchString(table) {
+		return fmt.Errorf("%w: invalid table name %q", ErrRejected, table)
+	}
+	payload := struct {
+		IgnoreUnknownValues bool           `json:"ignoreUnknownValues"`
+		Rows                []insertAllRow `json:"rows"`
+	}{IgnoreUnknownValues: true}
+	for _, row := range rows {
+		payload.Rows = append(payload.Rows, insertAllRow{InsertID: row.ID, JSON: row.Values})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+
+	token, err := s.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	u := fmt.Sprintf("%s/bigquery/v2/projects/%s/datasets/%s/tables/%s/insertAll",
+		strings.TrimSuffix(s.cfg.Endpoint, "/"), url.PathEscape(s.cfg.Project), s.cfg.Dataset, table)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		s.mu.Lock()
+		s.token = ""
+		s.mu.Unlock()
+	}
+	if resp.StatusCode >= 300 {
+		return statusError("bigquery insert into "+table, resp.StatusCode, resp.Status, data)
+	}
+
+	// Rows with errors fail the whole request, so none were inserted
+	var result insertAllResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("invalid bigquery response: %w", err)
+	}
+	for _, insertErr := range result.InsertErrors {
+		for _, e := range insertErr.Errors {
+			if e.Reason == "stopped" {
+				continue
+			}
+			return fmt.Errorf("%w: bigquery insert into %s: row %d: %s: %s", ErrRejected, table, insertErr.Index, e.Reason, e.Message)
+		}
+	}
+	return nil
+}
+
+// accessToken returns the configured token, or one issued for the service
+// account, renewed shortly before it expires
+func (s *BigQuerySink) accessToken(ctx context.Context) (string, error) {
+	if s.key == nil {
+		return s.cfg.AccessToken, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if s.token != "" && now.Before(s.expires.Add(-tokenEarlyRefresh)) {
+		return s.token, nil
+	}
+
+	// Exchange a signed assertion for a token (RFC 7523)
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(s.key.PrivateKey))
+	if err != nil {
+		return "", err
+	}
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   s.key.ClientEmail,
+		"scope": bigQueryScope,
+		"aud":   s.key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(privateKey)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.key.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("bigquery token request: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("invalid bigquery token response")
+	}
+	s.token = token.AccessToken
+	s.expires = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return s.token, nil
+}
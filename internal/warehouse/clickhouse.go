@@ -0,0 +1,118 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// identifierRe matches the database and table names a sink accepts, which
+// are quoted into SQL
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ClickHouseConfig locates a ClickHouse server's HTTP interface.
+// Credentials fall back to the CLICKHOUSE_USER and CLICKHOUSE_PASSWORD
+// environment variables.
+type ClickHouseConfig struct {
+	URL      string // Default http://localhost:8123
+	Database string // Default "default"
+	User     string
+	Password string
+}
+
+// ClickHouseSink inserts rows through ClickHouse's HTTP interface in
+// JSONEachRow format. Columns the table lacks are skipped, so a table may
+// keep only the columns it needs. ClickHouse doesn't deduplicate by ID; use
+// a ReplacingMergeTree keyed on id to collapse retried rows.
+type ClickHouseSink struct {
+	cfg    ClickHouseConfig
+	client *http.Client
+}
+
+// NewClickHouseSink creates a sink for the configured server
+func NewClickHouseSink(cfg ClickHouseConfig) (*ClickHouseSink, error) {
+	if cfg.URL == "" {
+		cfg.URL = "http://localhost:8123"
+	}
+	if cfg.Database == "" {
+		cfg.Database = "default"
+	}
+	if !identifierRe.MatchString(cfg.Database) {
+		return nil, fmt.Errorf("invalid clickhouse database name %q", cfg.Database)
+	}
+	if cfg.User == "" {
+		cfg.User = os.Getenv("CLICKHOUSE_USER")
+		cfg.Password = os.Getenv("CLICKHOUSE_PASSWORD")
+	}
+	if _, err := url.Parse(cfg.URL); err != nil {
+		return nil, fmt.Errorf("invalid clickhouse url: %w", err)
+	}
+	return &ClickHouseSink{cfg: cfg, client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// Name returns "clickhouse"
+func (s *ClickHouseSink) Name() string {
+	return "clickhouse"
+}
+
+// Insert adds rows to a table
+func (s *ClickHouseSink) Insert(ctx context.Context, table string, rows []Row) error {
+	if !identifierRe.MatchString(table) {
+		return fmt.Errorf("%w: invalid table name %q", ErrRejected, table)
+	}
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, row := range rows {
+		if err := enc.Encode(row.Values); err != nil {
+			return fmt.Errorf("%w: %v", ErrRejected, err)
+		}
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(s.cfg.URL, "/") + "/")
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("query", fmt.Sprintf("INSERT INTO `%s`.`%s` FORMAT JSONEachRow", s.cfg.Database, table))
+	q.Set("input_format_skip_unknown_fields", "1")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.cfg.User != "" {
+		req.Header.Set("X-ClickHouse-User", s.cfg.User)
+		req.Header.Set("X-ClickHouse-Key", s.cfg.Password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return statusError("clickhouse insert into "+table, resp.StatusCode, resp.Status, msg)
+	}
+	return nil
+}
+
+// statusError describes a failed request, as ErrRejected when repeating it
+// can't succeed
+func statusError(op string, code int, status string, msg []byte) error {
+	err := fmt.Errorf("%s: %s: %s", op, status, strings.TrimSpace(string(msg)))
+	if code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests &&
+		code != http.StatusUnauthorized && code != http.StatusForbidden {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+package warehouse
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jordanhubbard/loom/pkg/config"
+)
+
+// NewSink opens the sink an analytics sink config names, or returns nil
+// when none is configured
+func NewSink(cfg config.AnalyticsSinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case "":
+		return nil, nil
+	case "clickhouse":
+		return NewClickHouseSink(ClickHouseConfig{
+			URL:      cfg.ClickHouse.URL,
+			Database: cfg.ClickHouse.Database,
+			User:     cfg.ClickHouse.User,
+			Password: cfg.ClickHouse.Password,
+		})
+	case "bigquery":
+		return NewBigQuerySink(BigQueryConfig{
+			Project:         cfg.BigQuery.Project,
+			Dataset:         cfg.BigQuery.Dataset,
+			CredentialsFile: cfg.BigQuery.CredentialsFile,
+			AccessToken:     cfg.BigQuery.AccessToken,
+			Endpoint:        cfg.BigQuery.Endpoint,
+		})
+	default:
+		return nil, fmt.Errorf("unknown analytics sink type %q", cfg.Type)
+	}
+}
+
+// MirrorConfigFrom returns the mirror settings of an analytics sink config.
+// The deployment defaults to the hostname.
+func MirrorConfigFrom(cfg config.AnalyticsSinkConfig) MirrorConfig {
+	deployment := cfg.Deployment
+	if deployment == "" {
+		deployment, _ = os.Hostname()
+	}
+	return MirrorConfig{
+		Deployment:    deployment,
+		LogsTable:     cfg.LogsTable,
+		ReportsTable:  cfg.ReportsTable,
+		BatchSize:     cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		QueueSize:     cfg.QueueSize,
+	}
+}
@@ -0,0 +1,256 @@
+package warehouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/logging"
+	"github.com/jordanhubbard/loom/internal/patterns"
+)
+
+const (
+	defaultLogsTable     = "loom_request_logs"
+	defaultReportsTable  = "loom_pattern_reports"
+	defaultBatchSize     = 500
+	defaultFlushInterval = 5 * time.Second
+	defaultQueueSize     = 50000
+	// maxRetryBackoff caps the wait between failed inserts
+	maxRetryBackoff = time.Minute
+)
+
+var warehouseLog = logging.Module("warehouse")
+
+// MirrorConfig configures a Mirror
+type MirrorConfig struct {
+	Deployment    string        // Written to every row
+	LogsTable     string        // Default loom_request_logs
+	ReportsTable  string        // Default loom_pattern_reports
+	BatchSize     int           // Rows per insert (default 500)
+	FlushInterval time.Duration // Longest a row waits before an insert is attempted (default 5s)
+	QueueSize     int           // Rows held while inserts fail (default 50000)
+}
+
+// MirrorStats describes the state of a Mirror
+type MirrorStats struct {
+	Sink      string `json:"sink"`
+	Depth     int    `json:"depth"`    // Rows waiting to be sent
+	Sent      uint64 `json:"sent"`     // Rows inserted
+	Dropped   uint64 `json:"dropped"`  // Rows that arrived while the queue was full
+	Rejected  uint64 `json:"rejected"` // Rows the store refused
+	Errors    uint64 `json:"errors"`   // Failed inserts, retried
+	LastError string `json:"last_error,omitempty"`
+}
+
+// queuedRow is a row waiting for its table's next insert
+type queuedRow struct {
+	table string
+	row   Row
+}
+
+// Mirror copies request logs and pattern reports to a sink in near real
+// time. Rows are queued as they are observed and inserted in batches by a
+// background goroutine, so a slow or unreachable store never holds up the
+// request path. Failed inserts are retried with backoff; once the queue is
+// full new rows are dropped, since the local database keeps every log.
+type Mirror struct {
+	sink Sink
+	cfg  MirrorConfig
+
+	mu     sync.Mutex
+	queue  []queuedRow
+	closed bool
+	stats  MirrorStats
+
+	sendMu sync.Mutex // Serializes inserts so rows arrive in order
+	wake   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewMirror starts mirroring to sink. Call Close to send what is queued on
+// shutdown.
+func NewMirror(sink Sink, cfg MirrorConfig) *Mirror {
+	if cfg.LogsTable == "" {
+		cfg.LogsTable = defaultLogsTable
+	}
+	if cfg.ReportsTable == "" {
+		cfg.ReportsTable = defaultReportsTable
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	m := &Mirror{
+		sink:  sink,
+		cfg:   cfg,
+		stats: MirrorStats{Sink: sink.Name()},
+		wake:  make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go m.run()
+	return m
+}
+
+// ObserveLog queues a saved request log. It has the signature of an
+// analytics.Logger observer.
+func (m *Mirror) ObserveLog(log *analytics.RequestLog) {
+	m.enqueue(m.cfg.LogsTable, LogRow(m.cfg.Deployment, log))
+}
+
+// ObserveReport queues a stored pattern report
+func (m *Mirror) ObserveReport(report *patterns.StoredReport) {
+	if report == nil || report.Report == nil {
+		return
+	}
+	m.enqueue(m.cfg.ReportsTable, ReportRow(m.cfg.Deployment, report))
+}
+
+func (m *Mirror) enqueue(table string, row Row) {
+	m.mu.Lock()
+	if m.closed || len(m.queue) >= m.cfg.QueueSize {
+		m.stats.Dropped++
+		m.mu.Unlock()
+		return
+	}
+	m.queue = append(m.queue, queuedRow{table: table, row: row})
+	full := len(m.queue) >= m.cfg.BatchSize
+	m.mu.Unlock()
+	if full {
+		m.signal()
+	}
+}
+
+// Flush sends the queued rows now
+func (m *Mirror) Flush(ctx context.Context) error {
+	return m.send(ctx)
+}
+
+// Stats returns the mirror's queue depth and counters
+func (m *Mirror) Stats() MirrorStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	stats.Depth = len(m.queue)
+	return stats
+}
+
+// Close stops the background sender and sends what is queued until ctx is
+// done. Rows observed afterwards are dropped.
+func (m *Mirror) Close(ctx context.Context) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	m.mu.Unlock()
+
+	close(m.stop)
+	<-m.done
+	err := m.send(ctx)
+	if n := m.Stats().Depth; n > 0 {
+		return fmt.Errorf("%d rows not mirrored to %s: %w", n, m.sink.Name(), err)
+	}
+	return err
+}
+
+// run sends queued rows in batches, backing off while inserts fail
+func (m *Mirror) run() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.cfg.FlushInterval)
+	defer ticker.Stop()
+	// Close interrupts an insert in progress; its rows stay queued
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-m.stop
+		cancel()
+	}()
+
+	var backoff time.Duration
+	var retryAt time.Time
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-m.wake:
+		case <-ticker.C:
+		}
+		if time.Now().Before(retryAt) {
+			continue
+		}
+		if err := m.send(ctx); err != nil {
+			if ctx.Err() == nil {
+				warehouseLog.Warn("Analytics mirror insert failed", "sink", m.sink.Name(), "error", err)
+			}
+			backoff = min(max(backoff*2, m.cfg.FlushInterval), maxRetryBackoff)
+			retryAt = time.Now().Add(backoff)
+			continue
+		}
+		backoff = 0
+	}
+}
+
+// send inserts queued rows, one table's consecutive rows at a time, until
+// the queue is empty. Rows of a failed insert stay at the front of the
+// queue; rows the store rejects are dropped.
+func (m *Mirror) send(ctx context.Context) error {
+	m.sendMu.Lock()
+	defer m.sendMu.Unlock()
+	for {
+		m.mu.Lock()
+		if len(m.queue) == 0 {
+			m.queue = nil
+			m.mu.Unlock()
+			return nil
+		}
+		table := m.queue[0].table
+		var rows []Row
+		for _, q := range m.queue {
+			if q.table != table || len(rows) == m.cfg.BatchSize {
+				break
+			}
+			rows = append(rows, q.row)
+		}
+		m.mu.Unlock()
+
+		err := m.sink.Insert(ctx, table, rows)
+
+		m.mu.Lock()
+		if err != nil && !errors.Is(err, ErrRejected) {
+			m.stats.Errors++
+			m.stats.LastError = err.Error()
+			m.mu.Unlock()
+			return err
+		}
+		// Only send removes rows, so the batch is still at the front
+		m.queue = m.queue[len(rows):]
+		if err != nil {
+			m.stats.Rejected += uint64(len(rows))
+			m.stats.LastError = err.Error()
+		} else {
+			m.stats.Sent += uint64(len(rows))
+		}
+		m.mu.Unlock()
+		if err != nil {
+			warehouseLog.Warn("Analytics mirror rows dropped", "sink", m.sink.Name(), "table", table, "rows", len(rows), "error", err)
+		}
+	}
+}
+
+func (m *Mirror) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
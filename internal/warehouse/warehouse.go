@@ -0,0 +1,104 @@
+// Package warehouse mirrors request logs and pattern reports to an external
+// analytical store such as ClickHouse or BigQuery, for organizations that
+// already keep their LLM usage data there.
+package warehouse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/patterns"
+)
+
+// timeFormat is a UTC timestamp both ClickHouse (DateTime64) and BigQuery
+// (TIMESTAMP) parse without extra settings
+const timeFormat = "2006-01-02 15:04:05.000000"
+
+// ErrRejected wraps errors for rows the store refused, such as rows that
+// don't match the table's schema. Sending them again won't help, so they
+// are dropped instead of retried.
+var ErrRejected = errors.New("rows rejected by the analytical store")
+
+// Row is one row to insert. ID identifies the row so stores that
+// deduplicate can drop a row delivered twice.
+type Row struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+// Sink inserts rows into tables of an external store
+type Sink interface {
+	// Name is the store's type, such as "clickhouse"
+	Name() string
+	// Insert adds rows to a table. A failure may be retried with the same
+	// rows, so an insert must be safe to repeat.
+	Insert(ctx context.Context, table string, rows []Row) error
+}
+
+// LogRow converts a request log to a row. Request and response bodies stay
+// in the local database.
+func LogRow(deployment string, log *analytics.RequestLog) Row {
+	return Row{ID: log.ID, Values: map[string]interface{}{
+		"id":                log.ID,
+		"deployment":        deployment,
+		"timestamp":         formatTime(log.Timestamp),
+		"user_id":           log.UserID,
+		"method":            log.Method,
+		"path":              log.Path,
+		"provider_id":       log.ProviderID,
+		"model_name":        log.ModelName,
+		"prompt_tokens":     log.PromptTokens,
+		"completion_tokens": log.CompletionTokens,
+		"total_tokens":      log.TotalTokens,
+		"latency_ms":        log.LatencyMs,
+		"status_code":       log.StatusCode,
+		"cost_usd":          log.CostUSD,
+		"error_message":     log.ErrorMessage,
+		"metadata":          jsonString(log.Metadata),
+	}}
+}
+
+// ReportRow converts a stored pattern report to a row with its headline
+// numbers as columns and the whole report as JSON
+func ReportRow(deployment string, stored *patterns.StoredReport) Row {
+	r := stored.Report
+	values := map[string]interface{}{
+		"id":                  stored.ID,
+		"deployment":          deployment,
+		"analyzed_at":         formatTime(r.AnalyzedAt),
+		"time_window_seconds": int64(r.TimeWindow / time.Second),
+		"total_requests":      r.TotalRequests,
+		"total_cost_usd":      r.TotalCost,
+		"pattern_count":       len(r.Patterns),
+		"anomaly_count":       len(r.Anomalies),
+		"baseline_id":         "",
+		"total_cost_change":   0.0,
+		"report":              jsonString(r),
+		"trends":              "",
+	}
+	if stored.Trends != nil {
+		values["baseline_id"] = stored.Trends.BaselineID
+		values["total_cost_change"] = stored.Trends.TotalCostChange
+		values["trends"] = jsonString(stored.Trends)
+	}
+	return Row{ID: stored.ID, Values: values}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// jsonString renders a value for a string column, empty for an empty map
+func jsonString(v interface{}) string {
+	if m, ok := v.(map[string]string); ok && len(m) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,241 @@
+package warehouse
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jordanhubbard/loom/internal/analytics"
+	"github.com/jordanhubbard/loom/internal/patterns"
+)
+
+// recordingSink keeps inserted rows and fails inserts while err is set
+type recordingSink struct {
+	mu      sync.Mutex
+	err     error
+	inserts []string // table:row IDs of each insert
+}
+
+func (s *recordingSink) Name() string { return "test" }
+
+func (s *recordingSink) Insert(_ context.Context, table string, rows []Row) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	var ids []string
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	s.inserts = append(s.inserts, table+":"+strings.Join(ids, ","))
+	return nil
+}
+
+func (s *recordingSink) setErr(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+func (s *recordingSink) got() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.inserts...)
+}
+
+func TestMirror(t *testing.T) {
+	sink := &recordingSink{}
+	m := NewMirror(sink, MirrorConfig{Deployment: "prod", BatchSize: 2, FlushInterval: time.Hour, QueueSize: 5})
+	defer m.Close(context.Background())
+	ctx := context.Background()
+
+	// Rows go out in order, batched per table
+	sink.setErr(errors.New("connection refused"))
+	m.ObserveLog(&analytics.RequestLog{ID: "l1"})
+	m.ObserveLog(&analytics.RequestLog{ID: "l2"})
+	m.ObserveLog(&analytics.RequestLog{ID: "l3"})
+	m.ObserveReport(&patterns.StoredReport{ID: "r1", Report: &patterns.PatternReport{}})
+	m.ObserveLog(&analytics.RequestLog{ID: "l4"})
+	m.ObserveLog(&analytics.RequestLog{ID: "l5"}) // Queue full
+	if err := m.Flush(ctx); err == nil {
+		t.Fatal("Flush succeeded with a failing sink")
+	}
+	if stats := m.Stats(); stats.Depth != 5 || stats.Dropped != 1 || stats.Errors == 0 {
+		t.Fatalf("stats = %+v, want 5 queued and 1 dropped", stats)
+	}
+
+	sink.setErr(nil)
+	if err := m.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	want := []string{"loom_request_logs:l1,l2", "loom_request_logs:l3", "loom_pattern_reports:r1", "loom_request_logs:l4"}
+	if got := sink.got(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("inserts = %v, want %v", got, want)
+	}
+
+	// Rows the store refuses are dropped rather than retried
+	sink.setErr(fmt.Errorf("%w: no such column", ErrRejected))
+	m.ObserveLog(&analytics.RequestLog{ID: "l6"})
+	if err := m.Flush(ctx); err != nil {
+		t.Fatalf("Flush of rejected rows: %v", err)
+	}
+	if stats := m.Stats(); stats.Depth != 0 || stats.Sent != 5 || stats.Rejected != 1 {
+		t.Errorf("stats = %+v, want 5 sent and 1 rejected", stats)
+	}
+}
+
+func TestMirror_SendsInBackground(t *testing.T) {
+	sink := &recordingSink{}
+	m := NewMirror(sink, MirrorConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	m.ObserveLog(&analytics.RequestLog{ID: "l1"})
+	deadline := time.Now().Add(5 * time.Second)
+	for len(sink.got()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	m.ObserveLog(&analytics.RequestLog{ID: "l2"})
+	if err := m.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := sink.got(); len(got) != 2 || got[1] != "loom_request_logs:l2" {
+		t.Errorf("inserts = %v, want l1 in the background and l2 on Close", got)
+	}
+}
+
+func TestClickHouseSink(t *testing.T) {
+	var query, user string
+	var rows []map[string]interface{}
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, user = r.URL.Query().Get("query"), r.Header.Get("X-ClickHouse-User")
+		rows = nil
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var row map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				t.Errorf("bad row %q: %v", scanner.Text(), err)
+			}
+			rows = append(rows, row)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink, err := NewClickHouseSink(ClickHouseConfig{URL: srv.URL, Database: "usage", User: "loom"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Date(2026, 4, 1, 9, 30, 0, 0, time.FixedZone("PDT", -7*3600))
+	log := LogRow("prod", &analytics.RequestLog{ID: "l1", Timestamp: ts, ModelName: "gpt-4", TotalTokens: 42})
+	if err := sink.Insert(context.Background(), "requests", []Row{log}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if query != "INSERT INTO `usage`.`requests` FORMAT JSONEachRow" || user != "loom" {
+		t.Errorf("query %q as %q", query, user)
+	}
+	if len(rows) != 1 || rows[0]["timestamp"] != "2026-04-01 16:30:00.000000" || rows[0]["deployment"] != "prod" || rows[0]["total_tokens"] != 42.0 {
+		t.Errorf("rows = %v", rows)
+	}
+
+	status = http.StatusBadRequest
+	if err := sink.Insert(context.Background(), "requests", []Row{log}); !errors.Is(err, ErrRejected) {
+		t.Errorf("Insert on 400 = %v, want ErrRejected", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := sink.Insert(context.Background(), "requests", []Row{log}); err == nil || errors.Is(err, ErrRejected) {
+		t.Errorf("Insert on 503 = %v, want a retryable error", err)
+	}
+	if err := sink.Insert(context.Background(), "requests; DROP TABLE x", []Row{log}); !errors.Is(err, ErrRejected) {
+		t.Errorf("Insert into a bad table name = %v, want ErrRejected", err)
+	}
+}
+
+func TestBigQuerySink(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tokenRequests int
+	var payload struct {
+		Rows []insertAllRow `json:"rows"`
+	}
+	rejectRows := false
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			tokenRequests++
+			claims := jwt.MapClaims{}
+			_, err := jwt.ParseWithClaims(r.FormValue("assertion"), claims, func(*jwt.Token) (interface{}, error) {
+				return &key.PublicKey, nil
+			})
+			if err != nil || claims["iss"] != "loom@example.iam.gserviceaccount.com" || claims["scope"] != bigQueryScope {
+				t.Errorf("assertion claims %v, %v", claims, err)
+			}
+			fmt.Fprint(w, `{"access_token":"tok-1","expires_in":3600}`)
+		case r.URL.Path == "/bigquery/v2/projects/acme/datasets/usage/tables/reports/insertAll":
+			if r.Header.Get("Authorization") != "Bearer tok-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			if rejectRows {
+				fmt.Fprint(w, `{"insertErrors":[{"index":0,"errors":[{"reason":"invalid","message":"no such field: x"}]},{"index":1,"errors":[{"reason":"stopped"}]}]}`)
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	creds, _ := json.Marshal(map[string]string{
+		"type": "service_account", "client_email": "loom@example.iam.gserviceaccount.com",
+		"private_key": string(keyPEM), "token_uri": srv.URL + "/token",
+	})
+	credsFile := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(credsFile, creds, 0600); err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewBigQuerySink(BigQueryConfig{Project: "acme", Dataset: "usage", CredentialsFile: credsFile, Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := ReportRow("prod", &patterns.StoredReport{ID: "r1", Report: &patterns.PatternReport{TotalRequests: 7},
+		Trends: &patterns.TrendReport{BaselineID: "r0", TotalCostChange: 0.25}})
+	for i := 0; i < 2; i++ {
+		if err := sink.Insert(context.Background(), "reports", []Row{report}); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("%d token requests, want the token reused", tokenRequests)
+	}
+	if len(payload.Rows) != 1 || payload.Rows[0].InsertID != "r1" || payload.Rows[0].JSON["baseline_id"] != "r0" || payload.Rows[0].JSON["total_requests"] != 7.0 {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	rejectRows = true
+	if err := sink.Insert(context.Background(), "reports", []Row{report, report}); !errors.Is(err, ErrRejected) || !strings.Contains(err.Error(), "no such field") {
+		t.Errorf("Insert of invalid rows = %v, want ErrRejected", err)
+	}
+}
@@ -3,7 +3,6 @@ package worker
 import (
 	"context"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
@@ -75,7 +74,7 @@ func (p *Pool) SpawnWorker(agent *models.Agent, providerID string) (*Worker, err
 	// Add to pool
 	p.workers[agent.ID] = worker
 
-	log.Printf("Spawned worker %s for agent %s", workerID, agent.Name)
+	workerLog.Info("Spawned worker", "worker_id", workerID, "agent", agent.Name)
 
 	return worker, nil
 }
@@ -96,7 +95,7 @@ func (p *Pool) StopWorker(agentID string) error {
 	// Remove from pool
 	delete(p.workers, agentID)
 
-	log.Printf("Stopped worker for agent %s", agentID)
+	workerLog.Info("Stopped worker", "agent_id", agentID)
 
 	return nil
 }
@@ -190,7 +189,7 @@ func (p *Pool) StopAll() {
 		delete(p.workers, agentID)
 	}
 
-	log.Println("Stopped all workers in pool")
+	workerLog.Info("Stopped all workers in pool")
 }
 
 // PoolStats contains statistics about the worker pool
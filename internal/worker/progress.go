@@ -44,7 +44,8 @@ func (pt *ProgressTracker) Update(iteration int, results []actions.Result) {
 			if path, _ := r.Metadata["path"].(string); path != "" {
 				pt.filesWritten[path] = true
 			}
-		case actions.ActionEditCode, actions.ActionApplyPatch:
+		case actions.ActionEditCode, actions.ActionMultiEdit, actions.ActionApplyPatch,
+			actions.ActionAddImport, actions.ActionInsertFunctionAfter, actions.ActionAddStructField:
 			if path, _ := r.Metadata["path"].(string); path != "" {
 				pt.filesWritten[path] = true
 			}
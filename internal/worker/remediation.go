@@ -0,0 +1,236 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/ci"
+)
+
+// DefaultRepairAttempts is how many times an agent may try to fix a build or
+// test failure its own changes caused before the loop gives up
+const DefaultRepairAttempts = 3
+
+const (
+	// maxRepairErrors caps the parsed errors fed back per attempt
+	maxRepairErrors = 20
+	// maxRepairExcerpt caps the output excerpt kept when no errors parse
+	maxRepairExcerpt = 2000
+)
+
+// diagnosticPattern matches compiler and linter diagnostics such as
+// "store/store.go:12:5: undefined: x" and test failure headers
+var diagnosticPattern = regexp.MustCompile(`^\s*(([\w./\\-]+\.\w+):(\d+)(:\d+)?:?\s.+|--- FAIL: .+|FAIL\s+\S+.*|E\s+.+Error.*|●\s+.+)$`)
+
+// RepairAttempt is one failed build or test run after the agent changed
+// files, and what the repair loop did about it
+type RepairAttempt struct {
+	Attempt   int      `json:"attempt"`
+	Iteration int      `json:"iteration"`
+	Action    string   `json:"action"`
+	Errors    []string `json:"errors,omitempty"`
+	// Excerpt is the interesting part of the output when no errors parse
+	Excerpt string `json:"excerpt,omitempty"`
+	// Files are the files the agent changed before the run
+	Files []string `json:"files,omitempty"`
+	// SnapshotID is the workspace snapshot taken after the run, to restore
+	// the attempt's state
+	SnapshotID string `json:"snapshot_id,omitempty"`
+}
+
+// remediation follows build and test results through the action loop.
+// Once the agent has changed files, each failing verification is an
+// attempt: its parsed errors are fed back with the attempt count and the
+// workspace is snapshotted. A passing run ends the repair; exhausting the
+// attempts ends the loop.
+type remediation struct {
+	max      int
+	edited   bool
+	files    map[string]bool // Changed since the last verification
+	attempts []RepairAttempt
+}
+
+func newRemediation(max int) *remediation {
+	if max == 0 {
+		max = DefaultRepairAttempts
+	}
+	return &remediation{max: max, files: make(map[string]bool)}
+}
+
+// observe processes one iteration's results, returning the indexes of the
+// attempts the failures in it started
+func (rm *remediation) observe(iteration int, results []actions.Result) []int {
+	if rm.max < 0 {
+		return nil
+	}
+	var started []int
+	for _, r := range results {
+		switch r.ActionType {
+		case actions.ActionWriteFile, actions.ActionEditCode, actions.ActionMultiEdit, actions.ActionApplyPatch,
+			actions.ActionAddImport, actions.ActionInsertFunctionAfter, actions.ActionAddStructField:
+			if r.Status == "error" {
+				continue
+			}
+			rm.edited = true
+			if path, _ := r.Metadata["path"].(string); path != "" {
+				rm.files[path] = true
+			}
+		case actions.ActionBuildProject, actions.ActionRunTests:
+			if !verificationFailed(r) {
+				rm.attempts = nil
+				continue
+			}
+			if !rm.edited {
+				// The failure predates the agent's changes
+				continue
+			}
+			files := make([]string, 0, len(rm.files))
+			for f := range rm.files {
+				files = append(files, f)
+			}
+			sort.Strings(files)
+			rm.files = make(map[string]bool)
+			errs, excerpt := parseFailure(r)
+			rm.attempts = append(rm.attempts, RepairAttempt{
+				Attempt:   len(rm.attempts) + 1,
+				Iteration: iteration,
+				Action:    r.ActionType,
+				Errors:    errs,
+				Excerpt:   excerpt,
+				Files:     files,
+			})
+			started = append(started, len(rm.attempts)-1)
+		}
+	}
+	return started
+}
+
+// exhausted reports whether the failures outlasted the allowed attempts
+func (rm *remediation) exhausted() bool {
+	return rm.max > 0 && len(rm.attempts) > rm.max
+}
+
+// feedback tells the agent which repair attempt it is on and what to fix
+func (rm *remediation) feedback() string {
+	if len(rm.attempts) == 0 || rm.exhausted() {
+		return ""
+	}
+	last := rm.attempts[len(rm.attempts)-1]
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n## Repair Attempt %d of %d\n", last.Attempt, rm.max)
+	fmt.Fprintf(&sb, "%s failed after your changes. Fix the errors below, then run %s again.", last.Action, last.Action)
+	if last.Attempt == rm.max {
+		sb.WriteString(" This is the last attempt; if it still fails the bead is escalated.")
+	}
+	sb.WriteString("\n")
+	if last.SnapshotID != "" {
+		fmt.Fprintf(&sb, "The workspace was snapshotted as %s.\n", last.SnapshotID)
+	}
+	if len(last.Errors) > 0 {
+		sb.WriteString("```\n" + strings.Join(last.Errors, "\n") + "\n```\n")
+	}
+	return sb.String()
+}
+
+// dossier describes the failed repair for whoever picks the bead up
+func (rm *remediation) dossier() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Build/test failures persisted through %d repair attempts.\n", rm.max)
+	for _, a := range rm.attempts {
+		fmt.Fprintf(&sb, "\n### Attempt %d (iteration %d): %s failed\n", a.Attempt, a.Iteration, a.Action)
+		if len(a.Files) > 0 {
+			fmt.Fprintf(&sb, "Changed before the run: %s\n", strings.Join(a.Files, ", "))
+		}
+		if a.SnapshotID != "" {
+			fmt.Fprintf(&sb, "Snapshot: %s\n", a.SnapshotID)
+		}
+		if len(a.Errors) > 0 {
+			sb.WriteString("```\n" + strings.Join(a.Errors, "\n") + "\n```\n")
+		} else if a.Excerpt != "" {
+			sb.WriteString("```\n" + a.Excerpt + "\n```\n")
+		}
+	}
+	return sb.String()
+}
+
+// verificationFailed reports whether a build or test result failed
+func verificationFailed(r actions.Result) bool {
+	return r.Status == "error" || (r.Metadata != nil && r.Metadata["success"] == false)
+}
+
+// parseFailure extracts the diagnostics from a failed build or test,
+// falling back to an excerpt of its output
+func parseFailure(r actions.Result) ([]string, string) {
+	var output string
+	for _, key := range []string{"output", "raw_output"} {
+		if s, _ := r.Metadata[key].(string); s != "" {
+			output = s
+			break
+		}
+	}
+	if output == "" {
+		output = r.Message
+	}
+
+	var errs []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !diagnosticPattern.MatchString(line) || seen[line] {
+			continue
+		}
+		seen[line] = true
+		errs = append(errs, strings.TrimSpace(line))
+		if len(errs) == maxRepairErrors {
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return errs, ""
+	}
+	excerpt := ci.Excerpt(output)
+	if len(excerpt) > maxRepairExcerpt {
+		excerpt = excerpt[len(excerpt)-maxRepairExcerpt:]
+	}
+	return nil, excerpt
+}
+
+// snapshotRepair snapshots the workspace after a failed attempt so the
+// state each attempt left behind can be restored
+func snapshotRepair(ctx context.Context, config *LoopConfig, attempt *RepairAttempt) {
+	if config.Router == nil || config.Router.Snapshots == nil || config.ActionContext.ProjectID == "" {
+		return
+	}
+	label := fmt.Sprintf("repair: attempt %d after %s failed", attempt.Attempt, attempt.Action)
+	if config.ActionContext.BeadID != "" {
+		label += " for " + config.ActionContext.BeadID
+	}
+	snap, err := config.Router.Snapshots.CreateSnapshot(ctx, config.ActionContext.ProjectID, label)
+	if err != nil {
+		actionLoopLog.WarnContext(ctx, "Repair snapshot failed", "attempt", attempt.Attempt, "error", err)
+		return
+	}
+	attempt.SnapshotID = snap.ID
+}
+
+// escalateRepair hands a bead whose repair loop didn't converge to the CEO
+// with the failure dossier, and records it as a lesson
+func (w *Worker) escalateRepair(ctx context.Context, config *LoopConfig, rm *remediation) {
+	beadID := config.ActionContext.BeadID
+	dossier := rm.dossier()
+	actionLoopLog.WarnContext(ctx, "Repair loop exhausted", "bead_id", beadID, "attempts", len(rm.attempts))
+	if config.LessonsProvider != nil {
+		_ = config.LessonsProvider.RecordLesson(config.ActionContext.ProjectID, "repair_failure",
+			"Repair loop did not converge", truncateForLesson(dossier), beadID, w.agent.ID)
+	}
+	if beadID == "" || config.Router == nil || config.Router.Escalator == nil {
+		return
+	}
+	if _, err := config.Router.Escalator.EscalateBeadToCEO(beadID, dossier, config.ActionContext.AgentID); err != nil {
+		actionLoopLog.WarnContext(ctx, "Failed to escalate bead after repair loop", "bead_id", beadID, "error", err)
+	}
+}
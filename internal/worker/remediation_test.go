@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeSnapshots struct{ labels []string }
+
+func (f *fakeSnapshots) CreateSnapshot(_ context.Context, _, label string) (*files.Snapshot, error) {
+	f.labels = append(f.labels, label)
+	return &files.Snapshot{ID: "snap-1"}, nil
+}
+
+type fakeEscalator struct{ beadID, reason string }
+
+func (f *fakeEscalator) EscalateBeadToCEO(beadID, reason, _ string) (*models.DecisionBead, error) {
+	f.beadID, f.reason = beadID, reason
+	return &models.DecisionBead{}, nil
+}
+
+var (
+	edit      = actions.Result{ActionType: actions.ActionEditCode, Status: "executed", Metadata: map[string]interface{}{"path": "store/store.go"}}
+	buildFail = actions.Result{ActionType: actions.ActionBuildProject, Status: "executed", Metadata: map[string]interface{}{
+		"success": false,
+		"output":  "# example.com/app/store\nstore/store.go:12:5: undefined: x\nstore/store.go:12:5: undefined: x\n",
+	}}
+	buildPass = actions.Result{ActionType: actions.ActionBuildProject, Status: "executed", Metadata: map[string]interface{}{"success": true}}
+)
+
+func TestRemediationCountsFailuresAfterChanges(t *testing.T) {
+	rm := newRemediation(2)
+
+	// A failure before any change predates the agent's work
+	if started := rm.observe(1, []actions.Result{buildFail}); len(started) != 0 {
+		t.Fatalf("expected no attempt before changes, got %v", started)
+	}
+
+	rm.observe(2, []actions.Result{edit, buildFail})
+	if len(rm.attempts) != 1 || rm.attempts[0].Files[0] != "store/store.go" {
+		t.Fatalf("unexpected attempts %+v", rm.attempts)
+	}
+	if errs := rm.attempts[0].Errors; len(errs) != 1 || errs[0] != "store/store.go:12:5: undefined: x" {
+		t.Errorf("unexpected parsed errors %q", errs)
+	}
+	if fb := rm.feedback(); !strings.Contains(fb, "Repair Attempt 1 of 2") || !strings.Contains(fb, "undefined: x") {
+		t.Errorf("unexpected feedback:\n%s", fb)
+	}
+
+	// A passing build ends the repair
+	rm.observe(3, []actions.Result{buildPass})
+	if len(rm.attempts) != 0 || rm.feedback() != "" {
+		t.Fatalf("expected the repair to end, got %+v", rm.attempts)
+	}
+
+	for i := 4; i < 7; i++ {
+		rm.observe(i, []actions.Result{edit, buildFail})
+	}
+	if !rm.exhausted() {
+		t.Fatal("expected the attempts to be exhausted")
+	}
+	if d := rm.dossier(); !strings.Contains(d, "### Attempt 3 (iteration 6): build_project failed") {
+		t.Errorf("unexpected dossier:\n%s", d)
+	}
+
+	if disabled := newRemediation(-1); len(disabled.observe(1, []actions.Result{edit, buildFail})) != 0 || disabled.exhausted() {
+		t.Error("expected a negative limit to disable the repair loop")
+	}
+}
+
+func TestParseFailureFallsBackToExcerpt(t *testing.T) {
+	errs, excerpt := parseFailure(actions.Result{ActionType: actions.ActionRunTests, Status: "error", Message: "something broke badly"})
+	if len(errs) != 0 || excerpt != "something broke badly" {
+		t.Errorf("got errors %q and excerpt %q", errs, excerpt)
+	}
+}
+
+func TestRepairSnapshotAndEscalation(t *testing.T) {
+	snaps, esc := &fakeSnapshots{}, &fakeEscalator{}
+	config := &LoopConfig{
+		Router:        &actions.Router{Snapshots: snaps, Escalator: esc},
+		ActionContext: actions.ActionContext{ProjectID: "p", BeadID: "b", AgentID: "a"},
+	}
+	rm := newRemediation(1)
+	for i := 1; i <= 2; i++ {
+		for _, idx := range rm.observe(i, []actions.Result{edit, buildFail}) {
+			snapshotRepair(context.Background(), config, &rm.attempts[idx])
+		}
+	}
+	if len(snaps.labels) != 2 || rm.attempts[1].SnapshotID != "snap-1" || !strings.HasSuffix(snaps.labels[0], "for b") {
+		t.Fatalf("unexpected snapshots %v", snaps.labels)
+	}
+
+	w := &Worker{agent: &models.Agent{ID: "a"}}
+	w.escalateRepair(context.Background(), config, rm)
+	if esc.beadID != "b" || !strings.Contains(esc.reason, "Snapshot: snap-1") {
+		t.Errorf("unexpected escalation of %s: %s", esc.beadID, esc.reason)
+	}
+}
@@ -6,19 +6,29 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jordanhubbard/loom/internal/actions"
+	"github.com/jordanhubbard/loom/internal/contextpack"
 	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/internal/memory"
+	"github.com/jordanhubbard/loom/internal/metrics"
+	"github.com/jordanhubbard/loom/internal/promptfrag"
 	"github.com/jordanhubbard/loom/internal/provider"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
 
+var (
+	workerLog       = logging.Module("worker")
+	actionLoopLog   = logging.Module("actionloop")
+	contextPackLog  = logging.Module("contextpack")
+	contextRetryLog = logging.Module("contextretry")
+)
+
 // Worker represents an agent worker that processes tasks
 type Worker struct {
 	id          string
@@ -26,6 +36,8 @@ type Worker struct {
 	provider    *provider.RegisteredProvider
 	db          *database.Database
 	textMode    bool // Use simple text-based actions instead of JSON
+	rolePrompt  string // Role section of the system prompt for the current loop
+	fragments   *promptfrag.Store // Repeated prompt content of the current loop
 	status      WorkerStatus
 	currentTask string
 	startedAt   time.Time
@@ -73,7 +85,7 @@ func (w *Worker) Start() error {
 	w.status = WorkerStatusIdle
 	w.lastActive = time.Now()
 
-	log.Printf("Worker %s started for agent %s using provider %s", w.id, w.agent.Name, w.provider.Config.Name)
+	workerLog.Info("Worker started", "worker_id", w.id, "agent", w.agent.Name, "provider", w.provider.Config.Name)
 
 	// Worker is now ready to receive tasks
 	// The actual task processing will be handled by the pool
@@ -89,7 +101,7 @@ func (w *Worker) Stop() {
 	w.cancel()
 	w.status = WorkerStatusStopped
 
-	log.Printf("Worker %s stopped", w.id)
+	workerLog.Info("Worker stopped", "worker_id", w.id)
 }
 
 // SetDatabase sets the database for conversation context management
@@ -133,7 +145,7 @@ func (w *Worker) ExecuteTask(ctx context.Context, task *Task) (*TaskResult, erro
 		conversationCtx, err = w.db.GetConversationContextByBeadID(task.BeadID)
 		if err != nil {
 			// No existing conversation, create new one
-			log.Printf("No existing conversation for bead %s, creating new session", task.BeadID)
+			workerLog.InfoContext(ctx, "No existing conversation for bead, creating new session", "bead_id", task.BeadID)
 			conversationCtx = models.NewConversationContext(
 				uuid.New().String(),
 				task.BeadID,
@@ -146,12 +158,12 @@ func (w *Worker) ExecuteTask(ctx context.Context, task *Task) (*TaskResult, erro
 
 			// Save new session to database
 			if err := w.db.CreateConversationContext(conversationCtx); err != nil {
-				log.Printf("Warning: Failed to create conversation context: %v", err)
+				workerLog.WarnContext(ctx, "Failed to create conversation context", "error", err)
 				conversationCtx = nil // Fall back to single-shot
 			}
 		} else if conversationCtx.IsExpired() {
 			// Session expired, create new one
-			log.Printf("Conversation session %s expired, creating new session", conversationCtx.SessionID)
+			workerLog.InfoContext(ctx, "Conversation session expired, creating new session", "session_id", conversationCtx.SessionID)
 			conversationCtx = models.NewConversationContext(
 				uuid.New().String(),
 				task.BeadID,
@@ -163,7 +175,7 @@ func (w *Worker) ExecuteTask(ctx context.Context, task *Task) (*TaskResult, erro
 			}
 
 			if err := w.db.CreateConversationContext(conversationCtx); err != nil {
-				log.Printf("Warning: Failed to create conversation context: %v", err)
+				workerLog.WarnContext(ctx, "Failed to create conversation context", "error", err)
 				conversationCtx = nil
 			}
 		}
@@ -220,7 +232,7 @@ func (w *Worker) ExecuteTask(ctx context.Context, task *Task) (*TaskResult, erro
 
 		// Update conversation context in database
 		if err := w.db.UpdateConversationContext(conversationCtx); err != nil {
-			log.Printf("Warning: Failed to update conversation context: %v", err)
+			workerLog.WarnContext(ctx, "Failed to update conversation context", "error", err)
 		}
 	}
 
@@ -285,59 +297,42 @@ func (w *Worker) buildSingleShotMessages(task *Task) []provider.ChatMessage {
 
 // handleTokenLimits truncates messages if they exceed model token limits
 func (w *Worker) handleTokenLimits(messages []provider.ChatMessage) []provider.ChatMessage {
-	// Get model token limit (default to 100K if not specified)
-	modelLimit := w.getModelTokenLimit()
-	maxTokens := int(float64(modelLimit) * 0.8) // Use 80% of limit
+	return w.packContext(messages, -1, nil, nil).Messages
+}
 
-	// Calculate current tokens (rough estimate: 1 token ~= 4 characters)
-	totalTokens := 0
-	for _, msg := range messages {
-		totalTokens += len(msg.Content) / 4
+// packContext packs messages, plus the task's workflow state, file excerpts
+// and memories, into 80% of the model's context window. The first message
+// is the system prompt; the message at index pinned (if any) is never
+// dropped. Older messages go first when the rest doesn't fit.
+func (w *Worker) packContext(messages []provider.ChatMessage, pinned int, task *Task, priorities []contextpack.Kind) *contextpack.Result {
+	var in contextpack.Input
+	for i, msg := range messages {
+		if i == 0 && msg.Role == "system" {
+			in.System = msg.Content
+			continue
+		}
+		in.Messages = append(in.Messages, contextpack.Message{Role: msg.Role, Content: msg.Content, Parts: msg.Parts, Pinned: i == pinned})
 	}
-
-	if totalTokens <= maxTokens {
-		return messages // No truncation needed
+	if task != nil {
+		in.Workflow = task.Workflow
+		in.Files = task.Files
+		in.Memories = task.Memories
 	}
 
-	// Strategy: Sliding window - keep system message + recent messages
-	if len(messages) == 0 {
-		return messages
+	budget := int(float64(w.getModelTokenLimit()) * 0.8) // Use 80% of limit
+	packer := contextpack.NewPacker(budget, priorities)
+	if w.fragments != nil {
+		packer.Count = w.fragments.Tokens
 	}
-
-	systemMsg := messages[0] // Assume first message is system
-	systemTokens := len(systemMsg.Content) / 4
-
-	// Find how many recent messages we can keep
-	recentTokens := 0
-	startIndex := len(messages) // Start from end
-
-	// Work backwards to find where to truncate
-	for i := len(messages) - 1; i > 0; i-- {
-		msgTokens := len(messages[i].Content) / 4
-		if systemTokens+recentTokens+msgTokens > maxTokens {
-			// Can't fit this message
-			startIndex = i + 1
-			break
+	packed := packer.Pack(in)
+	if summary := packed.Summary(); summary != "" {
+		taskID, beadID := "", ""
+		if task != nil {
+			taskID, beadID = task.ID, task.BeadID
 		}
-		recentTokens += msgTokens
+		contextPackLog.Info("Packed context", "worker_id", w.id, "task_id", taskID, "bead_id", beadID, "summary", summary)
 	}
-
-	// If we truncated messages, add notice
-	if startIndex > 1 {
-		truncatedCount := startIndex - 1 // Don't count system message
-		noticeMsg := provider.ChatMessage{
-			Role:    "system",
-			Content: fmt.Sprintf("[Note: %d older messages truncated to stay within token limit]", truncatedCount),
-		}
-
-		// Build result: system message + notice + recent messages
-		result := []provider.ChatMessage{systemMsg, noticeMsg}
-		result = append(result, messages[startIndex:]...)
-		return result
-	}
-
-	// No truncation needed (edge case)
-	return messages
+	return packed
 }
 
 // getModelTokenLimit returns the token limit for the current model.
@@ -389,12 +384,12 @@ func truncateMessages(messages []provider.ChatMessage, fraction float64) []provi
 	return result
 }
 
-// callWithContextRetry calls CreateChatCompletion and retries with
+// callWithContextRetry calls ChatCompletion and retries with
 // progressively smaller message windows on ContextLengthError.
 // Returns the response and the final messages used (which may be truncated).
 func (w *Worker) callWithContextRetry(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, []provider.ChatMessage, error) {
 	// Attempt 1: use messages as-is
-	resp, err := w.provider.Protocol.CreateChatCompletion(ctx, req)
+	resp, err := w.complete(ctx, req)
 	if err == nil {
 		return resp, req.Messages, nil
 	}
@@ -411,13 +406,13 @@ func (w *Worker) callWithContextRetry(ctx context.Context, req *provider.ChatCom
 
 	for _, frac := range fractions {
 		truncated := truncateMessages(messages, frac)
-		log.Printf("[ContextRetry] Retrying with %.0f%% of history (%d -> %d messages)",
-			frac*100, len(messages), len(truncated))
+		contextRetryLog.InfoContext(ctx, "Retrying with part of the history",
+			"fraction", frac, "messages", len(messages), "kept", len(truncated))
 
 		retryReq := *req
 		retryReq.Messages = truncated
 
-		resp, err = w.provider.Protocol.CreateChatCompletion(ctx, &retryReq)
+		resp, err = w.complete(ctx, &retryReq)
 		if err == nil {
 			return resp, truncated, nil
 		}
@@ -428,17 +423,17 @@ func (w *Worker) callWithContextRetry(ctx context.Context, req *provider.ChatCom
 
 	// Final fallback: we're at system+user only and still too big.
 	// Truncate the user message content to half its size.
-	minimal := truncateMessages(messages, 0.0)
+	minimal := w.fragments.Expand(truncateMessages(messages, 0.0))
 	if len(minimal) >= 2 {
 		last := &minimal[len(minimal)-1]
 		if len(last.Content) > 2000 {
 			half := len(last.Content) / 2
 			last.Content = last.Content[:half] + "\n\n[Content truncated to fit context window]"
-			log.Printf("[ContextRetry] Final attempt: truncated user message to %d chars", len(last.Content))
+			contextRetryLog.InfoContext(ctx, "Final attempt: truncated user message", "chars", len(last.Content))
 
 			retryReq := *req
 			retryReq.Messages = minimal
-			resp, err = w.provider.Protocol.CreateChatCompletion(ctx, &retryReq)
+			resp, err = w.complete(ctx, &retryReq)
 			if err == nil {
 				return resp, minimal, nil
 			}
@@ -448,6 +443,17 @@ func (w *Worker) callWithContextRetry(ctx context.Context, req *provider.ChatCom
 	return nil, minimal, fmt.Errorf("context length exceeded after all retry attempts: %w", err)
 }
 
+// complete sends a chat completion request with its prompt fragments
+// expanded, so repeated content goes to the provider once
+func (w *Worker) complete(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	if w.fragments != nil {
+		expanded := *req
+		expanded.Messages = w.fragments.Expand(req.Messages)
+		req = &expanded
+	}
+	return w.provider.ChatCompletion(ctx, req)
+}
+
 // messageExists checks if a message with the same content already exists in history
 func (w *Worker) messageExists(messages []models.ChatMessage, content string) bool {
 	for _, msg := range messages {
@@ -520,7 +526,13 @@ type Task struct {
 	Context             string
 	BeadID              string
 	ProjectID           string
+	Subproject          string                      // Optional: monorepo subproject the bead is scoped to
 	ConversationSession *models.ConversationContext // Optional: enables multi-turn conversation
+
+	// Optional context packed into the prompt as the token budget allows
+	Workflow string             // Current workflow state of the bead
+	Files    []contextpack.File // Relevant file excerpts, most relevant first
+	Memories []string           // Recalled notes, most relevant first
 }
 
 // TaskResult represents the result of task execution
@@ -567,14 +579,46 @@ type LoopConfig struct {
 	LessonsProvider LessonsProvider
 	DB              *database.Database
 	TextMode        bool // Use simple text-based actions (~10 commands) instead of JSON (60+)
+	// ContextPriorities orders optional prompt context when it doesn't all
+	// fit; nil uses contextpack.DefaultPriorities
+	ContextPriorities []contextpack.Kind
+	// RolePrompt describes the agent's role and the actions it may use
+	RolePrompt string
+	// Images supplies the bead's images and those agents attach, for
+	// providers with vision; nil sends text only
+	Images ImageSource
+	// MaxRepairAttempts is how many failed builds or test runs after the
+	// agent's changes are fed back for repair before the bead is escalated
+	// (0 uses DefaultRepairAttempts, negative disables the repair loop)
+	MaxRepairAttempts int
+	// MaxCorrections is how many malformed responses in a row are fed back
+	// with the error before the loop gives up (0 uses
+	// actions.DefaultMaxCorrectionAttempts, negative disables correction)
+	MaxCorrections int
+}
+
+// ImageSource supplies images for the action loop
+type ImageSource interface {
+	// BeadImageParts returns message parts for the images attached to a bead
+	BeadImageParts(beadID string) ([]provider.ContentPart, error)
+	// ImagePart returns the message part of one attached image
+	ImagePart(id string) (provider.ContentPart, error)
 }
 
 // LoopResult contains the result of a multi-turn action loop.
 type LoopResult struct {
 	*TaskResult
 	Iterations     int              `json:"iterations"`
-	TerminalReason string           `json:"terminal_reason"` // "completed", "max_iterations", "escalated", "error", "no_actions", "parse_failures"
+	TerminalReason string           `json:"terminal_reason"` // "completed", "max_iterations", "escalated", "error", "no_actions", "parse_failures", "remediation_failed"
 	ActionLog      []ActionLogEntry `json:"action_log"`
+	// Repairs are the failed verifications of the current repair, or of the
+	// one that exhausted its attempts
+	Repairs []RepairAttempt `json:"repairs,omitempty"`
+	// ContextDropped is the context left out of the last prompt
+	ContextDropped []contextpack.Dropped `json:"context_dropped,omitempty"`
+	// PromptTokensSaved is the prompt tokens not sent because content was
+	// repeated within a request
+	PromptTokensSaved int `json:"prompt_tokens_saved,omitempty"`
 }
 
 // ActionLogEntry records a single iteration of the action loop.
@@ -613,10 +657,53 @@ func isConversationalResponse(response string) bool {
 	return false
 }
 
+// actionResponseFormat constrains action loop responses to the schema of the
+// action format in use, so providers that support structured output can't
+// produce an unparseable action
+func actionResponseFormat(textMode bool) *provider.ResponseFormat {
+	if textMode {
+		return provider.SchemaFormat("simple_action", actions.SimpleJSONSchema())
+	}
+	return provider.SchemaFormat("action_envelope", actions.EnvelopeSchema())
+}
+
+// attachedImages returns the images attach_image actions added and the
+// screenshots browser actions took, so the model sees them with the results
+func attachedImages(ctx context.Context, images ImageSource, results []actions.Result) []provider.ContentPart {
+	if images == nil {
+		return nil
+	}
+	var parts []provider.ContentPart
+	for _, r := range results {
+		if r.Status != "executed" {
+			continue
+		}
+		var ids []string
+		switch r.ActionType {
+		case actions.ActionAttachImage:
+			if id, _ := r.Metadata["image_id"].(string); id != "" {
+				ids = []string{id}
+			}
+		case actions.ActionBrowser:
+			ids, _ = r.Metadata["image_ids"].([]string)
+		}
+		for _, id := range ids {
+			part, err := images.ImagePart(id)
+			if err != nil {
+				actionLoopLog.WarnContext(ctx, "Failed to load attached image", "image_id", id, "error", err)
+				continue
+			}
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
 // ExecuteTaskWithLoop runs the task in a multi-turn action loop:
 // call LLM → parse actions → execute → format results → feed back → repeat.
 func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *LoopConfig) (*LoopResult, error) {
 	w.textMode = config.TextMode
+	w.rolePrompt = config.RolePrompt
 	w.mu.Lock()
 	if w.status != WorkerStatusIdle {
 		w.mu.Unlock()
@@ -626,12 +713,14 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 	w.currentTask = task.ID
 	w.lastActive = time.Now()
 	w.mu.Unlock()
+	w.fragments = promptfrag.NewStore(contextpack.EstimateTokens)
 
 	defer func() {
 		w.mu.Lock()
 		w.status = WorkerStatusIdle
 		w.currentTask = ""
 		w.lastActive = time.Now()
+		w.fragments = nil
 		w.mu.Unlock()
 	}()
 
@@ -657,7 +746,7 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 				conversationCtx.Metadata["agent_name"] = w.agent.Name
 			}
 			if createErr := config.DB.CreateConversationContext(conversationCtx); createErr != nil {
-				log.Printf("[ActionLoop] Warning: Failed to create conversation context: %v", createErr)
+				actionLoopLog.WarnContext(ctx, "Failed to create conversation context", "error", createErr)
 				conversationCtx = nil
 			}
 		} else if conversationCtx != nil && conversationCtx.IsExpired() {
@@ -699,6 +788,16 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 		}
 	}
 
+	// The task prompt stays in every request however long the loop runs
+	taskIndex := len(messages) - 1
+	if config.Images != nil && task.BeadID != "" {
+		parts, err := config.Images.BeadImageParts(task.BeadID)
+		if err != nil {
+			actionLoopLog.WarnContext(ctx, "Failed to load bead images", "bead_id", task.BeadID, "error", err)
+		}
+		messages[taskIndex].Parts = parts
+	}
+
 	loopResult := &LoopResult{
 		TaskResult: &TaskResult{
 			TaskID:   task.ID,
@@ -713,7 +812,9 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 	var allActions []actions.Result
 	consecutiveParseFailures := 0
 	consecutiveValidationFailures := 0
+	maxCorrections := actions.MaxCorrectionAttempts(config.MaxCorrections)
 	actionHashes := make(map[string]int) // for inner loop detection
+	repairs := newRemediation(config.MaxRepairAttempts)
 
 	for iteration := 0; iteration < maxIter; iteration++ {
 		select {
@@ -726,19 +827,22 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 		default:
 		}
 
-		// Handle token limits
-		trimmedMessages := w.handleTokenLimits(messages)
+		// Pack the conversation and task context into the token budget
+		packed := w.packContext(messages, taskIndex, task, config.ContextPriorities)
+		trimmedMessages := packed.Messages
+		loopResult.ContextDropped = packed.Dropped
 
 		req := &provider.ChatCompletionRequest{
 			Model:          w.provider.Config.Model,
 			Messages:       trimmedMessages,
 			Temperature:    0.7,
-			ResponseFormat: &provider.ResponseFormat{Type: "json_object"},
+			ResponseFormat: actionResponseFormat(config.TextMode),
 		}
 
-		log.Printf("[ActionLoop] Iteration %d/%d for task %s (messages: %d, textMode: %v)", iteration+1, maxIter, task.ID, len(trimmedMessages), config.TextMode)
+		actionLoopLog.DebugContext(ctx, "Iteration", "iteration", iteration+1, "max", maxIter, "task_id", task.ID, "messages", len(trimmedMessages), "text_mode", config.TextMode)
 
 		resp, usedMsgs, err := w.callWithContextRetry(ctx, req)
+		loopResult.PromptTokensSaved = int(w.fragments.Stats().TokensSaved)
 		if err != nil {
 			loopResult.TerminalReason = "error"
 			loopResult.Iterations = iteration + 1
@@ -748,9 +852,11 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 			loopResult.CompletedAt = time.Now()
 			return loopResult, fmt.Errorf("LLM call failed on iteration %d: %w", iteration+1, err)
 		}
-		// If messages were truncated by retry, update the working set
+		// If messages were truncated by retry, update the working set,
+		// keeping the unpacked system prompt so sections aren't added twice
 		if len(usedMsgs) < len(trimmedMessages) {
-			messages = usedMsgs
+			messages = append([]provider.ChatMessage{messages[0]}, usedMsgs[1:]...)
+			taskIndex = -1
 		}
 
 		if len(resp.Choices) == 0 {
@@ -789,7 +895,8 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 				// Give specific feedback and let the model retry — don't count
 				// this as a hard parse failure.
 				consecutiveValidationFailures++
-				if consecutiveValidationFailures >= 4 {
+				if consecutiveValidationFailures > maxCorrections {
+					metrics.NewMetrics().RecordActionCorrection(actions.CorrectionOutcomeExhausted, consecutiveValidationFailures-1)
 					loopResult.TerminalReason = "validation_failures"
 					loopResult.Iterations = iteration + 1
 					loopResult.Actions = allActions
@@ -799,11 +906,14 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 					return loopResult, nil
 				}
 				feedback := fmt.Sprintf("## Action Validation Error\n\nYour JSON was valid but the action is incomplete: %v\n\nPlease include all required fields. For write_file you need both \"path\" and \"content\". For read_code you need \"path\". Check the action schema and try again.", validationErr)
+				if !config.TextMode {
+					feedback = actions.BuildCorrectionPrompt(parseErr, llmResponse, consecutiveValidationFailures, maxCorrections)
+				}
 				messages = append(messages, provider.ChatMessage{Role: "user", Content: feedback})
 				if conversationCtx != nil {
 					conversationCtx.AddMessage("user", feedback, len(feedback)/4)
 				}
-				log.Printf("[ActionLoop] Validation error on iteration %d: %v", iteration+1, validationErr)
+				actionLoopLog.WarnContext(ctx, "Validation error", "iteration", iteration+1, "error", validationErr)
 				continue
 			}
 
@@ -823,29 +933,36 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 				if conversationCtx != nil {
 					conversationCtx.AddMessage("user", feedback, len(feedback)/4)
 				}
-				log.Printf("[ActionLoop] Conversational slip on iteration %d, nudging back to autonomous mode", iteration+1)
+				actionLoopLog.InfoContext(ctx, "Conversational slip, nudging back to autonomous mode", "iteration", iteration+1)
 				continue
 			}
 
 			consecutiveParseFailures++
-			if consecutiveParseFailures >= 2 {
+			if consecutiveParseFailures > maxCorrections {
+				metrics.NewMetrics().RecordActionCorrection(actions.CorrectionOutcomeExhausted, consecutiveParseFailures-1)
 				loopResult.TerminalReason = "parse_failures"
 				loopResult.Iterations = iteration + 1
 				loopResult.Actions = allActions
 				loopResult.Success = false
-				loopResult.Error = fmt.Sprintf("two consecutive parse failures: %v", parseErr)
+				loopResult.Error = fmt.Sprintf("%d consecutive parse failures: %v", consecutiveParseFailures, parseErr)
 				loopResult.CompletedAt = time.Now()
 				return loopResult, nil
 			}
 
 			feedback := fmt.Sprintf("## Parse Error\n\nFailed to parse your response as valid JSON actions: %v\n\nPlease respond with a valid JSON object containing an \"actions\" array. Do not include any text outside the JSON.", parseErr)
+			if !config.TextMode {
+				feedback = actions.BuildCorrectionPrompt(parseErr, llmResponse, consecutiveParseFailures, maxCorrections)
+			}
 			messages = append(messages, provider.ChatMessage{Role: "user", Content: feedback})
 			if conversationCtx != nil {
 				conversationCtx.AddMessage("user", feedback, len(feedback)/4)
 			}
-			log.Printf("[ActionLoop] Parse error on iteration %d: %v", iteration+1, parseErr)
+			actionLoopLog.WarnContext(ctx, "Parse error", "iteration", iteration+1, "error", parseErr)
 			continue
 		}
+		if attempts := consecutiveParseFailures + consecutiveValidationFailures; attempts > 0 {
+			metrics.NewMetrics().RecordActionCorrection(actions.CorrectionOutcomeCorrected, attempts)
+		}
 		consecutiveParseFailures = 0
 		consecutiveValidationFailures = 0
 
@@ -898,6 +1015,21 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 		// Record lessons from build failures even on non-terminal iterations
 		w.recordBuildLessons(config, env, results)
 
+		for _, i := range repairs.observe(iteration+1, results) {
+			snapshotRepair(ctx, config, &repairs.attempts[i])
+		}
+		loopResult.Repairs = repairs.attempts
+		if repairs.exhausted() {
+			loopResult.TerminalReason = "remediation_failed"
+			loopResult.Iterations = iteration + 1
+			loopResult.Actions = allActions
+			loopResult.Success = false
+			loopResult.Error = fmt.Sprintf("build or tests still failing after %d repair attempts", repairs.max)
+			loopResult.CompletedAt = time.Now()
+			w.escalateRepair(ctx, config, repairs)
+			return loopResult, nil
+		}
+
 		// Inner loop detection: hash the actions and check for repeats
 		hash := hashActions(env.Actions)
 		actionHashes[hash]++
@@ -920,12 +1052,12 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 			return loopResult, nil
 		}
 		if actionHashes[hash] >= 5 {
-			log.Printf("[ActionLoop] Warning: same actions repeated %d times (hash %s)", actionHashes[hash], hash[:8])
+			actionLoopLog.WarnContext(ctx, "Same actions repeated", "times", actionHashes[hash], "hash", hash[:8])
 		}
 
 		// Format results as user message, prepended with progress summary
-		feedback := tracker.Summary(iteration+1) + actions.FormatResultsAsUserMessage(results)
-		messages = append(messages, provider.ChatMessage{Role: "user", Content: feedback})
+		feedback := tracker.Summary(iteration+1) + actions.FormatResultsAsUserMessage(results) + repairs.feedback()
+		messages = append(messages, provider.ChatMessage{Role: "user", Content: w.fragments.Intern(feedback), Parts: attachedImages(ctx, config.Images, results)})
 		if conversationCtx != nil {
 			conversationCtx.AddMessage("user", feedback, len(feedback)/4)
 		}
@@ -933,7 +1065,7 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 		// Persist conversation context periodically
 		if conversationCtx != nil && config.DB != nil && (iteration%3 == 2 || iteration == maxIter-1) {
 			if err := config.DB.UpdateConversationContext(conversationCtx); err != nil {
-				log.Printf("[ActionLoop] Warning: Failed to persist conversation: %v", err)
+				actionLoopLog.WarnContext(ctx, "Failed to persist conversation", "error", err)
 			}
 		}
 	}
@@ -958,7 +1090,7 @@ func (w *Worker) ExecuteTaskWithLoop(ctx context.Context, task *Task, config *Lo
 	// Final persist
 	if conversationCtx != nil && config.DB != nil {
 		if err := config.DB.UpdateConversationContext(conversationCtx); err != nil {
-			log.Printf("[ActionLoop] Warning: Failed to persist final conversation: %v", err)
+			actionLoopLog.WarnContext(ctx, "Failed to persist final conversation", "error", err)
 		}
 	}
 
@@ -1010,6 +1142,11 @@ func (w *Worker) buildEnhancedSystemPrompt(lp LessonsProvider, projectID, progre
 		prompt += "\n"
 	}
 
+	// 3. Role definition from the role registry, including action limits
+	if w.rolePrompt != "" {
+		prompt += w.rolePrompt + "\n"
+	}
+
 	return prompt
 }
 
@@ -1066,7 +1203,8 @@ func (w *Worker) recordBuildLessons(config *LoopConfig, env *actions.ActionEnvel
 				}
 				detail = truncateForLesson(output)
 			}
-		case actions.ActionApplyPatch, actions.ActionEditCode:
+		case actions.ActionApplyPatch, actions.ActionEditCode, actions.ActionMultiEdit,
+			actions.ActionAddImport, actions.ActionInsertFunctionAfter, actions.ActionAddStructField:
 			if r.Status == "error" {
 				category = "edit_failure"
 				title = "Patch/edit failure"
@@ -3,10 +3,13 @@ package worker
 import (
 	"context"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/jordanhubbard/loom/internal/contextpack"
 	"github.com/jordanhubbard/loom/internal/database"
+	"github.com/jordanhubbard/loom/internal/promptfrag"
 	"github.com/jordanhubbard/loom/internal/provider"
 	"github.com/jordanhubbard/loom/pkg/models"
 )
@@ -297,6 +300,76 @@ func TestWorker_handleTokenLimits(t *testing.T) {
 	}
 }
 
+func TestWorker_packContextPinsTask(t *testing.T) {
+	registeredProvider := &provider.RegisteredProvider{
+		Config: &provider.ProviderConfig{ID: "test-provider", ContextWindow: 4096},
+	}
+	worker := NewWorker("worker-1", &models.Agent{ID: "test-agent", Name: "Test Agent"}, registeredProvider)
+
+	messages := []provider.ChatMessage{
+		{Role: "system", Content: "You are a helpful assistant"},
+		{Role: "user", Content: "Fix the failing build"},
+	}
+	for i := 0; i < 400; i++ {
+		messages = append(messages, provider.ChatMessage{Role: "user", Content: "This is a test message for truncation testing"})
+	}
+	task := &Task{ID: "task-1", Workflow: "Current step: implement"}
+
+	packed := worker.packContext(messages, 1, task, nil)
+	if len(packed.Dropped) == 0 {
+		t.Fatal("expected messages to be dropped")
+	}
+	if !strings.Contains(packed.Messages[0].Content, "## Workflow State") {
+		t.Error("expected workflow state in system prompt")
+	}
+	if packed.Messages[2].Content != "Fix the failing build" {
+		t.Errorf("task prompt should survive truncation, got %q", packed.Messages[2].Content)
+	}
+}
+
+// recordingProvider keeps the last request it was sent
+type recordingProvider struct {
+	MockConversationProvider
+	last *provider.ChatCompletionRequest
+}
+
+func (r *recordingProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	r.last = req
+	return r.MockConversationProvider.CreateChatCompletion(ctx, req)
+}
+
+func TestWorker_completeSendsRepeatedContentOnce(t *testing.T) {
+	recorder := &recordingProvider{MockConversationProvider: MockConversationProvider{responseContent: "ok"}}
+	registeredProvider := &provider.RegisteredProvider{
+		Config:   &provider.ProviderConfig{ID: "test-provider", ContextWindow: 4096},
+		Protocol: recorder,
+	}
+	worker := NewWorker("worker-1", &models.Agent{ID: "test-agent", Name: "Test Agent"}, registeredProvider)
+	worker.fragments = promptfrag.NewStore(contextpack.EstimateTokens)
+
+	content := strings.Repeat("package main // unchanged\n", 40)
+	result := "### read_file — success\n```\n" + content + "```\n"
+	messages := []provider.ChatMessage{
+		{Role: "system", Content: "You are a helpful assistant"},
+		{Role: "user", Content: worker.fragments.Intern(result + "\n---\n\n" + result)},
+		{Role: "user", Content: worker.fragments.Intern(result)},
+	}
+	if _, err := worker.complete(context.Background(), &provider.ChatCompletionRequest{Messages: messages}); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent strings.Builder
+	for _, m := range recorder.last.Messages {
+		sent.WriteString(m.Content)
+	}
+	if n := strings.Count(sent.String(), content); n != 1 {
+		t.Errorf("expected the repeated file content to be sent once, got %d copies", n)
+	}
+	if worker.fragments.Stats().TokensSaved == 0 {
+		t.Error("expected the saved tokens to be counted")
+	}
+}
+
 func TestWorker_buildConversationMessages(t *testing.T) {
 	agent := &models.Agent{
 		ID:   "test-agent",
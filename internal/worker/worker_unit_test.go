@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -716,6 +717,39 @@ func TestWorker_ExecuteTaskWithLoop_ParseFailure(t *testing.T) {
 	}
 }
 
+func TestWorker_ExecuteTaskWithLoop_MaxCorrections(t *testing.T) {
+	for _, tc := range []struct {
+		maxCorrections int
+		wantCalls      int
+	}{
+		{-1, 1},
+		{0, actions.DefaultMaxCorrectionAttempts + 1},
+		{4, 5},
+	} {
+		mock := &sequenceMockProvider{responses: []string{"This is not valid JSON at all"}}
+		rp := &provider.RegisteredProvider{
+			Config:   &provider.ProviderConfig{ID: "p1", Name: "P", Model: "m"},
+			Protocol: mock,
+		}
+		w := NewWorker("w1", &models.Agent{ID: "a1", Name: "Agent"}, rp)
+		_ = w.Start()
+
+		result, err := w.ExecuteTaskWithLoop(context.Background(), &Task{ID: "t1", Description: "do something"}, &LoopConfig{
+			MaxIterations:  10,
+			Router:         &actions.Router{},
+			ActionContext:  actions.ActionContext{ProjectID: "p1", BeadID: "b1"},
+			TextMode:       true,
+			MaxCorrections: tc.maxCorrections,
+		})
+		if err != nil {
+			t.Fatalf("ExecuteTaskWithLoop error = %v", err)
+		}
+		if result.TerminalReason != "parse_failures" || mock.callCount != tc.wantCalls {
+			t.Errorf("MaxCorrections %d: %s after %d calls, want parse_failures after %d", tc.maxCorrections, result.TerminalReason, mock.callCount, tc.wantCalls)
+		}
+	}
+}
+
 func TestWorker_ExecuteTaskWithLoop_EmptyActions(t *testing.T) {
 	mock := &sequenceMockProvider{
 		responses: []string{`{"actions": []}`},
@@ -880,3 +914,34 @@ func TestWorker_ExecuteTaskWithLoop_DefaultMaxIterations(t *testing.T) {
 		t.Errorf("TerminalReason = %q, want completed", result.TerminalReason)
 	}
 }
+
+type fakeImageSource map[string]string
+
+func (f fakeImageSource) BeadImageParts(beadID string) ([]provider.ContentPart, error) {
+	return nil, nil
+}
+
+func (f fakeImageSource) ImagePart(id string) (provider.ContentPart, error) {
+	url, ok := f[id]
+	if !ok {
+		return provider.ContentPart{}, errors.New("not found")
+	}
+	return provider.ImagePart(url), nil
+}
+
+func TestAttachedImages(t *testing.T) {
+	results := []actions.Result{
+		{ActionType: actions.ActionReadFile, Status: "executed", Metadata: map[string]interface{}{"image_id": "img-1"}},
+		{ActionType: actions.ActionAttachImage, Status: "executed", Metadata: map[string]interface{}{"image_id": "img-1"}},
+		{ActionType: actions.ActionAttachImage, Status: "error"},
+		{ActionType: actions.ActionAttachImage, Status: "executed", Metadata: map[string]interface{}{"image_id": "missing"}},
+		{ActionType: actions.ActionBrowser, Status: "executed", Metadata: map[string]interface{}{"image_ids": []string{"shot-1", "missing"}}},
+	}
+	parts := attachedImages(context.Background(), fakeImageSource{"img-1": "data:image/png;base64,AAAA", "shot-1": "data:image/png;base64,BBBB"}, results)
+	if len(parts) != 2 || parts[0].ImageURL.URL != "data:image/png;base64,AAAA" || parts[1].ImageURL.URL != "data:image/png;base64,BBBB" {
+		t.Errorf("attachedImages() = %+v", parts)
+	}
+	if parts := attachedImages(context.Background(), nil, results); parts != nil {
+		t.Errorf("expected no images without a source, got %+v", parts)
+	}
+}
@@ -2,12 +2,14 @@ package workflow
 
 import (
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jordanhubbard/loom/internal/logging"
 )
 
+var engineLog = logging.Module("workflow")
+
 // Database interface for workflow operations
 type Database interface {
 	GetWorkflow(id string) (*Workflow, error)
@@ -32,10 +34,24 @@ type BeadCreator interface {
 	GetBead(id string) (interface{}, error)
 }
 
+// Gate can hold a bead at its current node. CheckGate returns why the bead
+// may not pass the node, or "" to let it through.
+type Gate interface {
+	CheckGate(exec *WorkflowExecution, node *WorkflowNode) string
+}
+
+// gatedConditions maps the conditions gates apply to onto the condition the
+// bead takes instead when a gate holds it
+var gatedConditions = map[EdgeCondition]EdgeCondition{
+	EdgeConditionSuccess:  EdgeConditionFailure,
+	EdgeConditionApproved: EdgeConditionRejected,
+}
+
 // Engine manages workflow execution
 type Engine struct {
 	db    Database
 	beads BeadManager
+	gates []Gate
 }
 
 // NewEngine creates a new workflow engine
@@ -46,6 +62,12 @@ func NewEngine(db Database, beads BeadManager) *Engine {
 	}
 }
 
+// AddGate registers a gate consulted whenever a bead would pass a node. It
+// must be called before the engine is in use.
+func (e *Engine) AddGate(g Gate) {
+	e.gates = append(e.gates, g)
+}
+
 // GetDatabase returns the underlying database interface
 func (e *Engine) GetDatabase() Database {
 	return e.db
@@ -96,10 +118,10 @@ func (e *Engine) StartWorkflow(beadID, workflowID, projectID string) (*WorkflowE
 		},
 	}
 	if err := e.beads.UpdateBead(beadID, updates); err != nil {
-		log.Printf("[Workflow] Warning: failed to update bead context: %v", err)
+		engineLog.Warn("Failed to update bead context", "bead_id", beadID, "error", err)
 	}
 
-	log.Printf("[Workflow] Started workflow %s for bead %s (exec: %s)", wf.Name, beadID, exec.ID)
+	engineLog.Info("Started workflow", "workflow", wf.Name, "bead_id", beadID, "execution_id", exec.ID)
 	return exec, nil
 }
 
@@ -155,8 +177,9 @@ func (e *Engine) GetNextNode(execution *WorkflowExecution, condition EdgeConditi
 // shouldRedispatch determines if a bead should be redispatched immediately
 // based on the current workflow state and node type.
 func shouldRedispatch(exec *WorkflowExecution, node *WorkflowNode) string {
-	// Don't redispatch approval nodes (they wait for human decision)
-	if node.NodeType == NodeTypeApproval {
+	// Don't redispatch approval nodes (they wait for human decision) or
+	// review nodes (the review pipeline picks them up)
+	if node.NodeType == NodeTypeApproval || node.NodeType == NodeTypeReview {
 		return "false"
 	}
 
@@ -186,6 +209,19 @@ func (e *Engine) AdvanceWorkflow(executionID string, condition EdgeCondition, ag
 		return fmt.Errorf("workflow execution already %s", exec.Status)
 	}
 
+	// A gate turns a passing condition into its failing counterpart
+	gateReason := e.checkGates(exec, condition)
+	if gateReason != "" {
+		engineLog.Info("Gate held bead", "bead_id", exec.BeadID, "node", exec.CurrentNodeKey, "reason", gateReason)
+		gated := make(map[string]string, len(resultData)+1)
+		for k, v := range resultData {
+			gated[k] = v
+		}
+		gated["gate_reason"] = gateReason
+		resultData = gated
+		condition = gatedConditions[condition]
+	}
+
 	// Record history
 	resultJSON := ""
 	if resultData != nil {
@@ -204,11 +240,14 @@ func (e *Engine) AdvanceWorkflow(executionID string, condition EdgeCondition, ag
 		CreatedAt:     time.Now(),
 	}
 	if err := e.db.InsertWorkflowHistory(history); err != nil {
-		log.Printf("[Workflow] Warning: failed to insert history: %v", err)
+		engineLog.Warn("Failed to insert workflow history", "execution_id", exec.ID, "error", err)
 	}
 
 	// Get next node
 	nextNode, err := e.GetNextNode(exec, condition)
+	if err != nil && gateReason != "" {
+		return fmt.Errorf("held at node %s: %s", exec.CurrentNodeKey, gateReason)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get next node: %w", err)
 	}
@@ -232,10 +271,10 @@ func (e *Engine) AdvanceWorkflow(executionID string, condition EdgeCondition, ag
 			},
 		}
 		if err := e.beads.UpdateBead(exec.BeadID, updates); err != nil {
-			log.Printf("[Workflow] Warning: failed to update bead context: %v", err)
+			engineLog.Warn("Failed to update bead context", "bead_id", exec.BeadID, "error", err)
 		}
 
-		log.Printf("[Workflow] Completed workflow execution %s for bead %s", executionID, exec.BeadID)
+		engineLog.Info("Completed workflow execution", "execution_id", executionID, "bead_id", exec.BeadID)
 		return nil
 	}
 
@@ -245,7 +284,7 @@ func (e *Engine) AdvanceWorkflow(executionID string, condition EdgeCondition, ag
 		for _, h := range historyList {
 			if h.NodeKey == nextNode.NodeKey {
 				exec.CycleCount++
-				log.Printf("[Workflow] Cycle detected for bead %s: cycle_count=%d", exec.BeadID, exec.CycleCount)
+				engineLog.Info("Cycle detected", "bead_id", exec.BeadID, "cycle_count", exec.CycleCount)
 				break
 			}
 		}
@@ -272,6 +311,7 @@ func (e *Engine) AdvanceWorkflow(executionID string, condition EdgeCondition, ag
 			"workflow_status":      string(exec.Status),
 			"cycle_count":          fmt.Sprintf("%d", exec.CycleCount),
 			"redispatch_requested": shouldRedispatch(exec, nextNode),
+			"gate_reason":          gateReason,
 		},
 	}
 
@@ -282,15 +322,38 @@ func (e *Engine) AdvanceWorkflow(executionID string, condition EdgeCondition, ag
 	}
 
 	if err := e.beads.UpdateBead(exec.BeadID, updates); err != nil {
-		log.Printf("[Workflow] Warning: failed to update bead context: %v", err)
+		engineLog.Warn("Failed to update bead context", "bead_id", exec.BeadID, "error", err)
 	}
 
-	log.Printf("[Workflow] Advanced bead %s to node %s (attempt %d, cycle %d)",
-		exec.BeadID, nextNode.NodeKey, exec.NodeAttemptCount, exec.CycleCount)
+	engineLog.Info("Advanced bead to node", "bead_id", exec.BeadID, "node", nextNode.NodeKey,
+		"attempt", exec.NodeAttemptCount, "cycle", exec.CycleCount)
 
 	return nil
 }
 
+// checkGates returns why a gate holds the bead at its current node, or ""
+// when condition doesn't pass the node or every gate lets it through
+func (e *Engine) checkGates(exec *WorkflowExecution, condition EdgeCondition) string {
+	if _, ok := gatedConditions[condition]; !ok || len(e.gates) == 0 || exec.CurrentNodeKey == "" {
+		return ""
+	}
+	wf, err := e.db.GetWorkflow(exec.WorkflowID)
+	if err != nil {
+		return ""
+	}
+	for i := range wf.Nodes {
+		if wf.Nodes[i].NodeKey != exec.CurrentNodeKey {
+			continue
+		}
+		for _, g := range e.gates {
+			if reason := g.CheckGate(exec, &wf.Nodes[i]); reason != "" {
+				return reason
+			}
+		}
+	}
+	return ""
+}
+
 // CompleteNode marks a node as completed and advances the workflow
 func (e *Engine) CompleteNode(executionID, agentID string, result map[string]string) error {
 	exec, err := e.db.GetWorkflowExecution(executionID)
@@ -370,7 +433,7 @@ func (e *Engine) FailNode(executionID, agentID, reason string) error {
 
 // escalateWorkflow escalates the workflow to CEO
 func (e *Engine) escalateWorkflow(exec *WorkflowExecution, reason string) error {
-	log.Printf("[Workflow] Escalating workflow execution %s for bead %s: %s", exec.ID, exec.BeadID, reason)
+	engineLog.Warn("Escalating workflow execution", "execution_id", exec.ID, "bead_id", exec.BeadID, "reason", reason)
 
 	exec.Status = ExecutionStatusEscalated
 	now := time.Now()
@@ -391,10 +454,10 @@ func (e *Engine) escalateWorkflow(exec *WorkflowExecution, reason string) error
 		},
 	}
 	if err := e.beads.UpdateBead(exec.BeadID, updates); err != nil {
-		log.Printf("[Workflow] Warning: failed to update bead context: %v", err)
+		engineLog.Warn("Failed to update bead context", "bead_id", exec.BeadID, "error", err)
 	}
 
-	log.Printf("[Workflow] Workflow escalated for bead %s - CEO escalation bead should be created", exec.BeadID)
+	engineLog.Info("Workflow escalated, CEO escalation bead should be created", "bead_id", exec.BeadID)
 
 	return nil
 }
@@ -502,7 +565,7 @@ func (e *Engine) IsNodeReady(execution *WorkflowExecution) bool {
 
 	// Check for timeout
 	if err := e.CheckNodeTimeout(execution); err != nil {
-		log.Printf("[Workflow] Node timeout detected for bead %s: %v", execution.BeadID, err)
+		engineLog.Warn("Node timeout detected", "bead_id", execution.BeadID, "error", err)
 		return false
 	}
 
@@ -532,8 +595,8 @@ func (e *Engine) CheckNodeTimeout(execution *WorkflowExecution) error {
 
 	if timeSinceNode > timeoutDuration {
 		// Node has timed out - advance workflow with timeout condition
-		log.Printf("[Workflow] Node %s timed out for bead %s (elapsed: %v, timeout: %v)",
-			node.NodeKey, execution.BeadID, timeSinceNode, timeoutDuration)
+		engineLog.Warn("Node timed out", "node", node.NodeKey, "bead_id", execution.BeadID,
+			"elapsed", timeSinceNode, "timeout", timeoutDuration)
 
 		resultData := map[string]string{
 			"timeout_reason": fmt.Sprintf("Node exceeded timeout of %d minutes", node.TimeoutMinutes),
@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -37,6 +38,18 @@ func TestShouldRedispatch(t *testing.T) {
 			},
 			expected: "false",
 		},
+		{
+			name: "review node should not redispatch",
+			exec: &WorkflowExecution{
+				Status:           ExecutionStatusActive,
+				NodeAttemptCount: 0,
+			},
+			node: &WorkflowNode{
+				NodeType:    NodeTypeReview,
+				MaxAttempts: 2,
+			},
+			expected: "false",
+		},
 		{
 			name: "commit node with active workflow should redispatch",
 			exec: &WorkflowExecution{
@@ -557,3 +570,71 @@ func TestAdvanceWorkflow_ApprovalNode(t *testing.T) {
 		t.Errorf("Expected redispatch_requested = %q for approval node, got %q", "false", ctx["redispatch_requested"])
 	}
 }
+
+type gateFunc func(exec *WorkflowExecution, node *WorkflowNode) string
+
+func (f gateFunc) CheckGate(exec *WorkflowExecution, node *WorkflowNode) string { return f(exec, node) }
+
+func TestAdvanceWorkflow_GateHoldsBead(t *testing.T) {
+	db := newMockDatabase()
+	beads := newMockBeadManager()
+	engine := NewEngine(db, beads)
+	held := true
+	engine.AddGate(gateFunc(func(exec *WorkflowExecution, node *WorkflowNode) string {
+		if held && node.NodeType == NodeTypeVerify {
+			return "benchmarks regressed"
+		}
+		return ""
+	}))
+
+	db.workflows["wf-test"] = &Workflow{
+		ID: "wf-test",
+		Nodes: []WorkflowNode{
+			{NodeKey: "implement", NodeType: NodeTypeTask, MaxAttempts: 3},
+			{NodeKey: "verify", NodeType: NodeTypeVerify, MaxAttempts: 3},
+			{NodeKey: "commit", NodeType: NodeTypeCommit, MaxAttempts: 3},
+		},
+		Edges: []WorkflowEdge{
+			{FromNodeKey: "implement", ToNodeKey: "verify", Condition: EdgeConditionSuccess},
+			{FromNodeKey: "verify", ToNodeKey: "commit", Condition: EdgeConditionApproved},
+			{FromNodeKey: "verify", ToNodeKey: "implement", Condition: EdgeConditionRejected},
+			{FromNodeKey: "commit", ToNodeKey: "", Condition: EdgeConditionSuccess},
+		},
+	}
+	exec := &WorkflowExecution{ID: "exec-1", WorkflowID: "wf-test", BeadID: "bead-1", CurrentNodeKey: "verify", Status: ExecutionStatusActive}
+	db.executions["exec-1"] = exec
+
+	// An approval the gate holds takes the rejected edge instead
+	if err := engine.AdvanceWorkflow("exec-1", EdgeConditionApproved, "agent-1", map[string]string{"approved_by": "agent-1"}); err != nil {
+		t.Fatalf("AdvanceWorkflow() error = %v", err)
+	}
+	if exec.CurrentNodeKey != "implement" {
+		t.Errorf("expected the bead back at implement, got %q", exec.CurrentNodeKey)
+	}
+	ctx := beads.beads["bead-1"]["context"].(map[string]string)
+	if ctx["gate_reason"] != "benchmarks regressed" {
+		t.Errorf("expected the gate reason in the bead context, got %q", ctx["gate_reason"])
+	}
+	history := db.history["exec-1"]
+	if len(history) != 1 || history[0].Condition != EdgeConditionRejected || !strings.Contains(history[0].ResultData, "gate_reason") {
+		t.Errorf("expected a rejected history entry with the gate reason, got %+v", history)
+	}
+
+	// Without a failing edge the bead stays where it is
+	exec.CurrentNodeKey = "verify"
+	db.workflows["wf-test"].Edges = db.workflows["wf-test"].Edges[:2]
+	err := engine.AdvanceWorkflow("exec-1", EdgeConditionApproved, "agent-1", nil)
+	if err == nil || !strings.Contains(err.Error(), "benchmarks regressed") || exec.CurrentNodeKey != "verify" {
+		t.Errorf("expected the bead held at verify, got %v at %q", err, exec.CurrentNodeKey)
+	}
+
+	// Once the gate opens the approval goes through and the reason clears
+	held = false
+	if err := engine.AdvanceWorkflow("exec-1", EdgeConditionApproved, "agent-1", nil); err != nil {
+		t.Fatalf("AdvanceWorkflow() error = %v", err)
+	}
+	ctx = beads.beads["bead-1"]["context"].(map[string]string)
+	if exec.CurrentNodeKey != "commit" || ctx["gate_reason"] != "" {
+		t.Errorf("expected the bead at commit with no gate reason, got %q (%q)", exec.CurrentNodeKey, ctx["gate_reason"])
+	}
+}
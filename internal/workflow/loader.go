@@ -2,7 +2,6 @@ package workflow
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"time"
@@ -73,12 +72,12 @@ func LoadDefaultWorkflows(dir string) ([]*Workflow, error) {
 		path := filepath.Join(dir, file.Name())
 		wf, err := LoadWorkflowFromFile(path)
 		if err != nil {
-			log.Printf("[Workflow] Warning: failed to load %s: %v", file.Name(), err)
+			engineLog.Warn("Failed to load workflow", "file", file.Name(), "error", err)
 			continue
 		}
 
 		workflows = append(workflows, wf)
-		log.Printf("[Workflow] Loaded workflow: %s (%s)", wf.Name, wf.ID)
+		engineLog.Info("Loaded workflow", "workflow", wf.Name, "workflow_id", wf.ID)
 	}
 
 	return workflows, nil
@@ -146,25 +145,25 @@ func InstallDefaultWorkflows(db Database, workflowsDir string) error {
 	for _, wf := range workflows {
 		// Insert workflow
 		if err := db.UpsertWorkflow(wf); err != nil {
-			log.Printf("[Workflow] Warning: failed to upsert workflow %s: %v", wf.ID, err)
+			engineLog.Warn("Failed to upsert workflow", "workflow_id", wf.ID, "error", err)
 			continue
 		}
 
 		// Insert nodes
 		for _, node := range wf.Nodes {
 			if err := db.UpsertWorkflowNode(&node); err != nil {
-				log.Printf("[Workflow] Warning: failed to upsert node %s: %v", node.NodeKey, err)
+				engineLog.Warn("Failed to upsert workflow node", "node", node.NodeKey, "error", err)
 			}
 		}
 
 		// Insert edges
 		for _, edge := range wf.Edges {
 			if err := db.UpsertWorkflowEdge(&edge); err != nil {
-				log.Printf("[Workflow] Warning: failed to upsert edge: %v", err)
+				engineLog.Warn("Failed to upsert workflow edge", "workflow_id", wf.ID, "error", err)
 			}
 		}
 
-		log.Printf("[Workflow] Installed default workflow: %s", wf.Name)
+		engineLog.Info("Installed default workflow", "workflow", wf.Name)
 	}
 
 	return nil
@@ -12,6 +12,7 @@ const (
 	NodeTypeApproval NodeType = "approval" // Requires approval to proceed
 	NodeTypeCommit   NodeType = "commit"   // Git commit/push operation
 	NodeTypeVerify   NodeType = "verify"   // Verification/testing node
+	NodeTypeReview   NodeType = "review"   // Automated code review of the bead's commits
 )
 
 // EdgeCondition represents conditions for workflow transitions
@@ -56,7 +57,7 @@ type WorkflowNode struct {
 	ID             string            `json:"id"`
 	WorkflowID     string            `json:"workflow_id"`
 	NodeKey        string            `json:"node_key"`        // Unique key within workflow (e.g., "investigate", "approve", "commit")
-	NodeType       NodeType          `json:"node_type"`       // task, approval, commit, verify
+	NodeType       NodeType          `json:"node_type"`       // task, approval, commit, verify, review
 	RoleRequired   string            `json:"role_required"`   // Agent role required (e.g., "Engineering Manager")
 	PersonaHint    string            `json:"persona_hint"`    // Persona path hint for dispatcher
 	MaxAttempts    int               `json:"max_attempts"`    // Max attempts before escalation (0 = unlimited)
@@ -0,0 +1,474 @@
+// Package workingset assembles the context an agent needs at the start of a
+// turn on a bead: summaries of the files in play, recent failures, open
+// questions, related beads and the rules of the bead's workflow phase,
+// trimmed to a token budget. Agent runtimes fetch it from the server rather
+// than each gathering and curating the same context themselves.
+package workingset
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/internal/contextpack"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/ide"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+// DefaultBudget is the token budget when a caller doesn't give one
+const DefaultBudget = 8000
+
+// Kinds of working-set context, filled into the budget in this order
+const (
+	KindWorkflow  = contextpack.KindWorkflow
+	KindFailures  = contextpack.Kind("failures")
+	KindQuestions = contextpack.Kind("questions")
+	KindRelated   = contextpack.Kind("related")
+	KindFiles     = contextpack.KindFiles
+)
+
+const (
+	maxFailures  = 5
+	maxFiles     = 10
+	summaryLines = 40
+)
+
+// Why a file is in the working set
+const (
+	ReasonEdited    = "edited"    // An agent changed it for the bead
+	ReasonMentioned = "mentioned" // The bead names it
+)
+
+// BeadStore looks up beads
+type BeadStore interface {
+	GetBead(id string) (*models.Bead, error)
+}
+
+// HistoryStore supplies bead histories
+type HistoryStore interface {
+	History(beadID string) ([]*beads.BeadEvent, error)
+}
+
+// FileReader reads project files
+type FileReader interface {
+	ReadFile(ctx context.Context, projectID, relPath string) (*files.FileResult, error)
+}
+
+// EditTracker lists the files agents edited for a bead
+type EditTracker interface {
+	Files(beadID string) []ide.FileState
+}
+
+// QuestionStore lists decisions filed against beads
+type QuestionStore interface {
+	ListDecisions(filters map[string]interface{}) ([]*models.DecisionBead, error)
+}
+
+// WorkflowSource describes a bead's workflow phase and its rules
+type WorkflowSource interface {
+	WorkflowState(beadID string) string
+}
+
+// Sources are where a working set comes from. Beads is required; the rest
+// are optional and their sections are left empty when nil.
+type Sources struct {
+	Beads     BeadStore
+	History   HistoryStore
+	Files     FileReader
+	Edits     EditTracker
+	Questions QuestionStore
+	Workflow  WorkflowSource
+}
+
+// FileSummary outlines a file relevant to the bead
+type FileSummary struct {
+	Path    string `json:"path"`
+	Reason  string `json:"reason"`
+	Lines   int    `json:"lines"`
+	Summary string `json:"summary"`
+}
+
+// Failure is something that recently went wrong on the bead
+type Failure struct {
+	At      *time.Time `json:"at,omitempty"`
+	Source  string     `json:"source"` // The failed action type, "run" or "review"
+	Path    string     `json:"path,omitempty"`
+	Message string     `json:"message"`
+}
+
+// Question is an open decision blocking the bead
+type Question struct {
+	ID             string   `json:"id"`
+	Question       string   `json:"question"`
+	Options        []string `json:"options,omitempty"`
+	Recommendation string   `json:"recommendation,omitempty"`
+}
+
+// RelatedBead is a bead linked to the working set's bead
+type RelatedBead struct {
+	ID       string            `json:"id"`
+	Title    string            `json:"title"`
+	Status   models.BeadStatus `json:"status"`
+	Relation string            `json:"relation"` // parent, child, blocked_by, blocks or related
+}
+
+// WorkingSet is the curated context for one turn on a bead
+type WorkingSet struct {
+	BeadID    string                `json:"bead_id"`
+	ProjectID string                `json:"project_id"`
+	Workflow  string                `json:"workflow,omitempty"`
+	Failures  []Failure             `json:"failures"`
+	Questions []Question            `json:"questions"`
+	Related   []RelatedBead         `json:"related"`
+	Files     []FileSummary         `json:"files"`
+	Budget    int                   `json:"budget"`
+	Tokens    int                   `json:"tokens"`
+	Dropped   []contextpack.Dropped `json:"dropped,omitempty"`
+	// Prompt is the working set rendered as markdown for a system prompt
+	Prompt string `json:"prompt"`
+}
+
+// Builder assembles working sets
+type Builder struct {
+	src Sources
+}
+
+// New creates a builder
+func New(src Sources) *Builder {
+	return &Builder{src: src}
+}
+
+// Build assembles the working set for a bead. Sections are filled in order
+// — workflow rules, failures, questions, related beads, then files — and
+// anything that doesn't fit the budget is reported in Dropped. A budget of
+// 0 or less uses DefaultBudget.
+func (b *Builder) Build(ctx context.Context, beadID string, budget int) (*WorkingSet, error) {
+	bead, err := b.src.Beads.GetBead(beadID)
+	if err != nil {
+		return nil, err
+	}
+	if budget <= 0 {
+		budget = DefaultBudget
+	}
+	ws := &WorkingSet{
+		BeadID:    bead.ID,
+		ProjectID: bead.ProjectID,
+		Failures:  []Failure{},
+		Questions: []Question{},
+		Related:   []RelatedBead{},
+		Files:     []FileSummary{},
+		Budget:    budget,
+	}
+	p := &packer{ws: ws, remaining: budget}
+
+	if b.src.Workflow != nil {
+		if state := b.src.Workflow.WorkflowState(bead.ID); state != "" {
+			if p.add(KindWorkflow, "workflow phase", state) {
+				ws.Workflow = state
+			}
+		}
+	}
+	for _, f := range b.failures(bead) {
+		if p.add(KindFailures, f.Source, renderFailure(f)) {
+			ws.Failures = append(ws.Failures, f)
+		}
+	}
+	questions := b.questions(bead)
+	for _, q := range questions {
+		if p.add(KindQuestions, q.ID, renderQuestion(q)) {
+			ws.Questions = append(ws.Questions, q)
+		}
+	}
+	for _, r := range b.related(bead, questions) {
+		if p.add(KindRelated, r.ID, fmt.Sprintf("- %s (%s, %s): %s", r.ID, r.Relation, r.Status, r.Title)) {
+			ws.Related = append(ws.Related, r)
+		}
+	}
+	for _, f := range b.files(ctx, bead) {
+		block := fmt.Sprintf("### %s (%s, %d lines)\n```\n%s\n```", f.Path, f.Reason, f.Lines, f.Summary)
+		if p.add(KindFiles, f.Path, block) {
+			ws.Files = append(ws.Files, f)
+		}
+	}
+
+	ws.Prompt = p.render()
+	return ws, nil
+}
+
+// headings title the sections of the rendered prompt
+var headings = map[contextpack.Kind]string{
+	KindWorkflow:  "## Workflow Phase",
+	KindFailures:  "## Recent Failures",
+	KindQuestions: "## Open Questions",
+	KindRelated:   "## Related Beads",
+	KindFiles:     "## Files",
+}
+
+// packer fills a working set's budget section by section
+type packer struct {
+	ws        *WorkingSet
+	remaining int
+	order     []contextpack.Kind
+	items     map[contextpack.Kind][]string
+}
+
+// add includes an item, and its section heading if it's the section's
+// first, when both fit
+func (p *packer) add(kind contextpack.Kind, label, item string) bool {
+	if p.items == nil {
+		p.items = make(map[contextpack.Kind][]string)
+	}
+	first := len(p.items[kind]) == 0
+	tokens := contextpack.EstimateTokens(item + "\n")
+	if first {
+		tokens += contextpack.EstimateTokens(headings[kind] + "\n\n")
+	}
+	if tokens > p.remaining {
+		p.ws.Dropped = append(p.ws.Dropped, contextpack.Dropped{Kind: kind, Label: label, Tokens: tokens})
+		return false
+	}
+	if first {
+		p.order = append(p.order, kind)
+	}
+	p.items[kind] = append(p.items[kind], item)
+	p.remaining -= tokens
+	p.ws.Tokens += tokens
+	return true
+}
+
+func (p *packer) render() string {
+	parts := make([]string, 0, len(p.order))
+	for _, kind := range p.order {
+		sep := "\n"
+		if kind == KindWorkflow || kind == KindFiles {
+			sep = "\n\n"
+		}
+		parts = append(parts, headings[kind]+"\n\n"+strings.Join(p.items[kind], sep))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// failures collects the bead's latest errors, newest first: failed actions
+// from its history and the run and review errors recorded on it
+func (b *Builder) failures(bead *models.Bead) []Failure {
+	var list []Failure
+	if msg := bead.Context["last_run_error"]; msg != "" {
+		list = append(list, Failure{At: parseTime(bead.Context["last_run_at"]), Source: "run", Message: msg})
+	}
+	if msg := bead.Context["review_error"]; msg != "" {
+		list = append(list, Failure{Source: "review", Message: msg})
+	}
+	if bead.Context["loop_detected"] == "true" && bead.Context["loop_detected_reason"] != "" {
+		list = append(list, Failure{Source: "loop", Message: bead.Context["loop_detected_reason"]})
+	}
+	if b.src.History != nil {
+		events, _ := b.src.History.History(bead.ID)
+		for i := len(events) - 1; i >= 0 && len(list) < maxFailures; i-- {
+			e := events[i]
+			if e.Type != beads.EventActionExecuted || e.Data["status"] != "error" {
+				continue
+			}
+			at := e.Timestamp
+			list = append(list, Failure{
+				At:      &at,
+				Source:  str(e.Data["action_type"]),
+				Path:    str(e.Data["path"]),
+				Message: str(e.Data["message"]),
+			})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].At == nil || list[j].At == nil {
+			return list[j].At == nil && list[i].At != nil
+		}
+		return list[i].At.After(*list[j].At)
+	})
+	if len(list) > maxFailures {
+		list = list[:maxFailures]
+	}
+	return list
+}
+
+func renderFailure(f Failure) string {
+	var sb strings.Builder
+	sb.WriteString("- ")
+	if f.At != nil {
+		sb.WriteString(f.At.UTC().Format(time.RFC3339) + " ")
+	}
+	sb.WriteString(f.Source)
+	if f.Path != "" {
+		sb.WriteString(" " + f.Path)
+	}
+	sb.WriteString(": " + oneLine(f.Message, 500))
+	return sb.String()
+}
+
+// questions lists the undecided decisions filed against the bead
+func (b *Builder) questions(bead *models.Bead) []Question {
+	if b.src.Questions == nil {
+		return nil
+	}
+	decisions, err := b.src.Questions.ListDecisions(map[string]interface{}{"project_id": bead.ProjectID})
+	if err != nil {
+		return nil
+	}
+	sort.Slice(decisions, func(i, j int) bool { return decisions[i].CreatedAt.Before(decisions[j].CreatedAt) })
+	var list []Question
+	for _, d := range decisions {
+		if d.Parent != bead.ID || d.Status == models.BeadStatusClosed {
+			continue
+		}
+		list = append(list, Question{ID: d.ID, Question: d.Question, Options: d.Options, Recommendation: d.Recommendation})
+	}
+	return list
+}
+
+func renderQuestion(q Question) string {
+	line := fmt.Sprintf("- %s: %s", q.ID, oneLine(q.Question, 500))
+	if len(q.Options) > 0 {
+		line += " (options: " + strings.Join(q.Options, "; ") + ")"
+	}
+	if q.Recommendation != "" {
+		line += " Recommended: " + oneLine(q.Recommendation, 200)
+	}
+	return line
+}
+
+// related lists the beads linked to the bead, nearest first, leaving out
+// the decisions already listed as questions
+func (b *Builder) related(bead *models.Bead, questions []Question) []RelatedBead {
+	seen := map[string]bool{bead.ID: true}
+	for _, q := range questions {
+		seen[q.ID] = true
+	}
+	var list []RelatedBead
+	add := func(relation string, ids ...string) {
+		for _, id := range ids {
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			other, err := b.src.Beads.GetBead(id)
+			if err != nil || other == nil {
+				continue
+			}
+			list = append(list, RelatedBead{ID: other.ID, Title: other.Title, Status: other.Status, Relation: relation})
+		}
+	}
+	add("blocked_by", bead.BlockedBy...)
+	add("parent", bead.Parent)
+	add("blocks", bead.Blocks...)
+	add("child", bead.Children...)
+	add("related", bead.RelatedTo...)
+	return list
+}
+
+// pathPattern finds file paths named in bead text
+var pathPattern = regexp.MustCompile("(?:^|[\\s(\"'`])((?:[\\w.-]+/)*[\\w-]+\\.[A-Za-z][A-Za-z0-9]{0,7})\\b")
+
+// files summarizes the files agents edited for the bead, most recent first,
+// then those its title and description name
+func (b *Builder) files(ctx context.Context, bead *models.Bead) []FileSummary {
+	if b.src.Files == nil {
+		return nil
+	}
+	type candidate struct{ path, reason string }
+	var candidates []candidate
+	seen := map[string]bool{}
+	if b.src.Edits != nil {
+		for _, state := range b.src.Edits.Files(bead.ID) {
+			if state.FileDiff != nil && !seen[state.Path] {
+				seen[state.Path] = true
+				candidates = append(candidates, candidate{state.Path, ReasonEdited})
+			}
+		}
+	}
+	for _, m := range pathPattern.FindAllStringSubmatch(bead.Title+"\n"+bead.Description, -1) {
+		path := strings.TrimPrefix(m[1], "./")
+		if !seen[path] {
+			seen[path] = true
+			candidates = append(candidates, candidate{path, ReasonMentioned})
+		}
+	}
+
+	var list []FileSummary
+	for _, c := range candidates {
+		if len(list) == maxFiles {
+			break
+		}
+		res, err := b.src.Files.ReadFile(ctx, bead.ProjectID, c.path)
+		if err != nil {
+			continue
+		}
+		list = append(list, FileSummary{
+			Path:    c.path,
+			Reason:  c.reason,
+			Lines:   strings.Count(res.Content, "\n") + 1,
+			Summary: Summarize(c.path, res.Content),
+		})
+	}
+	return list
+}
+
+// declPattern matches the unindented lines that declare things in common
+// languages
+var declPattern = regexp.MustCompile(`^(package|func|type|var|const|class|def|async def|interface|struct|enum|trait|impl|fn|pub|export|module|public|abstract|final)\b`)
+
+// Summarize outlines a file in a few lines: the headings of a markdown
+// file, the top-level declarations of source code, otherwise its opening
+// lines
+func Summarize(path, content string) string {
+	markdown := strings.HasSuffix(strings.ToLower(path), ".md")
+	var outline, opening []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			continue
+		}
+		if len(opening) < summaryLines/2 {
+			opening = append(opening, line)
+		}
+		if markdown && strings.HasPrefix(line, "#") || !markdown && declPattern.MatchString(line) {
+			if i := strings.Index(line, " {"); i > 0 {
+				line = line[:i]
+			}
+			outline = append(outline, line)
+		}
+	}
+	if len(outline) == 0 {
+		outline = opening
+	}
+	if len(outline) > summaryLines {
+		outline = append(outline[:summaryLines], fmt.Sprintf("... (%d more)", len(outline)-summaryLines))
+	}
+	return strings.Join(outline, "\n")
+}
+
+func parseTime(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func str(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// oneLine flattens s onto one line of at most n bytes
+func oneLine(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > n {
+		s = s[:n] + "..."
+	}
+	return s
+}
@@ -0,0 +1,176 @@
+package workingset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanhubbard/loom/internal/beads"
+	"github.com/jordanhubbard/loom/internal/files"
+	"github.com/jordanhubbard/loom/internal/ide"
+	"github.com/jordanhubbard/loom/pkg/models"
+)
+
+type fakeBeads map[string]*models.Bead
+
+func (f fakeBeads) GetBead(id string) (*models.Bead, error) {
+	if b, ok := f[id]; ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("bead not found: %s", id)
+}
+
+type fakeHistory []*beads.BeadEvent
+
+func (f fakeHistory) History(string) ([]*beads.BeadEvent, error) { return f, nil }
+
+type fakeFiles map[string]string
+
+func (f fakeFiles) ReadFile(ctx context.Context, projectID, path string) (*files.FileResult, error) {
+	if c, ok := f[path]; ok {
+		return &files.FileResult{Path: path, Content: c}, nil
+	}
+	return nil, fmt.Errorf("no such file")
+}
+
+type fakeEdits []string
+
+func (f fakeEdits) Files(string) []ide.FileState {
+	var list []ide.FileState
+	for _, p := range f {
+		list = append(list, ide.FileState{FileDiff: &ide.FileDiff{Path: p}})
+	}
+	return list
+}
+
+type fakeDecisions []*models.DecisionBead
+
+func (f fakeDecisions) ListDecisions(map[string]interface{}) ([]*models.DecisionBead, error) {
+	return f, nil
+}
+
+type fakeWorkflow string
+
+func (f fakeWorkflow) WorkflowState(string) string { return string(f) }
+
+func sources() Sources {
+	now := time.Now()
+	return Sources{
+		Beads: fakeBeads{
+			"bd-1": {
+				ID: "bd-1", ProjectID: "p1", Title: "Fix token refresh",
+				Description: "The refresh in internal/auth/token.go races; see docs/AUTH.md.",
+				BlockedBy:   []string{"bd-dec-1", "bd-2"},
+				Parent:      "bd-0",
+				Context:     map[string]string{"last_run_error": "provider timeout", "last_run_at": now.Add(-time.Hour).UTC().Format(time.RFC3339)},
+			},
+			"bd-0": {ID: "bd-0", Title: "Auth hardening", Status: models.BeadStatusInProgress},
+			"bd-2": {ID: "bd-2", Title: "Upgrade jwt library", Status: models.BeadStatusOpen},
+		},
+		History: fakeHistory{
+			{Type: beads.EventActionExecuted, Timestamp: now.Add(-2 * time.Hour), Data: map[string]interface{}{"action_type": "run_tests", "status": "error", "message": "TestRefresh failed"}},
+			{Type: beads.EventActionExecuted, Timestamp: now.Add(-time.Minute), Data: map[string]interface{}{"action_type": "edit_code", "status": "error", "path": "internal/auth/token.go", "message": "patch did not apply"}},
+			{Type: beads.EventActionExecuted, Timestamp: now, Data: map[string]interface{}{"action_type": "read_code", "status": "executed"}},
+		},
+		Files: fakeFiles{
+			"internal/auth/token.go": "package auth\n\nimport \"sync\"\n\n// Token is a bearer token\ntype Token struct {\n\tvalue string\n}\n\nfunc (t *Token) Refresh() error {\n\treturn nil\n}\n",
+			"internal/auth/store.go": "package auth\n\nvar tokens = map[string]string{}\n",
+			"docs/AUTH.md":           "# Auth\n\nSome prose.\n\n## Refresh\n\nMore prose.\n",
+		},
+		Edits: fakeEdits{"internal/auth/store.go"},
+		Questions: fakeDecisions{
+			{Bead: &models.Bead{ID: "bd-dec-1", Parent: "bd-1", Status: models.BeadStatusOpen}, Question: "Keep the old refresh endpoint?", Options: []string{"yes", "no"}},
+			{Bead: &models.Bead{ID: "bd-dec-2", Parent: "bd-1", Status: models.BeadStatusClosed}, Question: "Already decided"},
+			{Bead: &models.Bead{ID: "bd-dec-3", Parent: "bd-9", Status: models.BeadStatusOpen}, Question: "Another bead's"},
+		},
+		Workflow: fakeWorkflow("Workflow bug-fix: active\nCurrent step: investigate (task), 1 previous attempts"),
+	}
+}
+
+func TestBuild(t *testing.T) {
+	ws, err := New(sources()).Build(context.Background(), "bd-1", 0)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if ws.Budget != DefaultBudget || len(ws.Dropped) != 0 {
+		t.Errorf("budget %d, dropped %v", ws.Budget, ws.Dropped)
+	}
+	if !strings.HasPrefix(ws.Workflow, "Workflow bug-fix") {
+		t.Errorf("workflow = %q", ws.Workflow)
+	}
+
+	var failures []string
+	for _, f := range ws.Failures {
+		failures = append(failures, f.Source)
+	}
+	if got := strings.Join(failures, ","); got != "edit_code,run,run_tests" {
+		t.Errorf("failures = %s, want newest first", got)
+	}
+
+	if len(ws.Questions) != 1 || ws.Questions[0].ID != "bd-dec-1" {
+		t.Errorf("questions = %+v", ws.Questions)
+	}
+
+	var related []string
+	for _, r := range ws.Related {
+		related = append(related, r.Relation+":"+r.ID)
+	}
+	if got := strings.Join(related, ","); got != "blocked_by:bd-2,parent:bd-0" {
+		t.Errorf("related = %s", got)
+	}
+
+	var paths []string
+	for _, f := range ws.Files {
+		paths = append(paths, f.Reason+":"+f.Path)
+	}
+	if got := strings.Join(paths, ","); got != "edited:internal/auth/store.go,mentioned:internal/auth/token.go,mentioned:docs/AUTH.md" {
+		t.Errorf("files = %s", got)
+	}
+	if s := ws.Files[1].Summary; !strings.Contains(s, "type Token struct") || !strings.Contains(s, "func (t *Token) Refresh() error") || strings.Contains(s, "import") {
+		t.Errorf("summary = %q", s)
+	}
+
+	for _, heading := range []string{"## Workflow Phase", "## Recent Failures", "## Open Questions", "## Related Beads", "## Files"} {
+		if !strings.Contains(ws.Prompt, heading) {
+			t.Errorf("prompt lacks %s:\n%s", heading, ws.Prompt)
+		}
+	}
+}
+
+func TestBuild_Budget(t *testing.T) {
+	ws, err := New(sources()).Build(context.Background(), "bd-1", 80)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if ws.Tokens > ws.Budget {
+		t.Errorf("used %d of %d tokens", ws.Tokens, ws.Budget)
+	}
+	if ws.Workflow == "" {
+		t.Error("workflow rules should be packed first")
+	}
+	if len(ws.Files) != 0 || len(ws.Dropped) == 0 {
+		t.Errorf("expected files dropped, got %d files, dropped %v", len(ws.Files), ws.Dropped)
+	}
+
+	if _, err := New(sources()).Build(context.Background(), "bd-404", 0); err == nil {
+		t.Error("expected an error for a missing bead")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	if got := Summarize("docs/AUTH.md", "# Auth\n\ntext\n\n## Refresh\n"); got != "# Auth\n## Refresh" {
+		t.Errorf("markdown summary = %q", got)
+	}
+	if got := Summarize("notes.txt", "first\n\nsecond\n"); got != "first\nsecond" {
+		t.Errorf("plain summary = %q", got)
+	}
+	var long strings.Builder
+	for i := 0; i < summaryLines+5; i++ {
+		fmt.Fprintf(&long, "func f%d() {}\n", i)
+	}
+	if got := Summarize("f.go", long.String()); !strings.HasSuffix(got, "... (5 more)") {
+		t.Errorf("long summary ends %q", got[len(got)-20:])
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"log"
 	"os"
 
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/pkg/config"
 	"github.com/jordanhubbard/loom/pkg/server"
 )
 
+var mainLog = logging.Module("main")
+
 func main() {
 	fmt.Println("Welcome to Loom - AI Coding Agent Orchestrator")
 	fmt.Println("==================================================")
@@ -21,21 +24,20 @@ func main() {
 
 	cfg, err := config.LoadConfigFromFile(configPath)
 	if err != nil {
-		log.Printf("Warning: Failed to load config from %s: %v", configPath, err)
-		log.Printf("Using default configuration")
+		mainLog.Warn("Failed to load config, using default configuration", "path", configPath, "error", err)
 		cfg = config.DefaultConfig()
 	} else {
-		log.Printf("Loaded configuration from %s", configPath)
+		mainLog.Info("Loaded configuration", "path", configPath)
 	}
 
 	// Override with environment variables if set
 	if temporalHost := os.Getenv("TEMPORAL_HOST"); temporalHost != "" {
 		cfg.Temporal.Host = temporalHost
-		log.Printf("Using Temporal host from environment: %s", temporalHost)
+		mainLog.Info("Using Temporal host from environment", "host", temporalHost)
 	}
 	if temporalNamespace := os.Getenv("TEMPORAL_NAMESPACE"); temporalNamespace != "" {
 		cfg.Temporal.Namespace = temporalNamespace
-		log.Printf("Using Temporal namespace from environment: %s", temporalNamespace)
+		mainLog.Info("Using Temporal namespace from environment", "namespace", temporalNamespace)
 	}
 
 	fmt.Println("\nLoom Worker System initialized")
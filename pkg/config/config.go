@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/jordanhubbard/loom/pkg/models"
 	"github.com/jordanhubbard/loom/pkg/secrets"
 	"gopkg.in/yaml.v3"
 )
@@ -15,13 +16,14 @@ const configFileName = ".loom.json"
 
 // Provider represents an AI service provider configuration (file/JSON config).
 type Provider struct {
-	ID       string `yaml:"id" json:"id"`
-	Name     string `yaml:"name" json:"name"`
-	Type     string `yaml:"type" json:"type"`
-	Endpoint string `yaml:"endpoint" json:"endpoint"`
-	APIKey   string `yaml:"api_key" json:"api_key"`
-	Model    string `yaml:"model" json:"model"`
-	Enabled  bool   `yaml:"enabled" json:"enabled"`
+	ID        string   `yaml:"id" json:"id"`
+	Name      string   `yaml:"name" json:"name"`
+	Type      string   `yaml:"type" json:"type"`
+	Endpoint  string   `yaml:"endpoint" json:"endpoint"`
+	Endpoints []string `yaml:"endpoints,omitempty" json:"endpoints,omitempty"` // Additional endpoints serving the same model
+	APIKey    string   `yaml:"api_key" json:"api_key"`
+	Model     string   `yaml:"model" json:"model"`
+	Enabled   bool     `yaml:"enabled" json:"enabled"`
 }
 
 // Config represents the main configuration for the loom system.
@@ -44,6 +46,36 @@ type Config struct {
 	Temporal  TemporalConfig  `yaml:"temporal" json:"temporal,omitempty"`
 	HotReload HotReloadConfig `yaml:"hot_reload" json:"hot_reload,omitempty"`
 	OpenClaw  OpenClawConfig  `yaml:"openclaw" json:"openclaw,omitempty"`
+	Executor  ExecutorConfig  `yaml:"executor" json:"executor,omitempty"`
+	CI        CIConfig        `yaml:"ci" json:"ci,omitempty"`
+	// ProviderRecording records or replays provider HTTP traffic
+	ProviderRecording ProviderRecordingConfig `yaml:"provider_recording" json:"provider_recording,omitempty"`
+	Backup            BackupConfig            `yaml:"backup" json:"backup,omitempty"`
+	Retention         RetentionConfig         `yaml:"retention" json:"retention,omitempty"`
+	Patterns          PatternsConfig          `yaml:"patterns" json:"patterns,omitempty"`
+	Pricing           PricingConfig           `yaml:"pricing" json:"pricing,omitempty"`
+	ProviderQueue     ProviderQueueConfig     `yaml:"provider_queue" json:"provider_queue,omitempty"`
+	ProviderHedging   ProviderHedgingConfig   `yaml:"provider_hedging" json:"provider_hedging,omitempty"`
+	RequestBatching   RequestBatchingConfig   `yaml:"request_batching" json:"request_batching,omitempty"`
+	APIThrottle       APIThrottleConfig       `yaml:"api_throttle" json:"api_throttle,omitempty"`
+	ReadOnly          ReadOnlyConfig          `yaml:"read_only" json:"read_only,omitempty"`
+	GRPC              GRPCConfig              `yaml:"grpc" json:"grpc,omitempty"`
+	Logging           LoggingConfig           `yaml:"logging" json:"logging,omitempty"`
+	Features          FeaturesConfig          `yaml:"features" json:"features,omitempty"`
+	Policy            PolicyConfig            `yaml:"policy" json:"policy,omitempty"`
+	Transcription     TranscriptionConfig     `yaml:"transcription" json:"transcription,omitempty"`
+	Containers        ContainersConfig        `yaml:"containers" json:"containers,omitempty"`
+	Kubernetes        KubernetesConfig        `yaml:"kubernetes" json:"kubernetes,omitempty"`
+	Browser           BrowserConfig           `yaml:"browser" json:"browser,omitempty"`
+	TestDatabases     TestDatabasesConfig     `yaml:"test_databases" json:"test_databases,omitempty"`
+	Benchmarks        BenchmarksConfig        `yaml:"benchmarks" json:"benchmarks,omitempty"`
+	Profiling         ProfilingConfig         `yaml:"profiling" json:"profiling,omitempty"`
+	TestSelection     TestSelectionConfig     `yaml:"test_selection" json:"test_selection,omitempty"`
+	Analytics         AnalyticsConfig         `yaml:"analytics" json:"analytics,omitempty"`
+	SLA               SLAConfig               `yaml:"sla" json:"sla,omitempty"`
+	Maintenance       MaintenanceConfig       `yaml:"maintenance" json:"maintenance,omitempty"`
+	Tracker           TrackerConfig           `yaml:"tracker" json:"tracker,omitempty"`
+	RepoMap           RepoMapConfig           `yaml:"repo_map" json:"repo_map,omitempty"`
 
 	// JSON/User-specific configuration fields
 	Providers   []Provider     `yaml:"providers,omitempty" json:"providers"`
@@ -62,6 +94,12 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
 	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// TLSClientAuth is "require" (default) or "request" when client
+	// certificates are verified (security.pki_enabled)
+	TLSClientAuth string `yaml:"tls_client_auth"`
+	// TLSReloadInterval is how often the certificate files are checked for
+	// rotation (default 1m)
+	TLSReloadInterval time.Duration `yaml:"tls_reload_interval"`
 }
 
 // DatabaseConfig configures the local storage
@@ -105,8 +143,25 @@ type AgentsConfig struct {
 	DefaultPersonaPath string        `yaml:"default_persona_path"`
 	HeartbeatInterval  time.Duration `yaml:"heartbeat_interval"`
 	FileLockTimeout    time.Duration `yaml:"file_lock_timeout"`
+	// PresenceTTL is how long an agent stays present on a bead after its
+	// last heartbeat (default 2m)
+	PresenceTTL time.Duration `yaml:"presence_ttl" json:"presence_ttl,omitempty"`
+	// EventBufferSize is how many of each bead's latest stream events are
+	// kept for clients reconnecting with Last-Event-ID (default 256)
+	EventBufferSize int `yaml:"event_buffer_size" json:"event_buffer_size,omitempty"`
 	CorpProfile        string        `yaml:"corp_profile" json:"corp_profile,omitempty"`
 	AllowedRoles       []string      `yaml:"allowed_roles" json:"allowed_roles,omitempty"`
+	// ContextPriorities orders optional prompt context (messages, workflow,
+	// files, memories) when it doesn't all fit the model's context window
+	ContextPriorities []string `yaml:"context_priorities" json:"context_priorities,omitempty"`
+	// RepairAttempts is how many failed builds or test runs after an
+	// agent's changes are fed back for repair before the bead is escalated
+	// with a failure dossier (default 3; negative disables)
+	RepairAttempts int `yaml:"repair_attempts" json:"repair_attempts,omitempty"`
+	// CorrectionAttempts is how many times in a row a malformed action
+	// response is sent back to the model with the error before the attempt
+	// fails (default 2; negative disables)
+	CorrectionAttempts int `yaml:"correction_attempts" json:"correction_attempts,omitempty"`
 }
 
 // ReadinessConfig controls readiness gating behavior
@@ -116,12 +171,96 @@ type ReadinessConfig struct {
 
 // DispatchConfig controls dispatcher guardrails
 type DispatchConfig struct {
-	MaxHops int `yaml:"max_hops" json:"max_hops,omitempty"`
+	MaxHops int                 `yaml:"max_hops" json:"max_hops,omitempty"`
+	Queue   DispatchQueueConfig `yaml:"queue" json:"queue,omitempty"`
+	// LabelRoutes send beads by label to agents of a role; the first
+	// matching route wins
+	LabelRoutes []LabelRouteConfig `yaml:"label_routes" json:"label_routes,omitempty"`
+}
+
+// LabelRouteConfig routes beads matching a label selector such as
+// "security" or "team in (infra,sre)" to agents of Role
+type LabelRouteConfig struct {
+	Selector string `yaml:"selector" json:"selector"`
+	Role     string `yaml:"role" json:"role"`
+}
+
+// DispatchQueueConfig sets how ready beads are ordered for dispatch
+type DispatchQueueConfig struct {
+	// AgingInterval is how long a bead waits to gain a priority level.
+	// 0 uses the default (4h); negative disables aging.
+	AgingInterval time.Duration `yaml:"aging_interval" json:"aging_interval,omitempty"`
+	// ProjectWeights scale the scores of each project's beads (default 1)
+	ProjectWeights map[string]float64 `yaml:"project_weights" json:"project_weights,omitempty"`
+	// RoleCapacity caps how many agents of each role work at once
+	RoleCapacity map[string]int `yaml:"role_capacity" json:"role_capacity,omitempty"`
 }
 
 // GitConfig controls git-related settings
 type GitConfig struct {
 	ProjectKeyDir string `yaml:"project_key_dir" json:"project_key_dir,omitempty"`
+	// AutoSnapshotPatchBytes snapshots a project workdir before action envelopes
+	// whose apply_patch actions total at least this many bytes.
+	// 0 uses the default (4096); negative disables auto-snapshots.
+	AutoSnapshotPatchBytes int `yaml:"auto_snapshot_patch_bytes" json:"auto_snapshot_patch_bytes,omitempty"`
+	// PatchFuzz is how many context lines at each end of a hunk the fuzzy
+	// fallback for patches that don't apply may ignore. 0 uses the default
+	// (2); negative disables the fuzzy fallback.
+	PatchFuzz int `yaml:"patch_fuzz" json:"patch_fuzz,omitempty"`
+	// SecretScan configures the secrets check run on agent commits and
+	// pushes
+	SecretScan SecretScanConfig `yaml:"secret_scan" json:"secret_scan,omitempty"`
+	// CommitPolicy sets the conventions agent commit messages must follow
+	CommitPolicy CommitPolicyConfig `yaml:"commit_policy" json:"commit_policy,omitempty"`
+}
+
+// CommitPolicyConfig is the commit message convention for agent commits.
+// Messages that break it are fixed when the fix is unambiguous (truncating
+// the subject, normalizing or inferring the type) and rejected otherwise.
+// The Bead trailer is always appended when the bead is known.
+type CommitPolicyConfig struct {
+	Conventional       bool     `yaml:"conventional" json:"conventional,omitempty"`                 // Require "type(scope): subject"
+	Types              []string `yaml:"types" json:"types,omitempty"`                               // Allowed conventional types; default feat, fix, docs, style, refactor, perf, test, build, ci, chore, revert
+	MaxSubjectLength   int      `yaml:"max_subject_length" json:"max_subject_length,omitempty"`     // 0 uses the default (72); negative disables the limit
+	RequireBeadTrailer bool     `yaml:"require_bead_trailer" json:"require_bead_trailer,omitempty"` // Reject commits with no bead to attribute them to
+	DisableAutoFix     bool     `yaml:"disable_autofix" json:"disable_autofix,omitempty"`           // Reject every violation instead of fixing it
+}
+
+// SecretScanConfig tunes the scan of lines added by agent commits (and of
+// unpushed commits before a push). Findings block the operation. Built-in
+// rules cover private keys and common token formats; Rules adds to them.
+// A line containing "secretscan:allow" is never reported.
+type SecretScanConfig struct {
+	Disabled         bool             `yaml:"disabled" json:"disabled,omitempty"`
+	Rules            []SecretScanRule `yaml:"rules" json:"rules,omitempty"`
+	DisableRules     []string         `yaml:"disable_rules" json:"disable_rules,omitempty"`         // Built-in rule IDs, or high-entropy-string
+	EntropyThreshold float64          `yaml:"entropy_threshold" json:"entropy_threshold,omitempty"` // Bits per character for string literals; default 4.5
+	AllowPatterns    []string         `yaml:"allow_patterns" json:"allow_patterns,omitempty"`       // Regular expressions for matches that are never findings
+	IgnorePaths      []string         `yaml:"ignore_paths" json:"ignore_paths,omitempty"`           // Globs, in addition to lockfiles
+	CreateBeads      bool             `yaml:"create_beads" json:"create_beads,omitempty"`           // File a remediation bead when an operation is blocked
+}
+
+// SecretScanRule is a custom secret pattern
+type SecretScanRule struct {
+	ID          string `yaml:"id" json:"id"`
+	Description string `yaml:"description" json:"description,omitempty"`
+	Pattern     string `yaml:"pattern" json:"pattern"` // Regular expression
+}
+
+// CIConfig configures CI status feedback for branches pushed by agents.
+// Each provider is enabled by setting its token or URL.
+type CIConfig struct {
+	// PollInterval is how often pushed branches are polled; 0 uses the
+	// default (1m) and negative disables polling (webhooks only).
+	PollInterval  time.Duration `yaml:"poll_interval" json:"poll_interval,omitempty"`
+	WebhookSecret string        `yaml:"webhook_secret" json:"webhook_secret,omitempty"` // Token expected from GitLab and Jenkins webhooks
+	GitHubToken   string        `yaml:"github_token" json:"github_token,omitempty"`
+	GitHubAPIURL  string        `yaml:"github_api_url" json:"github_api_url,omitempty"` // For GitHub Enterprise
+	GitLabToken   string        `yaml:"gitlab_token" json:"gitlab_token,omitempty"`
+	GitLabURL     string        `yaml:"gitlab_url" json:"gitlab_url,omitempty"`
+	JenkinsURL    string        `yaml:"jenkins_url" json:"jenkins_url,omitempty"`
+	JenkinsUser   string        `yaml:"jenkins_user" json:"jenkins_user,omitempty"`
+	JenkinsToken  string        `yaml:"jenkins_token" json:"jenkins_token,omitempty"`
 }
 
 // ModelsConfig configures model preferences for provider negotiation
@@ -129,6 +268,419 @@ type ModelsConfig struct {
 	PreferredModels []PreferredModel `yaml:"preferred_models" json:"preferred_models,omitempty"`
 }
 
+// ProviderRecordingConfig configures the provider VCR. In "record" mode
+// sanitized request/response pairs are appended to Cassette; in "replay"
+// mode they are served from it without contacting any provider.
+type ProviderRecordingConfig struct {
+	Mode     string `yaml:"mode" json:"mode,omitempty"` // "", "record" or "replay"
+	Cassette string `yaml:"cassette" json:"cassette,omitempty"`
+}
+
+// BackupConfig configures scheduled database and key store backups. Backups
+// go to S3 when S3.Bucket is set and to Dir otherwise.
+type BackupConfig struct {
+	Enabled      bool           `yaml:"enabled" json:"enabled,omitempty"`
+	Interval     time.Duration  `yaml:"interval" json:"interval,omitempty"`             // Default 24h
+	Dir          string         `yaml:"dir" json:"dir,omitempty"`                       // Default ./backups
+	KeepLast     int            `yaml:"keep_last" json:"keep_last,omitempty"`           // Newest backups always kept (default 7)
+	MaxAge       time.Duration  `yaml:"max_age" json:"max_age,omitempty"`               // Older backups beyond KeepLast are deleted; 0 deletes them all
+	KeyStorePath string         `yaml:"key_store_path" json:"key_store_path,omitempty"` // Default .keys.json
+	S3           BackupS3Config `yaml:"s3" json:"s3,omitempty"`
+}
+
+// BackupS3Config locates an S3 bucket for backups. Credentials default to
+// the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables.
+type BackupS3Config struct {
+	Bucket          string `yaml:"bucket" json:"bucket,omitempty"`
+	Prefix          string `yaml:"prefix" json:"prefix,omitempty"`
+	Region          string `yaml:"region" json:"region,omitempty"`
+	Endpoint        string `yaml:"endpoint" json:"endpoint,omitempty"` // S3-compatible endpoint such as MinIO
+	AccessKeyID     string `yaml:"access_key_id" json:"access_key_id,omitempty"`
+	SecretAccessKey string `yaml:"secret_access_key" json:"secret_access_key,omitempty"`
+}
+
+// RetentionConfig configures archival of closed beads and pruning of logs.
+// Closed beads older than ClosedBeadDays are moved, with their
+// conversations, command logs and action outputs, to gzipped archives in
+// ArchiveDir; request logs older than RequestLogDays are deleted.
+type RetentionConfig struct {
+	Enabled        bool          `yaml:"enabled" json:"enabled,omitempty"`
+	Interval       time.Duration `yaml:"interval" json:"interval,omitempty"`                 // Default 1h
+	ArchiveDir     string        `yaml:"archive_dir" json:"archive_dir,omitempty"`           // Default ./archive
+	ClosedBeadDays int           `yaml:"closed_bead_days" json:"closed_bead_days,omitempty"` // Default beads.compact_old_days, else 90
+	RequestLogDays int           `yaml:"request_log_days" json:"request_log_days,omitempty"` // Default 30
+}
+
+// PatternsConfig controls pattern report history and anomaly alerts. A
+// report is stored every SnapshotInterval and compared against the newest
+// report at least ComparePeriod older; reports older than ReportRetentionDays
+// are pruned. Anomalies at or above AlertMinSeverity raise an event and an ops
+// bead in AlertProjectID, at most once per AlertDedupWindow for the same
+// anomaly type, provider and model.
+type PatternsConfig struct {
+	SnapshotInterval    time.Duration `yaml:"snapshot_interval" json:"snapshot_interval,omitempty"`         // Default 24h; negative disables snapshots
+	ComparePeriod       time.Duration `yaml:"compare_period" json:"compare_period,omitempty"`               // Default 168h (week over week)
+	ReportRetentionDays int           `yaml:"report_retention_days" json:"report_retention_days,omitempty"` // Default 90; negative keeps forever
+
+	AlertInterval    time.Duration `yaml:"alert_interval" json:"alert_interval,omitempty"`         // Default 15m; negative disables alerts
+	AlertMinSeverity string        `yaml:"alert_min_severity" json:"alert_min_severity,omitempty"` // Default "high"
+	AlertDedupWindow time.Duration `yaml:"alert_dedup_window" json:"alert_dedup_window,omitempty"` // Default 1h
+	AlertProjectID   string        `yaml:"alert_project_id" json:"alert_project_id,omitempty"`     // Default "loom-self"
+}
+
+// ProviderQueueConfig controls queueing of requests to rate-limited
+// providers. After a 429 a provider's requests wait, most urgent bead first,
+// until its Retry-After (or a doubling backoff) has passed; a request gives
+// up after MaxWait or MaxRetries rate-limited attempts.
+type ProviderQueueConfig struct {
+	MaxWait           time.Duration  `yaml:"max_wait" json:"max_wait,omitempty"`                       // Default 2m; negative disables queueing
+	MaxRetries        int            `yaml:"max_retries" json:"max_retries,omitempty"`                 // Default 5
+	DefaultBackoff    time.Duration  `yaml:"default_backoff" json:"default_backoff,omitempty"`         // Default 5s, when a 429 has no Retry-After
+	MaxBackoff        time.Duration  `yaml:"max_backoff" json:"max_backoff,omitempty"`                 // Default 1m
+	RequestsPerMinute map[string]int `yaml:"requests_per_minute" json:"requests_per_minute,omitempty"` // Optional limits by provider ID
+}
+
+// ProviderHedgingConfig turns on hedged requests for short, latency-
+// sensitive calls such as the CEO REPL's next action. When the provider
+// hasn't answered after Delay, the request also goes to the next best
+// provider; the first success wins and the other request is cancelled.
+type ProviderHedgingConfig struct {
+	Enabled   bool          `yaml:"enabled" json:"enabled"`
+	Delay     time.Duration `yaml:"delay" json:"delay,omitempty"`           // Default 2s
+	MaxTokens int           `yaml:"max_tokens" json:"max_tokens,omitempty"` // Default 2048; requests allowing longer completions aren't hedged
+}
+
+// RequestBatchingConfig turns on server-side batching of embedding
+// requests. Requests arriving within Window of each other for the same
+// provider and model are sent as one embeddings call; each caller still
+// gets its own result.
+type RequestBatchingConfig struct {
+	Enabled  bool          `yaml:"enabled" json:"enabled"`
+	Window   time.Duration `yaml:"window" json:"window,omitempty"`       // Default 10ms
+	MaxBatch int           `yaml:"max_batch" json:"max_batch,omitempty"` // Default 64 embedded texts
+	// EmbeddingProvider, when set, embeds lessons and bead estimates with
+	// this provider (and EmbeddingModel) instead of the built-in hash
+	// embedder, falling back to it when the provider fails
+	EmbeddingProvider string `yaml:"embedding_provider" json:"embedding_provider,omitempty"`
+	EmbeddingModel    string `yaml:"embedding_model" json:"embedding_model,omitempty"`
+}
+
+// ReadOnlyConfig starts the server read-only: mutating API requests and
+// agent actions are rejected and no work is dispatched until an admin
+// turns it off through the API
+type ReadOnlyConfig struct {
+	Enabled bool   `yaml:"enabled" json:"enabled"`
+	Reason  string `yaml:"reason" json:"reason,omitempty"` // Shown to callers whose requests are rejected
+}
+
+// GRPCConfig enables the gRPC agent runtime API alongside the REST API. It
+// uses the HTTPS listener's certificate (and client verification) when
+// server.enable_https is set.
+type GRPCConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	Port    int  `yaml:"port" json:"port,omitempty"` // Default 9090
+}
+
+// LoggingConfig configures the structured logger. Modules override the
+// level of single modules (dispatcher, worker, actions, ...); levels can
+// also be changed at runtime through the API.
+type LoggingConfig struct {
+	Level   string            `yaml:"level" json:"level,omitempty"`     // debug, info (default), warn, error
+	Format  string            `yaml:"format" json:"format,omitempty"`   // text (default) or json
+	Modules map[string]string `yaml:"modules" json:"modules,omitempty"` // Module name to level
+}
+
+// FeaturesConfig turns feature flags on or off, globally and per project.
+// Flags not listed keep their defaults; runtime changes through the API
+// apply on top and last until restart.
+type FeaturesConfig struct {
+	Flags    map[string]bool            `yaml:"flags" json:"flags,omitempty"`       // Flag name to state
+	Projects map[string]map[string]bool `yaml:"projects" json:"projects,omitempty"` // Project ID to flag overrides
+}
+
+// PolicyConfig points at the action policy directory: global.yaml applies
+// to every project and <project_id>.yaml to one. The files are checked for
+// changes every ReloadInterval. Keep the directory outside project workdirs
+// so agents can't edit their own policies.
+type PolicyConfig struct {
+	Dir            string        `yaml:"dir" json:"dir,omitempty"`                         // Empty disables action policies
+	ReloadInterval time.Duration `yaml:"reload_interval" json:"reload_interval,omitempty"` // Default 30s
+}
+
+// TranscriptionConfig configures speech-to-text for beads filed by voice
+// memo. Provider "openai" posts to an OpenAI-compatible
+// /audio/transcriptions endpoint; "whisper_cpp" posts to a whisper.cpp
+// server's /inference endpoint, or runs Command with ModelPath when no
+// Endpoint is set.
+type TranscriptionConfig struct {
+	Provider  string        `yaml:"provider" json:"provider,omitempty"`     // "openai" or "whisper_cpp"; empty disables voice intake
+	Endpoint  string        `yaml:"endpoint" json:"endpoint,omitempty"`     // Default https://api.openai.com/v1 for openai
+	APIKey    string        `yaml:"api_key" json:"api_key,omitempty"`       // openai only
+	Model     string        `yaml:"model" json:"model,omitempty"`           // openai only; default whisper-1
+	Command   string        `yaml:"command" json:"command,omitempty"`       // whisper.cpp CLI, such as whisper-cli
+	ModelPath string        `yaml:"model_path" json:"model_path,omitempty"` // ggml model file for Command
+	Language  string        `yaml:"language" json:"language,omitempty"`     // ISO-639-1 hint; empty detects it
+	Timeout   time.Duration `yaml:"timeout" json:"timeout,omitempty"`       // Default 2m
+}
+
+// ContainersConfig configures the build_image and run_container actions,
+// which build project images and run smoke-test containers with Docker or
+// Podman. Containers are limited to Memory and CPUs and their ports are
+// published on localhost only.
+type ContainersConfig struct {
+	Disabled     bool          `yaml:"disabled" json:"disabled,omitempty"`
+	Runtime      string        `yaml:"runtime" json:"runtime,omitempty"`             // docker or podman; default the first installed
+	BuildTimeout time.Duration `yaml:"build_timeout" json:"build_timeout,omitempty"` // Default 10m
+	RunTimeout   time.Duration `yaml:"run_timeout" json:"run_timeout,omitempty"`     // Default 2m; how long a smoke test may take
+	Memory       string        `yaml:"memory" json:"memory,omitempty"`               // Default 1g
+	CPUs         string        `yaml:"cpus" json:"cpus,omitempty"`                   // Default 2
+}
+
+// KubernetesConfig enables per-bead staging deployments to a Kubernetes
+// cluster with kubectl and, for charts, helm. Each bead deploys into its own
+// namespace, which is deleted when the bead closes.
+type KubernetesConfig struct {
+	Enabled         bool          `yaml:"enabled" json:"enabled,omitempty"`
+	Kubeconfig      string        `yaml:"kubeconfig" json:"kubeconfig,omitempty"`             // Default: kubectl's own resolution
+	Context         string        `yaml:"context" json:"context,omitempty"`                   // kubeconfig context
+	NamespacePrefix string        `yaml:"namespace_prefix" json:"namespace_prefix,omitempty"` // Default loom-
+	RolloutTimeout  time.Duration `yaml:"rollout_timeout" json:"rollout_timeout,omitempty"`   // Default 5m
+	LogTailLines    int           `yaml:"log_tail_lines" json:"log_tail_lines,omitempty"`     // Pod log lines reported; default 50
+}
+
+// BrowserConfig configures the browser action, which drives a headless
+// browser through Playwright to check a web UI. Node and the playwright
+// package must be installed, in the project or in NodePath.
+type BrowserConfig struct {
+	Disabled    bool          `yaml:"disabled" json:"disabled,omitempty"`
+	Node        string        `yaml:"node" json:"node,omitempty"`                 // Default node
+	NodePath    string        `yaml:"node_path" json:"node_path,omitempty"`       // node_modules directory with playwright, when the project has none
+	Browser     string        `yaml:"browser" json:"browser,omitempty"`           // chromium (default), firefox or webkit
+	Timeout     time.Duration `yaml:"timeout" json:"timeout,omitempty"`           // Default 2m for a whole session
+	StepTimeout time.Duration `yaml:"step_timeout" json:"step_timeout,omitempty"` // Default 10s for each step
+}
+
+// TestDatabasesConfig configures the throwaway databases of the db_*
+// actions, which agents migrate, load with fixtures and query to validate
+// schema changes. SQLite databases are files in a temporary directory;
+// Postgres databases run in a Docker or Podman container on localhost.
+type TestDatabasesConfig struct {
+	Disabled      bool          `yaml:"disabled" json:"disabled,omitempty"`
+	Runtime       string        `yaml:"runtime" json:"runtime,omitempty"`               // docker or podman for postgres; default the first installed
+	PostgresImage string        `yaml:"postgres_image" json:"postgres_image,omitempty"` // Default postgres:16-alpine
+	StartTimeout  time.Duration `yaml:"start_timeout" json:"start_timeout,omitempty"`   // Default 1m for postgres to accept connections
+	IdleTimeout   time.Duration `yaml:"idle_timeout" json:"idle_timeout,omitempty"`     // Default 2h; idle databases are dropped
+}
+
+// BenchmarksConfig configures the run_benchmarks action, which runs go test
+// -bench or hyperfine, stores the metrics per commit and compares them with
+// the baseline branch. Beads whose last run regressed are held at verify
+// and commit nodes until a run comes back clean.
+type BenchmarksConfig struct {
+	Disabled       bool          `yaml:"disabled" json:"disabled,omitempty"`
+	BaselineBranch string        `yaml:"baseline_branch" json:"baseline_branch,omitempty"` // Default the remote's default branch, else main
+	Threshold      float64       `yaml:"threshold" json:"threshold,omitempty"`             // Percent slowdown or allocation growth counted as a regression; default 10
+	Timeout        time.Duration `yaml:"timeout" json:"timeout,omitempty"`                 // Default 20m for each run
+	SkipBaseline   bool          `yaml:"skip_baseline" json:"skip_baseline,omitempty"`     // Don't benchmark a missing baseline in a temporary worktree
+	DisableGate    bool          `yaml:"disable_gate" json:"disable_gate,omitempty"`       // Report regressions without holding beads in their workflow
+}
+
+// ProfilingConfig configures the profile_project action, which runs a
+// project's test or binary under the CPU and heap profilers and keeps the
+// pprof files on the bead.
+type ProfilingConfig struct {
+	Disabled bool          `yaml:"disabled" json:"disabled,omitempty"`
+	Timeout  time.Duration `yaml:"timeout" json:"timeout,omitempty"` // Default 10m for each run
+	Top      int           `yaml:"top" json:"top,omitempty"`         // Functions in each profile's summary; default 15
+	// ServerPprof serves the server's own runtime profiles to admins at
+	// /api/v1/debug/pprof/. Off unless set: profiles and goroutine dumps
+	// expose the server's internals.
+	ServerPprof bool `yaml:"server_pprof" json:"server_pprof,omitempty"`
+}
+
+// TestSelectionConfig configures run_tests in smart mode, which runs only
+// the tests covering the changed files. After FullEvery smart runs of a
+// project, or FullInterval after its last full run, the full suite runs
+// instead. With RequireFull beads are held at commit nodes until the full
+// suite passed after their last smart run.
+type TestSelectionConfig struct {
+	Disabled     bool          `yaml:"disabled" json:"disabled,omitempty"`
+	FullEvery    int           `yaml:"full_every" json:"full_every,omitempty"`       // Default 10
+	FullInterval time.Duration `yaml:"full_interval" json:"full_interval,omitempty"` // Default 24h
+	RequireFull  bool          `yaml:"require_full" json:"require_full,omitempty"`
+}
+
+// SLAConfig sets bead deadlines. Beads without a due date get one Targets
+// after creation by priority ("p0".."p3"); open beads are evaluated every
+// Interval and flagged at risk once AtRiskFraction of their time is left.
+// On breach a bead is raised one priority level ("bump"), escalated to the
+// CEO ("escalate") or only flagged ("none").
+type SLAConfig struct {
+	Interval       time.Duration            `yaml:"interval" json:"interval,omitempty"`                 // Default 5m; negative disables evaluation
+	Targets        map[string]time.Duration `yaml:"targets" json:"targets,omitempty"`                   // e.g. p0: 4h
+	AtRiskFraction float64                  `yaml:"at_risk_fraction" json:"at_risk_fraction,omitempty"` // Default 0.25
+	OnBreach       string                   `yaml:"on_breach" json:"on_breach,omitempty"`               // Default "bump"
+}
+
+// MaintenanceConfig adds templates for recurring maintenance beads to the
+// built-in dependency_audit, security_scan and key_rotation, or replaces
+// them by name
+type MaintenanceConfig struct {
+	Templates map[string]MaintenanceTemplate `yaml:"templates" json:"templates,omitempty"`
+}
+
+// MaintenanceTemplate is the bead filed for each occurrence of a chore.
+// "{date}" in the title or description becomes the occurrence's date.
+type MaintenanceTemplate struct {
+	Title       string        `yaml:"title" json:"title,omitempty"`
+	Description string        `yaml:"description" json:"description,omitempty"`
+	Type        string        `yaml:"type" json:"type,omitempty"`         // Default "task"
+	Priority    *int          `yaml:"priority" json:"priority,omitempty"` // 0-3; default 2
+	Tags        []string      `yaml:"tags" json:"tags,omitempty"`
+	Interval    time.Duration `yaml:"interval" json:"interval,omitempty"` // Default 168h
+}
+
+// RepoMapConfig configures repository maps, the structural summaries of
+// project checkouts served to agents
+type RepoMapConfig struct {
+	// Summarize asks the active provider what packages without a doc
+	// comment are for
+	Summarize bool `yaml:"summarize" json:"summarize,omitempty"`
+	// InjectPrompt adds the project's map to the context of every agent task
+	InjectPrompt bool `yaml:"inject_prompt" json:"inject_prompt,omitempty"`
+	PromptBudget int  `yaml:"prompt_budget" json:"prompt_budget,omitempty"` // Tokens; default 1500
+}
+
+// TrackerConfig configures two-way sync between beads and issues in an
+// external tracker. Each tracker is enabled by setting its credentials.
+// When a bead and its issue both changed status since the last sync,
+// Conflict decides which wins: "newest" (the most recently updated side),
+// "tracker" or "loom".
+type TrackerConfig struct {
+	Interval time.Duration       `yaml:"interval" json:"interval,omitempty"` // Default 5m; negative disables polling
+	Conflict string              `yaml:"conflict" json:"conflict,omitempty"` // Default "newest"
+	Jira     JiraTrackerConfig   `yaml:"jira" json:"jira,omitempty"`
+	Linear   LinearTrackerConfig `yaml:"linear" json:"linear,omitempty"`
+	GitHub   GitHubTrackerConfig `yaml:"github" json:"github,omitempty"`
+}
+
+// JiraTrackerConfig connects to Jira Cloud or Server. User and Token
+// authenticate with basic auth; Token alone is sent as a bearer token.
+type JiraTrackerConfig struct {
+	URL      string           `yaml:"url" json:"url,omitempty"`
+	User     string           `yaml:"user" json:"user,omitempty"`
+	Token    string           `yaml:"token" json:"token,omitempty"`
+	Statuses TrackerStatusMap `yaml:"statuses" json:"statuses,omitempty"`
+}
+
+// LinearTrackerConfig connects to Linear
+type LinearTrackerConfig struct {
+	APIKey   string           `yaml:"api_key" json:"api_key,omitempty"`
+	APIURL   string           `yaml:"api_url" json:"api_url,omitempty"` // Default https://api.linear.app/graphql
+	Statuses TrackerStatusMap `yaml:"statuses" json:"statuses,omitempty"`
+}
+
+// GitHubTrackerConfig configures importing GitHub issues as beads. Token and
+// APIURL default to the CI settings.
+type GitHubTrackerConfig struct {
+	Token          string         `yaml:"token" json:"token,omitempty"`
+	APIURL         string         `yaml:"api_url" json:"api_url,omitempty"`                 // For GitHub Enterprise
+	PriorityLabels map[string]int `yaml:"priority_labels" json:"priority_labels,omitempty"` // Label -> bead priority, beyond p0-p3, critical/high/medium/low
+}
+
+// TrackerStatusMap overrides how tracker statuses and bead statuses map onto
+// each other. Unmapped tracker statuses fall back on their category (to do,
+// in progress, done).
+type TrackerStatusMap struct {
+	Inbound  map[string]string `yaml:"inbound" json:"inbound,omitempty"`   // Tracker status name -> bead status
+	Outbound map[string]string `yaml:"outbound" json:"outbound,omitempty"` // Bead status -> tracker status name
+}
+
+// AnalyticsConfig configures how request logs reach analytics storage.
+// Logs are queued in memory and written in batches off the request path;
+// when the queue is full they spill to files in SpillDir, replayed once it
+// drains and on the next start.
+type AnalyticsConfig struct {
+	SyncWrites    bool          `yaml:"sync_writes" json:"sync_writes,omitempty"`         // Write each log in the request path instead of queueing
+	QueueSize     int           `yaml:"queue_size" json:"queue_size,omitempty"`           // Logs held in memory; default 10000
+	BatchSize     int           `yaml:"batch_size" json:"batch_size,omitempty"`           // Logs per write; default 200
+	FlushInterval time.Duration `yaml:"flush_interval" json:"flush_interval,omitempty"`   // Longest a log waits in memory; default 1s
+	SpillDir      string        `yaml:"spill_dir" json:"spill_dir,omitempty"`             // Default ./analytics-spill
+	MaxSpillBytes int64         `yaml:"max_spill_bytes" json:"max_spill_bytes,omitempty"` // Logs beyond this are dropped; default 256MB
+
+	Sink AnalyticsSinkConfig `yaml:"sink" json:"sink,omitempty"`
+}
+
+// AnalyticsSinkConfig mirrors request logs and pattern reports to an
+// external analytical store as they are written. The local database stays
+// the source of truth; rows that can't be delivered are dropped after
+// retries rather than holding up logging.
+type AnalyticsSinkConfig struct {
+	Type          string        `yaml:"type" json:"type,omitempty"`                     // "", "clickhouse" or "bigquery"; empty disables the sink
+	Deployment    string        `yaml:"deployment" json:"deployment,omitempty"`         // Written to every row to tell deployments apart; default the hostname
+	LogsTable     string        `yaml:"logs_table" json:"logs_table,omitempty"`         // Default loom_request_logs
+	ReportsTable  string        `yaml:"reports_table" json:"reports_table,omitempty"`   // Default loom_pattern_reports
+	BatchSize     int           `yaml:"batch_size" json:"batch_size,omitempty"`         // Rows per insert; default 500
+	FlushInterval time.Duration `yaml:"flush_interval" json:"flush_interval,omitempty"` // Longest a row waits; default 5s
+	QueueSize     int           `yaml:"queue_size" json:"queue_size,omitempty"`         // Rows held while the store is unreachable; default 50000
+
+	ClickHouse AnalyticsClickHouseConfig `yaml:"clickhouse" json:"clickhouse,omitempty"`
+	BigQuery   AnalyticsBigQueryConfig   `yaml:"bigquery" json:"bigquery,omitempty"`
+}
+
+// AnalyticsClickHouseConfig locates a ClickHouse server's HTTP interface.
+// Credentials fall back to the CLICKHOUSE_USER and CLICKHOUSE_PASSWORD
+// environment variables.
+type AnalyticsClickHouseConfig struct {
+	URL      string `yaml:"url" json:"url,omitempty"`           // Default http://localhost:8123
+	Database string `yaml:"database" json:"database,omitempty"` // Default "default"
+	User     string `yaml:"user" json:"user,omitempty"`
+	Password string `yaml:"password" json:"password,omitempty"`
+}
+
+// AnalyticsBigQueryConfig locates a BigQuery dataset. Rows are streamed
+// with a service account key, defaulting to GOOGLE_APPLICATION_CREDENTIALS,
+// or a fixed access token.
+type AnalyticsBigQueryConfig struct {
+	Project         string `yaml:"project" json:"project,omitempty"`
+	Dataset         string `yaml:"dataset" json:"dataset,omitempty"`
+	CredentialsFile string `yaml:"credentials_file" json:"credentials_file,omitempty"`
+	AccessToken     string `yaml:"access_token" json:"access_token,omitempty"`
+	Endpoint        string `yaml:"endpoint" json:"endpoint,omitempty"` // Default https://bigquery.googleapis.com
+}
+
+// APIThrottleConfig limits HTTP API requests per API key, or per user when
+// no key is sent, with a token bucket refilled at RequestsPerMinute and
+// holding up to Burst requests. Roles override the default limit.
+type APIThrottleConfig struct {
+	Enabled           bool                        `yaml:"enabled" json:"enabled"`
+	RequestsPerMinute int                         `yaml:"requests_per_minute" json:"requests_per_minute,omitempty"` // Default 600; negative is unlimited
+	Burst             int                         `yaml:"burst" json:"burst,omitempty"`                             // Default 100
+	Roles             map[string]APIThrottleLimit `yaml:"roles" json:"roles,omitempty"`                             // Overrides by role
+}
+
+// APIThrottleLimit is the request limit of one role
+type APIThrottleLimit struct {
+	RequestsPerMinute int `yaml:"requests_per_minute" json:"requests_per_minute"` // Default: the default rate; negative is unlimited
+	Burst             int `yaml:"burst" json:"burst,omitempty"`                   // Default: the default burst
+}
+
+// PricingConfig seeds per-model pricing rates at startup. A seed already
+// stored (same provider, model and effective date) is left alone, so to
+// change a price add a rate with a later effective date.
+type PricingConfig struct {
+	Rates []PricingRate `yaml:"rates" json:"rates,omitempty"`
+}
+
+// PricingRate is a per-million-token price effective from a date
+type PricingRate struct {
+	ProviderID    string  `yaml:"provider_id" json:"provider_id,omitempty"` // Empty matches any provider
+	ModelName     string  `yaml:"model" json:"model,omitempty"`             // Empty matches any model of the provider
+	InputPerMTok  float64 `yaml:"input_per_mtok" json:"input_per_mtok"`
+	OutputPerMTok float64 `yaml:"output_per_mtok" json:"output_per_mtok"`
+	EffectiveFrom string  `yaml:"effective_from" json:"effective_from,omitempty"` // YYYY-MM-DD or RFC3339; default always
+}
+
 // PreferredModel represents a model preference for negotiation with providers.
 // When a provider returns multiple models, Loom selects the best match from this list.
 type PreferredModel struct {
@@ -149,6 +701,12 @@ type SecurityConfig struct {
 	APIKeys        []string `yaml:"api_keys,omitempty"`
 	JWTSecret      string   `yaml:"jwt_secret" json:"jwt_secret,omitempty"`
 	WebhookSecret  string   `yaml:"webhook_secret" json:"webhook_secret,omitempty"` // GitHub webhook secret
+	// ScanCreateBeads files beads for new high and critical findings on every
+	// security scan, not only scans that ask for it.
+	ScanCreateBeads bool `yaml:"scan_create_beads" json:"scan_create_beads,omitempty"`
+	// ClientCertRole is the role of callers authenticated by a verified
+	// client certificate (default "service")
+	ClientCertRole string `yaml:"client_cert_role" json:"client_cert_role,omitempty"`
 }
 
 // TemporalConfig configures Temporal workflow engine
@@ -175,17 +733,45 @@ type CacheConfig struct {
 
 // ProjectConfig represents a project configuration
 type ProjectConfig struct {
-	ID              string            `yaml:"id"`
-	Name            string            `yaml:"name"`
-	GitRepo         string            `yaml:"git_repo"`
-	Branch          string            `yaml:"branch"`
-	BeadsPath       string            `yaml:"beads_path"`
-	GitAuthMethod   string            `yaml:"git_auth_method" json:"git_auth_method,omitempty"`
-	GitStrategy     string            `yaml:"git_strategy" json:"git_strategy,omitempty"`
-	GitCredentialID string            `yaml:"git_credential_id" json:"git_credential_id,omitempty"`
-	IsPerpetual     bool              `yaml:"is_perpetual" json:"is_perpetual,omitempty"`
-	IsSticky        bool              `yaml:"is_sticky" json:"is_sticky,omitempty"`
-	Context         map[string]string `yaml:"context"`
+	ID              string              `yaml:"id"`
+	Name            string              `yaml:"name"`
+	GitRepo         string              `yaml:"git_repo"`
+	Branch          string              `yaml:"branch"`
+	BeadsPath       string              `yaml:"beads_path"`
+	GitAuthMethod   string              `yaml:"git_auth_method" json:"git_auth_method,omitempty"`
+	GitStrategy     string              `yaml:"git_strategy" json:"git_strategy,omitempty"`
+	GitCredentialID string              `yaml:"git_credential_id" json:"git_credential_id,omitempty"`
+	IsPerpetual     bool                `yaml:"is_perpetual" json:"is_perpetual,omitempty"`
+	IsSticky        bool                `yaml:"is_sticky" json:"is_sticky,omitempty"`
+	Context         map[string]string   `yaml:"context"`
+	Subprojects     []models.Subproject `yaml:"subprojects" json:"subprojects,omitempty"`
+	MCPServers      []MCPServerConfig   `yaml:"mcp_servers" json:"mcp_servers,omitempty"`
+	Staging         *StagingConfig      `yaml:"staging" json:"staging,omitempty"`
+}
+
+// StagingConfig describes how a project is deployed to a bead's staging
+// namespace: either plain manifests or a Helm chart, both relative to the
+// repository root. Manifests may reference ${LOOM_IMAGE}, ${LOOM_NAMESPACE},
+// ${LOOM_BEAD} and ${LOOM_PROJECT}.
+type StagingConfig struct {
+	Manifests   string            `yaml:"manifests" json:"manifests,omitempty"`       // File or directory of YAML manifests
+	Chart       string            `yaml:"chart" json:"chart,omitempty"`               // Helm chart directory
+	Values      map[string]string `yaml:"values" json:"values,omitempty"`             // Helm --set values
+	ValuesFiles []string          `yaml:"values_files" json:"values_files,omitempty"` // Helm -f files
+	Image       string            `yaml:"image" json:"image,omitempty"`               // Default image; ${LOOM_BEAD} is replaced with the bead ID
+	ImageValue  string            `yaml:"image_value" json:"image_value,omitempty"`   // Helm value set to the image; default image.repository and image.tag
+}
+
+// MCPServerConfig is an external MCP tool server the project's agents can
+// call: a command speaking MCP over stdio, or a streamable HTTP URL.
+type MCPServerConfig struct {
+	Name    string            `yaml:"name" json:"name"`
+	Command string            `yaml:"command" json:"command,omitempty"`
+	Args    []string          `yaml:"args" json:"args,omitempty"`
+	Env     map[string]string `yaml:"env" json:"env,omitempty"`
+	URL     string            `yaml:"url" json:"url,omitempty"`
+	Headers map[string]string `yaml:"headers" json:"headers,omitempty"`
+	Timeout time.Duration     `yaml:"timeout" json:"timeout,omitempty"` // Per call; default 60s
 }
 
 // WebUIConfig configures the web interface
@@ -219,6 +805,26 @@ type OpenClawConfig struct {
 	EscalationsOnly  bool          `yaml:"escalations_only" json:"escalations_only"` // Only send P0/CEO-escalated decisions
 }
 
+// ExecutorConfig controls limits on agent command execution
+type ExecutorConfig struct {
+	DefaultQuota  ExecutorQuota            `yaml:"default_quota" json:"default_quota,omitempty"`
+	ProjectQuotas map[string]ExecutorQuota `yaml:"project_quotas" json:"project_quotas,omitempty"` // Keyed by project ID
+
+	// ActionTimeouts bounds each agent action type (e.g. read_file: 5s,
+	// run_tests: 15m); DefaultActionTimeout covers the rest. Zero is unlimited.
+	ActionTimeouts       map[string]time.Duration `yaml:"action_timeouts" json:"action_timeouts,omitempty"`
+	DefaultActionTimeout time.Duration            `yaml:"default_action_timeout" json:"default_action_timeout,omitempty"`
+}
+
+// ExecutorQuota limits one project's executor usage. Zero values are unlimited.
+type ExecutorQuota struct {
+	MaxConcurrentCommands int           `yaml:"max_concurrent_commands" json:"max_concurrent_commands,omitempty"`
+	MaxCPUSecondsPerDay   float64       `yaml:"max_cpu_seconds_per_day" json:"max_cpu_seconds_per_day,omitempty"`
+	MaxMemoryMB           int64         `yaml:"max_memory_mb" json:"max_memory_mb,omitempty"` // Per command, including children
+	MaxDiskMB             int64         `yaml:"max_disk_mb" json:"max_disk_mb,omitempty"`     // Working directory size
+	MaxWallClockPerDay    time.Duration `yaml:"max_wall_clock_per_day" json:"max_wall_clock_per_day,omitempty"`
+}
+
 // LoadConfigFromFile loads configuration from a YAML file at the specified path.
 // This is typically used for loading system-wide or project-specific configuration.
 func LoadConfigFromFile(path string) (*Config, error) {
@@ -339,6 +945,12 @@ func DefaultConfig() *Config {
 			RetryDelay:      2 * time.Second,
 			EscalationsOnly: true,
 		},
+		Backup: BackupConfig{
+			Interval:     24 * time.Hour,
+			Dir:          "./backups",
+			KeepLast:     7,
+			KeyStorePath: ".keys.json",
+		},
 	}
 }
 
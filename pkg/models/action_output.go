@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ActionOutput is the full text of one field of an action result (stdout,
+// file content, build output...) kept so truncated feedback can point at it
+// and the rest can be paged in on demand. Content is only loaded by ranged
+// reads.
+type ActionOutput struct {
+	ID         string    `json:"id"`
+	ProjectID  string    `json:"project_id,omitempty"`
+	BeadID     string    `json:"bead_id,omitempty"`
+	AgentID    string    `json:"agent_id,omitempty"`
+	ActionType string    `json:"action_type"`
+	Field      string    `json:"field"`
+	Size       int64     `json:"size"`
+	Content    string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
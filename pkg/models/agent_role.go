@@ -0,0 +1,151 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// AgentRole defines what an agent in a role is told and allowed to do.
+// Agents are matched to a role by their role or persona name (see Aliases);
+// agents matching no role are unrestricted.
+type AgentRole struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// SystemPrompt is a text/template rendered into the agent's system
+	// prompt with .Role, .AgentName and .ProjectID
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	// AllowedActions limits the role to these action types; empty allows all
+	AllowedActions []string `json:"allowed_actions,omitempty"`
+	// DeniedActions are never allowed, even when listed in AllowedActions
+	DeniedActions []string `json:"denied_actions,omitempty"`
+	// Aliases are agent role or persona names that map to this role
+	Aliases []string `json:"aliases,omitempty"`
+	// PreferredProviders are provider IDs to run the role on, in order
+	PreferredProviders []string `json:"preferred_providers,omitempty"`
+	// PreferredModel is a model name to prefer when choosing a provider
+	PreferredModel string    `json:"preferred_model,omitempty"`
+	BuiltIn        bool      `json:"built_in"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Allows reports whether the role may execute an action type
+func (r *AgentRole) Allows(actionType string) bool {
+	for _, a := range r.DeniedActions {
+		if a == actionType {
+			return false
+		}
+	}
+	if len(r.AllowedActions) == 0 {
+		return true
+	}
+	for _, a := range r.AllowedActions {
+		if a == actionType {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether an agent role or persona name maps to this role
+func (r *AgentRole) Matches(name string) bool {
+	name = NormalizeRoleName(name)
+	if name == "" {
+		return false
+	}
+	if name == NormalizeRoleName(r.Name) {
+		return true
+	}
+	for _, alias := range r.Aliases {
+		if name == NormalizeRoleName(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeRoleName lowercases a role or persona name and reduces it to
+// dash-separated words, so "Code Reviewer" and "default/code-reviewer"
+// both become "code-reviewer"
+func NormalizeRoleName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.NewReplacer("_", "-", " ", "-").Replace(name)
+	for strings.Contains(name, "--") {
+		name = strings.ReplaceAll(name, "--", "-")
+	}
+	return strings.Trim(name, "-")
+}
+
+// readOnlyActions are the inspection actions every role may use
+var readOnlyActions = []string{
+	"read_code", "read_file", "read_tree", "search_text", "read_result",
+	"find_references", "go_to_definition", "find_implementations",
+	"git_status", "git_diff", "git_log", "git_list_branches", "git_diff_branches", "git_bead_commits",
+	"ask_followup", "whats_next", "send_agent_message", "done",
+}
+
+// writeActions change the working tree or the remote repository
+var writeActions = []string{
+	"edit_code", "multi_edit", "write_file", "apply_patch", "move_file", "delete_file", "rename_file",
+	"extract_method", "rename_symbol", "inline_variable", "add_log", "add_breakpoint",
+	"add_import", "insert_function_after", "add_struct_field",
+	"run_formatter", "git_commit", "git_push", "git_merge", "git_revert", "git_branch_delete", "create_pr",
+}
+
+// DefaultAgentRoles returns the built-in roles seeded into an empty registry
+func DefaultAgentRoles() []*AgentRole {
+	withReadOnly := func(actions ...string) []string {
+		return append(append([]string{}, readOnlyActions...), actions...)
+	}
+	return []*AgentRole{
+		{
+			Name:        "ceo",
+			Description: "Sets priorities, approves escalations and delegates work",
+			SystemPrompt: "You are {{.AgentName}}, acting as CEO. Decide, prioritize and delegate; " +
+				"do not write code yourself.",
+			AllowedActions: withReadOnly("create_bead", "close_bead", "approve_bead", "reject_bead", "delegate_task"),
+			Aliases:        []string{"cto"},
+			BuiltIn:        true,
+		},
+		{
+			Name:        "architect",
+			Description: "Designs changes and breaks them into beads for coders",
+			SystemPrompt: "You are {{.AgentName}}, the architect. Study the code, design the change " +
+				"and split it into well-scoped beads; leave implementation to coders.",
+			AllowedActions: withReadOnly("create_bead", "delegate_task", "generate_docs", "escalate_ceo"),
+			Aliases:        []string{"software-architect"},
+			BuiltIn:        true,
+		},
+		{
+			Name:        "coder",
+			Description: "Implements, builds, tests and lands changes",
+			SystemPrompt: "You are {{.AgentName}}, a coder. Make the change, verify it builds and " +
+				"passes tests, then commit and push.",
+			DeniedActions: []string{"approve_bead", "reject_bead", "submit_review"},
+			Aliases: []string{"engineer", "backend-engineer", "frontend-engineer", "web-designer-engineer",
+				"devops-engineer", "engineering-manager"},
+			BuiltIn: true,
+		},
+		{
+			Name:        "reviewer",
+			Description: "Reviews changes without modifying them",
+			SystemPrompt: "You are {{.AgentName}}, a code reviewer. Read the change critically and " +
+				"report findings; never modify, commit or push code.",
+			DeniedActions: writeActions,
+			Aliases:       []string{"code-reviewer"},
+			BuiltIn:       true,
+		},
+		{
+			Name:        "tester",
+			Description: "Writes and runs tests and reports failures",
+			SystemPrompt: "You are {{.AgentName}}, a tester. Exercise the change with builds and " +
+				"tests and file beads for what fails.",
+			DeniedActions: []string{"git_push", "git_merge", "git_branch_delete", "create_pr", "approve_bead"},
+			Aliases:       []string{"qa", "qa-engineer", "quality-assurance"},
+			BuiltIn:       true,
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Benchmark metrics compared between runs
+const (
+	MetricNsPerOp     = "ns/op"
+	MetricBytesPerOp  = "B/op"
+	MetricAllocsPerOp = "allocs/op"
+)
+
+// BenchmarkResult holds one benchmark's metrics in a run. Repeated samples
+// (go test -count, hyperfine runs) are reduced to their median.
+type BenchmarkResult struct {
+	Name        string  `json:"name"`              // Benchmark name without the GOMAXPROCS suffix, or the hyperfine command
+	Package     string  `json:"package,omitempty"` // Go package import path
+	Samples     int     `json:"samples"`
+	Iterations  int64   `json:"iterations,omitempty"`
+	NsPerOp     float64 `json:"ns_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op,omitempty"`
+	AllocsPerOp float64 `json:"allocs_per_op,omitempty"`
+	Memory      bool    `json:"memory,omitempty"` // BytesPerOp and AllocsPerOp were measured
+}
+
+// Key identifies the benchmark across runs
+func (r *BenchmarkResult) Key() string {
+	if r.Package == "" {
+		return r.Name
+	}
+	return r.Package + "." + r.Name
+}
+
+// BenchmarkComparison is one metric of a benchmark measured against the
+// baseline run
+type BenchmarkComparison struct {
+	Name       string  `json:"name"` // BenchmarkResult.Key
+	Metric     string  `json:"metric"`
+	Baseline   float64 `json:"baseline"`
+	Current    float64 `json:"current"`
+	Delta      float64 `json:"delta"` // Change in percent; positive is slower or more allocation
+	Regression bool    `json:"regression,omitempty"`
+}
+
+// BenchmarkRun is the persisted result of running a project's benchmarks at
+// one commit, with the regressions found against its baseline
+type BenchmarkRun struct {
+	ID             string                `json:"id"`
+	ProjectID      string                `json:"project_id"`
+	BeadID         string                `json:"bead_id,omitempty"`
+	Branch         string                `json:"branch"`
+	Commit         string                `json:"commit"`
+	Tool           string                `json:"tool"` // go or hyperfine
+	Path           string                `json:"path,omitempty"`
+	Results        []BenchmarkResult     `json:"results"`
+	BaselineID     string                `json:"baseline_id,omitempty"`
+	BaselineCommit string                `json:"baseline_commit,omitempty"`
+	Threshold      float64               `json:"threshold,omitempty"` // Percent change counted as a regression
+	Regressions    []BenchmarkComparison `json:"regressions,omitempty"`
+	StartedAt      time.Time             `json:"started_at"`
+	CompletedAt    time.Time             `json:"completed_at"`
+}
+
+// Result returns the run's result for a benchmark key, or nil
+func (r *BenchmarkRun) Result(key string) *BenchmarkResult {
+	for i := range r.Results {
+		if r.Results[i].Key() == key {
+			return &r.Results[i]
+		}
+	}
+	return nil
+}
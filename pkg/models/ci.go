@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// CI run states, normalized across providers
+const (
+	CIStatusPending  = "pending"
+	CIStatusRunning  = "running"
+	CIStatusSuccess  = "success"
+	CIStatusFailure  = "failure"
+	CIStatusCanceled = "canceled"
+)
+
+// CIJob is one job (or stage) of a CI run
+type CIJob struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	URL        string `json:"url,omitempty"`
+	LogExcerpt string `json:"log_excerpt,omitempty"`
+}
+
+// CIRun is a pipeline or workflow run for a branch, attached to the bead that
+// owns the branch
+type CIRun struct {
+	ID         string    `json:"id"`
+	Provider   string    `json:"provider"`
+	ProjectID  string    `json:"project_id,omitempty"`
+	BeadID     string    `json:"bead_id,omitempty"`
+	Repository string    `json:"repository,omitempty"`
+	Branch     string    `json:"branch"`
+	CommitSHA  string    `json:"commit_sha,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	Status     string    `json:"status"`
+	URL        string    `json:"url,omitempty"`
+	Jobs       []CIJob   `json:"jobs,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Finished reports whether the run reached a terminal state
+func (r *CIRun) Finished() bool {
+	return r.Status == CIStatusSuccess || r.Status == CIStatusFailure || r.Status == CIStatusCanceled
+}
+
+// FailedJobs returns the jobs that failed
+func (r *CIRun) FailedJobs() []CIJob {
+	var failed []CIJob
+	for _, j := range r.Jobs {
+		if j.Status == CIStatusFailure {
+			failed = append(failed, j)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Custom field types
+const (
+	FieldTypeString = "string"
+	FieldTypeNumber = "number"
+	FieldTypeEnum   = "enum"
+	FieldTypeDate   = "date" // YYYY-MM-DD
+)
+
+// BeadFieldDefinition declares a custom field a project's beads may carry,
+// such as a ticket URL, customer or severity. Values live in Bead.Fields
+// under Name.
+type BeadFieldDefinition struct {
+	ProjectID   string   `json:"project_id"`
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Label       string   `json:"label,omitempty"` // Display name; defaults to Name
+	Description string   `json:"description,omitempty"`
+	Required    bool     `json:"required,omitempty"` // Must be set when a bead is created
+	Options     []string `json:"options,omitempty"`  // Allowed values of an enum
+	Searchable  bool     `json:"searchable,omitempty"`
+
+	// Rendering hints for the UI
+	Widget      string `json:"widget,omitempty"` // text, textarea, url, select, date or number
+	Placeholder string `json:"placeholder,omitempty"`
+	Order       int    `json:"order,omitempty"` // Fields are shown in ascending order
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -1,6 +1,8 @@
 package models
 
-import "log"
+import "github.com/jordanhubbard/loom/internal/logging"
+
+var migrationLog = logging.Module("migrations")
 
 // init registers all known entity migrations
 func init() {
@@ -25,7 +27,7 @@ func registerInitialMigrations() {
 			return nil
 		},
 	); err != nil {
-		log.Printf("Warning: failed to register agent migration: %v", err)
+		migrationLog.Warn("Failed to register agent migration", "error", err)
 	}
 
 	// Project migrations
@@ -41,7 +43,7 @@ func registerInitialMigrations() {
 			return nil
 		},
 	); err != nil {
-		log.Printf("Warning: failed to register project migration: %v", err)
+		migrationLog.Warn("Failed to register project migration", "error", err)
 	}
 
 	// Provider migrations
@@ -57,7 +59,7 @@ func registerInitialMigrations() {
 			return nil
 		},
 	); err != nil {
-		log.Printf("Warning: failed to register provider migration: %v", err)
+		migrationLog.Warn("Failed to register provider migration", "error", err)
 	}
 
 	// OrgChart migrations
@@ -73,7 +75,7 @@ func registerInitialMigrations() {
 			return nil
 		},
 	); err != nil {
-		log.Printf("Warning: failed to register org chart migration: %v", err)
+		migrationLog.Warn("Failed to register org chart migration", "error", err)
 	}
 
 	// Position migrations
@@ -89,7 +91,7 @@ func registerInitialMigrations() {
 			return nil
 		},
 	); err != nil {
-		log.Printf("Warning: failed to register position migration: %v", err)
+		migrationLog.Warn("Failed to register position migration", "error", err)
 	}
 
 	// Persona migrations
@@ -105,7 +107,7 @@ func registerInitialMigrations() {
 			return nil
 		},
 	); err != nil {
-		log.Printf("Warning: failed to register persona migration: %v", err)
+		migrationLog.Warn("Failed to register persona migration", "error", err)
 	}
 
 	// Bead migrations
@@ -121,7 +123,7 @@ func registerInitialMigrations() {
 			return nil
 		},
 	); err != nil {
-		log.Printf("Warning: failed to register bead migration: %v", err)
+		migrationLog.Warn("Failed to register bead migration", "error", err)
 	}
 }
 
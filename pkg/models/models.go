@@ -157,6 +157,9 @@ type Project struct {
 	LastSyncAt       *time.Time        `json:"last_sync_at,omitempty"`       // Last git pull/fetch
 	LastCommitHash   string            `json:"last_commit_hash,omitempty"`   // Last known commit SHA
 	GitConfigOptions map[string]string `json:"git_config_options,omitempty"` // Custom git config for this project
+
+	// Monorepo layout
+	Subprojects []Subproject `json:"subprojects,omitempty"` // Path-scoped build/test/search settings
 }
 
 // VersionedEntity interface implementation for Project
@@ -204,21 +207,23 @@ const (
 type Bead struct {
 	EntityMetadata `json:",inline"`
 
-	ID          string            `json:"id"`
-	Type        string            `json:"type"` // "task", "decision", "epic"
-	Title       string            `json:"title"`
-	Description string            `json:"description"`
-	Status      BeadStatus        `json:"status"`
-	Priority    BeadPriority      `json:"priority"`
-	ProjectID   string            `json:"project_id"`
-	AssignedTo  string            `json:"assigned_to,omitempty"` // Agent ID
-	BlockedBy   []string          `json:"blocked_by,omitempty"`  // Bead IDs
-	Blocks      []string          `json:"blocks,omitempty"`      // Bead IDs
-	RelatedTo   []string          `json:"related_to,omitempty"`  // Bead IDs
-	Parent      string            `json:"parent,omitempty"`      // Parent bead ID
-	Children    []string          `json:"children,omitempty"`    // Child bead IDs
-	Tags        []string          `json:"tags,omitempty"`
-	Context     map[string]string `json:"context,omitempty"`
+	ID          string                 `json:"id"`
+	Type        string                 `json:"type"` // "task", "decision", "epic"
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Status      BeadStatus             `json:"status"`
+	Priority    BeadPriority           `json:"priority"`
+	ProjectID   string                 `json:"project_id"`
+	AssignedTo  string                 `json:"assigned_to,omitempty"` // Agent ID
+	BlockedBy   []string               `json:"blocked_by,omitempty"`  // Bead IDs
+	Blocks      []string               `json:"blocks,omitempty"`      // Bead IDs
+	RelatedTo   []string               `json:"related_to,omitempty"`  // Bead IDs
+	Parent      string                 `json:"parent,omitempty"`      // Parent bead ID
+	Children    []string               `json:"children,omitempty"`    // Child bead IDs
+	Tags        []string               `json:"tags,omitempty"`
+	Labels      map[string]string      `json:"labels,omitempty"` // Key-only labels have an empty value
+	Fields      map[string]interface{} `json:"fields,omitempty"` // Custom field values by name
+	Context     map[string]string      `json:"context,omitempty"`
 
 	// Deadline tracking (motivation system)
 	DueDate       *time.Time `json:"due_date,omitempty"`       // When this bead should be completed
@@ -228,6 +233,10 @@ type Bead struct {
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+
+	// Version increments on every change; the API exposes it as the ETag
+	// for optimistic concurrency
+	Version int64 `json:"version"`
 }
 
 // VersionedEntity interface implementation for Bead
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Profile kinds
+const (
+	ProfileCPU  = "cpu"
+	ProfileHeap = "heap"
+)
+
+// ProfileEntry is one function in a profile's top list, as go tool pprof
+// -top prints it
+type ProfileEntry struct {
+	Function    string  `json:"function"`
+	Flat        string  `json:"flat"` // Sample value with its unit, e.g. 1.20s or 512kB
+	FlatPercent float64 `json:"flat_percent"`
+	Cum         string  `json:"cum"`
+	CumPercent  float64 `json:"cum_percent"`
+}
+
+// Profile is a pprof file captured from a project's test or binary and kept
+// as an artifact of the bead it was captured for
+type Profile struct {
+	ID        string         `json:"id"`
+	ProjectID string         `json:"project_id"`
+	BeadID    string         `json:"bead_id,omitempty"`
+	Kind      string         `json:"kind"`   // cpu or heap
+	Target    string         `json:"target"` // The test package or command profiled
+	Name      string         `json:"name"`   // File name for downloads
+	Size      int            `json:"size"`
+	Total     string         `json:"total,omitempty"` // Total sample value, e.g. 3.50s
+	Top       []ProfileEntry `json:"top,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	Data      []byte         `json:"-"`
+}
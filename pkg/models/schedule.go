@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Schedule is a recurring background job run by the scheduler. Kind selects
+// the registered handler; Params carries handler-specific settings.
+type Schedule struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Kind            string            `json:"kind"`
+	ProjectID       string            `json:"project_id,omitempty"`
+	IntervalSeconds int64             `json:"interval_seconds"`
+	Params          map[string]string `json:"params,omitempty"`
+	Enabled         bool              `json:"enabled"`
+	NextRunAt       time.Time         `json:"next_run_at"`
+	LastRunAt       *time.Time        `json:"last_run_at,omitempty"`
+	LastResult      string            `json:"last_result,omitempty"`
+	LastError       string            `json:"last_error,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// Interval returns the time between runs
+func (s *Schedule) Interval() time.Duration {
+	return time.Duration(s.IntervalSeconds) * time.Second
+}
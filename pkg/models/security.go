@@ -0,0 +1,67 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Security finding severities, most severe first
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityInfo     = "info"
+)
+
+// SecurityFinding is a single issue reported by a static analysis or
+// dependency scanner.
+type SecurityFinding struct {
+	Tool     string `json:"tool"`
+	RuleID   string `json:"rule_id"`
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"` // Relative to the repository root
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+	New      bool   `json:"new,omitempty"` // Not reported by the project's previous scan
+}
+
+// Fingerprint identifies a finding across scans. Line numbers are left out
+// so unrelated edits above a finding don't make it look new.
+func (f *SecurityFinding) Fingerprint() string {
+	return fmt.Sprintf("%s|%s|%s|%s", f.Tool, f.RuleID, f.File, f.Message)
+}
+
+// SecurityScan is the persisted result of running one or more scanners over
+// a project workdir.
+type SecurityScan struct {
+	ID          string            `json:"id"`
+	ProjectID   string            `json:"project_id"`
+	Path        string            `json:"path,omitempty"` // Scanned directory relative to the repository root
+	Tools       []string          `json:"tools"`
+	Findings    []SecurityFinding `json:"findings"`
+	Summary     map[string]int    `json:"summary"`            // Finding count by severity
+	Errors      map[string]string `json:"errors,omitempty"`   // Scanner failures by tool
+	Skipped     map[string]string `json:"skipped,omitempty"`  // Applicable scanners that were not run, with the reason
+	BeadIDs     []string          `json:"bead_ids,omitempty"` // Beads filed for high-severity findings
+	StartedAt   time.Time         `json:"started_at"`
+	CompletedAt time.Time         `json:"completed_at"`
+}
+
+// SeverityRank orders severities from critical (0) to info (4); unknown
+// severities rank below info.
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityCritical:
+		return 0
+	case SeverityHigh:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 3
+	case SeverityInfo:
+		return 4
+	}
+	return 5
+}
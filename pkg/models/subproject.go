@@ -0,0 +1,103 @@
+package models
+
+import (
+	"path"
+	"strings"
+)
+
+// Subproject is a path prefix inside a monorepo with its own build and test
+// commands, so work on one service doesn't build or search the whole tree.
+type Subproject struct {
+	Name         string   `json:"name" yaml:"name"`
+	Path         string   `json:"path" yaml:"path"`                                       // Relative to the repository root, e.g. "services/api"
+	BuildCommand string   `json:"build_command,omitempty" yaml:"build_command,omitempty"` // Run from Path
+	TestCommand  string   `json:"test_command,omitempty" yaml:"test_command,omitempty"`
+	LintCommand  string   `json:"lint_command,omitempty" yaml:"lint_command,omitempty"`
+	Ignore       []string `json:"ignore,omitempty" yaml:"ignore,omitempty"`     // Glob patterns relative to Path excluded from search
+	LSPRoot      string   `json:"lsp_root,omitempty" yaml:"lsp_root,omitempty"` // Relative to Path; defaults to Path itself
+}
+
+// CleanSubprojectPath normalizes a repository-relative path for prefix matching
+func CleanSubprojectPath(p string) string {
+	p = path.Clean(strings.TrimPrefix(strings.TrimSpace(p), "./"))
+	if p == "." || p == "/" {
+		return ""
+	}
+	return strings.Trim(p, "/")
+}
+
+// Contains reports whether the repository-relative path lies inside the subproject
+func (s *Subproject) Contains(relPath string) bool {
+	root := CleanSubprojectPath(s.Path)
+	relPath = CleanSubprojectPath(relPath)
+	if root == "" {
+		return true
+	}
+	return relPath == root || strings.HasPrefix(relPath, root+"/")
+}
+
+// Ignores reports whether the repository-relative path matches one of the
+// subproject's ignore rules. A pattern matches the path relative to the
+// subproject root, any single path element, or any leading directory.
+func (s *Subproject) Ignores(relPath string) bool {
+	if len(s.Ignore) == 0 || !s.Contains(relPath) {
+		return false
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(CleanSubprojectPath(relPath), CleanSubprojectPath(s.Path)), "/")
+	parts := strings.Split(rel, "/")
+	for _, pattern := range s.Ignore {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		for i, part := range parts {
+			if ok, _ := path.Match(pattern, part); ok {
+				return true
+			}
+			if ok, _ := path.Match(pattern, strings.Join(parts[:i+1], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LSPRootPath returns the repository-relative directory language servers
+// should be rooted at for this subproject.
+func (s *Subproject) LSPRootPath() string {
+	return CleanSubprojectPath(path.Join(CleanSubprojectPath(s.Path), s.LSPRoot))
+}
+
+// FindSubproject looks a subproject up by name or path
+func (p *Project) FindSubproject(ref string) *Subproject {
+	if ref == "" {
+		return nil
+	}
+	cleaned := CleanSubprojectPath(ref)
+	for i := range p.Subprojects {
+		sp := &p.Subprojects[i]
+		if sp.Name == ref || CleanSubprojectPath(sp.Path) == cleaned {
+			return sp
+		}
+	}
+	return nil
+}
+
+// SubprojectForPath returns the most specific subproject containing the
+// repository-relative path, or nil if none does.
+func (p *Project) SubprojectForPath(relPath string) *Subproject {
+	var best *Subproject
+	for i := range p.Subprojects {
+		sp := &p.Subprojects[i]
+		if CleanSubprojectPath(sp.Path) == "" || !sp.Contains(relPath) {
+			continue
+		}
+		if best == nil || len(CleanSubprojectPath(sp.Path)) > len(CleanSubprojectPath(best.Path)) {
+			best = sp
+		}
+	}
+	return best
+}
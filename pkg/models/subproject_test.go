@@ -0,0 +1,69 @@
+package models
+
+import "testing"
+
+func TestSubprojectForPathPicksMostSpecific(t *testing.T) {
+	p := &Project{Subprojects: []Subproject{
+		{Name: "services", Path: "services"},
+		{Name: "api", Path: "services/api"},
+		{Name: "web", Path: "web"},
+	}}
+
+	cases := map[string]string{
+		"services/api/main.go":    "api",
+		"./services/api":          "api",
+		"services/worker/main.go": "services",
+		"web/src/index.ts":        "web",
+		"services/apix/main.go":   "services",
+		"README.md":               "",
+		"webapp/index.ts":         "",
+	}
+	for path, want := range cases {
+		got := ""
+		if sp := p.SubprojectForPath(path); sp != nil {
+			got = sp.Name
+		}
+		if got != want {
+			t.Errorf("SubprojectForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestFindSubprojectByNameOrPath(t *testing.T) {
+	p := &Project{Subprojects: []Subproject{{Name: "api", Path: "services/api"}}}
+	if sp := p.FindSubproject("api"); sp == nil || sp.Path != "services/api" {
+		t.Fatalf("lookup by name failed: %+v", sp)
+	}
+	if sp := p.FindSubproject("services/api/"); sp == nil || sp.Name != "api" {
+		t.Fatalf("lookup by path failed: %+v", sp)
+	}
+	if sp := p.FindSubproject("web"); sp != nil {
+		t.Fatalf("expected no match, got %+v", sp)
+	}
+}
+
+func TestSubprojectIgnores(t *testing.T) {
+	sp := Subproject{Path: "web", Ignore: []string{"node_modules", "dist/*", "*.min.js"}}
+
+	ignored := []string{"web/node_modules", "web/node_modules/react/index.js", "web/dist/app.js", "web/src/vendor.min.js"}
+	for _, path := range ignored {
+		if !sp.Ignores(path) {
+			t.Errorf("expected %q to be ignored", path)
+		}
+	}
+	kept := []string{"web/src/app.js", "web/distribution/app.js", "node_modules/x.js", "services/api/dist/a.go"}
+	for _, path := range kept {
+		if sp.Ignores(path) {
+			t.Errorf("expected %q not to be ignored", path)
+		}
+	}
+}
+
+func TestSubprojectLSPRootPath(t *testing.T) {
+	if got := (&Subproject{Path: "services/api"}).LSPRootPath(); got != "services/api" {
+		t.Fatalf("default LSP root = %q", got)
+	}
+	if got := (&Subproject{Path: "web", LSPRoot: "app"}).LSPRootPath(); got != "web/app" {
+		t.Fatalf("LSP root = %q", got)
+	}
+}
@@ -2,12 +2,14 @@ package server
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 
+	"github.com/jordanhubbard/loom/internal/logging"
 	"github.com/jordanhubbard/loom/pkg/config"
 )
 
+var serverLog = logging.Module("server")
+
 // Server represents the Loom HTTP server
 type Server struct {
 	config *config.Config
@@ -24,9 +26,9 @@ func NewServer(cfg *config.Config) *Server {
 func (s *Server) Start() error {
 	addr := fmt.Sprintf(":%d", s.config.Server.HTTPPort)
 
-	log.Printf("Loom server starting on %s", addr)
-	log.Println("Note: This is a stub server. Full server implementation pending.")
-	log.Println("The worker system is available via the WorkerManager API.")
+	serverLog.Info("Loom server starting", "addr", addr)
+	serverLog.Info("Note: This is a stub server. Full server implementation pending.")
+	serverLog.Info("The worker system is available via the WorkerManager API.")
 
 	// Simple health check endpoint
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -1912,8 +1912,11 @@ async function saveBeadUpdate(beadId, payload, { successMessage = 'Bead updated'
     }
 
     try {
+        // The API rejects updates that don't name the version they were based on
+        const version = previous ? previous.version : (await apiCall(`/beads/${beadId}`)).version;
         const updated = await apiCall(`/beads/${beadId}`, {
             method: 'PATCH',
+            headers: { 'If-Match': `"${version}"` },
             body: JSON.stringify(payload)
         });
         updateBeadCache(updated);
@@ -2557,6 +2560,7 @@ async function ceoReplDispatchToAgent(agentQuery, taskMessage, responseEl, sendB
         await apiCall(`/beads/${bead.id}`, {
             method: 'PATCH',
             skipAutoFile: true,
+            headers: { 'If-Match': `"${bead.version}"` },
             body: JSON.stringify({ assigned_to: matchedAgent.id })
         });
 